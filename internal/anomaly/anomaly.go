@@ -0,0 +1,102 @@
+// Package anomaly 基于指数加权滚动均值和标准差检测指标异常，不依赖外部库
+package anomaly
+
+import "math"
+
+// 默认参数
+const (
+	// DefaultSigmas 偏离均值超过多少个标准差视为异常
+	DefaultSigmas = 3.0
+	// DefaultAlpha 指数加权的平滑系数，越大越偏重最近的样本
+	DefaultAlpha = 0.1
+	// DefaultWarmup 每个指标至少观测多少个样本后才开始判断异常
+	DefaultWarmup = 10
+	// DefaultMinStdDev 标准差的下限，避免几乎不变的指标（如磁盘使用率）因微小波动被判为异常
+	DefaultMinStdDev = 0.5
+)
+
+// Baseline 某个指标当前的滚动基线，StdDev 不小于检测器的标准差下限
+type Baseline struct {
+	Mean   float64
+	StdDev float64
+	Count  int
+}
+
+// stats 单个指标的指数加权均值和方差
+type stats struct {
+	mean     float64
+	variance float64
+	count    int
+}
+
+// update 加入一个新样本（West 的指数加权增量算法）
+func (s *stats) update(value, alpha float64) {
+	s.count++
+	if s.count == 1 {
+		s.mean = value
+		s.variance = 0
+		return
+	}
+
+	diff := value - s.mean
+	increment := alpha * diff
+	s.mean += increment
+	s.variance = (1 - alpha) * (s.variance + diff*increment)
+}
+
+// Detector 按指标名称维护滚动统计并判断异常，不是并发安全的
+type Detector struct {
+	sigmas    float64
+	alpha     float64
+	warmup    int
+	minStdDev float64
+	stats     map[string]*stats
+}
+
+// NewDetector 创建异常检测器，sigmas、alpha 和 warmup 不大于 0 时使用默认值（alpha 还需小于 1），
+// minStdDev 小于 0 时使用默认值
+func NewDetector(sigmas, alpha float64, warmup int, minStdDev float64) *Detector {
+	if sigmas <= 0 {
+		sigmas = DefaultSigmas
+	}
+	if alpha <= 0 || alpha >= 1 {
+		alpha = DefaultAlpha
+	}
+	if warmup <= 0 {
+		warmup = DefaultWarmup
+	}
+	if minStdDev < 0 {
+		minStdDev = DefaultMinStdDev
+	}
+
+	return &Detector{
+		sigmas:    sigmas,
+		alpha:     alpha,
+		warmup:    warmup,
+		minStdDev: minStdDev,
+		stats:     make(map[string]*stats),
+	}
+}
+
+// Sigmas 异常判断使用的标准差倍数
+func (d *Detector) Sigmas() float64 {
+	return d.sigmas
+}
+
+// Observe 用加入样本之前的基线判断 value 是否异常，然后更新基线。
+// 样本数不足 warmup 或（下限为 0 时）基线标准差为 0 时不判断异常。
+func (d *Detector) Observe(metric string, value float64) (Baseline, bool) {
+	s, found := d.stats[metric]
+	if !found {
+		s = &stats{}
+		d.stats[metric] = s
+	}
+
+	baseline := Baseline{Mean: s.mean, StdDev: math.Max(math.Sqrt(s.variance), d.minStdDev), Count: s.count}
+	anomalous := s.count >= d.warmup && baseline.StdDev > 0 &&
+		math.Abs(value-baseline.Mean) > d.sigmas*baseline.StdDev
+
+	s.update(value, d.alpha)
+
+	return baseline, anomalous
+}
@@ -0,0 +1,157 @@
+package anomaly
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+// observeAll 依次观测 values，返回被判为异常的样本下标
+func observeAll(d *Detector, metric string, values []float64) []int {
+	var flagged []int
+	for i, value := range values {
+		if _, anomalous := d.Observe(metric, value); anomalous {
+			flagged = append(flagged, i)
+		}
+	}
+	return flagged
+}
+
+// repeat n 个相同的值
+func repeat(value float64, n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = value
+	}
+	return values
+}
+
+// alternating 在 center±delta 之间交替的 n 个值
+func alternating(center, delta float64, n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = center + delta
+		if i%2 == 1 {
+			values[i] = center - delta
+		}
+	}
+	return values
+}
+
+func TestNewDetectorDefaults(t *testing.T) {
+	d := NewDetector(0, 0, 0, -1)
+	if d.sigmas != DefaultSigmas || d.alpha != DefaultAlpha || d.warmup != DefaultWarmup || d.minStdDev != DefaultMinStdDev {
+		t.Errorf("defaults = %+v", d)
+	}
+	// alpha 不小于 1 时同样使用默认值；标准差下限可以为 0
+	d = NewDetector(2, 1, 5, 0)
+	if d.Sigmas() != 2 || d.alpha != DefaultAlpha || d.warmup != 5 || d.minStdDev != 0 {
+		t.Errorf("configured = %+v", d)
+	}
+}
+
+func TestStatsUpdate(t *testing.T) {
+	var s stats
+	s.update(10, 0.5)
+	if s.mean != 10 || s.variance != 0 || s.count != 1 {
+		t.Fatalf("after the first sample = %+v", s)
+	}
+	// diff=2, increment=1: mean=11, variance=0.5*(0+2*1)=1
+	s.update(12, 0.5)
+	if s.mean != 11 || s.variance != 1 || s.count != 2 {
+		t.Fatalf("after the second sample = %+v", s)
+	}
+	// diff=-3, increment=-1.5: mean=9.5, variance=0.5*(1+4.5)=2.75
+	s.update(8, 0.5)
+	if s.mean != 9.5 || s.variance != 2.75 {
+		t.Fatalf("after the third sample = %+v", s)
+	}
+}
+
+func TestObserveReturnsBaselineBeforeUpdate(t *testing.T) {
+	d := NewDetector(3, 0.5, 1, 0)
+	if baseline, anomalous := d.Observe("cpu", 10); anomalous || baseline != (Baseline{}) {
+		t.Fatalf("first observation = %+v, %v", baseline, anomalous)
+	}
+	d.Observe("cpu", 12)
+	// 基线为加入 8 之前的均值 11、标准差 1；偏离 3 不超过 3σ
+	if baseline, anomalous := d.Observe("cpu", 8); anomalous || baseline != (Baseline{Mean: 11, StdDev: 1, Count: 2}) {
+		t.Errorf("third observation = %+v, %v", baseline, anomalous)
+	}
+	// 标准差为 sqrt(2.75)，偏离 9.5-20 超过 3σ
+	baseline, anomalous := d.Observe("cpu", 20)
+	if !anomalous || baseline.Mean != 9.5 || math.Abs(baseline.StdDev-math.Sqrt(2.75)) > 1e-12 || baseline.Count != 3 {
+		t.Errorf("fourth observation = %+v, %v", baseline, anomalous)
+	}
+}
+
+func TestObserveSyntheticSeries(t *testing.T) {
+	cases := []struct {
+		name   string
+		values []float64
+		// minStdDev 为 -1 时使用默认值
+		minStdDev float64
+		want      []int
+	}{
+		{"steady noise", alternating(50, 1, 60), -1, nil},
+		{"spike", append(alternating(50, 1, 30), 60, 50, 51), -1, []int{30}},
+		{"dip", append(alternating(50, 1, 30), 40), -1, []int{30}},
+		// 偏离在 3σ 以内不判为异常
+		{"small jump", append(alternating(50, 1, 30), 52.5), -1, nil},
+		// 持续的跳变只在第一个样本判为异常：跳变同时放大了方差，之后的样本不再超出
+		{"level shift", append(alternating(50, 1, 30), alternating(70, 1, 60)...), -1, []int{30}},
+		// 缓慢上升的趋势跟得上，不判为异常
+		{"slow ramp", ramp(20, 0.1, 200), -1, nil},
+		// 完全不变的指标靠标准差下限避免微小波动被判为异常
+		{"flat then wobble", append(repeat(80, 30), 80.4, 81.6), -1, []int{31}},
+		// 下限为 0 时基线标准差为 0 不判断
+		{"flat without a floor", append(repeat(80, 30), 99), 0, nil},
+		// 预热期内的剧烈变化不判为异常
+		{"spike during warmup", []float64{50, 51, 95, 50, 51, 50}, -1, nil},
+	}
+	for _, c := range cases {
+		d := NewDetector(3, 0.1, 10, c.minStdDev)
+		got := observeAll(d, "memory", c.values)
+		if !slices.Equal(got, c.want) {
+			t.Errorf("%s: flagged %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+// ramp 从 start 起每次增加 step 的 n 个值
+func ramp(start, step float64, n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = start + step*float64(i)
+	}
+	return values
+}
+
+func TestObserveKeepsMetricsSeparate(t *testing.T) {
+	d := NewDetector(3, 0.1, 10, -1)
+	observeAll(d, "disk|/", alternating(50, 1, 30))
+	observeAll(d, "disk|/data", alternating(90, 1, 30))
+
+	// 一个分区的正常值对另一个分区是异常
+	if _, anomalous := d.Observe("disk|/", 90); !anomalous {
+		t.Error("disk|/ = 90 not flagged")
+	}
+	if _, anomalous := d.Observe("disk|/data", 90); anomalous {
+		t.Error("disk|/data = 90 flagged")
+	}
+	// 新指标从预热开始
+	if baseline, anomalous := d.Observe("disk|/new", 1000); anomalous || baseline.Count != 0 {
+		t.Errorf("new metric = %+v, %v", baseline, anomalous)
+	}
+}
+
+func TestObserveSigmas(t *testing.T) {
+	series := append(alternating(50, 1, 30), 54)
+	// 偏离约 4σ：3σ 时为异常，5σ 时不是
+	if got := observeAll(NewDetector(3, 0.1, 10, -1), "cpu", series); !slices.Equal(got, []int{30}) {
+		t.Errorf("3σ flagged %v", got)
+	}
+	if got := observeAll(NewDetector(5, 0.1, 10, -1), "cpu", series); got != nil {
+		t.Errorf("5σ flagged %v", got)
+	}
+}
@@ -0,0 +1,109 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"mcp-example/internal/anomaly"
+	"mcp-example/internal/tools"
+	"mcp-example/internal/types"
+)
+
+// anomalySample 时间为 start 之后 i 分钟的样本，内存在 50±1 之间交替
+func anomalySample(start time.Time, i int, memory float64) types.MetricSample {
+	if memory == 0 {
+		memory = 51
+		if i%2 == 1 {
+			memory = 49
+		}
+	}
+	return types.MetricSample{
+		Timestamp:     start.Add(time.Duration(i) * time.Minute),
+		CPUPercent:    20,
+		MemoryPercent: memory,
+		DiskPercent:   map[string]float64{"/": 40, "/data": 70},
+	}
+}
+
+// storedAnomalies 存储中的异常记录
+func storedAnomalies(t *testing.T, dataStorage types.DataStorage) []types.Anomaly {
+	t.Helper()
+	var anomalies []types.Anomaly
+	if err := dataStorage.Load(tools.AnomaliesKey, &anomalies); err != nil {
+		t.Fatal(err)
+	}
+	return anomalies
+}
+
+func TestCollectorRecordsAnomalies(t *testing.T) {
+	c, dataStorage := newTestCollector()
+	c.DetectAnomalies(anomaly.NewDetector(3, 0.1, 10, -1))
+	var notified []types.Anomaly
+	c.OnAnomaly(func(item types.Anomaly) { notified = append(notified, item) })
+
+	start := time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC)
+	for i := 0; i < 30; i++ {
+		found, err := c.recordAnomalies(anomalySample(start, i, 0))
+		if err != nil || found != nil {
+			t.Fatalf("sample %d = %+v, %v", i, found, err)
+		}
+	}
+	// 没有异常时不写存储
+	if dataStorage.Exists(tools.AnomaliesKey) {
+		t.Fatal("anomalies stored before any was found")
+	}
+
+	spike := anomalySample(start, 30, 75)
+	spike.DiskPercent["/data"] = 99
+	found, err := c.recordAnomalies(spike)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 按内存、各分区（按挂载点排序）的顺序记录，同时写入存储并通知
+	if len(found) != 2 || found[0].Metric != tools.MetricMemoryPercent || found[0].Selector != "" ||
+		found[1].Metric != tools.MetricDiskPercent || found[1].Selector != "/data" {
+		t.Fatalf("found = %+v", found)
+	}
+	memory := found[0]
+	if memory.Value != 75 || !memory.Timestamp.Equal(spike.Timestamp) || memory.StdDev < anomaly.DefaultMinStdDev ||
+		memory.Mean < 49 || memory.Mean > 51 || memory.Sigmas != (75-memory.Mean)/memory.StdDev {
+		t.Errorf("memory anomaly = %+v", memory)
+	}
+	if stored := storedAnomalies(t, dataStorage); len(stored) != 2 || stored[0] != memory {
+		t.Errorf("stored = %+v", stored)
+	}
+	if len(notified) != 2 || notified[1] != found[1] {
+		t.Errorf("notified = %+v", notified)
+	}
+}
+
+func TestCollectorAnomalyRingBuffer(t *testing.T) {
+	c, dataStorage := newTestCollector()
+	// 存储中已有接近上限的记录，新记录接在后面，超出上限时丢弃最旧的
+	start := time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC)
+	existing := make([]types.Anomaly, tools.MaxAnomalies-1)
+	for i := range existing {
+		existing[i] = types.Anomaly{Timestamp: start.Add(-time.Duration(len(existing)-i) * time.Minute), Metric: tools.MetricCPUPercent, Value: float64(i)}
+	}
+	if err := dataStorage.Save(tools.AnomaliesKey, existing); err != nil {
+		t.Fatal(err)
+	}
+
+	// 预热 1 个样本、不设标准差下限且倍数极小：第二个样本时标准差为 0 不判断，之后每个样本都是异常
+	c.DetectAnomalies(anomaly.NewDetector(0.01, 0.1, 1, 0))
+	for i := 0; i < 5; i++ {
+		sample := types.MetricSample{Timestamp: start.Add(time.Duration(i) * time.Minute), CPUPercent: float64(20 + 60*(i%2))}
+		if _, err := c.recordAnomalies(sample); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stored := storedAnomalies(t, dataStorage)
+	if len(stored) != tools.MaxAnomalies {
+		t.Fatalf("stored %d anomalies, want %d", len(stored), tools.MaxAnomalies)
+	}
+	// 前两条旧记录被丢弃，最后三条是本次检测到的
+	if stored[0].Value != 2 || !stored[len(stored)-1].Timestamp.Equal(start.Add(4*time.Minute)) || stored[len(stored)-4].Value != float64(len(existing)-1) {
+		t.Errorf("ring buffer = first %+v, last %+v", stored[0], stored[len(stored)-1])
+	}
+}
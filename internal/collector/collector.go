@@ -0,0 +1,495 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mcp-example/internal/anomaly"
+	"mcp-example/internal/identity"
+	"mcp-example/internal/tools"
+	"mcp-example/internal/types"
+)
+
+// historyFlushInterval 当天的采集历史写入存储的最短间隔。日文件每次都整体重写，
+// 间隔很短时每个样本都写入会使一天的写入量随样本数平方增长
+const historyFlushInterval = time.Minute
+
+// Collector 后台指标采集器，按固定间隔采样并按天写入存储
+type Collector struct {
+	storage         types.DataStorage
+	interval        atomic.Int64
+	intervalChanged chan struct{}
+	// lastRun 最近一次采样完成的时间，nil 表示尚未采样
+	lastRun     atomic.Pointer[time.Time]
+	cycles      cycleStats
+	memoryTool  *tools.MemoryTool
+	diskTool    *tools.DiskTool
+	networkTool *tools.NetworkTool
+
+	day     string
+	samples []types.MetricSample
+	// unflushed 尚未写入存储的样本数，flushedAt 上一次写入时的单调时钟读数，flushed 为 false 表示尚未写入过
+	unflushed int
+	flushedAt time.Duration
+	flushed   bool
+	// historyRetention 采集历史的保留时长，0 表示不删除
+	historyRetention time.Duration
+	// lastSampled 本进程上一次采样的时间，lastMonotonic 为同时记录的单调时钟读数，用于检测两次采样之间的时钟跳变
+	lastSampled   time.Time
+	lastMonotonic time.Duration
+	// now、monotonic 墙上时钟和单调时钟（见 tools.Monotonic），测试中替换以模拟时钟跳变
+	now       func() time.Time
+	monotonic func() time.Duration
+	// newTicker、jitter、collect 采集循环的计时器、首次采样前的随机等待和每个周期的采样，测试中替换
+	newTicker func(time.Duration) ticker
+	jitter    func(interval time.Duration) time.Duration
+	collect   func(ctx context.Context) error
+	onSample  func(types.MetricSample)
+	onAnomaly func(types.Anomaly)
+
+	detector        *anomaly.Detector
+	anomalies       []types.Anomaly
+	anomaliesLoaded bool
+
+	incidents  *tools.IncidentLog
+	thresholds func() types.Thresholds
+
+	// stop 请求采集循环在当前采样完成后退出，done 在 Run 返回时关闭
+	started  atomic.Bool
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewCollector 创建新的后台采集器
+func NewCollector(dataStorage types.DataStorage, interval time.Duration, memoryTool *tools.MemoryTool, diskTool *tools.DiskTool, networkTool *tools.NetworkTool) *Collector {
+	c := &Collector{
+		storage:         dataStorage,
+		intervalChanged: make(chan struct{}, 1),
+		memoryTool:      memoryTool,
+		diskTool:        diskTool,
+		networkTool:     networkTool,
+		stop:            make(chan struct{}),
+		done:            make(chan struct{}),
+		now:             time.Now,
+		monotonic:       tools.Monotonic,
+		newTicker:       newTimeTicker,
+		jitter:          startJitter,
+	}
+	c.collect = c.collectOnce
+	c.interval.Store(int64(interval))
+	return c
+}
+
+// ticker 采集循环的周期计时器，与 time.Ticker 相同：到期时向 C 发送，接收方来不及接收时最多积压一次
+type ticker interface {
+	C() <-chan time.Time
+	Reset(d time.Duration)
+	Stop()
+}
+
+// timeTicker 基于 time.Ticker 的 ticker
+type timeTicker struct {
+	*time.Ticker
+}
+
+// newTimeTicker 创建周期为 d 的 timeTicker
+func newTimeTicker(d time.Duration) ticker {
+	return timeTicker{time.NewTicker(d)}
+}
+
+// C 到期通知
+func (t timeTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// SetInterval 修改采集间隔，运行中的采集循环会以新间隔重新计时，interval 必须大于 0
+func (c *Collector) SetInterval(interval time.Duration) {
+	c.interval.Store(int64(interval))
+
+	// 已有未处理的通知时无需重复发送，采集循环会读取最新的间隔
+	select {
+	case c.intervalChanged <- struct{}{}:
+	default:
+	}
+}
+
+// OnSample 注册每次采样保存后调用的回调，需在 Run 之前调用
+func (c *Collector) OnSample(callback func(types.MetricSample)) {
+	c.onSample = callback
+}
+
+// OnAnomaly 注册检测到异常时调用的回调，需在 Run 之前调用
+func (c *Collector) OnAnomaly(callback func(types.Anomaly)) {
+	c.onAnomaly = callback
+}
+
+// DetectAnomalies 对每次采样的 CPU、内存和各分区使用率做异常检测，异常写入 tools.AnomaliesKey，需在 Run 之前调用
+func (c *Collector) DetectAnomalies(detector *anomaly.Detector) {
+	c.detector = detector
+}
+
+// TrackIncidents 按 thresholds 返回的当前阈值和异常检测结果推进事件记录，需在 Run 之前调用
+func (c *Collector) TrackIncidents(incidents *tools.IncidentLog, thresholds func() types.Thresholds) {
+	c.incidents = incidents
+	c.thresholds = thresholds
+}
+
+// KeepHistory 只保留最近 retention 内的采集历史日文件，启动后和每天第一次采样时删除更早的文件，需在 Run 之前调用
+func (c *Collector) KeepHistory(retention time.Duration) {
+	c.historyRetention = retention
+}
+
+// LastRun 最近一次采样完成的时间，尚未采样时返回零值
+func (c *Collector) LastRun() time.Time {
+	if lastRun := c.lastRun.Load(); lastRun != nil {
+		return *lastRun
+	}
+	return time.Time{}
+}
+
+// Stats 采集器的运行统计：采样次数、跳过的周期和最近若干次采样的耗时分位数
+func (c *Collector) Stats() types.CollectorStats {
+	stats := c.cycles.snapshot()
+	stats.IntervalSeconds = time.Duration(c.interval.Load()).Seconds()
+	stats.LastRun = c.lastRun.Load()
+	return stats
+}
+
+// Run 运行采集循环，直到 ctx 取消或调用 Stop，只能调用一次。
+// 首次采样前随机等待一段时间（不超过采集间隔和 maxStartJitter），之后按采集间隔逐个周期串行采样：
+// 采样耗时超过间隔时，期间到期的周期被跳过并计入 Stats，不会在采样结束后立即补采
+func (c *Collector) Run(ctx context.Context) {
+	c.started.Store(true)
+	defer close(c.done)
+	defer func() {
+		if err := c.flushHistory(); err != nil {
+			slog.Warn("停止时写入采集历史失败", "error", err)
+		}
+	}()
+
+	// 建立 CPU 使用率的基准，之后每次采样计算两次调用之间的使用率
+	tools.CurrentProviders().CPU.Percent(ctx, 0, false)
+
+	if !c.sleep(ctx, c.jitter(time.Duration(c.interval.Load()))) {
+		return
+	}
+
+	ticker := c.newTicker(time.Duration(c.interval.Load()))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.stop:
+			return
+		case <-c.intervalChanged:
+			ticker.Reset(time.Duration(c.interval.Load()))
+		case <-ticker.C():
+			started := c.monotonic()
+			err := c.collect(ctx)
+			if err != nil {
+				slog.Warn("后台采集失败", "error", err)
+			}
+			duration := c.monotonic() - started
+			now := c.now()
+			c.lastRun.Store(&now)
+
+			interval := time.Duration(c.interval.Load())
+			if skipped := c.cycles.record(duration, interval, err != nil); skipped > 0 {
+				// ticker 在采样期间积压了一次到期，丢弃它，下一次采样在下一个周期开始
+				select {
+				case <-ticker.C():
+				default:
+				}
+				slog.Warn("采样耗时超过采集间隔，跳过期间到期的周期", "duration", duration.Round(time.Millisecond), "interval", interval, "skipped", skipped)
+			}
+		}
+	}
+}
+
+// sleep 等待 d，期间 ctx 取消或调用 Stop 时返回 false
+func (c *Collector) sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-c.stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Stop 请求采集循环退出并等待其返回：正在进行的采样会先完成，尚未写入的采集历史在退出前写入存储。
+// ctx 到期时返回 ctx 的错误，采集循环仍会在当前采样完成后退出。Run 尚未开始时立即返回
+func (c *Collector) Stop(ctx context.Context) error {
+	c.stopOnce.Do(func() { close(c.stop) })
+	if !c.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// collectOnce 执行一次采样并记录
+func (c *Collector) collectOnce(ctx context.Context) error {
+	monotonic := c.monotonic()
+	sample, err := c.sample(ctx)
+	if err != nil {
+		return err
+	}
+	return c.record(sample, monotonic)
+}
+
+// record 将样本追加到当天的历史（距上一次写入超过 historyFlushInterval 时写入存储），并做异常检测和事件记录。
+// monotonic 为取样本时间戳时的单调时钟读数
+func (c *Collector) record(sample types.MetricSample, monotonic time.Duration) error {
+	key := tools.HistoryKey(sample.Timestamp)
+	if key != c.day {
+		// 新的一天（或刚启动）：写入前一天剩余的样本，删除超出保留时长的历史，从存储中接续已有的样本
+		if err := c.flushHistory(); err != nil {
+			return err
+		}
+		c.day = key
+		c.pruneHistory(sample.Timestamp)
+		c.samples = nil
+		if c.storage.Exists(key) {
+			if err := c.storage.Load(key, &c.samples); err != nil {
+				slog.Warn("加载采集历史失败，将重新开始记录", "key", key, "error", err)
+				c.samples = nil
+			}
+		}
+	}
+
+	c.markClockJump(&sample, monotonic)
+	c.samples = append(c.samples, sample)
+	c.unflushed++
+	if !c.flushed || c.monotonic()-c.flushedAt >= historyFlushInterval {
+		if err := c.flushHistory(); err != nil {
+			return err
+		}
+	}
+
+	var found []types.Anomaly
+	if c.detector != nil {
+		var err error
+		found, err = c.recordAnomalies(sample)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.incidents != nil {
+		c.recordIncidents(sample, found)
+	}
+
+	if c.onSample != nil {
+		c.onSample(sample)
+	}
+
+	return nil
+}
+
+// flushHistory 将当天尚未写入的样本写入存储。写入失败时样本保留在内存中，下一次写入时重试
+func (c *Collector) flushHistory() error {
+	if c.unflushed == 0 {
+		return nil
+	}
+	if err := c.storage.Save(c.day, c.samples); err != nil {
+		return fmt.Errorf("保存采集历史失败: %v", err)
+	}
+	c.unflushed = 0
+	c.flushedAt, c.flushed = c.monotonic(), true
+	return nil
+}
+
+// pruneHistory 删除 now 之前超出保留时长的历史日文件，失败只记录日志，不影响采集
+func (c *Collector) pruneHistory(now time.Time) {
+	if c.historyRetention <= 0 {
+		return
+	}
+	deleted, err := tools.PruneHistory(c.storage, now.Add(-c.historyRetention))
+	if err != nil {
+		slog.Warn("删除过期的采集历史失败", "error", err)
+	}
+	if len(deleted) > 0 {
+		slog.Info("已删除过期的采集历史", "count", len(deleted), "retention", c.historyRetention)
+	}
+}
+
+// markClockJump 与上一次采样比较墙上时钟和单调时钟经过的时间，检测到系统时钟跳变时在样本中记录跳变量。
+// 本进程的第一次采样与存储中已有的最后一个样本比较，只能发现时间倒退
+func (c *Collector) markClockJump(sample *types.MetricSample, monotonic time.Duration) {
+	var previous time.Time
+	var jump time.Duration
+	switch {
+	case !c.lastSampled.IsZero():
+		previous = c.lastSampled
+		jump = tools.ClockJump(previous, sample.Timestamp, monotonic-c.lastMonotonic)
+	case len(c.samples) > 0:
+		previous = c.samples[len(c.samples)-1].Timestamp
+		jump = tools.ClockWentBack(previous, sample.Timestamp)
+	}
+	c.lastSampled, c.lastMonotonic = sample.Timestamp, monotonic
+
+	if jump != 0 {
+		sample.ClockJumpSeconds = jump.Seconds()
+		slog.Warn("检测到系统时钟跳变，跨越该样本的速率将被略过", "jump", jump, "previous", previous.Round(0), "current", sample.Timestamp.Round(0))
+	}
+}
+
+// recordAnomalies 检测样本中的异常值，有异常时追加到存储中的环形缓冲区，返回本次发现的异常
+func (c *Collector) recordAnomalies(sample types.MetricSample) ([]types.Anomaly, error) {
+	observe := func(metric, selector string, value float64) []types.Anomaly {
+		baseline, anomalous := c.detector.Observe(metric+"|"+selector, value)
+		if !anomalous {
+			return nil
+		}
+		return []types.Anomaly{{
+			Timestamp: sample.Timestamp,
+			Metric:    metric,
+			Selector:  selector,
+			Value:     value,
+			Mean:      baseline.Mean,
+			StdDev:    baseline.StdDev,
+			Sigmas:    math.Abs(value-baseline.Mean) / baseline.StdDev,
+		}}
+	}
+
+	var found []types.Anomaly
+	found = append(found, observe(tools.MetricCPUPercent, "", sample.CPUPercent)...)
+	found = append(found, observe(tools.MetricMemoryPercent, "", sample.MemoryPercent)...)
+	for _, mountpoint := range sortedMountpoints(sample.DiskPercent) {
+		found = append(found, observe(tools.MetricDiskPercent, mountpoint, sample.DiskPercent[mountpoint])...)
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+
+	// 首次发现异常时接续存储中已有的记录
+	if !c.anomaliesLoaded {
+		c.anomaliesLoaded = true
+		if c.storage.Exists(tools.AnomaliesKey) {
+			if err := c.storage.Load(tools.AnomaliesKey, &c.anomalies); err != nil {
+				slog.Warn("加载异常记录失败，将重新开始记录", "key", tools.AnomaliesKey, "error", err)
+				c.anomalies = nil
+			}
+		}
+	}
+
+	c.anomalies = append(c.anomalies, found...)
+	if overflow := len(c.anomalies) - tools.MaxAnomalies; overflow > 0 {
+		c.anomalies = append([]types.Anomaly(nil), c.anomalies[overflow:]...)
+	}
+	for _, item := range found {
+		slog.Info("检测到指标异常", "metric", item.Metric, "selector", item.Selector,
+			"value", item.Value, "baseline_mean", item.Mean, "sigmas", item.Sigmas)
+	}
+
+	if err := c.storage.Save(tools.AnomaliesKey, c.anomalies); err != nil {
+		return nil, fmt.Errorf("保存异常记录失败: %v", err)
+	}
+
+	if c.onAnomaly != nil {
+		for _, item := range found {
+			c.onAnomaly(item)
+		}
+	}
+	return found, nil
+}
+
+// recordIncidents 用本次样本的阈值检查和异常检测结果推进事件记录。失败只记录日志，不影响采集
+func (c *Collector) recordIncidents(sample types.MetricSample, found []types.Anomaly) {
+	observations := tools.ThresholdObservations(sample, c.thresholds())
+	if c.detector != nil {
+		observations = append(observations, tools.AnomalyObservations(sample, found)...)
+	}
+
+	opened, err := c.incidents.Record(sample.Timestamp, observations)
+	if err != nil {
+		slog.Warn("更新事件记录失败", "error", err)
+		return
+	}
+	for _, incident := range opened {
+		slog.Info("告警事件已打开", "id", incident.ID, "source", incident.Source, "metric", incident.Metric,
+			"selector", incident.Selector, "value", incident.LastValue, "reopened", incident.ReopenCount > 0)
+	}
+}
+
+// sortedMountpoints 按名称排序的挂载点，保证检测顺序稳定
+func sortedMountpoints(diskPercent map[string]float64) []string {
+	mountpoints := make([]string, 0, len(diskPercent))
+	for mountpoint := range diskPercent {
+		mountpoints = append(mountpoints, mountpoint)
+	}
+	sort.Strings(mountpoints)
+	return mountpoints
+}
+
+// sample 采集一次指标
+func (c *Collector) sample(ctx context.Context) (types.MetricSample, error) {
+	sample := types.MetricSample{
+		Timestamp:   c.now(),
+		DiskPercent: make(map[string]float64),
+		NetRxBytes:  make(map[string]uint64),
+		NetTxBytes:  make(map[string]uint64),
+		Host:        identity.Get(),
+	}
+
+	providers := tools.CurrentProviders()
+	cpuPercent, err := providers.CPU.Percent(ctx, 0, false)
+	if err != nil {
+		return sample, fmt.Errorf("获取 CPU 使用率失败: %v", err)
+	}
+	if len(cpuPercent) > 0 {
+		sample.CPUPercent = cpuPercent[0]
+	}
+
+	// 启动时间用于识别跨越重启的计数器，获取失败时保持为 0（未知）
+	if bootTime, err := providers.Host.BootTime(ctx); err == nil {
+		sample.BootTime = bootTime
+	}
+
+	memInfo, err := c.memoryTool.GetMemoryData(ctx)
+	if err != nil {
+		return sample, err
+	}
+	sample.MemoryPercent = memInfo.UsedPercent
+
+	diskInfo, err := c.diskTool.GetDiskData(ctx, false)
+	if err != nil {
+		return sample, err
+	}
+	for _, partition := range diskInfo.Partitions {
+		sample.DiskPercent[partition.Mountpoint] = partition.UsedPercent
+	}
+
+	netInfo, err := c.networkTool.GetNetworkData(ctx, false, "")
+	if err != nil {
+		return sample, err
+	}
+	for _, iface := range netInfo.Interfaces {
+		sample.NetRxBytes[iface.Name] = iface.BytesRecv
+		sample.NetTxBytes[iface.Name] = iface.BytesSent
+	}
+
+	return sample, nil
+}
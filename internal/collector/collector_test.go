@@ -0,0 +1,289 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/storage"
+	"mcp-example/internal/tools"
+	"mcp-example/internal/types"
+)
+
+// countingStorage 记录采集历史写入次数的内存存储
+type countingStorage struct {
+	*storage.MemoryStorage
+	historySaves int
+}
+
+func (cs *countingStorage) Save(key string, data interface{}) error {
+	if strings.HasPrefix(key, tools.HistoryKeyPrefix) {
+		cs.historySaves++
+	}
+	return cs.MemoryStorage.Save(key, data)
+}
+
+func newTestCollector() (*Collector, *countingStorage) {
+	dataStorage := &countingStorage{MemoryStorage: storage.NewMemoryStorage()}
+	return NewCollector(dataStorage, time.Second, nil, nil, nil), dataStorage
+}
+
+// storedSamples 存储中 day 的采集历史
+func storedSamples(t *testing.T, dataStorage types.DataStorage, day time.Time) []types.MetricSample {
+	t.Helper()
+	var samples []types.MetricSample
+	if err := dataStorage.Load(tools.HistoryKey(day), &samples); err != nil {
+		t.Fatal(err)
+	}
+	return samples
+}
+
+func TestCollectorBatchesHistoryWrites(t *testing.T) {
+	c, dataStorage := newTestCollector()
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.Local)
+	for i := 0; i < 100; i++ {
+		if err := c.record(types.MetricSample{Timestamp: start.Add(time.Duration(i) * time.Second)}, time.Duration(i)*time.Second); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 第一个样本立即写入，之后的样本等到 historyFlushInterval 后再写入
+	if dataStorage.historySaves != 1 {
+		t.Fatalf("history written %d times for 100 samples, want 1", dataStorage.historySaves)
+	}
+	if samples := storedSamples(t, dataStorage, start); len(samples) != 1 {
+		t.Fatalf("stored %d samples before flush, want 1", len(samples))
+	}
+
+	if err := c.flushHistory(); err != nil {
+		t.Fatal(err)
+	}
+	if samples := storedSamples(t, dataStorage, start); len(samples) != 100 {
+		t.Fatalf("stored %d samples after flush, want 100", len(samples))
+	}
+	// 没有新样本时不重复写入
+	if err := c.flushHistory(); err != nil || dataStorage.historySaves != 2 {
+		t.Fatalf("flush without new samples wrote history (%d writes, %v)", dataStorage.historySaves, err)
+	}
+}
+
+func TestCollectorFlushesPreviousDay(t *testing.T) {
+	c, dataStorage := newTestCollector()
+	day := time.Date(2024, 1, 2, 23, 59, 0, 0, time.Local)
+	for i := 0; i < 3; i++ {
+		if err := c.record(types.MetricSample{Timestamp: day.Add(time.Duration(i) * time.Second)}, time.Duration(i)*time.Second); err != nil {
+			t.Fatal(err)
+		}
+	}
+	next := day.Add(2 * time.Minute)
+	if err := c.record(types.MetricSample{Timestamp: next}, 2*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if samples := storedSamples(t, dataStorage, day); len(samples) != 3 {
+		t.Fatalf("previous day has %d stored samples, want 3", len(samples))
+	}
+	if err := c.flushHistory(); err != nil {
+		t.Fatal(err)
+	}
+	if samples := storedSamples(t, dataStorage, next); len(samples) != 1 {
+		t.Fatalf("new day has %d stored samples, want 1", len(samples))
+	}
+}
+
+func TestCollectorPrunesHistoryOnNewDay(t *testing.T) {
+	c, dataStorage := newTestCollector()
+	c.KeepHistory(48 * time.Hour)
+	now := time.Date(2024, 1, 10, 8, 0, 0, 0, time.Local)
+	for _, day := range []time.Time{now.AddDate(0, 0, -5), now.AddDate(0, 0, -2), now.AddDate(0, 0, -1)} {
+		if err := dataStorage.Save(tools.HistoryKey(day), []types.MetricSample{{Timestamp: day}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := c.record(types.MetricSample{Timestamp: now}, 0); err != nil {
+		t.Fatal(err)
+	}
+	keys, _ := dataStorage.ListKeysWithPrefix(tools.HistoryKeyPrefix)
+	if strings.Join(keys, ",") != "history_20240108,history_20240109,history_20240110" {
+		t.Fatalf("history keys after pruning = %v", keys)
+	}
+}
+
+// fakeClock 手动推进的墙上时钟和单调时钟，Jump 只移动墙上时钟以模拟 NTP 步进调整或挂起后恢复
+type fakeClock struct {
+	wall time.Time
+	mono time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{wall: time.Date(2024, 1, 2, 10, 0, 0, 0, time.Local), mono: time.Hour}
+}
+
+func (f *fakeClock) Now() time.Time             { return f.wall }
+func (f *fakeClock) Monotonic() time.Duration   { return f.mono }
+func (f *fakeClock) Jump(d time.Duration)       { f.wall = f.wall.Add(d) }
+func (f *fakeClock) Advance(d time.Duration)    { f.wall, f.mono = f.wall.Add(d), f.mono+d }
+func (f *fakeClock) install(c *Collector)       { c.now, c.monotonic = f.Now, f.Monotonic }
+func (f *fakeClock) sample() types.MetricSample { return types.MetricSample{Timestamp: f.Now()} }
+
+func TestCollectorMarksClockJumps(t *testing.T) {
+	c, dataStorage := newTestCollector()
+	clock := newFakeClock()
+	clock.install(c)
+
+	steps := []struct {
+		jump time.Duration
+		want float64
+	}{
+		{0, 0},
+		{0, 0},
+		// 挂起一小时后恢复：墙上时钟向前跳，单调时钟只走了一个间隔
+		{time.Hour, 3600},
+		{0, 0},
+		// NTP 步进将时钟拨回两小时
+		{-2 * time.Hour, -7200},
+		{0, 0},
+		// 漂移不超过 maxClockDrift 不算跳变
+		{4 * time.Second, 0},
+		{-4 * time.Second, 0},
+	}
+	for i, step := range steps {
+		clock.Jump(step.jump)
+		clock.Advance(10 * time.Second)
+		if err := c.record(clock.sample(), clock.Monotonic()); err != nil {
+			t.Fatal(err)
+		}
+		if got := c.samples[len(c.samples)-1].ClockJumpSeconds; got != step.want {
+			t.Errorf("sample %d after a %s jump: ClockJumpSeconds = %v, want %v", i, step.jump, got, step.want)
+		}
+	}
+
+	// 时钟倒退后仍按单调时钟每 historyFlushInterval 写入一次
+	saves := dataStorage.historySaves
+	for i := 0; i < 7; i++ {
+		clock.Advance(10 * time.Second)
+		if err := c.record(clock.sample(), clock.Monotonic()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if dataStorage.historySaves != saves+1 {
+		t.Errorf("history written %d times in 70s after a backward jump, want once", dataStorage.historySaves-saves)
+	}
+}
+
+func TestCollectorComparesFirstSampleWithStoredHistory(t *testing.T) {
+	stored := time.Date(2024, 1, 2, 10, 0, 0, 0, time.Local)
+	cases := []struct {
+		name  string
+		first time.Time
+		want  float64
+	}{
+		// 重启后墙上时钟早于存储中最后一个样本：时间倒退
+		{"backward", stored.Add(-time.Hour), -3600},
+		// 服务器停止期间经过的时间无法与单调时钟比较，向前的间隔不算跳变
+		{"restart gap", stored.Add(3 * time.Hour), 0},
+		{"small drift", stored.Add(-3 * time.Second), 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, dataStorage := newTestCollector()
+			clock := newFakeClock()
+			clock.install(c)
+			if err := dataStorage.Save(tools.HistoryKey(stored), []types.MetricSample{{Timestamp: stored}}); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.record(types.MetricSample{Timestamp: tc.first}, clock.Monotonic()); err != nil {
+				t.Fatal(err)
+			}
+			if got := c.samples[len(c.samples)-1].ClockJumpSeconds; got != tc.want {
+				t.Fatalf("ClockJumpSeconds = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// fakeCPU 使用率固定的 CPU 来源，其余方法使用真实来源
+type fakeCPU struct {
+	tools.CPUProvider
+	percent float64
+	err     error
+	calls   atomic.Int32
+}
+
+func (f *fakeCPU) Percent(context.Context, time.Duration, bool) ([]float64, error) {
+	f.calls.Add(1)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []float64{f.percent}, nil
+}
+
+// fakeBootTime 启动时间固定的主机来源，其余方法使用真实来源
+type fakeBootTime struct {
+	tools.HostProvider
+	bootTime uint64
+}
+
+func (f *fakeBootTime) BootTime(context.Context) (uint64, error) { return f.bootTime, nil }
+
+// useFakeCPUAndHost 在测试期间替换 CPU 来源和主机启动时间
+func useFakeCPUAndHost(t *testing.T, cpu *fakeCPU, bootTime uint64) {
+	current := tools.CurrentProviders()
+	cpu.CPUProvider = current.CPU
+	current.CPU = cpu
+	current.Host = &fakeBootTime{HostProvider: current.Host, bootTime: bootTime}
+	t.Cleanup(tools.SetProviders(current))
+}
+
+func TestCollectorSamplesThroughProviders(t *testing.T) {
+	cpu := &fakeCPU{percent: 42.5}
+	useFakeCPUAndHost(t, cpu, 1700000000)
+	cache := storage.NewMemoryCache()
+	style := tools.NewOutputStyle(tools.StylePlain, 0)
+	c := NewCollector(storage.NewMemoryStorage(), time.Second,
+		tools.NewMemoryTool(cache, tools.CacheOptions{}, style),
+		tools.NewDiskTool(cache, tools.CacheOptions{}, tools.PartitionFilter{}, style, nil),
+		tools.NewNetworkTool(cache, tools.CacheOptions{}, 0, nil))
+
+	sample, err := c.sample(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sample.CPUPercent != 42.5 || sample.BootTime != 1700000000 {
+		t.Fatalf("sample CPU %.1f%%, boot time %d; want the fake provider's 42.5%% and 1700000000", sample.CPUPercent, sample.BootTime)
+	}
+	// 每个样本都带有主机身份，汇总多台机器的历史时可以区分来源
+	if sample.Host == nil || sample.Host.Fingerprint != identity.Get().Fingerprint {
+		t.Errorf("sample host = %+v, want the cached identity", sample.Host)
+	}
+
+	cpu.err = errors.New("no /proc/stat")
+	if _, err := c.sample(context.Background()); err == nil || !strings.Contains(err.Error(), "no /proc/stat") {
+		t.Fatalf("sample() with a failing CPU provider = %v", err)
+	}
+}
+
+func TestRunPrimesCPUThroughProvider(t *testing.T) {
+	cpu := &fakeCPU{}
+	useFakeCPUAndHost(t, cpu, 0)
+	c, _ := newTestCollector()
+	jittered := make(chan struct{})
+	c.jitter = func(time.Duration) time.Duration {
+		close(jittered)
+		return time.Hour
+	}
+
+	go c.Run(context.Background())
+	<-jittered
+	if calls := cpu.calls.Load(); calls != 1 {
+		t.Errorf("CPU provider called %d times before the first cycle, want the baseline call", calls)
+	}
+	if err := c.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
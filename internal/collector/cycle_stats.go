@@ -0,0 +1,105 @@
+package collector
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"sync"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// maxCycleDurations 计算采样耗时分位数时保留的最近采样次数
+const maxCycleDurations = 256
+
+// maxStartJitter 首次采样前随机等待时间的上限，实际上限为采集间隔和该值中较小的一个
+const maxStartJitter = time.Minute
+
+// cycleStats 采集周期的统计，由采集循环写入，Stats 读取
+type cycleStats struct {
+	mutex        sync.Mutex
+	runs         uint64
+	failures     uint64
+	skippedTicks uint64
+	overlongRuns uint64
+	// durations 最近 maxCycleDurations 次采样的耗时（环形缓冲区），next 为下一次写入的位置
+	durations []time.Duration
+	next      int
+}
+
+// record 记录一次耗时 duration 的采样，返回采样期间到期、因此被跳过的周期数
+func (s *cycleStats) record(duration, interval time.Duration, failed bool) int {
+	skipped := missedTicks(duration, interval)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.runs++
+	if failed {
+		s.failures++
+	}
+	if skipped > 0 {
+		s.overlongRuns++
+		s.skippedTicks += uint64(skipped)
+	}
+	if len(s.durations) < maxCycleDurations {
+		s.durations = append(s.durations, duration)
+	} else {
+		s.durations[s.next] = duration
+	}
+	s.next = (s.next + 1) % maxCycleDurations
+	return skipped
+}
+
+// snapshot 返回计数和耗时分位数，不包括间隔和最近一次采样时间
+func (s *cycleStats) snapshot() types.CollectorStats {
+	s.mutex.Lock()
+	stats := types.CollectorStats{
+		Runs:         s.runs,
+		Failures:     s.failures,
+		SkippedTicks: s.skippedTicks,
+		OverlongRuns: s.overlongRuns,
+	}
+	durations := slices.Clone(s.durations)
+	s.mutex.Unlock()
+
+	if ticks := stats.Runs + stats.SkippedTicks; ticks > 0 {
+		stats.SkipPercent = float64(stats.SkippedTicks) / float64(ticks) * 100
+	}
+	if len(durations) > 0 {
+		slices.Sort(durations)
+		stats.DurationP50Ms = milliseconds(percentile(durations, 50))
+		stats.DurationP90Ms = milliseconds(percentile(durations, 90))
+		stats.DurationP99Ms = milliseconds(percentile(durations, 99))
+		stats.DurationMaxMs = milliseconds(durations[len(durations)-1])
+	}
+	return stats
+}
+
+// missedTicks 耗时 duration 的采样期间到期的周期数。采集循环逐个周期串行采样，这些周期不会排队补采
+func missedTicks(duration, interval time.Duration) int {
+	if interval <= 0 || duration < interval {
+		return 0
+	}
+	return int(duration / interval)
+}
+
+// percentile 已排序的 sorted 中第 p 百分位的值（最近秩法），sorted 不能为空
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// milliseconds 以毫秒表示的时长
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// startJitter 首次采样前的随机等待时间，避免同时启动的大量主机在同一时刻采样
+func startJitter(interval time.Duration) time.Duration {
+	limit := min(interval, maxStartJitter)
+	if limit <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(limit)))
+}
@@ -0,0 +1,311 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMissedTicks(t *testing.T) {
+	cases := []struct {
+		duration, interval time.Duration
+		want               int
+	}{
+		{0, time.Second, 0},
+		{999 * time.Millisecond, time.Second, 0},
+		{time.Second, time.Second, 1},
+		{2500 * time.Millisecond, time.Second, 2},
+		{time.Minute, 10 * time.Second, 6},
+		{time.Second, 0, 0},
+	}
+	for _, c := range cases {
+		if got := missedTicks(c.duration, c.interval); got != c.want {
+			t.Errorf("missedTicks(%s, %s) = %d, want %d", c.duration, c.interval, got, c.want)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var hundred []time.Duration
+	for i := 1; i <= 100; i++ {
+		hundred = append(hundred, time.Duration(i)*time.Millisecond)
+	}
+	three := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond}
+
+	cases := []struct {
+		sorted []time.Duration
+		p      float64
+		want   time.Duration
+	}{
+		{hundred, 50, 50 * time.Millisecond},
+		{hundred, 90, 90 * time.Millisecond},
+		{hundred, 99, 99 * time.Millisecond},
+		{hundred, 100, 100 * time.Millisecond},
+		{hundred, 0, time.Millisecond},
+		// 最近秩法：秩向上取整，不做插值
+		{three, 50, 20 * time.Millisecond},
+		{three, 34, 20 * time.Millisecond},
+		{three, 33, 10 * time.Millisecond},
+		{three, 99, 30 * time.Millisecond},
+		{three[:1], 99, 10 * time.Millisecond},
+	}
+	for _, c := range cases {
+		if got := percentile(c.sorted, c.p); got != c.want {
+			t.Errorf("percentile(%d values, %v) = %s, want %s", len(c.sorted), c.p, got, c.want)
+		}
+	}
+}
+
+func TestCycleStatsRecord(t *testing.T) {
+	var stats cycleStats
+	interval := 10 * time.Second
+	for _, run := range []struct {
+		duration time.Duration
+		failed   bool
+		skipped  int
+	}{
+		{time.Second, false, 0},
+		{2 * time.Second, true, 0},
+		{25 * time.Second, false, 2},
+		{3 * time.Second, false, 0},
+		{10 * time.Second, true, 1},
+	} {
+		if skipped := stats.record(run.duration, interval, run.failed); skipped != run.skipped {
+			t.Fatalf("record(%s) = %d skipped, want %d", run.duration, skipped, run.skipped)
+		}
+	}
+
+	snapshot := stats.snapshot()
+	if snapshot.Runs != 5 || snapshot.Failures != 2 || snapshot.SkippedTicks != 3 || snapshot.OverlongRuns != 2 {
+		t.Fatalf("snapshot = %+v, want 5 runs, 2 failures, 3 skipped ticks in 2 overlong runs", snapshot)
+	}
+	// 5 次采样加上 3 个跳过的周期，共 8 个到期的周期
+	if snapshot.SkipPercent != 37.5 {
+		t.Errorf("SkipPercent = %v, want 37.5", snapshot.SkipPercent)
+	}
+	if snapshot.DurationP50Ms != 3000 || snapshot.DurationP90Ms != 25000 || snapshot.DurationP99Ms != 25000 || snapshot.DurationMaxMs != 25000 {
+		t.Errorf("duration percentiles = %v/%v/%v/%v ms", snapshot.DurationP50Ms, snapshot.DurationP90Ms, snapshot.DurationP99Ms, snapshot.DurationMaxMs)
+	}
+
+	if empty := new(cycleStats).snapshot(); empty.SkipPercent != 0 || empty.DurationMaxMs != 0 {
+		t.Errorf("snapshot without runs = %+v", empty)
+	}
+}
+
+func TestCycleStatsKeepsRecentDurations(t *testing.T) {
+	var stats cycleStats
+	// 最早的一次耗时最长，被挤出环形缓冲区后不再影响分位数
+	stats.record(time.Hour, 2*time.Hour, false)
+	for i := 0; i < maxCycleDurations; i++ {
+		stats.record(time.Duration(i+1)*time.Millisecond, time.Hour, false)
+	}
+
+	snapshot := stats.snapshot()
+	if snapshot.Runs != maxCycleDurations+1 {
+		t.Fatalf("Runs = %d, want every run counted", snapshot.Runs)
+	}
+	if snapshot.DurationMaxMs != maxCycleDurations || snapshot.DurationP50Ms != maxCycleDurations/2 {
+		t.Fatalf("max = %v ms, p50 = %v ms; want only the last %d durations", snapshot.DurationMaxMs, snapshot.DurationP50Ms, maxCycleDurations)
+	}
+}
+
+func TestStartJitterBounds(t *testing.T) {
+	for _, interval := range []time.Duration{time.Millisecond, time.Second, 30 * time.Second, 10 * time.Minute} {
+		limit := min(interval, maxStartJitter)
+		var maxSeen time.Duration
+		for i := 0; i < 1000; i++ {
+			jitter := startJitter(interval)
+			if jitter < 0 || jitter >= limit {
+				t.Fatalf("startJitter(%s) = %s, want within [0, %s)", interval, jitter, limit)
+			}
+			maxSeen = max(maxSeen, jitter)
+		}
+		// 1000 次中至少有一次落在上限的后半段，等待时间确实是随机分布的
+		if maxSeen < limit/2 {
+			t.Errorf("startJitter(%s) never exceeded %s in 1000 draws", interval, maxSeen)
+		}
+	}
+	if jitter := startJitter(0); jitter != 0 {
+		t.Errorf("startJitter(0) = %s, want 0", jitter)
+	}
+}
+
+// manualTicker 由测试手动触发的 ticker，与 time.Ticker 一样最多积压一次到期
+type manualTicker struct {
+	c       chan time.Time
+	mutex   sync.Mutex
+	resets  []time.Duration
+	stopped bool
+}
+
+func newManualTicker() *manualTicker {
+	return &manualTicker{c: make(chan time.Time, 1)}
+}
+
+func (m *manualTicker) C() <-chan time.Time { return m.c }
+
+func (m *manualTicker) Reset(d time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.resets = append(m.resets, d)
+}
+
+func (m *manualTicker) Stop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.stopped = true
+}
+
+// tick 触发一次到期，已有积压时丢弃
+func (m *manualTicker) tick() {
+	select {
+	case m.c <- time.Time{}:
+	default:
+	}
+}
+
+// waitFor 等待 condition 成立，超时则测试失败
+func waitFor(t *testing.T, description string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", description)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// slowCollect 每次调用按 durations 中的耗时推进 clock，可在采样期间触发 ticker 模拟周期积压
+type slowCollect struct {
+	clock     *fakeClock
+	ticker    *manualTicker
+	durations []time.Duration
+	calls     atomic.Int32
+	active    atomic.Int32
+	overlap   atomic.Bool
+}
+
+func (s *slowCollect) collect(ctx context.Context) error {
+	if s.active.Add(1) > 1 {
+		s.overlap.Store(true)
+	}
+	defer s.active.Add(-1)
+
+	call := int(s.calls.Add(1)) - 1
+	duration := s.durations[call%len(s.durations)]
+	s.clock.Advance(duration)
+	// 采样期间每经过一个间隔 ticker 到期一次，只有一次会积压
+	for elapsed := 10 * time.Second; elapsed <= duration; elapsed += 10 * time.Second {
+		s.ticker.tick()
+	}
+	if call == 1 {
+		return errors.New("disk usage timed out")
+	}
+	return nil
+}
+
+func TestRunSkipsTicksDuringSlowCycles(t *testing.T) {
+	c, _ := newTestCollector()
+	c.SetInterval(10 * time.Second)
+	<-c.intervalChanged
+
+	clock := newFakeClock()
+	clock.install(c)
+	manual := newManualTicker()
+	var tickerPeriod time.Duration
+	c.newTicker = func(d time.Duration) ticker {
+		tickerPeriod = d
+		return manual
+	}
+	c.jitter = func(time.Duration) time.Duration { return 0 }
+	slow := &slowCollect{clock: clock, ticker: manual, durations: []time.Duration{time.Second, 35 * time.Second, 2 * time.Second}}
+	c.collect = slow.collect
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	for i := 1; i <= 3; i++ {
+		manual.tick()
+		waitFor(t, "the collection cycle", func() bool { return c.Stats().Runs == uint64(i) })
+	}
+	if err := c.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if tickerPeriod != 10*time.Second || !manual.stopped {
+		t.Errorf("ticker period = %s, stopped = %v", tickerPeriod, manual.stopped)
+	}
+	if slow.overlap.Load() {
+		t.Error("two collection cycles ran concurrently")
+	}
+	// 35 秒的采样期间积压的到期被丢弃，不会在采样结束后立即补采
+	if calls := slow.calls.Load(); calls != 3 {
+		t.Errorf("collect called %d times for 3 ticks, want the backlogged tick dropped", calls)
+	}
+
+	stats := c.Stats()
+	if stats.Runs != 3 || stats.Failures != 1 || stats.SkippedTicks != 3 || stats.OverlongRuns != 1 {
+		t.Fatalf("stats = %+v, want 3 runs, 1 failure and 3 ticks skipped by 1 overlong run", stats)
+	}
+	if stats.DurationMaxMs != 35000 || stats.DurationP50Ms != 2000 || stats.IntervalSeconds != 10 {
+		t.Errorf("stats = %+v, want durations measured on the injected clock", stats)
+	}
+	if stats.LastRun == nil || !stats.LastRun.Equal(clock.Now()) {
+		t.Errorf("LastRun = %v, want %v", stats.LastRun, clock.Now())
+	}
+}
+
+func TestRunResetsTickerOnIntervalChange(t *testing.T) {
+	c, _ := newTestCollector()
+	manual := newManualTicker()
+	c.newTicker = func(time.Duration) ticker { return manual }
+	c.jitter = func(time.Duration) time.Duration { return 0 }
+	c.collect = func(context.Context) error { return nil }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go c.Run(ctx)
+	c.SetInterval(30 * time.Second)
+	waitFor(t, "the ticker reset", func() bool {
+		manual.mutex.Lock()
+		defer manual.mutex.Unlock()
+		return len(manual.resets) == 1 && manual.resets[0] == 30*time.Second
+	})
+
+	cancel()
+	<-c.done
+}
+
+func TestRunWaitsForStartJitter(t *testing.T) {
+	c, _ := newTestCollector()
+	jitterFor := make(chan time.Duration, 1)
+	c.jitter = func(interval time.Duration) time.Duration {
+		jitterFor <- interval
+		return time.Hour
+	}
+	created := make(chan struct{})
+	c.newTicker = func(time.Duration) ticker {
+		close(created)
+		return newManualTicker()
+	}
+
+	go c.Run(context.Background())
+	if interval := <-jitterFor; interval != time.Second {
+		t.Errorf("jitter computed for %s, want the collection interval", interval)
+	}
+	// 随机等待期间 Stop 立即返回，不会创建 ticker 或采样
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-created:
+		t.Fatal("the ticker was created before the start jitter elapsed")
+	default:
+	}
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// DefaultStaleWindow 开启 stale-while-revalidate 但未指定窗口时使用的默认值
+const DefaultStaleWindow = time.Minute
+
+// DefaultRateWindow network_stats 计算速率时上一次采样的默认有效期
+const DefaultRateWindow = 5 * time.Minute
+
+// DefaultUsageTimeout disk_info 查询单个挂载点使用量的默认时限
+const DefaultUsageTimeout = 2 * time.Second
+
+// Config 配置文件结构，对应 configs/server_config.json
+type Config struct {
+	ServerName      string           `json:"server_name"`
+	DataDir         string           `json:"data_dir"`
+	Storage         string           `json:"storage"`
+	LogLevel        string           `json:"log_level"`
+	CacheEnabled    *bool            `json:"cache_enabled"`
+	CompressStorage *bool            `json:"compress_storage"`
+	CollectInterval *Duration        `json:"collect_interval"`
+	AllowTools      []string         `json:"allow_tools"`
+	DenyTools       []string         `json:"deny_tools"`
+	ReadOnly        *bool            `json:"read_only"`
+	Thresholds      types.Thresholds `json:"thresholds"`
+	OutputStyle     string           `json:"output_style"`
+	BarWidth        int              `json:"bar_width"`
+	AnomalySigmas   float64          `json:"anomaly_sigmas"`
+	IncidentResolve *Duration        `json:"incident_resolve_after"`
+	FallbackMaxAge  *Duration        `json:"fallback_max_age"`
+	MaxResultBytes  int              `json:"max_result_bytes"`
+	ResultRetention *Duration        `json:"result_retention"`
+	// HistoryRetention 后台采集历史的保留时长，0 表示不删除
+	HistoryRetention *Duration             `json:"history_retention"`
+	ToolsConfig      map[string]ToolConfig `json:"tools_config"`
+}
+
+// DefaultThresholds 健康检查的默认阈值。数据目录所在分区写满会影响服务器自身，阈值比普通分区略低
+func DefaultThresholds() types.Thresholds {
+	return types.Thresholds{
+		CPUPercent:         types.Threshold{Warning: 80, Critical: 95},
+		MemoryPercent:      types.Threshold{Warning: 85, Critical: 95},
+		SwapPercent:        types.Threshold{Warning: 50, Critical: 80},
+		DiskPercent:        types.Threshold{Warning: 85, Critical: 95},
+		LoadPerCore:        types.Threshold{Warning: 1.5, Critical: 3},
+		Zombies:            types.Threshold{Warning: 1, Critical: 50},
+		ClockOffsetMs:      types.Threshold{Warning: 100, Critical: 1000},
+		SelfRSSMB:          types.Threshold{Warning: 256, Critical: 1024},
+		DataDirMB:          types.Threshold{Warning: 512, Critical: 2048},
+		DataDirDiskPercent: types.Threshold{Warning: 80, Critical: 90},
+		// 偶尔一次慢采样不值得告警，持续跳过说明采集间隔对这台机器太短
+		CollectorSkipPercent: types.Threshold{Warning: 10, Critical: 50},
+	}
+}
+
+// EffectiveThresholds 配置文件中未设置（两个级别都为 0）的指标使用默认阈值
+func EffectiveThresholds(configured types.Thresholds) types.Thresholds {
+	defaults := DefaultThresholds()
+	pick := func(value, fallback types.Threshold) types.Threshold {
+		if value.Warning == 0 && value.Critical == 0 {
+			return fallback
+		}
+		return value
+	}
+
+	return types.Thresholds{
+		CPUPercent:           pick(configured.CPUPercent, defaults.CPUPercent),
+		MemoryPercent:        pick(configured.MemoryPercent, defaults.MemoryPercent),
+		SwapPercent:          pick(configured.SwapPercent, defaults.SwapPercent),
+		DiskPercent:          pick(configured.DiskPercent, defaults.DiskPercent),
+		LoadPerCore:          pick(configured.LoadPerCore, defaults.LoadPerCore),
+		Zombies:              pick(configured.Zombies, defaults.Zombies),
+		ClockOffsetMs:        pick(configured.ClockOffsetMs, defaults.ClockOffsetMs),
+		SelfRSSMB:            pick(configured.SelfRSSMB, defaults.SelfRSSMB),
+		DataDirMB:            pick(configured.DataDirMB, defaults.DataDirMB),
+		DataDirDiskPercent:   pick(configured.DataDirDiskPercent, defaults.DataDirDiskPercent),
+		CollectorSkipPercent: pick(configured.CollectorSkipPercent, defaults.CollectorSkipPercent),
+	}
+}
+
+// ToolConfig 单个工具的配置
+type ToolConfig struct {
+	StaleWhileRevalidate bool     `json:"stale_while_revalidate"`
+	StaleWindow          Duration `json:"stale_window"`
+	// ExtraParams kernel_params 在默认允许列表之外额外允许读取的参数
+	ExtraParams []string `json:"extra_params"`
+	// RateWindow network_stats 上一次采样在该时间内有效，用于计算平均速率
+	RateWindow Duration `json:"rate_window"`
+	// disk_info 分区过滤：挂载点按前缀匹配，未配置的跳过列表使用默认值，始终显示的规则优先
+	SkipMountpointPrefixes []string `json:"skip_mountpoint_prefixes"`
+	SkipFstypes            []string `json:"skip_fstypes"`
+	AlwaysShowMountpoints  []string `json:"always_show_mountpoints"`
+	AlwaysShowFstypes      []string `json:"always_show_fstypes"`
+	// disk_info 只读挂载属于正常情况的挂载点（按前缀匹配）和文件系统类型，未配置类型时使用默认值（squashfs、iso9660 等）
+	ExpectedReadOnlyMountpoints []string `json:"expected_read_only_mountpoints"`
+	ExpectedReadOnlyFstypes     []string `json:"expected_read_only_fstypes"`
+	// UsageTimeout disk_info 查询单个挂载点使用量的时限，超时的挂载点（如失联的 NFS）被跳过
+	UsageTimeout Duration `json:"usage_timeout"`
+	// RedactEnvPatterns process_detail 中需要隐藏值的环境变量名称模式（通配符，不区分大小写），未配置时使用默认值
+	RedactEnvPatterns []string `json:"redact_env_patterns"`
+	// 调用限流：每分钟允许的调用次数和突发次数（所有会话共享），0 表示不限制；未配置突发次数时等于每分钟次数
+	RateLimitPerMinute float64 `json:"rate_limit_per_minute"`
+	RateLimitBurst     int     `json:"rate_limit_burst"`
+}
+
+// EffectiveStaleWindow 获取生效的过期数据可用窗口，未开启时返回 0
+func (tc ToolConfig) EffectiveStaleWindow() time.Duration {
+	if !tc.StaleWhileRevalidate {
+		return 0
+	}
+	if tc.StaleWindow <= 0 {
+		return DefaultStaleWindow
+	}
+	return time.Duration(tc.StaleWindow)
+}
+
+// EffectiveRateWindow 获取生效的速率计算窗口，未配置时返回默认值
+func (tc ToolConfig) EffectiveRateWindow() time.Duration {
+	if tc.RateWindow <= 0 {
+		return DefaultRateWindow
+	}
+	return time.Duration(tc.RateWindow)
+}
+
+// EffectiveUsageTimeout 获取生效的挂载点使用量查询时限，未配置时返回默认值
+func (tc ToolConfig) EffectiveUsageTimeout() time.Duration {
+	if tc.UsageTimeout <= 0 {
+		return DefaultUsageTimeout
+	}
+	return time.Duration(tc.UsageTimeout)
+}
+
+// EffectiveRateLimit 获取生效的调用限流（每分钟次数和突发次数），未开启时 perMinute 为 0
+func (tc ToolConfig) EffectiveRateLimit() (perMinute float64, burst int) {
+	if tc.RateLimitPerMinute <= 0 {
+		return 0, 0
+	}
+	burst = tc.RateLimitBurst
+	if burst <= 0 {
+		burst = max(1, int(tc.RateLimitPerMinute))
+	}
+	return tc.RateLimitPerMinute, burst
+}
+
+// Duration 支持 "30s" 形式的 JSON 时长
+type Duration time.Duration
+
+// UnmarshalJSON 解析时长字符串
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("时长必须是字符串: %v", err)
+	}
+
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return fmt.Errorf("无效的时长 %q: %v", text, err)
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON 输出时长字符串
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Load 从文件加载配置
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	return &config, nil
+}
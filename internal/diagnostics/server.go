@@ -0,0 +1,87 @@
+// Package diagnostics 提供调试用的 HTTP 服务：net/http/pprof 和 /healthz，仅在指定 --debug-addr 时启动
+package diagnostics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// readHeaderTimeout 读取请求头的超时时间
+const readHeaderTimeout = 5 * time.Second
+
+// StatusFunc 返回当前诊断状态
+type StatusFunc func() types.DiagnosticsStatus
+
+// Server 诊断 HTTP 服务
+type Server struct {
+	httpServer *http.Server
+	listener   net.Listener
+}
+
+// NewHandler 创建诊断路由：/debug/pprof/ 下为 pprof，/healthz 返回 JSON 格式的诊断状态
+func NewHandler(status StatusFunc) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(status())
+	})
+
+	return mux
+}
+
+// Start 在 addr 上监听并在后台提供诊断服务，监听失败时返回错误
+func Start(addr string, status StatusFunc) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &Server{
+		httpServer: &http.Server{
+			Handler:           NewHandler(status),
+			ReadHeaderTimeout: readHeaderTimeout,
+		},
+		listener: listener,
+	}
+
+	go func() {
+		if err := server.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("诊断服务异常退出", "addr", addr, "error", err)
+		}
+	}()
+
+	return server, nil
+}
+
+// Addr 实际监听的地址（addr 端口为 0 时可用于获取分配的端口）
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Shutdown 关闭诊断服务，等待进行中的请求直到 ctx 结束
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
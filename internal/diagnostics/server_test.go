@@ -0,0 +1,123 @@
+package diagnostics
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// fixedStatus 固定的诊断状态
+func fixedStatus() types.DiagnosticsStatus {
+	lastRun := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	return types.DiagnosticsStatus{
+		UptimeSeconds:  42.5,
+		Goroutines:     17,
+		LastCollectRun: &lastRun,
+		Storage:        &types.StorageStats{Backend: "memory"},
+		Cache:          types.CacheStats{Hits: 3, Misses: 1},
+		Sessions:       []types.SessionStats{},
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	calls := 0
+	handler := NewHandler(func() types.DiagnosticsStatus {
+		calls++
+		return fixedStatus()
+	})
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("GET /healthz = %d %q", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+		t.Fatalf("body is not JSON: %v\n%s", err, recorder.Body)
+	}
+	if got["uptime_seconds"] != 42.5 || got["goroutines"] != float64(17) || got["last_collect_run"] != "2024-05-06T07:08:09Z" {
+		t.Errorf("GET /healthz = %s", recorder.Body)
+	}
+	if storage, _ := got["storage"].(map[string]interface{}); storage["backend"] != "memory" {
+		t.Errorf("storage = %v", got["storage"])
+	}
+	if cache, _ := got["cache"].(map[string]interface{}); cache["hits"] != float64(3) {
+		t.Errorf("cache = %v", got["cache"])
+	}
+
+	// 每次请求都重新获取状态
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodHead, "/healthz", nil))
+	if recorder.Code != http.StatusOK || calls != 2 {
+		t.Errorf("HEAD /healthz = %d after %d status calls", recorder.Code, calls)
+	}
+
+	// 其他方法不允许
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/healthz", strings.NewReader("{}")))
+	if recorder.Code != http.StatusMethodNotAllowed || recorder.Header().Get("Allow") != "GET, HEAD" || calls != 2 {
+		t.Errorf("POST /healthz = %d, Allow %q", recorder.Code, recorder.Header().Get("Allow"))
+	}
+}
+
+func TestPprofRoutes(t *testing.T) {
+	handler := NewHandler(fixedStatus)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "goroutine") {
+		t.Errorf("GET /debug/pprof/ = %d\n%s", recorder.Code, recorder.Body)
+	}
+
+	// 命名的 profile 由 pprof.Index 处理
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/pprof/goroutine?debug=1", nil))
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "goroutine profile:") {
+		t.Errorf("GET /debug/pprof/goroutine = %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("GET / = %d, want 404", recorder.Code)
+	}
+}
+
+func TestStartAndShutdown(t *testing.T) {
+	server, err := Start("127.0.0.1:0", fixedStatus)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get("http://" + server.Addr() + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `"goroutines": 17`) {
+		t.Fatalf("GET /healthz = %d\n%s", resp.StatusCode, body)
+	}
+
+	// 关闭后不再接受连接
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := http.Get("http://" + server.Addr() + "/healthz"); err == nil {
+		t.Error("GET /healthz after Shutdown succeeded")
+	}
+
+	// 无法监听时返回错误
+	if _, err := Start("127.0.0.1:-1", fixedStatus); err == nil {
+		t.Error("Start() with an invalid address succeeded")
+	}
+}
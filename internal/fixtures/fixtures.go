@@ -0,0 +1,270 @@
+// Package fixtures 格式化测试使用的代表性监控数据：固定不变、不读取主机状态，每次调用返回新的副本，
+// 只依赖 types 包，工具包的内部测试也可以导入
+package fixtures
+
+import (
+	"fmt"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// At 所有数据的采集时间
+var At = time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+// SystemInfo 运行 3 天多的 KVM 虚拟机，包含负载和权限探测结果
+func SystemInfo() types.SystemInfo {
+	return types.SystemInfo{
+		Hostname:             "web-01",
+		OS:                   "linux",
+		Platform:             "ubuntu 22.04",
+		KernelVersion:        "5.15.0-105-generic",
+		Architecture:         "x86_64",
+		Uptime:               3*86400 + 4*3600 + 5*60 + 6,
+		BootTime:             uint64(At.Unix()) - (3*86400 + 4*3600 + 5*60 + 6),
+		ProcessCount:         213,
+		VirtualizationSystem: "kvm",
+		VirtualizationRole:   "guest",
+		Load:                 &types.LoadAverage{Load1: 1.25, Load5: 0.98, Load15: 0.72},
+		Permissions:          Permissions(),
+		LastUpdated:          At,
+	}
+}
+
+// Permissions 以 root 运行、没有权限限制的探测结果
+func Permissions() *types.PermissionProfile {
+	return &types.PermissionProfile{
+		EUID:           0,
+		Privileged:     true,
+		OtherProcessIO: true,
+		ConnectionPIDs: true,
+		Sensors:        true,
+		CheckedAt:      At,
+	}
+}
+
+// CPUInfo 8 逻辑核心的 CPU，各核心负载不均，包含调度统计
+func CPUInfo() types.CPUInfo {
+	return types.CPUInfo{
+		ModelName:    "Intel(R) Xeon(R) Gold 6248 CPU @ 2.50GHz",
+		Sockets:      1,
+		Cores:        4,
+		LogicalCores: 8,
+		Frequency:    2.5,
+		Usage: types.CPUUsage{
+			Total:   37.5,
+			PerCore: []float64{92.3, 4.1, 55, 0, 61.75, 12.5, 100, 3.33},
+		},
+		Scheduler: &types.CPUSchedulerStats{
+			ContextSwitchesPerSec: 15234,
+			InterruptsPerSec:      8120.4,
+			ProcsRunning:          3,
+			ProcsBlocked:          1,
+		},
+		LastUpdated: At,
+	}
+}
+
+// MemoryInfo 16 GB 内存，使用率约 62%，交换空间少量使用，包含 /proc/meminfo 详细信息
+func MemoryInfo() types.MemoryInfo {
+	const gb = 1 << 30
+	return types.MemoryInfo{
+		Total:       16 * gb,
+		Used:        10 * gb,
+		Available:   6 * gb,
+		Free:        2 * gb,
+		Buffers:     512 << 20,
+		Cached:      3584 << 20,
+		UsedPercent: 62.5,
+		Swap: types.SwapInfo{
+			Total:       4 * gb,
+			Used:        256 << 20,
+			Free:        4*gb - 256<<20,
+			UsedPercent: 6.25,
+		},
+		Detail: &types.MemoryDetail{
+			Shmem:          128 << 20,
+			Slab:           768 << 20,
+			SReclaimable:   640 << 20,
+			SUnreclaim:     128 << 20,
+			HugePagesTotal: 0,
+			CommitLimit:    12 * gb,
+			CommittedAS:    9 * gb,
+		},
+		LastUpdated: At,
+	}
+}
+
+// DiskInfo 三个分区：接近写满的根分区、挂载点过长的数据分区和意外只读的分区，以及一个超时跳过的 NFS 挂载
+func DiskInfo() types.DiskInfo {
+	const gb = 1 << 30
+	return types.DiskInfo{
+		Partitions: []types.DiskPartition{
+			{
+				Device: "/dev/sda1", Mountpoint: "/", Fstype: "ext4",
+				Total: 100 * gb, Used: 91 * gb, Free: 9 * gb, UsedPercent: 91,
+				Opts: []string{"rw", "relatime"},
+			},
+			{
+				Device: "/dev/sdb1", Mountpoint: "/srv/data/postgresql/archive", Fstype: "xfs",
+				Total: 2048 * gb, Used: 615 * gb, Free: 1433 * gb, UsedPercent: 30.03,
+				Opts: []string{"rw", "noatime"},
+			},
+			{
+				Device: "/dev/sdc1", Mountpoint: "/var/log", Fstype: "ext4",
+				Total: 20 * gb, Used: 5 * gb, Free: 15 * gb, UsedPercent: 25,
+				Opts: []string{"ro", "relatime"}, ReadOnly: true,
+			},
+		},
+		SkippedMounts: []types.SkippedMount{
+			{Mountpoint: "/mnt/backup", Fstype: "nfs4", Reason: "timeout"},
+		},
+		LastUpdated: At,
+	}
+}
+
+// NetworkInfo 三个网络接口和五个连接，其中一个接口的计数器曾经重置
+func NetworkInfo() types.NetworkInfo {
+	firstSeen := At.Add(-48 * time.Hour)
+	resetAfter := At.Add(-6 * time.Hour)
+	return types.NetworkInfo{
+		Interfaces: []types.NetworkInterface{
+			{
+				Name: "eth0", BytesSent: 52428800000, BytesRecv: 157286400000,
+				PacketsSent: 41234567, PacketsRecv: 123456789, ErrorsIn: 3,
+				FirstSeen: &firstSeen,
+			},
+			{
+				Name: "eth1", BytesSent: 1048576, BytesRecv: 2097152,
+				PacketsSent: 1024, PacketsRecv: 2048,
+				FirstSeen: &firstSeen, ResetCount: 1, LastResetAfter: &resetAfter,
+			},
+			{
+				Name: "docker0", BytesSent: 0, BytesRecv: 0,
+				FirstSeen: &firstSeen,
+			},
+		},
+		Connections: types.NetworkConnections{
+			Total:      5,
+			ByStatus:   map[string]int{"ESTABLISHED": 3, "LISTEN": 2},
+			ByProtocol: map[string]int{"tcp4": 4, "tcp6": 1},
+			Details: []types.ConnectionDetail{
+				{Protocol: "tcp4", LocalIP: "0.0.0.0", LocalPort: 22, Status: "LISTEN", PID: 812},
+				{Protocol: "tcp6", LocalIP: "::", LocalPort: 443, Status: "LISTEN", PID: 1320},
+				{Protocol: "tcp4", LocalIP: "10.0.0.5", LocalPort: 22, RemoteIP: "203.0.113.7", RemotePort: 51234, Status: "ESTABLISHED", PID: 4410},
+				{Protocol: "tcp4", LocalIP: "10.0.0.5", LocalPort: 443, RemoteIP: "198.51.100.23", RemotePort: 60211, Status: "ESTABLISHED", PID: 1320},
+				{Protocol: "tcp4", LocalIP: "10.0.0.5", LocalPort: 5432, RemoteIP: "10.0.0.9", RemotePort: 40022, Status: "ESTABLISHED", PID: 2001},
+			},
+			Shown: 5,
+		},
+		LastUpdated: At,
+	}
+}
+
+// ProcessList 按内存排序的第一页进程，包含名称过长、启动时间未知和运行不到 1 分钟的进程
+func ProcessList() types.ProcessList {
+	processes := []types.ProcessInfo{
+		process(2001, "postgres", "S", 12.5, 2147483648, At.Add(-3*24*time.Hour-4*time.Hour)),
+		process(1320, "nginx: worker process with a very long title", "S", 3.25, 536870912, At.Add(-26*time.Hour)),
+		process(4410, "sshd", "S", 0, 8388608, At.Add(-90*time.Minute)),
+		process(9120, "backup.sh", "R", 98.7, 4194304, At.Add(-42*time.Second)),
+		process(7, "kworker/u16:2", "I", 0.1, 0, time.Time{}),
+	}
+	return types.ProcessList{
+		Processes:             processes,
+		Total:                 213,
+		ExcludedKernelThreads: 58,
+		IntervalCPU:           4,
+		Matching:              155,
+		NextOffset:            5,
+		LastUpdated:           At,
+	}
+}
+
+// ProcessGroups 按名称聚合的进程组
+func ProcessGroups() types.ProcessList {
+	return types.ProcessList{
+		GroupBy: "name",
+		Groups: []types.ProcessGroup{
+			{Key: "postgres", Count: 12, CPUPercent: 25.5, MemoryBytes: 4294967296, MemoryMB: 4096, TopPID: 2001, PIDs: []int32{2001, 2002}},
+			{Key: "nginx", Count: 5, CPUPercent: 6.75, MemoryBytes: 1073741824, MemoryMB: 1024, TopPID: 1320, PIDs: []int32{1319, 1320}},
+			{Key: "a-very-long-process-group-name-that-overflows", Count: 1, CPUPercent: 0, MemoryBytes: 1048576, MemoryMB: 1, TopPID: 3000, PIDs: []int32{3000}},
+		},
+		Total:       213,
+		Matching:    87,
+		NextOffset:  3,
+		LastUpdated: At,
+	}
+}
+
+// process 一个进程，started 为零值表示启动时间未知
+func process(pid int32, name, status string, cpuPercent float64, memoryBytes uint64, started time.Time) types.ProcessInfo {
+	proc := types.ProcessInfo{
+		PID:         pid,
+		Name:        name,
+		Status:      status,
+		CPUPercent:  cpuPercent,
+		MemoryBytes: memoryBytes,
+		MemoryMB:    float64(memoryBytes) / (1024 * 1024),
+		LastUpdated: At,
+	}
+	if !started.IsZero() {
+		proc.CreateTime = started.UnixMilli()
+		proc.StartTime = &started
+	}
+	return proc
+}
+
+// ManyProcesses n 个进程的一页结果，名称、使用率和启动时间按序号变化，用于基准测试和大输出的 golden 测试
+func ManyProcesses(n int) types.ProcessList {
+	statuses := []string{"S", "R", "D", "Z"}
+	processes := make([]types.ProcessInfo, n)
+	for i := range processes {
+		name := fmt.Sprintf("worker-%03d", i)
+		if i%10 == 0 {
+			name = fmt.Sprintf("java -Xmx4g -jar /opt/service-%03d/app.jar", i)
+		}
+		started := At.Add(-time.Duration(i*i+1) * 37 * time.Second)
+		processes[i] = process(int32(1000+i*7), name, statuses[i%len(statuses)], float64(n-i)*0.75, uint64(n-i)*3<<20, started)
+	}
+	return types.ProcessList{
+		Processes:   processes,
+		Total:       n * 3,
+		IntervalCPU: n,
+		Matching:    n * 2,
+		NextOffset:  n,
+		LastUpdated: At,
+	}
+}
+
+// ManyConnections 两个网络接口和 n 个连接，状态和协议按序号轮换
+func ManyConnections(n int) types.NetworkInfo {
+	info := NetworkInfo()
+	info.Interfaces = info.Interfaces[:2]
+
+	statuses := []string{"ESTABLISHED", "TIME_WAIT", "LISTEN", "CLOSE_WAIT", "SYN_SENT"}
+	protocols := []string{"tcp4", "tcp6", "udp4"}
+	connections := types.NetworkConnections{
+		Total:      n,
+		ByStatus:   map[string]int{},
+		ByProtocol: map[string]int{},
+		Details:    make([]types.ConnectionDetail, n),
+		Shown:      n,
+	}
+	for i := range connections.Details {
+		detail := types.ConnectionDetail{
+			Protocol:   protocols[i%len(protocols)],
+			LocalIP:    "10.0.0.5",
+			LocalPort:  uint32(1024 + i),
+			RemoteIP:   fmt.Sprintf("192.168.%d.%d", i/250, i%250+1),
+			RemotePort: uint32(40000 + i*3),
+			Status:     statuses[i%len(statuses)],
+			PID:        int32(2000 + i%17),
+		}
+		connections.Details[i] = detail
+		connections.ByStatus[detail.Status]++
+		connections.ByProtocol[detail.Protocol]++
+	}
+	info.Connections = connections
+	return info
+}
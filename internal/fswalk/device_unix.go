@@ -0,0 +1,27 @@
+//go:build !windows
+
+package fswalk
+
+import (
+	"io/fs"
+	"strconv"
+	"syscall"
+)
+
+// deviceID 文件所在文件系统的设备号，用于扫描时避免跨越挂载点
+func deviceID(info fs.FileInfo) (uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Dev), true
+}
+
+// OwnerUID 文件所有者的 UID，无法获取时返回 false
+func OwnerUID(info fs.FileInfo) (string, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", false
+	}
+	return strconv.FormatUint(uint64(stat.Uid), 10), true
+}
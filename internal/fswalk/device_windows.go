@@ -0,0 +1,15 @@
+//go:build windows
+
+package fswalk
+
+import "io/fs"
+
+// deviceID Windows 的 FileInfo 不提供设备号，扫描时不检查跨文件系统
+func deviceID(info fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+// OwnerUID Windows 的 FileInfo 不提供所有者
+func OwnerUID(info fs.FileInfo) (string, bool) {
+	return "", false
+}
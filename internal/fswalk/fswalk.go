@@ -0,0 +1,187 @@
+// Package fswalk 安全地遍历目录树：不跟随符号链接、不跨越文件系统、限制访问项数和时长，
+// 读取失败的路径计数后跳过而不是中断遍历
+package fswalk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxDeniedExamples 最多保留的无权限路径示例数
+const maxDeniedExamples = 5
+
+// ErrNotDirectory Walk 的起始路径不是目录
+var ErrNotDirectory = errors.New("不是目录")
+
+// Options 遍历限制
+type Options struct {
+	// MaxEntries 最多访问的文件和目录数，达到后停止遍历，0 表示不限制
+	MaxEntries int
+	// Timeout 遍历时限，到期后停止遍历并返回已访问的部分，0 表示不限制
+	Timeout time.Duration
+	// Progress 每访问 ProgressInterval 项调用一次，用于报告长时间遍历的进度
+	Progress         func(visited int)
+	ProgressInterval int
+}
+
+// Visitor 遍历回调，为 nil 的回调会被忽略
+type Visitor struct {
+	// File 对每个普通文件（非目录、非符号链接）调用，depth 为相对起始目录的层级（直接子项为 1）
+	File func(path string, depth int, info fs.FileInfo)
+	// Dir 在子目录遍历完毕后调用，size 和 files 为其下所有层级的文件大小和文件数（不含起始目录本身）
+	Dir func(path string, depth int, size uint64, files int)
+}
+
+// Result 遍历统计
+type Result struct {
+	// TotalBytes、TotalFiles 起始目录下所有已访问文件的表观大小和数量
+	TotalBytes uint64
+	TotalFiles int
+	Visited    int
+	// 跳过的项
+	PermissionDenied int
+	DeniedExamples   []string
+	OtherErrors      int
+	OtherFilesystems int
+	Symlinks         int
+	// Truncated 表示因访问项数上限或时限提前停止，TruncatedReason 说明原因
+	Truncated       bool
+	TruncatedReason string
+	Elapsed         time.Duration
+}
+
+// walker 一次遍历的状态
+type walker struct {
+	ctx     context.Context
+	options Options
+	visitor Visitor
+	// rootDevice 起始目录所在文件系统的设备号，hasDevice 为 false 时（Windows）不检查跨文件系统
+	rootDevice uint64
+	hasDevice  bool
+	result     *Result
+	stopped    bool
+}
+
+// Walk 遍历 root 下的目录树。root 不存在、不是目录或无法访问时返回 os 包的错误；
+// 调用方取消 ctx 时返回 ctx 的错误；访问项数上限或 Options.Timeout 到期时返回部分结果并标记 Truncated。
+func Walk(ctx context.Context, root string, options Options, visitor Visitor) (Result, error) {
+	var result Result
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return result, err
+	}
+	if !info.IsDir() {
+		return result, &fs.PathError{Op: "walk", Path: root, Err: ErrNotDirectory}
+	}
+
+	walkCtx := ctx
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		walkCtx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	w := &walker{ctx: walkCtx, options: options, visitor: visitor, result: &result}
+	w.rootDevice, w.hasDevice = deviceID(info)
+
+	start := time.Now()
+	result.TotalBytes, result.TotalFiles = w.walk(root, 0)
+	result.Elapsed = time.Since(start)
+
+	// 调用方取消时返回错误，只有遍历自身的时限到期才返回部分结果
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	if walkCtx.Err() != nil && !result.Truncated {
+		result.Truncated = true
+		result.TruncatedReason = fmt.Sprintf("超过 %s 的时限", options.Timeout)
+	}
+
+	return result, nil
+}
+
+// walk 遍历 dir，返回其下所有文件的大小和数量，depth 为 dir 相对起始目录的层级
+func (w *walker) walk(dir string, depth int) (uint64, int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		w.recordError(dir, err)
+		// ReadDir 出错时仍会返回已读取的部分
+	}
+
+	var size uint64
+	var files int
+	for _, entry := range entries {
+		if w.stopped || w.ctx.Err() != nil {
+			break
+		}
+		w.result.Visited++
+		if w.options.MaxEntries > 0 && w.result.Visited >= w.options.MaxEntries {
+			w.stopped = true
+			w.result.Truncated = true
+			w.result.TruncatedReason = fmt.Sprintf("已达到 %d 项的访问上限", w.options.MaxEntries)
+		}
+		if w.options.Progress != nil && w.options.ProgressInterval > 0 && w.result.Visited%w.options.ProgressInterval == 0 {
+			w.options.Progress(w.result.Visited)
+		}
+
+		path := filepath.Join(dir, entry.Name())
+
+		// 符号链接本身不计入大小，也不跟随
+		if entry.Type()&fs.ModeSymlink != 0 {
+			w.result.Symlinks++
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			w.recordError(path, err)
+			continue
+		}
+
+		if !entry.IsDir() {
+			if !info.Mode().IsRegular() {
+				continue
+			}
+			size += uint64(info.Size())
+			files++
+			if w.visitor.File != nil {
+				w.visitor.File(path, depth+1, info)
+			}
+			continue
+		}
+
+		if w.hasDevice {
+			if device, ok := deviceID(info); ok && device != w.rootDevice {
+				w.result.OtherFilesystems++
+				continue
+			}
+		}
+
+		subSize, subFiles := w.walk(path, depth+1)
+		size += subSize
+		files += subFiles
+		if w.visitor.Dir != nil {
+			w.visitor.Dir(path, depth+1, subSize, subFiles)
+		}
+	}
+
+	return size, files
+}
+
+// recordError 记录读取失败的路径，无权限的路径单独计数并保留少量示例
+func (w *walker) recordError(path string, err error) {
+	if errors.Is(err, fs.ErrPermission) {
+		w.result.PermissionDenied++
+		if len(w.result.DeniedExamples) < maxDeniedExamples {
+			w.result.DeniedExamples = append(w.result.DeniedExamples, path)
+		}
+		return
+	}
+	w.result.OtherErrors++
+}
@@ -0,0 +1,214 @@
+package fswalk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testTree 构造的目录树，共 8 项：
+//
+//	a/x      300 B
+//	a/b/y    500 B
+//	d/w      200 B
+//	f        100 B
+//	link -> a（符号链接）
+func testTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]int{"a/x": 300, "a/b/y": 500, "d/w": 200, "f": 100}
+	for name, size := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(root, "a"), filepath.Join(root, "link")); err != nil {
+		t.Skipf("无法创建符号链接: %v", err)
+	}
+	return root
+}
+
+// relative 相对 root 的斜杠路径
+func relative(root, path string) string {
+	rel, _ := filepath.Rel(root, path)
+	return filepath.ToSlash(rel)
+}
+
+func TestWalk(t *testing.T) {
+	root := testTree(t)
+
+	var files, dirs []string
+	result, err := Walk(context.Background(), root, Options{}, Visitor{
+		File: func(path string, depth int, info fs.FileInfo) {
+			files = append(files, fmt.Sprintf("%s@%d:%d", relative(root, path), depth, info.Size()))
+		},
+		Dir: func(path string, depth int, size uint64, count int) {
+			dirs = append(dirs, fmt.Sprintf("%s@%d:%d/%d", relative(root, path), depth, size, count))
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 符号链接不跟随，也不计入大小
+	if result.TotalBytes != 1100 || result.TotalFiles != 4 || result.Visited != 8 || result.Symlinks != 1 || result.Truncated {
+		t.Errorf("result = %+v", result)
+	}
+	slices.Sort(files)
+	if want := []string{"a/b/y@3:500", "a/x@2:300", "d/w@2:200", "f@1:100"}; !slices.Equal(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+	// 子目录遍历完毕后才回调，大小包含其下所有层级
+	slices.Sort(dirs)
+	if want := []string{"a/b@2:500/1", "a@1:800/2", "d@1:200/1"}; !slices.Equal(dirs, want) {
+		t.Errorf("dirs = %v, want %v", dirs, want)
+	}
+}
+
+func TestWalkMaxEntries(t *testing.T) {
+	root := testTree(t)
+
+	result, err := Walk(context.Background(), root, Options{MaxEntries: 3}, Visitor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Visited != 3 || !result.Truncated || result.TruncatedReason != "已达到 3 项的访问上限" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestWalkTimeout(t *testing.T) {
+	root := testTree(t)
+
+	// 第一项之后等待超过时限，遍历停止并返回部分结果
+	result, err := Walk(context.Background(), root, Options{
+		Timeout:          20 * time.Millisecond,
+		Progress:         func(visited int) { time.Sleep(50 * time.Millisecond) },
+		ProgressInterval: 1,
+	}, Visitor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Visited != 1 || !result.Truncated || result.TruncatedReason != "超过 20ms 的时限" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestWalkCancelled(t *testing.T) {
+	root := testTree(t)
+
+	// 调用方取消时返回错误，而不是标记为未完成
+	ctx, cancel := context.WithCancel(context.Background())
+	result, err := Walk(ctx, root, Options{Progress: func(int) { cancel() }, ProgressInterval: 2}, Visitor{})
+	if !errors.Is(err, context.Canceled) || result.Visited != 2 || result.Truncated {
+		t.Errorf("Walk() = %+v, %v", result, err)
+	}
+}
+
+func TestWalkProgress(t *testing.T) {
+	root := testTree(t)
+
+	var calls []int
+	if _, err := Walk(context.Background(), root, Options{Progress: func(visited int) { calls = append(calls, visited) }, ProgressInterval: 2}, Visitor{}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{2, 4, 6, 8}; !slices.Equal(calls, want) {
+		t.Errorf("progress = %v, want %v", calls, want)
+	}
+}
+
+func TestWalkRootErrors(t *testing.T) {
+	root := testTree(t)
+
+	if _, err := Walk(context.Background(), filepath.Join(root, "missing"), Options{}, Visitor{}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing root: err = %v", err)
+	}
+	if _, err := Walk(context.Background(), filepath.Join(root, "f"), Options{}, Visitor{}); !errors.Is(err, ErrNotDirectory) {
+		t.Errorf("file root: err = %v", err)
+	}
+}
+
+func TestWalkSkipsOtherDevices(t *testing.T) {
+	root := testTree(t)
+	info, err := os.Stat(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	device, ok := deviceID(info)
+	if !ok {
+		t.Skip("当前平台不提供设备号")
+	}
+
+	// 起始目录的设备号与子目录不同时，子目录视为其他文件系统的挂载点，不进入
+	var result Result
+	w := &walker{ctx: context.Background(), rootDevice: device + 1, hasDevice: true, result: &result}
+	size, files := w.walk(root, 0)
+	if size != 100 || files != 1 || result.OtherFilesystems != 2 || result.Symlinks != 1 {
+		t.Errorf("walk() = %d bytes, %d files, result %+v", size, files, result)
+	}
+}
+
+func TestWalkPermissionDenied(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("需要以普通用户在类 Unix 系统上运行")
+	}
+	root := testTree(t)
+	locked := filepath.Join(root, "a", "b")
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(locked, 0o755) })
+
+	// 无权限的目录计数后跳过，遍历继续
+	result, err := Walk(context.Background(), root, Options{}, Visitor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.PermissionDenied != 1 || !slices.Equal(result.DeniedExamples, []string{locked}) || result.TotalBytes != 600 {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestRecordError(t *testing.T) {
+	var result Result
+	w := &walker{result: &result}
+	for i := 0; i < maxDeniedExamples+2; i++ {
+		w.recordError(fmt.Sprintf("/denied/%d", i), &fs.PathError{Op: "open", Path: "x", Err: fs.ErrPermission})
+	}
+	w.recordError("/gone", &fs.PathError{Op: "lstat", Path: "/gone", Err: fs.ErrNotExist})
+
+	// 示例只保留前几个
+	if result.PermissionDenied != maxDeniedExamples+2 || len(result.DeniedExamples) != maxDeniedExamples || result.DeniedExamples[0] != "/denied/0" || result.OtherErrors != 1 {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestOwnerUID(t *testing.T) {
+	info, err := os.Stat(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid, ok := OwnerUID(info)
+	if runtime.GOOS == "windows" {
+		if ok {
+			t.Errorf("OwnerUID() = %q on Windows", uid)
+		}
+		return
+	}
+	if !ok || uid != strconv.Itoa(os.Getuid()) {
+		t.Errorf("OwnerUID() = %q, %v; want %d", uid, ok, os.Getuid())
+	}
+}
@@ -0,0 +1,130 @@
+// Package identity 计算并缓存主机身份，用于在多台机器汇总数据时区分来源
+package identity
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-example/internal/types"
+
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// detectTimeout 探测主机 ID 的超时时间
+const detectTimeout = 3 * time.Second
+
+var (
+	once    sync.Once
+	mutex   sync.RWMutex
+	current types.HostIdentity
+)
+
+// Init 在启动时计算主机身份并记录服务器名称和版本，之后的调用只更新服务器信息
+func Init(serverName, serverVersion string) types.HostIdentity {
+	once.Do(detect)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	current.ServerName = serverName
+	current.ServerVersion = serverVersion
+	return current
+}
+
+// Get 获取缓存的主机身份（未调用 Init 时按需计算，服务器信息为空）
+func Get() *types.HostIdentity {
+	once.Do(detect)
+
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	identity := current
+	return &identity
+}
+
+// detect 探测主机名、主机 ID 和主网卡 MAC
+func detect() {
+	ctx, cancel := context.WithTimeout(context.Background(), detectTimeout)
+	defer cancel()
+
+	hostname, _ := os.Hostname()
+
+	// 容器中通常读不到 /etc/machine-id 等来源，主机 ID 留空，由 MAC 和主机名兜底
+	hostID, err := host.HostIDWithContext(ctx)
+	if err != nil {
+		hostID = ""
+	}
+
+	identity := newIdentity(hostname, hostID, primaryMAC())
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	current = identity
+}
+
+// newIdentity 由探测到的主机名、主机 ID 和主网卡 MAC 组成主机身份
+func newIdentity(hostname, hostID, mac string) types.HostIdentity {
+	return types.HostIdentity{
+		Hostname:    hostname,
+		HostID:      strings.TrimSpace(hostID),
+		PrimaryMAC:  mac,
+		Fingerprint: Fingerprint(hostID, mac, hostname),
+	}
+}
+
+// primaryMAC 按接口名排序后取第一个非回环、有硬件地址的网卡 MAC
+func primaryMAC() string {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return ""
+	}
+
+	sort.Slice(interfaces, func(i, j int) bool {
+		return interfaces[i].Name < interfaces[j].Name
+	})
+
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback != 0 || len(iface.HardwareAddr) == 0 {
+			continue
+		}
+		if isZeroMAC(iface.HardwareAddr) {
+			continue
+		}
+		return iface.HardwareAddr.String()
+	}
+
+	return ""
+}
+
+// isZeroMAC 是否为全零硬件地址
+func isZeroMAC(addr net.HardwareAddr) bool {
+	for _, b := range addr {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Fingerprint 根据主机 ID 和主网卡 MAC 计算稳定的主机指纹。
+// 两者都缺失时（例如没有 machine-id 且只有回环接口的容器）退回到主机名。
+func Fingerprint(hostID, mac, hostname string) string {
+	hostID = strings.ToLower(strings.TrimSpace(hostID))
+	mac = strings.ToLower(strings.TrimSpace(mac))
+
+	source := hostID + "|" + mac
+	if hostID == "" && mac == "" {
+		source = "hostname|" + strings.ToLower(strings.TrimSpace(hostname))
+	}
+
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:8])
+}
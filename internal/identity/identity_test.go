@@ -0,0 +1,98 @@
+package identity
+
+import (
+	"regexp"
+	"testing"
+)
+
+// fingerprintPattern 指纹为 SHA-256 前 8 字节的十六进制
+var fingerprintPattern = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+func TestFingerprint(t *testing.T) {
+	const hostID = "8f4b2c1e-1d2a-4b3c-9e8f-0a1b2c3d4e5f"
+	const mac = "52:54:00:12:34:56"
+
+	fingerprint := Fingerprint(hostID, mac, "web-1")
+	if !fingerprintPattern.MatchString(fingerprint) {
+		t.Fatalf("Fingerprint() = %q, want 16 hex digits", fingerprint)
+	}
+	// 多次计算结果相同；主机名变化、大小写和首尾空白不影响指纹
+	for _, again := range []string{
+		Fingerprint(hostID, mac, "web-1"),
+		Fingerprint(hostID, mac, "renamed"),
+		Fingerprint(" 8F4B2C1E-1D2A-4B3C-9E8F-0A1B2C3D4E5F\n", "52:54:00:12:34:56", ""),
+	} {
+		if again != fingerprint {
+			t.Errorf("Fingerprint() = %q, want the stable %q", again, fingerprint)
+		}
+	}
+
+	// 主机 ID 或 MAC 不同的主机指纹不同
+	for _, other := range []string{
+		Fingerprint("00000000-0000-0000-0000-000000000001", mac, "web-1"),
+		Fingerprint(hostID, "52:54:00:12:34:57", "web-1"),
+		Fingerprint(hostID, "", "web-1"),
+	} {
+		if other == fingerprint {
+			t.Errorf("different hosts share the fingerprint %q", fingerprint)
+		}
+	}
+}
+
+func TestFingerprintWithoutHostID(t *testing.T) {
+	// 容器中没有 machine-id 时由 MAC 区分，主机名不参与
+	withMAC := Fingerprint("", "02:42:ac:11:00:02", "3f2a9c")
+	if withMAC != Fingerprint("", "02:42:ac:11:00:02", "7b1d4e") || withMAC == Fingerprint("", "02:42:ac:11:00:03", "3f2a9c") {
+		t.Errorf("fingerprint without a host ID does not follow the MAC")
+	}
+
+	// 两者都缺失时退回到主机名；不同主机名仍能区分，且不与空主机名冲突
+	byHostname := Fingerprint("", "", "Worker-1 ")
+	if !fingerprintPattern.MatchString(byHostname) || byHostname != Fingerprint(" ", "", "worker-1") {
+		t.Errorf("hostname fallback = %q, want a stable fingerprint", byHostname)
+	}
+	if byHostname == Fingerprint("", "", "worker-2") || byHostname == Fingerprint("", "", "") {
+		t.Errorf("hostname fallback does not distinguish hosts")
+	}
+}
+
+func TestNewIdentity(t *testing.T) {
+	identity := newIdentity("web-1", " 8f4b2c1e\n", "52:54:00:12:34:56")
+	if identity.HostID != "8f4b2c1e" || identity.Fingerprint != Fingerprint("8f4b2c1e", "52:54:00:12:34:56", "web-1") {
+		t.Errorf("identity = %+v", identity)
+	}
+
+	// 读不到主机 ID 的容器：主机 ID 留空，指纹仍然有效
+	container := newIdentity("3f2a9c", "", "")
+	if container.HostID != "" || container.Fingerprint != Fingerprint("", "", "3f2a9c") || !fingerprintPattern.MatchString(container.Fingerprint) {
+		t.Errorf("container identity = %+v", container)
+	}
+}
+
+func TestGetIsCachedAndCopied(t *testing.T) {
+	first := Get()
+	if first.Fingerprint == "" || !fingerprintPattern.MatchString(first.Fingerprint) {
+		t.Fatalf("Get() = %+v, want a fingerprint", first)
+	}
+
+	// 返回的是副本，调用方修改不影响缓存
+	first.Fingerprint = "modified"
+	first.Hostname = "modified"
+	second := Get()
+	if second.Fingerprint == "modified" || second.Hostname == "modified" {
+		t.Errorf("Get() returned the cached identity itself: %+v", second)
+	}
+
+	// Init 只更新服务器信息，不重新探测
+	initialized := Init("system-monitor", "1.2.3")
+	again := Get()
+	if initialized.Fingerprint != second.Fingerprint || again.Fingerprint != second.Fingerprint {
+		t.Errorf("fingerprint changed after Init: %q, %q, want %q", initialized.Fingerprint, again.Fingerprint, second.Fingerprint)
+	}
+	if again.ServerName != "system-monitor" || again.ServerVersion != "1.2.3" {
+		t.Errorf("server info = %q %q", again.ServerName, again.ServerVersion)
+	}
+	if Init("system-monitor", "1.2.4"); Get().ServerVersion != "1.2.4" {
+		t.Errorf("second Init did not update the server version")
+	}
+}
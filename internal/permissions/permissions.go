@@ -0,0 +1,163 @@
+// Package permissions 探测服务器进程的有效权限，供工具说明哪些数据因权限不足而不完整
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-example/internal/types"
+
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// probeTimeout 探测的超时时间
+const probeTimeout = 5 * time.Second
+
+// Checks 各项探测，替换其中的函数即可在没有真实权限环境时模拟探测结果
+type Checks struct {
+	// EUID 有效用户 ID，不支持的平台返回 -1
+	EUID func() int
+	// OtherProcessIO 能否读取其他用户进程的 IO 计数
+	OtherProcessIO func(ctx context.Context, euid int) bool
+	// ConnectionPIDs 网络连接能否对应到所属进程
+	ConnectionPIDs func(ctx context.Context) bool
+	// Sensors 温度传感器是否有响应
+	Sensors func(ctx context.Context) bool
+}
+
+// DefaultChecks 基于当前进程和 gopsutil 的探测
+func DefaultChecks() Checks {
+	return Checks{
+		EUID:           os.Geteuid,
+		OtherProcessIO: checkOtherProcessIO,
+		ConnectionPIDs: checkConnectionPIDs,
+		Sensors:        checkSensors,
+	}
+}
+
+var (
+	once    sync.Once
+	mutex   sync.RWMutex
+	current types.PermissionProfile
+)
+
+// Get 获取缓存的权限探测结果，首次调用时执行探测（启动预取会提前触发）
+func Get() *types.PermissionProfile {
+	once.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+		defer cancel()
+
+		profile := Probe(ctx, DefaultChecks())
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		current = profile
+	})
+
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	profile := current
+	return &profile
+}
+
+// Probe 执行探测并汇总限制说明
+func Probe(ctx context.Context, checks Checks) types.PermissionProfile {
+	euid := checks.EUID()
+	profile := types.PermissionProfile{
+		EUID:           euid,
+		Privileged:     euid == 0,
+		OtherProcessIO: checks.OtherProcessIO(ctx, euid),
+		ConnectionPIDs: checks.ConnectionPIDs(ctx),
+		Sensors:        checks.Sensors(ctx),
+		CheckedAt:      time.Now(),
+	}
+
+	if !profile.OtherProcessIO {
+		profile.Limitations = append(profile.Limitations, "无法读取其他用户进程的 IO 计数和命令行")
+	}
+	switch {
+	case profile.ConnectionPIDs:
+	case profile.Privileged:
+		// root 也看不到所属进程时，连接通常属于其他 PID 命名空间（如宿主机或其他容器）
+		profile.Limitations = append(profile.Limitations, "部分连接没有可见的所属进程（可能属于其他 PID 命名空间）")
+	default:
+		profile.Limitations = append(profile.Limitations, "无法确定其他用户的网络连接所属进程")
+	}
+	if !profile.Sensors {
+		profile.Limitations = append(profile.Limitations, "温度传感器无响应（没有传感器或缺少访问权限）")
+	}
+	return profile
+}
+
+// Summary 一行权限说明，如 "以普通用户运行（euid 1000）：无法读取其他用户进程的 IO 计数和命令行"
+func Summary(profile types.PermissionProfile) string {
+	var who string
+	switch {
+	case profile.Privileged:
+		who = "以 root 运行"
+	case profile.EUID < 0:
+		who = "当前平台无法判断运行用户"
+	default:
+		who = fmt.Sprintf("以普通用户运行（euid %d）", profile.EUID)
+	}
+
+	if len(profile.Limitations) == 0 {
+		return who + "，未发现权限限制"
+	}
+	return who + "：" + strings.Join(profile.Limitations, "；")
+}
+
+// checkOtherProcessIO 找一个不属于当前用户的进程读取其 IO 计数（/proc/<pid>/io）。
+// 没有其他用户的进程时视为没有限制；只在 Linux 上探测，其他平台的进程 IO 计数不依赖该权限
+func checkOtherProcessIO(ctx context.Context, euid int) bool {
+	if runtime.GOOS != "linux" {
+		return true
+	}
+
+	processes, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return true
+	}
+
+	for _, p := range processes {
+		if ctx.Err() != nil {
+			return true
+		}
+		uids, err := p.UidsWithContext(ctx)
+		if err != nil || len(uids) == 0 || int(uids[0]) == euid {
+			continue
+		}
+		_, err = p.IOCountersWithContext(ctx)
+		return err == nil
+	}
+	return true
+}
+
+// checkConnectionPIDs 检查监听和已建立的连接是否都能对应到进程（TIME_WAIT 等状态本来就没有所属进程）
+func checkConnectionPIDs(ctx context.Context) bool {
+	connections, err := net.ConnectionsWithContext(ctx, "inet")
+	if err != nil {
+		return true
+	}
+
+	for _, conn := range connections {
+		if (conn.Status == "LISTEN" || conn.Status == "ESTABLISHED") && conn.Pid == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// checkSensors 温度传感器是否返回了数据
+func checkSensors(ctx context.Context) bool {
+	temperatures, err := host.SensorsTemperaturesWithContext(ctx)
+	return err == nil && len(temperatures) > 0
+}
@@ -0,0 +1,72 @@
+package permissions
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"mcp-example/internal/types"
+)
+
+// fakeChecks 返回固定结果的探测，OtherProcessIO 记录收到的 euid
+func fakeChecks(euid int, otherIO, connPIDs, sensors bool, gotEUID *int) Checks {
+	return Checks{
+		EUID: func() int { return euid },
+		OtherProcessIO: func(ctx context.Context, euid int) bool {
+			*gotEUID = euid
+			return otherIO
+		},
+		ConnectionPIDs: func(ctx context.Context) bool { return connPIDs },
+		Sensors:        func(ctx context.Context) bool { return sensors },
+	}
+}
+
+func TestProbe(t *testing.T) {
+	cases := []struct {
+		name                      string
+		euid                      int
+		otherIO, connPIDs, sensor bool
+		limitations               []string
+		summary                   string
+	}{
+		{"root", 0, true, true, true, nil, "以 root 运行，未发现权限限制"},
+		{
+			"regular user", 1000, false, false, false,
+			[]string{"无法读取其他用户进程的 IO 计数和命令行", "无法确定其他用户的网络连接所属进程", "温度传感器无响应（没有传感器或缺少访问权限）"},
+			"以普通用户运行（euid 1000）：无法读取其他用户进程的 IO 计数和命令行；无法确定其他用户的网络连接所属进程；温度传感器无响应（没有传感器或缺少访问权限）",
+		},
+		// root 也看不到所属进程时说明为其他 PID 命名空间，而不是权限不足
+		{
+			"root in a container", 0, true, false, true,
+			[]string{"部分连接没有可见的所属进程（可能属于其他 PID 命名空间）"},
+			"以 root 运行：部分连接没有可见的所属进程（可能属于其他 PID 命名空间）",
+		},
+		{"user without limitations", 1000, true, true, true, nil, "以普通用户运行（euid 1000），未发现权限限制"},
+		{"unknown user", -1, true, true, true, nil, "当前平台无法判断运行用户，未发现权限限制"},
+	}
+	for _, c := range cases {
+		var gotEUID int
+		profile := Probe(context.Background(), fakeChecks(c.euid, c.otherIO, c.connPIDs, c.sensor, &gotEUID))
+
+		if profile.EUID != c.euid || profile.Privileged != (c.euid == 0) || gotEUID != c.euid {
+			t.Errorf("%s: euid %d, privileged %v, checked other processes against euid %d", c.name, profile.EUID, profile.Privileged, gotEUID)
+		}
+		if profile.OtherProcessIO != c.otherIO || profile.ConnectionPIDs != c.connPIDs || profile.Sensors != c.sensor || profile.CheckedAt.IsZero() {
+			t.Errorf("%s: profile = %+v", c.name, profile)
+		}
+		if !slices.Equal(profile.Limitations, c.limitations) {
+			t.Errorf("%s: Limitations = %q, want %q", c.name, profile.Limitations, c.limitations)
+		}
+		if got := Summary(profile); got != c.summary {
+			t.Errorf("%s: Summary() = %q, want %q", c.name, got, c.summary)
+		}
+	}
+}
+
+func TestSummaryWithoutProbe(t *testing.T) {
+	// Summary 只依据结果中的字段，不重新探测
+	profile := types.PermissionProfile{EUID: 501, Limitations: []string{"温度传感器无响应（没有传感器或缺少访问权限）"}}
+	if got, want := Summary(profile), "以普通用户运行（euid 501）：温度传感器无响应（没有传感器或缺少访问权限）"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
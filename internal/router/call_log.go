@@ -0,0 +1,146 @@
+package router
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"mcp-example/internal/tools"
+	"mcp-example/internal/trace"
+	"mcp-example/internal/types"
+)
+
+// maxRecentCalls 保留的最近工具调用记录数量
+const maxRecentCalls = 50
+
+// 工具调用结果
+const (
+	outcomeOK       = "ok"
+	outcomeError    = "error"
+	outcomeRPCError = "rpc_error"
+)
+
+// callLog 最近工具调用的环形缓冲区和各工具的累计统计，并发安全
+type callLog struct {
+	mutex   sync.Mutex
+	records []types.ToolCallRecord
+	next    int
+	stats   map[string]*types.ToolCallStats
+}
+
+// add 加入一条记录并计入该工具的累计统计，超过容量时覆盖最旧的记录
+func (l *callLog) add(record types.ToolCallRecord) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	// 被拒绝的请求（未知工具、被策略禁用的工具）没有执行工具，不计入累计统计，也避免任意工具名撑大 stats
+	if record.Outcome != outcomeRPCError {
+		l.addStats(record)
+	}
+
+	if len(l.records) < maxRecentCalls {
+		l.records = append(l.records, record)
+		return
+	}
+	l.records[l.next] = record
+	l.next = (l.next + 1) % maxRecentCalls
+}
+
+// addStats 计入工具的累计统计，需持有 mutex
+func (l *callLog) addStats(record types.ToolCallRecord) {
+	if l.stats == nil {
+		l.stats = make(map[string]*types.ToolCallStats)
+	}
+	stats, found := l.stats[record.Tool]
+	if !found {
+		stats = &types.ToolCallStats{Tool: record.Tool}
+		l.stats[record.Tool] = stats
+	}
+	if record.ErrorCode == string(tools.ErrRateLimited) {
+		stats.RateLimited++
+		return
+	}
+	stats.Calls++
+	if record.Outcome == outcomeError {
+		stats.Errors++
+	}
+	stats.TotalMs += record.DurationMs
+	stats.MaxMs = max(stats.MaxMs, record.DurationMs)
+}
+
+// recent 返回所有记录，最新的在前
+func (l *callLog) recent() []types.ToolCallRecord {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	records := make([]types.ToolCallRecord, 0, len(l.records))
+	for i := len(l.records) - 1; i >= 0; i-- {
+		records = append(records, l.records[(l.next+i)%len(l.records)])
+	}
+	return records
+}
+
+// toolStats 返回各工具的累计统计，按工具名排序
+func (l *callLog) toolStats() []types.ToolCallStats {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	stats := make([]types.ToolCallStats, 0, len(l.stats))
+	for _, toolStats := range l.stats {
+		stats = append(stats, *toolStats)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Tool < stats[j].Tool
+	})
+	return stats
+}
+
+// requestTraceID 获取请求的追踪 ID：优先使用 params._meta.traceId，无效或缺失时生成新的 ID
+func requestTraceID(req *types.JSONRPCRequest) string {
+	if params, ok := req.Params.(map[string]interface{}); ok {
+		if meta, ok := params["_meta"].(map[string]interface{}); ok {
+			if id, _ := meta["traceId"].(string); trace.ValidID(id) {
+				return id
+			}
+		}
+	}
+	return trace.NewID()
+}
+
+// attachTraceID 在错误响应的 Error.Data 中附加追踪 ID（Data 已被设置时保持不变）
+func attachTraceID(resp *types.JSONRPCResponse, traceID string) {
+	if resp == nil || resp.Error == nil || resp.Error.Data != nil {
+		return
+	}
+	resp.Error.Data = map[string]string{"trace_id": traceID}
+}
+
+// newCallRecord 根据 tools/call 的响应生成调用记录
+func newCallRecord(traceID, tool string, start time.Time, resp *types.JSONRPCResponse) types.ToolCallRecord {
+	record := types.ToolCallRecord{
+		TraceID:    traceID,
+		Tool:       tool,
+		StartedAt:  start,
+		DurationMs: time.Since(start).Milliseconds(),
+		Outcome:    outcomeOK,
+	}
+
+	switch {
+	case resp == nil:
+	case resp.Error != nil:
+		record.Outcome = outcomeRPCError
+		record.ErrorCode = strconv.Itoa(resp.Error.Code)
+	default:
+		if result, ok := resp.Result.(types.CallToolResult); ok && result.IsError {
+			record.Outcome = outcomeError
+			if structured, ok := result.StructuredContent.(map[string]interface{}); ok {
+				if toolErr, ok := structured["error"].(types.ToolError); ok {
+					record.ErrorCode = toolErr.Code
+				}
+			}
+		}
+	}
+
+	return record
+}
@@ -0,0 +1,224 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/tools"
+	"mcp-example/internal/trace"
+	"mcp-example/internal/types"
+)
+
+// loggingTool 执行时用调用的 context 记录一条日志，err 不为 nil 时返回错误
+type loggingTool struct {
+	echoTool
+	err error
+}
+
+func (lt *loggingTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	slog.InfoContext(ctx, "执行工具", "tool", lt.name)
+	if lt.err != nil {
+		return "", lt.err
+	}
+	return "done", nil
+}
+
+// captureTraceLogs 测试期间把默认日志（附加追踪 ID）写入返回的缓冲区
+func captureTraceLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(trace.NewHandler(slog.NewTextHandler(&buf, nil))))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+// tracedCallRequest 在 params._meta.traceId 中携带追踪 ID 的 tools/call 请求
+func tracedCallRequest(id int, tool, traceID string) *types.JSONRPCRequest {
+	req := callRequest(id, tool, nil)
+	req.Params.(map[string]interface{})["_meta"] = map[string]interface{}{"traceId": traceID}
+	return req
+}
+
+func TestTraceIDInLogsAndResponse(t *testing.T) {
+	logs := captureTraceLogs(t)
+	handler, _ := newTestHandler()
+	handler.RegisterTool(&loggingTool{echoTool: echoTool{name: "logging"}})
+	handler.RegisterTool(&loggingTool{echoTool: echoTool{name: "broken"}, err: errors.New("磁盘不可读")})
+
+	// 客户端提供的追踪 ID 出现在日志、结果的 _meta 和调用记录中
+	resp := handler.HandleRequest(context.Background(), nil, tracedCallRequest(1, "logging", "client-trace-1"))
+	if !strings.Contains(logs.String(), "msg=执行工具 tool=logging trace_id=client-trace-1") {
+		t.Errorf("log lacks the client trace ID:\n%s", logs)
+	}
+	if meta := resp.Result.(types.CallToolResult).Meta; meta["trace_id"] != "client-trace-1" {
+		t.Errorf("_meta = %v", meta)
+	}
+
+	// 没有提供时生成新的 ID，日志和错误结果中的 ID 相同
+	logs.Reset()
+	resp = handler.HandleRequest(context.Background(), nil, callRequest(2, "broken", nil))
+	text, toolErr := toolError(t, resp)
+	generated := toolErr.TraceID
+	if !trace.ValidID(generated) || generated == "client-trace-1" {
+		t.Fatalf("generated trace ID = %q", generated)
+	}
+	if !strings.Contains(logs.String(), "trace_id="+generated) || !strings.HasSuffix(text, "\n🔎 追踪 ID: "+generated) {
+		t.Errorf("trace ID %s missing from the log or the error text:\n%s\n%s", generated, logs, text)
+	}
+
+	// 请求被拒绝时追踪 ID 在 Error.Data 中；无效的客户端 ID 被替换
+	resp = handler.HandleRequest(context.Background(), nil, tracedCallRequest(3, "missing", "bad id"))
+	data, _ := resp.Error.Data.(map[string]string)
+	if resp.Error == nil || !trace.ValidID(data["trace_id"]) || data["trace_id"] == "bad id" {
+		t.Errorf("rejected call = %s", responseJSON(t, resp))
+	}
+
+	// 最近调用记录中的追踪 ID 与响应一致，最新的在前
+	calls := handler.RecentCalls()
+	if len(calls) != 3 {
+		t.Fatalf("RecentCalls() = %+v", calls)
+	}
+	want := []struct{ traceID, tool, outcome string }{
+		{data["trace_id"], "missing", outcomeRPCError},
+		{generated, "broken", outcomeError},
+		{"client-trace-1", "logging", outcomeOK},
+	}
+	for i, w := range want {
+		if calls[i].TraceID != w.traceID || calls[i].Tool != w.tool || calls[i].Outcome != w.outcome {
+			t.Errorf("call %d = %+v, want %+v", i, calls[i], w)
+		}
+	}
+}
+
+func TestRequestTraceID(t *testing.T) {
+	withMeta := func(meta interface{}) *types.JSONRPCRequest {
+		return rpc(1, types.MethodCallTool, map[string]interface{}{"name": "echo", "_meta": meta})
+	}
+	if id := requestTraceID(withMeta(map[string]interface{}{"traceId": "abc"})); id != "abc" {
+		t.Errorf("client trace ID = %q", id)
+	}
+	for _, req := range []*types.JSONRPCRequest{
+		rpc(1, types.MethodListTools, nil),
+		rpc(1, types.MethodCallTool, []interface{}{"echo"}),
+		withMeta("abc"),
+		withMeta(map[string]interface{}{"traceId": 42}),
+		withMeta(map[string]interface{}{"traceId": strings.Repeat("x", trace.MaxIDLength+1)}),
+	} {
+		if id := requestTraceID(req); len(id) != 12 || !trace.ValidID(id) {
+			t.Errorf("requestTraceID(%v) = %q, want a generated ID", req.Params, id)
+		}
+	}
+}
+
+func TestAttachTraceID(t *testing.T) {
+	resp := &types.JSONRPCResponse{Error: &types.RPCError{Code: -32601}}
+	attachTraceID(resp, "abc")
+	if data, _ := resp.Error.Data.(map[string]string); data["trace_id"] != "abc" {
+		t.Errorf("Error.Data = %v", resp.Error.Data)
+	}
+
+	// 已有的 Data 保持不变；成功响应和 nil 不处理
+	resp = &types.JSONRPCResponse{Error: &types.RPCError{Code: -32602, Data: "details"}}
+	attachTraceID(resp, "abc")
+	if resp.Error.Data != "details" {
+		t.Errorf("Error.Data = %v, want it unchanged", resp.Error.Data)
+	}
+	resp = &types.JSONRPCResponse{Result: "ok"}
+	attachTraceID(resp, "abc")
+	if resp.Error != nil {
+		t.Errorf("success response = %+v", resp)
+	}
+	attachTraceID(nil, "abc")
+}
+
+func TestCallLog(t *testing.T) {
+	var log callLog
+	start := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	for i := 0; i < maxRecentCalls+5; i++ {
+		record := types.ToolCallRecord{TraceID: fmt.Sprint(i), Tool: "cpu_info", StartedAt: start, DurationMs: int64(i), Outcome: outcomeOK}
+		if i%10 == 0 {
+			record.Outcome = outcomeError
+		}
+		log.add(record)
+	}
+	log.add(types.ToolCallRecord{TraceID: "limited", Tool: "cpu_info", Outcome: outcomeError, ErrorCode: string(tools.ErrRateLimited)})
+	log.add(types.ToolCallRecord{TraceID: "rejected", Tool: "no_such_tool", Outcome: outcomeRPCError, ErrorCode: "-32602"})
+
+	// 环形缓冲区只保留最近的记录，最新的在前
+	recent := log.recent()
+	if len(recent) != maxRecentCalls || recent[0].TraceID != "rejected" || recent[1].TraceID != "limited" || recent[2].TraceID != fmt.Sprint(maxRecentCalls+4) {
+		t.Fatalf("recent = %d records starting %+v", len(recent), recent[:3])
+	}
+	if oldest := recent[len(recent)-1]; oldest.TraceID != "7" {
+		t.Errorf("oldest = %+v, want trace 7", oldest)
+	}
+
+	// 限流单独计数；被拒绝的请求不计入累计统计
+	stats := log.toolStats()
+	want := types.ToolCallStats{Tool: "cpu_info", Calls: maxRecentCalls + 5, Errors: 6, RateLimited: 1, TotalMs: 1485, MaxMs: maxRecentCalls + 4}
+	if len(stats) != 1 || stats[0] != want {
+		t.Errorf("toolStats() = %+v, want %+v", stats, want)
+	}
+}
+
+func TestNewCallRecord(t *testing.T) {
+	start := time.Now().Add(-25 * time.Millisecond)
+
+	record := newCallRecord("abc", "echo", start, &types.JSONRPCResponse{Result: types.CallToolResult{}})
+	if record.TraceID != "abc" || record.Tool != "echo" || record.Outcome != outcomeOK || record.DurationMs < 25 || !record.StartedAt.Equal(start) {
+		t.Errorf("ok record = %+v", record)
+	}
+
+	failed := types.CallToolResult{IsError: true, StructuredContent: map[string]interface{}{"error": types.ToolError{Code: string(tools.ErrTimeout)}}}
+	if record := newCallRecord("abc", "echo", start, &types.JSONRPCResponse{Result: failed}); record.Outcome != outcomeError || record.ErrorCode != string(tools.ErrTimeout) {
+		t.Errorf("error record = %+v", record)
+	}
+	if record := newCallRecord("abc", "echo", start, &types.JSONRPCResponse{Error: &types.RPCError{Code: -32601}}); record.Outcome != outcomeRPCError || record.ErrorCode != "-32601" {
+		t.Errorf("rpc error record = %+v", record)
+	}
+}
+
+// sleepingTool 执行时先等待 delay，用于检查处理耗时的统计
+type sleepingTool struct {
+	echoTool
+	delay time.Duration
+}
+
+func (st *sleepingTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	time.Sleep(st.delay)
+	return "done", nil
+}
+
+func TestToolStatsRecordHandlingTime(t *testing.T) {
+	handler, _ := newTestHandler()
+	handler.RegisterTool(&sleepingTool{echoTool: echoTool{name: "slow"}, delay: 30 * time.Millisecond})
+
+	for i := 1; i <= 2; i++ {
+		if resp := handler.HandleRequest(context.Background(), nil, callRequest(i, "slow", nil)); resp.Error != nil {
+			t.Fatalf("call %d = %s", i, responseJSON(t, resp))
+		}
+	}
+
+	// 调用记录和累计统计都包含整个处理过程的耗时
+	for _, call := range handler.RecentCalls() {
+		if call.DurationMs < 30 {
+			t.Errorf("call %s took %dms, want at least the 30ms spent in the tool", call.TraceID, call.DurationMs)
+		}
+	}
+	var stats types.ToolCallStats
+	for _, s := range handler.ToolStats() {
+		if s.Tool == "slow" {
+			stats = s
+		}
+	}
+	if stats.Calls != 2 || stats.TotalMs < 60 || stats.MaxMs < 30 || stats.MaxMs > stats.TotalMs {
+		t.Errorf("ToolStats() for slow = %+v", stats)
+	}
+}
@@ -0,0 +1,95 @@
+package router
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// argsRecordingTool 记录收到的参数并写入其中的工具，参数为 nil map 时写入会 panic
+type argsRecordingTool struct {
+	echoTool
+	received map[string]interface{}
+}
+
+func (at *argsRecordingTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	at.received = args
+	args["seen"] = true
+	return at.echoTool.Execute(ctx, args)
+}
+
+func TestCallToolMalformedParams(t *testing.T) {
+	handler, _ := newTestHandler()
+	cases := []struct {
+		name   string
+		params interface{}
+		want   string
+	}{
+		{"missing params", nil, "requires params with a tool name"},
+		{"params not an object", []interface{}{"echo"}, "params must be an object, got array"},
+		{"missing name", map[string]interface{}{"arguments": map[string]interface{}{}}, "missing tool name"},
+		{"empty name", map[string]interface{}{"name": ""}, "missing tool name"},
+		{"string arguments", map[string]interface{}{"name": "echo", "arguments": "text=hi"}, "arguments must be an object, got string"},
+		{"array arguments", map[string]interface{}{"name": "echo", "arguments": []interface{}{"hi"}}, "arguments must be an object, got array"},
+		{"number arguments", map[string]interface{}{"name": "echo", "arguments": 1}, "arguments must be an object, got number"},
+		{"boolean arguments", map[string]interface{}{"name": "echo", "arguments": true}, "arguments must be an object, got boolean"},
+		{"unknown tool", map[string]interface{}{"name": "missing"}, "Unknown tool: missing"},
+	}
+	for _, c := range cases {
+		resp := handler.HandleRequest(context.Background(), nil, rpc(1, types.MethodCallTool, c.params))
+		if resp.Error == nil || resp.Error.Code != -32602 || !strings.Contains(resp.Error.Message, c.want) {
+			t.Errorf("%s: response = %s, want -32602 %q", c.name, responseJSON(t, resp), c.want)
+		}
+	}
+}
+
+func TestCallToolMissingArgumentsBecomeEmptyMap(t *testing.T) {
+	handler := NewMCPHandler("test-server", "0.0.0")
+	tool := &argsRecordingTool{echoTool: echoTool{name: "echo"}}
+	handler.RegisterTool(tool)
+
+	for name, params := range map[string]map[string]interface{}{
+		"missing arguments": {"name": "echo"},
+		"null arguments":    {"name": "echo", "arguments": nil},
+		"empty arguments":   {"name": "echo", "arguments": map[string]interface{}{}},
+	} {
+		tool.received = nil
+		resp := handler.HandleRequest(context.Background(), nil, rpc(1, types.MethodCallTool, params))
+		if text := resultText(t, resp); text != "echo: " || tool.received == nil {
+			t.Errorf("%s: result %q, tool received %v; want an empty non-nil map", name, text, tool.received)
+		}
+	}
+}
+
+func TestDefaultToolsTolerateEmptyArguments(t *testing.T) {
+	if testing.Short() {
+		t.Skip("executes every built-in tool against the host")
+	}
+	r := newDefaultRouter(t)
+	registered := r.handler.registeredTools()
+
+	// 各工具并发执行以控制总时长；空参数时应成功或返回带错误代码的工具错误（如缺少条件必需的参数），不能 panic 或返回协议错误
+	var wg sync.WaitGroup
+	for _, name := range sortedToolNames(registered) {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			resp := r.handler.HandleRequest(ctx, nil, rpc(1, types.MethodCallTool, map[string]interface{}{"name": name}))
+			result, ok := resp.Result.(types.CallToolResult)
+			if resp.Error != nil || !ok || len(result.Content) == 0 {
+				t.Errorf("%s with no arguments = %s", name, responseJSON(t, resp))
+				return
+			}
+			if result.IsError && !strings.Contains(result.Content[0].Text, "错误代码: ERR_") {
+				t.Errorf("%s with no arguments returned an unstructured error: %s", name, result.Content[0].Text)
+			}
+		}(name)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,223 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// fullInitializeRequest 声明了全部客户端能力的 initialize 请求
+func fullInitializeRequest(id interface{}) *types.JSONRPCRequest {
+	return rpc(id, types.MethodInitialize, map[string]interface{}{
+		"protocolVersion": "2025-06-18",
+		"capabilities": map[string]interface{}{
+			"roots":        map[string]interface{}{"listChanged": true},
+			"sampling":     map[string]interface{}{},
+			"elicitation":  map[string]interface{}{},
+			"experimental": map[string]interface{}{"tracing": map[string]interface{}{}},
+		},
+		"clientInfo": map[string]interface{}{"name": "full-client", "version": "2.1.0"},
+	})
+}
+
+// minimalInitializeRequest 只有协议版本的 initialize 请求
+func minimalInitializeRequest(id interface{}) *types.JSONRPCRequest {
+	return rpc(id, types.MethodInitialize, map[string]interface{}{"protocolVersion": "2024-11-05"})
+}
+
+func TestInitializeStoresClientCapabilities(t *testing.T) {
+	handler, _ := newTestHandler()
+
+	full := NewSession()
+	if resp := handler.HandleRequest(context.Background(), full, fullInitializeRequest(1)); resp.Error != nil {
+		t.Fatalf("initialize = %s", responseJSON(t, resp))
+	}
+	capabilities := full.Capabilities()
+	if capabilities.Roots == nil || !capabilities.Roots.ListChanged || capabilities.Sampling == nil || capabilities.Elicitation == nil || capabilities.Experimental["tracing"] == nil {
+		t.Errorf("capabilities = %+v", capabilities)
+	}
+	if info := full.ClientInfo(); info != (types.ClientInfo{Name: "full-client", Version: "2.1.0"}) || full.ProtocolVersion() != "2025-06-18" {
+		t.Errorf("client = %+v, protocol %s", info, full.ProtocolVersion())
+	}
+
+	minimal := NewSession()
+	if resp := handler.HandleRequest(context.Background(), minimal, minimalInitializeRequest(1)); resp.Error != nil {
+		t.Fatalf("initialize = %s", responseJSON(t, resp))
+	}
+	if capabilities := minimal.Capabilities(); capabilities.Roots != nil || capabilities.Sampling != nil || capabilities.Elicitation != nil {
+		t.Errorf("minimal capabilities = %+v", capabilities)
+	}
+	if minimal.ClientInfo() != (types.ClientInfo{}) || minimal.ProtocolVersion() != "2024-11-05" {
+		t.Errorf("minimal client = %+v, protocol %s", minimal.ClientInfo(), minimal.ProtocolVersion())
+	}
+
+	// 握手完成前只有客户端声明的能力和由协议版本决定的功能
+	if got := full.Stats().ClientFeatures; !slices.Equal(got, []string{"roots", "sampling", "elicitation", FeatureStructuredContent}) {
+		t.Errorf("full features before initialized = %v", got)
+	}
+	if got := minimal.Stats().ClientFeatures; got != nil {
+		t.Errorf("minimal features before initialized = %v", got)
+	}
+
+	for _, session := range []*Session{full, minimal} {
+		handler.HandleRequest(context.Background(), session, rpc(nil, types.MethodNotificationInitialized, nil))
+	}
+	if got := full.Stats().ClientFeatures; !slices.Equal(got, []string{"roots", "sampling", "elicitation", FeatureStructuredContent, FeatureProgress, FeatureToolsListChanged}) {
+		t.Errorf("full features = %v", got)
+	}
+	if got := minimal.Stats().ClientFeatures; !slices.Equal(got, []string{FeatureProgress, FeatureToolsListChanged}) {
+		t.Errorf("minimal features = %v", got)
+	}
+
+	// 日志消息要等客户端设置日志级别之后才发送
+	if full.Supports(FeatureLogging) || full.WantsLog("emergency") {
+		t.Error("logging enabled before logging/setLevel")
+	}
+	handler.HandleRequest(context.Background(), full, rpc(2, types.MethodSetLogLevel, map[string]interface{}{"level": "warning"}))
+	if !full.Supports(FeatureLogging) || !full.WantsLog("error") || full.WantsLog("info") {
+		t.Errorf("logging after setLevel warning: supports %v, error %v, info %v", full.Supports(FeatureLogging), full.WantsLog("error"), full.WantsLog("info"))
+	}
+	if full.Supports("unknown_feature") {
+		t.Error("unknown feature supported")
+	}
+}
+
+func TestInitializeRejectsMalformedParams(t *testing.T) {
+	handler, _ := newTestHandler()
+	session := NewSession()
+
+	for _, params := range []interface{}{
+		map[string]interface{}{"protocolVersion": "2025-06-18", "capabilities": "all"},
+		map[string]interface{}{"protocolVersion": 20250618},
+		map[string]interface{}{"clientInfo": []interface{}{"name"}},
+	} {
+		resp := handler.HandleRequest(context.Background(), session, rpc(1, types.MethodInitialize, params))
+		if resp.Error == nil || resp.Error.Code != -32602 || !strings.HasPrefix(resp.Error.Message, "Invalid params: ") {
+			t.Errorf("initialize %v = %s, want -32602", params, responseJSON(t, resp))
+		}
+	}
+
+	// 参数无效的请求不改变会话状态，之后仍可以正常握手
+	if resp := handler.HandleRequest(context.Background(), session, fullInitializeRequest(2)); resp.Error != nil {
+		t.Fatalf("initialize after invalid params = %s", responseJSON(t, resp))
+	}
+}
+
+func TestStructuredContentFollowsProtocolVersion(t *testing.T) {
+	handler, _ := newTestHandler()
+	handler.RegisterTool(&failingTool{echoTool: echoTool{name: "broken"}, err: errors.New("读取失败")})
+
+	for _, c := range []struct {
+		version    string
+		structured bool
+	}{
+		{"2024-11-05", false},
+		{"2025-03-26", false},
+		{"2025-06-18", true},
+	} {
+		session := NewSession()
+		handler.HandleRequest(context.Background(), session, initializeRequest(1, c.version))
+		handler.HandleRequest(context.Background(), session, rpc(nil, types.MethodNotificationInitialized, nil))
+
+		resp := handler.HandleRequest(context.Background(), session, callRequest(2, "broken", nil))
+		result := resp.Result.(types.CallToolResult)
+		// 旧版本客户端只收到文本内容，错误信息仍然完整
+		if (result.StructuredContent != nil) != c.structured || !result.IsError || !strings.HasPrefix(result.Content[0].Text, "❌ 读取失败") {
+			t.Errorf("%s: result = %s", c.version, responseJSON(t, resp))
+		}
+	}
+}
+
+// progressCall 带 progressToken 的 top_network_interfaces 调用
+func progressCall(id interface{}) *types.JSONRPCRequest {
+	return rpc(id, types.MethodCallTool, map[string]interface{}{
+		"name":      "top_network_interfaces",
+		"arguments": map[string]interface{}{"interval": "10ms"},
+		"_meta":     map[string]interface{}{"progressToken": "p-1"},
+	})
+}
+
+// collectUntilResponse 收集 id 的响应之前收到的通知方法
+func collectUntilResponse(t *testing.T, client *testClient, id interface{}) []string {
+	t.Helper()
+	var methods []string
+	for {
+		message := client.next(t)
+		if message["id"] == id {
+			if message["error"] != nil {
+				t.Fatalf("response = %v", message)
+			}
+			return methods
+		}
+		methods = append(methods, message["method"].(string))
+	}
+}
+
+func TestNotificationsFollowClientState(t *testing.T) {
+	r := startRouter(t, Options{CollectInterval: time.Hour})
+
+	ready := connectClient(t, r)
+	ready.setup(t, "")
+	// 只完成 initialize、未发送 initialized 的客户端
+	pending := connectClient(t, r)
+	if resp := pending.call(t, minimalInitializeRequest(1)); resp["error"] != nil {
+		t.Fatalf("initialize: %v", resp)
+	}
+
+	// 带 progressToken 的调用发送进度通知（未就绪的会话不能调用工具，也就不会收到进度）
+	ready.send(t, progressCall("call"))
+	if methods := collectUntilResponse(t, ready, "call"); len(methods) == 0 || !slices.Contains(methods, types.MethodProgress) {
+		t.Errorf("ready client received %v before the response, want progress notifications", methods)
+	}
+	if resp := pending.call(t, progressCall("call")); resp["error"] == nil {
+		t.Errorf("pending client called a tool: %v", resp)
+	}
+	// 没有 progressToken 时不发送进度
+	ready.send(t, rpc("plain", types.MethodCallTool, map[string]interface{}{"name": "top_network_interfaces", "arguments": map[string]interface{}{"interval": "10ms"}}))
+	if methods := collectUntilResponse(t, ready, "plain"); len(methods) != 0 {
+		t.Errorf("call without a progress token sent %v", methods)
+	}
+
+	// 工具列表变化只通知握手已完成的会话
+	r.RegisterTool(&echoTool{name: "late"})
+	if message := ready.next(t); message["method"] != types.MethodToolsListChanged {
+		t.Errorf("ready client got %v, want tools/list_changed", message)
+	}
+	pending.expectQuiet(t)
+}
+
+func TestServerStatsShowsClient(t *testing.T) {
+	r := startRouter(t, Options{CollectInterval: time.Hour})
+	client := connectClient(t, r)
+	if resp := client.call(t, fullInitializeRequest(1)); resp["error"] != nil {
+		t.Fatalf("initialize: %v", resp)
+	}
+	client.send(t, rpc(nil, types.MethodNotificationInitialized, nil))
+
+	resp := client.call(t, rpc(2, types.MethodCallTool, map[string]interface{}{"name": "server_stats"}))
+	text := resp["result"].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	for _, want := range []string{
+		"\n名称: full-client 2.1.0\n协议版本: 2025-06-18\n",
+		"\n功能: roots, sampling, elicitation, structured_content, progress, tools_list_changed\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("server_stats missing %q:\n%s", want, text)
+		}
+	}
+
+	// 未提供 clientInfo 的客户端在文本输出中单独说明
+	other := connectClient(t, r)
+	if resp := other.call(t, minimalInitializeRequest(1)); resp["error"] != nil {
+		t.Fatalf("initialize: %v", resp)
+	}
+	other.send(t, rpc(nil, types.MethodNotificationInitialized, nil))
+	resp = other.call(t, rpc(2, types.MethodCallTool, map[string]interface{}{"name": "server_stats"}))
+	if text := messageJSON(t, resp); !strings.Contains(text, "客户端未提供 clientInfo") {
+		t.Errorf("server_stats for a client without clientInfo = %s", text)
+	}
+}
@@ -0,0 +1,137 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"mcp-example/internal/types"
+)
+
+// completingTool 为 text 参数补全 candidates 中以前缀开头的值的 echo 工具
+type completingTool struct {
+	echoTool
+	candidates []string
+}
+
+func (ct *completingTool) Complete(ctx context.Context, argName, prefix string) []string {
+	if argName != "text" {
+		return nil
+	}
+	var matched []string
+	for _, candidate := range ct.candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			matched = append(matched, candidate)
+		}
+	}
+	return matched
+}
+
+// completingTemplate 为 {component} 参数补全组件名称的资源模板
+type completingTemplate struct{}
+
+func (ct completingTemplate) GetResourceTemplate() types.ResourceTemplate {
+	return types.ResourceTemplate{URITemplate: "test://live/{component}", Name: "live"}
+}
+func (ct completingTemplate) Resolve(uri string) (types.MonitorResource, error) { return nil, nil }
+func (ct completingTemplate) Complete(ctx context.Context, argName, prefix string) []string {
+	if argName != "component" {
+		return nil
+	}
+	return []string{"cpu", "disk", "memory"}
+}
+
+// newCompletionHandler 注册了补全 eth0/eth1/lo 的 net 工具、不支持补全的 echo 工具、被策略禁用的 kill 工具和补全资源模板的处理器
+func newCompletionHandler() *MCPHandler {
+	handler, _ := newTestHandler()
+	handler.RegisterTool(&completingTool{echoTool: echoTool{name: "net"}, candidates: []string{"eth0", "eth1", "lo"}})
+	handler.RegisterTool(&writingTool{echoTool{name: "kill"}})
+	handler.RegisterResourceTemplate(completingTemplate{})
+	handler.SetPolicy(Policy{ReadOnly: true})
+	return handler
+}
+
+// complete 发送 completion/complete 请求，返回补全结果
+func complete(t *testing.T, handler *MCPHandler, ref types.CompleteRef, argName, value string) types.CompletionValues {
+	t.Helper()
+	resp := handler.HandleRequest(context.Background(), nil, rpc(1, types.MethodComplete, types.CompleteParams{
+		Ref:      ref,
+		Argument: types.CompleteArgument{Name: argName, Value: value},
+	}))
+	result, ok := resp.Result.(types.CompleteResult)
+	if resp.Error != nil || !ok {
+		t.Fatalf("completion/complete = %s", responseJSON(t, resp))
+	}
+	return result.Completion
+}
+
+func TestCompleteToolArguments(t *testing.T) {
+	handler := newCompletionHandler()
+	net := types.CompleteRef{Type: types.RefTypeTool, Name: "net"}
+
+	if got := complete(t, handler, net, "text", "eth"); !slices.Equal(got.Values, []string{"eth0", "eth1"}) || got.Total != 2 || got.HasMore {
+		t.Fatalf("completing eth = %+v", got)
+	}
+	if got := complete(t, handler, net, "text", ""); !slices.Equal(got.Values, []string{"eth0", "eth1", "lo"}) {
+		t.Fatalf("completing an empty prefix = %+v", got)
+	}
+
+	// 资源模板参数同样补全
+	live := types.CompleteRef{Type: types.RefTypeResource, URI: "test://live/{component}"}
+	if got := complete(t, handler, live, "component", ""); !slices.Equal(got.Values, []string{"cpu", "disk", "memory"}) {
+		t.Fatalf("completing a template argument = %+v", got)
+	}
+}
+
+func TestCompleteUnknownReferencesReturnEmptyList(t *testing.T) {
+	handler := newCompletionHandler()
+	cases := []struct {
+		name    string
+		ref     types.CompleteRef
+		argName string
+	}{
+		{"no match", types.CompleteRef{Type: types.RefTypeTool, Name: "net"}, "text"},
+		{"unknown argument", types.CompleteRef{Type: types.RefTypeTool, Name: "net"}, "interface"},
+		{"tool without completion", types.CompleteRef{Type: types.RefTypeTool, Name: "echo"}, "text"},
+		{"unknown tool", types.CompleteRef{Type: types.RefTypeTool, Name: "missing"}, "text"},
+		{"tool disabled by policy", types.CompleteRef{Type: types.RefTypeTool, Name: "kill"}, "text"},
+		{"unknown template", types.CompleteRef{Type: types.RefTypeResource, URI: "test://other/{component}"}, "component"},
+		{"prompt", types.CompleteRef{Type: types.RefTypePrompt, Name: "diagnose"}, "text"},
+		{"unknown ref type", types.CompleteRef{Type: "ref/other"}, "text"},
+	}
+	for _, c := range cases {
+		// 返回空数组而不是 null
+		if got := complete(t, handler, c.ref, c.argName, "x"); got.Values == nil || len(got.Values) != 0 || got.Total != 0 {
+			t.Errorf("%s: completion = %+v, want an empty list", c.name, got)
+		}
+	}
+
+	if resp := handler.HandleRequest(context.Background(), nil, rpc(1, types.MethodComplete, []interface{}{"net"})); resp.Error == nil || resp.Error.Code != -32602 {
+		t.Errorf("completion/complete with array params = %s, want -32602", responseJSON(t, resp))
+	}
+}
+
+func TestCompleteCapsValues(t *testing.T) {
+	var candidates []string
+	for i := 0; i < maxCompletionValues+20; i++ {
+		candidates = append(candidates, fmt.Sprintf("veth%03d", i))
+	}
+	handler, _ := newTestHandler()
+	handler.RegisterTool(&completingTool{echoTool: echoTool{name: "net"}, candidates: candidates})
+
+	// 超过上限时截断，Total 为匹配的总数
+	got := complete(t, handler, types.CompleteRef{Type: types.RefTypeTool, Name: "net"}, "text", "veth")
+	if len(got.Values) != maxCompletionValues || got.Total != maxCompletionValues+20 || !got.HasMore || got.Values[0] != "veth000" {
+		t.Fatalf("completion = %d values, total %d, hasMore %v", len(got.Values), got.Total, got.HasMore)
+	}
+}
+
+func TestInitializeDeclaresCompletions(t *testing.T) {
+	handler, _ := newTestHandler()
+	resp := handler.HandleRequest(context.Background(), nil, initializeRequest(1, "2025-03-26"))
+	if !strings.Contains(responseJSON(t, resp), `"completions":{}`) {
+		t.Fatalf("initialize = %s, want the completions capability", responseJSON(t, resp))
+	}
+}
@@ -0,0 +1,265 @@
+package router
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sort"
+	"sync"
+
+	"mcp-example/internal/transcript"
+	"mcp-example/internal/types"
+)
+
+// connection 一个客户端连接（stdio 或 Serve 接受的套接字连接）。每个连接有自己的会话：
+// 握手状态、日志级别、资源订阅和监视互不影响，响应和通知只写到该连接的输出，会话记录中以该会话的 ID 标记
+type connection struct {
+	session *Session
+	output  io.Writer
+	// closer Stop 时关闭以结束阻塞的读取，stdio 连接为 nil（不关闭标准输入）
+	closer io.Closer
+
+	mutex sync.Mutex
+}
+
+// ErrRouterStopped 路由器已停止或正在关闭时 Serve 返回的错误
+var ErrRouterStopped = errors.New("路由器已停止")
+
+// Serve 在 conn 上服务一个客户端连接，为其创建独立的会话，直到连接的输入结束或路由器停止。
+// 需在 Start 之后调用（Ready 关闭后），可在多个 goroutine 中为不同的连接并发调用；
+// 工具、缓存和存储由所有连接共享。连接结束时清理会话（取消进行中的请求、清除资源订阅、停止监视），
+// 返回前关闭 conn；路由器停止时也会关闭 conn 以结束阻塞的读取
+func (r *Router) Serve(conn io.ReadWriteCloser) error {
+	defer conn.Close()
+	return r.serveConnection(conn, conn, conn)
+}
+
+// ServeListener 接受 listener 上的连接（如 Unix 套接字），每个连接在单独的 goroutine 中通过 Serve 服务。
+// listener 关闭时返回；路由器停止后接受的连接立即关闭
+func (r *Router) ServeListener(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("接受连接失败: %v", err)
+		}
+		go func() {
+			if err := r.Serve(conn); err != nil && !errors.Is(err, ErrRouterStopped) {
+				slog.Warn("连接异常结束", "remote", conn.RemoteAddr(), "error", err)
+			}
+		}()
+	}
+}
+
+// serveConnection 登记连接并运行消息处理循环，输入结束后清理会话
+func (r *Router) serveConnection(input io.Reader, output io.Writer, closer io.Closer) error {
+	conn, err := r.openConnection(output, closer)
+	if err != nil {
+		return err
+	}
+	defer r.closeConnection(conn)
+
+	return r.messageLoop(conn, input)
+}
+
+// openConnection 为新连接创建会话并登记，路由器已停止时返回 ErrRouterStopped
+func (r *Router) openConnection(output io.Writer, closer io.Closer) (*connection, error) {
+	conn := &connection{session: NewSession(), output: output, closer: closer}
+
+	r.connMutex.Lock()
+	defer r.connMutex.Unlock()
+	if r.closed {
+		return nil, ErrRouterStopped
+	}
+	r.connections[conn.session] = conn
+	return conn, nil
+}
+
+// closeConnection 连接结束（输入结束即连接断开）时清理会话，包括资源订阅、进行中的请求和监视
+func (r *Router) closeConnection(conn *connection) {
+	r.connMutex.Lock()
+	delete(r.connections, conn.session)
+	r.connMutex.Unlock()
+
+	conn.session.Shutdown()
+	r.reloadMutex.Lock()
+	watches := r.watches
+	r.reloadMutex.Unlock()
+	if watches != nil {
+		watches.StopSession(conn.session)
+	}
+}
+
+// messageLoop 读取连接的输入，逐行处理并写出响应
+func (r *Router) messageLoop(conn *connection, input io.Reader) error {
+	scanner := bufio.NewScanner(input)
+
+	// 不输出到 stdout，避免干扰 JSON-RPC 通信
+
+	for r.isRunning() && scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		if response := r.handleLine(conn, []byte(line)); response != nil {
+			r.writeMessage(conn, response)
+		}
+	}
+
+	if err := scanner.Err(); err != nil && r.isRunning() {
+		// 扫描错误，但不输出到 stdout；路由器停止时关闭连接导致的读取错误不报告
+		return fmt.Errorf("扫描输入时出错: %v", err)
+	}
+
+	return nil
+}
+
+// handleLine 处理连接上的一行输入，返回需要发送的响应（通知和没有 ID 的无效消息返回 nil）
+func (r *Router) handleLine(conn *connection, line []byte) *types.JSONRPCResponse {
+	if r.options.Transcript != nil {
+		r.options.Transcript.Record(transcript.DirectionIn, conn.session.ID(), line)
+	}
+
+	// 解析 JSON-RPC 请求
+	var req types.JSONRPCRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		// 解析失败，但不输出日志到避免干扰 JSON-RPC
+		// 发送解析错误响应（只有在有ID的情况下）
+		var rawMessage map[string]interface{}
+		json.Unmarshal(line, &rawMessage)
+		if id, hasID := rawMessage["id"]; hasID {
+			return &types.JSONRPCResponse{
+				JSONRPC: "2.0",
+				ID:      id,
+				Error: &types.RPCError{
+					Code:    -32700,
+					Message: "Parse error: " + err.Error(),
+				},
+			}
+		}
+		return nil
+	}
+
+	response := r.handler.HandleRequest(r.ctx, conn.session, &req)
+
+	// 只有非通知的请求才发送响应
+	if req.ID == nil {
+		return nil
+	}
+	return response
+}
+
+// writeMessage 序列化并写出一条消息到连接，同一连接的响应和通知可能来自不同 goroutine
+func (r *Router) writeMessage(conn *connection, message interface{}) {
+	respBytes, err := json.Marshal(message)
+	if err != nil {
+		// 序列化失败，但不输出日志避免干扰 JSON-RPC
+		return
+	}
+
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+
+	if r.options.Transcript != nil {
+		r.options.Transcript.Record(transcript.DirectionOut, conn.session.ID(), respBytes)
+	}
+	if _, err := fmt.Fprintln(conn.output, string(respBytes)); err != nil {
+		// 发送失败，但不输出日志避免干扰 JSON-RPC
+	}
+}
+
+// sendTo 向会话所在的连接发送通知，连接已关闭时丢弃
+func (r *Router) sendTo(session *Session, notification *types.JSONRPCNotification) {
+	r.connMutex.Lock()
+	conn, found := r.connections[session]
+	r.connMutex.Unlock()
+	if found {
+		r.writeMessage(conn, notification)
+	}
+}
+
+// sendToRequester 向 ctx 中的请求所属的连接发送通知（如进度通知），没有会话的内部调用丢弃
+func (r *Router) sendToRequester(ctx context.Context, notification *types.JSONRPCNotification) {
+	if session, ok := SessionFromContext(ctx); ok {
+		r.sendTo(session, notification)
+	}
+}
+
+// broadcast 向 wants 返回 true 的会话所在的连接发送通知
+func (r *Router) broadcast(notification *types.JSONRPCNotification, wants func(*Session) bool) {
+	for _, conn := range r.openConnections() {
+		if wants(conn.session) {
+			r.writeMessage(conn, notification)
+		}
+	}
+}
+
+// openConnections 当前的所有连接，按会话创建时间排序
+func (r *Router) openConnections() []*connection {
+	r.connMutex.Lock()
+	connections := make([]*connection, 0, len(r.connections))
+	for _, conn := range r.connections {
+		connections = append(connections, conn)
+	}
+	r.connMutex.Unlock()
+
+	sort.Slice(connections, func(i, j int) bool {
+		a, b := connections[i].session, connections[j].session
+		if !a.createdAt.Equal(b.createdAt) {
+			return a.createdAt.Before(b.createdAt)
+		}
+		return a.id < b.id
+	})
+	return connections
+}
+
+// currentSessionStats ctx 中的请求所属会话的统计，没有会话的内部调用返回 false
+func currentSessionStats(ctx context.Context) (types.SessionStats, bool) {
+	session, ok := SessionFromContext(ctx)
+	if !ok {
+		return types.SessionStats{}, false
+	}
+	return session.Stats(), true
+}
+
+// drainSessions 不再接受新连接，所有会话不再接受新请求，并发等待各自进行中的请求结束
+func (r *Router) drainSessions(ctx context.Context) error {
+	r.connMutex.Lock()
+	r.closed = true
+	r.connMutex.Unlock()
+
+	connections := r.openConnections()
+	errs := make([]error, len(connections))
+	var wg sync.WaitGroup
+	for i, conn := range connections {
+		wg.Add(1)
+		go func(i int, session *Session) {
+			defer wg.Done()
+			errs[i] = session.Drain(ctx)
+		}(i, conn.session)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// closeConnections 路由器停止：不再接受新连接，关闭所有会话并关闭 Serve 接受的连接以结束阻塞的读取
+func (r *Router) closeConnections() {
+	r.connMutex.Lock()
+	r.closed = true
+	r.connMutex.Unlock()
+
+	for _, conn := range r.openConnections() {
+		conn.session.Shutdown()
+		if conn.closer != nil {
+			conn.closer.Close()
+		}
+	}
+}
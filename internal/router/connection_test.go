@@ -0,0 +1,272 @@
+package router
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+// recordingTranscript 记录每条消息所属会话的会话记录
+type recordingTranscript struct {
+	mutex    sync.Mutex
+	sessions map[string][]string
+}
+
+func (rt *recordingTranscript) Record(direction, sessionID string, message []byte) {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+	rt.sessions[sessionID] = append(rt.sessions[sessionID], direction+" "+string(message))
+}
+
+// staticResource 内容固定的资源
+type staticResource struct{ uri string }
+
+func (sr staticResource) GetResource() types.Resource {
+	return types.Resource{URI: sr.uri, Name: "static"}
+}
+func (sr staticResource) Read(context.Context) (string, error) { return "static", nil }
+
+// startTestRouter 启动只注册了 echo 工具的路由器，stdio 连接的输入在测试结束时关闭
+func startTestRouter(t *testing.T, recorder Transcript) *Router {
+	t.Helper()
+	return startRouter(t, Options{
+		SkipDefaultTools: true,
+		Tools:            []types.MonitorTool{&echoTool{name: "echo"}},
+		Transcript:       recorder,
+	})
+}
+
+// startRouter 按 options 启动路由器，stdio 连接的输入在测试结束时关闭
+func startRouter(t *testing.T, options Options) *Router {
+	t.Helper()
+	r := NewRouter("test-server", "0.0.0", storage.NewMemoryStorage(), storage.NewMemoryCache(), options)
+	stdin, stdinWriter := io.Pipe()
+	r.SetInput(stdin)
+	r.SetOutput(io.Discard)
+
+	started := make(chan error, 1)
+	go func() { started <- r.Start() }()
+	t.Cleanup(func() {
+		r.Stop()
+		stdinWriter.Close()
+		<-started
+	})
+
+	select {
+	case <-r.Ready():
+	case <-time.After(5 * time.Second):
+		t.Fatal("router did not become ready")
+	}
+	return r
+}
+
+// testClient 通过 Serve 接入路由器的客户端，收到的消息按顺序放入 messages
+type testClient struct {
+	conn     net.Conn
+	messages chan map[string]interface{}
+	served   chan error
+}
+
+func connectClient(t *testing.T, r *Router) *testClient {
+	t.Helper()
+	serverSide, clientSide := net.Pipe()
+	client := &testClient{conn: clientSide, messages: make(chan map[string]interface{}, 64), served: make(chan error, 1)}
+	go func() { client.served <- r.Serve(serverSide) }()
+	go func() {
+		defer close(client.messages)
+		reader := bufio.NewReader(clientSide)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				return
+			}
+			var message map[string]interface{}
+			if json.Unmarshal(line, &message) == nil {
+				client.messages <- message
+			}
+		}
+	}()
+	t.Cleanup(func() { clientSide.Close() })
+	return client
+}
+
+func (c *testClient) send(t *testing.T, req *types.JSONRPCRequest) {
+	t.Helper()
+	data, _ := json.Marshal(req)
+	if _, err := c.conn.Write(append(data, '\n')); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+// next 下一条收到的消息，超时或连接关闭时测试失败
+func (c *testClient) next(t *testing.T) map[string]interface{} {
+	t.Helper()
+	select {
+	case message, ok := <-c.messages:
+		if !ok {
+			t.Fatal("connection closed")
+		}
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message within 5s")
+		return nil
+	}
+}
+
+// call 发送请求并返回下一条消息，期间收到的通知也会被返回
+func (c *testClient) call(t *testing.T, req *types.JSONRPCRequest) map[string]interface{} {
+	t.Helper()
+	c.send(t, req)
+	return c.next(t)
+}
+
+// setup 完成握手，level 非空时设置日志级别
+func (c *testClient) setup(t *testing.T, level string) {
+	t.Helper()
+	if resp := c.call(t, initializeRequest(1, "2025-06-18")); resp["error"] != nil {
+		t.Fatalf("initialize: %v", resp)
+	}
+	c.send(t, rpc(nil, types.MethodNotificationInitialized, nil))
+	if level != "" {
+		if resp := c.call(t, rpc(2, types.MethodSetLogLevel, map[string]interface{}{"level": level})); resp["error"] != nil {
+			t.Fatalf("setLevel: %v", resp)
+		}
+	}
+}
+
+// expectQuiet 确认在 ping 的响应之前没有收到其他消息
+func (c *testClient) expectQuiet(t *testing.T) {
+	t.Helper()
+	if message := c.call(t, rpc("quiet", types.MethodPing, nil)); message["id"] != "quiet" {
+		t.Fatalf("unexpected message before the ping response: %v", message)
+	}
+}
+
+func TestSessionsAtDifferentLogLevels(t *testing.T) {
+	r := startTestRouter(t, nil)
+	verbose := connectClient(t, r)
+	quiet := connectClient(t, r)
+	verbose.setup(t, "debug")
+	quiet.setup(t, "error")
+
+	r.handleAnomaly(types.Anomaly{Metric: "cpu_percent", Value: 97})
+
+	message := verbose.next(t)
+	if message["method"] != types.MethodLogMessage {
+		t.Fatalf("debug session got %v, want the warning log message", message)
+	}
+	if params := message["params"].(map[string]interface{}); params["level"] != "warning" || params["logger"] != "anomaly" {
+		t.Fatalf("log params = %v", params)
+	}
+	// 只接收 error 及以上级别的会话收不到 warning
+	quiet.expectQuiet(t)
+	verbose.expectQuiet(t)
+
+	var levels []string
+	for _, stats := range r.DiagnosticsStatus().Sessions {
+		if stats.LogLevel != "" {
+			levels = append(levels, stats.LogLevel)
+		}
+	}
+	if len(levels) != 2 {
+		t.Fatalf("session log levels = %v, want one debug and one error session", levels)
+	}
+}
+
+func TestNotificationsFollowSessionState(t *testing.T) {
+	r := startTestRouter(t, nil)
+	ready := connectClient(t, r)
+	pending := connectClient(t, r)
+	ready.setup(t, "")
+	if resp := pending.call(t, initializeRequest(1, "2025-06-18")); resp["error"] != nil {
+		t.Fatalf("initialize: %v", resp)
+	}
+
+	r.RegisterTool(&echoTool{name: "echo2"})
+	if message := ready.next(t); message["method"] != types.MethodToolsListChanged {
+		t.Fatalf("ready session got %v, want tools/list_changed", message)
+	}
+	// 未完成握手的会话不接收通知
+	pending.expectQuiet(t)
+
+	// 订阅只属于发起订阅的会话
+	for _, client := range []*testClient{ready, pending} {
+		client.send(t, rpc(nil, types.MethodNotificationInitialized, nil))
+	}
+	r.handler.RegisterResource(staticResource{uri: "monitor://test/static"})
+	if resp := ready.call(t, rpc(3, types.MethodSubscribeResource, map[string]interface{}{"uri": "monitor://test/static"})); resp["error"] != nil {
+		t.Fatalf("subscribe: %v", resp)
+	}
+	r.broadcast(&types.JSONRPCNotification{JSONRPC: "2.0", Method: types.MethodResourceUpdated}, func(session *Session) bool {
+		return session.IsSubscribed("monitor://test/static")
+	})
+	if message := ready.next(t); message["method"] != types.MethodResourceUpdated {
+		t.Fatalf("subscribed session got %v", message)
+	}
+	pending.expectQuiet(t)
+}
+
+func TestConnectionCleanup(t *testing.T) {
+	r := startTestRouter(t, nil)
+	first := connectClient(t, r)
+	second := connectClient(t, r)
+	first.setup(t, "debug")
+	second.setup(t, "debug")
+	// stdio 连接和两个 Serve 连接
+	if count := len(r.DiagnosticsStatus().Sessions); count != 3 {
+		t.Fatalf("sessions = %d, want 3", count)
+	}
+
+	first.conn.Close()
+	if err := <-first.served; err != nil {
+		t.Fatalf("Serve returned %v after the client disconnected", err)
+	}
+	if count := len(r.DiagnosticsStatus().Sessions); count != 2 {
+		t.Fatalf("sessions after disconnect = %d, want 2", count)
+	}
+	r.handleAnomaly(types.Anomaly{Metric: "cpu_percent"})
+	if message := second.next(t); message["method"] != types.MethodLogMessage {
+		t.Fatalf("remaining session got %v", message)
+	}
+
+	// 路由器停止时关闭所有连接，之后的连接被拒绝
+	r.Stop()
+	select {
+	case <-second.served:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after Stop")
+	}
+	serverSide, clientSide := net.Pipe()
+	defer clientSide.Close()
+	if err := r.Serve(serverSide); err != ErrRouterStopped {
+		t.Fatalf("Serve after Stop = %v, want ErrRouterStopped", err)
+	}
+}
+
+func TestTranscriptRecordsEachSession(t *testing.T) {
+	recorder := &recordingTranscript{sessions: make(map[string][]string)}
+	r := startTestRouter(t, recorder)
+	first := connectClient(t, r)
+	second := connectClient(t, r)
+	first.call(t, rpc(1, types.MethodPing, nil))
+	second.call(t, rpc(2, types.MethodPing, nil))
+	second.call(t, rpc(3, types.MethodPing, nil))
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	var counts []int
+	for _, messages := range recorder.sessions {
+		counts = append(counts, len(messages))
+	}
+	if len(counts) != 2 || counts[0]+counts[1] != 6 || (counts[0] != 2 && counts[0] != 4) {
+		t.Fatalf("recorded messages per session = %v, want 2 and 4", counts)
+	}
+}
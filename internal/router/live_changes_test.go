@@ -0,0 +1,125 @@
+package router
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/tools"
+	"mcp-example/internal/types"
+)
+
+// liveSample 时间为基准时间之后 after、内存使用率为 memory 的后台采样
+func liveSample(after time.Duration, memory float64) types.MetricSample {
+	return types.MetricSample{
+		Timestamp:     time.Date(2024, 5, 6, 7, 8, 0, 0, time.UTC).Add(after),
+		CPUPercent:    20,
+		MemoryPercent: memory,
+		DiskPercent:   map[string]float64{"/": 50},
+	}
+}
+
+// messageJSON 收到的消息重新编码为 JSON，便于按子串检查
+func messageJSON(t *testing.T, message map[string]interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// subscriptions 各会话的资源订阅数之和
+func subscriptions(r *Router) int {
+	total := 0
+	for _, stats := range r.DiagnosticsStatus().Sessions {
+		total += stats.Subscriptions
+	}
+	return total
+}
+
+func TestLiveChangesSubscription(t *testing.T) {
+	// 采集间隔足够长，测试期间采集器不会自行采样；采样通过采集器的完成回调注入
+	r := startRouter(t, Options{CollectInterval: time.Hour})
+	subscriber := connectClient(t, r)
+	other := connectClient(t, r)
+
+	resp := subscriber.call(t, initializeRequest(1, "2025-06-18"))
+	if !strings.Contains(messageJSON(t, resp), `"resources":{"subscribe":true`) {
+		t.Fatalf("initialize = %v, want resources.subscribe", resp)
+	}
+	subscriber.send(t, rpc(nil, types.MethodNotificationInitialized, nil))
+	other.setup(t, "")
+	if resp := subscriber.call(t, rpc(2, types.MethodSubscribeResource, map[string]interface{}{"uri": tools.LiveChangesURI})); resp["error"] != nil {
+		t.Fatalf("subscribe: %v", resp)
+	}
+
+	// 只有一次采样时没有可比较的内容，不通知
+	r.handleSample(liveSample(0, 40))
+	subscriber.expectQuiet(t)
+
+	r.handleSample(liveSample(time.Minute, 55))
+	message := subscriber.next(t)
+	if message["method"] != types.MethodResourceUpdated || message["params"].(map[string]interface{})["uri"] != tools.LiveChangesURI {
+		t.Fatalf("subscriber got %v, want resources/updated for %s", message, tools.LiveChangesURI)
+	}
+	// 未订阅的会话不接收通知
+	other.expectQuiet(t)
+
+	// 通知之后读取到的是最新的变化
+	resp = subscriber.call(t, rpc(3, types.MethodReadResource, map[string]interface{}{"uri": tools.LiveChangesURI}))
+	if text := messageJSON(t, resp); !strings.Contains(text, "内存: 40.00% → 55.00% (+15.00)") || !strings.Contains(text, "采样间隔: 1m0s") {
+		t.Fatalf("resources/read = %s", text)
+	}
+
+	// 渲染内容不变时不重复通知
+	r.handleSample(liveSample(time.Minute, 55))
+	if message := subscriber.next(t); message["method"] != types.MethodResourceUpdated {
+		t.Fatalf("subscriber got %v, want resources/updated", message)
+	}
+	r.handleSample(liveSample(time.Minute, 55))
+	subscriber.expectQuiet(t)
+
+	// 取消订阅后不再通知
+	if resp := subscriber.call(t, rpc(4, types.MethodUnsubscribeResource, map[string]interface{}{"uri": tools.LiveChangesURI})); resp["error"] != nil {
+		t.Fatalf("unsubscribe: %v", resp)
+	}
+	r.handleSample(liveSample(2*time.Minute, 60))
+	subscriber.expectQuiet(t)
+	if count := subscriptions(r); count != 0 {
+		t.Fatalf("subscriptions after unsubscribe = %d", count)
+	}
+
+	// 断开连接的会话连同订阅一起清理，之后的通知不受影响
+	if resp := other.call(t, rpc(2, types.MethodSubscribeResource, map[string]interface{}{"uri": tools.LiveChangesURI})); resp["error"] != nil {
+		t.Fatalf("subscribe: %v", resp)
+	}
+	if count := subscriptions(r); count != 1 {
+		t.Fatalf("subscriptions = %d, want 1", count)
+	}
+	other.conn.Close()
+	<-other.served
+	if count := subscriptions(r); count != 0 {
+		t.Fatalf("subscriptions after disconnect = %d", count)
+	}
+	if resp := subscriber.call(t, rpc(5, types.MethodSubscribeResource, map[string]interface{}{"uri": tools.LiveChangesURI})); resp["error"] != nil {
+		t.Fatalf("subscribe: %v", resp)
+	}
+	r.handleSample(liveSample(3*time.Minute, 65))
+	if message := subscriber.next(t); message["method"] != types.MethodResourceUpdated {
+		t.Fatalf("subscriber got %v after another session disconnected", message)
+	}
+}
+
+func TestSubscribeUnknownResource(t *testing.T) {
+	r := startRouter(t, Options{CollectInterval: time.Hour})
+	client := connectClient(t, r)
+	client.setup(t, "")
+
+	// 不存在的资源不能订阅
+	resp := client.call(t, rpc(2, types.MethodSubscribeResource, map[string]interface{}{"uri": "monitor://live/nothing"}))
+	if resp["error"] == nil || subscriptions(r) != 0 {
+		t.Fatalf("subscribing an unknown resource = %v", resp)
+	}
+}
@@ -0,0 +1,119 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"mcp-example/internal/tools"
+	"mcp-example/internal/types"
+)
+
+// componentTool 以结构化结果返回工具名和收到的参数的工具，代替实时数据资源各组件对应的工具
+type componentTool struct{ echoTool }
+
+func (ct *componentTool) ExecuteStructured(ctx context.Context, args map[string]interface{}) (string, interface{}, error) {
+	return ct.name, map[string]interface{}{"tool": ct.name, "args": args}, nil
+}
+
+// newLiveResourceHandler 注册了实时数据资源模板和各组件对应工具的处理器
+func newLiveResourceHandler() *MCPHandler {
+	handler, _ := newTestHandler()
+	for _, name := range []string{"system_overview", "cpu_info", "memory_info", "disk_info", "network_stats", "top_processes", "health_report"} {
+		handler.RegisterTool(&componentTool{echoTool{name: name}})
+	}
+	handler.RegisterResourceTemplate(tools.NewLiveResourceTemplate(handler.CallTool))
+	return handler
+}
+
+func TestListResourceTemplates(t *testing.T) {
+	handler := newLiveResourceHandler()
+	resp := handler.HandleRequest(context.Background(), nil, rpc(1, types.MethodListResourceTemplates, nil))
+	result, ok := resp.Result.(map[string]interface{})
+	if resp.Error != nil || !ok {
+		t.Fatalf("resources/templates/list = %s", responseJSON(t, resp))
+	}
+	templates, _ := result["resourceTemplates"].([]types.ResourceTemplate)
+	if len(templates) != 1 || templates[0].URITemplate != tools.LiveURITemplate || templates[0].MimeType != "application/json" ||
+		!strings.Contains(templates[0].Description, "system, cpu, memory, disk, network, processes, health") {
+		t.Errorf("templates = %+v", templates)
+	}
+
+	// 没有注册模板时为空列表而不是 null
+	handler, _ = newTestHandler()
+	resp = handler.HandleRequest(context.Background(), nil, rpc(1, types.MethodListResourceTemplates, nil))
+	if got := responseJSON(t, resp); !strings.Contains(got, `"resourceTemplates":[]`) {
+		t.Errorf("no templates = %s", got)
+	}
+}
+
+func TestReadLiveResource(t *testing.T) {
+	handler := newLiveResourceHandler()
+	components := map[string]string{
+		"system": "system_overview", "cpu": "cpu_info", "memory": "memory_info", "disk": "disk_info",
+		"network": "network_stats", "processes": "top_processes", "health": "health_report",
+	}
+	for component, tool := range components {
+		uri := "monitor://live/" + component
+		resp := readResource(handler, uri)
+		result, ok := resp.Result.(types.ReadResourceResult)
+		if resp.Error != nil || !ok || len(result.Contents) != 1 {
+			t.Fatalf("%s: resources/read = %s", component, responseJSON(t, resp))
+		}
+		contents := result.Contents[0]
+		if contents.URI != uri || contents.MimeType != "application/json" {
+			t.Errorf("%s: contents = %+v", component, contents)
+		}
+
+		var data struct {
+			Tool string                 `json:"tool"`
+			Args map[string]interface{} `json:"args"`
+		}
+		if err := json.Unmarshal([]byte(contents.Text), &data); err != nil {
+			t.Fatalf("%s: %v\n%s", component, err, contents.Text)
+		}
+		// 除 health_report 外使用工具的缓存
+		wantCache := tools.CacheModeAuto
+		if component == "health" {
+			wantCache = ""
+		}
+		if cache, _ := data.Args["cache"].(string); data.Tool != tool || cache != wantCache {
+			t.Errorf("%s: read %s with %v", component, data.Tool, data.Args)
+		}
+	}
+}
+
+func TestReadLiveResourceErrors(t *testing.T) {
+	handler := newLiveResourceHandler()
+
+	// 未知组件返回参数错误并列出可选的组件
+	resp := readResource(handler, "monitor://live/gpu")
+	if resp.Error == nil || resp.Error.Code != -32602 || !strings.Contains(resp.Error.Message, "Unknown resource: monitor://live/gpu") ||
+		!strings.Contains(resp.Error.Message, "可选: system, cpu") {
+		t.Errorf("unknown component = %s", responseJSON(t, resp))
+	}
+	resp = readResource(handler, "monitor://other/cpu")
+	if resp.Error == nil || resp.Error.Code != -32602 || resp.Error.Message != "Unknown resource: monitor://other/cpu" {
+		t.Errorf("unknown resource = %s", responseJSON(t, resp))
+	}
+
+	// 被策略禁用的工具无法通过资源读取
+	handler.SetPolicy(Policy{DenyTools: []string{"cpu_info"}})
+	resp = readResource(handler, "monitor://live/cpu")
+	if resp.Error == nil || resp.Error.Code != -32603 || !strings.Contains(resp.Error.Message, "cpu_info") {
+		t.Errorf("denied tool = %s", responseJSON(t, resp))
+	}
+	if resp := readResource(handler, "monitor://live/memory"); resp.Error != nil {
+		t.Errorf("allowed tool = %s", responseJSON(t, resp))
+	}
+
+	// 没有结构化结果的工具
+	handler, _ = newTestHandler()
+	handler.RegisterTool(&echoTool{name: "disk_info"})
+	handler.RegisterResourceTemplate(tools.NewLiveResourceTemplate(handler.CallTool))
+	resp = readResource(handler, "monitor://live/disk")
+	if resp.Error == nil || resp.Error.Code != -32603 || !strings.Contains(resp.Error.Message, "disk_info 没有返回结构化结果") {
+		t.Errorf("tool without structured result = %s", responseJSON(t, resp))
+	}
+}
@@ -1,9 +1,20 @@
 package router
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
 
+	"mcp-example/internal/identity"
+	"mcp-example/internal/telemetry"
+	"mcp-example/internal/tools"
+	"mcp-example/internal/trace"
 	"mcp-example/internal/types"
+	"mcp-example/internal/version"
 )
 
 // MCPHandler MCP 协议处理器
@@ -11,6 +22,24 @@ type MCPHandler struct {
 	serverName    string
 	serverVersion string
 	tools         map[string]types.MonitorTool
+	// toolsMutex 保护 tools，服务器运行中仍可注册或替换工具
+	toolsMutex  sync.RWMutex
+	resources   map[string]types.MonitorResource
+	templates   []types.MonitorResourceTemplate
+	toolTimeout time.Duration
+	notify      func(context.Context, *types.JSONRPCNotification)
+	middlewares []Middleware
+	policy      Policy
+	policyMutex sync.RWMutex
+	calls       callLog
+	presets     *tools.PresetStore
+	diffs       *tools.DiffStore
+	limiter     *toolLimiter
+	// maxResultBytes 工具结果的大小上限，0 表示不限制；超出时完整内容保存在 results 中
+	maxResultBytes int
+	results        *tools.ResultStore
+	// telemetry 为每次工具调用生成 span，未启用导出时为 telemetry.Nop
+	telemetry telemetry.Telemetry
 }
 
 // NewMCPHandler 创建新的 MCP 处理器
@@ -19,60 +48,326 @@ func NewMCPHandler(serverName, serverVersion string) *MCPHandler {
 		serverName:    serverName,
 		serverVersion: serverVersion,
 		tools:         make(map[string]types.MonitorTool),
+		resources:     make(map[string]types.MonitorResource),
+		telemetry:     telemetry.Nop,
 	}
 }
 
-// RegisterTool 注册工具
+// SetToolTimeout 设置单次工具调用的超时时间，0 表示不限制
+func (h *MCPHandler) SetToolTimeout(timeout time.Duration) {
+	h.toolTimeout = timeout
+}
+
+// SetToolRateLimits 设置按工具的调用限流（所有会话共享），未出现在 limits 中的工具不受限制
+func (h *MCPHandler) SetToolRateLimits(limits map[string]RateLimit) {
+	h.limiter = newToolLimiter(limits, time.Now)
+}
+
+// SetPolicy 设置工具访问策略，返回可用工具集合是否发生了变化
+func (h *MCPHandler) SetPolicy(policy Policy) bool {
+	h.policyMutex.Lock()
+	defer h.policyMutex.Unlock()
+
+	registered := h.registeredTools()
+	before := h.policy.allowedTools(registered)
+	h.policy = policy
+
+	return !slices.Equal(before, policy.allowedTools(registered))
+}
+
+// SetTelemetry 设置工具调用的追踪导出，需在开始处理请求之前调用
+func (h *MCPHandler) SetTelemetry(t telemetry.Telemetry) {
+	h.telemetry = t
+}
+
+// SetPresets 设置参数预设存储，设置后 tools/call 的 preset 参数会展开为预设的参数
+func (h *MCPHandler) SetPresets(presets *tools.PresetStore) {
+	h.presets = presets
+}
+
+// SetDiffStore 设置比较基准存储，设置后声明了 diff_previous 参数的工具可以附加与上一次结果相比的变化
+func (h *MCPHandler) SetDiffStore(diffs *tools.DiffStore) {
+	h.diffs = diffs
+}
+
+// applyPreset 展开参数中的 preset（显式参数优先），未设置预设存储时原样返回
+func (h *MCPHandler) applyPreset(tool string, args map[string]interface{}) (map[string]interface{}, error) {
+	if h.presets == nil {
+		return args, nil
+	}
+	return h.presets.Apply(tool, args)
+}
+
+// currentPolicy 获取当前的工具访问策略
+func (h *MCPHandler) currentPolicy() Policy {
+	h.policyMutex.RLock()
+	defer h.policyMutex.RUnlock()
+
+	return h.policy
+}
+
+// Use 注册中间件，按注册顺序由外向内包裹请求处理
+func (h *MCPHandler) Use(middleware Middleware) {
+	h.middlewares = append(h.middlewares, middleware)
+}
+
+// RegisterTool 注册工具，已有同名工具时替换并记录警告。可在处理请求期间调用
 func (h *MCPHandler) RegisterTool(tool types.MonitorTool) {
+	h.toolsMutex.Lock()
+	_, replaced := h.tools[tool.GetName()]
 	h.tools[tool.GetName()] = tool
-	// 工具注册成功，但不输出日志避免干扰 JSON-RPC
+	h.toolsMutex.Unlock()
+	if replaced {
+		slog.Warn("替换已注册的同名工具", "tool", tool.GetName())
+	}
+
+	// 调用示例必须符合工具自身的参数模式，防止模式变化后示例过时
+	if exampleTool, ok := tool.(types.ExampleTool); ok {
+		for _, example := range exampleTool.Examples() {
+			if _, err := tools.ValidateArguments(tool.GetInputSchema(), example.Arguments); err != nil {
+				slog.Warn("工具调用示例不符合参数模式", "tool", tool.GetName(), "example", example.Description, "error", err)
+			}
+		}
+	}
+}
+
+// lookupTool 按名称查找已注册的工具
+func (h *MCPHandler) lookupTool(name string) (types.MonitorTool, bool) {
+	h.toolsMutex.RLock()
+	defer h.toolsMutex.RUnlock()
+
+	tool, exists := h.tools[name]
+	return tool, exists
+}
+
+// registeredTools 已注册工具的副本，按名称索引
+func (h *MCPHandler) registeredTools() map[string]types.MonitorTool {
+	h.toolsMutex.RLock()
+	defer h.toolsMutex.RUnlock()
+
+	registered := make(map[string]types.MonitorTool, len(h.tools))
+	for name, tool := range h.tools {
+		registered[name] = tool
+	}
+	return registered
+}
+
+// SetNotifier 设置发送服务器通知（如进度通知）的函数，notify 将通知发送给 ctx 中的请求所属的会话
+func (h *MCPHandler) SetNotifier(notify func(ctx context.Context, notification *types.JSONRPCNotification)) {
+	h.notify = notify
+}
+
+// prefetchers 返回所有支持启动预取的工具
+func (h *MCPHandler) prefetchers() map[string]types.Prefetcher {
+	prefetchers := make(map[string]types.Prefetcher)
+	for name, tool := range h.registeredTools() {
+		if prefetcher, ok := tool.(types.Prefetcher); ok {
+			prefetchers[name] = prefetcher
+		}
+	}
+	return prefetchers
+}
+
+// RegisterResource 注册资源
+func (h *MCPHandler) RegisterResource(resource types.MonitorResource) {
+	h.resources[resource.GetResource().URI] = resource
+}
+
+// RegisterResourceTemplate 注册资源模板，resources/templates/list 按注册顺序列出
+func (h *MCPHandler) RegisterResourceTemplate(template types.MonitorResourceTemplate) {
+	h.templates = append(h.templates, template)
+}
+
+// HandleRequest 处理某个会话的 MCP 请求，ctx 取消或会话关闭时正在执行的工具调用会被中断。
+// session 为 nil 时不执行握手检查，也不支持资源订阅和日志级别。
+func (h *MCPHandler) HandleRequest(ctx context.Context, session *Session, req *types.JSONRPCRequest) *types.JSONRPCResponse {
+	handler := h.dispatch
+	for i := len(h.middlewares) - 1; i >= 0; i-- {
+		handler = h.middlewares[i](handler)
+	}
+
+	// 每个请求都有追踪 ID，处理期间的日志和错误响应中都会带上
+	traceID := requestTraceID(req)
+	ctx = trace.WithID(ctx, traceID)
+	start := time.Now()
+
+	if session != nil {
+		var done func()
+		ctx, done = session.begin(WithSession(ctx, session))
+		defer done()
+	}
+
+	resp := handler(ctx, req)
+	attachTraceID(resp, traceID)
+	if name := toolName(req); name != "" {
+		h.calls.add(newCallRecord(traceID, name, start, resp))
+	}
+
+	if session != nil {
+		session.record(resp)
+		if !session.Supports(FeatureStructuredContent) {
+			stripStructuredContent(resp)
+		}
+	}
+	return resp
+}
+
+// sessionSupports 当前请求的会话是否支持可选功能，没有会话时视为支持
+func sessionSupports(ctx context.Context, feature string) bool {
+	session, ok := SessionFromContext(ctx)
+	return !ok || session.Supports(feature)
+}
+
+// stripStructuredContent 去掉工具结果中的 structuredContent，用于不支持该字段的旧版本客户端
+func stripStructuredContent(resp *types.JSONRPCResponse) {
+	if resp == nil {
+		return
+	}
+	if result, ok := resp.Result.(types.CallToolResult); ok && result.StructuredContent != nil {
+		result.StructuredContent = nil
+		resp.Result = result
+	}
 }
 
-// HandleRequest 处理 MCP 请求
-func (h *MCPHandler) HandleRequest(req *types.JSONRPCRequest) *types.JSONRPCResponse {
+// RecentCalls 最近的工具调用记录（最新的在前）
+func (h *MCPHandler) RecentCalls() []types.ToolCallRecord {
+	return h.calls.recent()
+}
+
+// ToolStats 各工具自启动以来的调用次数、错误数和处理耗时（按工具名排序）
+func (h *MCPHandler) ToolStats() []types.ToolCallStats {
+	return h.calls.toolStats()
+}
+
+// dispatch 按方法分发请求
+func (h *MCPHandler) dispatch(ctx context.Context, req *types.JSONRPCRequest) *types.JSONRPCResponse {
 	// 处理请求，但不输出日志避免干扰 JSON-RPC
 
+	// 握手检查（仅在有会话时执行）
+	session, hasSession := SessionFromContext(ctx)
+	if hasSession {
+		if resp := h.checkSession(session, req); resp != nil {
+			return resp
+		}
+	}
+
 	switch req.Method {
+	case types.MethodPing:
+		return h.handlePing(req)
 	case types.MethodInitialize:
-		return h.handleInitialize(req)
-	case types.MethodInitialized, types.MethodNotificationInitialized:
-		return h.handleInitialized(req)
+		return h.handleInitialize(session, req)
+	case types.MethodInitialized:
+		return h.handleInitialized(session, req)
+	case types.MethodNotificationInitialized:
+		h.markReady(session)
+		return nil
 	case types.MethodListTools:
 		return h.handleListTools(req)
 	case types.MethodCallTool:
-		return h.handleCallTool(req)
+		return h.handleCallTool(ctx, req)
+	case types.MethodComplete:
+		return h.handleComplete(ctx, req)
 	case types.MethodListPrompts:
 		return h.handleListPrompts(req)
 	case types.MethodListResources:
 		return h.handleListResources(req)
 	case types.MethodReadResource:
-		return h.handleReadResource(req)
+		return h.handleReadResource(ctx, req)
+	case types.MethodListResourceTemplates:
+		return h.handleListResourceTemplates(req)
+	case types.MethodSubscribeResource:
+		return h.handleSubscribe(session, req, true)
+	case types.MethodUnsubscribeResource:
+		return h.handleSubscribe(session, req, false)
+	case types.MethodSetLogLevel:
+		return h.handleSetLogLevel(session, req)
 	default:
 		return h.errorResponse(req, -32601, "Method not found: "+req.Method)
 	}
 }
 
+// checkSession 检查会话状态是否允许处理该请求，允许时返回 nil。
+// ping 和握手消息在任何状态下都可以处理，其余请求需要会话已就绪。
+func (h *MCPHandler) checkSession(session *Session, req *types.JSONRPCRequest) *types.JSONRPCResponse {
+	switch req.Method {
+	case types.MethodPing, types.MethodInitialize, types.MethodInitialized, types.MethodNotificationInitialized:
+		return nil
+	}
+
+	switch session.State() {
+	case SessionReady:
+		return nil
+	case SessionShuttingDown:
+		return h.errorResponse(req, ErrCodeServerNotInitialized, "Server shutting down")
+	default:
+		return h.errorResponse(req, ErrCodeServerNotInitialized, "Server not initialized")
+	}
+}
+
+// markReady 收到 initialized 后将会话切换为就绪
+func (h *MCPHandler) markReady(session *Session) {
+	if session != nil {
+		session.transition(SessionInitializing, SessionReady)
+	}
+}
+
+// handlePing 处理 ping 请求
+func (h *MCPHandler) handlePing(req *types.JSONRPCRequest) *types.JSONRPCResponse {
+	return &types.JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  map[string]interface{}{},
+	}
+}
+
 // handleInitialize 处理初始化请求
-func (h *MCPHandler) handleInitialize(req *types.JSONRPCRequest) *types.JSONRPCResponse {
+func (h *MCPHandler) handleInitialize(session *Session, req *types.JSONRPCRequest) *types.JSONRPCResponse {
 	// 初始化服务器，但不输出日志避免干扰 JSON-RPC
 
+	var params types.InitializeParams
+	if req.Params != nil {
+		paramBytes, err := json.Marshal(req.Params)
+		if err != nil {
+			return h.errorResponse(req, -32602, "Invalid params: "+err.Error())
+		}
+		if err := json.Unmarshal(paramBytes, &params); err != nil {
+			return h.errorResponse(req, -32602, "Invalid params: "+err.Error())
+		}
+	}
+
+	if session != nil && !session.transition(SessionUninitialized, SessionInitializing) {
+		return h.errorResponse(req, -32600, "Server already initialized")
+	}
+
+	protocolVersion := negotiateProtocolVersion(params.ProtocolVersion)
+	if session != nil {
+		session.setClient(params, protocolVersion)
+	}
+
+	build := version.Get()
 	result := types.InitializeResult{
-		ProtocolVersion: "2024-11-05",
+		ProtocolVersion: protocolVersion,
 		Capabilities: types.ServerCapabilities{
 			Tools: &types.ToolsCapability{
 				ListChanged: true,
 			},
 			Resources: &types.ResourcesCapability{
-				Subscribe:   false,
+				Subscribe:   true,
 				ListChanged: false,
 			},
 			Prompts: &types.PromptsCapability{
 				ListChanged: false,
 			},
+			Completions: &types.CompletionsCapability{},
+			Logging:     &types.LoggingCapability{},
 		},
 		ServerInfo: types.ServerInfo{
 			Name:    h.serverName,
 			Version: h.serverVersion,
+			Build:   &build,
+		},
+		Meta: &types.InitializeMeta{
+			Host: identity.Get(),
 		},
 	}
 
@@ -83,25 +378,49 @@ func (h *MCPHandler) handleInitialize(req *types.JSONRPCRequest) *types.JSONRPCR
 	}
 }
 
-// handleInitialized 处理初始化完成通知
-func (h *MCPHandler) handleInitialized(req *types.JSONRPCRequest) *types.JSONRPCResponse {
+// supportedProtocolVersions 支持的 MCP 协议版本，最新的在最后
+var supportedProtocolVersions = []string{"2024-11-05", "2025-03-26", "2025-06-18"}
+
+// negotiateProtocolVersion 客户端请求的版本受支持时使用该版本，否则使用支持的最新版本
+func negotiateProtocolVersion(requested string) string {
+	if slices.Contains(supportedProtocolVersions, requested) {
+		return requested
+	}
+	return supportedProtocolVersions[len(supportedProtocolVersions)-1]
+}
+
+// handleInitialized 处理旧式的 initialized 消息。
+// 以通知形式发送时不返回响应；以请求形式发送（带 ID）时返回空结果。
+func (h *MCPHandler) handleInitialized(session *Session, req *types.JSONRPCRequest) *types.JSONRPCResponse {
 	// 服务器初始化完成，但不输出日志避免干扰 JSON-RPC
-	// 初始化完成通知通常不需要返回响应
-	return nil
+	h.markReady(session)
+
+	if req.ID == nil {
+		return nil
+	}
+	return &types.JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  map[string]interface{}{},
+	}
 }
 
 // handleListTools 处理工具列表请求
 func (h *MCPHandler) handleListTools(req *types.JSONRPCRequest) *types.JSONRPCResponse {
 	// 列出工具，但不输出日志避免干扰 JSON-RPC
 
+	policy := h.currentPolicy()
+
+	registered := h.registeredTools()
 	var tools []types.Tool
-	for _, tool := range h.tools {
-		mcpTool := types.Tool{
-			Name:        tool.GetName(),
-			Description: tool.GetDescription(),
-			InputSchema: tool.GetInputSchema(),
+	for _, name := range sortedToolNames(registered) {
+		tool := registered[name]
+		// 被策略禁用的工具不出现在列表中
+		if !policy.Allows(tool) {
+			continue
 		}
-		tools = append(tools, mcpTool)
+
+		tools = append(tools, describeTool(tool))
 	}
 
 	result := map[string]interface{}{
@@ -115,53 +434,377 @@ func (h *MCPHandler) handleListTools(req *types.JSONRPCRequest) *types.JSONRPCRe
 	}
 }
 
-// handleCallTool 处理工具调用请求
-func (h *MCPHandler) handleCallTool(req *types.JSONRPCRequest) *types.JSONRPCResponse {
-	var params types.CallToolParams
-	if req.Params != nil {
-		paramBytes, err := json.Marshal(req.Params)
-		if err != nil {
-			return h.errorResponse(req, -32602, "Invalid params: "+err.Error())
-		}
-		if err := json.Unmarshal(paramBytes, &params); err != nil {
-			return h.errorResponse(req, -32602, "Invalid params: "+err.Error())
+// describeTool 生成 tools/list 和 describe_tool 中的工具描述（包含调用示例）
+func describeTool(tool types.MonitorTool) types.Tool {
+	annotations := toolAnnotations(tool)
+	description := types.Tool{
+		Name:        tool.GetName(),
+		Description: tool.GetDescription(),
+		InputSchema: tool.GetInputSchema(),
+		Annotations: &annotations,
+	}
+	if exampleTool, ok := tool.(types.ExampleTool); ok {
+		description.Examples = exampleTool.Examples()
+	}
+	return description
+}
+
+// DescribeTool 获取当前策略下可用工具的描述，工具不存在或被禁用时返回 false
+func (h *MCPHandler) DescribeTool(name string) (types.Tool, bool) {
+	tool, exists := h.lookupTool(name)
+	if !exists || !h.currentPolicy().Allows(tool) {
+		return types.Tool{}, false
+	}
+	return describeTool(tool), true
+}
+
+// AllowedTools 当前策略下可用的工具名称（已排序）
+func (h *MCPHandler) AllowedTools() []string {
+	return h.currentPolicy().allowedTools(h.registeredTools())
+}
+
+// toolAnnotations 获取工具注解，未声明注解的工具默认只读、幂等
+func toolAnnotations(tool types.MonitorTool) types.ToolAnnotations {
+	if annotated, ok := tool.(types.AnnotatedTool); ok {
+		annotations := annotated.GetAnnotations()
+		if annotations.Title == "" {
+			annotations.Title = tool.GetName()
 		}
+		return annotations
+	}
+	return types.ReadOnlyAnnotations(tool.GetName())
+}
+
+// handleCallTool 处理工具调用请求
+func (h *MCPHandler) handleCallTool(ctx context.Context, req *types.JSONRPCRequest) *types.JSONRPCResponse {
+	params, err := decodeCallToolParams(req.Params)
+	if err != nil {
+		return h.errorResponse(req, -32602, "Invalid params: "+err.Error())
 	}
 
 	// 调用工具，但不输出日志避免干扰 JSON-RPC
 
 	// 查找工具
-	tool, exists := h.tools[params.Name]
+	tool, exists := h.lookupTool(params.Name)
 	if !exists {
 		return h.errorResponse(req, -32602, "Unknown tool: "+params.Name)
 	}
+	if !h.currentPolicy().Allows(tool) {
+		return h.errorResponse(req, ErrCodeToolDisabled, "Tool disabled by server policy: "+params.Name)
+	}
+
+	if h.limiter != nil {
+		if allowed, retryAfter := h.limiter.allow(params.Name); !allowed {
+			return &types.JSONRPCResponse{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Result:  h.rateLimitedResult(ctx, params.Name, params.Arguments, retryAfter),
+			}
+		}
+	}
+
+	// 客户端提供 progressToken 时，工具报告的进度以 notifications/progress 发送
+	if params.Meta != nil && params.Meta.ProgressToken != nil && h.notify != nil && sessionSupports(ctx, FeatureProgress) {
+		token := params.Meta.ProgressToken
+		requestCtx := ctx
+		ctx = tools.WithProgress(ctx, func(progress, total float64, message string) {
+			h.notify(requestCtx, &types.JSONRPCNotification{
+				JSONRPC: "2.0",
+				Method:  types.MethodProgress,
+				Params: types.ProgressParams{
+					ProgressToken: token,
+					Progress:      progress,
+					Total:         total,
+					Message:       message,
+				},
+			})
+		})
+	}
 
 	// 执行工具
-	result, err := tool.Execute(params.Arguments)
+	if h.toolTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.toolTimeout)
+		defer cancel()
+	}
+
+	var traceparent string
+	if params.Meta != nil {
+		traceparent = params.Meta.Traceparent
+	}
+	ctx, span := h.telemetry.StartToolCall(ctx, params.Name, traceparent)
+
+	ctx, callMeta := tools.WithCallMeta(ctx)
+	start := time.Now()
+	result, structured, err := h.executeWithPreset(ctx, tool, params.Arguments)
+	duration := time.Since(start)
 	if err != nil {
+		toolErr := tools.ClassifyError(err)
+		if ctx.Err() == context.DeadlineExceeded {
+			toolErr = tools.TimeoutError(h.toolTimeout, err)
+		}
+		span.End(telemetry.ToolCallResult{Cached: callMeta.Cached(), Duration: duration, ErrorCode: string(toolErr.Code), Error: toolErr.Error()})
 		// 工具执行失败，但不输出日志避免干扰 JSON-RPC
+		errorResult := toolErrorResult(ctx, toolErr)
+		errorResult.Meta = h.resultMeta(ctx, callMeta.Cached(), callMeta.DataAge(), duration)
 		return &types.JSONRPCResponse{
 			JSONRPC: "2.0",
 			ID:      req.ID,
-			Result: types.CallToolResult{
-				Content: []types.Content{
-					{Type: "text", Text: "❌ " + err.Error()},
-				},
-				IsError: true,
-			},
+			Result:  errorResult,
 		}
 	}
 
 	// 工具执行成功，但不输出日志避免干扰 JSON-RPC
+	span.End(telemetry.ToolCallResult{Cached: callMeta.Cached(), Duration: duration})
+
+	callResult := types.CallToolResult{
+		Content: []types.Content{
+			{Type: "text", Text: tools.SanitizeText(result)},
+		},
+		StructuredContent: structured,
+		Meta:              h.resultMeta(ctx, callMeta.Cached(), callMeta.DataAge(), duration),
+	}
+	callResult = h.limitResult(ctx, params.Name, callResult)
+	if h.limiter != nil {
+		h.limiter.remember(params.Name, params.Arguments, callResult)
+	}
 
 	return &types.JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      req.ID,
-		Result: types.CallToolResult{
-			Content: []types.Content{
-				{Type: "text", Text: result},
+		Result:  callResult,
+	}
+}
+
+// rateLimitedResult 生成调用被限流时的错误结果，工具有以相同参数调用的最近一次成功结果时附在错误之后
+func (h *MCPHandler) rateLimitedResult(ctx context.Context, name string, args map[string]interface{}, retryAfter time.Duration) types.CallToolResult {
+	toolErr := tools.RateLimitedError(name, retryAfter)
+	cached, age, found := h.limiter.cached(name, args)
+	if found {
+		toolErr.Message += fmt.Sprintf("; cached data from %ds ago follows", int(age.Seconds()))
+	}
+
+	result := toolErrorResult(ctx, toolErr)
+	if found {
+		result.Content = append(result.Content, cached.Content...)
+	}
+	result.Meta = h.resultMeta(ctx, found, age, 0)
+	return result
+}
+
+// resultMeta 生成调用结果的 _meta：
+//   - cached：所有数据都来自缓存（包括降级数据）
+//   - data_age_ms：数据中最旧一份的已缓存时长，实时采集时为 0
+//   - collection_duration_ms：本次调用的执行耗时
+//   - tool_version：服务器版本
+//   - trace_id：请求的追踪 ID（有时才包含）
+func (h *MCPHandler) resultMeta(ctx context.Context, cached bool, dataAge, duration time.Duration) map[string]interface{} {
+	meta := map[string]interface{}{
+		"cached":                 cached,
+		"data_age_ms":            dataAge.Milliseconds(),
+		"collection_duration_ms": duration.Milliseconds(),
+		"tool_version":           h.serverVersion,
+	}
+	if traceID := trace.FromContext(ctx); traceID != "" {
+		meta["trace_id"] = traceID
+	}
+	return meta
+}
+
+// decodeCallToolParams 解析 tools/call 的 params：缺少或为 null 的 arguments 视为空对象，
+// 缺少 params 或工具名、params 或 arguments 不是对象时返回说明原因的错误
+func decodeCallToolParams(raw interface{}) (types.CallToolParams, error) {
+	var params types.CallToolParams
+	if raw == nil {
+		return params, fmt.Errorf("tools/call requires params with a tool name")
+	}
+
+	paramBytes, err := json.Marshal(raw)
+	if err != nil {
+		return params, err
+	}
+	var shape struct {
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(paramBytes, &shape); err != nil {
+		return params, fmt.Errorf("params must be an object, got %s", jsonKind(paramBytes))
+	}
+	if kind := jsonKind(shape.Arguments); kind != "object" && kind != "null" {
+		return params, fmt.Errorf("arguments must be an object, got %s", kind)
+	}
+
+	if err := json.Unmarshal(paramBytes, &params); err != nil {
+		return params, err
+	}
+	if params.Name == "" {
+		return params, fmt.Errorf("missing tool name")
+	}
+	if params.Arguments == nil {
+		params.Arguments = map[string]interface{}{}
+	}
+	return params, nil
+}
+
+// jsonKind 紧凑编码的 JSON 值的类型名，空值视为 null
+func jsonKind(value json.RawMessage) string {
+	if len(value) == 0 {
+		return "null"
+	}
+	switch value[0] {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	default:
+		return "number"
+	}
+}
+
+// executeTool 按工具声明的参数模式校验并规范化参数后执行工具，校验失败返回 ErrBadArgument
+func executeTool(ctx context.Context, tool types.MonitorTool, args map[string]interface{}) (string, interface{}, error) {
+	arguments, err := tools.ValidateArguments(tool.GetInputSchema(), args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if structuredTool, ok := tool.(types.StructuredTool); ok {
+		return structuredTool.ExecuteStructured(ctx, arguments)
+	}
+	result, err := tool.Execute(ctx, arguments)
+	return result, nil, err
+}
+
+// executeWithPreset 展开 preset 参数后执行工具。diff_previous 为 true 时在文本结果之后附加与上一次
+// 同样参数的结果相比的变化，未设置比较基准存储时忽略该参数；指定了 fields 时只返回 JSON 结果中的这些字段
+func (h *MCPHandler) executeWithPreset(ctx context.Context, tool types.MonitorTool, args map[string]interface{}) (string, interface{}, error) {
+	args, err := h.applyPreset(tool.GetName(), args)
+	if err != nil {
+		return "", nil, err
+	}
+	args, diff, err := tools.TakeDiffArgument(tool.GetInputSchema(), args)
+	if err != nil {
+		return "", nil, err
+	}
+	args, fields, err := tools.TakeFieldsArgument(tool.GetInputSchema(), args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	text, structured, err := executeTool(ctx, tool, args)
+	if err == nil && fields != nil {
+		text, structured, err = tools.ProjectResult(text, structured, fields)
+	}
+	if err != nil || !diff || h.diffs == nil {
+		return text, structured, err
+	}
+	return text + h.diffs.Compare(tool.GetName(), args, structured), structured, nil
+}
+
+// CallTool 供其他工具（如 multi_query）在服务器内部调用工具：同样执行策略检查、限流、预设展开和参数校验，
+// 但不经过中间件、不发送进度通知，超时由调用方的 ctx 控制。工具不存在或被禁用时返回 ErrNotFound，
+// 超出限流时返回 ErrRateLimited，与直接调用共用令牌桶，不能借助 multi_query 等绕过限流
+func (h *MCPHandler) CallTool(ctx context.Context, name string, args map[string]interface{}) (string, interface{}, error) {
+	tool, exists := h.lookupTool(name)
+	if !exists || !h.currentPolicy().Allows(tool) {
+		return "", nil, tools.ToolNotFound(name, h.AllowedTools())
+	}
+	if h.limiter != nil {
+		if allowed, retryAfter := h.limiter.allow(name); !allowed {
+			return "", nil, tools.RateLimitedError(name, retryAfter)
+		}
+	}
+	return h.executeWithPreset(ctx, tool, args)
+}
+
+// toolErrorResult 将工具错误转换为调用结果：文本中包含错误代码和提示，
+// structuredContent 中提供同样的字段供解析 JSON 的客户端使用，两者都包含请求的追踪 ID
+func toolErrorResult(ctx context.Context, toolErr *tools.Error) types.CallToolResult {
+	traceID := trace.FromContext(ctx)
+	text := fmt.Sprintf("❌ %s\n错误代码: %s", toolErr.Error(), toolErr.Code)
+	if toolErr.Hint != "" {
+		text += "\n💡 " + toolErr.Hint
+	}
+	if traceID != "" {
+		text += "\n🔎 追踪 ID: " + traceID
+	}
+
+	return types.CallToolResult{
+		Content: []types.Content{
+			{Type: "text", Text: tools.SanitizeText(text)},
+		},
+		StructuredContent: map[string]interface{}{
+			"error": types.ToolError{
+				Code:     string(toolErr.Code),
+				Message:  toolErr.Error(),
+				Hint:     toolErr.Hint,
+				Argument: toolErr.Argument,
+				TraceID:  traceID,
 			},
 		},
+		IsError: true,
+	}
+}
+
+// maxCompletionValues 单次补全最多返回的候选数量
+const maxCompletionValues = 100
+
+// handleComplete 处理参数补全请求。
+// 支持工具参数（ref/tool）和资源模板参数（ref/resource，uri 为模板），未知的引用或参数返回空列表而不是错误。
+func (h *MCPHandler) handleComplete(ctx context.Context, req *types.JSONRPCRequest) *types.JSONRPCResponse {
+	var params types.CompleteParams
+	if req.Params != nil {
+		paramBytes, err := json.Marshal(req.Params)
+		if err != nil {
+			return h.errorResponse(req, -32602, "Invalid params: "+err.Error())
+		}
+		if err := json.Unmarshal(paramBytes, &params); err != nil {
+			return h.errorResponse(req, -32602, "Invalid params: "+err.Error())
+		}
+	}
+
+	values := []string{}
+	var completer types.Completer
+	switch params.Ref.Type {
+	case types.RefTypeTool:
+		tool, exists := h.lookupTool(params.Ref.Name)
+		if exists && h.currentPolicy().Allows(tool) {
+			completer, _ = tool.(types.Completer)
+		}
+	case types.RefTypeResource:
+		for _, template := range h.templates {
+			if template.GetResourceTemplate().URITemplate == params.Ref.URI {
+				completer, _ = template.(types.Completer)
+				break
+			}
+		}
+	}
+	if completer != nil {
+		if matched := completer.Complete(ctx, params.Argument.Name, params.Argument.Value); matched != nil {
+			values = matched
+		}
+	}
+
+	result := types.CompleteResult{
+		Completion: types.CompletionValues{
+			Values: values,
+			Total:  len(values),
+		},
+	}
+	if len(values) > maxCompletionValues {
+		result.Completion.Values = values[:maxCompletionValues]
+		result.Completion.HasMore = true
+	}
+
+	return &types.JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  result,
 	}
 }
 
@@ -184,8 +827,23 @@ func (h *MCPHandler) handleListPrompts(req *types.JSONRPCRequest) *types.JSONRPC
 func (h *MCPHandler) handleListResources(req *types.JSONRPCRequest) *types.JSONRPCResponse {
 	// 列出资源，但不输出日志避免干扰 JSON-RPC
 
+	uris := make([]string, 0, len(h.resources))
+	for uri := range h.resources {
+		uris = append(uris, uri)
+	}
+	slices.Sort(uris)
+
+	resources := []types.Resource{}
+	for _, uri := range uris {
+		resources = append(resources, h.resources[uri].GetResource())
+	}
+	// 被截断的工具结果的完整内容，保留期内可读取
+	if h.results != nil {
+		resources = append(resources, h.results.Resources()...)
+	}
+
 	result := map[string]interface{}{
-		"resources": []interface{}{},
+		"resources": resources,
 	}
 
 	return &types.JSONRPCResponse{
@@ -195,9 +853,142 @@ func (h *MCPHandler) handleListResources(req *types.JSONRPCRequest) *types.JSONR
 	}
 }
 
+// handleListResourceTemplates 处理资源模板列表请求
+func (h *MCPHandler) handleListResourceTemplates(req *types.JSONRPCRequest) *types.JSONRPCResponse {
+	templates := make([]types.ResourceTemplate, 0, len(h.templates))
+	for _, template := range h.templates {
+		templates = append(templates, template.GetResourceTemplate())
+	}
+
+	return &types.JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result: map[string]interface{}{
+			"resourceTemplates": templates,
+		},
+	}
+}
+
+// resourceURI 解析请求参数中的资源 URI，资源不存在时返回错误响应
+func (h *MCPHandler) resourceURI(req *types.JSONRPCRequest) (types.MonitorResource, *types.JSONRPCResponse) {
+	var params types.ReadResourceParams
+	if req.Params != nil {
+		paramBytes, err := json.Marshal(req.Params)
+		if err != nil {
+			return nil, h.errorResponse(req, -32602, "Invalid params: "+err.Error())
+		}
+		if err := json.Unmarshal(paramBytes, &params); err != nil {
+			return nil, h.errorResponse(req, -32602, "Invalid params: "+err.Error())
+		}
+	}
+
+	if resource, exists := h.resources[params.URI]; exists {
+		return resource, nil
+	}
+	if h.results != nil {
+		if resource, ok := h.results.Resource(params.URI); ok {
+			return resource, nil
+		}
+	}
+	for _, template := range h.templates {
+		resource, err := template.Resolve(params.URI)
+		if err != nil {
+			return nil, h.errorResponse(req, -32602, fmt.Sprintf("Unknown resource: %s (%v)", params.URI, err))
+		}
+		if resource != nil {
+			return resource, nil
+		}
+	}
+	return nil, h.errorResponse(req, -32602, "Unknown resource: "+params.URI)
+}
+
 // handleReadResource 处理资源读取请求
-func (h *MCPHandler) handleReadResource(req *types.JSONRPCRequest) *types.JSONRPCResponse {
-	return h.errorResponse(req, -32601, "Resource reading not implemented")
+func (h *MCPHandler) handleReadResource(ctx context.Context, req *types.JSONRPCRequest) *types.JSONRPCResponse {
+	resource, errResp := h.resourceURI(req)
+	if errResp != nil {
+		return errResp
+	}
+
+	if multi, ok := resource.(types.MultiContentResource); ok {
+		contents, err := multi.ReadContents(ctx)
+		if err != nil {
+			return h.errorResponse(req, -32603, "Failed to read resource: "+err.Error())
+		}
+		return &types.JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Result:  types.ReadResourceResult{Contents: contents},
+		}
+	}
+
+	text, err := resource.Read(ctx)
+	if err != nil {
+		return h.errorResponse(req, -32603, "Failed to read resource: "+err.Error())
+	}
+
+	info := resource.GetResource()
+	return &types.JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result: types.ReadResourceResult{
+			Contents: []types.ResourceContents{
+				{URI: info.URI, MimeType: info.MimeType, Text: text},
+			},
+		},
+	}
+}
+
+// handleSubscribe 处理资源订阅和取消订阅，订阅记录保存在会话中
+func (h *MCPHandler) handleSubscribe(session *Session, req *types.JSONRPCRequest, subscribe bool) *types.JSONRPCResponse {
+	if session == nil {
+		return h.errorResponse(req, -32600, "Resource subscriptions require a session")
+	}
+
+	resource, errResp := h.resourceURI(req)
+	if errResp != nil {
+		return errResp
+	}
+
+	uri := resource.GetResource().URI
+	if subscribe {
+		session.Subscribe(uri)
+	} else {
+		session.Unsubscribe(uri)
+	}
+
+	return &types.JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  map[string]interface{}{},
+	}
+}
+
+// handleSetLogLevel 处理 logging/setLevel，日志级别保存在会话中
+func (h *MCPHandler) handleSetLogLevel(session *Session, req *types.JSONRPCRequest) *types.JSONRPCResponse {
+	if session == nil {
+		return h.errorResponse(req, -32600, "Logging requires a session")
+	}
+
+	var params types.SetLevelParams
+	if req.Params != nil {
+		paramBytes, err := json.Marshal(req.Params)
+		if err != nil {
+			return h.errorResponse(req, -32602, "Invalid params: "+err.Error())
+		}
+		if err := json.Unmarshal(paramBytes, &params); err != nil {
+			return h.errorResponse(req, -32602, "Invalid params: "+err.Error())
+		}
+	}
+
+	if !session.SetLogLevel(params.Level) {
+		return h.errorResponse(req, -32602, "Invalid log level: "+params.Level)
+	}
+
+	return &types.JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  map[string]interface{}{},
+	}
 }
 
 // errorResponse 创建错误响应
@@ -214,12 +1005,18 @@ func (h *MCPHandler) errorResponse(req *types.JSONRPCRequest, code int, message
 	}
 }
 
-// GetRegisteredTools 获取已注册的工具列表
+// GetRegisteredTools 获取已注册的工具列表（按名称排序）
 func (h *MCPHandler) GetRegisteredTools() []string {
+	return sortedToolNames(h.registeredTools())
+}
+
+// sortedToolNames 工具名称（已排序）
+func sortedToolNames(registered map[string]types.MonitorTool) []string {
 	var toolNames []string
-	for name := range h.tools {
+	for name := range registered {
 		toolNames = append(toolNames, name)
 	}
+	slices.Sort(toolNames)
 	return toolNames
 }
 
@@ -0,0 +1,134 @@
+package router
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// ErrCodeRateLimited 请求被限流时返回的错误码
+const ErrCodeRateLimited = -32005
+
+// HandlerFunc 处理单个 JSON-RPC 请求
+type HandlerFunc func(ctx context.Context, req *types.JSONRPCRequest) *types.JSONRPCResponse
+
+// Middleware 包装 HandlerFunc，可以观察、修改或直接拦截请求
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// toolName 提取 tools/call 请求中的工具名称
+func toolName(req *types.JSONRPCRequest) string {
+	if req.Method != types.MethodCallTool {
+		return ""
+	}
+	params, ok := req.Params.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	name, _ := params["name"].(string)
+	return name
+}
+
+// LoggingMiddleware 以 debug 级别记录每个请求的方法、工具名称、耗时和是否出错（日志中带有追踪 ID）
+func LoggingMiddleware(logger *slog.Logger) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, req *types.JSONRPCRequest) *types.JSONRPCResponse {
+			start := time.Now()
+			resp := next(ctx, req)
+
+			isError := false
+			if resp != nil {
+				if resp.Error != nil {
+					isError = true
+				} else if result, ok := resp.Result.(types.CallToolResult); ok {
+					isError = result.IsError
+				}
+			}
+
+			attrs := []any{"method", req.Method, "duration", time.Since(start), "error", isError}
+			if name := toolName(req); name != "" {
+				attrs = append(attrs, "tool", name)
+			}
+			logger.DebugContext(ctx, "处理请求", attrs...)
+
+			return resp
+		}
+	}
+}
+
+// RateLimit 令牌桶限流参数
+type RateLimit struct {
+	// Rate 每秒补充的令牌数
+	Rate float64
+	// Burst 桶容量，即允许的突发请求数
+	Burst int
+}
+
+// tokenBucket 令牌桶
+type tokenBucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+// allow 尝试取出一个令牌
+func (b *tokenBucket) allow(now time.Time) bool {
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.Rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// RateLimitMiddleware 按方法进行令牌桶限流，未配置的方法不受限制。
+// 超出限制的请求直接返回 -32005 错误，不再进入后续处理。
+func RateLimitMiddleware(limits map[string]RateLimit) Middleware {
+	return rateLimitMiddleware(limits, time.Now)
+}
+
+// rateLimitMiddleware RateLimitMiddleware 的实现，now 为当前时间，测试中可替换
+func rateLimitMiddleware(limits map[string]RateLimit, now func() time.Time) Middleware {
+	var mutex sync.Mutex
+	buckets := make(map[string]*tokenBucket)
+
+	allow := func(method string) bool {
+		limit, limited := limits[method]
+		if !limited {
+			return true
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		current := now()
+		bucket, exists := buckets[method]
+		if !exists {
+			bucket = &tokenBucket{limit: limit, tokens: float64(limit.Burst), last: current}
+			buckets[method] = bucket
+		}
+		return bucket.allow(current)
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, req *types.JSONRPCRequest) *types.JSONRPCResponse {
+			if !allow(req.Method) {
+				return &types.JSONRPCResponse{
+					JSONRPC: "2.0",
+					ID:      req.ID,
+					Error: &types.RPCError{
+						Code:    ErrCodeRateLimited,
+						Message: "rate limited: " + req.Method,
+					},
+				}
+			}
+			return next(ctx, req)
+		}
+	}
+}
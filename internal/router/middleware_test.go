@@ -0,0 +1,210 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// echoTool 返回参数 text 的测试工具，记录执行次数
+type echoTool struct {
+	name  string
+	calls atomic.Int32
+}
+
+func (et *echoTool) GetName() string        { return et.name }
+func (et *echoTool) GetDescription() string { return "回显 text 参数" }
+func (et *echoTool) GetInputSchema() types.InputSchema {
+	return types.InputSchema{
+		Type:       "object",
+		Properties: map[string]types.Property{"text": {Type: "string", Description: "回显的文本"}},
+	}
+}
+func (et *echoTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	et.calls.Add(1)
+	text, _ := args["text"].(string)
+	return "echo: " + text, nil
+}
+
+// newTestHandler 注册了 echo 工具的处理器
+func newTestHandler() (*MCPHandler, *echoTool) {
+	handler := NewMCPHandler("test-server", "0.0.0")
+	tool := &echoTool{name: "echo"}
+	handler.RegisterTool(tool)
+	return handler, tool
+}
+
+// callRequest 调用工具的 tools/call 请求
+func callRequest(id int, tool string, args map[string]interface{}) *types.JSONRPCRequest {
+	return &types.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      id,
+		Method:  types.MethodCallTool,
+		Params:  map[string]interface{}{"name": tool, "arguments": args},
+	}
+}
+
+// responseJSON 响应的 JSON 编码
+func responseJSON(t *testing.T, resp *types.JSONRPCResponse) string {
+	t.Helper()
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// resultText 工具结果的文本内容
+func resultText(t *testing.T, resp *types.JSONRPCResponse) string {
+	t.Helper()
+	if resp == nil || resp.Error != nil {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	result, ok := resp.Result.(types.CallToolResult)
+	if !ok || len(result.Content) == 0 {
+		t.Fatalf("unexpected result: %+v", resp.Result)
+	}
+	return result.Content[0].Text
+}
+
+// recordingMiddleware 在调用下一层前后记录 name
+func recordingMiddleware(name string, events *[]string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, req *types.JSONRPCRequest) *types.JSONRPCResponse {
+			*events = append(*events, name+">")
+			resp := next(ctx, req)
+			*events = append(*events, "<"+name)
+			return resp
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	handler, tool := newTestHandler()
+	var events []string
+	handler.Use(recordingMiddleware("outer", &events))
+	handler.Use(recordingMiddleware("inner", &events))
+
+	resp := handler.HandleRequest(context.Background(), nil, callRequest(1, "echo", map[string]interface{}{"text": "hi"}))
+	if text := resultText(t, resp); text != "echo: hi" {
+		t.Fatalf("result = %q", text)
+	}
+	if got := strings.Join(events, " "); got != "outer> inner> <inner <outer" {
+		t.Fatalf("middleware order = %s, want registration order from the outside in", got)
+	}
+	if tool.calls.Load() != 1 {
+		t.Fatalf("tool executed %d times, want 1", tool.calls.Load())
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	handler, tool := newTestHandler()
+	var events []string
+	handler.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, req *types.JSONRPCRequest) *types.JSONRPCResponse {
+			return &types.JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: &types.RPCError{Code: -32001, Message: "vetoed"}}
+		}
+	})
+	handler.Use(recordingMiddleware("inner", &events))
+
+	resp := handler.HandleRequest(context.Background(), nil, callRequest(7, "echo", nil))
+	if resp.Error == nil || resp.Error.Code != -32001 || resp.ID != 7 {
+		t.Fatalf("response = %+v, want the veto", resp)
+	}
+	if len(events) != 0 || tool.calls.Load() != 0 {
+		t.Fatalf("later middlewares (%v) or the tool (%d calls) ran after a short circuit", events, tool.calls.Load())
+	}
+	// 被拦截的工具调用同样计入调用记录
+	if calls := handler.RecentCalls(); len(calls) != 1 || calls[0].Tool != "echo" {
+		t.Fatalf("RecentCalls() = %+v", calls)
+	}
+}
+
+func TestPassThroughMiddlewareKeepsResponse(t *testing.T) {
+	request := func() *types.JSONRPCRequest {
+		return &types.JSONRPCRequest{JSONRPC: "2.0", ID: 3, Method: types.MethodListTools}
+	}
+	plain, _ := newTestHandler()
+	wrapped, _ := newTestHandler()
+	wrapped.Use(func(next HandlerFunc) HandlerFunc { return next })
+
+	want := responseJSON(t, plain.HandleRequest(context.Background(), nil, request()))
+	if got := responseJSON(t, wrapped.HandleRequest(context.Background(), nil, request())); got != want {
+		t.Fatalf("response changed by a pass-through middleware:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	handler, tool := newTestHandler()
+	handler.Use(rateLimitMiddleware(map[string]RateLimit{types.MethodCallTool: {Rate: 1, Burst: 2}}, func() time.Time { return now }))
+
+	call := func(id int) *types.JSONRPCResponse {
+		return handler.HandleRequest(context.Background(), nil, callRequest(id, "echo", nil))
+	}
+	for id := 1; id <= 2; id++ {
+		if resp := call(id); resp.Error != nil {
+			t.Fatalf("call %d within the burst was limited: %+v", id, resp.Error)
+		}
+	}
+	resp := call(3)
+	if resp.Error == nil || resp.Error.Code != ErrCodeRateLimited || resp.ID != 3 {
+		t.Fatalf("third call = %+v, want -32005", resp)
+	}
+	if tool.calls.Load() != 2 {
+		t.Fatalf("tool executed %d times, want the limited call rejected before the tool", tool.calls.Load())
+	}
+
+	// 未配置限流的方法不受影响
+	if resp := handler.HandleRequest(context.Background(), nil, &types.JSONRPCRequest{JSONRPC: "2.0", ID: 4, Method: types.MethodListTools}); resp.Error != nil {
+		t.Fatalf("tools/list was limited: %+v", resp.Error)
+	}
+
+	// 每秒补充一个令牌
+	now = now.Add(time.Second)
+	if resp := call(5); resp.Error != nil {
+		t.Fatalf("call after refill was limited: %+v", resp.Error)
+	}
+	if resp := call(6); resp.Error == nil {
+		t.Fatal("only one token should be refilled per second")
+	}
+	// 令牌不会超过桶容量
+	now = now.Add(time.Hour)
+	for id := 7; id <= 8; id++ {
+		if resp := call(id); resp.Error != nil {
+			t.Fatalf("call %d after a long idle period was limited", id)
+		}
+	}
+	if resp := call(9); resp.Error == nil {
+		t.Fatal("tokens should be capped at the burst size")
+	}
+}
+
+func TestListToolsSortedByName(t *testing.T) {
+	handler := NewMCPHandler("test-server", "0.0.0")
+	for _, name := range []string{"zeta", "alpha", "mid", "beta", "omega"} {
+		handler.RegisterTool(&echoTool{name: name})
+	}
+	request := &types.JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: types.MethodListTools}
+
+	first := responseJSON(t, handler.HandleRequest(context.Background(), nil, request))
+	for i := 0; i < 20; i++ {
+		if again := responseJSON(t, handler.HandleRequest(context.Background(), nil, request)); again != first {
+			t.Fatalf("tools/list differs between identical calls:\n%s\n%s", first, again)
+		}
+	}
+
+	resp := handler.HandleRequest(context.Background(), nil, request)
+	var names []string
+	for _, tool := range resp.Result.(map[string]interface{})["tools"].([]types.Tool) {
+		names = append(names, tool.Name)
+	}
+	if got := strings.Join(names, ","); got != "alpha,beta,mid,omega,zeta" {
+		t.Fatalf("tools/list order = %s, want sorted by name", got)
+	}
+}
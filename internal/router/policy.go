@@ -0,0 +1,62 @@
+package router
+
+import (
+	"sort"
+
+	"mcp-example/internal/types"
+)
+
+// ErrCodeToolDisabled 工具被服务器策略禁用时返回的错误码
+const ErrCodeToolDisabled = -32006
+
+// Policy 工具访问策略，在 handler 中强制执行，与客户端请求无关
+type Policy struct {
+	// AllowTools 允许的工具列表，为空表示允许所有工具
+	AllowTools []string
+	// DenyTools 禁止的工具列表，优先于 AllowTools
+	DenyTools []string
+	// ReadOnly 只读模式，禁止所有未声明 ReadOnlyHint 的工具
+	ReadOnly bool
+}
+
+// Allows 判断策略是否允许调用指定工具
+func (p Policy) Allows(tool types.MonitorTool) bool {
+	name := tool.GetName()
+
+	for _, denied := range p.DenyTools {
+		if denied == name {
+			return false
+		}
+	}
+
+	if len(p.AllowTools) > 0 {
+		allowed := false
+		for _, candidate := range p.AllowTools {
+			if candidate == name {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+
+	if p.ReadOnly && !toolAnnotations(tool).ReadOnlyHint {
+		return false
+	}
+
+	return true
+}
+
+// allowedTools 策略下可用的工具名称（已排序）
+func (p Policy) allowedTools(tools map[string]types.MonitorTool) []string {
+	var names []string
+	for name, tool := range tools {
+		if p.Allows(tool) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
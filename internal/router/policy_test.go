@@ -0,0 +1,122 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"mcp-example/internal/tools"
+	"mcp-example/internal/types"
+)
+
+// writingTool 声明会修改系统状态的工具
+type writingTool struct{ echoTool }
+
+func (wt *writingTool) GetAnnotations() types.ToolAnnotations {
+	return types.ToolAnnotations{Title: "写入", DestructiveHint: true}
+}
+
+// newPolicyHandler 注册了只读的 echo、cpu_info 和会修改状态的 kill 工具的处理器
+func newPolicyHandler() *MCPHandler {
+	handler, _ := newTestHandler()
+	handler.RegisterTool(&echoTool{name: "cpu_info"})
+	handler.RegisterTool(&writingTool{echoTool{name: "kill"}})
+	return handler
+}
+
+// handlerToolNames 处理器 tools/list 响应中的工具名称
+func handlerToolNames(t *testing.T, handler *MCPHandler) []string {
+	t.Helper()
+	resp := handler.HandleRequest(context.Background(), nil, rpc(1, types.MethodListTools, nil))
+	if resp.Error != nil {
+		t.Fatalf("tools/list: %+v", resp.Error)
+	}
+	var names []string
+	for _, tool := range resp.Result.(map[string]interface{})["tools"].([]types.Tool) {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+func TestPolicyFiltersToolsList(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy Policy
+		want   []string
+	}{
+		{"no policy", Policy{}, []string{"cpu_info", "echo", "kill"}},
+		{"allow list", Policy{AllowTools: []string{"echo", "kill", "missing"}}, []string{"echo", "kill"}},
+		{"deny wins over allow", Policy{AllowTools: []string{"echo", "kill"}, DenyTools: []string{"kill"}}, []string{"echo"}},
+		{"read only", Policy{ReadOnly: true}, []string{"cpu_info", "echo"}},
+		{"read only and deny", Policy{ReadOnly: true, DenyTools: []string{"cpu_info"}}, []string{"echo"}},
+	}
+	for _, c := range cases {
+		handler := newPolicyHandler()
+		handler.SetPolicy(c.policy)
+		if got := handlerToolNames(t, handler); !slices.Equal(got, c.want) {
+			t.Errorf("%s: tools/list = %v, want %v", c.name, got, c.want)
+		}
+		if got := handler.AllowedTools(); !slices.Equal(got, c.want) {
+			t.Errorf("%s: AllowedTools() = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestPolicyBlocksCalls(t *testing.T) {
+	handler := newPolicyHandler()
+	handler.SetPolicy(Policy{ReadOnly: true, DenyTools: []string{"cpu_info"}})
+
+	for _, name := range []string{"kill", "cpu_info"} {
+		resp := handler.HandleRequest(context.Background(), nil, callRequest(1, name, nil))
+		if resp.Error == nil || resp.Error.Code != ErrCodeToolDisabled {
+			t.Errorf("calling %s = %s, want error %d", name, responseJSON(t, resp), ErrCodeToolDisabled)
+		}
+	}
+	// 未注册的工具与被禁用的工具错误码不同
+	if resp := handler.HandleRequest(context.Background(), nil, callRequest(2, "missing", nil)); resp.Error != nil && resp.Error.Code == ErrCodeToolDisabled {
+		t.Errorf("unknown tool reported as disabled: %s", responseJSON(t, resp))
+	}
+	if got := resultText(t, handler.HandleRequest(context.Background(), nil, callRequest(3, "echo", map[string]interface{}{"text": "hi"}))); got != "echo: hi" {
+		t.Errorf("allowed tool returned %q", got)
+	}
+
+	// 服务器内部调用（multi_query 等）同样受策略限制，不会借此绕过
+	_, _, err := handler.CallTool(context.Background(), "kill", nil)
+	var toolErr *tools.Error
+	if !errors.As(err, &toolErr) || toolErr.Code != tools.ErrNotFound {
+		t.Errorf("CallTool(kill) = %v, want ErrNotFound", err)
+	}
+	if _, found := handler.DescribeTool("kill"); found {
+		t.Error("DescribeTool describes a disabled tool")
+	}
+}
+
+func TestPolicyReloadNotifiesClients(t *testing.T) {
+	r := startTestRouter(t, nil)
+	r.RegisterTool(&writingTool{echoTool{name: "kill"}})
+	client := connectClient(t, r)
+	client.setup(t, "")
+	// ping 的响应说明 initialized 通知已经处理，会话可以接收通知
+	client.expectQuiet(t)
+
+	r.SetPolicy(Policy{ReadOnly: true})
+	if message := client.next(t); message["method"] != types.MethodToolsListChanged {
+		t.Fatalf("got %v after enabling read-only mode, want tools/list_changed", message)
+	}
+	if names := listedTools(t, client.call(t, rpc(2, types.MethodListTools, nil))); !slices.Equal(names, []string{"echo"}) {
+		t.Fatalf("tools/list = %v, want only the read-only echo tool", names)
+	}
+
+	// 可用工具集合不变的重新加载不通知
+	r.SetPolicy(Policy{ReadOnly: true, DenyTools: []string{"kill"}})
+	client.expectQuiet(t)
+
+	r.SetPolicy(Policy{})
+	if message := client.next(t); message["method"] != types.MethodToolsListChanged {
+		t.Fatalf("got %v after lifting the policy, want tools/list_changed", message)
+	}
+	if names := listedTools(t, client.call(t, rpc(3, types.MethodListTools, nil))); !slices.Equal(names, []string{"echo", "kill"}) {
+		t.Fatalf("tools/list = %v after lifting the policy", names)
+	}
+}
@@ -0,0 +1,154 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/tools"
+	"mcp-example/internal/types"
+)
+
+// argsTool 按参数名排序回显所有参数的工具，sort_by 为必需参数
+type argsTool struct{}
+
+func (at *argsTool) GetName() string        { return "top" }
+func (at *argsTool) GetDescription() string { return "回显参数" }
+func (at *argsTool) GetInputSchema() types.InputSchema {
+	return types.InputSchema{
+		Type: "object",
+		Properties: map[string]types.Property{
+			"sort_by":         {Type: "string", Enum: []string{"cpu", "memory"}},
+			"limit":           {Type: "integer"},
+			"include_cmdline": {Type: "boolean"},
+		},
+		Required: []string{"sort_by"},
+	}
+}
+func (at *argsTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var parts []string
+	for _, name := range []string{"include_cmdline", "limit", "sort_by"} {
+		if value, found := args[name]; found {
+			parts = append(parts, fmt.Sprintf("%s=%v", name, value))
+		}
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// newPresetHandler 注册了 echo、top 和 preset_admin 工具、预设保存在内存存储中的处理器
+func newPresetHandler() *MCPHandler {
+	handler, _ := newTestHandler()
+	handler.RegisterTool(&argsTool{})
+	presets := tools.NewPresetStore(storage.NewMemoryStorage())
+	handler.SetPresets(presets)
+	handler.RegisterTool(tools.NewPresetAdminTool(presets, handler.DescribeTool))
+	return handler
+}
+
+// callText tools/call 结果的文本，isError 表示工具返回的错误
+func callText(t *testing.T, handler *MCPHandler, tool string, args map[string]interface{}) (text string, isError bool) {
+	t.Helper()
+	resp := handler.HandleRequest(context.Background(), nil, callRequest(1, tool, args))
+	result, ok := resp.Result.(types.CallToolResult)
+	if resp.Error != nil || !ok || len(result.Content) == 0 {
+		t.Fatalf("tools/call %s = %s", tool, responseJSON(t, resp))
+	}
+	return result.Content[0].Text, result.IsError
+}
+
+// setPreset 通过 preset_admin 保存预设，失败时测试失败
+func setPreset(t *testing.T, handler *MCPHandler, name, tool string, arguments map[string]interface{}) {
+	t.Helper()
+	if text, isError := callText(t, handler, "preset_admin", map[string]interface{}{"action": "set", "name": name, "tool": tool, "arguments": arguments}); isError {
+		t.Fatalf("saving preset %s: %s", name, text)
+	}
+}
+
+func TestPresetMergePrecedence(t *testing.T) {
+	handler := newPresetHandler()
+	setPreset(t, handler, "cpu-top5", "top", map[string]interface{}{"sort_by": "cpu", "limit": 5, "include_cmdline": true})
+
+	// 预设参数为基础，显式参数覆盖同名的预设参数
+	if text, isError := callText(t, handler, "top", map[string]interface{}{"preset": "cpu-top5"}); isError || text != "include_cmdline=true limit=5 sort_by=cpu" {
+		t.Fatalf("preset only = %q", text)
+	}
+	if text, isError := callText(t, handler, "top", map[string]interface{}{"preset": "cpu-top5", "limit": 20, "sort_by": "memory"}); isError || text != "include_cmdline=true limit=20 sort_by=memory" {
+		t.Fatalf("preset with explicit arguments = %q", text)
+	}
+
+	// 合并后的参数仍按模式校验
+	if text, isError := callText(t, handler, "top", map[string]interface{}{"preset": "cpu-top5", "limit": "many"}); !isError || !strings.Contains(text, "ERR_BAD_ARGUMENT") {
+		t.Fatalf("invalid explicit argument = %q, want ERR_BAD_ARGUMENT", text)
+	}
+
+	// 预设可以只包含部分参数，必需参数在调用时传入
+	setPreset(t, handler, "brief", "top", map[string]interface{}{"limit": 3})
+	if text, isError := callText(t, handler, "top", map[string]interface{}{"preset": "brief"}); !isError || !strings.Contains(text, "sort_by") {
+		t.Fatalf("preset without a required argument = %q, want the missing sort_by", text)
+	}
+	if text, _ := callText(t, handler, "top", map[string]interface{}{"preset": "brief", "sort_by": "cpu"}); text != "limit=3 sort_by=cpu" {
+		t.Fatalf("partial preset = %q", text)
+	}
+}
+
+func TestUnknownPreset(t *testing.T) {
+	handler := newPresetHandler()
+
+	text, isError := callText(t, handler, "top", map[string]interface{}{"preset": "missing", "sort_by": "cpu"})
+	if !isError || !strings.Contains(text, "ERR_NOT_FOUND") || !strings.Contains(text, "尚未保存任何预设") {
+		t.Fatalf("unknown preset without presets = %q", text)
+	}
+
+	setPreset(t, handler, "cpu-top5", "top", map[string]interface{}{"sort_by": "cpu"})
+	setPreset(t, handler, "greeting", "echo", map[string]interface{}{"text": "hi"})
+	text, isError = callText(t, handler, "top", map[string]interface{}{"preset": "missing"})
+	if !isError || !strings.Contains(text, "参数预设不存在: missing") || !strings.Contains(text, "可用的预设: cpu-top5, greeting") {
+		t.Fatalf("unknown preset = %q, want the available presets listed", text)
+	}
+
+	// 其他工具的预设和非字符串的预设名都是参数错误
+	if text, isError := callText(t, handler, "top", map[string]interface{}{"preset": "greeting"}); !isError || !strings.Contains(text, "属于工具 echo，不能用于 top") {
+		t.Fatalf("another tool's preset = %q", text)
+	}
+	if text, isError := callText(t, handler, "top", map[string]interface{}{"preset": 5}); !isError || !strings.Contains(text, "ERR_BAD_ARGUMENT") {
+		t.Fatalf("non-string preset = %q", text)
+	}
+}
+
+func TestPresetValidatedOnSave(t *testing.T) {
+	handler := newPresetHandler()
+
+	for _, c := range []struct {
+		name      string
+		tool      string
+		arguments map[string]interface{}
+		want      string
+	}{
+		{"unknown tool", "missing", map[string]interface{}{}, "工具不存在或已被禁用: missing"},
+		{"enum", "top", map[string]interface{}{"sort_by": "disk"}, "预设参数不符合 top 的参数模式"},
+		{"type", "top", map[string]interface{}{"limit": "many"}, "预设参数不符合 top 的参数模式"},
+		{"nested preset", "top", map[string]interface{}{"preset": "other"}, "预设参数中不能再包含 preset"},
+	} {
+		text, isError := callText(t, handler, "preset_admin", map[string]interface{}{"action": "set", "name": "bad", "tool": c.tool, "arguments": c.arguments})
+		if !isError || !strings.Contains(text, c.want) {
+			t.Errorf("%s: set = %q, want %q", c.name, text, c.want)
+		}
+	}
+	if text, _ := callText(t, handler, "preset_admin", map[string]interface{}{"action": "list"}); !strings.Contains(text, "暂无预设") {
+		t.Fatalf("invalid presets were saved:\n%s", text)
+	}
+
+	// 保存时参数已规范化，列出和删除
+	setPreset(t, handler, "cpu-top5", "top", map[string]interface{}{"sort_by": "cpu", "limit": 5})
+	if text, _ := callText(t, handler, "preset_admin", map[string]interface{}{"action": "list"}); !strings.Contains(text, "• cpu-top5 → top limit=5 sort_by=cpu") || strings.Contains(text, "已失效") {
+		t.Fatalf("list =\n%s", text)
+	}
+	if text, isError := callText(t, handler, "preset_admin", map[string]interface{}{"action": "delete", "name": "cpu-top5"}); isError {
+		t.Fatalf("delete = %q", text)
+	}
+	if text, isError := callText(t, handler, "preset_admin", map[string]interface{}{"action": "delete", "name": "cpu-top5"}); !isError || !strings.Contains(text, "ERR_NOT_FOUND") {
+		t.Fatalf("second delete = %q, want ERR_NOT_FOUND", text)
+	}
+}
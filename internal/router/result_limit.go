@@ -0,0 +1,77 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"mcp-example/internal/tools"
+	"mcp-example/internal/trace"
+	"mcp-example/internal/types"
+)
+
+// SetResultLimit 设置工具结果的大小上限（文本和结构化内容的字节数之和），0 表示不限制。
+// 超出上限的结果被截断，完整内容保存到 results 中，以 monitor://results/<追踪 ID> 资源提供
+func (h *MCPHandler) SetResultLimit(maxBytes int, results *tools.ResultStore) {
+	h.maxResultBytes = maxBytes
+	h.results = results
+}
+
+// limitResult 结果超出大小上限时保存完整内容，并返回截断后的文本（附带资源 URI 和读取说明），
+// 不再包含结构化内容。未超出上限时原样返回
+func (h *MCPHandler) limitResult(ctx context.Context, tool string, result types.CallToolResult) types.CallToolResult {
+	if h.maxResultBytes <= 0 || len(result.Content) == 0 {
+		return result
+	}
+
+	text := result.Content[0].Text
+	size := len(text)
+	if result.StructuredContent != nil {
+		if jsonData, err := json.Marshal(result.StructuredContent); err == nil {
+			size += len(jsonData)
+		}
+	}
+	if size <= h.maxResultBytes {
+		return result
+	}
+
+	// 客户端提供的追踪 ID 不一定能用作存储键，此时为结果生成新的 ID
+	id := trace.FromContext(ctx)
+	if !tools.ValidResultID(id) {
+		id = trace.NewID()
+	}
+
+	var uri string
+	err := fmt.Errorf("未配置结果存储")
+	if h.results != nil {
+		uri, err = h.results.Save(tools.StoredResult{
+			ID:         id,
+			Tool:       tool,
+			At:         time.Now(),
+			Text:       text,
+			Structured: result.StructuredContent,
+		})
+	}
+
+	truncated := tools.TruncateText(text, h.maxResultBytes)
+	note := fmt.Sprintf("\n✂️ 输出共 %d 字节，超过 %d 字节的上限，以上为截断后的内容\n", size, h.maxResultBytes)
+	if err != nil {
+		slog.WarnContext(ctx, "保存完整结果失败", "tool", tool, "error", err)
+		note += fmt.Sprintf("⚠️ 完整内容保存失败: %v\n", err)
+	} else {
+		note += fmt.Sprintf("📎 完整内容（包括结构化数据）已保存为资源 %s，可通过 resources/read 读取，保留 %s\n", uri, h.results.Retention())
+	}
+
+	result.Content = append([]types.Content{{Type: "text", Text: truncated + note}}, result.Content[1:]...)
+	result.StructuredContent = nil
+	if result.Meta != nil {
+		result.Meta["truncated"] = true
+		result.Meta["full_size_bytes"] = size
+		if err == nil {
+			result.Meta["full_result_uri"] = uri
+		}
+	}
+	return result
+}
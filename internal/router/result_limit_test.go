@@ -0,0 +1,143 @@
+package router
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/tools"
+	"mcp-example/internal/types"
+)
+
+// structuredEchoTool 同时返回文本和结构化结果的 echo 工具
+type structuredEchoTool struct{ echoTool }
+
+func (st *structuredEchoTool) ExecuteStructured(ctx context.Context, args map[string]interface{}) (string, interface{}, error) {
+	text, err := st.Execute(ctx, args)
+	return text, map[string]interface{}{"text": args["text"]}, err
+}
+
+// newResultLimitHandler 结果上限为 limit 字节、完整结果保存在内存存储中的处理器
+func newResultLimitHandler(limit int) (*MCPHandler, *tools.ResultStore, *storage.MemoryStorage) {
+	handler, _ := newTestHandler()
+	handler.RegisterTool(&structuredEchoTool{echoTool{name: "structured_echo"}})
+	dataStorage := storage.NewMemoryStorage()
+	results := tools.NewResultStore(dataStorage, time.Hour)
+	handler.SetResultLimit(limit, results)
+	return handler, results, dataStorage
+}
+
+// callResult tools/call 的结果
+func callResult(t *testing.T, handler *MCPHandler, id int, tool, text string) types.CallToolResult {
+	t.Helper()
+	resp := handler.HandleRequest(context.Background(), nil, callRequest(id, tool, map[string]interface{}{"text": text}))
+	result, ok := resp.Result.(types.CallToolResult)
+	if resp.Error != nil || !ok || result.IsError {
+		t.Fatalf("tools/call %s = %s", tool, responseJSON(t, resp))
+	}
+	return result
+}
+
+// readResource resources/read 的响应
+func readResource(handler *MCPHandler, uri string) *types.JSONRPCResponse {
+	return handler.HandleRequest(context.Background(), nil, rpc(9, types.MethodReadResource, map[string]interface{}{"uri": uri}))
+}
+
+func TestResultLimitTruncatesAtCap(t *testing.T) {
+	handler, results, _ := newResultLimitHandler(100)
+
+	// "echo: " 加 94 字节正好等于上限，不截断
+	exact := strings.Repeat("a", 94)
+	if result := callResult(t, handler, 1, "echo", exact); result.Content[0].Text != "echo: "+exact || result.Meta["truncated"] != nil {
+		t.Fatalf("result at the cap = %+v, want it unchanged", result)
+	}
+	if stored, _ := results.List(); len(stored) != 0 {
+		t.Fatalf("stored %+v for a result within the cap", stored)
+	}
+
+	// 超出上限时按行截断，截断后的内容不超过上限
+	lines := strings.Repeat("0123456789\n", 9) + "tail"
+	result := callResult(t, handler, 2, "echo", lines)
+	text := result.Content[0].Text
+	body, note, found := strings.Cut(text, "\n✂️")
+	if !found || len(body) > 100 || !strings.HasSuffix(body, "0123456789\n") || strings.Contains(body, "tail") {
+		t.Fatalf("truncated text = %q, want whole lines within 100 bytes", text)
+	}
+	uri, _ := result.Meta["full_result_uri"].(string)
+	if !strings.HasPrefix(uri, tools.ResultURIPrefix) || result.Meta["truncated"] != true || result.Meta["full_size_bytes"] != len("echo: "+lines) {
+		t.Fatalf("_meta = %v, want truncated with the full size and URI", result.Meta)
+	}
+	if !strings.Contains(note, "输出共 109 字节，超过 100 字节的上限") || !strings.Contains(note, uri) || !strings.Contains(note, "保留 1h0m0s") {
+		t.Errorf("truncation note = %q", note)
+	}
+
+	// 结构化内容计入大小，截断后不再返回
+	result = callResult(t, handler, 3, "structured_echo", strings.Repeat("b", 60))
+	if result.StructuredContent != nil || result.Meta["truncated"] != true {
+		t.Fatalf("structured result = %+v, want it truncated without structured content", result)
+	}
+}
+
+func TestTruncatedResultReadBack(t *testing.T) {
+	handler, _, _ := newResultLimitHandler(64)
+	full := strings.Repeat("监控数据 ", 20)
+	result := callResult(t, handler, 1, "structured_echo", full)
+	uri := result.Meta["full_result_uri"].(string)
+
+	// 资源列表中列出，读取时返回完整文本和结构化 JSON
+	resp := handler.HandleRequest(context.Background(), nil, rpc(2, types.MethodListResources, nil))
+	listed := false
+	for _, resource := range resp.Result.(map[string]interface{})["resources"].([]types.Resource) {
+		listed = listed || resource.URI == uri && strings.HasPrefix(resource.Name, "structured_echo")
+	}
+	if !listed {
+		t.Fatalf("resources/list = %s, want %s", responseJSON(t, resp), uri)
+	}
+	resp = readResource(handler, uri)
+	contents, ok := resp.Result.(types.ReadResourceResult)
+	if resp.Error != nil || !ok || len(contents.Contents) != 2 {
+		t.Fatalf("resources/read = %s", responseJSON(t, resp))
+	}
+	if contents.Contents[0].MimeType != "text/plain" || contents.Contents[0].Text != "echo: "+full {
+		t.Errorf("text contents = %+v, want the full output", contents.Contents[0])
+	}
+	if contents.Contents[1].MimeType != "application/json" || !strings.Contains(contents.Contents[1].Text, `"text": "`+full+`"`) {
+		t.Errorf("JSON contents = %+v, want the structured result", contents.Contents[1])
+	}
+
+	// 结果 URI 中的非法 ID 视为未知资源
+	if resp := readResource(handler, tools.ResultURIPrefix+"../secrets"); resp.Error == nil || resp.Error.Code != -32602 {
+		t.Errorf("reading an invalid result ID = %s, want -32602", responseJSON(t, resp))
+	}
+}
+
+func TestTruncatedResultExpires(t *testing.T) {
+	handler, results, dataStorage := newResultLimitHandler(32)
+	result := callResult(t, handler, 1, "echo", strings.Repeat("x", 100))
+	uri := result.Meta["full_result_uri"].(string)
+	id := strings.TrimPrefix(uri, tools.ResultURIPrefix)
+
+	// 超过保留时长后不再列出和读取，保存新结果时清理
+	var stored tools.StoredResult
+	if err := dataStorage.Load("result_"+id, &stored); err != nil {
+		t.Fatal(err)
+	}
+	stored.At = stored.At.Add(-2 * time.Hour)
+	dataStorage.Save("result_"+id, stored)
+
+	if resp := readResource(handler, uri); resp.Error == nil || !strings.Contains(resp.Error.Message, "不存在或已过期") {
+		t.Fatalf("reading an expired result = %s, want not found", responseJSON(t, resp))
+	}
+	if listed, _ := results.List(); len(listed) != 0 {
+		t.Fatalf("expired results listed: %+v", listed)
+	}
+	callResult(t, handler, 2, "echo", strings.Repeat("y", 100))
+	if dataStorage.Exists("result_" + id) {
+		t.Error("expired result kept after saving a new one")
+	}
+	if listed, _ := results.List(); len(listed) != 1 || listed[0].ID == id {
+		t.Errorf("results = %+v, want only the new one", listed)
+	}
+}
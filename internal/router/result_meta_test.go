@@ -0,0 +1,80 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/trace"
+	"mcp-example/internal/types"
+)
+
+func TestCallToolResultMetaSerialization(t *testing.T) {
+	// 没有元信息时不输出 _meta
+	data, err := json.Marshal(types.CallToolResult{Content: []types.Content{{Type: "text", Text: "ok"}}})
+	if err != nil || strings.Contains(string(data), "_meta") {
+		t.Errorf("without meta: %s, %v", data, err)
+	}
+
+	data, err = json.Marshal(types.CallToolResult{Content: []types.Content{{Type: "text", Text: "ok"}}, Meta: map[string]interface{}{"cached": true, "data_age_ms": 1500}})
+	if err != nil || !strings.Contains(string(data), `"_meta":{"cached":true,"data_age_ms":1500}`) {
+		t.Errorf("with meta: %s, %v", data, err)
+	}
+}
+
+// metaKeys _meta 中始终存在的键
+var metaKeys = []string{"cached", "data_age_ms", "collection_duration_ms", "tool_version", "trace_id"}
+
+func TestCallResultMeta(t *testing.T) {
+	handler, _ := newTestHandler()
+	handler.RegisterTool(&failingTool{echoTool: echoTool{name: "broken"}, err: errors.New("磁盘不可读")})
+
+	// 不读取缓存的工具也带有全部键，文本内容不变
+	result := callResult(t, handler, 1, "echo", "hi")
+	for _, key := range metaKeys {
+		if _, found := result.Meta[key]; !found {
+			t.Errorf("_meta lacks %s: %v", key, result.Meta)
+		}
+	}
+	if result.Meta["cached"] != false || result.Meta["data_age_ms"] != int64(0) || result.Meta["tool_version"] != handler.serverVersion || !trace.ValidID(result.Meta["trace_id"].(string)) {
+		t.Errorf("_meta = %v", result.Meta)
+	}
+	if result.Content[0].Text != "echo: hi" {
+		t.Errorf("text = %q", result.Content[0].Text)
+	}
+
+	// 错误结果同样带有 _meta
+	resp := handler.HandleRequest(context.Background(), nil, callRequest(2, "broken", nil))
+	errorResult := resp.Result.(types.CallToolResult)
+	if !errorResult.IsError || errorResult.Meta["cached"] != false || errorResult.Meta["trace_id"] == nil {
+		t.Errorf("error result _meta = %v", errorResult.Meta)
+	}
+}
+
+func TestCallResultMetaFromCache(t *testing.T) {
+	r := newDefaultRouter(t)
+	call := func(id int, args map[string]interface{}) map[string]interface{} {
+		t.Helper()
+		resp := r.handler.HandleRequest(context.Background(), nil, callRequest(id, "memory_info", args))
+		result, ok := resp.Result.(types.CallToolResult)
+		if resp.Error != nil || !ok || result.IsError {
+			t.Fatalf("memory_info = %s", responseJSON(t, resp))
+		}
+		return result.Meta
+	}
+
+	meta := call(1, map[string]interface{}{"cache": "fresh"})
+	if meta["cached"] != false || meta["data_age_ms"] != int64(0) {
+		t.Errorf("fresh _meta = %v", meta)
+	}
+
+	// 命中缓存时标记 cached，并给出数据时长
+	time.Sleep(20 * time.Millisecond)
+	meta = call(2, map[string]interface{}{"cache": "auto"})
+	if age, _ := meta["data_age_ms"].(int64); meta["cached"] != true || age < 20 {
+		t.Errorf("cached _meta = %v", meta)
+	}
+}
@@ -1,148 +1,548 @@
 package router
 
 import (
-	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"mcp-example/internal/anomaly"
+	"mcp-example/internal/collector"
+	"mcp-example/internal/config"
+	"mcp-example/internal/scheduler"
 	"mcp-example/internal/tools"
 	"mcp-example/internal/types"
+	"mcp-example/internal/version"
 )
 
+// Options 路由器的可选配置
+type Options struct {
+	// ToolConfigs 按工具名称索引的工具配置
+	ToolConfigs map[string]config.ToolConfig
+	// CollectInterval 后台采集间隔，0 表示不启用后台采集
+	CollectInterval time.Duration
+	// HistoryRetention 采集历史的保留时长，0 表示不删除
+	HistoryRetention time.Duration
+	// ToolTimeout 单次工具调用的超时时间，0 表示不限制
+	ToolTimeout time.Duration
+	// EnableActions 是否注册会修改系统状态的操作工具（如 process_signal）
+	EnableActions bool
+	// EnableAdminTools 是否注册运维管理工具（如 cache_admin）
+	EnableAdminTools bool
+	// Prefetch 是否在启动时异步预取各工具的静态数据
+	Prefetch bool
+	// Thresholds 健康检查使用的阈值
+	Thresholds types.Thresholds
+	// OutputStyle 文本输出风格（是否显示使用率条及其宽度）
+	OutputStyle tools.OutputStyle
+	// AnomalySigmas 后台采集异常检测的标准差倍数，0 表示使用默认值
+	AnomalySigmas float64
+	// IncidentResolveAfter 指标恢复正常持续多久后自动解决告警事件，0 表示使用默认值
+	IncidentResolveAfter time.Duration
+	// FallbackMaxAge 实时采集失败时可返回的降级数据的最长有效期，0 表示不降级
+	FallbackMaxAge time.Duration
+	// MaxResultBytes 工具结果的大小上限，超出时截断并将完整内容保存为资源，0 表示不限制
+	MaxResultBytes int
+	// ResultRetention 被截断结果的完整内容的保留时长
+	ResultRetention time.Duration
+	// Transcript 记录收发的每一条消息（--record），nil 表示不记录
+	Transcript Transcript
+	// SkipDefaultTools 不注册内置的工具、资源和依赖它们的后台组件（后台采集、定时任务、参数预设），
+	// 只提供 Tools 和 RegisterTool 注册的工具，用于将路由器嵌入其他程序
+	SkipDefaultTools bool
+	// Tools 在内置工具之后注册的自定义工具，与内置工具同名时替换内置工具
+	Tools []types.MonitorTool
+}
+
+// Transcript 会话记录，需可在多个 goroutine 中调用（响应和通知可能来自不同 goroutine）
+type Transcript interface {
+	// Record 记录一条消息，direction 为 transcript.DirectionIn 或 transcript.DirectionOut
+	Record(direction, sessionID string, message []byte)
+}
+
 // Router MCP 路由器
 type Router struct {
-	handler *MCPHandler
-	storage types.DataStorage
-	cache   types.Cache
-	running bool
-	input   io.Reader
-	output  io.Writer
+	handler     *MCPHandler
+	storage     types.DataStorage
+	cache       types.AdminCache
+	options     Options
+	revalidator *tools.Revalidator
+	lastGood    *tools.LastGood
+	warmup      *tools.Warmup
+	liveChanges *tools.LiveChangesResource
+	results     *tools.ResultStore
+	healthTool  *tools.HealthReportTool
+	collector   *collector.Collector
+	scheduler   *scheduler.Scheduler
+	watches     *watchManager
+	// gauges 导出系统指标（--otel-endpoint）时读取数据的工具，InitializeTools 中设置
+	gauges *systemGauges
+	// toolsReady 工具已经初始化，InitializeTools 可以在 Start 之前单独调用
+	toolsReady bool
+	// pendingTools 工具初始化之前通过 RegisterTool 注册的工具，初始化时在内置工具之后注册
+	pendingTools []types.MonitorTool
+	// shutdown 已调用 Shutdown，shutdownHooks 为 OnShutdown 注册的步骤
+	shutdown      bool
+	shutdownHooks []shutdownStep
+	// reloadMutex 保护配置重新加载时访问的组件，信号处理可能早于工具初始化完成
+	reloadMutex sync.Mutex
+	ctx         context.Context
+	cancel      context.CancelFunc
+	running     atomic.Bool
+	// ready Start 启动后台组件、开始读取输入时关闭
+	ready     chan struct{}
+	startTime time.Time
+	input     io.Reader
+	output    io.Writer
+	// connections 按会话索引的所有连接（stdio 和 Serve 接受的连接），closed 为 true 后不再接受新连接
+	connMutex   sync.Mutex
+	connections map[*Session]*connection
+	closed      bool
+	// replay ProcessMessage 使用的连接，首次调用时创建
+	replay *connection
 }
 
 // NewRouter 创建新的路由器
-func NewRouter(serverName, serverVersion string, dataStorage types.DataStorage, cache types.Cache) *Router {
-	return &Router{
-		handler: NewMCPHandler(serverName, serverVersion),
-		storage: dataStorage,
-		cache:   cache,
-		input:   os.Stdin,
-		output:  os.Stdout,
+func NewRouter(serverName, serverVersion string, dataStorage types.DataStorage, cache types.AdminCache, options Options) *Router {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	handler := NewMCPHandler(serverName, serverVersion)
+	handler.SetToolTimeout(options.ToolTimeout)
+	handler.SetToolRateLimits(toolRateLimits(options.ToolConfigs))
+
+	router := &Router{
+		handler:     handler,
+		storage:     dataStorage,
+		cache:       cache,
+		options:     options,
+		revalidator: tools.NewRevalidator(ctx),
+		ctx:         ctx,
+		cancel:      cancel,
+		ready:       make(chan struct{}),
+		startTime:   time.Now(),
+		input:       os.Stdin,
+		output:      os.Stdout,
+		connections: make(map[*Session]*connection),
+	}
+	handler.SetNotifier(router.sendToRequester)
+
+	if options.FallbackMaxAge > 0 {
+		router.lastGood = tools.NewLastGood(dataStorage, options.FallbackMaxAge)
+	}
+	if options.MaxResultBytes > 0 {
+		router.results = tools.NewResultStore(dataStorage, options.ResultRetention)
+		handler.SetResultLimit(options.MaxResultBytes, router.results)
+	}
+
+	return router
+}
+
+// toolRateLimits 根据工具配置生成按工具的限流参数，未开启限流的工具不包含在内
+func toolRateLimits(configs map[string]config.ToolConfig) map[string]RateLimit {
+	limits := make(map[string]RateLimit)
+	for name, toolConfig := range configs {
+		if perMinute, burst := toolConfig.EffectiveRateLimit(); perMinute > 0 {
+			limits[name] = RateLimit{Rate: perMinute / 60, Burst: burst}
+		}
+	}
+	return limits
+}
+
+// cacheOptions 根据工具配置生成缓存选项
+func (r *Router) cacheOptions(toolName string) tools.CacheOptions {
+	return tools.CacheOptions{
+		StaleWindow: r.options.ToolConfigs[toolName].EffectiveStaleWindow(),
+		Revalidator: r.revalidator,
+		LastGood:    r.lastGood,
+		Name:        toolName,
+	}
+}
+
+// Use 注册请求中间件，需在 Start 之前调用
+func (r *Router) Use(middleware Middleware) {
+	r.handler.Use(middleware)
+}
+
+// SetPolicy 更新工具访问策略，可用工具集合变化、服务器运行中且客户端已完成握手时通知客户端
+func (r *Router) SetPolicy(policy Policy) {
+	if r.handler.SetPolicy(policy) {
+		r.notifyToolsListChanged()
+	}
+}
+
+// RegisterTool 注册自定义工具，已有同名工具（包括内置工具）时替换并记录警告。
+// 工具初始化之前调用时，工具在内置工具之后注册；服务器运行中调用且该工具未被访问策略禁用时，通知客户端工具列表已变化。
+// 替换内置工具只影响 tools/call 等请求，健康报告、后台采集等组件仍使用内置工具
+func (r *Router) RegisterTool(tool types.MonitorTool) {
+	r.reloadMutex.Lock()
+	if !r.toolsReady {
+		r.pendingTools = append(r.pendingTools, tool)
+		r.reloadMutex.Unlock()
+		return
+	}
+	r.reloadMutex.Unlock()
+
+	r.handler.RegisterTool(tool)
+	if r.handler.currentPolicy().Allows(tool) {
+		r.notifyToolsListChanged()
+	}
+}
+
+// notifyToolsListChanged 服务器运行中时向支持的会话发送 tools/list_changed 通知
+func (r *Router) notifyToolsListChanged() {
+	if !r.running.Load() {
+		return
+	}
+	r.broadcast(&types.JSONRPCNotification{
+		JSONRPC: "2.0",
+		Method:  types.MethodToolsListChanged,
+	}, func(session *Session) bool {
+		return session.Supports(FeatureToolsListChanged)
+	})
+}
+
+// SetThresholds 更新健康检查阈值，工具尚未初始化时只更新选项
+func (r *Router) SetThresholds(thresholds types.Thresholds) {
+	r.reloadMutex.Lock()
+	defer r.reloadMutex.Unlock()
+
+	r.options.Thresholds = thresholds
+	if r.healthTool != nil {
+		r.healthTool.SetThresholds(thresholds)
 	}
 }
 
-// InitializeTools 初始化所有监控工具
+// currentThresholds 获取当前的健康检查阈值，后台采集器据此记录告警事件
+func (r *Router) currentThresholds() types.Thresholds {
+	r.reloadMutex.Lock()
+	defer r.reloadMutex.Unlock()
+
+	return r.options.Thresholds
+}
+
+// SetCollectInterval 修改后台采集间隔。启动时未启用后台采集或 interval 不大于 0 时返回 false，需要重启才能生效
+func (r *Router) SetCollectInterval(interval time.Duration) bool {
+	r.reloadMutex.Lock()
+	defer r.reloadMutex.Unlock()
+
+	if r.collector == nil || interval <= 0 {
+		return false
+	}
+
+	r.options.CollectInterval = interval
+	r.collector.SetInterval(interval)
+	return true
+}
+
+// InitializeTools 注册内置工具（SkipDefaultTools 时跳过）和自定义工具，可在 Start 之前调用以查询已注册的工具，
+// 重复调用时不做任何事
 func (r *Router) InitializeTools() error {
+	r.reloadMutex.Lock()
+	if r.toolsReady {
+		r.reloadMutex.Unlock()
+		return nil
+	}
+	r.toolsReady = true
+	custom := append(slices.Clone(r.options.Tools), r.pendingTools...)
+	r.pendingTools = nil
+	r.reloadMutex.Unlock()
+
+	if r.options.Prefetch {
+		r.warmup = tools.NewWarmup()
+	}
+	if !r.options.SkipDefaultTools {
+		r.registerDefaultTools()
+	}
+
+	// 自定义工具在内置工具之后注册，同名时替换内置工具
+	for _, tool := range custom {
+		r.handler.RegisterTool(tool)
+	}
+
+	return nil
+}
+
+// registerDefaultTools 注册内置的工具和资源，并创建依赖它们的后台组件
+func (r *Router) registerDefaultTools() {
 	// 初始化监控工具，但不输出日志避免干扰 JSON-RPC
 
 	// 创建工具实例
-	cpuTool := tools.NewCPUTool(r.cache)
-	memoryTool := tools.NewMemoryTool(r.cache)
-	processTool := tools.NewProcessTool(r.cache)
-	networkTool := tools.NewNetworkTool(r.cache)
-	diskTool := tools.NewDiskTool(r.cache)
-	systemTool := tools.NewSystemTool(r.cache)
+	cpuTool := tools.NewCPUTool(r.cache, r.cacheOptions("cpu_info"), r.options.OutputStyle)
+	memoryTool := tools.NewMemoryTool(r.cache, r.cacheOptions("memory_info"), r.options.OutputStyle)
+	processTool := tools.NewProcessTool(r.cache, r.cacheOptions("top_processes"))
+	networkTool := tools.NewNetworkTool(r.cache, r.cacheOptions("network_stats"), r.options.ToolConfigs["network_stats"].EffectiveRateWindow(), r.storage)
+	diskConfig := r.options.ToolConfigs["disk_info"]
+	diskTool := tools.NewDiskTool(r.cache, r.cacheOptions("disk_info"), tools.NewPartitionFilter(
+		diskConfig.SkipMountpointPrefixes,
+		diskConfig.SkipFstypes,
+		diskConfig.AlwaysShowMountpoints,
+		diskConfig.AlwaysShowFstypes,
+	).WithExpectedReadOnly(diskConfig.ExpectedReadOnlyMountpoints, diskConfig.ExpectedReadOnlyFstypes), r.options.OutputStyle, r.storage).WithUsageTimeout(diskConfig.EffectiveUsageTimeout())
+	systemTool := tools.NewSystemTool(r.cache, r.cacheOptions("system_overview"))
+	historyTool := tools.NewMetricsHistoryTool(r.storage)
+	trendTool := tools.NewMetricsTrendTool(r.storage)
+	anomaliesTool := tools.NewAnomaliesTool(r.storage)
+	incidents := tools.NewIncidentLog(r.storage, r.options.IncidentResolveAfter)
+	kernelParamsTool := tools.NewKernelParamsTool(r.options.ToolConfigs["kernel_params"].ExtraParams)
 
 	// 注册工具
 	r.handler.RegisterTool(cpuTool)
 	r.handler.RegisterTool(memoryTool)
 	r.handler.RegisterTool(processTool)
+	r.handler.RegisterTool(tools.NewProcessChurnTool())
+	r.handler.RegisterTool(tools.NewUserUsageTool(processTool))
 	r.handler.RegisterTool(networkTool)
+	r.handler.RegisterTool(tools.NewTopNetworkInterfacesTool())
+
+	// 路由表工具仅在有可读的数据来源时注册
+	if routesTool := tools.NewNetworkRoutesTool(); routesTool.Available() {
+		r.handler.RegisterTool(routesTool)
+	}
 	r.handler.RegisterTool(diskTool)
+	r.handler.RegisterTool(tools.NewLargestDirectoriesTool())
+	r.handler.RegisterTool(tools.NewRecentLargeFilesTool())
+	r.handler.RegisterTool(tools.NewOpenFilesTool())
+	r.handler.RegisterTool(tools.NewProcessDetailTool(r.options.ToolConfigs["process_detail"].RedactEnvPatterns))
 	r.handler.RegisterTool(systemTool)
+	r.handler.RegisterTool(historyTool)
+	r.handler.RegisterTool(tools.NewMetricsExportTool(r.storage))
+	r.handler.RegisterTool(trendTool)
+	r.handler.RegisterTool(anomaliesTool)
+	r.handler.RegisterTool(tools.NewIncidentsTool(incidents))
+	r.handler.RegisterTool(kernelParamsTool)
+	r.handler.RegisterTool(tools.NewKernelEventsTool())
+	timeSyncTool := tools.NewTimeSyncTool()
+	r.handler.RegisterTool(timeSyncTool)
+	selfInfoTool := tools.NewSelfInfoTool(r.storage, r.options.EnableAdminTools)
+	r.handler.RegisterTool(selfInfoTool)
+	healthTool := tools.NewHealthReportTool(r.options.Thresholds, cpuTool, memoryTool, diskTool, timeSyncTool, selfInfoTool, r.GetStorageStats, r.CollectorStats, incidents)
+	r.handler.RegisterTool(healthTool)
 
-	// 工具初始化完成，但不输出日志避免干扰 JSON-RPC
+	watches := newWatchManager(r.ctx, r.handler.CallTool, r.sendTo, nil)
+	r.handler.RegisterTool(tools.NewServerStatsTool(r.cache, r.storage, r.warmup, r.handler.RecentCalls, r.handler.ToolStats, currentSessionStats, processTool.CPUBaselineCount, incidents, watches.List, r.CollectorStats))
+	r.handler.RegisterTool(tools.NewDescribeTool(r.handler.DescribeTool, r.handler.AllowedTools))
+	r.handler.RegisterTool(tools.NewMultiQueryTool(r.handler.CallTool, r.handler.DescribeTool))
+	r.handler.RegisterTool(tools.NewWatchStartTool(watches, r.handler.DescribeTool))
+	r.handler.RegisterTool(tools.NewWatchStopTool(watches))
 
-	return nil
+	// 操作工具和管理工具默认不注册
+	if r.options.EnableActions {
+		r.handler.RegisterTool(tools.NewProcessSignalTool())
+	}
+	if r.options.EnableAdminTools {
+		r.handler.RegisterTool(tools.NewCacheAdminTool(r.cache))
+	}
+
+	r.handler.RegisterResource(tools.NewStorageResource(r.GetStorageStats))
+	r.handler.RegisterResourceTemplate(tools.NewLiveResourceTemplate(r.handler.CallTool))
+
+	presets := tools.NewPresetStore(r.storage)
+	r.handler.SetPresets(presets)
+	r.handler.SetDiffStore(tools.NewDiffStore(r.storage))
+	r.handler.RegisterTool(tools.NewPresetAdminTool(presets, r.handler.DescribeTool))
+
+	schedules := tools.NewScheduleStore(r.storage)
+	r.handler.RegisterTool(tools.NewScheduleAdminTool(schedules, r.handler.DescribeTool))
+
+	r.reloadMutex.Lock()
+	defer r.reloadMutex.Unlock()
+	r.healthTool = healthTool
+	r.gauges = &systemGauges{cpu: cpuTool, memory: memoryTool, disk: diskTool, network: networkTool, system: systemTool}
+	r.scheduler = scheduler.NewScheduler(schedules, r.handler.CallTool, nil)
+	r.watches = watches
+
+	// 创建后台采集器，实时变化资源依赖采集器
+	if r.options.CollectInterval > 0 {
+		r.collector = collector.NewCollector(r.storage, r.options.CollectInterval, memoryTool, diskTool, networkTool)
+		r.liveChanges = tools.NewLiveChangesResource()
+		r.handler.RegisterResource(r.liveChanges)
+		r.collector.OnSample(r.handleSample)
+		r.collector.OnAnomaly(r.handleAnomaly)
+		r.collector.DetectAnomalies(anomaly.NewDetector(r.options.AnomalySigmas, anomaly.DefaultAlpha, anomaly.DefaultWarmup, anomaly.DefaultMinStdDev))
+		r.collector.TrackIncidents(incidents, r.currentThresholds)
+		r.collector.KeepHistory(r.options.HistoryRetention)
+	}
+
+	// 工具初始化完成，但不输出日志避免干扰 JSON-RPC
 }
 
-// Start 启动路由器
+// Start 启动路由器，在标准输入输出上服务一个连接（stdio 模式的隐式会话），直到输入结束或路由器停止。
+// 其他连接通过 Serve 接入，各自使用独立的会话
 func (r *Router) Start() error {
-	if r.running {
+	if !r.running.CompareAndSwap(false, true) {
 		return fmt.Errorf("路由器已经在运行")
 	}
 
 	// 启动 MCP 路由器，但不输出日志避免干扰 JSON-RPC
-	r.running = true
 
 	// 初始化工具
 	if err := r.InitializeTools(); err != nil {
 		return fmt.Errorf("初始化工具失败: %v", err)
 	}
 
-	// 启动消息处理循环
-	return r.messageLoop()
+	// 启动后台采集
+	if r.collector != nil {
+		go r.collector.Run(r.ctx)
+	}
+
+	// 启动定时任务
+	if r.scheduler != nil {
+		go r.scheduler.Run(r.ctx)
+	}
+
+	// 定期清理过期的完整结果
+	if r.results != nil {
+		go r.results.Run(r.ctx)
+	}
+
+	// 异步预取静态数据，不阻塞 initialize 响应
+	if r.warmup != nil {
+		go r.warmup.Run(r.ctx, r.handler.prefetchers())
+	}
+
+	// 启动消息处理循环，输入结束即连接断开，清理会话（包括资源订阅和监视）
+	close(r.ready)
+	return r.serveConnection(r.input, r.output, nil)
+}
+
+// isRunning 路由器是否在运行（Start 之后、Stop 或 Shutdown 之前）
+func (r *Router) isRunning() bool {
+	return r.running.Load()
+}
+
+// Ready 返回在 Start 完成启动（工具已初始化、后台组件已启动、开始读取输入）时关闭的通道，之后可以调用 Serve
+func (r *Router) Ready() <-chan struct{} {
+	return r.ready
+}
+
+// handleSample 后台采集完成一次采样后更新实时变化资源，内容变化时通知订阅了该资源的会话
+func (r *Router) handleSample(sample types.MetricSample) {
+	if !r.liveChanges.Observe(sample) {
+		return
+	}
+	r.broadcast(&types.JSONRPCNotification{
+		JSONRPC: "2.0",
+		Method:  types.MethodResourceUpdated,
+		Params:  types.ResourceURIParams{URI: tools.LiveChangesURI},
+	}, func(session *Session) bool {
+		return session.IsSubscribed(tools.LiveChangesURI)
+	})
+}
+
+// handleAnomaly 后台采集检测到异常时，向设置了 warning 或更低日志级别的会话发送日志通知
+func (r *Router) handleAnomaly(item types.Anomaly) {
+	r.broadcast(&types.JSONRPCNotification{
+		JSONRPC: "2.0",
+		Method:  types.MethodLogMessage,
+		Params: types.LogMessageParams{
+			Level:  "warning",
+			Logger: "anomaly",
+			Data:   item,
+		},
+	}, func(session *Session) bool {
+		return session.WantsLog("warning")
+	})
 }
 
-// Stop 停止路由器
+// Stop 停止路由器，关闭所有连接的会话
 func (r *Router) Stop() {
 	// 停止 MCP 路由器，但不输出日志避免干扰 JSON-RPC
-	r.running = false
+	r.running.Store(false)
+	r.closeConnections()
+
+	// 通知后台刷新和采集任务服务器已关闭
+	r.cancel()
 }
 
-// messageLoop 消息处理循环
-func (r *Router) messageLoop() error {
-	scanner := bufio.NewScanner(r.input)
+// ProcessMessage 同步处理一条消息并返回序列化的响应（通知返回 nil），不经过输入输出。
+// 所有调用共用一个会话，通知被丢弃。用于回放会话记录（--replay），需先调用 InitializeTools
+func (r *Router) ProcessMessage(line []byte) []byte {
+	r.connMutex.Lock()
+	if r.replay == nil {
+		r.replay = &connection{session: NewSession(), output: io.Discard}
+		r.connections[r.replay.session] = r.replay
+	}
+	conn := r.replay
+	r.connMutex.Unlock()
 
-	// 不输出到 stdout，避免干扰 JSON-RPC 通信
+	response := r.handleLine(conn, line)
+	if response == nil {
+		return nil
+	}
+	respBytes, err := json.Marshal(response)
+	if err != nil {
+		return nil
+	}
+	return respBytes
+}
 
-	for r.running && scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
-			continue
-		}
+// SetInput 设置 Start 读取消息的输入（默认为标准输入），需在 Start 之前调用
+func (r *Router) SetInput(input io.Reader) {
+	r.input = input
+}
 
-		// 解析 JSON-RPC 请求
-		var req types.JSONRPCRequest
-		if err := json.Unmarshal([]byte(line), &req); err != nil {
-			// 解析失败，但不输出日志到避免干扰 JSON-RPC
-			// 发送解析错误响应（只有在有ID的情况下）
-			var rawMessage map[string]interface{}
-			json.Unmarshal([]byte(line), &rawMessage)
-			if id, hasID := rawMessage["id"]; hasID {
-				errorResp := &types.JSONRPCResponse{
-					JSONRPC: "2.0",
-					ID:      id,
-					Error: &types.RPCError{
-						Code:    -32700,
-						Message: "Parse error: " + err.Error(),
-					},
-				}
-				r.sendResponse(errorResp)
-			}
-			continue
-		}
+// SetOutput 设置消息的输出位置（默认为标准输出），需在 Start 之前调用
+func (r *Router) SetOutput(output io.Writer) {
+	r.output = output
+}
 
-		// 检查是否是通知（没有 ID 字段）
-		isNotification := req.ID == nil
+// DiagnosticsStatus 获取服务器自身的诊断状态，可在任意 goroutine 中调用
+func (r *Router) DiagnosticsStatus() types.DiagnosticsStatus {
+	status := types.DiagnosticsStatus{
+		UptimeSeconds: time.Since(r.startTime).Seconds(),
+		Goroutines:    runtime.NumGoroutine(),
+		Cache:         r.cache.Stats(),
+		Build:         version.Get(),
+		Sessions:      r.sessionStats(),
+	}
 
-		// 处理请求
-		response := r.handler.HandleRequest(&req)
+	if storageStats, err := r.GetStorageStats(); err == nil {
+		status.Storage = &storageStats
+	}
 
-		// 发送响应（只有非通知的请求才发送响应）
-		if response != nil && !isNotification {
-			r.sendResponse(response)
-		}
+	if collectorStats := r.CollectorStats(); collectorStats != nil {
+		status.LastCollectRun = collectorStats.LastRun
+		status.Collector = collectorStats
 	}
 
-	if err := scanner.Err(); err != nil {
-		// 扫描错误，但不输出到 stdout
-		return fmt.Errorf("扫描输入时出错: %v", err)
+	return status
+}
+
+// CollectorStats 后台采集器的运行统计，未启用后台采集时返回 nil
+func (r *Router) CollectorStats() *types.CollectorStats {
+	r.reloadMutex.Lock()
+	backgroundCollector := r.collector
+	r.reloadMutex.Unlock()
+	if backgroundCollector == nil {
+		return nil
 	}
 
-	return nil
+	stats := backgroundCollector.Stats()
+	return &stats
 }
 
-// sendResponse 发送响应
-func (r *Router) sendResponse(response *types.JSONRPCResponse) {
-	respBytes, err := json.Marshal(response)
-	if err != nil {
-		// 序列化失败，但不输出日志避免干扰 JSON-RPC
-		return
+// GetStorageStats 获取存储后端统计信息
+func (r *Router) GetStorageStats() (types.StorageStats, error) {
+	provider, ok := r.storage.(types.StorageStatsProvider)
+	if !ok {
+		return types.StorageStats{Backend: "unknown"}, nil
 	}
 
-	if _, err := fmt.Fprintln(r.output, string(respBytes)); err != nil {
-		// 发送失败，但不输出日志避免干扰 JSON-RPC
+	return provider.Stats()
+}
+
+// sessionStats 所有连接的会话统计，按会话创建时间排序
+func (r *Router) sessionStats() []types.SessionStats {
+	connections := r.openConnections()
+	stats := make([]types.SessionStats, 0, len(connections))
+	for _, conn := range connections {
+		stats = append(stats, conn.session.Stats())
 	}
+	return stats
 }
@@ -0,0 +1,234 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/tools"
+	"mcp-example/internal/types"
+)
+
+// listedTools tools/list 响应中的工具名称
+func listedTools(t *testing.T, resp map[string]interface{}) []string {
+	t.Helper()
+	result, ok := resp["result"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("tools/list response = %v", resp)
+	}
+	var names []string
+	for _, tool := range result["tools"].([]interface{}) {
+		names = append(names, tool.(map[string]interface{})["name"].(string))
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestSkipDefaultToolsRegistersOnlyCustomTools(t *testing.T) {
+	r := startTestRouter(t, nil)
+	client := connectClient(t, r)
+	client.setup(t, "")
+
+	if names := listedTools(t, client.call(t, rpc(2, types.MethodListTools, nil))); !slices.Equal(names, []string{"echo"}) {
+		t.Fatalf("tools/list = %v, want only the custom echo tool", names)
+	}
+	resp := client.call(t, rpc(3, types.MethodListResources, nil))
+	if resources := resp["result"].(map[string]interface{})["resources"]; resources != nil && len(resources.([]interface{})) != 0 {
+		t.Errorf("resources/list = %v, want no built-in resources", resources)
+	}
+	// 依赖内置工具的后台组件也不创建
+	if r.collector != nil || r.scheduler != nil || r.healthTool != nil {
+		t.Error("background components were created without the default tools")
+	}
+}
+
+func TestCustomToolReplacesBuiltin(t *testing.T) {
+	r := NewRouter("test-server", "0.0.0", storage.NewMemoryStorage(), storage.NewMemoryCache(), Options{
+		Tools: []types.MonitorTool{&echoTool{name: "cpu_info"}},
+	})
+	t.Cleanup(r.Stop)
+	// 初始化之前注册的工具同样在内置工具之后注册
+	r.RegisterTool(&echoTool{name: "memory_info"})
+	if err := r.InitializeTools(); err != nil {
+		t.Fatal(err)
+	}
+
+	registered := r.handler.GetRegisteredTools()
+	for _, name := range []string{"cpu_info", "memory_info", "disk_info", "top_processes"} {
+		if !slices.Contains(registered, name) {
+			t.Errorf("registered tools = %v, want %s", registered, name)
+		}
+	}
+	for i, name := range []string{"cpu_info", "memory_info"} {
+		resp := r.handler.HandleRequest(context.Background(), nil, callRequest(i+1, name, map[string]interface{}{"text": name}))
+		if got := resultText(t, resp); got != "echo: "+name {
+			t.Errorf("%s returned %q, want the custom tool's result", name, got)
+		}
+	}
+}
+
+func TestLateRegisterToolNotifiesClients(t *testing.T) {
+	r := startTestRouter(t, nil)
+	client := connectClient(t, r)
+	client.setup(t, "")
+
+	// 策略禁用的工具不出现在列表中，注册时不通知
+	r.SetPolicy(Policy{DenyTools: []string{"blocked"}})
+	r.RegisterTool(&echoTool{name: "blocked"})
+	client.expectQuiet(t)
+
+	r.RegisterTool(&echoTool{name: "echo2"})
+	if message := client.next(t); message["method"] != types.MethodToolsListChanged {
+		t.Fatalf("got %v, want tools/list_changed", message)
+	}
+	if names := listedTools(t, client.call(t, rpc(4, types.MethodListTools, nil))); !slices.Equal(names, []string{"echo", "echo2"}) {
+		t.Fatalf("tools/list = %v, want echo and the late echo2 without the blocked tool", names)
+	}
+
+	// 替换已注册的工具同样通知客户端
+	r.RegisterTool(&echoTool{name: "echo2"})
+	if message := client.next(t); message["method"] != types.MethodToolsListChanged {
+		t.Fatalf("got %v after replacing echo2, want tools/list_changed", message)
+	}
+}
+
+// newDefaultRouter 注册了全部内置工具（包括操作和管理工具、后台采集相关的工具）的路由器，不启动
+func newDefaultRouter(t *testing.T) *Router {
+	t.Helper()
+	r := NewRouter("test-server", "0.0.0", storage.NewMemoryStorage(), storage.NewMemoryCache(), Options{
+		EnableActions:    true,
+		EnableAdminTools: true,
+		CollectInterval:  time.Minute,
+	})
+	t.Cleanup(r.Stop)
+	if err := r.InitializeTools(); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestDefaultToolExamplesMatchSchemas(t *testing.T) {
+	r := newDefaultRouter(t)
+	registered := r.handler.registeredTools()
+	if len(registered) < 30 {
+		t.Fatalf("only %d default tools registered", len(registered))
+	}
+
+	for _, name := range sortedToolNames(registered) {
+		tool := registered[name]
+		exampleTool, ok := tool.(types.ExampleTool)
+		if !ok {
+			t.Errorf("%s has no examples", name)
+			continue
+		}
+		// 没有参数的工具只有一种调用方式，一个示例即可
+		examples := exampleTool.Examples()
+		want := 2
+		if len(tool.GetInputSchema().Properties) == 0 {
+			want = 1
+		}
+		if len(examples) < want {
+			t.Errorf("%s has %d examples, want at least %d", name, len(examples), want)
+		}
+		for _, example := range examples {
+			if example.Description == "" {
+				t.Errorf("%s: example %v has no description", name, example.Arguments)
+			}
+			if _, err := tools.ValidateArguments(tool.GetInputSchema(), example.Arguments); err != nil {
+				t.Errorf("%s: example %q does not match the input schema: %v", name, example.Description, err)
+			}
+		}
+
+		// tools/list 和 describe_tool 中的描述包含示例
+		if described, found := r.handler.DescribeTool(name); !found || len(described.Examples) != len(examples) {
+			t.Errorf("%s: describe_tool lists %d examples, want %d", name, len(described.Examples), len(examples))
+		}
+	}
+}
+
+func TestToolsListIncludesAnnotations(t *testing.T) {
+	r := newDefaultRouter(t)
+	r.handler.RegisterTool(&echoTool{name: "echo"})
+	resp := r.handler.HandleRequest(context.Background(), nil, rpc(1, types.MethodListTools, nil))
+
+	// 按客户端看到的 JSON 检查，确认字段名和 false 值都会输出
+	var decoded struct {
+		Tools []map[string]json.RawMessage `json:"tools"`
+	}
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	annotations := make(map[string]map[string]interface{})
+	for _, tool := range decoded.Tools {
+		var name string
+		json.Unmarshal(tool["name"], &name)
+		var fields map[string]interface{}
+		if err := json.Unmarshal(tool["annotations"], &fields); err != nil {
+			t.Errorf("%s: annotations = %s: %v", name, tool["annotations"], err)
+			continue
+		}
+		for _, hint := range []string{"title", "readOnlyHint", "destructiveHint", "idempotentHint", "openWorldHint"} {
+			if _, found := fields[hint]; !found {
+				t.Errorf("%s: annotations %v lack %s", name, fields, hint)
+			}
+		}
+		if title, _ := fields["title"].(string); title == "" {
+			t.Errorf("%s: empty title", name)
+		} else if name != "echo" && title == name {
+			t.Errorf("%s: title repeats the tool name, want a human-readable title", name)
+		}
+		annotations[name] = fields
+	}
+
+	for name, want := range map[string]map[string]interface{}{
+		"cpu_info":       {"readOnlyHint": true, "destructiveHint": false, "idempotentHint": true},
+		"process_signal": {"readOnlyHint": false, "destructiveHint": true},
+		"metrics_export": {"readOnlyHint": false},
+		// 未声明注解的自定义工具默认只读，标题为工具名
+		"echo": {"title": "echo", "readOnlyHint": true, "destructiveHint": false},
+	} {
+		for hint, value := range want {
+			if got := annotations[name][hint]; got != value {
+				t.Errorf("%s: %s = %v, want %v", name, hint, got, value)
+			}
+		}
+	}
+}
+
+func TestSelfInfoPlatformSupportListsRegisteredTools(t *testing.T) {
+	r := newDefaultRouter(t)
+	resp := r.handler.HandleRequest(context.Background(), nil, callRequest(1, "self_info", map[string]interface{}{"format": "json"}))
+	result, ok := resp.Result.(types.CallToolResult)
+	if resp.Error != nil || !ok || result.IsError {
+		t.Fatalf("self_info = %s", responseJSON(t, resp))
+	}
+	var info struct {
+		Platform string `json:"platform"`
+		Support  []struct {
+			Tool    string `json:"tool"`
+			Support string `json:"support"`
+		} `json:"platform_support"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Platform == "" || len(info.Support) == 0 {
+		t.Fatalf("self_info = %+v", info)
+	}
+
+	// 支持表中的工具名称都对应已注册的工具（当前平台上不支持而未注册的除外）
+	registered := r.handler.registeredTools()
+	for _, entry := range info.Support {
+		if _, found := registered[entry.Tool]; !found && entry.Support != "unsupported" {
+			t.Errorf("platform_support lists %s, which is not registered", entry.Tool)
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package router
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"sync"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// ErrCodeServerNotInitialized 会话未完成初始化（或正在关闭）时返回的错误码
+const ErrCodeServerNotInitialized = -32002
+
+// SessionState 会话生命周期状态
+type SessionState int
+
+const (
+	// SessionUninitialized 尚未收到 initialize 请求
+	SessionUninitialized SessionState = iota
+	// SessionInitializing 已响应 initialize，等待 initialized 通知
+	SessionInitializing
+	// SessionReady 握手完成，可以处理所有请求
+	SessionReady
+	// SessionShuttingDown 服务器正在关闭
+	SessionShuttingDown
+)
+
+// String 状态名称
+func (s SessionState) String() string {
+	switch s {
+	case SessionUninitialized:
+		return "uninitialized"
+	case SessionInitializing:
+		return "initializing"
+	case SessionReady:
+		return "ready"
+	case SessionShuttingDown:
+		return "shutting-down"
+	default:
+		return "unknown"
+	}
+}
+
+// 可以按会话启用的可选功能。MCP 没有为这些功能定义客户端能力字段，
+// 以客户端实际表达的意愿判断是否支持：
+//   - structured_content：协商的协议版本不早于 structuredContentVersion
+//   - progress / tools_list_changed：握手已完成（initialized 之前不发送通知），
+//     progress 还要求请求中带有 progressToken
+//   - logging：握手已完成，且客户端通过 logging/setLevel 设置了日志级别
+const (
+	FeatureStructuredContent = "structured_content"
+	FeatureProgress          = "progress"
+	FeatureToolsListChanged  = "tools_list_changed"
+	FeatureLogging           = "logging"
+)
+
+// structuredContentVersion 引入工具结果 structuredContent 的协议版本
+const structuredContentVersion = "2025-06-18"
+
+// logLevels MCP 日志级别（RFC 5424），按严重程度升序
+var logLevels = []string{"debug", "info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+// Session 单个连接的会话状态。
+// 握手状态、协商的协议版本、客户端信息、日志级别、资源订阅、统计和进行中的请求都保存在会话中，
+// 多个连接可以共享同一个 handler（工具、缓存和存储是共享的）。stdio 模式只有一个隐式会话。
+type Session struct {
+	id        string
+	createdAt time.Time
+
+	mutex           sync.Mutex
+	state           SessionState
+	protocolVersion string
+	clientInfo      types.ClientInfo
+	capabilities    types.ClientCapabilities
+	logLevel        string
+	subscriptions   map[string]bool
+	requests        uint64
+	errors          uint64
+	nextRequest     uint64
+	inFlight        map[uint64]context.CancelFunc
+	// drained Drain 等待进行中的请求结束时设置，最后一个请求结束时关闭
+	drained chan struct{}
+}
+
+// NewSession 创建新的会话
+func NewSession() *Session {
+	return &Session{
+		id:            newSessionID(),
+		createdAt:     time.Now(),
+		subscriptions: make(map[string]bool),
+		inFlight:      make(map[uint64]context.CancelFunc),
+	}
+}
+
+// newSessionID 生成随机的会话 ID
+func newSessionID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		// 随机数不可用时退回到时间戳，仍能区分同一进程内的会话
+		return hex.EncodeToString([]byte(time.Now().Format(time.RFC3339Nano)))
+	}
+	return hex.EncodeToString(buf)
+}
+
+// ID 会话 ID
+func (s *Session) ID() string {
+	return s.id
+}
+
+// State 获取当前状态
+func (s *Session) State() SessionState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.state
+}
+
+// transition 仅在当前状态为 from 时切换到 to
+func (s *Session) transition(from, to SessionState) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.state != from {
+		return false
+	}
+	s.state = to
+	return true
+}
+
+// Shutdown 标记会话正在关闭，清除所有资源订阅并取消进行中的请求
+func (s *Session) Shutdown() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.state = SessionShuttingDown
+	s.subscriptions = make(map[string]bool)
+	for id, cancel := range s.inFlight {
+		cancel()
+		delete(s.inFlight, id)
+	}
+}
+
+// Drain 标记会话正在关闭（不再接受新请求，清除资源订阅）并等待进行中的请求结束。
+// ctx 到期时取消剩余的请求并返回 ctx 的错误
+func (s *Session) Drain(ctx context.Context) error {
+	s.mutex.Lock()
+	s.state = SessionShuttingDown
+	s.subscriptions = make(map[string]bool)
+	if len(s.inFlight) == 0 {
+		s.mutex.Unlock()
+		return nil
+	}
+	drained := make(chan struct{})
+	s.drained = drained
+	s.mutex.Unlock()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		s.Shutdown()
+		return ctx.Err()
+	}
+}
+
+// setClient 记录 initialize 中的客户端信息、客户端能力和协商的协议版本
+func (s *Session) setClient(params types.InitializeParams, protocolVersion string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.clientInfo = params.ClientInfo
+	s.capabilities = params.Capabilities
+	s.protocolVersion = protocolVersion
+}
+
+// ProtocolVersion 协商的协议版本，未初始化时为空
+func (s *Session) ProtocolVersion() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.protocolVersion
+}
+
+// ClientInfo 客户端在 initialize 中提供的信息
+func (s *Session) ClientInfo() types.ClientInfo {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.clientInfo
+}
+
+// Capabilities 客户端在 initialize 中声明的能力
+func (s *Session) Capabilities() types.ClientCapabilities {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.capabilities
+}
+
+// Supports 是否可以对该会话使用可选功能（Feature*），不支持时调用方应静默跳过
+func (s *Session) Supports(feature string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.supports(feature)
+}
+
+// supports 需持有 mutex
+func (s *Session) supports(feature string) bool {
+	switch feature {
+	case FeatureStructuredContent:
+		// 协议版本格式为 YYYY-MM-DD，可以按字符串比较
+		return s.protocolVersion >= structuredContentVersion
+	case FeatureProgress, FeatureToolsListChanged:
+		return s.state == SessionReady
+	case FeatureLogging:
+		return s.state == SessionReady && s.logLevel != ""
+	default:
+		return false
+	}
+}
+
+// features 客户端声明的能力和已启用的可选功能，需持有 mutex
+func (s *Session) features() []string {
+	var features []string
+	if s.capabilities.Roots != nil {
+		features = append(features, "roots")
+	}
+	if s.capabilities.Sampling != nil {
+		features = append(features, "sampling")
+	}
+	if s.capabilities.Elicitation != nil {
+		features = append(features, "elicitation")
+	}
+	for _, feature := range []string{FeatureStructuredContent, FeatureProgress, FeatureToolsListChanged, FeatureLogging} {
+		if s.supports(feature) {
+			features = append(features, feature)
+		}
+	}
+	return features
+}
+
+// SetLogLevel 设置客户端希望接收的最低日志级别，级别无效时返回 false
+func (s *Session) SetLogLevel(level string) bool {
+	if !slices.Contains(logLevels, level) {
+		return false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.logLevel = level
+	return true
+}
+
+// WantsLog 是否应向该会话发送该级别的日志消息。
+// 客户端未通过 logging/setLevel 设置级别或会话未就绪（包括正在关闭）时不发送。
+func (s *Session) WantsLog(level string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.supports(FeatureLogging) {
+		return false
+	}
+	return slices.Index(logLevels, level) >= slices.Index(logLevels, s.logLevel)
+}
+
+// begin 登记一个进行中的请求，返回可被 Shutdown 取消的 context 和请求结束时调用的清理函数
+func (s *Session) begin(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	s.mutex.Lock()
+	id := s.nextRequest
+	s.nextRequest++
+	s.inFlight[id] = cancel
+	s.mutex.Unlock()
+
+	return ctx, func() {
+		s.mutex.Lock()
+		delete(s.inFlight, id)
+		if len(s.inFlight) == 0 && s.drained != nil {
+			close(s.drained)
+			s.drained = nil
+		}
+		s.mutex.Unlock()
+		cancel()
+	}
+}
+
+// record 统计一次请求的结果
+func (s *Session) record(resp *types.JSONRPCResponse) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests++
+	if resp == nil {
+		return
+	}
+	if resp.Error != nil {
+		s.errors++
+	} else if result, ok := resp.Result.(types.CallToolResult); ok && result.IsError {
+		s.errors++
+	}
+}
+
+// Stats 获取会话的状态和统计
+func (s *Session) Stats() types.SessionStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return types.SessionStats{
+		ID:              s.id,
+		State:           s.state.String(),
+		ProtocolVersion: s.protocolVersion,
+		ClientName:      s.clientInfo.Name,
+		ClientVersion:   s.clientInfo.Version,
+		ClientFeatures:  s.features(),
+		LogLevel:        s.logLevel,
+		Subscriptions:   len(s.subscriptions),
+		Requests:        s.requests,
+		Errors:          s.errors,
+		InFlight:        len(s.inFlight),
+		CreatedAt:       s.createdAt,
+	}
+}
+
+// Subscribe 订阅资源更新
+func (s *Session) Subscribe(uri string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.subscriptions[uri] = true
+}
+
+// Unsubscribe 取消订阅资源更新
+func (s *Session) Unsubscribe(uri string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.subscriptions, uri)
+}
+
+// IsSubscribed 是否订阅了资源更新（关闭中的会话不再接收通知）
+func (s *Session) IsSubscribed(uri string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.state != SessionShuttingDown && s.subscriptions[uri]
+}
+
+// sessionKey 会话在 context 中的键
+type sessionKey struct{}
+
+// WithSession 将会话附加到 context，HandleRequest 会自动附加，中间件和处理函数据此获取当前会话
+func WithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// SessionFromContext 获取 context 中的会话
+func SessionFromContext(ctx context.Context) (*Session, bool) {
+	session, ok := ctx.Value(sessionKey{}).(*Session)
+	return session, ok
+}
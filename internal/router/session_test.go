@@ -0,0 +1,157 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/types"
+)
+
+// rpc 构造 JSON-RPC 请求，id 为 nil 时为通知
+func rpc(id interface{}, method string, params interface{}) *types.JSONRPCRequest {
+	return &types.JSONRPCRequest{JSONRPC: "2.0", ID: id, Method: method, Params: params}
+}
+
+// initializeRequest 指定协议版本的 initialize 请求
+func initializeRequest(id interface{}, protocolVersion string) *types.JSONRPCRequest {
+	return rpc(id, types.MethodInitialize, map[string]interface{}{
+		"protocolVersion": protocolVersion,
+		"clientInfo":      map[string]interface{}{"name": "test-client", "version": "1.0"},
+	})
+}
+
+// handshake 完成会话的 initialize / initialized 握手
+func handshake(t *testing.T, handler *MCPHandler, session *Session, protocolVersion string) {
+	t.Helper()
+	if resp := handler.HandleRequest(context.Background(), session, initializeRequest(0, protocolVersion)); resp == nil || resp.Error != nil {
+		t.Fatalf("initialize failed: %+v", resp)
+	}
+	if resp := handler.HandleRequest(context.Background(), session, rpc(nil, types.MethodNotificationInitialized, nil)); resp != nil {
+		t.Fatalf("initialized notification got a response: %+v", resp)
+	}
+}
+
+// errorCode 响应的错误码，没有错误时为 0
+func errorCode(resp *types.JSONRPCResponse) int {
+	if resp == nil || resp.Error == nil {
+		return 0
+	}
+	return resp.Error.Code
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	handler, tool := newTestHandler()
+	session := NewSession()
+	ctx := context.Background()
+
+	if session.State() != SessionUninitialized {
+		t.Fatalf("new session state = %s", session.State())
+	}
+	if code := errorCode(handler.HandleRequest(ctx, session, callRequest(1, "echo", nil))); code != ErrCodeServerNotInitialized {
+		t.Fatalf("tools/call before initialize: code %d, want %d", code, ErrCodeServerNotInitialized)
+	}
+	if code := errorCode(handler.HandleRequest(ctx, session, rpc(2, types.MethodPing, nil))); code != 0 {
+		t.Fatalf("ping before initialize failed with %d", code)
+	}
+
+	if code := errorCode(handler.HandleRequest(ctx, session, initializeRequest(3, "2025-06-18"))); code != 0 {
+		t.Fatalf("initialize failed with %d", code)
+	}
+	if session.State() != SessionInitializing || session.ProtocolVersion() != "2025-06-18" {
+		t.Fatalf("after initialize: state %s, protocol %q", session.State(), session.ProtocolVersion())
+	}
+	if code := errorCode(handler.HandleRequest(ctx, session, rpc(4, types.MethodListTools, nil))); code != ErrCodeServerNotInitialized {
+		t.Fatalf("tools/list while initializing: code %d, want %d", code, ErrCodeServerNotInitialized)
+	}
+	if code := errorCode(handler.HandleRequest(ctx, session, initializeRequest(5, "2025-06-18"))); code != -32600 {
+		t.Fatalf("duplicate initialize: code %d, want -32600", code)
+	}
+
+	if resp := handler.HandleRequest(ctx, session, rpc(nil, types.MethodNotificationInitialized, nil)); resp != nil {
+		t.Fatalf("initialized notification got a response: %+v", resp)
+	}
+	if session.State() != SessionReady {
+		t.Fatalf("after initialized: state %s", session.State())
+	}
+	if text := resultText(t, handler.HandleRequest(ctx, session, callRequest(6, "echo", map[string]interface{}{"text": "ok"}))); text != "echo: ok" {
+		t.Fatalf("tools/call when ready = %q", text)
+	}
+
+	session.Shutdown()
+	resp := handler.HandleRequest(ctx, session, callRequest(7, "echo", nil))
+	if errorCode(resp) != ErrCodeServerNotInitialized || resp.Error.Message != "Server shutting down" {
+		t.Fatalf("tools/call while shutting down = %+v", resp.Error)
+	}
+	if code := errorCode(handler.HandleRequest(ctx, session, rpc(8, types.MethodPing, nil))); code != 0 {
+		t.Fatalf("ping while shutting down failed with %d", code)
+	}
+	if tool.calls.Load() != 1 {
+		t.Fatalf("tool executed %d times, want only the call made when ready", tool.calls.Load())
+	}
+}
+
+func TestSessionInitializedBeforeInitialize(t *testing.T) {
+	handler, _ := newTestHandler()
+	session := NewSession()
+
+	handler.HandleRequest(context.Background(), session, rpc(nil, types.MethodNotificationInitialized, nil))
+	if session.State() != SessionUninitialized {
+		t.Fatalf("initialized before initialize moved the session to %s", session.State())
+	}
+	if code := errorCode(handler.HandleRequest(context.Background(), session, callRequest(1, "echo", nil))); code != ErrCodeServerNotInitialized {
+		t.Fatalf("tools/call after a premature initialized: code %d", code)
+	}
+}
+
+func TestSessionInitializedRequestGetsResponse(t *testing.T) {
+	handler, _ := newTestHandler()
+	session := NewSession()
+	handler.HandleRequest(context.Background(), session, initializeRequest(1, "2025-06-18"))
+
+	// 旧客户端以请求（带 ID）发送 initialized，需要响应
+	resp := handler.HandleRequest(context.Background(), session, rpc(2, types.MethodInitialized, nil))
+	if resp == nil || resp.Error != nil || resp.ID != 2 {
+		t.Fatalf("initialized request response = %+v", resp)
+	}
+	if session.State() != SessionReady {
+		t.Fatalf("state = %s, want ready", session.State())
+	}
+}
+
+func TestSessionsAreIndependent(t *testing.T) {
+	handler, _ := newTestHandler()
+	ready, fresh := NewSession(), NewSession()
+	handshake(t, handler, ready, "2025-06-18")
+
+	if code := errorCode(handler.HandleRequest(context.Background(), fresh, callRequest(1, "echo", nil))); code != ErrCodeServerNotInitialized {
+		t.Fatalf("another session's handshake leaked: code %d", code)
+	}
+	if code := errorCode(handler.HandleRequest(context.Background(), ready, callRequest(2, "echo", nil))); code != 0 {
+		t.Fatalf("ready session was rejected with %d", code)
+	}
+}
+
+func TestInitializeIncludesHostIdentity(t *testing.T) {
+	handler, _ := newTestHandler()
+	want := identity.Get()
+
+	// 主机身份作为 _meta 扩展随 initialize 结果返回，两个会话看到的相同
+	for _, session := range []*Session{NewSession(), NewSession()} {
+		var resp struct {
+			Result struct {
+				Meta struct {
+					Host *types.HostIdentity `json:"host"`
+				} `json:"_meta"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(responseJSON(t, handler.HandleRequest(context.Background(), session, initializeRequest(1, "2025-06-18")))), &resp); err != nil {
+			t.Fatal(err)
+		}
+		host := resp.Result.Meta.Host
+		if host == nil || host.Fingerprint == "" || host.Fingerprint != want.Fingerprint || host.Hostname != want.Hostname {
+			t.Fatalf("initialize _meta.host = %+v, want %+v", host, want)
+		}
+	}
+}
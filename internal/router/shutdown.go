@@ -0,0 +1,119 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// shutdownStep 关闭流程中的一步
+type shutdownStep struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// OnShutdown 注册在 Shutdown 最后执行的关闭步骤（如诊断服务等传输层），按注册顺序执行
+func (r *Router) OnShutdown(name string, run func(ctx context.Context) error) {
+	r.reloadMutex.Lock()
+	defer r.reloadMutex.Unlock()
+
+	r.shutdownHooks = append(r.shutdownHooks, shutdownStep{name: name, run: run})
+}
+
+// Shutdown 按顺序关闭服务器，ctx 限定整个过程的时长：
+//  1. 不再接受新连接，所有会话不再接受新请求，等待进行中的请求结束（超时则取消剩余的请求）后关闭连接
+//  2. 停止所有监视，等待正在进行的执行结束
+//  3. 后台采集器完成当前采样（包括写入存储）后停止
+//  4. 定时任务调度器完成正在执行的任务后停止
+//  5. 取消服务器 ctx，等待后台缓存刷新任务结束
+//  6. 执行通过 OnShutdown 注册的步骤（传输层等）
+//
+// 某一步超时不会阻止后续步骤执行（ctx 到期后每步最多执行 shutdownGrace），返回所有失败步骤的错误。重复调用时不做任何事
+func (r *Router) Shutdown(ctx context.Context) error {
+	r.reloadMutex.Lock()
+	if r.shutdown {
+		r.reloadMutex.Unlock()
+		return nil
+	}
+	r.shutdown = true
+	r.running.Store(false)
+	steps := r.shutdownSteps()
+	r.reloadMutex.Unlock()
+
+	return runShutdown(ctx, steps)
+}
+
+// shutdownSteps Shutdown 依次执行的步骤，调用方需持有 reloadMutex
+func (r *Router) shutdownSteps() []shutdownStep {
+	steps := []shutdownStep{
+		{name: "请求", run: func(ctx context.Context) error {
+			err := r.drainSessions(ctx)
+			r.closeConnections()
+			return err
+		}},
+	}
+	if r.watches != nil {
+		steps = append(steps, shutdownStep{name: "监视", run: r.watches.Stop})
+	}
+	if r.collector != nil {
+		steps = append(steps, shutdownStep{name: "后台采集", run: r.collector.Stop})
+	}
+	if r.scheduler != nil {
+		steps = append(steps, shutdownStep{name: "定时任务", run: r.scheduler.Stop})
+	}
+	steps = append(steps, shutdownStep{name: "后台刷新", run: func(ctx context.Context) error {
+		r.cancel()
+		return waitContext(ctx, r.revalidator.Wait)
+	}})
+	return append(steps, r.shutdownHooks...)
+}
+
+// shutdownGrace ctx 到期后每个剩余步骤的最长执行时间，使传输层等能立即完成的步骤仍然执行
+const shutdownGrace = time.Second
+
+// runShutdown 依次执行关闭步骤，每一步最多等待到 ctx 到期，超时或失败的步骤记录后继续执行下一步。
+// ctx 到期后的步骤各自最多执行 shutdownGrace
+func runShutdown(ctx context.Context, steps []shutdownStep) error {
+	var errs []error
+	for _, step := range steps {
+		stepCtx, cancel := ctx, context.CancelFunc(func() {})
+		if ctx.Err() != nil {
+			stepCtx, cancel = context.WithTimeout(context.WithoutCancel(ctx), shutdownGrace)
+		}
+		result := make(chan error, 1)
+		go func(step shutdownStep) {
+			result <- step.run(stepCtx)
+		}(step)
+
+		var err error
+		select {
+		case err = <-result:
+		case <-stepCtx.Done():
+			err = stepCtx.Err()
+		}
+		cancel()
+		if err != nil {
+			slog.Warn("关闭步骤未正常完成", "step", step.name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", step.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// waitContext 等待 wait 返回，ctx 先到期时返回 ctx 的错误
+func waitContext(ctx context.Context, wait func()) error {
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,185 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingTool 调用后阻塞，直到 release 关闭或请求被取消
+type blockingTool struct {
+	echoTool
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingTool(name string) *blockingTool {
+	return &blockingTool{echoTool: echoTool{name: name}, started: make(chan struct{}, 1), release: make(chan struct{})}
+}
+
+func (bt *blockingTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	bt.started <- struct{}{}
+	select {
+	case <-bt.release:
+		return "done", nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// eventLog 按发生顺序记录关闭过程中的事件
+type eventLog struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (el *eventLog) add(event string) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+	el.events = append(el.events, event)
+}
+
+func (el *eventLog) list() []string {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+	return slices.Clone(el.events)
+}
+
+// step 记录执行顺序的关闭步骤，block 为 true 时一直等到 ctx 到期
+func (el *eventLog) step(name string, block bool, err error) shutdownStep {
+	return shutdownStep{name: name, run: func(ctx context.Context) error {
+		el.add(name)
+		if block {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return err
+	}}
+}
+
+func TestShutdownStepOrder(t *testing.T) {
+	r := newDefaultRouter(t)
+	r.OnShutdown("stdio", func(context.Context) error { return nil })
+	r.OnShutdown("diagnostics", func(context.Context) error { return nil })
+
+	var names []string
+	for _, step := range r.shutdownSteps() {
+		names = append(names, step.name)
+	}
+	// 先结束请求，再停止采集和定时任务，传输层最后关闭
+	want := []string{"请求", "监视", "后台采集", "定时任务", "后台刷新", "stdio", "diagnostics"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("shutdown steps = %v, want %v", names, want)
+	}
+}
+
+func TestRunShutdownContinuesAfterTimeout(t *testing.T) {
+	var log eventLog
+	broken := errors.New("close failed")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := runShutdown(ctx, []shutdownStep{
+		log.step("请求", false, nil),
+		log.step("后台采集", true, nil),
+		log.step("定时任务", false, broken),
+		log.step("stdio", false, nil),
+	})
+	// 超时的步骤不阻止后续步骤执行，错误包含所有失败的步骤
+	if got := log.list(); !slices.Equal(got, []string{"请求", "后台采集", "定时任务", "stdio"}) {
+		t.Errorf("steps ran in order %v", got)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, broken) {
+		t.Fatalf("runShutdown = %v, want the timeout and the failed step", err)
+	}
+	for _, name := range []string{"后台采集: ", "定时任务: "} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q does not name step %q", err, name)
+		}
+	}
+	if strings.Contains(err.Error(), "请求") || strings.Contains(err.Error(), "stdio") {
+		t.Errorf("error %q names a step that completed", err)
+	}
+}
+
+func TestShutdownWaitsForRequestsBeforeTransports(t *testing.T) {
+	r := startTestRouter(t, nil)
+	tool := newBlockingTool("slow")
+	r.RegisterTool(tool)
+	var log eventLog
+	r.OnShutdown("transport", func(context.Context) error {
+		log.add("transport")
+		return nil
+	})
+	client := connectClient(t, r)
+	client.setup(t, "")
+	client.send(t, callRequest(2, "slow", nil))
+	<-tool.started
+
+	done := make(chan error, 1)
+	go func() { done <- r.Shutdown(context.Background()) }()
+
+	// 进行中的请求结束之前不关闭传输层
+	time.Sleep(50 * time.Millisecond)
+	if got := log.list(); len(got) != 0 {
+		t.Fatalf("%v ran while a request was in flight", got)
+	}
+	log.add("request")
+	close(tool.release)
+	if resp := client.next(t); resp["id"] != float64(2) || resp["error"] != nil {
+		t.Fatalf("in-flight request got %v, want its result", resp)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Shutdown = %v", err)
+	}
+	if got := log.list(); !slices.Equal(got, []string{"request", "transport"}) {
+		t.Errorf("events = %v, want the request to finish before the transport closes", got)
+	}
+	if r.isRunning() {
+		t.Error("router still running after Shutdown")
+	}
+	// 重复调用不再执行关闭步骤
+	if err := r.Shutdown(context.Background()); err != nil || len(log.list()) != 2 {
+		t.Errorf("second Shutdown = %v, events %v", err, log.list())
+	}
+}
+
+func TestShutdownTimeoutCancelsRequests(t *testing.T) {
+	r := startTestRouter(t, nil)
+	tool := newBlockingTool("slow")
+	r.RegisterTool(tool)
+	var log eventLog
+	r.OnShutdown("transport", func(context.Context) error {
+		log.add("transport")
+		return nil
+	})
+	client := connectClient(t, r)
+	client.setup(t, "")
+	client.send(t, callRequest(2, "slow", nil))
+	<-tool.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := r.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "请求: ") {
+		t.Fatalf("Shutdown = %v, want the request step to time out", err)
+	}
+	// 超时后取消剩余的请求，传输层仍然关闭
+	if got := log.list(); !slices.Equal(got, []string{"transport"}) {
+		t.Errorf("events = %v, want the transport closed after the timeout", got)
+	}
+	select {
+	case message, ok := <-client.messages:
+		// 被取消的请求返回工具错误，或者连接直接关闭
+		if result, _ := message["result"].(map[string]interface{}); ok && result["isError"] != true {
+			t.Errorf("cancelled request got a result: %v", message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection not closed after Shutdown")
+	}
+}
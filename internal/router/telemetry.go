@@ -0,0 +1,81 @@
+package router
+
+import (
+	"context"
+	"time"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/telemetry"
+	"mcp-example/internal/tools"
+)
+
+// systemGauges 导出系统指标时读取数据的工具，与工具调用共用缓存
+type systemGauges struct {
+	cpu     *tools.CPUTool
+	memory  *tools.MemoryTool
+	disk    *tools.DiskTool
+	network *tools.NetworkTool
+	system  *tools.SystemTool
+}
+
+// gaugeCPUInterval 导出指标时 CPU 使用率的采样时长
+const gaugeCPUInterval = time.Second
+
+// SetTelemetry 设置工具调用的追踪导出，需在 Start 之前调用
+func (r *Router) SetTelemetry(t telemetry.Telemetry) {
+	r.handler.SetTelemetry(t)
+}
+
+// TelemetrySnapshot 读取一次指标导出的数据：系统指标（与后台采集的样本相同的各项及平均负载）、
+// 诊断状态和各工具的调用统计。可在任意 goroutine 中调用，工具尚未初始化时只包含内部计数
+func (r *Router) TelemetrySnapshot(ctx context.Context) telemetry.Snapshot {
+	snapshot := telemetry.Snapshot{
+		Status: r.DiagnosticsStatus(),
+		Tools:  r.handler.ToolStats(),
+	}
+	snapshot.System.Timestamp = time.Now()
+	snapshot.System.Host = identity.Get()
+
+	r.reloadMutex.Lock()
+	gauges := r.gauges
+	r.reloadMutex.Unlock()
+	if gauges == nil {
+		snapshot.Missing = []string{"cpu", "memory", "disk", "network", "load"}
+		return snapshot
+	}
+
+	if cpuInfo, err := gauges.cpu.GetCPUData(ctx, gaugeCPUInterval); err == nil {
+		snapshot.System.CPUPercent = cpuInfo.Usage.Total
+	} else {
+		snapshot.Missing = append(snapshot.Missing, "cpu")
+	}
+	if memInfo, err := gauges.memory.GetMemoryData(ctx); err == nil {
+		snapshot.System.MemoryPercent = memInfo.UsedPercent
+	} else {
+		snapshot.Missing = append(snapshot.Missing, "memory")
+	}
+	if diskInfo, err := gauges.disk.GetDiskData(ctx, false); err == nil {
+		snapshot.System.DiskPercent = make(map[string]float64, len(diskInfo.Partitions))
+		for _, partition := range diskInfo.Partitions {
+			snapshot.System.DiskPercent[partition.Mountpoint] = partition.UsedPercent
+		}
+	} else {
+		snapshot.Missing = append(snapshot.Missing, "disk")
+	}
+	if netInfo, err := gauges.network.GetNetworkData(ctx, false, ""); err == nil {
+		snapshot.System.NetRxBytes = make(map[string]uint64, len(netInfo.Interfaces))
+		snapshot.System.NetTxBytes = make(map[string]uint64, len(netInfo.Interfaces))
+		for _, iface := range netInfo.Interfaces {
+			snapshot.System.NetRxBytes[iface.Name] = iface.BytesRecv
+			snapshot.System.NetTxBytes[iface.Name] = iface.BytesSent
+		}
+	} else {
+		snapshot.Missing = append(snapshot.Missing, "network")
+	}
+	if systemInfo, err := gauges.system.GetSystemData(ctx, true); err == nil && systemInfo.Load != nil {
+		snapshot.Load = systemInfo.Load
+	} else {
+		snapshot.Missing = append(snapshot.Missing, "load")
+	}
+	return snapshot
+}
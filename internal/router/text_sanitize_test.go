@@ -0,0 +1,38 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"unicode/utf8"
+
+	"mcp-example/internal/types"
+)
+
+// rawTextTool 原样返回 text 的测试工具，err 不为 nil 时返回该错误
+type rawTextTool struct {
+	echoTool
+	text string
+	err  error
+}
+
+func (rt *rawTextTool) Execute(context.Context, map[string]interface{}) (string, error) {
+	return rt.text, rt.err
+}
+
+func TestCallToolSanitizesInvalidUTF8(t *testing.T) {
+	handler := NewMCPHandler("test-server", "0.0.0")
+	handler.RegisterTool(&rawTextTool{echoTool: echoTool{name: "raw"}, text: "进程 bad\xff\xfename 数据\xe6\x95"})
+	handler.RegisterTool(&rawTextTool{echoTool: echoTool{name: "failing"}, err: errors.New("无法读取 /proc/42/comm: \xc3(")})
+
+	text := resultText(t, handler.HandleRequest(context.Background(), nil, callRequest(1, "raw", nil)))
+	if text != "进程 bad�name 数据�" {
+		t.Fatalf("text = %q, want invalid bytes replaced and valid multibyte text kept", text)
+	}
+
+	resp := handler.HandleRequest(context.Background(), nil, callRequest(2, "failing", nil))
+	result := resp.Result.(types.CallToolResult)
+	if !result.IsError || !utf8.ValidString(result.Content[0].Text) {
+		t.Fatalf("error result = %q, want valid UTF-8", result.Content[0].Text)
+	}
+}
@@ -0,0 +1,99 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/tools"
+	"mcp-example/internal/types"
+)
+
+// failingTool 返回 err 的工具，err 为 nil 时阻塞到 ctx 结束
+type failingTool struct {
+	echoTool
+	err error
+}
+
+func (ft *failingTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	if ft.err == nil {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	return "", ft.err
+}
+
+// toolError 工具错误结果，不是工具错误时测试失败
+func toolError(t *testing.T, resp *types.JSONRPCResponse) (string, types.ToolError) {
+	t.Helper()
+	result, ok := resp.Result.(types.CallToolResult)
+	if resp.Error != nil || !ok || !result.IsError {
+		t.Fatalf("response = %s, want a tool error result", responseJSON(t, resp))
+	}
+	return result.Content[0].Text, result.StructuredContent.(map[string]interface{})["error"].(types.ToolError)
+}
+
+func TestToolErrorShape(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		code     string
+		text     string
+		argument string
+	}{
+		{
+			name: "permission",
+			err:  fmt.Errorf("读取 /proc/1/io: %w", fs.ErrPermission),
+			code: "ERR_PERMISSION",
+			text: "❌ 读取 /proc/1/io: permission denied\n错误代码: ERR_PERMISSION\n💡 权限不足，可尝试以 root（或管理员）身份运行服务器",
+		},
+		{
+			name: "unsupported platform",
+			err:  errors.New("not implemented yet"),
+			code: "ERR_UNSUPPORTED_PLATFORM",
+			text: "❌ not implemented yet\n错误代码: ERR_UNSUPPORTED_PLATFORM\n💡 该指标在当前平台上不可用，请改用其他工具",
+		},
+		{
+			name: "internal without hint",
+			err:  errors.New("unexpected EOF"),
+			code: "ERR_INTERNAL",
+			text: "❌ unexpected EOF\n错误代码: ERR_INTERNAL",
+		},
+		{
+			name:     "bad argument",
+			err:      &tools.Error{Code: tools.ErrBadArgument, Message: "limit 应为正整数", Argument: "limit"},
+			code:     "ERR_BAD_ARGUMENT",
+			text:     "❌ limit 应为正整数\n错误代码: ERR_BAD_ARGUMENT",
+			argument: "limit",
+		},
+	}
+	for _, c := range cases {
+		handler := NewMCPHandler("test-server", "0.0.0")
+		handler.RegisterTool(&failingTool{echoTool: echoTool{name: "fail"}, err: c.err})
+		text, toolErr := toolError(t, handler.HandleRequest(context.Background(), nil, callRequest(1, "fail", nil)))
+
+		// 文本和 structuredContent 给出相同的代码、说明、提示和追踪 ID
+		if want := c.text + "\n🔎 追踪 ID: " + toolErr.TraceID; toolErr.TraceID == "" || text != want {
+			t.Errorf("%s: text = %q, want %q", c.name, text, want)
+		}
+		if toolErr.Code != c.code || toolErr.Message != c.err.Error() || toolErr.Argument != c.argument || !strings.Contains(text, toolErr.Hint) {
+			t.Errorf("%s: structured error = %+v", c.name, toolErr)
+		}
+	}
+}
+
+func TestToolTimeoutError(t *testing.T) {
+	handler := NewMCPHandler("test-server", "0.0.0")
+	handler.RegisterTool(&failingTool{echoTool: echoTool{name: "slow"}})
+	handler.SetToolTimeout(20 * time.Millisecond)
+
+	// 超过服务器的工具超时时报告超时时长，而不是工具返回的 context 错误
+	text, toolErr := toolError(t, handler.HandleRequest(context.Background(), nil, callRequest(1, "slow", nil)))
+	if toolErr.Code != "ERR_TIMEOUT" || !strings.HasPrefix(toolErr.Message, "工具执行超时（20ms）") || !strings.Contains(text, "--tool-timeout") {
+		t.Fatalf("timeout = %q, %+v", text, toolErr)
+	}
+}
@@ -0,0 +1,111 @@
+package router
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"mcp-example/internal/tools"
+	"mcp-example/internal/types"
+)
+
+// toolLimiter 按工具进行令牌桶限流（所有会话共享），并按工具和参数保存受限工具最近一次成功调用的结果，
+// 相同参数的调用被拒绝时随错误一起返回，避免调用方完全得不到数据。并发安全
+type toolLimiter struct {
+	mutex   sync.Mutex
+	limits  map[string]RateLimit
+	buckets map[string]*tokenBucket
+	// last 以 tools.CallKey 为键，参数不同的调用结果互不替代
+	last map[string]lastResult
+	// now 当前时间，测试中可替换
+	now func() time.Time
+}
+
+// maxRememberedResults 最多保存的调用结果数量，超出时丢弃最早保存的结果
+const maxRememberedResults = 64
+
+// lastResult 工具最近一次成功调用的结果
+type lastResult struct {
+	result types.CallToolResult
+	at     time.Time
+}
+
+// newToolLimiter 创建工具限流器，未出现在 limits 中的工具不受限制
+func newToolLimiter(limits map[string]RateLimit, now func() time.Time) *toolLimiter {
+	return &toolLimiter{
+		limits:  limits,
+		buckets: make(map[string]*tokenBucket),
+		last:    make(map[string]lastResult),
+		now:     now,
+	}
+}
+
+// limited 工具是否配置了限流
+func (l *toolLimiter) limited(tool string) bool {
+	_, found := l.limits[tool]
+	return found
+}
+
+// allow 尝试为工具取出一个令牌，被拒绝时返回下一个令牌可用前需等待的时间
+func (l *toolLimiter) allow(tool string) (bool, time.Duration) {
+	limit, found := l.limits[tool]
+	if !found {
+		return true, 0
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	bucket, exists := l.buckets[tool]
+	if !exists {
+		bucket = &tokenBucket{limit: limit, tokens: float64(limit.Burst), last: now}
+		l.buckets[tool] = bucket
+	}
+	if bucket.allow(now) {
+		return true, 0
+	}
+	seconds := math.Ceil((1 - bucket.tokens) / limit.Rate)
+	return false, time.Duration(seconds) * time.Second
+}
+
+// remember 保存受限工具以 args 调用时最近一次成功的结果
+func (l *toolLimiter) remember(tool string, args map[string]interface{}, result types.CallToolResult) {
+	if !l.limited(tool) {
+		return
+	}
+	key := tools.CallKey(tool, args)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if _, found := l.last[key]; !found && len(l.last) >= maxRememberedResults {
+		l.evictOldest()
+	}
+	l.last[key] = lastResult{result: result, at: l.now()}
+}
+
+// evictOldest 丢弃最早保存的结果，调用方需持有锁
+func (l *toolLimiter) evictOldest() {
+	var oldestKey string
+	var oldest time.Time
+	for key, last := range l.last {
+		if oldestKey == "" || last.at.Before(oldest) {
+			oldestKey, oldest = key, last.at
+		}
+	}
+	delete(l.last, oldestKey)
+}
+
+// cached 获取工具以 args 调用时最近一次成功的结果及其距今的时长，参数不同的调用结果不会返回
+func (l *toolLimiter) cached(tool string, args map[string]interface{}) (types.CallToolResult, time.Duration, bool) {
+	key := tools.CallKey(tool, args)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	last, found := l.last[key]
+	if !found {
+		return types.CallToolResult{}, 0, false
+	}
+	return last.result, l.now().Sub(last.at), true
+}
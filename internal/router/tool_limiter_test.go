@@ -0,0 +1,199 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/tools"
+	"mcp-example/internal/types"
+)
+
+// testClock 测试中手动推进的时钟
+type testClock struct {
+	now time.Time
+}
+
+func newTestClock() *testClock {
+	return &testClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+}
+
+func (c *testClock) Now() time.Time          { return c.now }
+func (c *testClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestToolLimiterAllow(t *testing.T) {
+	clock := newTestClock()
+	limiter := newToolLimiter(map[string]RateLimit{"top_processes": {Rate: 0.5, Burst: 2}}, clock.Now)
+
+	for i := 1; i <= 2; i++ {
+		if allowed, _ := limiter.allow("top_processes"); !allowed {
+			t.Fatalf("call %d within the burst was limited", i)
+		}
+	}
+	allowed, retryAfter := limiter.allow("top_processes")
+	if allowed || retryAfter != 2*time.Second {
+		t.Fatalf("allow() = %v, %s; want limited with a 2s retry at 0.5 tokens per second", allowed, retryAfter)
+	}
+
+	clock.Advance(time.Second)
+	if allowed, retryAfter := limiter.allow("top_processes"); allowed || retryAfter != time.Second {
+		t.Fatalf("after 1s allow() = %v, %s; want limited with the remaining 1s", allowed, retryAfter)
+	}
+	clock.Advance(time.Second)
+	if allowed, _ := limiter.allow("top_processes"); !allowed {
+		t.Fatal("a token should be refilled after 2s")
+	}
+	if allowed, _ := limiter.allow("top_processes"); allowed {
+		t.Fatal("only one token should be refilled after 2s")
+	}
+
+	// 桶容量之外不累积令牌
+	clock.Advance(time.Hour)
+	for i := 1; i <= 3; i++ {
+		if allowed, _ := limiter.allow("top_processes"); allowed != (i <= 2) {
+			t.Fatalf("call %d after an idle hour allowed = %v, want the burst of 2 only", i, allowed)
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		if allowed, _ := limiter.allow("cpu_info"); !allowed {
+			t.Fatal("a tool without a limit was limited")
+		}
+	}
+}
+
+// textResult 只包含一段文本的调用结果
+func textResult(text string) types.CallToolResult {
+	return types.CallToolResult{Content: []types.Content{{Type: "text", Text: text}}}
+}
+
+func TestToolLimiterCachedByArgs(t *testing.T) {
+	clock := newTestClock()
+	limiter := newToolLimiter(map[string]RateLimit{"top_processes": {Rate: 1, Burst: 1}}, clock.Now)
+
+	limiter.remember("top_processes", map[string]interface{}{"sort_by": "cpu", "limit": 5}, textResult("by cpu"))
+	limiter.remember("top_processes", map[string]interface{}{"sort_by": "memory", "limit": 5}, textResult("by memory"))
+	clock.Advance(12 * time.Second)
+
+	// 参数顺序、缓存控制参数和空值不影响匹配
+	result, age, found := limiter.cached("top_processes", map[string]interface{}{"limit": 5, "sort_by": "cpu", "cache": "fresh", "filter": ""})
+	if !found || result.Content[0].Text != "by cpu" || age != 12*time.Second {
+		t.Fatalf("cached() = %+v, %s, %v; want the cpu result from 12s ago", result, age, found)
+	}
+	if result, _, found := limiter.cached("top_processes", map[string]interface{}{"sort_by": "memory", "limit": 5}); !found || result.Content[0].Text != "by memory" {
+		t.Fatalf("cached() = %+v, %v; want the memory result kept separately", result, found)
+	}
+	for _, args := range []map[string]interface{}{
+		{"sort_by": "cpu", "limit": 10},
+		{"sort_by": "cpu", "limit": "5"},
+		{"sort_by": "cpu"},
+		nil,
+	} {
+		if result, _, found := limiter.cached("top_processes", args); found {
+			t.Errorf("cached(%v) = %+v, want no result for different arguments", args, result)
+		}
+	}
+
+	limiter.remember("cpu_info", nil, textResult("unlimited"))
+	if _, _, found := limiter.cached("cpu_info", nil); found {
+		t.Error("results of a tool without a limit should not be kept")
+	}
+}
+
+func TestToolLimiterEvictsOldest(t *testing.T) {
+	clock := newTestClock()
+	limiter := newToolLimiter(map[string]RateLimit{"top_processes": {Rate: 1, Burst: 1}}, clock.Now)
+
+	for i := 0; i <= maxRememberedResults; i++ {
+		limiter.remember("top_processes", map[string]interface{}{"limit": i}, textResult(fmt.Sprint(i)))
+		clock.Advance(time.Second)
+	}
+	if len(limiter.last) != maxRememberedResults {
+		t.Fatalf("kept %d results, want at most %d", len(limiter.last), maxRememberedResults)
+	}
+	if _, _, found := limiter.cached("top_processes", map[string]interface{}{"limit": 0}); found {
+		t.Error("the oldest result should be evicted first")
+	}
+	if _, _, found := limiter.cached("top_processes", map[string]interface{}{"limit": maxRememberedResults}); !found {
+		t.Error("the newest result should be kept")
+	}
+}
+
+// newLimitedHandler 注册了 echo 工具、echo 每秒限一次调用的处理器
+func newLimitedHandler(clock *testClock) (*MCPHandler, *echoTool) {
+	handler, tool := newTestHandler()
+	handler.limiter = newToolLimiter(map[string]RateLimit{"echo": {Rate: 1, Burst: 1}}, clock.Now)
+	return handler, tool
+}
+
+// allResultText 调用结果中各段文本，以换行连接
+func allResultText(t *testing.T, resp *types.JSONRPCResponse) string {
+	t.Helper()
+	result, ok := resp.Result.(types.CallToolResult)
+	if !ok {
+		t.Fatalf("response result = %#v, want a tool call result", resp.Result)
+	}
+	texts := make([]string, len(result.Content))
+	for i, content := range result.Content {
+		texts[i] = content.Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+func TestRateLimitedCallReturnsResultForSameArgs(t *testing.T) {
+	clock := newTestClock()
+	handler, tool := newLimitedHandler(clock)
+	call := func(id int, text string) *types.JSONRPCResponse {
+		return handler.HandleRequest(context.Background(), nil, callRequest(id, "echo", map[string]interface{}{"text": text}))
+	}
+
+	if text := allResultText(t, call(1, "a")); text != "echo: a" {
+		t.Fatalf("first call = %q", text)
+	}
+	clock.Advance(3 * time.Second)
+	if text := allResultText(t, call(2, "b")); text != "echo: b" {
+		t.Fatalf("call after refill = %q", text)
+	}
+
+	limited := allResultText(t, call(3, "a"))
+	if !strings.Contains(limited, string(tools.ErrRateLimited)) || !strings.HasSuffix(limited, "\necho: a") || strings.Contains(limited, "echo: b") {
+		t.Fatalf("limited call with text=a = %q, want the error followed by the earlier result for text=a only", limited)
+	}
+	limited = allResultText(t, call(4, "c"))
+	if !strings.Contains(limited, string(tools.ErrRateLimited)) || strings.Contains(limited, "echo:") || strings.Contains(limited, "cached data") {
+		t.Fatalf("limited call with text=c = %q, want only the error for arguments never called before", limited)
+	}
+	if tool.calls.Load() != 2 {
+		t.Fatalf("tool executed %d times, want limited calls rejected before the tool", tool.calls.Load())
+	}
+}
+
+func TestCallToolAppliesRateLimit(t *testing.T) {
+	clock := newTestClock()
+	handler, tool := newLimitedHandler(clock)
+	args := map[string]interface{}{"text": "a"}
+
+	if text, _, err := handler.CallTool(context.Background(), "echo", args); err != nil || text != "echo: a" {
+		t.Fatalf("CallTool() = %q, %v", text, err)
+	}
+	_, _, err := handler.CallTool(context.Background(), "echo", args)
+	var toolErr *tools.Error
+	if !errors.As(err, &toolErr) || toolErr.Code != tools.ErrRateLimited {
+		t.Fatalf("second CallTool() error = %v, want ErrRateLimited", err)
+	}
+
+	// 内部调用与 tools/call 共用令牌桶
+	if text := allResultText(t, handler.HandleRequest(context.Background(), nil, callRequest(1, "echo", args))); !strings.Contains(text, string(tools.ErrRateLimited)) {
+		t.Fatalf("tools/call after an internal call = %q, want it limited by the shared bucket", text)
+	}
+	clock.Advance(time.Second)
+	if _, _, err := handler.CallTool(context.Background(), "echo", args); err != nil {
+		t.Fatalf("CallTool() after refill = %v", err)
+	}
+	if tool.calls.Load() != 2 {
+		t.Fatalf("tool executed %d times, want 2", tool.calls.Load())
+	}
+}
@@ -0,0 +1,59 @@
+package router
+
+import (
+	"fmt"
+	"sort"
+
+	"mcp-example/internal/tools"
+)
+
+// ToolListing 已注册工具的名称、描述和可用状态，用于帮助信息和启动日志
+type ToolListing struct {
+	Name        string
+	Description string
+	// Disabled 被访问策略禁用
+	Disabled bool
+	// PlatformNote 当前平台受限或不支持时的说明，完整支持时为空
+	PlatformNote string
+}
+
+// ToolListings 列出已注册的工具（按名称排序），需在 InitializeTools 之后调用
+func (r *Router) ToolListings() []ToolListing {
+	policy := r.handler.currentPolicy()
+
+	registered := r.handler.registeredTools()
+	listings := make([]ToolListing, 0, len(registered))
+	for name, tool := range registered {
+		listings = append(listings, ToolListing{
+			Name:         name,
+			Description:  tool.GetDescription(),
+			Disabled:     !policy.Allows(tool),
+			PlatformNote: tools.HostPlatformNote(name),
+		})
+	}
+	sort.Slice(listings, func(i, j int) bool {
+		return listings[i].Name < listings[j].Name
+	})
+	return listings
+}
+
+// FormatToolListings 格式化工具列表，每个工具一行，被禁用或平台受限时在行尾标注
+func FormatToolListings(listings []ToolListing) string {
+	width := 0
+	for _, listing := range listings {
+		width = max(width, len(listing.Name))
+	}
+
+	var result string
+	for _, listing := range listings {
+		result += fmt.Sprintf("  • %-*s - %s", width, listing.Name, listing.Description)
+		if listing.Disabled {
+			result += "（🚫 已被访问策略禁用）"
+		}
+		if listing.PlatformNote != "" {
+			result += fmt.Sprintf("（%s）", listing.PlatformNote)
+		}
+		result += "\n"
+	}
+	return result
+}
@@ -0,0 +1,78 @@
+package router
+
+import (
+	"slices"
+	"testing"
+
+	"mcp-example/internal/tools"
+)
+
+func TestToolListingsMatchRegistry(t *testing.T) {
+	r := newDefaultRouter(t)
+	registered := r.handler.registeredTools()
+
+	// 每个已注册的工具一项，按名称排序，描述和平台说明来自工具本身和平台支持表
+	listings := r.ToolListings()
+	var names []string
+	for _, listing := range listings {
+		names = append(names, listing.Name)
+		tool, found := registered[listing.Name]
+		if !found {
+			t.Errorf("%s is listed but not registered", listing.Name)
+			continue
+		}
+		if listing.Description != tool.GetDescription() || listing.PlatformNote != tools.HostPlatformNote(listing.Name) || listing.Disabled {
+			t.Errorf("listing = %+v", listing)
+		}
+	}
+	if len(listings) != len(registered) || !slices.IsSorted(names) {
+		t.Errorf("listed %v, registered %d tools", names, len(registered))
+	}
+
+	// 重复初始化不会重复注册
+	if err := r.InitializeTools(); err != nil || len(r.ToolListings()) != len(listings) {
+		t.Errorf("second InitializeTools() = %v, %d listings", err, len(r.ToolListings()))
+	}
+}
+
+func TestToolListingsMarkDisabledTools(t *testing.T) {
+	r := newDefaultRouter(t)
+	r.SetPolicy(Policy{DenyTools: []string{"cpu_info"}, ReadOnly: true})
+
+	// 被拒绝的工具和只读模式下的操作工具都标注为禁用
+	want := map[string]bool{"cpu_info": true, "process_signal": true, "memory_info": false}
+	for _, listing := range r.ToolListings() {
+		disabled, found := want[listing.Name]
+		if !found {
+			continue
+		}
+		if listing.Disabled != disabled {
+			t.Errorf("%s: Disabled = %v, want %v", listing.Name, listing.Disabled, disabled)
+		}
+		delete(want, listing.Name)
+	}
+	if len(want) > 0 {
+		t.Errorf("not listed: %v", want)
+	}
+}
+
+func TestFormatToolListings(t *testing.T) {
+	listings := []ToolListing{
+		{Name: "cpu_info", Description: "CPU 信息"},
+		{Name: "process_signal", Description: "发送信号", Disabled: true},
+		{Name: "open_files", Description: "打开的文件", PlatformNote: "❌ 不支持"},
+	}
+	want := "  • cpu_info       - CPU 信息\n" +
+		"  • process_signal - 发送信号（🚫 已被访问策略禁用）\n" +
+		"  • open_files     - 打开的文件（❌ 不支持）\n"
+	if got := FormatToolListings(listings); got != want {
+		t.Errorf("FormatToolListings() =\n%s\nwant\n%s", got, want)
+	}
+	if got := FormatToolListings(nil); got != "" {
+		t.Errorf("FormatToolListings(nil) = %q", got)
+	}
+	// 名称按最长的一个对齐
+	if got := FormatToolListings(listings[:1]); got != "  • cpu_info - CPU 信息\n" {
+		t.Errorf("single listing = %q", got)
+	}
+}
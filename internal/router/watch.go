@@ -0,0 +1,281 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"mcp-example/internal/scheduler"
+	"mcp-example/internal/tools"
+	"mcp-example/internal/types"
+)
+
+// 监视的限制
+const (
+	// maxWatchesPerSession 每个会话同时运行的监视数上限
+	maxWatchesPerSession = 5
+	// watchRunTimeout 单次执行的时限
+	watchRunTimeout = time.Minute
+)
+
+// watchLogger 监视推送的 notifications/message 中的 logger
+const watchLogger = "watch"
+
+// watch 一个运行中的监视
+type watch struct {
+	seq      int
+	info     types.WatchInfo
+	interval time.Duration
+	session  *Session
+	cancel   context.CancelFunc
+}
+
+// watchManager 管理所有会话的监视：每个监视在单独的 goroutine 中依次执行工具，并把结果推送给启动它的会话。
+// 监视在 watch_stop、到期、会话结束或服务器关闭时停止
+type watchManager struct {
+	ctx    context.Context
+	call   tools.ToolCallFunc
+	notify func(*Session, *types.JSONRPCNotification)
+	clock  scheduler.Clock
+
+	mutex   sync.Mutex
+	watches map[string]*watch
+	nextSeq int
+	stopped bool
+	running sync.WaitGroup
+}
+
+// newWatchManager 创建监视管理器，ctx 取消时所有监视停止。call 用于调用工具（经过访问策略检查和参数校验），
+// notify 向会话所在的连接发送通知，clock 为 nil 时使用系统时钟
+func newWatchManager(ctx context.Context, call tools.ToolCallFunc, notify func(*Session, *types.JSONRPCNotification), clock scheduler.Clock) *watchManager {
+	if clock == nil {
+		clock = scheduler.SystemClock()
+	}
+	return &watchManager{
+		ctx:     ctx,
+		call:    call,
+		notify:  notify,
+		clock:   clock,
+		watches: make(map[string]*watch),
+	}
+}
+
+// StartWatch 为 ctx 中的会话启动监视，第一次执行立即开始
+func (wm *watchManager) StartWatch(ctx context.Context, tool string, args map[string]interface{}, interval, duration time.Duration) (types.WatchInfo, error) {
+	session, ok := SessionFromContext(ctx)
+	if !ok {
+		return types.WatchInfo{}, &tools.Error{
+			Code:    tools.ErrBadArgument,
+			Message: "监视只能由客户端会话启动",
+			Hint:    "定时任务等服务器内部调用没有可推送结果的会话，请改用 schedule_admin",
+		}
+	}
+
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+
+	if wm.stopped {
+		return types.WatchInfo{}, &tools.Error{Code: tools.ErrInternal, Message: "服务器正在关闭，无法启动监视"}
+	}
+	if count := len(wm.sessionWatches(session)); count >= maxWatchesPerSession {
+		return types.WatchInfo{}, &tools.Error{
+			Code:    tools.ErrRateLimited,
+			Message: fmt.Sprintf("每个会话最多同时运行 %d 个监视", maxWatchesPerSession),
+			Hint:    "请先通过 watch_stop 停止不再需要的监视",
+		}
+	}
+
+	wm.nextSeq++
+	now := wm.clock.Now()
+	watchCtx, cancel := context.WithCancel(wm.ctx)
+	w := &watch{
+		seq: wm.nextSeq,
+		info: types.WatchInfo{
+			ID:        fmt.Sprintf("watch-%d", wm.nextSeq),
+			SessionID: session.ID(),
+			Tool:      tool,
+			Arguments: args,
+			Interval:  interval.String(),
+			StartedAt: now,
+			ExpiresAt: now.Add(duration),
+		},
+		interval: interval,
+		session:  session,
+		cancel:   cancel,
+	}
+	wm.watches[w.info.ID] = w
+
+	wm.running.Add(1)
+	go wm.run(watchCtx, w)
+	return w.info, nil
+}
+
+// StopWatch 停止 ctx 中的会话启动的监视，其他会话的监视视为不存在
+func (wm *watchManager) StopWatch(ctx context.Context, id string) (types.WatchInfo, error) {
+	session, _ := SessionFromContext(ctx)
+
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+
+	w, found := wm.watches[id]
+	if !found || w.session != session {
+		return types.WatchInfo{}, &tools.Error{Code: tools.ErrNotFound, Message: "监视不存在或已停止: " + id}
+	}
+	w.cancel()
+	delete(wm.watches, id)
+	return w.info, nil
+}
+
+// StopSession 停止会话的所有监视，会话结束时调用
+func (wm *watchManager) StopSession(session *Session) {
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+
+	for _, w := range wm.sessionWatches(session) {
+		w.cancel()
+		delete(wm.watches, w.info.ID)
+	}
+}
+
+// Stop 停止所有监视并等待正在进行的执行结束，之后不再接受新的监视。ctx 到期时返回 ctx 的错误
+func (wm *watchManager) Stop(ctx context.Context) error {
+	wm.mutex.Lock()
+	wm.stopped = true
+	for id, w := range wm.watches {
+		w.cancel()
+		delete(wm.watches, id)
+	}
+	wm.mutex.Unlock()
+
+	return waitContext(ctx, wm.running.Wait)
+}
+
+// List 所有运行中的监视，按启动顺序排列
+func (wm *watchManager) List() []types.WatchInfo {
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+
+	watches := make([]*watch, 0, len(wm.watches))
+	for _, w := range wm.watches {
+		watches = append(watches, w)
+	}
+	sort.Slice(watches, func(i, j int) bool { return watches[i].seq < watches[j].seq })
+
+	infos := make([]types.WatchInfo, 0, len(watches))
+	for _, w := range watches {
+		infos = append(infos, w.info)
+	}
+	return infos
+}
+
+// sessionWatches 会话的监视，需持有 mutex
+func (wm *watchManager) sessionWatches(session *Session) []*watch {
+	var watches []*watch
+	for _, w := range wm.watches {
+		if w.session == session {
+			watches = append(watches, w)
+		}
+	}
+	return watches
+}
+
+// run 依次执行监视直到 ctx 取消或到期。到期时发送一条结束事件，间隔超过剩余时间时等到到期为止
+func (wm *watchManager) run(ctx context.Context, w *watch) {
+	defer wm.running.Done()
+
+	for sequence := 1; ; sequence++ {
+		wm.runOnce(ctx, w, sequence)
+
+		wait, expired := w.interval, false
+		if remaining := w.info.ExpiresAt.Sub(wm.clock.Now()); remaining <= wait {
+			wait, expired = max(remaining, 0), true
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-wm.clock.After(wait):
+		}
+
+		if expired {
+			wm.finish(ctx, w, sequence+1)
+			return
+		}
+	}
+}
+
+// runOnce 执行一次工具并推送结果，ctx 已取消（监视已停止）时不推送
+func (wm *watchManager) runOnce(ctx context.Context, w *watch, sequence int) {
+	start := wm.clock.Now()
+	callCtx, cancel := context.WithTimeout(ctx, watchRunTimeout)
+	text, structured, err := wm.call(callCtx, w.info.Tool, w.info.Arguments)
+	cancel()
+	if ctx.Err() != nil {
+		return
+	}
+
+	event := types.WatchEvent{
+		WatchID:  w.info.ID,
+		Tool:     w.info.Tool,
+		Sequence: sequence,
+		At:       start,
+	}
+	level := "info"
+	if err != nil {
+		toolErr := tools.ClassifyError(err)
+		event.Error = &types.ToolError{Code: string(toolErr.Code), Message: toolErr.Error(), Hint: toolErr.Hint}
+		level = "error"
+		slog.Debug("监视执行失败", "watch", w.info.ID, "tool", w.info.Tool, "error", err)
+	} else {
+		event.Text = text
+		event.Structured = structured
+	}
+
+	wm.mutex.Lock()
+	w.info.Runs++
+	if err != nil {
+		w.info.Errors++
+	}
+	w.info.LastRun = &start
+	wm.mutex.Unlock()
+
+	wm.send(w, level, event)
+}
+
+// finish 监视到期：从管理器中移除并发送结束事件
+func (wm *watchManager) finish(ctx context.Context, w *watch, sequence int) {
+	wm.mutex.Lock()
+	_, active := wm.watches[w.info.ID]
+	delete(wm.watches, w.info.ID)
+	wm.mutex.Unlock()
+	if !active || ctx.Err() != nil {
+		return
+	}
+
+	wm.send(w, "info", types.WatchEvent{
+		WatchID:  w.info.ID,
+		Tool:     w.info.Tool,
+		Sequence: sequence,
+		At:       wm.clock.Now(),
+		Ended:    "expired",
+	})
+}
+
+// send 向监视所属的会话发送 notifications/message。客户端通过 watch_start 明确要求推送，
+// 不受 logging/setLevel 的级别限制，但会话未就绪（包括正在关闭）时不发送
+func (wm *watchManager) send(w *watch, level string, event types.WatchEvent) {
+	if w.session.State() != SessionReady {
+		return
+	}
+	wm.notify(w.session, &types.JSONRPCNotification{
+		JSONRPC: "2.0",
+		Method:  types.MethodLogMessage,
+		Params: types.LogMessageParams{
+			Level:  level,
+			Logger: watchLogger,
+			Data:   event,
+		},
+	})
+}
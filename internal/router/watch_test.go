@@ -0,0 +1,228 @@
+package router
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mcp-example/internal/tools"
+	"mcp-example/internal/types"
+)
+
+// watchClock 手动推进的时钟，After 的每次调用都通过 sleeps 通知测试
+type watchClock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	waiters map[chan time.Time]time.Time
+	sleeps  chan time.Duration
+}
+
+func newWatchClock() *watchClock {
+	return &watchClock{
+		now:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		waiters: make(map[chan time.Time]time.Time),
+		sleeps:  make(chan time.Duration, 64),
+	}
+}
+
+func (wc *watchClock) Now() time.Time {
+	wc.mutex.Lock()
+	defer wc.mutex.Unlock()
+	return wc.now
+}
+
+func (wc *watchClock) After(d time.Duration) <-chan time.Time {
+	wc.mutex.Lock()
+	defer wc.mutex.Unlock()
+	ch := make(chan time.Time, 1)
+	wc.waiters[ch] = wc.now.Add(d)
+	wc.sleeps <- d
+	return ch
+}
+
+// Advance 推进时钟，触发到期的 After
+func (wc *watchClock) Advance(d time.Duration) {
+	wc.mutex.Lock()
+	defer wc.mutex.Unlock()
+	wc.now = wc.now.Add(d)
+	for ch, deadline := range wc.waiters {
+		if !deadline.After(wc.now) {
+			ch <- wc.now
+			delete(wc.waiters, ch)
+		}
+	}
+}
+
+// waitSleep 等待某个监视开始等待 want
+func (wc *watchClock) waitSleep(t *testing.T, want time.Duration) {
+	t.Helper()
+	select {
+	case d := <-wc.sleeps:
+		if d != want {
+			t.Fatalf("watch sleeps %v, want %v", d, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("watch did not start waiting %v", want)
+	}
+}
+
+// startWatchRouter 注册了 echo 和 watch_start、watch_stop 工具的路由器，监视使用 clock
+func startWatchRouter(t *testing.T, clock *watchClock) *Router {
+	t.Helper()
+	r := startTestRouter(t, nil)
+	watches := newWatchManager(r.ctx, r.handler.CallTool, r.sendTo, clock)
+	r.reloadMutex.Lock()
+	r.watches = watches
+	r.reloadMutex.Unlock()
+	t.Cleanup(func() { watches.Stop(context.Background()) })
+	r.RegisterTool(tools.NewWatchStartTool(watches, r.handler.DescribeTool))
+	r.RegisterTool(tools.NewWatchStopTool(watches))
+	return r
+}
+
+// watchEvent 从 notifications/message 中取出监视事件，不是监视推送时测试失败
+func watchEvent(t *testing.T, message map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	params, _ := message["params"].(map[string]interface{})
+	if message["method"] != types.MethodLogMessage || params["logger"] != watchLogger {
+		t.Fatalf("got %v, want a watch notification", message)
+	}
+	return params["data"].(map[string]interface{})
+}
+
+// messageText tools/call 响应中的文本，isError 表示工具返回的错误
+func messageText(t *testing.T, message map[string]interface{}) (text string, isError bool) {
+	t.Helper()
+	result, ok := message["result"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("got %v, want a tools/call result", message)
+	}
+	content := result["content"].([]interface{})
+	return content[0].(map[string]interface{})["text"].(string), result["isError"] == true
+}
+
+// startWatch 调用 watch_start，返回响应和第一次执行的推送（两者到达的先后顺序不定）
+func startWatch(t *testing.T, client *testClient, id int, interval, duration string) (text string, event map[string]interface{}) {
+	t.Helper()
+	client.send(t, callRequest(id, "watch_start", map[string]interface{}{
+		"tool":         "echo",
+		"arguments":    map[string]interface{}{"text": "hi"},
+		"interval":     interval,
+		"max_duration": duration,
+	}))
+	for text == "" || event == nil {
+		message := client.next(t)
+		if message["id"] == float64(id) {
+			var isError bool
+			if text, isError = messageText(t, message); isError {
+				t.Fatalf("watch_start failed: %s", text)
+			}
+			continue
+		}
+		event = watchEvent(t, message)
+	}
+	return text, event
+}
+
+func TestWatchPushesResultsUntilStopped(t *testing.T) {
+	clock := newWatchClock()
+	r := startWatchRouter(t, clock)
+	client := connectClient(t, r)
+	client.setup(t, "")
+	client.expectQuiet(t)
+	start := clock.Now()
+
+	text, event := startWatch(t, client, 2, "10s", "1m")
+	if !strings.Contains(text, "watch-1") {
+		t.Fatalf("watch_start = %q, want the watch ID", text)
+	}
+	// 启动后立即执行一次，之后按间隔推送
+	if event["watch_id"] != "watch-1" || event["sequence"] != float64(1) || event["text"] != "echo: hi" || event["at"] != start.Format(time.RFC3339) {
+		t.Fatalf("first event = %v", event)
+	}
+	clock.waitSleep(t, 10*time.Second)
+	clock.Advance(5 * time.Second)
+	client.expectQuiet(t)
+	clock.Advance(5 * time.Second)
+	if event := watchEvent(t, client.next(t)); event["sequence"] != float64(2) || event["text"] != "echo: hi" {
+		t.Fatalf("second event = %v", event)
+	}
+	clock.waitSleep(t, 10*time.Second)
+	if watches := r.watches.List(); len(watches) != 1 || watches[0].Runs != 2 || watches[0].SessionID == "" {
+		t.Fatalf("watches = %+v, want one watch with 2 runs", watches)
+	}
+
+	// 停止后不再推送，再次停止时监视不存在
+	text, _ = messageText(t, client.call(t, callRequest(3, "watch_stop", map[string]interface{}{"id": "watch-1"})))
+	if !strings.Contains(text, "共执行 2 次，失败 0 次") {
+		t.Fatalf("watch_stop = %q", text)
+	}
+	clock.Advance(time.Minute)
+	client.expectQuiet(t)
+	if watches := r.watches.List(); len(watches) != 0 {
+		t.Fatalf("watches after watch_stop = %+v", watches)
+	}
+	if text, isError := messageText(t, client.call(t, callRequest(4, "watch_stop", map[string]interface{}{"id": "watch-1"}))); !isError || !strings.Contains(text, "ERR_NOT_FOUND") {
+		t.Fatalf("second watch_stop = %q, want ERR_NOT_FOUND", text)
+	}
+}
+
+func TestWatchExpires(t *testing.T) {
+	clock := newWatchClock()
+	r := startWatchRouter(t, clock)
+	client := connectClient(t, r)
+	client.setup(t, "")
+	client.expectQuiet(t)
+
+	startWatch(t, client, 2, "10s", "15s")
+	clock.waitSleep(t, 10*time.Second)
+	clock.Advance(10 * time.Second)
+	watchEvent(t, client.next(t))
+
+	// 间隔超过剩余时间时等到到期为止，到期后发送结束事件并移除
+	clock.waitSleep(t, 5*time.Second)
+	clock.Advance(5 * time.Second)
+	if event := watchEvent(t, client.next(t)); event["ended"] != "expired" || event["sequence"] != float64(3) || event["text"] != nil {
+		t.Fatalf("final event = %v, want the expiry", event)
+	}
+	clock.Advance(time.Minute)
+	client.expectQuiet(t)
+	if watches := r.watches.List(); len(watches) != 0 {
+		t.Fatalf("watches after expiry = %+v", watches)
+	}
+}
+
+func TestWatchesBelongToTheirSession(t *testing.T) {
+	clock := newWatchClock()
+	r := startWatchRouter(t, clock)
+	first := connectClient(t, r)
+	second := connectClient(t, r)
+	first.setup(t, "")
+	second.setup(t, "")
+	first.expectQuiet(t)
+	second.expectQuiet(t)
+
+	startWatch(t, first, 2, "10s", "1m")
+	clock.waitSleep(t, 10*time.Second)
+	startWatch(t, second, 2, "10s", "1m")
+	clock.waitSleep(t, 10*time.Second)
+
+	// 其他会话的监视视为不存在
+	if text, isError := messageText(t, second.call(t, callRequest(3, "watch_stop", map[string]interface{}{"id": "watch-1"}))); !isError || !strings.Contains(text, "ERR_NOT_FOUND") {
+		t.Fatalf("stopping another session's watch = %q, want ERR_NOT_FOUND", text)
+	}
+
+	// 会话断开时停止它的监视，其他会话的监视继续推送
+	first.conn.Close()
+	<-first.served
+	watches := r.watches.List()
+	if len(watches) != 1 || watches[0].ID != "watch-2" {
+		t.Fatalf("watches after disconnect = %+v, want only watch-2", watches)
+	}
+	clock.Advance(10 * time.Second)
+	if event := watchEvent(t, second.next(t)); event["watch_id"] != "watch-2" || event["sequence"] != float64(2) {
+		t.Fatalf("remaining session got %v", event)
+	}
+}
@@ -0,0 +1,163 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mcp-example/internal/tools"
+)
+
+// 调度限制
+const (
+	// runTimeout 单次任务执行的时限
+	runTimeout = 2 * time.Minute
+	// idleWait 没有任务时重新检查的间隔（任务变化时会立即唤醒）
+	idleWait = time.Hour
+)
+
+// Clock 调度器使用的时钟，测试中可替换为手动推进的时钟
+type Clock interface {
+	Now() time.Time
+	// After 在 d 之后发送当前时间
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock 系统时钟
+type realClock struct{}
+
+// Now 当前时间
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// After 在 d 之后发送当前时间
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// SystemClock 系统时钟，供其他按时钟定时执行的组件（如监视）使用
+func SystemClock() Clock {
+	return realClock{}
+}
+
+// Scheduler 按 tools.ScheduleStore 中的定义定时调用工具并保存结果。
+// 任务依次执行，单个任务失败只记录在任务状态中，不影响后续执行
+type Scheduler struct {
+	store *tools.ScheduleStore
+	call  tools.ToolCallFunc
+	clock Clock
+
+	// stop 请求调度循环在当前任务完成后退出，done 在 Run 返回时关闭
+	started  atomic.Bool
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewScheduler 创建调度器，call 用于调用工具（经过访问策略检查和参数校验），clock 为 nil 时使用系统时钟
+func NewScheduler(store *tools.ScheduleStore, call tools.ToolCallFunc, clock Clock) *Scheduler {
+	if clock == nil {
+		clock = SystemClock()
+	}
+	return &Scheduler{
+		store: store,
+		call:  call,
+		clock: clock,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// Run 运行调度循环，直到 ctx 取消或调用 Stop，只能调用一次
+func (s *Scheduler) Run(ctx context.Context) {
+	s.started.Store(true)
+	defer close(s.done)
+
+	for {
+		wait := s.runDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stop:
+			return
+		case <-s.store.Changed():
+		case <-s.clock.After(wait):
+		}
+	}
+}
+
+// Stop 请求调度循环退出并等待其返回：正在执行的任务（包括保存结果）会先完成。
+// ctx 到期时返回 ctx 的错误。Run 尚未开始时立即返回
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	if !s.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runDue 执行所有已到期的任务，返回距离下一个任务到期的时间
+func (s *Scheduler) runDue(ctx context.Context) time.Duration {
+	schedules, err := s.store.All()
+	if err != nil {
+		slog.Warn("读取定时任务失败", "error", err)
+		return idleWait
+	}
+
+	wait := idleWait
+	for name, schedule := range schedules {
+		select {
+		case <-ctx.Done():
+			return wait
+		case <-s.stop:
+			return wait
+		default:
+		}
+
+		if !schedule.NextRun().After(s.clock.Now()) {
+			schedule.LastRun = s.runOnce(ctx, name, schedule)
+		}
+		wait = min(wait, schedule.NextRun().Sub(s.clock.Now()))
+	}
+	return max(wait, 0)
+}
+
+// runOnce 执行一次任务，保存结果并记录执行状态
+func (s *Scheduler) runOnce(ctx context.Context, name string, schedule tools.Schedule) *tools.ScheduleRun {
+	start := s.clock.Now()
+	callCtx, cancel := context.WithTimeout(ctx, runTimeout)
+	defer cancel()
+
+	text, structured, err := s.call(callCtx, schedule.Tool, schedule.Arguments)
+	run := tools.ScheduleRun{At: start, DurationMs: s.clock.Now().Sub(start).Milliseconds()}
+	if err == nil {
+		run.Key, err = s.store.SaveResult(name, tools.ScheduledResult{
+			Schedule:   name,
+			Tool:       schedule.Tool,
+			Arguments:  schedule.Arguments,
+			At:         start,
+			DurationMs: run.DurationMs,
+			Text:       text,
+			Structured: structured,
+		}, schedule.Retention)
+	}
+	if err != nil {
+		run.Error = err.Error()
+		slog.Warn("定时任务执行失败", "schedule", name, "tool", schedule.Tool, "error", err)
+	}
+
+	if err := s.store.RecordRun(name, run); err != nil {
+		slog.Warn("记录定时任务状态失败", "schedule", name, "error", err)
+	}
+	return &run
+}
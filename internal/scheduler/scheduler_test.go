@@ -0,0 +1,262 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/tools"
+)
+
+// manualClock 手动推进的时钟，After 的每次调用都通过 sleeps 通知测试
+type manualClock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	waiters []waiter
+	sleeps  chan time.Duration
+}
+
+type waiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func newManualClock() *manualClock {
+	return &manualClock{now: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), sleeps: make(chan time.Duration, 64)}
+}
+
+func (mc *manualClock) Now() time.Time {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	return mc.now
+}
+
+func (mc *manualClock) After(d time.Duration) <-chan time.Time {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	ch := make(chan time.Time, 1)
+	mc.waiters = append(mc.waiters, waiter{deadline: mc.now.Add(d), ch: ch})
+	mc.sleeps <- d
+	return ch
+}
+
+// Advance 推进时钟，触发到期的 After
+func (mc *manualClock) Advance(d time.Duration) {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	mc.now = mc.now.Add(d)
+	pending := mc.waiters[:0]
+	for _, w := range mc.waiters {
+		if w.deadline.After(mc.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- mc.now
+	}
+	mc.waiters = pending
+}
+
+// waitSleep 等待调度循环开始等待 want，期间的其他等待时长视为失败
+func (mc *manualClock) waitSleep(t *testing.T, want time.Duration) {
+	t.Helper()
+	select {
+	case d := <-mc.sleeps:
+		if d != want {
+			t.Fatalf("scheduler sleeps %v, want %v", d, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("scheduler did not start waiting %v", want)
+	}
+}
+
+// toolCall 调度器发出的一次工具调用
+type toolCall struct {
+	tool string
+	at   time.Time
+}
+
+// recordingCall 记录调用并返回 err 的 ToolCallFunc，release 非空时等待其关闭后才返回
+func recordingCall(clock *manualClock, calls chan<- toolCall, release <-chan struct{}, err error) tools.ToolCallFunc {
+	return func(ctx context.Context, name string, args map[string]interface{}) (string, interface{}, error) {
+		calls <- toolCall{tool: name, at: clock.Now()}
+		if release != nil {
+			<-release
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		return "ok: " + name, map[string]interface{}{"tool": name}, nil
+	}
+}
+
+// expectCall 等待下一次工具调用
+func expectCall(t *testing.T, calls <-chan toolCall) toolCall {
+	t.Helper()
+	select {
+	case call := <-calls:
+		return call
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduler did not call the tool")
+		return toolCall{}
+	}
+}
+
+// expectNoCall 确认没有工具调用，调度循环此时应处于等待中
+func expectNoCall(t *testing.T, calls <-chan toolCall) {
+	t.Helper()
+	select {
+	case call := <-calls:
+		t.Fatalf("unexpected call %+v", call)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// startScheduler 在 store 中保存一个任务后启动调度器，测试结束时停止
+func startScheduler(t *testing.T, clock *manualClock, store *tools.ScheduleStore, call tools.ToolCallFunc) *Scheduler {
+	t.Helper()
+	if err := store.Set("cpu", tools.Schedule{Tool: "cpu_info", Interval: "5m", Retention: 2, UpdatedAt: clock.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	// 保存任务产生的变更通知在启动前取走，调度循环的等待顺序固定
+	<-store.Changed()
+
+	scheduler := NewScheduler(store, call, clock)
+	ctx, cancel := context.WithCancel(context.Background())
+	go scheduler.Run(ctx)
+	t.Cleanup(func() {
+		cancel()
+		scheduler.Stop(context.Background())
+	})
+	return scheduler
+}
+
+func TestSchedulerRunsAtInterval(t *testing.T) {
+	clock := newManualClock()
+	store := tools.NewScheduleStore(storage.NewMemoryStorage())
+	calls := make(chan toolCall, 8)
+	startScheduler(t, clock, store, recordingCall(clock, calls, nil, nil))
+	start := clock.Now()
+
+	// 新任务立即执行一次，之后每 5 分钟执行
+	if call := expectCall(t, calls); call.tool != "cpu_info" || !call.at.Equal(start) {
+		t.Fatalf("first call = %+v, want cpu_info at %v", call, start)
+	}
+	clock.waitSleep(t, 5*time.Minute)
+	clock.Advance(4 * time.Minute)
+	expectNoCall(t, calls)
+	clock.Advance(time.Minute)
+	if call := expectCall(t, calls); !call.at.Equal(start.Add(5 * time.Minute)) {
+		t.Fatalf("second call at %v, want 5 minutes after the first", call.at)
+	}
+	clock.waitSleep(t, 5*time.Minute)
+	clock.Advance(5 * time.Minute)
+	expectCall(t, calls)
+	clock.waitSleep(t, 5*time.Minute)
+
+	// 每次执行都记录状态，结果只保留最近 Retention 个
+	schedules, err := store.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := schedules["cpu"].LastRun
+	if run == nil || !run.At.Equal(start.Add(10*time.Minute)) || run.Error != "" || run.Key == "" {
+		t.Fatalf("last run = %+v, want the third run", run)
+	}
+	keys, err := store.ResultKeys("cpu")
+	if err != nil || len(keys) != 2 || keys[1] != run.Key {
+		t.Fatalf("result keys = %v, %v, want the 2 most recent", keys, err)
+	}
+	if result, err := store.Result(run.Key); err != nil || result.Text != "ok: cpu_info" {
+		t.Errorf("saved result = %+v, %v", result, err)
+	}
+}
+
+func TestSchedulerRecordsFailures(t *testing.T) {
+	clock := newManualClock()
+	store := tools.NewScheduleStore(storage.NewMemoryStorage())
+	calls := make(chan toolCall, 8)
+	startScheduler(t, clock, store, recordingCall(clock, calls, nil, errors.New("collect failed")))
+
+	// 失败的任务照常按间隔重试，不保存结果
+	expectCall(t, calls)
+	clock.waitSleep(t, 5*time.Minute)
+	clock.Advance(5 * time.Minute)
+	expectCall(t, calls)
+	clock.waitSleep(t, 5*time.Minute)
+
+	schedules, _ := store.All()
+	if schedule := schedules["cpu"]; schedule.Failures != 2 || schedule.LastRun == nil || schedule.LastRun.Error != "collect failed" {
+		t.Fatalf("schedule = %+v, want 2 consecutive failures", schedule)
+	}
+	if keys, _ := store.ResultKeys("cpu"); len(keys) != 0 {
+		t.Errorf("failed runs saved results %v", keys)
+	}
+}
+
+func TestSchedulerStopsRunningRemovedSchedules(t *testing.T) {
+	clock := newManualClock()
+	store := tools.NewScheduleStore(storage.NewMemoryStorage())
+	calls := make(chan toolCall, 8)
+	startScheduler(t, clock, store, recordingCall(clock, calls, nil, nil))
+	expectCall(t, calls)
+	clock.waitSleep(t, 5*time.Minute)
+
+	// 删除任务立即唤醒调度循环，没有任务时等待 idleWait
+	if err := store.Delete("cpu"); err != nil {
+		t.Fatal(err)
+	}
+	clock.waitSleep(t, idleWait)
+	clock.Advance(10 * time.Minute)
+	expectNoCall(t, calls)
+	if keys, _ := store.ResultKeys("cpu"); len(keys) != 0 {
+		t.Errorf("results of the removed schedule kept: %v", keys)
+	}
+
+	// 新增的任务同样立即生效
+	if err := store.Set("disk", tools.Schedule{Tool: "disk_info", Interval: "1m", Retention: 1, UpdatedAt: clock.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if call := expectCall(t, calls); call.tool != "disk_info" {
+		t.Fatalf("call = %+v, want the new schedule", call)
+	}
+}
+
+func TestSchedulerStopWaitsForRunningTask(t *testing.T) {
+	clock := newManualClock()
+	store := tools.NewScheduleStore(storage.NewMemoryStorage())
+	calls := make(chan toolCall, 8)
+	release := make(chan struct{})
+	scheduler := startScheduler(t, clock, store, recordingCall(clock, calls, release, nil))
+	expectCall(t, calls)
+
+	// 任务执行中，Stop 等待到 ctx 到期
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := scheduler.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Stop during a run = %v, want the deadline", err)
+	}
+
+	// 任务完成并保存后调度循环退出，不再执行
+	close(release)
+	if err := scheduler.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop = %v", err)
+	}
+	schedules, _ := store.All()
+	if run := schedules["cpu"].LastRun; run == nil || run.Key == "" {
+		t.Fatalf("last run = %+v, want the running task recorded", run)
+	}
+	clock.Advance(time.Hour)
+	expectNoCall(t, calls)
+}
+
+func TestSchedulerStopBeforeRun(t *testing.T) {
+	scheduler := NewScheduler(tools.NewScheduleStore(storage.NewMemoryStorage()), nil, newManualClock())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := scheduler.Stop(ctx); err != nil {
+		t.Fatalf("Stop before Run = %v", err)
+	}
+}
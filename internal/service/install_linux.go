@@ -0,0 +1,53 @@
+//go:build linux
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// systemdUnitDir 安装 systemd 单元文件的目录
+const systemdUnitDir = "/etc/systemd/system"
+
+// unitPath 服务对应的单元文件路径
+func unitPath(name string) string {
+	return filepath.Join(systemdUnitDir, name+".service")
+}
+
+// Install 写入 systemd 单元文件，返回安装结果和后续操作说明。单元文件已存在时返回错误
+func Install(cfg Config) (string, error) {
+	path := unitPath(cfg.Name)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return "", fmt.Errorf("单元文件 %s 已存在，请先使用 --service-uninstall 卸载", path)
+		}
+		return "", fmt.Errorf("写入单元文件失败: %v", err)
+	}
+	if _, err := file.WriteString(systemdUnit(cfg)); err != nil {
+		file.Close()
+		os.Remove(path)
+		return "", fmt.Errorf("写入单元文件失败: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("写入单元文件失败: %v", err)
+	}
+
+	return fmt.Sprintf("已写入 %s\n启用并启动: systemctl daemon-reload && systemctl enable --now %s", path, cfg.Name), nil
+}
+
+// Uninstall 删除 systemd 单元文件，返回卸载结果和后续操作说明
+func Uninstall(name string) (string, error) {
+	path := unitPath(name)
+	if err := os.Remove(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("单元文件 %s 不存在", path)
+		}
+		return "", fmt.Errorf("删除单元文件失败: %v", err)
+	}
+	return fmt.Sprintf("已删除 %s\n如服务仍在运行，请执行: systemctl disable --now %s && systemctl daemon-reload", path, name), nil
+}
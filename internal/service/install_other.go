@@ -0,0 +1,18 @@
+//go:build !linux && !windows
+
+package service
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Install 当前平台不支持安装服务
+func Install(cfg Config) (string, error) {
+	return "", fmt.Errorf("%s 平台不支持安装服务（仅支持 Linux systemd 和 Windows）", runtime.GOOS)
+}
+
+// Uninstall 当前平台不支持卸载服务
+func Uninstall(name string) (string, error) {
+	return "", fmt.Errorf("%s 平台不支持卸载服务（仅支持 Linux systemd 和 Windows）", runtime.GOOS)
+}
@@ -0,0 +1,166 @@
+// Package service 让服务器作为系统服务运行：systemd 的 sd_notify 就绪通知和看门狗、
+// Windows 服务控制管理器（SCM）的启停请求，以及安装/卸载服务的辅助命令。
+// 不由服务管理器启动时（没有 NOTIFY_SOCKET、不是 Windows 服务）所有集成点都是空操作
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sd_notify 协议的状态，多个状态以换行分隔后一次发送
+const (
+	StateReady    = "READY=1"
+	StateStopping = "STOPPING=1"
+	StateWatchdog = "WATCHDOG=1"
+)
+
+// Notifier 向服务管理器报告服务状态
+type Notifier interface {
+	// Notify 发送 sd_notify 格式的状态，如 "READY=1\nSTATUS=..."
+	Notify(state string) error
+	// WatchdogInterval 服务管理器要求的看门狗超时时间，0 表示不需要看门狗心跳
+	WatchdogInterval() time.Duration
+}
+
+// nopNotifier 不由服务管理器启动时使用，所有通知都被忽略
+type nopNotifier struct{}
+
+func (nopNotifier) Notify(string) error             { return nil }
+func (nopNotifier) WatchdogInterval() time.Duration { return 0 }
+
+// systemdNotifier 通过 NOTIFY_SOCKET 指定的 unix 数据报套接字向 systemd 发送通知
+type systemdNotifier struct {
+	addr     *net.UnixAddr
+	watchdog time.Duration
+}
+
+// NewNotifier 根据环境变量创建通知器：设置了 NOTIFY_SOCKET 时通知 systemd
+// （WATCHDOG_USEC 为看门狗超时，WATCHDOG_PID 不是本进程时忽略），否则返回空操作的通知器
+func NewNotifier() Notifier {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nopNotifier{}
+	}
+	// 以 @ 开头的是抽象命名空间套接字，net 包会自动转换
+	return &systemdNotifier{
+		addr:     &net.UnixAddr{Name: socket, Net: "unixgram"},
+		watchdog: watchdogFromEnv(os.Getenv("WATCHDOG_USEC"), os.Getenv("WATCHDOG_PID"), os.Getpid()),
+	}
+}
+
+// watchdogFromEnv 解析 WATCHDOG_USEC，无效、为 0 或 WATCHDOG_PID 指定了其他进程时返回 0
+func watchdogFromEnv(usec, pid string, self int) time.Duration {
+	if usec == "" {
+		return 0
+	}
+	if pid != "" && pid != strconv.Itoa(self) {
+		return 0
+	}
+	value, err := strconv.ParseUint(usec, 10, 63)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(value) * time.Microsecond
+}
+
+func (n *systemdNotifier) Notify(state string) error {
+	conn, err := net.DialUnix("unixgram", nil, n.addr)
+	if err != nil {
+		return fmt.Errorf("连接 NOTIFY_SOCKET 失败: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("发送 sd_notify 通知失败: %v", err)
+	}
+	return nil
+}
+
+func (n *systemdNotifier) WatchdogInterval() time.Duration {
+	return n.watchdog
+}
+
+// RunWatchdog 服务管理器要求看门狗时，每半个超时时间发送一次 WATCHDOG=1，直到 ctx 取消
+func RunWatchdog(ctx context.Context, notifier Notifier) {
+	interval := notifier.WatchdogInterval() / 2
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := notifier.Notify(StateWatchdog); err != nil {
+				slog.Warn("看门狗心跳发送失败", "error", err)
+			}
+		}
+	}
+}
+
+// Readiness 跟踪启动步骤，全部完成后才通知一次 READY=1，避免服务管理器在监听器绑定、
+// 工具注册完成之前就认为服务已经就绪
+type Readiness struct {
+	notifier Notifier
+	mu       sync.Mutex
+	pending  map[string]bool
+	ready    bool
+	status   string
+}
+
+// NewReadiness 创建就绪跟踪，steps 为需要完成的启动步骤名称
+func NewReadiness(notifier Notifier, steps ...string) *Readiness {
+	pending := make(map[string]bool, len(steps))
+	for _, step := range steps {
+		pending[step] = true
+	}
+	return &Readiness{notifier: notifier, pending: pending}
+}
+
+// SetStatus 设置就绪时随 READY=1 一起发送的状态说明（STATUS=）
+func (r *Readiness) SetStatus(status string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+// Done 标记步骤已完成，最后一个步骤完成时发送 READY=1。重复标记或未知的步骤被忽略
+func (r *Readiness) Done(step string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ready || !r.pending[step] {
+		return
+	}
+	delete(r.pending, step)
+	if len(r.pending) > 0 {
+		return
+	}
+
+	r.ready = true
+	state := StateReady
+	if r.status != "" {
+		state += "\nSTATUS=" + strings.ReplaceAll(r.status, "\n", " ")
+	}
+	if err := r.notifier.Notify(state); err != nil {
+		slog.Warn("就绪通知发送失败", "error", err)
+	}
+}
+
+// Ready 是否所有步骤都已完成
+func (r *Readiness) Ready() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ready
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeNotifier 记录收到的所有通知
+type fakeNotifier struct {
+	mutex    sync.Mutex
+	states   []string
+	watchdog time.Duration
+}
+
+func (fn *fakeNotifier) Notify(state string) error {
+	fn.mutex.Lock()
+	defer fn.mutex.Unlock()
+	fn.states = append(fn.states, state)
+	return nil
+}
+
+func (fn *fakeNotifier) WatchdogInterval() time.Duration { return fn.watchdog }
+
+func (fn *fakeNotifier) sent() []string {
+	fn.mutex.Lock()
+	defer fn.mutex.Unlock()
+	return slices.Clone(fn.states)
+}
+
+func TestReadinessWaitsForAllSteps(t *testing.T) {
+	notifier := &fakeNotifier{}
+	readiness := NewReadiness(notifier, "tools", "diagnostics", "transport")
+
+	readiness.Done("tools")
+	readiness.Done("tools")
+	readiness.Done("unknown")
+	readiness.Done("transport")
+	if readiness.Ready() || len(notifier.sent()) != 0 {
+		t.Fatalf("ready before all steps completed: %v", notifier.sent())
+	}
+
+	readiness.SetStatus("running\non two lines")
+	readiness.Done("diagnostics")
+	want := []string{StateReady + "\nSTATUS=running on two lines"}
+	if !readiness.Ready() || !slices.Equal(notifier.sent(), want) {
+		t.Fatalf("sent %q, want %q", notifier.sent(), want)
+	}
+
+	// 就绪只通知一次
+	readiness.Done("diagnostics")
+	if len(notifier.sent()) != 1 {
+		t.Fatalf("sent %q, want a single READY=1", notifier.sent())
+	}
+}
+
+func TestReadinessConcurrentSteps(t *testing.T) {
+	notifier := &fakeNotifier{}
+	steps := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	readiness := NewReadiness(notifier, steps...)
+
+	var wg sync.WaitGroup
+	for _, step := range steps {
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func(step string) {
+				defer wg.Done()
+				readiness.Done(step)
+			}(step)
+		}
+	}
+	wg.Wait()
+	if sent := notifier.sent(); len(sent) != 1 || sent[0] != StateReady {
+		t.Fatalf("sent %q, want exactly one READY=1", sent)
+	}
+}
+
+func TestWatchdogFromEnv(t *testing.T) {
+	tests := []struct {
+		usec, pid string
+		want      time.Duration
+	}{
+		{"", "", 0},
+		{"30000000", "", 30 * time.Second},
+		{"30000000", "42", 30 * time.Second},
+		{"30000000", "43", 0},
+		{"abc", "", 0},
+		{"-5", "", 0},
+	}
+	for _, test := range tests {
+		if got := watchdogFromEnv(test.usec, test.pid, 42); got != test.want {
+			t.Errorf("watchdogFromEnv(%q, %q) = %s, want %s", test.usec, test.pid, got, test.want)
+		}
+	}
+}
+
+func TestRunWatchdog(t *testing.T) {
+	notifier := &fakeNotifier{watchdog: 20 * time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		RunWatchdog(ctx, notifier)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(notifier.sent()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+	sent := notifier.sent()
+	if len(sent) < 2 || sent[0] != StateWatchdog {
+		t.Fatalf("sent %q, want repeated WATCHDOG=1", sent)
+	}
+
+	// 不需要看门狗时立即返回
+	RunWatchdog(context.Background(), &fakeNotifier{})
+}
+
+func TestSystemdNotifier(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unixgram sockets are not available on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram socket: %v", err)
+	}
+	defer conn.Close()
+
+	t.Setenv("NOTIFY_SOCKET", path)
+	t.Setenv("WATCHDOG_USEC", "")
+	notifier := NewNotifier()
+	if err := notifier.Notify(StateReady + "\nSTATUS=ok"); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	buf := make([]byte, 256)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFromUnix(buf)
+	if err != nil || string(buf[:n]) != "READY=1\nSTATUS=ok" {
+		t.Fatalf("received %q, %v", buf[:n], err)
+	}
+}
+
+func TestNewNotifierWithoutSocket(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	notifier := NewNotifier()
+	if err := notifier.Notify(StateReady); err != nil || notifier.WatchdogInterval() != 0 {
+		t.Fatalf("nop notifier returned %v, watchdog %s", err, notifier.WatchdogInterval())
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package service
+
+import "context"
+
+// Run 运行服务：非 Windows 平台直接调用 run，由 systemd 启动时通过 NOTIFY_SOCKET 报告状态
+func Run(ctx context.Context, name string, run func(ctx context.Context, notifier Notifier) error) error {
+	return run(ctx, NewNotifier())
+}
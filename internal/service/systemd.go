@@ -0,0 +1,64 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// watchdogSeconds 安装的 systemd 单元的看门狗超时（WatchdogSec），心跳间隔为其一半
+const watchdogSeconds = 30
+
+// Config 安装服务所需的信息
+type Config struct {
+	// Name 服务名称（systemd 单元名或 Windows 服务名）
+	Name string
+	// Description 服务说明
+	Description string
+	// Executable 可执行文件的绝对路径
+	Executable string
+	// Args 服务启动时的命令行参数
+	Args []string
+	// WorkingDir 工作目录，相对路径的数据目录等基于它解析（Windows 服务不支持设置，使用程序所在目录）
+	WorkingDir string
+}
+
+// systemdUnit 生成 Type=notify 的 systemd 单元文件内容
+func systemdUnit(cfg Config) string {
+	command := make([]string, 0, len(cfg.Args)+1)
+	command = append(command, quoteExecArg(cfg.Executable))
+	for _, arg := range cfg.Args {
+		command = append(command, quoteExecArg(arg))
+	}
+
+	var unit strings.Builder
+	unit.WriteString("[Unit]\n")
+	fmt.Fprintf(&unit, "Description=%s\n", cfg.Description)
+	unit.WriteString("After=network.target\n\n")
+	unit.WriteString("[Service]\n")
+	unit.WriteString("Type=notify\n")
+	unit.WriteString("NotifyAccess=main\n")
+	fmt.Fprintf(&unit, "ExecStart=%s\n", strings.Join(command, " "))
+	unit.WriteString("ExecReload=/bin/kill -HUP $MAINPID\n")
+	if cfg.WorkingDir != "" {
+		// WorkingDirectory 不按命令行拆分，路径中的空格无需引号，只需转义 % 说明符
+		fmt.Fprintf(&unit, "WorkingDirectory=%s\n", strings.ReplaceAll(cfg.WorkingDir, "%", "%%"))
+	}
+	fmt.Fprintf(&unit, "WatchdogSec=%d\n", watchdogSeconds)
+	unit.WriteString("Restart=on-failure\n")
+	unit.WriteString("RestartSec=5\n\n")
+	unit.WriteString("[Install]\n")
+	unit.WriteString("WantedBy=multi-user.target\n")
+	return unit.String()
+}
+
+// quoteExecArg 按 systemd 命令行语法转义参数：% 和 $ 加倍，含空白、引号或反斜杠时加双引号
+func quoteExecArg(arg string) string {
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	arg = strings.ReplaceAll(arg, "$", "$$")
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\") {
+		return arg
+	}
+	arg = strings.ReplaceAll(arg, `\`, `\\`)
+	arg = strings.ReplaceAll(arg, `"`, `\"`)
+	return `"` + arg + `"`
+}
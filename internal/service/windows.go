@@ -0,0 +1,154 @@
+//go:build windows
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// acceptedCommands 服务就绪后接受的控制请求
+const acceptedCommands = svc.AcceptStop | svc.AcceptShutdown
+
+// recoveryResetPeriod 失败计数的重置周期（秒）
+const recoveryResetPeriod = 24 * 60 * 60
+
+// Run 运行服务：由 SCM 启动时注册服务处理程序，停止和关机请求会取消传给 run 的 ctx；
+// 在控制台中运行时直接调用 run
+func Run(ctx context.Context, name string, run func(ctx context.Context, notifier Notifier) error) error {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		return fmt.Errorf("检测 Windows 服务环境失败: %v", err)
+	}
+	if !isService {
+		return run(ctx, NewNotifier())
+	}
+
+	// 服务的工作目录是 System32，相对路径改为基于程序所在目录解析
+	if exe, err := os.Executable(); err == nil {
+		os.Chdir(filepath.Dir(exe))
+	}
+
+	handler := &windowsHandler{ctx: ctx, run: run}
+	if err := svc.Run(name, handler); err != nil {
+		return fmt.Errorf("运行 Windows 服务失败: %v", err)
+	}
+	return handler.err
+}
+
+// windowsHandler 处理 SCM 的控制请求
+type windowsHandler struct {
+	ctx context.Context
+	run func(ctx context.Context, notifier Notifier) error
+	err error
+}
+
+func (h *windowsHandler) Execute(args []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	changes <- svc.Status{State: svc.StartPending}
+
+	ctx, cancel := context.WithCancel(h.ctx)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- h.run(ctx, &scmNotifier{changes: changes})
+	}()
+
+	for {
+		select {
+		case err := <-done:
+			h.err = err
+			if err != nil {
+				return true, 1
+			}
+			return false, 0
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				changes <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				changes <- svc.Status{State: svc.StopPending}
+				cancel()
+			}
+		}
+	}
+}
+
+// scmNotifier 把 sd_notify 格式的状态转换为 SCM 服务状态：READY=1 为运行中，STOPPING=1 为正在停止
+type scmNotifier struct {
+	changes chan<- svc.Status
+}
+
+func (n *scmNotifier) Notify(state string) error {
+	for _, line := range strings.Split(state, "\n") {
+		switch line {
+		case StateReady:
+			n.changes <- svc.Status{State: svc.Running, Accepts: acceptedCommands}
+		case StateStopping:
+			n.changes <- svc.Status{State: svc.StopPending}
+		}
+	}
+	return nil
+}
+
+func (n *scmNotifier) WatchdogInterval() time.Duration {
+	return 0
+}
+
+// Install 在 SCM 中注册自动启动的服务，失败后 5 秒重启，返回安装结果和后续操作说明
+func Install(cfg Config) (string, error) {
+	manager, err := mgr.Connect()
+	if err != nil {
+		return "", fmt.Errorf("连接服务控制管理器失败（需要管理员权限）: %v", err)
+	}
+	defer manager.Disconnect()
+
+	if existing, err := manager.OpenService(cfg.Name); err == nil {
+		existing.Close()
+		return "", fmt.Errorf("服务 %s 已存在，请先使用 --service-uninstall 卸载", cfg.Name)
+	}
+
+	service, err := manager.CreateService(cfg.Name, cfg.Executable, mgr.Config{
+		DisplayName: cfg.Name,
+		Description: cfg.Description,
+		StartType:   mgr.StartAutomatic,
+	}, cfg.Args...)
+	if err != nil {
+		return "", fmt.Errorf("创建服务失败: %v", err)
+	}
+	defer service.Close()
+
+	recovery := []mgr.RecoveryAction{{Type: mgr.ServiceRestart, Delay: 5 * time.Second}}
+	if err := service.SetRecoveryActions(recovery, recoveryResetPeriod); err != nil {
+		return "", fmt.Errorf("设置服务失败恢复策略失败: %v", err)
+	}
+
+	return fmt.Sprintf("已注册服务 %s\n启动: sc start %s", cfg.Name, cfg.Name), nil
+}
+
+// Uninstall 从 SCM 中删除服务，返回卸载结果和后续操作说明
+func Uninstall(name string) (string, error) {
+	manager, err := mgr.Connect()
+	if err != nil {
+		return "", fmt.Errorf("连接服务控制管理器失败（需要管理员权限）: %v", err)
+	}
+	defer manager.Disconnect()
+
+	service, err := manager.OpenService(name)
+	if err != nil {
+		return "", fmt.Errorf("服务 %s 不存在: %v", name, err)
+	}
+	defer service.Close()
+
+	if err := service.Delete(); err != nil {
+		return "", fmt.Errorf("删除服务失败: %v", err)
+	}
+	return fmt.Sprintf("已删除服务 %s（运行中的服务停止后才会被移除）", name), nil
+}
@@ -0,0 +1,271 @@
+package storage
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// manifestName 归档中清单文件的名称
+const manifestName = "manifest.json"
+
+// ArchiveManifest 数据归档清单
+type ArchiveManifest struct {
+	ServerName    string         `json:"server_name"`
+	ServerVersion string         `json:"server_version"`
+	CreatedAt     time.Time      `json:"created_at"`
+	Files         []ArchiveEntry `json:"files"`
+	// Skipped 数据目录中未导出的子目录和非数据文件（如 exports 子目录中的指标导出文件）
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// ArchiveEntry 归档中的单个数据文件
+type ArchiveEntry struct {
+	Key    string `json:"key"`
+	File   string `json:"file"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	Manifest    ArchiveManifest `json:"manifest"`
+	Restored    []string        `json:"restored"`
+	Overwritten []string        `json:"overwritten"`
+}
+
+// Export 将数据目录中的所有数据文件打包为 tar.gz，并附带清单。
+// 存储键只保存在数据目录顶层，子目录和其他文件不导出，记录在清单的 Skipped 中
+func (js *JSONStorage) Export(w io.Writer, serverName, serverVersion string) (*ArchiveManifest, error) {
+	js.mutex.RLock()
+	defer js.mutex.RUnlock()
+
+	files, err := os.ReadDir(js.dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %v", err)
+	}
+
+	manifest := &ArchiveManifest{
+		ServerName:    serverName,
+		ServerVersion: serverVersion,
+		CreatedAt:     time.Now(),
+	}
+	contents := make(map[string][]byte)
+
+	for _, file := range files {
+		key, ok := keyFromFileName(file.Name())
+		if file.IsDir() || !ok || !validKey(key) {
+			name := file.Name()
+			if file.IsDir() {
+				name += "/"
+			}
+			manifest.Skipped = append(manifest.Skipped, name)
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(js.dataDir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %v", file.Name(), err)
+		}
+
+		manifest.Files = append(manifest.Files, ArchiveEntry{
+			Key:    key,
+			File:   file.Name(),
+			Size:   int64(len(data)),
+			SHA256: checksum(data),
+		})
+		contents[file.Name()] = data
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %v", err)
+	}
+
+	gzWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzWriter)
+
+	// 清单放在归档开头，导入时可先行校验
+	if err := writeTarEntry(tarWriter, manifestName, manifestData); err != nil {
+		return nil, err
+	}
+	for _, entry := range manifest.Files {
+		if err := writeTarEntry(tarWriter, entry.File, contents[entry.File]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %v", err)
+	}
+	if err := gzWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %v", err)
+	}
+
+	return manifest, nil
+}
+
+// Import 从 tar.gz 归档恢复数据。
+// 归档只能包含清单和清单中列出的普通文件，文件名必须是有效键加数据文件扩展名；
+// 所有文件的校验和通过后才会写入；已存在的键只有在 overwrite 为 true 时才会被覆盖。
+func (js *JSONStorage) Import(r io.Reader, overwrite bool) (*ImportResult, error) {
+	gzReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %v", err)
+	}
+	defer gzReader.Close()
+
+	var manifestData []byte
+	contents := make(map[string][]byte)
+
+	tarReader := tar.NewReader(gzReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %v", err)
+		}
+		// Export 只写入普通文件，目录、链接等条目说明归档不是本程序生成的
+		if header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("unexpected archive entry: %s", header.Name)
+		}
+		_, seen := contents[header.Name]
+		if seen || (header.Name == manifestName && manifestData != nil) {
+			return nil, fmt.Errorf("duplicate archive entry: %s", header.Name)
+		}
+
+		data, err := io.ReadAll(tarReader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive entry %s: %v", header.Name, err)
+		}
+
+		if header.Name == manifestName {
+			manifestData = data
+		} else {
+			contents[header.Name] = data
+		}
+	}
+
+	if manifestData == nil {
+		return nil, fmt.Errorf("archive has no %s", manifestName)
+	}
+
+	result := &ImportResult{}
+	if err := json.Unmarshal(manifestData, &result.Manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+
+	// 校验清单与文件内容
+	listed := make(map[string]bool, len(result.Manifest.Files))
+	keys := make(map[string]bool, len(result.Manifest.Files))
+	for _, entry := range result.Manifest.Files {
+		key, ok := keyFromFileName(entry.File)
+		if !ok || key != entry.Key || !validKey(key) {
+			return nil, fmt.Errorf("invalid manifest entry: %q", entry.File)
+		}
+		if keys[key] {
+			return nil, fmt.Errorf("duplicate key in manifest: %s", key)
+		}
+		keys[key] = true
+		listed[entry.File] = true
+
+		data, exists := contents[entry.File]
+		if !exists {
+			return nil, fmt.Errorf("file listed in manifest is missing: %s", entry.File)
+		}
+		if checksum(data) != entry.SHA256 {
+			return nil, fmt.Errorf("checksum mismatch: %s", entry.File)
+		}
+	}
+	for name := range contents {
+		if !listed[name] {
+			return nil, fmt.Errorf("file not listed in manifest: %s", name)
+		}
+	}
+
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+
+	// 检查冲突
+	var conflicts []string
+	for _, entry := range result.Manifest.Files {
+		if _, exists := js.existingPath(entry.Key); exists {
+			conflicts = append(conflicts, entry.Key)
+		}
+	}
+	if len(conflicts) > 0 && !overwrite {
+		sort.Strings(conflicts)
+		return nil, fmt.Errorf("keys already exist (use overwrite to replace): %s", strings.Join(conflicts, ", "))
+	}
+	result.Overwritten = conflicts
+
+	for _, entry := range result.Manifest.Files {
+		// 先删除同一个键的其他格式文件，再写入归档中的文件
+		for _, path := range []string{js.plainPath(entry.Key), js.compressedPath(entry.Key)} {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return result, fmt.Errorf("failed to replace %s: %v", entry.Key, err)
+			}
+		}
+
+		if err := writeFileAtomic(filepath.Join(js.dataDir, entry.File), contents[entry.File]); err != nil {
+			return result, fmt.Errorf("failed to write %s: %v", entry.File, err)
+		}
+		result.Restored = append(result.Restored, entry.Key)
+	}
+
+	return result, nil
+}
+
+// keyFromFileName 从数据文件名解析键名
+func keyFromFileName(name string) (string, bool) {
+	switch {
+	case strings.HasSuffix(name, compressedExt):
+		return strings.TrimSuffix(name, compressedExt), true
+	case strings.HasSuffix(name, jsonExt):
+		return strings.TrimSuffix(name, jsonExt), true
+	default:
+		return "", false
+	}
+}
+
+// validKey 键能否直接作为数据目录顶层的文件名：非空，不是 . 或 ..，不包含路径分隔符
+func validKey(key string) bool {
+	if key == "" || key == "." || key == ".." {
+		return false
+	}
+	return !strings.ContainsAny(key, `/\`) && filepath.Base(key) == key
+}
+
+// checksum 计算 SHA-256 校验和
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeTarEntry 向归档写入一个普通文件
+func writeTarEntry(tarWriter *tar.Writer, name string, data []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write archive entry %s: %v", name, err)
+	}
+	if _, err := tarWriter.Write(data); err != nil {
+		return fmt.Errorf("failed to write archive entry %s: %v", name, err)
+	}
+	return nil
+}
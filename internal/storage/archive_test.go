@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"mcp-example/internal/types"
+)
+
+// buildArchive 按给定清单和文件生成归档，用于构造 Export 不会生成的归档
+func buildArchive(t *testing.T, manifest ArchiveManifest, files map[string][]byte) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gzWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzWriter)
+
+	manifestData, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeTarEntry(tarWriter, manifestName, manifestData); err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if err := writeTarEntry(tarWriter, name, files[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+// archiveEntry 文件 name 内容为 data 的清单条目
+func archiveEntry(key, name string, data []byte) ArchiveEntry {
+	return ArchiveEntry{Key: key, File: name, Size: int64(len(data)), SHA256: checksum(data)}
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	source, err := NewJSONStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := source.Save("alert_rules", map[string]string{"cpu": "> 90"}); err != nil {
+		t.Fatal(err)
+	}
+	compressed, err := NewJSONStorage(source.GetDataDir(), WithCompression(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := compressed.Save("snapshot_20240102", snapshotProcesses(20)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(source.GetDataDir(), "exports"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	manifest, err := source.Export(&archive, "system-monitor", "1.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Files) != 2 {
+		t.Fatalf("exported %d files, want 2", len(manifest.Files))
+	}
+	if !slices.Equal(manifest.Skipped, []string{"exports/"}) {
+		t.Fatalf("Skipped = %v, want the exports subdirectory reported", manifest.Skipped)
+	}
+
+	target, err := NewJSONStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := target.Import(bytes.NewReader(archive.Bytes()), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(result.Restored)
+	if !slices.Equal(result.Restored, []string{"alert_rules", "snapshot_20240102"}) || len(result.Overwritten) != 0 {
+		t.Fatalf("Restored = %v, Overwritten = %v", result.Restored, result.Overwritten)
+	}
+	if result.Manifest.ServerName != "system-monitor" || result.Manifest.ServerVersion != "1.2.0" {
+		t.Fatalf("manifest = %+v", result.Manifest)
+	}
+
+	var rules map[string]string
+	if err := target.Load("alert_rules", &rules); err != nil || rules["cpu"] != "> 90" {
+		t.Fatalf("Load(alert_rules) = %v, %v", rules, err)
+	}
+	var processes []types.ProcessInfo
+	if err := target.Load("snapshot_20240102", &processes); err != nil || len(processes) != 20 {
+		t.Fatalf("Load(snapshot) = %d processes, %v", len(processes), err)
+	}
+	// 压缩文件按原格式恢复
+	if _, err := os.Stat(target.compressedPath("snapshot_20240102")); err != nil {
+		t.Fatalf("compressed file not restored as-is: %v", err)
+	}
+
+	// 已存在的键需要 overwrite
+	_, err = target.Import(bytes.NewReader(archive.Bytes()), false)
+	if err == nil || !strings.Contains(err.Error(), "alert_rules") {
+		t.Fatalf("second import error = %v, want a conflict naming the existing keys", err)
+	}
+	result, err = target.Import(bytes.NewReader(archive.Bytes()), true)
+	if err != nil || len(result.Overwritten) != 2 {
+		t.Fatalf("overwrite import = %+v, %v", result, err)
+	}
+}
+
+func TestArchiveImportChecksumMismatch(t *testing.T) {
+	data := []byte(`{"cpu": "> 90"}`)
+	entry := archiveEntry("alert_rules", "alert_rules.json", []byte(`{"cpu": "> 80"}`))
+	archive := buildArchive(t, ArchiveManifest{Files: []ArchiveEntry{entry}}, map[string][]byte{"alert_rules.json": data})
+
+	target, err := NewJSONStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = target.Import(archive, false)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch: alert_rules.json") {
+		t.Fatalf("Import error = %v, want a checksum mismatch naming the file", err)
+	}
+	if target.Exists("alert_rules") {
+		t.Fatal("a failed import must not write any file")
+	}
+}
+
+func TestArchiveImportRejectsInvalidArchives(t *testing.T) {
+	data := []byte(`{}`)
+	tests := []struct {
+		name    string
+		entries []ArchiveEntry
+		files   map[string][]byte
+		want    string
+	}{
+		{"dot key", []ArchiveEntry{archiveEntry(".", "..json", data)}, map[string][]byte{"..json": data}, "invalid manifest entry"},
+		{"dot dot key", []ArchiveEntry{archiveEntry("..", "...json", data)}, map[string][]byte{"...json": data}, "invalid manifest entry"},
+		{"empty key", []ArchiveEntry{archiveEntry("", ".json", data)}, map[string][]byte{".json": data}, "invalid manifest entry"},
+		{"path", []ArchiveEntry{archiveEntry("../evil", "../evil.json", data)}, map[string][]byte{"../evil.json": data}, "invalid manifest entry"},
+		{"key mismatch", []ArchiveEntry{archiveEntry("other", "a.json", data)}, map[string][]byte{"a.json": data}, "invalid manifest entry"},
+		{"duplicate key", []ArchiveEntry{archiveEntry("a", "a.json", data), archiveEntry("a", "a.json.gz", data)},
+			map[string][]byte{"a.json": data, "a.json.gz": data}, "duplicate key"},
+		{"missing file", []ArchiveEntry{archiveEntry("a", "a.json", data)}, nil, "missing: a.json"},
+		{"unlisted file", []ArchiveEntry{archiveEntry("a", "a.json", data)},
+			map[string][]byte{"a.json": data, "../../etc/cron.d/x": data}, "not listed in manifest: ../../etc/cron.d/x"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			target, err := NewJSONStorage(filepath.Join(t.TempDir(), "data"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = target.Import(buildArchive(t, ArchiveManifest{Files: test.entries}, test.files), true)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("Import error = %v, want %q", err, test.want)
+			}
+			if keys, _ := target.ListKeys(); len(keys) != 0 {
+				t.Fatalf("rejected import wrote keys %v", keys)
+			}
+		})
+	}
+}
+
+func TestArchiveImportRejectsNonRegularEntries(t *testing.T) {
+	var buf bytes.Buffer
+	gzWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzWriter)
+	manifestData, _ := json.Marshal(ArchiveManifest{})
+	if err := writeTarEntry(tarWriter, manifestName, manifestData); err != nil {
+		t.Fatal(err)
+	}
+	if err := tarWriter.WriteHeader(&tar.Header{Name: "link.json", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}); err != nil {
+		t.Fatal(err)
+	}
+	tarWriter.Close()
+	gzWriter.Close()
+
+	target, err := NewJSONStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := target.Import(&buf, true); err == nil || !strings.Contains(err.Error(), "unexpected archive entry: link.json") {
+		t.Fatalf("Import error = %v, want the symlink rejected", err)
+	}
+}
@@ -1,8 +1,12 @@
 package storage
 
 import (
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"mcp-example/internal/types"
 )
 
 // CacheItem 缓存项
@@ -13,8 +17,10 @@ type CacheItem struct {
 
 // MemoryCache 内存缓存实现
 type MemoryCache struct {
-	items map[string]*CacheItem
-	mutex sync.RWMutex
+	items  map[string]*CacheItem
+	mutex  sync.RWMutex
+	hits   atomic.Uint64
+	misses atomic.Uint64
 }
 
 // NewMemoryCache 创建新的内存缓存实例
@@ -50,6 +56,7 @@ func (mc *MemoryCache) Get(key string) (interface{}, bool) {
 
 	item, exists := mc.items[key]
 	if !exists {
+		mc.misses.Add(1)
 		return nil, false
 	}
 
@@ -61,9 +68,11 @@ func (mc *MemoryCache) Get(key string) (interface{}, bool) {
 			delete(mc.items, key)
 			mc.mutex.Unlock()
 		}()
+		mc.misses.Add(1)
 		return nil, false
 	}
 
+	mc.hits.Add(1)
 	return item.Value, true
 }
 
@@ -91,7 +100,16 @@ func (mc *MemoryCache) Size() int {
 	return len(mc.items)
 }
 
-// Keys 获取所有缓存键
+// Stats 获取缓存命中统计
+func (mc *MemoryCache) Stats() types.CacheStats {
+	return types.CacheStats{
+		Size:   mc.Size(),
+		Hits:   mc.hits.Load(),
+		Misses: mc.misses.Load(),
+	}
+}
+
+// Keys 获取所有缓存键（已排序）
 func (mc *MemoryCache) Keys() []string {
 	mc.mutex.RLock()
 	defer mc.mutex.RUnlock()
@@ -100,10 +118,36 @@ func (mc *MemoryCache) Keys() []string {
 	for key := range mc.items {
 		keys = append(keys, key)
 	}
+	sort.Strings(keys)
 
 	return keys
 }
 
+// Entries 获取所有未过期缓存项的键、剩余过期时间和值（按键排序）
+func (mc *MemoryCache) Entries() []types.CacheEntryInfo {
+	mc.mutex.RLock()
+	defer mc.mutex.RUnlock()
+
+	now := time.Now()
+	entries := make([]types.CacheEntryInfo, 0, len(mc.items))
+	for key, item := range mc.items {
+		if now.After(item.ExpiresAt) {
+			continue
+		}
+		entries = append(entries, types.CacheEntryInfo{
+			Key:   key,
+			TTL:   item.ExpiresAt.Sub(now),
+			Value: item.Value,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+
+	return entries
+}
+
 // cleanup 定期清理过期的缓存项
 func (mc *MemoryCache) cleanup() {
 	ticker := time.NewTicker(5 * time.Minute) // 每5分钟清理一次
@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheKeysSorted(t *testing.T) {
+	cache := NewMemoryCache()
+	for _, key := range []string{"network_stats", "cpu_info", "disk_info", "memory_info"} {
+		cache.Set(key, key, time.Minute)
+	}
+	want := []string{"cpu_info", "disk_info", "memory_info", "network_stats"}
+	if keys := cache.Keys(); !slices.Equal(keys, want) {
+		t.Fatalf("Keys() = %v, want %v", keys, want)
+	}
+	var entryKeys []string
+	for _, entry := range cache.Entries() {
+		entryKeys = append(entryKeys, entry.Key)
+	}
+	if !slices.Equal(entryKeys, want) {
+		t.Fatalf("Entries() keys = %v, want %v", entryKeys, want)
+	}
+}
@@ -1,29 +1,81 @@
 package storage
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+
+	"mcp-example/internal/fswalk"
+	"mcp-example/internal/types"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+const (
+	jsonExt       = ".json"
+	compressedExt = ".json.gz"
 )
 
 // JSONStorage JSON 文件存储实现
 type JSONStorage struct {
-	dataDir string
-	mutex   sync.RWMutex
+	dataDir  string
+	compress bool
+	mutex    sync.RWMutex
+}
+
+// JSONStorageOption JSON 存储的可选配置
+type JSONStorageOption func(*JSONStorage)
+
+// WithCompression 使用 gzip 压缩写入（.json.gz），读取时两种格式均可识别
+func WithCompression(compress bool) JSONStorageOption {
+	return func(js *JSONStorage) {
+		js.compress = compress
+	}
 }
 
 // NewJSONStorage 创建新的 JSON 存储实例
-func NewJSONStorage(dataDir string) (*JSONStorage, error) {
+func NewJSONStorage(dataDir string, opts ...JSONStorageOption) (*JSONStorage, error) {
 	// 确保数据目录存在
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create data directory: %v", err)
 	}
 
-	return &JSONStorage{
+	js := &JSONStorage{
 		dataDir: dataDir,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(js)
+	}
+
+	return js, nil
+}
+
+// plainPath 未压缩文件路径
+func (js *JSONStorage) plainPath(key string) string {
+	return filepath.Join(js.dataDir, key+jsonExt)
+}
+
+// compressedPath 压缩文件路径
+func (js *JSONStorage) compressedPath(key string) string {
+	return filepath.Join(js.dataDir, key+compressedExt)
+}
+
+// existingPath 查找键对应的已有文件，优先压缩格式
+func (js *JSONStorage) existingPath(key string) (string, bool) {
+	for _, path := range []string{js.compressedPath(key), js.plainPath(key)} {
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
 }
 
 // Save 保存数据到 JSON 文件
@@ -31,19 +83,37 @@ func (js *JSONStorage) Save(key string, data interface{}) error {
 	js.mutex.Lock()
 	defer js.mutex.Unlock()
 
-	filePath := filepath.Join(js.dataDir, key+".json")
-
 	// 序列化数据
 	jsonData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal data: %v", err)
 	}
 
+	filePath, stalePath := js.plainPath(key), js.compressedPath(key)
+	if js.compress {
+		filePath, stalePath = stalePath, filePath
+
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(jsonData); err != nil {
+			return fmt.Errorf("failed to compress data: %v", err)
+		}
+		if err := writer.Close(); err != nil {
+			return fmt.Errorf("failed to compress data: %v", err)
+		}
+		jsonData = buf.Bytes()
+	}
+
 	// 写入文件
-	if err := os.WriteFile(filePath, jsonData, 0644); err != nil {
+	if err := writeFileAtomic(filePath, jsonData); err != nil {
 		return fmt.Errorf("failed to write file: %v", err)
 	}
 
+	// 删除另一种格式的旧文件，避免同一个键存在两份数据
+	if err := os.Remove(stalePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale file: %v", err)
+	}
+
 	return nil
 }
 
@@ -52,11 +122,10 @@ func (js *JSONStorage) Load(key string, data interface{}) error {
 	js.mutex.RLock()
 	defer js.mutex.RUnlock()
 
-	filePath := filepath.Join(js.dataDir, key+".json")
-
 	// 检查文件是否存在
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return fmt.Errorf("file does not exist: %s", filePath)
+	filePath, exists := js.existingPath(key)
+	if !exists {
+		return fmt.Errorf("file does not exist: %s", js.plainPath(key))
 	}
 
 	// 读取文件
@@ -65,6 +134,18 @@ func (js *JSONStorage) Load(key string, data interface{}) error {
 		return fmt.Errorf("failed to read file: %v", err)
 	}
 
+	if strings.HasSuffix(filePath, compressedExt) {
+		reader, err := gzip.NewReader(bytes.NewReader(jsonData))
+		if err != nil {
+			return fmt.Errorf("failed to decompress file: %v", err)
+		}
+		defer reader.Close()
+
+		if jsonData, err = io.ReadAll(reader); err != nil {
+			return fmt.Errorf("failed to decompress file: %v", err)
+		}
+	}
+
 	// 反序列化数据
 	if err := json.Unmarshal(jsonData, data); err != nil {
 		return fmt.Errorf("failed to unmarshal data: %v", err)
@@ -73,15 +154,15 @@ func (js *JSONStorage) Load(key string, data interface{}) error {
 	return nil
 }
 
-// Delete 删除 JSON 文件
+// Delete 删除 JSON 文件（压缩与未压缩格式）
 func (js *JSONStorage) Delete(key string) error {
 	js.mutex.Lock()
 	defer js.mutex.Unlock()
 
-	filePath := filepath.Join(js.dataDir, key+".json")
-
-	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to delete file: %v", err)
+	for _, filePath := range []string{js.plainPath(key), js.compressedPath(key)} {
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete file: %v", err)
+		}
 	}
 
 	return nil
@@ -92,9 +173,8 @@ func (js *JSONStorage) Exists(key string) bool {
 	js.mutex.RLock()
 	defer js.mutex.RUnlock()
 
-	filePath := filepath.Join(js.dataDir, key+".json")
-	_, err := os.Stat(filePath)
-	return !os.IsNotExist(err)
+	_, exists := js.existingPath(key)
+	return exists
 }
 
 // ListKeys 列出所有存储的键
@@ -108,9 +188,16 @@ func (js *JSONStorage) ListKeys() ([]string, error) {
 	}
 
 	var keys []string
+	seen := make(map[string]bool)
 	for _, file := range files {
-		if !file.IsDir() && filepath.Ext(file.Name()) == ".json" {
-			key := file.Name()[:len(file.Name())-5] // 去掉 .json 扩展名
+		if file.IsDir() {
+			continue
+		}
+
+		// 去掉 .json / .json.gz 扩展名
+		key, ok := keyFromFileName(file.Name())
+		if ok && !seen[key] {
+			seen[key] = true
 			keys = append(keys, key)
 		}
 	}
@@ -118,7 +205,113 @@ func (js *JSONStorage) ListKeys() ([]string, error) {
 	return keys, nil
 }
 
+// ListKeysWithPrefix 列出指定前缀的键
+func (js *JSONStorage) ListKeysWithPrefix(prefix string) ([]string, error) {
+	keys, err := js.ListKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	return filterKeysWithPrefix(keys, prefix), nil
+}
+
+// Stats 获取存储统计信息：键数量、数据目录（含子目录）的文件大小和数量、
+// 存储键文件最早和最近的修改时间，以及数据目录所在分区的容量
+func (js *JSONStorage) Stats() (types.StorageStats, error) {
+	js.mutex.RLock()
+	defer js.mutex.RUnlock()
+
+	stats := types.StorageStats{
+		Backend: "json",
+		DataDir: js.dataDir,
+	}
+
+	seen := make(map[string]bool)
+	result, err := fswalk.Walk(context.Background(), js.dataDir, fswalk.Options{}, fswalk.Visitor{
+		File: func(path string, depth int, info fs.FileInfo) {
+			// 存储键只保存在数据目录顶层，子目录（如导出文件）只计入大小
+			key, ok := keyFromFileName(info.Name())
+			if depth != 1 || !ok {
+				return
+			}
+			if !seen[key] {
+				seen[key] = true
+				stats.KeyCount++
+			}
+
+			modTime := info.ModTime()
+			if stats.OldestSnapshot == nil || modTime.Before(*stats.OldestSnapshot) {
+				stats.OldestSnapshot = &modTime
+			}
+			if stats.NewestSnapshot == nil || modTime.After(*stats.NewestSnapshot) {
+				stats.NewestSnapshot = &modTime
+			}
+		},
+	})
+	if err != nil {
+		return types.StorageStats{}, fmt.Errorf("failed to read directory: %v", err)
+	}
+	stats.TotalBytes = result.TotalBytes
+	stats.FileCount = result.TotalFiles
+
+	if usage, err := disk.Usage(js.dataDir); err == nil {
+		stats.Partition = &types.StoragePartition{
+			TotalBytes:  usage.Total,
+			FreeBytes:   usage.Free,
+			UsedPercent: usage.UsedPercent,
+		}
+	}
+
+	return stats, nil
+}
+
 // GetDataDir 获取数据目录路径
 func (js *JSONStorage) GetDataDir() string {
 	return js.dataDir
 }
+
+// filterKeysWithPrefix 过滤出指定前缀的键
+func filterKeysWithPrefix(keys []string, prefix string) []string {
+	var filtered []string
+	for _, key := range keys {
+		if strings.HasPrefix(key, prefix) {
+			filtered = append(filtered, key)
+		}
+	}
+	return filtered
+}
+
+// writeFileAtomic 先写入临时文件再重命名，避免中断时留下不完整的文件
+func writeFileAtomic(filePath string, data []byte) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
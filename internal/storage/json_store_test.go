@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"mcp-example/internal/types"
+)
+
+// snapshotProcesses 进程列表快照，结构与后台采集保存的完整快照类似，重复度高，适合压缩
+func snapshotProcesses(count int) []types.ProcessInfo {
+	processes := make([]types.ProcessInfo, count)
+	for i := range processes {
+		processes[i] = types.ProcessInfo{
+			PID:         int32(1000 + i),
+			Name:        fmt.Sprintf("worker-%d", i%8),
+			Status:      "sleeping",
+			CPUPercent:  float64(i%10) / 10,
+			MemoryBytes: uint64(i) * 4096,
+			MemoryMB:    float64(i) * 4096 / 1024 / 1024,
+			CreateTime:  1700000000000 + int64(i),
+			Username:    "www-data",
+		}
+	}
+	return processes
+}
+
+// fileSize 数据目录中文件的大小
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat %s: %v", path, err)
+	}
+	return info.Size()
+}
+
+func TestJSONStorageCompressionReducesSize(t *testing.T) {
+	processes := snapshotProcesses(500)
+
+	plain, err := NewJSONStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed, err := NewJSONStorage(t.TempDir(), WithCompression(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, js := range []*JSONStorage{plain, compressed} {
+		if err := js.Save("snapshot", processes); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	plainSize := fileSize(t, plain.plainPath("snapshot"))
+	compressedSize := fileSize(t, compressed.compressedPath("snapshot"))
+	// 重复的字段名和缩进压缩后至少缩小到五分之一
+	if compressedSize*5 > plainSize {
+		t.Fatalf("compressed size %d bytes, plain %d bytes; want at least a 5x reduction", compressedSize, plainSize)
+	}
+	if _, err := os.Stat(compressed.plainPath("snapshot")); !os.IsNotExist(err) {
+		t.Fatalf("compressed storage wrote an uncompressed file: %v", err)
+	}
+
+	var loaded []types.ProcessInfo
+	if err := compressed.Load("snapshot", &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != len(processes) || loaded[499] != processes[499] {
+		t.Fatalf("compressed round trip lost data: got %d processes", len(loaded))
+	}
+}
+
+func TestJSONStorageMixedDirectory(t *testing.T) {
+	dir := t.TempDir()
+	// 开启压缩前写入的旧文件
+	if err := os.WriteFile(filepath.Join(dir, "old.json"), []byte(`{"value": "old"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	js, err := NewJSONStorage(dir, WithCompression(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := js.Save("new", map[string]string{"value": "new"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"old", "new"} {
+		var value map[string]string
+		if err := js.Load(key, &value); err != nil || value["value"] != key {
+			t.Fatalf("Load(%q) = %v, %v", key, value, err)
+		}
+		if !js.Exists(key) {
+			t.Fatalf("Exists(%q) = false", key)
+		}
+	}
+	keys, err := js.ListKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(keys)
+	if strings.Join(keys, ",") != "new,old" {
+		t.Fatalf("ListKeys() = %v, want logical keys without extensions", keys)
+	}
+
+	// 重新保存旧键后只保留压缩文件
+	if err := js.Save("old", map[string]string{"value": "old"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.json")); !os.IsNotExist(err) {
+		t.Fatal("saving a key in compressed form should remove its uncompressed file")
+	}
+	if keys, _ := js.ListKeys(); len(keys) != 2 {
+		t.Fatalf("ListKeys() = %v, want each key listed once", keys)
+	}
+
+	if err := js.Delete("old"); err != nil {
+		t.Fatal(err)
+	}
+	if js.Exists("old") {
+		t.Fatal("Delete should remove the compressed file")
+	}
+}
+
+func TestJSONStorageAtomicWriteLeavesNoTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	js, err := NewJSONStorage(dir, WithCompression(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := js.Save("snapshot", snapshotProcesses(10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "snapshot"+compressedExt {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Fatalf("data dir contains %v, want only snapshot%s", names, compressedExt)
+	}
+}
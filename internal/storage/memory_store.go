@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"mcp-example/internal/types"
+)
+
+// MemoryStorage 内存存储实现，不写入磁盘（用于临时运行环境和测试）
+type MemoryStorage struct {
+	items map[string][]byte
+	mutex sync.RWMutex
+}
+
+// NewMemoryStorage 创建新的内存存储实例
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		items: make(map[string][]byte),
+	}
+}
+
+// Save 保存数据（序列化为 JSON，与文件存储的语义保持一致）
+func (ms *MemoryStorage) Save(key string, data interface{}) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data: %v", err)
+	}
+
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	ms.items[key] = jsonData
+	return nil
+}
+
+// Load 加载数据
+func (ms *MemoryStorage) Load(key string, data interface{}) error {
+	ms.mutex.RLock()
+	jsonData, exists := ms.items[key]
+	ms.mutex.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("key does not exist: %s", key)
+	}
+
+	if err := json.Unmarshal(jsonData, data); err != nil {
+		return fmt.Errorf("failed to unmarshal data: %v", err)
+	}
+
+	return nil
+}
+
+// Delete 删除数据
+func (ms *MemoryStorage) Delete(key string) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	delete(ms.items, key)
+	return nil
+}
+
+// Exists 检查键是否存在
+func (ms *MemoryStorage) Exists(key string) bool {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	_, exists := ms.items[key]
+	return exists
+}
+
+// ListKeys 列出所有存储的键
+func (ms *MemoryStorage) ListKeys() ([]string, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	keys := make([]string, 0, len(ms.items))
+	for key := range ms.items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// ListKeysWithPrefix 列出指定前缀的键
+func (ms *MemoryStorage) ListKeysWithPrefix(prefix string) ([]string, error) {
+	keys, err := ms.ListKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	return filterKeysWithPrefix(keys, prefix), nil
+}
+
+// Stats 获取存储统计信息
+func (ms *MemoryStorage) Stats() (types.StorageStats, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	return types.StorageStats{
+		Backend:  "memory",
+		KeyCount: len(ms.items),
+	}, nil
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// DefaultNegativeTTL 默认的失败缓存时长
+const DefaultNegativeTTL = 5 * time.Second
+
+// failureEntry 缓存的采集失败记录
+type failureEntry struct {
+	Message   string
+	ExpiresAt time.Time
+}
+
+// NegativeCache 负缓存包装器，在普通缓存之上记录采集失败，
+// 避免慢速失败的采集（如失效的 NFS 挂载）在短时间内被反复执行。
+// 失败记录单独保存，不占用数据缓存的键，不会出现在 Keys、Size、Entries 和 cache_admin 的列表中
+type NegativeCache struct {
+	types.Cache
+	ttl          time.Duration
+	negativeHits atomic.Uint64
+
+	mutex    sync.Mutex
+	failures map[string]failureEntry
+	now      func() time.Time
+}
+
+// NewNegativeCache 创建负缓存包装器，ttl 为 0 时不记录失败
+func NewNegativeCache(cache types.Cache, ttl time.Duration) *NegativeCache {
+	return &NegativeCache{
+		Cache:    cache,
+		ttl:      ttl,
+		failures: make(map[string]failureEntry),
+		now:      time.Now,
+	}
+}
+
+// SetFailure 记录采集失败
+func (nc *NegativeCache) SetFailure(key string, err error) {
+	if nc.ttl <= 0 || err == nil {
+		return
+	}
+
+	nc.mutex.Lock()
+	defer nc.mutex.Unlock()
+	now := nc.now()
+	// 顺便清除已过期的记录，失败记录的键数量不会无限增长
+	for failedKey, entry := range nc.failures {
+		if !now.Before(entry.ExpiresAt) {
+			delete(nc.failures, failedKey)
+		}
+	}
+	nc.failures[key] = failureEntry{
+		Message:   err.Error(),
+		ExpiresAt: now.Add(nc.ttl),
+	}
+}
+
+// GetFailure 获取未过期的失败记录及剩余重试等待时间
+func (nc *NegativeCache) GetFailure(key string) (string, time.Duration, bool) {
+	if nc.ttl <= 0 {
+		return "", 0, false
+	}
+
+	nc.mutex.Lock()
+	defer nc.mutex.Unlock()
+	entry, found := nc.failures[key]
+	if !found {
+		return "", 0, false
+	}
+	retryAfter := entry.ExpiresAt.Sub(nc.now())
+	if retryAfter <= 0 {
+		delete(nc.failures, key)
+		return "", 0, false
+	}
+
+	nc.negativeHits.Add(1)
+	return entry.Message, retryAfter, true
+}
+
+// ClearFailure 清除失败记录（采集成功后调用）
+func (nc *NegativeCache) ClearFailure(key string) {
+	nc.mutex.Lock()
+	defer nc.mutex.Unlock()
+	delete(nc.failures, key)
+}
+
+// Delete 删除缓存项及同一键的失败记录
+func (nc *NegativeCache) Delete(key string) {
+	nc.Cache.Delete(key)
+	nc.ClearFailure(key)
+}
+
+// Clear 清空所有缓存项和失败记录
+func (nc *NegativeCache) Clear() {
+	nc.Cache.Clear()
+	nc.mutex.Lock()
+	defer nc.mutex.Unlock()
+	nc.failures = make(map[string]failureEntry)
+}
+
+// Entries 获取底层缓存的所有缓存项，底层缓存不支持时返回空列表
+func (nc *NegativeCache) Entries() []types.CacheEntryInfo {
+	if admin, ok := nc.Cache.(types.AdminCache); ok {
+		return admin.Entries()
+	}
+	return nil
+}
+
+// Stats 获取缓存统计，包含负缓存命中次数
+func (nc *NegativeCache) Stats() types.CacheStats {
+	var stats types.CacheStats
+	if provider, ok := nc.Cache.(types.CacheStatsProvider); ok {
+		stats = provider.Stats()
+	}
+	stats.NegativeHits = nc.negativeHits.Load()
+
+	return stats
+}
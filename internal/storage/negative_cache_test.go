@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestNegativeCache 使用可控时钟的负缓存，返回推进时钟的函数
+func newTestNegativeCache(ttl time.Duration) (*NegativeCache, func(time.Duration)) {
+	cache := NewNegativeCache(NewMemoryCache(), ttl)
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+	return cache, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestNegativeCacheExpires(t *testing.T) {
+	cache, advance := newTestNegativeCache(5 * time.Second)
+	cache.SetFailure("disk_info", errors.New("statfs timeout"))
+
+	advance(2 * time.Second)
+	message, retryAfter, found := cache.GetFailure("disk_info")
+	if !found || message != "statfs timeout" || retryAfter != 3*time.Second {
+		t.Fatalf("GetFailure = %q, %v, %v; want statfs timeout, 3s, true", message, retryAfter, found)
+	}
+
+	advance(3 * time.Second)
+	if _, _, found := cache.GetFailure("disk_info"); found {
+		t.Fatal("failure should expire after the TTL")
+	}
+	if hits := cache.Stats().NegativeHits; hits != 1 {
+		t.Fatalf("NegativeHits = %d, want 1", hits)
+	}
+}
+
+func TestNegativeCacheDisabled(t *testing.T) {
+	cache, _ := newTestNegativeCache(0)
+	cache.SetFailure("disk_info", errors.New("boom"))
+	if _, _, found := cache.GetFailure("disk_info"); found {
+		t.Fatal("ttl 0 should not record failures")
+	}
+}
+
+func TestNegativeCacheFailuresNotListed(t *testing.T) {
+	cache, _ := newTestNegativeCache(time.Minute)
+	cache.Set("disk_info", "data", time.Minute)
+	cache.SetFailure("disk_info", errors.New("boom"))
+	cache.SetFailure("network_stats", errors.New("boom"))
+
+	if size := cache.Stats().Size; size != 1 {
+		t.Fatalf("Stats().Size = %d, want 1 (failures must not count as entries)", size)
+	}
+	entries := cache.Entries()
+	if len(entries) != 1 || entries[0].Key != "disk_info" {
+		t.Fatalf("Entries() = %+v, want only disk_info", entries)
+	}
+	if keys := cache.Cache.(*MemoryCache).Keys(); len(keys) != 1 || keys[0] != "disk_info" {
+		t.Fatalf("underlying Keys() = %v, want [disk_info]", keys)
+	}
+}
+
+func TestNegativeCacheDeleteAndClear(t *testing.T) {
+	cache, _ := newTestNegativeCache(time.Minute)
+	cache.SetFailure("a", errors.New("boom"))
+	cache.SetFailure("b", errors.New("boom"))
+
+	cache.Delete("a")
+	if _, _, found := cache.GetFailure("a"); found {
+		t.Fatal("Delete should clear the failure of the same key")
+	}
+	if _, _, found := cache.GetFailure("b"); !found {
+		t.Fatal("Delete should not clear other keys")
+	}
+
+	cache.Clear()
+	if _, _, found := cache.GetFailure("b"); found {
+		t.Fatal("Clear should clear all failures")
+	}
+}
+
+func TestNegativeCacheClearFailure(t *testing.T) {
+	cache, _ := newTestNegativeCache(time.Minute)
+	cache.SetFailure("a", errors.New("boom"))
+	cache.ClearFailure("a")
+	if _, _, found := cache.GetFailure("a"); found {
+		t.Fatal("ClearFailure should remove the failure")
+	}
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"mcp-example/internal/types"
+)
+
+// storageBackends 运行一致性测试的存储实现
+func storageBackends() map[string]func(t *testing.T) types.DataStorage {
+	newJSON := func(opts ...JSONStorageOption) func(t *testing.T) types.DataStorage {
+		return func(t *testing.T) types.DataStorage {
+			js, err := NewJSONStorage(t.TempDir(), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return js
+		}
+	}
+	return map[string]func(t *testing.T) types.DataStorage{
+		"memory":          func(t *testing.T) types.DataStorage { return NewMemoryStorage() },
+		"json":            newJSON(),
+		"json_compressed": newJSON(WithCompression(true)),
+	}
+}
+
+// listKeys 排序后的指定前缀的键，各实现不保证 ListKeys 的顺序
+func listKeys(t *testing.T, ds types.DataStorage, prefix string) []string {
+	t.Helper()
+	keys, err := ds.ListKeysWithPrefix(prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+type conformanceRecord struct {
+	Name  string            `json:"name"`
+	Count int               `json:"count"`
+	Tags  map[string]string `json:"tags"`
+}
+
+func TestStorageConformance(t *testing.T) {
+	for name, newStorage := range storageBackends() {
+		t.Run(name, func(t *testing.T) {
+			t.Run("SaveLoad", func(t *testing.T) {
+				ds := newStorage(t)
+				want := conformanceRecord{Name: "cpu", Count: 3, Tags: map[string]string{"host": "a"}}
+				if err := ds.Save("record", want); err != nil {
+					t.Fatal(err)
+				}
+				var got conformanceRecord
+				if err := ds.Load("record", &got); err != nil {
+					t.Fatal(err)
+				}
+				if got.Name != want.Name || got.Count != want.Count || got.Tags["host"] != "a" {
+					t.Fatalf("Load = %+v, want %+v", got, want)
+				}
+			})
+
+			t.Run("Overwrite", func(t *testing.T) {
+				ds := newStorage(t)
+				for i := 1; i <= 2; i++ {
+					if err := ds.Save("record", conformanceRecord{Count: i}); err != nil {
+						t.Fatal(err)
+					}
+				}
+				var got conformanceRecord
+				if err := ds.Load("record", &got); err != nil || got.Count != 2 {
+					t.Fatalf("Load = %+v, %v; want the second save", got, err)
+				}
+				if keys := listKeys(t, ds, ""); len(keys) != 1 {
+					t.Fatalf("ListKeys() = %v, want one key", keys)
+				}
+			})
+
+			t.Run("MissingKey", func(t *testing.T) {
+				ds := newStorage(t)
+				var got conformanceRecord
+				if err := ds.Load("missing", &got); err == nil {
+					t.Fatal("Load of a missing key should fail")
+				}
+				if ds.Exists("missing") {
+					t.Fatal("Exists(missing) = true")
+				}
+				if err := ds.Delete("missing"); err != nil {
+					t.Fatalf("Delete of a missing key = %v, want nil", err)
+				}
+			})
+
+			t.Run("ExistsDelete", func(t *testing.T) {
+				ds := newStorage(t)
+				if err := ds.Save("record", conformanceRecord{}); err != nil {
+					t.Fatal(err)
+				}
+				if !ds.Exists("record") {
+					t.Fatal("Exists after Save = false")
+				}
+				if err := ds.Delete("record"); err != nil {
+					t.Fatal(err)
+				}
+				if ds.Exists("record") {
+					t.Fatal("Exists after Delete = true")
+				}
+				if keys := listKeys(t, ds, ""); len(keys) != 0 {
+					t.Fatalf("ListKeys() after Delete = %v", keys)
+				}
+			})
+
+			t.Run("ListKeys", func(t *testing.T) {
+				ds := newStorage(t)
+				if keys := listKeys(t, ds, ""); len(keys) != 0 {
+					t.Fatalf("empty storage ListKeys() = %v", keys)
+				}
+				for _, key := range []string{"snapshot_2", "alert_rules", "snapshot_1"} {
+					if err := ds.Save(key, conformanceRecord{Name: key}); err != nil {
+						t.Fatal(err)
+					}
+				}
+				keys, err := ds.ListKeys()
+				if err != nil {
+					t.Fatal(err)
+				}
+				slices.Sort(keys)
+				if !slices.Equal(keys, []string{"alert_rules", "snapshot_1", "snapshot_2"}) {
+					t.Fatalf("ListKeys() = %v", keys)
+				}
+				if keys := listKeys(t, ds, ""); len(keys) != 3 {
+					t.Fatalf("ListKeysWithPrefix(\"\") = %v, want all keys", keys)
+				}
+				if keys := listKeys(t, ds, "snapshot_"); !slices.Equal(keys, []string{"snapshot_1", "snapshot_2"}) {
+					t.Fatalf("ListKeysWithPrefix(snapshot_) = %v", keys)
+				}
+				if keys := listKeys(t, ds, "none_"); len(keys) != 0 {
+					t.Fatalf("ListKeysWithPrefix(none_) = %v", keys)
+				}
+			})
+
+			t.Run("Stats", func(t *testing.T) {
+				ds := newStorage(t)
+				for _, key := range []string{"a", "b"} {
+					if err := ds.Save(key, conformanceRecord{}); err != nil {
+						t.Fatal(err)
+					}
+				}
+				provider, ok := ds.(types.StorageStatsProvider)
+				if !ok {
+					t.Fatal("storage does not implement StorageStatsProvider")
+				}
+				stats, err := provider.Stats()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if stats.KeyCount != 2 || stats.Backend == "" {
+					t.Fatalf("Stats() = %+v, want 2 keys and a backend name", stats)
+				}
+			})
+
+			t.Run("Concurrent", func(t *testing.T) {
+				ds := newStorage(t)
+				var wg sync.WaitGroup
+				for i := 0; i < 8; i++ {
+					wg.Add(1)
+					go func(i int) {
+						defer wg.Done()
+						key := fmt.Sprintf("record_%d", i)
+						if err := ds.Save(key, conformanceRecord{Count: i}); err != nil {
+							t.Error(err)
+							return
+						}
+						var got conformanceRecord
+						if err := ds.Load(key, &got); err != nil || got.Count != i {
+							t.Errorf("Load(%s) = %+v, %v", key, got, err)
+						}
+					}(i)
+				}
+				wg.Wait()
+				if keys := listKeys(t, ds, ""); len(keys) != 8 {
+					t.Fatalf("ListKeys() = %v, want 8 keys", keys)
+				}
+			})
+		})
+	}
+}
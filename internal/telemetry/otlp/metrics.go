@@ -0,0 +1,139 @@
+package otlp
+
+import (
+	"context"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"mcp-example/internal/telemetry"
+)
+
+// instruments 导出的指标。系统指标使用 OpenTelemetry 语义约定的名称（使用率为 0–1 的比例），
+// 内部计数与 /healthz、server_stats 中的字段一一对应
+type instruments struct {
+	cpuUtilization        metric.Float64ObservableGauge
+	memoryUtilization     metric.Float64ObservableGauge
+	filesystemUtilization metric.Float64ObservableGauge
+	networkIO             metric.Int64ObservableCounter
+	load1                 metric.Float64ObservableGauge
+	load5                 metric.Float64ObservableGauge
+	load15                metric.Float64ObservableGauge
+
+	uptime            metric.Float64ObservableGauge
+	goroutines        metric.Int64ObservableGauge
+	cacheSize         metric.Int64ObservableGauge
+	cacheHits         metric.Int64ObservableCounter
+	cacheMisses       metric.Int64ObservableCounter
+	cacheNegativeHits metric.Int64ObservableCounter
+	sessionRequests   metric.Int64ObservableCounter
+	sessionErrors     metric.Int64ObservableCounter
+	toolCalls         metric.Int64ObservableCounter
+	toolErrors        metric.Int64ObservableCounter
+	toolDuration      metric.Int64ObservableCounter
+	toolRateLimited   metric.Int64ObservableCounter
+}
+
+// registerMetrics 创建所有指标，每次导出只调用一次 snapshot
+func registerMetrics(meter metric.Meter, snapshot telemetry.SnapshotFunc) error {
+	var m instruments
+	var err error
+	float64Gauge := func(target *metric.Float64ObservableGauge, name, unit, description string) {
+		if err == nil {
+			*target, err = meter.Float64ObservableGauge(name, metric.WithUnit(unit), metric.WithDescription(description))
+		}
+	}
+	int64Gauge := func(target *metric.Int64ObservableGauge, name, unit, description string) {
+		if err == nil {
+			*target, err = meter.Int64ObservableGauge(name, metric.WithUnit(unit), metric.WithDescription(description))
+		}
+	}
+	int64Counter := func(target *metric.Int64ObservableCounter, name, unit, description string) {
+		if err == nil {
+			*target, err = meter.Int64ObservableCounter(name, metric.WithUnit(unit), metric.WithDescription(description))
+		}
+	}
+
+	float64Gauge(&m.cpuUtilization, "system.cpu.utilization", "1", "CPU 使用率")
+	float64Gauge(&m.memoryUtilization, "system.memory.utilization", "1", "内存使用率")
+	float64Gauge(&m.filesystemUtilization, "system.filesystem.utilization", "1", "分区使用率")
+	int64Counter(&m.networkIO, "system.network.io", "By", "网络接口累计收发字节数")
+	float64Gauge(&m.load1, "system.cpu.load_average.1m", "1", "1 分钟平均负载")
+	float64Gauge(&m.load5, "system.cpu.load_average.5m", "1", "5 分钟平均负载")
+	float64Gauge(&m.load15, "system.cpu.load_average.15m", "1", "15 分钟平均负载")
+
+	float64Gauge(&m.uptime, "mcp.server.uptime", "s", "服务器运行时长")
+	int64Gauge(&m.goroutines, "mcp.server.goroutines", "{goroutine}", "goroutine 数")
+	int64Gauge(&m.cacheSize, "mcp.cache.size", "{entry}", "缓存项数")
+	int64Counter(&m.cacheHits, "mcp.cache.hits", "{hit}", "缓存命中次数")
+	int64Counter(&m.cacheMisses, "mcp.cache.misses", "{miss}", "缓存未命中次数")
+	int64Counter(&m.cacheNegativeHits, "mcp.cache.negative_hits", "{hit}", "采集失败缓存的命中次数")
+	int64Counter(&m.sessionRequests, "mcp.session.requests", "{request}", "会话处理的请求数")
+	int64Counter(&m.sessionErrors, "mcp.session.errors", "{request}", "会话中返回错误的请求数")
+	int64Counter(&m.toolCalls, "mcp.tool.calls", "{call}", "工具调用次数")
+	int64Counter(&m.toolErrors, "mcp.tool.errors", "{call}", "失败的工具调用次数")
+	int64Counter(&m.toolDuration, "mcp.tool.duration", "ms", "工具调用的累计耗时")
+	int64Counter(&m.toolRateLimited, "mcp.tool.rate_limited", "{call}", "因限流被拒绝的工具调用次数")
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, observer metric.Observer) error {
+		m.observe(observer, snapshot(ctx))
+		return nil
+	},
+		m.cpuUtilization, m.memoryUtilization, m.filesystemUtilization, m.networkIO, m.load1, m.load5, m.load15,
+		m.uptime, m.goroutines, m.cacheSize, m.cacheHits, m.cacheMisses, m.cacheNegativeHits,
+		m.sessionRequests, m.sessionErrors, m.toolCalls, m.toolErrors, m.toolDuration, m.toolRateLimited,
+	)
+	return err
+}
+
+// observe 记录一次导出的所有指标，读取失败的系统指标不记录
+func (m *instruments) observe(observer metric.Observer, s telemetry.Snapshot) {
+	system := s.System
+	if !slices.Contains(s.Missing, "cpu") {
+		observer.ObserveFloat64(m.cpuUtilization, system.CPUPercent/100)
+	}
+	if !slices.Contains(s.Missing, "memory") {
+		observer.ObserveFloat64(m.memoryUtilization, system.MemoryPercent/100)
+	}
+	for mountpoint, percent := range system.DiskPercent {
+		observer.ObserveFloat64(m.filesystemUtilization, percent/100,
+			metric.WithAttributes(attribute.String("system.filesystem.mountpoint", mountpoint)))
+	}
+	for device, bytes := range system.NetRxBytes {
+		observer.ObserveInt64(m.networkIO, int64(bytes),
+			metric.WithAttributes(attribute.String("system.device", device), attribute.String("network.io.direction", "receive")))
+	}
+	for device, bytes := range system.NetTxBytes {
+		observer.ObserveInt64(m.networkIO, int64(bytes),
+			metric.WithAttributes(attribute.String("system.device", device), attribute.String("network.io.direction", "transmit")))
+	}
+	if s.Load != nil {
+		observer.ObserveFloat64(m.load1, s.Load.Load1)
+		observer.ObserveFloat64(m.load5, s.Load.Load5)
+		observer.ObserveFloat64(m.load15, s.Load.Load15)
+	}
+
+	status := s.Status
+	observer.ObserveFloat64(m.uptime, status.UptimeSeconds)
+	observer.ObserveInt64(m.goroutines, int64(status.Goroutines))
+	observer.ObserveInt64(m.cacheSize, int64(status.Cache.Size))
+	observer.ObserveInt64(m.cacheHits, int64(status.Cache.Hits))
+	observer.ObserveInt64(m.cacheMisses, int64(status.Cache.Misses))
+	observer.ObserveInt64(m.cacheNegativeHits, int64(status.Cache.NegativeHits))
+	for _, session := range status.Sessions {
+		sessionAttribute := metric.WithAttributes(attribute.String("mcp.session.id", session.ID))
+		observer.ObserveInt64(m.sessionRequests, int64(session.Requests), sessionAttribute)
+		observer.ObserveInt64(m.sessionErrors, int64(session.Errors), sessionAttribute)
+	}
+	for _, tool := range s.Tools {
+		toolAttribute := metric.WithAttributes(attribute.String("mcp.tool.name", tool.Tool))
+		observer.ObserveInt64(m.toolCalls, int64(tool.Calls), toolAttribute)
+		observer.ObserveInt64(m.toolErrors, int64(tool.Errors), toolAttribute)
+		observer.ObserveInt64(m.toolDuration, tool.TotalMs, toolAttribute)
+		observer.ObserveInt64(m.toolRateLimited, int64(tool.RateLimited), toolAttribute)
+	}
+}
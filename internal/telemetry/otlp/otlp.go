@@ -0,0 +1,175 @@
+// Package otlp 基于 OpenTelemetry SDK 的 telemetry 实现：通过 OTLP/HTTP 导出每次工具调用的 span，
+// 并按固定间隔导出系统指标和服务器内部计数
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"mcp-example/internal/telemetry"
+)
+
+// DefaultInterval 指标的默认导出间隔
+const DefaultInterval = time.Minute
+
+// instrumentationName 追踪和指标的 instrumentation scope
+const instrumentationName = "mcp-example/internal/telemetry/otlp"
+
+// Options 导出配置
+type Options struct {
+	// Endpoint OTLP/HTTP 接收端的基础地址，如 http://localhost:4318，追踪和指标分别发送到 /v1/traces 和 /v1/metrics。
+	// 没有协议时按 http 处理
+	Endpoint string
+	// Interval 指标的导出间隔，不大于 0 时使用 DefaultInterval
+	Interval       time.Duration
+	ServiceName    string
+	ServiceVersion string
+	HostName       string
+}
+
+// Exporter OTLP 导出。调用 Shutdown 前可在多个 goroutine 中使用
+type Exporter struct {
+	tracerProvider *sdktrace.TracerProvider
+	meterProvider  *sdkmetric.MeterProvider
+	tracer         trace.Tracer
+}
+
+// New 创建 OTLP 导出，snapshot 在每次导出指标时调用。接收端暂时不可用不会导致失败，导出时重试并记录错误
+func New(ctx context.Context, options Options, snapshot telemetry.SnapshotFunc) (*Exporter, error) {
+	endpoint, err := parseEndpoint(options.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	traceOptions := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(endpoint.Host),
+		otlptracehttp.WithURLPath(strings.TrimSuffix(endpoint.Path, "/") + "/v1/traces"),
+	}
+	metricOptions := []otlpmetrichttp.Option{
+		otlpmetrichttp.WithEndpoint(endpoint.Host),
+		otlpmetrichttp.WithURLPath(strings.TrimSuffix(endpoint.Path, "/") + "/v1/metrics"),
+	}
+	if endpoint.Scheme != "https" {
+		traceOptions = append(traceOptions, otlptracehttp.WithInsecure())
+		metricOptions = append(metricOptions, otlpmetrichttp.WithInsecure())
+	}
+
+	traceExporter, err := otlptracehttp.New(ctx, traceOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("创建 OTLP 追踪导出失败: %v", err)
+	}
+	metricExporter, err := otlpmetrichttp.New(ctx, metricOptions...)
+	if err != nil {
+		traceExporter.Shutdown(ctx)
+		return nil, fmt.Errorf("创建 OTLP 指标导出失败: %v", err)
+	}
+
+	interval := options.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	res := serviceResource(options)
+	return newExporter(
+		[]sdktrace.TracerProviderOption{sdktrace.WithBatcher(traceExporter), sdktrace.WithResource(res)},
+		[]sdkmetric.Option{sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter, sdkmetric.WithInterval(interval))), sdkmetric.WithResource(res)},
+		snapshot,
+	)
+}
+
+// newExporter 用给定的 provider 配置创建导出，测试中可换成 SDK 的内存导出
+func newExporter(traceOptions []sdktrace.TracerProviderOption, metricOptions []sdkmetric.Option, snapshot telemetry.SnapshotFunc) (*Exporter, error) {
+	e := &Exporter{
+		tracerProvider: sdktrace.NewTracerProvider(traceOptions...),
+		meterProvider:  sdkmetric.NewMeterProvider(metricOptions...),
+	}
+	e.tracer = e.tracerProvider.Tracer(instrumentationName)
+	if err := registerMetrics(e.meterProvider.Meter(instrumentationName), snapshot); err != nil {
+		e.Shutdown(context.Background())
+		return nil, fmt.Errorf("注册指标失败: %v", err)
+	}
+	return e, nil
+}
+
+// parseEndpoint 解析接收端地址，host:port 形式按 http 处理
+func parseEndpoint(endpoint string) (*url.URL, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 OTLP 接收端地址 %q: %v", endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("无效的 OTLP 接收端地址 %q: 只支持 http 和 https", endpoint)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("无效的 OTLP 接收端地址 %q: 缺少主机", endpoint)
+	}
+	return u, nil
+}
+
+// serviceResource 导出数据的资源属性：服务名称、版本和主机名，以及 OTEL_RESOURCE_ATTRIBUTES 等环境变量中的属性
+func serviceResource(options Options) *resource.Resource {
+	attributes := []attribute.KeyValue{semconv.ServiceName(options.ServiceName)}
+	if options.ServiceVersion != "" {
+		attributes = append(attributes, semconv.ServiceVersion(options.ServiceVersion))
+	}
+	if options.HostName != "" {
+		attributes = append(attributes, semconv.HostName(options.HostName))
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, attributes...))
+	if err != nil {
+		return resource.NewWithAttributes(semconv.SchemaURL, attributes...)
+	}
+	return res
+}
+
+// StartToolCall 开始一个 tools/call span，traceparent 有效时以其为父级，否则开始新的追踪
+func (e *Exporter) StartToolCall(ctx context.Context, tool, traceparent string) (context.Context, telemetry.ToolCall) {
+	if traceparent != "" {
+		ctx = propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{"traceparent": traceparent})
+	}
+	ctx, span := e.tracer.Start(ctx, "tools/call "+tool,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("mcp.tool.name", tool)),
+	)
+	return ctx, toolCall{span: span}
+}
+
+// Shutdown 导出尚未发送的 span 和最后一次指标，然后关闭导出，最多等待到 ctx 结束
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	return errors.Join(e.tracerProvider.Shutdown(ctx), e.meterProvider.Shutdown(ctx))
+}
+
+// toolCall 进行中的 tools/call span
+type toolCall struct {
+	span trace.Span
+}
+
+func (c toolCall) End(result telemetry.ToolCallResult) {
+	c.span.SetAttributes(
+		attribute.Bool("mcp.tool.cached", result.Cached),
+		attribute.Int64("mcp.tool.duration_ms", result.Duration.Milliseconds()),
+		attribute.Bool("error", result.ErrorCode != ""),
+	)
+	if result.ErrorCode != "" {
+		c.span.SetAttributes(attribute.String("error.type", result.ErrorCode))
+		c.span.SetStatus(codes.Error, result.Error)
+	}
+	c.span.End()
+}
@@ -0,0 +1,206 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"mcp-example/internal/router"
+	"mcp-example/internal/telemetry"
+	"mcp-example/internal/types"
+)
+
+// stubTool 返回固定文本或错误的工具
+type stubTool struct {
+	name string
+	err  error
+}
+
+func (st stubTool) GetName() string        { return st.name }
+func (st stubTool) GetDescription() string { return "测试工具" }
+func (st stubTool) GetInputSchema() types.InputSchema {
+	return types.InputSchema{Type: "object", Properties: map[string]types.Property{}}
+}
+func (st stubTool) Execute(context.Context, map[string]interface{}) (string, error) {
+	return "ok", st.err
+}
+
+// newTestExporter 把 span 记录在内存中、指标由测试手动读取的导出，使用 handler 的调用统计作为指标数据
+func newTestExporter(t *testing.T, handler *router.MCPHandler) (*tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+	t.Helper()
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	exporter, err := newExporter(
+		[]sdktrace.TracerProviderOption{sdktrace.WithSpanProcessor(spans)},
+		[]sdkmetric.Option{sdkmetric.WithReader(reader)},
+		func(context.Context) telemetry.Snapshot {
+			return telemetry.Snapshot{Missing: []string{"cpu", "memory", "disk", "network", "load"}, Tools: handler.ToolStats()}
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { exporter.Shutdown(context.Background()) })
+	handler.SetTelemetry(exporter)
+	return spans, reader
+}
+
+// callTool 发送一次 tools/call，meta 非空时作为 params._meta
+func callTool(handler *router.MCPHandler, id int, name string, meta map[string]interface{}) *types.JSONRPCResponse {
+	params := map[string]interface{}{"name": name, "arguments": map[string]interface{}{}}
+	if meta != nil {
+		params["_meta"] = meta
+	}
+	return handler.HandleRequest(context.Background(), nil, &types.JSONRPCRequest{JSONRPC: "2.0", ID: id, Method: types.MethodCallTool, Params: params})
+}
+
+// spanAttributes span 的属性，按键索引
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attributes := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attributes[kv.Key] = kv.Value
+	}
+	return attributes
+}
+
+func TestToolCallSpans(t *testing.T) {
+	handler := router.NewMCPHandler("test-server", "0.0.0")
+	handler.RegisterTool(stubTool{name: "echo"})
+	handler.RegisterTool(stubTool{name: "broken", err: fmt.Errorf("open /proc/42/stat: %w", fs.ErrNotExist)})
+	spans, _ := newTestExporter(t, handler)
+
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	callTool(handler, 1, "echo", map[string]interface{}{"traceparent": parent})
+	callTool(handler, 2, "broken", nil)
+
+	ended := spans.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("%d spans ended, want one per tools/call", len(ended))
+	}
+
+	ok := ended[0]
+	if ok.Name() != "tools/call echo" || ok.SpanKind() != trace.SpanKindServer {
+		t.Errorf("span = %q (%s), want a server span named after the tool", ok.Name(), ok.SpanKind())
+	}
+	attributes := spanAttributes(ok)
+	if attributes["mcp.tool.name"].AsString() != "echo" || attributes["mcp.tool.cached"].AsBool() || attributes["error"].AsBool() {
+		t.Errorf("attributes = %v, want tool echo, not cached, no error", attributes)
+	}
+	if _, found := attributes["mcp.tool.duration_ms"]; !found {
+		t.Errorf("attributes = %v, want the duration", attributes)
+	}
+	if _, found := attributes["error.type"]; found || ok.Status().Code != codes.Unset {
+		t.Errorf("successful call has error.type or status %v", ok.Status())
+	}
+	// 客户端的 traceparent 作为父级，span 属于同一个追踪
+	if ok.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || ok.Parent().SpanID().String() != "00f067aa0ba902b7" || !ok.Parent().IsRemote() {
+		t.Errorf("trace %s parent %s, want the client's traceparent", ok.SpanContext().TraceID(), ok.Parent().SpanID())
+	}
+
+	failed := ended[1]
+	attributes = spanAttributes(failed)
+	if failed.Name() != "tools/call broken" || !attributes["error"].AsBool() || attributes["error.type"].AsString() != "ERR_NOT_FOUND" {
+		t.Errorf("failed span %q attributes = %v, want error.type ERR_NOT_FOUND", failed.Name(), attributes)
+	}
+	if failed.Status().Code != codes.Error || failed.Status().Description == "" {
+		t.Errorf("failed span status = %+v, want an error with the message", failed.Status())
+	}
+	// 没有 traceparent 时开始新的追踪
+	if failed.Parent().IsValid() || failed.SpanContext().TraceID() == ok.SpanContext().TraceID() {
+		t.Errorf("span without traceparent has parent %s", failed.Parent().SpanID())
+	}
+}
+
+func TestToolCallMetrics(t *testing.T) {
+	handler := router.NewMCPHandler("test-server", "0.0.0")
+	handler.RegisterTool(stubTool{name: "echo"})
+	handler.RegisterTool(stubTool{name: "broken", err: fs.ErrPermission})
+	_, reader := newTestExporter(t, handler)
+
+	callTool(handler, 1, "echo", nil)
+	callTool(handler, 2, "echo", nil)
+	callTool(handler, 3, "broken", nil)
+
+	var collected metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &collected); err != nil {
+		t.Fatal(err)
+	}
+	metrics := make(map[string]metricdata.Metrics)
+	for _, scope := range collected.ScopeMetrics {
+		if scope.Scope.Name != instrumentationName {
+			t.Errorf("scope = %q, want %q", scope.Scope.Name, instrumentationName)
+		}
+		for _, m := range scope.Metrics {
+			metrics[m.Name] = m
+		}
+	}
+
+	// 读取失败的系统指标不导出
+	for _, name := range []string{"system.cpu.utilization", "system.memory.utilization", "system.cpu.load_average.1m"} {
+		if _, found := metrics[name]; found {
+			t.Errorf("%s exported although the snapshot lists it as missing", name)
+		}
+	}
+
+	// perTool 累计计数指标各工具的数据点
+	perTool := func(name string) map[string]int64 {
+		t.Helper()
+		m, found := metrics[name]
+		if !found {
+			t.Fatalf("instrument %s not exported", name)
+		}
+		sum, ok := m.Data.(metricdata.Sum[int64])
+		if !ok || !sum.IsMonotonic || sum.Temporality != metricdata.CumulativeTemporality {
+			t.Fatalf("%s = %T, want a cumulative monotonic int64 sum", name, m.Data)
+		}
+		values := make(map[string]int64)
+		for _, point := range sum.DataPoints {
+			tool, _ := point.Attributes.Value("mcp.tool.name")
+			values[tool.AsString()] = point.Value
+		}
+		return values
+	}
+	if calls := perTool("mcp.tool.calls"); calls["echo"] != 2 || calls["broken"] != 1 || len(calls) != 2 {
+		t.Errorf("mcp.tool.calls = %v, want echo 2 and broken 1", calls)
+	}
+	if errs := perTool("mcp.tool.errors"); errs["echo"] != 0 || errs["broken"] != 1 {
+		t.Errorf("mcp.tool.errors = %v, want only broken", errs)
+	}
+	if _, found := perTool("mcp.tool.duration")["echo"]; !found {
+		t.Error("mcp.tool.duration has no data point for echo")
+	}
+	if m := metrics["mcp.tool.duration"]; m.Unit != "ms" {
+		t.Errorf("mcp.tool.duration unit = %q, want ms", m.Unit)
+	}
+	if _, found := metrics["mcp.server.goroutines"]; !found {
+		t.Error("internal counters are not exported")
+	}
+}
+
+func TestParseEndpoint(t *testing.T) {
+	for _, c := range []struct {
+		endpoint, scheme, host, path string
+	}{
+		{"localhost:4318", "http", "localhost:4318", ""},
+		{"https://collector.example.com/otlp", "https", "collector.example.com", "/otlp"},
+	} {
+		u, err := parseEndpoint(c.endpoint)
+		if err != nil || u.Scheme != c.scheme || u.Host != c.host || u.Path != c.path {
+			t.Errorf("parseEndpoint(%q) = %v, %v", c.endpoint, u, err)
+		}
+	}
+	for _, endpoint := range []string{"ftp://collector:21", "http://"} {
+		if _, err := parseEndpoint(endpoint); err == nil {
+			t.Errorf("parseEndpoint(%q) accepted an invalid endpoint", endpoint)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Package telemetry 服务器自身的可观测性数据导出（--otel-endpoint）。路由器只依赖本包的接口，
+// 未启用导出时使用 Nop，不创建 OpenTelemetry SDK 的任何组件；OTLP 实现见子包 otlp
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// Telemetry 为工具调用生成追踪数据
+type Telemetry interface {
+	// StartToolCall 开始一次 tools/call 的追踪。traceparent 为客户端在 params._meta 中传入的
+	// W3C traceparent（可为空），有效时作为父级
+	StartToolCall(ctx context.Context, tool, traceparent string) (context.Context, ToolCall)
+}
+
+// ToolCall 进行中的工具调用追踪
+type ToolCall interface {
+	// End 结束追踪并记录调用结果
+	End(result ToolCallResult)
+}
+
+// ToolCallResult 工具调用的结果
+type ToolCallResult struct {
+	// Cached 所有数据都来自缓存（包括降级数据）
+	Cached   bool
+	Duration time.Duration
+	// ErrorCode 失败时的错误代码（如 timeout、not_found），成功时为空
+	ErrorCode string
+	// Error 失败时的错误信息
+	Error string
+}
+
+// Snapshot 一次指标导出读取的数据：系统指标与后台采集的样本相同，内部计数与 /healthz 和 server_stats 相同
+type Snapshot struct {
+	// System 系统指标，读取失败的项保持为零值并在 Missing 中列出
+	System types.MetricSample
+	Load   *types.LoadAverage
+	// Missing 本次读取失败的系统指标（cpu、memory、disk、network、load），不导出
+	Missing []string
+	Status  types.DiagnosticsStatus
+	Tools   []types.ToolCallStats
+}
+
+// SnapshotFunc 读取指标导出的数据，在导出的 goroutine 中调用
+type SnapshotFunc func(ctx context.Context) Snapshot
+
+// Nop 不导出任何数据的实现
+var Nop Telemetry = nop{}
+
+type nop struct{}
+
+func (nop) StartToolCall(ctx context.Context, tool, traceparent string) (context.Context, ToolCall) {
+	return ctx, nop{}
+}
+
+func (nop) End(ToolCallResult) {}
@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/types"
+)
+
+// anomalyReport 异常记录查询结果（最新的在前）
+type anomalyReport struct {
+	Anomalies []types.Anomaly     `json:"anomalies"`
+	Total     int                 `json:"total"`
+	Host      *types.HostIdentity `json:"host,omitempty"`
+}
+
+// AnomaliesTool 异常记录查询工具，数据由后台采集器写入
+type AnomaliesTool struct {
+	storage types.DataStorage
+}
+
+// NewAnomaliesTool 创建新的异常记录查询工具
+func NewAnomaliesTool(dataStorage types.DataStorage) *AnomaliesTool {
+	return &AnomaliesTool{
+		storage: dataStorage,
+	}
+}
+
+// GetName 获取工具名称
+func (at *AnomaliesTool) GetName() string {
+	return "anomalies"
+}
+
+// GetDescription 获取工具描述
+func (at *AnomaliesTool) GetDescription() string {
+	return "查询后台采集检测到的指标异常（偏离滚动均值超过设定的标准差倍数），最新的在前"
+}
+
+// GetAnnotations 获取工具注解
+func (at *AnomaliesTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("指标异常")
+}
+
+// anomaliesArgs anomalies 的参数
+type anomaliesArgs struct {
+	Metric string `arg:"metric,enum=cpu_percent|memory_percent|disk_percent" desc:"只返回该指标的异常（为空表示全部）"`
+	Limit  int    `arg:"limit,default=20,min=1,max=500" desc:"最多返回的异常记录数量"`
+	formatArgs
+}
+
+// GetInputSchema 获取输入模式
+func (at *AnomaliesTool) GetInputSchema() types.InputSchema {
+	return argsSchema(anomaliesArgs{})
+}
+
+// Examples 获取调用示例
+func (at *AnomaliesTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "最近 20 条各类指标异常",
+			Arguments:   map[string]interface{}{},
+		},
+		{
+			Description: "最近 5 条 CPU 使用率异常，JSON 格式",
+			Arguments:   map[string]interface{}{"metric": MetricCPUPercent, "limit": 5, "format": "json"},
+		},
+	}
+}
+
+// Execute 查询异常记录
+func (at *AnomaliesTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a anomaliesArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+
+	var stored []types.Anomaly
+	if at.storage.Exists(AnomaliesKey) {
+		if err := at.storage.Load(AnomaliesKey, &stored); err != nil {
+			return "", wrapError("加载异常记录失败", err)
+		}
+	}
+
+	report := anomalyReport{Anomalies: []types.Anomaly{}}
+	for i := len(stored) - 1; i >= 0; i-- {
+		if a.Metric != "" && stored[i].Metric != a.Metric {
+			continue
+		}
+		report.Total++
+		if len(report.Anomalies) < a.Limit {
+			report.Anomalies = append(report.Anomalies, stored[i])
+		}
+	}
+
+	if a.Format == "json" {
+		report.Host = identity.Get()
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", wrapError("序列化异常记录失败", err)
+		}
+		return string(jsonData), nil
+	}
+
+	return at.formatReport(report), nil
+}
+
+// formatReport 格式化异常记录
+func (at *AnomaliesTool) formatReport(report anomalyReport) string {
+	var result string
+
+	result += "🚨 指标异常\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+
+	if len(report.Anomalies) == 0 {
+		result += "暂无异常记录（需要通过 --collect-interval 启用后台采集）\n"
+		return result
+	}
+
+	result += fmt.Sprintf("%-20s %-22s %-10s %-10s %-10s %s\n", "时间", "指标", "值", "均值", "标准差", "偏离")
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	for _, item := range report.Anomalies {
+		name := item.Metric
+		if item.Selector != "" {
+			name += " " + item.Selector
+		}
+		result += fmt.Sprintf("%-20s %s %-10.2f %-10.2f %-10.2f %.1fσ\n",
+			item.Timestamp.Format("2006-01-02 15:04:05"),
+			fitColumn(name, 22),
+			item.Value,
+			item.Mean,
+			item.StdDev,
+			item.Sigmas,
+		)
+	}
+
+	result += fmt.Sprintf("\n共 %d 条，显示最新的 %d 条\n", report.Total, len(report.Anomalies))
+
+	return result
+}
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+func TestAnomaliesTool(t *testing.T) {
+	dataStorage := storage.NewMemoryStorage()
+	tool := NewAnomaliesTool(dataStorage)
+
+	// 没有记录时提示启用后台采集
+	text, err := tool.Execute(context.Background(), map[string]interface{}{})
+	if err != nil || !strings.Contains(text, "暂无异常记录") {
+		t.Fatalf("without anomalies = %q, %v", text, err)
+	}
+
+	start := time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC)
+	stored := []types.Anomaly{
+		{Timestamp: start, Metric: MetricCPUPercent, Value: 95, Mean: 20, StdDev: 5, Sigmas: 15},
+		{Timestamp: start.Add(time.Minute), Metric: MetricDiskPercent, Selector: "/data", Value: 99, Mean: 70, StdDev: 0.5, Sigmas: 58},
+		{Timestamp: start.Add(2 * time.Minute), Metric: MetricMemoryPercent, Value: 75, Mean: 50, StdDev: 1, Sigmas: 25},
+		{Timestamp: start.Add(3 * time.Minute), Metric: MetricCPUPercent, Value: 3, Mean: 40, StdDev: 10, Sigmas: 3.7},
+	}
+	if err := dataStorage.Save(AnomaliesKey, stored); err != nil {
+		t.Fatal(err)
+	}
+
+	// 最新的在前，limit 只限制返回条数，total 为全部匹配数
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"limit": 3, "format": "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report anomalyReport
+	if err := json.Unmarshal([]byte(text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Total != 4 || len(report.Anomalies) != 3 || report.Anomalies[0] != stored[3] || report.Anomalies[2] != stored[1] {
+		t.Errorf("limit 3 = %+v", report)
+	}
+	if !strings.Contains(text, `"baseline_mean": 40`) || !strings.Contains(text, `"selector": "/data"`) {
+		t.Errorf("JSON output:\n%s", text)
+	}
+
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"metric": MetricCPUPercent, "format": "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report = anomalyReport{}
+	if err := json.Unmarshal([]byte(text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Total != 2 || len(report.Anomalies) != 2 || report.Anomalies[0] != stored[3] || report.Anomalies[1] != stored[0] {
+		t.Errorf("cpu only = %+v", report)
+	}
+
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"limit": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"2024-05-06 07:03:00  cpu_percent            3.00       40.00      10.00      3.7σ\n",
+		"2024-05-06 07:02:00  memory_percent         75.00      50.00      1.00       25.0σ\n",
+		"\n共 4 条，显示最新的 2 条\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "/data") {
+		t.Errorf("output beyond the limit:\n%s", text)
+	}
+}
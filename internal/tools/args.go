@@ -0,0 +1,343 @@
+package tools
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// 工具的参数以结构体声明，参数模式（GetInputSchema）和解码（Execute）都由同一组字段标签生成，两者不会不一致：
+//
+//	arg:"名称[,required][,default=值][,enum=a|b|c][,min=n][,max=n]"
+//	desc:"参数说明"
+//	pattern:"字符串参数需要匹配的正则表达式"
+//	items:"数组元素的说明"
+//
+// 字段类型决定参数类型：string 为 string；bool 为 "true"/"false" 字符串枚举（也接受 JSON 布尔值）；
+// int 为 integer；float64 为 number；time.Duration 为 string（Go 时长格式，格式由 pattern 限定）；
+// time.Time 为 string（RFC3339）；map[string]interface{} 为 object；[]map[string]interface{} 和 []string 为 array。
+// 嵌入的结构体（如 cacheArgs）的字段展开为同一层参数，嵌入 strictArgs 时拒绝未声明的参数。
+// 没有标签的字段不是参数
+
+// strictArgs 嵌入参数结构体时，参数模式拒绝未声明的参数（additionalProperties: false）
+type strictArgs struct{}
+
+// noArgs 没有参数的工具的参数结构体
+type noArgs struct{}
+
+// formatArgs 支持 JSON 输出的工具共用的参数
+type formatArgs struct {
+	Format string `arg:"format,enum=text|json,default=text" desc:"输出格式"`
+	fieldsArgs
+}
+
+// argKind 参数字段的类型
+type argKind int
+
+const (
+	argString argKind = iota
+	argBool
+	argInt
+	argFloat
+	argDuration
+	argTime
+	argObject
+	argObjectList
+	argStringList
+)
+
+// argField 一个参数字段
+type argField struct {
+	index    []int
+	kind     argKind
+	name     string
+	required bool
+	// defaultText 标签中的默认值，缺省时为空
+	defaultText string
+	property    types.Property
+}
+
+// argSpec 参数结构体解析后的参数列表，按字段声明顺序
+type argSpec struct {
+	fields []argField
+	strict bool
+}
+
+// argSpecs 按结构体类型缓存的 argSpec
+var argSpecs sync.Map
+
+var (
+	strictArgsType = reflect.TypeOf(strictArgs{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	timeType       = reflect.TypeOf(time.Time{})
+	objectType     = reflect.TypeOf(map[string]interface{}{})
+)
+
+// argsSchema 由参数结构体的标签生成工具的参数模式，args 为结构体或其指针
+func argsSchema(args interface{}) types.InputSchema {
+	spec := specFor(reflect.TypeOf(args))
+	schema := types.InputSchema{
+		Type:       "object",
+		Properties: make(map[string]types.Property, len(spec.fields)),
+	}
+	for _, field := range spec.fields {
+		schema.Properties[field.name] = field.property
+		if field.required {
+			schema.Required = append(schema.Required, field.name)
+		}
+	}
+	if spec.strict {
+		schema.AdditionalProperties = types.Bool(false)
+	}
+	return schema
+}
+
+// withEnum 设置参数模式中参数 name 的枚举值，用于取值随平台变化、无法写在标签中的参数。
+// decodeArgs 不检查这类枚举，由工具自行校验
+func withEnum(schema types.InputSchema, name string, values []string) types.InputSchema {
+	property := schema.Properties[name]
+	property.Enum = values
+	schema.Properties[name] = property
+	return schema
+}
+
+// decodeArgs 将调用参数解码到参数结构体 target（指针）。缺少、为 null 或为空字符串的参数使用标签中的默认值
+// （没有时为零值），类型、枚举、范围和格式的检查与 ValidateArguments 相同，失败时返回带参数名的 ErrBadArgument 错误。
+// 未声明的参数由 ValidateArguments 按参数模式处理，这里忽略
+func decodeArgs(args map[string]interface{}, target interface{}) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("decodeArgs: target 应为结构体指针，实际为 %T", target))
+	}
+	spec := specFor(value.Type())
+	for _, field := range spec.fields {
+		raw, found := args[field.name]
+		if !found || raw == nil || raw == "" {
+			if field.required {
+				return argumentError(field.name, "缺少必需参数: %s", field.name)
+			}
+			if field.defaultText == "" {
+				continue
+			}
+			raw = field.defaultText
+		}
+		if err := field.decode(value.Elem().FieldByIndex(field.index), raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decode 校验参数值并设置到字段
+func (f argField) decode(target reflect.Value, raw interface{}) error {
+	normalized, err := validateValue(f.name, f.property, raw)
+	if err != nil {
+		return err
+	}
+
+	switch f.kind {
+	case argString:
+		target.SetString(normalized.(string))
+	case argBool:
+		target.SetBool(normalized == "true")
+	case argInt:
+		number, _ := strconv.ParseInt(normalized.(string), 10, 64)
+		target.SetInt(number)
+	case argFloat:
+		number, _ := strconv.ParseFloat(normalized.(string), 64)
+		target.SetFloat(number)
+	case argDuration:
+		duration, err := time.ParseDuration(normalized.(string))
+		if err != nil {
+			return argumentError(f.name, "参数 %s 应为时长（如 30s、5m）: %q", f.name, normalized)
+		}
+		target.SetInt(int64(duration))
+	case argTime:
+		parsed, err := time.Parse(time.RFC3339, normalized.(string))
+		if err != nil {
+			return argumentError(f.name, "参数 %s 应为 RFC3339 时间（如 2024-01-02T15:04:05Z）: %q", f.name, normalized)
+		}
+		target.Set(reflect.ValueOf(parsed))
+	case argObject:
+		object, ok := normalized.(map[string]interface{})
+		if !ok {
+			return argumentError(f.name, "参数 %s 应为对象", f.name)
+		}
+		target.Set(reflect.ValueOf(object))
+	case argObjectList:
+		items := normalized.([]interface{})
+		objects := make([]map[string]interface{}, len(items))
+		for i, item := range items {
+			object, ok := item.(map[string]interface{})
+			if !ok {
+				return argumentError(f.name, "参数 %s[%d] 应为对象", f.name, i)
+			}
+			objects[i] = object
+		}
+		target.Set(reflect.ValueOf(objects))
+	case argStringList:
+		items := normalized.([]interface{})
+		texts := make([]string, len(items))
+		for i, item := range items {
+			texts[i] = item.(string)
+		}
+		target.Set(reflect.ValueOf(texts))
+	}
+	return nil
+}
+
+// specFor 获取结构体类型（或其指针）的 argSpec，标签有误时 panic（属于编程错误，注册工具时即可发现）
+func specFor(structType reflect.Type) *argSpec {
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	if cached, found := argSpecs.Load(structType); found {
+		return cached.(*argSpec)
+	}
+
+	spec := &argSpec{}
+	collectArgFields(spec, structType, nil)
+	seen := make(map[string]bool, len(spec.fields))
+	for _, field := range spec.fields {
+		if seen[field.name] {
+			panic(fmt.Sprintf("参数结构体 %s 中参数 %s 重复", structType, field.name))
+		}
+		seen[field.name] = true
+	}
+
+	cached, _ := argSpecs.LoadOrStore(structType, spec)
+	return cached.(*argSpec)
+}
+
+// collectArgFields 收集结构体（包括嵌入的结构体）中带 arg 标签的字段
+func collectArgFields(spec *argSpec, structType reflect.Type, index []int) {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		if field.Anonymous && field.Type == strictArgsType {
+			spec.strict = true
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			collectArgFields(spec, field.Type, fieldIndex)
+			continue
+		}
+		tag, tagged := field.Tag.Lookup("arg")
+		if !tagged {
+			continue
+		}
+		spec.fields = append(spec.fields, parseArgField(structType, field, fieldIndex, tag))
+	}
+}
+
+// parseArgField 解析字段的标签，生成参数的模式和解码方式
+func parseArgField(structType reflect.Type, field reflect.StructField, index []int, tag string) argField {
+	fail := func(format string, args ...interface{}) {
+		panic(fmt.Sprintf("参数结构体 %s 字段 %s: %s", structType, field.Name, fmt.Sprintf(format, args...)))
+	}
+
+	options := strings.Split(tag, ",")
+	f := argField{index: index, name: options[0]}
+	if f.name == "" {
+		fail("缺少参数名")
+	}
+	f.property.Description = field.Tag.Get("desc")
+	f.property.Pattern = field.Tag.Get("pattern")
+
+	switch {
+	case field.Type == durationType:
+		f.kind, f.property.Type = argDuration, "string"
+	case field.Type == timeType:
+		f.kind, f.property.Type = argTime, "string"
+	case field.Type == objectType:
+		f.kind, f.property.Type = argObject, "object"
+	case field.Type == reflect.TypeOf([]map[string]interface{}{}):
+		f.kind, f.property.Type = argObjectList, "array"
+		f.property.Items = &types.Property{Type: "object", Description: field.Tag.Get("items")}
+	case field.Type == reflect.TypeOf([]string{}):
+		f.kind, f.property.Type = argStringList, "array"
+		f.property.Items = &types.Property{Type: "string", Description: field.Tag.Get("items")}
+	case field.Type.Kind() == reflect.String:
+		f.kind, f.property.Type = argString, "string"
+	case field.Type.Kind() == reflect.Bool:
+		f.kind, f.property.Type = argBool, "string"
+		f.property.Enum = []string{"true", "false"}
+	case field.Type.Kind() == reflect.Int:
+		f.kind, f.property.Type = argInt, "integer"
+	case field.Type.Kind() == reflect.Float64:
+		f.kind, f.property.Type = argFloat, "number"
+	default:
+		fail("不支持的参数类型 %s", field.Type)
+	}
+
+	for _, option := range options[1:] {
+		key, value, _ := strings.Cut(option, "=")
+		switch key {
+		case "required":
+			f.required = true
+		case "default":
+			f.defaultText = value
+		case "enum":
+			f.property.Enum = strings.Split(value, "|")
+		case "min", "max":
+			bound, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				fail("无效的 %s: %q", key, value)
+			}
+			if key == "min" {
+				f.property.Minimum = types.Float64(bound)
+			} else {
+				f.property.Maximum = types.Float64(bound)
+			}
+		default:
+			fail("未知的标签选项 %q", option)
+		}
+	}
+
+	if f.defaultText != "" {
+		f.property.Default = defaultValue(f, fail)
+	}
+	return f
+}
+
+// defaultValue 参数模式中的默认值：数值参数为 JSON 数值，其他为字符串。默认值本身需通过校验
+func defaultValue(f argField, fail func(format string, args ...interface{})) interface{} {
+	if _, err := validateValue(f.name, f.property, f.defaultText); err != nil {
+		fail("默认值无效: %v", err)
+	}
+	switch f.kind {
+	case argInt:
+		number, _ := strconv.Atoi(f.defaultText)
+		return number
+	case argFloat:
+		number, _ := strconv.ParseFloat(f.defaultText, 64)
+		return number
+	}
+	return f.defaultText
+}
+
+// durationText 时长参数的简短写法，去掉 time.Duration.String() 末尾为零的分和秒（如 24h0m0s 为 24h），
+// 用于在结果中回显时长参数
+func durationText(d time.Duration) string {
+	text := d.String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
+
+// argumentError 参数 name 无效
+func argumentError(name, format string, args ...interface{}) *Error {
+	toolErr := badArgument(format, args...)
+	toolErr.Argument = name
+	return toolErr
+}
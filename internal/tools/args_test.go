@@ -0,0 +1,318 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// sampleArgs 覆盖所有参数类型和标签选项的参数结构体
+type sampleArgs struct {
+	Name     string                   `arg:"name,required" desc:"名称"`
+	Mode     string                   `arg:"mode,enum=fast|slow,default=fast" desc:"模式"`
+	Verbose  bool                     `arg:"verbose,default=false" desc:"是否详细"`
+	Limit    int                      `arg:"limit,default=10,min=1,max=100" desc:"数量"`
+	Ratio    float64                  `arg:"ratio,default=0.5,min=0,max=1" desc:"比例"`
+	Window   time.Duration            `arg:"window,default=5m" desc:"时间窗口" pattern:"^[0-9]+[smh]$"`
+	Since    time.Time                `arg:"since" desc:"起始时间"`
+	Filter   map[string]interface{}   `arg:"filter" desc:"过滤条件"`
+	Queries  []map[string]interface{} `arg:"queries" desc:"查询" items:"单个查询"`
+	Labels   []string                 `arg:"labels" desc:"标签" items:"单个标签"`
+	internal string
+	cacheArgs
+}
+
+// strictSampleArgs 拒绝未声明参数的参数结构体
+type strictSampleArgs struct {
+	Target string `arg:"target,default=all" desc:"目标"`
+	strictArgs
+}
+
+func TestArgsSchema(t *testing.T) {
+	want := types.InputSchema{
+		Type: "object",
+		Properties: map[string]types.Property{
+			"name":      {Type: "string", Description: "名称"},
+			"mode":      {Type: "string", Description: "模式", Enum: []string{"fast", "slow"}, Default: "fast"},
+			"verbose":   {Type: "string", Description: "是否详细", Enum: []string{"true", "false"}, Default: "false"},
+			"limit":     {Type: "integer", Description: "数量", Default: 10, Minimum: types.Float64(1), Maximum: types.Float64(100)},
+			"ratio":     {Type: "number", Description: "比例", Default: 0.5, Minimum: types.Float64(0), Maximum: types.Float64(1)},
+			"window":    {Type: "string", Description: "时间窗口", Default: "5m", Pattern: "^[0-9]+[smh]$"},
+			"since":     {Type: "string", Description: "起始时间"},
+			"filter":    {Type: "object", Description: "过滤条件"},
+			"queries":   {Type: "array", Description: "查询", Items: &types.Property{Type: "object", Description: "单个查询"}},
+			"labels":    {Type: "array", Description: "标签", Items: &types.Property{Type: "string", Description: "单个标签"}},
+			"cache":     argsSchema(cacheArgs{}).Properties["cache"],
+			"use_cache": argsSchema(cacheArgs{}).Properties["use_cache"],
+		},
+		Required: []string{"name"},
+	}
+	if got := argsSchema(sampleArgs{}); !reflect.DeepEqual(got, want) {
+		gotJSON, _ := json.MarshalIndent(got, "", "  ")
+		wantJSON, _ := json.MarshalIndent(want, "", "  ")
+		t.Fatalf("argsSchema() =\n%s\nwant\n%s", gotJSON, wantJSON)
+	}
+	if !reflect.DeepEqual(argsSchema(&sampleArgs{}), want) {
+		t.Fatal("argsSchema of a pointer differs from the struct")
+	}
+
+	strict := argsSchema(strictSampleArgs{})
+	if strict.AdditionalProperties == nil || *strict.AdditionalProperties {
+		t.Fatalf("AdditionalProperties = %v, want false for a struct embedding strictArgs", strict.AdditionalProperties)
+	}
+	if len(strict.Properties) != 1 || strict.Required != nil {
+		t.Fatalf("strict schema = %+v, want only the target parameter", strict)
+	}
+	if schema := argsSchema(noArgs{}); len(schema.Properties) != 0 || schema.AdditionalProperties != nil {
+		t.Fatalf("argsSchema(noArgs{}) = %+v, want an empty object schema", schema)
+	}
+}
+
+// TestInputSchemaJSON 扩展字段按 JSON Schema 的字段名序列化，未设置的约束不输出，为 0 的边界仍然输出
+func TestInputSchemaJSON(t *testing.T) {
+	schema := argsSchema(sampleArgs{})
+	data, err := json.Marshal(types.InputSchema{
+		Type: schema.Type,
+		Properties: map[string]types.Property{
+			"name":   schema.Properties["name"],
+			"ratio":  schema.Properties["ratio"],
+			"window": schema.Properties["window"],
+			"labels": schema.Properties["labels"],
+		},
+		Required:             schema.Required,
+		AdditionalProperties: types.Bool(false),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"object","properties":{` +
+		`"labels":{"type":"array","description":"标签","items":{"type":"string","description":"单个标签"}},` +
+		`"name":{"type":"string","description":"名称"},` +
+		`"ratio":{"type":"number","description":"比例","default":0.5,"minimum":0,"maximum":1},` +
+		`"window":{"type":"string","description":"时间窗口","default":"5m","pattern":"^[0-9]+[smh]$"}},` +
+		`"required":["name"],"additionalProperties":false}`
+	if string(data) != want {
+		t.Errorf("schema JSON =\n%s\nwant\n%s", data, want)
+	}
+
+	// 没有参数的工具只输出 type
+	if data, err := json.Marshal(argsSchema(noArgs{})); err != nil || string(data) != `{"type":"object"}` {
+		t.Errorf("empty schema JSON = %s, %v", data, err)
+	}
+}
+
+func TestDecodeArgsDefaults(t *testing.T) {
+	for _, args := range []map[string]interface{}{
+		{"name": "web"},
+		{"name": "web", "mode": nil, "verbose": nil, "limit": nil, "window": nil},
+		{"name": "web", "mode": "", "verbose": "", "limit": "", "ratio": "", "window": ""},
+	} {
+		var a sampleArgs
+		if err := decodeArgs(args, &a); err != nil {
+			t.Fatalf("decodeArgs(%v) = %v", args, err)
+		}
+		want := sampleArgs{Name: "web", Mode: "fast", Limit: 10, Ratio: 0.5, Window: 5 * time.Minute}
+		if !reflect.DeepEqual(a, want) {
+			t.Errorf("decodeArgs(%v) = %+v, want the tag defaults %+v", args, a, want)
+		}
+	}
+}
+
+func TestDecodeArgsValues(t *testing.T) {
+	// 与客户端发来的 arguments 一样经过 JSON 解码：数值为 float64，布尔值可以是 JSON 布尔值或字符串
+	var args map[string]interface{}
+	raw := `{
+		"name": "web",
+		"mode": "slow",
+		"verbose": true,
+		"limit": 25,
+		"ratio": "0.75",
+		"window": "30s",
+		"since": "2024-05-06T07:08:09Z",
+		"filter": {"user": "root"},
+		"queries": [{"tool": "cpu_info"}, {"tool": "memory_info"}],
+		"labels": ["a", "b"],
+		"cache": "auto",
+		"unknown": 1
+	}`
+	if err := json.Unmarshal([]byte(raw), &args); err != nil {
+		t.Fatal(err)
+	}
+
+	var a sampleArgs
+	if err := decodeArgs(args, &a); err != nil {
+		t.Fatal(err)
+	}
+	want := sampleArgs{
+		Name:      "web",
+		Mode:      "slow",
+		Verbose:   true,
+		Limit:     25,
+		Ratio:     0.75,
+		Window:    30 * time.Second,
+		Since:     time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC),
+		Filter:    map[string]interface{}{"user": "root"},
+		Queries:   []map[string]interface{}{{"tool": "cpu_info"}, {"tool": "memory_info"}},
+		Labels:    []string{"a", "b"},
+		cacheArgs: cacheArgs{Cache: "auto"},
+	}
+	if !reflect.DeepEqual(a, want) {
+		t.Fatalf("decodeArgs() = %+v\nwant %+v", a, want)
+	}
+
+	for _, value := range []interface{}{"true", true} {
+		var b sampleArgs
+		if err := decodeArgs(map[string]interface{}{"name": "web", "verbose": value, "limit": "7"}, &b); err != nil || !b.Verbose || b.Limit != 7 {
+			t.Errorf("verbose=%#v, limit=\"7\" decoded to %+v, %v", value, b, err)
+		}
+	}
+}
+
+func TestDecodeArgsErrors(t *testing.T) {
+	cases := []struct {
+		args     map[string]interface{}
+		argument string
+	}{
+		{map[string]interface{}{}, "name"},
+		{map[string]interface{}{"name": ""}, "name"},
+		{map[string]interface{}{"name": "web", "mode": "medium"}, "mode"},
+		{map[string]interface{}{"name": "web", "verbose": "yes"}, "verbose"},
+		{map[string]interface{}{"name": "web", "limit": 0.0}, "limit"},
+		{map[string]interface{}{"name": "web", "limit": 101.0}, "limit"},
+		{map[string]interface{}{"name": "web", "limit": 2.5}, "limit"},
+		{map[string]interface{}{"name": "web", "limit": "ten"}, "limit"},
+		{map[string]interface{}{"name": "web", "ratio": 1.5}, "ratio"},
+		{map[string]interface{}{"name": "web", "window": "5 minutes"}, "window"},
+		{map[string]interface{}{"name": "web", "window": "99999999999h"}, "window"},
+		{map[string]interface{}{"name": "web", "since": "yesterday"}, "since"},
+		{map[string]interface{}{"name": "web", "filter": "user=root"}, "filter"},
+		{map[string]interface{}{"name": "web", "queries": map[string]interface{}{}}, "queries"},
+		{map[string]interface{}{"name": "web", "queries": []interface{}{"cpu_info"}}, "queries"},
+		{map[string]interface{}{"name": "web", "labels": []interface{}{"a", map[string]interface{}{}}}, "labels[1]"},
+		{map[string]interface{}{"name": []interface{}{"web"}}, "name"},
+	}
+	for _, c := range cases {
+		var a sampleArgs
+		err := decodeArgs(c.args, &a)
+		var toolErr *Error
+		if !errors.As(err, &toolErr) || toolErr.Code != ErrBadArgument || toolErr.Argument != c.argument {
+			t.Errorf("decodeArgs(%v) = %v, want ErrBadArgument for %s", c.args, err, c.argument)
+		}
+	}
+}
+
+// TestDecodeArgsAgreesWithSchema 同一组参数经参数模式校验（ValidateArguments）和解码（decodeArgs）的结果一致，
+// 两者不会出现一个接受、另一个拒绝的情况
+func TestDecodeArgsAgreesWithSchema(t *testing.T) {
+	targets := []func() interface{}{
+		func() interface{} { return &sampleArgs{} },
+		func() interface{} { return &processArgs{} },
+		func() interface{} { return &diskArgs{} },
+		func() interface{} { return &cpuArgs{} },
+	}
+	inputs := []map[string]interface{}{
+		{},
+		{"name": "web"},
+		{"name": "web", "limit": 50.0, "sort_by": "cpu", "duration": "5s", "show_all": true},
+		{"name": "web", "limit": 0.0},
+		{"name": "web", "limit": "abc"},
+		{"name": "web", "sort_by": "disk"},
+		{"name": "web", "duration": "2s"},
+		{"name": "web", "format": "csv", "compact": "true"},
+		{"name": "web", "format": "xml"},
+		{"name": "web", "descending": "maybe", "verbose": "maybe", "detailed": "maybe", "show_all": "maybe"},
+		{"name": "web", "offset": -1.0},
+		{"name": "web", "cache": "sometimes"},
+		{"name": "web", "window": "1d"},
+	}
+	for _, newTarget := range targets {
+		schema := argsSchema(newTarget())
+		for _, input := range inputs {
+			_, validateErr := ValidateArguments(schema, input)
+			decodeErr := decodeArgs(input, newTarget())
+			if (validateErr == nil) != (decodeErr == nil) {
+				t.Errorf("%T with %v: ValidateArguments = %v, decodeArgs = %v", newTarget(), input, validateErr, decodeErr)
+			}
+		}
+	}
+}
+
+// panicMessage 调用 f 时 panic 的消息，没有 panic 时为空
+func panicMessage(f func()) (message string) {
+	defer func() {
+		if r := recover(); r != nil {
+			message = r.(string)
+		}
+	}()
+	f()
+	return ""
+}
+
+func TestArgsSchemaRejectsInvalidTags(t *testing.T) {
+	type missingName struct {
+		Value string `arg:",default=a"`
+	}
+	type unknownOption struct {
+		Value string `arg:"value,optional"`
+	}
+	type unsupportedType struct {
+		Value int64 `arg:"value"`
+	}
+	type invalidBound struct {
+		Value int `arg:"value,min=one"`
+	}
+	type defaultOutOfEnum struct {
+		Value string `arg:"value,enum=a|b,default=c"`
+	}
+	type defaultOutOfRange struct {
+		Value int `arg:"value,default=0,min=1"`
+	}
+	type duplicateName struct {
+		Cache string `arg:"cache"`
+		cacheArgs
+	}
+
+	cases := []struct {
+		args interface{}
+		want string
+	}{
+		{missingName{}, "缺少参数名"},
+		{unknownOption{}, "未知的标签选项"},
+		{unsupportedType{}, "不支持的参数类型"},
+		{invalidBound{}, "无效的 min"},
+		{defaultOutOfEnum{}, "默认值无效"},
+		{defaultOutOfRange{}, "默认值无效"},
+		{duplicateName{}, "参数 cache 重复"},
+	}
+	for _, c := range cases {
+		if message := panicMessage(func() { argsSchema(c.args) }); !strings.Contains(message, c.want) {
+			t.Errorf("argsSchema(%T) panic = %q, want it to mention %q", c.args, message, c.want)
+		}
+	}
+
+	if message := panicMessage(func() { _ = decodeArgs(map[string]interface{}{}, sampleArgs{}) }); !strings.Contains(message, "结构体指针") {
+		t.Errorf("decodeArgs with a non-pointer target panic = %q, want a message about the target", message)
+	}
+}
+
+func TestDurationText(t *testing.T) {
+	cases := map[time.Duration]string{
+		30 * time.Second:               "30s",
+		5 * time.Minute:                "5m",
+		90 * time.Minute:               "1h30m",
+		24 * time.Hour:                 "24h",
+		time.Hour + 30*time.Second:     "1h0m30s",
+		1500 * time.Millisecond:        "1.5s",
+		10*time.Minute + 5*time.Second: "10m5s",
+	}
+	for d, want := range cases {
+		if got := durationText(d); got != want {
+			t.Errorf("durationText(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
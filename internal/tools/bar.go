@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// defaultBarWidth 使用率条的默认宽度（字符数，不含方括号）
+const defaultBarWidth = 10
+
+// maxBarWidth 使用率条的最大宽度
+const maxBarWidth = 50
+
+// 文本输出风格
+const (
+	// StyleRich 在默认输出中显示使用率条
+	StyleRich = "rich"
+	// StylePlain 只输出数字，不显示使用率条
+	StylePlain = "plain"
+)
+
+// OutputStyle 文本输出风格，只影响文本输出，JSON 输出始终只包含数值
+type OutputStyle struct {
+	Style    string
+	BarWidth int
+}
+
+// NewOutputStyle 创建输出风格，宽度为 0 时使用默认宽度，超出范围时截断到 1~maxBarWidth
+func NewOutputStyle(style string, barWidth int) OutputStyle {
+	if style != StylePlain {
+		style = StyleRich
+	}
+	if barWidth == 0 {
+		barWidth = defaultBarWidth
+	}
+	barWidth = max(1, min(maxBarWidth, barWidth))
+
+	return OutputStyle{Style: style, BarWidth: barWidth}
+}
+
+// ShowBars 默认输出中是否显示使用率条
+func (s OutputStyle) ShowBars() bool {
+	return s.Style != StylePlain
+}
+
+// barCoresPerLine 紧凑模式下每行显示的核心数
+const barCoresPerLine = 4
+
+// renderBar 渲染宽度为 width 的使用率条，例如 61% → [██████----]。
+// 百分比限制在 0~100 之间（NaN 按 0 处理），宽度不大于 0 时使用默认宽度。
+func renderBar(percent float64, width int) string {
+	if width <= 0 {
+		width = defaultBarWidth
+	}
+	if math.IsNaN(percent) {
+		percent = 0
+	}
+	percent = math.Max(0, math.Min(100, percent))
+
+	filled := int(math.Round(percent / 100 * float64(width)))
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("-", width-filled) + "]"
+}
+
+// usageMarker 不依赖颜色的阈值标记：超过 95% 为 "!!"，超过 80% 为 "!"
+func usageMarker(percent float64) string {
+	switch {
+	case percent > 95:
+		return "!!"
+	case percent > 80:
+		return "!"
+	default:
+		return ""
+	}
+}
+
+// formatUsageBar 渲染带百分比和阈值标记的使用率条，例如 "[██████----]  61%"。
+// 标记占两个字符宽度，多个使用率条并排时保持对齐。
+func formatUsageBar(percent float64, width int) string {
+	return fmt.Sprintf("%s %3.0f%%%-2s", renderBar(percent, width), percent, usageMarker(percent))
+}
+
+// formatCoreGrid 将各核心使用率渲染为每行 barCoresPerLine 个的使用率条网格
+func formatCoreGrid(perCore []float64, width int) string {
+	var result string
+
+	for i, percent := range perCore {
+		if i%barCoresPerLine == 0 {
+			result += " "
+		}
+		result += fmt.Sprintf(" %3d %s", i+1, formatUsageBar(percent, width))
+		if i%barCoresPerLine == barCoresPerLine-1 || i == len(perCore)-1 {
+			result += "\n"
+		}
+	}
+
+	return result
+}
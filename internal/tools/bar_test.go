@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// barPercents golden 覆盖的使用率：边界、阈值标记两侧、四舍五入和超出范围的值
+var barPercents = []float64{-5, 0, 0.4, 4.9, 5, 50, 61, 80, 80.1, 95, 95.5, 99.6, 100, 130, math.NaN()}
+
+func TestGoldenUsageBars(t *testing.T) {
+	var result string
+	for _, width := range []int{-1, 0, 1, 3, 10, 20} {
+		result += fmt.Sprintf("width %d\n", width)
+		for _, percent := range barPercents {
+			result += fmt.Sprintf("%6.1f %s|\n", percent, formatUsageBar(percent, width))
+		}
+		result += "\n"
+	}
+	assertGolden(t, "usage_bars", result)
+}
+
+func TestGoldenCoreGrid(t *testing.T) {
+	perCore := []float64{0, 12.5, 61, 79.9, 80.1, 95.1, 100, 100.4, 33}
+	var result string
+	for _, width := range []int{5, 10} {
+		result += fmt.Sprintf("width %d\n", width)
+		result += formatCoreGrid(perCore, width)
+		result += formatCoreGrid(perCore[:barCoresPerLine], width)
+		result += formatCoreGrid(nil, width)
+	}
+	assertGolden(t, "core_grid", result)
+}
+
+func TestRenderBarWidth(t *testing.T) {
+	for _, percent := range barPercents {
+		for _, width := range []int{1, 7, maxBarWidth} {
+			// 方括号内的字符数总是等于宽度，超出范围的值也不会溢出
+			bar := []rune(renderBar(percent, width))
+			if len(bar) != width+2 || bar[0] != '[' || bar[len(bar)-1] != ']' {
+				t.Errorf("renderBar(%v, %d) = %q", percent, width, string(bar))
+			}
+		}
+	}
+	if renderBar(0, 4) != "[----]" || renderBar(100, 4) != "[████]" || renderBar(-20, 4) != "[----]" || renderBar(250, 4) != "[████]" {
+		t.Errorf("bars at the limits: %q %q %q %q", renderBar(0, 4), renderBar(100, 4), renderBar(-20, 4), renderBar(250, 4))
+	}
+}
+
+func TestNewOutputStyle(t *testing.T) {
+	cases := []struct {
+		style    string
+		width    int
+		want     OutputStyle
+		showBars bool
+	}{
+		{"", 0, OutputStyle{StyleRich, defaultBarWidth}, true},
+		{StylePlain, 0, OutputStyle{StylePlain, defaultBarWidth}, false},
+		// 未知风格按 rich 处理
+		{"fancy", 12, OutputStyle{StyleRich, 12}, true},
+		{StyleRich, -3, OutputStyle{StyleRich, 1}, true},
+		{StyleRich, maxBarWidth + 1, OutputStyle{StyleRich, maxBarWidth}, true},
+	}
+	for _, c := range cases {
+		got := NewOutputStyle(c.style, c.width)
+		if got != c.want || got.ShowBars() != c.showBars {
+			t.Errorf("NewOutputStyle(%q, %d) = %+v (bars %v), want %+v (bars %v)", c.style, c.width, got, got.ShowBars(), c.want, c.showBars)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// CacheAdminTool 缓存管理工具（需通过 --enable-admin-tools 启用）
+type CacheAdminTool struct {
+	cache types.AdminCache
+}
+
+// NewCacheAdminTool 创建新的缓存管理工具
+func NewCacheAdminTool(cache types.AdminCache) *CacheAdminTool {
+	return &CacheAdminTool{
+		cache: cache,
+	}
+}
+
+// GetName 获取工具名称
+func (ca *CacheAdminTool) GetName() string {
+	return "cache_admin"
+}
+
+// GetDescription 获取工具描述
+func (ca *CacheAdminTool) GetDescription() string {
+	return "查看缓存统计和缓存键，或清空/删除缓存项"
+}
+
+// GetAnnotations 获取工具注解
+func (ca *CacheAdminTool) GetAnnotations() types.ToolAnnotations {
+	return types.ToolAnnotations{
+		Title:           "缓存管理",
+		DestructiveHint: true,
+		IdempotentHint:  true,
+	}
+}
+
+// cacheAdminArgs cache_admin 的参数
+type cacheAdminArgs struct {
+	strictArgs
+	Action string `arg:"action,enum=stats|keys|clear|delete,default=stats" desc:"操作: stats 统计, keys 列出缓存键及其来源工具和参数, clear 清空缓存, delete 删除指定键或指定工具的全部缓存项"`
+	Key    string `arg:"key" desc:"delete 操作要删除的缓存键（由 keys 操作列出，键本身不透明）"`
+	Tool   string `arg:"tool" desc:"keys 操作只列出该工具（缓存作用域）的缓存项；delete 操作删除该工具的全部缓存项"`
+}
+
+// GetInputSchema 获取输入模式
+func (ca *CacheAdminTool) GetInputSchema() types.InputSchema {
+	return argsSchema(cacheAdminArgs{})
+}
+
+// Examples 获取调用示例
+func (ca *CacheAdminTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "缓存条目数和命中率统计",
+			Arguments:   map[string]interface{}{"action": "stats"},
+		},
+		{
+			Description: "删除 memory_info 的全部缓存项，下次调用重新采集",
+			Arguments:   map[string]interface{}{"action": "delete", "tool": "memory_info"},
+		},
+	}
+}
+
+// Execute 执行缓存管理操作
+func (ca *CacheAdminTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a cacheAdminArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+	tool, key := a.Tool, a.Key
+
+	var result string
+	result += "🗄️  缓存管理\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+
+	switch a.Action {
+	case "stats":
+		stats := ca.cache.Stats()
+		hitRate := 0.0
+		if total := stats.Hits + stats.Misses; total > 0 {
+			hitRate = float64(stats.Hits) / float64(total) * 100
+		}
+		result += fmt.Sprintf("缓存项数量: %d\n", stats.Size)
+		result += fmt.Sprintf("命中: %d, 未命中: %d, 命中率: %.1f%%\n", stats.Hits, stats.Misses, hitRate)
+		result += fmt.Sprintf("失败缓存命中: %d\n", stats.NegativeHits)
+
+	case "keys":
+		entries := ca.entries(tool)
+		if len(entries) == 0 {
+			result += "缓存为空\n"
+			break
+		}
+		for _, entry := range entries {
+			scope, summary := cacheEntryOrigin(entry)
+			result += fmt.Sprintf("%s（剩余 %s）\n", entry.Key, entry.TTL.Round(time.Second))
+			if summary == "" {
+				summary = "无参数"
+			}
+			result += fmt.Sprintf("  工具: %s  参数: %s\n", scope, summary)
+		}
+		result += fmt.Sprintf("\n共 %d 项\n", len(entries))
+
+	case "clear":
+		size := len(ca.cache.Entries())
+		ca.cache.Clear()
+		result += fmt.Sprintf("✅ 已清空缓存（%d 项）\n", size)
+
+	case "delete":
+		if key == "" && tool != "" {
+			entries := ca.entries(tool)
+			if len(entries) == 0 {
+				return "", notFound("工具 %s 没有缓存项", tool)
+			}
+			for _, entry := range entries {
+				ca.cache.Delete(entry.Key)
+			}
+			result += fmt.Sprintf("✅ 已删除工具 %s 的 %d 个缓存项\n", tool, len(entries))
+			break
+		}
+		if key == "" {
+			return "", badArgument("delete 操作需要提供 key 或 tool")
+		}
+		// 通过 Entries 判断是否存在，避免影响命中统计
+		found := false
+		for _, entry := range ca.cache.Entries() {
+			if entry.Key == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", notFound("缓存键不存在: %s", key)
+		}
+		ca.cache.Delete(key)
+		result += fmt.Sprintf("✅ 已删除缓存键: %s\n", key)
+
+	default:
+		return "", argumentError("action", "不支持的操作: %s", a.Action)
+	}
+
+	return result, nil
+}
+
+// entries 缓存项列表，tool 不为空时只保留该工具（缓存作用域）的缓存项
+func (ca *CacheAdminTool) entries(tool string) []types.CacheEntryInfo {
+	entries := ca.cache.Entries()
+	if tool == "" {
+		return entries
+	}
+
+	filtered := make([]types.CacheEntryInfo, 0, len(entries))
+	for _, entry := range entries {
+		if scope, _ := cacheEntryOrigin(entry); scope == tool {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// cacheEntryOrigin 缓存项的来源工具（作用域）和参数摘要。withCache 写入的缓存项随数据保存了这两项，
+// 其他缓存项（失败记录、网络速率基线等）只能从键的前缀得到作用域
+func cacheEntryOrigin(entry types.CacheEntryInfo) (string, string) {
+	if cached, ok := entry.Value.(cacheEntry); ok && cached.Scope != "" {
+		return cached.Scope, cached.Args
+	}
+	scope, _, _ := strings.Cut(entry.Key, cacheKeySeparator)
+	return scope, ""
+}
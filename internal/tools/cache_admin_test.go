@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+)
+
+// newPopulatedCache 通过 withCache 写入 cpu_info 的两组参数和 disk_info 的缓存项，以及一条 network_stats 的失败记录
+func newPopulatedCache(t *testing.T) *storage.NegativeCache {
+	t.Helper()
+	cache := storage.NewNegativeCache(storage.NewMemoryCache(), time.Minute)
+	for _, call := range []struct {
+		scope string
+		args  map[string]interface{}
+	}{
+		{"cpu_info", map[string]interface{}{"duration": "1s"}},
+		{"cpu_info", map[string]interface{}{"duration": "5s"}},
+		{"disk_info", map[string]interface{}{}},
+	} {
+		collector := &countingCollector{value: call.scope}
+		if _, _, err := withCache(context.Background(), cache, CacheOptions{Mode: CacheModeAuto}.forCall(call.args), call.scope, time.Minute, collector.collect); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cache.SetFailure(newCacheKey("network_stats", nil).Key, errors.New("read /proc/net/dev: EIO"))
+	return cache
+}
+
+// cacheAdmin 执行 cache_admin，失败时测试失败
+func cacheAdmin(t *testing.T, tool *CacheAdminTool, args map[string]interface{}) string {
+	t.Helper()
+	text, err := tool.Execute(context.Background(), args)
+	if err != nil {
+		t.Fatalf("cache_admin %v: %v", args, err)
+	}
+	return text
+}
+
+func TestCacheAdminStats(t *testing.T) {
+	cache := newPopulatedCache(t)
+	tool := NewCacheAdminTool(cache)
+
+	// 命中 1 次、未命中 1 次，命中 1 次失败记录
+	cache.Get(newCacheKey("disk_info", nil).Key)
+	cache.Get(newCacheKey("memory_info", nil).Key)
+	cache.GetFailure(newCacheKey("network_stats", nil).Key)
+	text := cacheAdmin(t, tool, map[string]interface{}{})
+	for _, want := range []string{"缓存项数量: 3\n", "命中: 1, 未命中: 1, 命中率: 50.0%\n", "失败缓存命中: 1\n"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("stats missing %q:\n%s", want, text)
+		}
+	}
+
+	// 空缓存的命中率为 0
+	if text := cacheAdmin(t, NewCacheAdminTool(storage.NewMemoryCache()), map[string]interface{}{"action": "stats"}); !strings.Contains(text, "命中率: 0.0%") {
+		t.Errorf("stats of an empty cache:\n%s", text)
+	}
+}
+
+func TestCacheAdminKeys(t *testing.T) {
+	tool := NewCacheAdminTool(newPopulatedCache(t))
+
+	// 列出来源工具和参数，失败记录不列出
+	text := cacheAdmin(t, tool, map[string]interface{}{"action": "keys"})
+	for _, want := range []string{`工具: cpu_info  参数: duration="1s"`, `工具: cpu_info  参数: duration="5s"`, "工具: disk_info  参数: 无参数", "（剩余 1m0s）", "共 3 项"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("keys missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "network_stats") {
+		t.Errorf("keys listed a failure record:\n%s", text)
+	}
+
+	if text := cacheAdmin(t, tool, map[string]interface{}{"action": "keys", "tool": "cpu_info"}); !strings.Contains(text, "共 2 项") || strings.Contains(text, "disk_info") {
+		t.Errorf("keys of cpu_info:\n%s", text)
+	}
+	if text := cacheAdmin(t, tool, map[string]interface{}{"action": "keys", "tool": "memory_info"}); !strings.Contains(text, "缓存为空") {
+		t.Errorf("keys of a tool without entries:\n%s", text)
+	}
+}
+
+func TestCacheAdminClear(t *testing.T) {
+	cache := newPopulatedCache(t)
+	tool := NewCacheAdminTool(cache)
+
+	// 清空缓存项和失败记录
+	if text := cacheAdmin(t, tool, map[string]interface{}{"action": "clear"}); !strings.Contains(text, "已清空缓存（3 项）") {
+		t.Fatalf("clear:\n%s", text)
+	}
+	if len(cache.Entries()) != 0 {
+		t.Errorf("entries after clear: %+v", cache.Entries())
+	}
+	if _, _, found := cache.GetFailure(newCacheKey("network_stats", nil).Key); found {
+		t.Error("failure record kept after clear")
+	}
+}
+
+func TestCacheAdminDelete(t *testing.T) {
+	cache := newPopulatedCache(t)
+	tool := NewCacheAdminTool(cache)
+	diskKey := newCacheKey("disk_info", map[string]interface{}{}).Key
+
+	// 按工具删除全部缓存项
+	if text := cacheAdmin(t, tool, map[string]interface{}{"action": "delete", "tool": "cpu_info"}); !strings.Contains(text, "已删除工具 cpu_info 的 2 个缓存项") {
+		t.Fatalf("delete by tool:\n%s", text)
+	}
+	if entries := cache.Entries(); len(entries) != 1 || entries[0].Key != diskKey {
+		t.Fatalf("entries after deleting cpu_info: %+v", entries)
+	}
+
+	// 按键删除，同时清除同一键的失败记录
+	cache.SetFailure(diskKey, errors.New("statfs timeout"))
+	if text := cacheAdmin(t, tool, map[string]interface{}{"action": "delete", "key": diskKey}); !strings.Contains(text, "已删除缓存键: "+diskKey) {
+		t.Fatalf("delete by key:\n%s", text)
+	}
+	if _, _, found := cache.GetFailure(diskKey); found || len(cache.Entries()) != 0 {
+		t.Fatal("delete by key kept the entry or its failure record")
+	}
+
+	var toolErr *Error
+	for _, c := range []struct {
+		args map[string]interface{}
+		code ErrorCode
+	}{
+		{map[string]interface{}{"action": "delete", "key": diskKey}, ErrNotFound},
+		{map[string]interface{}{"action": "delete", "tool": "cpu_info"}, ErrNotFound},
+		{map[string]interface{}{"action": "delete"}, ErrBadArgument},
+		{map[string]interface{}{"action": "purge"}, ErrBadArgument},
+	} {
+		if _, err := tool.Execute(context.Background(), c.args); !errors.As(err, &toolErr) || toolErr.Code != c.code {
+			t.Errorf("cache_admin %v = %v, want %s", c.args, err, c.code)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// staticCacheTTL CPU 型号、分区列表、主机信息等几乎不变的静态数据的缓存时间
+const staticCacheTTL = 10 * time.Minute
+
+// 缓存模式（cache 参数）。都未指定时为默认模式（CacheOptions.Mode 为空）：实时采集，但遵守缓存的失败记录；
+// 工具配置了 stale-while-revalidate 时与 auto 相同，慢速采集不会阻塞调用
+const (
+	// CacheModeAuto TTL 内返回缓存数据，否则采集并写入缓存
+	CacheModeAuto = "auto"
+	// CacheModeFresh 不读取缓存（包括失败记录），采集后刷新缓存项
+	CacheModeFresh = "fresh"
+	// CacheModeOnly 只返回缓存数据，没有时返回错误而不采集
+	CacheModeOnly = "only"
+)
+
+// CacheOptions 工具的缓存行为选项
+type CacheOptions struct {
+	// StaleWindow 数据过期后仍可直接返回的时间窗口，0 表示关闭 stale-while-revalidate
+	StaleWindow time.Duration
+	// Revalidator 负责过期数据的后台刷新
+	Revalidator *Revalidator
+	// LastGood 保存最近一次成功的采集结果，实时采集失败时返回，为 nil 表示不降级
+	LastGood *LastGood
+	// Name 工具名称，用作降级数据的存储键
+	Name string
+	// NoFallback 本次调用不使用降级数据（由 no_fallback 参数设置）
+	NoFallback bool
+	// Mode 本次调用的缓存模式（由 cache 或 use_cache 参数设置），为空时为默认模式
+	Mode string
+	// Args 本次调用的参数（由 forCall 设置），与作用域一起生成缓存键，缓存控制参数除外
+	Args map[string]interface{}
+
+	// now、monotonic 墙上时钟和单调时钟，为 nil 时使用 time.Now 和 Monotonic，测试中替换以模拟时钟跳变。
+	// 缓存数据的年龄和采集耗时按单调时钟计算，降级数据跨越进程重启，年龄只能按墙上时钟计算
+	now       func() time.Time
+	monotonic func() time.Duration
+}
+
+// wallNow 当前的墙上时钟时间
+func (co CacheOptions) wallNow() time.Time {
+	if co.now != nil {
+		return co.now()
+	}
+	return time.Now()
+}
+
+// monotonicNow 当前的单调时钟读数
+func (co CacheOptions) monotonicNow() time.Duration {
+	if co.monotonic != nil {
+		return co.monotonic()
+	}
+	return Monotonic()
+}
+
+// fallbackArgs 支持降级数据的工具共用的参数，实际由 CacheOptions.forCall 读取
+type fallbackArgs struct {
+	NoFallback bool `arg:"no_fallback,default=false" desc:"实时采集失败时直接返回错误，不使用最近一次成功采集的数据"`
+}
+
+// cacheArgs 使用缓存的工具共用的参数，实际由 CacheOptions.forCall 读取
+type cacheArgs struct {
+	Cache    string `arg:"cache,enum=fresh|auto|only" desc:"缓存模式: fresh 实时采集并刷新缓存（忽略缓存的失败记录）, auto 缓存未过期时直接返回缓存数据, only 只返回缓存数据（没有时报错，不采集）；不指定时实时采集，但短时间内刚失败过的采集直接返回缓存的失败，工具开启了 stale-while-revalidate 时与 auto 相同"`
+	UseCache bool   `arg:"use_cache" desc:"已弃用，请使用 cache：true 等同于 cache=auto，false 等同于 cache=fresh（同时指定时以 cache 为准）"`
+}
+
+// forCall 根据调用参数生成本次调用的缓存选项
+func (co CacheOptions) forCall(args map[string]interface{}) CacheOptions {
+	noFallback, _ := args["no_fallback"].(string)
+	co.NoFallback = noFallback == "true"
+	co.Mode = cacheMode(args)
+	co.Args = args
+	return co
+}
+
+// withoutArgs 返回去掉指定参数的缓存选项，用于只作用于缓存数据之后（如翻页）、不影响缓存内容的参数，
+// 使这些参数不同的调用共用同一缓存项
+func (co CacheOptions) withoutArgs(names ...string) CacheOptions {
+	args := make(map[string]interface{}, len(co.Args))
+	for name, value := range co.Args {
+		args[name] = value
+	}
+	for _, name := range names {
+		delete(args, name)
+	}
+	co.Args = args
+	return co
+}
+
+// cacheMode 解析缓存模式：优先使用 cache 参数，否则将 use_cache 映射为 auto/fresh，都未指定时为空（默认模式）
+func cacheMode(args map[string]interface{}) string {
+	if mode, _ := args["cache"].(string); mode != "" {
+		return mode
+	}
+	switch useCache, _ := args["use_cache"].(string); useCache {
+	case "true":
+		return CacheModeAuto
+	case "false":
+		return CacheModeFresh
+	}
+	return ""
+}
+
+// fallbackEnabled 本次调用是否可以使用降级数据
+func (co CacheOptions) fallbackEnabled() bool {
+	return co.LastGood != nil && co.Name != "" && !co.NoFallback
+}
+
+// staleEnabled 是否开启了 stale-while-revalidate
+func (co CacheOptions) staleEnabled() bool {
+	return co.StaleWindow > 0 && co.Revalidator != nil
+}
+
+// readsCache 本次调用是否返回缓存数据：auto 模式，或开启了 stale-while-revalidate 的默认模式
+func (co CacheOptions) readsCache() bool {
+	return co.Mode == CacheModeAuto || (co.Mode == "" && co.staleEnabled())
+}
+
+// readsFailures 本次调用是否返回缓存的失败记录，只有 fresh 模式绕过
+func (co CacheOptions) readsFailures() bool {
+	return co.Mode != CacheModeFresh
+}
+
+// cacheEntry 缓存中保存的数据及其采集时间，CollectedMonotonic 为采集完成时的单调时钟读数，用于计算缓存时长
+type cacheEntry struct {
+	Data               interface{}
+	CollectedAt        time.Time
+	CollectedMonotonic time.Duration
+	// Duration 采集耗时，缓存命中时仍可报告原始采集用了多久
+	Duration time.Duration
+	// Scope、Args 生成缓存键的作用域和参数摘要（缓存键本身不透明），供 cache_admin 显示
+	Scope string
+	Args  string
+}
+
+// cacheMeta 一次缓存读取的元信息
+type cacheMeta struct {
+	Cached bool
+	Stale  bool
+	Age    time.Duration
+	// CollectDuration 数据的采集耗时（缓存数据为原始采集的耗时）
+	CollectDuration time.Duration
+	// Fallback 实时采集失败，返回的是存储中的降级数据，FallbackErr 为采集错误
+	Fallback    bool
+	FallbackErr error
+}
+
+// withCache 工具共享的缓存读写流程。缓存键由 scope（通常是工具名称）和 opts.Args 生成，
+// 影响输出的参数都自动参与缓存键（见 newCacheKey）。按 opts.Mode 处理：
+//   - auto：优先返回缓存数据或缓存的失败记录，没有时执行采集并写入缓存
+//   - 默认（未指定）：返回缓存的失败记录，没有时执行采集并刷新缓存项；开启 stale-while-revalidate 时与 auto 相同
+//   - fresh：不读取缓存和失败记录，执行采集并刷新缓存项
+//   - only：只返回 TTL 内的缓存数据，没有时返回 ERR_NOT_FOUND，不采集也不使用降级数据
+//
+// 开启 stale-while-revalidate 时（auto 或默认模式），过期但仍在窗口内的数据会立即返回，并在后台刷新。
+// 采集失败会记录到负缓存（若缓存支持），成功后清除失败记录；调用方的 ctx 已取消或超时时不记录。
+// 前台采集使用调用方的 ctx；后台刷新使用 Revalidator 的 ctx，不受单次请求取消的影响。
+// 配置了 LastGood 时，每次成功采集的结果都会写入存储；采集失败（包括缓存的失败记录）时
+// 如果存在参数相同且未过期的记录，则返回该记录并在 cacheMeta 中标记 Fallback。
+// 成功读取的元信息会记录到 ctx 中的 CallMeta（如果有），用于调用结果的 _meta。
+func withCache[T any](ctx context.Context, cache types.Cache, opts CacheOptions, scope string, ttl time.Duration, collect func(ctx context.Context) (T, error)) (T, cacheMeta, error) {
+	data, meta, err := readThroughCache(ctx, cache, opts, newCacheKey(scope, opts.Args), ttl, collect)
+	if err == nil {
+		recordCacheMeta(ctx, meta)
+	}
+	return data, meta, err
+}
+
+// readThroughCache withCache 的缓存读写流程
+func readThroughCache[T any](ctx context.Context, cache types.Cache, opts CacheOptions, ck cacheKey, ttl time.Duration, collect func(ctx context.Context) (T, error)) (T, cacheMeta, error) {
+	failures, _ := cache.(types.FailureCache)
+	key := ck.Key
+
+	// 开启过期窗口时，缓存项需要保留到窗口结束
+	entryTTL := ttl
+	if opts.staleEnabled() {
+		entryTTL += opts.StaleWindow
+	}
+
+	store := func(data T, duration time.Duration) {
+		if failures != nil {
+			failures.ClearFailure(key)
+		}
+		collectedAt := opts.wallNow()
+		cache.Set(key, cacheEntry{Data: data, CollectedAt: collectedAt, CollectedMonotonic: opts.monotonicNow(), Duration: duration, Scope: ck.Scope, Args: ck.Summary}, entryTTL)
+		if opts.LastGood != nil && opts.Name != "" {
+			saveLastGood(opts.LastGood, opts.Name, key, data, collectedAt, duration)
+		}
+	}
+
+	fail := func(data T, err error) (T, cacheMeta, error) {
+		if opts.fallbackEnabled() {
+			if fallback, meta, found := loadLastGood[T](opts.LastGood, opts.Name, key, opts.wallNow()); found {
+				meta.FallbackErr = err
+				return fallback, meta, nil
+			}
+		}
+		return data, cacheMeta{}, err
+	}
+
+	if opts.Mode == CacheModeOnly {
+		if data, meta, found := cachedValue[T](cache, key, opts.monotonicNow()); found && meta.Age <= ttl {
+			return data, meta, nil
+		}
+		var zero T
+		toolErr := notFound("没有可用的缓存数据（不存在或已超过 %s 的有效期）", ttl)
+		toolErr.Hint = `使用 "cache": "auto" 或 "fresh" 实时采集`
+		return zero, cacheMeta{}, toolErr
+	}
+
+	if opts.readsCache() {
+		if data, meta, found := cachedValue[T](cache, key, opts.monotonicNow()); found {
+			if meta.Age <= ttl {
+				return data, meta, nil
+			}
+
+			if opts.staleEnabled() && meta.Age <= entryTTL {
+				opts.Revalidator.Refresh(key, func(ctx context.Context) error {
+					start := opts.monotonicNow()
+					fresh, err := collect(ctx)
+					if err != nil {
+						if failures != nil {
+							failures.SetFailure(key, err)
+						}
+						return err
+					}
+					// 服务器已关闭，丢弃刷新结果
+					if ctx.Err() != nil {
+						return nil
+					}
+					store(fresh, opts.monotonicNow()-start)
+					return nil
+				})
+				meta.Stale = true
+				return data, meta, nil
+			}
+		}
+	}
+
+	if failures != nil && opts.readsFailures() {
+		if message, retryAfter, found := failures.GetFailure(key); found {
+			var zero T
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			return fail(zero, fmt.Errorf("%s (cached failure, retrying after %ds)", message, seconds))
+		}
+	}
+
+	start := opts.monotonicNow()
+	data, err := collect(ctx)
+	duration := opts.monotonicNow() - start
+	if err != nil {
+		// 调用方取消或超时后的失败不说明采集本身有问题，不记录，否则之后的调用会直接返回这次的失败
+		if failures != nil && ctx.Err() == nil {
+			failures.SetFailure(key, err)
+		}
+		return fail(data, err)
+	}
+
+	store(data, duration)
+
+	return data, cacheMeta{CollectDuration: duration}, nil
+}
+
+// cachedValue 读取缓存中类型为 T 的数据，cacheMeta 中记录已缓存时长（monotonic 为当前的单调时钟读数）和原始采集耗时
+func cachedValue[T any](cache types.Cache, key string, monotonic time.Duration) (T, cacheMeta, bool) {
+	var zero T
+	cachedData, found := cache.Get(key)
+	if !found {
+		return zero, cacheMeta{}, false
+	}
+	entry, ok := cachedData.(cacheEntry)
+	if !ok {
+		return zero, cacheMeta{}, false
+	}
+	data, ok := entry.Data.(T)
+	if !ok {
+		return zero, cacheMeta{}, false
+	}
+	return data, cacheMeta{Cached: true, Age: monotonic - entry.CollectedMonotonic, CollectDuration: entry.Duration}, true
+}
+
+// cacheHeader 生成放在输出开头的说明，仅在返回降级数据时输出
+func cacheHeader(meta cacheMeta) string {
+	if !meta.Fallback {
+		return ""
+	}
+	return fmt.Sprintf("⚠️ 实时采集失败，以下为 %s的数据（错误: %v）\n\n", formatElapsedAgo(meta.Age), meta.FallbackErr)
+}
+
+// cacheNote 生成放在输出末尾的采集耗时，缓存数据同时给出缓存时长，过期数据另外说明正在后台刷新
+func cacheNote(meta cacheMeta) string {
+	var result string
+	result += fmt.Sprintf("\n⏱️ 采集耗时: %.2fs", meta.CollectDuration.Seconds())
+	if meta.Cached && !meta.Fallback {
+		result += fmt.Sprintf("（缓存于 %s）", formatElapsedAgo(meta.Age))
+	}
+	result += "\n"
+	if meta.Stale {
+		result += fmt.Sprintf("⏳ 数据为 %s的缓存，后台正在刷新\n", formatElapsedAgo(meta.Age))
+	}
+	return result
+}
+
+// collectionInfo JSON 输出中的采集耗时，缓存数据另有缓存时长
+type collectionInfo struct {
+	CollectionDurationMs int64 `json:"collection_duration_ms"`
+	CacheAgeMs           int64 `json:"cache_age_ms,omitempty"`
+}
+
+// newCollectionInfo 根据缓存元信息生成采集耗时说明
+func newCollectionInfo(meta cacheMeta) collectionInfo {
+	info := collectionInfo{CollectionDurationMs: meta.CollectDuration.Milliseconds()}
+	if meta.Cached {
+		info.CacheAgeMs = meta.Age.Milliseconds()
+	}
+	return info
+}
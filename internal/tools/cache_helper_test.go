@@ -0,0 +1,265 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+)
+
+// countingCollector 记录调用次数的采集函数，err 不为 nil 时返回该错误
+type countingCollector struct {
+	calls int
+	value string
+	err   error
+}
+
+func (c *countingCollector) collect(ctx context.Context) (string, error) {
+	c.calls++
+	return c.value, c.err
+}
+
+func TestWithCacheDefaultModeReturnsCachedFailure(t *testing.T) {
+	cache := storage.NewNegativeCache(storage.NewMemoryCache(), time.Minute)
+	failing := &countingCollector{err: errors.New("statfs timeout")}
+	opts := CacheOptions{}.forCall(map[string]interface{}{})
+
+	if _, _, err := withCache(context.Background(), cache, opts, "disk_info", time.Second, failing.collect); err == nil {
+		t.Fatal("first call should fail")
+	}
+	_, _, err := withCache(context.Background(), cache, opts, "disk_info", time.Second, failing.collect)
+	if err == nil || !strings.Contains(err.Error(), "cached failure") {
+		t.Fatalf("second call error = %v, want cached failure", err)
+	}
+	if failing.calls != 1 {
+		t.Fatalf("collector called %d times, want 1 (default mode must honor the negative cache)", failing.calls)
+	}
+}
+
+func TestWithCacheFreshBypassesCachedFailure(t *testing.T) {
+	for _, args := range []map[string]interface{}{
+		{"cache": CacheModeFresh},
+		{"use_cache": "false"},
+	} {
+		cache := storage.NewNegativeCache(storage.NewMemoryCache(), time.Minute)
+		cache.SetFailure(newCacheKey("disk_info", args).Key, errors.New("statfs timeout"))
+		healthy := &countingCollector{value: "ok"}
+
+		data, _, err := withCache(context.Background(), cache, CacheOptions{}.forCall(args), "disk_info", time.Second, healthy.collect)
+		if err != nil || data != "ok" || healthy.calls != 1 {
+			t.Fatalf("args %v: got %q, %v after %d calls; want a fresh collection", args, data, err, healthy.calls)
+		}
+		if _, _, found := cache.GetFailure(newCacheKey("disk_info", args).Key); found {
+			t.Fatalf("args %v: a successful collection should clear the failure", args)
+		}
+	}
+}
+
+func TestWithCacheAutoReturnsCachedFailure(t *testing.T) {
+	args := map[string]interface{}{"cache": CacheModeAuto}
+	cache := storage.NewNegativeCache(storage.NewMemoryCache(), time.Minute)
+	cache.SetFailure(newCacheKey("disk_info", args).Key, errors.New("statfs timeout"))
+	collector := &countingCollector{value: "ok"}
+
+	_, _, err := withCache(context.Background(), cache, CacheOptions{}.forCall(args), "disk_info", time.Second, collector.collect)
+	if err == nil || collector.calls != 0 {
+		t.Fatalf("got %v after %d calls, want the cached failure without collecting", err, collector.calls)
+	}
+}
+
+func TestCacheMode(t *testing.T) {
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{}, ""},
+		{map[string]interface{}{"use_cache": "true"}, CacheModeAuto},
+		{map[string]interface{}{"use_cache": "false"}, CacheModeFresh},
+		{map[string]interface{}{"cache": CacheModeOnly, "use_cache": "true"}, CacheModeOnly},
+		{map[string]interface{}{"cache": CacheModeFresh}, CacheModeFresh},
+	}
+	for _, test := range tests {
+		if got := cacheMode(test.args); got != test.want {
+			t.Errorf("cacheMode(%v) = %q, want %q", test.args, got, test.want)
+		}
+	}
+}
+
+// slowCollector 阻塞到 release 关闭后才返回的采集函数，用于模拟 CPU 采样等慢速采集
+type slowCollector struct {
+	calls   atomic.Int32
+	release chan struct{}
+	value   string
+}
+
+func newSlowCollector(value string) *slowCollector {
+	return &slowCollector{release: make(chan struct{}), value: value}
+}
+
+func (c *slowCollector) collect(ctx context.Context) (string, error) {
+	c.calls.Add(1)
+	select {
+	case <-c.release:
+		return c.value, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// setStaleEntry 写入一个采集于 age 之前的缓存项
+func setStaleEntry(cache *storage.MemoryCache, key, value string, age time.Duration) {
+	cache.Set(key, cacheEntry{Data: value, CollectedAt: time.Now().Add(-age), CollectedMonotonic: Monotonic() - age}, time.Hour)
+}
+
+func TestWithCacheDefaultModeServesStaleWithoutBlocking(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	revalidator := NewRevalidator(ctx)
+	cache := storage.NewMemoryCache()
+	args := map[string]interface{}{}
+	key := newCacheKey("cpu_info", args).Key
+	setStaleEntry(cache, key, "old", 2*time.Second)
+
+	slow := newSlowCollector("new")
+	opts := CacheOptions{StaleWindow: time.Minute, Revalidator: revalidator}.forCall(args)
+
+	done := make(chan struct{})
+	var data string
+	var meta cacheMeta
+	var err error
+	go func() {
+		defer close(done)
+		data, meta, err = withCache(context.Background(), cache, opts, "cpu_info", time.Second, slow.collect)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("default mode blocked on the slow collector instead of serving stale data")
+	}
+	if err != nil || data != "old" || !meta.Stale {
+		t.Fatalf("got %q, stale=%v, err=%v; want the stale value", data, meta.Stale, err)
+	}
+
+	// 刷新进行中时再次调用不会启动第二个刷新
+	if _, meta, _ := withCache(context.Background(), cache, opts, "cpu_info", time.Second, slow.collect); !meta.Stale {
+		t.Fatal("second call should still serve the stale value")
+	}
+
+	close(slow.release)
+	revalidator.Wait()
+	if calls := slow.calls.Load(); calls != 1 {
+		t.Fatalf("collector called %d times, want 1 deduplicated refresh", calls)
+	}
+	data, meta, err = withCache(context.Background(), cache, opts, "cpu_info", time.Second, slow.collect)
+	if err != nil || data != "new" || meta.Stale {
+		t.Fatalf("after refresh got %q, stale=%v, err=%v; want the refreshed value", data, meta.Stale, err)
+	}
+}
+
+func TestWithCacheDefaultModeWithoutStaleWindowCollects(t *testing.T) {
+	cache := storage.NewMemoryCache()
+	args := map[string]interface{}{}
+	setStaleEntry(cache, newCacheKey("cpu_info", args).Key, "old", 0)
+	collector := &countingCollector{value: "new"}
+
+	data, _, err := withCache(context.Background(), cache, CacheOptions{}.forCall(args), "cpu_info", time.Minute, collector.collect)
+	if err != nil || data != "new" || collector.calls != 1 {
+		t.Fatalf("got %q, %v after %d calls; want a fresh collection", data, err, collector.calls)
+	}
+}
+
+func TestWithCacheFreshIgnoresStaleWindow(t *testing.T) {
+	revalidator := NewRevalidator(context.Background())
+	cache := storage.NewMemoryCache()
+	args := map[string]interface{}{"cache": CacheModeFresh}
+	setStaleEntry(cache, newCacheKey("cpu_info", args).Key, "old", 2*time.Second)
+	collector := &countingCollector{value: "new"}
+
+	opts := CacheOptions{StaleWindow: time.Minute, Revalidator: revalidator}.forCall(args)
+	data, meta, err := withCache(context.Background(), cache, opts, "cpu_info", time.Second, collector.collect)
+	if err != nil || data != "new" || meta.Stale || collector.calls != 1 {
+		t.Fatalf("got %q, stale=%v, err=%v after %d calls; want a fresh collection", data, meta.Stale, err, collector.calls)
+	}
+}
+
+func TestRevalidatorStopsAfterShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	revalidator := NewRevalidator(ctx)
+	cancel()
+
+	if revalidator.Refresh("cpu_info", func(context.Context) error { return nil }) {
+		t.Fatal("Refresh should not start after the context is cancelled")
+	}
+}
+
+func TestRevalidatorDiscardsResultAfterShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	revalidator := NewRevalidator(ctx)
+	cache := storage.NewMemoryCache()
+	args := map[string]interface{}{}
+	key := newCacheKey("cpu_info", args).Key
+	setStaleEntry(cache, key, "old", 2*time.Second)
+	slow := newSlowCollector("new")
+
+	opts := CacheOptions{StaleWindow: time.Minute, Revalidator: revalidator}.forCall(args)
+	if _, meta, _ := withCache(context.Background(), cache, opts, "cpu_info", time.Second, slow.collect); !meta.Stale {
+		t.Fatal("want the stale value")
+	}
+	cancel()
+	revalidator.Wait()
+
+	data, _, _ := cachedValue[string](cache, key, Monotonic())
+	if data != "old" {
+		t.Fatalf("cached value = %q, want the refresh to be abandoned on shutdown", data)
+	}
+}
+
+func TestWithCacheCallerCancellationNotCached(t *testing.T) {
+	for _, newContext := range []func() (context.Context, context.CancelFunc){
+		func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			return ctx, cancel
+		},
+		func() (context.Context, context.CancelFunc) {
+			return context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		},
+	} {
+		ctx, cancel := newContext()
+		cache := storage.NewNegativeCache(storage.NewMemoryCache(), time.Minute)
+		opts := CacheOptions{}.forCall(map[string]interface{}{})
+		aborted := func(ctx context.Context) (string, error) { return "", ctx.Err() }
+
+		if _, _, err := withCache(ctx, cache, opts, "cpu_info", time.Second, aborted); err == nil {
+			t.Fatal("aborted collection should fail")
+		}
+		cancel()
+		if _, _, found := cache.GetFailure(newCacheKey("cpu_info", opts.Args).Key); found {
+			t.Fatalf("%v: the caller's cancellation was cached as a collection failure", ctx.Err())
+		}
+
+		healthy := &countingCollector{value: "ok"}
+		data, _, err := withCache(context.Background(), cache, opts, "cpu_info", time.Second, healthy.collect)
+		if err != nil || data != "ok" || healthy.calls != 1 {
+			t.Fatalf("next call got %q, %v after %d calls; want a fresh collection", data, err, healthy.calls)
+		}
+	}
+}
+
+func TestSampleCPUCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := NewCPUTool(nil, CacheOptions{}, OutputStyle{}).sampleCPU(ctx, "30s", false)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("sampleCPU returned after %s, want prompt return on cancellation", elapsed)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("sampleCPU error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// cacheKeySeparator 缓存键中作用域与参数摘要之间的分隔符
+const cacheKeySeparator = ":"
+
+// cacheKeyHashBytes 缓存键中参数哈希的字节数（十六进制后为两倍长度）
+const cacheKeyHashBytes = 16
+
+// cacheControlArgs 只影响缓存行为、不影响输出内容的参数，不参与缓存键
+var cacheControlArgs = map[string]bool{
+	"cache":       true,
+	"use_cache":   true,
+	"no_fallback": true,
+}
+
+// cacheKey 由作用域（通常是工具名称）和参数生成的缓存键。
+// Key 不透明：作用域加上规范化参数的哈希；Scope 和 Summary 随缓存项保存，供 cache_admin 显示
+type cacheKey struct {
+	Key     string
+	Scope   string
+	Summary string
+}
+
+// newCacheKey 生成缓存键：参数去掉缓存控制参数和空值后按名称排序，以 JSON 编码后与作用域一起哈希。
+// 参数值保留类型（字符串 "1" 与数字 1 不同），嵌套的对象同样按键排序，
+// 因此参数相同（与顺序无关）的调用得到相同的键，任何影响输出的参数不同时键也不同
+func newCacheKey(scope string, args map[string]interface{}) cacheKey {
+	canonical := canonicalCacheArgs(args)
+
+	// encoding/json 按键排序编码 map，结果与插入顺序无关
+	encoded, err := json.Marshal(canonical)
+	if err != nil {
+		encoded = []byte(fmt.Sprintf("%#v", canonical))
+	}
+	hash := sha256.New()
+	hash.Write([]byte(scope))
+	hash.Write([]byte{0})
+	hash.Write(encoded)
+	sum := hash.Sum(nil)
+
+	return cacheKey{
+		Key:     scope + cacheKeySeparator + hex.EncodeToString(sum[:cacheKeyHashBytes]),
+		Scope:   scope,
+		Summary: summarizeCacheArgs(canonical),
+	}
+}
+
+// CallKey 工具调用的规范化键：参数相同（与顺序无关、忽略缓存控制参数和空值）的调用得到相同的键，
+// 规则与缓存键相同（见 newCacheKey），供工具包之外需要按调用区分结果的地方使用
+func CallKey(tool string, args map[string]interface{}) string {
+	return newCacheKey(tool, args).Key
+}
+
+// canonicalCacheArgs 去掉缓存控制参数和空值（nil、空字符串）后的参数
+func canonicalCacheArgs(args map[string]interface{}) map[string]interface{} {
+	canonical := make(map[string]interface{}, len(args))
+	for name, value := range args {
+		if cacheControlArgs[name] || value == nil || value == "" {
+			continue
+		}
+		canonical[name] = value
+	}
+	return canonical
+}
+
+// summarizeCacheArgs 参数摘要，如 detailed=true, format="json"，按名称排序，没有参数时为空
+func summarizeCacheArgs(args map[string]interface{}) string {
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		value, err := json.Marshal(args[name])
+		if err != nil {
+			value = []byte(fmt.Sprintf("%v", args[name]))
+		}
+		parts = append(parts, name+"="+string(value))
+	}
+	return strings.Join(parts, ", ")
+}
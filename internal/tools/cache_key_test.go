@@ -0,0 +1,198 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+)
+
+// permutations members 的所有排列
+func permutations(members []string) [][]string {
+	if len(members) <= 1 {
+		return [][]string{append([]string(nil), members...)}
+	}
+	var result [][]string
+	for i, first := range members {
+		rest := append(append([]string(nil), members[:i]...), members[i+1:]...)
+		for _, tail := range permutations(rest) {
+			result = append(result, append([]string{first}, tail...))
+		}
+	}
+	return result
+}
+
+// decodeArgsJSON 按给定顺序拼出的 JSON 对象解码得到的参数，与客户端发来的 arguments 一样经过 JSON 解码
+func decodeArgsJSON(t *testing.T, members []string) map[string]interface{} {
+	t.Helper()
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte("{"+strings.Join(members, ",")+"}"), &args); err != nil {
+		t.Fatal(err)
+	}
+	return args
+}
+
+func TestCacheKeyIgnoresArgumentOrder(t *testing.T) {
+	members := []string{
+		`"sort_by": "cpu"`,
+		`"limit": 5`,
+		`"detailed": true`,
+		`"filter": {"user": "root", "name": "nginx", "min_cpu": 1.5}`,
+	}
+	nested := []string{`"user": "root"`, `"name": "nginx"`, `"min_cpu": 1.5`}
+
+	want := newCacheKey("top_processes", decodeArgsJSON(t, members))
+	for _, order := range permutations(members) {
+		for _, inner := range permutations(nested) {
+			permuted := make([]string, len(order))
+			for i, member := range order {
+				if strings.HasPrefix(member, `"filter"`) {
+					member = `"filter": {` + strings.Join(inner, ",") + `}`
+				}
+				permuted[i] = member
+			}
+			if got := newCacheKey("top_processes", decodeArgsJSON(t, permuted)); got != want {
+				t.Fatalf("key for {%s} = %+v, want %+v", strings.Join(permuted, ","), got, want)
+			}
+		}
+	}
+	if want.Summary != `detailed=true, filter={"min_cpu":1.5,"name":"nginx","user":"root"}, limit=5, sort_by="cpu"` {
+		t.Errorf("Summary = %s, want the arguments sorted by name", want.Summary)
+	}
+}
+
+func TestCacheKeyIgnoresCacheControlAndEmptyArgs(t *testing.T) {
+	want := newCacheKey("disk_info", map[string]interface{}{"show_all": true})
+	for _, args := range []map[string]interface{}{
+		{"show_all": true, "cache": "fresh"},
+		{"show_all": true, "cache": "only", "use_cache": false, "no_fallback": "true"},
+		{"show_all": true, "format": ""},
+		{"show_all": true, "compact": nil},
+	} {
+		if got := newCacheKey("disk_info", args); got != want {
+			t.Errorf("key for %v = %+v, want %+v", args, got, want)
+		}
+	}
+}
+
+// TestCacheKeyDistinguishesArgs 所有取值组合中，去掉空值后参数相同的调用键相同，其余组合两两不同
+func TestCacheKeyDistinguishesArgs(t *testing.T) {
+	domains := []struct {
+		name   string
+		values []interface{}
+	}{
+		{"detailed", []interface{}{nil, true, false, "true"}},
+		{"limit", []interface{}{nil, 5, "5", 10, 5.5}},
+		{"sort_by", []interface{}{nil, "", "cpu", "memory", "cpu,limit=5"}},
+		{"filter", []interface{}{nil, map[string]interface{}{"name": "x"}, map[string]interface{}{"name": "y"}, `{"name":"x"}`}},
+	}
+
+	type combination struct {
+		args      map[string]interface{}
+		canonical map[string]interface{}
+	}
+	var combinations []combination
+	var build func(i int, args map[string]interface{})
+	build = func(i int, args map[string]interface{}) {
+		if i == len(domains) {
+			copied := make(map[string]interface{}, len(args))
+			for name, value := range args {
+				copied[name] = value
+			}
+			combinations = append(combinations, combination{args: copied, canonical: canonicalCacheArgs(copied)})
+			return
+		}
+		for _, value := range domains[i].values {
+			if value == nil {
+				delete(args, domains[i].name)
+			} else {
+				args[domains[i].name] = value
+			}
+			build(i+1, args)
+		}
+	}
+	build(0, map[string]interface{}{})
+
+	keys := make([]string, len(combinations))
+	for i, c := range combinations {
+		keys[i] = newCacheKey("top_processes", c.args).Key
+	}
+	for i := range combinations {
+		for j := i + 1; j < len(combinations); j++ {
+			same := reflect.DeepEqual(combinations[i].canonical, combinations[j].canonical)
+			if (keys[i] == keys[j]) != same {
+				t.Fatalf("%v and %v: keys equal = %v, want %v", combinations[i].args, combinations[j].args, keys[i] == keys[j], same)
+			}
+		}
+	}
+}
+
+func TestCacheKeyDistinguishesScopes(t *testing.T) {
+	cases := []struct {
+		scope string
+		args  map[string]interface{}
+	}{
+		{"cpu_info", nil},
+		{"memory_info", nil},
+		{"cpu_info", map[string]interface{}{"detailed": true}},
+		{"memory_info", map[string]interface{}{"detailed": true}},
+		// 分隔符出现在作用域或参数中时不能拼出相同的键
+		{"net:eth0", nil},
+		{"net", map[string]interface{}{"eth0": true}},
+		{"net", map[string]interface{}{"interface": "eth0"}},
+		{"net:", map[string]interface{}{"interface": "eth0"}},
+	}
+	seen := make(map[string]string)
+	for _, c := range cases {
+		key := newCacheKey(c.scope, c.args)
+		description := fmt.Sprintf("%s %v", c.scope, c.args)
+		if other, found := seen[key.Key]; found {
+			t.Errorf("%s and %s share the key %s", description, other, key.Key)
+		}
+		seen[key.Key] = description
+		if !strings.HasPrefix(key.Key, c.scope+cacheKeySeparator) || key.Scope != c.scope {
+			t.Errorf("key %+v does not start with the scope %q", key, c.scope)
+		}
+	}
+}
+
+func TestWithCacheSharesEntryAcrossArgumentOrder(t *testing.T) {
+	cache := storage.NewMemoryCache()
+	collector := &countingCollector{value: "processes"}
+	members := []string{`"sort_by": "cpu"`, `"limit": 5`, `"cache": "auto"`, `"user": "root"`}
+
+	for _, order := range permutations(members) {
+		opts := CacheOptions{}.forCall(decodeArgsJSON(t, order))
+		if data, _, err := withCache(context.Background(), cache, opts, "top_processes", time.Minute, collector.collect); err != nil || data != "processes" {
+			t.Fatalf("withCache() = %q, %v", data, err)
+		}
+	}
+	if collector.calls != 1 {
+		t.Fatalf("collected %d times for %d orderings of the same arguments, want 1", collector.calls, len(permutations(members)))
+	}
+
+	different := CacheOptions{}.forCall(map[string]interface{}{"sort_by": "memory", "limit": 5, "cache": "auto", "user": "root"})
+	if _, _, err := withCache(context.Background(), cache, different, "top_processes", time.Minute, collector.collect); err != nil {
+		t.Fatal(err)
+	}
+	if collector.calls != 2 {
+		t.Fatalf("collected %d times, want a separate entry for different arguments", collector.calls)
+	}
+}
+
+func TestCacheKeyWithoutPagingArgs(t *testing.T) {
+	first := CacheOptions{}.forCall(map[string]interface{}{"sort_by": "cpu", "limit": 10, "offset": 0}).withoutArgs("limit", "offset")
+	second := CacheOptions{}.forCall(map[string]interface{}{"offset": 10, "sort_by": "cpu", "limit": 20}).withoutArgs("limit", "offset")
+	if a, b := newCacheKey("top_processes", first.Args), newCacheKey("top_processes", second.Args); a != b {
+		t.Fatalf("pages of the same list have different keys: %+v, %+v", a, b)
+	}
+	other := CacheOptions{}.forCall(map[string]interface{}{"sort_by": "memory", "limit": 10}).withoutArgs("limit", "offset")
+	if newCacheKey("top_processes", first.Args) == newCacheKey("top_processes", other.Args) {
+		t.Fatal("removing the paging arguments must keep the other arguments in the key")
+	}
+}
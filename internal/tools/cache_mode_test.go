@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+)
+
+// countingCPUProvider 记录使用率采样次数的假 CPU 来源
+type countingCPUProvider struct {
+	*fakeCPUProvider
+	samples int
+}
+
+func (cc *countingCPUProvider) Percent(ctx context.Context, interval time.Duration, perCPU bool) ([]float64, error) {
+	if !perCPU {
+		cc.samples++
+	}
+	return cc.fakeCPUProvider.Percent(ctx, interval, perCPU)
+}
+
+// cpuTotal 执行 cpu_info，返回总使用率和输出文本
+func cpuTotal(t *testing.T, tool *CPUTool, args map[string]interface{}) (float64, string, error) {
+	t.Helper()
+	text, structured, err := tool.ExecuteStructured(context.Background(), args)
+	if err != nil {
+		return 0, text, err
+	}
+	return structured.(cpuReport).Usage.Total, text, nil
+}
+
+// assertOnlyMiss 检查 cache=only 在没有缓存数据时返回 ERR_NOT_FOUND 并提示实时采集
+func assertOnlyMiss(t *testing.T, err error) {
+	t.Helper()
+	var toolErr *Error
+	if !errors.As(err, &toolErr) || toolErr.Code != ErrNotFound || !strings.Contains(toolErr.Hint, `"cache": "auto"`) {
+		t.Fatalf("cache=only without cached data = %v, want ERR_NOT_FOUND with a hint", err)
+	}
+}
+
+func TestCPUToolCacheModes(t *testing.T) {
+	provider := &countingCPUProvider{fakeCPUProvider: &fakeCPUProvider{
+		infos:         []CPUInfoStat{{ModelName: "Fake CPU", PhysicalID: "0", CoreID: "0"}},
+		physicalCores: 1,
+		perCore:       []float64{10},
+		total:         10,
+	}}
+	current := providers
+	current.CPU = provider
+	t.Cleanup(SetProviders(current))
+	tool := NewCPUTool(storage.NewMemoryCache(), CacheOptions{}, NewOutputStyle(StylePlain, 0))
+
+	// only：没有缓存时报错，不采集
+	_, _, err := cpuTotal(t, tool, map[string]interface{}{"cache": CacheModeOnly})
+	assertOnlyMiss(t, err)
+	if provider.samples != 0 {
+		t.Fatalf("cache=only sampled the CPU %d times", provider.samples)
+	}
+
+	// auto：第一次采集并写入缓存，TTL 内返回缓存数据
+	if total, _, err := cpuTotal(t, tool, map[string]interface{}{"cache": CacheModeAuto}); err != nil || total != 10 {
+		t.Fatalf("first cache=auto = %v, %v", total, err)
+	}
+	provider.total = 50
+	total, text, err := cpuTotal(t, tool, map[string]interface{}{"cache": CacheModeAuto})
+	if err != nil || total != 10 || !strings.Contains(text, "缓存于") || provider.samples != 1 {
+		t.Fatalf("second cache=auto = %v, %v after %d samples, want the cached 10%%", total, err, provider.samples)
+	}
+
+	// only：返回缓存数据
+	if total, _, err := cpuTotal(t, tool, map[string]interface{}{"cache": CacheModeOnly}); err != nil || total != 10 || provider.samples != 1 {
+		t.Fatalf("cache=only = %v, %v after %d samples, want the cached 10%%", total, err, provider.samples)
+	}
+
+	// fresh：总是采集，并刷新缓存项供之后的 auto 使用
+	if total, text, err := cpuTotal(t, tool, map[string]interface{}{"cache": CacheModeFresh}); err != nil || total != 50 || strings.Contains(text, "缓存于") {
+		t.Fatalf("cache=fresh = %v, %v, want a new 50%% sample", total, err)
+	}
+	if total, _, err := cpuTotal(t, tool, map[string]interface{}{"cache": CacheModeAuto}); err != nil || total != 50 || provider.samples != 2 {
+		t.Fatalf("cache=auto after fresh = %v, %v after %d samples, want the refreshed 50%%", total, err, provider.samples)
+	}
+
+	// 已弃用的 use_cache 映射为 auto/fresh
+	provider.total = 70
+	if total, _, _ := cpuTotal(t, tool, map[string]interface{}{"use_cache": "true"}); total != 50 {
+		t.Errorf("use_cache=true = %v, want the cached 50%%", total)
+	}
+	if total, _, _ := cpuTotal(t, tool, map[string]interface{}{"use_cache": "false"}); total != 70 {
+		t.Errorf("use_cache=false = %v, want a new 70%% sample", total)
+	}
+	if provider.samples != 3 {
+		t.Errorf("sampled %d times, want 3", provider.samples)
+	}
+}
+
+func TestDiskToolCacheModes(t *testing.T) {
+	provider := &fakeDiskProvider{
+		partitions: []PartitionStat{{Device: "/dev/sda1", Mountpoint: "/", Fstype: "ext4"}},
+		usage:      map[string]UsageStat{"/": {Path: "/", Total: 100 * gb, Used: 40 * gb, Free: 60 * gb, UsedPercent: 40}},
+	}
+	useFakeDisk(t, provider)
+	tool := NewDiskTool(storage.NewMemoryCache(), CacheOptions{}, PartitionFilter{}, NewOutputStyle(StylePlain, 0), nil)
+	tool.platform = platformLinux
+	tool.mountsPath = filepath.Join(t.TempDir(), "mounts")
+
+	usedPercent := func(args map[string]interface{}) (float64, error) {
+		t.Helper()
+		_, structured, err := tool.ExecuteStructured(context.Background(), args)
+		if err != nil {
+			return 0, err
+		}
+		return structured.(diskReport).Partitions[0].UsedPercent, nil
+	}
+	setUsed := func(percent float64) {
+		provider.usage = map[string]UsageStat{"/": {Path: "/", Total: 100 * gb, UsedPercent: percent}}
+	}
+
+	_, err := usedPercent(map[string]interface{}{"cache": CacheModeOnly})
+	assertOnlyMiss(t, err)
+
+	// auto 命中缓存时不查询新的使用量，fresh 查询并刷新缓存项
+	if used, err := usedPercent(map[string]interface{}{"cache": CacheModeAuto}); err != nil || used != 40 {
+		t.Fatalf("first cache=auto = %v, %v", used, err)
+	}
+	setUsed(70)
+	if used, err := usedPercent(map[string]interface{}{"cache": CacheModeAuto}); err != nil || used != 40 {
+		t.Fatalf("second cache=auto = %v, %v, want the cached 40%%", used, err)
+	}
+	if used, err := usedPercent(map[string]interface{}{"cache": CacheModeOnly}); err != nil || used != 40 {
+		t.Fatalf("cache=only = %v, %v, want the cached 40%%", used, err)
+	}
+	if used, err := usedPercent(map[string]interface{}{"cache": CacheModeFresh}); err != nil || used != 70 {
+		t.Fatalf("cache=fresh = %v, %v, want a new 70%%", used, err)
+	}
+	if used, err := usedPercent(map[string]interface{}{"cache": CacheModeAuto}); err != nil || used != 70 {
+		t.Fatalf("cache=auto after fresh = %v, %v, want the refreshed 70%%", used, err)
+	}
+
+	// 参数不同的调用使用各自的缓存项
+	if _, err := usedPercent(map[string]interface{}{"cache": CacheModeOnly, "show_all": "true"}); err == nil {
+		t.Error("cache=only with other arguments returned data cached for the defaults")
+	}
+}
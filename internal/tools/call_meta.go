@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CallMeta 汇总一次工具调用中各次缓存读取的元信息，由 withCache 记录
+type CallMeta struct {
+	mu sync.Mutex
+	// reads 缓存读取次数，cached 为其中命中缓存的次数
+	reads  int
+	cached int
+	// oldest 返回数据中最旧一份的已缓存时长
+	oldest time.Duration
+}
+
+type callMetaKey struct{}
+
+// WithCallMeta 返回携带 CallMeta 的 ctx，调用方在工具执行后读取其中的缓存信息
+func WithCallMeta(ctx context.Context) (context.Context, *CallMeta) {
+	meta := &CallMeta{}
+	return context.WithValue(ctx, callMetaKey{}, meta), meta
+}
+
+// recordCacheMeta 记录一次缓存读取，ctx 中没有 CallMeta 时不做任何事
+func recordCacheMeta(ctx context.Context, meta cacheMeta) {
+	callMeta, ok := ctx.Value(callMetaKey{}).(*CallMeta)
+	if !ok {
+		return
+	}
+
+	callMeta.mu.Lock()
+	defer callMeta.mu.Unlock()
+	callMeta.reads++
+	// 降级数据同样来自之前的采集
+	if meta.Cached || meta.Fallback {
+		callMeta.cached++
+		if meta.Age > callMeta.oldest {
+			callMeta.oldest = meta.Age
+		}
+	}
+}
+
+// Cached 调用读取过缓存且所有数据都来自缓存时返回 true
+func (cm *CallMeta) Cached() bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	return cm.reads > 0 && cm.cached == cm.reads
+}
+
+// DataAge 返回数据中最旧一份的已缓存时长，全部为实时采集时为 0
+func (cm *CallMeta) DataAge() time.Duration {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	return cm.oldest
+}
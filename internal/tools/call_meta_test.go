@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+)
+
+func TestCallMeta(t *testing.T) {
+	cases := []struct {
+		name   string
+		reads  []cacheMeta
+		cached bool
+		age    time.Duration
+	}{
+		{"no reads", nil, false, 0},
+		{"fresh", []cacheMeta{{}}, false, 0},
+		{"all cached", []cacheMeta{{Cached: true, Age: 2 * time.Second}, {Cached: true, Age: 7 * time.Second}}, true, 7 * time.Second},
+		// 部分实时采集时整体不算缓存，数据时长仍取缓存中最旧的一份
+		{"mixed", []cacheMeta{{Cached: true, Age: 5 * time.Second}, {}}, false, 5 * time.Second},
+		// 降级数据同样来自之前的采集
+		{"fallback", []cacheMeta{{Cached: true, Fallback: true, Age: time.Hour}}, true, time.Hour},
+	}
+	for _, c := range cases {
+		ctx, callMeta := WithCallMeta(context.Background())
+		for _, read := range c.reads {
+			recordCacheMeta(ctx, read)
+		}
+		if callMeta.Cached() != c.cached || callMeta.DataAge() != c.age {
+			t.Errorf("%s: Cached() = %v, DataAge() = %s; want %v, %s", c.name, callMeta.Cached(), callMeta.DataAge(), c.cached, c.age)
+		}
+	}
+
+	// 没有 CallMeta 的 ctx 不受影响
+	recordCacheMeta(context.Background(), cacheMeta{Cached: true})
+}
+
+func TestCallMetaConcurrentReads(t *testing.T) {
+	ctx, callMeta := WithCallMeta(context.Background())
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(age time.Duration) {
+			defer wg.Done()
+			recordCacheMeta(ctx, cacheMeta{Cached: true, Age: age})
+		}(time.Duration(i) * time.Second)
+	}
+	wg.Wait()
+	if !callMeta.Cached() || callMeta.DataAge() != 20*time.Second {
+		t.Errorf("Cached() = %v, DataAge() = %s", callMeta.Cached(), callMeta.DataAge())
+	}
+}
+
+func TestWithCacheRecordsCallMeta(t *testing.T) {
+	clock := newJumpClock()
+	cache := storage.NewMemoryCache()
+	collector := &countingCollector{value: "data"}
+	fresh := clock.options(CacheOptions{}.forCall(nil))
+	auto := clock.options(CacheOptions{}.forCall(map[string]interface{}{"cache": "auto"}))
+
+	// 第一次调用实时采集
+	ctx, callMeta := WithCallMeta(context.Background())
+	if _, _, err := withCache(ctx, cache, fresh, "memory_info", time.Minute, collector.collect); err != nil {
+		t.Fatal(err)
+	}
+	if callMeta.Cached() || callMeta.DataAge() != 0 {
+		t.Errorf("fresh call: Cached() = %v, DataAge() = %s", callMeta.Cached(), callMeta.DataAge())
+	}
+
+	// 第二次调用命中缓存，数据时长按单调时钟计算
+	clock.Advance(4 * time.Second)
+	ctx, callMeta = WithCallMeta(context.Background())
+	if _, _, err := withCache(ctx, cache, auto, "memory_info", time.Minute, collector.collect); err != nil {
+		t.Fatal(err)
+	}
+	if !callMeta.Cached() || callMeta.DataAge() != 4*time.Second || collector.calls != 1 {
+		t.Errorf("cached call: Cached() = %v, DataAge() = %s after %d collections", callMeta.Cached(), callMeta.DataAge(), collector.calls)
+	}
+
+	// 失败的读取不计入
+	ctx, callMeta = WithCallMeta(context.Background())
+	failing := &countingCollector{err: context.DeadlineExceeded}
+	if _, _, err := withCache(ctx, cache, fresh, "disk_info", time.Minute, failing.collect); err == nil {
+		t.Fatal("failing collection returned no error")
+	}
+	if callMeta.Cached() {
+		t.Error("failed read counted as cached")
+	}
+}
@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// maxClockDrift 两次采样之间墙上时钟与单调时钟经过的时间允许的差值，超过时视为时钟跳变
+// （NTP 步进调整、手动修改时间、挂起后恢复等）
+const maxClockDrift = 5 * time.Second
+
+// monotonicStart 单调时钟读数的起点
+var monotonicStart = time.Now()
+
+// Monotonic 自进程启动以来按单调时钟经过的时间，不受墙上时钟调整影响。
+// 需要与墙上时钟比较、或在测试中替换时钟的组件同时记录该读数和 time.Now()，
+// 因为 time.Time 中的单调时钟读数无法单独替换
+func Monotonic() time.Duration {
+	return time.Since(monotonicStart)
+}
+
+// ClockJump 返回 prev 到 curr 之间墙上时钟相对单调时钟的跳变量（正数为向前跳），未超过 maxClockDrift 时返回 0。
+// elapsed 为同一段时间内单调时钟经过的时间（两次 Monotonic 读数之差）
+func ClockJump(prev, curr time.Time, elapsed time.Duration) time.Duration {
+	jump := curr.Round(0).Sub(prev.Round(0)) - elapsed
+	if jump.Abs() <= maxClockDrift {
+		return 0
+	}
+	return jump
+}
+
+// ClockWentBack prev 来自存储（上一个进程的样本，没有可比较的单调时钟读数）时只能发现时间倒退：
+// 返回 curr 早于 prev 的时长（负数），未超过 maxClockDrift 时返回 0
+func ClockWentBack(prev, curr time.Time) time.Duration {
+	wall := curr.Round(0).Sub(prev.Round(0))
+	if wall >= -maxClockDrift {
+		return 0
+	}
+	return wall
+}
+
+// detectClockJumps 返回时钟跳变后第一个样本的时间，跳变发生在该样本与上一个样本之间
+func detectClockJumps(samples []types.MetricSample) []time.Time {
+	var jumps []time.Time
+	for _, sample := range samples {
+		if sample.ClockJumpSeconds != 0 {
+			jumps = append(jumps, sample.Timestamp)
+		}
+	}
+	return jumps
+}
@@ -0,0 +1,196 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+// jumpClock 手动推进的墙上时钟和单调时钟，Jump 只移动墙上时钟以模拟 NTP 步进调整或挂起后恢复
+type jumpClock struct {
+	wall time.Time
+	mono time.Duration
+}
+
+func newJumpClock() *jumpClock {
+	return &jumpClock{wall: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), mono: time.Hour}
+}
+
+func (c *jumpClock) Now() time.Time           { return c.wall }
+func (c *jumpClock) Monotonic() time.Duration { return c.mono }
+func (c *jumpClock) Jump(d time.Duration)     { c.wall = c.wall.Add(d) }
+func (c *jumpClock) Advance(d time.Duration)  { c.wall, c.mono = c.wall.Add(d), c.mono+d }
+
+// options 使用该时钟的缓存选项
+func (c *jumpClock) options(opts CacheOptions) CacheOptions {
+	opts.now, opts.monotonic = c.Now, c.Monotonic
+	return opts
+}
+
+func TestClockJump(t *testing.T) {
+	prev := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name    string
+		wall    time.Duration
+		elapsed time.Duration
+		want    time.Duration
+	}{
+		{"steady", 10 * time.Second, 10 * time.Second, 0},
+		{"drift within limit", 14 * time.Second, 10 * time.Second, 0},
+		{"forward", time.Hour + 10*time.Second, 10 * time.Second, time.Hour},
+		{"backward", -time.Hour + 10*time.Second, 10 * time.Second, -time.Hour},
+		{"backward past prev by less than the interval", -6 * time.Second, 0, -6 * time.Second},
+	}
+	for _, c := range cases {
+		if got := ClockJump(prev, prev.Add(c.wall), c.elapsed); got != c.want {
+			t.Errorf("%s: ClockJump() = %s, want %s", c.name, got, c.want)
+		}
+	}
+
+	// time.Now 的读数带单调时钟，比较时只使用墙上时钟部分
+	now := time.Now()
+	if got := ClockJump(now, now.Add(time.Minute), 0); got != time.Minute {
+		t.Errorf("ClockJump() with monotonic readings = %s, want the wall clock difference", got)
+	}
+}
+
+func TestClockWentBack(t *testing.T) {
+	stored := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		curr time.Time
+		want time.Duration
+	}{
+		{stored.Add(3 * time.Hour), 0},
+		{stored.Add(-5 * time.Second), 0},
+		{stored.Add(-time.Hour), -time.Hour},
+	} {
+		if got := ClockWentBack(stored, c.curr); got != c.want {
+			t.Errorf("ClockWentBack(%s) = %s, want %s", c.curr.Sub(stored), got, c.want)
+		}
+	}
+}
+
+func TestMonotonicAdvances(t *testing.T) {
+	first := Monotonic()
+	time.Sleep(time.Millisecond)
+	if second := Monotonic(); second <= first {
+		t.Fatalf("Monotonic() went from %s to %s", first, second)
+	}
+}
+
+func TestWithCacheTTLIgnoresClockJumps(t *testing.T) {
+	for _, jump := range []time.Duration{time.Hour, -time.Hour} {
+		clock := newJumpClock()
+		cache := storage.NewMemoryCache()
+		collector := &countingCollector{value: "cpu"}
+		opts := clock.options(CacheOptions{}.forCall(map[string]interface{}{"cache": CacheModeAuto}))
+
+		if _, _, err := withCache(context.Background(), cache, opts, "cpu_info", 5*time.Second, collector.collect); err != nil {
+			t.Fatal(err)
+		}
+		clock.Jump(jump)
+		clock.Advance(2 * time.Second)
+
+		// 时钟向前跳不会使缓存立即过期，向后跳不会得到负的缓存时长
+		_, meta, err := withCache(context.Background(), cache, opts, "cpu_info", 5*time.Second, collector.collect)
+		if err != nil || !meta.Cached || meta.Age != 2*time.Second || collector.calls != 1 {
+			t.Fatalf("%s jump: meta = %+v, %v after %d collections; want the cached value aged 2s", jump, meta, err, collector.calls)
+		}
+
+		// 按单调时钟超过 TTL 后重新采集，即使墙上时钟已倒退
+		clock.Advance(4 * time.Second)
+		if _, meta, _ := withCache(context.Background(), cache, opts, "cpu_info", 5*time.Second, collector.collect); meta.Cached || collector.calls != 2 {
+			t.Fatalf("%s jump: meta = %+v after %d collections; want a fresh collection past the TTL", jump, meta, collector.calls)
+		}
+	}
+}
+
+func TestWithCacheCollectDurationIgnoresClockJumps(t *testing.T) {
+	clock := newJumpClock()
+	opts := clock.options(CacheOptions{}.forCall(nil))
+	collect := func(ctx context.Context) (string, error) {
+		clock.Jump(-time.Hour)
+		clock.Advance(300 * time.Millisecond)
+		return "slow", nil
+	}
+
+	_, meta, err := withCache(context.Background(), storage.NewMemoryCache(), opts, "cpu_info", time.Second, collect)
+	if err != nil || meta.CollectDuration != 300*time.Millisecond {
+		t.Fatalf("CollectDuration = %s, %v; want the monotonic 300ms", meta.CollectDuration, err)
+	}
+}
+
+func TestLastGoodAgeAcrossClockJumps(t *testing.T) {
+	cases := []struct {
+		name  string
+		jump  time.Duration
+		found bool
+	}{
+		{"no jump", 0, true},
+		// 墙上时钟倒退后记录的采集时间在"未来"，年龄不可信
+		{"backward", -time.Hour, false},
+		// 向前跳过最长有效期后记录视为过期
+		{"forward past max age", time.Hour, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clock := newJumpClock()
+			lastGood := NewLastGood(storage.NewMemoryStorage(), 10*time.Minute)
+			opts := clock.options(CacheOptions{LastGood: lastGood, Name: "disk_info"}.forCall(nil))
+
+			healthy := &countingCollector{value: "partitions"}
+			if _, _, err := withCache(context.Background(), storage.NewMemoryCache(), opts, "disk_info", time.Second, healthy.collect); err != nil {
+				t.Fatal(err)
+			}
+			clock.Jump(c.jump)
+			clock.Advance(30 * time.Second)
+
+			failing := &countingCollector{err: errors.New("statfs timeout")}
+			data, meta, err := withCache(context.Background(), storage.NewMemoryCache(), opts, "disk_info", time.Second, failing.collect)
+			if c.found {
+				if err != nil || data != "partitions" || !meta.Fallback || meta.Age != 30*time.Second {
+					t.Fatalf("withCache() = %q, %+v, %v; want the last good data aged 30s", data, meta, err)
+				}
+				return
+			}
+			if err == nil || meta.Fallback {
+				t.Fatalf("withCache() = %q, %+v, %v; want the collection error", data, meta, err)
+			}
+		})
+	}
+}
+
+func TestRatesSkipClockJumps(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	// 时钟倒退使时间差为负时不计算速率，不会得到负的速率
+	prev := counterSample{BytesRecv: 1000, BytesSent: 1000, Timestamp: start}
+	if rate, ok := computeRate(prev, counterSample{BytesRecv: 2000, BytesSent: 2000, Timestamp: start.Add(-time.Hour)}); ok {
+		t.Fatalf("computeRate() across a backward jump = %+v, want no rate", rate)
+	}
+
+	samples := []types.MetricSample{
+		{Timestamp: start, NetRxBytes: map[string]uint64{"eth0": 0}},
+		{Timestamp: start.Add(10 * time.Second), NetRxBytes: map[string]uint64{"eth0": 10000}},
+		// 挂起一小时后恢复：这一段的时间差不可信
+		{Timestamp: start.Add(time.Hour + 20*time.Second), NetRxBytes: map[string]uint64{"eth0": 20000}, ClockJumpSeconds: 3600},
+		{Timestamp: start.Add(time.Hour + 30*time.Second), NetRxBytes: map[string]uint64{"eth0": 30000}},
+	}
+	values := extractMetric(samples, MetricNetRxBytes, "eth0")
+	if len(values) != 2 || values[0].Value != 1000 || values[1].Value != 1000 {
+		t.Fatalf("rates = %+v, want the two 1000 B/s intervals without the jump", values)
+	}
+	if jumps := detectClockJumps(samples); len(jumps) != 1 || !jumps[0].Equal(samples[2].Timestamp) {
+		t.Fatalf("detectClockJumps() = %v, want the sample after the jump", jumps)
+	}
+
+	diff := renderSampleDiff(samples[1], samples[2])
+	if !strings.Contains(diff, "系统时钟跳变 +3600 秒") || strings.Contains(diff, "🌐") {
+		t.Fatalf("diff across a jump:\n%s\nwant the warning and no network rates", diff)
+	}
+}
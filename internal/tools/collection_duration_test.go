@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+)
+
+func TestCacheNote(t *testing.T) {
+	cases := []struct {
+		name string
+		meta cacheMeta
+		want string
+	}{
+		{"fresh", cacheMeta{CollectDuration: 1020 * time.Millisecond}, "\n⏱️ 采集耗时: 1.02s\n"},
+		// 缓存命中同时给出原始采集耗时和缓存时长
+		{"cached", cacheMeta{Cached: true, Age: 42 * time.Second, CollectDuration: 11 * time.Second}, "\n⏱️ 采集耗时: 11.00s（缓存于 42秒前）\n"},
+		{"stale", cacheMeta{Cached: true, Stale: true, Age: 90 * time.Second, CollectDuration: 300 * time.Millisecond}, "\n⏱️ 采集耗时: 0.30s（缓存于 1分钟前）\n⏳ 数据为 1分钟前的缓存，后台正在刷新\n"},
+		// 降级数据的时长已在开头的警告中说明
+		{"fallback", cacheMeta{Cached: true, Fallback: true, Age: time.Hour, CollectDuration: 2 * time.Second}, "\n⏱️ 采集耗时: 2.00s\n"},
+	}
+	for _, c := range cases {
+		if got := cacheNote(c.meta); got != c.want {
+			t.Errorf("%s: cacheNote() = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestNewCollectionInfo(t *testing.T) {
+	cases := []struct {
+		name string
+		meta cacheMeta
+		want string
+	}{
+		{"fresh", cacheMeta{CollectDuration: 1020 * time.Millisecond}, `{"collection_duration_ms":1020}`},
+		{"cached", cacheMeta{Cached: true, Age: 42 * time.Second, CollectDuration: 11 * time.Second}, `{"collection_duration_ms":11000,"cache_age_ms":42000}`},
+	}
+	for _, c := range cases {
+		data, err := json.Marshal(newCollectionInfo(c.meta))
+		if err != nil || string(data) != c.want {
+			t.Errorf("%s: newCollectionInfo() = %s, %v; want %s", c.name, data, err, c.want)
+		}
+	}
+}
+
+func TestWithCacheKeepsCollectDurationOnHit(t *testing.T) {
+	clock := newJumpClock()
+	cache := storage.NewMemoryCache()
+	collect := func(ctx context.Context) (string, error) {
+		clock.Advance(1500 * time.Millisecond)
+		return "cpu", nil
+	}
+
+	_, meta, err := withCache(context.Background(), cache, clock.options(CacheOptions{}.forCall(nil)), "cpu_info", time.Minute, collect)
+	if err != nil || meta.Cached || meta.CollectDuration != 1500*time.Millisecond {
+		t.Fatalf("fresh: meta = %+v, %v", meta, err)
+	}
+
+	// 缓存命中时保留原始采集耗时，缓存时长按单调时钟计算
+	clock.Advance(10 * time.Second)
+	auto := clock.options(CacheOptions{}.forCall(map[string]interface{}{"cache": "auto"}))
+	_, meta, err = withCache(context.Background(), cache, auto, "cpu_info", time.Minute, collect)
+	if err != nil || !meta.Cached || meta.Age != 10*time.Second || meta.CollectDuration != 1500*time.Millisecond {
+		t.Fatalf("cached: meta = %+v, %v", meta, err)
+	}
+
+	// 采集失败时没有耗时可报告
+	failing := func(ctx context.Context) (string, error) {
+		clock.Advance(time.Second)
+		return "", errors.New("超时")
+	}
+	if _, meta, err := withCache(context.Background(), cache, clock.options(CacheOptions{}.forCall(nil)), "disk_info", time.Minute, failing); err == nil || meta.CollectDuration != 0 {
+		t.Errorf("failed: meta = %+v, %v", meta, err)
+	}
+}
+
+func TestNetworkToolReportsCollectionDuration(t *testing.T) {
+	useFakeNet(t, &fakeNetProvider{counters: []NetIOCountersStat{{Name: "eth0"}}})
+	clock := newJumpClock()
+	tool := NewNetworkTool(storage.NewMemoryCache(), clock.options(CacheOptions{}), 0, nil)
+
+	// 实时采集的 JSON 只有采集耗时
+	text, err := tool.Execute(context.Background(), map[string]interface{}{"format": "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := report["collection_duration_ms"]; !found {
+		t.Errorf("fresh JSON lacks collection_duration_ms:\n%s", text)
+	}
+	if _, found := report["cache_age_ms"]; found {
+		t.Errorf("fresh JSON has cache_age_ms:\n%s", text)
+	}
+
+	// 缓存命中的 JSON 另有缓存时长
+	clock.Advance(3 * time.Second)
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"format": "json", "cache": "auto"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report = nil
+	if err := json.Unmarshal([]byte(text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report["cache_age_ms"] != 3000.0 {
+		t.Errorf("cached JSON cache_age_ms = %v, want 3000", report["cache_age_ms"])
+	}
+
+	// 文本输出按不同的参数缓存，缓存命中时同时给出两者
+	if _, err := tool.Execute(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(3 * time.Second)
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"cache": "auto"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "\n⏱️ 采集耗时: 0.00s（缓存于 3秒前）\n") {
+		t.Errorf("cached text lacks the duration line:\n%s", text)
+	}
+}
@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"time"
+)
+
+// commandTimeout 外部命令的最长执行时间，避免挂起服务器
+const commandTimeout = 3 * time.Second
+
+// commandWaitDelay 超时杀死命令后等待输出管道关闭的最长时间。
+// 命令派生的子进程可能继承并一直持有标准输出，不设置时即使超时也要等到子进程退出
+const commandWaitDelay = 100 * time.Millisecond
+
+// maxCommandOutput 外部命令标准输出的最大保留字节数，超出部分丢弃
+const maxCommandOutput = 1 << 20
+
+// commandRunner 执行外部命令并返回标准输出，便于替换为测试夹具
+type commandRunner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// limitedBuffer 只保留前 limit 个字节的输出缓冲
+type limitedBuffer struct {
+	buffer bytes.Buffer
+	limit  int
+}
+
+// Write 写入输出，超出上限的部分静默丢弃，避免命令因管道关闭而失败
+func (lb *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := lb.limit - lb.buffer.Len(); remaining > 0 {
+		if len(p) > remaining {
+			lb.buffer.Write(p[:remaining])
+		} else {
+			lb.buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// runCommand 使用 commandTimeout 执行外部命令，标准输出最多保留 maxCommandOutput 字节
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return runCommandTimeout(ctx, commandTimeout, name, args...)
+}
+
+// runCommandTimeout 使用指定的超时执行外部命令
+func runCommandTimeout(ctx context.Context, timeout time.Duration, name string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, notFound("未找到命令 %s", name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	output := &limitedBuffer{limit: maxCommandOutput}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = output
+	cmd.WaitDelay = commandWaitDelay
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, newError(ErrTimeout, "%s 执行超时（%s）", name, timeout)
+	}
+	return output.buffer.Bytes(), err
+}
@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// requireCommands 缺少任一命令时跳过测试
+func requireCommands(t *testing.T, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if _, err := exec.LookPath(name); err != nil {
+			t.Skipf("%s not available", name)
+		}
+	}
+}
+
+func TestRunCommandTimeout(t *testing.T) {
+	requireCommands(t, "sh", "sleep")
+
+	// 子进程继承了标准输出时，超时后也不等它退出
+	start := time.Now()
+	_, err := runCommandTimeout(context.Background(), 100*time.Millisecond, "sh", "-c", "sleep 5; :")
+	var toolErr *Error
+	if !errors.As(err, &toolErr) || toolErr.Code != ErrTimeout || toolErr.Message != "sh 执行超时（100ms）" {
+		t.Fatalf("runCommandTimeout() = %v, want ERR_TIMEOUT", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("runCommandTimeout() returned after %s, want shortly after the timeout", elapsed)
+	}
+
+	// 调用方取消时同样立即返回
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start = time.Now()
+	if _, err := runCommand(ctx, "sleep", "5"); err == nil || time.Since(start) > 2*time.Second {
+		t.Errorf("canceled runCommand() = %v after %s", err, time.Since(start))
+	}
+}
+
+func TestRunCommandOutput(t *testing.T) {
+	requireCommands(t, "sh", "head")
+
+	output, err := runCommand(context.Background(), "sh", "-c", "echo ok; echo ignored >&2")
+	if err != nil || string(output) != "ok\n" {
+		t.Fatalf("runCommand() = %q, %v; want only stdout", output, err)
+	}
+
+	// 超出上限的输出被丢弃，命令仍然正常结束
+	output, err = runCommand(context.Background(), "head", "-c", "2000000", "/dev/zero")
+	if err != nil || len(output) != maxCommandOutput {
+		t.Errorf("runCommand() kept %d bytes, %v; want %d", len(output), err, maxCommandOutput)
+	}
+
+	// 不存在的命令返回 ERR_NOT_FOUND，命令失败时返回退出错误
+	var toolErr *Error
+	if _, err := runCommand(context.Background(), "no-such-command-for-tests"); !errors.As(err, &toolErr) || toolErr.Code != ErrNotFound {
+		t.Errorf("missing command = %v, want ErrNotFound", err)
+	}
+	var exitErr *exec.ExitError
+	if output, err := runCommand(context.Background(), "sh", "-c", "echo partial; exit 3"); !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 || !strings.HasPrefix(string(output), "partial") {
+		t.Errorf("failing command = %q, %v", output, err)
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	buffer := &limitedBuffer{limit: 5}
+	for _, chunk := range []string{"abc", "defg", "hij"} {
+		// 超出部分静默丢弃，仍报告全部写入，避免命令因管道错误退出
+		if n, err := buffer.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	if got := buffer.buffer.String(); got != "abcde" {
+		t.Errorf("buffer = %q, want the first 5 bytes", got)
+	}
+}
@@ -0,0 +1,25 @@
+package tools
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// completionCacheTTL 补全候选数据的缓存时长
+const completionCacheTTL = 30 * time.Second
+
+// matchPrefix 筛选以 prefix 开头的候选值（去重并排序）
+func matchPrefix(candidates []string, prefix string) []string {
+	seen := make(map[string]bool)
+	var matched []string
+	for _, candidate := range candidates {
+		if seen[candidate] || !strings.HasPrefix(candidate, prefix) {
+			continue
+		}
+		seen[candidate] = true
+		matched = append(matched, candidate)
+	}
+	sort.Strings(matched)
+	return matched
+}
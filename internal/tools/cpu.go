@@ -1,24 +1,36 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"runtime"
+	"strconv"
+	"strings"
 	"time"
 
 	"mcp-example/internal/types"
-
-	"github.com/shirou/gopsutil/v3/cpu"
 )
 
 // CPUTool CPU 监控工具
 type CPUTool struct {
-	cache types.Cache
+	cache        types.Cache
+	cacheOptions CacheOptions
+	style        OutputStyle
+	platform     string
+	run          commandRunner
+	// statPath /proc/stat 的位置，detailed 时在采样前后读取
+	statPath string
 }
 
 // NewCPUTool 创建新的 CPU 监控工具
-func NewCPUTool(cache types.Cache) *CPUTool {
+func NewCPUTool(cache types.Cache, cacheOptions CacheOptions, style OutputStyle) *CPUTool {
 	return &CPUTool{
-		cache: cache,
+		cache:        cache,
+		cacheOptions: cacheOptions,
+		style:        style,
+		platform:     hostPlatform,
+		run:          runCommand,
+		statPath:     procStatPath,
 	}
 }
 
@@ -32,133 +44,330 @@ func (ct *CPUTool) GetDescription() string {
 	return "获取 CPU 使用率和详细信息"
 }
 
+// GetAnnotations 获取工具注解
+func (ct *CPUTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("CPU 信息")
+}
+
+// cpuArgs cpu_info 的参数
+type cpuArgs struct {
+	Duration string `arg:"duration,enum=1s|5s|10s,default=1s" desc:"监控持续时间 (1s, 5s, 10s)"`
+	Detailed bool   `arg:"detailed,default=false" desc:"是否包含上下文切换、中断速率和运行队列等调度统计（仅 Linux）"`
+	Compact  bool   `arg:"compact,default=false" desc:"是否以紧凑的使用率条网格显示各核心（每行 4 个核心，超过 80%/95% 标记 !/!!）"`
+	fallbackArgs
+	cacheArgs
+	diffArgs
+}
+
 // GetInputSchema 获取输入模式
 func (ct *CPUTool) GetInputSchema() types.InputSchema {
-	return types.InputSchema{
-		Type: "object",
-		Properties: map[string]types.Property{
-			"duration": {
-				Type:        "string",
-				Description: "监控持续时间 (1s, 5s, 10s)",
-				Enum:        []string{"1s", "5s", "10s"},
-				Default:     "1s",
-			},
-			"use_cache": {
-				Type:        "string",
-				Description: "是否使用缓存数据",
-				Enum:        []string{"true", "false"},
-				Default:     "false",
-			},
+	return argsSchema(cpuArgs{})
+}
+
+// Examples 获取调用示例
+func (ct *CPUTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "1 秒采样的总体和各核心使用率",
+			Arguments:   map[string]interface{}{},
+		},
+		{
+			Description: "5 秒采样，附带上下文切换、中断和运行队列统计",
+			Arguments:   map[string]interface{}{"duration": "5s", "detailed": "true"},
+		},
+		{
+			Description: "以紧凑的使用率条网格显示各核心",
+			Arguments:   map[string]interface{}{"compact": "true"},
 		},
 	}
 }
 
+// cpuReport cpu_info 的结构化结果
+type cpuReport struct {
+	types.CPUInfo
+}
+
 // Execute 执行 CPU 监控
-func (ct *CPUTool) Execute(args map[string]interface{}) (string, error) {
-	// 解析参数
-	durationStr, _ := args["duration"].(string)
-	if durationStr == "" {
-		durationStr = "1s"
-	}
-
-	useCacheStr, _ := args["use_cache"].(string)
-	useCache := useCacheStr == "true"
-
-	// 检查缓存
-	cacheKey := fmt.Sprintf("cpu_info_%s", durationStr)
-	if useCache {
-		if cachedData, found := ct.cache.Get(cacheKey); found {
-			if cpuInfo, ok := cachedData.(types.CPUInfo); ok {
-				return ct.formatCPUInfo(cpuInfo, durationStr), nil
+func (ct *CPUTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	text, _, err := ct.ExecuteStructured(ctx, args)
+	return text, err
+}
+
+// ExecuteStructured 执行 CPU 监控，同时返回结构化的 CPU 信息
+func (ct *CPUTool) ExecuteStructured(ctx context.Context, args map[string]interface{}) (string, interface{}, error) {
+	var a cpuArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", nil, err
+	}
+
+	// 静态信息（型号、核心数）长时间缓存，使用率每次采样或按短 TTL 缓存
+	static, err := ct.getCPUStatic(ctx)
+	if err != nil {
+		return "", nil, wrapError("获取 CPU 信息失败", err)
+	}
+
+	// 获取 CPU 使用率（缓存30秒）
+	sample, meta, err := withCache(ctx, ct.cache, ct.cacheOptions.forCall(args), ct.GetName(), 30*time.Second, func(ctx context.Context) (cpuSample, error) {
+		return ct.sampleCPU(ctx, a.Duration, a.Detailed)
+	})
+	if err != nil {
+		return "", nil, wrapError("获取 CPU 信息失败", err)
+	}
+
+	report := cpuReport{CPUInfo: mergeCPUInfo(static, sample)}
+	return cacheHeader(meta) + ct.formatCPUInfo(static, sample, a.Duration, a.Compact) + cacheNote(meta), report, nil
+}
+
+// cpuStatic CPU 型号、核心数等不随时间变化的信息
+type cpuStatic struct {
+	ModelName    string
+	Sockets      int
+	Cores        int32
+	LogicalCores int
+	// Frequency 主频（GHz），0 表示无法获取
+	Frequency float64
+}
+
+// getCPUStatic 获取 CPU 静态信息，cpu.Info() 较慢，结果缓存 staticCacheTTL
+func (ct *CPUTool) getCPUStatic(ctx context.Context) (cpuStatic, error) {
+	static, _, err := withCache(ctx, ct.cache, CacheOptions{Mode: CacheModeAuto}, "static_cpu", staticCacheTTL, func(ctx context.Context) (cpuStatic, error) {
+		var static cpuStatic
+
+		cpuInfos, err := providers.CPU.Info(ctx)
+		if err != nil {
+			return static, fmt.Errorf("获取 CPU 基本信息失败: %w", err)
+		}
+
+		// 物理核心数获取失败时从 cpu.Info 的结果推算
+		physicalCores, _ := providers.CPU.Counts(ctx, false)
+		static = summarizeCPUInfo(cpuInfos, ct.platform, physicalCores)
+		if static.Frequency == 0 {
+			static.Frequency = ct.sysctlFrequency(ctx)
+		}
+		static.LogicalCores = runtime.NumCPU()
+
+		return static, nil
+	})
+	return static, err
+}
+
+// summarizeCPUInfo 汇总 cpu.Info 的各项。Linux 上每个逻辑 CPU 一项：插槽数为不同 PhysicalID 的数量，
+// 物理核心数为不同 (PhysicalID, CoreID) 的数量（没有 CoreID 时使用 physicalCores）；其他平台每个插槽一项，
+// 各项的 Cores 在 Windows 上是逻辑核心数，因此优先使用 physicalCores，为 0 时才累加各项的 Cores。
+// 主频取第一个非 0 的值，Apple Silicon 等平台不提供时为 0
+func summarizeCPUInfo(infos []CPUInfoStat, platform string, physicalCores int) cpuStatic {
+	var static cpuStatic
+	if len(infos) == 0 {
+		static.Cores = int32(physicalCores)
+		return static
+	}
+
+	for _, info := range infos {
+		if static.ModelName == "" {
+			static.ModelName = info.ModelName
+		}
+		if static.Frequency == 0 && info.Mhz > 0 {
+			static.Frequency = info.Mhz / 1000 // 转换为 GHz
+		}
+	}
+
+	if platform == platformLinux {
+		sockets := make(map[string]bool)
+		cores := make(map[string]bool)
+		for _, info := range infos {
+			if info.PhysicalID != "" {
+				sockets[info.PhysicalID] = true
 			}
+			if info.CoreID != "" {
+				cores[info.PhysicalID+"/"+info.CoreID] = true
+			}
+		}
+		static.Sockets = max(len(sockets), 1)
+		static.Cores = int32(len(cores))
+		if static.Cores == 0 {
+			static.Cores = int32(physicalCores)
+		}
+		return static
+	}
+
+	static.Sockets = len(infos)
+	static.Cores = int32(physicalCores)
+	if static.Cores == 0 {
+		for _, info := range infos {
+			static.Cores += info.Cores
 		}
 	}
+	return static
+}
 
-	// 获取 CPU 信息
-	cpuInfo, err := ct.getCPUInfo(durationStr)
+// sysctlFrequency 在 macOS 上通过 sysctl hw.cpufrequency 读取主频（GHz），其他平台或读取失败时返回 0
+func (ct *CPUTool) sysctlFrequency(ctx context.Context) float64 {
+	if ct.platform != platformDarwin {
+		return 0
+	}
+	output, err := ct.run(ctx, "sysctl", "-n", "hw.cpufrequency")
 	if err != nil {
-		return "", fmt.Errorf("获取 CPU 信息失败: %v", err)
+		return 0
+	}
+	hertz, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil || hertz <= 0 {
+		return 0
 	}
+	return hertz / 1e9
+}
 
-	// 缓存结果（缓存30秒）
-	ct.cache.Set(cacheKey, cpuInfo, 30*time.Second)
+// formatFrequency 格式化主频，无法获取时说明原因
+func formatFrequency(ghz float64) string {
+	if ghz <= 0 {
+		return "无法获取（该平台未提供主频信息）"
+	}
+	return fmt.Sprintf("%.2f GHz", ghz)
+}
+
+// Prefetch 预取 CPU 静态信息
+func (ct *CPUTool) Prefetch(ctx context.Context) error {
+	_, err := ct.getCPUStatic(ctx)
+	return err
+}
 
-	return ct.formatCPUInfo(cpuInfo, durationStr), nil
+// cpuSample 一次 CPU 使用率采样的结果
+type cpuSample struct {
+	Usage       types.CPUUsage
+	Scheduler   *types.CPUSchedulerStats
+	Thermal     *types.ThermalState
+	LastUpdated time.Time
 }
 
-// getCPUInfo 获取 CPU 信息
-func (ct *CPUTool) getCPUInfo(durationStr string) (types.CPUInfo, error) {
+// getCPUInfo 获取 CPU 信息（静态信息与使用率采样合并）
+func (ct *CPUTool) getCPUInfo(ctx context.Context, durationStr string, detailed bool) (types.CPUInfo, error) {
 	var cpuInfo types.CPUInfo
 
-	// 解析持续时间
-	duration, err := time.ParseDuration(durationStr)
+	static, err := ct.getCPUStatic(ctx)
 	if err != nil {
-		duration = time.Second
+		return cpuInfo, err
 	}
 
-	// 获取 CPU 基本信息
-	cpuInfos, err := cpu.Info()
+	sample, err := ct.sampleCPU(ctx, durationStr, detailed)
 	if err != nil {
-		return cpuInfo, fmt.Errorf("获取 CPU 基本信息失败: %v", err)
+		return cpuInfo, err
 	}
 
-	if len(cpuInfos) > 0 {
-		cpuInfo.ModelName = cpuInfos[0].ModelName
-		cpuInfo.Cores = cpuInfos[0].Cores
-		cpuInfo.Frequency = cpuInfos[0].Mhz / 1000 // 转换为 GHz
+	return mergeCPUInfo(static, sample), nil
+}
+
+// mergeCPUInfo 合并静态信息与使用率采样
+func mergeCPUInfo(static cpuStatic, sample cpuSample) types.CPUInfo {
+	return types.CPUInfo{
+		ModelName:    static.ModelName,
+		Sockets:      static.Sockets,
+		Cores:        static.Cores,
+		Frequency:    static.Frequency,
+		LogicalCores: static.LogicalCores,
+		Usage:        sample.Usage,
+		Scheduler:    sample.Scheduler,
+		Thermal:      sample.Thermal,
+		LastUpdated:  sample.LastUpdated,
 	}
+}
 
-	cpuInfo.LogicalCores = runtime.NumCPU()
+// sampleCPU 采样 CPU 使用率。
+// detailed 为 true 时在使用率采样前后各读取一次 /proc/stat，复用采样窗口计算调度速率，不额外阻塞。
+func (ct *CPUTool) sampleCPU(ctx context.Context, durationStr string, detailed bool) (cpuSample, error) {
+	var sample cpuSample
+
+	// 解析持续时间
+	duration, err := time.ParseDuration(durationStr)
+	if err != nil {
+		duration = time.Second
+	}
+
+	// 采样前读取调度计数（不支持的平台跳过）
+	var statBefore procStat
+	var statStart time.Time
+	collectScheduler := detailed && ct.platform == platformLinux
+	if collectScheduler {
+		if statBefore, err = readProcStat(ct.statPath); err != nil {
+			collectScheduler = false
+		}
+		statStart = time.Now()
+	}
 
 	// 获取 CPU 使用率
-	cpuPercent, err := cpu.Percent(duration, true)
+	cpuPercent, err := providers.CPU.Percent(ctx, duration, true)
 	if err != nil {
-		return cpuInfo, fmt.Errorf("获取 CPU 使用率失败: %v", err)
+		return sample, fmt.Errorf("获取 CPU 使用率失败: %w", err)
 	}
 
 	// 获取总体 CPU 使用率
-	totalCPU, err := cpu.Percent(duration, false)
+	totalCPU, err := providers.CPU.Percent(ctx, duration, false)
 	if err != nil {
-		return cpuInfo, fmt.Errorf("获取总体 CPU 使用率失败: %v", err)
+		return sample, fmt.Errorf("获取总体 CPU 使用率失败: %w", err)
 	}
 
 	// 设置使用率数据
-	cpuInfo.Usage.PerCore = cpuPercent
+	sample.Usage.PerCore = cpuPercent
 	if len(totalCPU) > 0 {
-		cpuInfo.Usage.Total = totalCPU[0]
+		sample.Usage.Total = totalCPU[0]
 	}
 
-	cpuInfo.LastUpdated = time.Now()
+	if collectScheduler {
+		if statAfter, err := readProcStat(ct.statPath); err == nil {
+			sample.Scheduler = schedulerStats(statBefore, statAfter, time.Since(statStart))
+		}
+	}
+
+	// macOS 上附带温控状态，降频时使用率数据可能偏离平时
+	sample.Thermal = readThermalState(ctx, ct.run)
 
-	return cpuInfo, nil
+	sample.LastUpdated = time.Now()
+
+	return sample, nil
 }
 
-// formatCPUInfo 格式化 CPU 信息输出
-func (ct *CPUTool) formatCPUInfo(cpuInfo types.CPUInfo, durationStr string) string {
+// formatCPUInfo 格式化 CPU 信息输出，合并静态信息和使用率采样
+func (ct *CPUTool) formatCPUInfo(static cpuStatic, sample cpuSample, durationStr string, compact bool) string {
 	var result string
 
 	result += "🖥️  CPU 信息\n"
 	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
-	result += fmt.Sprintf("型号: %s\n", cpuInfo.ModelName)
-	result += fmt.Sprintf("核心数: %d 物理核心, %d 逻辑核心\n", cpuInfo.Cores, cpuInfo.LogicalCores)
-	result += fmt.Sprintf("主频: %.2f GHz\n", cpuInfo.Frequency)
+	result += fmt.Sprintf("型号: %s\n", static.ModelName)
+	if static.Sockets > 1 {
+		result += fmt.Sprintf("核心数: %d 物理核心, %d 逻辑核心（%d 个插槽）\n", static.Cores, static.LogicalCores, static.Sockets)
+	} else {
+		result += fmt.Sprintf("核心数: %d 物理核心, %d 逻辑核心\n", static.Cores, static.LogicalCores)
+	}
+	result += fmt.Sprintf("主频: %s\n", formatFrequency(static.Frequency))
 
 	result += fmt.Sprintf("\n📊 CPU 使用率 (监控时长: %s)\n", durationStr)
 	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
-	result += fmt.Sprintf("总体使用率: %.2f%%\n\n", cpuInfo.Usage.Total)
+	result += fmt.Sprintf("总体使用率: %.2f%%\n\n", sample.Usage.Total)
 
 	result += "各核心使用率:\n"
-	for i, percent := range cpuInfo.Usage.PerCore {
-		result += fmt.Sprintf("  核心 %d: %.2f%%\n", i+1, percent)
+	if compact {
+		result += formatCoreGrid(sample.Usage.PerCore, ct.style.BarWidth)
+	} else {
+		for i, percent := range sample.Usage.PerCore {
+			result += fmt.Sprintf("  核心 %d: %.2f%%\n", i+1, percent)
+		}
+	}
+
+	if scheduler := sample.Scheduler; scheduler != nil {
+		result += "\n🔀 调度统计\n"
+		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		result += fmt.Sprintf("上下文切换: %.0f 次/秒\n", scheduler.ContextSwitchesPerSec)
+		result += fmt.Sprintf("中断: %.0f 次/秒\n", scheduler.InterruptsPerSec)
+		result += fmt.Sprintf("运行队列: %d 个可运行, %d 个阻塞 (I/O 等待)\n", scheduler.ProcsRunning, scheduler.ProcsBlocked)
 	}
 
-	result += fmt.Sprintf("\n📅 更新时间: %s\n", cpuInfo.LastUpdated.Format("2006-01-02 15:04:05"))
+	result += formatThermalState(sample.Thermal)
+
+	result += fmt.Sprintf("\n📅 更新时间: %s\n", sample.LastUpdated.Format("2006-01-02 15:04:05"))
 
 	return result
 }
 
 // GetCPUData 获取 CPU 数据（供其他组件使用）
-func (ct *CPUTool) GetCPUData(duration time.Duration) (types.CPUInfo, error) {
+func (ct *CPUTool) GetCPUData(ctx context.Context, duration time.Duration) (types.CPUInfo, error) {
 	durationStr := duration.String()
-	return ct.getCPUInfo(durationStr)
+	return ct.getCPUInfo(ctx, durationStr, false)
 }
@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxCPUBaselines CPU 时间基线最多保留的进程数，超出时丢弃最久未更新的
+const maxCPUBaselines = 20000
+
+// cpuBaseline 某个进程上一次采集到的累计 CPU 时间
+type cpuBaseline struct {
+	// CreateTime 用于识别 PID 复用：同一 PID 的启动时间变化说明已是另一个进程
+	CreateTime int64
+	// CPUSeconds 用户态和内核态 CPU 时间之和（秒）
+	CPUSeconds float64
+	At         time.Time
+}
+
+// cpuBaselines 在多次调用之间保存各进程的累计 CPU 时间，
+// 再次采集时用两次之间的增量计算区间 CPU 使用率，而不是进程启动以来的平均值，也不需要等待采样
+type cpuBaselines struct {
+	mu      sync.Mutex
+	entries map[int32]cpuBaseline
+	limit   int
+}
+
+// newCPUBaselines 创建最多保留 limit 个进程的基线表
+func newCPUBaselines(limit int) *cpuBaselines {
+	return &cpuBaselines{
+		entries: make(map[int32]cpuBaseline),
+		limit:   limit,
+	}
+}
+
+// observe 记录进程在 now 时的累计 CPU 时间，并返回与上一次记录之间的 CPU 使用率（与 gopsutil 一致，
+// 多核进程可超过 100%）。没有同一进程的上一次记录（首次出现或 PID 已被复用）时 ok 为 false
+func (b *cpuBaselines) observe(pid int32, createTime int64, cpuSeconds float64, now time.Time) (percent float64, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	prev, found := b.entries[pid]
+	b.entries[pid] = cpuBaseline{CreateTime: createTime, CPUSeconds: cpuSeconds, At: now}
+	if !found || prev.CreateTime != createTime {
+		return 0, false
+	}
+
+	elapsed := now.Sub(prev.At).Seconds()
+	if elapsed <= 0 || cpuSeconds < prev.CPUSeconds {
+		return 0, false
+	}
+	return (cpuSeconds - prev.CPUSeconds) / elapsed * 100, true
+}
+
+// prune 删除 seen 中没有的进程（已退出），超出上限时再丢弃最久未更新的记录
+func (b *cpuBaselines) prune(seen map[int32]struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for pid := range b.entries {
+		if _, ok := seen[pid]; !ok {
+			delete(b.entries, pid)
+		}
+	}
+	if len(b.entries) <= b.limit {
+		return
+	}
+
+	pids := make([]int32, 0, len(b.entries))
+	for pid := range b.entries {
+		pids = append(pids, pid)
+	}
+	sort.Slice(pids, func(i, j int) bool {
+		return b.entries[pids[i]].At.Before(b.entries[pids[j]].At)
+	})
+	for _, pid := range pids[:len(pids)-b.limit] {
+		delete(b.entries, pid)
+	}
+}
+
+// size 当前保存的基线数
+func (b *cpuBaselines) size() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.entries)
+}
@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+func TestCPUBaselinesObserve(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	baselines := newCPUBaselines(10)
+
+	// 依次注入累计 CPU 时间
+	steps := []struct {
+		name       string
+		createTime int64
+		cpuSeconds float64
+		at         time.Duration
+		percent    float64
+		ok         bool
+	}{
+		{"first observation", 1000, 10, 0, 0, false},
+		{"one core busy", 1000, 12, 2 * time.Second, 100, true},
+		{"idle", 1000, 12, 4 * time.Second, 0, true},
+		// 多核进程可超过 100%
+		{"two cores busy", 1000, 16, 6 * time.Second, 200, true},
+		{"zero elapsed", 1000, 17, 6 * time.Second, 0, false},
+		// PID 被复用：启动时间不同，不与上一个进程的记录比较
+		{"pid reused", 2000, 1, 8 * time.Second, 0, false},
+		{"after reuse", 2000, 1.5, 9 * time.Second, 50, true},
+		// 累计时间回退（读到了不同的来源）不给出使用率
+		{"went backwards", 2000, 1, 10 * time.Second, 0, false},
+		{"after backwards", 2000, 1.25, 11 * time.Second, 25, true},
+	}
+	for _, s := range steps {
+		percent, ok := baselines.observe(42, s.createTime, s.cpuSeconds, start.Add(s.at))
+		if ok != s.ok || math.Abs(percent-s.percent) > 1e-9 {
+			t.Errorf("%s: observe() = %v, %v; want %v, %v", s.name, percent, ok, s.percent, s.ok)
+		}
+	}
+	if baselines.size() != 1 {
+		t.Errorf("size() = %d, want 1", baselines.size())
+	}
+}
+
+func TestCPUBaselinesPrune(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	baselines := newCPUBaselines(3)
+	for pid := int32(1); pid <= 5; pid++ {
+		baselines.observe(pid, 1, 0, start.Add(time.Duration(pid)*time.Second))
+	}
+
+	// 已退出的进程被删除
+	baselines.prune(map[int32]struct{}{1: {}, 2: {}, 3: {}, 4: {}})
+	if baselines.size() != 3 {
+		t.Fatalf("size() = %d after pruning, want 3", baselines.size())
+	}
+	if _, ok := baselines.entries[5]; ok {
+		t.Error("exited PID 5 was kept")
+	}
+	// 超出上限时丢弃最久未更新的
+	if _, ok := baselines.entries[1]; ok {
+		t.Error("oldest PID 1 was kept over the limit")
+	}
+
+	// 重新出现的 PID 没有记录，不会与已删除的基线比较
+	if _, ok := baselines.observe(5, 1, 100, start.Add(time.Minute)); ok {
+		t.Error("evicted PID 5 still has a baseline")
+	}
+}
+
+// baselineClock 可手动推进的采集时刻
+type baselineClock struct{ now time.Time }
+
+func (c *baselineClock) Now() time.Time { return c.now }
+
+func TestTopProcessesIntervalCPU(t *testing.T) {
+	clock := &baselineClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	busy := &fakeProcess{pid: 100, name: "busy", ppid: 1, cmdline: []string{"busy"}, createTime: 1000, cpuPercent: 3, times: &TimesStat{User: 50, System: 10}}
+	idle := &fakeProcess{pid: 200, name: "idle", ppid: 1, cmdline: []string{"idle"}, createTime: 2000, cpuPercent: 40, times: &TimesStat{User: 5}}
+	provider := newFakeProcessProvider(busy, idle)
+	useFakeProcesses(t, provider)
+
+	tool := NewProcessTool(storage.NewMemoryCache(), CacheOptions{})
+	tool.now = clock.Now
+	top := func() types.ProcessList {
+		t.Helper()
+		text, err := tool.Execute(context.Background(), map[string]interface{}{"sort_by": "cpu", "format": "json", "cache": "fresh"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var list types.ProcessList
+		if err := json.Unmarshal([]byte(text), &list); err != nil {
+			t.Fatal(err)
+		}
+		return list
+	}
+	cpu := func(list types.ProcessList) string {
+		var result []string
+		for _, process := range list.Processes {
+			result = append(result, fmt.Sprintf("%s=%.0f", process.Name, process.CPUPercent))
+		}
+		return strings.Join(result, " ")
+	}
+
+	// 第一次调用没有基线，使用启动以来的平均值
+	list := top()
+	if got := cpu(list); got != "idle=40 busy=3" || list.IntervalCPU != 0 {
+		t.Errorf("first call = %s (%d interval)", got, list.IntervalCPU)
+	}
+	if tool.CPUBaselineCount() != 2 {
+		t.Errorf("CPUBaselineCount() = %d, want 2", tool.CPUBaselineCount())
+	}
+
+	// 5 秒内 busy 使用了 7.5 秒 CPU，idle 没有使用：第二次调用给出区间使用率，不需要等待
+	clock.now = clock.now.Add(5 * time.Second)
+	busy.times = &TimesStat{User: 56, System: 11.5}
+	list = top()
+	if got := cpu(list); got != "busy=150 idle=0" || list.IntervalCPU != 2 {
+		t.Errorf("second call = %s (%d interval)", got, list.IntervalCPU)
+	}
+
+	// idle 退出后 PID 被新进程复用：基线被替换，新进程使用启动以来的平均值
+	clock.now = clock.now.Add(5 * time.Second)
+	idle.createTime, idle.name, idle.cpuPercent, idle.times = 3000, "reused", 7, &TimesStat{User: 1}
+	busy.times = &TimesStat{User: 57, System: 11.5}
+	list = top()
+	if got := cpu(list); got != "busy=20 reused=7" || list.IntervalCPU != 1 {
+		t.Errorf("after PID reuse = %s (%d interval)", got, list.IntervalCPU)
+	}
+
+	// 已退出的进程从基线中删除
+	delete(provider.processes, 100)
+	top()
+	if tool.CPUBaselineCount() != 1 {
+		t.Errorf("CPUBaselineCount() = %d after busy exited, want 1", tool.CPUBaselineCount())
+	}
+
+	stats := NewServerStatsTool(storage.NewMemoryCache(), storage.NewMemoryStorage(), nil, nil, nil, nil, tool.CPUBaselineCount, nil, nil, nil)
+	text, err := stats.Execute(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf("进程 CPU 基线: 1 个进程（上限 %d）\n", maxCPUBaselines); !strings.Contains(text, want) {
+		t.Errorf("server_stats lacks %q:\n%s", want, text)
+	}
+}
@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"mcp-example/internal/storage"
+)
+
+// linuxCPUInfos Linux 上 cpu.Info 的形状：每个逻辑 CPU 一项，各插槽的 CoreID 从 0 开始编号
+func linuxCPUInfos(sockets, coresPerSocket, threadsPerCore int, mhz float64) []CPUInfoStat {
+	var infos []CPUInfoStat
+	for socket := 0; socket < sockets; socket++ {
+		for thread := 0; thread < threadsPerCore; thread++ {
+			for core := 0; core < coresPerSocket; core++ {
+				infos = append(infos, CPUInfoStat{
+					ModelName:  "Intel(R) Xeon(R) Gold 6248",
+					PhysicalID: strconv.Itoa(socket),
+					CoreID:     strconv.Itoa(core),
+					Cores:      1,
+					Mhz:        mhz,
+				})
+			}
+		}
+	}
+	return infos
+}
+
+func TestSummarizeCPUInfo(t *testing.T) {
+	cases := []struct {
+		name          string
+		infos         []CPUInfoStat
+		platform      string
+		physicalCores int
+		want          cpuStatic
+	}{
+		{
+			"single socket with hyper-threading", linuxCPUInfos(1, 4, 2, 2400), platformLinux, 4,
+			cpuStatic{ModelName: "Intel(R) Xeon(R) Gold 6248", Sockets: 1, Cores: 4, Frequency: 2.4},
+		},
+		// 两个插槽的 CoreID 相同，按 (PhysicalID, CoreID) 计数，不采用只统计了一个插槽的 physicalCores
+		{
+			"dual socket", linuxCPUInfos(2, 20, 2, 2500), platformLinux, 20,
+			cpuStatic{ModelName: "Intel(R) Xeon(R) Gold 6248", Sockets: 2, Cores: 40, Frequency: 2.5},
+		},
+		// 容器或部分 ARM 主机不提供拓扑信息时使用 physicalCores
+		{
+			"no topology", []CPUInfoStat{{ModelName: "Neoverse-N1"}, {ModelName: "Neoverse-N1"}}, platformLinux, 2,
+			cpuStatic{ModelName: "Neoverse-N1", Sockets: 1, Cores: 2},
+		},
+		// Apple Silicon 只有一项，不提供主频
+		{
+			"apple silicon", []CPUInfoStat{{ModelName: "Apple M2 Pro", Cores: 12}}, platformDarwin, 12,
+			cpuStatic{ModelName: "Apple M2 Pro", Sockets: 1, Cores: 12},
+		},
+		// Windows 每个插槽一项，各项的 Cores 是逻辑核心数
+		{
+			"windows dual socket", []CPUInfoStat{{ModelName: "Xeon", Cores: 32, Mhz: 2100}, {ModelName: "Xeon", Cores: 32, Mhz: 2100}}, platformWindows, 32,
+			cpuStatic{ModelName: "Xeon", Sockets: 2, Cores: 32, Frequency: 2.1},
+		},
+		{
+			"windows without physical count", []CPUInfoStat{{ModelName: "Xeon", Cores: 8}, {ModelName: "Xeon", Cores: 8}}, platformWindows, 0,
+			cpuStatic{ModelName: "Xeon", Sockets: 2, Cores: 16},
+		},
+		// 主频取第一个非 0 的值
+		{
+			"first entry without frequency", []CPUInfoStat{{ModelName: "EPYC", PhysicalID: "0", CoreID: "0"}, {ModelName: "EPYC", PhysicalID: "0", CoreID: "1", Mhz: 3000}}, platformLinux, 2,
+			cpuStatic{ModelName: "EPYC", Sockets: 1, Cores: 2, Frequency: 3},
+		},
+		{"no entries", nil, platformLinux, 6, cpuStatic{Cores: 6}},
+	}
+	for _, c := range cases {
+		if got := summarizeCPUInfo(c.infos, c.platform, c.physicalCores); got != c.want {
+			t.Errorf("%s: summarizeCPUInfo() = %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestCPUStaticFrequencyFallback(t *testing.T) {
+	useFakeCPU(t, &fakeCPUProvider{infos: []CPUInfoStat{{ModelName: "Apple M1", Cores: 8}}, physicalCores: 8})
+
+	cases := []struct {
+		name     string
+		platform string
+		commands map[string]string
+		want     float64
+	}{
+		// macOS 上主频为 0 时读取 sysctl hw.cpufrequency
+		{"sysctl", platformDarwin, map[string]string{"sysctl": "3200000000\n"}, 3.2},
+		// Apple Silicon 没有 hw.cpufrequency
+		{"sysctl missing", platformDarwin, map[string]string{}, 0},
+		{"sysctl garbage", platformDarwin, map[string]string{"sysctl": "unknown oid\n"}, 0},
+		// 其他平台不调用 sysctl
+		{"linux", platformLinux, map[string]string{"sysctl": "3200000000\n"}, 0},
+	}
+	for _, c := range cases {
+		tool := NewCPUTool(storage.NewMemoryCache(), CacheOptions{}, NewOutputStyle(StylePlain, 0))
+		tool.platform, tool.run = c.platform, fakeCommands(c.commands)
+		static, err := tool.getCPUStatic(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if static.Frequency != c.want || static.Cores != 8 {
+			t.Errorf("%s: static = %+v, want %v GHz", c.name, static, c.want)
+		}
+	}
+}
+
+func TestFormatCPUStatic(t *testing.T) {
+	tool := NewCPUTool(nil, CacheOptions{}, NewOutputStyle(StylePlain, 0))
+
+	text := tool.formatCPUInfo(cpuStatic{ModelName: "Xeon", Sockets: 2, Cores: 40, LogicalCores: 80, Frequency: 2.5}, cpuSample{}, "1s", true)
+	if !strings.Contains(text, "核心数: 40 物理核心, 80 逻辑核心（2 个插槽）\n主频: 2.50 GHz\n") {
+		t.Errorf("dual socket output:\n%s", text)
+	}
+
+	// 单插槽不显示插槽数；主频为 0 时说明无法获取而不是显示 0.00 GHz
+	text = tool.formatCPUInfo(cpuStatic{ModelName: "Apple M2 Pro", Sockets: 1, Cores: 12, LogicalCores: 12}, cpuSample{}, "1s", true)
+	if !strings.Contains(text, "核心数: 12 物理核心, 12 逻辑核心\n主频: 无法获取（该平台未提供主频信息）\n") {
+		t.Errorf("apple silicon output:\n%s", text)
+	}
+}
@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"mcp-example/internal/types"
+)
+
+// ToolLookupFunc 按名称查找当前策略下可用的工具描述
+type ToolLookupFunc func(name string) (types.Tool, bool)
+
+// toolDescription describe_tool 的输出：完整的工具描述和一个示例调用
+type toolDescription struct {
+	types.Tool
+	Example exampleInvocation `json:"example"`
+}
+
+// exampleInvocation 可直接作为 tools/call 参数的示例调用
+type exampleInvocation struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// ToolNotFound 工具不存在或被服务器策略禁用，提示中列出可用工具
+func ToolNotFound(name string, available []string) *Error {
+	err := notFound("工具不存在或被服务器策略禁用: %s", name)
+	err.Hint = "可用工具: " + strings.Join(available, ", ")
+	return err
+}
+
+// DescribeTool 工具自描述工具，返回指定工具的完整参数模式、注解和示例调用
+type DescribeTool struct {
+	lookup ToolLookupFunc
+	names  func() []string
+}
+
+// NewDescribeTool 创建新的工具自描述工具，lookup 和 names 只应返回策略允许的工具
+func NewDescribeTool(lookup ToolLookupFunc, names func() []string) *DescribeTool {
+	return &DescribeTool{
+		lookup: lookup,
+		names:  names,
+	}
+}
+
+// GetName 获取工具名称
+func (dt *DescribeTool) GetName() string {
+	return "describe_tool"
+}
+
+// GetDescription 获取工具描述
+func (dt *DescribeTool) GetDescription() string {
+	return "返回指定工具的完整 JSON Schema（含取值范围和格式约束）、描述、注解和示例调用"
+}
+
+// GetAnnotations 获取工具注解
+func (dt *DescribeTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("工具说明")
+}
+
+// describeToolArgs describe_tool 的参数
+type describeToolArgs struct {
+	strictArgs
+	Name string `arg:"name,required" desc:"要查看的工具名称"`
+}
+
+// GetInputSchema 获取输入模式
+func (dt *DescribeTool) GetInputSchema() types.InputSchema {
+	return argsSchema(describeToolArgs{})
+}
+
+// Examples 获取调用示例
+func (dt *DescribeTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "top_processes 的完整参数模式、注解和示例",
+			Arguments:   map[string]interface{}{"name": "top_processes"},
+		},
+		{
+			Description: "metrics_history 支持的指标和时间范围参数",
+			Arguments:   map[string]interface{}{"name": "metrics_history"},
+		},
+	}
+}
+
+// Complete 为 name 参数补全可用的工具名称
+func (dt *DescribeTool) Complete(ctx context.Context, argName, prefix string) []string {
+	if argName != "name" {
+		return nil
+	}
+
+	var matched []string
+	for _, name := range dt.names() {
+		if strings.HasPrefix(name, prefix) {
+			matched = append(matched, name)
+		}
+	}
+	return matched
+}
+
+// Execute 返回工具描述（JSON 格式）
+func (dt *DescribeTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a describeToolArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+
+	tool, found := dt.lookup(a.Name)
+	if !found {
+		return "", ToolNotFound(a.Name, dt.names())
+	}
+
+	description := toolDescription{
+		Tool: tool,
+		Example: exampleInvocation{
+			Name:      tool.Name,
+			Arguments: exampleArguments(tool.InputSchema),
+		},
+	}
+
+	jsonData, err := json.MarshalIndent(description, "", "  ")
+	if err != nil {
+		return "", wrapError("序列化工具描述失败", err)
+	}
+	return string(jsonData), nil
+}
+
+// exampleArguments 根据参数模式生成示例参数：必需参数和有默认值的参数，
+// 取默认值、第一个枚举值、最小值或占位符
+func exampleArguments(schema types.InputSchema) map[string]interface{} {
+	required := make(map[string]bool, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	arguments := make(map[string]interface{})
+	for _, name := range names {
+		property := schema.Properties[name]
+		switch {
+		case property.Default != nil && property.Default != "":
+			arguments[name] = property.Default
+		case !required[name]:
+			continue
+		case len(property.Enum) > 0:
+			arguments[name] = property.Enum[0]
+		case property.Type == "integer" || property.Type == "number":
+			if property.Minimum != nil {
+				arguments[name] = *property.Minimum
+			} else {
+				arguments[name] = 1
+			}
+		case property.Type == "boolean":
+			arguments[name] = false
+		case property.Type == "array":
+			arguments[name] = []interface{}{}
+		default:
+			arguments[name] = "<" + name + ">"
+		}
+	}
+	return arguments
+}
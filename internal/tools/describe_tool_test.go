@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"mcp-example/internal/types"
+)
+
+// newSampleDescribeTool 只认识 sample 和 strict 两个工具的 describe_tool
+func newSampleDescribeTool() *DescribeTool {
+	described := map[string]types.Tool{
+		"sample": {
+			Name:        "sample",
+			Description: "示例工具",
+			InputSchema: argsSchema(sampleArgs{}),
+			Annotations: &types.ToolAnnotations{Title: "示例", ReadOnlyHint: true},
+		},
+		"strict": {Name: "strict", InputSchema: argsSchema(strictSampleArgs{})},
+	}
+	lookup := func(name string) (types.Tool, bool) {
+		tool, found := described[name]
+		return tool, found
+	}
+	return NewDescribeTool(lookup, func() []string { return []string{"sample", "strict"} })
+}
+
+func TestDescribeToolOutput(t *testing.T) {
+	text, err := newSampleDescribeTool().Execute(context.Background(), map[string]interface{}{"name": "sample"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var described struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		InputSchema struct {
+			Properties map[string]map[string]interface{} `json:"properties"`
+			Required   []string                          `json:"required"`
+		} `json:"inputSchema"`
+		Annotations map[string]interface{} `json:"annotations"`
+		Example     struct {
+			Name      string                 `json:"name"`
+			Arguments map[string]interface{} `json:"arguments"`
+		} `json:"example"`
+	}
+	if err := json.Unmarshal([]byte(text), &described); err != nil {
+		t.Fatal(err)
+	}
+	if described.Name != "sample" || described.Description != "示例工具" || described.Annotations["title"] != "示例" || described.Annotations["readOnlyHint"] != true {
+		t.Errorf("described = %+v", described)
+	}
+	if !slices.Equal(described.InputSchema.Required, []string{"name"}) {
+		t.Errorf("required = %v", described.InputSchema.Required)
+	}
+	limit := described.InputSchema.Properties["limit"]
+	if limit["type"] != "integer" || limit["minimum"] != float64(1) || limit["maximum"] != float64(100) || limit["default"] != float64(10) {
+		t.Errorf("limit = %v", limit)
+	}
+	if described.InputSchema.Properties["window"]["pattern"] != "^[0-9]+[smh]$" {
+		t.Errorf("window = %v", described.InputSchema.Properties["window"])
+	}
+	if items, _ := described.InputSchema.Properties["labels"]["items"].(map[string]interface{}); items["type"] != "string" {
+		t.Errorf("labels = %v", described.InputSchema.Properties["labels"])
+	}
+
+	// 示例调用包含必需参数的占位符和有默认值的参数，且能通过参数校验
+	want := map[string]interface{}{
+		"name":    "<name>",
+		"mode":    "fast",
+		"verbose": "false",
+		"limit":   float64(10),
+		"ratio":   0.5,
+		"window":  "5m",
+	}
+	if described.Example.Name != "sample" || !reflect.DeepEqual(described.Example.Arguments, want) {
+		t.Errorf("example = %+v, want %v", described.Example, want)
+	}
+	if _, err := ValidateArguments(argsSchema(sampleArgs{}), described.Example.Arguments); err != nil {
+		t.Errorf("example does not validate: %v", err)
+	}
+
+	// 拒绝未声明参数的工具在模式中声明 additionalProperties: false
+	text, err = newSampleDescribeTool().Execute(context.Background(), map[string]interface{}{"name": "strict"})
+	if err != nil || !strings.Contains(text, `"additionalProperties": false`) {
+		t.Errorf("strict tool = %s, %v", text, err)
+	}
+}
+
+func TestDescribeToolNotFound(t *testing.T) {
+	tool := newSampleDescribeTool()
+	_, err := tool.Execute(context.Background(), map[string]interface{}{"name": "missing"})
+	var toolErr *Error
+	if !errors.As(err, &toolErr) || toolErr.Code != ErrNotFound || toolErr.Hint != "可用工具: sample, strict" {
+		t.Errorf("missing tool = %#v", err)
+	}
+
+	if got := tool.Complete(context.Background(), "name", "s"); !slices.Equal(got, []string{"sample", "strict"}) {
+		t.Errorf("Complete(s) = %v", got)
+	}
+	if got := tool.Complete(context.Background(), "name", "st"); !slices.Equal(got, []string{"strict"}) {
+		t.Errorf("Complete(st) = %v", got)
+	}
+	if got := tool.Complete(context.Background(), "format", ""); got != nil {
+		t.Errorf("Complete(format) = %v", got)
+	}
+}
+
+func TestExampleArguments(t *testing.T) {
+	schema := types.InputSchema{
+		Type: "object",
+		Properties: map[string]types.Property{
+			"pid":      {Type: "integer", Minimum: types.Float64(1)},
+			"count":    {Type: "integer"},
+			"mode":     {Type: "string", Enum: []string{"a", "b"}},
+			"force":    {Type: "boolean"},
+			"paths":    {Type: "array"},
+			"optional": {Type: "string"},
+		},
+		Required: []string{"pid", "count", "mode", "force", "paths"},
+	}
+	want := map[string]interface{}{"pid": 1.0, "count": 1, "mode": "a", "force": false, "paths": []interface{}{}}
+	if got := exampleArguments(schema); !reflect.DeepEqual(got, want) {
+		t.Errorf("exampleArguments() = %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,194 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// DiffArgument tools/call 中要求与上一次结果比较的参数，由处理器取出后移除，不会传给工具
+const DiffArgument = "diff_previous"
+
+// diffKeyPrefix 用于比较的上一次结果在存储中的键前缀，完整的键为 diff_<工具名>
+const diffKeyPrefix = "diff_"
+
+// 比较时忽略的波动：CPU 使用率波动较大，单独使用更高的阈值
+const (
+	diffCPUPercentNoise = 5.0
+	diffPercentNoise    = 1.0
+	diffBytesNoise      = 100 * 1024 * 1024
+	// diffConnectionNoise 连接总数变化超过该数量且超过 20% 时才报告
+	diffConnectionNoise = 10
+)
+
+// diffArgs 支持比较的工具共用的参数，调用前由处理器通过 TakeDiffArgument 取出，不会传给工具
+type diffArgs struct {
+	DiffPrevious bool `arg:"diff_previous,default=false" desc:"是否在文本结果后附加与上一次同样参数调用（同样指定了 diff_previous）的结果相比的变化，仅用于文本输出"`
+}
+
+// Differ 支持 diff_previous 的结构化结果：与上一次的结果比较，每条值得注意的变化返回一行说明，
+// 低于噪声阈值的波动不报告。previous 由上一次结果的 JSON 解码而来，类型与接收者相同
+type Differ interface {
+	Diff(previous Differ) []string
+}
+
+// TakeDiffArgument 工具的参数模式声明了 diff_previous 时，从参数中取出并移除它（返回新的参数集合）。
+// 未声明的工具原样返回，由参数校验报告未知参数
+func TakeDiffArgument(schema types.InputSchema, args map[string]interface{}) (map[string]interface{}, bool, error) {
+	if _, declared := schema.Properties[DiffArgument]; !declared {
+		return args, false, nil
+	}
+	value, found := args[DiffArgument]
+	if !found {
+		return args, false, nil
+	}
+
+	var diff bool
+	switch value {
+	case true, "true":
+		diff = true
+	case false, "false", nil:
+	default:
+		return nil, false, argumentError(DiffArgument, "参数 %s 应为 true 或 false", DiffArgument)
+	}
+	if format, _ := args["format"].(string); diff && format != "" && format != "text" {
+		toolErr := argumentError(DiffArgument, "%s 只用于文本输出", DiffArgument)
+		toolErr.Hint = "JSON、CSV 和 Markdown 输出可自行比较两次结果，或去掉 format 参数"
+		return nil, false, toolErr
+	}
+
+	remaining := make(map[string]interface{}, len(args))
+	for name, value := range args {
+		if name != DiffArgument {
+			remaining[name] = value
+		}
+	}
+	return remaining, diff, nil
+}
+
+// diffRecord 存储中保存的上一次结果，Key 为调用参数的缓存键，只有参数相同的调用才会与之比较
+type diffRecord struct {
+	Key  string          `json:"key"`
+	At   time.Time       `json:"at"`
+	Data json.RawMessage `json:"data"`
+}
+
+// DiffStore 为 diff_previous 保存每个工具最近一次的结构化结果。
+// 与降级数据相同，每个工具只保存一条记录，参数不同的调用会替换比较基准
+type DiffStore struct {
+	storage types.DataStorage
+	mutex   sync.Mutex
+	now     func() time.Time
+}
+
+// NewDiffStore 创建比较基准存储
+func NewDiffStore(dataStorage types.DataStorage) *DiffStore {
+	return &DiffStore{
+		storage: dataStorage,
+		now:     time.Now,
+	}
+}
+
+// Compare 将本次的结构化结果与上一次参数相同的结果比较，保存本次结果作为下一次的基准，
+// 返回附加到文本结果之后的“变化”部分
+func (ds *DiffStore) Compare(tool string, args map[string]interface{}, structured interface{}) string {
+	current, ok := structured.(Differ)
+	if !ok {
+		return formatDiffSection("⚠️ 本次结果不支持比较\n")
+	}
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	key := newCacheKey(tool, args).Key
+	storageKey := diffKeyPrefix + tool
+	at := ds.now()
+
+	var body string
+	var record diffRecord
+	switch {
+	case !ds.storage.Exists(storageKey):
+		body = "首次比较，已保存本次结果作为比较基准\n"
+	case ds.storage.Load(storageKey, &record) != nil:
+		body = "上一次的结果无法读取，已保存本次结果作为新的比较基准\n"
+	case record.Key != key:
+		body = "上一次的结果使用了不同的参数，已保存本次结果作为新的比较基准\n"
+	default:
+		previous, err := decodeDiffer(record.Data, current)
+		if err != nil {
+			body = "上一次的结果无法解析，已保存本次结果作为新的比较基准\n"
+			break
+		}
+		body = fmt.Sprintf("与 %s 的结果相比（间隔 %s）:\n", record.At.Format("15:04:05"), at.Sub(record.At).Round(time.Second))
+		body += formatDiffChanges(current.Diff(previous))
+	}
+
+	data, err := json.Marshal(structured)
+	if err == nil {
+		err = ds.storage.Save(storageKey, diffRecord{Key: key, At: at, Data: data})
+	}
+	if err != nil {
+		slog.Debug("保存比较基准失败", "tool", tool, "error", err)
+	}
+	return formatDiffSection(body)
+}
+
+// decodeDiffer 按 current 的类型解码上一次的结果
+func decodeDiffer(data json.RawMessage, current Differ) (Differ, error) {
+	previous := reflect.New(reflect.TypeOf(current))
+	if err := json.Unmarshal(data, previous.Interface()); err != nil {
+		return nil, err
+	}
+	return previous.Elem().Interface().(Differ), nil
+}
+
+// formatDiffChanges 格式化变化列表
+func formatDiffChanges(changes []string) string {
+	if len(changes) == 0 {
+		return "无明显变化（低于噪声阈值的波动已忽略）\n"
+	}
+
+	var result string
+	for _, change := range changes {
+		result += fmt.Sprintf("• %s\n", change)
+	}
+	return result
+}
+
+// formatDiffSection 格式化附加到文本结果之后的“变化”部分
+func formatDiffSection(body string) string {
+	var result string
+	result += "\n🔀 变化\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result += body
+	return result
+}
+
+// formatPercentChange 格式化百分比的变化，如 "12.3% → 45.6%（+33.3）"
+func formatPercentChange(previous, current float64) string {
+	return fmt.Sprintf("%.1f%% → %.1f%%（%+.1f）", previous, current, current-previous)
+}
+
+// formatBytesChange 格式化字节数的变化，如 "10.20 GB → 9.10 GB（-1.10 GB）"
+func formatBytesChange(previous, current uint64) string {
+	sign := "+"
+	delta := current - previous
+	if current < previous {
+		sign = "-"
+		delta = previous - current
+	}
+	return fmt.Sprintf("%s → %s（%s%s）", formatBytes(previous), formatBytes(current), sign, formatBytes(delta))
+}
+
+// bytesChanged 字节数的变化是否超过噪声阈值
+func bytesChanged(previous, current uint64, noise uint64) bool {
+	if current > previous {
+		return current-previous >= noise
+	}
+	return previous-current >= noise
+}
@@ -0,0 +1,265 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+
+	"mcp-example/internal/types"
+)
+
+// Diff 比较 CPU 总使用率、各核心使用率和阻塞的进程数
+func (report cpuReport) Diff(previous Differ) []string {
+	prev, ok := previous.(cpuReport)
+	if !ok {
+		return nil
+	}
+
+	var changes []string
+	if math.Abs(report.Usage.Total-prev.Usage.Total) >= diffCPUPercentNoise {
+		changes = append(changes, "CPU 总使用率 "+formatPercentChange(prev.Usage.Total, report.Usage.Total))
+	}
+
+	// 核心数不同（如 CPU 热插拔）时不逐核比较
+	if len(report.Usage.PerCore) == len(prev.Usage.PerCore) {
+		changed, maxCore, maxDelta := 0, 0, 0.0
+		for i, usage := range report.Usage.PerCore {
+			delta := usage - prev.Usage.PerCore[i]
+			if math.Abs(delta) < diffCPUPercentNoise {
+				continue
+			}
+			changed++
+			if math.Abs(delta) > math.Abs(maxDelta) {
+				maxCore, maxDelta = i, delta
+			}
+		}
+		if changed > 0 {
+			changes = append(changes, fmt.Sprintf("%d 个核心的使用率变化超过 %.0f 个百分点，变化最大的是核心 %d（%+.1f）",
+				changed, diffCPUPercentNoise, maxCore, maxDelta))
+		}
+	}
+
+	if report.Scheduler != nil && prev.Scheduler != nil && report.Scheduler.ProcsBlocked != prev.Scheduler.ProcsBlocked {
+		changes = append(changes, fmt.Sprintf("阻塞的进程数 %d → %d", prev.Scheduler.ProcsBlocked, report.Scheduler.ProcsBlocked))
+	}
+	return changes
+}
+
+// Diff 比较内存和交换空间使用率、OOM 风险等级以及内存压力等级（仅 macOS）
+func (report memoryReport) Diff(previous Differ) []string {
+	prev, ok := previous.(memoryReport)
+	if !ok {
+		return nil
+	}
+
+	var changes []string
+	if math.Abs(report.UsedPercent-prev.UsedPercent) >= diffPercentNoise {
+		changes = append(changes, fmt.Sprintf("内存使用率 %s，可用 %s", formatPercentChange(prev.UsedPercent, report.UsedPercent),
+			formatBytesChange(prev.Available, report.Available)))
+	}
+	if math.Abs(report.Swap.UsedPercent-prev.Swap.UsedPercent) >= diffPercentNoise {
+		changes = append(changes, "交换空间使用率 "+formatPercentChange(prev.Swap.UsedPercent, report.Swap.UsedPercent))
+	}
+
+	prevLevel, level := "", ""
+	if prev.OOMRisk != nil {
+		prevLevel = prev.OOMRisk.Level
+	}
+	if report.OOMRisk != nil {
+		level = report.OOMRisk.Level
+	}
+	if prevLevel != "" && level != "" && prevLevel != level {
+		changes = append(changes, fmt.Sprintf("OOM 风险 %s → %s", prevLevel, level))
+	}
+	if report.Pressure != nil && prev.Pressure != nil && report.Pressure.Level != prev.Pressure.Level {
+		changes = append(changes, fmt.Sprintf("内存压力 %s → %s", prev.Pressure.Level, report.Pressure.Level))
+	}
+	return changes
+}
+
+// Diff 比较分区的挂载、卸载、可用空间和只读状态，本次被跳过的分区不视为卸载
+func (report diskReport) Diff(previous Differ) []string {
+	prev, ok := previous.(diskReport)
+	if !ok {
+		return nil
+	}
+
+	previousPartitions := make(map[string]types.DiskPartition, len(prev.Partitions))
+	for _, partition := range prev.Partitions {
+		previousPartitions[partition.Mountpoint] = partition
+	}
+
+	var changes []string
+	for _, partition := range report.Partitions {
+		old, found := previousPartitions[partition.Mountpoint]
+		delete(previousPartitions, partition.Mountpoint)
+		if !found {
+			changes = append(changes, fmt.Sprintf("新挂载 %s（%s，%s，已使用 %.1f%%）",
+				partition.Mountpoint, partition.Fstype, formatBytes(partition.Total), partition.UsedPercent))
+			continue
+		}
+		if bytesChanged(old.Free, partition.Free, diffBytesNoise) || math.Abs(partition.UsedPercent-old.UsedPercent) >= diffPercentNoise {
+			changes = append(changes, fmt.Sprintf("%s 可用空间 %s，使用率 %s",
+				partition.Mountpoint, formatBytesChange(old.Free, partition.Free), formatPercentChange(old.UsedPercent, partition.UsedPercent)))
+		}
+		if partition.ReadOnly != old.ReadOnly {
+			state := "变为只读"
+			if !partition.ReadOnly {
+				state = "恢复为读写"
+			}
+			changes = append(changes, fmt.Sprintf("%s %s", partition.Mountpoint, state))
+		}
+	}
+	// 本次跳过的挂载点仍然存在，只是没有使用量
+	skipped := make(map[string]bool, len(report.SkippedMounts))
+	for _, mount := range report.SkippedMounts {
+		skipped[mount.Mountpoint] = true
+	}
+	for _, mountpoint := range sortedKeys(previousPartitions) {
+		if skipped[mountpoint] {
+			changes = append(changes, fmt.Sprintf("%s 本次查询失败或无响应，已跳过", mountpoint))
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("已卸载 %s", mountpoint))
+	}
+	return changes
+}
+
+// Diff 比较进程列表（或进程组）：进入和离开列表的条目、CPU 和内存变化明显的条目。
+// PID 相同但启动时间不同的进程视为不同的进程
+func (report processReport) Diff(previous Differ) []string {
+	prev, ok := previous.(processReport)
+	if !ok {
+		return nil
+	}
+	if report.GroupBy != groupByNone && report.GroupBy != "" {
+		return diffProcessGroups(prev.Groups, report.Groups)
+	}
+
+	type processKey struct {
+		PID        int32
+		CreateTime int64
+	}
+	previousProcesses := make(map[processKey]types.ProcessInfo, len(prev.Processes))
+	for _, process := range prev.Processes {
+		previousProcesses[processKey{process.PID, process.CreateTime}] = process
+	}
+
+	var changes []string
+	for _, process := range report.Processes {
+		key := processKey{process.PID, process.CreateTime}
+		old, found := previousProcesses[key]
+		delete(previousProcesses, key)
+		if !found {
+			changes = append(changes, fmt.Sprintf("进入列表: %s (PID %d)，CPU %.1f%%，内存 %s",
+				process.Name, process.PID, process.CPUPercent, formatBytes(process.MemoryBytes)))
+			continue
+		}
+		changes = append(changes, diffUsage(fmt.Sprintf("%s (PID %d)", process.Name, process.PID),
+			old.CPUPercent, process.CPUPercent, old.MemoryBytes, process.MemoryBytes)...)
+	}
+	for _, process := range prev.Processes {
+		if _, left := previousProcesses[processKey{process.PID, process.CreateTime}]; left {
+			changes = append(changes, fmt.Sprintf("离开列表: %s (PID %d)", process.Name, process.PID))
+		}
+	}
+	return changes
+}
+
+// diffProcessGroups 比较进程组：按组名匹配，额外报告进程数的变化
+func diffProcessGroups(previous, current []types.ProcessGroup) []string {
+	previousGroups := make(map[string]types.ProcessGroup, len(previous))
+	for _, group := range previous {
+		previousGroups[group.Key] = group
+	}
+
+	var changes []string
+	for _, group := range current {
+		old, found := previousGroups[group.Key]
+		delete(previousGroups, group.Key)
+		if !found {
+			changes = append(changes, fmt.Sprintf("进入列表: %s（%d 个进程），CPU %.1f%%，内存 %s",
+				group.Key, group.Count, group.CPUPercent, formatBytes(group.MemoryBytes)))
+			continue
+		}
+		if group.Count != old.Count {
+			changes = append(changes, fmt.Sprintf("%s 进程数 %d → %d", group.Key, old.Count, group.Count))
+		}
+		changes = append(changes, diffUsage(group.Key, old.CPUPercent, group.CPUPercent, old.MemoryBytes, group.MemoryBytes)...)
+	}
+	for _, group := range previous {
+		if _, left := previousGroups[group.Key]; left {
+			changes = append(changes, fmt.Sprintf("离开列表: %s", group.Key))
+		}
+	}
+	return changes
+}
+
+// diffUsage 比较同一进程（或进程组）的 CPU 和内存占用
+func diffUsage(name string, previousCPU, currentCPU float64, previousMemory, currentMemory uint64) []string {
+	var changes []string
+	if math.Abs(currentCPU-previousCPU) >= diffCPUPercentNoise {
+		changes = append(changes, fmt.Sprintf("%s CPU %s", name, formatPercentChange(previousCPU, currentCPU)))
+	}
+	if bytesChanged(previousMemory, currentMemory, diffBytesNoise) {
+		changes = append(changes, fmt.Sprintf("%s 内存 %s", name, formatBytesChange(previousMemory, currentMemory)))
+	}
+	return changes
+}
+
+// Diff 比较网络接口的出现和消失、错误和丢包计数的增长以及连接总数
+func (report networkReport) Diff(previous Differ) []string {
+	prev, ok := previous.(networkReport)
+	if !ok {
+		return nil
+	}
+
+	previousInterfaces := make(map[string]types.NetworkInterface, len(prev.Interfaces))
+	for _, iface := range prev.Interfaces {
+		previousInterfaces[iface.Name] = iface
+	}
+
+	var changes []string
+	for _, iface := range report.Interfaces {
+		old, found := previousInterfaces[iface.Name]
+		delete(previousInterfaces, iface.Name)
+		if !found {
+			changes = append(changes, fmt.Sprintf("新接口 %s", iface.Name))
+			continue
+		}
+		// 计数器重置后无法得知增量，只说明重置
+		if iface.ResetCount != old.ResetCount || iface.BytesRecv < old.BytesRecv || iface.BytesSent < old.BytesSent {
+			changes = append(changes, fmt.Sprintf("%s 的计数器已重置", iface.Name))
+			continue
+		}
+		for _, counter := range []struct {
+			label             string
+			previous, current uint64
+		}{
+			{"接收错误", old.ErrorsIn, iface.ErrorsIn},
+			{"发送错误", old.ErrorsOut, iface.ErrorsOut},
+			{"接收丢包", old.DropIn, iface.DropIn},
+			{"发送丢包", old.DropOut, iface.DropOut},
+		} {
+			if counter.current > counter.previous {
+				changes = append(changes, fmt.Sprintf("%s %s +%d（共 %d）", iface.Name, counter.label, counter.current-counter.previous, counter.current))
+			}
+		}
+	}
+	for _, name := range sortedKeys(previousInterfaces) {
+		changes = append(changes, fmt.Sprintf("接口 %s 已消失", name))
+	}
+
+	delta := report.Connections.Total - prev.Connections.Total
+	if abs(delta) >= diffConnectionNoise && float64(abs(delta)) >= 0.2*float64(prev.Connections.Total) {
+		changes = append(changes, fmt.Sprintf("连接总数 %d → %d（%+d）", prev.Connections.Total, report.Connections.Total, delta))
+	}
+	return changes
+}
+
+// abs 整数的绝对值
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,225 @@
+package tools
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+const gb = 1024 * 1024 * 1024
+
+// partition 测试用的分区，free 以 GB 计
+func partition(mountpoint string, freeGB uint64, usedPercent float64) types.DiskPartition {
+	return types.DiskPartition{Device: "/dev/sda1", Mountpoint: mountpoint, Fstype: "ext4", Total: 100 * gb, Free: freeGB * gb, UsedPercent: usedPercent}
+}
+
+// assertChanges 检查变化列表，want 中的每一项是对应变化的前缀
+func assertChanges(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("changes = %q, want %d changes starting with %q", got, len(want), want)
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("change %d = %q, want prefix %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDiskDiff(t *testing.T) {
+	previous := diskReport{types.DiskInfo{Partitions: []types.DiskPartition{
+		partition("/", 50, 50), partition("/data", 20, 80), partition("/mnt/nfs", 10, 90), partition("/old", 5, 95),
+	}}}
+
+	// 没有变化，以及低于噪声阈值的波动
+	quiet := previous
+	quiet.Partitions = slices.Clone(previous.Partitions)
+	quiet.Partitions[0].Free -= 50 * 1024 * 1024
+	quiet.Partitions[0].UsedPercent += 0.5
+	assertChanges(t, quiet.Diff(previous))
+
+	current := diskReport{types.DiskInfo{
+		Partitions:    []types.DiskPartition{partition("/", 50, 50), partition("/data", 10, 90), partition("/backup", 400, 20)},
+		SkippedMounts: []types.SkippedMount{{Mountpoint: "/mnt/nfs", Fstype: "nfs", Reason: "timeout"}},
+	}}
+	current.Partitions[0].ReadOnly = true
+	assertChanges(t, current.Diff(previous),
+		"/ 变为只读",
+		"/data 可用空间 20.00 GB → 10.00 GB（-10.00 GB），使用率 80.0% → 90.0%（+10.0）",
+		"新挂载 /backup（ext4，100.00 GB，已使用 20.0%）",
+		"/mnt/nfs 本次查询失败或无响应，已跳过",
+		"已卸载 /old",
+	)
+
+	// 恢复读写同样报告
+	assertChanges(t, previous.Diff(diskReport{types.DiskInfo{Partitions: []types.DiskPartition{func() types.DiskPartition {
+		p := partition("/", 50, 50)
+		p.ReadOnly = true
+		return p
+	}()}}}), "/ 恢复为读写", "新挂载 /data", "新挂载 /mnt/nfs", "新挂载 /old")
+}
+
+func TestProcessDiff(t *testing.T) {
+	process := func(pid int32, name string, createTime int64, cpuPercent float64, memoryGB uint64) types.ProcessInfo {
+		return types.ProcessInfo{PID: pid, Name: name, CreateTime: createTime, CPUPercent: cpuPercent, MemoryBytes: memoryGB * gb}
+	}
+	previous := processReport{ProcessList: types.ProcessList{Processes: []types.ProcessInfo{
+		process(1, "init", 100, 0, 0), process(42, "worker", 200, 10, 1), process(50, "cron", 300, 1, 0),
+	}}}
+	current := processReport{ProcessList: types.ProcessList{Processes: []types.ProcessInfo{
+		process(1, "init", 100, 3, 0),
+		// PID 相同但启动时间不同：原进程已退出，PID 被新进程复用
+		process(42, "worker", 900, 10, 1),
+		process(50, "cron", 300, 30, 3),
+	}}}
+	assertChanges(t, current.Diff(previous),
+		"进入列表: worker (PID 42)，CPU 10.0%",
+		"cron (PID 50) CPU 1.0% → 30.0%（+29.0）",
+		"cron (PID 50) 内存 0 B → 3.00 GB",
+		"离开列表: worker (PID 42)",
+	)
+
+	groups := func(groups ...types.ProcessGroup) processReport {
+		return processReport{ProcessList: types.ProcessList{GroupBy: "name", Groups: groups}}
+	}
+	assertChanges(t, groups(
+		types.ProcessGroup{Key: "nginx", Count: 6, CPUPercent: 12},
+		types.ProcessGroup{Key: "redis", Count: 1},
+	).Diff(groups(
+		types.ProcessGroup{Key: "nginx", Count: 4, CPUPercent: 2},
+		types.ProcessGroup{Key: "postgres", Count: 8},
+	)),
+		"nginx 进程数 4 → 6",
+		"nginx CPU 2.0% → 12.0%",
+		"进入列表: redis（1 个进程）",
+		"离开列表: postgres",
+	)
+}
+
+func TestNetworkDiff(t *testing.T) {
+	previous := networkReport{NetworkInfo: types.NetworkInfo{
+		Interfaces: []types.NetworkInterface{
+			{Name: "eth0", BytesRecv: 1000, BytesSent: 1000, ErrorsIn: 1, DropOut: 5},
+			{Name: "eth1", BytesRecv: 5000, BytesSent: 5000},
+			{Name: "docker0"},
+		},
+		Connections: types.NetworkConnections{Total: 100},
+	}}
+	current := networkReport{NetworkInfo: types.NetworkInfo{
+		Interfaces: []types.NetworkInterface{
+			{Name: "eth0", BytesRecv: 2000, BytesSent: 2000, ErrorsIn: 4, DropOut: 5},
+			// 计数器变小：接口重建或计数器回绕，不报告增量
+			{Name: "eth1", BytesRecv: 10, BytesSent: 10, ErrorsIn: 100},
+			{Name: "wg0"},
+		},
+		Connections: types.NetworkConnections{Total: 125},
+	}}
+	assertChanges(t, current.Diff(previous),
+		"eth0 接收错误 +3（共 4）",
+		"eth1 的计数器已重置",
+		"新接口 wg0",
+		"接口 docker0 已消失",
+		"连接总数 100 → 125（+25）",
+	)
+
+	// 连接数变化需同时超过绝对值和 20% 才报告
+	current.Interfaces = previous.Interfaces
+	current.Connections.Total = 115
+	assertChanges(t, current.Diff(previous))
+}
+
+func TestCPUAndMemoryDiff(t *testing.T) {
+	previous := cpuReport{types.CPUInfo{Usage: types.CPUUsage{Total: 20, PerCore: []float64{10, 20, 30, 20}}}}
+	current := cpuReport{types.CPUInfo{Usage: types.CPUUsage{Total: 23, PerCore: []float64{10, 60, 20, 23}}}}
+	assertChanges(t, current.Diff(previous), "2 个核心的使用率变化超过 5 个百分点，变化最大的是核心 1（+40.0）")
+	// 核心数变化时不逐核比较
+	current.Usage.PerCore = current.Usage.PerCore[:2]
+	current.Usage.Total = 40
+	assertChanges(t, current.Diff(previous), "CPU 总使用率 20.0% → 40.0%（+20.0）")
+
+	memory := memoryReport{types.MemoryInfo{UsedPercent: 50, Available: 8 * gb, OOMRisk: &types.OOMRisk{Level: "low"}}}
+	assertChanges(t, memoryReport{types.MemoryInfo{UsedPercent: 75, Available: 4 * gb, OOMRisk: &types.OOMRisk{Level: "high"}}}.Diff(memory),
+		"内存使用率 50.0% → 75.0%（+25.0），可用 8.00 GB → 4.00 GB（-4.00 GB）",
+		"OOM 风险 low → high",
+	)
+	// 上一次没有 OOM 风险评估时不比较等级
+	assertChanges(t, memoryReport{types.MemoryInfo{UsedPercent: 50, Available: 8 * gb, OOMRisk: &types.OOMRisk{Level: "high"}}}.Diff(memoryReport{types.MemoryInfo{UsedPercent: 50.5, Available: 8 * gb}}))
+}
+
+func TestDiffStoreCompare(t *testing.T) {
+	dataStorage := storage.NewMemoryStorage()
+	diffs := NewDiffStore(dataStorage)
+	now := fixedTime
+	diffs.now = func() time.Time { return now }
+	args := map[string]interface{}{"mountpoint": "/"}
+	report := func(free uint64) diskReport {
+		return diskReport{types.DiskInfo{Partitions: []types.DiskPartition{partition("/", free, 100-float64(free))}}}
+	}
+
+	// 没有上一次的结果时保存基准
+	if got := diffs.Compare("disk_info", args, report(50)); !strings.Contains(got, "首次比较") {
+		t.Fatalf("first compare = %q", got)
+	}
+	now = now.Add(90 * time.Second)
+	got := diffs.Compare("disk_info", args, report(50))
+	if !strings.Contains(got, "与 "+fixedTime.Format("15:04:05")+" 的结果相比（间隔 1m30s）") || !strings.Contains(got, "无明显变化") {
+		t.Fatalf("unchanged compare = %q", got)
+	}
+	now = now.Add(time.Minute)
+	if got := diffs.Compare("disk_info", args, report(30)); !strings.Contains(got, "• / 可用空间 50.00 GB → 30.00 GB") {
+		t.Fatalf("changed compare = %q", got)
+	}
+
+	// 参数不同时替换基准，之后以新参数比较
+	other := map[string]interface{}{"mountpoint": "/data"}
+	if got := diffs.Compare("disk_info", other, report(30)); !strings.Contains(got, "不同的参数") {
+		t.Fatalf("compare with other arguments = %q", got)
+	}
+	if got := diffs.Compare("disk_info", args, report(30)); !strings.Contains(got, "不同的参数") {
+		t.Fatalf("baseline was not replaced: %q", got)
+	}
+
+	// 基准损坏时重新保存，不支持比较的结果不影响基准
+	dataStorage.Save(diffKeyPrefix+"disk_info", diffRecord{Key: newCacheKey("disk_info", args).Key, At: now, Data: []byte(`{"partitions": "broken"}`)})
+	if got := diffs.Compare("disk_info", args, report(30)); !strings.Contains(got, "无法解析") {
+		t.Fatalf("compare with a corrupt baseline = %q", got)
+	}
+	if got := diffs.Compare("disk_info", args, map[string]interface{}{"free": 1}); !strings.Contains(got, "不支持比较") {
+		t.Fatalf("compare with a non-Differ result = %q", got)
+	}
+	if got := diffs.Compare("disk_info", args, report(30)); !strings.Contains(got, "无明显变化") {
+		t.Fatalf("compare after a non-Differ result = %q", got)
+	}
+}
+
+func TestTakeDiffArgument(t *testing.T) {
+	schema := NewDiskTool(nil, CacheOptions{}, PartitionFilter{}, NewOutputStyle(StyleRich, 0), nil).GetInputSchema()
+
+	args, diff, err := TakeDiffArgument(schema, map[string]interface{}{"diff_previous": "true", "mountpoint": "/"})
+	if err != nil || !diff || len(args) != 1 || args["mountpoint"] != "/" {
+		t.Fatalf("TakeDiffArgument = %v, %v, %v, want diff_previous removed", args, diff, err)
+	}
+	if args, diff, _ := TakeDiffArgument(schema, map[string]interface{}{"diff_previous": false}); diff || len(args) != 0 {
+		t.Errorf("diff_previous=false = %v, %v", args, diff)
+	}
+	// 未声明 diff_previous 的工具原样返回，由参数校验报告
+	undeclared := map[string]interface{}{"diff_previous": true}
+	if args, diff, err := TakeDiffArgument(types.InputSchema{Type: "object"}, undeclared); err != nil || diff || len(args) != 1 {
+		t.Errorf("undeclared diff_previous = %v, %v, %v", args, diff, err)
+	}
+
+	var toolErr *Error
+	for _, bad := range []map[string]interface{}{
+		{"diff_previous": "yes"},
+		{"diff_previous": true, "format": "json"},
+	} {
+		if _, _, err := TakeDiffArgument(schema, bad); !errors.As(err, &toolErr) || toolErr.Code != ErrBadArgument {
+			t.Errorf("TakeDiffArgument(%v) = %v, want ErrBadArgument", bad, err)
+		}
+	}
+}
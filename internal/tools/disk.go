@@ -1,23 +1,54 @@
 package tools
 
 import (
+	"context"
 	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"mcp-example/internal/types"
-
-	"github.com/shirou/gopsutil/v3/disk"
 )
 
 // DiskTool 磁盘监控工具
 type DiskTool struct {
-	cache types.Cache
+	cache        types.Cache
+	cacheOptions CacheOptions
+	filter       PartitionFilter
+	style        OutputStyle
+	storage      types.DataStorage
+	platform     string
+	// mountsPath Linux 挂载表的位置，每次采集时读取最新的挂载选项
+	mountsPath string
+	// usageTimeout 查询单个挂载点使用量的时限，pendingUsage 为尚未返回的查询（见 usageWithTimeout）
+	usageTimeout time.Duration
+	// usage 查询挂载点的使用量，为 nil 时使用当前的磁盘数据来源；测试中替换以模拟阻塞在 statfs 中的挂载点
+	usage        func(ctx context.Context, path string) (*UsageStat, error)
+	pendingMutex sync.Mutex
+	pendingUsage map[string]bool
 }
 
-// NewDiskTool 创建新的磁盘监控工具
-func NewDiskTool(cache types.Cache) *DiskTool {
+// defaultUsageTimeout 查询单个挂载点使用量的默认时限
+const defaultUsageTimeout = 2 * time.Second
+
+// diskTrendWindow 分区用量变化和写满预测使用的历史窗口
+const diskTrendWindow = 7 * 24 * time.Hour
+
+// NewDiskTool 创建新的磁盘监控工具，dataStorage 为后台采集的历史，用于各分区的用量变化和写满预测
+func NewDiskTool(cache types.Cache, cacheOptions CacheOptions, filter PartitionFilter, style OutputStyle, dataStorage types.DataStorage) *DiskTool {
 	return &DiskTool{
-		cache: cache,
+		cache:        cache,
+		cacheOptions: cacheOptions,
+		filter:       filter,
+		style:        style,
+		storage:      dataStorage,
+		platform:     hostPlatform,
+		mountsPath:   procMountsPath,
+		usageTimeout: defaultUsageTimeout,
+		pendingUsage: make(map[string]bool),
 	}
 }
 
@@ -31,89 +62,207 @@ func (dt *DiskTool) GetDescription() string {
 	return "获取磁盘使用情况"
 }
 
+// GetAnnotations 获取工具注解
+func (dt *DiskTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("磁盘信息")
+}
+
+// diskArgs disk_info 的参数。include_trend 未提供时与 true 不同（没有历史时不提示），因此按字符串解码
+type diskArgs struct {
+	ShowAll      bool   `arg:"show_all,default=false" desc:"是否显示所有分区（包括系统分区）"`
+	Compact      bool   `arg:"compact,default=false" desc:"是否以使用率条紧凑显示各分区"`
+	IncludeTrend string `arg:"include_trend,enum=true|false" desc:"是否根据后台采集的历史显示各分区 7 天内的用量变化和预计写满时间（有历史时默认 true）"`
+	Format       string `arg:"format,enum=text|csv|markdown,default=text" desc:"输出格式: text、csv（原始数值，适合电子表格）或 markdown（表格）"`
+	fallbackArgs
+	cacheArgs
+	diffArgs
+}
+
 // GetInputSchema 获取输入模式
 func (dt *DiskTool) GetInputSchema() types.InputSchema {
-	return types.InputSchema{
-		Type: "object",
-		Properties: map[string]types.Property{
-			"show_all": {
-				Type:        "string",
-				Description: "是否显示所有分区（包括系统分区）",
-				Enum:        []string{"true", "false"},
-				Default:     "false",
-			},
-			"use_cache": {
-				Type:        "string",
-				Description: "是否使用缓存数据",
-				Enum:        []string{"true", "false"},
-				Default:     "false",
-			},
+	return argsSchema(diskArgs{})
+}
+
+// Examples 获取调用示例
+func (dt *DiskTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "物理分区的容量和使用率",
+			Arguments:   map[string]interface{}{},
+		},
+		{
+			Description: "包含 tmpfs、overlay 等虚拟文件系统在内的全部分区",
+			Arguments:   map[string]interface{}{"show_all": "true"},
 		},
 	}
 }
 
+// diskReport disk_info 的结构化结果
+type diskReport struct {
+	types.DiskInfo
+}
+
 // Execute 执行磁盘监控
-func (dt *DiskTool) Execute(args map[string]interface{}) (string, error) {
-	// 解析参数
-	showAllStr, _ := args["show_all"].(string)
-	showAll := showAllStr == "true"
-
-	useCacheStr, _ := args["use_cache"].(string)
-	useCache := useCacheStr == "true"
-
-	// 检查缓存
-	cacheKey := fmt.Sprintf("disk_info_%t", showAll)
-	if useCache {
-		if cachedData, found := dt.cache.Get(cacheKey); found {
-			if diskInfo, ok := cachedData.(types.DiskInfo); ok {
-				return dt.formatDiskInfo(diskInfo), nil
-			}
-		}
+func (dt *DiskTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	text, _, err := dt.ExecuteStructured(ctx, args)
+	return text, err
+}
+
+// ExecuteStructured 执行磁盘监控，同时返回结构化的分区信息
+func (dt *DiskTool) ExecuteStructured(ctx context.Context, args map[string]interface{}) (string, interface{}, error) {
+	var a diskArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", nil, err
 	}
 
-	// 获取磁盘信息
-	diskInfo, err := dt.getDiskInfo(showAll)
+	// 获取磁盘信息（缓存30秒）
+	diskInfo, meta, err := withCache(ctx, dt.cache, dt.cacheOptions.forCall(args), dt.GetName(), 30*time.Second, func(ctx context.Context) (types.DiskInfo, error) {
+		return dt.getDiskInfo(ctx, a.ShowAll)
+	})
 	if err != nil {
-		return "", fmt.Errorf("获取磁盘信息失败: %v", err)
+		return "", nil, wrapError("获取磁盘信息失败", err)
 	}
 
-	// 缓存结果（缓存30秒）
-	dt.cache.Set(cacheKey, diskInfo, 30*time.Second)
+	if isTableFormat(a.Format) {
+		text, err := renderTable(partitionTable(diskInfo.Partitions), a.Format)
+		return text, diskReport{DiskInfo: diskInfo}, err
+	}
 
-	return dt.formatDiskInfo(diskInfo), nil
+	// 用量趋势基于历史样本，不随磁盘信息缓存
+	var trends map[string]usageProjection
+	if a.IncludeTrend != "false" {
+		trends = dt.getUsageTrends(time.Now())
+	}
+
+	result := cacheHeader(meta) + dt.formatDiskInfo(diskInfo, a.Compact, trends)
+	if a.IncludeTrend == "true" && len(trends) == 0 {
+		result += "💡 没有可用的历史采样，请通过 --collect-interval 启用后台采集\n"
+	}
+	return result + cacheNote(meta), diskReport{DiskInfo: diskInfo}, nil
+}
+
+// getUsageTrends 按挂载点计算 diskTrendWindow 内的用量投影，没有历史时返回空集合
+func (dt *DiskTool) getUsageTrends(now time.Time) map[string]usageProjection {
+	if dt.storage == nil {
+		return nil
+	}
+	samples, _ := loadHistory(dt.storage, now.Add(-diskTrendWindow), now)
+	if len(samples) == 0 {
+		return nil
+	}
+
+	trends := make(map[string]usageProjection)
+	for mountpoint := range samples[len(samples)-1].DiskPercent {
+		trends[mountpoint] = projectUsage(extractMetric(samples, MetricDiskPercent, mountpoint), minProjectionSamples)
+	}
+	return trends
+}
+
+// formatUsageTrend 格式化分区的用量变化，如 "7天变化: +12.40 GB, 预计 ~23 天后写满"。
+// 变化量按使用率的变化和当前总容量换算
+func formatUsageTrend(projection usageProjection, total uint64) string {
+	change := projection.ChangePercent / 100 * float64(total)
+	sign := "+"
+	if change < 0 {
+		sign = "-"
+	}
+	line := fmt.Sprintf("%s变化: %s%s", formatProjectionWindow(projection.Newest.Sub(projection.Oldest)), sign, formatBytes(uint64(math.Abs(change))))
+
+	switch {
+	case !projection.Meaningful:
+		return line + fmt.Sprintf("（%s，写满预测无意义）", projection.Reason)
+	case projection.Clamped:
+		return line + fmt.Sprintf(", 预计超过 %d 天后写满", maxProjectionDays)
+	default:
+		return line + fmt.Sprintf(", 预计 ~%.0f 天后写满", math.Ceil(*projection.DaysToFull))
+	}
+}
+
+// getPartitions 获取需要展示的分区列表（设备、挂载点、文件系统）。
+// 挂载点很少变化，枚举和过滤结果缓存 staticCacheTTL，每次调用只需查询使用量。
+func (dt *DiskTool) getPartitions(ctx context.Context, showAll bool) ([]PartitionStat, error) {
+	cacheOptions := CacheOptions{Mode: CacheModeAuto, Args: map[string]interface{}{"all": showAll}}
+	partitions, _, err := withCache(ctx, dt.cache, cacheOptions, "static_disk_partitions", staticCacheTTL, func(ctx context.Context) ([]PartitionStat, error) {
+		partitions, err := providers.Disk.Partitions(ctx, showAll)
+		if err != nil {
+			return nil, fmt.Errorf("获取磁盘分区失败: %w", err)
+		}
+
+		// 过滤一些不需要显示的分区，避免对其查询使用量
+		if showAll {
+			return partitions, nil
+		}
+
+		// 配置了始终显示的规则时，还需要从完整列表中找出 tmpfs 等默认不枚举的分区
+		var all []PartitionStat
+		if dt.filter.hasOverrides() {
+			all, err = providers.Disk.Partitions(ctx, true)
+			if err != nil {
+				all = nil
+			}
+		}
+		return dt.filter.Apply(partitions, all), nil
+	})
+	return partitions, err
+}
+
+// Prefetch 预取分区列表
+func (dt *DiskTool) Prefetch(ctx context.Context) error {
+	_, err := dt.getPartitions(ctx, false)
+	return err
 }
 
 // getDiskInfo 获取磁盘信息
-func (dt *DiskTool) getDiskInfo(showAll bool) (types.DiskInfo, error) {
+func (dt *DiskTool) getDiskInfo(ctx context.Context, showAll bool) (types.DiskInfo, error) {
 	var diskInfo types.DiskInfo
 
-	// 获取磁盘分区
-	partitions, err := disk.Partitions(showAll)
+	// 获取磁盘分区（静态数据，长时间缓存）
+	partitions, err := dt.getPartitions(ctx, showAll)
 	if err != nil {
-		return diskInfo, fmt.Errorf("获取磁盘分区失败: %v", err)
+		return diskInfo, err
 	}
 
-	for _, partition := range partitions {
-		// 获取分区使用情况
-		usage, err := disk.Usage(partition.Mountpoint)
-		if err != nil {
-			// 跳过无法访问的分区
+	// 分区列表会缓存，而文件系统出错后可能随时被重新挂载为只读，Linux 上每次都从挂载表读取最新的挂载选项，
+	// 读取失败或挂载表中没有的分区使用枚举分区时得到的选项
+	var mountOptions map[string][]string
+	if dt.platform == platformLinux {
+		mountOptions, _ = readMountOptions(dt.mountsPath)
+	}
+
+	// 各分区并发查询使用量，无响应或无法访问的分区跳过并记录原因
+	usages, skipped, err := dt.collectUsage(ctx, partitions)
+	if err != nil {
+		return diskInfo, err
+	}
+	diskInfo.SkippedMounts = skipped
+
+	for i, partition := range partitions {
+		usage := usages[i]
+		if usage == nil {
 			continue
 		}
 
-		// 过滤一些不需要显示的分区
-		if !showAll && dt.shouldSkipPartition(partition.Mountpoint, partition.Fstype) {
-			continue
+		opts := partition.Opts
+		if current, found := mountOptions[partition.Mountpoint]; found {
+			opts = current
 		}
+		readOnly := slices.Contains(opts, "ro")
 
 		diskPartition := types.DiskPartition{
-			Device:      partition.Device,
-			Mountpoint:  partition.Mountpoint,
-			Fstype:      partition.Fstype,
-			Total:       usage.Total,
-			Used:        usage.Used,
-			Free:        usage.Free,
-			UsedPercent: usage.UsedPercent,
+			Device:           partition.Device,
+			Mountpoint:       partition.Mountpoint,
+			Fstype:           partition.Fstype,
+			Total:            usage.Total,
+			Used:             usage.Used,
+			Free:             usage.Free,
+			UsedPercent:      usage.UsedPercent,
+			Opts:             opts,
+			ReadOnly:         readOnly,
+			ExpectedReadOnly: readOnly && dt.filter.ExpectedReadOnly(partition.Mountpoint, partition.Fstype),
+		}
+		// 同一 APFS 容器中的卷共享空间，记录容器以便总计中只计一次
+		if dt.platform == platformDarwin {
+			diskPartition.APFSContainer = apfsContainer(partition.Device, partition.Fstype)
 		}
 
 		diskInfo.Partitions = append(diskInfo.Partitions, diskPartition)
@@ -124,59 +273,58 @@ func (dt *DiskTool) getDiskInfo(showAll bool) (types.DiskInfo, error) {
 	return diskInfo, nil
 }
 
-// shouldSkipPartition 判断是否应该跳过某个分区
-func (dt *DiskTool) shouldSkipPartition(mountpoint, fstype string) bool {
-	// 跳过一些系统分区和虚拟文件系统
-	skipMountpoints := []string{
-		"/dev", "/proc", "/sys", "/run", "/boot/efi",
-		"/snap", "/var/snap", "/tmp", "/dev/shm",
-	}
+// partitionHeader 磁盘分区表的表头
+var partitionHeader = fmt.Sprintf("%-20s %-10s %-12s %-12s %-12s %-10s\n",
+	"挂载点", "文件系统", "总大小", "已使用", "可用", "使用率")
 
-	skipFstypes := []string{
-		"tmpfs", "devtmpfs", "sysfs", "proc", "devfs",
-		"squashfs", "overlay", "aufs", "fuse",
-	}
+// diskLineSize 磁盘信息输出每行的预估字节数，用于预留输出缓冲区
+const diskLineSize = 100
 
-	// 检查挂载点
-	for _, skip := range skipMountpoints {
-		if mountpoint == skip {
-			return true
-		}
-	}
+// formatDiskInfo 格式化磁盘信息输出
+func (dt *DiskTool) formatDiskInfo(diskInfo types.DiskInfo, compact bool, trends map[string]usageProjection) string {
+	result := getOutput((len(diskInfo.Partitions)*2 + len(diskInfo.SkippedMounts) + 10) * diskLineSize)
+
+	result.WriteString("💽 磁盘信息\n")
+	result.WriteString(separatorLine)
 
-	// 检查文件系统类型
-	for _, skip := range skipFstypes {
-		if fstype == skip {
-			return true
+	if mountpoints := unexpectedReadOnly(diskInfo.Partitions); len(mountpoints) > 0 {
+		fmt.Fprintf(result, "🚨 %d 个分区意外以只读方式挂载: %s\n", len(mountpoints), strings.Join(mountpoints, ", "))
+		result.WriteString("   通常是文件系统出错后被内核重新挂载为只读，写入会失败，请检查 dmesg 中的文件系统错误\n\n")
+	}
+	if lines := describeSkippedMounts(diskInfo.SkippedMounts); len(lines) > 0 {
+		for _, line := range lines {
+			result.WriteString("⚠️ " + line + "\n")
 		}
+		result.WriteString("\n")
 	}
 
-	return false
-}
-
-// formatDiskInfo 格式化磁盘信息输出
-func (dt *DiskTool) formatDiskInfo(diskInfo types.DiskInfo) string {
-	var result string
-
-	result += "💽 磁盘信息\n"
-	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
-
 	if len(diskInfo.Partitions) == 0 {
-		result += "未找到可用的磁盘分区\n"
+		result.WriteString("未找到可用的磁盘分区\n")
+	} else if compact {
+		for _, partition := range diskInfo.Partitions {
+			// 截断过长的挂载点
+			mountpoint := fitColumn(partition.Mountpoint, 20)
+
+			fmt.Fprintf(result, "%s %s %s / %s\n",
+				mountpoint,
+				formatUsageBar(partition.UsedPercent, dt.style.BarWidth),
+				formatBytes(partition.Used),
+				formatBytes(partition.Total),
+			)
+			result.WriteString(formatReadOnly(partition))
+			if projection, found := trends[partition.Mountpoint]; found {
+				fmt.Fprintf(result, "  %s\n", formatUsageTrend(projection, partition.Total))
+			}
+		}
 	} else {
-		result += fmt.Sprintf("%-20s %-10s %-12s %-12s %-12s %-10s\n",
-			"挂载点", "文件系统", "总大小", "已使用", "可用", "使用率")
-		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		result.WriteString(partitionHeader)
+		result.WriteString(separatorLine)
 
-		var totalSize, totalUsed, totalFree uint64
 		for _, partition := range diskInfo.Partitions {
 			// 截断过长的挂载点
-			mountpoint := partition.Mountpoint
-			if len(mountpoint) > 20 {
-				mountpoint = mountpoint[:17] + "..."
-			}
+			mountpoint := fitColumn(partition.Mountpoint, 20)
 
-			result += fmt.Sprintf("%-20s %-10s %-12s %-12s %-12s %-10.1f%%\n",
+			fmt.Fprintf(result, "%s %-10s %-12s %-12s %-12s %-10.1f%%",
 				mountpoint,
 				partition.Fstype,
 				formatBytes(partition.Total),
@@ -184,45 +332,95 @@ func (dt *DiskTool) formatDiskInfo(diskInfo types.DiskInfo) string {
 				formatBytes(partition.Free),
 				partition.UsedPercent,
 			)
-
-			// 累计总计
-			totalSize += partition.Total
-			totalUsed += partition.Used
-			totalFree += partition.Free
+			if dt.style.ShowBars() {
+				result.WriteString(" " + renderBar(partition.UsedPercent, dt.style.BarWidth))
+			}
+			result.WriteString("\n")
+			result.WriteString(formatReadOnly(partition))
+			if projection, found := trends[partition.Mountpoint]; found {
+				fmt.Fprintf(result, "  %s\n", formatUsageTrend(projection, partition.Total))
+			}
 		}
 
-		// 显示总计
+		// 显示总计（同一 APFS 容器的空间只计一次）
 		if len(diskInfo.Partitions) > 1 {
-			result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
-			totalUsedPercent := float64(totalUsed) / float64(totalSize) * 100
-			result += fmt.Sprintf("%-20s %-10s %-12s %-12s %-12s %-10.1f%%\n",
+			totals := sumPartitions(diskInfo.Partitions)
+			result.WriteString(separatorLine)
+			totalUsedPercent := float64(totals.Used) / float64(totals.Total) * 100
+			fmt.Fprintf(result, "%-20s %-10s %-12s %-12s %-12s %-10.1f%%\n",
 				"总计",
 				"-",
-				formatBytes(totalSize),
-				formatBytes(totalUsed),
-				formatBytes(totalFree),
+				formatBytes(totals.Total),
+				formatBytes(totals.Used),
+				formatBytes(totals.Free),
 				totalUsedPercent,
 			)
+			for _, container := range totals.SharedContainers {
+				fmt.Fprintf(result, "💡 APFS 容器 %s 中的 %d 个卷共享 %s 空间，总计中只计一次\n",
+					container.Name, container.Volumes, formatBytes(container.Total))
+			}
+		}
+	}
+
+	fmt.Fprintf(result, "\n📅 更新时间: %s\n", diskInfo.LastUpdated.Format("2006-01-02 15:04:05"))
+
+	return putOutput(result)
+}
+
+// partitionTable 分区列表的表格形式（csv、markdown 输出）
+func partitionTable(partitions []types.DiskPartition) table {
+	rows := table{columns: []tableColumn{
+		{"mountpoint", "挂载点"}, {"fstype", "文件系统"}, {"total_bytes", "总大小"}, {"used_bytes", "已使用"},
+		{"free_bytes", "可用"}, {"used_percent", "使用率"}, {"read_only", "只读"},
+	}}
+	for _, partition := range partitions {
+		usedPercent := floatCell(partition.UsedPercent)
+		usedPercent.text = fmt.Sprintf("%.1f%%", partition.UsedPercent)
+		readOnly := tableCell{raw: strconv.FormatBool(partition.ReadOnly)}
+		if partition.ReadOnly {
+			readOnly.text = "是"
 		}
+		rows.addRow(textCell(partition.Mountpoint), textCell(partition.Fstype), bytesCell(partition.Total), bytesCell(partition.Used),
+			bytesCell(partition.Free), usedPercent, readOnly)
 	}
+	return rows
+}
 
-	result += fmt.Sprintf("\n📅 更新时间: %s\n", diskInfo.LastUpdated.Format("2006-01-02 15:04:05"))
+// unexpectedReadOnly 意外以只读方式挂载的分区的挂载点
+func unexpectedReadOnly(partitions []types.DiskPartition) []string {
+	var mountpoints []string
+	for _, partition := range partitions {
+		if partition.ReadOnly && !partition.ExpectedReadOnly {
+			mountpoints = append(mountpoints, partition.Mountpoint)
+		}
+	}
+	return mountpoints
+}
 
-	return result
+// formatReadOnly 只读挂载的分区下方的说明行，读写挂载时返回空字符串
+func formatReadOnly(partition types.DiskPartition) string {
+	switch {
+	case !partition.ReadOnly:
+		return ""
+	case partition.ExpectedReadOnly:
+		return "  🔒 只读挂载（属于正常情况）\n"
+	default:
+		return "  🚨 只读挂载（意外）\n"
+	}
 }
 
 // GetDiskData 获取磁盘数据（供其他组件使用）
-func (dt *DiskTool) GetDiskData(showAll bool) (types.DiskInfo, error) {
-	return dt.getDiskInfo(showAll)
+func (dt *DiskTool) GetDiskData(ctx context.Context, showAll bool) (types.DiskInfo, error) {
+	return dt.getDiskInfo(ctx, showAll)
 }
 
 // GetDiskUsageByPath 获取指定路径的磁盘使用情况
-func (dt *DiskTool) GetDiskUsageByPath(path string) (types.DiskPartition, error) {
+func (dt *DiskTool) GetDiskUsageByPath(ctx context.Context, path string) (types.DiskPartition, error) {
 	var partition types.DiskPartition
 
-	usage, err := disk.Usage(path)
+	usage, err := providers.Disk.Usage(ctx, path)
 	if err != nil {
-		return partition, fmt.Errorf("获取路径 %s 的磁盘使用情况失败: %v", path, err)
+		return partition, fmt.Errorf("获取路径 %s 的磁盘使用情况失败: %w", path, err)
 	}
 
 	partition = types.DiskPartition{
@@ -239,10 +437,10 @@ func (dt *DiskTool) GetDiskUsageByPath(path string) (types.DiskPartition, error)
 }
 
 // GetDiskIOStats 获取磁盘 I/O 统计信息
-func (dt *DiskTool) GetDiskIOStats() (map[string]interface{}, error) {
-	ioStats, err := disk.IOCounters()
+func (dt *DiskTool) GetDiskIOStats(ctx context.Context) (map[string]interface{}, error) {
+	ioStats, err := providers.Disk.IOCounters(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("获取磁盘 I/O 统计失败: %v", err)
+		return nil, fmt.Errorf("获取磁盘 I/O 统计失败: %w", err)
 	}
 
 	result := make(map[string]interface{})
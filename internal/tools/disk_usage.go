@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// 跳过挂载点的原因
+const (
+	// SkipReasonTimeout 查询使用量超时（statfs 阻塞，如失联的 NFS 或 FUSE 挂载）
+	SkipReasonTimeout = "timeout"
+	// SkipReasonError 查询使用量失败（如权限不足）
+	SkipReasonError = "error"
+)
+
+// errUsagePending 同一挂载点上一次的使用量查询仍未返回
+var errUsagePending = errors.New("上一次查询仍未返回")
+
+// WithUsageTimeout 设置查询单个挂载点使用量的时限，不大于 0 时保持默认值
+func (dt *DiskTool) WithUsageTimeout(timeout time.Duration) *DiskTool {
+	if timeout > 0 {
+		dt.usageTimeout = timeout
+	}
+	return dt
+}
+
+// collectUsage 并发查询各分区的使用量，每个挂载点单独限时。返回的使用量与 partitions 一一对应
+// （与完成顺序无关），超时或失败的分区为 nil 并按原顺序记录在 skipped 中。ctx 取消时返回 ctx 的错误
+func (dt *DiskTool) collectUsage(ctx context.Context, partitions []PartitionStat) ([]*UsageStat, []types.SkippedMount, error) {
+	usages := make([]*UsageStat, len(partitions))
+	errs := make([]error, len(partitions))
+
+	var wg sync.WaitGroup
+	for i, partition := range partitions {
+		wg.Add(1)
+		go func(i int, mountpoint string) {
+			defer wg.Done()
+			usages[i], errs[i] = dt.usageWithTimeout(ctx, mountpoint)
+		}(i, partition.Mountpoint)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var skipped []types.SkippedMount
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		mount := types.SkippedMount{Mountpoint: partitions[i].Mountpoint, Fstype: partitions[i].Fstype, Reason: SkipReasonError, Detail: err.Error()}
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, errUsagePending) {
+			mount.Reason = SkipReasonTimeout
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			mount.Detail = fmt.Sprintf("超过 %s 没有响应", dt.usageTimeout)
+		}
+		skipped = append(skipped, mount)
+	}
+	return usages, skipped, nil
+}
+
+// usageWithTimeout 查询挂载点的使用量，最多等待 usageTimeout，超时返回 context.DeadlineExceeded。
+// 阻塞在 statfs 中的查询无法中断，会在后台继续直到返回；在此之前同一挂载点的查询直接返回 errUsagePending，
+// 避免每次调用都为无响应的挂载点新增一个阻塞的 goroutine
+func (dt *DiskTool) usageWithTimeout(ctx context.Context, mountpoint string) (*UsageStat, error) {
+	dt.pendingMutex.Lock()
+	if dt.pendingUsage[mountpoint] {
+		dt.pendingMutex.Unlock()
+		return nil, errUsagePending
+	}
+	dt.pendingUsage[mountpoint] = true
+	dt.pendingMutex.Unlock()
+
+	usageCtx, cancel := context.WithTimeout(ctx, dt.usageTimeout)
+	defer cancel()
+
+	type result struct {
+		usage *UsageStat
+		err   error
+	}
+	// 查询可能在本函数返回后继续，启动前取出数据来源
+	query := dt.usage
+	if query == nil {
+		query = providers.Disk.Usage
+	}
+	done := make(chan result, 1)
+	go func() {
+		usage, err := query(usageCtx, mountpoint)
+
+		dt.pendingMutex.Lock()
+		delete(dt.pendingUsage, mountpoint)
+		dt.pendingMutex.Unlock()
+		done <- result{usage, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.usage, r.err
+	case <-usageCtx.Done():
+		return nil, usageCtx.Err()
+	}
+}
+
+// describeSkippedMounts 跳过的挂载点的说明，无响应和读取失败的分别一行，没有跳过时返回 nil
+func describeSkippedMounts(skipped []types.SkippedMount) []string {
+	var timedOut, failed []string
+	for _, mount := range skipped {
+		if mount.Reason == SkipReasonTimeout {
+			timedOut = append(timedOut, mount.Mountpoint)
+		} else {
+			failed = append(failed, fmt.Sprintf("%s（%s）", mount.Mountpoint, mount.Detail))
+		}
+	}
+
+	var lines []string
+	if len(timedOut) > 0 {
+		lines = append(lines, fmt.Sprintf("跳过 %d 个无响应的挂载点: %s", len(timedOut), strings.Join(timedOut, ", ")))
+	}
+	if len(failed) > 0 {
+		lines = append(lines, fmt.Sprintf("跳过 %d 个无法读取的挂载点: %s", len(failed), strings.Join(failed, ", ")))
+	}
+	return lines
+}
@@ -0,0 +1,229 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+// blockingUsage 模拟阻塞在 statfs 中的挂载点：blocked 中的挂载点忽略 ctx，直到 release 被调用才返回；
+// 其他挂载点随机延迟后返回，使完成顺序与分区顺序无关
+type blockingUsage struct {
+	mutex   sync.Mutex
+	blocked map[string]chan struct{}
+	failing map[string]error
+	calls   map[string]int
+}
+
+func newBlockingUsage(blocked ...string) *blockingUsage {
+	bu := &blockingUsage{blocked: make(map[string]chan struct{}), failing: make(map[string]error), calls: make(map[string]int)}
+	for _, mountpoint := range blocked {
+		bu.blocked[mountpoint] = make(chan struct{})
+	}
+	return bu
+}
+
+func (bu *blockingUsage) usage(ctx context.Context, path string) (*UsageStat, error) {
+	bu.mutex.Lock()
+	bu.calls[path]++
+	release := bu.blocked[path]
+	err := bu.failing[path]
+	bu.mutex.Unlock()
+
+	if release != nil {
+		<-release
+	} else {
+		time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &UsageStat{Path: path, Total: 100 * gb, Used: 10 * gb, Free: 90 * gb, UsedPercent: 10}, nil
+}
+
+// release 让阻塞在 mountpoint 上的查询返回
+func (bu *blockingUsage) release(mountpoint string) {
+	bu.mutex.Lock()
+	defer bu.mutex.Unlock()
+	close(bu.blocked[mountpoint])
+	delete(bu.blocked, mountpoint)
+}
+
+// calledTimes 查询 mountpoint 的次数
+func (bu *blockingUsage) calledTimes(mountpoint string) int {
+	bu.mutex.Lock()
+	defer bu.mutex.Unlock()
+	return bu.calls[mountpoint]
+}
+
+// waitPending 等待 mountpoint 上后台的查询返回
+func waitPending(t *testing.T, tool *DiskTool, mountpoint string) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		tool.pendingMutex.Lock()
+		pending := tool.pendingUsage[mountpoint]
+		tool.pendingMutex.Unlock()
+		if !pending {
+			return
+		}
+	}
+	t.Fatalf("usage query for %s still pending", mountpoint)
+}
+
+func TestCollectUsage(t *testing.T) {
+	var partitions []PartitionStat
+	for i := 0; i < 20; i++ {
+		partitions = append(partitions, PartitionStat{Mountpoint: fmt.Sprintf("/mnt/disk%02d", i), Fstype: "ext4"})
+	}
+	partitions[3] = PartitionStat{Mountpoint: "/mnt/nfs1", Fstype: "nfs4"}
+	partitions[12] = PartitionStat{Mountpoint: "/mnt/backup", Fstype: "fuse.sshfs"}
+	partitions[7].Mountpoint = "/mnt/denied"
+
+	fake := newBlockingUsage("/mnt/nfs1", "/mnt/backup")
+	fake.failing["/mnt/denied"] = errors.New("permission denied")
+	defer fake.release("/mnt/nfs1")
+
+	tool := NewDiskTool(storage.NewMemoryCache(), CacheOptions{}, PartitionFilter{}, NewOutputStyle(StylePlain, 0), nil)
+	tool.WithUsageTimeout(50 * time.Millisecond).usage = fake.usage
+
+	// 阻塞的查询不影响其他挂载点，整体只等待一个时限
+	start := time.Now()
+	usages, skipped, err := tool.collectUsage(context.Background(), partitions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("collectUsage() took %s with a %s timeout", elapsed, tool.usageTimeout)
+	}
+
+	// 使用量与分区一一对应，与完成顺序无关
+	for i, partition := range partitions {
+		usage := usages[i]
+		switch partition.Mountpoint {
+		case "/mnt/nfs1", "/mnt/backup", "/mnt/denied":
+			if usage != nil {
+				t.Errorf("%s: usage = %+v, want nil", partition.Mountpoint, usage)
+			}
+		default:
+			if usage == nil || usage.Path != partition.Mountpoint {
+				t.Errorf("usages[%d] = %+v, want %s", i, usage, partition.Mountpoint)
+			}
+		}
+	}
+	// 跳过的挂载点按分区顺序
+	var got []string
+	for _, mount := range skipped {
+		got = append(got, fmt.Sprintf("%s:%s:%s:%s", mount.Mountpoint, mount.Fstype, mount.Reason, mount.Detail))
+	}
+	want := "/mnt/nfs1:nfs4:timeout:超过 50ms 没有响应 /mnt/denied:ext4:error:permission denied /mnt/backup:fuse.sshfs:timeout:超过 50ms 没有响应"
+	if strings.Join(got, " ") != want {
+		t.Errorf("skipped = %s, want %s", strings.Join(got, " "), want)
+	}
+
+	// 上一次查询仍阻塞时不再发起新的查询，直接跳过
+	_, skipped, err = tool.collectUsage(context.Background(), partitions[3:4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 1 || skipped[0].Reason != SkipReasonTimeout || skipped[0].Detail != errUsagePending.Error() || fake.calledTimes("/mnt/nfs1") != 1 {
+		t.Errorf("pending query: skipped = %+v, %d calls", skipped, fake.calledTimes("/mnt/nfs1"))
+	}
+
+	// 挂载点恢复后，后台的查询返回，下一次查询正常
+	fake.release("/mnt/backup")
+	waitPending(t, tool, "/mnt/backup")
+	usages, skipped, err = tool.collectUsage(context.Background(), partitions[12:13])
+	if err != nil || len(skipped) != 0 || usages[0] == nil || fake.calledTimes("/mnt/backup") != 2 {
+		t.Errorf("recovered mount: usages %v, skipped %+v, err %v", usages, skipped, err)
+	}
+
+	// ctx 取消时返回 ctx 的错误
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := tool.collectUsage(ctx, partitions); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: err = %v", err)
+	}
+}
+
+func TestDiskInfoSkippedMounts(t *testing.T) {
+	useFakeDisk(t, &fakeDiskProvider{partitions: []PartitionStat{
+		{Device: "/dev/sda1", Mountpoint: "/", Fstype: "ext4"},
+		{Device: "nas:/export", Mountpoint: "/mnt/nfs1", Fstype: "nfs4"},
+		{Device: "/dev/sdc1", Mountpoint: "/mnt/broken", Fstype: "ext4"},
+	}})
+	fake := newBlockingUsage("/mnt/nfs1")
+	fake.failing["/mnt/broken"] = errors.New("input/output error")
+	defer fake.release("/mnt/nfs1")
+
+	tool := NewDiskTool(storage.NewMemoryCache(), CacheOptions{}, PartitionFilter{}, NewOutputStyle(StylePlain, 0), nil)
+	tool.platform, tool.usage = platformDarwin, fake.usage
+	tool.WithUsageTimeout(20 * time.Millisecond)
+
+	text, err := tool.Execute(context.Background(), map[string]interface{}{"cache": "fresh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"跳过 1 个无响应的挂载点: /mnt/nfs1", "跳过 1 个无法读取的挂载点: /mnt/broken（input/output error）"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output lacks %q:\n%s", want, text)
+		}
+	}
+
+	// 结构化结果包含跳过的挂载点和原因
+	_, structured, err := tool.ExecuteStructured(context.Background(), map[string]interface{}{"cache": "fresh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(structured)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report types.DiskInfo
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	reasons := make(map[string]string)
+	for _, mount := range report.SkippedMounts {
+		reasons[mount.Mountpoint] = mount.Reason + ":" + mount.Detail
+	}
+	// 第二次调用时 /mnt/nfs1 上一次的查询仍未返回
+	if len(report.Partitions) != 1 || report.Partitions[0].Mountpoint != "/" ||
+		reasons["/mnt/nfs1"] != "timeout:上一次查询仍未返回" || reasons["/mnt/broken"] != "error:input/output error" {
+		t.Errorf("report = %s", data)
+	}
+}
+
+func TestWithUsageTimeout(t *testing.T) {
+	tool := NewDiskTool(nil, CacheOptions{}, PartitionFilter{}, NewOutputStyle(StylePlain, 0), nil)
+	if tool.WithUsageTimeout(0).usageTimeout != defaultUsageTimeout || tool.WithUsageTimeout(-time.Second).usageTimeout != defaultUsageTimeout {
+		t.Errorf("non-positive timeout changed the default: %s", tool.usageTimeout)
+	}
+	if tool.WithUsageTimeout(5*time.Second).usageTimeout != 5*time.Second {
+		t.Errorf("usageTimeout = %s, want 5s", tool.usageTimeout)
+	}
+}
+
+func TestDescribeSkippedMounts(t *testing.T) {
+	lines := describeSkippedMounts(nil)
+	if lines != nil {
+		t.Errorf("describeSkippedMounts(nil) = %v", lines)
+	}
+	lines = describeSkippedMounts([]types.SkippedMount{
+		{Mountpoint: "/mnt/nfs1", Reason: SkipReasonTimeout},
+		{Mountpoint: "/mnt/usb", Reason: SkipReasonError, Detail: "permission denied"},
+		{Mountpoint: "/mnt/backup", Reason: SkipReasonTimeout},
+	})
+	want := []string{"跳过 2 个无响应的挂载点: /mnt/nfs1, /mnt/backup", "跳过 1 个无法读取的挂载点: /mnt/usb（permission denied）"}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("describeSkippedMounts() = %q, want %q", lines, want)
+	}
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// environment 运行环境探测的输入来源，root 和 getenv 可替换为测试夹具
+type environment struct {
+	root   string
+	getenv func(string) string
+}
+
+// hostEnvironment 当前进程所在的运行环境
+var hostEnvironment = environment{
+	root:   "/",
+	getenv: os.Getenv,
+}
+
+// cgroupMarkers /proc/1/cgroup 中表示容器运行时的标记（按优先级排列）
+var cgroupMarkers = []struct {
+	marker  string
+	runtime string
+}{
+	{"kubepods", "kubernetes"},
+	{"docker", "docker"},
+	{"libpod", "podman"},
+	{"containerd", "containerd"},
+	{"lxc", "lxc"},
+}
+
+// exists 判断环境中的文件是否存在
+func (e environment) exists(path string) bool {
+	_, err := os.Stat(filepath.Join(e.root, path))
+	return err == nil
+}
+
+// containerRuntime 检测容器运行时，不在容器中时返回空字符串
+func (e environment) containerRuntime() string {
+	if e.getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return "kubernetes"
+	}
+
+	if e.exists("/.dockerenv") {
+		return "docker"
+	}
+	if e.exists("/run/.containerenv") {
+		return "podman"
+	}
+
+	if data, err := os.ReadFile(filepath.Join(e.root, "/proc/1/cgroup")); err == nil {
+		content := string(data)
+		for _, m := range cgroupMarkers {
+			if strings.Contains(content, m.marker) {
+				return m.runtime
+			}
+		}
+	}
+
+	// systemd-nspawn、podman 等会设置 container 环境变量
+	return e.getenv("container")
+}
+
+// describeEnvironment 生成运行环境的描述
+func describeEnvironment(virtSystem, virtRole, containerRuntime string) string {
+	var parts []string
+	if containerRuntime != "" {
+		parts = append(parts, "容器 ("+containerRuntime+")")
+	}
+	// gopsutil 会把容器运行时也报告为虚拟化系统，避免重复
+	if virtSystem != "" && virtSystem != containerRuntime {
+		if virtRole != "" {
+			parts = append(parts, virtSystem+" "+virtRole)
+		} else {
+			parts = append(parts, virtSystem)
+		}
+	}
+
+	if len(parts) == 0 {
+		return "物理机（未检测到虚拟化）"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// containerNote 容器环境下对监控数据局限性的说明
+func containerNote(containerRuntime string) string {
+	if containerRuntime == "" {
+		return ""
+	}
+	return "运行在容器中（" + containerRuntime + "），温度传感器和部分 /proc 信息通常不可用，数据反映的是容器视角"
+}
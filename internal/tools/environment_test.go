@@ -0,0 +1,61 @@
+package tools
+
+import "testing"
+
+// fakeEnvironment 以 files 为根目录内容、env 为环境变量的运行环境
+func fakeEnvironment(t *testing.T, files map[string]string, env map[string]string) environment {
+	t.Helper()
+	return environment{root: writeTree(t, files), getenv: func(name string) string { return env[name] }}
+}
+
+func TestContainerRuntime(t *testing.T) {
+	cases := []struct {
+		name  string
+		files map[string]string
+		env   map[string]string
+		want  string
+	}{
+		{"physical host", map[string]string{"proc/1/cgroup": "0::/init.scope\n"}, nil, ""},
+		{"no /proc", nil, nil, ""},
+		{"kubernetes env", map[string]string{".dockerenv": ""}, map[string]string{"KUBERNETES_SERVICE_HOST": "10.96.0.1"}, "kubernetes"},
+		{"dockerenv", map[string]string{".dockerenv": "", "proc/1/cgroup": "0::/\n"}, nil, "docker"},
+		{"podman containerenv", map[string]string{"run/.containerenv": "engine=\"podman-4.9\"\n"}, nil, "podman"},
+		{"cgroup v1 docker", map[string]string{"proc/1/cgroup": "12:pids:/docker/3f2a9c\n11:memory:/docker/3f2a9c\n"}, nil, "docker"},
+		// kubepods 路径中同时出现 docker 时按 kubernetes 识别
+		{"cgroup kubepods", map[string]string{"proc/1/cgroup": "0::/kubepods.slice/kubepods-burstable.slice/docker-3f2a9c.scope\n"}, nil, "kubernetes"},
+		{"cgroup libpod", map[string]string{"proc/1/cgroup": "0::/machine.slice/libpod-3f2a9c.scope\n"}, nil, "podman"},
+		{"cgroup lxc", map[string]string{"proc/1/cgroup": "0::/lxc.payload.web\n"}, nil, "lxc"},
+		// cgroup v2 命名空间中只能看到 0::/，回退到 container 环境变量
+		{"container env", map[string]string{"proc/1/cgroup": "0::/\n"}, map[string]string{"container": "systemd-nspawn"}, "systemd-nspawn"},
+	}
+	for _, c := range cases {
+		if got := fakeEnvironment(t, c.files, c.env).containerRuntime(); got != c.want {
+			t.Errorf("%s: containerRuntime() = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestDescribeEnvironment(t *testing.T) {
+	cases := []struct {
+		system, role, runtime string
+		want                  string
+	}{
+		{"", "", "", "物理机（未检测到虚拟化）"},
+		{"kvm", "guest", "", "kvm guest"},
+		{"xen", "", "", "xen"},
+		{"docker", "guest", "docker", "容器 (docker)"},
+		{"kvm", "guest", "kubernetes", "容器 (kubernetes), kvm guest"},
+	}
+	for _, c := range cases {
+		if got := describeEnvironment(c.system, c.role, c.runtime); got != c.want {
+			t.Errorf("describeEnvironment(%q, %q, %q) = %q, want %q", c.system, c.role, c.runtime, got, c.want)
+		}
+	}
+
+	if note := containerNote(""); note != "" {
+		t.Errorf("containerNote() outside a container = %q", note)
+	}
+	if note := containerNote("docker"); note != "运行在容器中（docker），温度传感器和部分 /proc 信息通常不可用，数据反映的是容器视角" {
+		t.Errorf("containerNote(docker) = %q", note)
+	}
+}
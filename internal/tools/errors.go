@@ -0,0 +1,143 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrorCode 机器可读的错误代码
+type ErrorCode string
+
+const (
+	// ErrUnsupportedPlatform 当前平台不支持该指标或操作
+	ErrUnsupportedPlatform ErrorCode = "ERR_UNSUPPORTED_PLATFORM"
+	// ErrPermission 权限不足
+	ErrPermission ErrorCode = "ERR_PERMISSION"
+	// ErrNotFound 目标（进程、接口、键等）不存在
+	ErrNotFound ErrorCode = "ERR_NOT_FOUND"
+	// ErrTimeout 采集超时或被取消
+	ErrTimeout ErrorCode = "ERR_TIMEOUT"
+	// ErrBadArgument 参数无效
+	ErrBadArgument ErrorCode = "ERR_BAD_ARGUMENT"
+	// ErrRateLimited 工具调用超过配置的频率限制
+	ErrRateLimited ErrorCode = "ERR_RATE_LIMITED"
+	// ErrInternal 其他采集失败
+	ErrInternal ErrorCode = "ERR_INTERNAL"
+)
+
+// 各错误代码的默认提示
+var defaultHints = map[ErrorCode]string{
+	ErrUnsupportedPlatform: "该指标在当前平台上不可用，请改用其他工具",
+	ErrPermission:          "权限不足，可尝试以 root（或管理员）身份运行服务器",
+	ErrTimeout:             "可缩短采样时长，或通过 --tool-timeout 调大超时时间",
+}
+
+// Error 工具执行失败的结构化错误
+type Error struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	Hint    string    `json:"hint,omitempty"`
+	// Argument 参数无效（ErrBadArgument）时出错的参数名，无法归属到单个参数时为空
+	Argument string `json:"argument,omitempty"`
+	Err      error  `json:"-"`
+}
+
+// Error 错误描述，包含底层错误。ClassifyError 以底层错误的描述作为说明，此时不再重复
+func (e *Error) Error() string {
+	if e.Err != nil && e.Err.Error() != e.Message {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap 返回底层错误
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// newError 创建结构化错误，使用错误代码的默认提示
+func newError(code ErrorCode, format string, args ...interface{}) *Error {
+	return &Error{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+		Hint:    defaultHints[code],
+	}
+}
+
+// badArgument 参数无效
+func badArgument(format string, args ...interface{}) *Error {
+	return newError(ErrBadArgument, format, args...)
+}
+
+// notFound 目标不存在
+func notFound(format string, args ...interface{}) *Error {
+	return newError(ErrNotFound, format, args...)
+}
+
+// unsupportedPlatform 当前平台不支持
+func unsupportedPlatform(format string, args ...interface{}) *Error {
+	return newError(ErrUnsupportedPlatform, format, args...)
+}
+
+// wrapError 为底层错误加上说明并分类，底层已是结构化错误时沿用其代码和提示
+func wrapError(message string, err error) *Error {
+	classified := ClassifyError(err)
+	return &Error{
+		Code:    classified.Code,
+		Message: message,
+		Hint:    classified.Hint,
+		Err:     err,
+	}
+}
+
+// ClassifyError 将任意错误映射为结构化错误，是错误代码映射的唯一入口
+func ClassifyError(err error) *Error {
+	var toolErr *Error
+	if errors.As(err, &toolErr) {
+		return toolErr
+	}
+
+	code := ErrInternal
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		code = ErrTimeout
+	case errors.Is(err, fs.ErrPermission), errors.Is(err, syscall.EPERM), errors.Is(err, syscall.EACCES):
+		code = ErrPermission
+	case errors.Is(err, fs.ErrNotExist):
+		code = ErrNotFound
+	case strings.Contains(err.Error(), "not implemented"):
+		// gopsutil 在不支持的平台上返回 "not implemented yet"
+		code = ErrUnsupportedPlatform
+	}
+
+	return &Error{
+		Code:    code,
+		Message: err.Error(),
+		Hint:    defaultHints[code],
+		Err:     err,
+	}
+}
+
+// TimeoutError 工具调用超过服务器设定的超时时间
+func TimeoutError(timeout time.Duration, err error) *Error {
+	return &Error{
+		Code:    ErrTimeout,
+		Message: fmt.Sprintf("工具执行超时（%s）", timeout),
+		Hint:    defaultHints[ErrTimeout],
+		Err:     err,
+	}
+}
+
+// RateLimitedError 工具调用超过配置的频率限制，retryAfter 后才有可用的调用次数
+func RateLimitedError(tool string, retryAfter time.Duration) *Error {
+	return &Error{
+		Code:    ErrRateLimited,
+		Message: fmt.Sprintf("rate limit exceeded for %s", tool),
+		Hint:    fmt.Sprintf("调用过于频繁，请在 %s 后重试，或改用 cache=auto 读取缓存数据", retryAfter.Round(time.Second)),
+	}
+}
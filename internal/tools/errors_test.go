@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		code ErrorCode
+	}{
+		{"deadline", fmt.Errorf("采样失败: %w", context.DeadlineExceeded), ErrTimeout},
+		{"canceled", context.Canceled, ErrTimeout},
+		{"fs permission", &fs.PathError{Op: "open", Path: "/proc/1/fd", Err: fs.ErrPermission}, ErrPermission},
+		{"EACCES", &os.PathError{Op: "open", Path: "/proc/1/io", Err: syscall.EACCES}, ErrPermission},
+		{"EPERM", fmt.Errorf("kill: %w", syscall.EPERM), ErrPermission},
+		{"not exist", &fs.PathError{Op: "open", Path: "/sys/class/thermal", Err: syscall.ENOENT}, ErrNotFound},
+		{"gopsutil not implemented", errors.New("not implemented yet"), ErrUnsupportedPlatform},
+		{"other", errors.New("unexpected EOF"), ErrInternal},
+	}
+	for _, c := range cases {
+		got := ClassifyError(c.err)
+		if got.Code != c.code || got.Hint != defaultHints[c.code] || got.Message != c.err.Error() || got.Error() != c.err.Error() || !errors.Is(got, c.err) {
+			t.Errorf("%s: ClassifyError() = %+v, want code %s with the default hint, wrapping the error", c.name, got, c.code)
+		}
+	}
+}
+
+func TestStructuredErrorsKeepTheirCode(t *testing.T) {
+	// 已是结构化错误（包括被 fmt.Errorf 包装过）时原样返回
+	original := argumentError("pid", "进程不存在: %d", 42)
+	original.Code = ErrNotFound
+	if got := ClassifyError(fmt.Errorf("查询失败: %w", original)); got != original {
+		t.Fatalf("ClassifyError() = %+v, want the wrapped *Error itself", got)
+	}
+
+	// wrapError 换上新的说明，沿用代码和提示，底层错误仍可匹配
+	wrapped := wrapError("获取进程信息失败", fmt.Errorf("读取状态: %w", syscall.EACCES))
+	if wrapped.Code != ErrPermission || wrapped.Hint != defaultHints[ErrPermission] || !errors.Is(wrapped, syscall.EACCES) {
+		t.Fatalf("wrapError() = %+v", wrapped)
+	}
+	if want := "获取进程信息失败: 读取状态: permission denied"; wrapped.Error() != want {
+		t.Errorf("Error() = %q, want %q", wrapped.Error(), want)
+	}
+	custom := &Error{Code: ErrRateLimited, Message: "too fast", Hint: "slow down"}
+	if got := wrapError("调用失败", custom); got.Code != ErrRateLimited || got.Hint != "slow down" || got.Message != "调用失败" {
+		t.Errorf("wrapError() of a structured error = %+v, want its code and hint", got)
+	}
+
+	// 没有底层错误时只有说明；参数错误记录参数名
+	if err := argumentError("limit", "limit 应为正整数"); err.Error() != "limit 应为正整数" || err.Code != ErrBadArgument || err.Argument != "limit" {
+		t.Errorf("argumentError() = %+v", err)
+	}
+	if err := unsupportedPlatform("仅支持 Linux"); err.Code != ErrUnsupportedPlatform || err.Hint == "" {
+		t.Errorf("unsupportedPlatform() = %+v, want the default hint", err)
+	}
+}
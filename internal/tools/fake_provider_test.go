@@ -0,0 +1,320 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// errNoProcess 假进程来源中不存在的 PID
+var errNoProcess = errors.New("process does not exist")
+
+// fakeProcess 字段即各方法返回值的假进程
+type fakeProcess struct {
+	pid        int32
+	name       string
+	ppid       int32
+	cmdline    []string
+	uids       []int32
+	username   string
+	status     []string
+	createTime int64
+	cpuPercent float64
+	times      *TimesStat
+	memory     *MemoryInfoStat
+	numFDs     int32
+	openFiles  []OpenFilesStat
+	// nameErr 不为 nil 时读取进程名返回该错误，模拟没有权限或已退出的进程
+	nameErr error
+	// numFDsErr 不为 nil 时读取文件描述符数返回该错误，模拟权限不足
+	numFDsErr error
+	// onSignal 收到信号时调用，为 nil 时忽略信号
+	onSignal func(sig syscall.Signal)
+}
+
+func (p *fakeProcess) PID() int32 { return p.pid }
+func (p *fakeProcess) NameWithContext(context.Context) (string, error) {
+	return p.name, p.nameErr
+}
+func (p *fakeProcess) PpidWithContext(context.Context) (int32, error) { return p.ppid, nil }
+func (p *fakeProcess) CmdlineSliceWithContext(context.Context) ([]string, error) {
+	return p.cmdline, nil
+}
+func (p *fakeProcess) UidsWithContext(context.Context) ([]int32, error) { return p.uids, nil }
+func (p *fakeProcess) UsernameWithContext(context.Context) (string, error) {
+	return p.username, nil
+}
+func (p *fakeProcess) StatusWithContext(context.Context) ([]string, error) {
+	return p.status, nil
+}
+func (p *fakeProcess) CreateTimeWithContext(context.Context) (int64, error) {
+	return p.createTime, nil
+}
+func (p *fakeProcess) CPUPercentWithContext(context.Context) (float64, error) {
+	return p.cpuPercent, nil
+}
+func (p *fakeProcess) TimesWithContext(context.Context) (*TimesStat, error) {
+	if p.times == nil {
+		return &TimesStat{}, nil
+	}
+	return p.times, nil
+}
+func (p *fakeProcess) MemoryInfoWithContext(context.Context) (*MemoryInfoStat, error) {
+	if p.memory == nil {
+		return &MemoryInfoStat{}, nil
+	}
+	return p.memory, nil
+}
+func (p *fakeProcess) NumFDsWithContext(context.Context) (int32, error) {
+	return p.numFDs, p.numFDsErr
+}
+func (p *fakeProcess) OpenFilesWithContext(context.Context) ([]OpenFilesStat, error) {
+	return p.openFiles, nil
+}
+func (p *fakeProcess) SendSignalWithContext(_ context.Context, sig syscall.Signal) error {
+	if p.onSignal != nil {
+		p.onSignal(sig)
+	}
+	return nil
+}
+
+// fakeProcessProvider 进程列表固定的进程来源，可在测试中增删进程
+type fakeProcessProvider struct {
+	processes map[int32]*fakeProcess
+}
+
+func newFakeProcessProvider(processes ...*fakeProcess) *fakeProcessProvider {
+	provider := &fakeProcessProvider{processes: make(map[int32]*fakeProcess)}
+	for _, p := range processes {
+		provider.processes[p.pid] = p
+	}
+	return provider
+}
+
+func (fp *fakeProcessProvider) Pids(context.Context) ([]int32, error) {
+	pids := make([]int32, 0, len(fp.processes))
+	for pid := range fp.processes {
+		pids = append(pids, pid)
+	}
+	return pids, nil
+}
+
+func (fp *fakeProcessProvider) Processes(context.Context) ([]Process, error) {
+	processes := make([]Process, 0, len(fp.processes))
+	for _, p := range fp.processes {
+		processes = append(processes, p)
+	}
+	return processes, nil
+}
+
+func (fp *fakeProcessProvider) NewProcess(_ context.Context, pid int32) (Process, error) {
+	p, found := fp.processes[pid]
+	if !found {
+		return nil, errNoProcess
+	}
+	return p, nil
+}
+
+func (fp *fakeProcessProvider) PidExists(_ context.Context, pid int32) (bool, error) {
+	_, found := fp.processes[pid]
+	return found, nil
+}
+
+// useFakeProcesses 在测试期间用假进程替换进程来源
+func useFakeProcesses(t interface{ Cleanup(func()) }, provider *fakeProcessProvider) {
+	current := providers
+	current.Process = provider
+	t.Cleanup(SetProviders(current))
+}
+
+// fakeNetProvider 接口计数和连接列表固定的网络来源，每次调用返回顺序打乱的副本；err 不为 nil 时读取接口计数返回该错误
+type fakeNetProvider struct {
+	counters    []NetIOCountersStat
+	connections []ConnectionStat
+	err         error
+}
+
+func (fn *fakeNetProvider) IOCounters(_ context.Context, perNIC bool) ([]NetIOCountersStat, error) {
+	if fn.err != nil {
+		return nil, fn.err
+	}
+	return shuffled(fn.counters), nil
+}
+
+func (fn *fakeNetProvider) Connections(context.Context, string) ([]ConnectionStat, error) {
+	return shuffled(fn.connections), nil
+}
+
+// shuffled 顺序随机打乱的副本，用于确认输出不依赖数据来源的顺序
+func shuffled[T any](items []T) []T {
+	copied := slices.Clone(items)
+	rand.Shuffle(len(copied), func(i, j int) { copied[i], copied[j] = copied[j], copied[i] })
+	return copied
+}
+
+// useFakeNet 在测试期间用假网络来源替换网络数据来源
+func useFakeNet(t interface{ Cleanup(func()) }, provider *fakeNetProvider) {
+	current := providers
+	current.Net = provider
+	t.Cleanup(SetProviders(current))
+}
+
+// connection 构造一条连接，地址字段的类型在 gopsutil 版本间没有别名，只能逐个赋值
+func connection(protocolType, family uint32, localIP string, localPort uint32, remoteIP string, remotePort uint32, status string, pid int32) ConnectionStat {
+	conn := ConnectionStat{Type: protocolType, Family: family, Status: status, Pid: pid}
+	conn.Laddr.IP, conn.Laddr.Port = localIP, localPort
+	conn.Raddr.IP, conn.Raddr.Port = remoteIP, remotePort
+	return conn
+}
+
+// fakeCPUProvider 型号信息、核心数和使用率固定的 CPU 来源
+type fakeCPUProvider struct {
+	infos         []CPUInfoStat
+	physicalCores int
+	perCore       []float64
+	total         float64
+}
+
+func (fc *fakeCPUProvider) Percent(_ context.Context, _ time.Duration, perCPU bool) ([]float64, error) {
+	if perCPU {
+		return slices.Clone(fc.perCore), nil
+	}
+	return []float64{fc.total}, nil
+}
+
+func (fc *fakeCPUProvider) Counts(_ context.Context, logical bool) (int, error) {
+	if logical {
+		return len(fc.perCore), nil
+	}
+	return fc.physicalCores, nil
+}
+
+func (fc *fakeCPUProvider) Info(context.Context) ([]CPUInfoStat, error) {
+	return slices.Clone(fc.infos), nil
+}
+
+// useFakeCPU 在测试期间用假 CPU 来源替换 CPU 数据来源
+func useFakeCPU(t interface{ Cleanup(func()) }, provider *fakeCPUProvider) {
+	current := providers
+	current.CPU = provider
+	t.Cleanup(SetProviders(current))
+}
+
+// fakeMemProvider 内存和交换空间统计固定的内存来源
+type fakeMemProvider struct {
+	virtual VirtualMemoryStat
+	swap    SwapMemoryStat
+}
+
+func (fm *fakeMemProvider) VirtualMemory(context.Context) (*VirtualMemoryStat, error) {
+	virtual := fm.virtual
+	return &virtual, nil
+}
+
+func (fm *fakeMemProvider) SwapMemory(context.Context) (*SwapMemoryStat, error) {
+	swap := fm.swap
+	return &swap, nil
+}
+
+// useFakeMem 在测试期间用假内存来源替换内存数据来源
+func useFakeMem(t interface{ Cleanup(func()) }, provider *fakeMemProvider) {
+	current := providers
+	current.Mem = provider
+	t.Cleanup(SetProviders(current))
+}
+
+// fakeDiskProvider 分区列表和使用量固定的磁盘来源。hung 中的挂载点查询使用量时一直阻塞到 ctx 结束，
+// 模拟无响应的网络文件系统；failing 中的挂载点返回对应的错误
+type fakeDiskProvider struct {
+	partitions []PartitionStat
+	usage      map[string]UsageStat
+	hung       map[string]bool
+	failing    map[string]error
+}
+
+func (fd *fakeDiskProvider) Partitions(context.Context, bool) ([]PartitionStat, error) {
+	return shuffled(fd.partitions), nil
+}
+
+func (fd *fakeDiskProvider) Usage(ctx context.Context, path string) (*UsageStat, error) {
+	if fd.hung[path] {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if err := fd.failing[path]; err != nil {
+		return nil, err
+	}
+	usage, found := fd.usage[path]
+	if !found {
+		return nil, syscall.ENOENT
+	}
+	return &usage, nil
+}
+
+func (fd *fakeDiskProvider) IOCounters(context.Context) (map[string]DiskIOCountersStat, error) {
+	return map[string]DiskIOCountersStat{}, nil
+}
+
+// useFakeDisk 在测试期间用假磁盘来源替换磁盘数据来源
+func useFakeDisk(t interface{ Cleanup(func()) }, provider *fakeDiskProvider) {
+	current := providers
+	current.Disk = provider
+	t.Cleanup(SetProviders(current))
+}
+
+// fakeHostProvider 主机信息、运行时间和负载固定的主机来源
+type fakeHostProvider struct {
+	info         HostInfoStat
+	uptime       uint64
+	bootTime     uint64
+	users        []UserStat
+	temperatures []TemperatureStat
+	load         LoadAvgStat
+}
+
+func (fh *fakeHostProvider) Info(context.Context) (*HostInfoStat, error) {
+	info := fh.info
+	return &info, nil
+}
+
+func (fh *fakeHostProvider) Uptime(context.Context) (uint64, error)   { return fh.uptime, nil }
+func (fh *fakeHostProvider) BootTime(context.Context) (uint64, error) { return fh.bootTime, nil }
+func (fh *fakeHostProvider) Users(context.Context) ([]UserStat, error) {
+	return slices.Clone(fh.users), nil
+}
+func (fh *fakeHostProvider) Temperatures(context.Context) ([]TemperatureStat, error) {
+	return slices.Clone(fh.temperatures), nil
+}
+func (fh *fakeHostProvider) LoadAvg(context.Context) (*LoadAvgStat, error) {
+	load := fh.load
+	return &load, nil
+}
+
+// useFakeHost 在测试期间用假主机来源替换主机数据来源
+func useFakeHost(t interface{ Cleanup(func()) }, provider *fakeHostProvider) {
+	current := providers
+	current.Host = provider
+	t.Cleanup(SetProviders(current))
+}
+
+// writeTree 在临时目录中按相对路径写入文件，返回该目录，用作假的 /proc、/sys 或根目录
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
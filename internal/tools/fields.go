@@ -0,0 +1,257 @@
+package tools
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+
+	"mcp-example/internal/types"
+)
+
+// FieldsArgument tools/call 中只返回 JSON 结果部分字段的参数，由处理器取出后移除，不会传给工具
+const FieldsArgument = "fields"
+
+// fieldsArgs 支持 JSON 输出的工具共用的参数，调用前由处理器通过 TakeFieldsArgument 取出，不会传给工具
+type fieldsArgs struct {
+	Fields string `arg:"fields" desc:"仅用于 JSON 输出: 只返回这些字段，逗号分隔的点号路径（数组按元素取字段），如 partitions.mountpoint,partitions.used_percent"`
+}
+
+// fieldTree 字段路径按层合并后的树，值为 nil 的字段保留完整的值
+type fieldTree map[string]fieldTree
+
+// TakeFieldsArgument 工具的参数模式声明了 fields 时，从参数中取出并移除它（返回新的参数集合）和解析后的字段路径，
+// 未指定时路径为 nil。未声明的工具原样返回，由参数校验报告未知参数
+func TakeFieldsArgument(schema types.InputSchema, args map[string]interface{}) (map[string]interface{}, [][]string, error) {
+	if _, declared := schema.Properties[FieldsArgument]; !declared {
+		return args, nil, nil
+	}
+	value, found := args[FieldsArgument]
+	if !found {
+		return args, nil, nil
+	}
+
+	remaining := make(map[string]interface{}, len(args))
+	for name, value := range args {
+		if name != FieldsArgument {
+			remaining[name] = value
+		}
+	}
+	if value == nil || value == "" {
+		return remaining, nil, nil
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return nil, nil, argumentError(FieldsArgument, "参数 %s 应为字符串", FieldsArgument)
+	}
+	if format, _ := args["format"].(string); format != "json" {
+		toolErr := argumentError(FieldsArgument, "%s 只用于 JSON 输出", FieldsArgument)
+		toolErr.Hint = `同时指定 "format": "json"`
+		return nil, nil, toolErr
+	}
+	paths, err := parseFieldPaths(text)
+	if err != nil {
+		return nil, nil, err
+	}
+	return remaining, paths, nil
+}
+
+// parseFieldPaths 解析逗号分隔的点号路径，忽略逗号两侧的空白
+func parseFieldPaths(text string) ([][]string, error) {
+	var paths [][]string
+	for _, item := range strings.Split(text, ",") {
+		item = strings.TrimSpace(item)
+		path := strings.Split(item, ".")
+		if slices.Contains(path, "") {
+			return nil, argumentError(FieldsArgument, "参数 %s 中的字段路径无效: %q", FieldsArgument, item)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// ProjectResult 将工具的 JSON 文本结果投影到 paths 指定的字段，返回缩进格式的 JSON 文本；
+// 工具返回了结构化结果时，以投影后的对象替换。任一路径不存在时返回 ErrBadArgument，提示中列出出错那一层可用的字段；
+// 结果中省略了空值的字段（omitempty）时，只要结构化结果的类型声明了该路径就不算错误
+func ProjectResult(text string, structured interface{}, paths [][]string) (string, interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	// 保留数值的原始写法，超过 2^53 的字节计数不会损失精度
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", nil, wrapError("结果不是 JSON，无法按 fields 筛选", err)
+	}
+
+	for _, path := range paths {
+		if err := checkFieldPath(value, path, 0); err != nil && !declaresField(structured, path) {
+			return "", nil, err
+		}
+	}
+	projected := projectFields(value, buildFieldTree(paths))
+
+	data, err := json.MarshalIndent(projected, "", "  ")
+	if err != nil {
+		return "", nil, wrapError("JSON 序列化失败", err)
+	}
+	if structured != nil {
+		// structuredContent 必须是对象，投影结果为数组时不再返回结构化结果
+		object, _ := projected.(map[string]interface{})
+		if object == nil {
+			return string(data), nil, nil
+		}
+		structured = object
+	}
+	return string(data), structured, nil
+}
+
+// buildFieldTree 合并字段路径，同时指定了字段和它的子字段时保留完整的字段
+func buildFieldTree(paths [][]string) fieldTree {
+	tree := fieldTree{}
+	for _, path := range paths {
+		node := tree
+		for i, name := range path {
+			if i == len(path)-1 {
+				node[name] = nil
+				break
+			}
+			child, found := node[name]
+			if found && child == nil {
+				break
+			}
+			if !found {
+				child = fieldTree{}
+				node[name] = child
+			}
+			node = child
+		}
+	}
+	return tree
+}
+
+// checkFieldPath 检查 path[depth:] 在 value 中是否存在。数组中任一元素存在即可（省略空值的字段不会出现在每个元素中），
+// 空数组和 null 无法检查，视为存在
+func checkFieldPath(value interface{}, path []string, depth int) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		var firstErr error
+		for _, item := range v {
+			err := checkFieldPath(item, path, depth)
+			if err == nil {
+				return nil
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	case map[string]interface{}:
+		field, found := v[path[depth]]
+		if !found {
+			toolErr := argumentError(FieldsArgument, "字段 %s 不存在", strings.Join(path[:depth+1], "."))
+			if depth == 0 {
+				toolErr.Hint = "可用的顶层字段: " + strings.Join(sortedKeys(v), ", ")
+			} else {
+				toolErr.Hint = strings.Join(path[:depth], ".") + " 的字段: " + strings.Join(sortedKeys(v), ", ")
+			}
+			return toolErr
+		}
+		if depth == len(path)-1 {
+			return nil
+		}
+		return checkFieldPath(field, path, depth+1)
+	default:
+		return argumentError(FieldsArgument, "字段 %s 不是对象，没有子字段 %s",
+			strings.Join(path[:depth], "."), path[depth])
+	}
+}
+
+// projectFields 按字段树筛选 value：对象只保留树中的字段（缺少的字段跳过），数组对每个元素筛选，其他值原样返回
+func projectFields(value interface{}, tree fieldTree) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		projected := make(map[string]interface{}, len(tree))
+		for name, subtree := range tree {
+			field, found := v[name]
+			if !found {
+				continue
+			}
+			if subtree == nil {
+				projected[name] = field
+			} else {
+				projected[name] = projectFields(field, subtree)
+			}
+		}
+		return projected
+	case []interface{}:
+		projected := make([]interface{}, len(v))
+		for i, item := range v {
+			projected[i] = projectFields(item, tree)
+		}
+		return projected
+	}
+	return value
+}
+
+// declaresField structured 的类型中是否按 JSON 字段名声明了 path（切片和指针取元素类型，映射接受任意键），
+// structured 为 nil 时返回 false
+func declaresField(structured interface{}, path []string) bool {
+	if structured == nil {
+		return false
+	}
+	fieldType := reflect.TypeOf(structured)
+	for _, name := range path {
+		for fieldType.Kind() == reflect.Pointer || fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array {
+			fieldType = fieldType.Elem()
+		}
+		switch fieldType.Kind() {
+		case reflect.Map:
+			fieldType = fieldType.Elem()
+		case reflect.Struct:
+			field, found := jsonField(fieldType, name)
+			if !found {
+				return false
+			}
+			fieldType = field
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// jsonField 结构体中 JSON 字段名为 name 的字段类型，包括嵌入结构体展开的字段
+func jsonField(structType reflect.Type, name string) (reflect.Type, bool) {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		tagName, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && tagName == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if found, ok := jsonField(embedded, name); ok {
+					return found, true
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if tagName == "" {
+			tagName = field.Name
+		}
+		if tagName == name {
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,268 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"mcp-example/internal/fixtures"
+	"mcp-example/internal/types"
+)
+
+// projectJSON 以 paths（逗号分隔）投影 JSON 文本，返回投影后按 JSON 解码的值
+func projectJSON(t *testing.T, text, fields string, structured interface{}) (interface{}, interface{}) {
+	t.Helper()
+	paths, err := parseFieldPaths(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	projected, projectedStructured, err := ProjectResult(text, structured, paths)
+	if err != nil {
+		t.Fatalf("ProjectResult(%s) = %v", fields, err)
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(projected), &value); err != nil {
+		t.Fatalf("projected text is not JSON: %v\n%s", err, projected)
+	}
+	return value, projectedStructured
+}
+
+// decodeJSON 解码测试中的期望值
+func decodeJSON(t *testing.T, text string) interface{} {
+	t.Helper()
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+// fieldsError 期望 ProjectResult 返回的 ErrBadArgument 错误，返回其提示
+func fieldsError(t *testing.T, text, fields string, structured interface{}) *Error {
+	t.Helper()
+	paths, err := parseFieldPaths(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = ProjectResult(text, structured, paths)
+	var toolErr *Error
+	if !errors.As(err, &toolErr) || toolErr.Code != ErrBadArgument || toolErr.Argument != FieldsArgument {
+		t.Fatalf("ProjectResult(%s) error = %v, want ErrBadArgument for %s", fields, err, FieldsArgument)
+	}
+	return toolErr
+}
+
+func TestProjectResultNestedArrays(t *testing.T) {
+	text := `{
+		"groups": [
+			{"name": "web", "count": 2, "members": [{"pid": 1, "name": "nginx", "cpu": 1.5}, {"pid": 2, "name": "php", "cpu": 0}]},
+			{"name": "db", "count": 0, "members": []},
+			{"name": "idle", "count": 0, "members": null}
+		],
+		"matrix": [[{"x": 1, "y": 2}], [], [{"x": 3, "y": 4}, {"y": 5}]],
+		"total": 2
+	}`
+
+	got, _ := projectJSON(t, text, "groups.name, groups.members.pid, matrix.x", nil)
+	want := decodeJSON(t, `{
+		"groups": [
+			{"name": "web", "members": [{"pid": 1}, {"pid": 2}]},
+			{"name": "db", "members": []},
+			{"name": "idle", "members": null}
+		],
+		"matrix": [[{"x": 1}], [], [{"x": 3}, {}]]
+	}`)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("projection = %v\nwant %v", got, want)
+	}
+}
+
+func TestProjectResultMergesFieldAndSubfield(t *testing.T) {
+	text := `{"load": {"load1": 1.5, "load5": 1.2, "load15": 0.9}, "hostname": "web-01", "uptime": 100}`
+	want := decodeJSON(t, `{"load": {"load1": 1.5, "load5": 1.2, "load15": 0.9}, "hostname": "web-01"}`)
+	for _, fields := range []string{"load,load.load1,hostname", "load.load1,load,hostname", "hostname,load.load5,load"} {
+		if got, _ := projectJSON(t, text, fields, nil); !reflect.DeepEqual(got, want) {
+			t.Errorf("fields=%s: projection = %v, want the whole load object", fields, got)
+		}
+	}
+}
+
+func TestProjectResultMissingPaths(t *testing.T) {
+	text := `{"partitions": [{"mountpoint": "/", "total": 10}, {"mountpoint": "/data", "total": 20}], "last_updated": "2024-05-06T07:08:09Z", "count": 2}`
+
+	toolErr := fieldsError(t, text, "partitions.mountpoint,hostname", nil)
+	if !strings.Contains(toolErr.Message, "hostname") || toolErr.Hint != "可用的顶层字段: count, last_updated, partitions" {
+		t.Errorf("top-level error = %q, hint %q", toolErr.Message, toolErr.Hint)
+	}
+
+	toolErr = fieldsError(t, text, "partitions.used", nil)
+	if !strings.Contains(toolErr.Message, "partitions.used") || toolErr.Hint != "partitions 的字段: mountpoint, total" {
+		t.Errorf("nested error = %q, hint %q", toolErr.Message, toolErr.Hint)
+	}
+
+	toolErr = fieldsError(t, text, "count.value", nil)
+	if !strings.Contains(toolErr.Message, "count") || !strings.Contains(toolErr.Message, "value") {
+		t.Errorf("scalar error = %q, want it to name the field and the missing subfield", toolErr.Message)
+	}
+
+	// 路径大小写与 JSON 字段名不同时同样不存在
+	fieldsError(t, text, "Partitions.mountpoint", nil)
+}
+
+// omittedReport 带 omitempty 字段的结构化结果，用于检查省略的空字段不算不存在
+type omittedReport struct {
+	Name    string `json:"name"`
+	Alert   string `json:"alert,omitempty"`
+	Details []struct {
+		Label string `json:"label,omitempty"`
+	} `json:"details,omitempty"`
+	embeddedReport
+}
+
+type embeddedReport struct {
+	Source string `json:"source,omitempty"`
+}
+
+func TestProjectResultOmittedFieldsDeclaredByStructured(t *testing.T) {
+	report := omittedReport{Name: "disk"}
+	data, _ := json.Marshal(report)
+
+	got, structured := projectJSON(t, string(data), "name,alert,details.label,source", report)
+	if want := decodeJSON(t, `{"name": "disk"}`); !reflect.DeepEqual(got, want) {
+		t.Errorf("projection = %v, want only the present field", got)
+	}
+	if object, ok := structured.(map[string]interface{}); !ok || len(object) != 1 || object["name"] != "disk" {
+		t.Errorf("structured = %#v, want the projected object", structured)
+	}
+
+	// 类型中也没有声明的字段仍然报错
+	fieldsError(t, string(data), "name,severity", report)
+	fieldsError(t, string(data), "details.value", report)
+}
+
+func TestProjectResultEmptyResults(t *testing.T) {
+	// 空数组和 null 无法检查子字段，视为存在并保持原样
+	got, _ := projectJSON(t, `{"processes": [], "groups": null, "total": 0}`, "processes.pid,groups.key,total", nil)
+	if want := decodeJSON(t, `{"processes": [], "groups": null, "total": 0}`); !reflect.DeepEqual(got, want) {
+		t.Errorf("projection = %v, want empty collections kept", got)
+	}
+
+	// 空对象中没有任何字段
+	toolErr := fieldsError(t, `{}`, "total", nil)
+	if toolErr.Hint != "可用的顶层字段: " {
+		t.Errorf("hint = %q, want an empty field list", toolErr.Hint)
+	}
+
+	// 结果本身是数组时逐个元素投影，不再返回结构化结果
+	text, structured, err := ProjectResult(`[{"a": 1, "b": 2}, {"a": 3}]`, map[string]interface{}{}, [][]string{{"a"}})
+	if err != nil || structured != nil {
+		t.Fatalf("ProjectResult() = %v, %v; want no structured content for an array result", structured, err)
+	}
+	if got, want := decodeJSON(t, text), decodeJSON(t, `[{"a": 1}, {"a": 3}]`); !reflect.DeepEqual(got, want) {
+		t.Errorf("projection = %v, want %v", got, want)
+	}
+	text, _, err = ProjectResult(`[]`, nil, [][]string{{"a"}})
+	if err != nil || strings.TrimSpace(text) != "[]" {
+		t.Errorf("ProjectResult([]) = %q, %v; want an empty array", text, err)
+	}
+}
+
+func TestProjectResultPreservesNumbers(t *testing.T) {
+	got, _, err := ProjectResult(`{"bytes_sent": 18446744073709551615, "ratio": 0.1, "skip": 1}`, nil, [][]string{{"bytes_sent"}, {"ratio"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "18446744073709551615") || !strings.Contains(got, "0.1") || strings.Contains(got, "skip") {
+		t.Fatalf("projection = %s, want the original number literals", got)
+	}
+}
+
+func TestProjectResultRejectsNonJSON(t *testing.T) {
+	_, _, err := ProjectResult("💽 磁盘信息\n", nil, [][]string{{"partitions"}})
+	if err == nil || !strings.Contains(err.Error(), "不是 JSON") {
+		t.Fatalf("ProjectResult(text) = %v, want an error about non-JSON output", err)
+	}
+}
+
+func TestProjectResultDiskReport(t *testing.T) {
+	report := diskReport{DiskInfo: fixtures.DiskInfo()}
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, structured := projectJSON(t, string(data), "partitions.mountpoint,partitions.used_percent,skipped_mounts", report)
+	partitions := got.(map[string]interface{})["partitions"].([]interface{})
+	if len(partitions) != 3 {
+		t.Fatalf("partitions = %v, want all three partitions", partitions)
+	}
+	for _, partition := range partitions {
+		if fields := partition.(map[string]interface{}); len(fields) != 2 || fields["mountpoint"] == nil || fields["used_percent"] == nil {
+			t.Errorf("partition = %v, want only mountpoint and used_percent", fields)
+		}
+	}
+	if !reflect.DeepEqual(got, normalizeJSON(t, structured)) {
+		t.Errorf("structured content %v differs from the projected text %v", structured, got)
+	}
+}
+
+// normalizeJSON 将值编码后再解码，便于与解码得到的值比较
+func normalizeJSON(t *testing.T, value interface{}) interface{} {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decodeJSON(t, string(data))
+}
+
+func TestTakeFieldsArgument(t *testing.T) {
+	schema := argsSchema(formatArgs{})
+	args := map[string]interface{}{"format": "json", "fields": " partitions.mountpoint , total ", "show_all": true}
+
+	remaining, paths, err := TakeFieldsArgument(schema, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{"partitions", "mountpoint"}, {"total"}}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+	if _, found := remaining["fields"]; found || remaining["show_all"] != true || remaining["format"] != "json" {
+		t.Errorf("remaining = %v, want every argument except fields", remaining)
+	}
+	if _, found := args["fields"]; !found {
+		t.Error("TakeFieldsArgument modified the caller's arguments")
+	}
+
+	for _, empty := range []interface{}{nil, ""} {
+		remaining, paths, err := TakeFieldsArgument(schema, map[string]interface{}{"fields": empty})
+		if err != nil || paths != nil || len(remaining) != 0 {
+			t.Errorf("fields=%#v: %v, %v, %v; want the argument removed without paths", empty, remaining, paths, err)
+		}
+	}
+
+	undeclared := types.InputSchema{Type: "object", Properties: map[string]types.Property{}}
+	if remaining, paths, err := TakeFieldsArgument(undeclared, args); err != nil || paths != nil || !reflect.DeepEqual(remaining, args) {
+		t.Errorf("undeclared fields: %v, %v, %v; want the arguments unchanged", remaining, paths, err)
+	}
+
+	for _, c := range []struct {
+		args map[string]interface{}
+		hint string
+	}{
+		{map[string]interface{}{"fields": "total"}, `同时指定 "format": "json"`},
+		{map[string]interface{}{"format": "text", "fields": "total"}, `同时指定 "format": "json"`},
+		{map[string]interface{}{"format": "json", "fields": []interface{}{"total"}}, ""},
+		{map[string]interface{}{"format": "json", "fields": "partitions..mountpoint"}, ""},
+		{map[string]interface{}{"format": "json", "fields": "total,"}, ""},
+		{map[string]interface{}{"format": "json", "fields": ".total"}, ""},
+	} {
+		_, _, err := TakeFieldsArgument(schema, c.args)
+		var toolErr *Error
+		if !errors.As(err, &toolErr) || toolErr.Argument != FieldsArgument || toolErr.Hint != c.hint {
+			t.Errorf("TakeFieldsArgument(%v) = %v, want ErrBadArgument for fields with hint %q", c.args, err, c.hint)
+		}
+	}
+}
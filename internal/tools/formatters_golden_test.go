@@ -0,0 +1,258 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/fixtures"
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+// goldenStyles 格式化 golden 测试覆盖的输出风格
+var goldenStyles = []string{StyleRich, StylePlain}
+
+// splitCPUInfo 将合并后的 CPU 信息拆回格式化函数使用的静态信息和使用率采样
+func splitCPUInfo(info types.CPUInfo) (cpuStatic, cpuSample) {
+	static := cpuStatic{
+		ModelName:    info.ModelName,
+		Sockets:      info.Sockets,
+		Cores:        info.Cores,
+		LogicalCores: info.LogicalCores,
+		Frequency:    info.Frequency,
+	}
+	sample := cpuSample{
+		Usage:       info.Usage,
+		Scheduler:   info.Scheduler,
+		Thermal:     info.Thermal,
+		LastUpdated: info.LastUpdated,
+	}
+	return static, sample
+}
+
+func TestGoldenSystemOverview(t *testing.T) {
+	tool := NewSystemTool(nil, CacheOptions{})
+	tool.platform = platformLinux
+	assertGolden(t, "system_overview", tool.formatSystemInfo(fixtures.SystemInfo(), true))
+
+	windows := NewSystemTool(nil, CacheOptions{})
+	windows.platform = platformWindows
+	info := fixtures.SystemInfo()
+	info.Load = nil
+	info.ContainerRuntime = "docker"
+	assertGolden(t, "system_overview_windows", windows.formatSystemInfo(info, true))
+}
+
+func TestGoldenCPUInfo(t *testing.T) {
+	static, sample := splitCPUInfo(fixtures.CPUInfo())
+	for _, style := range goldenStyles {
+		tool := NewCPUTool(nil, CacheOptions{}, NewOutputStyle(style, 0))
+		assertGolden(t, "cpu_info_"+style, tool.formatCPUInfo(static, sample, "1s", false))
+		assertGolden(t, "cpu_info_compact_"+style, tool.formatCPUInfo(static, sample, "1s", true))
+	}
+}
+
+func TestGoldenMemoryInfo(t *testing.T) {
+	for _, style := range goldenStyles {
+		tool := NewMemoryTool(nil, CacheOptions{}, NewOutputStyle(style, 0))
+		tool.platform = platformLinux
+		assertGolden(t, "memory_info_"+style, tool.formatMemoryInfo(fixtures.MemoryInfo(), false))
+		assertGolden(t, "memory_info_compact_"+style, tool.formatMemoryInfo(fixtures.MemoryInfo(), true))
+	}
+
+	windows := NewMemoryTool(nil, CacheOptions{}, NewOutputStyle(StylePlain, 0))
+	windows.platform = platformWindows
+	info := fixtures.MemoryInfo()
+	info.Detail = nil
+	assertGolden(t, "memory_info_windows", windows.formatMemoryInfo(info, false))
+}
+
+func TestGoldenDiskInfo(t *testing.T) {
+	daysToFull := 12.3
+	trends := map[string]usageProjection{
+		"/": {
+			Samples: 20, Oldest: fixtures.At.Add(-7 * 24 * time.Hour), Newest: fixtures.At,
+			Current: 91, ChangePercent: 4.5, DaysToFull: &daysToFull, Meaningful: true,
+		},
+	}
+	for _, style := range goldenStyles {
+		tool := NewDiskTool(nil, CacheOptions{}, PartitionFilter{}, NewOutputStyle(style, 0), nil)
+		assertGolden(t, "disk_info_"+style, tool.formatDiskInfo(fixtures.DiskInfo(), false, trends))
+		assertGolden(t, "disk_info_compact_"+style, tool.formatDiskInfo(fixtures.DiskInfo(), true, trends))
+	}
+
+	tool := NewDiskTool(nil, CacheOptions{}, PartitionFilter{}, NewOutputStyle(StyleRich, 0), nil)
+	assertGolden(t, "disk_info_empty", tool.formatDiskInfo(types.DiskInfo{LastUpdated: fixtures.At}, false, nil))
+}
+
+// fullAndEmptyMemory 内存用满、交换未使用的内存信息
+func fullAndEmptyMemory() types.MemoryInfo {
+	info := fixtures.MemoryInfo()
+	info.Used, info.Available, info.Free, info.UsedPercent = info.Total, 0, 0, 100
+	info.Swap.Used, info.Swap.Free, info.Swap.UsedPercent = 0, info.Swap.Total, 0
+	info.Detail = nil
+	return info
+}
+
+// barDiskInfo 使用率为 0%、4.9%、50%、95.5% 和 100% 的分区
+func barDiskInfo() types.DiskInfo {
+	info := types.DiskInfo{LastUpdated: fixtures.At}
+	for i, percent := range []float64{0, 4.9, 50, 95.5, 100} {
+		total := uint64(100 * gb)
+		used := uint64(percent / 100 * float64(total))
+		info.Partitions = append(info.Partitions, types.DiskPartition{
+			Device: fmt.Sprintf("/dev/sd%c1", 'a'+i), Mountpoint: fmt.Sprintf("/mnt/disk%d", i), Fstype: "ext4",
+			Total: total, Used: used, Free: total - used, UsedPercent: percent,
+		})
+	}
+	return info
+}
+
+func TestGoldenUsageBarWidths(t *testing.T) {
+	// 0% 和 100% 的使用率条分别为全空和全满，宽度按配置，不超出
+	for _, width := range []int{5, 20} {
+		memoryTool := NewMemoryTool(nil, CacheOptions{}, NewOutputStyle(StyleRich, width))
+		memoryTool.platform = platformLinux
+		diskTool := NewDiskTool(nil, CacheOptions{}, PartitionFilter{}, NewOutputStyle(StyleRich, width), nil)
+		for _, compact := range []bool{false, true} {
+			name := fmt.Sprintf("_width%d", width)
+			if compact {
+				name = "_compact" + name
+			}
+			assertGolden(t, "memory_info_bars"+name, memoryTool.formatMemoryInfo(fullAndEmptyMemory(), compact))
+			assertGolden(t, "disk_info_bars"+name, diskTool.formatDiskInfo(barDiskInfo(), compact, nil))
+		}
+	}
+
+	// plain 风格的默认输出不显示使用率条
+	plainMemory := NewMemoryTool(nil, CacheOptions{}, NewOutputStyle(StylePlain, 20))
+	plainMemory.platform = platformLinux
+	plainDisk := NewDiskTool(nil, CacheOptions{}, PartitionFilter{}, NewOutputStyle(StylePlain, 20), nil)
+	for _, text := range []string{plainMemory.formatMemoryInfo(fullAndEmptyMemory(), false), plainDisk.formatDiskInfo(barDiskInfo(), false, nil)} {
+		if strings.Contains(text, "█") || strings.Contains(text, "[---") {
+			t.Errorf("plain output shows usage bars:\n%s", text)
+		}
+	}
+}
+
+func TestUsageBarsOnlyInText(t *testing.T) {
+	useFakeMem(t, &fakeMemProvider{
+		virtual: VirtualMemoryStat{Total: 8 * gb, Used: 8 * gb, UsedPercent: 100},
+		swap:    SwapMemoryStat{Total: 2 * gb, Used: gb, Free: gb, UsedPercent: 50},
+	})
+	useFakeDisk(t, &fakeDiskProvider{
+		partitions: []PartitionStat{{Device: "/dev/sda1", Mountpoint: "/", Fstype: "ext4"}},
+		usage:      map[string]UsageStat{"/": {Path: "/", Fstype: "ext4", Total: 100 * gb, Used: 100 * gb, UsedPercent: 100}},
+	})
+	style := NewOutputStyle(StyleRich, 20)
+	memoryTool := NewMemoryTool(storage.NewMemoryCache(), CacheOptions{}, style)
+	memoryTool.platform = platformWindows
+
+	// rich 风格的文本有使用率条，结构化结果（JSON 输出）只有数值
+	for _, tool := range []types.StructuredTool{memoryTool, NewDiskTool(storage.NewMemoryCache(), CacheOptions{}, PartitionFilter{}, style, nil)} {
+		for _, compact := range []bool{false, true} {
+			text, structured, err := tool.ExecuteStructured(context.Background(), map[string]interface{}{"compact": compact})
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := json.Marshal(structured)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(text, "[████████████████████]") || strings.Contains(string(data), "█") {
+				t.Errorf("%s compact=%v: bars in text %v, in JSON %v", tool.(types.MonitorTool).GetName(), compact, strings.Contains(text, "█"), strings.Contains(string(data), "█"))
+			}
+		}
+	}
+}
+
+func TestGoldenNetworkStats(t *testing.T) {
+	tool := NewNetworkTool(nil, CacheOptions{}, time.Minute, nil)
+	tool.permissions = fixtures.Permissions
+	assertGolden(t, "network_stats", tool.formatNetworkInfo(fixtures.NetworkInfo(), nil, true))
+	assertGolden(t, "network_stats_no_connections", tool.formatNetworkInfo(fixtures.NetworkInfo(), nil, false))
+
+	rates := map[string]interfaceRate{
+		"eth0": {SendRate: 1536000, RecvRate: 4194304, Elapsed: 30 * time.Second},
+		"eth1": {CounterReset: true, Elapsed: 30 * time.Second},
+	}
+	assertGolden(t, "network_stats_rates", tool.formatNetworkInfo(fixtures.NetworkInfo(), rates, false))
+
+	unprivileged := NewNetworkTool(nil, CacheOptions{}, time.Minute, nil)
+	unprivileged.permissions = func() *types.PermissionProfile {
+		profile := fixtures.Permissions()
+		profile.Privileged = false
+		return profile
+	}
+	info := fixtures.NetworkInfo()
+	info.Connections.NoPID = 2
+	info.Connections.Truncated = true
+	info.Connections.Total = 40
+	assertGolden(t, "network_stats_unprivileged", unprivileged.formatNetworkInfo(info, nil, true))
+}
+
+func TestGoldenProcessList(t *testing.T) {
+	query := processQuery{SortBy: "memory", Limit: 5}
+	for _, platform := range []string{platformLinux, platformWindows} {
+		tool := NewProcessTool(nil, CacheOptions{})
+		tool.platform = platform
+		tool.permissions = fixtures.Permissions
+		assertGolden(t, "top_processes_"+platform, tool.formatProcessList(fixtures.ProcessList(), query))
+	}
+
+	tool := NewProcessTool(nil, CacheOptions{})
+	tool.platform = platformLinux
+	tool.permissions = fixtures.Permissions
+	page := fixtures.ProcessList()
+	page.Offset = 5
+	page.NextOffset = 0
+	page.Inaccessible = 3
+	assertGolden(t, "top_processes_page", tool.formatProcessList(page, processQuery{SortBy: "cpu", Limit: 5}))
+	assertGolden(t, "top_processes_groups", tool.formatProcessGroups(fixtures.ProcessGroups(), "memory", 3))
+}
+
+func TestGoldenProcessDetail(t *testing.T) {
+	started := fixtures.At.Add(-(26*time.Hour + 5*time.Minute))
+	soft, hard := uint64(1024), uint64(524288)
+	detail := processDetail{
+		PID:         4242,
+		Name:        "api",
+		PPID:        1,
+		Username:    "www-data",
+		Status:      "sleep",
+		CreateTime:  &started,
+		Cmdline:     []string{"/usr/bin/api", "--port", "8080"},
+		CPUPercent:  1.5,
+		RSSBytes:    64 << 20,
+		VMSBytes:    1 << 30,
+		NumFDs:      37,
+		LastUpdated: fixtures.At,
+		Environ: &processEnviron{
+			Vars:          []envVar{{Name: "HOME", Value: "/srv"}, {Name: "API_TOKEN", Value: redactedValue, Redacted: true}},
+			RedactedCount: 1,
+		},
+		Limits: []processLimit{{Name: "nofile", Soft: &soft, Hard: &hard}, {Name: "core", Soft: nil, Hard: nil, Unit: "bytes"}},
+		Notes:  []string{"没有权限读取资源限制（需要以 root 或进程所有者身份运行）"},
+	}
+	assertGolden(t, "process_detail", formatProcessDetail(detail, true, true))
+}
+
+func TestGoldenStorageStats(t *testing.T) {
+	oldest := fixtures.At.Add(-3 * 24 * time.Hour)
+	newest := fixtures.At.Add(-90 * time.Second)
+	stats := types.StorageStats{
+		Backend:        "json",
+		KeyCount:       12,
+		DataDir:        "/var/lib/system-mcp",
+		TotalBytes:     3 << 20,
+		FileCount:      14,
+		OldestSnapshot: &oldest,
+		NewestSnapshot: &newest,
+		Partition:      &types.StoragePartition{TotalBytes: 100 << 30, FreeBytes: 40 << 30, UsedPercent: 60},
+	}
+	assertGolden(t, "storage_stats", formatStorageStats(stats, fixtures.At))
+}
@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"mcp-example/internal/fswalk"
+)
+
+// 文件系统扫描限制（largest_directories、recent_large_files 共用）
+const (
+	// maxWalkEntries 单次调用最多访问的文件和目录数，超过后停止扫描并返回已统计的部分
+	maxWalkEntries = 500000
+	// walkTimeout 单次调用的扫描时限
+	walkTimeout = 30 * time.Second
+	// walkProgressInterval 每访问多少项报告一次进度
+	walkProgressInterval = 10000
+)
+
+// walkStats 扫描统计（JSON 输出）
+type walkStats struct {
+	Visited          int      `json:"visited_entries"`
+	PermissionDenied int      `json:"permission_denied,omitempty"`
+	DeniedExamples   []string `json:"denied_examples,omitempty"`
+	OtherErrors      int      `json:"other_errors,omitempty"`
+	OtherFilesystems int      `json:"other_filesystems,omitempty"`
+	Symlinks         int      `json:"symlinks,omitempty"`
+	// Truncated 表示因访问项数上限或时限提前停止，结果只覆盖已扫描的部分
+	Truncated       bool   `json:"truncated"`
+	TruncatedReason string `json:"truncated_reason,omitempty"`
+	ElapsedMs       int64  `json:"elapsed_ms"`
+}
+
+// walkPath 以共用的限制扫描 root，并向客户端报告进度。root 的错误转换为工具错误
+func walkPath(ctx context.Context, root string, visitor fswalk.Visitor) (fswalk.Result, error) {
+	root = filepath.Clean(root)
+	reportProgress(ctx, 0, 0, "开始扫描 "+root)
+
+	result, err := fswalk.Walk(ctx, root, fswalk.Options{
+		MaxEntries: maxWalkEntries,
+		Timeout:    walkTimeout,
+		Progress: func(visited int) {
+			reportProgress(ctx, float64(visited), 0, fmt.Sprintf("已扫描 %d 项", visited))
+		},
+		ProgressInterval: walkProgressInterval,
+	}, visitor)
+	switch {
+	case ctx.Err() != nil:
+		return result, ctx.Err()
+	case errors.Is(err, fs.ErrNotExist):
+		return result, notFound("路径不存在: %s", root)
+	case errors.Is(err, fs.ErrPermission):
+		return result, &Error{Code: ErrPermission, Message: fmt.Sprintf("没有权限访问: %s", root), Err: err}
+	case errors.Is(err, fswalk.ErrNotDirectory):
+		return result, badArgument("不是目录: %s", root)
+	case err != nil:
+		return result, wrapError("读取路径失败", err)
+	}
+
+	reportProgress(ctx, float64(result.Visited), float64(result.Visited), "扫描完成")
+	return result, nil
+}
+
+// newWalkStats 转换扫描统计
+func newWalkStats(result fswalk.Result) walkStats {
+	return walkStats{
+		Visited:          result.Visited,
+		PermissionDenied: result.PermissionDenied,
+		DeniedExamples:   result.DeniedExamples,
+		OtherErrors:      result.OtherErrors,
+		OtherFilesystems: result.OtherFilesystems,
+		Symlinks:         result.Symlinks,
+		Truncated:        result.Truncated,
+		TruncatedReason:  result.TruncatedReason,
+		ElapsedMs:        result.Elapsed.Milliseconds(),
+	}
+}
+
+// formatWalkStats 格式化扫描统计：访问项数、耗时、是否完整及跳过的路径
+func formatWalkStats(stats walkStats) string {
+	var result string
+
+	result += fmt.Sprintf("\n📊 扫描了 %d 项，耗时 %.2fs（大小为文件的表观大小）\n", stats.Visited, float64(stats.ElapsedMs)/1000)
+	if stats.Truncated {
+		result += fmt.Sprintf("⚠️ 扫描未完成（%s），结果只覆盖已扫描的部分\n", stats.TruncatedReason)
+	}
+	if stats.PermissionDenied > 0 {
+		result += fmt.Sprintf("🔒 %d 个路径没有读取权限，已跳过，如 %s\n", stats.PermissionDenied, stats.DeniedExamples[0])
+	}
+	if stats.OtherErrors > 0 {
+		result += fmt.Sprintf("⚠️ %d 个路径读取失败（可能在扫描期间被删除），已跳过\n", stats.OtherErrors)
+	}
+	if stats.OtherFilesystems > 0 {
+		result += fmt.Sprintf("💡 跳过了 %d 个其他文件系统的挂载点\n", stats.OtherFilesystems)
+	}
+	if stats.Symlinks > 0 {
+		result += fmt.Sprintf("💡 跳过了 %d 个符号链接（不跟随）\n", stats.Symlinks)
+	}
+
+	return result
+}
@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"mcp-example/internal/types"
+)
+
+// 健康检查的严重程度
+const (
+	severityOK       = "ok"
+	severityWarning  = "warning"
+	severityCritical = "critical"
+)
+
+// 整体健康状态
+const (
+	healthHealthy  = "healthy"
+	healthWarning  = "warning"
+	healthCritical = "critical"
+)
+
+// 每条发现扣除的分数
+const (
+	warningPenalty  = 10
+	criticalPenalty = 25
+)
+
+// healthCheck 一项待评估的测量值
+type healthCheck struct {
+	Metric         string
+	Selector       string
+	Value          float64
+	Unit           string
+	Threshold      types.Threshold
+	Recommendation string
+}
+
+// healthFinding 超过阈值的测量值
+type healthFinding struct {
+	Metric         string  `json:"metric"`
+	Selector       string  `json:"selector,omitempty"`
+	Value          float64 `json:"value"`
+	Unit           string  `json:"unit"`
+	Threshold      float64 `json:"threshold"`
+	Severity       string  `json:"severity"`
+	Recommendation string  `json:"recommendation"`
+}
+
+// healthSummary 评估结果
+type healthSummary struct {
+	Status   string          `json:"status"`
+	Score    int             `json:"score"`
+	Checked  int             `json:"checked"`
+	Findings []healthFinding `json:"findings"`
+}
+
+// evaluateThreshold 评估单个值，返回严重程度和被超过的阈值
+func evaluateThreshold(value float64, threshold types.Threshold) (string, float64) {
+	if threshold.Critical > 0 && value >= threshold.Critical {
+		return severityCritical, threshold.Critical
+	}
+	if threshold.Warning > 0 && value >= threshold.Warning {
+		return severityWarning, threshold.Warning
+	}
+	return severityOK, 0
+}
+
+// evaluateChecks 评估所有测量值，生成整体状态、分数（100 分起，每条警告扣 10 分、严重扣 25 分）和发现列表。
+// 发现按严重程度排序，严重的在前，同级别保持检查顺序。
+func evaluateChecks(checks []healthCheck) healthSummary {
+	summary := healthSummary{
+		Status:   healthHealthy,
+		Score:    100,
+		Checked:  len(checks),
+		Findings: []healthFinding{},
+	}
+
+	var warnings []healthFinding
+	for _, check := range checks {
+		severity, limit := evaluateThreshold(check.Value, check.Threshold)
+		if severity == severityOK {
+			continue
+		}
+
+		finding := healthFinding{
+			Metric:         check.Metric,
+			Selector:       check.Selector,
+			Value:          check.Value,
+			Unit:           check.Unit,
+			Threshold:      limit,
+			Severity:       severity,
+			Recommendation: check.Recommendation,
+		}
+		if severity == severityCritical {
+			summary.Findings = append(summary.Findings, finding)
+			summary.Score -= criticalPenalty
+			summary.Status = healthCritical
+		} else {
+			warnings = append(warnings, finding)
+			summary.Score -= warningPenalty
+			if summary.Status == healthHealthy {
+				summary.Status = healthWarning
+			}
+		}
+	}
+	summary.Findings = append(summary.Findings, warnings...)
+
+	if summary.Score < 0 {
+		summary.Score = 0
+	}
+
+	return summary
+}
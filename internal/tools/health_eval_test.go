@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+func TestEvaluateThreshold(t *testing.T) {
+	both := types.Threshold{Warning: 80, Critical: 90}
+	cases := []struct {
+		name      string
+		value     float64
+		threshold types.Threshold
+		severity  string
+		limit     float64
+	}{
+		{"below warning", 79.99, both, severityOK, 0},
+		// 达到阈值即触发，边界值属于更高的级别
+		{"at warning", 80, both, severityWarning, 80},
+		{"between", 89.99, both, severityWarning, 80},
+		{"at critical", 90, both, severityCritical, 90},
+		{"above critical", 150, both, severityCritical, 90},
+		{"zero value", 0, both, severityOK, 0},
+		// 阈值为 0 表示不检查该级别
+		{"warning only", 1000, types.Threshold{Warning: 5}, severityWarning, 5},
+		{"critical only below", 0.5, types.Threshold{Critical: 1}, severityOK, 0},
+		{"critical only at", 1, types.Threshold{Critical: 1}, severityCritical, 1},
+		{"disabled", 1e9, types.Threshold{}, severityOK, 0},
+		// 警告阈值高于严重阈值（配置错误）时严重优先
+		{"inverted", 85, types.Threshold{Warning: 95, Critical: 85}, severityCritical, 85},
+	}
+	for _, c := range cases {
+		severity, limit := evaluateThreshold(c.value, c.threshold)
+		if severity != c.severity || limit != c.limit {
+			t.Errorf("%s: evaluateThreshold(%v, %+v) = %s, %v; want %s, %v", c.name, c.value, c.threshold, severity, limit, c.severity, c.limit)
+		}
+	}
+}
+
+// percentCheck 阈值为 80/90 的百分比测量值
+func percentCheck(metric, selector string, value float64) healthCheck {
+	return healthCheck{Metric: metric, Selector: selector, Value: value, Unit: "%", Threshold: types.Threshold{Warning: 80, Critical: 90}, Recommendation: "disk_info {}"}
+}
+
+func TestEvaluateChecks(t *testing.T) {
+	cases := []struct {
+		name     string
+		checks   []healthCheck
+		status   string
+		score    int
+		findings []string
+	}{
+		{"no checks", nil, healthHealthy, 100, nil},
+		{"all ok", []healthCheck{percentCheck("cpu_percent", "", 79.9), percentCheck("memory_percent", "", 0)}, healthHealthy, 100, nil},
+		{"one warning", []healthCheck{percentCheck("cpu_percent", "", 80)}, healthWarning, 90, []string{"cpu_percent:warning"}},
+		{"one critical", []healthCheck{percentCheck("cpu_percent", "", 90)}, healthCritical, 75, []string{"cpu_percent:critical"}},
+		// 严重的在前，同级别保持检查顺序；一条严重即为整体严重
+		{"mixed", []healthCheck{
+			percentCheck("disk_percent", "/", 85),
+			percentCheck("disk_percent", "/data", 95),
+			percentCheck("memory_percent", "", 81),
+			percentCheck("disk_percent", "/var", 99),
+		}, healthCritical, 30, []string{"disk_percent /data:critical", "disk_percent /var:critical", "disk_percent /:warning", "memory_percent:warning"}},
+		// 分数不低于 0
+		{"many criticals", []healthCheck{
+			percentCheck("a", "", 100), percentCheck("b", "", 100), percentCheck("c", "", 100),
+			percentCheck("d", "", 100), percentCheck("e", "", 100),
+		}, healthCritical, 0, []string{"a:critical", "b:critical", "c:critical", "d:critical", "e:critical"}},
+	}
+	for _, c := range cases {
+		summary := evaluateChecks(c.checks)
+		var findings []string
+		for _, finding := range summary.Findings {
+			name := finding.Metric
+			if finding.Selector != "" {
+				name += " " + finding.Selector
+			}
+			findings = append(findings, name+":"+finding.Severity)
+		}
+		if summary.Status != c.status || summary.Score != c.score || summary.Checked != len(c.checks) || strings.Join(findings, ",") != strings.Join(c.findings, ",") {
+			t.Errorf("%s: summary = %s %d (%d checked) %v; want %s %d %v", c.name, summary.Status, summary.Score, summary.Checked, findings, c.status, c.score, c.findings)
+		}
+		// 没有发现时为空数组，JSON 中不是 null
+		if summary.Findings == nil {
+			t.Errorf("%s: Findings is nil", c.name)
+		}
+	}
+
+	// 发现记录被超过的阈值和建议的下一步
+	summary := evaluateChecks([]healthCheck{percentCheck("disk_percent", "/data", 95)})
+	want := healthFinding{Metric: "disk_percent", Selector: "/data", Value: 95, Unit: "%", Threshold: 90, Severity: severityCritical, Recommendation: "disk_info {}"}
+	if summary.Findings[0] != want {
+		t.Errorf("finding = %+v, want %+v", summary.Findings[0], want)
+	}
+}
+
+func TestFormatHealthReport(t *testing.T) {
+	generated := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	report := healthReport{
+		healthSummary: evaluateChecks([]healthCheck{
+			percentCheck("disk_percent", "/data", 95),
+			{Metric: "clock_offset_ms", Value: 250, Unit: "ms", Threshold: types.Threshold{Warning: 100, Critical: 1000}, Recommendation: "time_sync {}"},
+			{Metric: "load_per_core", Value: 0.5, Threshold: types.Threshold{Warning: 1, Critical: 2}},
+		}),
+		GeneratedAt: generated,
+	}
+	text := (&HealthReportTool{}).formatReport(report)
+	for _, want := range []string{
+		"状态: 🔴 严重\n",
+		"评分: 65/100（已检查 3 项）\n",
+		"• [严重] disk_percent /data: 95.0%（阈值 90.0%）\n  建议: disk_info {}\n",
+		"• [警告] clock_offset_ms: 250 ms（阈值 100 ms）\n  建议: time_sync {}\n",
+		"📅 更新时间: 2024-05-06 07:08:09\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("report missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "load_per_core") {
+		t.Errorf("report lists a check within its thresholds:\n%s", text)
+	}
+
+	healthy := (&HealthReportTool{}).formatReport(healthReport{healthSummary: evaluateChecks(nil), GeneratedAt: generated})
+	if !strings.Contains(healthy, "状态: ✅ 健康\n评分: 100/100（已检查 0 项）\n\n✅ 所有指标均在阈值内\n") {
+		t.Errorf("healthy report:\n%s", healthy)
+	}
+}
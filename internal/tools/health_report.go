@@ -0,0 +1,457 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/permissions"
+	"mcp-example/internal/types"
+)
+
+// healthReport 健康报告，同时作为 structuredContent 返回
+type healthReport struct {
+	healthSummary
+	// OOMRisk memory_info 的 OOM 风险评估（等级、因素和输入），同时作为 oom_risk 检查
+	OOMRisk *types.OOMRisk `json:"oom_risk,omitempty"`
+	// Incidents 未解决的告警事件数，未启用事件记录时为空
+	Incidents   *IncidentCounts     `json:"incidents,omitempty"`
+	Notes       []string            `json:"notes,omitempty"`
+	GeneratedAt time.Time           `json:"generated_at"`
+	Host        *types.HostIdentity `json:"host,omitempty"`
+	// Permissions 权限探测结果，说明哪些检查的数据可能因权限不足而不完整
+	Permissions *types.PermissionProfile `json:"permissions,omitempty"`
+}
+
+// HealthReportTool 健康报告工具：一次调用回答“这台机器是否正常”
+type HealthReportTool struct {
+	thresholds      types.Thresholds
+	thresholdsMutex sync.RWMutex
+	cpuTool         *CPUTool
+	memoryTool      *MemoryTool
+	diskTool        *DiskTool
+	timeSyncTool    *TimeSyncTool
+	selfInfoTool    *SelfInfoTool
+	storageStats    StorageStatsFunc
+	collectorStats  CollectorStatsFunc
+	incidents       *IncidentLog
+}
+
+// NewHealthReportTool 创建新的健康报告工具，storageStats 用于检查服务器数据目录的占用，
+// collectorStats 用于检查后台采集跳过的周期，incidents 为 nil 时不报告未解决的告警事件数
+func NewHealthReportTool(thresholds types.Thresholds, cpuTool *CPUTool, memoryTool *MemoryTool, diskTool *DiskTool, timeSyncTool *TimeSyncTool, selfInfoTool *SelfInfoTool, storageStats StorageStatsFunc, collectorStats CollectorStatsFunc, incidents *IncidentLog) *HealthReportTool {
+	return &HealthReportTool{
+		thresholds:     thresholds,
+		cpuTool:        cpuTool,
+		memoryTool:     memoryTool,
+		diskTool:       diskTool,
+		timeSyncTool:   timeSyncTool,
+		selfInfoTool:   selfInfoTool,
+		storageStats:   storageStats,
+		collectorStats: collectorStats,
+		incidents:      incidents,
+	}
+}
+
+// SetThresholds 替换健康检查阈值（配置重新加载时调用），进行中的检查继续使用旧阈值
+func (ht *HealthReportTool) SetThresholds(thresholds types.Thresholds) {
+	ht.thresholdsMutex.Lock()
+	defer ht.thresholdsMutex.Unlock()
+
+	ht.thresholds = thresholds
+}
+
+// currentThresholds 获取当前的健康检查阈值
+func (ht *HealthReportTool) currentThresholds() types.Thresholds {
+	ht.thresholdsMutex.RLock()
+	defer ht.thresholdsMutex.RUnlock()
+
+	return ht.thresholds
+}
+
+// GetName 获取工具名称
+func (ht *HealthReportTool) GetName() string {
+	return "health_report"
+}
+
+// GetDescription 获取工具描述
+func (ht *HealthReportTool) GetDescription() string {
+	return "检查 CPU、内存、交换空间、磁盘、负载、僵尸进程、时钟同步、服务器自身内存和数据目录，给出整体状态、评分和建议的下一步操作"
+}
+
+// GetAnnotations 获取工具注解
+func (ht *HealthReportTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("健康报告")
+}
+
+// GetInputSchema 获取输入模式
+func (ht *HealthReportTool) GetInputSchema() types.InputSchema {
+	return argsSchema(noArgs{})
+}
+
+// Examples 获取调用示例
+func (ht *HealthReportTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "整体健康状态、评分、各项检查结果和建议",
+			Arguments:   map[string]interface{}{},
+		},
+	}
+}
+
+// Execute 执行健康检查
+func (ht *HealthReportTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	text, _, err := ht.ExecuteStructured(ctx, args)
+	return text, err
+}
+
+// ExecuteStructured 执行健康检查，同时返回结构化报告
+func (ht *HealthReportTool) ExecuteStructured(ctx context.Context, args map[string]interface{}) (string, interface{}, error) {
+	checks, oomRisk, notes := ht.collectChecks(ctx)
+	if err := ctx.Err(); err != nil {
+		return "", nil, err
+	}
+
+	report := healthReport{
+		healthSummary: evaluateChecks(checks),
+		OOMRisk:       oomRisk,
+		Notes:         notes,
+		GeneratedAt:   time.Now(),
+		Host:          identity.Get(),
+		Permissions:   permissions.Get(),
+	}
+	if ht.incidents != nil {
+		if counts, err := ht.incidents.Counts(); err != nil {
+			report.Notes = append(report.Notes, fmt.Sprintf("告警事件不可用: %v", err))
+		} else {
+			report.Incidents = &counts
+		}
+	}
+
+	return ht.formatReport(report), report, nil
+}
+
+// collectChecks 采集各项测量值，单项采集失败时记录备注并继续。同时返回内存信息中的 OOM 风险评估
+func (ht *HealthReportTool) collectChecks(ctx context.Context) ([]healthCheck, *types.OOMRisk, []string) {
+	var checks []healthCheck
+	var oomRisk *types.OOMRisk
+	var notes []string
+	thresholds := ht.currentThresholds()
+
+	cpuInfo, err := ht.cpuTool.GetCPUData(ctx, time.Second)
+	if err != nil {
+		notes = append(notes, fmt.Sprintf("CPU 使用率不可用: %v", err))
+	} else {
+		checks = append(checks, healthCheck{
+			Metric:         "cpu_percent",
+			Value:          cpuInfo.Usage.Total,
+			Unit:           "%",
+			Threshold:      thresholds.CPUPercent,
+			Recommendation: `top_processes {"sort_by": "cpu"}`,
+		})
+	}
+
+	memInfo, err := ht.memoryTool.GetMemoryData(ctx)
+	if err != nil {
+		notes = append(notes, fmt.Sprintf("内存信息不可用: %v", err))
+	} else {
+		checks = append(checks, healthCheck{
+			Metric:         "memory_percent",
+			Value:          memInfo.UsedPercent,
+			Unit:           "%",
+			Threshold:      thresholds.MemoryPercent,
+			Recommendation: `top_processes {"sort_by": "memory"}`,
+		})
+		if memInfo.Swap.Total > 0 {
+			checks = append(checks, healthCheck{
+				Metric:         "swap_percent",
+				Value:          memInfo.Swap.UsedPercent,
+				Unit:           "%",
+				Threshold:      thresholds.SwapPercent,
+				Recommendation: `memory_info {"detailed": "true"}`,
+			})
+		}
+		oomRisk = memInfo.OOMRisk
+		if check, note, ok := oomRiskCheck(oomRisk); ok {
+			checks = append(checks, check)
+		} else if note != "" {
+			notes = append(notes, note)
+		}
+	}
+
+	diskInfo, err := ht.diskTool.GetDiskData(ctx, false)
+	if err != nil {
+		notes = append(notes, fmt.Sprintf("磁盘信息不可用: %v", err))
+	} else {
+		// 跳过的挂载点没有使用率，不参与检查
+		notes = append(notes, describeSkippedMounts(diskInfo.SkippedMounts)...)
+		checks = append(checks, diskChecks(diskInfo.Partitions, thresholds.DiskPercent)...)
+	}
+
+	if !hasLoadAverage(hostPlatform) {
+		notes = append(notes, "当前平台没有系统负载，跳过 load_per_core 检查")
+	} else if loadPerCore, err := loadPerCore(ctx); err != nil {
+		notes = append(notes, fmt.Sprintf("系统负载不可用: %v", err))
+	} else {
+		checks = append(checks, healthCheck{
+			Metric:         "load_per_core",
+			Value:          loadPerCore,
+			Threshold:      thresholds.LoadPerCore,
+			Recommendation: `cpu_info {"detailed": "true"}`,
+		})
+	}
+
+	if !hasProcessStatus(hostPlatform) {
+		notes = append(notes, "当前平台不提供进程状态，跳过 zombies 检查")
+	} else if zombies, err := countZombies(ctx); err != nil {
+		notes = append(notes, fmt.Sprintf("僵尸进程数不可用: %v", err))
+	} else {
+		checks = append(checks, healthCheck{
+			Metric:         "zombies",
+			Value:          float64(zombies),
+			Threshold:      thresholds.Zombies,
+			Recommendation: `top_processes {"include_kernel_threads": "true"}`,
+		})
+	}
+
+	clockThreshold := time.Duration(thresholds.ClockOffsetMs.Warning * float64(time.Millisecond))
+	timeReport := ht.timeSyncTool.buildReport(ctx, clockThreshold)
+	if status := timeReport.Status; status.Synchronized != nil && !*status.Synchronized {
+		// 未同步本身就是一条警告：测量值 1 达到阈值 1
+		checks = append(checks, healthCheck{
+			Metric:         "clock_unsynchronized",
+			Value:          1,
+			Threshold:      types.Threshold{Warning: 1},
+			Recommendation: `time_sync {}`,
+		})
+	}
+	if offset := timeReport.Status.Offset; offset != nil {
+		checks = append(checks, healthCheck{
+			Metric:         "clock_offset_ms",
+			Value:          math.Abs(float64(*offset) / float64(time.Millisecond)),
+			Unit:           "ms",
+			Threshold:      thresholds.ClockOffsetMs,
+			Recommendation: `time_sync {}`,
+		})
+	} else if timeReport.Status.Synchronized == nil {
+		notes = append(notes, "时钟同步状态不可用")
+	}
+
+	// 服务器自身内存只在超过阈值时出现在发现列表中
+	if self, err := ht.selfInfoTool.collectSelfData(ctx); err != nil {
+		notes = append(notes, fmt.Sprintf("服务器自身资源不可用: %v", err))
+	} else if self.RSSBytes > 0 {
+		checks = append(checks, healthCheck{
+			Metric:         "self_rss_mb",
+			Value:          float64(self.RSSBytes) / (1024 * 1024),
+			Unit:           "MB",
+			Threshold:      thresholds.SelfRSSMB,
+			Recommendation: `self_info {}`,
+		})
+	}
+
+	// 服务器数据目录的大小和所在分区的使用率，只有文件存储有数据目录
+	if stats, err := ht.storageStats(); err != nil {
+		notes = append(notes, fmt.Sprintf("存储统计不可用: %v", err))
+	} else if stats.DataDir != "" {
+		checks = append(checks, healthCheck{
+			Metric:         "data_dir_mb",
+			Selector:       stats.DataDir,
+			Value:          float64(stats.TotalBytes) / (1024 * 1024),
+			Unit:           "MB",
+			Threshold:      thresholds.DataDirMB,
+			Recommendation: fmt.Sprintf(`largest_directories {"path": %q}`, stats.DataDir),
+		})
+		if stats.Partition != nil {
+			checks = append(checks, healthCheck{
+				Metric:         "data_dir_disk_percent",
+				Selector:       stats.DataDir,
+				Value:          stats.Partition.UsedPercent,
+				Unit:           "%",
+				Threshold:      thresholds.DataDirDiskPercent,
+				Recommendation: `disk_info {}`,
+			})
+		}
+	}
+
+	// 后台采集持续跳过周期说明采集间隔对这台机器太短，历史数据会有空洞；周期太少时比例没有意义
+	if stats := ht.collectorStats(); stats != nil && stats.Runs+stats.SkippedTicks >= minCollectorTicks {
+		checks = append(checks, healthCheck{
+			Metric:         "collector_skip_percent",
+			Value:          stats.SkipPercent,
+			Unit:           "%",
+			Threshold:      thresholds.CollectorSkipPercent,
+			Recommendation: `server_stats {}`,
+		})
+	}
+
+	return checks, oomRisk, notes
+}
+
+// oomRiskCheck OOM 风险等级作为测量值（low 0、medium 1、high 2），medium 为警告、high 为严重。
+// 平台不支持评估（没有等级）时返回评估中的说明
+func oomRiskCheck(risk *types.OOMRisk) (healthCheck, string, bool) {
+	if risk == nil {
+		return healthCheck{}, "", false
+	}
+	if risk.Level == "" {
+		return healthCheck{}, risk.Note, false
+	}
+	return healthCheck{
+		Metric:         "oom_risk",
+		Value:          oomRiskLevels[risk.Level],
+		Threshold:      types.Threshold{Warning: 1, Critical: 2},
+		Recommendation: `memory_info {}`,
+	}, "", true
+}
+
+// diskChecks 各分区的使用率检查，意外以只读方式挂载的分区另加一项检查
+func diskChecks(partitions []types.DiskPartition, threshold types.Threshold) []healthCheck {
+	var checks []healthCheck
+	for _, partition := range partitions {
+		checks = append(checks, healthCheck{
+			Metric:         "disk_percent",
+			Selector:       partition.Mountpoint,
+			Value:          partition.UsedPercent,
+			Unit:           "%",
+			Threshold:      threshold,
+			Recommendation: `metrics_trend {"disk_threshold": "0"}`,
+		})
+		// 意外的只读挂载本身就是严重问题：测量值 1 达到严重阈值 1
+		if partition.ReadOnly && !partition.ExpectedReadOnly {
+			checks = append(checks, healthCheck{
+				Metric:         "disk_read_only",
+				Selector:       partition.Mountpoint,
+				Value:          1,
+				Threshold:      types.Threshold{Critical: 1},
+				Recommendation: `disk_info {}`,
+			})
+		}
+	}
+	return checks
+}
+
+// loadPerCore 1 分钟平均负载除以逻辑核心数
+func loadPerCore(ctx context.Context) (float64, error) {
+	avg, err := providers.Host.LoadAvg(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	cores, err := providers.CPU.Counts(ctx, true)
+	if err != nil || cores <= 0 {
+		return 0, fmt.Errorf("无法获取逻辑核心数: %v", err)
+	}
+
+	return avg.Load1 / float64(cores), nil
+}
+
+// countZombies 统计僵尸进程数量
+func countZombies(ctx context.Context) (int, error) {
+	processes, err := providers.Process.Processes(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	zombies := 0
+	for _, p := range processes {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		statuses, err := p.StatusWithContext(ctx)
+		if err != nil {
+			continue
+		}
+		for _, status := range statuses {
+			if status == processZombie {
+				zombies++
+				break
+			}
+		}
+	}
+
+	return zombies, nil
+}
+
+// formatHealthValue 按单位格式化测量值
+func formatHealthValue(value float64, unit string) string {
+	switch unit {
+	case "%":
+		return fmt.Sprintf("%.1f%%", value)
+	case "ms":
+		return fmt.Sprintf("%.0f ms", value)
+	case "MB":
+		return fmt.Sprintf("%.1f MB", value)
+	default:
+		return fmt.Sprintf("%.2f", value)
+	}
+}
+
+// formatReport 格式化健康报告
+func (ht *HealthReportTool) formatReport(report healthReport) string {
+	var result string
+
+	result += "🩺 健康报告\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+
+	switch report.Status {
+	case healthCritical:
+		result += "状态: 🔴 严重\n"
+	case healthWarning:
+		result += "状态: ⚠️  警告\n"
+	default:
+		result += "状态: ✅ 健康\n"
+	}
+	result += fmt.Sprintf("评分: %d/100（已检查 %d 项）\n", report.Score, report.Checked)
+
+	if len(report.Findings) == 0 {
+		result += "\n✅ 所有指标均在阈值内\n"
+	} else {
+		result += "\n发现:\n"
+		for _, finding := range report.Findings {
+			label := "警告"
+			if finding.Severity == severityCritical {
+				label = "严重"
+			}
+			name := finding.Metric
+			if finding.Selector != "" {
+				name += " " + finding.Selector
+			}
+			result += fmt.Sprintf("• [%s] %s: %s（阈值 %s）\n", label, name,
+				formatHealthValue(finding.Value, finding.Unit), formatHealthValue(finding.Threshold, finding.Unit))
+			result += fmt.Sprintf("  建议: %s\n", finding.Recommendation)
+		}
+	}
+
+	if risk := report.OOMRisk; risk != nil && risk.Level != "" && risk.Level != oomRiskLow {
+		result += fmt.Sprintf("\n🧯 OOM 风险 %s 的因素:\n", risk.Level)
+		for _, factor := range risk.Factors {
+			result += fmt.Sprintf("  • %s\n", factor.Description)
+		}
+	}
+
+	if counts := report.Incidents; counts != nil {
+		result += fmt.Sprintf("\n📟 未解决的告警事件: %d 个未确认, %d 个已确认\n", counts.Open, counts.Acknowledged)
+		if counts.Open+counts.Acknowledged > 0 {
+			result += "  建议: incidents {}\n"
+		}
+	}
+
+	if len(report.Notes) > 0 {
+		result += "\n📝 备注:\n"
+		for _, note := range report.Notes {
+			result += fmt.Sprintf("  %s\n", note)
+		}
+	}
+
+	if report.Permissions != nil {
+		result += fmt.Sprintf("\n🔐 权限: %s\n", permissions.Summary(*report.Permissions))
+	}
+
+	result += fmt.Sprintf("\n📅 更新时间: %s\n", report.GeneratedAt.Format("2006-01-02 15:04:05"))
+
+	return result
+}
@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// HistoryKeyPrefix 采集历史在存储中的键前缀，每天一个文件
+const HistoryKeyPrefix = "history_"
+
+// AnomaliesKey 异常记录在存储中的键，保存最近 MaxAnomalies 条
+const AnomaliesKey = "anomalies"
+
+// MaxAnomalies 异常记录环形缓冲区的容量
+const MaxAnomalies = 500
+
+// HistoryKey 获取指定日期的采集历史存储键
+func HistoryKey(day time.Time) string {
+	return HistoryKeyPrefix + day.Format("20060102")
+}
+
+// PruneHistory 删除 before 所在日期之前的采集历史日文件，返回删除的键。
+// 键名中的日期无法解析的文件不会被删除
+func PruneHistory(dataStorage types.DataStorage, before time.Time) ([]string, error) {
+	keys, err := dataStorage.ListKeysWithPrefix(HistoryKeyPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("列出采集历史失败: %v", err)
+	}
+
+	cutoff := HistoryKey(before)
+	var deleted []string
+	for _, key := range keys {
+		if _, err := time.Parse("20060102", strings.TrimPrefix(key, HistoryKeyPrefix)); err != nil {
+			continue
+		}
+		// 日期格式定长，键可以直接按字符串比较
+		if key >= cutoff {
+			continue
+		}
+		if err := dataStorage.Delete(key); err != nil {
+			return deleted, fmt.Errorf("删除采集历史 %s 失败: %v", key, err)
+		}
+		deleted = append(deleted, key)
+	}
+	return deleted, nil
+}
+
+// timeRangeArgs 按时间范围查询历史的工具共用的参数
+type timeRangeArgs struct {
+	From time.Time `arg:"from" desc:"开始时间（RFC3339，默认 24 小时前）"`
+	To   time.Time `arg:"to" desc:"结束时间（RFC3339，默认当前时间）"`
+}
+
+// resolve 补全未提供的 from/to：to 默认为当前时间，from 默认为 to 之前 span
+func (r timeRangeArgs) resolve(span time.Duration) (time.Time, time.Time, error) {
+	from, to := r.From, r.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-span)
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, argumentError("from", "开始时间必须早于结束时间")
+	}
+	return from, to, nil
+}
+
+// loadHistory 加载时间范围内的采集样本（按时间排序），
+// 缺失或损坏的日文件会被跳过并计入 skipped。日文件按本地时区的日期划分，from/to 为其他时区时先转换为本地时间
+func loadHistory(dataStorage types.DataStorage, from, to time.Time) (samples []types.MetricSample, skipped int) {
+	from, to = from.In(time.Local), to.In(time.Local)
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	for !day.After(to) {
+		var daySamples []types.MetricSample
+		if err := dataStorage.Load(HistoryKey(day), &daySamples); err != nil {
+			skipped++
+		} else {
+			for _, sample := range daySamples {
+				if !sample.Timestamp.Before(from) && !sample.Timestamp.After(to) {
+					samples = append(samples, sample)
+				}
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].Timestamp.Before(samples[j].Timestamp)
+	})
+
+	return samples, skipped
+}
@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+func TestPruneHistory(t *testing.T) {
+	dataStorage := storage.NewMemoryStorage()
+	for _, key := range []string{"history_20240101", "history_20240102", "history_20240103", "history_backup", "anomalies"} {
+		if err := dataStorage.Save(key, []int{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := PruneHistory(dataStorage, time.Date(2024, 1, 2, 15, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(deleted, []string{"history_20240101"}) {
+		t.Fatalf("deleted %v, want only the days before the cutoff day", deleted)
+	}
+	keys, _ := dataStorage.ListKeys()
+	if !slices.Equal(keys, []string{"anomalies", "history_20240102", "history_20240103", "history_backup"}) {
+		t.Fatalf("remaining keys %v", keys)
+	}
+}
+
+func TestLoadHistoryConvertsToLocalDays(t *testing.T) {
+	dataStorage := storage.NewMemoryStorage()
+	// 本地时间 23:30 的样本保存在本地日期的日文件中
+	sampled := time.Date(2024, 1, 2, 23, 30, 0, 0, time.Local)
+	if err := dataStorage.Save(HistoryKey(sampled), []types.MetricSample{{Timestamp: sampled, CPUPercent: 42}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 比本地时区快 12 小时的时区中，同一时刻已经是第二天
+	_, offset := sampled.Zone()
+	ahead := time.FixedZone("ahead", offset+12*3600)
+	from, to := sampled.Add(-10*time.Minute).In(ahead), sampled.Add(10*time.Minute).In(ahead)
+	if HistoryKey(from) == HistoryKey(sampled) {
+		t.Fatal("test zone should put the sample on a different day")
+	}
+
+	samples, skipped := loadHistory(dataStorage, from, to)
+	if len(samples) != 1 || samples[0].CPUPercent != 42 {
+		t.Fatalf("loadHistory returned %d samples (skipped %d), want the sample stored under the local day", len(samples), skipped)
+	}
+	if skipped != 0 {
+		t.Fatalf("skipped = %d, want 0", skipped)
+	}
+}
@@ -0,0 +1,661 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/types"
+)
+
+// incidentsKey 事件记录在存储中的键
+const incidentsKey = "incidents"
+
+// maxIncidents 保留的事件数量上限，超出时先删除最早解决的事件
+const maxIncidents = 500
+
+// DefaultIncidentResolveAfter 指标恢复正常持续多久后自动解决事件
+const DefaultIncidentResolveAfter = 5 * time.Minute
+
+// 事件状态：open 未处理、acknowledged 已确认（仍在持续）、resolved 已解决
+const (
+	IncidentOpen         = "open"
+	IncidentAcknowledged = "acknowledged"
+	IncidentResolved     = "resolved"
+)
+
+// 事件来源：threshold 超过健康检查阈值、anomaly 偏离滚动均值
+const (
+	IncidentSourceThreshold = "threshold"
+	IncidentSourceAnomaly   = "anomaly"
+)
+
+// IncidentNote 事件的备注
+type IncidentNote struct {
+	At   time.Time `json:"at"`
+	Text string    `json:"text"`
+}
+
+// Incident 一次持续的告警：同一来源、指标和选择器的连续超限合并为一个事件
+type Incident struct {
+	ID       string `json:"id"`
+	Source   string `json:"source"`
+	Metric   string `json:"metric"`
+	Selector string `json:"selector,omitempty"`
+	// Severity 事件期间出现过的最高严重程度（warning 或 critical）
+	Severity  string    `json:"severity"`
+	State     string    `json:"state"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	PeakValue float64   `json:"peak_value"`
+	LastValue float64   `json:"last_value"`
+	// Threshold 最近一次超限时的阈值（threshold 来源）或滚动均值（anomaly 来源）
+	Threshold float64 `json:"threshold"`
+	// Occurrences 超限的样本数
+	Occurrences    int        `json:"occurrences"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	// RecoveredSince 指标恢复正常的开始时间，持续 resolveAfter 后事件自动解决，再次超限时清除
+	RecoveredSince *time.Time `json:"recovered_since,omitempty"`
+	// ReopenCount 解决后在 resolveAfter 内再次超限而重新打开的次数
+	ReopenCount int            `json:"reopen_count,omitempty"`
+	Notes       []IncidentNote `json:"notes,omitempty"`
+}
+
+// key 事件的去重键，同一键同时最多有一个未解决的事件
+func (incident Incident) key() string {
+	return incident.Source + "|" + incident.Metric + "|" + incident.Selector
+}
+
+// incidentLogData 存储中的事件记录，NextID 保证重启后编号不重复
+type incidentLogData struct {
+	NextID    int        `json:"next_id"`
+	Incidents []Incident `json:"incidents"`
+}
+
+// IncidentObservation 一次采样中某个指标的检查结果，未超限的检查用于推进事件的自动解决
+type IncidentObservation struct {
+	Source    string
+	Metric    string
+	Selector  string
+	Value     float64
+	Breached  bool
+	Severity  string
+	Threshold float64
+}
+
+func (observation IncidentObservation) key() string {
+	return observation.Source + "|" + observation.Metric + "|" + observation.Selector
+}
+
+// IncidentLog 保存在存储中的事件记录，并发安全。
+// 状态只由存储中的记录和样本时间决定，采集器重启后同一指标继续超限会更新原事件而不是新建
+type IncidentLog struct {
+	storage      types.DataStorage
+	resolveAfter time.Duration
+	mutex        sync.Mutex
+	now          func() time.Time
+}
+
+// NewIncidentLog 创建事件记录，resolveAfter 为指标恢复正常后自动解决事件的等待时间，
+// 也是解决后再次超限时重新打开原事件的时间窗口，不大于 0 时使用默认值
+func NewIncidentLog(dataStorage types.DataStorage, resolveAfter time.Duration) *IncidentLog {
+	if resolveAfter <= 0 {
+		resolveAfter = DefaultIncidentResolveAfter
+	}
+	return &IncidentLog{
+		storage:      dataStorage,
+		resolveAfter: resolveAfter,
+		now:          time.Now,
+	}
+}
+
+// load 读取事件记录，需持有 mutex。尚未记录过事件时返回空记录
+func (il *IncidentLog) load() (incidentLogData, error) {
+	data := incidentLogData{NextID: 1}
+	if !il.storage.Exists(incidentsKey) {
+		return data, nil
+	}
+	if err := il.storage.Load(incidentsKey, &data); err != nil {
+		return incidentLogData{}, wrapError("读取事件记录失败", err)
+	}
+	if data.NextID < 1 {
+		data.NextID = 1
+	}
+	return data, nil
+}
+
+// save 保存事件记录，需持有 mutex
+func (il *IncidentLog) save(data incidentLogData) error {
+	if err := il.storage.Save(incidentsKey, data); err != nil {
+		return wrapError("保存事件记录失败", err)
+	}
+	return nil
+}
+
+// Record 按一次采样的检查结果推进事件状态，返回新打开或重新打开的事件：
+//   - 超限时更新该键未解决的事件；没有时重新打开 resolveAfter 内解决的事件，否则新建事件
+//   - 未超限（或本次没有检查该键，如分区已卸载）时开始计算恢复时间，持续 resolveAfter 后自动解决
+//
+// at 不晚于事件的最后记录时间时忽略该检查结果，重复提交同一采样不会改变状态
+func (il *IncidentLog) Record(at time.Time, observations []IncidentObservation) ([]Incident, error) {
+	il.mutex.Lock()
+	defer il.mutex.Unlock()
+
+	data, err := il.load()
+	if err != nil {
+		return nil, err
+	}
+
+	// 每个键最新的事件，编号递增，后面的覆盖前面的
+	latest := make(map[string]int, len(data.Incidents))
+	for i, incident := range data.Incidents {
+		latest[incident.key()] = i
+	}
+
+	changed := false
+	var opened []Incident
+	observed := make(map[string]bool, len(observations))
+	for _, observation := range observations {
+		key := observation.key()
+		observed[key] = true
+
+		index, found := latest[key]
+		if !observation.Breached {
+			if found && il.markRecovered(&data.Incidents[index], at) {
+				changed = true
+			}
+			continue
+		}
+
+		if found {
+			incident := &data.Incidents[index]
+			if !at.After(incident.LastSeen) {
+				continue
+			}
+			if incident.State != IncidentResolved {
+				updateIncident(incident, observation, at)
+				changed = true
+				continue
+			}
+			if incident.ResolvedAt != nil && at.Sub(*incident.ResolvedAt) <= il.resolveAfter {
+				// 重新打开的事件需要重新确认
+				incident.State = IncidentOpen
+				incident.ResolvedAt = nil
+				incident.AcknowledgedAt = nil
+				incident.ReopenCount++
+				updateIncident(incident, observation, at)
+				opened = append(opened, *incident)
+				changed = true
+				continue
+			}
+		}
+
+		incident := Incident{
+			ID:        fmt.Sprintf("inc-%d", data.NextID),
+			Source:    observation.Source,
+			Metric:    observation.Metric,
+			Selector:  observation.Selector,
+			Severity:  observation.Severity,
+			State:     IncidentOpen,
+			FirstSeen: at,
+			PeakValue: observation.Value,
+		}
+		updateIncident(&incident, observation, at)
+		data.NextID++
+		data.Incidents = append(data.Incidents, incident)
+		latest[key] = len(data.Incidents) - 1
+		opened = append(opened, incident)
+		changed = true
+	}
+
+	// 本次没有检查的键视为已恢复
+	for key, index := range latest {
+		if !observed[key] && il.markRecovered(&data.Incidents[index], at) {
+			changed = true
+		}
+	}
+
+	if !changed {
+		return opened, nil
+	}
+	data.Incidents = pruneIncidents(data.Incidents)
+	return opened, il.save(data)
+}
+
+// updateIncident 记录一次超限：更新最后记录时间、峰值和最高严重程度，清除恢复计时
+func updateIncident(incident *Incident, observation IncidentObservation, at time.Time) {
+	incident.LastSeen = at
+	incident.LastValue = observation.Value
+	incident.Threshold = observation.Threshold
+	incident.Occurrences++
+	incident.RecoveredSince = nil
+	if observation.Value > incident.PeakValue {
+		incident.PeakValue = observation.Value
+	}
+	if observation.Severity == severityCritical {
+		incident.Severity = severityCritical
+	}
+}
+
+// markRecovered 记录一次未超限，恢复持续 resolveAfter 后解决事件，返回事件是否有变化
+func (il *IncidentLog) markRecovered(incident *Incident, at time.Time) bool {
+	if incident.State == IncidentResolved || !at.After(incident.LastSeen) {
+		return false
+	}
+	if incident.RecoveredSince == nil {
+		since := at
+		incident.RecoveredSince = &since
+		return true
+	}
+	if at.Sub(*incident.RecoveredSince) < il.resolveAfter {
+		return false
+	}
+	resolvedAt := at
+	incident.State = IncidentResolved
+	incident.ResolvedAt = &resolvedAt
+	incident.RecoveredSince = nil
+	return true
+}
+
+// pruneIncidents 超过上限时按时间顺序删除最早的已解决事件，未解决的事件始终保留
+func pruneIncidents(incidents []Incident) []Incident {
+	overflow := len(incidents) - maxIncidents
+	if overflow <= 0 {
+		return incidents
+	}
+
+	kept := make([]Incident, 0, len(incidents))
+	for _, incident := range incidents {
+		if overflow > 0 && incident.State == IncidentResolved {
+			overflow--
+			continue
+		}
+		kept = append(kept, incident)
+	}
+	return kept
+}
+
+// List 按状态过滤事件，最新的在前。state 为 active 时返回未解决的事件，为 all 时返回全部
+func (il *IncidentLog) List(state string) ([]Incident, error) {
+	il.mutex.Lock()
+	defer il.mutex.Unlock()
+
+	data, err := il.load()
+	if err != nil {
+		return nil, err
+	}
+
+	incidents := []Incident{}
+	for i := len(data.Incidents) - 1; i >= 0; i-- {
+		if incidentMatchesState(data.Incidents[i], state) {
+			incidents = append(incidents, data.Incidents[i])
+		}
+	}
+	return incidents, nil
+}
+
+// incidentMatchesState 事件是否符合状态过滤
+func incidentMatchesState(incident Incident, state string) bool {
+	switch state {
+	case "all":
+		return true
+	case "", "active":
+		return incident.State != IncidentResolved
+	default:
+		return incident.State == state
+	}
+}
+
+// IncidentCounts 未解决事件的数量：open 为未确认的，acknowledged 为已确认但仍在持续的
+type IncidentCounts struct {
+	Open         int `json:"open"`
+	Acknowledged int `json:"acknowledged"`
+}
+
+// Counts 统计未解决的事件，读取失败时返回错误
+func (il *IncidentLog) Counts() (IncidentCounts, error) {
+	il.mutex.Lock()
+	defer il.mutex.Unlock()
+
+	data, err := il.load()
+	if err != nil {
+		return IncidentCounts{}, err
+	}
+
+	var counts IncidentCounts
+	for _, incident := range data.Incidents {
+		switch incident.State {
+		case IncidentOpen:
+			counts.Open++
+		case IncidentAcknowledged:
+			counts.Acknowledged++
+		}
+	}
+	return counts, nil
+}
+
+// Acknowledge 确认事件，已确认的事件保持不变。事件不存在时返回 ERR_NOT_FOUND，已解决时返回 ERR_BAD_ARGUMENT
+func (il *IncidentLog) Acknowledge(id string) (Incident, error) {
+	return il.modify(id, func(incident *Incident) (bool, error) {
+		switch incident.State {
+		case IncidentResolved:
+			return false, badArgument("事件 %s 已解决，无需确认", id)
+		case IncidentAcknowledged:
+			return false, nil
+		}
+		at := il.now()
+		incident.State = IncidentAcknowledged
+		incident.AcknowledgedAt = &at
+		return true, nil
+	})
+}
+
+// AddNote 为事件追加备注，任何状态的事件都可以添加。事件不存在时返回 ERR_NOT_FOUND
+func (il *IncidentLog) AddNote(id, text string) (Incident, error) {
+	return il.modify(id, func(incident *Incident) (bool, error) {
+		incident.Notes = append(incident.Notes, IncidentNote{At: il.now(), Text: text})
+		return true, nil
+	})
+}
+
+// modify 读取、修改并保存单个事件，change 返回 false 时不保存
+func (il *IncidentLog) modify(id string, change func(incident *Incident) (bool, error)) (Incident, error) {
+	il.mutex.Lock()
+	defer il.mutex.Unlock()
+
+	data, err := il.load()
+	if err != nil {
+		return Incident{}, err
+	}
+
+	for i := range data.Incidents {
+		incident := &data.Incidents[i]
+		if incident.ID != id {
+			continue
+		}
+		changed, err := change(incident)
+		if err != nil {
+			return Incident{}, err
+		}
+		if changed {
+			if err := il.save(data); err != nil {
+				return Incident{}, err
+			}
+		}
+		return *incident, nil
+	}
+
+	toolErr := notFound("事件不存在: %s", id)
+	toolErr.Hint = `可通过 incidents {"state": "all"} 查看所有事件的 ID`
+	return Incident{}, toolErr
+}
+
+// ThresholdObservations 按健康检查阈值检查一次采样的 CPU、内存和各分区使用率
+func ThresholdObservations(sample types.MetricSample, thresholds types.Thresholds) []IncidentObservation {
+	observe := func(metric, selector string, value float64, threshold types.Threshold) IncidentObservation {
+		severity, limit := evaluateThreshold(value, threshold)
+		return IncidentObservation{
+			Source:    IncidentSourceThreshold,
+			Metric:    metric,
+			Selector:  selector,
+			Value:     value,
+			Breached:  severity != severityOK,
+			Severity:  severity,
+			Threshold: limit,
+		}
+	}
+
+	observations := []IncidentObservation{
+		observe(MetricCPUPercent, "", sample.CPUPercent, thresholds.CPUPercent),
+		observe(MetricMemoryPercent, "", sample.MemoryPercent, thresholds.MemoryPercent),
+	}
+	for _, mountpoint := range sortedKeys(sample.DiskPercent) {
+		observations = append(observations, observe(MetricDiskPercent, mountpoint, sample.DiskPercent[mountpoint], thresholds.DiskPercent))
+	}
+	return observations
+}
+
+// AnomalyObservations 把一次采样的异常检测结果转换为检查结果，found 中的指标为超限（warning），其余指标为正常
+func AnomalyObservations(sample types.MetricSample, found []types.Anomaly) []IncidentObservation {
+	anomalies := make(map[string]types.Anomaly, len(found))
+	for _, item := range found {
+		anomalies[item.Metric+"|"+item.Selector] = item
+	}
+
+	observe := func(metric, selector string, value float64) IncidentObservation {
+		observation := IncidentObservation{
+			Source:   IncidentSourceAnomaly,
+			Metric:   metric,
+			Selector: selector,
+			Value:    value,
+		}
+		if item, anomalous := anomalies[metric+"|"+selector]; anomalous {
+			observation.Breached = true
+			observation.Severity = severityWarning
+			observation.Threshold = item.Mean
+		}
+		return observation
+	}
+
+	observations := []IncidentObservation{
+		observe(MetricCPUPercent, "", sample.CPUPercent),
+		observe(MetricMemoryPercent, "", sample.MemoryPercent),
+	}
+	for _, mountpoint := range sortedKeys(sample.DiskPercent) {
+		observations = append(observations, observe(MetricDiskPercent, mountpoint, sample.DiskPercent[mountpoint]))
+	}
+	return observations
+}
+
+// incidentReport incidents 工具的 list 结果（最新的在前）
+type incidentReport struct {
+	State     string              `json:"state"`
+	Incidents []Incident          `json:"incidents"`
+	Total     int                 `json:"total"`
+	Counts    IncidentCounts      `json:"counts"`
+	Host      *types.HostIdentity `json:"host,omitempty"`
+}
+
+// IncidentsTool 事件记录管理工具：列出、确认事件和添加备注
+type IncidentsTool struct {
+	incidents *IncidentLog
+}
+
+// NewIncidentsTool 创建新的事件记录管理工具
+func NewIncidentsTool(incidents *IncidentLog) *IncidentsTool {
+	return &IncidentsTool{incidents: incidents}
+}
+
+// GetName 获取工具名称
+func (it *IncidentsTool) GetName() string {
+	return "incidents"
+}
+
+// GetDescription 获取工具描述
+func (it *IncidentsTool) GetDescription() string {
+	return "管理后台采集记录的告警事件（超过健康检查阈值或偏离滚动均值）：按状态列出、确认事件、添加备注。指标恢复正常持续一段时间后事件自动解决"
+}
+
+// GetAnnotations 获取工具注解
+func (it *IncidentsTool) GetAnnotations() types.ToolAnnotations {
+	return types.ToolAnnotations{
+		Title:          "告警事件",
+		IdempotentHint: false,
+	}
+}
+
+// incidentsArgs incidents 的参数。state 中 active 为未解决（open 和 acknowledged）
+type incidentsArgs struct {
+	strictArgs
+	Action string `arg:"action,enum=list|acknowledge|note,default=list" desc:"操作: list 列出事件, acknowledge 确认事件, note 为事件添加备注"`
+	State  string `arg:"state,enum=active|open|acknowledged|resolved|all,default=active" desc:"list 的状态过滤: active（未解决）、open（未确认）、acknowledged（已确认）、resolved（已解决）或 all"`
+	ID     string `arg:"id" desc:"事件 ID，如 inc-3（acknowledge、note 必需）"`
+	Note   string `arg:"note" desc:"备注内容（note 必需）"`
+	Limit  int    `arg:"limit,default=20,min=1,max=500" desc:"list 最多列出的事件数量"`
+	formatArgs
+}
+
+// GetInputSchema 获取输入模式
+func (it *IncidentsTool) GetInputSchema() types.InputSchema {
+	return argsSchema(incidentsArgs{})
+}
+
+// Examples 获取调用示例
+func (it *IncidentsTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "列出未解决的事件",
+			Arguments:   map[string]interface{}{},
+		},
+		{
+			Description: "最近 10 个已解决的事件，JSON 格式",
+			Arguments:   map[string]interface{}{"state": "resolved", "limit": 10, "format": "json"},
+		},
+		{
+			Description: "确认事件",
+			Arguments:   map[string]interface{}{"action": "acknowledge", "id": "inc-3"},
+		},
+		{
+			Description: "为事件添加备注",
+			Arguments:   map[string]interface{}{"action": "note", "id": "inc-3", "note": "已扩容 /var 分区"},
+		},
+	}
+}
+
+// Execute 执行事件管理操作
+func (it *IncidentsTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a incidentsArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+	id := a.ID
+
+	switch a.Action {
+	case "list":
+		return it.list(a)
+
+	case "acknowledge":
+		if id == "" {
+			return "", argumentError("id", "acknowledge 操作需要提供 id")
+		}
+		incident, err := it.incidents.Acknowledge(id)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✅ 已确认事件 %s（%s）\n", incident.ID, incidentName(incident)), nil
+
+	case "note":
+		if id == "" {
+			return "", argumentError("id", "note 操作需要提供 id")
+		}
+		note := a.Note
+		if strings.TrimSpace(note) == "" {
+			return "", argumentError("note", "note 操作需要提供 note")
+		}
+		incident, err := it.incidents.AddNote(id, strings.TrimSpace(note))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✅ 已为事件 %s 添加备注（共 %d 条）\n", incident.ID, len(incident.Notes)), nil
+
+	default:
+		return "", argumentError("action", "不支持的操作: %s", a.Action)
+	}
+}
+
+// list 按状态列出事件
+func (it *IncidentsTool) list(a incidentsArgs) (string, error) {
+	state, limit := a.State, a.Limit
+
+	incidents, err := it.incidents.List(state)
+	if err != nil {
+		return "", err
+	}
+	counts, err := it.incidents.Counts()
+	if err != nil {
+		return "", err
+	}
+
+	report := incidentReport{State: state, Incidents: incidents, Total: len(incidents), Counts: counts}
+	if len(report.Incidents) > limit {
+		report.Incidents = report.Incidents[:limit]
+	}
+
+	if a.Format == "json" {
+		report.Host = identity.Get()
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", wrapError("序列化事件记录失败", err)
+		}
+		return string(jsonData), nil
+	}
+
+	return formatIncidents(report), nil
+}
+
+// incidentName 事件的显示名称，如 disk_percent / (threshold)
+func incidentName(incident Incident) string {
+	name := incident.Metric
+	if incident.Selector != "" {
+		name += " " + incident.Selector
+	}
+	return name + " (" + incident.Source + ")"
+}
+
+// formatIncidents 格式化事件列表
+func formatIncidents(report incidentReport) string {
+	var result string
+
+	result += "📟 告警事件\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result += fmt.Sprintf("未解决: %d 个未确认, %d 个已确认\n", report.Counts.Open, report.Counts.Acknowledged)
+
+	if len(report.Incidents) == 0 {
+		result += "\n没有符合条件的事件（事件由后台采集记录，需要通过 --collect-interval 启用）\n"
+		return result
+	}
+
+	stateLabels := map[string]string{
+		IncidentOpen:         "🔴 未确认",
+		IncidentAcknowledged: "🟡 已确认",
+		IncidentResolved:     "✅ 已解决",
+	}
+	severityLabels := map[string]string{severityWarning: "警告", severityCritical: "严重"}
+
+	for _, incident := range report.Incidents {
+		result += fmt.Sprintf("\n%s [%s] %s %s\n", incident.ID, severityLabels[incident.Severity], incidentName(incident), stateLabels[incident.State])
+		result += fmt.Sprintf("  首次: %s  最近: %s  持续 %s\n",
+			incident.FirstSeen.Format("2006-01-02 15:04:05"),
+			incident.LastSeen.Format("2006-01-02 15:04:05"),
+			incident.LastSeen.Sub(incident.FirstSeen).Round(time.Second))
+		reference := "阈值"
+		if incident.Source == IncidentSourceAnomaly {
+			reference = "均值"
+		}
+		result += fmt.Sprintf("  峰值: %.2f  最近值: %.2f  %s: %.2f  超限 %d 次", incident.PeakValue, incident.LastValue, reference, incident.Threshold, incident.Occurrences)
+		if incident.ReopenCount > 0 {
+			result += fmt.Sprintf("  重新打开 %d 次", incident.ReopenCount)
+		}
+		result += "\n"
+		switch {
+		case incident.ResolvedAt != nil:
+			result += fmt.Sprintf("  解决于: %s\n", incident.ResolvedAt.Format("2006-01-02 15:04:05"))
+		case incident.RecoveredSince != nil:
+			result += fmt.Sprintf("  已于 %s 恢复正常，等待自动解决\n", incident.RecoveredSince.Format("2006-01-02 15:04:05"))
+		}
+		if incident.AcknowledgedAt != nil {
+			result += fmt.Sprintf("  确认于: %s\n", incident.AcknowledgedAt.Format("2006-01-02 15:04:05"))
+		}
+		for _, note := range incident.Notes {
+			result += fmt.Sprintf("  📝 %s %s\n", note.At.Format("2006-01-02 15:04:05"), note.Text)
+		}
+	}
+
+	result += fmt.Sprintf("\n共 %d 个，显示最新的 %d 个\n", report.Total, len(report.Incidents))
+	return result
+}
@@ -0,0 +1,246 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+// cpuObservation CPU 使用率阈值检查的结果，severity 为空表示未超限
+func cpuObservation(value float64, severity string) IncidentObservation {
+	return IncidentObservation{
+		Source:    IncidentSourceThreshold,
+		Metric:    MetricCPUPercent,
+		Value:     value,
+		Breached:  severity != "",
+		Severity:  severity,
+		Threshold: 80,
+	}
+}
+
+// recordIncidents 记录一次采样，失败时测试失败
+func recordIncidents(t *testing.T, log *IncidentLog, at time.Time, observations ...IncidentObservation) []Incident {
+	t.Helper()
+	opened, err := log.Record(at, observations)
+	if err != nil {
+		t.Fatalf("Record(%s): %v", at.Format(time.TimeOnly), err)
+	}
+	return opened
+}
+
+// onlyIncident 记录中唯一的事件
+func onlyIncident(t *testing.T, log *IncidentLog) Incident {
+	t.Helper()
+	incidents, err := log.List("all")
+	if err != nil || len(incidents) != 1 {
+		t.Fatalf("incidents = %+v, %v, want exactly one", incidents, err)
+	}
+	return incidents[0]
+}
+
+func TestIncidentOpensAndResolves(t *testing.T) {
+	log := NewIncidentLog(storage.NewMemoryStorage(), 5*time.Minute)
+	log.now = func() time.Time { return fixedTime }
+	start := fixedTime
+
+	opened := recordIncidents(t, log, start, cpuObservation(85, severityWarning))
+	if len(opened) != 1 || opened[0].ID != "inc-1" || opened[0].State != IncidentOpen {
+		t.Fatalf("opened = %+v, want inc-1 open", opened)
+	}
+	// 持续超限更新同一事件，严重程度只升不降
+	if opened := recordIncidents(t, log, start.Add(time.Minute), cpuObservation(97, severityCritical)); len(opened) != 0 {
+		t.Fatalf("continued breach opened %+v", opened)
+	}
+	recordIncidents(t, log, start.Add(2*time.Minute), cpuObservation(86, severityWarning))
+	incident := onlyIncident(t, log)
+	if incident.Occurrences != 3 || incident.Severity != severityCritical || incident.PeakValue != 97 || incident.LastValue != 86 || !incident.LastSeen.Equal(start.Add(2*time.Minute)) {
+		t.Fatalf("incident = %+v, want 3 occurrences peaking at 97 critical", incident)
+	}
+	// 重复提交同一采样不改变状态
+	recordIncidents(t, log, start.Add(2*time.Minute), cpuObservation(90, severityWarning))
+	if incident := onlyIncident(t, log); incident.Occurrences != 3 {
+		t.Fatalf("replayed sample counted: %+v", incident)
+	}
+
+	if _, err := log.Acknowledge("inc-1"); err != nil {
+		t.Fatal(err)
+	}
+	if counts, _ := log.Counts(); counts != (IncidentCounts{Acknowledged: 1}) {
+		t.Fatalf("counts = %+v, want one acknowledged", counts)
+	}
+
+	// 恢复正常持续 resolveAfter 后自动解决
+	recovered := start.Add(3 * time.Minute)
+	recordIncidents(t, log, recovered, cpuObservation(40, ""))
+	recordIncidents(t, log, recovered.Add(4*time.Minute), cpuObservation(40, ""))
+	if incident := onlyIncident(t, log); incident.State != IncidentAcknowledged || incident.RecoveredSince == nil || !incident.RecoveredSince.Equal(recovered) {
+		t.Fatalf("incident after 4 minutes of recovery = %+v, want acknowledged and recovering", incident)
+	}
+	recordIncidents(t, log, recovered.Add(5*time.Minute), cpuObservation(40, ""))
+	incident = onlyIncident(t, log)
+	if incident.State != IncidentResolved || incident.ResolvedAt == nil || !incident.ResolvedAt.Equal(recovered.Add(5*time.Minute)) || incident.RecoveredSince != nil {
+		t.Fatalf("incident = %+v, want resolved after 5 minutes of recovery", incident)
+	}
+	if active, _ := log.List("active"); len(active) != 0 {
+		t.Errorf("active incidents = %+v", active)
+	}
+
+	var toolErr *Error
+	if _, err := log.Acknowledge("inc-1"); !errors.As(err, &toolErr) || toolErr.Code != ErrBadArgument {
+		t.Errorf("acknowledging a resolved incident = %v, want ErrBadArgument", err)
+	}
+	if _, err := log.Acknowledge("inc-9"); !errors.As(err, &toolErr) || toolErr.Code != ErrNotFound || toolErr.Hint == "" {
+		t.Errorf("acknowledging a missing incident = %v, want ErrNotFound with a hint", err)
+	}
+}
+
+func TestIncidentRecoveryIsInterruptedByBreach(t *testing.T) {
+	log := NewIncidentLog(storage.NewMemoryStorage(), 5*time.Minute)
+	start := fixedTime
+
+	recordIncidents(t, log, start, cpuObservation(85, severityWarning))
+	recordIncidents(t, log, start.Add(time.Minute), cpuObservation(40, ""))
+	recordIncidents(t, log, start.Add(4*time.Minute), cpuObservation(88, severityWarning))
+	// 再次超限清除恢复计时，之后需要重新恢复满 resolveAfter
+	recordIncidents(t, log, start.Add(5*time.Minute), cpuObservation(40, ""))
+	recordIncidents(t, log, start.Add(7*time.Minute), cpuObservation(40, ""))
+	if incident := onlyIncident(t, log); incident.State != IncidentOpen || !incident.RecoveredSince.Equal(start.Add(5*time.Minute)) {
+		t.Fatalf("incident = %+v, want open and recovering since the second recovery", incident)
+	}
+
+	// 本次没有检查的键（如分区已卸载）视为已恢复
+	disk := IncidentObservation{Source: IncidentSourceThreshold, Metric: MetricDiskPercent, Selector: "/mnt", Value: 95, Breached: true, Severity: severityCritical, Threshold: 90}
+	recordIncidents(t, log, start.Add(8*time.Minute), disk)
+	recordIncidents(t, log, start.Add(9*time.Minute))
+	recordIncidents(t, log, start.Add(14*time.Minute))
+	incidents, _ := log.List("all")
+	if len(incidents) != 2 || incidents[0].Selector != "/mnt" || incidents[0].State != IncidentResolved || incidents[1].State != IncidentResolved {
+		t.Fatalf("incidents = %+v, want both resolved once nothing is observed", incidents)
+	}
+}
+
+func TestIncidentReopensWithinResolveWindow(t *testing.T) {
+	log := NewIncidentLog(storage.NewMemoryStorage(), 5*time.Minute)
+	start := fixedTime
+
+	recordIncidents(t, log, start, cpuObservation(85, severityWarning))
+	log.Acknowledge("inc-1")
+	recordIncidents(t, log, start.Add(time.Minute), cpuObservation(40, ""))
+	recordIncidents(t, log, start.Add(6*time.Minute), cpuObservation(40, ""))
+
+	// 解决后 resolveAfter 内再次超限：重新打开原事件，需要重新确认
+	opened := recordIncidents(t, log, start.Add(10*time.Minute), cpuObservation(90, severityWarning))
+	if len(opened) != 1 || opened[0].ID != "inc-1" || opened[0].State != IncidentOpen || opened[0].ReopenCount != 1 || opened[0].AcknowledgedAt != nil || opened[0].ResolvedAt != nil {
+		t.Fatalf("opened = %+v, want inc-1 reopened and unacknowledged", opened)
+	}
+
+	// 超过时间窗口后再次超限：新建事件
+	recordIncidents(t, log, start.Add(11*time.Minute), cpuObservation(40, ""))
+	recordIncidents(t, log, start.Add(16*time.Minute), cpuObservation(40, ""))
+	opened = recordIncidents(t, log, start.Add(22*time.Minute), cpuObservation(90, severityWarning))
+	if len(opened) != 1 || opened[0].ID != "inc-2" || opened[0].ReopenCount != 0 {
+		t.Fatalf("opened = %+v, want a new inc-2", opened)
+	}
+	if incidents, _ := log.List("all"); len(incidents) != 2 || incidents[0].ID != "inc-2" || incidents[1].State != IncidentResolved {
+		t.Fatalf("incidents = %+v, want inc-2 first and inc-1 resolved", incidents)
+	}
+}
+
+func TestIncidentsPersistAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	open := func() *IncidentLog {
+		t.Helper()
+		dataStorage, err := storage.NewJSONStorage(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewIncidentLog(dataStorage, 5*time.Minute)
+	}
+	start := fixedTime
+
+	first := open()
+	recordIncidents(t, first, start, cpuObservation(85, severityWarning))
+	first.Acknowledge("inc-1")
+	first.AddNote("inc-1", "正在排查")
+
+	// 重启后同一指标继续超限更新原事件，确认状态和备注保留
+	second := open()
+	if opened := recordIncidents(t, second, start.Add(time.Minute), cpuObservation(91, severityWarning)); len(opened) != 0 {
+		t.Fatalf("restart opened %+v, want the existing incident updated", opened)
+	}
+	incident := onlyIncident(t, second)
+	if incident.State != IncidentAcknowledged || incident.Occurrences != 2 || len(incident.Notes) != 1 || !incident.FirstSeen.Equal(start) {
+		t.Fatalf("incident after restart = %+v", incident)
+	}
+
+	// 恢复计时跨越重启，编号不重复
+	recordIncidents(t, second, start.Add(2*time.Minute), cpuObservation(40, ""))
+	third := open()
+	recordIncidents(t, third, start.Add(7*time.Minute), cpuObservation(40, ""))
+	if incident := onlyIncident(t, third); incident.State != IncidentResolved {
+		t.Fatalf("incident = %+v, want resolved across the restart", incident)
+	}
+	disk := IncidentObservation{Source: IncidentSourceThreshold, Metric: MetricDiskPercent, Selector: "/", Value: 95, Breached: true, Severity: severityCritical, Threshold: 90}
+	if opened := recordIncidents(t, open(), start.Add(20*time.Minute), cpuObservation(40, ""), disk); len(opened) != 1 || opened[0].ID != "inc-2" {
+		t.Fatalf("opened = %+v, want inc-2 after restarts", opened)
+	}
+}
+
+func TestIncidentsToolActions(t *testing.T) {
+	log := NewIncidentLog(storage.NewMemoryStorage(), 5*time.Minute)
+	log.now = func() time.Time { return fixedTime.Add(time.Hour) }
+	recordIncidents(t, log, fixedTime, cpuObservation(85, severityWarning))
+	tool := NewIncidentsTool(log)
+
+	for _, c := range []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{}, "inc-1 [警告] cpu_percent (threshold) 🔴 未确认"},
+		{map[string]interface{}{"action": "acknowledge", "id": "inc-1"}, "已确认事件 inc-1"},
+		{map[string]interface{}{"action": "note", "id": "inc-1", "note": "  已扩容  "}, "共 1 条"},
+		{map[string]interface{}{"state": "acknowledged"}, "📝 " + fixedTime.Add(time.Hour).Format(time.DateTime) + " 已扩容"},
+	} {
+		text, err := tool.Execute(context.Background(), c.args)
+		if err != nil || !strings.Contains(text, c.want) {
+			t.Errorf("incidents %v = %q, %v, want %q", c.args, text, err, c.want)
+		}
+	}
+	for _, args := range []map[string]interface{}{
+		{"action": "acknowledge"},
+		{"action": "note", "id": "inc-1", "note": " "},
+	} {
+		var toolErr *Error
+		if _, err := tool.Execute(context.Background(), args); !errors.As(err, &toolErr) || toolErr.Code != ErrBadArgument {
+			t.Errorf("incidents %v = %v, want ErrBadArgument", args, err)
+		}
+	}
+}
+
+func TestThresholdObservations(t *testing.T) {
+	sample := types.MetricSample{CPUPercent: 96, MemoryPercent: 50, DiskPercent: map[string]float64{"/": 85, "/data": 10}}
+	thresholds := types.Thresholds{
+		CPUPercent:    types.Threshold{Warning: 80, Critical: 95},
+		MemoryPercent: types.Threshold{Warning: 80, Critical: 95},
+		DiskPercent:   types.Threshold{Warning: 80, Critical: 90},
+	}
+	observations := ThresholdObservations(sample, thresholds)
+	want := []struct {
+		selector string
+		breached bool
+		severity string
+	}{{"", true, severityCritical}, {"", false, severityOK}, {"/", true, severityWarning}, {"/data", false, severityOK}}
+	if len(observations) != len(want) {
+		t.Fatalf("observations = %+v", observations)
+	}
+	for i, w := range want {
+		if o := observations[i]; o.Selector != w.selector || o.Breached != w.breached || o.Severity != w.severity {
+			t.Errorf("observation %d = %+v, want %+v", i, o, w)
+		}
+	}
+}
@@ -0,0 +1,658 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/types"
+)
+
+// /dev/kmsg 读取限制
+const (
+	// kmsgReadTimeout 读取 /dev/kmsg 的最长时间
+	kmsgReadTimeout = 2 * time.Second
+	// kmsgRecordSize 单条记录的读取缓冲（内核单条记录不超过 8KB，缓冲过小时 read 返回 EINVAL）
+	kmsgRecordSize = 16 << 10
+	// maxKmsgRecords 最多读取的记录数
+	maxKmsgRecords = 100000
+)
+
+// 内核事件查询的上限
+const (
+	maxKernelLookback = 30 * 24 * time.Hour
+	// maxKernelLineBytes 单行原始消息的最大字节数，超出部分截断
+	maxKernelLineBytes = 512
+	// maxKernelLinesBytes 原始消息总字节数上限，超出时丢弃较早的消息
+	maxKernelLinesBytes = 64 << 10
+	// maxKernelEvents 每类事件最多列出的数量（计数不受影响）
+	maxKernelEvents = 50
+)
+
+// kernelLevels syslog 严重级别名称，下标即级别数值（越小越严重）
+var kernelLevels = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// 内核事件类型
+const (
+	kernelEventOOMKill  = "oom_kill"
+	kernelEventIOError  = "io_error"
+	kernelEventHungTask = "hung_task"
+)
+
+// kernelMessage 一条内核消息
+type kernelMessage struct {
+	Time    time.Time
+	Level   int
+	Message string
+}
+
+// kernelEvent 从内核消息中识别出的事件
+type kernelEvent struct {
+	Kind    string    `json:"kind"`
+	Time    time.Time `json:"time"`
+	PID     int       `json:"pid,omitempty"`
+	Process string    `json:"process,omitempty"`
+	Device  string    `json:"device,omitempty"`
+	Message string    `json:"message"`
+}
+
+// kernelLine 输出的原始消息
+type kernelLine struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+	Event   string    `json:"event,omitempty"`
+}
+
+// kernelEventCounts 各类事件的数量
+type kernelEventCounts struct {
+	OOMKills  int `json:"oom_kills"`
+	IOErrors  int `json:"io_errors"`
+	HungTasks int `json:"hung_tasks"`
+}
+
+// kernelEventsReport 内核事件查询结果
+type kernelEventsReport struct {
+	Source   string            `json:"source"`
+	Since    time.Time         `json:"since"`
+	Lookback string            `json:"lookback"`
+	MinLevel string            `json:"min_level"`
+	Scanned  int               `json:"scanned"`
+	Counts   kernelEventCounts `json:"counts"`
+	// Events 每类最多 maxKernelEvents 个，最新的在后
+	Events []kernelEvent `json:"events"`
+	// Lines 达到级别阈值或被识别为事件的原始消息，按 limit 和总大小保留最新的部分，LinesTotal 为截断前的数量
+	Lines      []kernelLine        `json:"lines"`
+	LinesTotal int                 `json:"lines_total"`
+	Truncated  bool                `json:"truncated,omitempty"`
+	Notes      []string            `json:"notes,omitempty"`
+	Host       *types.HostIdentity `json:"host,omitempty"`
+}
+
+// kernelLogSource 内核日志来源
+type kernelLogSource interface {
+	Name() string
+	// Read 读取 since 之后的内核消息（来源可能返回更早的消息，由调用方过滤）
+	Read(ctx context.Context, since time.Time) ([]kernelMessage, error)
+}
+
+// kmsgSource 直接读取 /dev/kmsg
+type kmsgSource struct {
+	path     string
+	bootTime func(ctx context.Context) (time.Time, error)
+}
+
+// Name 来源名称
+func (s kmsgSource) Name() string {
+	return s.path
+}
+
+// Read 读取内核环形缓冲区中的全部记录
+func (s kmsgSource) Read(ctx context.Context, since time.Time) ([]kernelMessage, error) {
+	boot, err := s.bootTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	records, err := readKmsg(ctx, s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]kernelMessage, 0, len(records))
+	for _, record := range records {
+		if message, ok := parseKmsgRecord(record, boot); ok {
+			messages = append(messages, message)
+		}
+	}
+	return messages, nil
+}
+
+// parseKmsgRecord 解析 /dev/kmsg 的一条记录："<pri>,<seq>,<微秒>,<flags>[,...];<消息>"，
+// 之后可能跟着以空格开头的 KEY=value 续行。时间戳是启动以来的单调时钟（不含休眠时间），加上启动时间换算为墙上时间
+func parseKmsgRecord(record string, boot time.Time) (kernelMessage, bool) {
+	header, body, found := strings.Cut(record, ";")
+	if !found {
+		return kernelMessage{}, false
+	}
+	fields := strings.Split(header, ",")
+	if len(fields) < 3 {
+		return kernelMessage{}, false
+	}
+	priority, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return kernelMessage{}, false
+	}
+	usec, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return kernelMessage{}, false
+	}
+
+	message, _, _ := strings.Cut(body, "\n")
+	return kernelMessage{
+		Time:    boot.Add(time.Duration(usec) * time.Microsecond),
+		Level:   priority & 7,
+		Message: message,
+	}, true
+}
+
+// dmesgSource 通过 `dmesg --json` 读取
+type dmesgSource struct {
+	run      commandRunner
+	bootTime func(ctx context.Context) (time.Time, error)
+}
+
+// Name 来源名称
+func (s dmesgSource) Name() string {
+	return "dmesg"
+}
+
+// Read 读取内核环形缓冲区
+func (s dmesgSource) Read(ctx context.Context, since time.Time) ([]kernelMessage, error) {
+	boot, err := s.bootTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	output, err := s.run(ctx, "dmesg", "--json")
+	if err != nil {
+		return nil, err
+	}
+	return parseDmesgJSON(output, boot)
+}
+
+// parseDmesgJSON 解析 `dmesg --json` 输出，例如：
+//
+//	{"dmesg": [{"pri": 3, "time": 1234.567890, "msg": "..."}]}
+//
+// pri 为 facility<<3|level 数值，使用 --decode 时为级别名称；time 为启动以来的秒数
+func parseDmesgJSON(output []byte, boot time.Time) ([]kernelMessage, error) {
+	var document struct {
+		Dmesg []struct {
+			Pri  json.RawMessage `json:"pri"`
+			Time float64         `json:"time"`
+			Msg  string          `json:"msg"`
+		} `json:"dmesg"`
+	}
+	if err := json.Unmarshal(output, &document); err != nil {
+		return nil, fmt.Errorf("无法解析 dmesg --json 输出: %w", err)
+	}
+
+	messages := make([]kernelMessage, 0, len(document.Dmesg))
+	for _, entry := range document.Dmesg {
+		level := parseKernelPriority(entry.Pri)
+		messages = append(messages, kernelMessage{
+			Time:    boot.Add(time.Duration(entry.Time * float64(time.Second))),
+			Level:   level,
+			Message: entry.Msg,
+		})
+	}
+	return messages, nil
+}
+
+// parseKernelPriority 解析数值或名称形式的优先级，无法解析时视为 info
+func parseKernelPriority(raw json.RawMessage) int {
+	var number int
+	if err := json.Unmarshal(raw, &number); err == nil {
+		return number & 7
+	}
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		if level, ok := kernelLevelByName(name); ok {
+			return level
+		}
+	}
+	return 6
+}
+
+// journalSource 通过 `journalctl -k -o json` 读取 systemd 日志中的内核消息
+type journalSource struct {
+	run commandRunner
+}
+
+// Name 来源名称
+func (s journalSource) Name() string {
+	return "journalctl"
+}
+
+// Read 读取本次启动 since 之后的内核消息
+func (s journalSource) Read(ctx context.Context, since time.Time) ([]kernelMessage, error) {
+	output, err := s.run(ctx, "journalctl", "-k", "-o", "json", "--no-pager", "-q", "--since", fmt.Sprintf("@%d", since.Unix()))
+	if err != nil {
+		return nil, err
+	}
+	return parseJournalJSON(output)
+}
+
+// parseJournalJSON 解析 `journalctl -o json` 输出（每行一个 JSON 对象），例如：
+//
+//	{"PRIORITY": "3", "__REALTIME_TIMESTAMP": "1700000000123456", "MESSAGE": "..."}
+//
+// MESSAGE 含有非 UTF-8 内容时为字节数组。输出被截断时丢弃不完整的最后一行
+func parseJournalJSON(output []byte) ([]kernelMessage, error) {
+	var messages []kernelMessage
+	parsed := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64<<10), maxCommandOutput)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var entry struct {
+			Priority   string          `json:"PRIORITY"`
+			Realtime   string          `json:"__REALTIME_TIMESTAMP"`
+			RawMessage json.RawMessage `json:"MESSAGE"`
+		}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		parsed++
+
+		usec, err := strconv.ParseInt(entry.Realtime, 10, 64)
+		if err != nil {
+			continue
+		}
+		level, err := strconv.Atoi(entry.Priority)
+		if err != nil {
+			level = 6
+		}
+		messages = append(messages, kernelMessage{
+			Time:    time.UnixMicro(usec),
+			Level:   level & 7,
+			Message: journalMessageText(entry.RawMessage),
+		})
+	}
+
+	if parsed == 0 && len(bytes.TrimSpace(output)) > 0 {
+		return nil, fmt.Errorf("无法解析 journalctl 输出")
+	}
+	return messages, nil
+}
+
+// journalMessageText journald 的 MESSAGE 字段，可能是字符串或字节数组
+func journalMessageText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	var data []byte
+	var values []int
+	if err := json.Unmarshal(raw, &values); err == nil {
+		for _, value := range values {
+			data = append(data, byte(value))
+		}
+	}
+	return strings.ToValidUTF8(string(data), "?")
+}
+
+// kernelLevelByName 级别名称对应的数值，兼容 warn、error 等别名
+func kernelLevelByName(name string) (int, bool) {
+	switch name = strings.ToLower(name); name {
+	case "warn":
+		name = "warning"
+	case "error":
+		name = "err"
+	case "panic":
+		name = "emerg"
+	}
+	for level, levelName := range kernelLevels {
+		if levelName == name {
+			return level, true
+		}
+	}
+	return 0, false
+}
+
+// 事件识别规则
+var (
+	// 全局和 cgroup 的 OOM 都输出 "Killed process <pid> (<name>)"，较新的内核还会先输出一行 oom-kill:...,task=<name>,pid=<pid>
+	oomKilledPattern = regexp.MustCompile(`Killed process (\d+) \((.*?)\)`)
+	oomKillPattern   = regexp.MustCompile(`oom-kill:.*\btask=([^,]*),pid=(\d+)`)
+	hungTaskPattern  = regexp.MustCompile(`task (.+):(\d+) blocked for more than \d+ seconds`)
+	ioErrorPatterns  = []*regexp.Regexp{
+		regexp.MustCompile(`I/O error`),
+		regexp.MustCompile(`EXT[234]-fs error`),
+		regexp.MustCompile(`EXT[234]-fs \([^)]*\): Remounting filesystem read-only`),
+		regexp.MustCompile(`XFS \([^)]*\):.*(?:[Ee]rror|Corruption|Shutting down filesystem)`),
+		regexp.MustCompile(`BTRFS (?:error|critical)`),
+		regexp.MustCompile(`(?:Medium Error|critical medium error|Unrecovered read error)`),
+	}
+	ioDevicePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\(device ([^)\s]+)\)`),
+		regexp.MustCompile(`^(?:XFS|EXT[234]-fs) \(([^)\s]+)\)`),
+		regexp.MustCompile(`\bdev ([A-Za-z0-9_.-]+)`),
+		regexp.MustCompile(`\[(sd[a-z]+|nvme\d+n\d+|vd[a-z]+)\]`),
+	}
+)
+
+// classifyKernelMessage 识别 OOM kill、文件系统/IO 错误和 hung task 警告
+func classifyKernelMessage(message kernelMessage) (kernelEvent, bool) {
+	event := kernelEvent{Time: message.Time, Message: message.Message}
+
+	if match := oomKilledPattern.FindStringSubmatch(message.Message); match != nil {
+		event.Kind = kernelEventOOMKill
+		event.PID, _ = strconv.Atoi(match[1])
+		event.Process = match[2]
+		return event, true
+	}
+	if match := oomKillPattern.FindStringSubmatch(message.Message); match != nil {
+		event.Kind = kernelEventOOMKill
+		event.Process = match[1]
+		event.PID, _ = strconv.Atoi(match[2])
+		return event, true
+	}
+	if match := hungTaskPattern.FindStringSubmatch(message.Message); match != nil {
+		event.Kind = kernelEventHungTask
+		event.Process = match[1]
+		event.PID, _ = strconv.Atoi(match[2])
+		return event, true
+	}
+	for _, pattern := range ioErrorPatterns {
+		if !pattern.MatchString(message.Message) {
+			continue
+		}
+		event.Kind = kernelEventIOError
+		for _, devicePattern := range ioDevicePatterns {
+			if match := devicePattern.FindStringSubmatch(message.Message); match != nil {
+				event.Device = strings.TrimSuffix(match[1], ",")
+				break
+			}
+		}
+		return event, true
+	}
+	return kernelEvent{}, false
+}
+
+// buildKernelEventsReport 过滤 since 之后的消息，识别事件，并保留达到级别阈值或被识别为事件的最新 limit 条原始消息
+func buildKernelEventsReport(messages []kernelMessage, since time.Time, minLevel, limit int) kernelEventsReport {
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Time.Before(messages[j].Time)
+	})
+
+	report := kernelEventsReport{Since: since, MinLevel: kernelLevels[minLevel], Events: []kernelEvent{}, Lines: []kernelLine{}}
+	var lines []kernelLine
+	// 同一次 OOM kill 会产生 oom-kill 和 Killed process 两行，按 PID 合并
+	lastOOM := make(map[int]time.Time)
+	perKind := make(map[string]int)
+	for _, message := range messages {
+		if message.Time.Before(since) {
+			continue
+		}
+		report.Scanned++
+
+		event, isEvent := classifyKernelMessage(message)
+		if isEvent && event.Kind == kernelEventOOMKill && event.PID > 0 {
+			// 重复的行仍作为事件行列入原始消息，但不再计数
+			last, seen := lastOOM[event.PID]
+			lastOOM[event.PID] = message.Time
+			isEvent = !seen || message.Time.Sub(last) >= 10*time.Second
+		}
+		if isEvent {
+			switch event.Kind {
+			case kernelEventOOMKill:
+				report.Counts.OOMKills++
+			case kernelEventIOError:
+				report.Counts.IOErrors++
+			case kernelEventHungTask:
+				report.Counts.HungTasks++
+			}
+		}
+		if isEvent && perKind[event.Kind] < maxKernelEvents {
+			perKind[event.Kind]++
+			event.Message = TruncateText(event.Message, maxKernelLineBytes)
+			report.Events = append(report.Events, event)
+		}
+
+		if message.Level > minLevel && event.Kind == "" {
+			continue
+		}
+		lines = append(lines, kernelLine{
+			Time:    message.Time,
+			Level:   kernelLevels[message.Level],
+			Message: TruncateText(message.Message, maxKernelLineBytes),
+			Event:   event.Kind,
+		})
+	}
+
+	report.LinesTotal = len(lines)
+	if len(lines) > limit {
+		lines = lines[len(lines)-limit:]
+	}
+	size := 0
+	start := len(lines)
+	for start > 0 && size+len(lines[start-1].Message) <= maxKernelLinesBytes {
+		start--
+		size += len(lines[start].Message)
+	}
+	report.Lines = append(report.Lines, lines[start:]...)
+	report.Truncated = len(report.Lines) < report.LinesTotal
+	return report
+}
+
+// KernelEventsTool 内核事件工具：读取最近的内核消息并识别 OOM kill、IO 错误和 hung task（仅 Linux）
+type KernelEventsTool struct {
+	platform string
+	sources  []kernelLogSource
+	now      func() time.Time
+}
+
+// NewKernelEventsTool 创建新的内核事件工具，依次尝试 /dev/kmsg、dmesg --json 和 journalctl
+func NewKernelEventsTool() *KernelEventsTool {
+	return &KernelEventsTool{
+		platform: hostPlatform,
+		sources: []kernelLogSource{
+			kmsgSource{path: "/dev/kmsg", bootTime: hostBootTime},
+			dmesgSource{run: runCommand, bootTime: hostBootTime},
+			journalSource{run: runCommand},
+		},
+		now: time.Now,
+	}
+}
+
+// hostBootTime 系统启动时间
+func hostBootTime(ctx context.Context) (time.Time, error) {
+	bootTime, err := providers.Host.BootTime(ctx)
+	if err != nil {
+		return time.Time{}, wrapError("获取启动时间失败", err)
+	}
+	return time.Unix(int64(bootTime), 0), nil
+}
+
+// GetName 获取工具名称
+func (kt *KernelEventsTool) GetName() string {
+	return "kernel_events"
+}
+
+// GetDescription 获取工具描述
+func (kt *KernelEventsTool) GetDescription() string {
+	return "读取最近的内核消息，识别 OOM kill（含被杀进程）、文件系统/IO 错误和 hung task 警告，并返回匹配的原始消息（仅 Linux，通常需要 root 或 adm/systemd-journal 组）"
+}
+
+// GetAnnotations 获取工具注解
+func (kt *KernelEventsTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("内核事件")
+}
+
+// kernelEventsArgs kernel_events 的参数，min_level 的可选值与 kernelLevels 相同
+type kernelEventsArgs struct {
+	strictArgs
+	Since    time.Duration `arg:"since,default=1h" pattern:"^[0-9]+(\\.[0-9]+)?(s|m|h)$" desc:"回溯时长，如 30m、6h，最长 720h"`
+	MinLevel string        `arg:"min_level,enum=emerg|alert|crit|err|warning|notice|info|debug,default=warning" desc:"原始消息的最低严重级别（识别出的事件不受此限制）"`
+	Limit    int           `arg:"limit,default=50,min=1,max=1000" desc:"最多返回的原始消息数量，保留最新的"`
+	formatArgs
+}
+
+// GetInputSchema 获取输入模式
+func (kt *KernelEventsTool) GetInputSchema() types.InputSchema {
+	return argsSchema(kernelEventsArgs{})
+}
+
+// Examples 获取调用示例
+func (kt *KernelEventsTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "最近 1 小时的 OOM kill、IO 错误和 hung task，以及 warning 及以上级别的内核消息",
+			Arguments:   map[string]interface{}{},
+		},
+		{
+			Description: "最近 24 小时 err 及以上级别的内核消息，JSON 格式",
+			Arguments:   map[string]interface{}{"since": "24h", "min_level": "err", "format": "json"},
+		},
+	}
+}
+
+// Execute 读取并分析最近的内核消息
+func (kt *KernelEventsTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	if kt.platform != platformLinux {
+		return "", unsupportedPlatform("kernel_events 仅支持 Linux（当前平台: %s）", kt.platform)
+	}
+
+	var a kernelEventsArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+	lookback := a.Since
+	if lookback <= 0 || lookback > maxKernelLookback {
+		return "", argumentError("since", "无效的回溯时长: %s（应在 0 到 %s 之间）", lookback, maxKernelLookback)
+	}
+	minLevel, ok := kernelLevelByName(a.MinLevel)
+	if !ok {
+		return "", argumentError("min_level", "无效的级别: %q", a.MinLevel)
+	}
+
+	since := kt.now().Add(-lookback)
+	messages, source, notes, err := kt.read(ctx, since)
+	if err != nil {
+		return "", err
+	}
+
+	report := buildKernelEventsReport(messages, since, minLevel, a.Limit)
+	report.Source = source
+	report.Lookback = lookback.String()
+	report.Notes = notes
+
+	if a.Format == "json" {
+		report.Host = identity.Get()
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", wrapError("序列化内核事件失败", err)
+		}
+		return string(jsonData), nil
+	}
+
+	return formatKernelEvents(report), nil
+}
+
+// read 依次尝试各来源，返回第一个成功的来源的消息，以及之前失败的来源的说明。
+// 全部失败且 /dev/kmsg 因权限被拒绝时返回 ErrPermission
+func (kt *KernelEventsTool) read(ctx context.Context, since time.Time) ([]kernelMessage, string, []string, error) {
+	var notes []string
+	var errs []error
+	permissionDenied := false
+	for _, source := range kt.sources {
+		messages, err := source.Read(ctx, since)
+		if err == nil {
+			return messages, source.Name(), notes, nil
+		}
+		if ctx.Err() != nil {
+			return nil, "", nil, wrapError("读取内核日志失败", ctx.Err())
+		}
+		if ClassifyError(err).Code == ErrPermission {
+			permissionDenied = true
+		}
+		notes = append(notes, fmt.Sprintf("%s 不可用: %v", source.Name(), err))
+		errs = append(errs, fmt.Errorf("%s: %w", source.Name(), err))
+	}
+
+	if permissionDenied {
+		return nil, "", nil, &Error{
+			Code:    ErrPermission,
+			Message: "读取内核日志需要更高权限或用户组成员身份",
+			Hint:    "以 root 运行服务器，或授予 CAP_SYSLOG 能力；通过 journalctl 读取时需加入 systemd-journal 或 adm 组（kernel.dmesg_restrict=1 时普通用户无法读取 /dev/kmsg 和 dmesg）",
+			Err:     errors.Join(errs...),
+		}
+	}
+	return nil, "", nil, wrapError("读取内核日志失败", errors.Join(errs...))
+}
+
+// kernelEventLabels 事件类型的显示名称
+var kernelEventLabels = map[string]string{
+	kernelEventOOMKill:  "OOM kill",
+	kernelEventIOError:  "IO 错误",
+	kernelEventHungTask: "Hung task",
+}
+
+// formatKernelEvents 格式化内核事件
+func formatKernelEvents(report kernelEventsReport) string {
+	var result string
+
+	result += fmt.Sprintf("🧾 内核事件（最近 %s，来源: %s）\n", report.Lookback, report.Source)
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result += fmt.Sprintf("检查的消息: %d 条（自 %s）\n", report.Scanned, report.Since.Format("2006-01-02 15:04:05"))
+	result += fmt.Sprintf("OOM kill: %d  IO 错误: %d  Hung task: %d\n", report.Counts.OOMKills, report.Counts.IOErrors, report.Counts.HungTasks)
+
+	if len(report.Events) > 0 {
+		result += "\n🚨 识别出的事件:\n"
+		for _, event := range report.Events {
+			subject := ""
+			switch {
+			case event.Process != "" && event.PID > 0:
+				subject = fmt.Sprintf("%s (PID %d)", event.Process, event.PID)
+			case event.Device != "":
+				subject = event.Device
+			default:
+				subject = event.Message
+			}
+			result += fmt.Sprintf("  %s  %s  %s\n", event.Time.Format("01-02 15:04:05"), kernelEventLabels[event.Kind], subject)
+		}
+	}
+
+	result += fmt.Sprintf("\n📜 原始消息（%s 及以上级别或识别为事件）:\n", report.MinLevel)
+	if len(report.Lines) == 0 {
+		result += "  无\n"
+	}
+	for _, line := range report.Lines {
+		result += fmt.Sprintf("  %s [%s] %s\n", line.Time.Format("01-02 15:04:05"), line.Level, line.Message)
+	}
+	if report.Truncated {
+		result += fmt.Sprintf("  … 共 %d 条，仅显示最新的 %d 条\n", report.LinesTotal, len(report.Lines))
+	}
+
+	if len(report.Notes) > 0 {
+		result += "\n"
+		for _, note := range report.Notes {
+			result += fmt.Sprintf("ℹ️  %s\n", note)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,324 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseKmsgRecord(t *testing.T) {
+	boot := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	cases := []struct {
+		record string
+		ok     bool
+		want   kernelMessage
+	}{
+		// facility 0（kern）级别 3
+		{"3,1234,5000000,-;EXT4-fs error (device sda1): ext4_find_entry", true, kernelMessage{Time: boot.Add(5 * time.Second), Level: 3, Message: "EXT4-fs error (device sda1): ext4_find_entry"}},
+		// facility 非 0 时只取低 3 位，续行被丢弃
+		{"30,99,1500,c;systemd[1]: Started foo.\n SUBSYSTEM=unit\n", true, kernelMessage{Time: boot.Add(1500 * time.Microsecond), Level: 6, Message: "systemd[1]: Started foo."}},
+		{"6,1,0,-,caller=T1;hello", true, kernelMessage{Time: boot, Level: 6, Message: "hello"}},
+		{"no separator", false, kernelMessage{}},
+		{"3,1;too few fields", false, kernelMessage{}},
+		{"x,1,0,-;bad priority", false, kernelMessage{}},
+		{"3,1,x,-;bad timestamp", false, kernelMessage{}},
+	}
+	for _, c := range cases {
+		got, ok := parseKmsgRecord(c.record, boot)
+		if ok != c.ok || got != c.want {
+			t.Errorf("parseKmsgRecord(%q) = %+v, %v; want %+v, %v", c.record, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestParseDmesgJSON(t *testing.T) {
+	boot := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	output := `{"dmesg": [
+		{"pri": 3, "time": 12.5, "msg": "Out of memory: Killed process 1234 (postgres)"},
+		{"pri": 30, "time": 13, "msg": "systemd[1]: Started foo."},
+		{"pri": "warn", "time": 14, "msg": "decoded warning"},
+		{"pri": "bogus", "time": 15, "msg": "unknown level"},
+		{"time": 16, "msg": "no priority"}
+	]}`
+	messages, err := parseDmesgJSON([]byte(output), boot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []kernelMessage{
+		{Time: boot.Add(12500 * time.Millisecond), Level: 3, Message: "Out of memory: Killed process 1234 (postgres)"},
+		{Time: boot.Add(13 * time.Second), Level: 6, Message: "systemd[1]: Started foo."},
+		// --decode 输出的级别名称
+		{Time: boot.Add(14 * time.Second), Level: 4, Message: "decoded warning"},
+		// 无法解析的优先级视为 info
+		{Time: boot.Add(15 * time.Second), Level: 6, Message: "unknown level"},
+		{Time: boot.Add(16 * time.Second), Level: 6, Message: "no priority"},
+	}
+	if fmt.Sprint(messages) != fmt.Sprint(want) {
+		t.Errorf("parseDmesgJSON() = %+v, want %+v", messages, want)
+	}
+
+	if _, err := parseDmesgJSON([]byte("dmesg: read kernel buffer failed: Operation not permitted"), boot); err == nil {
+		t.Error("non-JSON output accepted")
+	}
+}
+
+func TestParseJournalJSON(t *testing.T) {
+	output := `{"PRIORITY": "3", "__REALTIME_TIMESTAMP": "1714550400123456", "MESSAGE": "I/O error, dev sda, sector 2048"}
+{"PRIORITY": "6", "__REALTIME_TIMESTAMP": "1714550401000000", "MESSAGE": [104, 105, 255]}
+{"__REALTIME_TIMESTAMP": "1714550402000000", "MESSAGE": "no priority"}
+{"PRIORITY": "4", "__REALTIME_TIMESTAMP": "bad", "MESSAGE": "bad timestamp"}
+
+{"PRIORITY": "3", "__REALTIME_TIMESTAMP": "17145504`
+	messages, err := parseJournalJSON([]byte(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []kernelMessage{
+		{Time: time.UnixMicro(1714550400123456), Level: 3, Message: "I/O error, dev sda, sector 2048"},
+		// 非 UTF-8 的消息为字节数组，无效字节替换为 ?
+		{Time: time.UnixMicro(1714550401000000), Level: 6, Message: "hi?"},
+		{Time: time.UnixMicro(1714550402000000), Level: 6, Message: "no priority"},
+	}
+	if fmt.Sprint(messages) != fmt.Sprint(want) {
+		t.Errorf("parseJournalJSON() = %+v, want %+v", messages, want)
+	}
+
+	if messages, err := parseJournalJSON(nil); err != nil || len(messages) != 0 {
+		t.Errorf("empty output = %v, %v", messages, err)
+	}
+	if _, err := parseJournalJSON([]byte("No journal files were found.\n")); err == nil {
+		t.Error("non-JSON output accepted")
+	}
+}
+
+func TestClassifyKernelMessage(t *testing.T) {
+	cases := []struct {
+		message string
+		kind    string
+		pid     int
+		process string
+		device  string
+	}{
+		// OOM kill：全局、cgroup 和较新内核的 oom-kill 摘要行
+		{"Out of memory: Killed process 1234 (postgres) total-vm:8388608kB, anon-rss:4194304kB, file-rss:0kB", kernelEventOOMKill, 1234, "postgres", ""},
+		{"Memory cgroup out of memory: Killed process 5678 (java) total-vm:2097152kB", kernelEventOOMKill, 5678, "java", ""},
+		{"oom-kill:constraint=CONSTRAINT_NONE,nodemask=(null),cpuset=/,mems_allowed=0,global_oom,task_memcg=/system.slice/postgresql.service,task=postgres,pid=1234,uid=113", kernelEventOOMKill, 1234, "postgres", ""},
+		{"Out of memory: Killed process 42 (Web Content) total-vm:1kB", kernelEventOOMKill, 42, "Web Content", ""},
+		// hung task
+		{"INFO: task jbd2/sda1-8:312 blocked for more than 120 seconds.", kernelEventHungTask, 312, "jbd2/sda1-8", ""},
+		{"INFO: task kworker/u8:2:9876 blocked for more than 241 seconds.", kernelEventHungTask, 9876, "kworker/u8:2", ""},
+		// 文件系统和块设备错误
+		{"blk_update_request: I/O error, dev sda, sector 123456 op 0x0:(READ) flags 0x0", kernelEventIOError, 0, "", "sda"},
+		{"Buffer I/O error on dev dm-1, logical block 0, async page read", kernelEventIOError, 0, "", "dm-1"},
+		{"EXT4-fs error (device sda1): ext4_find_entry:1455: inode #2: comm ls: reading directory lblock 0", kernelEventIOError, 0, "", "sda1"},
+		{"EXT4-fs (sdb1): Remounting filesystem read-only", kernelEventIOError, 0, "", "sdb1"},
+		{"XFS (dm-0): Corruption detected. Unmount and run xfs_repair", kernelEventIOError, 0, "", "dm-0"},
+		{"XFS (nvme0n1p1): Metadata I/O error in \"xfs_buf_ioend\" at daddr 0x2 len 1 error 5", kernelEventIOError, 0, "", "nvme0n1p1"},
+		{"BTRFS error (device nvme0n1p2): bdev /dev/nvme0n1p2 errs: wr 1, rd 0, flush 0", kernelEventIOError, 0, "", "nvme0n1p2"},
+		{"sd 2:0:0:0: [sdb] tag#0 Sense Key : Medium Error [current]", kernelEventIOError, 0, "", "sdb"},
+		{"print_req_error: critical medium error, dev nvme0n1, sector 4096", kernelEventIOError, 0, "", "nvme0n1"},
+		// 不是事件
+		{"usb 1-1: new high-speed USB device number 2 using xhci_hcd", "", 0, "", ""},
+		{"EXT4-fs (sda1): mounted filesystem with ordered data mode", "", 0, "", ""},
+		{"XFS (sdc1): Mounting V5 Filesystem", "", 0, "", ""},
+	}
+	at := time.Date(2024, 5, 1, 14, 2, 0, 0, time.UTC)
+	for _, c := range cases {
+		event, ok := classifyKernelMessage(kernelMessage{Time: at, Level: 3, Message: c.message})
+		if ok != (c.kind != "") || event.Kind != c.kind || event.PID != c.pid || event.Process != c.process || event.Device != c.device {
+			t.Errorf("classifyKernelMessage(%q) = %+v, %v; want %s pid %d process %q device %q", c.message, event, ok, c.kind, c.pid, c.process, c.device)
+			continue
+		}
+		if ok && (!event.Time.Equal(at) || event.Message != c.message) {
+			t.Errorf("classifyKernelMessage(%q) time/message = %v %q", c.message, event.Time, event.Message)
+		}
+	}
+}
+
+// kernelLog 从 start 开始按顺序构造的内核消息
+func kernelLog(start time.Time, entries ...string) []kernelMessage {
+	var messages []kernelMessage
+	for i, entry := range entries {
+		level, message, _ := strings.Cut(entry, " ")
+		levelNumber, _ := kernelLevelByName(level)
+		messages = append(messages, kernelMessage{Time: start.Add(time.Duration(i) * time.Second), Level: levelNumber, Message: message})
+	}
+	return messages
+}
+
+func TestBuildKernelEventsReport(t *testing.T) {
+	start := time.Date(2024, 5, 1, 14, 0, 0, 0, time.Local)
+	messages := kernelLog(start,
+		"err Out of memory: Killed process 1 (old)",
+		"info eth0: link up",
+		"info oom-kill:constraint=CONSTRAINT_NONE,global_oom,task=postgres,pid=1234,uid=113",
+		"err Out of memory: Killed process 1234 (postgres) total-vm:8388608kB",
+		"warning EXT4-fs (sda1): Remounting filesystem read-only",
+		"notice random notice",
+		"err INFO: task jbd2/sda1-8:312 blocked for more than 120 seconds.",
+		"debug debug noise",
+	)
+	// 消息顺序被打乱，报告按时间排列
+	messages[2], messages[5] = messages[5], messages[2]
+
+	report := buildKernelEventsReport(messages, start.Add(time.Second), 4, 50)
+	// 窗口外的消息不计入；同一次 OOM 的两行只计一次
+	if report.Scanned != 7 || report.Counts != (kernelEventCounts{OOMKills: 1, IOErrors: 1, HungTasks: 1}) || report.MinLevel != "warning" {
+		t.Errorf("report = %+v", report)
+	}
+	var events []string
+	for _, event := range report.Events {
+		events = append(events, fmt.Sprintf("%s:%s:%d:%s", event.Kind, event.Process, event.PID, event.Device))
+	}
+	if want := "oom_kill:postgres:1234: io_error::0:sda1 hung_task:jbd2/sda1-8:312:"; strings.Join(events, " ") != want {
+		t.Errorf("events = %v, want %s", events, want)
+	}
+	// 原始消息：warning 及以上，加上低于阈值但被识别为事件的行（info 级别的 oom-kill 摘要）
+	var lines []string
+	for _, line := range report.Lines {
+		lines = append(lines, line.Level+":"+line.Event)
+	}
+	if want := "info:oom_kill err:oom_kill warning:io_error err:hung_task"; strings.Join(lines, " ") != want || report.LinesTotal != 4 || report.Truncated {
+		t.Errorf("lines = %v (%d total, truncated %v), want %s", lines, report.LinesTotal, report.Truncated, want)
+	}
+
+	// limit 保留最新的消息
+	report = buildKernelEventsReport(messages, start.Add(time.Second), 4, 2)
+	if len(report.Lines) != 2 || report.LinesTotal != 4 || !report.Truncated || report.Lines[1].Event != kernelEventHungTask {
+		t.Errorf("limited report = %+v", report)
+	}
+
+	// 同一 PID 间隔 10 秒以上的 OOM 视为两次
+	repeated := []kernelMessage{
+		{Time: start, Level: 3, Message: "Killed process 7 (worker)"},
+		{Time: start.Add(5 * time.Second), Level: 3, Message: "Killed process 7 (worker)"},
+		{Time: start.Add(time.Minute), Level: 3, Message: "Killed process 7 (worker)"},
+	}
+	if report := buildKernelEventsReport(repeated, start, 4, 50); report.Counts.OOMKills != 2 || len(report.Lines) != 3 {
+		t.Errorf("repeated OOM = %+v", report)
+	}
+}
+
+func TestBuildKernelEventsReportCaps(t *testing.T) {
+	start := time.Date(2024, 5, 1, 14, 0, 0, 0, time.Local)
+	var messages []kernelMessage
+	// 200 条超长消息，截断后的总大小仍超过上限
+	for i := 0; i < 200; i++ {
+		messages = append(messages, kernelMessage{
+			Time:    start.Add(time.Duration(i) * time.Second),
+			Level:   3,
+			Message: fmt.Sprintf("Buffer I/O error on dev sdb, logical block %d %s", i, strings.Repeat("x", 2000)),
+		})
+	}
+
+	report := buildKernelEventsReport(messages, start, 4, 1000)
+	// 计数不受列出数量的限制
+	if report.Counts.IOErrors != 200 || len(report.Events) != maxKernelEvents {
+		t.Errorf("%d IO errors, %d events listed", report.Counts.IOErrors, len(report.Events))
+	}
+	// 单行截断，总大小超出上限时丢弃较早的行
+	size := 0
+	for _, line := range report.Lines {
+		if len(line.Message) > maxKernelLineBytes {
+			t.Fatalf("line of %d bytes", len(line.Message))
+		}
+		size += len(line.Message)
+	}
+	if size > maxKernelLinesBytes || !report.Truncated || report.LinesTotal != 200 || !strings.Contains(report.Lines[len(report.Lines)-1].Message, "block 199 ") {
+		t.Errorf("%d lines, %d bytes, truncated %v", len(report.Lines), size, report.Truncated)
+	}
+}
+
+// fakeKernelSource 返回固定结果的内核日志来源
+type fakeKernelSource struct {
+	name     string
+	messages []kernelMessage
+	err      error
+}
+
+func (s fakeKernelSource) Name() string { return s.name }
+
+func (s fakeKernelSource) Read(context.Context, time.Time) ([]kernelMessage, error) {
+	return s.messages, s.err
+}
+
+func TestKernelEventsTool(t *testing.T) {
+	now := time.Date(2024, 5, 1, 15, 0, 0, 0, time.Local)
+	permissionDenied := &os.PathError{Op: "open", Path: "/dev/kmsg", Err: fs.ErrPermission}
+	tool := &KernelEventsTool{
+		platform: platformLinux,
+		now:      func() time.Time { return now },
+		sources: []kernelLogSource{
+			fakeKernelSource{name: "/dev/kmsg", err: permissionDenied},
+			fakeKernelSource{name: "journalctl", messages: kernelLog(now.Add(-2*time.Hour+time.Second),
+				"err Out of memory: Killed process 9 (too old)",
+				"err Out of memory: Killed process 1234 (postgres)",
+				"err blk_update_request: I/O error, dev sda, sector 2048",
+			)},
+		},
+	}
+	// 第一个来源失败时使用下一个，并说明原因
+	text, err := tool.Execute(context.Background(), map[string]interface{}{"since": "2h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"🧾 内核事件（最近 2h0m0s，来源: journalctl）\n",
+		"OOM kill: 2  IO 错误: 1  Hung task: 0\n",
+		"  05-01 13:00:02  OOM kill  postgres (PID 1234)\n",
+		"  05-01 13:00:03  IO 错误  sda\n",
+		"  05-01 13:00:03 [err] blk_update_request: I/O error, dev sda, sector 2048\n",
+		"ℹ️  /dev/kmsg 不可用: open /dev/kmsg: permission denied\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output lacks %q:\n%s", want, text)
+		}
+	}
+
+	// 回溯时长过滤较早的消息
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"since": "7198s", "format": "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report kernelEventsReport
+	if err := json.Unmarshal([]byte(text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Source != "journalctl" || report.Scanned != 2 || report.Counts.OOMKills != 1 || len(report.Notes) != 1 {
+		t.Errorf("JSON report = %+v", report)
+	}
+
+	// 全部来源失败且有权限错误时提示需要更高权限
+	tool.sources = []kernelLogSource{
+		fakeKernelSource{name: "/dev/kmsg", err: permissionDenied},
+		fakeKernelSource{name: "dmesg", err: errors.New("dmesg: read kernel buffer failed: Operation not permitted")},
+		fakeKernelSource{name: "journalctl", err: errors.New("executable file not found")},
+	}
+	_, err = tool.Execute(context.Background(), nil)
+	var toolErr *Error
+	if !errors.As(err, &toolErr) || toolErr.Code != ErrPermission || !strings.Contains(toolErr.Message, "需要更高权限或用户组成员身份") || !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("all sources denied: err = %v", err)
+	}
+
+	tool.sources = []kernelLogSource{fakeKernelSource{name: "dmesg", err: errors.New("boom")}}
+	if _, err := tool.Execute(context.Background(), nil); !errors.As(err, &toolErr) || toolErr.Code == ErrPermission {
+		t.Errorf("all sources failed: err = %v", err)
+	}
+
+	for _, args := range []map[string]interface{}{{"since": "0s"}, {"since": "721h"}, {"min_level": "loud"}} {
+		if _, err := tool.Execute(context.Background(), args); !errors.As(err, &toolErr) || toolErr.Code != ErrBadArgument {
+			t.Errorf("Execute(%v): err = %v", args, err)
+		}
+	}
+
+	tool.platform = platformDarwin
+	if _, err := tool.Execute(context.Background(), nil); !errors.As(err, &toolErr) || toolErr.Code != ErrUnsupportedPlatform {
+		t.Errorf("darwin: err = %v", err)
+	}
+}
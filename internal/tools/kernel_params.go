@@ -0,0 +1,274 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/types"
+)
+
+// defaultKernelParams 默认允许读取的内核参数
+var defaultKernelParams = []string{
+	"vm.swappiness",
+	"vm.overcommit_memory",
+	"vm.overcommit_ratio",
+	"vm.dirty_ratio",
+	"vm.dirty_background_ratio",
+	"vm.max_map_count",
+	"fs.file-max",
+	"fs.file-nr",
+	"fs.inotify.max_user_watches",
+	"fs.inotify.max_user_instances",
+	"kernel.pid_max",
+	"kernel.threads-max",
+	"net.core.somaxconn",
+	"net.core.netdev_max_backlog",
+	"net.core.rmem_max",
+	"net.core.wmem_max",
+	"net.ipv4.ip_forward",
+	"net.ipv4.ip_local_port_range",
+	"net.ipv4.tcp_max_syn_backlog",
+	"net.ipv4.tcp_fin_timeout",
+	"net.ipv4.tcp_tw_reuse",
+	"net.ipv4.tcp_keepalive_time",
+	"net.ipv4.tcp_rmem",
+	"net.ipv4.tcp_wmem",
+}
+
+// kernelParamFields 多值参数各字段的含义
+var kernelParamFields = map[string][]string{
+	"fs.file-nr":                   {"allocated", "free", "max"},
+	"net.ipv4.ip_local_port_range": {"min", "max"},
+	"net.ipv4.tcp_rmem":            {"min", "default", "max"},
+	"net.ipv4.tcp_wmem":            {"min", "default", "max"},
+}
+
+// kernelParam 单个内核参数
+type kernelParam struct {
+	Name   string            `json:"name"`
+	Group  string            `json:"group"`
+	Raw    string            `json:"raw,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// kernelParamsReport JSON 输出
+type kernelParamsReport struct {
+	Params []kernelParam       `json:"params"`
+	Host   *types.HostIdentity `json:"host,omitempty"`
+}
+
+// KernelParamsTool 内核参数（sysctl）查询工具，仅支持 Linux
+type KernelParamsTool struct {
+	allowed  map[string]bool
+	procRoot string
+}
+
+// NewKernelParamsTool 创建新的内核参数工具，extraParams 追加到默认允许列表
+func NewKernelParamsTool(extraParams []string) *KernelParamsTool {
+	allowed := make(map[string]bool)
+	for _, name := range defaultKernelParams {
+		allowed[name] = true
+	}
+	for _, name := range extraParams {
+		allowed[name] = true
+	}
+
+	return &KernelParamsTool{
+		allowed:  allowed,
+		procRoot: "/proc/sys",
+	}
+}
+
+// GetName 获取工具名称
+func (kt *KernelParamsTool) GetName() string {
+	return "kernel_params"
+}
+
+// GetDescription 获取工具描述
+func (kt *KernelParamsTool) GetDescription() string {
+	return "读取常用内核参数（sysctl），如 vm.swappiness、net.core.somaxconn（仅 Linux）"
+}
+
+// GetAnnotations 获取工具注解
+func (kt *KernelParamsTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("内核参数")
+}
+
+// kernelParamsArgs kernel_params 的参数
+type kernelParamsArgs struct {
+	Name string `arg:"name" pattern:"^[A-Za-z0-9_-]+(\\.[A-Za-z0-9_-]+)*$" desc:"参数名称（如 vm.swappiness），为空则返回全部允许的参数"`
+	formatArgs
+}
+
+// GetInputSchema 获取输入模式
+func (kt *KernelParamsTool) GetInputSchema() types.InputSchema {
+	return argsSchema(kernelParamsArgs{})
+}
+
+// Examples 获取调用示例
+func (kt *KernelParamsTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "按子系统分组的全部允许查询的内核参数",
+			Arguments:   map[string]interface{}{},
+		},
+		{
+			Description: "vm.swappiness 的当前值和说明，JSON 格式",
+			Arguments:   map[string]interface{}{"name": "vm.swappiness", "format": "json"},
+		},
+	}
+}
+
+// Execute 读取内核参数
+func (kt *KernelParamsTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	if runtime.GOOS != "linux" {
+		return "", unsupportedPlatform("kernel_params 仅支持 Linux（当前平台: %s）", runtime.GOOS)
+	}
+
+	var a kernelParamsArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+	name := a.Name
+
+	var params []kernelParam
+	if name != "" {
+		if !kt.allowed[name] {
+			return "", argumentError("name", "参数 %s 不在允许列表中", name)
+		}
+		param, err := kt.readParam(name)
+		if err != nil {
+			return "", wrapError(fmt.Sprintf("读取参数 %s 失败", name), err)
+		}
+		params = append(params, param)
+	} else {
+		names := make([]string, 0, len(kt.allowed))
+		for allowedName := range kt.allowed {
+			names = append(names, allowedName)
+		}
+		sort.Strings(names)
+		for _, allowedName := range names {
+			param, _ := kt.readParam(allowedName)
+			params = append(params, param)
+		}
+	}
+
+	if a.Format == "json" {
+		report := kernelParamsReport{Params: params, Host: identity.Get()}
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", wrapError("序列化内核参数失败", err)
+		}
+		return string(jsonData), nil
+	}
+
+	return kt.formatParams(params), nil
+}
+
+// readParam 读取并解析单个参数，读取失败时错误同时记录在 Error 字段中
+func (kt *KernelParamsTool) readParam(name string) (kernelParam, error) {
+	param := kernelParam{
+		Name:  name,
+		Group: kernelParamGroup(name),
+	}
+
+	raw, err := readSysctl(kt.procRoot, name)
+	if err != nil {
+		param.Error = err.Error()
+		return param, err
+	}
+	param.Raw = raw
+	param.Fields = interpretKernelParam(name, raw)
+
+	return param, nil
+}
+
+// readSysctl 从 /proc/sys 读取参数值（名称中的 . 对应目录层级），不调用 sysctl 命令
+func readSysctl(procRoot, name string) (string, error) {
+	if name == "" || strings.Contains(name, "/") || strings.Contains(name, "..") {
+		return "", badArgument("无效的参数名称: %q", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(procRoot, strings.ReplaceAll(name, ".", "/")))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", notFound("当前内核不支持该参数")
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// kernelParamGroup 参数所属子系统（去掉最后一段）
+func kernelParamGroup(name string) string {
+	if index := strings.LastIndex(name, "."); index > 0 {
+		return name[:index]
+	}
+	return name
+}
+
+// interpretKernelParam 将多值参数拆分为具名字段，无法解释时返回 nil
+func interpretKernelParam(name, raw string) map[string]string {
+	labels, known := kernelParamFields[name]
+	if !known {
+		return nil
+	}
+
+	values := strings.Fields(raw)
+	if len(values) != len(labels) {
+		return nil
+	}
+
+	fields := make(map[string]string, len(labels))
+	for i, label := range labels {
+		fields[label] = values[i]
+	}
+	return fields
+}
+
+// formatParams 格式化内核参数输出
+func (kt *KernelParamsTool) formatParams(params []kernelParam) string {
+	var result string
+
+	result += "⚙️  内核参数\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+
+	sort.SliceStable(params, func(i, j int) bool {
+		return params[i].Group < params[j].Group
+	})
+
+	group := ""
+	for _, param := range params {
+		if param.Group != group {
+			group = param.Group
+			result += fmt.Sprintf("\n[%s]\n", group)
+		}
+
+		if param.Error != "" {
+			result += fmt.Sprintf("  %-40s ⚠️  %s\n", param.Name, param.Error)
+			continue
+		}
+
+		// 多值参数以制表符分隔，显示时统一为空格
+		result += fmt.Sprintf("  %-40s %s", param.Name, strings.Join(strings.Fields(param.Raw), " "))
+		if param.Fields != nil {
+			var parts []string
+			for _, label := range kernelParamFields[param.Name] {
+				parts = append(parts, label+"="+param.Fields[label])
+			}
+			result += fmt.Sprintf("  (%s)", strings.Join(parts, ", "))
+		}
+		result += "\n"
+	}
+
+	return result
+}
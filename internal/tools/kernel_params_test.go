@@ -0,0 +1,183 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// sysctlFixture /proc/sys 下的部分参数，多值参数与内核一样以制表符分隔
+var sysctlFixture = map[string]string{
+	"vm/swappiness":                 "60\n",
+	"fs/file-nr":                    "12736\t0\t9223372036854775807\n",
+	"net/core/somaxconn":            "4096\n",
+	"net/ipv4/ip_local_port_range":  "32768\t60999\n",
+	"net/ipv4/tcp_rmem":             "4096\t131072\t6291456\n",
+	"kernel/core_pattern":           "|/usr/lib/systemd/systemd-coredump %P\n",
+	"net/ipv4/conf/all/rp_filter":   "2\n",
+	"fs/inotify/max_user_watches":   "65536\n",
+	"fs/inotify/max_user_instances": "128\n",
+	"net/ipv4/tcp_keepalive_time":   "7200\n",
+	"net/ipv4/tcp_max_syn_backlog":  "1024\n",
+	"net/ipv4/tcp_fin_timeout":      "60\n",
+	"vm/overcommit_memory":          "0\n",
+	"kernel/pid_max":                "4194304\n",
+	"net/core/netdev_max_backlog":   "1000\n",
+	"net/ipv4/ip_forward":           "1\n",
+	"vm/dirty_ratio":                "20\n",
+	"vm/dirty_background_ratio":     "10\n",
+	"vm/max_map_count":              "65530\n",
+	"net/ipv4/tcp_tw_reuse":         "2\n",
+	"net/ipv4/tcp_wmem":             "4096\t16384\t4194304\n",
+	"net/core/rmem_max":             "212992\n",
+	"net/core/wmem_max":             "212992\n",
+	"kernel/threads-max":            "126633\n",
+	"vm/overcommit_ratio":           "50\n",
+	"fs/file-max":                   "9223372036854775807\n",
+	"../outside":                    "secret\n",
+}
+
+func TestReadSysctl(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"sys/vm/swappiness":                "60\n",
+		"sys/net/ipv4/ip_local_port_range": "32768\t60999\n",
+		"secret":                           "outside /proc/sys\n",
+	})
+	procRoot := root + "/sys"
+
+	if value, err := readSysctl(procRoot, "vm.swappiness"); err != nil || value != "60" {
+		t.Fatalf("readSysctl(vm.swappiness) = %q, %v", value, err)
+	}
+	if value, err := readSysctl(procRoot, "net.ipv4.ip_local_port_range"); err != nil || value != "32768\t60999" {
+		t.Fatalf("readSysctl(ip_local_port_range) = %q, %v", value, err)
+	}
+
+	var toolErr *Error
+	if _, err := readSysctl(procRoot, "vm.nonexistent"); !errors.As(err, &toolErr) || toolErr.Code != ErrNotFound {
+		t.Errorf("readSysctl of a missing parameter = %v, want ErrNotFound", err)
+	}
+	// 名称中的 / 和 .. 会跳出 /proc/sys，不读取
+	for _, name := range []string{"", "../secret", "vm/../../secret", "vm..swappiness", "..", "/etc/passwd"} {
+		if value, err := readSysctl(procRoot, name); !errors.As(err, &toolErr) || toolErr.Code != ErrBadArgument {
+			t.Errorf("readSysctl(%q) = %q, %v; want ErrBadArgument", name, value, err)
+		}
+	}
+}
+
+func TestInterpretKernelParam(t *testing.T) {
+	cases := []struct {
+		name, raw string
+		want      map[string]string
+	}{
+		{"net.ipv4.ip_local_port_range", "32768\t60999", map[string]string{"min": "32768", "max": "60999"}},
+		{"net.ipv4.tcp_rmem", "4096 131072  6291456", map[string]string{"min": "4096", "default": "131072", "max": "6291456"}},
+		{"fs.file-nr", "12736\t0\t9223372036854775807", map[string]string{"allocated": "12736", "free": "0", "max": "9223372036854775807"}},
+		// 字段数与预期不符时不解释
+		{"net.ipv4.ip_local_port_range", "32768", nil},
+		{"vm.swappiness", "60", nil},
+	}
+	for _, c := range cases {
+		got := interpretKernelParam(c.name, c.raw)
+		if len(got) != len(c.want) || (c.want == nil) != (got == nil) {
+			t.Errorf("interpretKernelParam(%s, %q) = %v, want %v", c.name, c.raw, got, c.want)
+			continue
+		}
+		for label, value := range c.want {
+			if got[label] != value {
+				t.Errorf("interpretKernelParam(%s, %q)[%s] = %q, want %q", c.name, c.raw, label, got[label], value)
+			}
+		}
+	}
+}
+
+// newKernelParamsTool 从 sysctlFixture 读取参数的内核参数工具
+func newKernelParamsTool(t *testing.T, extraParams ...string) *KernelParamsTool {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("kernel_params only runs on Linux")
+	}
+	files := make(map[string]string, len(sysctlFixture))
+	for name, content := range sysctlFixture {
+		files["sys/"+name] = content
+	}
+	tool := NewKernelParamsTool(extraParams)
+	tool.procRoot = writeTree(t, files) + "/sys"
+	return tool
+}
+
+func TestKernelParamsSingle(t *testing.T) {
+	tool := newKernelParamsTool(t)
+
+	text, err := tool.Execute(context.Background(), map[string]interface{}{"name": "net.ipv4.ip_local_port_range", "format": "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report kernelParamsReport
+	if err := json.Unmarshal([]byte(text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Params) != 1 {
+		t.Fatalf("params = %+v, want only the requested one", report.Params)
+	}
+	param := report.Params[0]
+	if param.Group != "net.ipv4" || param.Raw != "32768\t60999" || param.Fields["min"] != "32768" || param.Fields["max"] != "60999" {
+		t.Errorf("param = %+v", param)
+	}
+
+	// 不在允许列表中的参数即使存在也不读取
+	var toolErr *Error
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"name": "kernel.core_pattern"}); !errors.As(err, &toolErr) || toolErr.Code != ErrBadArgument || toolErr.Argument != "name" {
+		t.Errorf("kernel.core_pattern = %v, want it rejected by the allowlist", err)
+	}
+	for _, name := range []string{"../outside", "vm/swappiness", "vm..swappiness"} {
+		if _, err := tool.Execute(context.Background(), map[string]interface{}{"name": name}); !errors.As(err, &toolErr) || toolErr.Code != ErrBadArgument {
+			t.Errorf("name %q = %v, want ErrBadArgument", name, err)
+		}
+	}
+}
+
+func TestKernelParamsAllowlistExtension(t *testing.T) {
+	// 配置追加的参数可以查询；追加了路径形式的名称时同样不会跳出 /proc/sys
+	tool := newKernelParamsTool(t, "kernel.core_pattern", "net.ipv4.conf.all.rp_filter", "vm...outside")
+
+	text, err := tool.Execute(context.Background(), map[string]interface{}{"name": "kernel.core_pattern"})
+	if err != nil || !strings.Contains(text, "|/usr/lib/systemd/systemd-coredump %P") {
+		t.Fatalf("kernel.core_pattern = %q, %v", text, err)
+	}
+
+	text, err = tool.Execute(context.Background(), map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"[net.ipv4.conf.all]\n  net.ipv4.conf.all.rp_filter",
+		"[kernel]\n  kernel.core_pattern",
+		"net.ipv4.ip_local_port_range             32768 60999  (min=32768, max=60999)\n",
+		"net.ipv4.tcp_rmem                        4096 131072 6291456  (min=4096, default=131072, max=6291456)\n",
+		"vm...outside                             ⚠️  无效的参数名称",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "secret") {
+		t.Errorf("output read a file outside /proc/sys:\n%s", text)
+	}
+}
+
+func TestKernelParamsMissingParameter(t *testing.T) {
+	tool := newKernelParamsTool(t, "vm.nonexistent")
+
+	// 单个参数不存在时返回 ERR_NOT_FOUND；全部列出时标记在该参数上
+	var toolErr *Error
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"name": "vm.nonexistent"}); !errors.As(err, &toolErr) || toolErr.Code != ErrNotFound {
+		t.Errorf("vm.nonexistent = %v, want ErrNotFound", err)
+	}
+	text, err := tool.Execute(context.Background(), map[string]interface{}{})
+	if err != nil || !strings.Contains(text, "vm.nonexistent                           ⚠️  当前内核不支持该参数") || !strings.Contains(text, "vm.swappiness                            60\n") {
+		t.Errorf("output =\n%s\n%v", text, err)
+	}
+}
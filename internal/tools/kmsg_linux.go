@@ -0,0 +1,45 @@
+//go:build linux
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+// readKmsg 以非阻塞方式读取 /dev/kmsg 中当前缓冲的全部记录（每次 read 返回一条）。
+// 读到缓冲区末尾（EAGAIN）、超过 kmsgReadTimeout、读满 maxKmsgRecords 条或 ctx 取消时停止，不会等待新消息
+func readKmsg(ctx context.Context, path string) ([]string, error) {
+	fd, err := syscall.Open(path, syscall.O_RDONLY|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	defer syscall.Close(fd)
+
+	deadline := time.Now().Add(kmsgReadTimeout)
+	buf := make([]byte, kmsgRecordSize)
+	var records []string
+	for len(records) < maxKmsgRecords {
+		if ctx.Err() != nil || time.Now().After(deadline) {
+			break
+		}
+
+		n, err := syscall.Read(fd, buf)
+		switch {
+		case errors.Is(err, syscall.EAGAIN):
+			return records, nil
+		case errors.Is(err, syscall.EINTR), errors.Is(err, syscall.EPIPE):
+			// EPIPE：下一条记录在读取前已被覆盖，继续读取之后的记录
+			continue
+		case err != nil:
+			return records, &os.PathError{Op: "read", Path: path, Err: err}
+		case n == 0:
+			return records, nil
+		}
+		records = append(records, string(buf[:n]))
+	}
+	return records, nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package tools
+
+import (
+	"context"
+	"runtime"
+)
+
+// readKmsg /dev/kmsg 仅存在于 Linux
+func readKmsg(ctx context.Context, path string) ([]string, error) {
+	return nil, unsupportedPlatform("%s 仅支持 Linux（当前平台: %s）", path, runtime.GOOS)
+}
@@ -0,0 +1,197 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"mcp-example/internal/fswalk"
+	"mcp-example/internal/identity"
+	"mcp-example/internal/types"
+)
+
+// LargestDirectoriesTool 最大目录工具：找出分区中占用空间最多的子目录
+type LargestDirectoriesTool struct{}
+
+// NewLargestDirectoriesTool 创建新的最大目录工具
+func NewLargestDirectoriesTool() *LargestDirectoriesTool {
+	return &LargestDirectoriesTool{}
+}
+
+// directoryUsage 单个子目录的累计大小（包含其下所有层级）
+type directoryUsage struct {
+	Path      string  `json:"path"`
+	Depth     int     `json:"depth"`
+	SizeBytes uint64  `json:"size_bytes"`
+	SizeMB    float64 `json:"size_mb"`
+	Files     int     `json:"files"`
+}
+
+// directoryReport 目录扫描结果
+type directoryReport struct {
+	Path        string           `json:"path"`
+	Depth       int              `json:"depth"`
+	TotalBytes  uint64           `json:"total_bytes"`
+	TotalFiles  int              `json:"total_files"`
+	Directories []directoryUsage `json:"directories"`
+	// Matching 满足 min_size_mb 的目录数，Directories 只保留其中最大的 top 个
+	Matching int `json:"matching_count"`
+	walkStats
+	Host *types.HostIdentity `json:"host,omitempty"`
+}
+
+// GetName 获取工具名称
+func (ld *LargestDirectoriesTool) GetName() string {
+	return "largest_directories"
+}
+
+// GetDescription 获取工具描述
+func (ld *LargestDirectoriesTool) GetDescription() string {
+	return "找出指定路径下占用空间最多的子目录（不跟随符号链接，不跨越文件系统）"
+}
+
+// GetAnnotations 获取工具注解
+func (ld *LargestDirectoriesTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("最大目录")
+}
+
+// largestDirectoriesArgs largest_directories 的参数
+type largestDirectoriesArgs struct {
+	strictArgs
+	Path      string  `arg:"path,required" desc:"要扫描的目录，如 disk_info 中使用率较高的挂载点"`
+	Depth     int     `arg:"depth,default=2,min=1,max=10" desc:"列出的子目录最大层级（1 为直接子目录），更深的内容仍计入上层目录的大小"`
+	Top       int     `arg:"top,default=10,min=1,max=100" desc:"返回最大的目录数量"`
+	MinSizeMB float64 `arg:"min_size_mb,default=0,min=0" desc:"只列出不小于该大小（MB）的目录"`
+	formatArgs
+}
+
+// GetInputSchema 获取输入模式
+func (ld *LargestDirectoriesTool) GetInputSchema() types.InputSchema {
+	return argsSchema(largestDirectoriesArgs{})
+}
+
+// Examples 获取调用示例
+func (ld *LargestDirectoriesTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "/var 下最大的 10 个目录（两层以内）",
+			Arguments:   map[string]interface{}{"path": "/var"},
+		},
+		{
+			Description: "/home 下不小于 1 GB 的直接子目录",
+			Arguments:   map[string]interface{}{"path": "/home", "depth": 1, "min_size_mb": 1024},
+		},
+	}
+}
+
+// Execute 扫描目录
+func (ld *LargestDirectoriesTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a largestDirectoriesArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+
+	report, err := scanDirectories(ctx, a.Path, a.Depth)
+	if err != nil {
+		return "", err
+	}
+	selectLargestDirectories(&report, a.Top, uint64(a.MinSizeMB*1024*1024))
+
+	if a.Format == "json" {
+		report.Host = identity.Get()
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", wrapError("序列化目录扫描结果失败", err)
+		}
+		return string(jsonData), nil
+	}
+
+	return formatDirectoryReport(report, a.Top, a.MinSizeMB), nil
+}
+
+// scanDirectories 扫描 root 下的目录树，记录最多 maxDepth 层的各子目录累计大小和文件数
+func scanDirectories(ctx context.Context, root string, maxDepth int) (directoryReport, error) {
+	report := directoryReport{Path: filepath.Clean(root), Depth: maxDepth}
+
+	result, err := walkPath(ctx, root, fswalk.Visitor{
+		Dir: func(path string, depth int, size uint64, files int) {
+			if depth > maxDepth {
+				return
+			}
+			report.Directories = append(report.Directories, directoryUsage{
+				Path:      path,
+				Depth:     depth,
+				SizeBytes: size,
+				SizeMB:    float64(size) / (1024 * 1024),
+				Files:     files,
+			})
+		},
+	})
+	if err != nil {
+		return report, err
+	}
+
+	report.TotalBytes = result.TotalBytes
+	report.TotalFiles = result.TotalFiles
+	report.walkStats = newWalkStats(result)
+	return report, nil
+}
+
+// selectLargestDirectories 过滤小于 minBytes 的目录，按大小降序（相同时按路径）保留前 top 个
+func selectLargestDirectories(report *directoryReport, top int, minBytes uint64) {
+	var matching []directoryUsage
+	for _, dir := range report.Directories {
+		if dir.SizeBytes >= minBytes {
+			matching = append(matching, dir)
+		}
+	}
+
+	sort.Slice(matching, func(i, j int) bool {
+		if matching[i].SizeBytes != matching[j].SizeBytes {
+			return matching[i].SizeBytes > matching[j].SizeBytes
+		}
+		return matching[i].Path < matching[j].Path
+	})
+
+	report.Matching = len(matching)
+	if len(matching) > top {
+		matching = matching[:top]
+	}
+	report.Directories = matching
+}
+
+// formatDirectoryReport 格式化目录扫描结果
+func formatDirectoryReport(report directoryReport, top int, minSizeMB float64) string {
+	var result string
+
+	result += fmt.Sprintf("📂 %s 下最大的目录（%d 层以内）\n", report.Path, report.Depth)
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result += fmt.Sprintf("总大小: %s，%d 个文件\n\n", formatBytes(report.TotalBytes), report.TotalFiles)
+
+	if len(report.Directories) == 0 {
+		if minSizeMB > 0 {
+			result += fmt.Sprintf("没有不小于 %.0f MB 的子目录\n", minSizeMB)
+		} else {
+			result += "没有子目录\n"
+		}
+	} else {
+		result += fmt.Sprintf("%-12s %-8s %-10s %s\n", "大小", "占比", "文件数", "目录")
+		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for _, dir := range report.Directories {
+			var share float64
+			if report.TotalBytes > 0 {
+				share = float64(dir.SizeBytes) / float64(report.TotalBytes) * 100
+			}
+			result += fmt.Sprintf("%-12s %-8s %-10d %s\n", formatBytes(dir.SizeBytes), fmt.Sprintf("%.1f%%", share), dir.Files, dir.Path)
+		}
+		if report.Matching > top {
+			result += fmt.Sprintf("\n显示 %d / %d 个目录，可调大 top 或设置 min_size_mb\n", top, report.Matching)
+		}
+	}
+
+	result += formatWalkStats(report.walkStats)
+
+	return result
+}
@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// directoryTree 构造的目录树：
+//
+//	a/x      3000 B
+//	a/b/y    5000 B
+//	a/b/c/z  1000 B（第 3 层）
+//	d/w      2000 B
+//	f         100 B
+//	link -> a（符号链接，不跟随）
+func directoryTree(t *testing.T) string {
+	t.Helper()
+	root := writeTree(t, map[string]string{
+		"a/x":     strings.Repeat("x", 3000),
+		"a/b/y":   strings.Repeat("y", 5000),
+		"a/b/c/z": strings.Repeat("z", 1000),
+		"d/w":     strings.Repeat("w", 2000),
+		"f":       strings.Repeat("f", 100),
+	})
+	if err := os.Symlink(filepath.Join(root, "a"), filepath.Join(root, "link")); err != nil {
+		t.Skipf("无法创建符号链接: %v", err)
+	}
+	return root
+}
+
+func TestScanDirectories(t *testing.T) {
+	root := directoryTree(t)
+
+	report, err := scanDirectories(context.Background(), root, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 符号链接指向的内容不重复计入
+	if report.TotalBytes != 11100 || report.TotalFiles != 5 || report.Symlinks != 1 || report.Truncated {
+		t.Errorf("report = %+v", report)
+	}
+
+	// 超过 depth 的目录不单独列出，但计入上层目录
+	sizes := make(map[string]directoryUsage)
+	for _, dir := range report.Directories {
+		sizes[strings.TrimPrefix(dir.Path, root+string(filepath.Separator))] = dir
+	}
+	want := map[string]struct {
+		depth int
+		size  uint64
+		files int
+	}{
+		"a":                     {1, 9000, 3},
+		filepath.Join("a", "b"): {2, 6000, 2},
+		"d":                     {1, 2000, 1},
+	}
+	if len(sizes) != len(want) {
+		t.Fatalf("directories = %+v", report.Directories)
+	}
+	for path, w := range want {
+		got := sizes[path]
+		if got.Depth != w.depth || got.SizeBytes != w.size || got.Files != w.files {
+			t.Errorf("%s = %+v, want depth %d, %d bytes, %d files", path, got, w.depth, w.size, w.files)
+		}
+	}
+}
+
+func TestSelectLargestDirectories(t *testing.T) {
+	report := directoryReport{Directories: []directoryUsage{
+		{Path: "/d", SizeBytes: 2000},
+		{Path: "/a/b", SizeBytes: 6000},
+		{Path: "/e", SizeBytes: 2000},
+		{Path: "/a", SizeBytes: 9000},
+	}}
+	selectLargestDirectories(&report, 3, 0)
+	// 按大小降序，相同大小按路径
+	var paths []string
+	for _, dir := range report.Directories {
+		paths = append(paths, dir.Path)
+	}
+	if strings.Join(paths, " ") != "/a /a/b /d" || report.Matching != 4 {
+		t.Errorf("top 3 = %v of %d", paths, report.Matching)
+	}
+
+	selectLargestDirectories(&report, 10, 5000)
+	if len(report.Directories) != 2 || report.Matching != 2 {
+		t.Errorf("min 5000 bytes = %+v", report.Directories)
+	}
+}
+
+func TestLargestDirectoriesTool(t *testing.T) {
+	root := directoryTree(t)
+	tool := NewLargestDirectoriesTool()
+
+	var messages []string
+	ctx := WithProgress(context.Background(), func(done, total float64, message string) {
+		messages = append(messages, message)
+	})
+	text, err := tool.Execute(ctx, map[string]interface{}{"path": root, "depth": 1, "top": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"📂 " + root + " 下最大的目录（1 层以内）\n",
+		"总大小: 10.84 KB，5 个文件\n",
+		"8.79 KB      81.1%    3          " + filepath.Join(root, "a") + "\n",
+		"\n显示 1 / 2 个目录，可调大 top 或设置 min_size_mb\n",
+		"💡 跳过了 1 个符号链接（不跟随）\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output lacks %q:\n%s", want, text)
+		}
+	}
+	if len(messages) != 2 || messages[0] != "开始扫描 "+root || messages[1] != "扫描完成" {
+		t.Errorf("progress = %q", messages)
+	}
+
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"path": root, "min_size_mb": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "没有不小于 1 MB 的子目录\n") {
+		t.Errorf("min_size_mb output:\n%s", text)
+	}
+
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"path": root, "format": "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report directoryReport
+	if err := json.Unmarshal([]byte(text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.TotalBytes != 11100 || report.Matching != 3 || len(report.Directories) != 3 || report.Directories[0].Files != 3 || report.Visited != 10 {
+		t.Errorf("JSON report = %+v", report)
+	}
+}
+
+func TestLargestDirectoriesErrors(t *testing.T) {
+	root := directoryTree(t)
+	tool := NewLargestDirectoriesTool()
+
+	cases := []struct {
+		name string
+		path string
+		code ErrorCode
+	}{
+		{"missing", filepath.Join(root, "missing"), ErrNotFound},
+		{"file", filepath.Join(root, "f"), ErrBadArgument},
+	}
+	for _, c := range cases {
+		_, err := tool.Execute(context.Background(), map[string]interface{}{"path": c.path})
+		var toolErr *Error
+		if !errors.As(err, &toolErr) || toolErr.Code != c.code {
+			t.Errorf("%s: err = %v, want %s", c.name, err, c.code)
+		}
+	}
+
+	// 调用方取消时返回取消错误，不返回部分结果
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := tool.Execute(ctx, map[string]interface{}{"path": root}); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: err = %v", err)
+	}
+}
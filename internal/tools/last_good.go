@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"log/slog"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// DefaultFallbackMaxAge 降级数据的默认最长有效期
+const DefaultFallbackMaxAge = 10 * time.Minute
+
+// lastGoodKeyPrefix 最近一次成功采集结果在存储中的键前缀，完整的键为 lastgood_<工具名>
+const lastGoodKeyPrefix = "lastgood_"
+
+// lastGoodRecord 存储中保存的最近一次成功采集结果，Key 为采集时的缓存键（包含参数），
+// 只有参数相同的调用才会使用该记录降级
+type lastGoodRecord[T any] struct {
+	Key         string    `json:"key"`
+	CollectedAt time.Time `json:"collected_at"`
+	DurationMs  int64     `json:"duration_ms,omitempty"`
+	Data        T         `json:"data"`
+}
+
+// LastGood 将每个工具最近一次成功的采集结果持久化，实时采集失败时作为降级数据返回。
+// 每个工具只保存一条记录，避免不同参数组合产生大量存储文件。
+type LastGood struct {
+	storage types.DataStorage
+	maxAge  time.Duration
+}
+
+// NewLastGood 创建降级数据存储，早于 maxAge 的记录不会被使用
+func NewLastGood(dataStorage types.DataStorage, maxAge time.Duration) *LastGood {
+	return &LastGood{
+		storage: dataStorage,
+		maxAge:  maxAge,
+	}
+}
+
+// MaxAge 降级数据的最长有效期
+func (lg *LastGood) MaxAge() time.Duration {
+	return lg.maxAge
+}
+
+// saveLastGood 保存工具的最近一次成功采集结果及采集耗时，写入失败只记录日志
+func saveLastGood[T any](lg *LastGood, name, key string, data T, collectedAt time.Time, duration time.Duration) {
+	record := lastGoodRecord[T]{Key: key, CollectedAt: collectedAt, DurationMs: duration.Milliseconds(), Data: data}
+	if err := lg.storage.Save(lastGoodKeyPrefix+name, record); err != nil {
+		slog.Debug("保存降级数据失败", "tool", name, "error", err)
+	}
+}
+
+// loadLastGood 读取与 key 参数相同、且未超过最长有效期的降级数据，cacheMeta 中记录数据的年龄和原始采集耗时。
+// 记录可能来自之前的进程，年龄按墙上时钟 now 计算：时钟倒退使采集时间晚于 now 时不使用该记录
+func loadLastGood[T any](lg *LastGood, name, key string, now time.Time) (T, cacheMeta, bool) {
+	var record lastGoodRecord[T]
+	storageKey := lastGoodKeyPrefix + name
+	if !lg.storage.Exists(storageKey) {
+		return record.Data, cacheMeta{}, false
+	}
+	if err := lg.storage.Load(storageKey, &record); err != nil || record.Key != key {
+		return record.Data, cacheMeta{}, false
+	}
+
+	age := now.Round(0).Sub(record.CollectedAt)
+	if age < 0 || age > lg.maxAge {
+		return record.Data, cacheMeta{}, false
+	}
+	meta := cacheMeta{
+		Cached:          true,
+		Age:             age,
+		Fallback:        true,
+		CollectDuration: time.Duration(record.DurationMs) * time.Millisecond,
+	}
+	return record.Data, meta, true
+}
+
+// fallbackInfo JSON 输出中的降级数据说明
+type fallbackInfo struct {
+	AgeSeconds int    `json:"age_seconds"`
+	Error      string `json:"error"`
+}
+
+// newFallbackInfo 返回降级数据的说明，不是降级数据时返回 nil
+func newFallbackInfo(meta cacheMeta) *fallbackInfo {
+	if !meta.Fallback {
+		return nil
+	}
+	return &fallbackInfo{AgeSeconds: int(meta.Age.Seconds()), Error: meta.FallbackErr.Error()}
+}
@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+)
+
+func TestLastGoodFallback(t *testing.T) {
+	clock := newJumpClock()
+	dataStorage := storage.NewMemoryStorage()
+	lastGood := NewLastGood(dataStorage, 10*time.Minute)
+	args := map[string]interface{}{"show_all": "true"}
+	options := func(args map[string]interface{}) CacheOptions {
+		return clock.options(CacheOptions{LastGood: lastGood, Name: "disk_info"}.forCall(args))
+	}
+	collectErr := errors.New("statfs timeout")
+
+	// 没有成功采集过时直接返回采集错误
+	failing := &countingCollector{err: collectErr}
+	if _, meta, err := withCache(context.Background(), storage.NewMemoryCache(), options(args), "disk_info", time.Second, failing.collect); !errors.Is(err, collectErr) || meta.Fallback {
+		t.Fatalf("withCache() without a last good record = %+v, %v; want the collection error", meta, err)
+	}
+	if dataStorage.Exists(lastGoodKeyPrefix + "disk_info") {
+		t.Fatal("a failed collection saved a last good record")
+	}
+
+	// 成功采集后保存，之后实时采集失败时返回该记录并标记为降级数据
+	healthy := &countingCollector{value: "partitions"}
+	if _, _, err := withCache(context.Background(), storage.NewMemoryCache(), options(args), "disk_info", time.Second, healthy.collect); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(30 * time.Second)
+	data, meta, err := withCache(context.Background(), storage.NewMemoryCache(), options(args), "disk_info", time.Second, failing.collect)
+	if err != nil || data != "partitions" || !meta.Fallback || !errors.Is(meta.FallbackErr, collectErr) {
+		t.Fatalf("withCache() = %q, %+v, %v; want the last good data", data, meta, err)
+	}
+	if header := cacheHeader(meta); header != "⚠️ 实时采集失败，以下为 30秒前的数据（错误: statfs timeout）\n\n" {
+		t.Errorf("cacheHeader() = %q", header)
+	}
+	if info := newFallbackInfo(meta); info == nil || info.AgeSeconds != 30 || info.Error != "statfs timeout" {
+		t.Errorf("newFallbackInfo() = %+v", info)
+	}
+
+	// 缓存的失败记录同样返回降级数据
+	failures := storage.NewNegativeCache(storage.NewMemoryCache(), time.Minute)
+	failures.SetFailure(newCacheKey("disk_info", args).Key, collectErr)
+	if data, meta, err := withCache(context.Background(), failures, options(args), "disk_info", time.Second, healthy.collect); err != nil || data != "partitions" || !meta.Fallback || healthy.calls != 1 {
+		t.Fatalf("withCache() with a cached failure = %q, %+v, %v; want the last good data", data, meta, err)
+	}
+
+	// no_fallback、参数不同或记录超过最长有效期时返回采集错误
+	noFallback := map[string]interface{}{"show_all": "true", "no_fallback": "true"}
+	for name, opts := range map[string]CacheOptions{
+		"no_fallback":     options(noFallback),
+		"other arguments": options(map[string]interface{}{}),
+	} {
+		if _, meta, err := withCache(context.Background(), storage.NewMemoryCache(), opts, "disk_info", time.Second, failing.collect); !errors.Is(err, collectErr) || meta.Fallback {
+			t.Errorf("%s: withCache() = %+v, %v; want the collection error", name, meta, err)
+		}
+	}
+	clock.Advance(10 * time.Minute)
+	if _, meta, err := withCache(context.Background(), storage.NewMemoryCache(), options(args), "disk_info", time.Second, failing.collect); !errors.Is(err, collectErr) || meta.Fallback {
+		t.Fatalf("withCache() past the max age = %+v, %v; want the collection error", meta, err)
+	}
+}
+
+func TestNetworkToolFallback(t *testing.T) {
+	provider := &fakeNetProvider{counters: []NetIOCountersStat{{Name: "eth0", BytesRecv: 1000, BytesSent: 2000}}}
+	useFakeNet(t, provider)
+	clock := newJumpClock()
+	cacheOptions := clock.options(CacheOptions{LastGood: NewLastGood(storage.NewMemoryStorage(), DefaultFallbackMaxAge), Name: "network_stats"})
+	tool := NewNetworkTool(storage.NewMemoryCache(), cacheOptions, 0, nil)
+
+	collectErr := errors.New("/proc/net/dev: resource temporarily unavailable")
+	// fallbackOutput 先成功采集一次，2 分钟后实时采集失败时以相同参数再次调用
+	fallbackOutput := func(args map[string]interface{}) (string, error) {
+		t.Helper()
+		provider.err = nil
+		if _, _, err := tool.ExecuteStructured(context.Background(), args); err != nil {
+			t.Fatal(err)
+		}
+		clock.Advance(2 * time.Minute)
+		provider.err = collectErr
+		text, _, err := tool.ExecuteStructured(context.Background(), args)
+		return text, err
+	}
+
+	// 文本输出以降级提示开头，JSON 输出带 fallback 字段
+	text, err := fallbackOutput(map[string]interface{}{"cache": CacheModeFresh})
+	if err != nil || !strings.HasPrefix(text, "⚠️ 实时采集失败，以下为 2分钟前的数据（错误: 获取网络接口统计失败") || !strings.Contains(text, "eth0") {
+		t.Fatalf("text output = %q, %v; want the last good data with the staleness marker", text, err)
+	}
+	text, err = fallbackOutput(map[string]interface{}{"cache": CacheModeFresh, "format": "json"})
+	var report struct {
+		Fallback *fallbackInfo `json:"fallback"`
+	}
+	if err != nil || json.Unmarshal([]byte(text), &report) != nil || report.Fallback == nil || report.Fallback.AgeSeconds != 120 {
+		t.Fatalf("JSON output = %s, %v; want the fallback age", text, err)
+	}
+
+	// no_fallback 时返回结构化的采集错误
+	_, _, err = tool.ExecuteStructured(context.Background(), map[string]interface{}{"cache": CacheModeFresh, "no_fallback": "true"})
+	var toolErr *Error
+	if !errors.As(err, &toolErr) || toolErr.Message != "获取网络信息失败" || !errors.Is(err, collectErr) {
+		t.Fatalf("no_fallback = %v, want the collection error", err)
+	}
+}
@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// LiveChangesURI 最近两次后台采样之间变化的资源 URI
+const LiveChangesURI = "monitor://live/changes"
+
+// LiveChangesResource 渲染后台采集器最近两次采样之间的变化
+type LiveChangesResource struct {
+	mutex    sync.Mutex
+	previous *types.MetricSample
+	latest   *types.MetricSample
+	rendered string
+}
+
+// NewLiveChangesResource 创建新的实时变化资源
+func NewLiveChangesResource() *LiveChangesResource {
+	return &LiveChangesResource{
+		rendered: "⏳ 后台采集器尚未产生两次采样\n",
+	}
+}
+
+// GetResource 获取资源描述
+func (lc *LiveChangesResource) GetResource() types.Resource {
+	return types.Resource{
+		URI:         LiveChangesURI,
+		Name:        "实时变化",
+		Description: "后台采集器最近两次采样之间的 CPU、内存、磁盘和网络变化，可订阅更新",
+		MimeType:    "text/plain",
+	}
+}
+
+// Read 读取渲染后的变化内容
+func (lc *LiveChangesResource) Read(ctx context.Context) (string, error) {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	return lc.rendered, nil
+}
+
+// Observe 记录一次新的采样并重新渲染，返回渲染内容是否发生变化
+func (lc *LiveChangesResource) Observe(sample types.MetricSample) bool {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	lc.previous, lc.latest = lc.latest, &sample
+	if lc.previous == nil {
+		return false
+	}
+
+	rendered := renderSampleDiff(*lc.previous, *lc.latest)
+	if rendered == lc.rendered {
+		return false
+	}
+	lc.rendered = rendered
+	return true
+}
+
+// renderSampleDiff 渲染两次采样之间的变化
+func renderSampleDiff(previous, latest types.MetricSample) string {
+	var result string
+
+	elapsed := latest.Timestamp.Sub(previous.Timestamp)
+	result += "🔄 实时变化\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result += fmt.Sprintf("采样间隔: %s\n", elapsed.Round(time.Second))
+	if latest.ClockJumpSeconds != 0 {
+		result += fmt.Sprintf("⏰ 两次采样之间系统时钟跳变 %+.0f 秒（NTP 步进调整或挂起后恢复），本次不计算网络速率\n", latest.ClockJumpSeconds)
+	}
+	result += fmt.Sprintf("CPU: %.2f%% → %.2f%% (%+.2f)\n", previous.CPUPercent, latest.CPUPercent, latest.CPUPercent-previous.CPUPercent)
+	result += fmt.Sprintf("内存: %.2f%% → %.2f%% (%+.2f)\n", previous.MemoryPercent, latest.MemoryPercent, latest.MemoryPercent-previous.MemoryPercent)
+
+	if len(latest.DiskPercent) > 0 {
+		result += "\n💽 磁盘使用率\n"
+		for _, mountpoint := range sortedKeys(latest.DiskPercent) {
+			current := latest.DiskPercent[mountpoint]
+			before, found := previous.DiskPercent[mountpoint]
+			if !found {
+				result += fmt.Sprintf("  %s: %.2f%% (新增)\n", mountpoint, current)
+				continue
+			}
+			result += fmt.Sprintf("  %s: %.2f%% (%+.2f)\n", mountpoint, current, current-before)
+		}
+	}
+
+	if len(latest.NetRxBytes) > 0 && latest.ClockJumpSeconds == 0 {
+		result += "\n🌐 网络速率\n"
+		for _, name := range sortedKeys(latest.NetRxBytes) {
+			prevRx, foundRx := previous.NetRxBytes[name]
+			prevTx, foundTx := previous.NetTxBytes[name]
+			if !foundRx || !foundTx {
+				result += fmt.Sprintf("  %s: (新增)\n", name)
+				continue
+			}
+
+			rate, ok := computeRate(
+				counterSample{BytesSent: prevTx, BytesRecv: prevRx, Timestamp: previous.Timestamp},
+				counterSample{BytesSent: latest.NetTxBytes[name], BytesRecv: latest.NetRxBytes[name], Timestamp: latest.Timestamp},
+			)
+			switch {
+			case !ok:
+				continue
+			case rate.CounterReset:
+				result += fmt.Sprintf("  %s: 计数器重置\n", name)
+			default:
+				result += fmt.Sprintf("  %s: 接收 %s/s, 发送 %s/s\n", name, formatBytes(uint64(rate.RecvRate)), formatBytes(uint64(rate.SendRate)))
+			}
+		}
+	}
+
+	result += fmt.Sprintf("\n📅 最新采样: %s\n", latest.Timestamp.Format("2006-01-02 15:04:05"))
+
+	return result
+}
@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"mcp-example/internal/types"
+)
+
+// LiveURITemplate 实时数据资源的 URI 模板
+const LiveURITemplate = "monitor://live/{component}"
+
+// liveURIPrefix 实时数据资源 URI 的前缀
+const liveURIPrefix = "monitor://live/"
+
+// liveComponent 实时数据资源的一个组件，读取时调用对应的工具并返回其结构化结果
+type liveComponent struct {
+	Name        string
+	Tool        string
+	Description string
+}
+
+// liveComponents 实时数据资源的组件，按 resources/templates/list 中列出的顺序
+var liveComponents = []liveComponent{
+	{Name: "system", Tool: "system_overview", Description: "系统概览"},
+	{Name: "cpu", Tool: "cpu_info", Description: "CPU 使用率"},
+	{Name: "memory", Tool: "memory_info", Description: "内存使用情况"},
+	{Name: "disk", Tool: "disk_info", Description: "磁盘分区使用情况"},
+	{Name: "network", Tool: "network_stats", Description: "网络接口统计"},
+	{Name: "processes", Tool: "top_processes", Description: "资源占用最高的进程"},
+	{Name: "health", Tool: "health_report", Description: "健康报告"},
+}
+
+// liveCacheArgs 读取实时数据资源时传给工具的参数：与工具共用缓存，TTL 内（CPU、磁盘 30 秒，内存 15 秒等）直接返回缓存数据。
+// health_report 没有缓存参数，其中的各项采集由对应工具缓存
+var liveCacheArgs = map[string]interface{}{"cache": CacheModeAuto}
+
+// LiveResourceTemplate 实时数据资源模板 monitor://live/{component}，供偏好资源而不是工具的客户端读取各组件的 JSON 数据。
+// 读取经过工具调用的完整路径，工具访问策略、超时和限流同样适用
+type LiveResourceTemplate struct {
+	call ToolCallFunc
+}
+
+// NewLiveResourceTemplate 创建新的实时数据资源模板，call 用于调用组件对应的工具
+func NewLiveResourceTemplate(call ToolCallFunc) *LiveResourceTemplate {
+	return &LiveResourceTemplate{call: call}
+}
+
+// GetResourceTemplate 获取资源模板描述
+func (lt *LiveResourceTemplate) GetResourceTemplate() types.ResourceTemplate {
+	return types.ResourceTemplate{
+		URITemplate: LiveURITemplate,
+		Name:        "实时数据",
+		Description: fmt.Sprintf("各组件的实时数据（JSON，与对应工具的 structuredContent 相同，短时间内的读取共用工具的缓存）。component 可选: %s",
+			strings.Join(liveComponentNames(), ", ")),
+		MimeType: "application/json",
+	}
+}
+
+// Resolve 解析 monitor://live/<component>，不是该前缀的 URI 返回 nil, nil，未知组件返回错误
+func (lt *LiveResourceTemplate) Resolve(uri string) (types.MonitorResource, error) {
+	name, ok := strings.CutPrefix(uri, liveURIPrefix)
+	if !ok {
+		return nil, nil
+	}
+	for _, component := range liveComponents {
+		if component.Name == name {
+			return &LiveResource{call: lt.call, component: component}, nil
+		}
+	}
+	return nil, fmt.Errorf("未知的组件 %q，可选: %s", name, strings.Join(liveComponentNames(), ", "))
+}
+
+// Complete 补全模板参数 component
+func (lt *LiveResourceTemplate) Complete(ctx context.Context, argName, prefix string) []string {
+	if argName != "component" {
+		return nil
+	}
+	return matchPrefix(liveComponentNames(), prefix)
+}
+
+// liveComponentNames 所有组件名称，按列出的顺序
+func liveComponentNames() []string {
+	names := make([]string, 0, len(liveComponents))
+	for _, component := range liveComponents {
+		names = append(names, component.Name)
+	}
+	return names
+}
+
+// LiveResource 实时数据资源中的一个组件
+type LiveResource struct {
+	call      ToolCallFunc
+	component liveComponent
+}
+
+// GetResource 获取资源描述
+func (lr *LiveResource) GetResource() types.Resource {
+	return types.Resource{
+		URI:         liveURIPrefix + lr.component.Name,
+		Name:        "实时数据: " + lr.component.Name,
+		Description: fmt.Sprintf("%s（%s 的结构化结果）", lr.component.Description, lr.component.Tool),
+		MimeType:    "application/json",
+	}
+}
+
+// Read 调用组件对应的工具，返回其结构化结果的 JSON
+func (lr *LiveResource) Read(ctx context.Context) (string, error) {
+	args := liveCacheArgs
+	if lr.component.Tool == "health_report" {
+		args = map[string]interface{}{}
+	}
+
+	_, structured, err := lr.call(ctx, lr.component.Tool, args)
+	if err != nil {
+		return "", err
+	}
+	if structured == nil {
+		return "", newError(ErrInternal, "%s 没有返回结构化结果", lr.component.Tool)
+	}
+
+	data, err := json.MarshalIndent(structured, "", "  ")
+	if err != nil {
+		return "", wrapError("序列化实时数据失败", err)
+	}
+	return string(data), nil
+}
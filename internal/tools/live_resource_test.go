@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"mcp-example/internal/storage"
+)
+
+// recordingCall 记录调用的工具和参数，返回 structured 作为结构化结果
+type recordingCall struct {
+	tool       string
+	args       map[string]interface{}
+	structured interface{}
+	err        error
+}
+
+func (rc *recordingCall) call(ctx context.Context, name string, args map[string]interface{}) (string, interface{}, error) {
+	rc.tool, rc.args = name, args
+	return "text", rc.structured, rc.err
+}
+
+func TestLiveResourceResolve(t *testing.T) {
+	template := NewLiveResourceTemplate((&recordingCall{}).call)
+	if got := template.GetResourceTemplate(); got.URITemplate != "monitor://live/{component}" || got.MimeType != "application/json" ||
+		!strings.Contains(got.Description, "system, cpu, memory, disk, network, processes, health") {
+		t.Errorf("GetResourceTemplate() = %+v", got)
+	}
+
+	tools := map[string]string{
+		"system": "system_overview", "cpu": "cpu_info", "memory": "memory_info", "disk": "disk_info",
+		"network": "network_stats", "processes": "top_processes", "health": "health_report",
+	}
+	for component, tool := range tools {
+		resource, err := template.Resolve("monitor://live/" + component)
+		if err != nil {
+			t.Fatalf("%s: %v", component, err)
+		}
+		info := resource.GetResource()
+		if info.URI != "monitor://live/"+component || info.MimeType != "application/json" || !strings.Contains(info.Description, tool) {
+			t.Errorf("%s: resource = %+v", component, info)
+		}
+	}
+
+	// 其他前缀的 URI 交给其他模板
+	for _, uri := range []string{"monitor://system/info", "file:///live/cpu", "monitor://live"} {
+		if resource, err := template.Resolve(uri); resource != nil || err != nil {
+			t.Errorf("Resolve(%q) = %v, %v; want nil, nil", uri, resource, err)
+		}
+	}
+	// 未知组件列出可选的组件名
+	for _, uri := range []string{"monitor://live/gpu", "monitor://live/", "monitor://live/cpu/0", "monitor://live/CPU"} {
+		resource, err := template.Resolve(uri)
+		if resource != nil || err == nil || !strings.Contains(err.Error(), "可选: system, cpu, memory, disk, network, processes, health") {
+			t.Errorf("Resolve(%q) = %v, %v", uri, resource, err)
+		}
+	}
+}
+
+func TestLiveResourceComplete(t *testing.T) {
+	template := NewLiveResourceTemplate((&recordingCall{}).call)
+	if got := template.Complete(context.Background(), "component", ""); len(got) != len(liveComponents) {
+		t.Errorf("completing an empty prefix = %v", got)
+	}
+	if got := template.Complete(context.Background(), "component", "m"); !slices.Equal(got, []string{"memory"}) {
+		t.Errorf("completing m = %v", got)
+	}
+	if got := template.Complete(context.Background(), "uri", "m"); got != nil {
+		t.Errorf("completing an unknown argument = %v", got)
+	}
+}
+
+func TestLiveResourceRead(t *testing.T) {
+	call := &recordingCall{structured: map[string]interface{}{"total_percent": 12.5}}
+	template := NewLiveResourceTemplate(call.call)
+
+	resource, _ := template.Resolve("monitor://live/cpu")
+	text, err := resource.Read(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 与工具共用缓存
+	if call.tool != "cpu_info" || call.args["cache"] != CacheModeAuto {
+		t.Errorf("called %s with %v", call.tool, call.args)
+	}
+	if text != "{\n  \"total_percent\": 12.5\n}" {
+		t.Errorf("Read() = %q", text)
+	}
+
+	// health_report 没有缓存参数
+	resource, _ = template.Resolve("monitor://live/health")
+	if _, err := resource.Read(context.Background()); err != nil || call.tool != "health_report" || len(call.args) != 0 {
+		t.Errorf("health: called %s with %v, %v", call.tool, call.args, err)
+	}
+
+	// 工具调用的错误原样返回，没有结构化结果时报告内部错误
+	denied := ToolNotFound("cpu_info", nil)
+	call.err = denied
+	resource, _ = template.Resolve("monitor://live/cpu")
+	if _, err := resource.Read(context.Background()); !errors.Is(err, denied) {
+		t.Errorf("denied tool: err = %v", err)
+	}
+	call.err, call.structured = nil, nil
+	_, err = resource.Read(context.Background())
+	var toolErr *Error
+	if !errors.As(err, &toolErr) || toolErr.Code != ErrInternal {
+		t.Errorf("no structured result: err = %v", err)
+	}
+}
+
+func TestLiveResourceSharesToolCache(t *testing.T) {
+	mem := &fakeMemProvider{virtual: VirtualMemoryStat{Total: 16 * gb, Available: 8 * gb, Used: 8 * gb, UsedPercent: 50}}
+	useFakeMem(t, mem)
+	tool := NewMemoryTool(storage.NewMemoryCache(), CacheOptions{}, NewOutputStyle(StylePlain, 0))
+	call := func(ctx context.Context, name string, args map[string]interface{}) (string, interface{}, error) {
+		return tool.ExecuteStructured(ctx, args)
+	}
+	resource, err := NewLiveResourceTemplate(call).Resolve("monitor://live/memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	usedPercent := func(text string) float64 {
+		t.Helper()
+		var data struct {
+			UsedPercent float64 `json:"used_percent"`
+		}
+		if err := json.Unmarshal([]byte(text), &data); err != nil {
+			t.Fatal(err)
+		}
+		return data.UsedPercent
+	}
+
+	first, err := resource.Read(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// TTL 内再次读取返回工具缓存的数据
+	mem.virtual.Used, mem.virtual.UsedPercent = 12*gb, 75
+	second, err := resource.Read(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usedPercent(first) != 50 || usedPercent(second) != 50 {
+		t.Errorf("reads = %v, %v; want both cached at 50", usedPercent(first), usedPercent(second))
+	}
+	if _, structured, err := tool.ExecuteStructured(context.Background(), map[string]interface{}{"cache": "fresh"}); err != nil {
+		t.Fatal(err)
+	} else if data, _ := json.Marshal(structured); usedPercent(string(data)) != 75 {
+		t.Errorf("fresh tool call = %s", data)
+	}
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 文本输出只有中文：服务器没有语言选项，时长、时间戳和数量等格式都经由本文件的函数，
+// 各工具的输出因此保持一致
+
+// durationUnit 时长的单位及其名称
+type durationUnit struct {
+	size time.Duration
+	name string
+}
+
+// durationUnits 时长分解使用的单位，从大到小
+var durationUnits = []durationUnit{
+	{24 * time.Hour, "天"},
+	{time.Hour, "小时"},
+	{time.Minute, "分钟"},
+	{time.Second, "秒"},
+}
+
+// weekdayNames 星期的简称，按 time.Weekday 排列
+var weekdayNames = [...]string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"}
+
+// formatDurationWords 将时长分解为天、小时、分钟，如 "3天 4小时 5分钟"。
+// 省略为 0 的单位；不足 1 分钟时以秒表示，只有时长不足 1 分钟才显示秒
+func formatDurationWords(d time.Duration) string {
+	if d < time.Minute {
+		return formatUnit(int64(max(d, 0)/time.Second), durationUnits[3])
+	}
+
+	var parts []string
+	remaining := d
+	for _, unit := range durationUnits[:3] {
+		count := int64(remaining / unit.size)
+		remaining -= time.Duration(count) * unit.size
+		if count > 0 {
+			parts = append(parts, formatUnit(count, unit))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatUnit 数量和单位，如 "3天"
+func formatUnit(count int64, unit durationUnit) string {
+	return fmt.Sprintf("%d%s", count, unit.name)
+}
+
+// formatAgo 以最大的单位粗略描述多久以前，如 "3天前"，不足 1 分钟时为 "刚刚"
+func formatAgo(elapsed time.Duration) string {
+	for _, unit := range durationUnits[:3] {
+		if elapsed >= unit.size {
+			return formatUnit(int64(elapsed/unit.size), unit) + "前"
+		}
+	}
+	return "刚刚"
+}
+
+// formatElapsedAgo 精确描述多久以前，如 "12秒前"、"3天 4小时前"
+func formatElapsedAgo(elapsed time.Duration) string {
+	return formatDurationWords(elapsed) + "前"
+}
+
+// formatTimestamp 带星期的时间戳，如 "2024-05-06 周一 07:08:09"，用于启动时间、快照时间等可能相隔多日的时间
+func formatTimestamp(t time.Time) string {
+	return t.Format("2006-01-02") + " " + weekdayNames[t.Weekday()] + " " + t.Format("15:04:05")
+}
+
+// formatCount 带千位分隔符的整数，如 1,234,567
+func formatCount(n uint64) string {
+	digits := strconv.FormatUint(n, 10)
+	if len(digits) <= 3 {
+		return digits
+	}
+
+	var builder strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		builder.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if builder.Len() > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteString(digits[i : i+3])
+	}
+	return builder.String()
+}
@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocaleGolden(t *testing.T) {
+	var b strings.Builder
+
+	b.WriteString("# formatDurationWords\n")
+	for _, d := range []time.Duration{
+		-time.Second,
+		0,
+		59 * time.Second,
+		time.Minute,
+		time.Hour,
+		2*time.Hour + 10*time.Minute,
+		24 * time.Hour,
+		3*24*time.Hour + 4*time.Hour + 5*time.Minute + 6*time.Second,
+		400 * 24 * time.Hour,
+	} {
+		fmt.Fprintf(&b, "%s\t%s\n", d, formatDurationWords(d))
+	}
+
+	b.WriteString("\n# formatAgo\n")
+	for _, d := range []time.Duration{0, 30 * time.Second, 90 * time.Second, 5 * time.Hour, 49 * time.Hour} {
+		fmt.Fprintf(&b, "%s\t%s\n", d, formatAgo(d))
+	}
+
+	b.WriteString("\n# formatElapsedAgo\n")
+	for _, d := range []time.Duration{12 * time.Second, 3*24*time.Hour + 4*time.Hour} {
+		fmt.Fprintf(&b, "%s\t%s\n", d, formatElapsedAgo(d))
+	}
+
+	b.WriteString("\n# formatTimestamp\n")
+	for day := 0; day < 7; day++ {
+		at := time.Date(2024, 5, 5+day, 7, 8, 9, 0, time.UTC)
+		fmt.Fprintf(&b, "%s\t%s\n", at.Weekday(), formatTimestamp(at))
+	}
+
+	b.WriteString("\n# formatCount\n")
+	for _, n := range []uint64{0, 999, 1000, 1234567, 18446744073709551615} {
+		fmt.Fprintf(&b, "%d\t%s\n", n, formatCount(n))
+	}
+
+	assertGolden(t, "locale_zh", b.String())
+}
@@ -0,0 +1,211 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"mcp-example/internal/types"
+)
+
+// macOS 专用采集的输出解析。解析函数与平台无关，实际的命令调用见 macos_darwin.go
+
+// memoryPressureLevels kern.memorystatus_vm_pressure_level 的取值
+var memoryPressureLevels = map[int]string{
+	1: "normal",
+	2: "warn",
+	4: "critical",
+}
+
+// parseMemoryPressureLevel 解析 sysctl -n kern.memorystatus_vm_pressure_level 的输出
+func parseMemoryPressureLevel(output []byte) (string, error) {
+	value, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil {
+		return "", fmt.Errorf("无法解析内存压力等级: %w", err)
+	}
+	level, found := memoryPressureLevels[value]
+	if !found {
+		return "", fmt.Errorf("未知的内存压力等级: %d", value)
+	}
+	return level, nil
+}
+
+// vmStatPageSize vm_stat 第一行中的页面大小，如 "(page size of 16384 bytes)"
+var vmStatPageSize = regexp.MustCompile(`page size of (\d+) bytes`)
+
+// parseVMStatCompressor 解析 vm_stat 的输出，返回压缩器占用的内存和被压缩页面的原始大小（字节）
+func parseVMStatCompressor(output []byte) (compressed, stored uint64, err error) {
+	var pageSize uint64
+	var foundCompressed bool
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := vmStatPageSize.FindStringSubmatch(line); match != nil {
+			pageSize, _ = strconv.ParseUint(match[1], 10, 64)
+			continue
+		}
+
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		pages, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(value), "."), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch strings.TrimSpace(name) {
+		case "Pages occupied by compressor":
+			compressed, foundCompressed = pages, true
+		case "Pages stored in compressor":
+			stored = pages
+		}
+	}
+	if pageSize == 0 {
+		return 0, 0, fmt.Errorf("vm_stat 输出中没有页面大小")
+	}
+	if !foundCompressed {
+		return 0, 0, fmt.Errorf("vm_stat 输出中没有压缩器统计")
+	}
+	return compressed * pageSize, stored * pageSize, nil
+}
+
+// pmsetThermalLevel pmset -g therm 输出中记录的温控警告等级，如 "Thermal warning level set to 2."
+var pmsetThermalLevel = regexp.MustCompile(`(?i)thermal warning level set to (\d+)`)
+
+// parsePmsetTherm 解析 pmset -g therm 的输出。没有记录温控警告时等级为 0；
+// CPU_Speed_Limit 低于 100 或有温控警告时视为正在降频
+func parsePmsetTherm(output []byte) (*types.ThermalState, error) {
+	var state types.ThermalState
+	var recognized bool
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if match := pmsetThermalLevel.FindStringSubmatch(line); match != nil {
+			state.WarningLevel, _ = strconv.Atoi(match[1])
+			recognized = true
+			continue
+		}
+		if strings.Contains(line, "No thermal warning level") {
+			recognized = true
+			continue
+		}
+
+		name, value, found := strings.Cut(line, "=")
+		if found && strings.TrimSpace(name) == "CPU_Speed_Limit" {
+			if limit, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+				state.SpeedLimit = limit
+				recognized = true
+			}
+		}
+	}
+	if !recognized {
+		return nil, fmt.Errorf("无法识别 pmset -g therm 的输出")
+	}
+	state.Throttled = state.WarningLevel > 0 || (state.SpeedLimit > 0 && state.SpeedLimit < 100)
+	return &state, nil
+}
+
+// apfsDevice APFS 卷的设备名，如 /dev/disk3s1 或快照 /dev/disk3s1s1，容器为 disk3
+var apfsDevice = regexp.MustCompile(`^(?:/dev/)?(disk\d+)s\d+(?:s\d+)*$`)
+
+// apfsContainer APFS 卷所在的容器，非 APFS 分区或无法识别的设备名返回空字符串
+func apfsContainer(device, fstype string) string {
+	if fstype != "apfs" {
+		return ""
+	}
+	if match := apfsDevice.FindStringSubmatch(device); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// diskTotals 分区的合计
+type diskTotals struct {
+	Total uint64
+	Used  uint64
+	Free  uint64
+	// SharedContainers 有多个卷的 APFS 容器及其卷数，按容器名排序
+	SharedContainers []sharedContainer
+}
+
+// sharedContainer 多个卷共享空间的 APFS 容器
+type sharedContainer struct {
+	Name    string
+	Volumes int
+	Total   uint64
+}
+
+// sumPartitions 合计各分区的容量。同一 APFS 容器中的卷报告的总容量和可用空间都是整个容器的，
+// 只计一次；各卷的已使用空间是卷自身的，分别累加
+func sumPartitions(partitions []types.DiskPartition) diskTotals {
+	var totals diskTotals
+	containers := make(map[string]*sharedContainer)
+	for _, partition := range partitions {
+		totals.Used += partition.Used
+		if partition.APFSContainer == "" {
+			totals.Total += partition.Total
+			totals.Free += partition.Free
+			continue
+		}
+
+		container, found := containers[partition.APFSContainer]
+		if !found {
+			container = &sharedContainer{Name: partition.APFSContainer, Total: partition.Total}
+			containers[partition.APFSContainer] = container
+			totals.Total += partition.Total
+			totals.Free += partition.Free
+		}
+		container.Volumes++
+	}
+
+	for _, name := range sortedKeys(containers) {
+		if container := containers[name]; container.Volumes > 1 {
+			totals.SharedContainers = append(totals.SharedContainers, *container)
+		}
+	}
+	return totals
+}
+
+// formatMemoryPressure 格式化 macOS 内存压力，没有采集时返回空字符串
+func formatMemoryPressure(pressure *types.MemoryPressure) string {
+	if pressure == nil {
+		return ""
+	}
+
+	labels := map[string]string{"normal": "🟢 正常", "warn": "🟡 警告", "critical": "🔴 严重"}
+	var result string
+	result += "\n🗜️ 内存压力\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	if pressure.Level != "" {
+		result += fmt.Sprintf("压力等级: %s (%s)\n", labels[pressure.Level], pressure.Level)
+	}
+	result += fmt.Sprintf("压缩内存: %s（压缩前 %s）\n", formatBytes(pressure.Compressed), formatBytes(pressure.Stored))
+	result += "💡 macOS 的已使用内存包含可压缩的页面，内存压力更能反映内存是否紧张\n"
+	return result
+}
+
+// formatThermalState 格式化 macOS 温控状态，没有采集时返回空字符串
+func formatThermalState(thermal *types.ThermalState) string {
+	if thermal == nil {
+		return ""
+	}
+
+	var result string
+	result += "\n🌡️ 温控状态\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	if thermal.WarningLevel > 0 {
+		result += fmt.Sprintf("温控警告等级: %d\n", thermal.WarningLevel)
+	} else {
+		result += "温控警告: 无\n"
+	}
+	if thermal.SpeedLimit > 0 {
+		result += fmt.Sprintf("CPU 速度上限: %d%%\n", thermal.SpeedLimit)
+	}
+	if thermal.Throttled {
+		result += "⚠️ CPU 正在因温度降频，使用率和性能数据可能偏离平时\n"
+	}
+	return result
+}
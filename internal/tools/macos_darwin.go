@@ -0,0 +1,51 @@
+//go:build darwin
+
+package tools
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// thermalTimeout pmset 偶尔会卡住，读取温控状态使用比 commandTimeout 更短的超时
+const thermalTimeout = 2 * time.Second
+
+// readMemoryPressure 读取内存压力等级（sysctl）和压缩内存（vm_stat），两者都失败时返回 nil
+func readMemoryPressure(ctx context.Context, run commandRunner) *types.MemoryPressure {
+	var pressure types.MemoryPressure
+	output, levelErr := run(ctx, "sysctl", "-n", "kern.memorystatus_vm_pressure_level")
+	if levelErr == nil {
+		pressure.Level, levelErr = parseMemoryPressureLevel(output)
+	}
+
+	output, vmStatErr := run(ctx, "vm_stat")
+	if vmStatErr == nil {
+		pressure.Compressed, pressure.Stored, vmStatErr = parseVMStatCompressor(output)
+	}
+	if levelErr != nil && vmStatErr != nil {
+		slog.Debug("读取内存压力失败", "sysctl_error", levelErr, "vm_stat_error", vmStatErr)
+		return nil
+	}
+	return &pressure
+}
+
+// readThermalState 通过 pmset -g therm 读取温控状态，失败或超时时返回 nil
+func readThermalState(ctx context.Context, run commandRunner) *types.ThermalState {
+	ctx, cancel := context.WithTimeout(ctx, thermalTimeout)
+	defer cancel()
+
+	output, err := run(ctx, "pmset", "-g", "therm")
+	if err != nil {
+		slog.Debug("读取温控状态失败", "error", err)
+		return nil
+	}
+	thermal, err := parsePmsetTherm(output)
+	if err != nil {
+		slog.Debug("解析温控状态失败", "error", err)
+		return nil
+	}
+	return thermal
+}
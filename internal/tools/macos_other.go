@@ -0,0 +1,19 @@
+//go:build !darwin
+
+package tools
+
+import (
+	"context"
+
+	"mcp-example/internal/types"
+)
+
+// readMemoryPressure 内存压力仅在 macOS 上采集
+func readMemoryPressure(ctx context.Context, run commandRunner) *types.MemoryPressure {
+	return nil
+}
+
+// readThermalState 温控状态仅在 macOS 上采集
+func readThermalState(ctx context.Context, run commandRunner) *types.ThermalState {
+	return nil
+}
@@ -0,0 +1,262 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+// vmStatFixture Apple Silicon 上 vm_stat 的输出，页面大小为 16 KB
+const vmStatFixture = `Mach Virtual Memory Statistics: (page size of 16384 bytes)
+Pages free:                               13523.
+Pages active:                            328391.
+Pages inactive:                          322085.
+Pages speculative:                         5340.
+Pages throttled:                              0.
+Pages wired down:                        140233.
+Pages purgeable:                           6125.
+"Translation faults":                 890123456.
+Pages copy-on-write:                   12345678.
+Pages zero filled:                    345678901.
+Pages reactivated:                      1234567.
+Pages purged:                            234567.
+File-backed pages:                       189234.
+Anonymous pages:                         466582.
+Pages stored in compressor:              612345.
+Pages occupied by compressor:            201234.
+Decompressions:                         3456789.
+Compressions:                           5678901.
+Pageins:                                2345678.
+Pageouts:                                 12345.
+Swapins:                                      0.
+Swapouts:                                     0.
+`
+
+func TestParseMemoryPressureLevel(t *testing.T) {
+	cases := []struct {
+		output string
+		level  string
+		ok     bool
+	}{
+		{"1\n", "normal", true},
+		{"2\n", "warn", true},
+		{"4\n", "critical", true},
+		// 3 不是 kern.memorystatus_vm_pressure_level 的取值
+		{"3\n", "", false},
+		{"", "", false},
+		{"sysctl: unknown oid 'kern.memorystatus_vm_pressure_level'\n", "", false},
+	}
+	for _, c := range cases {
+		level, err := parseMemoryPressureLevel([]byte(c.output))
+		if level != c.level || (err == nil) != c.ok {
+			t.Errorf("parseMemoryPressureLevel(%q) = %q, %v; want %q", c.output, level, err, c.level)
+		}
+	}
+}
+
+func TestParseVMStatCompressor(t *testing.T) {
+	compressed, stored, err := parseVMStatCompressor([]byte(vmStatFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compressed != 201234*16384 || stored != 612345*16384 {
+		t.Errorf("parseVMStatCompressor() = %d, %d", compressed, stored)
+	}
+
+	// Intel 上页面大小为 4 KB；没有 "Pages stored in compressor" 时原始大小为 0
+	intel := "Mach Virtual Memory Statistics: (page size of 4096 bytes)\nPages free:  1000.\nPages occupied by compressor:  256.\n"
+	compressed, stored, err = parseVMStatCompressor([]byte(intel))
+	if err != nil || compressed != 1<<20 || stored != 0 {
+		t.Errorf("intel = %d, %d, %v", compressed, stored, err)
+	}
+
+	for name, output := range map[string]string{
+		"no page size":  "Pages occupied by compressor:  256.\n",
+		"no compressor": "Mach Virtual Memory Statistics: (page size of 4096 bytes)\nPages free:  1000.\n",
+		"empty":         "",
+	} {
+		if _, _, err := parseVMStatCompressor([]byte(output)); err == nil {
+			t.Errorf("%s: parseVMStatCompressor() succeeded, want an error", name)
+		}
+	}
+}
+
+func TestParsePmsetTherm(t *testing.T) {
+	cases := []struct {
+		name   string
+		output string
+		want   types.ThermalState
+	}{
+		// Apple Silicon 只输出说明，没有 CPU_Speed_Limit
+		{
+			"apple silicon",
+			"Note: No thermal warning level has been recorded\nNote: No performance warning level has been recorded\nNote: No CPU power status has been recorded\n",
+			types.ThermalState{},
+		},
+		{
+			"intel at full speed",
+			"Note: No thermal warning level has been recorded\nNote: No performance warning level has been recorded\n" +
+				"2024-05-01 12:00:00 +0800 CPU Power notify\n\tCPU_Scheduler_Limit \t= 100\n\tCPU_Available_CPUs \t= 8\n\tCPU_Speed_Limit \t= 100\n",
+			types.ThermalState{SpeedLimit: 100},
+		},
+		// 速度上限低于 100 视为降频
+		{
+			"intel throttled",
+			"Note: No thermal warning level has been recorded\n" +
+				"2024-05-01 12:00:00 +0800 CPU Power notify\n\tCPU_Scheduler_Limit \t= 100\n\tCPU_Available_CPUs \t= 8\n\tCPU_Speed_Limit \t= 72\n",
+			types.ThermalState{SpeedLimit: 72, Throttled: true},
+		},
+		{
+			"thermal warning",
+			"2024-05-01 12:00:00 +0800 Thermal Warning Level set to 2.\nNote: No performance warning level has been recorded\n",
+			types.ThermalState{WarningLevel: 2, Throttled: true},
+		},
+	}
+	for _, c := range cases {
+		state, err := parsePmsetTherm([]byte(c.output))
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if *state != c.want {
+			t.Errorf("%s: parsePmsetTherm() = %+v, want %+v", c.name, *state, c.want)
+		}
+	}
+
+	for _, output := range []string{"", "pmset: unrecognized option\n", "\tCPU_Speed_Limit \t= fast\n"} {
+		if _, err := parsePmsetTherm([]byte(output)); err == nil {
+			t.Errorf("parsePmsetTherm(%q) succeeded, want an error", output)
+		}
+	}
+}
+
+func TestAPFSContainer(t *testing.T) {
+	cases := []struct {
+		device, fstype string
+		want           string
+	}{
+		{"/dev/disk3s1", "apfs", "disk3"},
+		// 快照挂载
+		{"/dev/disk3s1s1", "apfs", "disk3"},
+		{"disk10s5", "apfs", "disk10"},
+		// 整个磁盘、非 APFS 分区和无法识别的设备名
+		{"/dev/disk3", "apfs", ""},
+		{"/dev/disk4s1", "exfat", ""},
+		{"map auto_home", "autofs", ""},
+		{"//user@server/share", "apfs", ""},
+	}
+	for _, c := range cases {
+		if got := apfsContainer(c.device, c.fstype); got != c.want {
+			t.Errorf("apfsContainer(%q, %q) = %q, want %q", c.device, c.fstype, got, c.want)
+		}
+	}
+}
+
+// apfsLayout 合成的 APFS 布局：内置磁盘的 disk3 容器有 4 个卷，外置磁盘的 disk5 容器只有 1 个卷，另有一个 exFAT 分区
+var apfsLayout = []types.DiskPartition{
+	{Device: "/dev/disk3s1s1", Mountpoint: "/", Fstype: "apfs", Total: 500 * gb, Used: 10 * gb, Free: 300 * gb, APFSContainer: "disk3"},
+	{Device: "/dev/disk3s5", Mountpoint: "/System/Volumes/Data", Fstype: "apfs", Total: 500 * gb, Used: 180 * gb, Free: 300 * gb, APFSContainer: "disk3"},
+	{Device: "/dev/disk3s6", Mountpoint: "/System/Volumes/VM", Fstype: "apfs", Total: 500 * gb, Used: 2 * gb, Free: 300 * gb, APFSContainer: "disk3"},
+	{Device: "/dev/disk3s2", Mountpoint: "/System/Volumes/Preboot", Fstype: "apfs", Total: 500 * gb, Used: 8 * gb, Free: 300 * gb, APFSContainer: "disk3"},
+	{Device: "/dev/disk5s1", Mountpoint: "/Volumes/Backup", Fstype: "apfs", Total: 2000 * gb, Used: 500 * gb, Free: 1500 * gb, APFSContainer: "disk5"},
+	{Device: "/dev/disk4s1", Mountpoint: "/Volumes/USB", Fstype: "exfat", Total: 64 * gb, Used: 4 * gb, Free: 60 * gb},
+}
+
+func TestSumPartitions(t *testing.T) {
+	totals := sumPartitions(apfsLayout)
+	// disk3 的容量和可用空间只计一次，已使用空间按卷累加
+	if totals.Total != 2564*gb || totals.Free != 1860*gb || totals.Used != 704*gb {
+		t.Errorf("totals = %d GB total, %d GB free, %d GB used", totals.Total/gb, totals.Free/gb, totals.Used/gb)
+	}
+	// 只有一个卷的容器不列出
+	if fmt.Sprint(totals.SharedContainers) != fmt.Sprint([]sharedContainer{{Name: "disk3", Volumes: 4, Total: 500 * gb}}) {
+		t.Errorf("shared containers = %+v", totals.SharedContainers)
+	}
+
+	// 非 macOS 上没有容器，逐个累加
+	var plain []types.DiskPartition
+	for _, partition := range apfsLayout {
+		partition.APFSContainer = ""
+		plain = append(plain, partition)
+	}
+	totals = sumPartitions(plain)
+	if totals.Total != 4064*gb || totals.Free != 2760*gb || totals.Used != 704*gb || totals.SharedContainers != nil {
+		t.Errorf("without containers = %+v", totals)
+	}
+}
+
+func TestDiskInfoAPFSTotals(t *testing.T) {
+	disk := &fakeDiskProvider{usage: map[string]UsageStat{}}
+	for _, partition := range apfsLayout {
+		disk.partitions = append(disk.partitions, PartitionStat{Device: partition.Device, Mountpoint: partition.Mountpoint, Fstype: partition.Fstype})
+		disk.usage[partition.Mountpoint] = UsageStat{Total: partition.Total, Used: partition.Used, Free: partition.Free, UsedPercent: float64(partition.Used) / float64(partition.Total) * 100}
+	}
+	useFakeDisk(t, disk)
+
+	note := "💡 APFS 容器 disk3 中的 4 个卷共享 500.00 GB 空间，总计中只计一次\n"
+	// 只有 macOS 按容器合计
+	cases := []struct {
+		platform string
+		total    string
+		note     bool
+	}{
+		{platformDarwin, "2.50 TB      704.00 GB    1.82 TB", true},
+		{platformLinux, "3.97 TB      704.00 GB    2.70 TB", false},
+	}
+	for _, c := range cases {
+		tool := NewDiskTool(storage.NewMemoryCache(), CacheOptions{}, newPartitionFilter(c.platform, nil, nil, nil, nil), NewOutputStyle(StylePlain, 0), nil)
+		tool.platform, tool.mountsPath = c.platform, writeTree(t, nil)+"/mounts"
+		text, err := tool.Execute(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(text, c.total) || strings.Contains(text, note) != c.note {
+			t.Errorf("%s output:\n%s", c.platform, text)
+		}
+	}
+}
+
+func TestFormatMemoryPressure(t *testing.T) {
+	want := "\n🗜️ 内存压力\n" +
+		"━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n" +
+		"压力等级: 🟡 警告 (warn)\n" +
+		"压缩内存: 3.07 GB（压缩前 9.34 GB）\n" +
+		"💡 macOS 的已使用内存包含可压缩的页面，内存压力更能反映内存是否紧张\n"
+	if got := formatMemoryPressure(&types.MemoryPressure{Level: "warn", Compressed: 201234 * 16384, Stored: 612345 * 16384}); got != want {
+		t.Errorf("formatMemoryPressure() = %q, want %q", got, want)
+	}
+
+	// sysctl 失败时只显示压缩内存
+	if got := formatMemoryPressure(&types.MemoryPressure{Compressed: 1 << 30}); strings.Contains(got, "压力等级") || !strings.Contains(got, "压缩内存: 1.00 GB（压缩前 0 B）\n") {
+		t.Errorf("without level = %q", got)
+	}
+	if got := formatMemoryPressure(nil); got != "" {
+		t.Errorf("formatMemoryPressure(nil) = %q", got)
+	}
+}
+
+func TestFormatThermalState(t *testing.T) {
+	want := "\n🌡️ 温控状态\n" +
+		"━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n" +
+		"温控警告: 无\n" +
+		"CPU 速度上限: 72%\n" +
+		"⚠️ CPU 正在因温度降频，使用率和性能数据可能偏离平时\n"
+	if got := formatThermalState(&types.ThermalState{SpeedLimit: 72, Throttled: true}); got != want {
+		t.Errorf("formatThermalState() = %q, want %q", got, want)
+	}
+
+	// Apple Silicon 没有速度上限
+	if got := formatThermalState(&types.ThermalState{}); !strings.HasSuffix(got, "温控警告: 无\n") {
+		t.Errorf("apple silicon = %q", got)
+	}
+	if got := formatThermalState(&types.ThermalState{WarningLevel: 2, Throttled: true}); !strings.Contains(got, "温控警告等级: 2\n") {
+		t.Errorf("warning level = %q", got)
+	}
+	if got := formatThermalState(nil); got != "" {
+		t.Errorf("formatThermalState(nil) = %q", got)
+	}
+}
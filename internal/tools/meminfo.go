@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"mcp-example/internal/types"
+)
+
+// procMeminfoPath Linux 内存详细信息文件
+const procMeminfoPath = "/proc/meminfo"
+
+// readMemoryDetail 读取 /proc/meminfo 中 VirtualMemory 未提供的字段
+func readMemoryDetail(path string) (*types.MemoryDetail, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	defer file.Close()
+
+	return parseMeminfo(file)
+}
+
+// parseMeminfo 解析 /proc/meminfo。
+// 不同内核版本的字段不完全相同，缺失的字段保持为 0，无法识别的行会被忽略。
+func parseMeminfo(r io.Reader) (*types.MemoryDetail, error) {
+	values := make(map[string]uint64)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, rest, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		// 带 kB 单位的字段换算为字节，HugePages_* 等计数字段没有单位
+		if len(fields) > 1 && fields[1] == "kB" {
+			value *= 1024
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("解析 meminfo 失败: %w", err)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("meminfo 内容为空")
+	}
+
+	return &types.MemoryDetail{
+		Shmem:          values["Shmem"],
+		Slab:           values["Slab"],
+		SReclaimable:   values["SReclaimable"],
+		SUnreclaim:     values["SUnreclaim"],
+		HugePagesTotal: values["HugePages_Total"],
+		HugePagesFree:  values["HugePages_Free"],
+		HugePageSize:   values["Hugepagesize"],
+		CommitLimit:    values["CommitLimit"],
+		CommittedAS:    values["Committed_AS"],
+	}, nil
+}
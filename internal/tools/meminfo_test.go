@@ -0,0 +1,180 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+// meminfoFixtures 不同内核版本上采集的 /proc/meminfo（节选）
+var meminfoFixtures = map[string]string{
+	// 6.x 内核，配置了 2MB 大页
+	"linux-6.5": `MemTotal:       16318412 kB
+MemFree:         1204512 kB
+MemAvailable:    9876544 kB
+Buffers:          402112 kB
+Cached:          8123456 kB
+Shmem:            524288 kB
+KReclaimable:     612340 kB
+Slab:             819200 kB
+SReclaimable:     612340 kB
+SUnreclaim:       206860 kB
+CommitLimit:    12353780 kB
+Committed_AS:    9437184 kB
+HugePages_Total:      64
+HugePages_Free:       16
+HugePages_Rsvd:        0
+HugePages_Surp:        0
+Hugepagesize:       2048 kB
+Hugetlb:          131072 kB
+`,
+	// 2.6.18 内核：没有 Shmem、SReclaimable 和 SUnreclaim
+	"linux-2.6.18": `MemTotal:      4059136 kB
+MemFree:        102400 kB
+Buffers:         51200 kB
+Cached:        1024000 kB
+Slab:           204800 kB
+CommitLimit:   4077292 kB
+Committed_AS:  1048576 kB
+HugePages_Total:     0
+HugePages_Free:      0
+Hugepagesize:     2048 kB
+`,
+	// 超售的主机（vm.overcommit_memory=1），没有大页相关的行
+	"overcommitted": `MemTotal:        2014480 kB
+Shmem:              1024 kB
+Slab:              65536 kB
+SReclaimable:      32768 kB
+SUnreclaim:        32768 kB
+CommitLimit:     1007240 kB
+Committed_AS:    3145728 kB
+`,
+}
+
+func TestParseMeminfoFixtures(t *testing.T) {
+	const kb = 1024
+	cases := []struct {
+		fixture string
+		want    types.MemoryDetail
+	}{
+		{"linux-6.5", types.MemoryDetail{
+			Shmem: 524288 * kb, Slab: 819200 * kb, SReclaimable: 612340 * kb, SUnreclaim: 206860 * kb,
+			HugePagesTotal: 64, HugePagesFree: 16, HugePageSize: 2048 * kb,
+			CommitLimit: 12353780 * kb, CommittedAS: 9437184 * kb,
+		}},
+		// 缺失的字段为 0
+		{"linux-2.6.18", types.MemoryDetail{
+			Slab: 204800 * kb, HugePageSize: 2048 * kb, CommitLimit: 4077292 * kb, CommittedAS: 1048576 * kb,
+		}},
+		{"overcommitted", types.MemoryDetail{
+			Shmem: 1024 * kb, Slab: 65536 * kb, SReclaimable: 32768 * kb, SUnreclaim: 32768 * kb,
+			CommitLimit: 1007240 * kb, CommittedAS: 3145728 * kb,
+		}},
+	}
+	for _, c := range cases {
+		detail, err := parseMeminfo(strings.NewReader(meminfoFixtures[c.fixture]))
+		if err != nil {
+			t.Errorf("%s: %v", c.fixture, err)
+			continue
+		}
+		if *detail != c.want {
+			t.Errorf("%s: parseMeminfo() = %+v, want %+v", c.fixture, *detail, c.want)
+		}
+	}
+}
+
+func TestParseMeminfoToleratesMalformedLines(t *testing.T) {
+	// 无法识别的行跳过，其他字段照常解析
+	detail, err := parseMeminfo(strings.NewReader("Shmem: 8 kB\nSlab: lots kB\nno colon here\nDirectMap4k:\nHugePages_Total: 4\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *detail != (types.MemoryDetail{Shmem: 8 * 1024, HugePagesTotal: 4}) {
+		t.Errorf("parseMeminfo() = %+v", *detail)
+	}
+
+	for _, content := range []string{"", "garbage\n"} {
+		if _, err := parseMeminfo(strings.NewReader(content)); err == nil {
+			t.Errorf("parseMeminfo(%q) succeeded, want an error", content)
+		}
+	}
+	if _, err := readMemoryDetail(writeTree(t, nil) + "/meminfo"); err == nil {
+		t.Error("readMemoryDetail of a missing file succeeded")
+	}
+}
+
+// newMeminfoTool 从 meminfoFixtures 中的 fixture 读取 /proc/meminfo 的 Linux 内存工具
+func newMeminfoTool(t *testing.T, fixture string) *MemoryTool {
+	t.Helper()
+	useFakeMem(t, &fakeMemProvider{
+		virtual: VirtualMemoryStat{Total: 2 << 30, Used: 1 << 30, Available: 1 << 30, UsedPercent: 50},
+	})
+	tool := NewMemoryTool(storage.NewMemoryCache(), CacheOptions{}, NewOutputStyle(StylePlain, 0))
+	tool.platform = platformLinux
+	tool.meminfoPath = writeTree(t, map[string]string{"meminfo": meminfoFixtures[fixture]}) + "/meminfo"
+	return tool
+}
+
+func TestMemoryToolDetailed(t *testing.T) {
+	tool := newMeminfoTool(t, "linux-6.5")
+
+	text, structured, err := tool.ExecuteStructured(context.Background(), map[string]interface{}{"detailed": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := structured.(memoryReport)
+	if report.Detail == nil || report.Detail.HugePagesTotal != 64 {
+		t.Fatalf("Detail = %+v, want the fixture values", report.Detail)
+	}
+	// OOM 风险评估的内存提交比例同样来自该文件
+	if ratio := report.OOMRisk.Inputs.CommitRatio; ratio == nil || *ratio != 9437184.0/12353780.0 {
+		t.Errorf("CommitRatio = %v, want Committed_AS / CommitLimit of the fixture", ratio)
+	}
+	for _, want := range []string{
+		"🔬 详细信息\n",
+		"共享内存 (Shmem): 512.00 MB\n",
+		"Slab: 800.00 MB (可回收 597.99 MB, 不可回收 202.01 MB)\n",
+		"大页: 16/64 空闲, 页大小 2.00 MB\n",
+		"内存提交: 9.00 GB / 11.78 GB (76.4%)\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "内存超售") {
+		t.Errorf("warned about overcommit below the limit:\n%s", text)
+	}
+
+	// 不传 detailed 时没有详细信息
+	text, structured, err = tool.ExecuteStructured(context.Background(), map[string]interface{}{})
+	if err != nil || structured.(memoryReport).Detail != nil || strings.Contains(text, "详细信息") {
+		t.Errorf("detail shown without detailed=true:\n%s", text)
+	}
+}
+
+func TestMemoryToolDetailedOvercommit(t *testing.T) {
+	text, _, err := newMeminfoTool(t, "overcommitted").ExecuteStructured(context.Background(), map[string]interface{}{"detailed": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"大页: 未配置\n", "(312.3%)\n", "⚠️  已提交内存超过提交上限 (Committed_AS > CommitLimit)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestMemoryToolDetailedOtherPlatforms(t *testing.T) {
+	// 其他平台上忽略 detailed，不读取 /proc/meminfo
+	tool := newMeminfoTool(t, "linux-6.5")
+	tool.platform = platformDarwin
+	tool.run = fakeCommands(nil)
+
+	text, structured, err := tool.ExecuteStructured(context.Background(), map[string]interface{}{"detailed": true})
+	if err != nil || structured.(memoryReport).Detail != nil || strings.Contains(text, "详细信息") {
+		t.Errorf("detail on darwin = %v:\n%s", err, text)
+	}
+}
@@ -1,23 +1,36 @@
 package tools
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"mcp-example/internal/types"
-
-	"github.com/shirou/gopsutil/v3/mem"
 )
 
 // MemoryTool 内存监控工具
 type MemoryTool struct {
-	cache types.Cache
+	cache        types.Cache
+	cacheOptions CacheOptions
+	style        OutputStyle
+	platform     string
+	run          commandRunner
+	// meminfoPath /proc/meminfo 的位置，detailed 时和评估 OOM 风险时读取
+	meminfoPath string
+	// swapIn 上一次采样的换入计数，用于计算 OOM 风险评估中的近期换入速率
+	swapIn swapInTracker
 }
 
 // NewMemoryTool 创建新的内存监控工具
-func NewMemoryTool(cache types.Cache) *MemoryTool {
+func NewMemoryTool(cache types.Cache, cacheOptions CacheOptions, style OutputStyle) *MemoryTool {
 	return &MemoryTool{
-		cache: cache,
+		cache:        cache,
+		cacheOptions: cacheOptions,
+		style:        style,
+		platform:     hostPlatform,
+		run:          runCommand,
+		meminfoPath:  procMeminfoPath,
 	}
 }
 
@@ -31,63 +44,92 @@ func (mt *MemoryTool) GetDescription() string {
 	return "获取内存使用情况详细信息"
 }
 
+// GetAnnotations 获取工具注解
+func (mt *MemoryTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("内存信息")
+}
+
+// memoryArgs memory_info 的参数
+type memoryArgs struct {
+	Detailed bool `arg:"detailed,default=false" desc:"是否包含共享内存、Slab、大页和内存提交等详细信息（仅 Linux）"`
+	Compact  bool `arg:"compact,default=false" desc:"是否以使用率条紧凑显示内存和交换空间"`
+	fallbackArgs
+	cacheArgs
+	diffArgs
+}
+
 // GetInputSchema 获取输入模式
 func (mt *MemoryTool) GetInputSchema() types.InputSchema {
-	return types.InputSchema{
-		Type: "object",
-		Properties: map[string]types.Property{
-			"use_cache": {
-				Type:        "string",
-				Description: "是否使用缓存数据",
-				Enum:        []string{"true", "false"},
-				Default:     "false",
-			},
+	return argsSchema(memoryArgs{})
+}
+
+// Examples 获取调用示例
+func (mt *MemoryTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "物理内存和交换内存使用情况",
+			Arguments:   map[string]interface{}{},
+		},
+		{
+			Description: "附带共享内存、Slab、大页和内存提交等详细信息",
+			Arguments:   map[string]interface{}{"detailed": "true"},
 		},
 	}
 }
 
+// memoryReport memory_info 的结构化结果
+type memoryReport struct {
+	types.MemoryInfo
+}
+
 // Execute 执行内存监控
-func (mt *MemoryTool) Execute(args map[string]interface{}) (string, error) {
-	// 解析参数
-	useCacheStr, _ := args["use_cache"].(string)
-	useCache := useCacheStr == "true"
-
-	// 检查缓存
-	cacheKey := "memory_info"
-	if useCache {
-		if cachedData, found := mt.cache.Get(cacheKey); found {
-			if memInfo, ok := cachedData.(types.MemoryInfo); ok {
-				return mt.formatMemoryInfo(memInfo), nil
-			}
-		}
+func (mt *MemoryTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	text, _, err := mt.ExecuteStructured(ctx, args)
+	return text, err
+}
+
+// ExecuteStructured 执行内存监控，同时返回结构化的内存信息（包括 OOM 风险评估及其输入）
+func (mt *MemoryTool) ExecuteStructured(ctx context.Context, args map[string]interface{}) (string, interface{}, error) {
+	var a memoryArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", nil, err
 	}
 
-	// 获取内存信息
-	memInfo, err := mt.getMemoryInfo()
+	// 获取内存信息（缓存15秒）
+	memInfo, meta, err := withCache(ctx, mt.cache, mt.cacheOptions.forCall(args), mt.GetName(), 15*time.Second, func(ctx context.Context) (types.MemoryInfo, error) {
+		memInfo, err := mt.getMemoryInfo(ctx)
+		if err != nil || !a.Detailed || mt.platform != platformLinux {
+			return memInfo, err
+		}
+
+		detail, err := readMemoryDetail(mt.meminfoPath)
+		if err != nil {
+			return memInfo, err
+		}
+		memInfo.Detail = detail
+		return memInfo, nil
+	})
 	if err != nil {
-		return "", fmt.Errorf("获取内存信息失败: %v", err)
+		return "", nil, wrapError("获取内存信息失败", err)
 	}
 
-	// 缓存结果（缓存15秒）
-	mt.cache.Set(cacheKey, memInfo, 15*time.Second)
-
-	return mt.formatMemoryInfo(memInfo), nil
+	return cacheHeader(meta) + mt.formatMemoryInfo(memInfo, a.Compact) + cacheNote(meta), memoryReport{MemoryInfo: memInfo}, nil
 }
 
 // getMemoryInfo 获取内存信息
-func (mt *MemoryTool) getMemoryInfo() (types.MemoryInfo, error) {
+func (mt *MemoryTool) getMemoryInfo(ctx context.Context) (types.MemoryInfo, error) {
 	var memInfo types.MemoryInfo
 
 	// 获取虚拟内存信息
-	vmStat, err := mem.VirtualMemory()
+	vmStat, err := providers.Mem.VirtualMemory(ctx)
 	if err != nil {
-		return memInfo, fmt.Errorf("获取虚拟内存信息失败: %v", err)
+		return memInfo, fmt.Errorf("获取虚拟内存信息失败: %w", err)
 	}
 
 	// 获取交换内存信息
-	swapStat, err := mem.SwapMemory()
+	swapStat, err := providers.Mem.SwapMemory(ctx)
 	if err != nil {
-		return memInfo, fmt.Errorf("获取交换内存信息失败: %v", err)
+		return memInfo, fmt.Errorf("获取交换内存信息失败: %w", err)
 	}
 
 	// 填充内存信息
@@ -105,38 +147,131 @@ func (mt *MemoryTool) getMemoryInfo() (types.MemoryInfo, error) {
 	memInfo.Swap.Free = swapStat.Free
 	memInfo.Swap.UsedPercent = swapStat.UsedPercent
 
+	// macOS 的已使用内存包含可压缩的页面，附带内存压力和压缩内存
+	memInfo.Pressure = readMemoryPressure(ctx, mt.run)
+
 	memInfo.LastUpdated = time.Now()
+	memInfo.OOMRisk = mt.assessOOMRisk(memInfo, swapStat.Sin, memInfo.LastUpdated)
 
 	return memInfo, nil
 }
 
+// assessOOMRisk 收集 OOM 风险评估的输入并评估，缺少必要输入的平台上只给出说明
+func (mt *MemoryTool) assessOOMRisk(memInfo types.MemoryInfo, swapIn uint64, now time.Time) *types.OOMRisk {
+	if !hasOOMRiskInputs(mt.platform) {
+		return &types.OOMRisk{
+			Factors: []types.OOMRiskFactor{},
+			Note:    fmt.Sprintf("当前平台（%s）不提供换入计数和内存提交统计，不评估 OOM 风险", mt.platform),
+		}
+	}
+
+	inputs := types.OOMRiskInputs{
+		SwapTotal:  memInfo.Swap.Total,
+		SwapInRate: mt.swapIn.observe(swapIn, now),
+	}
+	if memInfo.Total > 0 {
+		inputs.AvailablePercent = float64(memInfo.Available) / float64(memInfo.Total) * 100
+	}
+	if memInfo.Swap.Total > 0 {
+		inputs.SwapFreePercent = float64(memInfo.Swap.Free) / float64(memInfo.Swap.Total) * 100
+	}
+
+	var notes []string
+	if detail, err := readMemoryDetail(mt.meminfoPath); err != nil {
+		notes = append(notes, fmt.Sprintf("内存提交统计不可用: %v", err))
+	} else if detail.CommitLimit > 0 {
+		ratio := float64(detail.CommittedAS) / float64(detail.CommitLimit)
+		inputs.CommitRatio = &ratio
+	}
+	if inputs.SwapInRate == nil && memInfo.Swap.Total > 0 {
+		notes = append(notes, "尚无近期的上一次采样，换入速率未计入（稍后再次调用或开启后台采集后可用）")
+	}
+
+	risk := assessOOMRisk(inputs)
+	risk.Note = strings.Join(notes, "；")
+	return &risk
+}
+
 // formatMemoryInfo 格式化内存信息输出
-func (mt *MemoryTool) formatMemoryInfo(memInfo types.MemoryInfo) string {
+func (mt *MemoryTool) formatMemoryInfo(memInfo types.MemoryInfo, compact bool) string {
 	var result string
 
 	result += "💾 内存信息\n"
 	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	if compact {
+		result += fmt.Sprintf("内存 %s %s / %s\n", formatUsageBar(memInfo.UsedPercent, mt.style.BarWidth),
+			formatBytes(memInfo.Used), formatBytes(memInfo.Total))
+		result += fmt.Sprintf("交换 %s %s / %s\n", formatUsageBar(memInfo.Swap.UsedPercent, mt.style.BarWidth),
+			formatBytes(memInfo.Swap.Used), formatBytes(memInfo.Swap.Total))
+		return result + mt.formatMemoryDetail(memInfo)
+	}
 	result += fmt.Sprintf("总内存: %s\n", formatBytes(memInfo.Total))
 	result += fmt.Sprintf("已使用: %s (%.2f%%)\n", formatBytes(memInfo.Used), memInfo.UsedPercent)
+	if mt.style.ShowBars() {
+		result += fmt.Sprintf("使用率: %s\n", renderBar(memInfo.UsedPercent, mt.style.BarWidth))
+	}
 	result += fmt.Sprintf("可用内存: %s\n", formatBytes(memInfo.Available))
-	result += fmt.Sprintf("空闲内存: %s\n", formatBytes(memInfo.Free))
-	result += fmt.Sprintf("缓冲区: %s\n", formatBytes(memInfo.Buffers))
-	result += fmt.Sprintf("缓存: %s\n", formatBytes(memInfo.Cached))
+	// Windows 上没有缓冲区/缓存的统计，空闲内存与可用内存相同，不显示这些字段
+	if hasBufferCache(mt.platform) {
+		result += fmt.Sprintf("空闲内存: %s\n", formatBytes(memInfo.Free))
+		result += fmt.Sprintf("缓冲区: %s\n", formatBytes(memInfo.Buffers))
+		result += fmt.Sprintf("缓存: %s\n", formatBytes(memInfo.Cached))
+	}
 
-	result += "\n🔄 交换内存\n"
+	if mt.platform == platformWindows {
+		result += "\n🔄 页面文件\n"
+	} else {
+		result += "\n🔄 交换内存\n"
+	}
 	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
 	result += fmt.Sprintf("总交换: %s\n", formatBytes(memInfo.Swap.Total))
 	result += fmt.Sprintf("已使用: %s (%.2f%%)\n", formatBytes(memInfo.Swap.Used), memInfo.Swap.UsedPercent)
+	if mt.style.ShowBars() {
+		result += fmt.Sprintf("使用率: %s\n", renderBar(memInfo.Swap.UsedPercent, mt.style.BarWidth))
+	}
 	result += fmt.Sprintf("空闲交换: %s\n", formatBytes(memInfo.Swap.Free))
 
+	return result + mt.formatMemoryDetail(memInfo)
+}
+
+// formatMemoryDetail 格式化内存压力（仅 macOS）、详细信息（仅 detailed 时存在）和更新时间
+func (mt *MemoryTool) formatMemoryDetail(memInfo types.MemoryInfo) string {
+	var result string
+
+	result += formatMemoryPressure(memInfo.Pressure)
+
+	if detail := memInfo.Detail; detail != nil {
+		result += "\n🔬 详细信息\n"
+		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		result += fmt.Sprintf("共享内存 (Shmem): %s\n", formatBytes(detail.Shmem))
+		result += fmt.Sprintf("Slab: %s (可回收 %s, 不可回收 %s)\n",
+			formatBytes(detail.Slab), formatBytes(detail.SReclaimable), formatBytes(detail.SUnreclaim))
+		if detail.HugePagesTotal > 0 {
+			result += fmt.Sprintf("大页: %d/%d 空闲, 页大小 %s\n",
+				detail.HugePagesFree, detail.HugePagesTotal, formatBytes(detail.HugePageSize))
+		} else {
+			result += "大页: 未配置\n"
+		}
+		if detail.CommitLimit > 0 {
+			ratio := float64(detail.CommittedAS) / float64(detail.CommitLimit) * 100
+			result += fmt.Sprintf("内存提交: %s / %s (%.1f%%)\n",
+				formatBytes(detail.CommittedAS), formatBytes(detail.CommitLimit), ratio)
+			if detail.CommittedAS > detail.CommitLimit {
+				result += "⚠️  已提交内存超过提交上限 (Committed_AS > CommitLimit)，存在内存超售\n"
+			}
+		}
+	}
+
+	result += formatOOMRisk(memInfo.OOMRisk)
+
 	result += fmt.Sprintf("\n📅 更新时间: %s\n", memInfo.LastUpdated.Format("2006-01-02 15:04:05"))
 
 	return result
 }
 
 // GetMemoryData 获取内存数据（供其他组件使用）
-func (mt *MemoryTool) GetMemoryData() (types.MemoryInfo, error) {
-	return mt.getMemoryInfo()
+func (mt *MemoryTool) GetMemoryData(ctx context.Context) (types.MemoryInfo, error) {
+	return mt.getMemoryInfo(ctx)
 }
 
 // formatBytes 格式化字节数
@@ -0,0 +1,467 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/types"
+)
+
+// 导出格式
+const (
+	ExportFormatInflux = "influx"
+	ExportFormatCSV    = "csv"
+)
+
+// maxInlineExportBytes 直接在工具结果中返回的导出内容上限，超过时需写入文件
+const maxInlineExportBytes = 256 * 1024
+
+// exportDir 数据目录下保存导出文件的子目录
+const exportDir = "exports"
+
+// maxExportFiles exports 子目录中保留的导出文件数，写入新文件后删除更早的文件
+const maxExportFiles = 20
+
+// ExportResult 导出统计
+type ExportResult struct {
+	// Samples 写出的样本数
+	Samples int
+	// Skipped 因时间戳缺失或数值无效被跳过的样本数
+	Skipped int
+	// SkippedFiles 缺失或损坏的日文件数
+	SkippedFiles int
+	// Bytes 写出的字节数
+	Bytes int64
+}
+
+// ExportMetrics 将时间范围内的采集样本按时间顺序以 InfluxDB 行协议或 CSV 写入 w
+func ExportMetrics(w io.Writer, dataStorage types.DataStorage, from, to time.Time, format string) (ExportResult, error) {
+	samples, skippedFiles := loadHistory(dataStorage, from, to)
+
+	counter := &countingWriter{w: w}
+	var result ExportResult
+	var err error
+	switch format {
+	case ExportFormatInflux:
+		result, err = writeInfluxLines(counter, samples, defaultHostname())
+	case ExportFormatCSV:
+		result, err = writeMetricsCSV(counter, samples, defaultHostname())
+	default:
+		return result, badArgument("不支持的导出格式: %q（可选 influx、csv）", format)
+	}
+
+	result.SkippedFiles = skippedFiles
+	result.Bytes = counter.n
+	return result, err
+}
+
+// countingWriter 统计写出的字节数
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write 写入并计数
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// defaultHostname 样本中没有主机信息时使用的主机名
+func defaultHostname() string {
+	if host := identity.Get(); host != nil {
+		return host.Hostname
+	}
+	return ""
+}
+
+// exportPoint 一个样本中的一个指标值，selector 为挂载点或接口名
+type exportPoint struct {
+	metric   string
+	selector string
+	value    float64
+	integer  bool
+}
+
+// validSample 样本是否可以导出：时间戳存在且所有数值有限、非负
+func validSample(sample types.MetricSample) bool {
+	if sample.Timestamp.IsZero() {
+		return false
+	}
+	for _, value := range []float64{sample.CPUPercent, sample.MemoryPercent} {
+		if math.IsNaN(value) || math.IsInf(value, 0) || value < 0 {
+			return false
+		}
+	}
+	for _, value := range sample.DiskPercent {
+		if math.IsNaN(value) || math.IsInf(value, 0) || value < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// samplePoints 展开样本中的所有指标值，map 按键排序以保证输出稳定
+func samplePoints(sample types.MetricSample) []exportPoint {
+	points := []exportPoint{
+		{metric: MetricCPUPercent, value: sample.CPUPercent},
+		{metric: MetricMemoryPercent, value: sample.MemoryPercent},
+	}
+	for _, mountpoint := range sortedKeys(sample.DiskPercent) {
+		points = append(points, exportPoint{metric: MetricDiskPercent, selector: mountpoint, value: sample.DiskPercent[mountpoint]})
+	}
+	for _, name := range sortedKeys(sample.NetRxBytes) {
+		points = append(points, exportPoint{metric: MetricNetRxBytes, selector: name, value: float64(sample.NetRxBytes[name]), integer: true})
+	}
+	for _, name := range sortedKeys(sample.NetTxBytes) {
+		points = append(points, exportPoint{metric: MetricNetTxBytes, selector: name, value: float64(sample.NetTxBytes[name]), integer: true})
+	}
+	return points
+}
+
+// sampleHostname 样本记录的主机名，旧样本没有主机信息时使用 fallback
+func sampleHostname(sample types.MetricSample, fallback string) string {
+	if sample.Host != nil && sample.Host.Hostname != "" {
+		return sample.Host.Hostname
+	}
+	return fallback
+}
+
+// selectorTag 各指标的 selector 在行协议中的标签名
+func selectorTag(metric string) string {
+	if metric == MetricDiskPercent {
+		return "mountpoint"
+	}
+	return "interface"
+}
+
+// writeInfluxLines 以 InfluxDB 行协议写出样本：每个指标一个 measurement，字段为 value，
+// 带 host 标签（磁盘和网络指标另有 mountpoint/interface 标签），时间戳为纳秒
+func writeInfluxLines(w io.Writer, samples []types.MetricSample, hostname string) (ExportResult, error) {
+	var result ExportResult
+	for _, sample := range samples {
+		if !validSample(sample) {
+			result.Skipped++
+			continue
+		}
+
+		host := escapeInfluxTag(sampleHostname(sample, hostname))
+		timestamp := sample.Timestamp.UnixNano()
+		for _, point := range samplePoints(sample) {
+			line := point.metric
+			if host != "" {
+				line += ",host=" + host
+			}
+			if point.selector != "" {
+				line += "," + selectorTag(point.metric) + "=" + escapeInfluxTag(point.selector)
+			}
+			if point.integer {
+				line += fmt.Sprintf(" value=%di %d\n", uint64(point.value), timestamp)
+			} else {
+				line += fmt.Sprintf(" value=%s %d\n", strconv.FormatFloat(point.value, 'f', -1, 64), timestamp)
+			}
+			if _, err := io.WriteString(w, line); err != nil {
+				return result, err
+			}
+		}
+		result.Samples++
+	}
+	return result, nil
+}
+
+// influxTagEscaper 行协议中标签键和值需要转义的字符
+var influxTagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", "")
+
+// escapeInfluxTag 转义标签值中的逗号、等号和空格，去掉换行
+func escapeInfluxTag(value string) string {
+	return influxTagEscaper.Replace(value)
+}
+
+// writeMetricsCSV 以 CSV 写出样本，每行一个指标值：timestamp,host,metric,selector,value
+func writeMetricsCSV(w io.Writer, samples []types.MetricSample, hostname string) (ExportResult, error) {
+	var result ExportResult
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"timestamp", "host", "metric", "selector", "value"}); err != nil {
+		return result, err
+	}
+
+	for _, sample := range samples {
+		if !validSample(sample) {
+			result.Skipped++
+			continue
+		}
+
+		timestamp := sample.Timestamp.UTC().Format(time.RFC3339Nano)
+		host := sampleHostname(sample, hostname)
+		for _, point := range samplePoints(sample) {
+			value := strconv.FormatFloat(point.value, 'f', -1, 64)
+			if point.integer {
+				value = strconv.FormatUint(uint64(point.value), 10)
+			}
+			if err := writer.Write([]string{timestamp, host, point.metric, point.selector, value}); err != nil {
+				return result, err
+			}
+		}
+		result.Samples++
+	}
+
+	writer.Flush()
+	return result, writer.Error()
+}
+
+// exportExtension 导出文件的扩展名
+func exportExtension(format string) string {
+	if format == ExportFormatCSV {
+		return ".csv"
+	}
+	return ".lp"
+}
+
+// MetricsExportTool 采集历史导出工具
+type MetricsExportTool struct {
+	storage types.DataStorage
+}
+
+// NewMetricsExportTool 创建新的采集历史导出工具
+func NewMetricsExportTool(dataStorage types.DataStorage) *MetricsExportTool {
+	return &MetricsExportTool{
+		storage: dataStorage,
+	}
+}
+
+// GetName 获取工具名称
+func (me *MetricsExportTool) GetName() string {
+	return "metrics_export"
+}
+
+// GetDescription 获取工具描述
+func (me *MetricsExportTool) GetDescription() string {
+	return "以 InfluxDB 行协议或 CSV 导出后台采集的历史样本，内容较小时直接返回，否则写入数据目录"
+}
+
+// GetAnnotations 获取工具注解。file 模式会在数据目录中创建文件并删除超出保留数的旧导出文件，
+// 因此不声明只读，只读策略和 multi_query 不会调用该工具
+func (me *MetricsExportTool) GetAnnotations() types.ToolAnnotations {
+	return types.ToolAnnotations{
+		Title: "导出历史指标",
+	}
+}
+
+// metricsExportArgs metrics_export 的参数
+type metricsExportArgs struct {
+	strictArgs
+	Format string `arg:"format,enum=influx|csv,default=influx" desc:"导出格式: influx 为 InfluxDB 行协议（纳秒时间戳）, csv 为 timestamp,host,metric,selector,value"`
+	timeRangeArgs
+	Output string `arg:"output,enum=inline|file,default=inline" desc:"输出方式: inline 直接返回（不超过 256 KB）, file 写入数据目录的 exports 子目录（不覆盖已有文件，只保留最近 20 个）"`
+}
+
+// GetInputSchema 获取输入模式
+func (me *MetricsExportTool) GetInputSchema() types.InputSchema {
+	return argsSchema(metricsExportArgs{})
+}
+
+// Examples 获取调用示例
+func (me *MetricsExportTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "最近 24 小时的样本，InfluxDB 行协议",
+			Arguments:   map[string]interface{}{"format": ExportFormatInflux},
+		},
+		{
+			Description: "指定时间范围导出为 CSV 文件",
+			Arguments:   map[string]interface{}{"format": ExportFormatCSV, "from": "2024-01-01T00:00:00Z", "to": "2024-01-08T00:00:00Z", "output": "file"},
+		},
+	}
+}
+
+// Execute 执行导出
+func (me *MetricsExportTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	text, _, err := me.ExecuteStructured(ctx, args)
+	return text, err
+}
+
+// exportSummary 导出统计，作为 structuredContent 返回（inline 模式的文本只包含导出内容本身）
+type exportSummary struct {
+	Format       string `json:"format"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+	Samples      int    `json:"samples"`
+	Skipped      int    `json:"skipped_samples"`
+	SkippedFiles int    `json:"skipped_files"`
+	Bytes        int64  `json:"bytes"`
+	Path         string `json:"path,omitempty"`
+}
+
+// ExecuteStructured 执行导出，同时返回导出统计
+func (me *MetricsExportTool) ExecuteStructured(ctx context.Context, args map[string]interface{}) (string, interface{}, error) {
+	var a metricsExportArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", nil, err
+	}
+	format := a.Format
+	from, to, err := a.resolve(24 * time.Hour)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if a.Output == "file" {
+		return me.exportToFile(from, to, format)
+	}
+
+	var buf bytes.Buffer
+	result, err := ExportMetrics(&buf, me.storage, from, to, format)
+	if err != nil {
+		return "", nil, wrapError("导出历史指标失败", err)
+	}
+	if buf.Len() > maxInlineExportBytes {
+		toolErr := badArgument("导出内容 %s 超过直接返回的上限 %s", formatBytes(uint64(buf.Len())), formatBytes(maxInlineExportBytes))
+		toolErr.Hint = `使用 "output": "file" 写入数据目录，或缩小 from/to 时间范围`
+		return "", nil, toolErr
+	}
+
+	summary := newExportSummary(format, from, to, result, "")
+	if result.Samples == 0 {
+		return fmt.Sprintf("该时间范围内没有可导出的样本（跳过无效样本 %d 个）\n", result.Skipped), summary, nil
+	}
+	return buf.String(), summary, nil
+}
+
+// newExportSummary 生成导出统计
+func newExportSummary(format string, from, to time.Time, result ExportResult, path string) exportSummary {
+	return exportSummary{
+		Format:       format,
+		From:         from.Format(time.RFC3339),
+		To:           to.Format(time.RFC3339),
+		Samples:      result.Samples,
+		Skipped:      result.Skipped,
+		SkippedFiles: result.SkippedFiles,
+		Bytes:        result.Bytes,
+		Path:         path,
+	}
+}
+
+// exportToFile 将导出内容写入数据目录的 exports 子目录
+func (me *MetricsExportTool) exportToFile(from, to time.Time, format string) (string, interface{}, error) {
+	dataDir := ""
+	if provider, ok := me.storage.(types.StorageStatsProvider); ok {
+		if stats, err := provider.Stats(); err == nil {
+			dataDir = stats.DataDir
+		}
+	}
+	if dataDir == "" {
+		toolErr := newError(ErrUnsupportedPlatform, "当前存储没有数据目录，无法写入导出文件")
+		toolErr.Hint = `使用 "output": "inline"，或以 --storage=file 启动服务器`
+		return "", nil, toolErr
+	}
+
+	dir := filepath.Join(dataDir, exportDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", nil, wrapError("创建导出目录失败", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("metrics_%s_%s%s", from.UTC().Format("20060102T150405"), to.UTC().Format("20060102T150405"), exportExtension(format)))
+
+	// 同一时间范围的导出文件已存在时不覆盖
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		toolErr := badArgument("导出文件 %s 已存在", path)
+		toolErr.Hint = "调整 from/to 时间范围，或先删除已有的导出文件"
+		return "", nil, toolErr
+	}
+	if err != nil {
+		return "", nil, wrapError("创建导出文件失败", err)
+	}
+	defer file.Close()
+
+	result, err := ExportMetrics(file, me.storage, from, to, format)
+	if err != nil {
+		file.Close()
+		os.Remove(path)
+		return "", nil, wrapError("导出历史指标失败", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(path)
+		return "", nil, wrapError("写入导出文件失败", err)
+	}
+
+	pruned, err := pruneExports(dir, maxExportFiles)
+	if err != nil {
+		slog.Warn("删除旧的导出文件失败", "dir", dir, "error", err)
+	}
+
+	var text string
+	text += "📤 历史指标导出\n"
+	text += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	text += fmt.Sprintf("文件: %s\n", path)
+	text += fmt.Sprintf("格式: %s\n", format)
+	text += fmt.Sprintf("时间范围: %s ~ %s\n", from.Format("2006-01-02 15:04:05"), to.Format("2006-01-02 15:04:05"))
+	text += fmt.Sprintf("样本: %d 个（跳过无效样本 %d 个，缺失或损坏的日文件 %d 个）\n", result.Samples, result.Skipped, result.SkippedFiles)
+	text += fmt.Sprintf("大小: %s\n", formatBytes(uint64(result.Bytes)))
+	if len(pruned) > 0 {
+		text += fmt.Sprintf("已删除较早的导出文件 %d 个（只保留最近 %d 个）\n", len(pruned), maxExportFiles)
+	}
+	return text, newExportSummary(format, from, to, result, path), nil
+}
+
+// isExportFile 文件名是否为 metrics_export 写出的导出文件
+func isExportFile(name string) bool {
+	return strings.HasPrefix(name, "metrics_") && (strings.HasSuffix(name, ".lp") || strings.HasSuffix(name, ".csv"))
+}
+
+// pruneExports 只保留 dir 中修改时间最新的 keep 个导出文件，返回删除的文件名。
+// 目录中的其他文件不受影响
+func pruneExports(dir string, keep int) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type exportFile struct {
+		name    string
+		modTime time.Time
+	}
+	var files []exportFile
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !isExportFile(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, exportFile{name: entry.Name(), modTime: info.ModTime()})
+	}
+	if len(files) <= keep {
+		return nil, nil
+	}
+
+	// 修改时间相同时按文件名（即时间范围）排序
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].modTime.Equal(files[j].modTime) {
+			return files[i].modTime.After(files[j].modTime)
+		}
+		return files[i].name > files[j].name
+	})
+	var deleted []string
+	for _, file := range files[keep:] {
+		if err := os.Remove(filepath.Join(dir, file.name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return deleted, err
+		}
+		deleted = append(deleted, file.name)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,247 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+// exportSamples 导出测试使用的样本：包含需要转义的主机名和挂载点、没有主机信息的旧样本和三个无效样本
+func exportSamples() []types.MetricSample {
+	start := time.Date(2024, 5, 6, 7, 8, 0, 0, time.UTC)
+	host := &types.HostIdentity{Hostname: "web 01,eu=1"}
+	return []types.MetricSample{
+		{
+			Timestamp:     start,
+			CPUPercent:    12.5,
+			MemoryPercent: 40,
+			DiskPercent:   map[string]float64{"/": 71.25, "/mnt/my disk": 3},
+			NetRxBytes:    map[string]uint64{"eth0": 1 << 40, "lo": 0},
+			NetTxBytes:    map[string]uint64{"eth0": 123456789},
+			Host:          host,
+		},
+		{Timestamp: time.Time{}, CPUPercent: 1},
+		{Timestamp: start.Add(30 * time.Second), CPUPercent: math.NaN(), Host: host},
+		{Timestamp: start.Add(45 * time.Second), CPUPercent: 1, DiskPercent: map[string]float64{"/": -1}, Host: host},
+		{
+			Timestamp:     start.Add(time.Minute + 500*time.Millisecond),
+			CPUPercent:    0.1,
+			MemoryPercent: 41,
+		},
+	}
+}
+
+func TestGoldenMetricsExportInflux(t *testing.T) {
+	var buf bytes.Buffer
+	result, err := writeInfluxLines(&buf, exportSamples(), "fallback-host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Samples != 2 || result.Skipped != 3 {
+		t.Fatalf("result = %+v, want 2 samples written and 3 skipped", result)
+	}
+	assertGolden(t, "metrics_export_influx", buf.String())
+}
+
+func TestGoldenMetricsExportCSV(t *testing.T) {
+	var buf bytes.Buffer
+	result, err := writeMetricsCSV(&buf, exportSamples(), "fallback-host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Samples != 2 || result.Skipped != 3 {
+		t.Fatalf("result = %+v, want 2 samples written and 3 skipped", result)
+	}
+	assertGolden(t, "metrics_export_csv", buf.String())
+}
+
+// saveHistory 按日文件保存样本，日文件内的顺序与 samples 相同
+func saveHistory(t *testing.T, dataStorage types.DataStorage, samples []types.MetricSample) {
+	t.Helper()
+	days := make(map[string][]types.MetricSample)
+	for _, sample := range samples {
+		key := HistoryKey(sample.Timestamp)
+		days[key] = append(days[key], sample)
+	}
+	for key, daySamples := range days {
+		if err := dataStorage.Save(key, daySamples); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExportMetricsOrdersAcrossDays(t *testing.T) {
+	dataStorage := storage.NewMemoryStorage()
+	midnight := time.Date(2024, 5, 7, 0, 0, 0, 0, time.Local)
+	// 日文件内乱序保存，跨越两天
+	saveHistory(t, dataStorage, []types.MetricSample{
+		{Timestamp: midnight.Add(2 * time.Minute), CPUPercent: 4},
+		{Timestamp: midnight.Add(-time.Minute), CPUPercent: 2},
+		{Timestamp: midnight.Add(time.Minute), CPUPercent: 3},
+		{Timestamp: midnight.Add(-2 * time.Minute), CPUPercent: 1},
+		{Timestamp: midnight.Add(-90 * time.Second), CPUPercent: 5, MemoryPercent: -1},
+	})
+
+	var buf bytes.Buffer
+	result, err := ExportMetrics(&buf, dataStorage, midnight.Add(-time.Hour), midnight.Add(time.Hour), ExportFormatInflux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Samples != 4 || result.Skipped != 1 || result.SkippedFiles != 0 || result.Bytes != int64(buf.Len()) {
+		t.Fatalf("result = %+v for %d bytes", result, buf.Len())
+	}
+
+	var cpuLines []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if strings.HasPrefix(line, MetricCPUPercent) {
+			cpuLines = append(cpuLines, line)
+		}
+	}
+	for i, line := range cpuLines {
+		want := fmt.Sprintf(" value=%d %d", i+1, midnight.Add(time.Duration([]int{-2, -1, 1, 2}[i])*time.Minute).UnixNano())
+		if !strings.HasSuffix(line, want) {
+			t.Errorf("cpu line %d = %q, want it to end with %q", i, line, want)
+		}
+	}
+
+	if _, err := ExportMetrics(&buf, dataStorage, midnight.Add(-time.Hour), midnight, "json"); err == nil {
+		t.Error("ExportMetrics accepted an unknown format")
+	}
+}
+
+// newFileExportTool 使用临时数据目录中 JSON 存储的导出工具，存储中有一个样本
+func newFileExportTool(t *testing.T) (*MetricsExportTool, string) {
+	t.Helper()
+	dataDir := t.TempDir()
+	dataStorage, err := storage.NewJSONStorage(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saveHistory(t, dataStorage, []types.MetricSample{{Timestamp: time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC), CPUPercent: 5}})
+	return NewMetricsExportTool(dataStorage), dataDir
+}
+
+func TestMetricsExportFileRefusesOverwrite(t *testing.T) {
+	tool, dataDir := newFileExportTool(t)
+	args := map[string]interface{}{"format": "csv", "from": "2024-05-06T00:00:00Z", "to": "2024-05-07T00:00:00Z", "output": "file"}
+
+	_, structured, err := tool.ExecuteStructured(context.Background(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := structured.(exportSummary).Path
+	if want := filepath.Join(dataDir, exportDir, "metrics_20240506T000000_20240507T000000.csv"); path != want {
+		t.Fatalf("path = %s, want %s", path, want)
+	}
+	if err := os.WriteFile(path, []byte("edited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = tool.ExecuteStructured(context.Background(), args)
+	var toolErr *Error
+	if !errors.As(err, &toolErr) || toolErr.Code != ErrBadArgument || !strings.Contains(toolErr.Message, "已存在") {
+		t.Fatalf("second export error = %v, want ErrBadArgument for the existing file", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "edited" {
+		t.Fatalf("existing export was overwritten: %q", data)
+	}
+
+	// 不同格式的文件名不同，不受影响
+	args["format"] = "influx"
+	if _, _, err := tool.ExecuteStructured(context.Background(), args); err != nil {
+		t.Fatalf("influx export of the same range = %v", err)
+	}
+}
+
+func TestMetricsExportFilePrunesOldExports(t *testing.T) {
+	tool, dataDir := newFileExportTool(t)
+	dir := filepath.Join(dataDir, exportDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	for i := 0; i < maxExportFiles; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("metrics_202401%02dT000000_202401%02dT000000.lp", i+1, i+2))
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, old.Add(time.Duration(i)*time.Minute), old.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 其他文件不算作导出文件，不会被删除
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(dir, "notes.txt"), old.Add(-time.Hour), old.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	text, _, err := tool.ExecuteStructured(context.Background(), map[string]interface{}{"from": "2024-05-06T00:00:00Z", "to": "2024-05-07T00:00:00Z", "output": "file"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "已删除较早的导出文件 1 个") {
+		t.Errorf("output does not report the pruned file:\n%s", text)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if len(names) != maxExportFiles+1 || !slices.Contains(names, "notes.txt") || !slices.Contains(names, "metrics_20240506T000000_20240507T000000.lp") {
+		t.Fatalf("export directory = %v, want the newest %d exports and the unrelated file", names, maxExportFiles)
+	}
+	if slices.Contains(names, "metrics_20240101T000000_20240102T000000.lp") {
+		t.Error("the oldest export should be deleted")
+	}
+}
+
+func TestMetricsExportInlineLimit(t *testing.T) {
+	dataStorage := storage.NewMemoryStorage()
+	start := time.Date(2024, 5, 6, 0, 0, 0, 0, time.Local)
+	disks := make(map[string]float64)
+	for i := 0; i < 50; i++ {
+		disks[fmt.Sprintf("/mnt/volume%02d", i)] = 50
+	}
+	var samples []types.MetricSample
+	for i := 0; i < 100; i++ {
+		samples = append(samples, types.MetricSample{Timestamp: start.Add(time.Duration(i) * time.Minute), DiskPercent: disks})
+	}
+	saveHistory(t, dataStorage, samples)
+
+	tool := NewMetricsExportTool(dataStorage)
+	_, _, err := tool.ExecuteStructured(context.Background(), map[string]interface{}{"from": start.Format(time.RFC3339), "to": start.Add(2 * time.Hour).Format(time.RFC3339)})
+	var toolErr *Error
+	if !errors.As(err, &toolErr) || toolErr.Code != ErrBadArgument || !strings.Contains(toolErr.Hint, `"output": "file"`) {
+		t.Fatalf("inline export over the limit = %v, want an error suggesting file output", err)
+	}
+
+	// 没有数据目录的存储不支持 file 模式
+	_, _, err = tool.ExecuteStructured(context.Background(), map[string]interface{}{"output": "file"})
+	if !errors.As(err, &toolErr) || toolErr.Code != ErrUnsupportedPlatform {
+		t.Fatalf("file export without a data directory = %v, want ErrUnsupportedPlatform", err)
+	}
+}
+
+func TestMetricsExportIsNotReadOnly(t *testing.T) {
+	annotations := NewMetricsExportTool(storage.NewMemoryStorage()).GetAnnotations()
+	if annotations.ReadOnlyHint {
+		t.Fatal("metrics_export writes and deletes files in file mode and must not declare readOnlyHint")
+	}
+}
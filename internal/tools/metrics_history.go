@@ -0,0 +1,392 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/types"
+)
+
+// 支持查询的历史指标
+const (
+	MetricCPUPercent    = "cpu_percent"
+	MetricMemoryPercent = "memory_percent"
+	MetricDiskPercent   = "disk_percent"
+	MetricNetRxBytes    = "net_rx_bytes"
+	MetricNetTxBytes    = "net_tx_bytes"
+)
+
+// sparkChars 迷你趋势图使用的字符（由低到高）
+var sparkChars = []rune("▁▂▃▄▅▆▇█")
+
+// MetricsHistoryTool 采集历史查询工具
+type MetricsHistoryTool struct {
+	storage types.DataStorage
+}
+
+// NewMetricsHistoryTool 创建新的采集历史查询工具
+func NewMetricsHistoryTool(dataStorage types.DataStorage) *MetricsHistoryTool {
+	return &MetricsHistoryTool{
+		storage: dataStorage,
+	}
+}
+
+// historyPoint 降采样后的单个数据点
+type historyPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Avg       float64   `json:"avg"`
+	Min       float64   `json:"min"`
+	Max       float64   `json:"max"`
+	Count     int       `json:"count"`
+}
+
+// historySeries 历史查询结果
+type historySeries struct {
+	Metric       string         `json:"metric"`
+	Selector     string         `json:"selector,omitempty"`
+	Unit         string         `json:"unit"`
+	From         time.Time      `json:"from"`
+	To           time.Time      `json:"to"`
+	RawSamples   int            `json:"raw_samples"`
+	SkippedFiles int            `json:"skipped_files"`
+	Points       []historyPoint `json:"points"`
+	// ClockJumps 时钟跳变后第一个样本的时间
+	ClockJumps []time.Time         `json:"clock_jumps,omitempty"`
+	Host       *types.HostIdentity `json:"host,omitempty"`
+}
+
+// GetName 获取工具名称
+func (mh *MetricsHistoryTool) GetName() string {
+	return "metrics_history"
+}
+
+// GetDescription 获取工具描述
+func (mh *MetricsHistoryTool) GetDescription() string {
+	return "查询后台采集的历史指标（降采样后返回趋势）"
+}
+
+// GetAnnotations 获取工具注解
+func (mh *MetricsHistoryTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("历史指标")
+}
+
+// metricsHistoryArgs metrics_history 的参数
+type metricsHistoryArgs struct {
+	Metric     string `arg:"metric,required,enum=cpu_percent|memory_percent|disk_percent|net_rx_bytes|net_tx_bytes" desc:"指标名称"`
+	Mountpoint string `arg:"mountpoint" desc:"disk_percent 的挂载点（默认 /）"`
+	Interface  string `arg:"interface" desc:"网络指标的接口名称（为空则汇总所有接口）"`
+	timeRangeArgs
+	Points int `arg:"points,default=100,min=1,max=1000" desc:"最多返回的数据点数量"`
+	formatArgs
+}
+
+// GetInputSchema 获取输入模式
+func (mh *MetricsHistoryTool) GetInputSchema() types.InputSchema {
+	return argsSchema(metricsHistoryArgs{})
+}
+
+// Examples 获取调用示例
+func (mh *MetricsHistoryTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "最近的 CPU 使用率历史（最多 100 个点）",
+			Arguments:   map[string]interface{}{"metric": MetricCPUPercent},
+		},
+		{
+			Description: "根分区最近 50 个使用率数据点，JSON 格式",
+			Arguments:   map[string]interface{}{"metric": MetricDiskPercent, "mountpoint": "/", "points": 50, "format": "json"},
+		},
+		{
+			Description: "eth0 的接收速率历史",
+			Arguments:   map[string]interface{}{"metric": MetricNetRxBytes, "interface": "eth0"},
+		},
+	}
+}
+
+// Execute 执行历史查询
+func (mh *MetricsHistoryTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a metricsHistoryArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+	from, to, err := a.resolve(24 * time.Hour)
+	if err != nil {
+		return "", err
+	}
+
+	metric, selector := a.Metric, ""
+	switch metric {
+	case MetricDiskPercent:
+		selector = a.Mountpoint
+		if selector == "" {
+			selector = "/"
+		}
+	case MetricNetRxBytes, MetricNetTxBytes:
+		selector = a.Interface
+	}
+
+	// 加载并降采样
+	samples, skipped := loadHistory(mh.storage, from, to)
+	series := historySeries{
+		Metric:       metric,
+		Selector:     selector,
+		Unit:         metricUnit(metric),
+		From:         from,
+		To:           to,
+		RawSamples:   len(samples),
+		SkippedFiles: skipped,
+		Points:       downsample(extractMetric(samples, metric, selector), a.Points),
+		ClockJumps:   detectClockJumps(samples),
+	}
+
+	if a.Format == "json" {
+		series.Host = identity.Get()
+		jsonData, err := json.MarshalIndent(series, "", "  ")
+		if err != nil {
+			return "", wrapError("序列化历史数据失败", err)
+		}
+		return string(jsonData), nil
+	}
+
+	return mh.formatSeries(series), nil
+}
+
+// Complete 根据最近 24 小时的采集历史补全 mountpoint 和 interface 参数
+func (mh *MetricsHistoryTool) Complete(ctx context.Context, argName, prefix string) []string {
+	if argName != "mountpoint" && argName != "interface" {
+		return nil
+	}
+
+	now := time.Now()
+	samples, _ := loadHistory(mh.storage, now.Add(-24*time.Hour), now)
+
+	var candidates []string
+	for _, sample := range samples {
+		if argName == "mountpoint" {
+			for mountpoint := range sample.DiskPercent {
+				candidates = append(candidates, mountpoint)
+			}
+		} else {
+			for name := range sample.NetRxBytes {
+				candidates = append(candidates, name)
+			}
+		}
+	}
+
+	return matchPrefix(candidates, prefix)
+}
+
+// timedValue 带时间戳的指标值
+type timedValue struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// metricUnit 指标单位
+func metricUnit(metric string) string {
+	if metric == MetricNetRxBytes || metric == MetricNetTxBytes {
+		return "B/s"
+	}
+	return "%"
+}
+
+// extractMetric 从样本中提取指标值。
+// 网络指标按相邻样本的计数器差值换算为每秒字节数，计数器回退（接口重置）、跨越重启或时钟跳变的区间会被跳过。
+func extractMetric(samples []types.MetricSample, metric, selector string) []timedValue {
+	var values []timedValue
+
+	switch metric {
+	case MetricCPUPercent, MetricMemoryPercent, MetricDiskPercent:
+		for _, sample := range samples {
+			var value float64
+			switch metric {
+			case MetricCPUPercent:
+				value = sample.CPUPercent
+			case MetricMemoryPercent:
+				value = sample.MemoryPercent
+			default:
+				percent, exists := sample.DiskPercent[selector]
+				if !exists {
+					continue
+				}
+				value = percent
+			}
+			values = append(values, timedValue{Timestamp: sample.Timestamp, Value: value})
+		}
+
+	case MetricNetRxBytes, MetricNetTxBytes:
+		counter := func(sample types.MetricSample) (uint64, bool) {
+			counters := sample.NetRxBytes
+			if metric == MetricNetTxBytes {
+				counters = sample.NetTxBytes
+			}
+			if selector != "" {
+				value, exists := counters[selector]
+				return value, exists
+			}
+			var total uint64
+			for _, value := range counters {
+				total += value
+			}
+			return total, len(counters) > 0
+		}
+
+		for i := 1; i < len(samples); i++ {
+			prev, okPrev := counter(samples[i-1])
+			curr, okCurr := counter(samples[i])
+			seconds := samples[i].Timestamp.Sub(samples[i-1].Timestamp).Seconds()
+			if !okPrev || !okCurr || curr < prev || seconds <= 0 {
+				continue
+			}
+			// 重启后计数器从零开始，即使数值变大，跨越重启的差值也没有意义；时钟跳变时两个样本的时间差不可信
+			if rebooted(samples[i-1].BootTime, samples[i].BootTime) || samples[i].ClockJumpSeconds != 0 {
+				continue
+			}
+			values = append(values, timedValue{
+				Timestamp: samples[i].Timestamp,
+				Value:     float64(curr-prev) / seconds,
+			})
+		}
+	}
+
+	return values
+}
+
+// downsample 将数据按时间均匀分桶，每个桶计算平均值、最小值和最大值
+func downsample(values []timedValue, maxPoints int) []historyPoint {
+	if len(values) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].Timestamp.Before(values[j].Timestamp)
+	})
+
+	start := values[0].Timestamp
+	span := values[len(values)-1].Timestamp.Sub(start)
+
+	buckets := maxPoints
+	if len(values) < buckets {
+		buckets = len(values)
+	}
+
+	points := make([]historyPoint, buckets)
+	for _, v := range values {
+		index := 0
+		if span > 0 {
+			index = int(float64(v.Timestamp.Sub(start)) / float64(span) * float64(buckets))
+			if index >= buckets {
+				index = buckets - 1
+			}
+		}
+
+		point := &points[index]
+		if point.Count == 0 {
+			point.Timestamp = v.Timestamp
+			point.Min = v.Value
+			point.Max = v.Value
+		}
+		point.Avg += v.Value
+		point.Min = math.Min(point.Min, v.Value)
+		point.Max = math.Max(point.Max, v.Value)
+		point.Count++
+	}
+
+	// 去掉空桶并计算平均值
+	result := points[:0]
+	for _, point := range points {
+		if point.Count == 0 {
+			continue
+		}
+		point.Avg /= float64(point.Count)
+		result = append(result, point)
+	}
+
+	return result
+}
+
+// sparkline 生成迷你趋势图
+func sparkline(points []historyPoint) string {
+	if len(points) == 0 {
+		return ""
+	}
+
+	low, high := points[0].Avg, points[0].Avg
+	for _, point := range points {
+		low = math.Min(low, point.Avg)
+		high = math.Max(high, point.Avg)
+	}
+
+	var builder strings.Builder
+	for _, point := range points {
+		index := 0
+		if high > low {
+			index = int((point.Avg - low) / (high - low) * float64(len(sparkChars)-1))
+		}
+		builder.WriteRune(sparkChars[index])
+	}
+
+	return builder.String()
+}
+
+// formatHistoryValue 按单位格式化指标值
+func formatHistoryValue(value float64, unit string) string {
+	if unit == "B/s" {
+		return formatBytes(uint64(value)) + "/s"
+	}
+	return fmt.Sprintf("%.2f%%", value)
+}
+
+// formatSeries 格式化历史查询结果
+func (mh *MetricsHistoryTool) formatSeries(series historySeries) string {
+	var result string
+
+	result += "📈 历史指标\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result += fmt.Sprintf("指标: %s", series.Metric)
+	if series.Selector != "" {
+		result += fmt.Sprintf(" (%s)", series.Selector)
+	}
+	result += "\n"
+	result += fmt.Sprintf("时间范围: %s ~ %s\n", series.From.Format("2006-01-02 15:04:05"), series.To.Format("2006-01-02 15:04:05"))
+	result += fmt.Sprintf("原始样本: %d, 数据点: %d\n", series.RawSamples, len(series.Points))
+
+	if len(series.Points) == 0 {
+		result += "\n该时间范围内没有采集数据（请确认已通过 --collect-interval 启用后台采集）\n"
+	} else {
+		low, high, total := series.Points[0].Min, series.Points[0].Max, 0.0
+		samples := 0
+		for _, point := range series.Points {
+			low = math.Min(low, point.Min)
+			high = math.Max(high, point.Max)
+			total += point.Avg * float64(point.Count)
+			samples += point.Count
+		}
+
+		result += fmt.Sprintf("\n最小值: %s, 平均值: %s, 最大值: %s\n",
+			formatHistoryValue(low, series.Unit),
+			formatHistoryValue(total/float64(samples), series.Unit),
+			formatHistoryValue(high, series.Unit),
+		)
+		result += fmt.Sprintf("趋势: %s\n", sparkline(series.Points))
+	}
+
+	if series.SkippedFiles > 0 {
+		result += fmt.Sprintf("\n⚠️  跳过了 %d 个缺失或损坏的历史文件\n", series.SkippedFiles)
+	}
+	if len(series.ClockJumps) > 0 {
+		result += fmt.Sprintf("\n⏰ 检测到 %d 次系统时钟跳变（NTP 步进调整或挂起后恢复），时间轴在跳变处不连续", len(series.ClockJumps))
+		if series.Unit == "B/s" {
+			result += "，跨越跳变的速率已略过"
+		}
+		result += "\n"
+	}
+
+	return result
+}
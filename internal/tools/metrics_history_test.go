@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+// rebootSamples 每分钟一个样本，eth0 每秒接收 1000 字节；第 3 个样本前系统重启，
+// 重启后的计数器比重启前还大，直接相减会得到一个看似合理但没有意义的速率
+func rebootSamples(start time.Time) []types.MetricSample {
+	rx := []uint64{1000, 61000, 121000, 500000, 560000, 620000}
+	boot := []uint64{1700000000, 1700000003, 1700000000, 1700000400, 1700000398, 1700000400}
+	samples := make([]types.MetricSample, len(rx))
+	for i := range rx {
+		samples[i] = types.MetricSample{
+			Timestamp:   start.Add(time.Duration(i) * time.Minute),
+			BootTime:    boot[i],
+			CPUPercent:  float64(10 + i),
+			NetRxBytes:  map[string]uint64{"eth0": rx[i]},
+			NetTxBytes:  map[string]uint64{"eth0": 0},
+			DiskPercent: map[string]float64{"/": 50},
+		}
+	}
+	return samples
+}
+
+func TestExtractMetricAcrossReboot(t *testing.T) {
+	start := time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC)
+	samples := rebootSamples(start)
+
+	// 跨越重启的区间被跳过；启动时间几秒内的抖动不算重启
+	values := extractMetric(samples, MetricNetRxBytes, "eth0")
+	var minutes []int
+	for _, value := range values {
+		if value.Value != 1000 {
+			t.Errorf("rate at %s = %.1f, want 1000", value.Timestamp, value.Value)
+		}
+		minutes = append(minutes, int(value.Timestamp.Sub(start).Minutes()))
+	}
+	if len(minutes) != 4 || minutes[0] != 1 || minutes[1] != 2 || minutes[2] != 4 || minutes[3] != 5 {
+		t.Fatalf("rates at minutes %v, want 1, 2, 4, 5", minutes)
+	}
+
+	// 启动时间未知的旧样本照常计算
+	for i := range samples {
+		samples[i].BootTime = 0
+	}
+	if values := extractMetric(samples, MetricNetRxBytes, "eth0"); len(values) != 5 {
+		t.Errorf("%d rates without boot times, want 5", len(values))
+	}
+
+	// 百分比指标不是计数器，重启不影响
+	if values := extractMetric(rebootSamples(start), MetricCPUPercent, ""); len(values) != 6 {
+		t.Errorf("%d cpu values, want all 6 samples", len(values))
+	}
+}
+
+func TestMetricsHistoryAcrossReboot(t *testing.T) {
+	start := time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC)
+	dataStorage := storage.NewMemoryStorage()
+	if err := dataStorage.Save(HistoryKey(start), rebootSamples(start)); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewMetricsHistoryTool(dataStorage)
+
+	text, err := tool.Execute(context.Background(), map[string]interface{}{
+		"metric":    MetricNetRxBytes,
+		"interface": "eth0",
+		"from":      start.Add(-time.Minute).Format(time.RFC3339),
+		"to":        start.Add(time.Hour).Format(time.RFC3339),
+		"format":    "json",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var series historySeries
+	if err := json.Unmarshal([]byte(text), &series); err != nil {
+		t.Fatal(err)
+	}
+	rates := 0
+	for _, point := range series.Points {
+		rates += point.Count
+		if point.Max != 1000 {
+			t.Errorf("point %+v, want no spike across the reboot", point)
+		}
+	}
+	if series.RawSamples != 6 || rates != 4 {
+		t.Fatalf("series has %d samples and %d rates, want 6 and 4 without the reboot interval", series.RawSamples, rates)
+	}
+}
@@ -0,0 +1,355 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/types"
+)
+
+// MetricsTrendTool 指标趋势工具：对比当前值与 1 小时前、24 小时前的采样
+type MetricsTrendTool struct {
+	storage types.DataStorage
+}
+
+// NewMetricsTrendTool 创建新的指标趋势工具
+func NewMetricsTrendTool(dataStorage types.DataStorage) *MetricsTrendTool {
+	return &MetricsTrendTool{
+		storage: dataStorage,
+	}
+}
+
+// trendComparison 与历史某一时刻的对比
+type trendComparison struct {
+	Value         float64   `json:"value"`
+	Timestamp     time.Time `json:"timestamp"`
+	Change        float64   `json:"change"`
+	ChangePercent float64   `json:"change_percent"`
+	Direction     string    `json:"direction"`
+	AcrossReboot  bool      `json:"across_reboot,omitempty"`
+}
+
+// metricTrend 单个指标的趋势
+type metricTrend struct {
+	Metric      string           `json:"metric"`
+	Selector    string           `json:"selector,omitempty"`
+	Unit        string           `json:"unit"`
+	Current     float64          `json:"current"`
+	HourAgo     *trendComparison `json:"hour_ago,omitempty"`
+	DayAgo      *trendComparison `json:"day_ago,omitempty"`
+	SlopePerDay float64          `json:"slope_per_day"`
+	DaysToFull  *float64         `json:"days_to_full,omitempty"`
+}
+
+// trendReport 趋势报告
+type trendReport struct {
+	Samples     int                 `json:"samples"`
+	Oldest      time.Time           `json:"oldest"`
+	Newest      time.Time           `json:"newest"`
+	WindowHours int                 `json:"window_hours"`
+	Trends      []metricTrend       `json:"trends"`
+	Reboots     []time.Time         `json:"reboots,omitempty"`
+	ClockJumps  []time.Time         `json:"clock_jumps,omitempty"`
+	Host        *types.HostIdentity `json:"host,omitempty"`
+}
+
+// GetName 获取工具名称
+func (mt *MetricsTrendTool) GetName() string {
+	return "metrics_trend"
+}
+
+// GetDescription 获取工具描述
+func (mt *MetricsTrendTool) GetDescription() string {
+	return "对比关键指标与 1 小时前、24 小时前的变化，并预测磁盘写满时间"
+}
+
+// GetAnnotations 获取工具注解
+func (mt *MetricsTrendTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("指标趋势")
+}
+
+// metricsTrendArgs metrics_trend 的参数
+type metricsTrendArgs struct {
+	Hours         int     `arg:"hours,default=6,min=1,max=168" desc:"线性回归使用的最近小时数"`
+	DiskThreshold float64 `arg:"disk_threshold,default=80,min=0,max=100" desc:"使用率超过该百分比的分区才预测写满时间"`
+	formatArgs
+}
+
+// GetInputSchema 获取输入模式
+func (mt *MetricsTrendTool) GetInputSchema() types.InputSchema {
+	return argsSchema(metricsTrendArgs{})
+}
+
+// Examples 获取调用示例
+func (mt *MetricsTrendTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "最近 6 小时的指标趋势和使用率超过 80% 的分区写满预测",
+			Arguments:   map[string]interface{}{},
+		},
+		{
+			Description: "按最近 24 小时回归，预测使用率超过 90% 的分区",
+			Arguments:   map[string]interface{}{"hours": 24, "disk_threshold": 90},
+		},
+	}
+}
+
+// Execute 执行趋势分析
+func (mt *MetricsTrendTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a metricsTrendArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+	hours, threshold := a.Hours, a.DiskThreshold
+
+	// 加载足够覆盖 24 小时对比和回归窗口的历史
+	now := time.Now()
+	lookback := 24*time.Hour + trendTolerance(24*time.Hour)
+	if window := time.Duration(hours) * time.Hour; window > lookback {
+		lookback = window
+	}
+	samples, _ := loadHistory(mt.storage, now.Add(-lookback), now)
+
+	report := buildTrendReport(samples, time.Duration(hours)*time.Hour, threshold)
+	report.WindowHours = hours
+
+	if a.Format == "json" {
+		report.Host = identity.Get()
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", wrapError("序列化趋势数据失败", err)
+		}
+		return string(jsonData), nil
+	}
+
+	return mt.formatTrendReport(report, threshold), nil
+}
+
+// trendTolerance 查找历史对比样本时允许的最大时间偏差
+func trendTolerance(offset time.Duration) time.Duration {
+	return offset / 4
+}
+
+// buildTrendReport 根据采样生成趋势报告
+func buildTrendReport(samples []types.MetricSample, window time.Duration, diskThreshold float64) trendReport {
+	report := trendReport{Samples: len(samples)}
+	if len(samples) < 2 {
+		if len(samples) == 1 {
+			report.Oldest, report.Newest = samples[0].Timestamp, samples[0].Timestamp
+		}
+		return report
+	}
+	report.Oldest = samples[0].Timestamp
+	report.Newest = samples[len(samples)-1].Timestamp
+	report.Reboots = detectReboots(samples)
+	report.ClockJumps = detectClockJumps(samples)
+
+	type metricRef struct {
+		metric   string
+		selector string
+	}
+	refs := []metricRef{
+		{MetricCPUPercent, ""},
+		{MetricMemoryPercent, ""},
+	}
+
+	var mountpoints []string
+	for mountpoint := range samples[len(samples)-1].DiskPercent {
+		mountpoints = append(mountpoints, mountpoint)
+	}
+	sort.Strings(mountpoints)
+	for _, mountpoint := range mountpoints {
+		refs = append(refs, metricRef{MetricDiskPercent, mountpoint})
+	}
+	refs = append(refs, metricRef{MetricNetRxBytes, ""}, metricRef{MetricNetTxBytes, ""})
+
+	for _, ref := range refs {
+		values := extractMetric(samples, ref.metric, ref.selector)
+		if len(values) == 0 {
+			continue
+		}
+
+		latest := values[len(values)-1]
+		trend := metricTrend{
+			Metric:   ref.metric,
+			Selector: ref.selector,
+			Unit:     metricUnit(ref.metric),
+			Current:  latest.Value,
+		}
+
+		for _, offset := range []time.Duration{time.Hour, 24 * time.Hour} {
+			index := nearestSample(values, latest.Timestamp.Add(-offset), trendTolerance(offset))
+			if index < 0 {
+				continue
+			}
+			comparison := compareValues(latest.Value, values[index])
+			comparison.AcrossReboot = rebootBetween(report.Reboots, values[index].Timestamp, latest.Timestamp)
+			if offset == time.Hour {
+				trend.HourAgo = &comparison
+			} else {
+				trend.DayAgo = &comparison
+			}
+		}
+
+		// 回归窗口内的斜率
+		var windowValues []timedValue
+		for _, v := range values {
+			if latest.Timestamp.Sub(v.Timestamp) <= window {
+				windowValues = append(windowValues, v)
+			}
+		}
+		trend.SlopePerDay = linearSlope(windowValues) * 24 * 3600
+
+		if ref.metric == MetricDiskPercent && trend.Current >= diskThreshold {
+			if days, ok := daysUntilFull(trend.Current, trend.SlopePerDay); ok {
+				trend.DaysToFull = &days
+			}
+		}
+
+		report.Trends = append(report.Trends, trend)
+	}
+
+	return report
+}
+
+// nearestSample 查找距离目标时间最近的样本，超出容差时返回 -1
+func nearestSample(values []timedValue, target time.Time, tolerance time.Duration) int {
+	best := -1
+	var bestDiff time.Duration
+	for i, v := range values {
+		diff := v.Timestamp.Sub(target)
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > tolerance {
+			continue
+		}
+		if best < 0 || diff < bestDiff {
+			best, bestDiff = i, diff
+		}
+	}
+	return best
+}
+
+// compareValues 计算当前值相对历史值的变化
+func compareValues(current float64, past timedValue) trendComparison {
+	comparison := trendComparison{
+		Value:     past.Value,
+		Timestamp: past.Timestamp,
+		Change:    current - past.Value,
+	}
+	if past.Value != 0 {
+		comparison.ChangePercent = comparison.Change / math.Abs(past.Value) * 100
+	}
+
+	switch {
+	case math.Abs(comparison.ChangePercent) < 1 && math.Abs(comparison.Change) < 0.5:
+		comparison.Direction = "→"
+	case comparison.Change > 0:
+		comparison.Direction = "↑"
+	default:
+		comparison.Direction = "↓"
+	}
+
+	return comparison
+}
+
+// linearSlope 最小二乘线性回归斜率（每秒的变化量），样本不足时返回 0
+func linearSlope(values []timedValue) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+
+	origin := values[0].Timestamp
+	var sumX, sumY, sumXY, sumXX float64
+	for _, v := range values {
+		x := v.Timestamp.Sub(origin).Seconds()
+		sumX += x
+		sumY += v.Value
+		sumXY += x * v.Value
+		sumXX += x * x
+	}
+
+	n := float64(len(values))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+
+	return (n*sumXY - sumX*sumY) / denominator
+}
+
+// formatTrendReport 格式化趋势报告
+func (mt *MetricsTrendTool) formatTrendReport(report trendReport, diskThreshold float64) string {
+	var result string
+
+	result += "📉 指标趋势\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+
+	if report.Samples < 2 {
+		result += fmt.Sprintf("历史数据不足：目前仅采集了 %d 个样本", report.Samples)
+		if report.Samples == 1 {
+			result += fmt.Sprintf("（%s）", report.Newest.Format("2006-01-02 15:04:05"))
+		}
+		result += "\n请确认已通过 --collect-interval 启用后台采集\n"
+		return result
+	}
+
+	coverage := report.Newest.Sub(report.Oldest).Round(time.Minute)
+	result += fmt.Sprintf("已采集 %d 个样本，覆盖 %s（%s ~ %s）\n", report.Samples, coverage,
+		report.Oldest.Format("2006-01-02 15:04"), report.Newest.Format("2006-01-02 15:04"))
+	for _, reboot := range report.Reboots {
+		result += fmt.Sprintf("🔁 检测到系统重启（重启后首个样本: %s），跨越重启的网络计数器差值已忽略\n", reboot.Format("2006-01-02 15:04"))
+	}
+	for _, jump := range report.ClockJumps {
+		result += fmt.Sprintf("⏰ 检测到系统时钟跳变（跳变后首个样本: %s），跨越跳变的网络速率已略过，对比和斜率可能有偏差\n", jump.Format("2006-01-02 15:04"))
+	}
+	result += "\n"
+
+	for _, trend := range report.Trends {
+		name := trend.Metric
+		if trend.Selector != "" {
+			name += " " + trend.Selector
+		}
+
+		result += fmt.Sprintf("%s: 当前 %s\n", name, formatHistoryValue(trend.Current, trend.Unit))
+		for _, item := range []struct {
+			label      string
+			comparison *trendComparison
+		}{
+			{"1小时前", trend.HourAgo},
+			{"24小时前", trend.DayAgo},
+		} {
+			if item.comparison == nil {
+				result += fmt.Sprintf("  %s: 无足够历史\n", item.label)
+				continue
+			}
+			result += fmt.Sprintf("  %s: %s (%s %+.2f, %+.1f%%)\n",
+				item.label,
+				formatHistoryValue(item.comparison.Value, trend.Unit),
+				item.comparison.Direction,
+				item.comparison.Change,
+				item.comparison.ChangePercent,
+			)
+			if item.comparison.AcrossReboot {
+				result += "    （期间发生过重启）\n"
+			}
+		}
+
+		if trend.Metric == MetricDiskPercent && trend.Current >= diskThreshold {
+			if trend.DaysToFull != nil {
+				result += fmt.Sprintf("  ⚠️  按最近 %d 小时的增长（%+.2f%%/天），预计约 %.1f 天后写满\n",
+					report.WindowHours, trend.SlopePerDay, *trend.DaysToFull)
+			} else {
+				result += fmt.Sprintf("  使用率超过 %.0f%%，但最近 %d 小时没有增长\n", diskThreshold, report.WindowHours)
+			}
+		}
+	}
+
+	return result
+}
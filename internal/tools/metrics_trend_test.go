@@ -0,0 +1,168 @@
+package tools
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// trendStart 合成序列的起始时间
+var trendStart = time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+// linearSeries 每隔 step 一个样本、从 start 开始每秒增加 perSecond 的序列
+func linearSeries(count int, step time.Duration, start, perSecond float64) []timedValue {
+	values := make([]timedValue, count)
+	for i := range values {
+		offset := time.Duration(i) * step
+		values[i] = timedValue{Timestamp: trendStart.Add(offset), Value: start + perSecond*offset.Seconds()}
+	}
+	return values
+}
+
+func TestNearestSample(t *testing.T) {
+	values := []timedValue{
+		{Timestamp: trendStart},
+		{Timestamp: trendStart.Add(50 * time.Minute)},
+		{Timestamp: trendStart.Add(58 * time.Minute)},
+		{Timestamp: trendStart.Add(63 * time.Minute)},
+	}
+	tests := []struct {
+		name      string
+		target    time.Time
+		tolerance time.Duration
+		want      int
+	}{
+		{"closest before", trendStart.Add(time.Hour), 15 * time.Minute, 2},
+		{"closest after", trendStart.Add(62 * time.Minute), 15 * time.Minute, 3},
+		{"exact", trendStart, time.Minute, 0},
+		{"outside tolerance", trendStart.Add(30 * time.Minute), 10 * time.Minute, -1},
+		{"at tolerance", trendStart.Add(40 * time.Minute), 10 * time.Minute, 1},
+		{"tie keeps earlier", trendStart.Add(54 * time.Minute), 10 * time.Minute, 1},
+	}
+	for _, test := range tests {
+		if got := nearestSample(values, test.target, test.tolerance); got != test.want {
+			t.Errorf("%s: nearestSample = %d, want %d", test.name, got, test.want)
+		}
+	}
+	if got := nearestSample(nil, trendStart, time.Hour); got != -1 {
+		t.Errorf("empty series: nearestSample = %d, want -1", got)
+	}
+}
+
+func TestLinearSlope(t *testing.T) {
+	// 每天增长 2 个百分点
+	perSecond := 2.0 / (24 * 3600)
+	if got := linearSlope(linearSeries(48, time.Hour, 80, perSecond)) * 24 * 3600; math.Abs(got-2) > 1e-9 {
+		t.Fatalf("slope per day = %v, want 2", got)
+	}
+	if got := linearSlope(linearSeries(10, time.Hour, 50, 0)); got != 0 {
+		t.Fatalf("flat series slope = %v, want 0", got)
+	}
+	if got := linearSlope(linearSeries(1, time.Hour, 50, 1)); got != 0 {
+		t.Fatalf("single sample slope = %v, want 0", got)
+	}
+	// 时间相同的样本无法回归
+	same := []timedValue{{Timestamp: trendStart, Value: 1}, {Timestamp: trendStart, Value: 2}}
+	if got := linearSlope(same); got != 0 {
+		t.Fatalf("identical timestamps slope = %v, want 0", got)
+	}
+}
+
+func TestLinearSlopeIgnoresNoise(t *testing.T) {
+	values := linearSeries(24, time.Hour, 60, 1.0/3600)
+	for i := range values {
+		// 围绕直线交替的噪声不改变斜率
+		if i%2 == 0 {
+			values[i].Value += 0.5
+		} else {
+			values[i].Value -= 0.5
+		}
+	}
+	if got := linearSlope(values) * 3600; math.Abs(got-1) > 0.05 {
+		t.Fatalf("slope per hour = %v, want about 1", got)
+	}
+}
+
+func TestDaysUntilFull(t *testing.T) {
+	if days, ok := daysUntilFull(90, 2); !ok || days != 5 {
+		t.Fatalf("daysUntilFull(90, 2) = %v, %v; want 5 days", days, ok)
+	}
+	for _, test := range []struct{ used, slope float64 }{{90, 0}, {90, -1}, {100, 2}} {
+		if _, ok := daysUntilFull(test.used, test.slope); ok {
+			t.Errorf("daysUntilFull(%v, %v) should not extrapolate", test.used, test.slope)
+		}
+	}
+}
+
+func TestBuildTrendReportExtrapolatesDisk(t *testing.T) {
+	// 两天内每 10 分钟一个样本，/data 每天增长 2 个百分点，最新为 90%
+	var samples []types.MetricSample
+	for i := 0; i <= 2*24*6; i++ {
+		at := trendStart.Add(time.Duration(i) * 10 * time.Minute)
+		elapsedDays := at.Sub(trendStart).Hours() / 24
+		samples = append(samples, types.MetricSample{
+			Timestamp:     at,
+			CPUPercent:    20,
+			MemoryPercent: 50 + elapsedDays,
+			DiskPercent:   map[string]float64{"/data": 86 + 2*elapsedDays, "/": 40},
+		})
+	}
+
+	report := buildTrendReport(samples, 24*time.Hour, 85)
+	trends := map[string]metricTrend{}
+	for _, trend := range report.Trends {
+		trends[trend.Metric+"|"+trend.Selector] = trend
+	}
+
+	data := trends[MetricDiskPercent+"|/data"]
+	if math.Abs(data.Current-90) > 1e-9 || data.DaysToFull == nil || math.Abs(*data.DaysToFull-5) > 1e-6 {
+		t.Fatalf("/data trend = %+v, want 90%% and 5 days to full", data)
+	}
+	if data.DayAgo == nil || data.DayAgo.Direction != "↑" || math.Abs(data.DayAgo.Change-2) > 1e-9 {
+		t.Fatalf("/data day-ago comparison = %+v, want +2 ↑", data.DayAgo)
+	}
+	if root := trends[MetricDiskPercent+"|/"]; root.DaysToFull != nil {
+		t.Fatalf("/ is below the threshold and should not be extrapolated: %+v", root)
+	}
+	if cpu := trends[MetricCPUPercent+"|"]; cpu.HourAgo == nil || cpu.HourAgo.Direction != "→" {
+		t.Fatalf("cpu hour-ago comparison = %+v, want →", cpu.HourAgo)
+	}
+}
+
+func TestBuildTrendReportInsufficientHistory(t *testing.T) {
+	samples := []types.MetricSample{{Timestamp: trendStart, CPUPercent: 10}}
+	report := buildTrendReport(samples, time.Hour, 85)
+	if report.Samples != 1 || len(report.Trends) != 0 || !report.Oldest.Equal(trendStart) {
+		t.Fatalf("report = %+v, want only the sample count", report)
+	}
+}
+
+func TestTrendReportAcrossReboot(t *testing.T) {
+	start := time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC)
+	var samples []types.MetricSample
+	for i := 0; i <= 120; i++ {
+		boot := uint64(1700000000)
+		if i >= 90 {
+			boot = 1700005400
+		}
+		samples = append(samples, types.MetricSample{Timestamp: start.Add(time.Duration(i) * time.Minute), BootTime: boot, CPUPercent: 20})
+	}
+
+	// 1 小时前的样本在重启之前，对比标记为跨越重启
+	report := buildTrendReport(samples, 24*time.Hour, 85)
+	if len(report.Reboots) != 1 || !report.Reboots[0].Equal(start.Add(90*time.Minute)) {
+		t.Fatalf("reboots = %v", report.Reboots)
+	}
+	if len(report.Trends) == 0 || report.Trends[0].HourAgo == nil || !report.Trends[0].HourAgo.AcrossReboot {
+		t.Fatalf("trends = %+v, want the hour-ago comparison marked across the reboot", report.Trends)
+	}
+	text := (&MetricsTrendTool{}).formatTrendReport(report, 85)
+	for _, want := range []string{"🔁 检测到系统重启（重启后首个样本: 2024-05-06 08:30）", "（期间发生过重启）"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("trend output missing %q:\n%s", want, text)
+		}
+	}
+}
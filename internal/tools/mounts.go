@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// procMountsPath Linux 上当前挂载表的位置
+const procMountsPath = "/proc/mounts"
+
+// readMountOptions 读取 Linux 挂载表，返回按挂载点索引的挂载选项
+func readMountOptions(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseMountOptions(data), nil
+}
+
+// parseMountOptions 解析 /proc/mounts 格式的挂载表（设备 挂载点 类型 选项 dump pass），返回按挂载点索引的挂载选项。
+// 挂载点中的空格等字符以 \040 形式的八进制转义，同一挂载点被多次挂载时以最后一次（最上层）为准，格式不完整的行被忽略
+func parseMountOptions(data []byte) map[string][]string {
+	options := make(map[string][]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		options[unescapeMountField(fields[1])] = strings.Split(fields[3], ",")
+	}
+	return options
+}
+
+// unescapeMountField 还原挂载表字段中的八进制转义（如 \040 为空格），无效的转义原样保留
+func unescapeMountField(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+
+	var result strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+4 <= len(field) {
+			if value, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				result.WriteByte(byte(value))
+				i += 3
+				continue
+			}
+		}
+		result.WriteByte(field[i])
+	}
+	return result.String()
+}
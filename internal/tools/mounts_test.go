@@ -0,0 +1,176 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+// procMountsFixture /proc/mounts：/data 出错后被重新挂载为只读，/mnt/usb 被挂载了两次
+const procMountsFixture = `sysfs /sys sysfs rw,nosuid,nodev,noexec,relatime 0 0
+proc /proc proc rw,nosuid,nodev,noexec,relatime 0 0
+/dev/sda1 / ext4 rw,relatime,errors=remount-ro 0 0
+/dev/sdb1 /data xfs ro,relatime,attr2,inode64,noquota 0 0
+/dev/loop0 /snap/core/16202 squashfs ro,nodev,relatime 0 0
+/dev/sdc1 /mnt/my\040disk ext4 rw,relatime 0 0
+/dev/sdd1 /mnt/usb vfat rw,relatime 0 0
+/dev/sde1 /mnt/usb vfat ro,relatime 0 0
+broken line
+`
+
+func TestParseMountOptions(t *testing.T) {
+	got := parseMountOptions([]byte(procMountsFixture))
+	want := map[string][]string{
+		"/sys":             {"rw", "nosuid", "nodev", "noexec", "relatime"},
+		"/proc":            {"rw", "nosuid", "nodev", "noexec", "relatime"},
+		"/":                {"rw", "relatime", "errors=remount-ro"},
+		"/data":            {"ro", "relatime", "attr2", "inode64", "noquota"},
+		"/snap/core/16202": {"ro", "nodev", "relatime"},
+		// 挂载点中的空格以八进制转义
+		"/mnt/my disk": {"rw", "relatime"},
+		// 同一挂载点以最后一次（最上层）为准
+		"/mnt/usb": {"ro", "relatime"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMountOptions() = %v, want %v", got, want)
+	}
+
+	if got := parseMountOptions(nil); len(got) != 0 {
+		t.Errorf("empty mount table = %v", got)
+	}
+}
+
+func TestUnescapeMountField(t *testing.T) {
+	cases := map[string]string{
+		"/data":                   "/data",
+		`/mnt/my\040disk`:         "/mnt/my disk",
+		`/mnt/tab\011and\134back`: "/mnt/tab\tand\\back",
+		// 无效的转义原样保留
+		`/mnt/a\09b`:  `/mnt/a\09b`,
+		`/mnt/end\04`: `/mnt/end\04`,
+		`/mnt/\`:      `/mnt/\`,
+	}
+	for field, want := range cases {
+		if got := unescapeMountField(field); got != want {
+			t.Errorf("unescapeMountField(%q) = %q, want %q", field, got, want)
+		}
+	}
+}
+
+func TestDiskInfoReadOnlyMounts(t *testing.T) {
+	useFakeDisk(t, &fakeDiskProvider{
+		// 分区枚举的结果会缓存，挂载选项可能已过时
+		partitions: []PartitionStat{
+			{Device: "/dev/sda1", Mountpoint: "/", Fstype: "ext4", Opts: []string{"rw"}},
+			{Device: "/dev/sdb1", Mountpoint: "/data", Fstype: "xfs", Opts: []string{"rw"}},
+			{Device: "/dev/sr0", Mountpoint: "/media/cdrom", Fstype: "iso9660", Opts: []string{"ro"}},
+			{Device: "/dev/sdf1", Mountpoint: "/backup", Fstype: "ext4", Opts: []string{"ro", "relatime"}},
+		},
+		usage: map[string]UsageStat{
+			"/":            {Total: 100 * gb, Used: 10 * gb, UsedPercent: 10},
+			"/data":        {Total: 100 * gb, Used: 50 * gb, UsedPercent: 50},
+			"/media/cdrom": {Total: gb, Used: gb, UsedPercent: 100},
+			"/backup":      {Total: 100 * gb, Used: 20 * gb, UsedPercent: 20},
+		},
+	})
+	mounts := writeTree(t, map[string]string{"mounts": procMountsFixture})
+
+	tool := NewDiskTool(storage.NewMemoryCache(), CacheOptions{}, newPartitionFilter(platformLinux, nil, nil, nil, nil), NewOutputStyle(StylePlain, 0), nil)
+	tool.platform, tool.mountsPath = platformLinux, filepath.Join(mounts, "mounts")
+	info, err := tool.GetDiskData(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 挂载表中的选项优先；挂载表中没有的分区使用枚举时的选项
+	got := make(map[string]types.DiskPartition)
+	for _, partition := range info.Partitions {
+		got[partition.Mountpoint] = partition
+	}
+	cases := []struct {
+		mountpoint         string
+		readOnly, expected bool
+	}{
+		{"/", false, false},
+		{"/data", true, false},
+		{"/media/cdrom", true, true},
+		{"/backup", true, false},
+	}
+	for _, c := range cases {
+		if p := got[c.mountpoint]; p.ReadOnly != c.readOnly || p.ExpectedReadOnly != c.expected {
+			t.Errorf("%s: read only %v, expected %v; want %v, %v", c.mountpoint, p.ReadOnly, p.ExpectedReadOnly, c.readOnly, c.expected)
+		}
+	}
+	if opts := got["/data"].Opts; len(opts) == 0 || opts[0] != "ro" {
+		t.Errorf("/data opts = %v, want the mount table's", opts)
+	}
+
+	text, err := tool.Execute(context.Background(), map[string]interface{}{"cache": "fresh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 分区按枚举顺序列出
+	if !strings.Contains(text, "🚨 2 个分区意外以只读方式挂载: /data, /backup\n") && !strings.Contains(text, "🚨 2 个分区意外以只读方式挂载: /backup, /data\n") {
+		t.Errorf("output lacks the read-only summary:\n%s", text)
+	}
+	for _, want := range []string{
+		"  🔒 只读挂载（属于正常情况）\n",
+		"  🚨 只读挂载（意外）\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output lacks %q:\n%s", want, text)
+		}
+	}
+
+	// 挂载表变化后（问题修复并重新挂载为读写）下次采集即生效
+	fixed := strings.Replace(procMountsFixture, "/data xfs ro,", "/data xfs rw,", 1) + "/dev/sdf1 /backup ext4 rw 0 0\n"
+	if err := os.WriteFile(tool.mountsPath, []byte(fixed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"cache": "fresh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(text, "意外") {
+		t.Errorf("remounted read-write partitions still flagged:\n%s", text)
+	}
+
+	// 其他平台不读取挂载表
+	tool.platform = platformDarwin
+	if err := os.WriteFile(tool.mountsPath, []byte(procMountsFixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info, err = tool.GetDiskData(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, partition := range info.Partitions {
+		if partition.Mountpoint == "/data" && partition.ReadOnly {
+			t.Error("darwin read /proc/mounts")
+		}
+	}
+}
+
+func TestDiskChecksReadOnly(t *testing.T) {
+	checks := diskChecks([]types.DiskPartition{
+		{Mountpoint: "/", UsedPercent: 10},
+		{Mountpoint: "/data", UsedPercent: 50, ReadOnly: true},
+		{Mountpoint: "/media/cdrom", UsedPercent: 100, ReadOnly: true, ExpectedReadOnly: true},
+	}, types.Threshold{Warning: 80, Critical: 90})
+
+	// 意外的只读挂载为严重，正常只读的光盘只按使用率检查
+	summary := evaluateChecks(checks)
+	var findings []string
+	for _, finding := range summary.Findings {
+		findings = append(findings, finding.Metric+":"+finding.Selector+":"+finding.Severity)
+	}
+	want := []string{"disk_read_only:/data:critical", "disk_percent:/media/cdrom:critical"}
+	if !reflect.DeepEqual(findings, want) || summary.Status != healthCritical || len(checks) != 4 {
+		t.Errorf("findings = %v, status %s, %d checks", findings, summary.Status, len(checks))
+	}
+}
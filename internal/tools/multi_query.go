@@ -0,0 +1,304 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/types"
+)
+
+// multiQueryToolName 组合查询工具的名称，不允许在查询中调用自身
+const multiQueryToolName = "multi_query"
+
+// maxMultiQueries 单次组合查询最多包含的子查询数
+const maxMultiQueries = 10
+
+// ToolCallFunc 在服务器内部调用工具，执行与 tools/call 相同的策略检查和参数校验
+type ToolCallFunc func(ctx context.Context, name string, args map[string]interface{}) (string, interface{}, error)
+
+type multiQueryKey struct{}
+
+// subQuery 组合查询中的一个子查询
+type subQuery struct {
+	Tool      string
+	Arguments map[string]interface{}
+}
+
+// subQueryResult 子查询的执行结果，Result 和 Error 只有一个非空
+type subQueryResult struct {
+	Tool       string           `json:"tool"`
+	DurationMs int64            `json:"duration_ms"`
+	Result     interface{}      `json:"result,omitempty"`
+	Error      *types.ToolError `json:"error,omitempty"`
+	text       string
+}
+
+// multiQueryReport JSON 输出，Results 的键为工具名，同一工具出现多次时依次为 name、name#2……
+type multiQueryReport struct {
+	Results   map[string]subQueryResult `json:"results"`
+	Succeeded int                       `json:"succeeded"`
+	Failed    int                       `json:"failed"`
+	Host      *types.HostIdentity       `json:"host,omitempty"`
+}
+
+// MultiQueryTool 组合查询工具，在一次调用中并发执行多个只读工具
+type MultiQueryTool struct {
+	call   ToolCallFunc
+	lookup ToolLookupFunc
+}
+
+// NewMultiQueryTool 创建新的组合查询工具，call 和 lookup 只应访问策略允许的工具
+func NewMultiQueryTool(call ToolCallFunc, lookup ToolLookupFunc) *MultiQueryTool {
+	return &MultiQueryTool{
+		call:   call,
+		lookup: lookup,
+	}
+}
+
+// GetName 获取工具名称
+func (mq *MultiQueryTool) GetName() string {
+	return multiQueryToolName
+}
+
+// GetDescription 获取工具描述
+func (mq *MultiQueryTool) GetDescription() string {
+	return "在一次调用中并发执行多个只读工具（如 cpu_info、memory_info、disk_info），单个工具失败不影响其他结果"
+}
+
+// GetAnnotations 获取工具注解
+func (mq *MultiQueryTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("组合查询")
+}
+
+// multiQueryArgs multi_query 的参数
+type multiQueryArgs struct {
+	strictArgs
+	Queries        []map[string]interface{} `arg:"queries,required" items:"子查询：tool 为工具名，arguments 为可选的参数对象" desc:"子查询列表，每项为 {\"tool\": 工具名, \"arguments\": 参数对象}，最多 10 项，只能包含只读工具"`
+	Format         string                   `arg:"format,enum=text|json,default=text" desc:"输出格式: text 按工具分段的文本, json 工具名到结构化结果的映射"`
+	TimeoutSeconds int                      `arg:"timeout_seconds,default=10,min=1,max=60" desc:"所有子查询共享的超时时间（秒），超时未完成的子查询返回 ERR_TIMEOUT"`
+	fieldsArgs
+}
+
+// GetInputSchema 获取输入模式
+func (mq *MultiQueryTool) GetInputSchema() types.InputSchema {
+	return argsSchema(multiQueryArgs{})
+}
+
+// Examples 获取调用示例
+func (mq *MultiQueryTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "一次获取 CPU、内存和磁盘概况",
+			Arguments: map[string]interface{}{"queries": []interface{}{
+				map[string]interface{}{"tool": "cpu_info"},
+				map[string]interface{}{"tool": "memory_info", "arguments": map[string]interface{}{"compact": "true"}},
+				map[string]interface{}{"tool": "disk_info"},
+			}},
+		},
+		{
+			Description: "以 JSON 返回进程排行和网络统计",
+			Arguments: map[string]interface{}{
+				"queries": []interface{}{
+					map[string]interface{}{"tool": "top_processes", "arguments": map[string]interface{}{"limit": "5"}},
+					map[string]interface{}{"tool": "network_stats"},
+				},
+				"format": "json",
+			},
+		},
+	}
+}
+
+// Execute 并发执行所有子查询
+func (mq *MultiQueryTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	if ctx.Value(multiQueryKey{}) != nil {
+		return "", badArgument("multi_query 不能嵌套调用")
+	}
+
+	var a multiQueryArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+	queries, err := mq.parseQueries(a.Queries)
+	if err != nil {
+		return "", err
+	}
+
+	results := mq.run(ctx, queries, a.Format == "json", time.Duration(a.TimeoutSeconds)*time.Second)
+
+	if a.Format == "json" {
+		report := multiQueryReport{Results: make(map[string]subQueryResult, len(results)), Host: identity.Get()}
+		for i, result := range results {
+			report.Results[resultKey(results[:i], result.Tool)] = result
+			if result.Error != nil {
+				report.Failed++
+			} else {
+				report.Succeeded++
+			}
+		}
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", wrapError("序列化组合查询结果失败", err)
+		}
+		return string(jsonData), nil
+	}
+
+	return formatMultiQuery(results), nil
+}
+
+// parseQueries 解析并检查子查询列表：工具名不能为空、不能调用自身，且必须是只读工具
+func (mq *MultiQueryTool) parseQueries(items []map[string]interface{}) ([]subQuery, error) {
+	if len(items) == 0 {
+		return nil, argumentError("queries", "queries 不能为空")
+	}
+	if len(items) > maxMultiQueries {
+		return nil, argumentError("queries", "子查询最多 %d 项，当前 %d 项", maxMultiQueries, len(items))
+	}
+
+	queries := make([]subQuery, 0, len(items))
+	for i, object := range items {
+		name, _ := object["tool"].(string)
+		if name == "" {
+			return nil, argumentError("queries", "queries[%d] 缺少 tool", i)
+		}
+		if name == multiQueryToolName {
+			return nil, argumentError("queries", "multi_query 不能调用自身（queries[%d]）", i)
+		}
+
+		arguments := map[string]interface{}{}
+		if raw, found := object["arguments"]; found && raw != nil {
+			var ok bool
+			if arguments, ok = raw.(map[string]interface{}); !ok {
+				return nil, argumentError("queries", "queries[%d].arguments 应为对象", i)
+			}
+		}
+
+		// 不存在或被禁用的工具在执行时作为该子查询的错误返回
+		if tool, found := mq.lookup(name); found && tool.Annotations != nil && !tool.Annotations.ReadOnlyHint {
+			return nil, argumentError("queries", "multi_query 只能组合只读工具: %s", name)
+		}
+		queries = append(queries, subQuery{Tool: name, Arguments: arguments})
+	}
+	return queries, nil
+}
+
+// run 在共享的超时时间内并发执行子查询，结果顺序与 queries 相同
+func (mq *MultiQueryTool) run(ctx context.Context, queries []subQuery, structured bool, timeout time.Duration) []subQueryResult {
+	ctx, cancel := context.WithTimeout(context.WithValue(ctx, multiQueryKey{}, true), timeout)
+	defer cancel()
+
+	results := make([]subQueryResult, len(queries))
+	var completed sync.WaitGroup
+	var progressMutex sync.Mutex
+	done := 0
+	for i, query := range queries {
+		completed.Add(1)
+		go func(i int, query subQuery) {
+			defer completed.Done()
+			results[i] = mq.runQuery(ctx, query, structured, timeout)
+
+			progressMutex.Lock()
+			defer progressMutex.Unlock()
+			done++
+			reportProgress(ctx, float64(done), float64(len(queries)), query.Tool+" 完成")
+		}(i, query)
+	}
+	completed.Wait()
+
+	return results
+}
+
+// runQuery 执行单个子查询，失败时记录结构化错误而不是中断整个调用
+func (mq *MultiQueryTool) runQuery(ctx context.Context, query subQuery, structured bool, timeout time.Duration) subQueryResult {
+	arguments := query.Arguments
+	if structured {
+		arguments = mq.jsonArguments(query)
+	}
+
+	start := time.Now()
+	text, data, err := mq.call(ctx, query.Tool, arguments)
+	result := subQueryResult{Tool: query.Tool, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		toolErr := ClassifyError(err)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			toolErr = TimeoutError(timeout, err)
+		}
+		result.Error = &types.ToolError{Code: string(toolErr.Code), Message: toolErr.Error(), Hint: toolErr.Hint}
+		return result
+	}
+
+	result.text = text
+	switch {
+	case data != nil:
+		result.Result = data
+	case json.Valid([]byte(text)):
+		result.Result = json.RawMessage(text)
+	default:
+		result.Result = text
+	}
+	return result
+}
+
+// jsonArguments 工具支持 format=json 且调用方未指定格式时，以 JSON 格式调用以获得结构化结果
+func (mq *MultiQueryTool) jsonArguments(query subQuery) map[string]interface{} {
+	if _, specified := query.Arguments["format"]; specified {
+		return query.Arguments
+	}
+	tool, found := mq.lookup(query.Tool)
+	if !found || !slices.Contains(tool.InputSchema.Properties["format"].Enum, "json") {
+		return query.Arguments
+	}
+
+	arguments := make(map[string]interface{}, len(query.Arguments)+1)
+	for name, value := range query.Arguments {
+		arguments[name] = value
+	}
+	arguments["format"] = "json"
+	return arguments
+}
+
+// resultKey JSON 输出中的结果键，同一工具再次出现时加上序号
+func resultKey(previous []subQueryResult, tool string) string {
+	count := 1
+	for _, result := range previous {
+		if result.Tool == tool {
+			count++
+		}
+	}
+	if count == 1 {
+		return tool
+	}
+	return fmt.Sprintf("%s#%d", tool, count)
+}
+
+// formatMultiQuery 按子查询顺序拼接各工具的文本输出
+func formatMultiQuery(results []subQueryResult) string {
+	var result string
+	failed := 0
+
+	for i, query := range results {
+		result += fmt.Sprintf("📦 [%d/%d] %s（%d ms）\n", i+1, len(results), query.Tool, query.DurationMs)
+		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		if query.Error != nil {
+			failed++
+			result += fmt.Sprintf("❌ %s\n错误代码: %s\n", query.Error.Message, query.Error.Code)
+			if query.Error.Hint != "" {
+				result += "💡 " + query.Error.Hint + "\n"
+			}
+		} else {
+			result += query.text
+			if len(query.text) > 0 && query.text[len(query.text)-1] != '\n' {
+				result += "\n"
+			}
+		}
+		result += "\n"
+	}
+
+	result += fmt.Sprintf("📋 共 %d 个查询：成功 %d，失败 %d\n", len(results), len(results)-failed, failed)
+	return result
+}
@@ -0,0 +1,193 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+// funcTool 由函数实现的工具，用于组合查询的测试
+type funcTool struct {
+	name    string
+	execute func(ctx context.Context, args map[string]interface{}) (string, interface{}, error)
+}
+
+func (ft *funcTool) GetName() string        { return ft.name }
+func (ft *funcTool) GetDescription() string { return ft.name }
+func (ft *funcTool) GetInputSchema() types.InputSchema {
+	return types.InputSchema{Type: "object", Properties: map[string]types.Property{
+		"format": {Type: "string", Enum: []string{"text", "json"}},
+	}}
+}
+func (ft *funcTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	text, _, err := ft.execute(ctx, args)
+	return text, err
+}
+
+// toolRegistry 组合查询测试使用的工具表，call 和 lookup 与 handler 中的行为一致：
+// 未声明注解的工具视为只读，结构化结果通过 StructuredTool 返回
+type toolRegistry map[string]types.MonitorTool
+
+func (tr toolRegistry) call(ctx context.Context, name string, args map[string]interface{}) (string, interface{}, error) {
+	tool, found := tr[name]
+	if !found {
+		return "", nil, ToolNotFound(name, nil)
+	}
+	if ft, ok := tool.(*funcTool); ok {
+		return ft.execute(ctx, args)
+	}
+	if structured, ok := tool.(types.StructuredTool); ok {
+		return structured.ExecuteStructured(ctx, args)
+	}
+	text, err := tool.Execute(ctx, args)
+	return text, nil, err
+}
+
+func (tr toolRegistry) lookup(name string) (types.Tool, bool) {
+	tool, found := tr[name]
+	if !found {
+		return types.Tool{}, false
+	}
+	annotations := types.ReadOnlyAnnotations(name)
+	if annotated, ok := tool.(types.AnnotatedTool); ok {
+		annotations = annotated.GetAnnotations()
+	}
+	return types.Tool{Name: name, InputSchema: tool.GetInputSchema(), Annotations: &annotations}, true
+}
+
+// newMultiQueryRegistry 包含成功、失败、超时的工具，会修改文件的 metrics_export，
+// 以及在内部再次调用 multi_query 的 proxy
+func newMultiQueryRegistry() (toolRegistry, *MultiQueryTool) {
+	registry := toolRegistry{
+		"cpu_info": &funcTool{name: "cpu_info", execute: func(_ context.Context, args map[string]interface{}) (string, interface{}, error) {
+			return fmt.Sprintf("CPU 25%% (format=%v)", args["format"]), map[string]interface{}{"usage": 25}, nil
+		}},
+		"disk_info": &funcTool{name: "disk_info", execute: func(context.Context, map[string]interface{}) (string, interface{}, error) {
+			return "", nil, fmt.Errorf("statfs /mnt/secret: %w", fs.ErrPermission)
+		}},
+		"slow": &funcTool{name: "slow", execute: func(ctx context.Context, _ map[string]interface{}) (string, interface{}, error) {
+			<-ctx.Done()
+			return "", nil, ctx.Err()
+		}},
+		"metrics_export": NewMetricsExportTool(storage.NewMemoryStorage()),
+	}
+	multiQuery := NewMultiQueryTool(registry.call, registry.lookup)
+	registry[multiQueryToolName] = multiQuery
+	registry["proxy"] = &funcTool{name: "proxy", execute: func(ctx context.Context, _ map[string]interface{}) (string, interface{}, error) {
+		return registry.call(ctx, multiQueryToolName, map[string]interface{}{"queries": []interface{}{map[string]interface{}{"tool": "cpu_info"}}})
+	}}
+	return registry, multiQuery
+}
+
+// queries 由工具名构造子查询列表
+func queries(names ...string) []interface{} {
+	items := make([]interface{}, len(names))
+	for i, name := range names {
+		items[i] = map[string]interface{}{"tool": name}
+	}
+	return items
+}
+
+func TestMultiQueryPartialFailure(t *testing.T) {
+	_, multiQuery := newMultiQueryRegistry()
+
+	text, err := multiQuery.Execute(context.Background(), map[string]interface{}{
+		"queries":         queries("cpu_info", "disk_info", "missing", "slow", "cpu_info"),
+		"format":          "json",
+		"timeout_seconds": 1,
+	})
+	if err != nil {
+		t.Fatalf("a failing sub-query failed the whole call: %v", err)
+	}
+	var report struct {
+		Results   map[string]subQueryResult `json:"results"`
+		Succeeded int                       `json:"succeeded"`
+		Failed    int                       `json:"failed"`
+	}
+	if err := json.Unmarshal([]byte(text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Succeeded != 2 || report.Failed != 3 || len(report.Results) != 5 {
+		t.Fatalf("report = %+v, want 2 succeeded and 3 failed under 5 keys", report)
+	}
+	// 同一工具的第二次调用使用带序号的键；JSON 输出时以 format=json 调用支持的工具，得到结构化结果
+	for _, key := range []string{"cpu_info", "cpu_info#2"} {
+		if result := report.Results[key]; result.Error != nil || fmt.Sprint(result.Result) != "map[usage:25]" {
+			t.Errorf("%s = %+v, want the structured result", key, result)
+		}
+	}
+	for key, code := range map[string]ErrorCode{"disk_info": ErrPermission, "missing": ErrNotFound, "slow": ErrTimeout} {
+		if result := report.Results[key]; result.Error == nil || result.Error.Code != string(code) {
+			t.Errorf("%s = %+v, want error %s", key, result, code)
+		}
+	}
+
+	text, err = multiQuery.Execute(context.Background(), map[string]interface{}{"queries": queries("cpu_info", "disk_info")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"[1/2] cpu_info", "CPU 25% (format=<nil>)", "[2/2] disk_info", "错误代码: ERR_PERMISSION", "成功 1，失败 1"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text output lacks %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestMultiQueryRejectsRecursion(t *testing.T) {
+	registry, multiQuery := newMultiQueryRegistry()
+
+	_, err := multiQuery.Execute(context.Background(), map[string]interface{}{"queries": queries("cpu_info", multiQueryToolName)})
+	var toolErr *Error
+	if !errors.As(err, &toolErr) || toolErr.Code != ErrBadArgument || !strings.Contains(toolErr.Message, "不能调用自身") {
+		t.Fatalf("multi_query calling itself = %v, want ErrBadArgument", err)
+	}
+
+	// 子查询中的工具再间接调用 multi_query 时，内层调用失败，外层返回该子查询的错误
+	text, err := multiQuery.Execute(context.Background(), map[string]interface{}{"queries": queries("proxy"), "format": "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report multiQueryReport
+	if err := json.Unmarshal([]byte(text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if result := report.Results["proxy"]; result.Error == nil || result.Error.Code != string(ErrBadArgument) || !strings.Contains(result.Error.Message, "不能嵌套") {
+		t.Fatalf("nested multi_query = %+v, want a bad-argument error", result)
+	}
+	if _, _, err := registry.call(context.Background(), "proxy", nil); err != nil {
+		t.Fatalf("proxy outside multi_query = %v, want the nested call to succeed", err)
+	}
+}
+
+func TestMultiQueryOnlyCombinesReadOnlyTools(t *testing.T) {
+	_, multiQuery := newMultiQueryRegistry()
+
+	// metrics_export 在 file 模式下写入和删除文件，不是只读工具
+	_, err := multiQuery.Execute(context.Background(), map[string]interface{}{"queries": queries("cpu_info", "metrics_export")})
+	var toolErr *Error
+	if !errors.As(err, &toolErr) || toolErr.Code != ErrBadArgument || !strings.Contains(toolErr.Message, "只读工具: metrics_export") {
+		t.Fatalf("multi_query with metrics_export = %v, want it rejected as not read-only", err)
+	}
+
+	for _, c := range []struct {
+		items []interface{}
+		want  string
+	}{
+		{nil, "不能为空"},
+		{queries("cpu_info", "cpu_info", "cpu_info", "cpu_info", "cpu_info", "cpu_info", "cpu_info", "cpu_info", "cpu_info", "cpu_info", "cpu_info"), "最多 10 项"},
+		{[]interface{}{map[string]interface{}{"arguments": map[string]interface{}{}}}, "缺少 tool"},
+		{[]interface{}{map[string]interface{}{"tool": "cpu_info", "arguments": "format=json"}}, "应为对象"},
+	} {
+		_, err := multiQuery.Execute(context.Background(), map[string]interface{}{"queries": c.items})
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("queries %v = %v, want an error containing %q", c.items, err, c.want)
+		}
+	}
+}
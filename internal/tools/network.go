@@ -1,23 +1,60 @@
 package tools
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
 	"time"
 
+	"mcp-example/internal/identity"
+	"mcp-example/internal/permissions"
 	"mcp-example/internal/types"
-
-	"github.com/shirou/gopsutil/v3/net"
 )
 
+// defaultConnectionLimit 默认最多显示的连接详情数量
+const defaultConnectionLimit = 20
+
+// connectionQuery 连接过滤条件和详情数量限制
+type connectionQuery struct {
+	State     string
+	LocalPort uint32
+	Limit     int
+}
+
+// networkReport JSON 输出，同时作为 structuredContent 返回
+type networkReport struct {
+	types.NetworkInfo
+	Host *types.HostIdentity `json:"host,omitempty"`
+	// Fallback 实时采集失败时返回降级数据的说明
+	Fallback *fallbackInfo `json:"fallback,omitempty"`
+	collectionInfo
+}
+
 // NetworkTool 网络监控工具
 type NetworkTool struct {
-	cache types.Cache
+	cache        types.Cache
+	cacheOptions CacheOptions
+	rateWindow   time.Duration
+	// permissions 服务器进程的权限探测结果，用于说明无法确定所属进程的连接
+	permissions func() *types.PermissionProfile
+	// storage 保存各接口计数器的起点记录，historyMutex 保证并发调用时记录的读取和更新不交错
+	storage      types.DataStorage
+	historyMutex sync.Mutex
 }
 
-// NewNetworkTool 创建新的网络监控工具
-func NewNetworkTool(cache types.Cache) *NetworkTool {
+// NewNetworkTool 创建新的网络监控工具，rateWindow 内的上一次调用用于计算平均速率，
+// storage 用于记录各接口计数器的起点和重置次数，为 nil 时不记录
+func NewNetworkTool(cache types.Cache, cacheOptions CacheOptions, rateWindow time.Duration, storage types.DataStorage) *NetworkTool {
 	return &NetworkTool{
-		cache: cache,
+		cache:        cache,
+		cacheOptions: cacheOptions,
+		rateWindow:   rateWindow,
+		permissions:  permissions.Get,
+		storage:      storage,
 	}
 }
 
@@ -31,80 +68,189 @@ func (nt *NetworkTool) GetDescription() string {
 	return "获取网络连接状态和传输速度"
 }
 
+// GetAnnotations 获取工具注解
+func (nt *NetworkTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("网络统计")
+}
+
+// networkArgs network_stats 的参数。conn_state 中 UDP 等无状态连接为 NONE
+type networkArgs struct {
+	ShowConnections bool   `arg:"show_connections,default=false" desc:"是否显示连接详情"`
+	InterfaceFilter string `arg:"interface_filter" desc:"网络接口过滤器（为空则显示所有）"`
+	ConnLimit       int    `arg:"conn_limit,default=20,min=1,max=500" desc:"最多显示的连接详情数量，超出时按 LISTEN、ESTABLISHED、其他状态的顺序保留"`
+	ConnState       string `arg:"conn_state,enum=LISTEN|ESTABLISHED|SYN_SENT|SYN_RECV|FIN_WAIT1|FIN_WAIT2|TIME_WAIT|CLOSE|CLOSE_WAIT|LAST_ACK|CLOSING|NONE" desc:"只统计该状态的连接"`
+	LocalPort       int    `arg:"local_port,min=1,max=65535" desc:"只统计该本地端口的连接"`
+	tableFormatArgs
+	fallbackArgs
+	cacheArgs
+	diffArgs
+}
+
 // GetInputSchema 获取输入模式
 func (nt *NetworkTool) GetInputSchema() types.InputSchema {
-	return types.InputSchema{
-		Type: "object",
-		Properties: map[string]types.Property{
-			"show_connections": {
-				Type:        "string",
-				Description: "是否显示连接详情",
-				Enum:        []string{"true", "false"},
-				Default:     "false",
-			},
-			"interface_filter": {
-				Type:        "string",
-				Description: "网络接口过滤器（为空则显示所有）",
-				Default:     "",
-			},
-			"use_cache": {
-				Type:        "string",
-				Description: "是否使用缓存数据",
-				Enum:        []string{"true", "false"},
-				Default:     "false",
-			},
+	return argsSchema(networkArgs{})
+}
+
+// Examples 获取调用示例
+func (nt *NetworkTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "各网络接口的收发字节数和速率",
+			Arguments:   map[string]interface{}{},
+		},
+		{
+			Description: "只看 eth0 接口，并附带连接状态统计",
+			Arguments:   map[string]interface{}{"show_connections": "true", "interface_filter": "eth0"},
+		},
+		{
+			Description: "处于 ESTABLISHED 状态、本地端口为 443 的前 50 个连接，JSON 格式",
+			Arguments:   map[string]interface{}{"show_connections": "true", "conn_state": "ESTABLISHED", "local_port": 443, "conn_limit": 50, "format": "json"},
 		},
 	}
 }
 
 // Execute 执行网络监控
-func (nt *NetworkTool) Execute(args map[string]interface{}) (string, error) {
-	// 解析参数
-	showConnStr, _ := args["show_connections"].(string)
-	showConnections := showConnStr == "true"
-
-	interfaceFilter, _ := args["interface_filter"].(string)
-
-	useCacheStr, _ := args["use_cache"].(string)
-	useCache := useCacheStr == "true"
-
-	// 检查缓存
-	cacheKey := fmt.Sprintf("network_stats_%t_%s", showConnections, interfaceFilter)
-	if useCache {
-		if cachedData, found := nt.cache.Get(cacheKey); found {
-			if netInfo, ok := cachedData.(types.NetworkInfo); ok {
-				return nt.formatNetworkInfo(netInfo, showConnections), nil
+func (nt *NetworkTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	text, _, err := nt.ExecuteStructured(ctx, args)
+	return text, err
+}
+
+// ExecuteStructured 执行网络监控，同时返回与 JSON 输出相同的结构化结果
+func (nt *NetworkTool) ExecuteStructured(ctx context.Context, args map[string]interface{}) (string, interface{}, error) {
+	var a networkArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", nil, err
+	}
+	query := connectionQuery{Limit: a.ConnLimit, State: a.ConnState, LocalPort: uint32(a.LocalPort)}
+
+	// 获取网络信息（缓存10秒）
+	netInfo, meta, err := withCache(ctx, nt.cache, nt.cacheOptions.forCall(args), nt.GetName(), 10*time.Second, func(ctx context.Context) (types.NetworkInfo, error) {
+		return nt.getNetworkInfo(ctx, a.ShowConnections, a.InterfaceFilter, query)
+	})
+	if err != nil {
+		return "", nil, wrapError("获取网络信息失败", err)
+	}
+
+	// 缓存数据不是新采样，不参与速率计算，也不更新计数器起点记录
+	var rates map[string]interfaceRate
+	if !meta.Cached {
+		rates = nt.updateRates(netInfo)
+	}
+	netInfo = nt.annotateCounters(netInfo, !meta.Cached)
+
+	report := networkReport{NetworkInfo: netInfo, Host: identity.Get(), Fallback: newFallbackInfo(meta), collectionInfo: newCollectionInfo(meta)}
+	if a.Format == "json" {
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", nil, wrapError("序列化网络信息失败", err)
+		}
+		return string(jsonData), report, nil
+	}
+	if isTableFormat(a.Format) {
+		text, err := renderTable(interfaceTable(netInfo.Interfaces, rates), a.Format)
+		return text, report, err
+	}
+
+	return cacheHeader(meta) + nt.formatNetworkInfo(netInfo, rates, a.ShowConnections) + cacheNote(meta), report, nil
+}
+
+// updateRates 将本次计数保存到缓存，并与 rateWindow 内的上一次采样比较得到各接口的平均速率
+func (nt *NetworkTool) updateRates(netInfo types.NetworkInfo) map[string]interfaceRate {
+	if nt.rateWindow <= 0 {
+		return nil
+	}
+
+	rates := make(map[string]interfaceRate)
+	for _, iface := range netInfo.Interfaces {
+		key := rateCacheKey(iface.Name)
+		curr := counterSample{
+			BytesSent: iface.BytesSent,
+			BytesRecv: iface.BytesRecv,
+			Timestamp: netInfo.LastUpdated,
+		}
+
+		if cached, found := nt.cache.Get(key); found {
+			if prev, ok := cached.(counterSample); ok && curr.Timestamp.Sub(prev.Timestamp) <= nt.rateWindow {
+				if rate, ok := computeRate(prev, curr); ok {
+					rates[iface.Name] = rate
+				}
 			}
 		}
+
+		nt.cache.Set(key, curr, nt.rateWindow)
 	}
 
-	// 获取网络信息
-	netInfo, err := nt.getNetworkInfo(showConnections, interfaceFilter)
-	if err != nil {
-		return "", fmt.Errorf("获取网络信息失败: %v", err)
+	return rates
+}
+
+// annotateCounters 为各接口附加计数器起点信息（首次看到的时间、重置次数、最近一次重置），
+// update 为 true 时先用本次采样更新存储中的记录。返回的接口列表是副本，不修改缓存中的数据
+func (nt *NetworkTool) annotateCounters(netInfo types.NetworkInfo, update bool) types.NetworkInfo {
+	if nt.storage == nil || len(netInfo.Interfaces) == 0 {
+		return netInfo
+	}
+
+	nt.historyMutex.Lock()
+	defer nt.historyMutex.Unlock()
+
+	histories := make(map[string]counterHistory)
+	if nt.storage.Exists(counterHistoryKey) {
+		if err := nt.storage.Load(counterHistoryKey, &histories); err != nil {
+			slog.Debug("读取网络计数器起点记录失败", "error", err)
+		}
+	}
+
+	interfaces := make([]types.NetworkInterface, len(netInfo.Interfaces))
+	copy(interfaces, netInfo.Interfaces)
+	for i := range interfaces {
+		iface := &interfaces[i]
+		history, found := histories[iface.Name]
+		if update {
+			var previous *counterHistory
+			if found {
+				previous = &history
+			}
+			history = observeCounters(previous, counterSample{
+				BytesSent: iface.BytesSent,
+				BytesRecv: iface.BytesRecv,
+				Timestamp: netInfo.LastUpdated,
+			})
+			histories[iface.Name] = history
+		} else if !found {
+			continue
+		}
+
+		firstSeen := history.FirstSeen.Timestamp
+		iface.FirstSeen = &firstSeen
+		iface.ResetCount = history.ResetCount
+		iface.LastResetAfter = history.LastResetAfter
 	}
 
-	// 缓存结果（缓存10秒）
-	nt.cache.Set(cacheKey, netInfo, 10*time.Second)
+	if update {
+		if err := nt.storage.Save(counterHistoryKey, histories); err != nil {
+			slog.Debug("保存网络计数器起点记录失败", "error", err)
+		}
+	}
 
-	return nt.formatNetworkInfo(netInfo, showConnections), nil
+	netInfo.Interfaces = interfaces
+	return netInfo
 }
 
 // getNetworkInfo 获取网络信息
-func (nt *NetworkTool) getNetworkInfo(showConnections bool, interfaceFilter string) (types.NetworkInfo, error) {
+func (nt *NetworkTool) getNetworkInfo(ctx context.Context, showConnections bool, interfaceFilter string, query connectionQuery) (types.NetworkInfo, error) {
 	var netInfo types.NetworkInfo
 
 	// 获取网络接口统计
-	netStats, err := net.IOCounters(true)
+	netStats, err := providers.Net.IOCounters(ctx, true)
 	if err != nil {
-		return netInfo, fmt.Errorf("获取网络接口统计失败: %v", err)
+		return netInfo, fmt.Errorf("获取网络接口统计失败: %w", err)
 	}
 
 	// 过滤网络接口
-	var filteredStats []net.IOCountersStat
+	var filteredStats []NetIOCountersStat
 	for _, stat := range netStats {
 		// 跳过回环接口
-		if stat.Name == "lo" || stat.Name == "lo0" {
+		if isLoopbackInterface(hostPlatform, stat.Name) {
 			continue
 		}
 
@@ -116,6 +262,11 @@ func (nt *NetworkTool) getNetworkInfo(showConnections bool, interfaceFilter stri
 		filteredStats = append(filteredStats, stat)
 	}
 
+	// 按接口名称排序，保证输出顺序稳定
+	sort.Slice(filteredStats, func(i, j int) bool {
+		return filteredStats[i].Name < filteredStats[j].Name
+	})
+
 	// 转换为内部类型
 	for _, stat := range filteredStats {
 		netInterface := types.NetworkInterface{
@@ -134,9 +285,9 @@ func (nt *NetworkTool) getNetworkInfo(showConnections bool, interfaceFilter stri
 
 	// 获取网络连接信息
 	if showConnections {
-		connections, err := net.Connections("all")
+		connections, err := providers.Net.Connections(ctx, "all")
 		if err == nil {
-			netInfo.Connections = nt.processConnections(connections)
+			netInfo.Connections = processConnections(connections, query)
 		}
 	}
 
@@ -145,15 +296,25 @@ func (nt *NetworkTool) getNetworkInfo(showConnections bool, interfaceFilter stri
 	return netInfo, nil
 }
 
-// processConnections 处理网络连接信息
-func (nt *NetworkTool) processConnections(connections []net.ConnectionStat) types.NetworkConnections {
+// processConnections 统计符合过滤条件的连接，并按相关性排序后保留最多 query.Limit 条详情。
+// 返回值中 Total 为符合条件的连接总数，Shown/Truncated 说明详情是否被截断。
+func processConnections(connections []ConnectionStat, query connectionQuery) types.NetworkConnections {
 	var netConn types.NetworkConnections
 
-	netConn.Total = len(connections)
 	netConn.ByStatus = make(map[string]int)
 	netConn.ByProtocol = make(map[string]int)
 
+	var details []types.ConnectionDetail
 	for _, conn := range connections {
+		if query.State != "" && conn.Status != query.State {
+			continue
+		}
+		if query.LocalPort != 0 && conn.Laddr.Port != query.LocalPort {
+			continue
+		}
+
+		netConn.Total++
+
 		// 按状态统计
 		netConn.ByStatus[conn.Status]++
 
@@ -161,80 +322,177 @@ func (nt *NetworkTool) processConnections(connections []net.ConnectionStat) type
 		protocol := fmt.Sprintf("%d-%d", conn.Type, conn.Family)
 		netConn.ByProtocol[protocol]++
 
-		// 添加连接详情（限制数量避免输出过多）
-		if len(netConn.Details) < 20 {
-			detail := types.ConnectionDetail{
-				Protocol:   protocol,
-				LocalIP:    conn.Laddr.IP,
-				LocalPort:  conn.Laddr.Port,
-				RemoteIP:   conn.Raddr.IP,
-				RemotePort: conn.Raddr.Port,
-				Status:     conn.Status,
-				PID:        conn.Pid,
-			}
-			netConn.Details = append(netConn.Details, detail)
+		// TIME_WAIT 等状态本来就没有所属进程，只统计监听和已建立的连接
+		if (conn.Status == "LISTEN" || conn.Status == "ESTABLISHED") && conn.Pid == 0 {
+			netConn.NoPID++
 		}
+
+		details = append(details, types.ConnectionDetail{
+			Protocol:   protocol,
+			LocalIP:    conn.Laddr.IP,
+			LocalPort:  conn.Laddr.Port,
+			RemoteIP:   conn.Raddr.IP,
+			RemotePort: conn.Raddr.Port,
+			Status:     conn.Status,
+			PID:        conn.Pid,
+		})
+	}
+
+	// 先排序再截断，保证保留的是最相关的连接且结果稳定
+	sortConnectionDetails(details)
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultConnectionLimit
+	}
+	if len(details) > limit {
+		details = details[:limit]
+		netConn.Truncated = true
 	}
+	netConn.Details = details
+	netConn.Shown = len(details)
 
 	return netConn
 }
 
-// formatNetworkInfo 格式化网络信息输出
-func (nt *NetworkTool) formatNetworkInfo(netInfo types.NetworkInfo, showConnections bool) string {
-	var result string
+// connectionStatusRank 连接状态的排序优先级：监听端口最重要，其次是已建立的连接
+func connectionStatusRank(status string) int {
+	switch status {
+	case "LISTEN":
+		return 0
+	case "ESTABLISHED":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// sortConnectionDetails 按状态优先级、本地端口、本地地址、远程地址和 PID 排序
+func sortConnectionDetails(details []types.ConnectionDetail) {
+	sort.Slice(details, func(i, j int) bool {
+		a, b := details[i], details[j]
+		if rankA, rankB := connectionStatusRank(a.Status), connectionStatusRank(b.Status); rankA != rankB {
+			return rankA < rankB
+		}
+		if a.Status != b.Status {
+			return a.Status < b.Status
+		}
+		if a.LocalPort != b.LocalPort {
+			return a.LocalPort < b.LocalPort
+		}
+		if a.LocalIP != b.LocalIP {
+			return a.LocalIP < b.LocalIP
+		}
+		if a.RemoteIP != b.RemoteIP {
+			return a.RemoteIP < b.RemoteIP
+		}
+		if a.RemotePort != b.RemotePort {
+			return a.RemotePort < b.RemotePort
+		}
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		return a.PID < b.PID
+	})
+}
+
+// 网络状态输出的表头，不随调用变化
+var (
+	interfaceHeader = fmt.Sprintf("%-15s %-12s %-12s %-12s %-12s %-8s %-8s",
+		"接口", "发送(MB)", "接收(MB)", "发送包数", "接收包数", "发送错误", "接收错误")
+	interfaceRateHeader = fmt.Sprintf(" %-14s %-14s", "发送速率", "接收速率")
+	connectionHeader    = fmt.Sprintf("%-10s %-15s %-6s %-15s %-6s %-12s\n",
+		"协议", "本地IP", "端口", "远程IP", "端口", "状态")
+)
+
+// networkLineSize 网络状态输出每行的预估字节数，用于预留输出缓冲区
+const networkLineSize = 100
+
+// formatNetworkInfo 格式化网络信息输出，rates 非空时增加距上次调用的平均速率列
+func (nt *NetworkTool) formatNetworkInfo(netInfo types.NetworkInfo, rates map[string]interfaceRate, showConnections bool) string {
+	lines := len(netInfo.Interfaces) + 20
+	if showConnections {
+		lines += len(netInfo.Connections.Details) + len(netInfo.Connections.ByStatus) + len(netInfo.Connections.ByProtocol)
+	}
+	result := getOutput(lines * networkLineSize)
 
-	result += "🌐 网络状态\n"
-	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result.WriteString("🌐 网络状态\n")
+	result.WriteString(separatorLine)
 
 	// 网络接口统计
 	if len(netInfo.Interfaces) > 0 {
-		result += "网络接口统计:\n"
-		result += fmt.Sprintf("%-15s %-12s %-12s %-12s %-12s %-8s %-8s\n",
-			"接口", "发送(MB)", "接收(MB)", "发送包数", "接收包数", "发送错误", "接收错误")
-		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		result.WriteString("网络接口统计:\n")
+		result.WriteString(interfaceHeader)
+		if len(rates) > 0 {
+			result.WriteString(interfaceRateHeader)
+		}
+		result.WriteString("\n")
+		result.WriteString(separatorLine)
 
+		var elapsed time.Duration
 		for _, iface := range netInfo.Interfaces {
-			result += fmt.Sprintf("%-15s %-12.2f %-12.2f %-12d %-12d %-8d %-8d\n",
+			fmt.Fprintf(result, "%-15s %-12.2f %-12.2f %-12s %-12s %-8s %-8s",
 				iface.Name,
 				float64(iface.BytesSent)/(1024*1024),
 				float64(iface.BytesRecv)/(1024*1024),
-				iface.PacketsSent,
-				iface.PacketsRecv,
-				iface.ErrorsOut,
-				iface.ErrorsIn,
+				formatCount(iface.PacketsSent),
+				formatCount(iface.PacketsRecv),
+				formatCount(iface.ErrorsOut),
+				formatCount(iface.ErrorsIn),
 			)
+			if len(rates) > 0 {
+				rate, found := rates[iface.Name]
+				switch {
+				case !found:
+					fmt.Fprintf(result, " %-14s %-14s", "-", "-")
+				case rate.CounterReset:
+					fmt.Fprintf(result, " %-14s %-14s", "计数器重置", "计数器重置")
+				default:
+					fmt.Fprintf(result, " %-14s %-14s",
+						formatBytes(uint64(rate.SendRate))+"/s",
+						formatBytes(uint64(rate.RecvRate))+"/s")
+				}
+				if found && rate.Elapsed > elapsed {
+					elapsed = rate.Elapsed
+				}
+			}
+			result.WriteString("\n")
+		}
+
+		if len(rates) > 0 {
+			fmt.Fprintf(result, "\n⏱️  速率为距上次调用 %s 内的平均值\n", elapsed.Round(time.Second))
 		}
+		result.WriteString(formatCounterOrigins(netInfo.Interfaces))
 	}
 
 	// 网络连接统计
 	if showConnections && netInfo.Connections.Total > 0 {
-		result += "\n🔗 网络连接统计:\n"
-		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
-		result += fmt.Sprintf("总连接数: %d\n", netInfo.Connections.Total)
+		result.WriteString("\n🔗 网络连接统计:\n")
+		result.WriteString(separatorLine)
+		fmt.Fprintf(result, "总连接数: %d\n", netInfo.Connections.Total)
 
 		if len(netInfo.Connections.ByStatus) > 0 {
-			result += "\n按状态分类:\n"
-			for status, count := range netInfo.Connections.ByStatus {
-				result += fmt.Sprintf("  %s: %d\n", status, count)
+			result.WriteString("\n按状态分类:\n")
+			for _, status := range sortedKeys(netInfo.Connections.ByStatus) {
+				fmt.Fprintf(result, "  %s: %d\n", status, netInfo.Connections.ByStatus[status])
 			}
 		}
 
 		if len(netInfo.Connections.ByProtocol) > 0 {
-			result += "\n按协议分类:\n"
-			for protocol, count := range netInfo.Connections.ByProtocol {
-				result += fmt.Sprintf("  %s: %d\n", protocol, count)
+			result.WriteString("\n按协议分类:\n")
+			for _, protocol := range sortedKeys(netInfo.Connections.ByProtocol) {
+				fmt.Fprintf(result, "  %s: %d\n", protocol, netInfo.Connections.ByProtocol[protocol])
 			}
 		}
 
 		// 显示部分连接详情
 		if len(netInfo.Connections.Details) > 0 {
-			result += "\n连接详情 (前20个):\n"
-			result += fmt.Sprintf("%-10s %-15s %-6s %-15s %-6s %-12s\n",
-				"协议", "本地IP", "端口", "远程IP", "端口", "状态")
-			result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+			result.WriteString("\n连接详情:\n")
+			result.WriteString(connectionHeader)
+			result.WriteString(separatorLine)
 
 			for _, detail := range netInfo.Connections.Details {
-				result += fmt.Sprintf("%-10s %-15s %-6d %-15s %-6d %-12s\n",
+				fmt.Fprintf(result, "%-10s %-15s %-6d %-15s %-6d %-12s\n",
 					detail.Protocol,
 					detail.LocalIP,
 					detail.LocalPort,
@@ -243,64 +501,192 @@ func (nt *NetworkTool) formatNetworkInfo(netInfo types.NetworkInfo, showConnecti
 					detail.Status,
 				)
 			}
+			if netInfo.Connections.Truncated {
+				fmt.Fprintf(result, "\n显示 %d / %d 个连接，可通过 conn_state、local_port 过滤或调大 conn_limit\n",
+					netInfo.Connections.Shown, netInfo.Connections.Total)
+			}
+		}
+
+		if netInfo.Connections.NoPID > 0 {
+			if profile := nt.permissions(); !profile.Privileged {
+				fmt.Fprintf(result, "\n⚠️ 以普通用户运行，%d 个监听或已建立的连接无法确定所属进程\n", netInfo.Connections.NoPID)
+			} else {
+				fmt.Fprintf(result, "\n⚠️ %d 个监听或已建立的连接没有可见的所属进程（可能属于其他 PID 命名空间）\n", netInfo.Connections.NoPID)
+			}
 		}
 	}
 
-	result += fmt.Sprintf("\n📅 更新时间: %s\n", netInfo.LastUpdated.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(result, "\n📅 更新时间: %s\n", netInfo.LastUpdated.Format("2006-01-02 15:04:05"))
 
-	return result
+	return putOutput(result)
+}
+
+// interfaceTable 网络接口统计的表格形式（csv、markdown 输出，不包含连接），rates 非空时增加速率列（字节/秒）
+func interfaceTable(interfaces []types.NetworkInterface, rates map[string]interfaceRate) table {
+	rows := table{columns: []tableColumn{
+		{"name", "接口"}, {"bytes_sent", "发送"}, {"bytes_recv", "接收"},
+		{"packets_sent", "发送包数"}, {"packets_recv", "接收包数"}, {"errors_out", "发送错误"}, {"errors_in", "接收错误"},
+	}}
+	if len(rates) > 0 {
+		rows.columns = append(rows.columns, tableColumn{"send_rate", "发送速率"}, tableColumn{"recv_rate", "接收速率"})
+	}
+
+	for _, iface := range interfaces {
+		cells := []tableCell{
+			textCell(iface.Name), bytesCell(iface.BytesSent), bytesCell(iface.BytesRecv),
+			countCell(iface.PacketsSent), countCell(iface.PacketsRecv), countCell(iface.ErrorsOut), countCell(iface.ErrorsIn),
+		}
+		if len(rates) > 0 {
+			rate, found := rates[iface.Name]
+			switch {
+			case !found:
+				cells = append(cells, tableCell{text: "-"}, tableCell{text: "-"})
+			case rate.CounterReset:
+				cells = append(cells, tableCell{text: "计数器重置"}, tableCell{text: "计数器重置"})
+			default:
+				cells = append(cells, rateCell(rate.SendRate), rateCell(rate.RecvRate))
+			}
+		}
+		rows.addRow(cells...)
+	}
+	return rows
+}
+
+// formatCounterOrigins 说明各接口的收发字节数从何时开始累计：从未检测到重置时为开机或接口启用以来，
+// 否则为最近一次重置以来，避免把重置后的小计数误读为长期流量
+func formatCounterOrigins(interfaces []types.NetworkInterface) string {
+	var result string
+	for _, iface := range interfaces {
+		if iface.FirstSeen == nil {
+			continue
+		}
+		observed := fmt.Sprintf("自 %s 起观察", iface.FirstSeen.Format("2006-01-02 15:04"))
+		if iface.LastResetAfter == nil {
+			result += fmt.Sprintf("  %s: 开机或接口启用以来的累计值（%s，未检测到计数器重置）\n", iface.Name, observed)
+		} else {
+			result += fmt.Sprintf("  %s: 自 %s 后的最近一次计数器重置以来的累计值（%s，共检测到 %d 次重置）\n",
+				iface.Name, iface.LastResetAfter.Format("2006-01-02 15:04"), observed, iface.ResetCount)
+		}
+	}
+	if result == "" {
+		return ""
+	}
+	return "\n📍 字节计数起点:\n" + result
 }
 
 // GetNetworkData 获取网络数据（供其他组件使用）
-func (nt *NetworkTool) GetNetworkData(showConnections bool, interfaceFilter string) (types.NetworkInfo, error) {
-	return nt.getNetworkInfo(showConnections, interfaceFilter)
+func (nt *NetworkTool) GetNetworkData(ctx context.Context, showConnections bool, interfaceFilter string) (types.NetworkInfo, error) {
+	return nt.getNetworkInfo(ctx, showConnections, interfaceFilter, connectionQuery{Limit: defaultConnectionLimit})
 }
 
 // GetNetworkSpeed 计算网络传输速度（需要两次采样）
-func (nt *NetworkTool) GetNetworkSpeed(interfaceName string, interval time.Duration) (float64, float64, error) {
-	// 第一次采样
-	stats1, err := net.IOCounters(true)
+func (nt *NetworkTool) GetNetworkSpeed(ctx context.Context, interfaceName string, interval time.Duration) (float64, float64, error) {
+	before, after, elapsed, err := sampleIOCounters(ctx, interval)
 	if err != nil {
-		return 0, 0, fmt.Errorf("获取第一次网络统计失败: %v", err)
+		return 0, 0, err
 	}
 
-	var stat1 *net.IOCountersStat
-	for _, stat := range stats1 {
-		if stat.Name == interfaceName {
-			stat1 = &stat
-			break
+	for _, bandwidth := range computeBandwidth(before, after, elapsed) {
+		if bandwidth.Name == interfaceName {
+			return bandwidth.SendRate, bandwidth.RecvRate, nil
 		}
 	}
 
-	if stat1 == nil {
-		return 0, 0, fmt.Errorf("找不到网络接口: %s", interfaceName)
+	return 0, 0, notFound("找不到网络接口: %s", interfaceName)
+}
+
+// ioCountersByName 获取所有接口的计数，按接口名称索引
+func ioCountersByName(ctx context.Context) (map[string]NetIOCountersStat, error) {
+	stats, err := providers.Net.IOCounters(ctx, true)
+	if err != nil {
+		return nil, err
 	}
 
-	// 等待间隔
-	time.Sleep(interval)
+	counters := make(map[string]NetIOCountersStat, len(stats))
+	for _, stat := range stats {
+		counters[stat.Name] = stat
+	}
+	return counters, nil
+}
+
+// sampleIOCounters 间隔 interval 对所有接口各采样一次，返回前后两次计数和实际间隔。
+// 等待期间每秒报告一次进度，ctx 取消时立即返回。
+func sampleIOCounters(ctx context.Context, interval time.Duration) (before, after map[string]NetIOCountersStat, elapsed time.Duration, err error) {
+	// 第一次采样
+	before, err = ioCountersByName(ctx)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("获取第一次网络统计失败: %w", err)
+	}
+	start := time.Now()
+
+	if err := waitInterval(ctx, interval); err != nil {
+		return nil, nil, 0, err
+	}
 
 	// 第二次采样
-	stats2, err := net.IOCounters(true)
+	after, err = ioCountersByName(ctx)
 	if err != nil {
-		return 0, 0, fmt.Errorf("获取第二次网络统计失败: %v", err)
+		return nil, nil, 0, fmt.Errorf("获取第二次网络统计失败: %w", err)
 	}
+	reportProgress(ctx, interval.Seconds(), interval.Seconds(), "采样完成")
 
-	var stat2 *net.IOCountersStat
-	for _, stat := range stats2 {
-		if stat.Name == interfaceName {
-			stat2 = &stat
-			break
+	return before, after, time.Since(start), nil
+}
+
+// waitInterval 等待两次采样之间的间隔，期间每秒报告一次进度，ctx 取消时立即返回其错误
+func waitInterval(ctx context.Context, interval time.Duration) error {
+	start := time.Now()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	total := interval.Seconds()
+	reportProgress(ctx, 0, total, "采样中")
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			reportProgress(ctx, math.Min(time.Since(start).Seconds(), total), total, "采样中")
+		case <-timer.C:
+			return nil
 		}
 	}
+}
 
-	if stat2 == nil {
-		return 0, 0, fmt.Errorf("找不到网络接口: %s", interfaceName)
+// Complete 为 interface_filter 参数补全网络接口名称
+func (nt *NetworkTool) Complete(ctx context.Context, argName, prefix string) []string {
+	if argName != "interface_filter" {
+		return nil
 	}
 
-	// 计算速度 (bytes/second)
-	seconds := interval.Seconds()
-	uploadSpeed := float64(stat2.BytesSent-stat1.BytesSent) / seconds
-	downloadSpeed := float64(stat2.BytesRecv-stat1.BytesRecv) / seconds
+	names, err := nt.interfaceNames(ctx)
+	if err != nil {
+		return nil
+	}
+
+	return matchPrefix(names, prefix)
+}
+
+// interfaceNames 获取网络接口名称列表（缓存 completionCacheTTL）
+func (nt *NetworkTool) interfaceNames(ctx context.Context) ([]string, error) {
+	names, _, err := withCache(ctx, nt.cache, CacheOptions{Mode: CacheModeAuto}, "completion_network_interfaces", completionCacheTTL, func(ctx context.Context) ([]string, error) {
+		stats, err := providers.Net.IOCounters(ctx, true)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(stats))
+		for _, stat := range stats {
+			names = append(names, stat.Name)
+		}
+		return names, nil
+	})
+	return names, err
+}
 
-	return uploadSpeed, downloadSpeed, nil
+// Prefetch 预取网络接口列表
+func (nt *NetworkTool) Prefetch(ctx context.Context) error {
+	_, err := nt.interfaceNames(ctx)
+	return err
 }
@@ -0,0 +1,233 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/types"
+)
+
+// maxBandwidthInterval 主动采样的最大间隔
+const maxBandwidthInterval = 10 * time.Second
+
+// interfaceBandwidth 一次主动采样中单个接口的吞吐量
+type interfaceBandwidth struct {
+	Name            string  `json:"name"`
+	SendRate        float64 `json:"send_bytes_per_sec"`
+	RecvRate        float64 `json:"recv_bytes_per_sec"`
+	PacketsSentRate float64 `json:"packets_sent_per_sec"`
+	PacketsRecvRate float64 `json:"packets_recv_per_sec"`
+	ErrorsIn        uint64  `json:"errors_in"`
+	ErrorsOut       uint64  `json:"errors_out"`
+	CounterReset    bool    `json:"counter_reset,omitempty"`
+}
+
+// totalRate 发送和接收速率之和
+func (ib interfaceBandwidth) totalRate() float64 {
+	return ib.SendRate + ib.RecvRate
+}
+
+// counterDelta 计算计数器差值，计数器变小（重置或回绕）时返回 false
+func counterDelta(prev, curr uint64) (uint64, bool) {
+	if curr < prev {
+		return 0, false
+	}
+	return curr - prev, true
+}
+
+// computeBandwidth 根据两次采样计算各接口吞吐量，按总速率降序排列（相同时按名称）。
+// 只出现在一次采样中的接口被忽略；任一计数变小的接口标记为计数器重置，速率和增量均为 0。
+func computeBandwidth(before, after map[string]NetIOCountersStat, elapsed time.Duration) []interfaceBandwidth {
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		return nil
+	}
+
+	var result []interfaceBandwidth
+	for name, curr := range after {
+		prev, ok := before[name]
+		if !ok {
+			continue
+		}
+
+		bandwidth := interfaceBandwidth{Name: name}
+		deltas := make([]uint64, 0, 6)
+		valid := true
+		for _, pair := range [][2]uint64{
+			{prev.BytesSent, curr.BytesSent},
+			{prev.BytesRecv, curr.BytesRecv},
+			{prev.PacketsSent, curr.PacketsSent},
+			{prev.PacketsRecv, curr.PacketsRecv},
+			{prev.Errin, curr.Errin},
+			{prev.Errout, curr.Errout},
+		} {
+			delta, ok := counterDelta(pair[0], pair[1])
+			if !ok {
+				valid = false
+				break
+			}
+			deltas = append(deltas, delta)
+		}
+
+		if !valid {
+			bandwidth.CounterReset = true
+		} else {
+			bandwidth.SendRate = float64(deltas[0]) / seconds
+			bandwidth.RecvRate = float64(deltas[1]) / seconds
+			bandwidth.PacketsSentRate = float64(deltas[2]) / seconds
+			bandwidth.PacketsRecvRate = float64(deltas[3]) / seconds
+			bandwidth.ErrorsIn = deltas[4]
+			bandwidth.ErrorsOut = deltas[5]
+		}
+
+		result = append(result, bandwidth)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].totalRate() != result[j].totalRate() {
+			return result[i].totalRate() > result[j].totalRate()
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// TopNetworkInterfacesTool 主动采样各网络接口的实时吞吐量
+type TopNetworkInterfacesTool struct{}
+
+// NewTopNetworkInterfacesTool 创建新的网络接口吞吐量工具
+func NewTopNetworkInterfacesTool() *TopNetworkInterfacesTool {
+	return &TopNetworkInterfacesTool{}
+}
+
+// GetName 获取工具名称
+func (tn *TopNetworkInterfacesTool) GetName() string {
+	return "top_network_interfaces"
+}
+
+// GetDescription 获取工具描述
+func (tn *TopNetworkInterfacesTool) GetDescription() string {
+	return "在短时间内主动采样，按吞吐量列出当前最繁忙的网络接口"
+}
+
+// GetAnnotations 获取工具注解
+func (tn *TopNetworkInterfacesTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("网络接口吞吐排行")
+}
+
+// topNetworkInterfacesArgs top_network_interfaces 的参数
+type topNetworkInterfacesArgs struct {
+	Interval time.Duration `arg:"interval,default=2s" pattern:"^[0-9]+(\\.[0-9]+)?(ms|s)$" desc:"采样间隔，最长 10s"`
+	formatArgs
+}
+
+// GetInputSchema 获取输入模式
+func (tn *TopNetworkInterfacesTool) GetInputSchema() types.InputSchema {
+	return argsSchema(topNetworkInterfacesArgs{})
+}
+
+// Examples 获取调用示例
+func (tn *TopNetworkInterfacesTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "采样 2 秒后按吞吐量排序的网络接口",
+			Arguments:   map[string]interface{}{},
+		},
+		{
+			Description: "采样 500 毫秒的接口吞吐排行，JSON 格式",
+			Arguments:   map[string]interface{}{"interval": "500ms", "format": "json"},
+		},
+	}
+}
+
+// bandwidthReport 主动采样结果
+type bandwidthReport struct {
+	Interval   string               `json:"interval"`
+	Elapsed    float64              `json:"elapsed_seconds"`
+	Interfaces []interfaceBandwidth `json:"interfaces"`
+	SampledAt  time.Time            `json:"sampled_at"`
+	Host       *types.HostIdentity  `json:"host,omitempty"`
+}
+
+// Execute 执行吞吐量采样
+func (tn *TopNetworkInterfacesTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a topNetworkInterfacesArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+	interval := a.Interval
+	if interval <= 0 || interval > maxBandwidthInterval {
+		return "", argumentError("interval", "无效的采样间隔: %s（应在 0 到 %s 之间）", interval, maxBandwidthInterval)
+	}
+
+	before, after, elapsed, err := sampleIOCounters(ctx, interval)
+	if err != nil {
+		return "", wrapError("采样网络接口失败", err)
+	}
+
+	report := bandwidthReport{
+		Interval:   interval.String(),
+		Elapsed:    elapsed.Seconds(),
+		Interfaces: []interfaceBandwidth{},
+		SampledAt:  time.Now(),
+	}
+	for _, bandwidth := range computeBandwidth(before, after, elapsed) {
+		// 跳过回环接口
+		if isLoopbackInterface(hostPlatform, bandwidth.Name) {
+			continue
+		}
+		report.Interfaces = append(report.Interfaces, bandwidth)
+	}
+
+	if a.Format == "json" {
+		report.Host = identity.Get()
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", wrapError("序列化吞吐量数据失败", err)
+		}
+		return string(jsonData), nil
+	}
+
+	return tn.formatReport(report), nil
+}
+
+// formatReport 格式化吞吐量排行
+func (tn *TopNetworkInterfacesTool) formatReport(report bandwidthReport) string {
+	var result string
+
+	result += fmt.Sprintf("🚦 网络接口吞吐排行 (采样时长: %s)\n", report.Interval)
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+
+	if len(report.Interfaces) == 0 {
+		result += "没有可用的网络接口\n"
+	} else {
+		result += fmt.Sprintf("%-15s %-14s %-14s %-10s %-10s %-12s\n",
+			"接口", "发送速率", "接收速率", "发送包/s", "接收包/s", "新增错误(入/出)")
+		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+
+		for _, bandwidth := range report.Interfaces {
+			if bandwidth.CounterReset {
+				result += fmt.Sprintf("%-15s 计数器重置\n", bandwidth.Name)
+				continue
+			}
+			result += fmt.Sprintf("%-15s %-14s %-14s %-10.1f %-10.1f %d/%d\n",
+				bandwidth.Name,
+				formatBytes(uint64(bandwidth.SendRate))+"/s",
+				formatBytes(uint64(bandwidth.RecvRate))+"/s",
+				bandwidth.PacketsSentRate,
+				bandwidth.PacketsRecvRate,
+				bandwidth.ErrorsIn,
+				bandwidth.ErrorsOut,
+			)
+		}
+	}
+
+	result += fmt.Sprintf("\n📅 更新时间: %s\n", report.SampledAt.Format("2006-01-02 15:04:05"))
+
+	return result
+}
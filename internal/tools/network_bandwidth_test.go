@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sequenceNetProvider 依次返回 samples 中各次采样的接口计数，用完后重复最后一次
+type sequenceNetProvider struct {
+	fakeNetProvider
+	samples [][]NetIOCountersStat
+	calls   int
+}
+
+func (sn *sequenceNetProvider) IOCounters(context.Context, bool) ([]NetIOCountersStat, error) {
+	sample := sn.samples[min(sn.calls, len(sn.samples)-1)]
+	sn.calls++
+	return shuffled(sample), nil
+}
+
+// bandwidthSamples 两次采样：eth0 发送 2MB/接收 1MB，eth1 只接收 4MB，wlan0 计数器重置，
+// docker0 只出现在第二次采样中，lo 为回环接口
+func bandwidthSamples() [][]NetIOCountersStat {
+	return [][]NetIOCountersStat{
+		{
+			{Name: "eth0", BytesSent: 1000, BytesRecv: 1000, PacketsSent: 10, PacketsRecv: 10, Errin: 1},
+			{Name: "eth1", BytesSent: 500, BytesRecv: 0},
+			{Name: "wlan0", BytesSent: 1 << 40, BytesRecv: 1 << 40},
+			{Name: "lo", BytesSent: 0, BytesRecv: 0},
+		},
+		{
+			{Name: "eth0", BytesSent: 1000 + 2<<20, BytesRecv: 1000 + 1<<20, PacketsSent: 2010, PacketsRecv: 1010, Errin: 4, Errout: 2},
+			{Name: "eth1", BytesSent: 500, BytesRecv: 4 << 20},
+			{Name: "wlan0", BytesSent: 100, BytesRecv: 100},
+			{Name: "docker0", BytesSent: 1 << 30, BytesRecv: 1 << 30},
+			{Name: "lo", BytesSent: 1 << 30, BytesRecv: 1 << 30},
+		},
+	}
+}
+
+// byName 以接口名称为键的采样
+func byName(stats []NetIOCountersStat) map[string]NetIOCountersStat {
+	result := make(map[string]NetIOCountersStat, len(stats))
+	for _, stat := range stats {
+		result[stat.Name] = stat
+	}
+	return result
+}
+
+func TestComputeBandwidth(t *testing.T) {
+	samples := bandwidthSamples()
+	got := computeBandwidth(byName(samples[0]), byName(samples[1]), 2*time.Second)
+
+	// 按总速率降序；只出现在一次采样中的接口忽略；回环接口由工具过滤，这里仍然计算
+	var names []string
+	for _, bandwidth := range got {
+		names = append(names, bandwidth.Name)
+	}
+	if strings.Join(names, ",") != "lo,eth1,eth0,wlan0" {
+		t.Fatalf("order = %v, want lo,eth1,eth0,wlan0", names)
+	}
+	want := interfaceBandwidth{Name: "eth0", SendRate: 1 << 20, RecvRate: 512 << 10, PacketsSentRate: 1000, PacketsRecvRate: 500, ErrorsIn: 3, ErrorsOut: 2}
+	if got[2] != want {
+		t.Errorf("eth0 = %+v, want %+v", got[2], want)
+	}
+	// 计数器重置时速率和错误增量均为 0
+	if got[3] != (interfaceBandwidth{Name: "wlan0", CounterReset: true}) {
+		t.Errorf("wlan0 = %+v, want a counter reset", got[3])
+	}
+
+	// 总速率相同时按名称排序
+	same := []NetIOCountersStat{{Name: "b", BytesSent: 100}, {Name: "a", BytesRecv: 100}}
+	if got := computeBandwidth(byName([]NetIOCountersStat{{Name: "a"}, {Name: "b"}}), byName(same), time.Second); got[0].Name != "a" || got[1].Name != "b" {
+		t.Errorf("ties = %+v, want them ordered by name", got)
+	}
+
+	if got := computeBandwidth(byName(samples[0]), byName(samples[1]), 0); got != nil {
+		t.Errorf("zero elapsed = %+v, want nil", got)
+	}
+}
+
+func TestTopNetworkInterfaces(t *testing.T) {
+	provider := &sequenceNetProvider{samples: bandwidthSamples()}
+	current := providers
+	current.Net = provider
+	t.Cleanup(SetProviders(current))
+
+	var mu sync.Mutex
+	var progress []string
+	ctx := WithProgress(context.Background(), func(done, total float64, message string) {
+		mu.Lock()
+		defer mu.Unlock()
+		progress = append(progress, message)
+	})
+
+	text, err := NewTopNetworkInterfacesTool().Execute(ctx, map[string]interface{}{"interval": "50ms", "format": "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report bandwidthReport
+	if err := json.Unmarshal([]byte(text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if provider.calls != 2 || report.Interval != "50ms" || report.Elapsed < 0.05 {
+		t.Fatalf("sampled %d times, report = %+v", provider.calls, report)
+	}
+	var names []string
+	for _, bandwidth := range report.Interfaces {
+		names = append(names, bandwidth.Name)
+	}
+	if strings.Join(names, ",") != "eth1,eth0,wlan0" {
+		t.Errorf("interfaces = %v, want eth1,eth0,wlan0 without the loopback", names)
+	}
+	if eth0 := report.Interfaces[1]; eth0.ErrorsIn != 3 || eth0.ErrorsOut != 2 || eth0.SendRate <= eth0.RecvRate {
+		t.Errorf("eth0 = %+v", eth0)
+	}
+
+	// 客户端提供了进度令牌时报告采样进度
+	mu.Lock()
+	if len(progress) < 2 || progress[0] != "采样中" || progress[len(progress)-1] != "采样完成" {
+		t.Errorf("progress = %v", progress)
+	}
+	mu.Unlock()
+
+	provider.calls = 0
+	text, err = NewTopNetworkInterfacesTool().Execute(context.Background(), map[string]interface{}{"interval": "10ms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"🚦 网络接口吞吐排行 (采样时长: 10ms)\n", "\neth1 ", "\nwlan0           计数器重置\n"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestTopNetworkInterfacesInterval(t *testing.T) {
+	useFakeNet(t, &fakeNetProvider{counters: bandwidthSamples()[0]})
+	tool := NewTopNetworkInterfacesTool()
+
+	var toolErr *Error
+	for _, interval := range []string{"0s", "11s", "2m", "fast"} {
+		if _, err := tool.Execute(context.Background(), map[string]interface{}{"interval": interval}); !errors.As(err, &toolErr) || toolErr.Code != ErrBadArgument {
+			t.Errorf("interval %q = %v, want ErrBadArgument", interval, err)
+		}
+	}
+
+	// 等待期间取消时立即返回，不等到采样间隔结束
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := tool.Execute(ctx, map[string]interface{}{"interval": "10s"})
+	if !errors.Is(err, context.Canceled) || !errors.As(err, &toolErr) || toolErr.Code != ErrTimeout {
+		t.Errorf("canceled sample = %v, want a timeout wrapping context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("canceled sample returned after %s", elapsed)
+	}
+}
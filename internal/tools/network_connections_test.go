@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+// largeConnectionSet 4211 个连接：11 个监听端口、200 个 443 端口上的已建立连接和 4000 个 TIME_WAIT，顺序打乱
+func largeConnectionSet() []ConnectionStat {
+	var connections []ConnectionStat
+	for i := 0; i < 4000; i++ {
+		connections = append(connections, connection(1, 2, "10.0.0.5", uint32(30000+i), "192.0.2.1", 80, "TIME_WAIT", 0))
+	}
+	for i := 0; i < 200; i++ {
+		connections = append(connections, connection(1, 2, "10.0.0.5", 443, fmt.Sprintf("198.51.100.%d", i), uint32(50000+i), "ESTABLISHED", 700))
+	}
+	for port := uint32(8010); port >= 8000; port-- {
+		connections = append(connections, connection(1, 2, "0.0.0.0", port, "", 0, "LISTEN", int32(port)))
+	}
+	return shuffled(connections)
+}
+
+func TestProcessConnectionsCap(t *testing.T) {
+	connections := largeConnectionSet()
+
+	got := processConnections(connections, connectionQuery{Limit: 20})
+	if got.Total != 4211 || got.Shown != 20 || !got.Truncated || len(got.Details) != 20 {
+		t.Fatalf("limit 20: total %d, shown %d, truncated %v, %d details", got.Total, got.Shown, got.Truncated, len(got.Details))
+	}
+	// 先保留全部监听端口（按端口排序），再保留已建立的连接（按远程地址排序），TIME_WAIT 全部截掉
+	for i, detail := range got.Details[:11] {
+		if detail.Status != "LISTEN" || detail.LocalPort != uint32(8000+i) {
+			t.Errorf("detail %d = %+v, want LISTEN on %d", i, detail, 8000+i)
+		}
+	}
+	for _, detail := range got.Details[11:] {
+		if detail.Status != "ESTABLISHED" {
+			t.Errorf("detail = %+v, want ESTABLISHED", detail)
+		}
+	}
+	if first := got.Details[11]; first.RemoteIP != "198.51.100.0" {
+		t.Errorf("first established = %+v", first)
+	}
+	// 统计覆盖全部连接，不受截断影响
+	if got.ByStatus["TIME_WAIT"] != 4000 || got.ByStatus["ESTABLISHED"] != 200 || got.ByStatus["LISTEN"] != 11 || got.ByProtocol["1-2"] != 4211 {
+		t.Errorf("ByStatus = %v, ByProtocol = %v", got.ByStatus, got.ByProtocol)
+	}
+
+	// 未指定上限时使用默认值
+	if got := processConnections(connections, connectionQuery{}); got.Shown != defaultConnectionLimit || !got.Truncated {
+		t.Errorf("default limit: shown %d, truncated %v", got.Shown, got.Truncated)
+	}
+
+	// 过滤后的连接数不超过上限时不截断
+	got = processConnections(connections, connectionQuery{State: "ESTABLISHED", LocalPort: 443, Limit: 200})
+	if got.Total != 200 || got.Shown != 200 || got.Truncated {
+		t.Errorf("filtered: total %d, shown %d, truncated %v", got.Total, got.Shown, got.Truncated)
+	}
+	got = processConnections(connections, connectionQuery{State: "TIME_WAIT", Limit: 5})
+	if got.Total != 4000 || got.Shown != 5 || !got.Truncated || got.Details[0].LocalPort != 30000 || got.Details[4].LocalPort != 30004 {
+		t.Errorf("TIME_WAIT: %+v", got)
+	}
+	if got := processConnections(connections, connectionQuery{LocalPort: 9999}); got.Total != 0 || got.Shown != 0 || got.Truncated || got.Details != nil {
+		t.Errorf("no match: %+v", got)
+	}
+}
+
+func TestNetworkToolConnectionCap(t *testing.T) {
+	useFakeNet(t, &fakeNetProvider{counters: []NetIOCountersStat{{Name: "eth0"}}, connections: largeConnectionSet()})
+	tool := NewNetworkTool(storage.NewMemoryCache(), CacheOptions{}, 0, nil)
+
+	text, err := tool.Execute(context.Background(), map[string]interface{}{"show_connections": "true", "conn_limit": 15, "format": "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var netInfo types.NetworkInfo
+	if err := json.Unmarshal([]byte(text), &netInfo); err != nil {
+		t.Fatal(err)
+	}
+	if netInfo.Connections.Total != 4211 || netInfo.Connections.Shown != 15 || !netInfo.Connections.Truncated || len(netInfo.Connections.Details) != 15 {
+		t.Errorf("JSON connections: total %d, shown %d, truncated %v", netInfo.Connections.Total, netInfo.Connections.Shown, netInfo.Connections.Truncated)
+	}
+	if !strings.Contains(text, `"shown": 15`) || !strings.Contains(text, `"truncated": true`) {
+		t.Errorf("JSON output lacks truncation fields")
+	}
+
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"show_connections": "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "\n显示 20 / 4211 个连接，可通过 conn_state、local_port 过滤或调大 conn_limit\n") {
+		t.Errorf("text output lacks the truncation line:\n%s", text)
+	}
+	if rows := strings.Count(text, "\n1-2 "); rows != 20 {
+		t.Errorf("text output shows %d connection rows, want 20", rows)
+	}
+
+	// 没有截断时不显示提示，JSON 中 truncated 为 false
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"show_connections": "true", "conn_state": "LISTEN"})
+	if err != nil || strings.Contains(text, "个连接，可通过") {
+		t.Errorf("untruncated output = %v\n%s", err, text)
+	}
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"show_connections": "true", "conn_state": "LISTEN", "format": "json"})
+	if err != nil || !strings.Contains(text, `"shown": 11`) || !strings.Contains(text, `"truncated": false`) {
+		t.Errorf("untruncated JSON = %v\n%s", err, text)
+	}
+}
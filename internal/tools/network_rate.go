@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"time"
+)
+
+// counterSample 网络接口的一次字节计数采样
+type counterSample struct {
+	BytesSent uint64    `json:"bytes_sent"`
+	BytesRecv uint64    `json:"bytes_recv"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// counterReset 计数器是否在两次采样之间重置（接口重启、驱动重新加载、计数器回绕等）：任一方向的计数变小
+func counterReset(prev, curr counterSample) bool {
+	return curr.BytesSent < prev.BytesSent || curr.BytesRecv < prev.BytesRecv
+}
+
+// interfaceRate 两次采样之间的平均速率
+type interfaceRate struct {
+	SendRate     float64 // 字节/秒
+	RecvRate     float64 // 字节/秒
+	Elapsed      time.Duration
+	CounterReset bool
+}
+
+// computeRate 根据前后两次采样计算平均速率。
+// 计数器变小（接口重启、计数器回绕等）时无法得到可信的差值，标记为计数器重置。
+func computeRate(prev, curr counterSample) (interfaceRate, bool) {
+	elapsed := curr.Timestamp.Sub(prev.Timestamp)
+	if elapsed <= 0 {
+		return interfaceRate{}, false
+	}
+
+	rate := interfaceRate{Elapsed: elapsed}
+	if counterReset(prev, curr) {
+		rate.CounterReset = true
+		return rate, true
+	}
+
+	seconds := elapsed.Seconds()
+	rate.SendRate = float64(curr.BytesSent-prev.BytesSent) / seconds
+	rate.RecvRate = float64(curr.BytesRecv-prev.BytesRecv) / seconds
+
+	return rate, true
+}
+
+// rateCacheKey 保存接口上一次采样的缓存键
+func rateCacheKey(interfaceName string) string {
+	return newCacheKey("network_rate_prev", map[string]interface{}{"interface": interfaceName}).Key
+}
+
+// counterHistoryKey 各接口计数器起点记录在存储中的键
+const counterHistoryKey = "network_counter_history"
+
+// counterHistory 接口计数器的起点记录：首次看到接口的时间和计数、检测到的重置次数，
+// 以及最近一次重置前的最后一次采样时间（重置发生在此之后，当前计数从那时起累计）
+type counterHistory struct {
+	FirstSeen      counterSample `json:"first_seen"`
+	Last           counterSample `json:"last"`
+	ResetCount     int           `json:"reset_count"`
+	LastResetAfter *time.Time    `json:"last_reset_after,omitempty"`
+}
+
+// observeCounters 用本次采样更新接口的起点记录：第一次看到接口时记录起点，计数比上一次采样小时计为一次重置。
+// 早于上一次采样的数据（如并发调用的旧采样）不更新记录。两次采样之间发生重置、且计数已超过重置前的值时无法检测
+func observeCounters(history *counterHistory, curr counterSample) counterHistory {
+	if history == nil {
+		return counterHistory{FirstSeen: curr, Last: curr}
+	}
+
+	updated := *history
+	if curr.Timestamp.Before(history.Last.Timestamp) {
+		return updated
+	}
+	if counterReset(history.Last, curr) {
+		updated.ResetCount++
+		resetAfter := history.Last.Timestamp
+		updated.LastResetAfter = &resetAfter
+	}
+	updated.Last = curr
+	return updated
+}
@@ -0,0 +1,243 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+func TestComputeRate(t *testing.T) {
+	start := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	sample := func(sent, recv uint64, after time.Duration) counterSample {
+		return counterSample{BytesSent: sent, BytesRecv: recv, Timestamp: start.Add(after)}
+	}
+	cases := []struct {
+		name       string
+		prev, curr counterSample
+		want       interfaceRate
+		ok         bool
+	}{
+		{"steady", sample(1000, 5000, 0), sample(11000, 45000, 10*time.Second), interfaceRate{SendRate: 1000, RecvRate: 4000, Elapsed: 10 * time.Second}, true},
+		{"idle", sample(1000, 5000, 0), sample(1000, 5000, time.Minute), interfaceRate{Elapsed: time.Minute}, true},
+		// 接口重启后计数从 0 开始
+		{"interface bounce", sample(1<<30, 1<<31, 0), sample(2048, 4096, 30*time.Second), interfaceRate{Elapsed: 30 * time.Second, CounterReset: true}, true},
+		// 32 位计数器回绕：差值按无符号相减会得到接近 2^64 的速率
+		{"32-bit wraparound", sample(4294967000, 100, 0), sample(500, 200, 10*time.Second), interfaceRate{Elapsed: 10 * time.Second, CounterReset: true}, true},
+		{"recv only reset", sample(100, 4294967000, 0), sample(200, 500, 10*time.Second), interfaceRate{Elapsed: 10 * time.Second, CounterReset: true}, true},
+		// 64 位计数接近上限时的差值不溢出
+		{"near 64-bit max", sample(1<<64-2001, 0, 0), sample(1<<64-1, 0, 2*time.Second), interfaceRate{SendRate: 1000, Elapsed: 2 * time.Second}, true},
+		{"same timestamp", sample(1000, 1000, 0), sample(2000, 2000, 0), interfaceRate{}, false},
+		{"clock went back", sample(1000, 1000, 0), sample(2000, 2000, -time.Second), interfaceRate{}, false},
+	}
+	for _, c := range cases {
+		got, ok := computeRate(c.prev, c.curr)
+		if ok != c.ok || got != c.want {
+			t.Errorf("%s: computeRate() = %+v, %v; want %+v, %v", c.name, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestUpdateRatesSequence(t *testing.T) {
+	tool := NewNetworkTool(storage.NewMemoryCache(), CacheOptions{}, 5*time.Minute, nil)
+	start := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	observe := func(after time.Duration, counters map[string][2]uint64) map[string]interfaceRate {
+		t.Helper()
+		netInfo := types.NetworkInfo{LastUpdated: start.Add(after)}
+		for _, name := range []string{"eth0", "eth1"} {
+			if c, found := counters[name]; found {
+				netInfo.Interfaces = append(netInfo.Interfaces, types.NetworkInterface{Name: name, BytesSent: c[0], BytesRecv: c[1]})
+			}
+		}
+		return tool.updateRates(netInfo)
+	}
+
+	// 第一次调用没有上一次采样，不给出速率
+	if rates := observe(0, map[string][2]uint64{"eth0": {1000, 2000}}); len(rates) != 0 {
+		t.Fatalf("first call rates = %+v, want none", rates)
+	}
+	// 速率为两次调用之间的平均值；新出现的接口同样没有速率
+	rates := observe(10*time.Second, map[string][2]uint64{"eth0": {11000, 42000}, "eth1": {5, 5}})
+	if rates["eth0"] != (interfaceRate{SendRate: 1000, RecvRate: 4000, Elapsed: 10 * time.Second}) || len(rates) != 1 {
+		t.Fatalf("second call rates = %+v", rates)
+	}
+	// 计数变小时报告计数器重置，重置后的计数作为新的起点
+	rates = observe(20*time.Second, map[string][2]uint64{"eth0": {500, 1000}, "eth1": {105, 1005}})
+	if !rates["eth0"].CounterReset || rates["eth1"] != (interfaceRate{SendRate: 10, RecvRate: 100, Elapsed: 10 * time.Second}) {
+		t.Fatalf("rates after a reset = %+v", rates)
+	}
+	rates = observe(30*time.Second, map[string][2]uint64{"eth0": {1500, 3000}})
+	if rates["eth0"] != (interfaceRate{SendRate: 100, RecvRate: 200, Elapsed: 10 * time.Second}) {
+		t.Fatalf("rates after recovering from a reset = %+v", rates)
+	}
+	// 上一次采样超出窗口时不给出速率
+	if rates := observe(30*time.Second+5*time.Minute+time.Second, map[string][2]uint64{"eth0": {2500, 4000}}); len(rates) != 0 {
+		t.Fatalf("rates after the window = %+v, want none", rates)
+	}
+
+	if rates := NewNetworkTool(storage.NewMemoryCache(), CacheOptions{}, 0, nil).updateRates(types.NetworkInfo{}); rates != nil {
+		t.Errorf("rates with the window disabled = %+v, want nil", rates)
+	}
+}
+
+func TestNetworkToolRates(t *testing.T) {
+	provider := &fakeNetProvider{counters: []NetIOCountersStat{{Name: "eth0", BytesSent: 1 << 30, BytesRecv: 1 << 31}}}
+	useFakeNet(t, provider)
+	tool := NewNetworkTool(storage.NewMemoryCache(), CacheOptions{}, 5*time.Minute, nil)
+
+	// 第一次调用没有速率列
+	text, err := tool.Execute(context.Background(), map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(text, "发送速率") || strings.Contains(text, "速率为距上次调用") {
+		t.Fatalf("first call shows rates:\n%s", text)
+	}
+
+	// 接口重启后计数变小，报告计数器重置而不是巨大的速率
+	provider.counters = []NetIOCountersStat{{Name: "eth0", BytesSent: 4096, BytesRecv: 8192}}
+	text, err = tool.Execute(context.Background(), map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "发送速率") || !strings.Contains(text, "计数器重置") || strings.Contains(text, "PB/s") || strings.Contains(text, "EB/s") {
+		t.Fatalf("rates after a reset:\n%s", text)
+	}
+
+	// 缓存命中的数据不是新采样，不参与速率计算
+	if text, err := tool.Execute(context.Background(), map[string]interface{}{"cache": "auto"}); err != nil || strings.Contains(text, "发送速率") {
+		t.Errorf("cached call shows rates:\n%s", text)
+	}
+}
+
+func TestObserveCounters(t *testing.T) {
+	start := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	sample := func(sent, recv uint64, after time.Duration) counterSample {
+		return counterSample{BytesSent: sent, BytesRecv: recv, Timestamp: start.Add(after)}
+	}
+	at := func(after time.Duration) *time.Time {
+		resetAfter := start.Add(after)
+		return &resetAfter
+	}
+
+	// 依次注入的采样和每一步之后期望的记录
+	steps := []struct {
+		name       string
+		sample     counterSample
+		resets     int
+		resetAfter *time.Time
+	}{
+		{"first seen", sample(1000, 2000, 0), 0, nil},
+		{"growing", sample(5000, 9000, time.Hour), 0, nil},
+		// 接口重启后计数从 0 开始
+		{"interface bounce", sample(100, 200, 2*time.Hour), 1, at(time.Hour)},
+		{"growing after reset", sample(8000, 9000, 3*time.Hour), 1, at(time.Hour)},
+		// 只有一个方向变小也是重置
+		{"recv reset", sample(9000, 10, 4*time.Hour), 2, at(3 * time.Hour)},
+		// 并发调用中较旧的采样不更新记录，也不会被误判为重置
+		{"stale sample", sample(1, 1, 3*time.Hour+30*time.Minute), 2, at(3 * time.Hour)},
+		{"driver reload", sample(0, 0, 5*time.Hour), 3, at(4 * time.Hour)},
+		{"unchanged", sample(0, 0, 6*time.Hour), 3, at(4 * time.Hour)},
+	}
+
+	var history *counterHistory
+	for _, s := range steps {
+		var previous counterHistory
+		if history != nil {
+			previous = *history
+		}
+		updated := observeCounters(history, s.sample)
+		if updated.FirstSeen != sample(1000, 2000, 0) || updated.ResetCount != s.resets {
+			t.Errorf("%s: history = %+v", s.name, updated)
+		}
+		if (updated.LastResetAfter == nil) != (s.resetAfter == nil) || (s.resetAfter != nil && !updated.LastResetAfter.Equal(*s.resetAfter)) {
+			t.Errorf("%s: LastResetAfter = %v, want %v", s.name, updated.LastResetAfter, s.resetAfter)
+		}
+		if s.name != "stale sample" && updated.Last != s.sample {
+			t.Errorf("%s: Last = %+v, want %+v", s.name, updated.Last, s.sample)
+		}
+		// 不修改传入的记录
+		if history != nil && *history != previous {
+			t.Errorf("%s: previous history modified", s.name)
+		}
+		history = &updated
+	}
+}
+
+func TestAnnotateCounters(t *testing.T) {
+	dataStorage := storage.NewMemoryStorage()
+	start := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	sample := func(tool *NetworkTool, after time.Duration, update bool, counters map[string]uint64) map[string]types.NetworkInterface {
+		t.Helper()
+		netInfo := types.NetworkInfo{LastUpdated: start.Add(after)}
+		for _, name := range []string{"eth0", "eth1"} {
+			if c, found := counters[name]; found {
+				netInfo.Interfaces = append(netInfo.Interfaces, types.NetworkInterface{Name: name, BytesSent: c, BytesRecv: c})
+			}
+		}
+		interfaces := make(map[string]types.NetworkInterface)
+		for _, iface := range tool.annotateCounters(netInfo, update).Interfaces {
+			interfaces[iface.Name] = iface
+		}
+		return interfaces
+	}
+
+	tool := NewNetworkTool(storage.NewMemoryCache(), CacheOptions{}, 5*time.Minute, dataStorage)
+	sample(tool, 0, true, map[string]uint64{"eth0": 1 << 30})
+	sample(tool, time.Hour, true, map[string]uint64{"eth0": 4 << 20, "eth1": 100})
+	got := sample(tool, 2*time.Hour, true, map[string]uint64{"eth0": 8 << 20, "eth1": 50})
+	if eth0 := got["eth0"]; eth0.FirstSeen == nil || !eth0.FirstSeen.Equal(start) || eth0.ResetCount != 1 || !eth0.LastResetAfter.Equal(start) {
+		t.Errorf("eth0 = %+v", eth0)
+	}
+	if eth1 := got["eth1"]; eth1.FirstSeen == nil || !eth1.FirstSeen.Equal(start.Add(time.Hour)) || eth1.ResetCount != 1 {
+		t.Errorf("eth1 = %+v", eth1)
+	}
+
+	// 记录保存在存储中，服务重启（新的工具实例）后继续累计
+	restarted := NewNetworkTool(storage.NewMemoryCache(), CacheOptions{}, 5*time.Minute, dataStorage)
+	got = sample(restarted, 3*time.Hour, true, map[string]uint64{"eth0": 1024})
+	if eth0 := got["eth0"]; eth0.ResetCount != 2 || !eth0.FirstSeen.Equal(start) || !eth0.LastResetAfter.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("eth0 after restart = %+v", eth0)
+	}
+
+	// 缓存命中的数据不更新记录，只附加已有的信息；从未见过的接口不附加
+	got = sample(restarted, 4*time.Hour, false, map[string]uint64{"eth0": 1, "eth1": 1})
+	if eth0 := got["eth0"]; eth0.ResetCount != 2 {
+		t.Errorf("cached eth0 = %+v", eth0)
+	}
+	if eth1 := got["eth1"]; eth1.ResetCount != 1 {
+		t.Errorf("cached eth1 = %+v", eth1)
+	}
+	fresh := NewNetworkTool(storage.NewMemoryCache(), CacheOptions{}, 5*time.Minute, storage.NewMemoryStorage())
+	if eth0 := sample(fresh, 0, false, map[string]uint64{"eth0": 1})["eth0"]; eth0.FirstSeen != nil {
+		t.Errorf("unseen eth0 = %+v", eth0)
+	}
+
+	// 没有存储时不附加
+	noStorage := NewNetworkTool(storage.NewMemoryCache(), CacheOptions{}, 5*time.Minute, nil)
+	if eth0 := sample(noStorage, 0, true, map[string]uint64{"eth0": 1})["eth0"]; eth0.FirstSeen != nil {
+		t.Errorf("eth0 without storage = %+v", eth0)
+	}
+}
+
+func TestFormatCounterOrigins(t *testing.T) {
+	firstSeen := time.Date(2024, 3, 1, 9, 30, 0, 0, time.Local)
+	resetAfter := time.Date(2024, 5, 6, 7, 8, 0, 0, time.Local)
+	text := formatCounterOrigins([]types.NetworkInterface{
+		{Name: "eth0", FirstSeen: &firstSeen},
+		{Name: "eth1", FirstSeen: &firstSeen, ResetCount: 3, LastResetAfter: &resetAfter},
+		{Name: "lo"},
+	})
+	want := "\n📍 字节计数起点:\n" +
+		"  eth0: 开机或接口启用以来的累计值（自 2024-03-01 09:30 起观察，未检测到计数器重置）\n" +
+		"  eth1: 自 2024-05-06 07:08 后的最近一次计数器重置以来的累计值（自 2024-03-01 09:30 起观察，共检测到 3 次重置）\n"
+	if text != want {
+		t.Errorf("formatCounterOrigins() = %q, want %q", text, want)
+	}
+	if text := formatCounterOrigins([]types.NetworkInterface{{Name: "lo"}}); text != "" {
+		t.Errorf("no history = %q", text)
+	}
+}
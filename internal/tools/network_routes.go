@@ -0,0 +1,488 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/types"
+)
+
+// 路由标志（linux/route.h）
+const (
+	routeFlagUp     = 0x0001
+	routeFlagReject = 0x0200
+)
+
+// routeEntry 路由表中的一条路由
+type routeEntry struct {
+	Family      string `json:"family"`
+	Destination string `json:"destination"`
+	Gateway     string `json:"gateway"`
+	Interface   string `json:"interface"`
+	Metric      int    `json:"metric"`
+	Default     bool   `json:"default"`
+}
+
+// neighborEntry 邻居缓存（ARP）中的一项
+type neighborEntry struct {
+	IP        string `json:"ip"`
+	MAC       string `json:"mac"`
+	Interface string `json:"interface"`
+}
+
+// routesReport 路由工具的输出
+type routesReport struct {
+	Source      string              `json:"source"`
+	Routes      []routeEntry        `json:"routes"`
+	Neighbors   []neighborEntry     `json:"neighbors,omitempty"`
+	Notes       []string            `json:"notes,omitempty"`
+	LastUpdated time.Time           `json:"last_updated"`
+	Host        *types.HostIdentity `json:"host,omitempty"`
+}
+
+// NetworkRoutesTool 路由表与邻居缓存工具（Linux 读取 /proc/net，其他平台解析 netstat/arp 输出）
+type NetworkRoutesTool struct {
+	procRoot string
+	run      commandRunner
+}
+
+// NewNetworkRoutesTool 创建新的路由表工具
+func NewNetworkRoutesTool() *NetworkRoutesTool {
+	return &NetworkRoutesTool{
+		procRoot: "/proc",
+		run:      runCommand,
+	}
+}
+
+// Available 当前平台是否至少有一个可用的路由数据来源
+func (nr *NetworkRoutesTool) Available() bool {
+	switch runtime.GOOS {
+	case "linux":
+		for _, name := range []string{"net/route", "net/ipv6_route"} {
+			if file, err := os.Open(filepath.Join(nr.procRoot, name)); err == nil {
+				file.Close()
+				return true
+			}
+		}
+		return false
+	case "windows":
+		return false
+	default:
+		_, err := exec.LookPath("netstat")
+		return err == nil
+	}
+}
+
+// GetName 获取工具名称
+func (nr *NetworkRoutesTool) GetName() string {
+	return "network_routes"
+}
+
+// GetDescription 获取工具描述
+func (nr *NetworkRoutesTool) GetDescription() string {
+	return "获取路由表（含默认网关）和可选的 ARP/邻居缓存"
+}
+
+// GetAnnotations 获取工具注解
+func (nr *NetworkRoutesTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("路由表")
+}
+
+// networkRoutesArgs network_routes 的参数
+type networkRoutesArgs struct {
+	IncludeNeighbors bool `arg:"include_neighbors,default=false" desc:"是否包含 ARP/邻居缓存"`
+	formatArgs
+}
+
+// GetInputSchema 获取输入模式
+func (nr *NetworkRoutesTool) GetInputSchema() types.InputSchema {
+	return argsSchema(networkRoutesArgs{})
+}
+
+// Examples 获取调用示例
+func (nr *NetworkRoutesTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "路由表和默认网关",
+			Arguments:   map[string]interface{}{},
+		},
+		{
+			Description: "路由表以及 ARP/邻居缓存，JSON 格式",
+			Arguments:   map[string]interface{}{"include_neighbors": "true", "format": "json"},
+		},
+	}
+}
+
+// Execute 获取路由表
+func (nr *NetworkRoutesTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a networkRoutesArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+	includeNeighbors := a.IncludeNeighbors
+
+	var report routesReport
+	var err error
+	if runtime.GOOS == "linux" {
+		report, err = nr.collectProc(includeNeighbors)
+	} else {
+		report, err = nr.collectCommands(ctx, includeNeighbors)
+	}
+	if err != nil {
+		return "", wrapError("获取路由表失败", err)
+	}
+	report.LastUpdated = time.Now()
+
+	if a.Format == "json" {
+		report.Host = identity.Get()
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", wrapError("序列化路由表失败", err)
+		}
+		return string(jsonData), nil
+	}
+
+	return nr.formatReport(report, includeNeighbors), nil
+}
+
+// collectProc 从 /proc/net 读取路由表和邻居缓存，IPv4 和 IPv6 任一可读即可
+func (nr *NetworkRoutesTool) collectProc(includeNeighbors bool) (routesReport, error) {
+	report := routesReport{Source: "/proc/net", Routes: []routeEntry{}}
+
+	var readErr error
+	readable := 0
+	for _, source := range []struct {
+		name  string
+		parse func(io.Reader) ([]routeEntry, error)
+	}{
+		{"net/route", parseProcNetRoute},
+		{"net/ipv6_route", parseProcNetIPv6Route},
+	} {
+		routes, err := readProcFile(filepath.Join(nr.procRoot, source.name), source.parse)
+		if err != nil {
+			readErr = err
+			report.Notes = append(report.Notes, fmt.Sprintf("无法读取 /proc/%s: %v", source.name, err))
+			continue
+		}
+		readable++
+		report.Routes = append(report.Routes, routes...)
+	}
+	if readable == 0 {
+		return report, readErr
+	}
+
+	if includeNeighbors {
+		neighbors, err := readProcFile(filepath.Join(nr.procRoot, "net/arp"), parseProcNetArp)
+		if err != nil {
+			report.Notes = append(report.Notes, fmt.Sprintf("无法读取 /proc/net/arp: %v", err))
+		} else {
+			report.Neighbors = neighbors
+		}
+	}
+
+	return report, nil
+}
+
+// collectCommands 解析 netstat -rn 和 arp -an 的输出
+func (nr *NetworkRoutesTool) collectCommands(ctx context.Context, includeNeighbors bool) (routesReport, error) {
+	report := routesReport{Source: "netstat -rn"}
+
+	output, err := nr.run(ctx, "netstat", "-rn")
+	if err != nil {
+		return report, err
+	}
+	report.Routes = parseNetstatRoutes(string(output))
+
+	if includeNeighbors {
+		output, err := nr.run(ctx, "arp", "-an")
+		if err != nil {
+			report.Notes = append(report.Notes, fmt.Sprintf("无法获取邻居缓存: %v", err))
+		} else {
+			report.Neighbors = parseArpOutput(string(output))
+		}
+	}
+
+	return report, nil
+}
+
+// readProcFile 打开文件并交给解析函数
+func readProcFile[T any](path string, parse func(io.Reader) (T, error)) (T, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	defer file.Close()
+
+	return parse(file)
+}
+
+// parseProcNetRoute 解析 /proc/net/route，地址为小端序十六进制，只保留处于 up 状态的路由
+func parseProcNetRoute(r io.Reader) ([]routeEntry, error) {
+	var routes []routeEntry
+
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // 跳过表头
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil || flags&routeFlagUp == 0 {
+			continue
+		}
+		destination, err1 := parseHexIPv4(fields[1])
+		gateway, err2 := parseHexIPv4(fields[2])
+		mask, err3 := parseHexIPv4(fields[7])
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		metric, _ := strconv.Atoi(fields[6])
+		prefixLen, _ := net.IPMask(mask.To4()).Size()
+
+		routes = append(routes, routeEntry{
+			Family:      "ipv4",
+			Destination: fmt.Sprintf("%s/%d", destination, prefixLen),
+			Gateway:     gatewayString(gateway),
+			Interface:   fields[0],
+			Metric:      metric,
+			Default:     destination.Equal(net.IPv4zero) && prefixLen == 0,
+		})
+	}
+
+	return routes, scanner.Err()
+}
+
+// parseHexIPv4 解析 /proc/net/route 中小端序的十六进制 IPv4 地址
+func parseHexIPv4(value string) (net.IP, error) {
+	raw, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return nil, err
+	}
+	ip := make(net.IP, 4)
+	binary.LittleEndian.PutUint32(ip, uint32(raw))
+	return ip, nil
+}
+
+// parseProcNetIPv6Route 解析 /proc/net/ipv6_route，跳过未启用和 reject 路由
+func parseProcNetIPv6Route(r io.Reader) ([]routeEntry, error) {
+	var routes []routeEntry
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+
+		flags, err := strconv.ParseUint(fields[8], 16, 32)
+		if err != nil || flags&routeFlagUp == 0 || flags&routeFlagReject != 0 {
+			continue
+		}
+		destination, err1 := parseHexIPv6(fields[0])
+		gateway, err2 := parseHexIPv6(fields[4])
+		prefixLen, err3 := strconv.ParseUint(fields[1], 16, 8)
+		metric, err4 := strconv.ParseUint(fields[5], 16, 32)
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+			continue
+		}
+
+		routes = append(routes, routeEntry{
+			Family:      "ipv6",
+			Destination: fmt.Sprintf("%s/%d", destination, prefixLen),
+			Gateway:     gatewayString(gateway),
+			Interface:   fields[9],
+			Metric:      int(metric),
+			Default:     destination.Equal(net.IPv6zero) && prefixLen == 0,
+		})
+	}
+
+	return routes, scanner.Err()
+}
+
+// parseHexIPv6 解析 32 位十六进制的 IPv6 地址
+func parseHexIPv6(value string) (net.IP, error) {
+	raw, err := hex.DecodeString(value)
+	if err != nil || len(raw) != net.IPv6len {
+		return nil, fmt.Errorf("无效的 IPv6 地址: %q", value)
+	}
+	return net.IP(raw), nil
+}
+
+// gatewayString 网关地址，未指定（直连路由）时返回 "-"
+func gatewayString(gateway net.IP) string {
+	if gateway.IsUnspecified() {
+		return "-"
+	}
+	return gateway.String()
+}
+
+// parseProcNetArp 解析 /proc/net/arp，跳过未完成解析（flags 为 0）的项
+func parseProcNetArp(r io.Reader) ([]neighborEntry, error) {
+	var neighbors []neighborEntry
+
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // 跳过表头
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 || fields[2] == "0x0" {
+			continue
+		}
+		neighbors = append(neighbors, neighborEntry{
+			IP:        fields[0],
+			MAC:       fields[3],
+			Interface: fields[5],
+		})
+	}
+
+	return neighbors, scanner.Err()
+}
+
+// parseNetstatRoutes 解析 netstat -rn 的输出（BSD/macOS 和 net-tools 格式）。
+// 每个表以包含 Destination 和 Gateway 的表头开始，按表头定位接口和跃点列。
+func parseNetstatRoutes(output string) []routeEntry {
+	routes := []routeEntry{}
+
+	family := "ipv4"
+	columns := map[string]int(nil)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		fields := strings.Fields(line)
+
+		switch {
+		case line == "":
+			columns = nil
+			continue
+		case strings.HasPrefix(line, "Internet6"):
+			family, columns = "ipv6", nil
+			continue
+		case strings.HasPrefix(line, "Internet"):
+			family, columns = "ipv4", nil
+			continue
+		case len(fields) > 0 && fields[0] == "Destination":
+			columns = make(map[string]int, len(fields))
+			for i, field := range fields {
+				columns[field] = i
+			}
+			continue
+		}
+		if columns == nil || len(fields) < 2 {
+			continue
+		}
+
+		route := routeEntry{
+			Family:      family,
+			Destination: fields[0],
+			Gateway:     fields[1],
+		}
+		if strings.Contains(route.Destination, ":") {
+			route.Family = "ipv6"
+		}
+		for _, name := range []string{"Netif", "Iface", "Interface"} {
+			if index, ok := columns[name]; ok && index < len(fields) {
+				route.Interface = fields[index]
+				break
+			}
+		}
+		if index, ok := columns["Metric"]; ok && index < len(fields) {
+			route.Metric, _ = strconv.Atoi(fields[index])
+		}
+		route.Default = route.Destination == "default" || route.Destination == "0.0.0.0" || route.Destination == "::/0"
+
+		routes = append(routes, route)
+	}
+
+	return routes
+}
+
+// parseArpOutput 解析 arp -an 的输出，例如：
+// ? (192.168.1.1) at aa:bb:cc:dd:ee:ff on en0 ifscope [ethernet]
+func parseArpOutput(output string) []neighborEntry {
+	var neighbors []neighborEntry
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[2] != "at" {
+			continue
+		}
+		ip := strings.Trim(fields[1], "()")
+		mac := fields[3]
+		if net.ParseIP(ip) == nil || strings.HasPrefix(mac, "(") {
+			// 未完成解析的项显示为 (incomplete)
+			continue
+		}
+
+		neighbor := neighborEntry{IP: ip, MAC: mac}
+		for i := 4; i+1 < len(fields); i++ {
+			if fields[i] == "on" {
+				neighbor.Interface = fields[i+1]
+				break
+			}
+		}
+		neighbors = append(neighbors, neighbor)
+	}
+
+	return neighbors
+}
+
+// formatReport 格式化路由表输出，默认路由以 ⭐ 标记
+func (nr *NetworkRoutesTool) formatReport(report routesReport, includeNeighbors bool) string {
+	var result string
+
+	result += "🧭 路由表\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result += fmt.Sprintf("数据来源: %s\n", report.Source)
+
+	for _, route := range report.Routes {
+		if route.Default {
+			result += fmt.Sprintf("默认网关 (%s): %s via %s\n", route.Family, route.Gateway, route.Interface)
+		}
+	}
+
+	result += fmt.Sprintf("\n   %-44s %-28s %-12s %-6s\n", "目标", "网关", "接口", "跃点")
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	for _, route := range report.Routes {
+		marker := "  "
+		if route.Default {
+			marker = "⭐"
+		}
+		result += fmt.Sprintf("%s %-44s %-28s %-12s %-6d\n", marker, route.Destination, route.Gateway, route.Interface, route.Metric)
+	}
+
+	if includeNeighbors {
+		result += "\n🔎 邻居缓存 (ARP)\n"
+		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		if len(report.Neighbors) == 0 {
+			result += "没有邻居缓存项\n"
+		}
+		for _, neighbor := range report.Neighbors {
+			result += fmt.Sprintf("%-40s %-20s %s\n", neighbor.IP, neighbor.MAC, neighbor.Interface)
+		}
+	}
+
+	for _, note := range report.Notes {
+		result += fmt.Sprintf("\nℹ️  %s\n", note)
+	}
+
+	result += fmt.Sprintf("\n📅 更新时间: %s\n", report.LastUpdated.Format("2006-01-02 15:04:05"))
+
+	return result
+}
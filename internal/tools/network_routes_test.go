@@ -0,0 +1,221 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// procNetRouteFixture /proc/net/route：默认路由、直连子网和一条未启用的路由
+const procNetRouteFixture = `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	00000000	0101A8C0	0003	0	0	100	00000000	0	0	0
+eth0	0001A8C0	00000000	0001	0	0	100	00FFFFFF	0	0	0
+docker0	000011AC	00000000	0000	0	0	0	0000FFFF	0	0	0
+`
+
+// procNetIPv6RouteFixture /proc/net/ipv6_route：默认路由、链路本地子网和一条 reject 路由
+const procNetIPv6RouteFixture = `00000000000000000000000000000000 00 00000000000000000000000000000000 00 fe800000000000000000000000000001 00000400 00000001 00000000 00000003 eth0
+fe800000000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000001 00000000 00000001 eth0
+00000000000000000000000000000000 00 00000000000000000000000000000000 00 00000000000000000000000000000000 ffffffff 00000001 00000000 00200200 lo
+`
+
+// procNetArpFixture /proc/net/arp，第二项尚未完成解析
+const procNetArpFixture = `IP address       HW type     Flags       HW address            Mask     Device
+192.168.1.1      0x1         0x2         aa:bb:cc:dd:ee:ff     *        eth0
+192.168.1.77     0x1         0x0         00:00:00:00:00:00     *        eth0
+`
+
+// netstatDarwinFixture macOS 上 netstat -rn 的输出
+const netstatDarwinFixture = `Routing tables
+
+Internet:
+Destination        Gateway            Flags        Netif Expire
+default            192.168.1.1        UGScg          en0
+127                127.0.0.1          UCS            lo0
+192.168.1          link#6             UCS            en0      !
+
+Internet6:
+Destination                             Gateway                                 Flags         Netif Expire
+default                                 fe80::1%en0                             UGcg            en0
+::1                                     ::1                                     UHL             lo0
+`
+
+// netstatNetToolsFixture Linux net-tools 的 netstat -rn 输出
+const netstatNetToolsFixture = `Kernel IP routing table
+Destination     Gateway         Genmask         Flags   MSS Window  irtt Iface
+0.0.0.0         10.0.0.1        0.0.0.0         UG        0 0          0 eth0
+10.0.0.0        0.0.0.0         255.255.255.0   U         0 0          0 eth0
+`
+
+// arpFixture arp -an 的输出：macOS 格式（含未完成解析的项）和 Linux net-tools 格式
+const arpFixture = `? (192.168.1.1) at aa:bb:cc:dd:ee:ff on en0 ifscope [ethernet]
+? (192.168.1.50) at (incomplete) on en0 ifscope [ethernet]
+? (224.0.0.251) at 1:0:5e:0:0:fb on en0 ifscope permanent [ethernet]
+? (10.0.0.1) at 52:54:00:12:34:56 [ether] on eth0
+garbage
+`
+
+// assertRoutes 比较解析出的路由
+func assertRoutes(t *testing.T, name string, got, want []routeEntry) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: %d routes %+v, want %d", name, len(got), got, len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%s: route %d = %+v, want %+v", name, i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseProcNetRoutes(t *testing.T) {
+	routes, err := parseProcNetRoute(strings.NewReader(procNetRouteFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 地址为小端序；未启用的路由跳过
+	assertRoutes(t, "/proc/net/route", routes, []routeEntry{
+		{Family: "ipv4", Destination: "0.0.0.0/0", Gateway: "192.168.1.1", Interface: "eth0", Metric: 100, Default: true},
+		{Family: "ipv4", Destination: "192.168.1.0/24", Gateway: "-", Interface: "eth0", Metric: 100},
+	})
+
+	routes, err = parseProcNetIPv6Route(strings.NewReader(procNetIPv6RouteFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 跃点为十六进制；reject 路由跳过
+	assertRoutes(t, "/proc/net/ipv6_route", routes, []routeEntry{
+		{Family: "ipv6", Destination: "::/0", Gateway: "fe80::1", Interface: "eth0", Metric: 1024, Default: true},
+		{Family: "ipv6", Destination: "fe80::/64", Gateway: "-", Interface: "eth0", Metric: 256},
+	})
+
+	// 字段不足或无法解析的行跳过
+	malformed := "Iface Destination\neth0 zz 00000000 0003 0 0 0 00000000\neth0 00000000\n"
+	if routes, err := parseProcNetRoute(strings.NewReader(malformed)); err != nil || len(routes) != 0 {
+		t.Errorf("malformed /proc/net/route = %+v, %v", routes, err)
+	}
+
+	neighbors, err := parseProcNetArp(strings.NewReader(procNetArpFixture))
+	if err != nil || len(neighbors) != 1 || neighbors[0] != (neighborEntry{IP: "192.168.1.1", MAC: "aa:bb:cc:dd:ee:ff", Interface: "eth0"}) {
+		t.Errorf("/proc/net/arp = %+v, %v; want only the resolved entry", neighbors, err)
+	}
+}
+
+func TestParseNetstatRoutes(t *testing.T) {
+	assertRoutes(t, "darwin", parseNetstatRoutes(netstatDarwinFixture), []routeEntry{
+		{Family: "ipv4", Destination: "default", Gateway: "192.168.1.1", Interface: "en0", Default: true},
+		{Family: "ipv4", Destination: "127", Gateway: "127.0.0.1", Interface: "lo0"},
+		{Family: "ipv4", Destination: "192.168.1", Gateway: "link#6", Interface: "en0"},
+		{Family: "ipv6", Destination: "default", Gateway: "fe80::1%en0", Interface: "en0", Default: true},
+		{Family: "ipv6", Destination: "::1", Gateway: "::1", Interface: "lo0"},
+	})
+	assertRoutes(t, "net-tools", parseNetstatRoutes(netstatNetToolsFixture), []routeEntry{
+		{Family: "ipv4", Destination: "0.0.0.0", Gateway: "10.0.0.1", Interface: "eth0", Default: true},
+		{Family: "ipv4", Destination: "10.0.0.0", Gateway: "0.0.0.0", Interface: "eth0"},
+	})
+	// 没有表头的输出不解析出路由，结果为空数组而不是 null
+	if routes := parseNetstatRoutes("netstat: sysctl: Operation not permitted\n"); routes == nil || len(routes) != 0 {
+		t.Errorf("output without a header = %+v", routes)
+	}
+}
+
+func TestParseArpOutput(t *testing.T) {
+	got := parseArpOutput(arpFixture)
+	want := []neighborEntry{
+		{IP: "192.168.1.1", MAC: "aa:bb:cc:dd:ee:ff", Interface: "en0"},
+		{IP: "224.0.0.251", MAC: "1:0:5e:0:0:fb", Interface: "en0"},
+		{IP: "10.0.0.1", MAC: "52:54:00:12:34:56", Interface: "eth0"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseArpOutput() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("neighbor %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// newRoutesTool 从夹具 /proc 读取、命令输出为 outputs 的路由表工具
+func newRoutesTool(t *testing.T, files map[string]string, outputs map[string]string) *NetworkRoutesTool {
+	t.Helper()
+	return &NetworkRoutesTool{procRoot: writeTree(t, files), run: fakeCommands(outputs)}
+}
+
+func TestNetworkRoutesCollectProc(t *testing.T) {
+	tool := newRoutesTool(t, map[string]string{"net/route": procNetRouteFixture, "net/arp": procNetArpFixture}, nil)
+
+	// IPv6 路由表不可读时只说明，不影响 IPv4
+	report, err := tool.collectProc(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Routes) != 2 || len(report.Neighbors) != 1 || len(report.Notes) != 1 || !strings.HasPrefix(report.Notes[0], "无法读取 /proc/net/ipv6_route") {
+		t.Fatalf("report = %+v", report)
+	}
+	if report, err := tool.collectProc(false); err != nil || report.Neighbors != nil {
+		t.Errorf("neighbors read without include_neighbors: %+v, %v", report, err)
+	}
+
+	// 路由表都不可读时返回错误；工具不注册
+	empty := newRoutesTool(t, map[string]string{"net/arp": procNetArpFixture}, nil)
+	if _, err := empty.collectProc(false); err == nil {
+		t.Error("collectProc() without route tables succeeded")
+	}
+	if runtime.GOOS == "linux" && (empty.Available() || !tool.Available()) {
+		t.Errorf("Available() = %v/%v, want it to follow the readable route tables", tool.Available(), empty.Available())
+	}
+}
+
+func TestNetworkRoutesCollectCommands(t *testing.T) {
+	tool := newRoutesTool(t, nil, map[string]string{"netstat": netstatDarwinFixture, "arp": arpFixture})
+	report, err := tool.collectCommands(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Source != "netstat -rn" || len(report.Routes) != 5 || len(report.Neighbors) != 3 {
+		t.Fatalf("report = %+v", report)
+	}
+
+	// arp 不可用时只说明；netstat 超时或不可用时返回错误
+	tool = newRoutesTool(t, nil, map[string]string{"netstat": netstatDarwinFixture})
+	if report, err := tool.collectCommands(context.Background(), true); err != nil || len(report.Notes) != 1 || !strings.Contains(report.Notes[0], "未找到命令 arp") {
+		t.Errorf("report without arp = %+v, %v", report, err)
+	}
+	timeout := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return nil, newError(ErrTimeout, "%s 执行超时（%s）", name, commandTimeout)
+	}
+	var toolErr *Error
+	if _, err := (&NetworkRoutesTool{run: timeout}).collectCommands(context.Background(), false); !errors.As(err, &toolErr) || toolErr.Code != ErrTimeout {
+		t.Errorf("netstat timeout = %v, want ERR_TIMEOUT", err)
+	}
+}
+
+func TestNetworkRoutesOutput(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("network_routes reads /proc/net only on Linux")
+	}
+	tool := newRoutesTool(t, map[string]string{"net/route": procNetRouteFixture, "net/ipv6_route": procNetIPv6RouteFixture, "net/arp": procNetArpFixture}, nil)
+
+	text, err := tool.Execute(context.Background(), map[string]interface{}{"include_neighbors": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"默认网关 (ipv4): 192.168.1.1 via eth0\n",
+		"默认网关 (ipv6): fe80::1 via eth0\n",
+		"⭐ 0.0.0.0/0 ",
+		"   192.168.1.0/24 ",
+		"🔎 邻居缓存 (ARP)\n",
+		"192.168.1.1                              aa:bb:cc:dd:ee:ff    eth0\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "docker0") || strings.Contains(text, "192.168.1.77") {
+		t.Errorf("output includes a down route or an unresolved neighbor:\n%s", text)
+	}
+}
@@ -0,0 +1,167 @@
+package tools
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// OOM 风险等级
+const (
+	oomRiskLow    = "low"
+	oomRiskMedium = "medium"
+	oomRiskHigh   = "high"
+)
+
+// OOM 风险等级对应的最低分数
+const (
+	oomRiskMediumScore = 2
+	oomRiskHighScore   = 4
+)
+
+// maxSwapInInterval 两次采样间隔超过该值时不再计算换入速率（太久以前的平均值不代表近期压力）
+const maxSwapInInterval = 10 * time.Minute
+
+// minSwapInInterval 两次采样间隔小于该值时不计算换入速率（间隔太短，速率抖动大）
+const minSwapInInterval = time.Second
+
+// oomRiskLevels 风险等级的数值，用于健康检查（low 0、medium 1、high 2）
+var oomRiskLevels = map[string]float64{
+	oomRiskLow:    0,
+	oomRiskMedium: 1,
+	oomRiskHigh:   2,
+}
+
+// hasOOMRiskInputs 平台是否提供评估 OOM 风险所需的换入计数和内存提交统计（仅 Linux）
+func hasOOMRiskInputs(goos string) bool {
+	return goos == platformLinux
+}
+
+// assessOOMRisk 根据输入计算 OOM 风险：每个因素按严重程度计 1-3 分，
+// 总分达到 2 为 medium、达到 4 为 high。未知的输入（nil）不计分
+func assessOOMRisk(inputs types.OOMRiskInputs) types.OOMRisk {
+	risk := types.OOMRisk{Inputs: inputs, Factors: []types.OOMRiskFactor{}}
+	add := func(factor string, value float64, points int, description string) {
+		risk.Factors = append(risk.Factors, types.OOMRiskFactor{Factor: factor, Value: value, Points: points, Description: description})
+		risk.Score += points
+	}
+
+	switch available := inputs.AvailablePercent; {
+	case available < 5:
+		add("available_memory", available, 3, fmt.Sprintf("可用内存仅剩 %.1f%%", available))
+	case available < 10:
+		add("available_memory", available, 2, fmt.Sprintf("可用内存仅剩 %.1f%%", available))
+	case available < 20:
+		add("available_memory", available, 1, fmt.Sprintf("可用内存低于 20%%（%.1f%%）", available))
+	}
+
+	// 没有交换空间时内存耗尽会直接触发 OOM，只在可用内存已经偏低时计分
+	switch free := inputs.SwapFreePercent; {
+	case inputs.SwapTotal == 0:
+		if inputs.AvailablePercent < 20 {
+			add("no_swap", 0, 1, "没有交换空间作为缓冲")
+		}
+	case free < 10:
+		add("swap_free", free, 2, fmt.Sprintf("交换空间仅剩 %.1f%%", free))
+	case free < 25:
+		add("swap_free", free, 1, fmt.Sprintf("交换空间剩余不足 25%%（%.1f%%）", free))
+	}
+
+	if rate := inputs.SwapInRate; rate != nil {
+		switch {
+		case *rate >= 10<<20:
+			add("swap_in_rate", *rate, 2, fmt.Sprintf("持续换入 %s/s，内存压力大", formatBytes(uint64(*rate))))
+		case *rate >= 1<<20:
+			add("swap_in_rate", *rate, 1, fmt.Sprintf("正在换入 %s/s", formatBytes(uint64(*rate))))
+		}
+	}
+
+	if ratio := inputs.CommitRatio; ratio != nil && *ratio > 1 {
+		add("commit_ratio", *ratio, 1, fmt.Sprintf("已提交内存为提交上限的 %.0f%%（Committed_AS > CommitLimit）", *ratio*100))
+	}
+
+	switch {
+	case risk.Score >= oomRiskHighScore:
+		risk.Level = oomRiskHigh
+	case risk.Score >= oomRiskMediumScore:
+		risk.Level = oomRiskMedium
+	default:
+		risk.Level = oomRiskLow
+	}
+	return risk
+}
+
+// swapSample 换入计数的一次采样
+type swapSample struct {
+	SwapIn uint64
+	At     time.Time
+}
+
+// swapInTracker 保存上一次的换入计数，用相邻两次采样（包括后台采集和其他工具的调用）计算近期换入速率
+type swapInTracker struct {
+	mu   sync.Mutex
+	last *swapSample
+}
+
+// observe 记录本次采样并返回与上一次采样之间的换入速率（字节/秒），
+// 没有上一次采样、间隔不在 [minSwapInInterval, maxSwapInInterval] 内或计数器变小（重置）时返回 nil
+func (st *swapInTracker) observe(swapIn uint64, now time.Time) *float64 {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	prev := st.last
+	elapsed := time.Duration(0)
+	if prev != nil {
+		elapsed = now.Sub(prev.At)
+		// 间隔太短时保留旧的基线，避免连续调用时总是得不到速率
+		if elapsed >= 0 && elapsed < minSwapInInterval {
+			return nil
+		}
+	}
+	st.last = &swapSample{SwapIn: swapIn, At: now}
+
+	if prev == nil || elapsed <= 0 || elapsed > maxSwapInInterval {
+		return nil
+	}
+	delta, ok := counterDelta(prev.SwapIn, swapIn)
+	if !ok {
+		return nil
+	}
+	rate := float64(delta) / elapsed.Seconds()
+	return &rate
+}
+
+// formatOOMRisk 格式化 OOM 风险评估
+func formatOOMRisk(risk *types.OOMRisk) string {
+	if risk == nil {
+		return ""
+	}
+
+	var result string
+	result += "\n🧯 OOM 风险\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	if risk.Level == "" {
+		return result + fmt.Sprintf("ℹ️  %s\n", risk.Note)
+	}
+
+	switch risk.Level {
+	case oomRiskHigh:
+		result += "风险: 🔴 高\n"
+	case oomRiskMedium:
+		result += "风险: ⚠️  中\n"
+	default:
+		result += "风险: ✅ 低\n"
+	}
+	for _, factor := range risk.Factors {
+		result += fmt.Sprintf("  • %s\n", factor.Description)
+	}
+	if risk.Inputs.SwapInRate != nil {
+		result += fmt.Sprintf("换入速率: %s/s\n", formatBytes(uint64(*risk.Inputs.SwapInRate)))
+	}
+	if risk.Note != "" {
+		result += fmt.Sprintf("ℹ️  %s\n", risk.Note)
+	}
+	return result
+}
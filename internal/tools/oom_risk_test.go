@@ -0,0 +1,194 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+func TestAssessOOMRisk(t *testing.T) {
+	rate := func(value float64) *float64 { return &value }
+	cases := []struct {
+		name    string
+		inputs  types.OOMRiskInputs
+		level   string
+		score   int
+		factors string
+	}{
+		{"healthy", types.OOMRiskInputs{AvailablePercent: 60, SwapTotal: 4 * gb, SwapFreePercent: 100, SwapInRate: rate(0), CommitRatio: rate(0.5)}, oomRiskLow, 0, ""},
+		// 没有交换空间本身不是风险，可用内存偏低时才计分
+		{"no swap, plenty available", types.OOMRiskInputs{AvailablePercent: 50}, oomRiskLow, 0, ""},
+		{"no swap, low available", types.OOMRiskInputs{AvailablePercent: 15}, oomRiskMedium, 2, "available_memory:1 no_swap:1"},
+		{"low available with swap", types.OOMRiskInputs{AvailablePercent: 15, SwapTotal: 4 * gb, SwapFreePercent: 90}, oomRiskLow, 1, "available_memory:1"},
+		{"very low available", types.OOMRiskInputs{AvailablePercent: 8, SwapTotal: 4 * gb, SwapFreePercent: 90}, oomRiskMedium, 2, "available_memory:2"},
+		{"almost out", types.OOMRiskInputs{AvailablePercent: 3, SwapTotal: 4 * gb, SwapFreePercent: 90}, oomRiskMedium, 3, "available_memory:3"},
+		{"almost out without swap", types.OOMRiskInputs{AvailablePercent: 3}, oomRiskHigh, 4, "available_memory:3 no_swap:1"},
+		// 交换空间耗尽加上持续换入：可用内存看起来还够，但已在抖动
+		{"thrashing", types.OOMRiskInputs{AvailablePercent: 25, SwapTotal: 4 * gb, SwapFreePercent: 5, SwapInRate: rate(20 << 20)}, oomRiskHigh, 4, "swap_free:2 swap_in_rate:2"},
+		{"swap filling", types.OOMRiskInputs{AvailablePercent: 40, SwapTotal: 4 * gb, SwapFreePercent: 20, SwapInRate: rate(2 << 20)}, oomRiskMedium, 2, "swap_free:1 swap_in_rate:1"},
+		{"slow swap-in", types.OOMRiskInputs{AvailablePercent: 40, SwapTotal: 4 * gb, SwapFreePercent: 80, SwapInRate: rate(512 << 10)}, oomRiskLow, 0, ""},
+		// 超额提交只在 Committed_AS 超过 CommitLimit 时计分
+		{"overcommitted", types.OOMRiskInputs{AvailablePercent: 15, SwapTotal: 4 * gb, SwapFreePercent: 50, CommitRatio: rate(1.3)}, oomRiskMedium, 2, "available_memory:1 commit_ratio:1"},
+		{"at commit limit", types.OOMRiskInputs{AvailablePercent: 40, SwapTotal: 4 * gb, SwapFreePercent: 50, CommitRatio: rate(1)}, oomRiskLow, 0, ""},
+		{"everything", types.OOMRiskInputs{AvailablePercent: 2, SwapTotal: 4 * gb, SwapFreePercent: 1, SwapInRate: rate(50 << 20), CommitRatio: rate(2)}, oomRiskHigh, 8, "available_memory:3 swap_free:2 swap_in_rate:2 commit_ratio:1"},
+	}
+	for _, c := range cases {
+		risk := assessOOMRisk(c.inputs)
+		var factors []string
+		for _, factor := range risk.Factors {
+			factors = append(factors, fmt.Sprintf("%s:%d", factor.Factor, factor.Points))
+		}
+		if risk.Level != c.level || risk.Score != c.score || strings.Join(factors, " ") != c.factors {
+			t.Errorf("%s: assessOOMRisk() = %s (%d) %v; want %s (%d) %s", c.name, risk.Level, risk.Score, factors, c.level, c.score, c.factors)
+		}
+		if risk.Factors == nil || risk.Inputs != c.inputs {
+			t.Errorf("%s: factors %v, inputs %+v", c.name, risk.Factors, risk.Inputs)
+		}
+	}
+}
+
+func TestSwapInTracker(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var tracker swapInTracker
+	steps := []struct {
+		name   string
+		swapIn uint64
+		at     time.Duration
+		rate   float64
+		ok     bool
+	}{
+		{"first sample", 1000, 0, 0, false},
+		{"10 seconds later", 1000 + 10<<20, 10 * time.Second, 1 << 20, true},
+		// 间隔太短时不计算，保留旧的基线
+		{"too soon", 1000 + 20<<20, 10*time.Second + 500*time.Millisecond, 0, false},
+		{"after too soon", 1000 + 30<<20, 20 * time.Second, 2 << 20, true},
+		// 间隔太长的平均值不代表近期压力
+		{"too long ago", 1000 + 40<<20, 20*time.Second + 11*time.Minute, 0, false},
+		{"after long gap", 1000 + 40<<20, 20*time.Second + 12*time.Minute, 0, true},
+		// 计数器变小（重置）
+		{"reset", 10, 20*time.Second + 13*time.Minute, 0, false},
+		// 时钟回退
+		{"clock went back", 20, 20*time.Second + 12*time.Minute, 0, false},
+	}
+	for _, s := range steps {
+		rate := tracker.observe(s.swapIn, start.Add(s.at))
+		if (rate != nil) != s.ok || (rate != nil && *rate != s.rate) {
+			t.Errorf("%s: observe() = %v, want %v (%v)", s.name, rate, s.rate, s.ok)
+		}
+	}
+}
+
+func TestMemoryToolOOMRisk(t *testing.T) {
+	useFakeMem(t, &fakeMemProvider{
+		virtual: VirtualMemoryStat{Total: 16 * gb, Available: 1 * gb, Used: 15 * gb, UsedPercent: 93.75},
+		swap:    SwapMemoryStat{Total: 4 * gb, Free: 200 << 20, Used: 4*gb - 200<<20, UsedPercent: 95, Sin: 1 << 30},
+	})
+	tool := NewMemoryTool(storage.NewMemoryCache(), CacheOptions{}, NewOutputStyle(StylePlain, 0))
+	tool.platform = platformLinux
+	tool.meminfoPath = writeTree(t, map[string]string{"meminfo": "MemTotal: 16777216 kB\nCommitLimit: 10000000 kB\nCommitted_AS: 15000000 kB\n"}) + "/meminfo"
+
+	info, err := tool.GetMemoryData(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	risk := info.OOMRisk
+	// 可用 6.25%、交换空间剩余 4.9%、超额提交；首次采样没有换入速率
+	if risk == nil || risk.Level != oomRiskHigh || risk.Score != 5 || risk.Inputs.SwapInRate != nil || *risk.Inputs.CommitRatio != 1.5 {
+		t.Fatalf("OOMRisk = %+v", risk)
+	}
+	if !strings.Contains(risk.Note, "换入速率未计入") {
+		t.Errorf("Note = %q", risk.Note)
+	}
+
+	// 换入速率使用相邻两次采样
+	now := time.Now()
+	tool.swapIn.observe(0, now.Add(-10*time.Second))
+	risk = tool.assessOOMRisk(info, 100<<20, now)
+	if risk.Inputs.SwapInRate == nil || *risk.Inputs.SwapInRate != 10<<20 || risk.Level != oomRiskHigh || risk.Note != "" {
+		t.Errorf("with swap-in rate = %+v", risk)
+	}
+
+	// 读取不到 /proc/meminfo 时不计入提交比例，并说明原因
+	tool.meminfoPath = writeTree(t, nil) + "/meminfo"
+	risk = tool.assessOOMRisk(info, 0, now.Add(time.Minute))
+	if risk.Inputs.CommitRatio != nil || !strings.Contains(risk.Note, "内存提交统计不可用") {
+		t.Errorf("without meminfo = %+v", risk)
+	}
+
+	// 缺少输入的平台不给出等级，只有说明
+	tool.platform = platformDarwin
+	risk = tool.assessOOMRisk(info, 0, now)
+	if risk.Level != "" || risk.Factors == nil || !strings.Contains(risk.Note, "不评估 OOM 风险") {
+		t.Errorf("darwin OOMRisk = %+v", risk)
+	}
+	if text := formatOOMRisk(risk); !strings.Contains(text, "ℹ️  当前平台（darwin）不提供换入计数和内存提交统计，不评估 OOM 风险\n") || strings.Contains(text, "风险:") {
+		t.Errorf("darwin output:\n%s", text)
+	}
+}
+
+func TestFormatOOMRisk(t *testing.T) {
+	swapIn := float64(20 << 20)
+	risk := assessOOMRisk(types.OOMRiskInputs{AvailablePercent: 25, SwapTotal: 4 * gb, SwapFreePercent: 5, SwapInRate: &swapIn})
+	want := "\n🧯 OOM 风险\n" +
+		"━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n" +
+		"风险: 🔴 高\n" +
+		"  • 交换空间仅剩 5.0%\n" +
+		"  • 持续换入 20.00 MB/s，内存压力大\n" +
+		"换入速率: 20.00 MB/s\n"
+	if got := formatOOMRisk(&risk); got != want {
+		t.Errorf("formatOOMRisk() = %q, want %q", got, want)
+	}
+
+	low := assessOOMRisk(types.OOMRiskInputs{AvailablePercent: 80})
+	low.Note = "尚无近期的上一次采样"
+	if got := formatOOMRisk(&low); !strings.Contains(got, "风险: ✅ 低\nℹ️  尚无近期的上一次采样\n") {
+		t.Errorf("low risk output = %q", got)
+	}
+	if got := formatOOMRisk(nil); got != "" {
+		t.Errorf("formatOOMRisk(nil) = %q", got)
+	}
+}
+
+func TestOOMRiskHealthCheck(t *testing.T) {
+	cases := []struct {
+		level    string
+		severity string
+	}{
+		{oomRiskLow, severityOK},
+		{oomRiskMedium, severityWarning},
+		{oomRiskHigh, severityCritical},
+	}
+	for _, c := range cases {
+		check, _, ok := oomRiskCheck(&types.OOMRisk{Level: c.level})
+		if !ok {
+			t.Fatalf("%s: no check", c.level)
+		}
+		if severity, _ := evaluateThreshold(check.Value, check.Threshold); severity != c.severity {
+			t.Errorf("%s: severity %s, want %s", c.level, severity, c.severity)
+		}
+	}
+
+	// 平台不支持时不检查，说明进入报告备注
+	if _, note, ok := oomRiskCheck(&types.OOMRisk{Note: "不评估 OOM 风险"}); ok || note != "不评估 OOM 风险" {
+		t.Errorf("unsupported platform: note %q, ok %v", note, ok)
+	}
+	if _, note, ok := oomRiskCheck(nil); ok || note != "" {
+		t.Errorf("nil risk: note %q, ok %v", note, ok)
+	}
+
+	// 健康报告列出 medium 及以上等级的因素
+	risk := assessOOMRisk(types.OOMRiskInputs{AvailablePercent: 3})
+	text := (&HealthReportTool{}).formatReport(healthReport{healthSummary: evaluateChecks(nil), OOMRisk: &risk, GeneratedAt: time.Now()})
+	if !strings.Contains(text, "\n🧯 OOM 风险 high 的因素:\n  • 可用内存仅剩 3.0%\n  • 没有交换空间作为缓冲\n") {
+		t.Errorf("health report output:\n%s", text)
+	}
+	low := assessOOMRisk(types.OOMRiskInputs{AvailablePercent: 80})
+	if text := (&HealthReportTool{}).formatReport(healthReport{healthSummary: evaluateChecks(nil), OOMRisk: &low, GeneratedAt: time.Now()}); strings.Contains(text, "OOM 风险") {
+		t.Errorf("low risk shown in health report:\n%s", text)
+	}
+}
@@ -0,0 +1,487 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/types"
+)
+
+// 进程打开文件扫描限制
+const (
+	// maxOpenFilesProcesses 单次调用最多扫描的进程数，超过后停止扫描并返回已扫描的部分
+	maxOpenFilesProcesses = 10000
+	// openFilesTimeout 单次调用的扫描时限
+	openFilesTimeout = 15 * time.Second
+	// openFilesWorkers 并发扫描的进程数
+	openFilesWorkers = 8
+	// openFilesProgressInterval 每扫描多少个进程报告一次进度
+	openFilesProgressInterval = 500
+)
+
+// deletedSuffix Linux 中指向已删除文件的 fd 符号链接目标的后缀
+const deletedSuffix = " (deleted)"
+
+// OpenFilesTool 打开文件工具：找出打开了指定路径下文件的进程，或（仅 Linux）已删除但仍被打开的文件
+type OpenFilesTool struct {
+	procRoot string
+	platform string
+}
+
+// NewOpenFilesTool 创建新的打开文件工具
+func NewOpenFilesTool() *OpenFilesTool {
+	return &OpenFilesTool{
+		procRoot: "/proc",
+		platform: hostPlatform,
+	}
+}
+
+// openFile 被进程打开的单个文件
+type openFile struct {
+	PID      int32  `json:"pid"`
+	Process  string `json:"process"`
+	Username string `json:"username,omitempty"`
+	FD       uint64 `json:"fd"`
+	Path     string `json:"path"`
+	Deleted  bool   `json:"deleted,omitempty"`
+	// SizeBytes 文件大小，只在 deleted_only 时读取
+	SizeBytes uint64 `json:"size_bytes,omitempty"`
+	// info 已删除文件的信息，用于识别被多次打开的同一文件
+	info fs.FileInfo
+}
+
+// openFilesStats 进程扫描统计（JSON 输出）
+type openFilesStats struct {
+	Scanned int `json:"scanned_processes"`
+	// PermissionDenied 没有权限读取打开文件的进程数
+	PermissionDenied int `json:"permission_denied,omitempty"`
+	// OtherErrors 读取失败（通常是扫描期间已退出）的进程数
+	OtherErrors int `json:"other_errors,omitempty"`
+	// Truncated 表示因进程数上限或时限提前停止，结果只覆盖已扫描的部分
+	Truncated       bool   `json:"truncated"`
+	TruncatedReason string `json:"truncated_reason,omitempty"`
+	ElapsedMs       int64  `json:"elapsed_ms"`
+}
+
+// openFilesReport 打开文件扫描结果
+type openFilesReport struct {
+	Path        string     `json:"path,omitempty"`
+	DeletedOnly bool       `json:"deleted_only"`
+	Files       []openFile `json:"files"`
+	// Matching 匹配的打开文件数，Files 只保留前 limit 个
+	Matching int `json:"matching_count"`
+	// Processes 持有匹配文件的进程数
+	Processes int `json:"process_count"`
+	// WastedBytes 已删除文件仍占用的空间（同一文件被多次打开只计一次），只在 deleted_only 时统计
+	WastedBytes uint64 `json:"wasted_bytes,omitempty"`
+	openFilesStats
+	Host *types.HostIdentity `json:"host,omitempty"`
+}
+
+// GetName 获取工具名称
+func (of *OpenFilesTool) GetName() string {
+	return "open_files"
+}
+
+// GetDescription 获取工具描述
+func (of *OpenFilesTool) GetDescription() string {
+	return "找出打开了指定文件或目录（挂载点）下文件的进程，或已删除但仍被打开、继续占用空间的文件（仅 Linux）"
+}
+
+// GetAnnotations 获取工具注解
+func (of *OpenFilesTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("打开的文件")
+}
+
+// openFilesArgs open_files 的参数
+type openFilesArgs struct {
+	strictArgs
+	Path        string `arg:"path" desc:"文件或目录的绝对路径，目录时匹配其下所有文件；deleted_only=true 时可省略（扫描所有路径）"`
+	DeletedOnly bool   `arg:"deleted_only,default=false" desc:"只列出已删除但仍被打开的文件，并统计其占用的空间（仅 Linux）"`
+	Limit       int    `arg:"limit,default=50,min=1,max=500" desc:"最多列出的打开文件数"`
+	formatArgs
+}
+
+// GetInputSchema 获取输入模式
+func (of *OpenFilesTool) GetInputSchema() types.InputSchema {
+	return argsSchema(openFilesArgs{})
+}
+
+// Examples 获取调用示例
+func (of *OpenFilesTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "哪些进程占用着 /data（无法卸载时）",
+			Arguments:   map[string]interface{}{"path": "/data"},
+		},
+		{
+			Description: "已删除但仍被打开的文件及其占用的空间",
+			Arguments:   map[string]interface{}{"deleted_only": "true"},
+		},
+		{
+			Description: "/var/log 下已删除但仍被打开的文件，JSON 格式",
+			Arguments:   map[string]interface{}{"path": "/var/log", "deleted_only": "true", "format": "json"},
+		},
+	}
+}
+
+// Execute 扫描进程打开的文件
+func (of *OpenFilesTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a openFilesArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+	deletedOnly, path, limit := a.DeletedOnly, a.Path, a.Limit
+	if deletedOnly && of.platform != platformLinux {
+		return "", unsupportedPlatform("deleted_only 仅支持 Linux（当前平台: %s）", of.platform)
+	}
+	if of.platform != platformLinux && of.platform != platformWindows {
+		return "", unsupportedPlatform("open_files 仅支持 Linux 和 Windows（当前平台: %s）", of.platform)
+	}
+
+	switch {
+	case path == "" && !deletedOnly:
+		return "", argumentError("path", "必须提供 path（或 deleted_only=true）")
+	case path != "" && !filepath.IsAbs(path):
+		return "", argumentError("path", "path 必须是绝对路径: %s", path)
+	case path != "":
+		path = resolveOpenFilesPath(path)
+	}
+
+	processes, err := providers.Process.Processes(ctx)
+	if err != nil {
+		return "", wrapError("获取进程列表失败", err)
+	}
+
+	scan := of.processOpenFiles
+	if deletedOnly {
+		scan = of.processDeletedFiles
+	}
+	files, stats, err := scanOpenFiles(ctx, processes, func(ctx context.Context, p Process) ([]openFile, error) {
+		files, err := scan(ctx, p)
+		return filterOpenFiles(files, path), err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	report := newOpenFilesReport(files, path, deletedOnly, limit)
+	report.openFilesStats = stats
+
+	if a.Format == "json" {
+		report.Host = identity.Get()
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", wrapError("序列化打开文件信息失败", err)
+		}
+		return string(jsonData), nil
+	}
+
+	return formatOpenFilesReport(report, limit), nil
+}
+
+// resolveOpenFilesPath 解析路径中的符号链接（进程打开的文件路径是解析后的），路径已不存在时（如已删除的文件）只做清理
+func resolveOpenFilesPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
+
+// processOpenFiles 读取进程打开的普通文件，Linux 上标记已删除的文件
+func (of *OpenFilesTool) processOpenFiles(ctx context.Context, p Process) ([]openFile, error) {
+	stats, err := p.OpenFilesWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]openFile, 0, len(stats))
+	for _, stat := range stats {
+		path, deleted := parseFDTarget(stat.Path)
+		if path == "" {
+			continue
+		}
+		files = append(files, openFile{PID: p.PID(), FD: stat.Fd, Path: path, Deleted: deleted})
+	}
+	return files, nil
+}
+
+// processDeletedFiles 读取进程打开的已删除文件及其大小
+func (of *OpenFilesTool) processDeletedFiles(ctx context.Context, p Process) ([]openFile, error) {
+	files, err := readDeletedFDs(filepath.Join(of.procRoot, strconv.Itoa(int(p.PID())), "fd"))
+	for i := range files {
+		files[i].PID = p.PID()
+	}
+	return files, err
+}
+
+// readDeletedFDs 读取 fdDir（/proc/<pid>/fd）中指向已删除文件的描述符。
+// 大小通过 fd 符号链接读取（内核会解析到仍被打开的文件），读取失败时为 0
+func readDeletedFDs(fdDir string) ([]openFile, error) {
+	entries, err := os.ReadDir(fdDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []openFile
+	for _, entry := range entries {
+		fd, err := strconv.ParseUint(entry.Name(), 10, 64)
+		if err != nil {
+			continue
+		}
+		// 描述符可能在读取期间被关闭
+		target, err := os.Readlink(filepath.Join(fdDir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		path, deleted := parseFDTarget(target)
+		if path == "" || !deleted {
+			continue
+		}
+
+		file := openFile{FD: fd, Path: path, Deleted: true}
+		if info, err := os.Stat(filepath.Join(fdDir, entry.Name())); err == nil && info.Mode().IsRegular() {
+			file.SizeBytes = uint64(info.Size())
+			file.info = info
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// parseFDTarget 解析 fd 符号链接的目标，返回文件路径及是否已删除。
+// 套接字、管道、匿名 inode（目标不是绝对路径）以及 memfd 和 System V 共享内存（不占用磁盘空间）返回空路径
+func parseFDTarget(target string) (string, bool) {
+	if !filepath.IsAbs(target) || strings.HasPrefix(target, "/memfd:") || strings.HasPrefix(target, "/SYSV") {
+		return "", false
+	}
+	if path, found := strings.CutSuffix(target, deletedSuffix); found {
+		return path, true
+	}
+	return target, false
+}
+
+// filterOpenFiles 只保留 path 本身或其下的文件，path 为空时全部保留
+func filterOpenFiles(files []openFile, path string) []openFile {
+	if path == "" {
+		return files
+	}
+
+	prefix := path
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+	matched := files[:0]
+	for _, file := range files {
+		if file.Path == path || strings.HasPrefix(file.Path, prefix) {
+			matched = append(matched, file)
+		}
+	}
+	return matched
+}
+
+// scanOpenFiles 以 openFilesWorkers 个 goroutine 并发对每个进程调用 scan，并补充进程名和用户名。
+// 最多扫描 maxOpenFilesProcesses 个进程、openFilesTimeout 时长，超出时返回已扫描的部分；
+// 没有权限和读取失败的进程计数后跳过
+func scanOpenFiles(ctx context.Context, processes []Process, scan func(ctx context.Context, p Process) ([]openFile, error)) ([]openFile, openFilesStats, error) {
+	start := time.Now()
+	var stats openFilesStats
+	if len(processes) > maxOpenFilesProcesses {
+		processes = processes[:maxOpenFilesProcesses]
+		stats.Truncated = true
+		stats.TruncatedReason = fmt.Sprintf("超过 %d 个进程的上限", maxOpenFilesProcesses)
+	}
+
+	scanCtx, cancel := context.WithTimeout(ctx, openFilesTimeout)
+	defer cancel()
+	reportProgress(ctx, 0, float64(len(processes)), "开始扫描进程打开的文件")
+
+	var mutex sync.Mutex
+	var files []openFile
+	usernames := make(usernameCache)
+
+	forEachProcess(scanCtx, processes, openFilesWorkers, func(p Process) {
+		found, err := scan(scanCtx, p)
+		if scanCtx.Err() != nil {
+			return
+		}
+		var name, username string
+		if len(found) > 0 {
+			name, _ = p.NameWithContext(scanCtx)
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		stats.Scanned++
+		switch {
+		case errors.Is(err, fs.ErrPermission):
+			stats.PermissionDenied++
+		case err != nil:
+			stats.OtherErrors++
+		}
+		if len(found) > 0 {
+			username = usernames.lookup(scanCtx, p)
+		}
+		for _, file := range found {
+			file.Process = name
+			file.Username = username
+			files = append(files, file)
+		}
+		if stats.Scanned%openFilesProgressInterval == 0 {
+			reportProgress(ctx, float64(stats.Scanned), float64(len(processes)), fmt.Sprintf("已扫描 %d 个进程", stats.Scanned))
+		}
+	})
+
+	if err := ctx.Err(); err != nil {
+		return nil, stats, err
+	}
+	if scanCtx.Err() != nil {
+		stats.Truncated = true
+		stats.TruncatedReason = fmt.Sprintf("超过 %s 时限", openFilesTimeout)
+	}
+	stats.ElapsedMs = time.Since(start).Milliseconds()
+	reportProgress(ctx, float64(stats.Scanned), float64(len(processes)), "扫描完成")
+	return files, stats, nil
+}
+
+// newOpenFilesReport 汇总扫描结果：统计进程数和已删除文件占用的空间，排序后保留前 limit 个。
+// deleted_only 时按大小降序，否则按路径、PID 和 fd 排列
+func newOpenFilesReport(files []openFile, path string, deletedOnly bool, limit int) openFilesReport {
+	report := openFilesReport{Path: path, DeletedOnly: deletedOnly, Files: []openFile{}, Matching: len(files)}
+
+	pids := make(map[int32]bool)
+	for _, file := range files {
+		pids[file.PID] = true
+	}
+	report.Processes = len(pids)
+	if deletedOnly {
+		report.WastedBytes = wastedBytes(files)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if deletedOnly && files[i].SizeBytes != files[j].SizeBytes {
+			return files[i].SizeBytes > files[j].SizeBytes
+		}
+		if files[i].Path != files[j].Path {
+			return files[i].Path < files[j].Path
+		}
+		if files[i].PID != files[j].PID {
+			return files[i].PID < files[j].PID
+		}
+		return files[i].FD < files[j].FD
+	})
+	if len(files) > limit {
+		files = files[:limit]
+	}
+	if len(files) > 0 {
+		report.Files = files
+	}
+	return report
+}
+
+// wastedBytes 已删除文件占用的空间，被多个描述符或进程打开的同一文件只计一次
+func wastedBytes(files []openFile) uint64 {
+	var total uint64
+	var counted []fs.FileInfo
+	for _, file := range files {
+		if file.info == nil || containsSameFile(counted, file.info) {
+			continue
+		}
+		counted = append(counted, file.info)
+		total += file.SizeBytes
+	}
+	return total
+}
+
+// containsSameFile infos 中是否有与 info 相同的文件（设备号和 inode 相同）
+func containsSameFile(infos []fs.FileInfo, info fs.FileInfo) bool {
+	for _, counted := range infos {
+		if os.SameFile(counted, info) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatOpenFilesReport 格式化打开文件扫描结果
+func formatOpenFilesReport(report openFilesReport, limit int) string {
+	var result string
+
+	switch {
+	case report.DeletedOnly && report.Path != "":
+		result += fmt.Sprintf("🗑️ %s 下已删除但仍被打开的文件\n", report.Path)
+	case report.DeletedOnly:
+		result += "🗑️ 已删除但仍被打开的文件\n"
+	default:
+		result += fmt.Sprintf("📂 打开了 %s 的进程\n", report.Path)
+	}
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+
+	if len(report.Files) == 0 {
+		result += "没有匹配的打开文件\n"
+	} else {
+		if report.DeletedOnly {
+			result += fmt.Sprintf("%-8s %-20s %-12s %-6s %-12s %s\n", "PID", "进程名", "用户", "FD", "大小", "路径")
+		} else {
+			result += fmt.Sprintf("%-8s %-20s %-12s %-6s %s\n", "PID", "进程名", "用户", "FD", "路径")
+		}
+		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for _, file := range report.Files {
+			name := fitColumn(file.Process, 20)
+			username := file.Username
+			if username == "" {
+				username = "-"
+			}
+			if report.DeletedOnly {
+				result += fmt.Sprintf("%-8d %s %-12s %-6d %-12s %s\n", file.PID, name, username, file.FD, formatBytes(file.SizeBytes), file.Path)
+				continue
+			}
+			path := file.Path
+			if file.Deleted {
+				path += "（已删除）"
+			}
+			result += fmt.Sprintf("%-8d %s %-12s %-6d %s\n", file.PID, name, username, file.FD, path)
+		}
+		if report.Matching > limit {
+			result += fmt.Sprintf("\n显示 %d / %d 个打开的文件，可调大 limit\n", limit, report.Matching)
+		}
+	}
+
+	result += fmt.Sprintf("\n📊 %d 个进程打开了 %d 个匹配的文件", report.Processes, report.Matching)
+	if report.DeletedOnly {
+		result += fmt.Sprintf("，已删除文件仍占用 %s（同一文件只计一次）", formatBytes(report.WastedBytes))
+	}
+	result += "\n"
+	result += formatOpenFilesStats(report.openFilesStats)
+
+	return result
+}
+
+// formatOpenFilesStats 格式化进程扫描统计：扫描的进程数、耗时、是否完整及跳过的进程
+func formatOpenFilesStats(stats openFilesStats) string {
+	var result string
+
+	result += fmt.Sprintf("🔍 扫描了 %d 个进程，耗时 %.2fs\n", stats.Scanned, float64(stats.ElapsedMs)/1000)
+	if stats.Truncated {
+		result += fmt.Sprintf("⚠️ 扫描未完成（%s），结果只覆盖已扫描的部分\n", stats.TruncatedReason)
+	}
+	if stats.PermissionDenied > 0 {
+		result += fmt.Sprintf("🔒 %d 个进程没有权限读取打开的文件，已跳过（以 root 运行可查看全部进程）\n", stats.PermissionDenied)
+	}
+	if stats.OtherErrors > 0 {
+		result += fmt.Sprintf("⚠️ %d 个进程读取失败（可能在扫描期间已退出），已跳过\n", stats.OtherErrors)
+	}
+
+	return result
+}
@@ -0,0 +1,268 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseFDTarget(t *testing.T) {
+	cases := []struct {
+		target  string
+		path    string
+		deleted bool
+	}{
+		{"/var/log/app.log", "/var/log/app.log", false},
+		{"/var/log/app.log (deleted)", "/var/log/app.log", true},
+		// 套接字、管道和匿名 inode 没有路径
+		{"socket:[12345]", "", false},
+		{"pipe:[678]", "", false},
+		{"anon_inode:[eventpoll]", "", false},
+		// memfd 和 System V 共享内存不占用磁盘空间
+		{"/memfd:buffer (deleted)", "", false},
+		{"/SYSV00000000 (deleted)", "", false},
+	}
+	for _, c := range cases {
+		path, deleted := parseFDTarget(c.target)
+		if path != c.path || deleted != c.deleted {
+			t.Errorf("parseFDTarget(%q) = %q, %v; want %q, %v", c.target, path, deleted, c.path, c.deleted)
+		}
+	}
+}
+
+func TestFilterOpenFiles(t *testing.T) {
+	files := func() []openFile {
+		return []openFile{{Path: "/data"}, {Path: "/data/db/1.sst"}, {Path: "/database/x"}, {Path: "/var/log/syslog"}}
+	}
+	paths := func(files []openFile) string {
+		var result []string
+		for _, file := range files {
+			result = append(result, file.Path)
+		}
+		return strings.Join(result, " ")
+	}
+
+	cases := []struct {
+		path string
+		want string
+	}{
+		// 目录匹配其本身和其下的文件，不匹配同名前缀的兄弟目录
+		{"/data", "/data /data/db/1.sst"},
+		{"/data/", "/data/db/1.sst"},
+		{"/var/log/syslog", "/var/log/syslog"},
+		{"/", "/data /data/db/1.sst /database/x /var/log/syslog"},
+		{"", "/data /data/db/1.sst /database/x /var/log/syslog"},
+		{"/tmp", ""},
+	}
+	for _, c := range cases {
+		if got := paths(filterOpenFiles(files(), c.path)); got != c.want {
+			t.Errorf("filterOpenFiles(%q) = %q, want %q", c.path, got, c.want)
+		}
+	}
+}
+
+// fakeProcRoot 在临时目录中构造 /proc/<pid>/fd 布局，fds 为各进程的描述符及其符号链接目标
+func fakeProcRoot(t *testing.T, fds map[int32]map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for pid, links := range fds {
+		fdDir := filepath.Join(root, strconv.Itoa(int(pid)), "fd")
+		if err := os.MkdirAll(fdDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for fd, target := range links {
+			if err := os.Symlink(target, filepath.Join(fdDir, fd)); err != nil {
+				t.Skipf("无法创建符号链接: %v", err)
+			}
+		}
+	}
+	return root
+}
+
+// deletedFixture 创建名称以 " (deleted)" 结尾的文件，fd 符号链接指向它时读取到的大小与内核解析到已删除文件时相同
+func deletedFixture(t *testing.T, dir, name string, size int) (target, path string) {
+	t.Helper()
+	path = filepath.Join(dir, name)
+	target = path + deletedSuffix
+	if err := os.WriteFile(target, make([]byte, size), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return target, path
+}
+
+func TestReadDeletedFDs(t *testing.T) {
+	dir := t.TempDir()
+	bigTarget, bigPath := deletedFixture(t, dir, "big.log", 4096)
+	live := filepath.Join(dir, "live.log")
+	if err := os.WriteFile(live, []byte("live"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root := fakeProcRoot(t, map[int32]map[string]string{100: {
+		"3":   bigTarget,
+		"4":   live,
+		"5":   "socket:[12345]",
+		"6":   "/memfd:buffer (deleted)",
+		"7":   "/var/log/gone.log (deleted)",
+		"cwd": bigTarget,
+	}})
+
+	files, err := readDeletedFDs(filepath.Join(root, "100", "fd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 只保留已删除的文件；读取不到大小的记为 0
+	got := make(map[uint64]openFile)
+	for _, file := range files {
+		got[file.FD] = file
+	}
+	if len(files) != 2 || got[3].Path != bigPath || got[3].SizeBytes != 4096 || !got[3].Deleted || got[7].Path != "/var/log/gone.log" || got[7].SizeBytes != 0 {
+		t.Errorf("readDeletedFDs() = %+v", files)
+	}
+
+	if _, err := readDeletedFDs(filepath.Join(root, "999", "fd")); !os.IsNotExist(err) {
+		t.Errorf("missing process: err = %v", err)
+	}
+}
+
+func TestOpenFilesDeletedOnly(t *testing.T) {
+	dir := t.TempDir()
+	bigTarget, bigPath := deletedFixture(t, dir, "big.log", 8192)
+	smallTarget, smallPath := deletedFixture(t, dir, "small.log", 1024)
+	// 两个进程打开同一个已删除的文件，占用的空间只计一次
+	root := fakeProcRoot(t, map[int32]map[string]string{
+		100: {"3": bigTarget, "4": bigTarget},
+		200: {"5": bigTarget, "6": smallTarget, "7": "/other/x.log (deleted)"},
+	})
+	useFakeProcesses(t, newFakeProcessProvider(
+		&fakeProcess{pid: 100, name: "nginx", username: "www"},
+		&fakeProcess{pid: 200, name: "logger", username: "root"},
+		// 没有 fd 目录的进程视为已退出
+		&fakeProcess{pid: 300, name: "gone"},
+	))
+
+	tool := NewOpenFilesTool()
+	tool.procRoot, tool.platform = root, platformLinux
+	text, err := tool.Execute(context.Background(), map[string]interface{}{"deleted_only": true, "path": dir, "format": "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report openFilesReport
+	if err := json.Unmarshal([]byte(text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Matching != 4 || report.Processes != 2 || report.WastedBytes != 9216 || report.Scanned != 3 || report.OtherErrors != 1 {
+		t.Errorf("report = %+v", report)
+	}
+	// 按大小降序，相同大小按路径、PID 和 fd
+	var order []string
+	for _, file := range report.Files {
+		order = append(order, file.Process+":"+strconv.FormatUint(file.FD, 10))
+	}
+	if strings.Join(order, " ") != "nginx:3 nginx:4 logger:5 logger:6" || report.Files[3].Path != smallPath || report.Files[0].Path != bigPath {
+		t.Errorf("files = %v", order)
+	}
+
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"deleted_only": true, "limit": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"🗑️ 已删除但仍被打开的文件\n",
+		"\n100      nginx                www          3      8.00 KB      " + bigPath + "\n",
+		"\n显示 1 / 5 个打开的文件，可调大 limit\n",
+		"\n📊 2 个进程打开了 5 个匹配的文件，已删除文件仍占用 9.00 KB（同一文件只计一次）\n",
+		"⚠️ 1 个进程读取失败（可能在扫描期间已退出），已跳过\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output lacks %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestOpenFilesByPath(t *testing.T) {
+	useFakeProcesses(t, newFakeProcessProvider(
+		&fakeProcess{pid: 10, name: "postgres", username: "postgres", openFiles: []OpenFilesStat{{Fd: 5, Path: "/data/pg/base/1"}, {Fd: 6, Path: "/var/log/pg.log"}}},
+		&fakeProcess{pid: 20, name: "backup", username: "root", openFiles: []OpenFilesStat{{Fd: 3, Path: "/data/dump.sql (deleted)"}}},
+		&fakeProcess{pid: 30, name: "sshd", username: "root", openFiles: []OpenFilesStat{{Fd: 3, Path: "/database/x"}}},
+	))
+
+	tool := NewOpenFilesTool()
+	tool.platform = platformLinux
+	text, err := tool.Execute(context.Background(), map[string]interface{}{"path": "/data"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"📂 打开了 /data 的进程\n",
+		"\n10       postgres             postgres     5      /data/pg/base/1\n",
+		"\n20       backup               root         3      /data/dump.sql（已删除）\n",
+		"\n📊 2 个进程打开了 2 个匹配的文件\n",
+		"🔍 扫描了 3 个进程",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output lacks %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "sshd") || strings.Contains(text, "pg.log") {
+		t.Errorf("output lists files outside /data:\n%s", text)
+	}
+
+	// 参数校验
+	for _, args := range []map[string]interface{}{{}, {"path": "data"}} {
+		if _, err := tool.Execute(context.Background(), args); err == nil {
+			t.Errorf("Execute(%v) succeeded", args)
+		}
+	}
+}
+
+func TestOpenFilesOwnProcess(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("deleted_only 仅支持 Linux")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "held.log")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.Write(make([]byte, 3000)); err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 测试进程自身打开的文件
+	tool := NewOpenFilesTool()
+	text, err := tool.Execute(context.Background(), map[string]interface{}{"path": dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid := strconv.Itoa(os.Getpid())
+	if !strings.Contains(text, "\n"+pid+" ") || !strings.Contains(text, resolved+"\n") {
+		t.Errorf("own open file missing:\n%s", text)
+	}
+
+	// 删除后仍被打开，deleted_only 能找到并统计大小
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"path": dir, "deleted_only": true, "format": "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report openFilesReport
+	if err := json.Unmarshal([]byte(text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Files) != 1 || report.Files[0].PID != int32(os.Getpid()) || report.Files[0].Path != resolved || report.WastedBytes != 3000 {
+		t.Errorf("deleted report = %+v", report)
+	}
+}
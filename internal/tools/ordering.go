@@ -0,0 +1,13 @@
+package tools
+
+import "sort"
+
+// sortedKeys 返回排序后的 map 键，保证格式化输出的顺序稳定
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixedTime 确定性测试中统一的采集时间
+var fixedTime = time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local)
+
+func newOrderingNet() *fakeNetProvider {
+	return &fakeNetProvider{
+		counters: []NetIOCountersStat{
+			{Name: "wlan0", BytesSent: 3 << 20, BytesRecv: 4 << 20},
+			{Name: "eth1", BytesSent: 5 << 20, BytesRecv: 6 << 20},
+			{Name: "lo", BytesSent: 1 << 20, BytesRecv: 1 << 20},
+			{Name: "eth0", BytesSent: 1 << 20, BytesRecv: 2 << 20},
+			{Name: "docker0", BytesSent: 7 << 20, BytesRecv: 8 << 20},
+		},
+		connections: []ConnectionStat{
+			connection(1, 2, "0.0.0.0", 22, "", 0, "LISTEN", 100),
+			connection(1, 10, "::", 443, "", 0, "LISTEN", 200),
+			connection(1, 2, "10.0.0.5", 22, "10.0.0.9", 51000, "ESTABLISHED", 300),
+			connection(1, 2, "10.0.0.5", 22, "10.0.0.8", 51000, "ESTABLISHED", 301),
+			connection(1, 2, "10.0.0.5", 40000, "1.1.1.1", 443, "TIME_WAIT", 0),
+			connection(1, 2, "10.0.0.5", 40001, "1.1.1.1", 443, "CLOSE_WAIT", 0),
+			connection(2, 2, "0.0.0.0", 53, "", 0, "NONE", 400),
+			connection(2, 10, "::", 5353, "", 0, "NONE", 401),
+		},
+	}
+}
+
+func TestNetworkOutputIsDeterministic(t *testing.T) {
+	useFakeNet(t, newOrderingNet())
+	nt := NewNetworkTool(nil, CacheOptions{}, 0, nil)
+	query := connectionQuery{Limit: defaultConnectionLimit}
+
+	render := func() string {
+		netInfo, err := nt.getNetworkInfo(context.Background(), true, "", query)
+		if err != nil {
+			t.Fatalf("getNetworkInfo: %v", err)
+		}
+		netInfo.LastUpdated = fixedTime
+		return nt.formatNetworkInfo(netInfo, nil, true)
+	}
+
+	first := render()
+	for i := 0; i < 20; i++ {
+		if again := render(); again != first {
+			t.Fatalf("output differs between identical calls:\n%s\n---\n%s", first, again)
+		}
+	}
+
+	netInfo, _ := nt.getNetworkInfo(context.Background(), false, "", query)
+	var names []string
+	for _, iface := range netInfo.Interfaces {
+		names = append(names, iface.Name)
+	}
+	if got := strings.Join(names, ","); got != "docker0,eth0,eth1,wlan0" {
+		t.Fatalf("interfaces = %s, want sorted by name without loopback", got)
+	}
+}
+
+func TestProcessConnectionsSortsTies(t *testing.T) {
+	connections := newOrderingNet().connections
+	want := processConnections(connections, connectionQuery{Limit: defaultConnectionLimit})
+	for i := 0; i < 20; i++ {
+		got := processConnections(shuffled(connections), connectionQuery{Limit: defaultConnectionLimit})
+		for j := range want.Details {
+			if got.Details[j] != want.Details[j] {
+				t.Fatalf("detail %d = %+v, want %+v", j, got.Details[j], want.Details[j])
+			}
+		}
+	}
+	// 本地地址和端口相同的两条连接按远程地址排序
+	if first, second := want.Details[2], want.Details[3]; first.RemoteIP != "10.0.0.8" || second.RemoteIP != "10.0.0.9" {
+		t.Fatalf("ties ordered as %s, %s; want by remote address", first.RemoteIP, second.RemoteIP)
+	}
+}
+
+func newOrderingProcesses() *fakeProcessProvider {
+	var processes []*fakeProcess
+	// 内存和 CPU 完全相同的进程只能按 PID 区分先后
+	for _, pid := range []int32{907, 12, 450, 3, 88, 1201} {
+		processes = append(processes, &fakeProcess{
+			pid:        pid,
+			name:       "worker",
+			ppid:       1,
+			cmdline:    []string{"/usr/bin/worker"},
+			status:     []string{"S"},
+			createTime: fixedTime.Add(-time.Hour).UnixMilli(),
+			memory:     &MemoryInfoStat{RSS: 64 << 20},
+		})
+	}
+	processes = append(processes, &fakeProcess{
+		pid: 5000, name: "big", ppid: 1, cmdline: []string{"big"}, status: []string{"R"},
+		createTime: fixedTime.Add(-2 * time.Hour).UnixMilli(), memory: &MemoryInfoStat{RSS: 512 << 20},
+	})
+	return newFakeProcessProvider(processes...)
+}
+
+func TestProcessListIsDeterministic(t *testing.T) {
+	useFakeProcesses(t, newOrderingProcesses())
+
+	for _, sortBy := range []string{"memory", "cpu", sortByAge} {
+		query := processQuery{SortBy: sortBy, Limit: 10, GroupBy: groupByNone}
+		var first string
+		for i := 0; i < 10; i++ {
+			// 每次使用新的工具实例，不受上次采集的 CPU 基线影响
+			pt := NewProcessTool(nil, CacheOptions{})
+			processList, err := pt.collectProcesses(context.Background(), query)
+			if err != nil {
+				t.Fatalf("collectProcesses: %v", err)
+			}
+			processList.LastUpdated = fixedTime
+			output := pt.formatProcessList(processList, query)
+			if i == 0 {
+				first = output
+			} else if output != first {
+				t.Fatalf("sort %s: output differs between identical calls:\n%s\n---\n%s", sortBy, first, output)
+			}
+		}
+	}
+
+	pt := NewProcessTool(nil, CacheOptions{})
+	processList, _ := pt.collectProcesses(context.Background(), processQuery{SortBy: "memory", Limit: 10, GroupBy: groupByNone})
+	var pids []int32
+	for _, proc := range processList.Processes {
+		pids = append(pids, proc.PID)
+	}
+	want := []int32{5000, 3, 12, 88, 450, 907, 1201}
+	if len(pids) != len(want) {
+		t.Fatalf("pids = %v, want %v", pids, want)
+	}
+	for i := range want {
+		if pids[i] != want[i] {
+			t.Fatalf("pids = %v, want %v (ties broken by PID)", pids, want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"bytes"
+	"sync"
+)
+
+// separatorLine 文本输出中标题、表头与内容之间的分隔线
+const separatorLine = "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+
+// maxPooledOutput 放回池中的输出缓冲区的容量上限，更大的缓冲区（如上千个连接的输出）用完即丢弃，
+// 避免池长期占用偶发的大块内存
+const maxPooledOutput = 256 << 10
+
+// outputPool 逐行输出较多的格式化函数（进程、连接、分区列表）共用的缓冲区。
+// 逐行 result += 每次都复制已有的全部文本，行数多时产生大量垃圾；后台采集和频繁调用并存时会拉长 GC 停顿
+var outputPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// getOutput 从池中取出空的输出缓冲区，并预留 size 字节（按行数估计的输出大小）
+func getOutput(size int) *bytes.Buffer {
+	buf := outputPool.Get().(*bytes.Buffer)
+	buf.Grow(size)
+	return buf
+}
+
+// putOutput 返回缓冲区中的文本并将缓冲区放回池中，之后不能再使用 buf
+func putOutput(buf *bytes.Buffer) string {
+	text := buf.String()
+	if buf.Cap() <= maxPooledOutput {
+		buf.Reset()
+		outputPool.Put(buf)
+	}
+	return text
+}
@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"sync"
+	"testing"
+
+	"mcp-example/internal/fixtures"
+)
+
+// newOutputTools 格式化大输出使用的进程、网络和磁盘工具，权限固定为没有限制
+func newOutputTools() (*ProcessTool, *NetworkTool, *DiskTool) {
+	processTool := NewProcessTool(nil, CacheOptions{})
+	processTool.platform = platformLinux
+	processTool.permissions = fixtures.Permissions
+	networkTool := NewNetworkTool(nil, CacheOptions{}, 0, nil)
+	networkTool.permissions = fixtures.Permissions
+	diskTool := NewDiskTool(nil, CacheOptions{}, PartitionFilter{}, NewOutputStyle(StyleRich, 0), nil)
+	return processTool, networkTool, diskTool
+}
+
+func TestGoldenLargeOutput(t *testing.T) {
+	processTool, networkTool, _ := newOutputTools()
+	assertGolden(t, "top_processes_100", processTool.formatProcessList(fixtures.ManyProcesses(100), processQuery{SortBy: "memory", Limit: 100}))
+	assertGolden(t, "network_stats_200", networkTool.formatNetworkInfo(fixtures.ManyConnections(200), nil, true))
+}
+
+// TestPooledOutputReuse 池中的缓冲区被不同大小的输出复用后，结果与单独格式化时逐字节相同
+func TestPooledOutputReuse(t *testing.T) {
+	processTool, networkTool, diskTool := newOutputTools()
+	large := fixtures.ManyProcesses(100)
+	small := fixtures.ProcessList()
+	query := processQuery{SortBy: "memory", Limit: 100}
+
+	formatters := []func() string{
+		func() string { return processTool.formatProcessList(large, query) },
+		func() string { return processTool.formatProcessList(small, query) },
+		func() string { return networkTool.formatNetworkInfo(fixtures.ManyConnections(200), nil, true) },
+		func() string { return diskTool.formatDiskInfo(fixtures.DiskInfo(), false, nil) },
+	}
+	want := make([]string, len(formatters))
+	for i, format := range formatters {
+		want[i] = format()
+	}
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for round := 0; round < 20; round++ {
+				i := (worker + round) % len(formatters)
+				if got := formatters[i](); got != want[i] {
+					t.Errorf("formatter %d produced different output after reusing a pooled buffer", i)
+					return
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+}
+
+func BenchmarkFormatProcessList(b *testing.B) {
+	processTool, _, _ := newOutputTools()
+	processList := fixtures.ManyProcesses(100)
+	query := processQuery{SortBy: "memory", Limit: 100}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		processTool.formatProcessList(processList, query)
+	}
+}
+
+func BenchmarkFormatNetworkInfo(b *testing.B) {
+	_, networkTool, _ := newOutputTools()
+	netInfo := fixtures.ManyConnections(200)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		networkTool.formatNetworkInfo(netInfo, nil, true)
+	}
+}
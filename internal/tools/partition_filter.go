@@ -0,0 +1,187 @@
+package tools
+
+import (
+	"strings"
+)
+
+// 默认跳过的挂载点前缀：伪文件系统、snap 包和容器运行时的存储目录
+var defaultSkipMountpointPrefixes = []string{
+	"/dev", "/proc", "/sys", "/run", "/boot/efi",
+	"/snap", "/var/snap", "/tmp",
+	"/var/lib/docker", "/var/lib/containerd", "/var/lib/kubelet/pods",
+}
+
+// 默认跳过的文件系统类型
+var defaultSkipFstypes = []string{
+	"tmpfs", "devtmpfs", "sysfs", "proc", "devfs",
+	"squashfs", "overlay", "aufs", "fuse",
+}
+
+// Windows 上默认跳过的文件系统类型：光驱（盘符没有伪文件系统，不按挂载点跳过）
+var defaultWindowsSkipFstypes = []string{"CDFS", "UDF"}
+
+// 默认视为正常只读的文件系统类型：只读镜像和光盘格式
+var defaultExpectedReadOnlyFstypes = []string{
+	"squashfs", "iso9660", "udf", "erofs", "cramfs", "CDFS", "UDF",
+}
+
+// PartitionFilter 磁盘分区过滤规则。
+// 挂载点按路径前缀匹配，AlwaysShow* 优先于 Skip*。
+// Windows 上挂载点为盘符，"c:"、"C:\" 和 "C:/" 视为相同，挂载点和文件系统类型都不区分大小写。
+type PartitionFilter struct {
+	SkipMountpointPrefixes []string
+	SkipFstypes            []string
+	AlwaysShowMountpoints  []string
+	AlwaysShowFstypes      []string
+	// 只读挂载属于正常情况的挂载点（按前缀匹配）和文件系统类型，其他只读挂载视为异常（通常是出错后被重新挂载为只读）
+	ExpectedReadOnlyMountpoints []string
+	ExpectedReadOnlyFstypes     []string
+	platform                    string
+}
+
+// NewPartitionFilter 创建当前平台的分区过滤规则，跳过列表为 nil 时使用平台的默认列表（空列表表示不跳过）
+func NewPartitionFilter(skipMountpointPrefixes, skipFstypes, alwaysShowMountpoints, alwaysShowFstypes []string) PartitionFilter {
+	return newPartitionFilter(hostPlatform, skipMountpointPrefixes, skipFstypes, alwaysShowMountpoints, alwaysShowFstypes)
+}
+
+// newPartitionFilter 创建指定平台的分区过滤规则
+func newPartitionFilter(goos string, skipMountpointPrefixes, skipFstypes, alwaysShowMountpoints, alwaysShowFstypes []string) PartitionFilter {
+	if skipMountpointPrefixes == nil && goos != platformWindows {
+		skipMountpointPrefixes = defaultSkipMountpointPrefixes
+	}
+	if skipFstypes == nil {
+		skipFstypes = defaultSkipFstypes
+		if goos == platformWindows {
+			skipFstypes = defaultWindowsSkipFstypes
+		}
+	}
+
+	return PartitionFilter{
+		SkipMountpointPrefixes:  skipMountpointPrefixes,
+		SkipFstypes:             skipFstypes,
+		AlwaysShowMountpoints:   alwaysShowMountpoints,
+		AlwaysShowFstypes:       alwaysShowFstypes,
+		ExpectedReadOnlyFstypes: defaultExpectedReadOnlyFstypes,
+		platform:                goos,
+	}
+}
+
+// WithExpectedReadOnly 返回设置了正常只读挂载规则的副本，fstypes 为 nil 时保留默认的只读文件系统类型
+func (pf PartitionFilter) WithExpectedReadOnly(mountpoints, fstypes []string) PartitionFilter {
+	pf.ExpectedReadOnlyMountpoints = mountpoints
+	if fstypes != nil {
+		pf.ExpectedReadOnlyFstypes = fstypes
+	}
+	return pf
+}
+
+// ExpectedReadOnly 分区以只读方式挂载是否属于正常情况
+func (pf PartitionFilter) ExpectedReadOnly(mountpoint, fstype string) bool {
+	for _, prefix := range pf.ExpectedReadOnlyMountpoints {
+		if pf.matchMountpoint(mountpoint, prefix) {
+			return true
+		}
+	}
+
+	for _, expected := range pf.ExpectedReadOnlyFstypes {
+		if pf.matchFstype(fstype, expected) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Skip 判断是否应该跳过某个分区
+func (pf PartitionFilter) Skip(mountpoint, fstype string) bool {
+	if pf.alwaysShown(mountpoint, fstype) {
+		return false
+	}
+
+	for _, prefix := range pf.SkipMountpointPrefixes {
+		if pf.matchMountpoint(mountpoint, prefix) {
+			return true
+		}
+	}
+
+	for _, skip := range pf.SkipFstypes {
+		if pf.matchFstype(fstype, skip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// alwaysShown 是否命中始终显示的规则
+func (pf PartitionFilter) alwaysShown(mountpoint, fstype string) bool {
+	for _, prefix := range pf.AlwaysShowMountpoints {
+		if pf.matchMountpoint(mountpoint, prefix) {
+			return true
+		}
+	}
+
+	for _, show := range pf.AlwaysShowFstypes {
+		if pf.matchFstype(fstype, show) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchMountpoint 挂载点是否等于 prefix 或位于其下，Windows 上先统一盘符格式
+func (pf PartitionFilter) matchMountpoint(mountpoint, prefix string) bool {
+	return hasPathPrefix(normalizeMountpoint(pf.platform, mountpoint), normalizeMountpoint(pf.platform, prefix))
+}
+
+// matchFstype 文件系统类型是否相同，Windows 上不区分大小写（"NTFS" 与 "ntfs"）
+func (pf PartitionFilter) matchFstype(fstype, want string) bool {
+	if pf.platform == platformWindows {
+		return strings.EqualFold(fstype, want)
+	}
+	return fstype == want
+}
+
+// hasOverrides 是否配置了始终显示的规则
+func (pf PartitionFilter) hasOverrides() bool {
+	return len(pf.AlwaysShowMountpoints) > 0 || len(pf.AlwaysShowFstypes) > 0
+}
+
+// Apply 过滤分区列表。
+// all 为包含伪文件系统在内的完整列表（可为 nil），其中命中始终显示规则的分区也会被加入结果，
+// 因为 tmpfs 等文件系统不会出现在默认的分区枚举中。
+func (pf PartitionFilter) Apply(partitions, all []PartitionStat) []PartitionStat {
+	filtered := make([]PartitionStat, 0, len(partitions))
+	seen := make(map[string]bool)
+	for _, partition := range partitions {
+		if !pf.Skip(partition.Mountpoint, partition.Fstype) {
+			filtered = append(filtered, partition)
+			seen[partition.Mountpoint] = true
+		}
+	}
+
+	for _, partition := range all {
+		if seen[partition.Mountpoint] || !pf.alwaysShown(partition.Mountpoint, partition.Fstype) {
+			continue
+		}
+		filtered = append(filtered, partition)
+		seen[partition.Mountpoint] = true
+	}
+
+	return filtered
+}
+
+// hasPathPrefix 判断路径是否等于 prefix 或位于其下（"/snap" 匹配 "/snap/core/123"，不匹配 "/snapshots"）
+func hasPathPrefix(path, prefix string) bool {
+	if prefix != "/" {
+		prefix = strings.TrimSuffix(prefix, "/")
+	}
+	if prefix == "" {
+		return false
+	}
+	if path == prefix || prefix == "/" {
+		return true
+	}
+	return strings.HasPrefix(path, prefix+"/")
+}
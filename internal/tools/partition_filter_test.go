@@ -0,0 +1,221 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"mcp-example/internal/storage"
+)
+
+// partitionCase 一个分区及默认规则下是否跳过
+type partitionCase struct {
+	mountpoint string
+	fstype     string
+	skip       bool
+}
+
+// linuxPartitions 普通 Linux 服务器的挂载表
+var linuxPartitions = []partitionCase{
+	{"/", "ext4", false},
+	{"/boot", "ext4", false},
+	{"/boot/efi", "vfat", true},
+	{"/home", "xfs", false},
+	{"/dev", "devtmpfs", true},
+	{"/dev/shm", "tmpfs", true},
+	{"/run/user/1000", "tmpfs", true},
+	{"/proc", "proc", true},
+	{"/sys/fs/cgroup", "cgroup2", true},
+	{"/snap/core20/2105", "squashfs", true},
+	// 按路径前缀匹配，名称相似的目录不受影响
+	{"/snapshots", "btrfs", false},
+	{"/tmpdata", "ext4", false},
+	{"/mnt/usb", "fuseblk", false},
+	{"/mnt/sshfs", "fuse", true},
+}
+
+// kubernetesNodePartitions Kubernetes 节点：容器运行时和 kubelet 的挂载点大量出现
+var kubernetesNodePartitions = []partitionCase{
+	{"/", "ext4", false},
+	{"/var/lib/docker", "ext4", true},
+	{"/var/lib/docker/overlay2/3f2a9c/merged", "overlay", true},
+	{"/var/lib/containerd/io.containerd.snapshotter.v1.overlayfs/snapshots/12/fs", "ext4", true},
+	{"/run/containerd/io.containerd.runtime.v2.task/k8s.io/abc/rootfs", "overlay", true},
+	{"/var/lib/kubelet/pods/5e1f/volumes/kubernetes.io~secret/token", "tmpfs", true},
+	{"/var/lib/kubelet/plugins", "ext4", false},
+	{"/var/lib/etcd", "xfs", false},
+	{"/var/lib/dockerd-data", "ext4", false},
+}
+
+// darwinPartitions macOS 的挂载表
+var darwinPartitions = []partitionCase{
+	{"/", "apfs", false},
+	{"/System/Volumes/Data", "apfs", false},
+	{"/dev", "devfs", true},
+	{"/Volumes/Backup", "hfs", false},
+	{"/Volumes/Installer", "udf", false},
+}
+
+func TestPartitionFilterDefaults(t *testing.T) {
+	for _, fixture := range []struct {
+		name       string
+		platform   string
+		partitions []partitionCase
+	}{
+		{"linux", platformLinux, linuxPartitions},
+		{"kubernetes node", platformLinux, kubernetesNodePartitions},
+		{"darwin", platformDarwin, darwinPartitions},
+		{"windows", platformWindows, []partitionCase{
+			{`C:\`, "NTFS", false},
+			{`D:\`, "CDFS", true},
+			{`E:\`, "udf", true},
+			// Windows 上默认不按挂载点跳过
+			{`F:\`, "exFAT", false},
+		}},
+	} {
+		filter := newPartitionFilter(fixture.platform, nil, nil, nil, nil)
+		for _, p := range fixture.partitions {
+			if got := filter.Skip(p.mountpoint, p.fstype); got != p.skip {
+				t.Errorf("%s: Skip(%q, %q) = %v, want %v", fixture.name, p.mountpoint, p.fstype, got, p.skip)
+			}
+		}
+	}
+}
+
+func TestPartitionFilterConfigured(t *testing.T) {
+	cases := []struct {
+		name       string
+		filter     PartitionFilter
+		mountpoint string
+		fstype     string
+		skip       bool
+	}{
+		// 空列表表示不跳过，nil 才使用默认值
+		{"empty lists", newPartitionFilter(platformLinux, []string{}, []string{}, nil, nil), "/dev/shm", "tmpfs", false},
+		{"custom prefix", newPartitionFilter(platformLinux, []string{"/data/cache/"}, []string{}, nil, nil), "/data/cache/a", "ext4", true},
+		{"custom prefix parent", newPartitionFilter(platformLinux, []string{"/data/cache/"}, []string{}, nil, nil), "/data", "ext4", false},
+		{"custom fstype", newPartitionFilter(platformLinux, []string{}, []string{"nfs4"}, nil, nil), "/mnt/nas", "nfs4", true},
+		// 始终显示的规则优先于跳过列表
+		{"always show fstype", newPartitionFilter(platformLinux, nil, nil, nil, []string{"tmpfs"}), "/dev/shm", "tmpfs", false},
+		{"always show fstype keeps other skips", newPartitionFilter(platformLinux, nil, nil, nil, []string{"tmpfs"}), "/proc", "proc", true},
+		{"always show mountpoint", newPartitionFilter(platformLinux, nil, nil, []string{"/run/media"}, nil), "/run/media/alice/usb", "vfat", false},
+		{"root prefix skips everything", newPartitionFilter(platformLinux, []string{"/"}, []string{}, nil, nil), "/home", "ext4", true},
+		// Windows 上盘符的几种写法和文件系统类型都不区分大小写
+		{"windows drive forms", newPartitionFilter(platformWindows, []string{"d:"}, nil, nil, nil), `D:\`, "NTFS", true},
+		{"windows always show", newPartitionFilter(platformWindows, nil, nil, []string{"e:/"}, nil), `E:\`, "CDFS", false},
+		{"windows fstype case", newPartitionFilter(platformWindows, []string{}, []string{"ntfs"}, nil, nil), `C:\`, "NTFS", true},
+		// 其他平台区分大小写
+		{"linux fstype case", newPartitionFilter(platformLinux, []string{}, []string{"TMPFS"}, nil, nil), "/dev/shm", "tmpfs", false},
+	}
+	for _, c := range cases {
+		if got := c.filter.Skip(c.mountpoint, c.fstype); got != c.skip {
+			t.Errorf("%s: Skip(%q, %q) = %v, want %v", c.name, c.mountpoint, c.fstype, got, c.skip)
+		}
+	}
+}
+
+func TestPartitionFilterApply(t *testing.T) {
+	filter := newPartitionFilter(platformLinux, nil, nil, []string{"/dev/shm"}, nil)
+	partitions := []PartitionStat{
+		{Device: "/dev/sda1", Mountpoint: "/", Fstype: "ext4"},
+		{Device: "overlay", Mountpoint: "/var/lib/docker/overlay2/3f2a9c/merged", Fstype: "overlay"},
+	}
+	// tmpfs 不在默认的分区枚举中，命中始终显示规则时从完整列表中补充，且不重复
+	all := append(slices.Clone(partitions),
+		PartitionStat{Device: "tmpfs", Mountpoint: "/dev/shm", Fstype: "tmpfs"},
+		PartitionStat{Device: "tmpfs", Mountpoint: "/run", Fstype: "tmpfs"},
+		PartitionStat{Device: "/dev/sda1", Mountpoint: "/", Fstype: "ext4"},
+	)
+
+	var mountpoints []string
+	for _, partition := range filter.Apply(partitions, all) {
+		mountpoints = append(mountpoints, partition.Mountpoint)
+	}
+	if !slices.Equal(mountpoints, []string{"/", "/dev/shm"}) {
+		t.Errorf("Apply() = %v, want / and the always-shown /dev/shm", mountpoints)
+	}
+	if got := filter.Apply(nil, nil); got == nil || len(got) != 0 {
+		t.Errorf("Apply(nil) = %v, want an empty list", got)
+	}
+}
+
+func TestHasPathPrefix(t *testing.T) {
+	for _, c := range []struct {
+		path, prefix string
+		want         bool
+	}{
+		{"/snap", "/snap", true},
+		{"/snap/core/123", "/snap", true},
+		{"/snap/core/123", "/snap/", true},
+		{"/snapshots", "/snap", false},
+		{"/", "/", true},
+		{"/home", "/", true},
+		{"/home", "", false},
+		{"C:/Users", "C:", true},
+	} {
+		if got := hasPathPrefix(c.path, c.prefix); got != c.want {
+			t.Errorf("hasPathPrefix(%q, %q) = %v, want %v", c.path, c.prefix, got, c.want)
+		}
+	}
+}
+
+func TestDiskToolShowAllBypassesFilter(t *testing.T) {
+	usage := map[string]UsageStat{}
+	var partitions []PartitionStat
+	for _, p := range kubernetesNodePartitions {
+		partitions = append(partitions, PartitionStat{Device: "/dev/sda1", Mountpoint: p.mountpoint, Fstype: p.fstype})
+		usage[p.mountpoint] = UsageStat{Path: p.mountpoint, Fstype: p.fstype, Total: 100 * gb, Used: 10 * gb, Free: 90 * gb, UsedPercent: 10}
+	}
+	useFakeDisk(t, &fakeDiskProvider{partitions: partitions, usage: usage})
+	tool := NewDiskTool(storage.NewMemoryCache(), CacheOptions{}, newPartitionFilter(platformLinux, nil, nil, nil, nil), NewOutputStyle(StylePlain, 0), nil)
+
+	count := func(showAll bool) int {
+		t.Helper()
+		info, err := tool.GetDiskData(context.Background(), showAll)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(info.Partitions)
+	}
+	// 默认只显示真实的数据分区；show_all 跳过全部过滤规则
+	if got := count(false); got != 4 {
+		t.Errorf("%d partitions by default, want /, kubelet plugins, etcd and dockerd-data", got)
+	}
+	if got := count(true); got != len(kubernetesNodePartitions) {
+		t.Errorf("%d partitions with show_all, want all %d", got, len(kubernetesNodePartitions))
+	}
+}
+
+func TestPartitionFilterExpectedReadOnly(t *testing.T) {
+	linux := newPartitionFilter(platformLinux, nil, nil, nil, nil)
+	cases := []struct {
+		name       string
+		filter     PartitionFilter
+		mountpoint string
+		fstype     string
+		expected   bool
+	}{
+		// 只读镜像和光盘格式默认属于正常只读
+		{"squashfs", linux, "/snap/core/123", "squashfs", true},
+		{"iso9660", linux, "/media/cdrom", "iso9660", true},
+		{"erofs", linux, "/system", "erofs", true},
+		{"data partition", linux, "/data", "xfs", false},
+		{"root", linux, "/", "ext4", false},
+		// 配置的挂载点按前缀匹配
+		{"configured mountpoint", linux.WithExpectedReadOnly([]string{"/mnt/archive"}, nil), "/mnt/archive/2023", "ext4", true},
+		{"configured mountpoint sibling", linux.WithExpectedReadOnly([]string{"/mnt/archive"}, nil), "/mnt/archives", "ext4", false},
+		{"configured mountpoint keeps default fstypes", linux.WithExpectedReadOnly([]string{"/mnt/archive"}, nil), "/snap/x", "squashfs", true},
+		// 配置的类型替换默认列表
+		{"configured fstypes", linux.WithExpectedReadOnly(nil, []string{"nfs4"}), "/mnt/nas", "nfs4", true},
+		{"configured fstypes replace defaults", linux.WithExpectedReadOnly(nil, []string{"nfs4"}), "/snap/x", "squashfs", false},
+		{"empty fstypes", linux.WithExpectedReadOnly(nil, []string{}), "/media/cdrom", "iso9660", false},
+		// Windows 上盘符和类型都不区分大小写
+		{"windows cd", newPartitionFilter(platformWindows, nil, nil, nil, nil), `E:\`, "cdfs", true},
+		{"windows drive", newPartitionFilter(platformWindows, nil, nil, nil, nil).WithExpectedReadOnly([]string{"f:"}, nil), `F:\`, "NTFS", true},
+	}
+	for _, c := range cases {
+		if got := c.filter.ExpectedReadOnly(c.mountpoint, c.fstype); got != c.expected {
+			t.Errorf("%s: ExpectedReadOnly(%q, %q) = %v, want %v", c.name, c.mountpoint, c.fstype, got, c.expected)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"mcp-example/internal/fixtures"
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+// regularUser 以普通用户运行、无法读取其他用户进程和连接所属进程的探测结果
+func regularUser() *types.PermissionProfile {
+	return &types.PermissionProfile{EUID: 1000, Limitations: []string{"无法读取其他用户进程的 IO 计数和命令行", "无法确定其他用户的网络连接所属进程"}}
+}
+
+func TestProcessPermissionNote(t *testing.T) {
+	root := fixtures.Permissions()
+	readable := regularUser()
+	readable.OtherProcessIO = true
+
+	cases := []struct {
+		name         string
+		profile      *types.PermissionProfile
+		inaccessible int
+		want         string
+	}{
+		{"user with skipped processes", regularUser(), 214, "⚠️ 以普通用户运行，部分进程信息不可见 (跳过 214 个)\n"},
+		// 没有跳过进程时仍说明其他用户进程的信息不完整
+		{"user without io access", regularUser(), 0, "⚠️ 以普通用户运行，其他用户进程的 IO 计数和命令行不可见\n"},
+		{"user with io access", readable, 0, ""},
+		// root 跳过的进程只可能是已退出的
+		{"root with skipped processes", root, 3, "⚠️ 跳过 3 个无法读取的进程（可能已退出）\n"},
+		{"root", root, 0, ""},
+		{"no profile", nil, 2, "⚠️ 跳过 2 个无法读取的进程（可能已退出）\n"},
+	}
+	for _, c := range cases {
+		if got := processPermissionNote(types.ProcessList{Inaccessible: c.inaccessible}, c.profile); got != c.want {
+			t.Errorf("%s: processPermissionNote() = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestTopProcessesCountsInaccessible(t *testing.T) {
+	processes := []*fakeProcess{
+		{pid: 1, name: "init", cmdline: []string{"/sbin/init"}, username: "root", memory: &MemoryInfoStat{RSS: 4 << 20}},
+		{pid: 2, name: "app", cmdline: []string{"/usr/bin/app"}, username: "alice", memory: &MemoryInfoStat{RSS: 8 << 20}},
+	}
+	for pid := int32(100); pid < 105; pid++ {
+		processes = append(processes, &fakeProcess{pid: pid, nameErr: fmt.Errorf("open /proc/%d/status: %w", pid, fs.ErrPermission)})
+	}
+	useFakeProcesses(t, newFakeProcessProvider(processes...))
+
+	tool := NewProcessTool(storage.NewMemoryCache(), CacheOptions{})
+	tool.permissions = regularUser
+	text, err := tool.Execute(context.Background(), map[string]interface{}{"format": "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 无法读取的进程计入总数，但不出现在列表中
+	if !strings.Contains(text, `"inaccessible": 5`) || !strings.Contains(text, `"total_count": 7`) || strings.Contains(text, `"pid": 100`) {
+		t.Errorf("JSON lacks the inaccessible count:\n%s", text)
+	}
+
+	// 文本输出说明跳过的数量
+	text, err = tool.Execute(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "\n⚠️ 以普通用户运行，部分进程信息不可见 (跳过 5 个)\n") {
+		t.Errorf("text output lacks the permission note:\n%s", text)
+	}
+}
+
+func TestNetworkStatsUnownedConnections(t *testing.T) {
+	connections := []ConnectionStat{
+		connection(1, 2, "0.0.0.0", 22, "", 0, "LISTEN", 0),
+		connection(1, 2, "10.0.0.5", 22, "10.0.0.9", 51000, "ESTABLISHED", 0),
+		connection(1, 2, "10.0.0.5", 8080, "10.0.0.9", 52000, "ESTABLISHED", 812),
+		// TIME_WAIT 本来就没有所属进程，不计入
+		connection(1, 2, "10.0.0.5", 443, "10.0.0.9", 53000, "TIME_WAIT", 0),
+	}
+	useFakeNet(t, &fakeNetProvider{counters: []NetIOCountersStat{{Name: "eth0"}}, connections: connections})
+
+	cases := []struct {
+		name    string
+		profile func() *types.PermissionProfile
+		want    string
+	}{
+		{"regular user", regularUser, "\n⚠️ 以普通用户运行，2 个监听或已建立的连接无法确定所属进程\n"},
+		// root 也看不到所属进程时说明可能属于其他 PID 命名空间
+		{"root", fixtures.Permissions, "\n⚠️ 2 个监听或已建立的连接没有可见的所属进程（可能属于其他 PID 命名空间）\n"},
+	}
+	for _, c := range cases {
+		tool := NewNetworkTool(storage.NewMemoryCache(), CacheOptions{}, 0, nil)
+		tool.permissions = c.profile
+		text, err := tool.Execute(context.Background(), map[string]interface{}{"show_connections": "true"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(text, c.want) {
+			t.Errorf("%s: text output lacks %q:\n%s", c.name, c.want, text)
+		}
+	}
+}
+
+func TestHealthReportPermissionSummary(t *testing.T) {
+	tool := &HealthReportTool{}
+	report := healthReport{healthSummary: healthSummary{Status: "ok"}, GeneratedAt: fixtures.At, Permissions: regularUser()}
+	want := "\n🔐 权限: 以普通用户运行（euid 1000）：无法读取其他用户进程的 IO 计数和命令行；无法确定其他用户的网络连接所属进程\n"
+	if text := tool.formatReport(report); !strings.Contains(text, want) {
+		t.Errorf("health report lacks the permission summary:\n%s", text)
+	}
+
+	report.Permissions = nil
+	if text := tool.formatReport(report); strings.Contains(text, "🔐") {
+		t.Errorf("health report without a profile mentions permissions:\n%s", text)
+	}
+}
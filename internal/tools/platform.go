@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// 平台标识（runtime.GOOS 的取值）
+const (
+	platformLinux   = "linux"
+	platformWindows = "windows"
+	platformDarwin  = "darwin"
+)
+
+// hostPlatform 当前平台，平台相关的判断都以参数形式接收平台标识，便于按平台分支
+var hostPlatform = runtime.GOOS
+
+// 平台支持程度
+const (
+	supportFull        = "full"
+	supportPartial     = "partial"
+	supportUnsupported = "unsupported"
+)
+
+// platformSupport 单个工具在某个平台上的支持情况
+type platformSupport struct {
+	Tool    string `json:"tool"`
+	Support string `json:"support"`
+	Note    string `json:"note,omitempty"`
+}
+
+// toolPlatformSupport 各工具在 goos 上的支持情况（只列出与平台相关的工具，其余工具在所有平台上完整支持）
+func toolPlatformSupport(goos string) []platformSupport {
+	switch goos {
+	case platformLinux:
+		return []platformSupport{
+			{Tool: "cpu_info", Support: supportFull},
+			{Tool: "memory_info", Support: supportFull},
+			{Tool: "kernel_params", Support: supportFull},
+			{Tool: "kernel_events", Support: supportFull},
+			{Tool: "process_churn", Support: supportFull},
+			{Tool: "network_routes", Support: supportFull},
+			{Tool: "time_sync", Support: supportFull},
+			{Tool: "system_overview", Support: supportFull},
+			{Tool: "open_files", Support: supportFull},
+			{Tool: "process_detail", Support: supportFull},
+		}
+	case platformWindows:
+		return []platformSupport{
+			{Tool: "cpu_info", Support: supportPartial, Note: "不支持 detailed 调度统计"},
+			{Tool: "memory_info", Support: supportPartial, Note: "无缓冲区/缓存字段，交换内存为页面文件，不支持 detailed"},
+			{Tool: "top_processes", Support: supportPartial, Note: "不提供进程状态，不区分内核线程"},
+			{Tool: "kernel_params", Support: supportUnsupported, Note: "仅支持 Linux"},
+			{Tool: "kernel_events", Support: supportUnsupported, Note: "仅支持 Linux"},
+			{Tool: "process_churn", Support: supportUnsupported, Note: "仅支持 Linux"},
+			{Tool: "network_routes", Support: supportUnsupported, Note: "未注册"},
+			{Tool: "time_sync", Support: supportPartial, Note: "仅报告系统时间和时区"},
+			{Tool: "system_overview", Support: supportPartial, Note: "Windows 没有系统负载"},
+			{Tool: "health_report", Support: supportPartial, Note: "不检查系统负载和僵尸进程"},
+			{Tool: "process_signal", Support: supportPartial, Note: "不支持 USR1/USR2"},
+			{Tool: "largest_directories", Support: supportPartial, Note: "不检查跨文件系统"},
+			{Tool: "recent_large_files", Support: supportPartial, Note: "不检查跨文件系统，不显示文件所有者"},
+			{Tool: "open_files", Support: supportPartial, Note: "不支持 deleted_only"},
+			{Tool: "process_detail", Support: supportPartial, Note: "不支持 include_environ 和 include_limits"},
+		}
+	default:
+		return []platformSupport{
+			{Tool: "cpu_info", Support: supportPartial, Note: "不支持 detailed 调度统计"},
+			{Tool: "memory_info", Support: supportPartial, Note: "不支持 detailed"},
+			{Tool: "top_processes", Support: supportPartial, Note: "不区分内核线程"},
+			{Tool: "kernel_params", Support: supportUnsupported, Note: "仅支持 Linux"},
+			{Tool: "kernel_events", Support: supportUnsupported, Note: "仅支持 Linux"},
+			{Tool: "process_churn", Support: supportUnsupported, Note: "仅支持 Linux"},
+			{Tool: "network_routes", Support: supportPartial, Note: "依赖 netstat 和 arp 命令"},
+			{Tool: "time_sync", Support: supportPartial, Note: "仅报告系统时间和时区"},
+			{Tool: "system_overview", Support: supportFull},
+			{Tool: "open_files", Support: supportUnsupported, Note: "仅支持 Linux 和 Windows"},
+			{Tool: "user_usage", Support: supportPartial, Note: "不统计文件描述符数"},
+			{Tool: "process_detail", Support: supportPartial, Note: "不支持 include_environ 和 include_limits"},
+		}
+	}
+}
+
+// HostPlatformNote 工具在当前平台上受限或不支持时的说明（带图标），完整支持时返回空字符串
+func HostPlatformNote(tool string) string {
+	for _, entry := range toolPlatformSupport(hostPlatform) {
+		if entry.Tool != tool || entry.Support == supportFull {
+			continue
+		}
+		label := "部分支持"
+		if entry.Support == supportUnsupported {
+			label = "不支持"
+		}
+		if entry.Note == "" {
+			return fmt.Sprintf("%s %s", supportIcon(entry.Support), label)
+		}
+		return fmt.Sprintf("%s %s: %s", supportIcon(entry.Support), label, entry.Note)
+	}
+	return ""
+}
+
+// supportIcon 支持程度对应的图标
+func supportIcon(support string) string {
+	switch support {
+	case supportFull:
+		return "✅"
+	case supportPartial:
+		return "⚠️"
+	default:
+		return "❌"
+	}
+}
+
+// isLoopbackInterface 判断是否为回环接口：Linux 的 lo、macOS/BSD 的 lo0，
+// Windows 的 "Loopback Pseudo-Interface N"
+func isLoopbackInterface(goos, name string) bool {
+	if goos == platformWindows {
+		return strings.HasPrefix(strings.ToLower(name), "loopback")
+	}
+	return name == "lo" || name == "lo0"
+}
+
+// hasLoadAverage 平台是否提供系统负载（Windows 没有对应的指标）
+func hasLoadAverage(goos string) bool {
+	return goos != platformWindows
+}
+
+// hasProcessStatus 平台是否提供进程状态（Windows 上 gopsutil 不实现，僵尸进程也不存在）
+func hasProcessStatus(goos string) bool {
+	return goos != platformWindows
+}
+
+// hasBufferCache 平台是否提供缓冲区/缓存内存，Windows 上这两项总为 0，空闲内存与可用内存相同
+func hasBufferCache(goos string) bool {
+	return goos != platformWindows
+}
+
+// normalizeMountpoint 统一挂载点格式以便比较。Windows 上路径不区分大小写，且盘符可能写作
+// "c:"、"C:\" 或 "C:/"：将反斜杠转换为 /、统一转为大写并去掉末尾的 /（三者都变为 "C:"）。
+// 其他平台原样返回。
+func normalizeMountpoint(goos, mountpoint string) string {
+	if goos != platformWindows {
+		return mountpoint
+	}
+
+	normalized := strings.ToUpper(strings.ReplaceAll(mountpoint, `\`, "/"))
+	if len(normalized) > 1 {
+		normalized = strings.TrimSuffix(normalized, "/")
+	}
+	return normalized
+}
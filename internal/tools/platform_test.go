@@ -0,0 +1,168 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mcp-example/internal/types"
+)
+
+func TestUnsupportedPlatformErrors(t *testing.T) {
+	churn := NewProcessChurnTool()
+	churn.platform = platformWindows
+	events := NewKernelEventsTool()
+	events.platform = platformDarwin
+	darwinFiles := NewOpenFilesTool()
+	darwinFiles.platform = platformDarwin
+	windowsFiles := NewOpenFilesTool()
+	windowsFiles.platform = platformWindows
+
+	cases := []struct {
+		name    string
+		tool    types.MonitorTool
+		args    map[string]interface{}
+		message string
+	}{
+		{"process_churn", churn, nil, "process_churn 仅支持 Linux（当前平台: windows）"},
+		{"kernel_events", events, nil, "kernel_events 仅支持 Linux（当前平台: darwin）"},
+		{"open_files", darwinFiles, nil, "open_files 仅支持 Linux 和 Windows（当前平台: darwin）"},
+		{"deleted_only", windowsFiles, map[string]interface{}{"deleted_only": true}, "deleted_only 仅支持 Linux（当前平台: windows）"},
+	}
+	for _, c := range cases {
+		_, err := c.tool.Execute(context.Background(), c.args)
+		// 错误代码和默认提示一致，分类时保持不变
+		var toolErr *Error
+		if !errors.As(err, &toolErr) || toolErr.Code != ErrUnsupportedPlatform || toolErr.Message != c.message ||
+			toolErr.Hint != defaultHints[ErrUnsupportedPlatform] || toolErr.Argument != "" {
+			t.Errorf("%s: Execute() = %#v, want %q", c.name, err, c.message)
+			continue
+		}
+		if classified := ClassifyError(wrapError("调用失败", err)); classified.Code != ErrUnsupportedPlatform {
+			t.Errorf("%s: wrapped error classified as %s", c.name, classified.Code)
+		}
+	}
+}
+
+func TestToolPlatformSupport(t *testing.T) {
+	valid := map[string]bool{supportFull: true, supportPartial: true, supportUnsupported: true}
+	for _, goos := range []string{platformLinux, platformWindows, platformDarwin, "freebsd"} {
+		seen := make(map[string]bool)
+		for _, entry := range toolPlatformSupport(goos) {
+			if seen[entry.Tool] {
+				t.Errorf("%s: %s listed twice", goos, entry.Tool)
+			}
+			seen[entry.Tool] = true
+			if !valid[entry.Support] {
+				t.Errorf("%s: %s support = %q", goos, entry.Tool, entry.Support)
+			}
+			// 受限或不支持时说明原因
+			if (entry.Support == supportFull) != (entry.Note == "") {
+				t.Errorf("%s: %s = %+v, want a note exactly when support is limited", goos, entry.Tool, entry)
+			}
+		}
+	}
+
+	// Linux 上所有列出的工具都完整支持；其他非 Windows 平台使用与 macOS 相同的表
+	for _, entry := range toolPlatformSupport(platformLinux) {
+		if entry.Support != supportFull {
+			t.Errorf("linux: %+v", entry)
+		}
+	}
+	if darwin, freebsd := toolPlatformSupport(platformDarwin), toolPlatformSupport("freebsd"); len(darwin) != len(freebsd) || darwin[0] != freebsd[0] {
+		t.Errorf("freebsd table differs from darwin")
+	}
+
+	// 实际返回平台错误的工具在表中标为不支持
+	for goos, unsupported := range map[string][]string{
+		platformWindows: {"kernel_params", "kernel_events", "process_churn"},
+		platformDarwin:  {"kernel_params", "kernel_events", "process_churn", "open_files"},
+	} {
+		for _, tool := range unsupported {
+			if support := supportOf(goos, tool); support != supportUnsupported {
+				t.Errorf("%s: %s support = %q, want unsupported", goos, tool, support)
+			}
+		}
+	}
+}
+
+// supportOf 工具在 goos 上的支持程度，表中没有时为完整支持
+func supportOf(goos, tool string) string {
+	for _, entry := range toolPlatformSupport(goos) {
+		if entry.Tool == tool {
+			return entry.Support
+		}
+	}
+	return supportFull
+}
+
+func TestHostPlatformNote(t *testing.T) {
+	previous := hostPlatform
+	t.Cleanup(func() { hostPlatform = previous })
+
+	hostPlatform = platformWindows
+	cases := map[string]string{
+		"kernel_events":   "❌ 不支持: 仅支持 Linux",
+		"system_overview": "⚠️ 部分支持: Windows 没有系统负载",
+		// 表中没有的工具完整支持
+		"disk_info": "",
+	}
+	for tool, want := range cases {
+		if got := HostPlatformNote(tool); got != want {
+			t.Errorf("windows: HostPlatformNote(%s) = %q, want %q", tool, got, want)
+		}
+	}
+
+	hostPlatform = platformLinux
+	if got := HostPlatformNote("kernel_events"); got != "" {
+		t.Errorf("linux: HostPlatformNote(kernel_events) = %q", got)
+	}
+}
+
+func TestPlatformPredicates(t *testing.T) {
+	loopback := []struct {
+		goos, name string
+		want       bool
+	}{
+		{platformLinux, "lo", true},
+		{platformLinux, "lo0", true},
+		{platformLinux, "eth0", false},
+		{platformLinux, "Loopback Pseudo-Interface 1", false},
+		{platformDarwin, "lo0", true},
+		{platformDarwin, "en0", false},
+		{platformWindows, "Loopback Pseudo-Interface 1", true},
+		{platformWindows, "loopback", true},
+		{platformWindows, "lo", false},
+		{platformWindows, "Ethernet", false},
+	}
+	for _, c := range loopback {
+		if got := isLoopbackInterface(c.goos, c.name); got != c.want {
+			t.Errorf("isLoopbackInterface(%s, %q) = %v, want %v", c.goos, c.name, got, c.want)
+		}
+	}
+
+	for _, goos := range []string{platformLinux, platformDarwin, platformWindows} {
+		want := goos != platformWindows
+		if hasLoadAverage(goos) != want || hasProcessStatus(goos) != want || hasBufferCache(goos) != want {
+			t.Errorf("%s: load %v, status %v, buffers %v; want %v", goos, hasLoadAverage(goos), hasProcessStatus(goos), hasBufferCache(goos), want)
+		}
+	}
+
+	mountpoints := []struct {
+		goos, mountpoint, want string
+	}{
+		{platformWindows, `C:\`, "C:"},
+		{platformWindows, "c:", "C:"},
+		{platformWindows, "C:/", "C:"},
+		{platformWindows, `d:\Mounts\Data\`, "D:/MOUNTS/DATA"},
+		{platformWindows, "/", "/"},
+		// 其他平台区分大小写，原样返回
+		{platformLinux, "/Data/", "/Data/"},
+		{platformDarwin, `C:\`, `C:\`},
+	}
+	for _, c := range mountpoints {
+		if got := normalizeMountpoint(c.goos, c.mountpoint); got != c.want {
+			t.Errorf("normalizeMountpoint(%s, %q) = %q, want %q", c.goos, c.mountpoint, got, c.want)
+		}
+	}
+}
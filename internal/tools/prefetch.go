@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// PrefetchResult 单个工具的预取结果
+type PrefetchResult struct {
+	Tool     string        `json:"tool"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// PrefetchStatus 启动预取的整体状态
+type PrefetchStatus struct {
+	Started  bool             `json:"started"`
+	Running  bool             `json:"running"`
+	Duration time.Duration    `json:"duration"`
+	Results  []PrefetchResult `json:"results"`
+}
+
+// Warmup 启动时异步预取各工具的静态数据，记录耗时和失败情况
+type Warmup struct {
+	mutex    sync.Mutex
+	started  time.Time
+	finished time.Time
+	results  []PrefetchResult
+}
+
+// NewWarmup 创建预取器
+func NewWarmup() *Warmup {
+	return &Warmup{}
+}
+
+// Run 并发执行所有预取任务，阻塞到全部完成；ctx 取消（服务器关闭）时各任务随之中止
+func (w *Warmup) Run(ctx context.Context, prefetchers map[string]types.Prefetcher) {
+	w.mutex.Lock()
+	w.started = time.Now()
+	w.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	for name, prefetcher := range prefetchers {
+		wg.Add(1)
+		go func(name string, prefetcher types.Prefetcher) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := prefetcher.Prefetch(ctx)
+			result := PrefetchResult{Tool: name, Duration: time.Since(start)}
+			if err != nil {
+				result.Error = err.Error()
+				slog.Warn("预取静态数据失败", "tool", name, "error", err)
+			}
+
+			w.mutex.Lock()
+			w.results = append(w.results, result)
+			w.mutex.Unlock()
+		}(name, prefetcher)
+	}
+	wg.Wait()
+
+	w.mutex.Lock()
+	w.finished = time.Now()
+	w.mutex.Unlock()
+}
+
+// Status 获取预取状态，结果按工具名称排序
+func (w *Warmup) Status() PrefetchStatus {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	status := PrefetchStatus{
+		Started: !w.started.IsZero(),
+		Running: !w.started.IsZero() && w.finished.IsZero(),
+		Results: append([]PrefetchResult(nil), w.results...),
+	}
+	if !w.finished.IsZero() {
+		status.Duration = w.finished.Sub(w.started)
+	}
+	sort.Slice(status.Results, func(i, j int) bool {
+		return status.Results[i].Tool < status.Results[j].Tool
+	})
+
+	return status
+}
@@ -0,0 +1,336 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// presetsKey 参数预设在存储中的键
+const presetsKey = "presets"
+
+// PresetArgument tools/call 中指定预设名称的参数，由处理器展开后移除，不会传给工具
+const PresetArgument = "preset"
+
+// Preset 命名的参数预设：调用 Tool 时以 Arguments 为基础，显式传入的参数优先
+type Preset struct {
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// PresetStore 保存在存储中的参数预设（预设名 → 预设），并发安全
+type PresetStore struct {
+	storage types.DataStorage
+	mutex   sync.Mutex
+}
+
+// NewPresetStore 创建参数预设存储
+func NewPresetStore(dataStorage types.DataStorage) *PresetStore {
+	return &PresetStore{
+		storage: dataStorage,
+	}
+}
+
+// load 读取所有预设，需持有 mutex。尚未保存过预设时返回空集合
+func (ps *PresetStore) load() (map[string]Preset, error) {
+	presets := make(map[string]Preset)
+	if !ps.storage.Exists(presetsKey) {
+		return presets, nil
+	}
+	if err := ps.storage.Load(presetsKey, &presets); err != nil {
+		return nil, wrapError("读取参数预设失败", err)
+	}
+	return presets, nil
+}
+
+// All 获取所有预设
+func (ps *PresetStore) All() (map[string]Preset, error) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	return ps.load()
+}
+
+// Get 获取预设，不存在时返回 ERR_NOT_FOUND 并列出可用的预设
+func (ps *PresetStore) Get(name string) (Preset, error) {
+	presets, err := ps.All()
+	if err != nil {
+		return Preset{}, err
+	}
+
+	preset, found := presets[name]
+	if !found {
+		toolErr := notFound("参数预设不存在: %s", name)
+		if len(presets) > 0 {
+			toolErr.Hint = "可用的预设: " + strings.Join(sortedKeys(presets), ", ")
+		} else {
+			toolErr.Hint = "尚未保存任何预设，可通过 preset_admin 的 set 操作创建"
+		}
+		return Preset{}, toolErr
+	}
+	return preset, nil
+}
+
+// Set 保存预设，同名预设会被覆盖
+func (ps *PresetStore) Set(name string, preset Preset) error {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	presets, err := ps.load()
+	if err != nil {
+		return err
+	}
+	presets[name] = preset
+	if err := ps.storage.Save(presetsKey, presets); err != nil {
+		return wrapError("保存参数预设失败", err)
+	}
+	return nil
+}
+
+// Delete 删除预设，不存在时返回 ERR_NOT_FOUND
+func (ps *PresetStore) Delete(name string) error {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	presets, err := ps.load()
+	if err != nil {
+		return err
+	}
+	if _, found := presets[name]; !found {
+		return notFound("参数预设不存在: %s", name)
+	}
+	delete(presets, name)
+	if err := ps.storage.Save(presetsKey, presets); err != nil {
+		return wrapError("保存参数预设失败", err)
+	}
+	return nil
+}
+
+// Apply 展开调用参数中的 preset：以预设的参数为基础，显式传入的参数覆盖同名的预设参数。
+// 没有 preset 参数时原样返回；预设属于其他工具时返回 ERR_BAD_ARGUMENT。
+func (ps *PresetStore) Apply(tool string, args map[string]interface{}) (map[string]interface{}, error) {
+	value, found := args[PresetArgument]
+	if !found {
+		return args, nil
+	}
+	name, _ := value.(string)
+	if name == "" {
+		return nil, argumentError(PresetArgument, "参数 %s 应为预设名称", PresetArgument)
+	}
+
+	preset, err := ps.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if preset.Tool != tool {
+		return nil, argumentError(PresetArgument, "参数预设 %s 属于工具 %s，不能用于 %s", name, preset.Tool, tool)
+	}
+
+	return mergePresetArguments(preset.Arguments, args), nil
+}
+
+// mergePresetArguments 合并预设参数和显式参数，显式参数优先，结果中不包含 preset 参数
+func mergePresetArguments(preset, explicit map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(preset)+len(explicit))
+	for name, value := range preset {
+		merged[name] = value
+	}
+	for name, value := range explicit {
+		if name != PresetArgument {
+			merged[name] = value
+		}
+	}
+	return merged
+}
+
+// validatePreset 按目标工具当前的参数模式校验预设参数。预设可以只包含部分参数，
+// 必需参数允许在调用时显式传入，因此不检查 Required；调用时合并后的参数仍会完整校验。
+func validatePreset(tool types.Tool, arguments map[string]interface{}) (map[string]interface{}, error) {
+	if _, found := arguments[PresetArgument]; found {
+		return nil, badArgument("预设参数中不能再包含 %s", PresetArgument)
+	}
+	schema := tool.InputSchema
+	schema.Required = nil
+	return ValidateArguments(schema, arguments)
+}
+
+// PresetAdminTool 参数预设管理工具
+type PresetAdminTool struct {
+	presets *PresetStore
+	lookup  ToolLookupFunc
+}
+
+// NewPresetAdminTool 创建新的参数预设管理工具，lookup 用于在保存时按目标工具的参数模式校验预设
+func NewPresetAdminTool(presets *PresetStore, lookup ToolLookupFunc) *PresetAdminTool {
+	return &PresetAdminTool{
+		presets: presets,
+		lookup:  lookup,
+	}
+}
+
+// GetName 获取工具名称
+func (pa *PresetAdminTool) GetName() string {
+	return "preset_admin"
+}
+
+// GetDescription 获取工具描述
+func (pa *PresetAdminTool) GetDescription() string {
+	return "管理命名的参数预设：调用任意工具时传入 \"preset\": 预设名，即以预设的参数为基础，显式参数优先"
+}
+
+// GetAnnotations 获取工具注解
+func (pa *PresetAdminTool) GetAnnotations() types.ToolAnnotations {
+	return types.ToolAnnotations{
+		Title:          "参数预设管理",
+		IdempotentHint: true,
+	}
+}
+
+// presetAdminArgs preset_admin 的参数
+type presetAdminArgs struct {
+	strictArgs
+	Action    string                 `arg:"action,enum=list|set|delete,default=list" desc:"操作: list 列出预设, set 保存预设（同名覆盖）, delete 删除预设"`
+	Name      string                 `arg:"name" pattern:"^[A-Za-z0-9_.-]{1,64}$" desc:"预设名称（set、delete 必需），只能包含字母、数字、-、_ 和 ."`
+	Tool      string                 `arg:"tool" desc:"预设对应的工具（set 必需）"`
+	Arguments map[string]interface{} `arg:"arguments" desc:"预设的参数（set 使用），保存时按工具的参数模式校验"`
+}
+
+// GetInputSchema 获取输入模式
+func (pa *PresetAdminTool) GetInputSchema() types.InputSchema {
+	return argsSchema(presetAdminArgs{})
+}
+
+// Examples 获取调用示例
+func (pa *PresetAdminTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "列出所有预设",
+			Arguments:   map[string]interface{}{"action": "list"},
+		},
+		{
+			Description: "保存 CPU 占用前 5 的进程查询，之后以 {\"preset\": \"cpu-top5\"} 调用 top_processes",
+			Arguments: map[string]interface{}{
+				"action":    "set",
+				"name":      "cpu-top5",
+				"tool":      "top_processes",
+				"arguments": map[string]interface{}{"sort_by": "cpu", "limit": 5},
+			},
+		},
+		{
+			Description: "删除预设",
+			Arguments:   map[string]interface{}{"action": "delete", "name": "cpu-top5"},
+		},
+	}
+}
+
+// Execute 执行预设管理操作
+func (pa *PresetAdminTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a presetAdminArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+	name := a.Name
+
+	switch a.Action {
+	case "list":
+		return pa.list()
+
+	case "set":
+		if name == "" {
+			return "", argumentError("name", "set 操作需要提供 name")
+		}
+		return pa.set(a)
+
+	case "delete":
+		if name == "" {
+			return "", argumentError("name", "delete 操作需要提供 name")
+		}
+		if err := pa.presets.Delete(name); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✅ 已删除参数预设: %s\n", name), nil
+
+	default:
+		return "", argumentError("action", "不支持的操作: %s", a.Action)
+	}
+}
+
+// set 校验并保存预设
+func (pa *PresetAdminTool) set(a presetAdminArgs) (string, error) {
+	name, toolName := a.Name, a.Tool
+	if toolName == "" {
+		return "", argumentError("tool", "set 操作需要提供 tool")
+	}
+	tool, found := pa.lookup(toolName)
+	if !found {
+		return "", notFound("工具不存在或已被禁用: %s", toolName)
+	}
+
+	arguments := a.Arguments
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+
+	validated, err := validatePreset(tool, arguments)
+	if err != nil {
+		toolErr := ClassifyError(err)
+		return "", &Error{Code: toolErr.Code, Message: fmt.Sprintf("预设参数不符合 %s 的参数模式: %s", toolName, toolErr.Message), Hint: toolErr.Hint}
+	}
+
+	if err := pa.presets.Set(name, Preset{Tool: toolName, Arguments: validated, UpdatedAt: time.Now()}); err != nil {
+		return "", err
+	}
+
+	var result string
+	result += fmt.Sprintf("✅ 已保存参数预设: %s\n", name)
+	result += fmt.Sprintf("工具: %s\n", toolName)
+	result += fmt.Sprintf("参数: %s\n", formatPresetArguments(validated))
+	result += fmt.Sprintf("💡 调用 %s 时传入 {\"preset\": %q} 即可使用，显式参数会覆盖预设中的同名参数\n", toolName, name)
+	return result, nil
+}
+
+// list 列出所有预设，并标记按目标工具当前的参数模式已不再有效的预设
+func (pa *PresetAdminTool) list() (string, error) {
+	presets, err := pa.presets.All()
+	if err != nil {
+		return "", err
+	}
+
+	var result string
+	result += "🔖 参数预设\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	if len(presets) == 0 {
+		result += "暂无预设，可通过 set 操作创建\n"
+		return result, nil
+	}
+
+	for _, name := range sortedKeys(presets) {
+		preset := presets[name]
+		result += fmt.Sprintf("• %s → %s %s\n", name, preset.Tool, formatPresetArguments(preset.Arguments))
+		if tool, found := pa.lookup(preset.Tool); !found {
+			result += "  ⚠️ 工具不存在或已被禁用\n"
+		} else if _, err := validatePreset(tool, preset.Arguments); err != nil {
+			result += fmt.Sprintf("  ⚠️ 已失效: %s\n", ClassifyError(err).Message)
+		}
+	}
+	result += fmt.Sprintf("\n共 %d 个预设\n", len(presets))
+	return result, nil
+}
+
+// formatPresetArguments 按参数名排序格式化预设参数，如 limit=5 sort_by=cpu
+func formatPresetArguments(arguments map[string]interface{}) string {
+	if len(arguments) == 0 {
+		return "（无参数）"
+	}
+
+	parts := make([]string, 0, len(arguments))
+	for _, name := range sortedKeys(arguments) {
+		parts = append(parts, fmt.Sprintf("%s=%v", name, arguments[name]))
+	}
+	return strings.Join(parts, " ")
+}
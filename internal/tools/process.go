@@ -1,26 +1,58 @@
 package tools
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"sort"
-	"strconv"
+	"strings"
 	"time"
 
+	"mcp-example/internal/identity"
+	"mcp-example/internal/permissions"
 	"mcp-example/internal/types"
-
-	"github.com/shirou/gopsutil/v3/process"
 )
 
 // ProcessTool 进程监控工具
 type ProcessTool struct {
-	cache types.Cache
+	cache        types.Cache
+	cacheOptions CacheOptions
+	platform     string
+	// permissions 服务器进程的权限探测结果，用于说明因权限不足而不完整的进程信息
+	permissions func() *types.PermissionProfile
+	// cpuBaselines 各进程上一次采集的 CPU 时间，跨调用保留，用于计算区间 CPU 使用率
+	cpuBaselines *cpuBaselines
+	// now 采集时刻，测试中替换以得到确定的区间 CPU 使用率
+	now func() time.Time
 }
 
 // NewProcessTool 创建新的进程监控工具
-func NewProcessTool(cache types.Cache) *ProcessTool {
+func NewProcessTool(cache types.Cache, cacheOptions CacheOptions) *ProcessTool {
 	return &ProcessTool{
-		cache: cache,
+		cache:        cache,
+		cacheOptions: cacheOptions,
+		platform:     hostPlatform,
+		permissions:  permissions.Get,
+		cpuBaselines: newCPUBaselines(maxCPUBaselines),
+		now:          time.Now,
+	}
+}
+
+// CPUBaselineCount 当前保存 CPU 时间基线的进程数
+func (pt *ProcessTool) CPUBaselineCount() int {
+	return pt.cpuBaselines.size()
+}
+
+// processCPUPercent 进程的 CPU 使用率：之前采集过同一进程时为两次采集之间的区间使用率（interval 为 true），
+// 否则为 gopsutil 计算的启动以来平均使用率
+func (pt *ProcessTool) processCPUPercent(ctx context.Context, p Process, createTime int64, now time.Time) (percent float64, interval bool) {
+	if times, err := p.TimesWithContext(ctx); err == nil && times != nil {
+		if percent, ok := pt.cpuBaselines.observe(p.PID(), createTime, times.User+times.System, now); ok {
+			return percent, true
+		}
 	}
+	percent, _ = p.CPUPercentWithContext(ctx)
+	return percent, false
 }
 
 // GetName 获取工具名称
@@ -33,100 +65,206 @@ func (pt *ProcessTool) GetDescription() string {
 	return "获取 CPU 或内存占用最高的进程"
 }
 
+// GetAnnotations 获取工具注解
+func (pt *ProcessTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("高占用进程")
+}
+
+// processArgs top_processes 的参数
+type processArgs struct {
+	SortBy               string `arg:"sort_by,enum=cpu|memory|age,default=memory" desc:"排序方式: cpu、memory 或 age（运行时长）"`
+	Descending           bool   `arg:"descending,default=true" desc:"按 age 排序时的顺序: true 运行最久的在前，false 最近启动的在前（cpu、memory 总是从高到低）"`
+	Limit                int    `arg:"limit,default=10,min=1,max=100" desc:"返回进程数量（分组时为进程组数量）"`
+	Offset               int    `arg:"offset,default=0,min=0" desc:"跳过排序后的前若干项（分组时为进程组），用于翻页；翻页时建议 cache=auto 以复用同一次采集的排序结果"`
+	GroupBy              string `arg:"group_by,enum=none|name|user,default=none" desc:"聚合方式: none 按进程列出，name 按进程名聚合，user 按用户聚合"`
+	User                 string `arg:"user" desc:"只显示该用户的进程（用户名精确匹配）"`
+	IncludeKernelThreads bool   `arg:"include_kernel_threads,default=false" desc:"是否包含内核线程（仅 Linux 区分）"`
+	tableFormatArgs
+	fallbackArgs
+	cacheArgs
+	diffArgs
+}
+
 // GetInputSchema 获取输入模式
 func (pt *ProcessTool) GetInputSchema() types.InputSchema {
-	return types.InputSchema{
-		Type: "object",
-		Properties: map[string]types.Property{
-			"sort_by": {
-				Type:        "string",
-				Description: "排序方式: cpu 或 memory",
-				Enum:        []string{"cpu", "memory"},
-				Default:     "memory",
-			},
-			"limit": {
-				Type:        "string",
-				Description: "返回进程数量",
-				Default:     "10",
-			},
-			"use_cache": {
-				Type:        "string",
-				Description: "是否使用缓存数据",
-				Enum:        []string{"true", "false"},
-				Default:     "false",
-			},
+	return argsSchema(processArgs{})
+}
+
+// Examples 获取调用示例
+func (pt *ProcessTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "CPU 占用最高的 5 个进程",
+			Arguments:   map[string]interface{}{"sort_by": "cpu", "limit": 5},
+		},
+		{
+			Description: "内存占用第 11–20 名的进程（第二页）",
+			Arguments:   map[string]interface{}{"limit": 10, "offset": 10, "cache": CacheModeAuto},
+		},
+		{
+			Description: "按进程名聚合的内存占用前 10 组",
+			Arguments:   map[string]interface{}{"group_by": groupByName},
+		},
+		{
+			Description: "root 用户的进程，包含内核线程，JSON 格式",
+			Arguments:   map[string]interface{}{"user": "root", "include_kernel_threads": "true", "format": "json"},
+		},
+		{
+			Description: "最近启动的 10 个进程",
+			Arguments:   map[string]interface{}{"sort_by": sortByAge, "descending": "false"},
 		},
 	}
 }
 
 // Execute 执行进程监控
-func (pt *ProcessTool) Execute(args map[string]interface{}) (string, error) {
-	// 解析参数
-	sortBy, _ := args["sort_by"].(string)
-	if sortBy == "" {
-		sortBy = "memory"
-	}
+func (pt *ProcessTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	text, _, err := pt.ExecuteStructured(ctx, args)
+	return text, err
+}
 
-	limitStr, _ := args["limit"].(string)
-	if limitStr == "" {
-		limitStr = "10"
+// ExecuteStructured 执行进程监控，同时返回与 JSON 输出相同的结构化结果
+func (pt *ProcessTool) ExecuteStructured(ctx context.Context, args map[string]interface{}) (string, interface{}, error) {
+	var a processArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", nil, err
+	}
+	if a.SortBy == sortByAge && a.GroupBy != groupByNone {
+		return "", nil, argumentError("sort_by", "按进程组聚合时不支持按 age 排序")
 	}
-	limit, _ := strconv.Atoi(limitStr)
-	if limit <= 0 || limit > 100 {
-		limit = 10
+	query := processQuery{
+		SortBy:               a.SortBy,
+		Descending:           a.Descending,
+		Limit:                a.Limit,
+		Offset:               a.Offset,
+		GroupBy:              a.GroupBy,
+		User:                 a.User,
+		IncludeKernelThreads: a.IncludeKernelThreads,
 	}
 
-	useCacheStr, _ := args["use_cache"].(string)
-	useCache := useCacheStr == "true"
+	// 缓存过滤并排序后的完整列表（20秒），翻页时只在缓存结果上截取，不重新枚举进程
+	cacheOptions := pt.cacheOptions.forCall(args).withoutArgs("limit", "offset")
+	sorted, meta, err := withCache(ctx, pt.cache, cacheOptions, pt.GetName(), 20*time.Second, func(ctx context.Context) (types.ProcessList, error) {
+		return pt.collectProcesses(ctx, query)
+	})
+	if err != nil {
+		return "", nil, wrapError("获取进程信息失败", err)
+	}
+	processList := pageProcessList(sorted, a.Offset, a.Limit)
+	report := processReport{ProcessList: processList, Host: identity.Get(), Fallback: newFallbackInfo(meta), collectionInfo: newCollectionInfo(meta)}
 
-	// 检查缓存
-	cacheKey := fmt.Sprintf("top_processes_%s_%d", sortBy, limit)
-	if useCache {
-		if cachedData, found := pt.cache.Get(cacheKey); found {
-			if processList, ok := cachedData.(types.ProcessList); ok {
-				return pt.formatProcessList(processList, sortBy, limit), nil
-			}
+	if a.Format == "json" {
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", nil, wrapError("序列化进程信息失败", err)
+		}
+		return string(jsonData), report, nil
+	}
+	if isTableFormat(a.Format) {
+		rows := pt.processTable(processList)
+		if a.GroupBy != groupByNone {
+			rows = processGroupTable(processList)
 		}
+		text, err := renderTable(rows, a.Format)
+		return text, report, err
 	}
 
-	// 获取进程信息
-	processList, err := pt.getTopProcesses(sortBy, limit)
-	if err != nil {
-		return "", fmt.Errorf("获取进程信息失败: %v", err)
+	if a.GroupBy != groupByNone {
+		return cacheHeader(meta) + pt.formatProcessGroups(processList, a.SortBy, a.Limit) + cacheNote(meta), report, nil
+	}
+	return cacheHeader(meta) + pt.formatProcessList(processList, query) + cacheNote(meta), report, nil
+}
+
+// processPermissionNote 说明因权限限制而不完整的进程信息，没有限制时返回空字符串
+func processPermissionNote(processList types.ProcessList, profile *types.PermissionProfile) string {
+	switch {
+	case profile != nil && !profile.Privileged && processList.Inaccessible > 0:
+		return fmt.Sprintf("⚠️ 以普通用户运行，部分进程信息不可见 (跳过 %d 个)\n", processList.Inaccessible)
+	case profile != nil && !profile.Privileged && !profile.OtherProcessIO:
+		return "⚠️ 以普通用户运行，其他用户进程的 IO 计数和命令行不可见\n"
+	case processList.Inaccessible > 0:
+		return fmt.Sprintf("⚠️ 跳过 %d 个无法读取的进程（可能已退出）\n", processList.Inaccessible)
 	}
+	return ""
+}
 
-	// 缓存结果（缓存20秒）
-	pt.cache.Set(cacheKey, processList, 20*time.Second)
+// processReport JSON 输出，同时作为 structuredContent 返回
+type processReport struct {
+	types.ProcessList
+	Host *types.HostIdentity `json:"host,omitempty"`
+	// Fallback 实时采集失败时返回降级数据的说明
+	Fallback *fallbackInfo `json:"fallback,omitempty"`
+	collectionInfo
+}
 
-	return pt.formatProcessList(processList, sortBy, limit), nil
+// getTopProcesses 获取进程信息中按 Offset 和 Limit 截取的一页，GroupBy 不为 none 时返回聚合后的进程组
+func (pt *ProcessTool) getTopProcesses(ctx context.Context, query processQuery) (types.ProcessList, error) {
+	processList, err := pt.collectProcesses(ctx, query)
+	if err != nil {
+		return processList, err
+	}
+	return pageProcessList(processList, query.Offset, query.Limit), nil
 }
 
-// getTopProcesses 获取进程信息
-func (pt *ProcessTool) getTopProcesses(sortBy string, limit int) (types.ProcessList, error) {
+// collectProcesses 枚举符合过滤条件的全部进程并排序（不截取），GroupBy 不为 none 时返回聚合后的全部进程组
+func (pt *ProcessTool) collectProcesses(ctx context.Context, query processQuery) (types.ProcessList, error) {
 	var processList types.ProcessList
+	usernames := make(usernameCache)
 
 	// 获取所有进程
-	processes, err := process.Processes()
+	processes, err := providers.Process.Processes(ctx)
 	if err != nil {
-		return processList, fmt.Errorf("获取进程列表失败: %v", err)
+		return processList, fmt.Errorf("获取进程列表失败: %w", err)
 	}
 
+	// seen 记录本次枚举到的全部 PID（包括被过滤的），用于清理已退出进程的 CPU 基线
+	seen := make(map[int32]struct{}, len(processes))
+	now := pt.now()
+
 	var procInfos []types.ProcessInfo
 	for _, p := range processes {
-		name, err := p.Name()
-		if err != nil || name == "" {
+		if err := ctx.Err(); err != nil {
+			return processList, err
+		}
+
+		seen[p.PID()] = struct{}{}
+
+		name, err := p.NameWithContext(ctx)
+		if err != nil {
+			processList.Inaccessible++
+			continue
+		}
+		if name == "" {
+			continue
+		}
+
+		// 过滤内核线程和其他用户的进程，分别计数
+		if !query.IncludeKernelThreads && isKernelThread(ctx, p) {
+			processList.ExcludedKernelThreads++
+			continue
+		}
+
+		// 用户名只在按用户过滤或聚合时获取
+		var username string
+		if query.User != "" || query.GroupBy == groupByUser {
+			username = usernames.lookup(ctx, p)
+		}
+		if query.User != "" && username != query.User {
+			processList.ExcludedByUser++
 			continue
 		}
 
 		// 获取进程信息
-		memInfo, _ := p.MemoryInfo()
-		cpuPercent, _ := p.CPUPercent()
-		statusSlice, _ := p.Status()
+		memInfo, _ := p.MemoryInfoWithContext(ctx)
+		createTime, _ := p.CreateTimeWithContext(ctx)
+		cpuPercent, interval := pt.processCPUPercent(ctx, p, createTime, now)
+		if interval {
+			processList.IntervalCPU++
+		}
+		statusSlice, _ := p.StatusWithContext(ctx)
 		status := ""
 		if len(statusSlice) > 0 {
 			status = statusSlice[0]
 		}
-		createTime, _ := p.CreateTime()
 
 		var memBytes uint64
 		var memMB float64
@@ -136,104 +274,247 @@ func (pt *ProcessTool) getTopProcesses(sortBy string, limit int) (types.ProcessL
 		}
 
 		procInfo := types.ProcessInfo{
-			PID:         p.Pid,
+			PID:         p.PID(),
 			Name:        name,
 			Status:      status,
 			CPUPercent:  cpuPercent,
 			MemoryBytes: memBytes,
 			MemoryMB:    memMB,
 			CreateTime:  createTime,
-			LastUpdated: time.Now(),
+			Username:    username,
+			LastUpdated: now,
+		}
+		if createTime > 0 {
+			started := time.UnixMilli(createTime)
+			procInfo.StartTime = &started
 		}
 
 		procInfos = append(procInfos, procInfo)
 	}
 
-	// 排序
-	if sortBy == "cpu" {
-		sort.Slice(procInfos, func(i, j int) bool {
-			return procInfos[i].CPUPercent > procInfos[j].CPUPercent
-		})
-	} else {
-		sort.Slice(procInfos, func(i, j int) bool {
-			return procInfos[i].MemoryBytes > procInfos[j].MemoryBytes
-		})
-	}
+	pt.cpuBaselines.prune(seen)
+
+	processList.Total = len(processes)
+	processList.LastUpdated = now
 
-	// 限制数量
-	if len(procInfos) > limit {
-		procInfos = procInfos[:limit]
+	if query.GroupBy != groupByNone {
+		processList.GroupBy = query.GroupBy
+		processList.Groups = groupProcesses(procInfos, query.GroupBy, query.SortBy)
+		processList.Matching = len(processList.Groups)
+		return processList, nil
 	}
 
+	// 排序（数值相同时按 PID 升序，保证顺序稳定，翻页时不会重复或遗漏）
+	sort.Slice(procInfos, func(i, j int) bool {
+		if query.SortBy == sortByAge {
+			return processAgeLess(procInfos[i], procInfos[j], query.Descending)
+		}
+		return processLess(procInfos[i], procInfos[j], query.SortBy)
+	})
+
 	processList.Processes = procInfos
-	processList.Total = len(processes)
-	processList.LastUpdated = time.Now()
+	processList.Matching = len(procInfos)
 
 	return processList, nil
 }
 
-// formatProcessList 格式化进程列表输出
-func (pt *ProcessTool) formatProcessList(processList types.ProcessList, sortBy string, limit int) string {
-	var result string
+// pageProcessList 从排序后的完整列表中截取 [offset, offset+limit) 一页，并记录下一页的 offset。
+// 只重新切片而不修改 sorted，缓存中的完整列表可以被多次翻页共享。
+func pageProcessList(sorted types.ProcessList, offset, limit int) types.ProcessList {
+	page := sorted
+	page.Offset = offset
+	page.NextOffset = 0
+
+	start := min(offset, sorted.Matching)
+	end := min(start+limit, sorted.Matching)
+	if sorted.GroupBy != "" {
+		page.Groups = sorted.Groups[start:end]
+	} else {
+		page.Processes = sorted.Processes[start:end]
+	}
+	if end < sorted.Matching {
+		page.NextOffset = end
+	}
+	return page
+}
 
-	if sortBy == "cpu" {
-		result += fmt.Sprintf("🚀 CPU 占用最高的 %d 个进程\n", limit)
+// 进程列表的表头，不随调用变化
+var (
+	processListHeader         = fmt.Sprintf("%-8s %-25s %-10s %-12s %-10s %s\n", "PID", "进程名", "CPU%", "内存(MB)", "状态", "运行时长")
+	processListHeaderNoStatus = fmt.Sprintf("%-8s %-25s %-10s %-12s %s\n", "PID", "进程名", "CPU%", "内存(MB)", "运行时长")
+)
+
+// processLineSize 进程列表每行的预估字节数，用于预留输出缓冲区
+const processLineSize = 100
+
+// formatProcessList 格式化进程列表输出
+func (pt *ProcessTool) formatProcessList(processList types.ProcessList, query processQuery) string {
+	result := getOutput((len(processList.Processes) + 10) * processLineSize)
+
+	result.WriteString(processListTitle(query, processList.Offset))
+	result.WriteString(separatorLine)
+	// Windows 不提供进程状态，不显示状态列
+	showStatus := hasProcessStatus(pt.platform)
+	if showStatus {
+		result.WriteString(processListHeader)
 	} else {
-		result += fmt.Sprintf("💾 内存占用最高的 %d 个进程\n", limit)
+		result.WriteString(processListHeaderNoStatus)
 	}
-	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
-	result += fmt.Sprintf("%-8s %-25s %-10s %-12s %-10s\n", "PID", "进程名", "CPU%", "内存(MB)", "状态")
-	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result.WriteString(separatorLine)
 
 	for _, proc := range processList.Processes {
 		// 截断过长的进程名
-		name := proc.Name
-		if len(name) > 25 {
-			name = name[:22] + "..."
-		}
+		name := fitColumn(proc.Name, 25)
+		age := formatProcessAge(proc, processList.LastUpdated)
 
-		result += fmt.Sprintf("%-8d %-25s %-10.2f %-12.2f %-10s\n",
+		if !showStatus {
+			fmt.Fprintf(result, "%-8d %s %-10.2f %-12.2f %s\n", proc.PID, name, proc.CPUPercent, proc.MemoryMB, age)
+			continue
+		}
+		fmt.Fprintf(result, "%-8d %s %-10.2f %-12.2f %-10s %s\n",
 			proc.PID,
 			name,
 			proc.CPUPercent,
 			proc.MemoryMB,
 			proc.Status,
+			age,
 		)
 	}
 
-	result += fmt.Sprintf("\n📊 总进程数: %d\n", processList.Total)
+	result.WriteString(formatProcessTotals(processList, pt.permissions()))
+
+	return putOutput(result)
+}
+
+// processTable 进程列表的表格形式（csv、markdown 输出），运行时长在 CSV 中为秒数
+func (pt *ProcessTool) processTable(processList types.ProcessList) table {
+	now := processList.LastUpdated
+	showStatus := hasProcessStatus(pt.platform)
+	rows := table{columns: []tableColumn{{"pid", "PID"}, {"name", "进程名"}, {"cpu_percent", "CPU%"}, {"memory_bytes", "内存"}}}
+	if showStatus {
+		rows.columns = append(rows.columns, tableColumn{"status", "状态"})
+	}
+	rows.columns = append(rows.columns, tableColumn{"age_seconds", "运行时长"})
+
+	for _, proc := range processList.Processes {
+		cells := []tableCell{intCell(int64(proc.PID)), textCell(proc.Name), floatCell(proc.CPUPercent), bytesCell(proc.MemoryBytes)}
+		if showStatus {
+			cells = append(cells, textCell(proc.Status))
+		}
+		age := tableCell{text: "-"}
+		if proc.CreateTime != 0 {
+			age = intCell(int64(now.Sub(time.UnixMilli(proc.CreateTime)) / time.Second))
+			age.text = formatProcessAge(proc, now)
+		}
+		rows.addRow(append(cells, age)...)
+	}
+	return rows
+}
+
+// processListTitle 进程列表的标题，如 "🚀 CPU 占用最高的 10 个进程"，翻页时给出本页的名次
+func processListTitle(query processQuery, offset int) string {
+	first, last := offset+1, offset+query.Limit
+	switch {
+	case query.SortBy == sortByAge && query.Descending && offset > 0:
+		return fmt.Sprintf("⏳ 运行时间最长的第 %d–%d 个进程\n", first, last)
+	case query.SortBy == sortByAge && query.Descending:
+		return fmt.Sprintf("⏳ 运行时间最长的 %d 个进程\n", query.Limit)
+	case query.SortBy == sortByAge && offset > 0:
+		return fmt.Sprintf("🆕 最近启动的第 %d–%d 个进程\n", first, last)
+	case query.SortBy == sortByAge:
+		return fmt.Sprintf("🆕 最近启动的 %d 个进程\n", query.Limit)
+	case query.SortBy == "cpu" && offset > 0:
+		return fmt.Sprintf("🚀 CPU 占用第 %d–%d 名的进程\n", first, last)
+	case query.SortBy == "cpu":
+		return fmt.Sprintf("🚀 CPU 占用最高的 %d 个进程\n", query.Limit)
+	case offset > 0:
+		return fmt.Sprintf("💾 内存占用第 %d–%d 名的进程\n", first, last)
+	default:
+		return fmt.Sprintf("💾 内存占用最高的 %d 个进程\n", query.Limit)
+	}
+}
+
+// formatProcessAge 进程在 now 时已运行的时长，如 "3天 4小时 5分钟"，启动时间未知时为 "-"
+func formatProcessAge(proc types.ProcessInfo, now time.Time) string {
+	if proc.CreateTime == 0 {
+		return "-"
+	}
+	return formatDurationWords(now.Sub(time.UnixMilli(proc.CreateTime)))
+}
+
+// formatProcessTotals 格式化分页位置、总进程数、各过滤条件排除的数量及权限限制说明
+func formatProcessTotals(processList types.ProcessList, profile *types.PermissionProfile) string {
+	var result string
+
+	unit := "进程"
+	if processList.GroupBy != "" {
+		unit = "进程组"
+	}
+	shown := len(processList.Processes) + len(processList.Groups)
+	if shown == 0 {
+		result += fmt.Sprintf("\n📄 offset %d 之后没有更多%s（共 %d 个匹配的%s）\n", processList.Offset, unit, processList.Matching, unit)
+	} else {
+		result += fmt.Sprintf("\n📄 显示第 %d–%d 项，共 %d 个匹配的%s\n", processList.Offset+1, processList.Offset+shown, processList.Matching, unit)
+	}
+	if processList.NextOffset > 0 {
+		result += fmt.Sprintf("➡️ 下一页: offset=%d（配合 cache=auto 复用本次排序结果）\n", processList.NextOffset)
+	}
+
+	result += fmt.Sprintf("📊 总进程数: %d", processList.Total)
+	var excluded []string
+	if processList.ExcludedKernelThreads > 0 {
+		excluded = append(excluded, fmt.Sprintf("内核线程 %d", processList.ExcludedKernelThreads))
+	}
+	if processList.ExcludedByUser > 0 {
+		excluded = append(excluded, fmt.Sprintf("其他用户 %d", processList.ExcludedByUser))
+	}
+	if len(excluded) > 0 {
+		result += fmt.Sprintf("（已排除: %s）", strings.Join(excluded, "，"))
+	}
+	result += "\n"
+	if processList.IntervalCPU > 0 {
+		result += fmt.Sprintf("⏱️ CPU%% 为距上次采集的区间使用率（%d 个进程），其余为启动以来平均\n", processList.IntervalCPU)
+	} else {
+		result += "⏱️ CPU% 为进程启动以来的平均使用率，再次调用时显示距本次采集的区间使用率\n"
+	}
+	result += processPermissionNote(processList, profile)
 	result += fmt.Sprintf("📅 更新时间: %s\n", processList.LastUpdated.Format("2006-01-02 15:04:05"))
 
 	return result
 }
 
 // GetProcessData 获取进程数据（供其他组件使用）
-func (pt *ProcessTool) GetProcessData(sortBy string, limit int) (types.ProcessList, error) {
-	return pt.getTopProcesses(sortBy, limit)
+func (pt *ProcessTool) GetProcessData(ctx context.Context, sortBy string, limit int) (types.ProcessList, error) {
+	return pt.getTopProcesses(ctx, processQuery{
+		SortBy:               sortBy,
+		Limit:                limit,
+		GroupBy:              groupByNone,
+		IncludeKernelThreads: true,
+	})
 }
 
 // GetProcessByPID 根据 PID 获取特定进程信息
-func (pt *ProcessTool) GetProcessByPID(pid int32) (types.ProcessInfo, error) {
+func (pt *ProcessTool) GetProcessByPID(ctx context.Context, pid int32) (types.ProcessInfo, error) {
 	var procInfo types.ProcessInfo
 
-	p, err := process.NewProcess(pid)
+	p, err := providers.Process.NewProcess(ctx, pid)
 	if err != nil {
-		return procInfo, fmt.Errorf("找不到 PID 为 %d 的进程: %v", pid, err)
+		return procInfo, &Error{Code: ErrNotFound, Message: fmt.Sprintf("找不到 PID 为 %d 的进程", pid), Err: err}
 	}
 
-	name, err := p.Name()
+	name, err := p.NameWithContext(ctx)
 	if err != nil {
-		return procInfo, fmt.Errorf("获取进程名失败: %v", err)
+		return procInfo, fmt.Errorf("获取进程名失败: %w", err)
 	}
 
-	memInfo, _ := p.MemoryInfo()
-	cpuPercent, _ := p.CPUPercent()
-	statusSlice, _ := p.Status()
+	memInfo, _ := p.MemoryInfoWithContext(ctx)
+	createTime, _ := p.CreateTimeWithContext(ctx)
+	cpuPercent, _ := pt.processCPUPercent(ctx, p, createTime, pt.now())
+	statusSlice, _ := p.StatusWithContext(ctx)
 	status := ""
 	if len(statusSlice) > 0 {
 		status = statusSlice[0]
 	}
-	createTime, _ := p.CreateTime()
 
 	var memBytes uint64
 	var memMB float64
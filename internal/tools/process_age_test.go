@@ -0,0 +1,196 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+func TestFormatProcessAge(t *testing.T) {
+	now := fixedTime
+	cases := []struct {
+		started time.Time
+		want    string
+	}{
+		{now.Add(-30 * time.Second), "30秒"},
+		{now.Add(-59*time.Second - 900*time.Millisecond), "59秒"},
+		{now.Add(-time.Minute), "1分钟"},
+		{now.Add(-2*time.Hour - 10*time.Minute - 5*time.Second), "2小时 10分钟"},
+		{now.Add(-3*24*time.Hour - 4*time.Hour - 5*time.Minute - 6*time.Second), "3天 4小时 5分钟"},
+		{now.Add(-400 * 24 * time.Hour), "400天"},
+		// 启动时间晚于采集时刻（时钟回拨）时不显示负数
+		{now.Add(time.Minute), "0秒"},
+	}
+	for _, c := range cases {
+		proc := types.ProcessInfo{CreateTime: c.started.UnixMilli()}
+		if got := formatProcessAge(proc, now); got != c.want {
+			t.Errorf("formatProcessAge(%s before) = %q, want %q", now.Sub(c.started), got, c.want)
+		}
+	}
+	if got := formatProcessAge(types.ProcessInfo{}, now); got != "-" {
+		t.Errorf("unknown start time = %q, want -", got)
+	}
+}
+
+// ageFixture 40 个进程：按 PID 除以 4 的余数分为 4 组，同组的进程在同一时刻启动；PID 为 7 和 33 的进程启动时间未知
+func ageFixture() []types.ProcessInfo {
+	var procInfos []types.ProcessInfo
+	for pid := int32(1); pid <= 40; pid++ {
+		started := fixedTime.Add(-time.Duration(pid%4) * time.Hour).UnixMilli()
+		if pid == 7 || pid == 33 {
+			started = 0
+		}
+		procInfos = append(procInfos, types.ProcessInfo{PID: pid, CreateTime: started})
+	}
+	return procInfos
+}
+
+// ageOrder 排序后的 PID，按启动时间分段，未知启动时间的段为 "?"
+func ageOrder(procInfos []types.ProcessInfo) string {
+	var segments []string
+	var current []string
+	for i, proc := range procInfos {
+		current = append(current, fmt.Sprint(proc.PID))
+		if i == len(procInfos)-1 || procInfos[i+1].CreateTime != proc.CreateTime {
+			label := "?"
+			if proc.CreateTime != 0 {
+				label = formatProcessAge(proc, fixedTime)
+			}
+			segments = append(segments, label+":"+strings.Join(current, ","))
+			current = nil
+		}
+	}
+	return strings.Join(segments, " ")
+}
+
+func TestProcessAgeLessStable(t *testing.T) {
+	oldestFirst := "3小时:3,11,15,19,23,27,31,35,39 2小时:2,6,10,14,18,22,26,30,34,38 1小时:1,5,9,13,17,21,25,29,37 0秒:4,8,12,16,20,24,28,32,36,40 ?:7,33"
+	newestFirst := "0秒:4,8,12,16,20,24,28,32,36,40 1小时:1,5,9,13,17,21,25,29,37 2小时:2,6,10,14,18,22,26,30,34,38 3小时:3,11,15,19,23,27,31,35,39 ?:7,33"
+
+	// 输入顺序不同时结果相同：启动时间相同的按 PID 升序，未知启动时间的总是在最后
+	for i := 0; i < 20; i++ {
+		procInfos := ageFixture()
+		rand.Shuffle(len(procInfos), func(i, j int) { procInfos[i], procInfos[j] = procInfos[j], procInfos[i] })
+		sort.Slice(procInfos, func(i, j int) bool { return processAgeLess(procInfos[i], procInfos[j], true) })
+		if got := ageOrder(procInfos); got != oldestFirst {
+			t.Fatalf("oldest first = %s, want %s", got, oldestFirst)
+		}
+
+		rand.Shuffle(len(procInfos), func(i, j int) { procInfos[i], procInfos[j] = procInfos[j], procInfos[i] })
+		sort.Slice(procInfos, func(i, j int) bool { return processAgeLess(procInfos[i], procInfos[j], false) })
+		if got := ageOrder(procInfos); got != newestFirst {
+			t.Fatalf("newest first = %s, want %s", got, newestFirst)
+		}
+	}
+}
+
+// ageProvider 与 ageFixture 相同的启动时间的假进程
+func ageProvider() *fakeProcessProvider {
+	var processes []*fakeProcess
+	for _, proc := range ageFixture() {
+		processes = append(processes, &fakeProcess{
+			pid:        proc.PID,
+			name:       fmt.Sprintf("worker-%02d", proc.PID),
+			ppid:       1,
+			cmdline:    []string{"/usr/bin/worker"},
+			status:     []string{"S"},
+			createTime: proc.CreateTime,
+		})
+	}
+	return newFakeProcessProvider(processes...)
+}
+
+func TestTopProcessesSortByAge(t *testing.T) {
+	useFakeProcesses(t, ageProvider())
+	tool := NewProcessTool(storage.NewMemoryCache(), CacheOptions{})
+	tool.now = func() time.Time { return fixedTime }
+
+	// 逐页读取的结果与一次读取全部相同，启动时间相同的进程跨页时既不重复也不遗漏
+	for _, descending := range []string{"true", "false"} {
+		all := executePage(t, tool, map[string]interface{}{"sort_by": "age", "descending": descending, "limit": 100, "cache": "auto"})
+		var paged []int32
+		for offset := 0; offset < 40; offset += 7 {
+			page := executePage(t, tool, map[string]interface{}{"sort_by": "age", "descending": descending, "limit": 7, "offset": offset, "cache": "auto"})
+			paged = append(paged, pagePIDs(page)...)
+		}
+		if !slices.Equal(paged, pagePIDs(all)) || len(paged) != 40 {
+			t.Errorf("descending=%s: pages %v, want %v", descending, paged, pagePIDs(all))
+		}
+	}
+
+	// 默认运行最久的在前；JSON 中包含 RFC3339 启动时间，启动时间未知时省略
+	list := executePage(t, tool, map[string]interface{}{"sort_by": "age", "limit": 100})
+	first, last := list.Processes[0], list.Processes[len(list.Processes)-1]
+	if first.PID != 3 || first.StartTime == nil || !first.StartTime.Equal(fixedTime.Add(-3*time.Hour)) {
+		t.Errorf("first = %+v", first)
+	}
+	if last.PID != 33 || last.StartTime != nil {
+		t.Errorf("last = %+v", last)
+	}
+	text, err := tool.Execute(context.Background(), map[string]interface{}{"sort_by": "age", "limit": 1, "format": "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"start_time": "` + fixedTime.Add(-3*time.Hour).Format(time.RFC3339); !strings.Contains(text, want) {
+		t.Errorf("JSON lacks %s:\n%s", want, text)
+	}
+
+	// 进程组没有单一的启动时间
+	_, err = tool.Execute(context.Background(), map[string]interface{}{"sort_by": "age", "group_by": "name"})
+	var toolErr *Error
+	if !errors.As(err, &toolErr) || toolErr.Code != ErrBadArgument || toolErr.Argument != "sort_by" {
+		t.Errorf("group_by with sort_by=age: err = %v", err)
+	}
+}
+
+func TestTopProcessesAgeColumn(t *testing.T) {
+	useFakeProcesses(t, ageProvider())
+	tool := NewProcessTool(storage.NewMemoryCache(), CacheOptions{})
+	tool.now = func() time.Time { return fixedTime }
+
+	text, err := tool.Execute(context.Background(), map[string]interface{}{"sort_by": "age", "descending": "false", "limit": 40})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"🆕 最近启动的 40 个进程\n",
+		"运行时长\n",
+		"\n4        worker-04",
+		"0秒\n",
+		"3小时\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output lacks %q:\n%s", want, text)
+		}
+	}
+	// 启动时间未知的进程显示 -，排在最后
+	lines := strings.Split(strings.TrimSpace(text[strings.Index(text, "\n33 "):]), "\n")
+	if !strings.HasPrefix(lines[0], "33 ") || !strings.HasSuffix(lines[0], " -") {
+		t.Errorf("last process line = %q", lines[0])
+	}
+	if !strings.Contains(text, "\n7        worker-07") || strings.Index(text, "\n7        ") > strings.Index(text, "\n33 ") {
+		t.Errorf("unknown start times are not last:\n%s", text)
+	}
+}
+
+func TestFormatProcessDetailUnknownStartTime(t *testing.T) {
+	detail := processDetail{PID: 42, Name: "worker", LastUpdated: fixedTime}
+	if output := formatProcessDetail(detail, false, false); !strings.Contains(output, "启动时间: -\n") {
+		t.Errorf("unknown start time:\n%s", output)
+	}
+
+	started := fixedTime.Add(-26 * time.Hour)
+	detail.CreateTime = &started
+	if output := formatProcessDetail(detail, false, false); !strings.Contains(output, "（已运行 1天 2小时）\n") {
+		t.Errorf("known start time:\n%s", output)
+	}
+}
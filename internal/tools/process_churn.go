@@ -0,0 +1,365 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/types"
+)
+
+// maxChurnInterval 进程变动采样的最大间隔
+const maxChurnInterval = 30 * time.Second
+
+// procEntry /proc/<pid>/stat 中与进程变动相关的字段
+type procEntry struct {
+	PID  int32
+	PPID int32
+	Name string
+	// StartTime 进程启动时间（启动以来的时钟周期数），用于识别被复用的 PID
+	StartTime uint64
+}
+
+// procSnapshot 某一时刻的进程表（PID → 进程）
+type procSnapshot map[int32]procEntry
+
+// churnProcess 两次采样之间出现或消失的进程
+type churnProcess struct {
+	PID        int32  `json:"pid"`
+	PPID       int32  `json:"ppid"`
+	Name       string `json:"name"`
+	ParentName string `json:"parent_name,omitempty"`
+}
+
+// churnParent 按观察到的新子进程数排列的父进程
+type churnParent struct {
+	PID         int32  `json:"pid"`
+	Name        string `json:"name,omitempty"`
+	NewChildren int    `json:"new_children"`
+}
+
+// churnReport 进程变动采样结果
+type churnReport struct {
+	Interval string  `json:"interval"`
+	Elapsed  float64 `json:"elapsed_seconds"`
+	// Forks 采样期间 /proc/stat 的 processes 计数增量（包括线程），ForksPerSec 为其速率
+	Forks       uint64         `json:"forks"`
+	ForksPerSec float64        `json:"forks_per_sec"`
+	Appeared    []churnProcess `json:"appeared"`
+	Disappeared []churnProcess `json:"disappeared"`
+	// AppearedCount、DisappearedCount 为总数，列表只保留前 limit 个
+	AppearedCount    int `json:"appeared_count"`
+	DisappearedCount int `json:"disappeared_count"`
+	// Unobserved 两次采样都没有看到的创建次数（线程，或在两次采样之间开始并结束的进程）
+	Unobserved  uint64              `json:"unobserved_forks"`
+	TopParents  []churnParent       `json:"top_parents"`
+	BeforeCount int                 `json:"processes_before"`
+	AfterCount  int                 `json:"processes_after"`
+	SampledAt   time.Time           `json:"sampled_at"`
+	Host        *types.HostIdentity `json:"host,omitempty"`
+}
+
+// ProcessChurnTool 进程变动工具：两次读取 /proc，找出期间出现和消失的进程并估算创建速率（仅 Linux）
+type ProcessChurnTool struct {
+	procRoot string
+	platform string
+}
+
+// NewProcessChurnTool 创建新的进程变动工具
+func NewProcessChurnTool() *ProcessChurnTool {
+	return &ProcessChurnTool{
+		procRoot: "/proc",
+		platform: hostPlatform,
+	}
+}
+
+// GetName 获取工具名称
+func (pc *ProcessChurnTool) GetName() string {
+	return "process_churn"
+}
+
+// GetDescription 获取工具描述
+func (pc *ProcessChurnTool) GetDescription() string {
+	return "间隔两次读取进程表，列出期间出现和消失的进程、进程创建速率和新建子进程最多的父进程，用于发现 cron、脚本管道等短命进程（仅 Linux，会漏掉在两次读取之间开始并结束的进程）"
+}
+
+// GetAnnotations 获取工具注解
+func (pc *ProcessChurnTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("进程变动")
+}
+
+// processChurnArgs process_churn 的参数
+type processChurnArgs struct {
+	strictArgs
+	Interval time.Duration `arg:"interval,default=2s" pattern:"^[0-9]+(\\.[0-9]+)?(ms|s)$" desc:"两次读取之间的间隔，如 500ms、5s，最长 30s"`
+	Limit    int           `arg:"limit,default=10,min=1,max=100" desc:"出现、消失的进程和父进程排行各最多列出的数量"`
+	formatArgs
+}
+
+// GetInputSchema 获取输入模式
+func (pc *ProcessChurnTool) GetInputSchema() types.InputSchema {
+	return argsSchema(processChurnArgs{})
+}
+
+// Examples 获取调用示例
+func (pc *ProcessChurnTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "采样 2 秒内的进程变动",
+			Arguments:   map[string]interface{}{},
+		},
+		{
+			Description: "采样 10 秒，列出新建子进程最多的 5 个父进程，JSON 格式",
+			Arguments:   map[string]interface{}{"interval": "10s", "limit": 5, "format": "json"},
+		},
+	}
+}
+
+// Execute 执行进程变动采样
+func (pc *ProcessChurnTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	if pc.platform != platformLinux {
+		return "", unsupportedPlatform("process_churn 仅支持 Linux（当前平台: %s）", pc.platform)
+	}
+
+	var a processChurnArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+	if a.Interval <= 0 || a.Interval > maxChurnInterval {
+		return "", argumentError("interval", "无效的采样间隔: %s（应在 0 到 %s 之间）", a.Interval, maxChurnInterval)
+	}
+
+	report, err := pc.sample(ctx, a.Interval)
+	if err != nil {
+		return "", err
+	}
+	truncateChurnReport(&report, a.Limit)
+
+	if a.Format == "json" {
+		report.Host = identity.Get()
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", wrapError("序列化进程变动数据失败", err)
+		}
+		return string(jsonData), nil
+	}
+
+	return formatChurnReport(report), nil
+}
+
+// sample 间隔 interval 读取两次进程表和 /proc/stat 并计算变动
+func (pc *ProcessChurnTool) sample(ctx context.Context, interval time.Duration) (churnReport, error) {
+	statPath := filepath.Join(pc.procRoot, "stat")
+	statBefore, err := readProcStat(statPath)
+	if err != nil {
+		return churnReport{}, wrapError("读取进程创建计数失败", err)
+	}
+	before, err := readProcSnapshot(pc.procRoot)
+	if err != nil {
+		return churnReport{}, wrapError("读取进程表失败", err)
+	}
+	start := time.Now()
+
+	if err := waitInterval(ctx, interval); err != nil {
+		return churnReport{}, err
+	}
+
+	statAfter, err := readProcStat(statPath)
+	if err != nil {
+		return churnReport{}, wrapError("读取进程创建计数失败", err)
+	}
+	after, err := readProcSnapshot(pc.procRoot)
+	if err != nil {
+		return churnReport{}, wrapError("读取进程表失败", err)
+	}
+	elapsed := time.Since(start)
+	reportProgress(ctx, interval.Seconds(), interval.Seconds(), "采样完成")
+
+	report := diffProcSnapshots(before, after)
+	report.Interval = interval.String()
+	report.Elapsed = elapsed.Seconds()
+	if forks, ok := counterDelta(statBefore.Forks, statAfter.Forks); ok {
+		report.Forks = forks
+		report.ForksPerSec = float64(forks) / elapsed.Seconds()
+		if observed := uint64(report.AppearedCount); forks > observed {
+			report.Unobserved = forks - observed
+		}
+	}
+	report.SampledAt = time.Now()
+	return report, nil
+}
+
+// readProcSnapshot 读取 procRoot 下所有进程的 stat，读取期间退出的进程被忽略
+func readProcSnapshot(procRoot string) (procSnapshot, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(procSnapshot, len(entries))
+	for _, entry := range entries {
+		pid, err := strconv.ParseInt(entry.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(procRoot, entry.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		process, err := parseProcPIDStat(data)
+		if err != nil || process.PID != int32(pid) {
+			continue
+		}
+		snapshot[process.PID] = process
+	}
+	return snapshot, nil
+}
+
+// parseProcPIDStat 解析 /proc/<pid>/stat：pid (comm) state ppid ... starttime（第 22 个字段）。
+// comm 可能包含空格和括号，以最后一个 ")" 为界
+func parseProcPIDStat(data []byte) (procEntry, error) {
+	open := bytes.IndexByte(data, '(')
+	end := bytes.LastIndexByte(data, ')')
+	if open < 0 || end < open {
+		return procEntry{}, fmt.Errorf("无效的进程 stat: %q", data)
+	}
+
+	pid, err := strconv.ParseInt(strings.TrimSpace(string(data[:open])), 10, 32)
+	if err != nil {
+		return procEntry{}, fmt.Errorf("无效的 PID: %w", err)
+	}
+	// comm 之后从 state（第 3 个字段）开始
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 20 {
+		return procEntry{}, fmt.Errorf("进程 stat 字段不足: %d", len(fields)+2)
+	}
+	ppid, err := strconv.ParseInt(fields[1], 10, 32)
+	if err != nil {
+		return procEntry{}, fmt.Errorf("无效的 PPID: %w", err)
+	}
+	startTime, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return procEntry{}, fmt.Errorf("无效的启动时间: %w", err)
+	}
+
+	return procEntry{
+		PID:       int32(pid),
+		PPID:      int32(ppid),
+		Name:      SanitizeText(string(data[open+1 : end])),
+		StartTime: startTime,
+	}, nil
+}
+
+// diffProcSnapshots 比较两次进程表：PID 相同但启动时间不同（PID 被复用）视为一个进程消失、另一个出现。
+// 按出现的进程的父进程统计新子进程数，出现和消失的进程按 PID 排列，父进程按新子进程数降序（相同时按 PID）
+func diffProcSnapshots(before, after procSnapshot) churnReport {
+	report := churnReport{BeforeCount: len(before), AfterCount: len(after)}
+
+	parentName := func(ppid int32) string {
+		if parent, found := after[ppid]; found {
+			return parent.Name
+		}
+		return before[ppid].Name
+	}
+
+	children := make(map[int32]int)
+	for pid, process := range after {
+		if previous, found := before[pid]; found && previous.StartTime == process.StartTime {
+			continue
+		}
+		report.Appeared = append(report.Appeared, churnProcess{PID: pid, PPID: process.PPID, Name: process.Name, ParentName: parentName(process.PPID)})
+		children[process.PPID]++
+	}
+	for pid, process := range before {
+		if current, found := after[pid]; found && current.StartTime == process.StartTime {
+			continue
+		}
+		report.Disappeared = append(report.Disappeared, churnProcess{PID: pid, PPID: process.PPID, Name: process.Name, ParentName: parentName(process.PPID)})
+	}
+	sort.Slice(report.Appeared, func(i, j int) bool { return report.Appeared[i].PID < report.Appeared[j].PID })
+	sort.Slice(report.Disappeared, func(i, j int) bool { return report.Disappeared[i].PID < report.Disappeared[j].PID })
+	report.AppearedCount = len(report.Appeared)
+	report.DisappearedCount = len(report.Disappeared)
+
+	for ppid, count := range children {
+		report.TopParents = append(report.TopParents, churnParent{PID: ppid, Name: parentName(ppid), NewChildren: count})
+	}
+	sort.Slice(report.TopParents, func(i, j int) bool {
+		if report.TopParents[i].NewChildren != report.TopParents[j].NewChildren {
+			return report.TopParents[i].NewChildren > report.TopParents[j].NewChildren
+		}
+		return report.TopParents[i].PID < report.TopParents[j].PID
+	})
+	return report
+}
+
+// truncateChurnReport 出现、消失的进程和父进程排行各只保留前 limit 个，空列表输出为 []
+func truncateChurnReport(report *churnReport, limit int) {
+	report.Appeared = append([]churnProcess{}, report.Appeared[:min(limit, len(report.Appeared))]...)
+	report.Disappeared = append([]churnProcess{}, report.Disappeared[:min(limit, len(report.Disappeared))]...)
+	report.TopParents = append([]churnParent{}, report.TopParents[:min(limit, len(report.TopParents))]...)
+}
+
+// formatChurnReport 格式化进程变动采样结果
+func formatChurnReport(report churnReport) string {
+	var result string
+
+	result += fmt.Sprintf("🔄 进程变动 (采样时长: %s)\n", report.Interval)
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result += fmt.Sprintf("进程数: %d → %d\n", report.BeforeCount, report.AfterCount)
+	result += fmt.Sprintf("进程/线程创建: %d 次（%.1f 次/秒）\n", report.Forks, report.ForksPerSec)
+	result += fmt.Sprintf("新出现: %d 个，已消失: %d 个，未观察到: %d 次（线程或两次读取之间已结束的进程）\n",
+		report.AppearedCount, report.DisappearedCount, report.Unobserved)
+
+	if len(report.TopParents) > 0 {
+		result += "\n👪 新建子进程最多的父进程\n"
+		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		result += fmt.Sprintf("%-8s %-25s %s\n", "PID", "进程名", "新子进程")
+		for _, parent := range report.TopParents {
+			result += fmt.Sprintf("%-8d %s %d\n", parent.PID, fitColumn(churnName(parent.Name), 25), parent.NewChildren)
+		}
+	}
+
+	result += formatChurnProcesses("🆕 新出现的进程", report.Appeared, report.AppearedCount)
+	result += formatChurnProcesses("💨 已消失的进程", report.Disappeared, report.DisappearedCount)
+
+	result += "\n💡 只比较两次读取的进程表，在两次读取之间开始并结束的进程不会出现在列表中，仅体现在创建次数里\n"
+	result += fmt.Sprintf("📅 更新时间: %s\n", report.SampledAt.Format("2006-01-02 15:04:05"))
+
+	return result
+}
+
+// formatChurnProcesses 格式化出现或消失的进程列表
+func formatChurnProcesses(title string, processes []churnProcess, total int) string {
+	if len(processes) == 0 {
+		return ""
+	}
+
+	var result string
+	result += fmt.Sprintf("\n%s\n", title)
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result += fmt.Sprintf("%-8s %-25s %-8s %s\n", "PID", "进程名", "PPID", "父进程")
+	for _, process := range processes {
+		result += fmt.Sprintf("%-8d %s %-8d %s\n", process.PID, fitColumn(churnName(process.Name), 25), process.PPID, churnName(process.ParentName))
+	}
+	if total > len(processes) {
+		result += fmt.Sprintf("… 共 %d 个，可调大 limit\n", total)
+	}
+	return result
+}
+
+// churnName 截断过长的进程名，未知时显示 -
+func churnName(name string) string {
+	if name == "" {
+		return "-"
+	}
+	return truncateWidth(name, 25)
+}
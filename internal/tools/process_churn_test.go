@@ -0,0 +1,256 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// procPIDStat 构造 /proc/<pid>/stat 的内容，starttime 为第 22 个字段
+func procPIDStat(pid, ppid int32, comm string, startTime uint64) string {
+	return fmt.Sprintf("%d (%s) S %d 1 1 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 %d 0 0\n", pid, comm, ppid, startTime)
+}
+
+// snapshot 由进程列表构造进程表
+func snapshot(entries ...procEntry) procSnapshot {
+	result := make(procSnapshot, len(entries))
+	for _, entry := range entries {
+		result[entry.PID] = entry
+	}
+	return result
+}
+
+func TestParseProcPIDStat(t *testing.T) {
+	entry, err := parseProcPIDStat([]byte(procPIDStat(4242, 1, "cron", 987654)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry != (procEntry{PID: 4242, PPID: 1, Name: "cron", StartTime: 987654}) {
+		t.Errorf("parseProcPIDStat() = %+v", entry)
+	}
+
+	// comm 可以包含空格和括号
+	entry, err = parseProcPIDStat([]byte(procPIDStat(7, 2, "a) (b c", 5)))
+	if err != nil || entry.Name != "a) (b c" || entry.PPID != 2 || entry.StartTime != 5 {
+		t.Errorf("comm with parentheses = %+v, %v", entry, err)
+	}
+
+	for _, data := range []string{
+		"",
+		"4242 cron S 1",
+		"x (cron) S 1 1 1 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 5",
+		"4242 (cron) S 1 1 1",
+		"4242 (cron) S x 1 1 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 5",
+		"4242 (cron) S 1 1 1 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 x",
+	} {
+		if _, err := parseProcPIDStat([]byte(data)); err == nil {
+			t.Errorf("parseProcPIDStat(%q) succeeded, want an error", data)
+		}
+	}
+}
+
+func TestDiffProcSnapshots(t *testing.T) {
+	before := snapshot(
+		procEntry{PID: 1, PPID: 0, Name: "systemd", StartTime: 1},
+		procEntry{PID: 500, PPID: 1, Name: "cron", StartTime: 100},
+		procEntry{PID: 600, PPID: 500, Name: "backup.sh", StartTime: 200},
+		procEntry{PID: 700, PPID: 1, Name: "old", StartTime: 300},
+	)
+	after := snapshot(
+		procEntry{PID: 1, PPID: 0, Name: "systemd", StartTime: 1},
+		procEntry{PID: 500, PPID: 1, Name: "cron", StartTime: 100},
+		procEntry{PID: 800, PPID: 500, Name: "logrotate", StartTime: 400},
+		procEntry{PID: 801, PPID: 500, Name: "gzip", StartTime: 401},
+		// PID 被复用：启动时间不同，视为 old 消失、new 出现
+		procEntry{PID: 700, PPID: 1, Name: "new", StartTime: 402},
+		// 父进程在第二次读取前已退出，名称取第一次读取的
+		procEntry{PID: 900, PPID: 600, Name: "tar", StartTime: 403},
+	)
+
+	report := diffProcSnapshots(before, after)
+	describe := func(processes []churnProcess) string {
+		var result []string
+		for _, process := range processes {
+			result = append(result, fmt.Sprintf("%d:%s<%s", process.PID, process.Name, process.ParentName))
+		}
+		return strings.Join(result, " ")
+	}
+	if got := describe(report.Appeared); got != "700:new<systemd 800:logrotate<cron 801:gzip<cron 900:tar<backup.sh" {
+		t.Errorf("appeared = %s", got)
+	}
+	if got := describe(report.Disappeared); got != "600:backup.sh<cron 700:old<systemd" {
+		t.Errorf("disappeared = %s", got)
+	}
+	if report.AppearedCount != 4 || report.DisappearedCount != 2 || report.BeforeCount != 4 || report.AfterCount != 6 {
+		t.Errorf("report = %+v", report)
+	}
+	// 按新子进程数降序，相同时按 PID
+	want := []churnParent{{PID: 500, Name: "cron", NewChildren: 2}, {PID: 1, Name: "systemd", NewChildren: 1}, {PID: 600, Name: "backup.sh", NewChildren: 1}}
+	if fmt.Sprint(report.TopParents) != fmt.Sprint(want) {
+		t.Errorf("top parents = %+v, want %+v", report.TopParents, want)
+	}
+
+	// 进程表不变时没有变动
+	report = diffProcSnapshots(before, before)
+	if report.AppearedCount != 0 || report.DisappearedCount != 0 || len(report.TopParents) != 0 {
+		t.Errorf("unchanged = %+v", report)
+	}
+}
+
+func TestTruncateChurnReport(t *testing.T) {
+	report := churnReport{
+		Appeared:    []churnProcess{{PID: 1}, {PID: 2}, {PID: 3}},
+		Disappeared: []churnProcess{{PID: 4}},
+	}
+	truncateChurnReport(&report, 2)
+	if len(report.Appeared) != 2 || len(report.Disappeared) != 1 {
+		t.Errorf("truncated = %+v", report)
+	}
+	// 空列表在 JSON 中为 []
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"top_parents":[]`) {
+		t.Errorf("JSON = %s", data)
+	}
+}
+
+// churnProcRoot 构造包含 stat 和各进程 stat 的假 /proc
+func churnProcRoot(t *testing.T, forks int, entries ...procEntry) string {
+	t.Helper()
+	files := map[string]string{"stat": "ctxt 100\nprocesses " + strconv.Itoa(forks) + "\n"}
+	for _, entry := range entries {
+		files[filepath.Join(strconv.Itoa(int(entry.PID)), "stat")] = procPIDStat(entry.PID, entry.PPID, entry.Name, entry.StartTime)
+	}
+	return writeTree(t, files)
+}
+
+func TestProcessChurnTool(t *testing.T) {
+	root := churnProcRoot(t, 1000,
+		procEntry{PID: 1, Name: "systemd", StartTime: 1},
+		procEntry{PID: 500, PPID: 1, Name: "cron", StartTime: 100},
+		procEntry{PID: 600, PPID: 500, Name: "backup.sh", StartTime: 200},
+	)
+	// 非进程目录和无法解析的 stat 被忽略
+	if err := os.MkdirAll(filepath.Join(root, "sys"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "42"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一次读取后（开始等待时）改变进程表：backup.sh 退出，cron 新建两个子进程；期间共创建 10 次
+	ctx := WithProgress(context.Background(), func(done, total float64, message string) {
+		if done != 0 || message != "采样中" {
+			return
+		}
+		if err := os.RemoveAll(filepath.Join(root, "600")); err != nil {
+			t.Error(err)
+		}
+		update := map[string]string{
+			"stat":     "ctxt 200\nprocesses 1010\n",
+			"800/stat": procPIDStat(800, 500, "logrotate", 300),
+			"801/stat": procPIDStat(801, 500, "gzip", 301),
+		}
+		for name, content := range update {
+			if err := os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0o755); err != nil {
+				t.Error(err)
+			}
+			if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	tool := NewProcessChurnTool()
+	tool.procRoot, tool.platform = root, platformLinux
+	text, err := tool.Execute(ctx, map[string]interface{}{"interval": "20ms", "format": "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report churnReport
+	if err := json.Unmarshal([]byte(text), &report); err != nil {
+		t.Fatal(err)
+	}
+	// 10 次创建中只观察到 2 个进程，其余计为未观察到
+	if report.Forks != 10 || report.Unobserved != 8 || report.AppearedCount != 2 || report.DisappearedCount != 1 ||
+		report.BeforeCount != 3 || report.AfterCount != 4 || report.ForksPerSec <= 0 || report.Interval != "20ms" {
+		t.Errorf("report = %+v", report)
+	}
+	if len(report.TopParents) != 1 || report.TopParents[0] != (churnParent{PID: 500, Name: "cron", NewChildren: 2}) {
+		t.Errorf("top parents = %+v", report.TopParents)
+	}
+
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"interval": "10ms", "limit": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"🔄 进程变动 (采样时长: 10ms)\n",
+		"进程数: 4 → 4\n",
+		"进程/线程创建: 0 次（0.0 次/秒）\n",
+		"新出现: 0 个，已消失: 0 个，未观察到: 0 次",
+		"💡 只比较两次读取的进程表，在两次读取之间开始并结束的进程不会出现在列表中，仅体现在创建次数里\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output lacks %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestFormatChurnReport(t *testing.T) {
+	text := formatChurnReport(churnReport{
+		Interval:         "2s",
+		Forks:            10,
+		ForksPerSec:      5,
+		Appeared:         []churnProcess{{PID: 800, PPID: 500, Name: "logrotate", ParentName: "cron"}},
+		AppearedCount:    3,
+		Disappeared:      []churnProcess{{PID: 600, PPID: 500, Name: "backup.sh"}},
+		DisappearedCount: 1,
+		Unobserved:       7,
+		TopParents:       []churnParent{{PID: 500, Name: "cron", NewChildren: 3}},
+		BeforeCount:      3,
+		AfterCount:       5,
+	})
+	for _, want := range []string{
+		"进程/线程创建: 10 次（5.0 次/秒）\n",
+		"新出现: 3 个，已消失: 1 个，未观察到: 7 次（线程或两次读取之间已结束的进程）\n",
+		"\n500      cron                      3\n",
+		"\n800      logrotate                 500      cron\n",
+		"… 共 3 个，可调大 limit\n",
+		// 父进程未知时显示 -
+		"\n600      backup.sh                 500      -\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output lacks %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestProcessChurnArguments(t *testing.T) {
+	tool := NewProcessChurnTool()
+	tool.procRoot, tool.platform = t.TempDir(), platformLinux
+	for _, interval := range []string{"0s", "31s", "2m"} {
+		if _, err := tool.Execute(context.Background(), map[string]interface{}{"interval": interval}); err == nil {
+			t.Errorf("interval %s accepted", interval)
+		}
+	}
+	// 缺少 /proc/stat
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"interval": "10ms"}); err == nil {
+		t.Error("missing /proc/stat accepted")
+	}
+
+	tool.platform = platformDarwin
+	_, err := tool.Execute(context.Background(), nil)
+	var toolErr *Error
+	if !errors.As(err, &toolErr) || toolErr.Code != ErrUnsupportedPlatform {
+		t.Errorf("darwin: err = %v", err)
+	}
+}
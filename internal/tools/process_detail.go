@@ -0,0 +1,446 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/types"
+)
+
+// maxEnvironBytes 读取 /proc/<pid>/environ 的字节数上限，超出部分（包括被截断的最后一个变量）丢弃
+const maxEnvironBytes = 256 << 10
+
+// redactedValue 名称匹配隐藏模式的环境变量显示的值
+const redactedValue = "[redacted]"
+
+// DefaultRedactEnvPatterns 默认隐藏值的环境变量名称模式（通配符，不区分大小写），会话记录也用它隐藏工具参数
+var DefaultRedactEnvPatterns = []string{
+	"*TOKEN*",
+	"*SECRET*",
+	"*PASSWORD*",
+	"*PASSWD*",
+	"*CREDENTIAL*",
+	"*API_KEY*",
+	"*APIKEY*",
+	"*PRIVATE_KEY*",
+	"*ACCESS_KEY*",
+}
+
+// processLimitRows 报告的 rlimit 及其在 /proc/<pid>/limits 中的行名
+var processLimitRows = []struct {
+	Name  string
+	Label string
+}{
+	{Name: "nofile", Label: "Max open files"},
+	{Name: "nproc", Label: "Max processes"},
+	{Name: "core", Label: "Max core file size"},
+	{Name: "as", Label: "Max address space"},
+}
+
+// ProcessDetailTool 进程详情工具：单个进程的基本信息，可选环境变量（隐藏敏感值）和资源限制（仅 Linux）
+type ProcessDetailTool struct {
+	procRoot       string
+	platform       string
+	redactPatterns []string
+}
+
+// NewProcessDetailTool 创建新的进程详情工具，redactPatterns 为 nil 时使用默认的隐藏模式（空列表表示不隐藏），
+// 无效的模式会被忽略
+func NewProcessDetailTool(redactPatterns []string) *ProcessDetailTool {
+	if redactPatterns == nil {
+		redactPatterns = DefaultRedactEnvPatterns
+	}
+
+	valid := make([]string, 0, len(redactPatterns))
+	for _, pattern := range redactPatterns {
+		pattern = strings.ToUpper(pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			slog.Warn("忽略无效的环境变量隐藏模式", "pattern", pattern, "error", err)
+			continue
+		}
+		valid = append(valid, pattern)
+	}
+
+	return &ProcessDetailTool{
+		procRoot:       "/proc",
+		platform:       hostPlatform,
+		redactPatterns: valid,
+	}
+}
+
+// envVar 进程的一个环境变量
+type envVar struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Redacted bool   `json:"redacted,omitempty"`
+}
+
+// processEnviron 进程的环境变量
+type processEnviron struct {
+	Vars []envVar `json:"vars"`
+	// RedactedCount 值被隐藏的变量数
+	RedactedCount int `json:"redacted_count"`
+	// Truncated 环境超过 maxEnvironBytes，只包含前面的变量
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// processLimit 一项资源限制，Soft/Hard 为 null 表示 unlimited
+type processLimit struct {
+	Name string  `json:"name"`
+	Soft *uint64 `json:"soft"`
+	Hard *uint64 `json:"hard"`
+	Unit string  `json:"unit,omitempty"`
+}
+
+// processDetail 进程详情，读取失败的字段留空
+type processDetail struct {
+	PID        int32           `json:"pid"`
+	Name       string          `json:"name"`
+	PPID       int32           `json:"ppid"`
+	Username   string          `json:"username,omitempty"`
+	Status     string          `json:"status,omitempty"`
+	CreateTime *time.Time      `json:"create_time,omitempty"`
+	Cmdline    []string        `json:"cmdline,omitempty"`
+	CPUPercent float64         `json:"cpu_percent"`
+	RSSBytes   uint64          `json:"rss_bytes"`
+	VMSBytes   uint64          `json:"vms_bytes"`
+	NumFDs     int32           `json:"num_fds,omitempty"`
+	Environ    *processEnviron `json:"environ,omitempty"`
+	Limits     []processLimit  `json:"limits,omitempty"`
+	// Notes 无法读取的可选部分（权限不足、平台不支持等）
+	Notes []string `json:"notes,omitempty"`
+	// LastUpdated 采集时间，已运行时长相对于它计算
+	LastUpdated time.Time           `json:"last_updated"`
+	Host        *types.HostIdentity `json:"host,omitempty"`
+}
+
+// GetName 获取工具名称
+func (pd *ProcessDetailTool) GetName() string {
+	return "process_detail"
+}
+
+// GetDescription 获取工具描述
+func (pd *ProcessDetailTool) GetDescription() string {
+	return "查看单个进程的详情：命令行、用户、状态、CPU 和内存，可选环境变量（敏感值已隐藏）和资源限制 rlimit（仅 Linux）"
+}
+
+// GetAnnotations 获取工具注解
+func (pd *ProcessDetailTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("进程详情")
+}
+
+// processDetailArgs process_detail 的参数
+type processDetailArgs struct {
+	strictArgs
+	PID            int  `arg:"pid,required,min=1" desc:"进程 ID"`
+	IncludeEnviron bool `arg:"include_environ,default=false" desc:"包含进程的环境变量，名称匹配隐藏模式（如 *TOKEN*、*SECRET*、*PASSWORD*）的值显示为 [redacted]（仅 Linux）"`
+	IncludeLimits  bool `arg:"include_limits,default=false" desc:"包含资源限制 nofile、nproc、core、as 的软/硬限制（仅 Linux）"`
+	formatArgs
+}
+
+// GetInputSchema 获取输入模式
+func (pd *ProcessDetailTool) GetInputSchema() types.InputSchema {
+	return argsSchema(processDetailArgs{})
+}
+
+// Examples 获取调用示例
+func (pd *ProcessDetailTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "进程 1234 的基本信息",
+			Arguments:   map[string]interface{}{"pid": 1234},
+		},
+		{
+			Description: "进程 1234 的环境变量和资源限制",
+			Arguments:   map[string]interface{}{"pid": 1234, "include_environ": "true", "include_limits": "true"},
+		},
+	}
+}
+
+// Execute 读取进程详情
+func (pd *ProcessDetailTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a processDetailArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+	pid := a.PID
+	if pid > math.MaxInt32 {
+		return "", argumentError("pid", "无效的 PID: %d", pid)
+	}
+
+	p, err := providers.Process.NewProcess(ctx, int32(pid))
+	if err != nil {
+		return "", &Error{Code: ErrNotFound, Message: fmt.Sprintf("找不到 PID 为 %d 的进程", pid), Err: err}
+	}
+
+	detail := pd.collect(ctx, p)
+	if a.IncludeEnviron {
+		pd.addEnviron(&detail)
+	}
+	if a.IncludeLimits {
+		pd.addLimits(&detail)
+	}
+
+	if a.Format == "json" {
+		detail.Host = identity.Get()
+		jsonData, err := json.MarshalIndent(detail, "", "  ")
+		if err != nil {
+			return "", wrapError("序列化进程详情失败", err)
+		}
+		return string(jsonData), nil
+	}
+
+	return formatProcessDetail(detail, a.IncludeEnviron, a.IncludeLimits), nil
+}
+
+// collect 读取进程的基本信息，无法读取的字段（权限不足或平台不支持）留空
+func (pd *ProcessDetailTool) collect(ctx context.Context, p Process) processDetail {
+	detail := processDetail{PID: p.PID(), LastUpdated: time.Now()}
+	detail.Name, _ = p.NameWithContext(ctx)
+	detail.PPID, _ = p.PpidWithContext(ctx)
+	detail.Username, _ = p.UsernameWithContext(ctx)
+	if status, err := p.StatusWithContext(ctx); err == nil && len(status) > 0 {
+		detail.Status = status[0]
+	}
+	if createTime, err := p.CreateTimeWithContext(ctx); err == nil && createTime > 0 {
+		started := time.UnixMilli(createTime)
+		detail.CreateTime = &started
+	}
+	detail.Cmdline, _ = p.CmdlineSliceWithContext(ctx)
+	detail.CPUPercent, _ = p.CPUPercentWithContext(ctx)
+	if memInfo, err := p.MemoryInfoWithContext(ctx); err == nil && memInfo != nil {
+		detail.RSSBytes = memInfo.RSS
+		detail.VMSBytes = memInfo.VMS
+	}
+	detail.NumFDs, _ = p.NumFDsWithContext(ctx)
+	return detail
+}
+
+// addEnviron 读取环境变量并隐藏敏感值，失败时记录说明而不是返回错误
+func (pd *ProcessDetailTool) addEnviron(detail *processDetail) {
+	if pd.platform != platformLinux {
+		detail.Notes = append(detail.Notes, fmt.Sprintf("环境变量仅支持 Linux（当前平台: %s）", pd.platform))
+		return
+	}
+
+	data, truncated, err := readEnviron(filepath.Join(pd.procRoot, strconv.Itoa(int(detail.PID)), "environ"))
+	if err != nil {
+		detail.Notes = append(detail.Notes, sectionErrorNote("环境变量", err))
+		return
+	}
+
+	vars := redactEnviron(parseEnviron(data), pd.redactPatterns)
+	environ := &processEnviron{Vars: vars, Truncated: truncated}
+	for _, v := range vars {
+		if v.Redacted {
+			environ.RedactedCount++
+		}
+	}
+	detail.Environ = environ
+}
+
+// addLimits 读取资源限制，失败时记录说明而不是返回错误
+func (pd *ProcessDetailTool) addLimits(detail *processDetail) {
+	if pd.platform != platformLinux {
+		detail.Notes = append(detail.Notes, fmt.Sprintf("资源限制仅支持 Linux（当前平台: %s）", pd.platform))
+		return
+	}
+
+	data, err := os.ReadFile(filepath.Join(pd.procRoot, strconv.Itoa(int(detail.PID)), "limits"))
+	if err != nil {
+		detail.Notes = append(detail.Notes, sectionErrorNote("资源限制", err))
+		return
+	}
+	detail.Limits = parseProcLimits(string(data))
+}
+
+// sectionErrorNote 可选部分读取失败时的说明
+func sectionErrorNote(section string, err error) string {
+	if errors.Is(err, fs.ErrPermission) {
+		return fmt.Sprintf("没有权限读取%s（需要以 root 或进程所有者身份运行）", section)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Sprintf("读取%s失败：进程可能已退出", section)
+	}
+	return fmt.Sprintf("读取%s失败: %v", section, err)
+}
+
+// readEnviron 读取 environ 文件，最多 maxEnvironBytes 字节，超出时丢弃最后一个不完整的变量并返回 truncated
+func readEnviron(path string) ([]byte, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxEnvironBytes+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(data) <= maxEnvironBytes {
+		return data, false, nil
+	}
+
+	data = data[:maxEnvironBytes]
+	if end := bytes.LastIndexByte(data, 0); end >= 0 {
+		data = data[:end+1]
+	} else {
+		data = nil
+	}
+	return data, true, nil
+}
+
+// parseEnviron 解析以 NUL 分隔的 NAME=value 列表，保持原有顺序，跳过空项；没有 = 的项值为空
+func parseEnviron(data []byte) []envVar {
+	vars := []envVar{}
+	for _, entry := range bytes.Split(data, []byte{0}) {
+		if len(entry) == 0 {
+			continue
+		}
+		name, value, _ := strings.Cut(string(entry), "=")
+		vars = append(vars, envVar{Name: name, Value: value})
+	}
+	return vars
+}
+
+// redactEnviron 名称匹配任一模式（已转为大写）的变量值替换为 [redacted]，匹配时不区分大小写
+func redactEnviron(vars []envVar, patterns []string) []envVar {
+	for i := range vars {
+		if matchesEnvPattern(vars[i].Name, patterns) {
+			vars[i].Value = redactedValue
+			vars[i].Redacted = true
+		}
+	}
+	return vars
+}
+
+// matchesEnvPattern 变量名是否匹配任一通配符模式（patterns 已转为大写）
+func matchesEnvPattern(name string, patterns []string) bool {
+	upper := strings.ToUpper(name)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, upper); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// parseProcLimits 从 /proc/<pid>/limits 中解析 processLimitRows 列出的限制，按该顺序返回
+func parseProcLimits(text string) []processLimit {
+	rows := make(map[string]string)
+	for _, line := range strings.Split(text, "\n") {
+		for _, row := range processLimitRows {
+			if rest, found := strings.CutPrefix(line, row.Label); found {
+				rows[row.Name] = rest
+			}
+		}
+	}
+
+	limits := []processLimit{}
+	for _, row := range processLimitRows {
+		fields := strings.Fields(rows[row.Name])
+		if len(fields) < 2 {
+			continue
+		}
+		limit := processLimit{Name: row.Name, Soft: parseLimitValue(fields[0]), Hard: parseLimitValue(fields[1])}
+		if len(fields) > 2 {
+			limit.Unit = fields[2]
+		}
+		limits = append(limits, limit)
+	}
+	return limits
+}
+
+// parseLimitValue 解析限制值，unlimited 或无法解析时返回 nil
+func parseLimitValue(value string) *uint64 {
+	parsed, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
+
+// formatLimitValue 格式化限制值，bytes 单位的值以可读大小显示
+func formatLimitValue(value *uint64, unit string) string {
+	if value == nil {
+		return "unlimited"
+	}
+	if unit == "bytes" && *value > 0 {
+		return formatBytes(*value)
+	}
+	return strconv.FormatUint(*value, 10)
+}
+
+// formatProcessDetail 格式化进程详情
+func formatProcessDetail(detail processDetail, includeEnviron, includeLimits bool) string {
+	var result string
+
+	result += fmt.Sprintf("🔎 进程详情 (PID %d)\n", detail.PID)
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result += fmt.Sprintf("进程名: %s\n", detail.Name)
+	result += fmt.Sprintf("父进程: %d\n", detail.PPID)
+	if detail.Username != "" {
+		result += fmt.Sprintf("用户: %s\n", detail.Username)
+	}
+	if detail.Status != "" {
+		result += fmt.Sprintf("状态: %s\n", detail.Status)
+	}
+	if detail.CreateTime != nil {
+		result += fmt.Sprintf("启动时间: %s（已运行 %s）\n", formatTimestamp(detail.CreateTime.Local()),
+			formatDurationWords(detail.LastUpdated.Sub(*detail.CreateTime)))
+	} else {
+		result += "启动时间: -\n"
+	}
+	if len(detail.Cmdline) > 0 {
+		result += fmt.Sprintf("命令行: %s\n", strings.Join(detail.Cmdline, " "))
+	}
+	result += fmt.Sprintf("CPU: %.1f%%\n", detail.CPUPercent)
+	result += fmt.Sprintf("内存: RSS %s，虚拟 %s\n", formatBytes(detail.RSSBytes), formatBytes(detail.VMSBytes))
+	if detail.NumFDs > 0 {
+		result += fmt.Sprintf("文件描述符: %d\n", detail.NumFDs)
+	}
+
+	if includeLimits && detail.Limits != nil {
+		result += "\n📏 资源限制\n"
+		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		result += fmt.Sprintf("%-8s %-14s %-14s\n", "名称", "软限制", "硬限制")
+		for _, limit := range detail.Limits {
+			result += fmt.Sprintf("%-8s %-14s %-14s\n", limit.Name, formatLimitValue(limit.Soft, limit.Unit), formatLimitValue(limit.Hard, limit.Unit))
+		}
+	}
+
+	if includeEnviron && detail.Environ != nil {
+		environ := detail.Environ
+		result += fmt.Sprintf("\n🌱 环境变量 (%d 个，已隐藏 %d 个值)\n", len(environ.Vars), environ.RedactedCount)
+		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for _, v := range environ.Vars {
+			result += fmt.Sprintf("%s=%s\n", v.Name, v.Value)
+		}
+		if environ.Truncated {
+			result += fmt.Sprintf("⚠️ 环境超过 %s，只显示前面的变量\n", formatBytes(maxEnvironBytes))
+		}
+	}
+
+	for _, note := range detail.Notes {
+		result += fmt.Sprintf("\nℹ️  %s", note)
+	}
+	if len(detail.Notes) > 0 {
+		result += "\n"
+	}
+
+	return result
+}
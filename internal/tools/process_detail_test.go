@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEnviron(t *testing.T) {
+	data := []byte("PATH=/usr/bin:/bin\x00\x00EMPTY=\x00NOVALUE\x00OPTS=a=b=c\x00")
+	want := []envVar{
+		{Name: "PATH", Value: "/usr/bin:/bin"},
+		{Name: "EMPTY"},
+		{Name: "NOVALUE"},
+		{Name: "OPTS", Value: "a=b=c"},
+	}
+	if got := parseEnviron(data); !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseEnviron() = %+v, want %+v", got, want)
+	}
+	if got := parseEnviron(nil); got == nil || len(got) != 0 {
+		t.Fatalf("parseEnviron(nil) = %#v, want an empty non-nil list", got)
+	}
+}
+
+func TestRedactEnviron(t *testing.T) {
+	tool := NewProcessDetailTool(nil)
+	vars := redactEnviron([]envVar{
+		{Name: "GITHUB_TOKEN", Value: "ghp_secret"},
+		{Name: "db_password", Value: "hunter2"},
+		{Name: "AWS_ACCESS_KEY_ID", Value: "AKIA"},
+		{Name: "HOME", Value: "/root"},
+		{Name: "TOKENIZER", Value: "bpe"},
+	}, tool.redactPatterns)
+
+	redacted := map[string]bool{"GITHUB_TOKEN": true, "db_password": true, "AWS_ACCESS_KEY_ID": true, "TOKENIZER": true}
+	for _, v := range vars {
+		if redacted[v.Name] {
+			if !v.Redacted || v.Value != redactedValue {
+				t.Errorf("%s = %q, want the value redacted", v.Name, v.Value)
+			}
+		} else if v.Redacted || v.Value != "/root" {
+			t.Errorf("%s = %q, want the value kept", v.Name, v.Value)
+		}
+	}
+}
+
+func TestRedactPatternsConfigurable(t *testing.T) {
+	vars := []envVar{{Name: "API_TOKEN", Value: "x"}, {Name: "internal_url", Value: "y"}}
+
+	none := NewProcessDetailTool([]string{})
+	if got := redactEnviron(append([]envVar(nil), vars...), none.redactPatterns); got[0].Redacted || got[1].Redacted {
+		t.Fatalf("an empty pattern list should not redact anything: %+v", got)
+	}
+
+	// 无效的模式被忽略，其余模式不区分大小写
+	custom := NewProcessDetailTool([]string{"[", "internal_*"})
+	got := redactEnviron(append([]envVar(nil), vars...), custom.redactPatterns)
+	if got[0].Redacted || !got[1].Redacted {
+		t.Fatalf("custom patterns redacted %+v, want only internal_url", got)
+	}
+}
+
+func TestReadEnvironTruncates(t *testing.T) {
+	dir := t.TempDir()
+	entry := []byte("VAR=" + strings.Repeat("x", 995) + "\x00")
+	var data []byte
+	for len(data) <= maxEnvironBytes {
+		data = append(data, entry...)
+	}
+	path := filepath.Join(dir, "environ")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	read, truncated, err := readEnviron(path)
+	if err != nil || !truncated {
+		t.Fatalf("readEnviron() truncated=%v, err=%v; want a truncated read", truncated, err)
+	}
+	if len(read) > maxEnvironBytes || len(read)%len(entry) != 0 || !bytes.HasSuffix(read, []byte{0}) {
+		t.Fatalf("read %d bytes, want whole variables within %d bytes", len(read), maxEnvironBytes)
+	}
+
+	small := filepath.Join(dir, "small")
+	if err := os.WriteFile(small, entry, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if read, truncated, err := readEnviron(small); err != nil || truncated || !bytes.Equal(read, entry) {
+		t.Fatalf("readEnviron(small) = %d bytes, truncated=%v, err=%v", len(read), truncated, err)
+	}
+}
+
+// newDetailTool 从临时目录读取 /proc 的 Linux 进程详情工具
+func newDetailTool(t *testing.T, pid string, environ string) *ProcessDetailTool {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, pid), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, pid, "environ"), []byte(environ), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewProcessDetailTool(nil)
+	tool.procRoot = root
+	tool.platform = platformLinux
+	return tool
+}
+
+func TestProcessDetailEnviron(t *testing.T) {
+	useFakeProcesses(t, newFakeProcessProvider(&fakeProcess{pid: 4242, name: "api", cmdline: []string{"api"}}))
+	tool := newDetailTool(t, "4242", "HOME=/srv\x00SECRET_KEY=abc\x00")
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"pid": 4242, "include_environ": "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"环境变量 (2 个，已隐藏 1 个值)", "HOME=/srv\n", "SECRET_KEY=[redacted]\n"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %q:\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "abc") {
+		t.Fatalf("the secret leaked into the output:\n%s", result)
+	}
+}
+
+func TestProcessDetailEnvironUnsupported(t *testing.T) {
+	useFakeProcesses(t, newFakeProcessProvider(&fakeProcess{pid: 4242, name: "api", cmdline: []string{"api"}}))
+	tool := newDetailTool(t, "4242", "SECRET_KEY=abc\x00")
+	tool.platform = platformDarwin
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"pid": 4242, "include_environ": "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, "环境变量仅支持 Linux") || strings.Contains(result, "SECRET_KEY") {
+		t.Fatalf("unexpected result:\n%s", result)
+	}
+}
+
+func TestFormatProcessDetailUsesCollectionTime(t *testing.T) {
+	started := fixedTime.Add(-(26*time.Hour + 5*time.Minute))
+	detail := processDetail{PID: 4242, Name: "api", CreateTime: &started, LastUpdated: fixedTime}
+
+	output := formatProcessDetail(detail, false, false)
+	if want := "（已运行 1天 2小时 5分钟）"; !strings.Contains(output, want) {
+		t.Fatalf("output missing %q:\n%s", want, output)
+	}
+	if again := formatProcessDetail(detail, false, false); again != output {
+		t.Fatal("formatProcessDetail is not deterministic")
+	}
+}
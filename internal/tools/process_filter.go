@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+)
+
+// kthreaddPID Linux 内核线程的父进程 kthreadd 的 PID
+const kthreaddPID = 2
+
+// processQuery top_processes 的查询条件
+type processQuery struct {
+	SortBy string
+	// Descending 按 age 排序时运行最久的在前，否则最近启动的在前
+	Descending           bool
+	Limit                int
+	Offset               int
+	GroupBy              string
+	User                 string
+	IncludeKernelThreads bool
+}
+
+// isKernelThread 判断是否为 Linux 内核线程（父进程为 kthreadd 或命令行为空），其他平台总是返回 false
+func isKernelThread(ctx context.Context, p Process) bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+
+	if ppid, err := p.PpidWithContext(ctx); err == nil && ppid == kthreaddPID {
+		return true
+	}
+
+	cmdline, err := p.CmdlineSliceWithContext(ctx)
+	return err == nil && len(cmdline) == 0
+}
+
+// usernameCache 单次调用内按 UID 缓存用户名，避免对每个进程重复查询用户数据库
+type usernameCache map[int32]string
+
+// lookup 获取进程的用户名，无法获取 UID 的平台直接查询
+func (uc usernameCache) lookup(ctx context.Context, p Process) string {
+	uids, err := p.UidsWithContext(ctx)
+	if err != nil || len(uids) == 0 {
+		username, _ := p.UsernameWithContext(ctx)
+		return username
+	}
+
+	if username, found := uc[uids[0]]; found {
+		return username
+	}
+
+	username, _ := p.UsernameWithContext(ctx)
+	uc[uids[0]] = username
+	return username
+}
+
+// lookupOrUID 获取进程的用户名，用户名无法解析（如容器中不存在的 UID）时返回数字 UID，两者都无法获取时返回空字符串
+func (uc usernameCache) lookupOrUID(ctx context.Context, p Process) string {
+	if username := uc.lookup(ctx, p); username != "" {
+		return username
+	}
+	if uids, err := p.UidsWithContext(ctx); err == nil && len(uids) > 0 {
+		return strconv.Itoa(int(uids[0]))
+	}
+	return ""
+}
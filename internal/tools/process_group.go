@@ -0,0 +1,184 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+
+	"mcp-example/internal/types"
+)
+
+// 进程分组方式
+const (
+	groupByNone = "none"
+	groupByName = "name"
+	groupByUser = "user"
+)
+
+// unknownGroupKey 无法获取用户名时使用的分组键
+const unknownGroupKey = "(未知)"
+
+// groupProcesses 按名称或用户聚合进程：CPU%、RSS 和文件描述符数求和，
+// 组内按排序指标选出最高的进程作为 TopPID，组之间按同一指标降序排列
+func groupProcesses(procInfos []types.ProcessInfo, groupBy, sortBy string) []types.ProcessGroup {
+	groups := make(map[string]*types.ProcessGroup)
+	top := make(map[string]types.ProcessInfo)
+
+	for _, proc := range procInfos {
+		key := proc.Name
+		if groupBy == groupByUser {
+			key = proc.Username
+		}
+		if key == "" {
+			key = unknownGroupKey
+		}
+
+		group, found := groups[key]
+		if !found {
+			group = &types.ProcessGroup{Key: key}
+			groups[key] = group
+		}
+		group.Count++
+		group.CPUPercent += proc.CPUPercent
+		group.MemoryBytes += proc.MemoryBytes
+		group.NumFDs += int64(proc.NumFDs)
+		group.PIDs = append(group.PIDs, proc.PID)
+
+		if current, found := top[key]; !found || processLess(proc, current, sortBy) {
+			top[key] = proc
+		}
+	}
+
+	result := make([]types.ProcessGroup, 0, len(groups))
+	for _, key := range sortedKeys(groups) {
+		group := groups[key]
+		group.MemoryMB = float64(group.MemoryBytes) / (1024 * 1024)
+		group.TopPID = top[key].PID
+		sort.Slice(group.PIDs, func(i, j int) bool {
+			return group.PIDs[i] < group.PIDs[j]
+		})
+		result = append(result, *group)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return groupLess(result[i], result[j], sortBy)
+	})
+
+	return result
+}
+
+// groupLess 按排序指标（cpu、memory、fds 或 count）比较两个进程组，a 排在 b 之前时返回 true（数值相同时按分组键升序，保证顺序稳定）
+func groupLess(a, b types.ProcessGroup, sortBy string) bool {
+	switch sortBy {
+	case "cpu":
+		if a.CPUPercent != b.CPUPercent {
+			return a.CPUPercent > b.CPUPercent
+		}
+	case "fds":
+		if a.NumFDs != b.NumFDs {
+			return a.NumFDs > b.NumFDs
+		}
+	case "count":
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+	default:
+		if a.MemoryBytes != b.MemoryBytes {
+			return a.MemoryBytes > b.MemoryBytes
+		}
+	}
+	return a.Key < b.Key
+}
+
+// processLess 按排序指标比较两个进程，a 排在 b 之前时返回 true（数值相同时按 PID 升序）。
+// fds 按文件描述符数，其余指标（包括 count）按内存
+func processLess(a, b types.ProcessInfo, sortBy string) bool {
+	switch sortBy {
+	case "cpu":
+		if a.CPUPercent != b.CPUPercent {
+			return a.CPUPercent > b.CPUPercent
+		}
+	case "fds":
+		if a.NumFDs != b.NumFDs {
+			return a.NumFDs > b.NumFDs
+		}
+	default:
+		if a.MemoryBytes != b.MemoryBytes {
+			return a.MemoryBytes > b.MemoryBytes
+		}
+	}
+	return a.PID < b.PID
+}
+
+// sortByAge 按运行时长排序，只用于按进程列出（不支持进程组）
+const sortByAge = "age"
+
+// processAgeLess 按运行时长比较两个进程，oldestFirst 为 true 时启动最早的在前，否则最近启动的在前。
+// 启动时间未知（CreateTime 为 0）的进程总是排在最后；启动时间相同时按 PID 升序，保证顺序稳定
+func processAgeLess(a, b types.ProcessInfo, oldestFirst bool) bool {
+	switch {
+	case a.CreateTime == b.CreateTime:
+		return a.PID < b.PID
+	case a.CreateTime == 0:
+		return false
+	case b.CreateTime == 0:
+		return true
+	case oldestFirst:
+		return a.CreateTime < b.CreateTime
+	default:
+		return a.CreateTime > b.CreateTime
+	}
+}
+
+// processGroupTable 进程组列表的表格形式（csv、markdown 输出）
+func processGroupTable(processList types.ProcessList) table {
+	keyTitle := "名称"
+	if processList.GroupBy == groupByUser {
+		keyTitle = "用户"
+	}
+	rows := table{columns: []tableColumn{{"key", keyTitle}, {"count", "实例数"}, {"cpu_percent", "总CPU%"}, {"memory_bytes", "总内存"}, {"top_pid", "最高PID"}}}
+	for _, group := range processList.Groups {
+		rows.addRow(textCell(group.Key), intCell(int64(group.Count)), floatCell(group.CPUPercent), bytesCell(group.MemoryBytes), intCell(int64(group.TopPID)))
+	}
+	return rows
+}
+
+// formatProcessGroups 格式化分组后的进程列表
+func (pt *ProcessTool) formatProcessGroups(processList types.ProcessList, sortBy string, limit int) string {
+	var result string
+
+	groupLabel := "名称"
+	if processList.GroupBy == groupByUser {
+		groupLabel = "用户"
+	}
+
+	switch {
+	case processList.Offset > 0 && sortBy == "cpu":
+		result += fmt.Sprintf("🚀 CPU 占用第 %d–%d 名的进程组（按%s）\n", processList.Offset+1, processList.Offset+limit, groupLabel)
+	case processList.Offset > 0:
+		result += fmt.Sprintf("💾 内存占用第 %d–%d 名的进程组（按%s）\n", processList.Offset+1, processList.Offset+limit, groupLabel)
+	case sortBy == "cpu":
+		result += fmt.Sprintf("🚀 CPU 占用最高的 %d 个进程组（按%s）\n", limit, groupLabel)
+	default:
+		result += fmt.Sprintf("💾 内存占用最高的 %d 个进程组（按%s）\n", limit, groupLabel)
+	}
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result += fmt.Sprintf("%-25s %-8s %-12s %-14s %-10s\n", groupLabel, "实例数", "总CPU%", "总内存(MB)", "最高PID")
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+
+	for _, group := range processList.Groups {
+		// 截断过长的分组名
+		key := fitColumn(group.Key, 25)
+
+		result += fmt.Sprintf("%s %-8d %-12.2f %-14.2f %-10d\n",
+			key,
+			group.Count,
+			group.CPUPercent,
+			group.MemoryMB,
+			group.TopPID,
+		)
+	}
+
+	result += formatProcessTotals(processList, pt.permissions())
+
+	return result
+}
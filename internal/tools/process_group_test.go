@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+// groupFixture 合成的进程列表：4 个 chrome（alice）、2 个 postgres、1 个 nginx，
+// 以及一个取不到名称和用户的进程
+func groupFixture() []types.ProcessInfo {
+	return []types.ProcessInfo{
+		{PID: 101, Name: "chrome", Username: "alice", CPUPercent: 5, MemoryBytes: 200 << 20, NumFDs: 40},
+		{PID: 102, Name: "chrome", Username: "alice", CPUPercent: 30, MemoryBytes: 150 << 20, NumFDs: 20},
+		{PID: 103, Name: "chrome", Username: "alice", CPUPercent: 1, MemoryBytes: 300 << 20, NumFDs: 10},
+		{PID: 104, Name: "chrome", Username: "alice", CPUPercent: 4, MemoryBytes: 50 << 20, NumFDs: 10},
+		{PID: 20, Name: "postgres", Username: "postgres", CPUPercent: 12, MemoryBytes: 400 << 20, NumFDs: 300},
+		{PID: 21, Name: "postgres", Username: "postgres", CPUPercent: 12, MemoryBytes: 100 << 20, NumFDs: 5},
+		{PID: 30, Name: "nginx", Username: "alice", CPUPercent: 50, MemoryBytes: 10 << 20, NumFDs: 1000},
+		{PID: 40, CPUPercent: 0.5, MemoryBytes: 1 << 20},
+	}
+}
+
+// groupSummary 进程组中测试关心的字段
+type groupSummary struct {
+	key    string
+	count  int
+	cpu    float64
+	memory uint64
+	topPID int32
+	pids   []int32
+}
+
+func TestGroupProcesses(t *testing.T) {
+	chrome := groupSummary{"chrome", 4, 40, 700 << 20, 103, []int32{101, 102, 103, 104}}
+	postgres := groupSummary{"postgres", 2, 24, 500 << 20, 20, []int32{20, 21}}
+	nginx := groupSummary{"nginx", 1, 50, 10 << 20, 30, []int32{30}}
+	unknown := groupSummary{unknownGroupKey, 1, 0.5, 1 << 20, 40, []int32{40}}
+
+	cases := []struct {
+		name    string
+		groupBy string
+		sortBy  string
+		want    []groupSummary
+	}{
+		// 组内按同一指标选出最高的进程：内存排序时为 103，CPU 排序时为 102；CPU 相同时取较小的 PID
+		{"name by memory", groupByName, "memory", []groupSummary{chrome, postgres, nginx, unknown}},
+		{"name by cpu", groupByName, "cpu", []groupSummary{nginx, {"chrome", 4, 40, 700 << 20, 102, chrome.pids}, postgres, unknown}},
+		{"name by fds", groupByName, "fds", []groupSummary{nginx, postgres, {"chrome", 4, 40, 700 << 20, 101, chrome.pids}, unknown}},
+		// 实例数相同时按分组键排序
+		{"name by count", groupByName, "count", []groupSummary{chrome, postgres, unknown, nginx}},
+		{"user by memory", groupByUser, "memory", []groupSummary{
+			{"alice", 5, 90, 710 << 20, 103, []int32{30, 101, 102, 103, 104}},
+			postgres,
+			unknown,
+		}},
+		{"user by cpu", groupByUser, "cpu", []groupSummary{
+			{"alice", 5, 90, 710 << 20, 30, []int32{30, 101, 102, 103, 104}},
+			postgres,
+			unknown,
+		}},
+	}
+	for _, c := range cases {
+		// 输入顺序不影响结果
+		for _, input := range [][]types.ProcessInfo{groupFixture(), shuffled(groupFixture())} {
+			groups := groupProcesses(input, c.groupBy, c.sortBy)
+			if len(groups) != len(c.want) {
+				t.Fatalf("%s: %d groups %+v, want %d", c.name, len(groups), groups, len(c.want))
+			}
+			for i, want := range c.want {
+				got := groups[i]
+				if got.Key != want.key || got.Count != want.count || got.CPUPercent != want.cpu || got.MemoryBytes != want.memory || got.TopPID != want.topPID || !slices.Equal(got.PIDs, want.pids) {
+					t.Errorf("%s: group %d = %+v, want %+v", c.name, i, got, want)
+				}
+				if got.MemoryMB != float64(want.memory)/(1024*1024) {
+					t.Errorf("%s: %s MemoryMB = %.2f", c.name, got.Key, got.MemoryMB)
+				}
+			}
+		}
+	}
+
+	if groups := groupProcesses(nil, groupByName, "memory"); groups == nil || len(groups) != 0 {
+		t.Errorf("no processes = %+v, want an empty list", groups)
+	}
+}
+
+func TestProcessToolGroupBy(t *testing.T) {
+	var processes []*fakeProcess
+	for _, info := range groupFixture()[:7] {
+		processes = append(processes, &fakeProcess{pid: info.PID, name: info.Name, ppid: 1, cmdline: []string{info.Name}, username: info.Username, memory: &MemoryInfoStat{RSS: info.MemoryBytes}})
+	}
+	useFakeProcesses(t, newFakeProcessProvider(processes...))
+	tool := NewProcessTool(storage.NewMemoryCache(), CacheOptions{})
+	execute := func(args map[string]interface{}) string {
+		t.Helper()
+		args["cache"] = CacheModeAuto
+		text, err := tool.Execute(context.Background(), args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return text
+	}
+
+	// 先缓存按进程列出的结果；分组方式是缓存键的一部分，分组调用不会复用它
+	if text := execute(map[string]interface{}{"format": "json"}); !strings.Contains(text, `"processes"`) {
+		t.Fatalf("ungrouped output:\n%s", text)
+	}
+	var report processReport
+	if err := json.Unmarshal([]byte(execute(map[string]interface{}{"group_by": "name", "format": "json"})), &report); err != nil {
+		t.Fatal(err)
+	}
+	// JSON 中每组嵌套成员 PID，不再列出单个进程
+	if report.GroupBy != groupByName || len(report.Processes) != 0 || len(report.Groups) != 3 || !slices.Equal(report.Groups[0].PIDs, []int32{101, 102, 103, 104}) {
+		t.Fatalf("grouped report = %+v", report.ProcessList)
+	}
+	if err := json.Unmarshal([]byte(execute(map[string]interface{}{"group_by": "user", "format": "json"})), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.GroupBy != groupByUser || len(report.Groups) != 2 || report.Groups[0].Key != "alice" {
+		t.Fatalf("grouped by user = %+v", report.Groups)
+	}
+
+	// 分组后表格的列变为：名称/用户、实例数、总 CPU%、总内存、最高 PID
+	text := execute(map[string]interface{}{"group_by": "name", "limit": 2})
+	for _, want := range []string{
+		"💾 内存占用最高的 2 个进程组（按名称）\n",
+		"实例数",
+		"最高PID",
+		"\nchrome                    4        0.00         700.00         103       \n",
+		"\npostgres                  2        0.00         500.00         20        \n",
+		"📄 显示第 1–2 项，共 3 个匹配的进程组\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("grouped text missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "nginx") {
+		t.Errorf("limit 2 shows a third group:\n%s", text)
+	}
+	markdown := execute(map[string]interface{}{"group_by": "user", "format": "markdown"})
+	if !strings.Contains(markdown, "| 用户 | 实例数 | 总CPU% | 总内存 | 最高PID |") || !strings.Contains(markdown, "| alice | 5 |") {
+		t.Errorf("grouped markdown:\n%s", markdown)
+	}
+
+	// 进程组不支持按运行时长排序
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"group_by": "name", "sort_by": "age"}); err == nil {
+		t.Error("group_by with sort_by=age succeeded")
+	}
+}
@@ -0,0 +1,218 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+// sortedPageFixture 已排序的 25 个进程，PID 依次为 1–25
+func sortedPageFixture() types.ProcessList {
+	var list types.ProcessList
+	for pid := int32(1); pid <= 25; pid++ {
+		list.Processes = append(list.Processes, types.ProcessInfo{PID: pid})
+	}
+	list.Total, list.Matching = 30, 25
+	return list
+}
+
+// pagePIDs 一页中的 PID
+func pagePIDs(list types.ProcessList) []int32 {
+	pids := []int32{}
+	for _, proc := range list.Processes {
+		pids = append(pids, proc.PID)
+	}
+	return pids
+}
+
+// pidRange [first, last] 的 PID
+func pidRange(first, last int32) []int32 {
+	pids := []int32{}
+	for pid := first; pid <= last; pid++ {
+		pids = append(pids, pid)
+	}
+	return pids
+}
+
+func TestPageProcessList(t *testing.T) {
+	sorted := sortedPageFixture()
+	cases := []struct {
+		name          string
+		offset, limit int
+		want          []int32
+		next          int
+	}{
+		{"first page", 0, 10, pidRange(1, 10), 10},
+		{"middle page", 10, 10, pidRange(11, 20), 20},
+		// 最后一页不足 limit 项，没有下一页
+		{"last page", 20, 10, pidRange(21, 25), 0},
+		{"exact end", 15, 10, pidRange(16, 25), 0},
+		// offset 正好在末尾或超出末尾时为空页，offset 保持请求的值
+		{"at the end", 25, 10, []int32{}, 0},
+		{"past the end", 100, 10, []int32{}, 0},
+		{"whole list", 0, 100, pidRange(1, 25), 0},
+	}
+	for _, c := range cases {
+		page := pageProcessList(sorted, c.offset, c.limit)
+		if got := pagePIDs(page); !slices.Equal(got, c.want) || page.NextOffset != c.next || page.Offset != c.offset {
+			t.Errorf("%s: PIDs %v, offset %d, next %d; want %v, %d, %d", c.name, got, page.Offset, page.NextOffset, c.want, c.offset, c.next)
+		}
+		if page.Matching != 25 || page.Total != 30 {
+			t.Errorf("%s: matching %d, total %d", c.name, page.Matching, page.Total)
+		}
+	}
+	// 翻页不修改缓存中的完整列表
+	if len(sorted.Processes) != 25 || sorted.Offset != 0 || sorted.NextOffset != 0 {
+		t.Errorf("sorted list changed: %d processes, offset %d", len(sorted.Processes), sorted.Offset)
+	}
+
+	// 分组时对进程组翻页
+	groups := types.ProcessList{GroupBy: groupByName, Matching: 3, Groups: []types.ProcessGroup{{Key: "a"}, {Key: "b"}, {Key: "c"}}}
+	page := pageProcessList(groups, 1, 1)
+	if len(page.Groups) != 1 || page.Groups[0].Key != "b" || page.NextOffset != 2 || page.Processes != nil {
+		t.Errorf("group page = %+v", page)
+	}
+	if page := pageProcessList(groups, 5, 1); len(page.Groups) != 0 || page.NextOffset != 0 {
+		t.Errorf("group page past the end = %+v", page)
+	}
+}
+
+func TestProcessListTitle(t *testing.T) {
+	cases := []struct {
+		query  processQuery
+		offset int
+		want   string
+	}{
+		{processQuery{SortBy: "memory", Limit: 10}, 0, "💾 内存占用最高的 10 个进程\n"},
+		{processQuery{SortBy: "memory", Limit: 10}, 20, "💾 内存占用第 21–30 名的进程\n"},
+		{processQuery{SortBy: "cpu", Limit: 5}, 0, "🚀 CPU 占用最高的 5 个进程\n"},
+		{processQuery{SortBy: "cpu", Limit: 5}, 5, "🚀 CPU 占用第 6–10 名的进程\n"},
+		{processQuery{SortBy: sortByAge, Limit: 3}, 0, "🆕 最近启动的 3 个进程\n"},
+		{processQuery{SortBy: sortByAge, Limit: 3}, 3, "🆕 最近启动的第 4–6 个进程\n"},
+		{processQuery{SortBy: sortByAge, Descending: true, Limit: 3}, 0, "⏳ 运行时间最长的 3 个进程\n"},
+		{processQuery{SortBy: sortByAge, Descending: true, Limit: 3}, 9, "⏳ 运行时间最长的第 10–12 个进程\n"},
+	}
+	for _, c := range cases {
+		if got := processListTitle(c.query, c.offset); got != c.want {
+			t.Errorf("processListTitle(%+v, %d) = %q, want %q", c.query, c.offset, got, c.want)
+		}
+	}
+}
+
+// pagingProvider 25 个内存各不相同的进程：PID 1–25 的内存依次减少，奇数 PID 属于 alice，偶数属于 bob
+func pagingProvider() *fakeProcessProvider {
+	var processes []*fakeProcess
+	for pid := int32(1); pid <= 25; pid++ {
+		user := "alice"
+		if pid%2 == 0 {
+			user = "bob"
+		}
+		processes = append(processes, &fakeProcess{
+			pid:        pid,
+			name:       fmt.Sprintf("worker-%02d", pid),
+			ppid:       1,
+			cmdline:    []string{"/usr/bin/worker"},
+			username:   user,
+			status:     []string{"S"},
+			createTime: fixedTime.Add(-time.Hour).UnixMilli(),
+			memory:     &MemoryInfoStat{RSS: uint64(100-pid) << 20},
+		})
+	}
+	return newFakeProcessProvider(processes...)
+}
+
+// executePage 以 JSON 格式调用 top_processes，返回解析后的一页
+func executePage(t *testing.T, tool *ProcessTool, args map[string]interface{}) types.ProcessList {
+	t.Helper()
+	args["format"] = "json"
+	text, err := tool.Execute(context.Background(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report processReport
+	if err := json.Unmarshal([]byte(text), &report); err != nil {
+		t.Fatal(err)
+	}
+	return report.ProcessList
+}
+
+func TestTopProcessesPaging(t *testing.T) {
+	provider := pagingProvider()
+	useFakeProcesses(t, provider)
+	tool := NewProcessTool(storage.NewMemoryCache(), CacheOptions{})
+
+	// 逐页翻到末尾，拼起来正好是完整的排序结果
+	var all []int32
+	offset := 0
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("paging did not terminate")
+		}
+		page := executePage(t, tool, map[string]interface{}{"sort_by": "memory", "limit": 7, "offset": offset, "cache": CacheModeAuto})
+		all = append(all, pagePIDs(page)...)
+		if page.Matching != 25 || page.Offset != offset {
+			t.Fatalf("page at %d: matching %d, offset %d", offset, page.Matching, page.Offset)
+		}
+		if page.NextOffset == 0 {
+			break
+		}
+		offset = page.NextOffset
+		// 翻页复用缓存的排序结果：进程退出不影响后续页
+		delete(provider.processes, int32(offset))
+	}
+	if !slices.Equal(all, pidRange(1, 25)) {
+		t.Errorf("pages = %v, want PIDs 1–25 in memory order", all)
+	}
+
+	// 按用户过滤后对过滤结果翻页；重新采集时 PID 7、14、21 已退出，bob 剩下 11 个进程
+	page := executePage(t, tool, map[string]interface{}{"user": "bob", "limit": 5, "offset": 5, "cache": CacheModeFresh})
+	if got := pagePIDs(page); page.Matching != 11 || page.ExcludedByUser != 11 || !slices.Equal(got, []int32{12, 16, 18, 20, 22}) || page.NextOffset != 10 {
+		t.Errorf("bob page 2 = %v, matching %d, excluded %d, next %d", got, page.Matching, page.ExcludedByUser, page.NextOffset)
+	}
+}
+
+func TestTopProcessesPagingOutput(t *testing.T) {
+	useFakeProcesses(t, pagingProvider())
+	tool := NewProcessTool(storage.NewMemoryCache(), CacheOptions{})
+
+	text, err := tool.Execute(context.Background(), map[string]interface{}{"sort_by": "memory", "limit": 10, "offset": 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"💾 内存占用第 11–20 名的进程\n",
+		"\n11       worker-11 ",
+		"\n📄 显示第 11–20 项，共 25 个匹配的进程\n➡️ 下一页: offset=20（配合 cache=auto 复用本次排序结果）\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("page 2 missing %q:\n%s", want, text)
+		}
+	}
+
+	// 最后一页没有下一页提示；超出末尾时说明没有更多
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"sort_by": "memory", "limit": 10, "offset": 20})
+	if err != nil || !strings.Contains(text, "\n📄 显示第 21–25 项，共 25 个匹配的进程\n") || strings.Contains(text, "下一页") {
+		t.Errorf("last page = %v\n%s", err, text)
+	}
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"limit": 10, "offset": 40})
+	if err != nil || !strings.Contains(text, "\n📄 offset 40 之后没有更多进程（共 25 个匹配的进程）\n") {
+		t.Errorf("page past the end = %v\n%s", err, text)
+	}
+
+	// 分组时对进程组翻页：alice 13 个进程占用的内存多于 bob 的 12 个
+	page := executePage(t, tool, map[string]interface{}{"group_by": "user", "limit": 1, "offset": 1})
+	if page.Matching != 2 || len(page.Groups) != 1 || page.Groups[0].Key != "bob" || page.Groups[0].Count != 12 || page.NextOffset != 0 {
+		t.Errorf("user groups page 2 = %+v", page)
+	}
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"group_by": "user", "limit": 1})
+	if err != nil || !strings.Contains(text, "共 2 个匹配的进程组") || !strings.Contains(text, "下一页: offset=1") {
+		t.Errorf("user groups page 1 = %v\n%s", err, text)
+	}
+}
@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"context"
+	"sync"
+)
+
+// forEachProcess 以 workers 个 goroutine 并发对每个进程调用 fn（fn 需自行同步对共享数据的访问）。
+// ctx 取消后不再分发新的进程，等待进行中的调用结束后返回
+func forEachProcess(ctx context.Context, processes []Process, workers int, fn func(p Process)) {
+	queue := make(chan Process)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range queue {
+				fn(p)
+			}
+		}()
+	}
+
+feed:
+	for _, p := range processes {
+		select {
+		case queue <- p:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+}
@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// signalGracePeriod 发送信号后等待进程退出的时间
+const signalGracePeriod = 500 * time.Millisecond
+
+// ProcessSignalTool 向进程发送信号的操作工具（需通过 --enable-actions 启用）
+type ProcessSignalTool struct{}
+
+// NewProcessSignalTool 创建新的进程信号工具
+func NewProcessSignalTool() *ProcessSignalTool {
+	return &ProcessSignalTool{}
+}
+
+// GetName 获取工具名称
+func (ps *ProcessSignalTool) GetName() string {
+	return "process_signal"
+}
+
+// GetDescription 获取工具描述
+func (ps *ProcessSignalTool) GetDescription() string {
+	return "向指定 PID 的进程发送信号（需提供当前进程名以防 PID 复用）"
+}
+
+// GetAnnotations 获取工具注解
+func (ps *ProcessSignalTool) GetAnnotations() types.ToolAnnotations {
+	return types.ToolAnnotations{
+		Title:           "发送进程信号",
+		DestructiveHint: true,
+	}
+}
+
+// processSignalArgs process_signal 的参数，signal 的枚举值随平台变化，由 GetInputSchema 设置
+type processSignalArgs struct {
+	strictArgs
+	PID         int    `arg:"pid,required,min=1,max=2147483647" desc:"目标进程 PID"`
+	Signal      string `arg:"signal,default=TERM" desc:"要发送的信号"`
+	ConfirmName string `arg:"confirm_name,required" desc:"目标进程的当前名称，必须与实际进程名一致"`
+}
+
+// GetInputSchema 获取输入模式
+func (ps *ProcessSignalTool) GetInputSchema() types.InputSchema {
+	return withEnum(argsSchema(processSignalArgs{}), "signal", supportedSignalNames())
+}
+
+// Examples 获取调用示例
+func (ps *ProcessSignalTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "向 PID 1234 的 nginx 进程发送 TERM 信号",
+			Arguments:   map[string]interface{}{"pid": 1234, "confirm_name": "nginx"},
+		},
+		{
+			Description: "让 PID 1234 的 nginx 进程重新加载配置",
+			Arguments:   map[string]interface{}{"pid": 1234, "signal": "HUP", "confirm_name": "nginx"},
+		},
+	}
+}
+
+// Execute 发送信号
+func (ps *ProcessSignalTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a processSignalArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+	pid, confirmName := a.PID, a.ConfirmName
+	signalName := a.Signal
+	sig, ok := supportedSignals[signalName]
+	if !ok {
+		return "", argumentError("signal", "不支持的信号: %s", signalName)
+	}
+
+	slog.WarnContext(ctx, "收到进程信号请求", "pid", pid, "signal", signalName, "confirm_name", confirmName)
+
+	if pid == 1 {
+		return "", newError(ErrPermission, "拒绝向 PID 1 发送信号")
+	}
+	if pid == os.Getpid() {
+		return "", newError(ErrPermission, "拒绝向服务器自身发送信号")
+	}
+
+	// 校验进程存在且名称一致
+	p, err := providers.Process.NewProcess(ctx, int32(pid))
+	if err != nil {
+		return "", &Error{Code: ErrNotFound, Message: fmt.Sprintf("找不到 PID 为 %d 的进程", pid), Err: err}
+	}
+	name, err := p.NameWithContext(ctx)
+	if err != nil {
+		return "", wrapError("获取进程名失败", err)
+	}
+	if name != confirmName {
+		return "", argumentError("confirm_name", "进程名不匹配: PID %d 当前为 %q，而不是 %q", pid, name, confirmName)
+	}
+
+	if err := p.SendSignalWithContext(ctx, sig); err != nil {
+		slog.WarnContext(ctx, "发送进程信号失败", "pid", pid, "name", name, "signal", signalName, "error", err)
+		return "", wrapError("发送信号失败", err)
+	}
+	slog.WarnContext(ctx, "已发送进程信号", "pid", pid, "name", name, "signal", signalName)
+
+	// 等待一小段时间后检查进程是否仍在运行。信号已经发出，请求在等待期间被取消时仍报告发送成功
+	state := "信号已发送，等待期间请求被取消，进程是否退出未知"
+	select {
+	case <-ctx.Done():
+	case <-time.After(signalGracePeriod):
+		state = signalOutcome(ctx, p)
+	}
+
+	var result string
+	result += "📨 进程信号\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result += fmt.Sprintf("进程: %s (PID %d)\n", name, pid)
+	result += fmt.Sprintf("信号: SIG%s\n", signalName)
+	result += fmt.Sprintf("状态: %s\n", state)
+
+	return result, nil
+}
+
+// signalOutcome 描述等待 signalGracePeriod 后进程的状态。已退出但尚未被父进程回收的僵尸进程
+// 仍然存在 PID，按已退出报告
+func signalOutcome(ctx context.Context, p Process) string {
+	exists, err := providers.Process.PidExists(ctx, p.PID())
+	if err != nil {
+		return fmt.Sprintf("%v 后无法确认进程是否退出: %v", signalGracePeriod, err)
+	}
+	if !exists {
+		return fmt.Sprintf("%v 内进程已退出", signalGracePeriod)
+	}
+
+	statuses, err := p.StatusWithContext(ctx)
+	if err != nil {
+		// 检查存在后进程才退出时读取状态会失败
+		if exists, existsErr := providers.Process.PidExists(ctx, p.PID()); existsErr == nil && !exists {
+			return fmt.Sprintf("%v 内进程已退出", signalGracePeriod)
+		}
+		return fmt.Sprintf("%v 后进程仍存在（无法读取状态: %v）", signalGracePeriod, err)
+	}
+	for _, status := range statuses {
+		if status == processZombie {
+			return fmt.Sprintf("%v 内进程已退出（僵尸进程，等待父进程回收）", signalGracePeriod)
+		}
+	}
+	return fmt.Sprintf("%v 后进程仍在运行", signalGracePeriod)
+}
+
+// supportedSignalNames 支持的信号名称（已排序）
+func supportedSignalNames() []string {
+	names := make([]string, 0, len(supportedSignals))
+	for name := range supportedSignals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestProcessSignalReportsExit(t *testing.T) {
+	provider := newFakeProcessProvider()
+	target := &fakeProcess{pid: 4242, name: "nginx"}
+	target.onSignal = func(sig syscall.Signal) {
+		if sig == syscall.SIGTERM {
+			delete(provider.processes, target.pid)
+		}
+	}
+	provider.processes[target.pid] = target
+	useFakeProcesses(t, provider)
+
+	result, err := NewProcessSignalTool().Execute(context.Background(), map[string]interface{}{"pid": 4242, "confirm_name": "nginx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, "进程已退出") || strings.Contains(result, "僵尸") {
+		t.Fatalf("result does not report the exit:\n%s", result)
+	}
+}
+
+func TestProcessSignalZombieCountsAsExited(t *testing.T) {
+	target := &fakeProcess{pid: 4242, name: "worker", status: []string{"running"}}
+	target.onSignal = func(syscall.Signal) { target.status = []string{processZombie} }
+	useFakeProcesses(t, newFakeProcessProvider(target))
+
+	result, err := NewProcessSignalTool().Execute(context.Background(), map[string]interface{}{"pid": 4242, "signal": "KILL", "confirm_name": "worker"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, "进程已退出（僵尸进程") {
+		t.Fatalf("a zombie should be reported as exited:\n%s", result)
+	}
+}
+
+func TestProcessSignalStillRunning(t *testing.T) {
+	useFakeProcesses(t, newFakeProcessProvider(&fakeProcess{pid: 4242, name: "nginx", status: []string{"sleep"}}))
+
+	result, err := NewProcessSignalTool().Execute(context.Background(), map[string]interface{}{"pid": 4242, "signal": "HUP", "confirm_name": "nginx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, "进程仍在运行") {
+		t.Fatalf("result should report the process as running:\n%s", result)
+	}
+}
+
+func TestProcessSignalCancelledDuringGracePeriod(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signalled := false
+	target := &fakeProcess{pid: 4242, name: "nginx"}
+	target.onSignal = func(syscall.Signal) {
+		signalled = true
+		cancel()
+	}
+	useFakeProcesses(t, newFakeProcessProvider(target))
+
+	result, err := NewProcessSignalTool().Execute(ctx, map[string]interface{}{"pid": 4242, "confirm_name": "nginx"})
+	if err != nil {
+		t.Fatalf("cancellation after the signal was sent should not be an error: %v", err)
+	}
+	if !signalled || !strings.Contains(result, "信号已发送") || !strings.Contains(result, "未知") {
+		t.Fatalf("result should say the signal was sent and the outcome is unknown:\n%s", result)
+	}
+}
+
+func TestProcessSignalRefusals(t *testing.T) {
+	useFakeProcesses(t, newFakeProcessProvider(&fakeProcess{pid: 4242, name: "nginx"}))
+	tests := []struct {
+		args map[string]interface{}
+		code ErrorCode
+	}{
+		{map[string]interface{}{"pid": 1, "confirm_name": "init"}, ErrPermission},
+		{map[string]interface{}{"pid": os.Getpid(), "confirm_name": "self"}, ErrPermission},
+		{map[string]interface{}{"pid": 4242, "confirm_name": "apache"}, ErrBadArgument},
+		{map[string]interface{}{"pid": 4343, "confirm_name": "nginx"}, ErrNotFound},
+	}
+	for _, test := range tests {
+		_, err := NewProcessSignalTool().Execute(context.Background(), test.args)
+		var toolErr *Error
+		if !errors.As(err, &toolErr) || toolErr.Code != test.code {
+			t.Errorf("args %v: error = %v, want code %s", test.args, err, test.code)
+		}
+	}
+}
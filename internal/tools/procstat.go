@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// procStatPath Linux 内核/系统统计文件
+const procStatPath = "/proc/stat"
+
+// procStat /proc/stat 中与调度相关的计数
+type procStat struct {
+	ContextSwitches uint64
+	Interrupts      uint64
+	ProcsRunning    uint64
+	ProcsBlocked    uint64
+	// Forks 启动以来创建的进程和线程总数（processes 行）
+	Forks uint64
+}
+
+// readProcStat 读取 /proc/stat
+func readProcStat(path string) (procStat, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return procStat{}, fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	defer file.Close()
+
+	return parseProcStat(file)
+}
+
+// parseProcStat 解析 /proc/stat 的 ctxt、intr（首个字段为总数）、procs_running、procs_blocked 和 processes 行
+func parseProcStat(r io.Reader) (procStat, error) {
+	var stat procStat
+	found := false
+
+	scanner := bufio.NewScanner(r)
+	// intr 行在中断较多的机器上可能很长
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		switch fields[0] {
+		case "ctxt":
+			stat.ContextSwitches = value
+			found = true
+		case "intr":
+			stat.Interrupts = value
+			found = true
+		case "procs_running":
+			stat.ProcsRunning = value
+		case "procs_blocked":
+			stat.ProcsBlocked = value
+		case "processes":
+			stat.Forks = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return stat, fmt.Errorf("解析 /proc/stat 失败: %w", err)
+	}
+	if !found {
+		return stat, fmt.Errorf("/proc/stat 中没有 ctxt/intr 数据")
+	}
+
+	return stat, nil
+}
+
+// schedulerStats 根据采样窗口前后两次读取计算每秒速率，运行队列取第二次读取的瞬时值
+func schedulerStats(before, after procStat, elapsed time.Duration) *types.CPUSchedulerStats {
+	seconds := elapsed.Seconds()
+	if seconds <= 0 || after.ContextSwitches < before.ContextSwitches || after.Interrupts < before.Interrupts {
+		return nil
+	}
+
+	return &types.CPUSchedulerStats{
+		ContextSwitchesPerSec: float64(after.ContextSwitches-before.ContextSwitches) / seconds,
+		InterruptsPerSec:      float64(after.Interrupts-before.Interrupts) / seconds,
+		ProcsRunning:          after.ProcsRunning,
+		ProcsBlocked:          after.ProcsBlocked,
+	}
+}
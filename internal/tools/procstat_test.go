@@ -0,0 +1,147 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+// procStatFixture 双核机器上的 /proc/stat，intr 行的首个字段为中断总数
+const procStatFixture = `cpu  10132153 290696 3084719 46828483 16683 0 25195 0 0 0
+cpu0 1393280 32966 572056 13343292 6130 0 17875 0 0 0
+cpu1 1335133 27104 530936 13399588 3489 0 2433 0 0 0
+intr 199292 9 0 0 0 0 0 0 0 1 0 0 0 0 0 0 0 34 0 0 0
+ctxt 3185491
+btime 1714615200
+processes 41623
+procs_running 3
+procs_blocked 1
+softirq 12874423 6 3864521 30 1263874 86343 0 12 4006543 0 3653094
+`
+
+func TestParseProcStat(t *testing.T) {
+	stat, err := parseProcStat(strings.NewReader(procStatFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := procStat{ContextSwitches: 3185491, Interrupts: 199292, ProcsRunning: 3, ProcsBlocked: 1, Forks: 41623}
+	if stat != want {
+		t.Fatalf("parseProcStat() = %+v, want %+v", stat, want)
+	}
+
+	// 中断很多的机器上 intr 行超过 bufio 默认的 64KB 行长
+	longIntr := "intr 5000" + strings.Repeat(" 0", 40000) + "\nctxt 12\n"
+	if stat, err := parseProcStat(strings.NewReader(longIntr)); err != nil || stat.Interrupts != 5000 || stat.ContextSwitches != 12 {
+		t.Errorf("long intr line = %+v, %v", stat, err)
+	}
+
+	// 老内核没有 procs_running/procs_blocked 时为 0；无法解析的值跳过
+	if stat, err := parseProcStat(strings.NewReader("ctxt abc\nintr 10\n")); err != nil || stat != (procStat{Interrupts: 10}) {
+		t.Errorf("partial /proc/stat = %+v, %v", stat, err)
+	}
+
+	for _, content := range []string{"", "cpu  1 2 3 4\nprocs_running 2\n"} {
+		if _, err := parseProcStat(strings.NewReader(content)); err == nil {
+			t.Errorf("parseProcStat(%q) succeeded, want an error", content)
+		}
+	}
+}
+
+func TestSchedulerStats(t *testing.T) {
+	before := procStat{ContextSwitches: 1000, Interrupts: 500, ProcsRunning: 9, ProcsBlocked: 9}
+	after := procStat{ContextSwitches: 5000, Interrupts: 1500, ProcsRunning: 2, ProcsBlocked: 1}
+
+	// 速率按采样窗口计算，运行队列取第二次读取的值
+	got := schedulerStats(before, after, 2*time.Second)
+	want := types.CPUSchedulerStats{ContextSwitchesPerSec: 2000, InterruptsPerSec: 500, ProcsRunning: 2, ProcsBlocked: 1}
+	if got == nil || *got != want {
+		t.Fatalf("schedulerStats() = %+v, want %+v", got, want)
+	}
+
+	// 计数回绕（或读到了不同的来源）和零长度窗口不给出速率
+	for _, c := range []struct {
+		name          string
+		before, after procStat
+		elapsed       time.Duration
+	}{
+		{"ctxt went backwards", after, procStat{ContextSwitches: 10, Interrupts: 2000}, time.Second},
+		{"intr went backwards", before, procStat{ContextSwitches: 2000, Interrupts: 10}, time.Second},
+		{"zero window", before, after, 0},
+	} {
+		if got := schedulerStats(c.before, c.after, c.elapsed); got != nil {
+			t.Errorf("%s: schedulerStats() = %+v, want nil", c.name, got)
+		}
+	}
+}
+
+// statRewritingCPUProvider 采样使用率时改写 /proc/stat 夹具，模拟采样窗口内的调度活动
+type statRewritingCPUProvider struct {
+	fakeCPUProvider
+	statPath string
+	after    string
+	samples  []time.Duration
+}
+
+func (sp *statRewritingCPUProvider) Percent(ctx context.Context, interval time.Duration, perCPU bool) ([]float64, error) {
+	sp.samples = append(sp.samples, interval)
+	if err := os.WriteFile(sp.statPath, []byte(sp.after), 0o644); err != nil {
+		return nil, err
+	}
+	return sp.fakeCPUProvider.Percent(ctx, interval, perCPU)
+}
+
+func TestCPUToolSchedulerStats(t *testing.T) {
+	statPath := writeTree(t, map[string]string{"stat": "intr 1000\nctxt 2000\nprocs_running 1\nprocs_blocked 0\n"}) + "/stat"
+	provider := &statRewritingCPUProvider{
+		fakeCPUProvider: fakeCPUProvider{infos: []CPUInfoStat{{ModelName: "Fake CPU", Cores: 2}}, physicalCores: 2, perCore: []float64{10, 30}, total: 20},
+		statPath:        statPath,
+		after:           "intr 6000\nctxt 9000\nprocs_running 4\nprocs_blocked 2\n",
+	}
+	current := providers
+	current.CPU = provider
+	t.Cleanup(SetProviders(current))
+
+	tool := NewCPUTool(storage.NewMemoryCache(), CacheOptions{}, NewOutputStyle(StylePlain, 0))
+	tool.platform = platformLinux
+	tool.statPath = statPath
+
+	text, err := tool.Execute(context.Background(), map[string]interface{}{"duration": "5s", "detailed": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 调度统计复用使用率的采样窗口，不额外采样
+	if len(provider.samples) != 2 || provider.samples[0] != 5*time.Second || provider.samples[1] != 5*time.Second {
+		t.Errorf("Percent sampled %v, want only the two usage samples of 5s", provider.samples)
+	}
+	for _, want := range []string{"\n🔀 调度统计\n", "上下文切换: ", "中断: ", "运行队列: 4 个可运行, 2 个阻塞 (I/O 等待)\n"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "上下文切换: 0 次/秒") {
+		t.Errorf("context switch rate ignored the counters read after sampling:\n%s", text)
+	}
+
+	// 不传 detailed、其他平台或 /proc/stat 不可读时跳过该部分
+	for _, c := range []struct {
+		name     string
+		platform string
+		statPath string
+		args     map[string]interface{}
+	}{
+		{"not detailed", platformLinux, statPath, map[string]interface{}{"use_cache": false}},
+		{"windows", platformWindows, statPath, map[string]interface{}{"detailed": true, "use_cache": false}},
+		{"missing /proc/stat", platformLinux, statPath + ".missing", map[string]interface{}{"detailed": true, "use_cache": false}},
+	} {
+		tool.platform, tool.statPath = c.platform, c.statPath
+		text, err := tool.Execute(context.Background(), c.args)
+		if err != nil || strings.Contains(text, "调度统计") {
+			t.Errorf("%s: output = %v:\n%s", c.name, err, text)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package tools
+
+import "context"
+
+// ProgressFunc 接收工具执行进度，total 为 0 表示总量未知
+type ProgressFunc func(progress, total float64, message string)
+
+type progressKey struct{}
+
+// WithProgress 返回携带进度回调的 ctx，客户端请求进度通知时由调用方设置
+func WithProgress(ctx context.Context, report ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, report)
+}
+
+// reportProgress 报告执行进度，ctx 中没有进度回调时不做任何事
+func reportProgress(ctx context.Context, progress, total float64, message string) {
+	if report, ok := ctx.Value(progressKey{}).(ProgressFunc); ok {
+		report(progress, total, message)
+	}
+}
@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"fmt"
+	"time"
+)
+
+// 用量投影参数
+const (
+	// minProjectionSamples 窗口内至少需要的样本数，样本太少时首尾两点的差值不可信
+	minProjectionSamples = 3
+	// maxProjectionDays 写满天数的上限，超过时只说明"超过 N 天"
+	maxProjectionDays = 3650
+)
+
+// usageProjection 按窗口内最早和最新的样本线性推算的用量变化
+type usageProjection struct {
+	Samples       int
+	Oldest        time.Time
+	Newest        time.Time
+	Current       float64
+	ChangePercent float64
+	SlopePerDay   float64
+	// DaysToFull 预计写满的天数，Meaningful 为 false 时为 nil；Clamped 表示已截断为 maxProjectionDays
+	DaysToFull *float64
+	Clamped    bool
+	// Meaningful 为 false 时 Reason 说明原因（样本不足、用量下降或没有变化）
+	Meaningful bool
+	Reason     string
+}
+
+// projectUsage 用窗口内最早和最新的使用率样本（百分比，按时间排序）推算变化和写满时间。
+// 样本少于 minSamples、时间跨度为零或用量没有增长时，投影标记为没有意义
+func projectUsage(values []timedValue, minSamples int) usageProjection {
+	projection := usageProjection{Samples: len(values)}
+	if len(values) == 0 {
+		projection.Reason = "没有历史样本"
+		return projection
+	}
+
+	oldest, newest := values[0], values[len(values)-1]
+	projection.Oldest = oldest.Timestamp
+	projection.Newest = newest.Timestamp
+	projection.Current = newest.Value
+	projection.ChangePercent = newest.Value - oldest.Value
+
+	span := newest.Timestamp.Sub(oldest.Timestamp)
+	if len(values) < minSamples || span <= 0 {
+		projection.Reason = fmt.Sprintf("仅 %d 个样本，至少需要 %d 个", len(values), minSamples)
+		return projection
+	}
+	projection.SlopePerDay = projection.ChangePercent / span.Hours() * 24
+
+	switch {
+	case projection.ChangePercent < 0:
+		projection.Reason = "用量下降"
+		return projection
+	case projection.ChangePercent == 0:
+		projection.Reason = "用量没有变化"
+		return projection
+	}
+
+	days, ok := daysUntilFull(newest.Value, projection.SlopePerDay)
+	if !ok {
+		projection.Reason = "已写满"
+		return projection
+	}
+	if days > maxProjectionDays {
+		days, projection.Clamped = maxProjectionDays, true
+	}
+	projection.DaysToFull = &days
+	projection.Meaningful = true
+	return projection
+}
+
+// daysUntilFull 按每天的使用率增长推算写满所需天数，使用率不增长时返回 false
+func daysUntilFull(usedPercent, slopePerDay float64) (float64, bool) {
+	if slopePerDay <= 0 || usedPercent >= 100 {
+		return 0, false
+	}
+	return (100 - usedPercent) / slopePerDay, true
+}
+
+// formatProjectionWindow 以天或小时描述投影覆盖的时间跨度，如 "7天"、"5小时"
+func formatProjectionWindow(span time.Duration) string {
+	if span >= 24*time.Hour {
+		return fmt.Sprintf("%.0f天", span.Hours()/24)
+	}
+	return fmt.Sprintf("%.0f小时", max(span.Hours(), 1))
+}
@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+// usageCurve 从 start 开始每隔 step 一个样本的使用率序列
+func usageCurve(start time.Time, step time.Duration, values ...float64) []timedValue {
+	curve := make([]timedValue, len(values))
+	for i, value := range values {
+		curve[i] = timedValue{Timestamp: start.Add(time.Duration(i) * step), Value: value}
+	}
+	return curve
+}
+
+func TestProjectUsage(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	cases := []struct {
+		name       string
+		values     []timedValue
+		meaningful bool
+		change     float64
+		days       float64
+		clamped    bool
+		reason     string
+	}{
+		// 每天增长 2%，从 24% 到写满还需 38 天
+		{"steady growth", usageCurve(start, day, 10, 12, 14, 16, 18, 20, 22, 24), true, 14, 38, false, ""},
+		// 只看首尾两点，中间的波动不影响结果
+		{"noisy growth", usageCurve(start, day, 10, 30, 5, 40, 12, 35, 8, 24), true, 14, 38, false, ""},
+		{"shrinking", usageCurve(start, day, 50, 48, 45, 40), false, -10, 0, false, "用量下降"},
+		{"flat", usageCurve(start, day, 30, 30, 30, 30), false, 0, 0, false, "用量没有变化"},
+		{"too few samples", usageCurve(start, day, 10, 20), false, 10, 0, false, "仅 2 个样本，至少需要 3 个"},
+		{"zero span", usageCurve(start, 0, 10, 20, 30), false, 20, 0, false, "仅 3 个样本，至少需要 3 个"},
+		{"already full", usageCurve(start, day, 90, 95, 100), false, 10, 0, false, "已写满"},
+		// 增长极慢时截断为 maxProjectionDays
+		{"slow growth", usageCurve(start, day, 10, 10.005, 10.01), true, 0.01, maxProjectionDays, true, ""},
+		{"no samples", nil, false, 0, 0, false, "没有历史样本"},
+	}
+	for _, c := range cases {
+		got := projectUsage(c.values, minProjectionSamples)
+		if got.Meaningful != c.meaningful || got.Reason != c.reason || got.Clamped != c.clamped || got.Samples != len(c.values) {
+			t.Errorf("%s: projection = %+v", c.name, got)
+			continue
+		}
+		if math.Abs(got.ChangePercent-c.change) > 1e-9 {
+			t.Errorf("%s: ChangePercent = %v, want %v", c.name, got.ChangePercent, c.change)
+		}
+		switch {
+		case !c.meaningful && got.DaysToFull != nil:
+			t.Errorf("%s: DaysToFull = %v, want nil", c.name, *got.DaysToFull)
+		case c.meaningful && got.DaysToFull == nil:
+			t.Errorf("%s: DaysToFull = nil, want %v", c.name, c.days)
+		case c.meaningful && math.Abs(*got.DaysToFull-c.days) > 1e-6:
+			t.Errorf("%s: DaysToFull = %v, want %v", c.name, *got.DaysToFull, c.days)
+		}
+	}
+}
+
+func TestFormatUsageTrend(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	total := uint64(100 * gb)
+	cases := []struct {
+		name   string
+		values []timedValue
+		want   string
+	}{
+		{"growth", usageCurve(start, day, 10, 12, 14, 16, 18, 20, 22, 24), "7天变化: +14.00 GB, 预计 ~38 天后写满"},
+		{"shrinking", usageCurve(start, day, 50, 48, 45, 40), "3天变化: -10.00 GB（用量下降，写满预测无意义）"},
+		{"flat", usageCurve(start, day, 30, 30, 30), "2天变化: +0 B（用量没有变化，写满预测无意义）"},
+		{"insufficient", usageCurve(start, 5*time.Hour, 10, 11), "5小时变化: +1.00 GB（仅 2 个样本，至少需要 3 个，写满预测无意义）"},
+		{"clamped", usageCurve(start, day, 10, 10.005, 10.01), "2天变化: +10.24 MB, 预计超过 3650 天后写满"},
+	}
+	for _, c := range cases {
+		if got := formatUsageTrend(projectUsage(c.values, minProjectionSamples), total); got != c.want {
+			t.Errorf("%s: formatUsageTrend() = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestDiskInfoUsageTrend(t *testing.T) {
+	useFakeDisk(t, &fakeDiskProvider{
+		partitions: []PartitionStat{{Device: "/dev/sda1", Mountpoint: "/", Fstype: "ext4"}, {Device: "/dev/sdb1", Mountpoint: "/data", Fstype: "xfs"}},
+		usage: map[string]UsageStat{
+			"/":     {Path: "/", Fstype: "ext4", Total: 100 * gb, Used: 24 * gb, Free: 76 * gb, UsedPercent: 24},
+			"/data": {Path: "/data", Fstype: "xfs", Total: 100 * gb, Used: 40 * gb, Free: 60 * gb, UsedPercent: 40},
+		},
+	})
+
+	// 根分区每天增长 2%，/data 在下降；窗口外的旧样本不参与计算
+	dataStorage := storage.NewMemoryStorage()
+	now := time.Now()
+	samples := []types.MetricSample{{Timestamp: now.Add(-30 * 24 * time.Hour), DiskPercent: map[string]float64{"/": 1, "/data": 90}}}
+	for i := 0; i < 7; i++ {
+		samples = append(samples, types.MetricSample{
+			Timestamp:   now.Add(time.Duration(i-6)*24*time.Hour - time.Minute),
+			DiskPercent: map[string]float64{"/": 12 + 2*float64(i), "/data": 52 - 2*float64(i)},
+		})
+	}
+	saveHistory(t, dataStorage, samples)
+
+	tool := NewDiskTool(storage.NewMemoryCache(), CacheOptions{}, PartitionFilter{}, NewOutputStyle(StylePlain, 0), dataStorage)
+	text, err := tool.Execute(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"\n  6天变化: +12.00 GB, 预计 ~38 天后写满\n", "\n  6天变化: -12.00 GB（用量下降，写满预测无意义）\n"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("disk_info lacks %q:\n%s", want, text)
+		}
+	}
+
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"include_trend": "false", "cache": "auto"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(text, "变化") {
+		t.Errorf("include_trend=false still shows trends:\n%s", text)
+	}
+
+	// 没有历史时输出与原来相同，显式要求趋势时提示启用后台采集
+	noHistory := NewDiskTool(storage.NewMemoryCache(), CacheOptions{}, PartitionFilter{}, NewOutputStyle(StylePlain, 0), storage.NewMemoryStorage())
+	text, err = noHistory.Execute(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(text, "变化") || strings.Contains(text, "💡") {
+		t.Errorf("disk_info without history mentions trends:\n%s", text)
+	}
+	text, err = noHistory.Execute(context.Background(), map[string]interface{}{"include_trend": "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "💡 没有可用的历史采样，请通过 --collect-interval 启用后台采集\n") {
+		t.Errorf("include_trend=true without history lacks the hint:\n%s", text)
+	}
+}
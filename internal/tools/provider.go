@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"context"
+	"syscall"
+	"time"
+)
+
+// Providers 工具读取主机数据的来源。默认实现基于 gopsutil v3，使用 gopsutil_v4 构建标签时为 v4；
+// 工具只通过这些接口访问主机，测试中可以用 SetProviders 换成不访问真实主机的实现
+type Providers struct {
+	CPU     CPUProvider
+	Mem     MemProvider
+	Disk    DiskProvider
+	Net     NetProvider
+	Host    HostProvider
+	Process ProcessProvider
+}
+
+// CPUProvider CPU 数据来源
+type CPUProvider interface {
+	// Percent 在 interval 内采样 CPU 使用率，perCPU 为 true 时返回每个逻辑核心的使用率
+	Percent(ctx context.Context, interval time.Duration, perCPU bool) ([]float64, error)
+	// Counts 逻辑（logical 为 true）或物理核心数
+	Counts(ctx context.Context, logical bool) (int, error)
+	Info(ctx context.Context) ([]CPUInfoStat, error)
+}
+
+// MemProvider 内存数据来源
+type MemProvider interface {
+	VirtualMemory(ctx context.Context) (*VirtualMemoryStat, error)
+	SwapMemory(ctx context.Context) (*SwapMemoryStat, error)
+}
+
+// DiskProvider 磁盘数据来源
+type DiskProvider interface {
+	// Partitions 分区列表，all 为 false 时只返回物理设备
+	Partitions(ctx context.Context, all bool) ([]PartitionStat, error)
+	Usage(ctx context.Context, path string) (*UsageStat, error)
+	// IOCounters 按设备名索引的累计 I/O 计数
+	IOCounters(ctx context.Context) (map[string]DiskIOCountersStat, error)
+}
+
+// NetProvider 网络数据来源
+type NetProvider interface {
+	// IOCounters 累计流量计数，perNIC 为 false 时只返回所有接口的合计
+	IOCounters(ctx context.Context, perNIC bool) ([]NetIOCountersStat, error)
+	// Connections 网络连接，kind 为 all、inet、tcp、udp 等
+	Connections(ctx context.Context, kind string) ([]ConnectionStat, error)
+}
+
+// HostProvider 主机数据来源
+type HostProvider interface {
+	Info(ctx context.Context) (*HostInfoStat, error)
+	// Uptime 运行时间（秒）
+	Uptime(ctx context.Context) (uint64, error)
+	// BootTime 启动时间（Unix 秒）
+	BootTime(ctx context.Context) (uint64, error)
+	Users(ctx context.Context) ([]UserStat, error)
+	Temperatures(ctx context.Context) ([]TemperatureStat, error)
+	LoadAvg(ctx context.Context) (*LoadAvgStat, error)
+}
+
+// ProcessProvider 进程数据来源
+type ProcessProvider interface {
+	Pids(ctx context.Context) ([]int32, error)
+	Processes(ctx context.Context) ([]Process, error)
+	// NewProcess 获取指定 PID 的进程，进程不存在时返回错误
+	NewProcess(ctx context.Context, pid int32) (Process, error)
+	PidExists(ctx context.Context, pid int32) (bool, error)
+}
+
+// Process 单个进程，方法与 gopsutil v3 的 process.Process 一致
+type Process interface {
+	PID() int32
+	NameWithContext(ctx context.Context) (string, error)
+	PpidWithContext(ctx context.Context) (int32, error)
+	CmdlineSliceWithContext(ctx context.Context) ([]string, error)
+	UidsWithContext(ctx context.Context) ([]int32, error)
+	UsernameWithContext(ctx context.Context) (string, error)
+	StatusWithContext(ctx context.Context) ([]string, error)
+	CreateTimeWithContext(ctx context.Context) (int64, error)
+	CPUPercentWithContext(ctx context.Context) (float64, error)
+	// TimesWithContext 进程启动以来累计的 CPU 时间
+	TimesWithContext(ctx context.Context) (*TimesStat, error)
+	MemoryInfoWithContext(ctx context.Context) (*MemoryInfoStat, error)
+	NumFDsWithContext(ctx context.Context) (int32, error)
+	// OpenFilesWithContext 进程打开的文件，不支持的平台返回错误
+	OpenFilesWithContext(ctx context.Context) ([]OpenFilesStat, error)
+	SendSignalWithContext(ctx context.Context, sig syscall.Signal) error
+}
+
+// providers 当前使用的数据来源
+var providers = gopsutilProviders()
+
+// CurrentProviders 当前使用的数据来源，供工具包之外直接读取主机数据的组件（如后台采集器）使用，
+// 使这些组件同样遵循构建标签选择的实现和测试中 SetProviders 的替换
+func CurrentProviders() Providers {
+	return providers
+}
+
+// SetProviders 替换工具使用的数据来源并返回恢复原来源的函数，用于测试
+func SetProviders(p Providers) (restore func()) {
+	previous := providers
+	providers = p
+	return func() {
+		providers = previous
+	}
+}
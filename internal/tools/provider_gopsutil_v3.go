@@ -0,0 +1,169 @@
+//go:build !gopsutil_v4
+
+package tools
+
+import (
+	"context"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// gopsutil v3 的数据类型，v4 实现中同名类型指向 v4 的对应类型
+type (
+	CPUInfoStat        = cpu.InfoStat
+	VirtualMemoryStat  = mem.VirtualMemoryStat
+	SwapMemoryStat     = mem.SwapMemoryStat
+	PartitionStat      = disk.PartitionStat
+	UsageStat          = disk.UsageStat
+	DiskIOCountersStat = disk.IOCountersStat
+	NetIOCountersStat  = net.IOCountersStat
+	ConnectionStat     = net.ConnectionStat
+	HostInfoStat       = host.InfoStat
+	UserStat           = host.UserStat
+	TemperatureStat    = host.TemperatureStat
+	LoadAvgStat        = load.AvgStat
+	MemoryInfoStat     = process.MemoryInfoStat
+	OpenFilesStat      = process.OpenFilesStat
+	TimesStat          = cpu.TimesStat
+)
+
+// processZombie 僵尸进程的状态
+const processZombie = process.Zombie
+
+// gopsutilProviders 基于 gopsutil v3 的数据来源
+func gopsutilProviders() Providers {
+	return Providers{
+		CPU:     gopsutilCPU{},
+		Mem:     gopsutilMem{},
+		Disk:    gopsutilDisk{},
+		Net:     gopsutilNet{},
+		Host:    gopsutilHost{},
+		Process: gopsutilProcesses{},
+	}
+}
+
+type gopsutilCPU struct{}
+
+func (gopsutilCPU) Percent(ctx context.Context, interval time.Duration, perCPU bool) ([]float64, error) {
+	return cpu.PercentWithContext(ctx, interval, perCPU)
+}
+
+func (gopsutilCPU) Counts(ctx context.Context, logical bool) (int, error) {
+	return cpu.CountsWithContext(ctx, logical)
+}
+
+func (gopsutilCPU) Info(ctx context.Context) ([]CPUInfoStat, error) {
+	return cpu.InfoWithContext(ctx)
+}
+
+type gopsutilMem struct{}
+
+func (gopsutilMem) VirtualMemory(ctx context.Context) (*VirtualMemoryStat, error) {
+	return mem.VirtualMemoryWithContext(ctx)
+}
+
+func (gopsutilMem) SwapMemory(ctx context.Context) (*SwapMemoryStat, error) {
+	return mem.SwapMemoryWithContext(ctx)
+}
+
+type gopsutilDisk struct{}
+
+func (gopsutilDisk) Partitions(ctx context.Context, all bool) ([]PartitionStat, error) {
+	return disk.PartitionsWithContext(ctx, all)
+}
+
+func (gopsutilDisk) Usage(ctx context.Context, path string) (*UsageStat, error) {
+	return disk.UsageWithContext(ctx, path)
+}
+
+func (gopsutilDisk) IOCounters(ctx context.Context) (map[string]DiskIOCountersStat, error) {
+	return disk.IOCountersWithContext(ctx)
+}
+
+type gopsutilNet struct{}
+
+func (gopsutilNet) IOCounters(ctx context.Context, perNIC bool) ([]NetIOCountersStat, error) {
+	return net.IOCountersWithContext(ctx, perNIC)
+}
+
+func (gopsutilNet) Connections(ctx context.Context, kind string) ([]ConnectionStat, error) {
+	return net.ConnectionsWithContext(ctx, kind)
+}
+
+type gopsutilHost struct{}
+
+func (gopsutilHost) Info(ctx context.Context) (*HostInfoStat, error) {
+	return host.InfoWithContext(ctx)
+}
+
+func (gopsutilHost) Uptime(ctx context.Context) (uint64, error) {
+	return host.UptimeWithContext(ctx)
+}
+
+func (gopsutilHost) BootTime(ctx context.Context) (uint64, error) {
+	return host.BootTimeWithContext(ctx)
+}
+
+func (gopsutilHost) Users(ctx context.Context) ([]UserStat, error) {
+	return host.UsersWithContext(ctx)
+}
+
+func (gopsutilHost) Temperatures(ctx context.Context) ([]TemperatureStat, error) {
+	return host.SensorsTemperaturesWithContext(ctx)
+}
+
+func (gopsutilHost) LoadAvg(ctx context.Context) (*LoadAvgStat, error) {
+	return load.AvgWithContext(ctx)
+}
+
+type gopsutilProcesses struct{}
+
+func (gopsutilProcesses) Pids(ctx context.Context) ([]int32, error) {
+	return process.PidsWithContext(ctx)
+}
+
+func (gopsutilProcesses) Processes(ctx context.Context) ([]Process, error) {
+	processes, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wrapped := make([]Process, len(processes))
+	for i, p := range processes {
+		wrapped[i] = gopsutilProcess{p}
+	}
+	return wrapped, nil
+}
+
+func (gopsutilProcesses) NewProcess(ctx context.Context, pid int32) (Process, error) {
+	p, err := process.NewProcessWithContext(ctx, pid)
+	if err != nil {
+		return nil, err
+	}
+	return gopsutilProcess{p}, nil
+}
+
+func (gopsutilProcesses) PidExists(ctx context.Context, pid int32) (bool, error) {
+	return process.PidExistsWithContext(ctx, pid)
+}
+
+// gopsutilProcess 为 process.Process 补充 PID 方法，并保证进程名是合法的 UTF-8
+type gopsutilProcess struct {
+	*process.Process
+}
+
+func (p gopsutilProcess) PID() int32 {
+	return p.Pid
+}
+
+// NameWithContext 进程名来自 /proc/<pid>/comm 等，可能包含无效的 UTF-8 字节，替换为 U+FFFD
+func (p gopsutilProcess) NameWithContext(ctx context.Context) (string, error) {
+	name, err := p.Process.NameWithContext(ctx)
+	return SanitizeText(name), err
+}
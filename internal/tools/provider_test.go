@@ -0,0 +1,331 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+func TestCPUToolUsesProvider(t *testing.T) {
+	useFakeCPU(t, &fakeCPUProvider{
+		infos: []CPUInfoStat{
+			{ModelName: "Fake CPU 9000", PhysicalID: "0", CoreID: "0", Mhz: 2400},
+			{ModelName: "Fake CPU 9000", PhysicalID: "0", CoreID: "1", Mhz: 2400},
+			{ModelName: "Fake CPU 9000", PhysicalID: "0", CoreID: "0", Mhz: 2400},
+			{ModelName: "Fake CPU 9000", PhysicalID: "0", CoreID: "1", Mhz: 2400},
+		},
+		physicalCores: 2,
+		perCore:       []float64{10, 20, 30, 40},
+		total:         25,
+	})
+	tool := NewCPUTool(storage.NewMemoryCache(), CacheOptions{}, NewOutputStyle(StylePlain, 0))
+	tool.platform = platformLinux
+
+	text, structured, err := tool.ExecuteStructured(context.Background(), map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := structured.(cpuReport)
+	if report.ModelName != "Fake CPU 9000" || report.Sockets != 1 || report.Cores != 2 || report.Frequency != 2.4 {
+		t.Errorf("static info = %q, %d sockets, %d cores, %.2f GHz; want the fake CPU's 1 socket, 2 cores, 2.40 GHz",
+			report.ModelName, report.Sockets, report.Cores, report.Frequency)
+	}
+	if report.LogicalCores != runtime.NumCPU() {
+		t.Errorf("LogicalCores = %d, want runtime.NumCPU() = %d", report.LogicalCores, runtime.NumCPU())
+	}
+	if report.Usage.Total != 25 || !slices.Equal(report.Usage.PerCore, []float64{10, 20, 30, 40}) {
+		t.Errorf("Usage = %+v, want the fake total and per-core percentages", report.Usage)
+	}
+	if !strings.Contains(text, "Fake CPU 9000") || !strings.Contains(text, "25.0") {
+		t.Errorf("text does not describe the fake CPU:\n%s", text)
+	}
+}
+
+func TestMemoryToolUsesProvider(t *testing.T) {
+	const gb = 1 << 30
+	useFakeMem(t, &fakeMemProvider{
+		virtual: VirtualMemoryStat{Total: 8 * gb, Used: 6 * gb, Available: 2 * gb, Free: gb, Buffers: 64 << 20, Cached: 512 << 20, UsedPercent: 75},
+		swap:    SwapMemoryStat{Total: 2 * gb, Used: gb, Free: gb, UsedPercent: 50},
+	})
+	tool := NewMemoryTool(storage.NewMemoryCache(), CacheOptions{}, NewOutputStyle(StylePlain, 0))
+	tool.platform = platformWindows
+
+	text, structured, err := tool.ExecuteStructured(context.Background(), map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := structured.(memoryReport)
+	if report.Total != 8*gb || report.Used != 6*gb || report.Available != 2*gb || report.UsedPercent != 75 {
+		t.Errorf("memory = %+v, want the fake virtual memory statistics", report.MemoryInfo)
+	}
+	if report.Buffers != 64<<20 || report.Cached != 512<<20 {
+		t.Errorf("buffers/cached = %d/%d, want the fake values", report.Buffers, report.Cached)
+	}
+	if report.Swap.Total != 2*gb || report.Swap.Used != gb || report.Swap.UsedPercent != 50 {
+		t.Errorf("swap = %+v, want the fake swap statistics", report.Swap)
+	}
+	if report.OOMRisk == nil || report.OOMRisk.Note == "" {
+		t.Errorf("OOMRisk = %+v, want a note that the platform is not assessed", report.OOMRisk)
+	}
+	if !strings.Contains(text, "8.00 GB") {
+		t.Errorf("text does not show the fake total:\n%s", text)
+	}
+}
+
+func TestDiskToolUsesProvider(t *testing.T) {
+	const gb = 1 << 30
+	useFakeDisk(t, &fakeDiskProvider{
+		partitions: []PartitionStat{
+			{Device: "/dev/sda1", Mountpoint: "/", Fstype: "ext4", Opts: []string{"rw"}},
+			{Device: "/dev/sdb1", Mountpoint: "/data", Fstype: "xfs", Opts: []string{"rw"}},
+			{Device: "nas:/export", Mountpoint: "/mnt/nas", Fstype: "nfs4", Opts: []string{"rw"}},
+			{Device: "/dev/sdc1", Mountpoint: "/mnt/broken", Fstype: "ext4", Opts: []string{"rw"}},
+		},
+		usage: map[string]UsageStat{
+			"/":     {Path: "/", Total: 100 * gb, Used: 40 * gb, Free: 60 * gb, UsedPercent: 40},
+			"/data": {Path: "/data", Total: 500 * gb, Used: 450 * gb, Free: 50 * gb, UsedPercent: 90},
+		},
+		hung:    map[string]bool{"/mnt/nas": true},
+		failing: map[string]error{"/mnt/broken": errors.New("input/output error")},
+	})
+
+	// 挂载表中 /data 已被重新挂载为只读，应覆盖枚举分区时得到的选项
+	mounts := filepath.Join(t.TempDir(), "mounts")
+	table := "/dev/sda1 / ext4 rw,relatime 0 0\n/dev/sdb1 /data xfs ro,noatime 0 0\n"
+	if err := os.WriteFile(mounts, []byte(table), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewDiskTool(storage.NewMemoryCache(), CacheOptions{}, PartitionFilter{}, NewOutputStyle(StylePlain, 0), nil)
+	tool.platform = platformLinux
+	tool.mountsPath = mounts
+	tool.usageTimeout = 50 * time.Millisecond
+
+	_, structured, err := tool.ExecuteStructured(context.Background(), map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := structured.(diskReport)
+
+	partitions := make(map[string]bool)
+	for _, partition := range report.Partitions {
+		partitions[partition.Mountpoint] = partition.ReadOnly
+		want := map[string]uint64{"/": 100 * gb, "/data": 500 * gb}[partition.Mountpoint]
+		if partition.Total != want {
+			t.Errorf("%s Total = %d, want %d", partition.Mountpoint, partition.Total, want)
+		}
+	}
+	if len(partitions) != 2 || partitions["/"] || !partitions["/data"] {
+		t.Errorf("partitions (mountpoint → read-only) = %v, want / writable and /data read-only from the mount table", partitions)
+	}
+
+	skipped := make(map[string]string)
+	for _, mount := range report.SkippedMounts {
+		skipped[mount.Mountpoint] = mount.Reason
+	}
+	if skipped["/mnt/nas"] != SkipReasonTimeout || skipped["/mnt/broken"] != SkipReasonError || len(skipped) != 2 {
+		t.Errorf("skipped mounts = %v, want /mnt/nas timed out and /mnt/broken failed", skipped)
+	}
+}
+
+func TestSystemToolUsesProvider(t *testing.T) {
+	bootTime := uint64(fixedTime.Unix()) - 90061
+	useFakeHost(t, &fakeHostProvider{
+		info: HostInfoStat{
+			Hostname: "fake-host", OS: "linux", Platform: "debian", PlatformVersion: "12",
+			KernelVersion: "6.1.0-18-amd64", KernelArch: "x86_64",
+			VirtualizationSystem: "kvm", VirtualizationRole: "guest",
+		},
+		uptime:   90061,
+		bootTime: bootTime,
+		load:     LoadAvgStat{Load1: 0.5, Load5: 0.25, Load15: 0.125},
+	})
+	useFakeProcesses(t, newFakeProcessProvider(&fakeProcess{pid: 1}, &fakeProcess{pid: 2}, &fakeProcess{pid: 3}))
+	tool := NewSystemTool(storage.NewMemoryCache(), CacheOptions{})
+	tool.platform = platformLinux
+
+	text, structured, err := tool.ExecuteStructured(context.Background(), map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := structured.(systemReport)
+	if report.Hostname != "fake-host" || report.KernelVersion != "6.1.0-18-amd64" || report.Architecture != "x86_64" {
+		t.Errorf("host = %q %q %q, want the fake host's identity", report.Hostname, report.KernelVersion, report.Architecture)
+	}
+	if report.Uptime != 90061 || report.BootTime != bootTime || report.ProcessCount != 3 {
+		t.Errorf("uptime %d, boot time %d, %d processes; want 90061, %d, 3", report.Uptime, report.BootTime, report.ProcessCount, bootTime)
+	}
+	if report.Load == nil || report.Load.Load1 != 0.5 || report.Load.Load15 != 0.125 {
+		t.Errorf("Load = %+v, want the fake load average", report.Load)
+	}
+	if !strings.Contains(text, "主机名: fake-host\n") || !strings.Contains(text, "运行时间: 1天 1小时 1分钟\n") {
+		t.Errorf("text does not describe the fake host:\n%s", text)
+	}
+}
+
+func TestSystemToolWithoutLoadAverage(t *testing.T) {
+	useFakeHost(t, &fakeHostProvider{info: HostInfoStat{Hostname: "win-01", OS: "windows"}, load: LoadAvgStat{Load1: 9}})
+	useFakeProcesses(t, newFakeProcessProvider())
+	tool := NewSystemTool(storage.NewMemoryCache(), CacheOptions{})
+	tool.platform = platformWindows
+
+	_, structured, err := tool.ExecuteStructured(context.Background(), map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if load := structured.(systemReport).Load; load != nil {
+		t.Errorf("Load = %+v on Windows, want nil", load)
+	}
+}
+
+func TestProcessToolUsesProvider(t *testing.T) {
+	started := fixedTime.Add(-time.Hour).UnixMilli()
+	useFakeProcesses(t, newFakeProcessProvider(
+		&fakeProcess{pid: 10, name: "postgres", ppid: 1, cmdline: []string{"postgres"}, username: "postgres", status: []string{"S"}, createTime: started, memory: &MemoryInfoStat{RSS: 300 << 20}},
+		&fakeProcess{pid: 11, name: "postgres", ppid: 10, cmdline: []string{"postgres: writer"}, username: "postgres", status: []string{"S"}, createTime: started, memory: &MemoryInfoStat{RSS: 100 << 20}},
+		&fakeProcess{pid: 20, name: "nginx", ppid: 1, cmdline: []string{"nginx"}, username: "www-data", status: []string{"R"}, createTime: started, memory: &MemoryInfoStat{RSS: 200 << 20}},
+		&fakeProcess{pid: 30, name: "kworker/0:1", ppid: kthreaddPID, username: "root", status: []string{"I"}, createTime: started},
+		// 名称为空的进程（已退出）不计入结果
+		&fakeProcess{pid: 40, ppid: 1, cmdline: []string{"gone"}},
+	))
+	tool := NewProcessTool(storage.NewMemoryCache(), CacheOptions{})
+
+	_, structured, err := tool.ExecuteStructured(context.Background(), map[string]interface{}{"sort_by": "memory"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := structured.(processReport)
+	var pids []int32
+	for _, p := range report.Processes {
+		pids = append(pids, p.PID)
+	}
+	want := []int32{10, 20, 11}
+	if runtime.GOOS != "linux" {
+		want = append(want, 30)
+	}
+	if !slices.Equal(pids, want) || report.Total != 5 {
+		t.Fatalf("processes = %v of %d, want %v by memory of the 5 fake processes", pids, report.Total, want)
+	}
+	if first := report.Processes[0]; first.Name != "postgres" || first.MemoryBytes != 300<<20 || first.Status != "S" || first.StartTime == nil || first.StartTime.UnixMilli() != started {
+		t.Errorf("first process = %+v, want the fake postgres process", first)
+	}
+
+	_, structured, err = tool.ExecuteStructured(context.Background(), map[string]interface{}{"group_by": "user", "cache": CacheModeFresh})
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := structured.(processReport).Groups
+	if len(groups) != 2 || groups[0].Key != "postgres" || groups[0].Count != 2 || groups[0].MemoryBytes != 400<<20 || groups[1].Key != "www-data" {
+		t.Fatalf("groups = %+v, want postgres (2 processes, 400 MB) then www-data", groups)
+	}
+
+	_, structured, err = tool.ExecuteStructured(context.Background(), map[string]interface{}{"user": "www-data", "cache": CacheModeFresh})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report := structured.(processReport); len(report.Processes) != 1 || report.Processes[0].PID != 20 || report.ExcludedByUser == 0 {
+		t.Fatalf("user filter = %+v, want only nginx", report.ProcessList)
+	}
+}
+
+func TestNetworkToolUsesProvider(t *testing.T) {
+	useFakeNet(t, &fakeNetProvider{
+		counters: []NetIOCountersStat{
+			{Name: "eth0", BytesSent: 1000, BytesRecv: 5000, PacketsSent: 10, PacketsRecv: 50, Errin: 1, Dropout: 2},
+			{Name: "lo", BytesSent: 700, BytesRecv: 700},
+			{Name: "wlan0", BytesSent: 30, BytesRecv: 40},
+		},
+		connections: []ConnectionStat{
+			connection(1, 2, "0.0.0.0", 22, "", 0, "LISTEN", 100),
+			connection(1, 2, "10.0.0.5", 22, "10.0.0.9", 51000, "ESTABLISHED", 300),
+			connection(1, 2, "10.0.0.5", 40000, "1.1.1.1", 443, "ESTABLISHED", 0),
+			connection(2, 2, "0.0.0.0", 53, "", 0, "NONE", 400),
+		},
+	})
+	tool := NewNetworkTool(storage.NewMemoryCache(), CacheOptions{}, 0, nil)
+
+	text, structured, err := tool.ExecuteStructured(context.Background(), map[string]interface{}{"show_connections": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := structured.(networkReport)
+	var names []string
+	for _, iface := range report.Interfaces {
+		names = append(names, iface.Name)
+	}
+	if !slices.Equal(names, []string{"eth0", "wlan0"}) {
+		t.Fatalf("interfaces = %v, want the fake interfaces without loopback", names)
+	}
+	if eth0 := report.Interfaces[0]; eth0.BytesRecv != 5000 || eth0.PacketsSent != 10 || eth0.ErrorsIn != 1 || eth0.DropOut != 2 {
+		t.Errorf("eth0 = %+v, want the fake counters", eth0)
+	}
+	connections := report.Connections
+	if connections.Total != 4 || connections.ByStatus["ESTABLISHED"] != 2 || connections.ByProtocol["1-2"] != 3 || connections.ByProtocol["2-2"] != 1 || connections.NoPID != 1 {
+		t.Errorf("connections = %+v, want the 4 fake connections", connections)
+	}
+	if !strings.Contains(text, "eth0") || strings.Contains(text, "lo:") {
+		t.Errorf("text does not describe the fake interfaces:\n%s", text)
+	}
+}
+
+func TestJSONOutputCarriesHostIdentity(t *testing.T) {
+	useFakeHost(t, &fakeHostProvider{info: HostInfoStat{Hostname: "fake-host", OS: "linux"}})
+	useFakeProcesses(t, newFakeProcessProvider(&fakeProcess{pid: 1, name: "init"}))
+	useFakeNet(t, &fakeNetProvider{counters: []NetIOCountersStat{{Name: "eth0", BytesSent: 1000, BytesRecv: 5000}}})
+	want := identity.Get().Fingerprint
+
+	assertHost := func(name string, data []byte) {
+		t.Helper()
+		var report struct {
+			Host *types.HostIdentity `json:"host"`
+		}
+		if err := json.Unmarshal(data, &report); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if report.Host == nil || report.Host.Fingerprint != want {
+			t.Errorf("%s host = %+v, want fingerprint %s", name, report.Host, want)
+		}
+	}
+
+	// 结构化结果和 format=json 的输出都带有同一个主机身份，便于汇总多台机器的数据
+	for _, tool := range []types.StructuredTool{
+		NewSystemTool(storage.NewMemoryCache(), CacheOptions{}),
+		NewNetworkTool(storage.NewMemoryCache(), CacheOptions{}, 0, nil),
+		NewProcessTool(storage.NewMemoryCache(), CacheOptions{}),
+	} {
+		name := tool.(types.MonitorTool).GetName()
+		_, structured, err := tool.ExecuteStructured(context.Background(), map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		data, err := json.Marshal(structured)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertHost(name, data)
+	}
+	text, err := NewTopNetworkInterfacesTool().Execute(context.Background(), map[string]interface{}{"interval": "10ms", "format": "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertHost("top_network_interfaces", []byte(text))
+
+	// 存储的综合快照同样带有主机身份
+	snapshot, err := NewSystemTool(storage.NewMemoryCache(), CacheOptions{}).GetComprehensiveOverview(context.Background(), nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Host == nil || snapshot.Host.Fingerprint != want {
+		t.Errorf("snapshot host = %+v, want fingerprint %s", snapshot.Host, want)
+	}
+}
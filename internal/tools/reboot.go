@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// bootTimeJitter 启动时间允许的抖动（秒）。部分平台的启动时间由当前时间减去运行时间推算，会有少量偏差
+const bootTimeJitter = 5
+
+// rebooted 判断两次启动时间是否属于不同的启动，任一为 0（未知）时返回 false
+func rebooted(previous, current uint64) bool {
+	if previous == 0 || current == 0 {
+		return false
+	}
+	if current > previous {
+		return current-previous > bootTimeJitter
+	}
+	return previous-current > bootTimeJitter
+}
+
+// detectReboots 返回每次重启后第一个样本的时间
+func detectReboots(samples []types.MetricSample) []time.Time {
+	var reboots []time.Time
+	var lastBoot uint64
+	for _, sample := range samples {
+		if sample.BootTime == 0 {
+			continue
+		}
+		if rebooted(lastBoot, sample.BootTime) {
+			reboots = append(reboots, sample.Timestamp)
+		}
+		lastBoot = sample.BootTime
+	}
+	return reboots
+}
+
+// rebootBetween 判断 (from, to] 区间内是否发生过重启
+func rebootBetween(reboots []time.Time, from, to time.Time) bool {
+	for _, reboot := range reboots {
+		if reboot.After(from) && !reboot.After(to) {
+			return true
+		}
+	}
+	return false
+}
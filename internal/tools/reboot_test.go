@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+func TestRebooted(t *testing.T) {
+	cases := []struct {
+		previous, current uint64
+		want              bool
+	}{
+		{1700000000, 1700000000, false},
+		// 由当前时间减去运行时间推算的启动时间有少量抖动
+		{1700000000, 1700000000 + bootTimeJitter, false},
+		{1700000000, 1700000000 - bootTimeJitter, false},
+		{1700000000, 1700000000 + bootTimeJitter + 1, true},
+		{1700000000, 1700086400, true},
+		// 启动时间变早（例如 NTP 校正了重启后的时钟）同样是另一次启动
+		{1700086400, 1700000000, true},
+		// 旧样本或获取失败时启动时间为 0，不判断为重启
+		{0, 1700000000, false},
+		{1700000000, 0, false},
+	}
+	for _, c := range cases {
+		if got := rebooted(c.previous, c.current); got != c.want {
+			t.Errorf("rebooted(%d, %d) = %v, want %v", c.previous, c.current, got, c.want)
+		}
+	}
+}
+
+func TestDetectReboots(t *testing.T) {
+	start := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	samples := []types.MetricSample{
+		{Timestamp: at(0), BootTime: 1700000000},
+		{Timestamp: at(1), BootTime: 1700000002},
+		// 启动时间未知的样本不打断比较，之后的样本与最后一个已知启动时间比较
+		{Timestamp: at(2)},
+		{Timestamp: at(3), BootTime: 1700000001},
+		{Timestamp: at(4), BootTime: 1700003600},
+		{Timestamp: at(5), BootTime: 1700003600},
+		{Timestamp: at(6), BootTime: 1700007200},
+	}
+	reboots := detectReboots(samples)
+	if !slices.Equal(reboots, []time.Time{at(4), at(6)}) {
+		t.Fatalf("detectReboots() = %v, want the first samples after each reboot", reboots)
+	}
+
+	// 区间为左开右闭：重启后首个样本本身属于跨越重启的区间
+	for _, c := range []struct {
+		from, to int
+		want     bool
+	}{
+		{0, 3, false},
+		{3, 4, true},
+		{4, 5, false},
+		{0, 6, true},
+		{5, 6, true},
+	} {
+		if got := rebootBetween(reboots, at(c.from), at(c.to)); got != c.want {
+			t.Errorf("rebootBetween(%d, %d) = %v, want %v", c.from, c.to, got, c.want)
+		}
+	}
+	if detectReboots(nil) != nil {
+		t.Error("detectReboots(nil) should be empty")
+	}
+}
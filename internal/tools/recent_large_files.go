@@ -0,0 +1,211 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"mcp-example/internal/fswalk"
+	"mcp-example/internal/identity"
+	"mcp-example/internal/types"
+)
+
+// RecentLargeFilesTool 最近大文件工具：找出最近修改过的大文件（日志暴涨、core dump 等）
+type RecentLargeFilesTool struct{}
+
+// NewRecentLargeFilesTool 创建新的最近大文件工具
+func NewRecentLargeFilesTool() *RecentLargeFilesTool {
+	return &RecentLargeFilesTool{}
+}
+
+// largeFile 单个大文件
+type largeFile struct {
+	Path      string    `json:"path"`
+	SizeBytes uint64    `json:"size_bytes"`
+	SizeMB    float64   `json:"size_mb"`
+	ModTime   time.Time `json:"mod_time"`
+	Owner     string    `json:"owner,omitempty"`
+	// uid 所有者的 UID，只对最终保留的文件解析为用户名
+	uid string
+}
+
+// largeFileReport 大文件扫描结果
+type largeFileReport struct {
+	Path           string      `json:"path"`
+	MinSizeMB      float64     `json:"min_size_mb"`
+	ModifiedWithin string      `json:"modified_within"`
+	Files          []largeFile `json:"files"`
+	// Matching 满足条件的文件数，Files 只保留其中最大的 top 个
+	Matching int `json:"matching_count"`
+	walkStats
+	Host *types.HostIdentity `json:"host,omitempty"`
+}
+
+// GetName 获取工具名称
+func (rl *RecentLargeFilesTool) GetName() string {
+	return "recent_large_files"
+}
+
+// GetDescription 获取工具描述
+func (rl *RecentLargeFilesTool) GetDescription() string {
+	return "找出指定路径下最近修改过的大文件，用于定位磁盘突然增长的原因（不跟随符号链接，不跨越文件系统）"
+}
+
+// GetAnnotations 获取工具注解
+func (rl *RecentLargeFilesTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("最近大文件")
+}
+
+// recentLargeFilesArgs recent_large_files 的参数
+type recentLargeFilesArgs struct {
+	strictArgs
+	Path           string        `arg:"path,required" desc:"要扫描的目录"`
+	MinSizeMB      float64       `arg:"min_size_mb,default=100,min=0" desc:"只列出不小于该大小（MB）的文件"`
+	ModifiedWithin time.Duration `arg:"modified_within,default=24h" pattern:"^[0-9]+(\\.[0-9]+)?(s|m|h)$" desc:"只列出在该时长内修改过的文件，如 30m、24h"`
+	Top            int           `arg:"top,default=20,min=1,max=100" desc:"返回最大的文件数量"`
+	formatArgs
+}
+
+// GetInputSchema 获取输入模式
+func (rl *RecentLargeFilesTool) GetInputSchema() types.InputSchema {
+	return argsSchema(recentLargeFilesArgs{})
+}
+
+// Examples 获取调用示例
+func (rl *RecentLargeFilesTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "/var 下最近 24 小时内修改过的 100 MB 以上文件",
+			Arguments:   map[string]interface{}{"path": "/var"},
+		},
+		{
+			Description: "/var/log 下最近 1 小时内修改过的 10 MB 以上文件",
+			Arguments:   map[string]interface{}{"path": "/var/log", "min_size_mb": 10, "modified_within": "1h"},
+		},
+	}
+}
+
+// Execute 扫描大文件
+func (rl *RecentLargeFilesTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a recentLargeFilesArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+	if a.ModifiedWithin <= 0 {
+		return "", argumentError("modified_within", "无效的 modified_within: %s", a.ModifiedWithin)
+	}
+
+	report, err := findRecentLargeFiles(ctx, a.Path, uint64(a.MinSizeMB*1024*1024), time.Now().Add(-a.ModifiedWithin))
+	if err != nil {
+		return "", err
+	}
+	report.MinSizeMB = a.MinSizeMB
+	report.ModifiedWithin = durationText(a.ModifiedWithin)
+
+	report.Matching = len(report.Files)
+	if len(report.Files) > a.Top {
+		report.Files = report.Files[:a.Top]
+	}
+	owners := make(ownerCache)
+	for i := range report.Files {
+		report.Files[i].Owner = owners.lookup(report.Files[i].uid)
+	}
+
+	if a.Format == "json" {
+		report.Host = identity.Get()
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", wrapError("序列化文件扫描结果失败", err)
+		}
+		return string(jsonData), nil
+	}
+
+	return formatLargeFileReport(report, a.Top), nil
+}
+
+// findRecentLargeFiles 扫描 root 下不小于 minBytes 且在 since 之后修改过的文件，按大小降序（相同时按路径）排列
+func findRecentLargeFiles(ctx context.Context, root string, minBytes uint64, since time.Time) (largeFileReport, error) {
+	report := largeFileReport{Path: filepath.Clean(root)}
+
+	result, err := walkPath(ctx, root, fswalk.Visitor{
+		File: func(path string, depth int, info fs.FileInfo) {
+			if uint64(info.Size()) < minBytes || info.ModTime().Before(since) {
+				return
+			}
+			uid, _ := fswalk.OwnerUID(info)
+			report.Files = append(report.Files, largeFile{
+				Path:      path,
+				SizeBytes: uint64(info.Size()),
+				SizeMB:    float64(info.Size()) / (1024 * 1024),
+				ModTime:   info.ModTime(),
+				uid:       uid,
+			})
+		},
+	})
+	if err != nil {
+		return report, err
+	}
+
+	sort.Slice(report.Files, func(i, j int) bool {
+		if report.Files[i].SizeBytes != report.Files[j].SizeBytes {
+			return report.Files[i].SizeBytes > report.Files[j].SizeBytes
+		}
+		return report.Files[i].Path < report.Files[j].Path
+	})
+	report.walkStats = newWalkStats(result)
+	return report, nil
+}
+
+// ownerCache 单次调用内按 UID 缓存用户名
+type ownerCache map[string]string
+
+// lookup 解析 UID 对应的用户名，无法解析时返回 UID 本身，UID 为空时返回空字符串
+func (oc ownerCache) lookup(uid string) string {
+	if uid == "" {
+		return ""
+	}
+	if username, found := oc[uid]; found {
+		return username
+	}
+
+	username := uid
+	if u, err := user.LookupId(uid); err == nil {
+		username = u.Username
+	}
+	oc[uid] = username
+	return username
+}
+
+// formatLargeFileReport 格式化大文件扫描结果
+func formatLargeFileReport(report largeFileReport, top int) string {
+	var result string
+
+	result += fmt.Sprintf("📄 %s 下最近 %s 内修改过的大文件（≥ %.0f MB）\n", report.Path, report.ModifiedWithin, report.MinSizeMB)
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+
+	if len(report.Files) == 0 {
+		result += "没有符合条件的文件\n"
+	} else {
+		result += fmt.Sprintf("%-12s %-20s %-12s %s\n", "大小", "修改时间", "所有者", "路径")
+		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for _, file := range report.Files {
+			owner := file.Owner
+			if owner == "" {
+				owner = "-"
+			}
+			result += fmt.Sprintf("%-12s %-20s %-12s %s\n", formatBytes(file.SizeBytes), file.ModTime.Format("2006-01-02 15:04:05"), owner, file.Path)
+		}
+		if report.Matching > top {
+			result += fmt.Sprintf("\n显示 %d / %d 个文件，可调大 top 或 min_size_mb\n", top, report.Matching)
+		}
+	}
+
+	result += formatWalkStats(report.walkStats)
+
+	return result
+}
@@ -0,0 +1,274 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"mcp-example/internal/types"
+)
+
+// ResultURIPrefix 被截断的工具结果的完整内容以 monitor://results/<追踪 ID> 提供
+const ResultURIPrefix = "monitor://results/"
+
+// DefaultResultRetention 完整结果的默认保留时长
+const DefaultResultRetention = time.Hour
+
+// maxResultPruneInterval 定期清理过期结果的最长间隔
+const maxResultPruneInterval = 10 * time.Minute
+
+// resultKeyPrefix 完整结果在存储中的键前缀，完整的键为 result_<追踪 ID>
+const resultKeyPrefix = "result_"
+
+// StoredResult 超出大小上限而被截断的工具调用的完整输出
+type StoredResult struct {
+	ID         string      `json:"id"`
+	Tool       string      `json:"tool"`
+	At         time.Time   `json:"at"`
+	Text       string      `json:"text"`
+	Structured interface{} `json:"structured,omitempty"`
+}
+
+// ResultStore 在存储中保存被截断的工具结果，供客户端通过 resources/read 读取完整内容。
+// 超过保留时长的结果不再返回，并在保存新结果时和 Run 定期清理时删除
+type ResultStore struct {
+	storage   types.DataStorage
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewResultStore 创建完整结果存储，retention 为结果的保留时长，不大于 0 时使用默认值
+func NewResultStore(dataStorage types.DataStorage, retention time.Duration) *ResultStore {
+	if retention <= 0 {
+		retention = DefaultResultRetention
+	}
+	return &ResultStore{
+		storage:   dataStorage,
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+// Retention 结果的保留时长
+func (rs *ResultStore) Retention() time.Duration {
+	return rs.retention
+}
+
+// ResultURI 结果 ID 对应的资源 URI
+func ResultURI(id string) string {
+	return ResultURIPrefix + id
+}
+
+// ValidResultID 结果 ID 会用作存储键（文件名），只允许字母、数字、下划线和连字符
+func ValidResultID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// Save 保存完整结果并清理过期的结果，返回资源 URI。ID 不能用作存储键时返回错误
+func (rs *ResultStore) Save(result StoredResult) (string, error) {
+	if !ValidResultID(result.ID) {
+		return "", fmt.Errorf("无效的结果 ID: %q", result.ID)
+	}
+	if result.At.IsZero() {
+		result.At = rs.now()
+	}
+	if err := rs.storage.Save(resultKeyPrefix+result.ID, result); err != nil {
+		return "", err
+	}
+
+	if _, err := rs.Prune(); err != nil {
+		slog.Debug("清理过期的完整结果失败", "error", err)
+	}
+	return ResultURI(result.ID), nil
+}
+
+// Load 读取未过期的完整结果，不存在或已过期时返回 ErrNotFound
+func (rs *ResultStore) Load(id string) (StoredResult, error) {
+	var result StoredResult
+	key := resultKeyPrefix + id
+	if !ValidResultID(id) || !rs.storage.Exists(key) {
+		return result, notFound("结果 %s 不存在或已过期", id)
+	}
+	if err := rs.storage.Load(key, &result); err != nil {
+		return result, wrapError("读取完整结果失败", err)
+	}
+	if rs.expired(result) {
+		return StoredResult{}, notFound("结果 %s 不存在或已过期", id)
+	}
+	return result, nil
+}
+
+// List 未过期的完整结果（不含内容），最新的在前
+func (rs *ResultStore) List() ([]StoredResult, error) {
+	keys, err := rs.storage.ListKeysWithPrefix(resultKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []StoredResult
+	for _, key := range keys {
+		var result StoredResult
+		if err := rs.storage.Load(key, &result); err != nil || rs.expired(result) {
+			continue
+		}
+		results = append(results, StoredResult{ID: result.ID, Tool: result.Tool, At: result.At})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if !results[i].At.Equal(results[j].At) {
+			return results[i].At.After(results[j].At)
+		}
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
+
+// Prune 删除超过保留时长的结果（包括无法读取的记录），返回删除的数量
+func (rs *ResultStore) Prune() (int, error) {
+	keys, err := rs.storage.ListKeysWithPrefix(resultKeyPrefix)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, key := range keys {
+		var result StoredResult
+		if err := rs.storage.Load(key, &result); err == nil && !rs.expired(result) {
+			continue
+		}
+		if err := rs.storage.Delete(key); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Run 立即清理一次过期的结果，之后定期清理（间隔为保留时长，最长 10 分钟），直到 ctx 取消
+func (rs *ResultStore) Run(ctx context.Context) {
+	ticker := time.NewTicker(min(rs.retention, maxResultPruneInterval))
+	defer ticker.Stop()
+
+	for {
+		if removed, err := rs.Prune(); err != nil {
+			slog.Warn("清理过期的完整结果失败", "error", err)
+		} else if removed > 0 {
+			slog.Debug("已清理过期的完整结果", "count", removed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// expired 结果是否已超过保留时长
+func (rs *ResultStore) expired(result StoredResult) bool {
+	return rs.now().Sub(result.At) > rs.retention
+}
+
+// ResultResource 以资源形式提供一个被截断的工具结果的完整内容：文本输出，以及有结构化内容时的 JSON
+type ResultResource struct {
+	store *ResultStore
+	id    string
+}
+
+// Resource 获取结果 URI 对应的资源，URI 不是结果 URI 时返回 false
+func (rs *ResultStore) Resource(uri string) (*ResultResource, bool) {
+	id, ok := strings.CutPrefix(uri, ResultURIPrefix)
+	if !ok || !ValidResultID(id) {
+		return nil, false
+	}
+	return &ResultResource{store: rs, id: id}, true
+}
+
+// Resources 列出未过期的结果对应的资源描述
+func (rs *ResultStore) Resources() []types.Resource {
+	results, err := rs.List()
+	if err != nil {
+		slog.Debug("列出完整结果失败", "error", err)
+		return nil
+	}
+
+	resources := make([]types.Resource, 0, len(results))
+	for _, result := range results {
+		resources = append(resources, resultDescription(result))
+	}
+	return resources
+}
+
+// resultDescription 结果的资源描述
+func resultDescription(result StoredResult) types.Resource {
+	return types.Resource{
+		URI:         ResultURI(result.ID),
+		Name:        fmt.Sprintf("%s 完整结果", result.Tool),
+		Description: fmt.Sprintf("%s 调用于 %s 的完整输出（响应中已截断）", result.Tool, result.At.Local().Format("2006-01-02 15:04:05")),
+		MimeType:    "text/plain",
+	}
+}
+
+// GetResource 获取资源描述
+func (rr *ResultResource) GetResource() types.Resource {
+	result, err := rr.store.Load(rr.id)
+	if err != nil {
+		return types.Resource{URI: ResultURI(rr.id), Name: "完整结果", MimeType: "text/plain"}
+	}
+	return resultDescription(result)
+}
+
+// Read 读取完整的文本输出
+func (rr *ResultResource) Read(ctx context.Context) (string, error) {
+	result, err := rr.store.Load(rr.id)
+	if err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
+
+// ReadContents 读取完整的文本输出和结构化内容（JSON），结果不存在或已过期时返回 ErrNotFound
+func (rr *ResultResource) ReadContents(ctx context.Context) ([]types.ResourceContents, error) {
+	result, err := rr.store.Load(rr.id)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := ResultURI(rr.id)
+	contents := []types.ResourceContents{{URI: uri, MimeType: "text/plain", Text: result.Text}}
+	if result.Structured != nil {
+		jsonData, err := json.MarshalIndent(result.Structured, "", "  ")
+		if err != nil {
+			return nil, wrapError("序列化结构化内容失败", err)
+		}
+		contents = append(contents, types.ResourceContents{URI: uri, MimeType: "application/json", Text: string(jsonData)})
+	}
+	return contents, nil
+}
+
+// TruncateText 将 text 截断到最多 limit 字节，不拆分 UTF-8 字符，且尽量在换行处截断
+func TruncateText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	if newline := strings.LastIndexByte(text[:cut], '\n'); newline > cut/2 {
+		cut = newline + 1
+	}
+	return text[:cut]
+}
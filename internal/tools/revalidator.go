@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// Revalidator 在后台刷新过期的缓存数据（stale-while-revalidate），
+// 同一缓存键同时只允许一个刷新任务，服务器关闭后不再启动新任务
+type Revalidator struct {
+	ctx      context.Context
+	mutex    sync.Mutex
+	inflight map[string]bool
+	wg       sync.WaitGroup
+}
+
+// NewRevalidator 创建后台刷新器，ctx 取消即表示服务器关闭
+func NewRevalidator(ctx context.Context) *Revalidator {
+	return &Revalidator{
+		ctx:      ctx,
+		inflight: make(map[string]bool),
+	}
+}
+
+// Refresh 为指定缓存键启动后台刷新，已有任务在执行或服务器已关闭时返回 false
+func (rv *Revalidator) Refresh(key string, refresh func(ctx context.Context) error) bool {
+	if rv.ctx.Err() != nil {
+		return false
+	}
+
+	rv.mutex.Lock()
+	if rv.inflight[key] {
+		rv.mutex.Unlock()
+		return false
+	}
+	rv.inflight[key] = true
+	rv.mutex.Unlock()
+
+	rv.wg.Add(1)
+	go func() {
+		defer rv.wg.Done()
+		defer func() {
+			rv.mutex.Lock()
+			delete(rv.inflight, key)
+			rv.mutex.Unlock()
+		}()
+
+		if err := refresh(rv.ctx); err != nil {
+			slog.Warn("后台刷新缓存失败", "key", key, "error", err)
+		}
+	}()
+
+	return true
+}
+
+// Wait 等待所有后台刷新任务结束
+func (rv *Revalidator) Wait() {
+	rv.wg.Wait()
+}
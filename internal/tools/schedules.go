@@ -0,0 +1,503 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// schedulesKey 定时任务定义在存储中的键
+const schedulesKey = "schedules"
+
+// ScheduledResultPrefix 定时任务结果的存储键前缀，完整的键为 scheduled_<任务名>_<时间戳>
+const ScheduledResultPrefix = "scheduled_"
+
+// scheduledTimeLayout 定时任务结果键中的时间戳格式（UTC）
+const scheduledTimeLayout = "20060102T150405Z"
+
+// minScheduleInterval 定时任务的最短执行间隔
+const minScheduleInterval = time.Minute
+
+// Schedule 定时任务：每隔 Interval 以 Arguments 调用一次 Tool，保留最近 Retention 个结果
+type Schedule struct {
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments"`
+	Interval  string                 `json:"interval"`
+	Retention int                    `json:"retention"`
+	UpdatedAt time.Time              `json:"updated_at"`
+	// LastRun 最近一次执行的结果，尚未执行时为 nil
+	LastRun *ScheduleRun `json:"last_run,omitempty"`
+	// Failures 连续失败的次数，成功后清零
+	Failures int `json:"consecutive_failures,omitempty"`
+}
+
+// ScheduleRun 定时任务单次执行的结果
+type ScheduleRun struct {
+	At         time.Time `json:"at"`
+	DurationMs int64     `json:"duration_ms"`
+	// Key 保存结果的存储键，失败时为空
+	Key   string `json:"key,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// interval 解析执行间隔，定义在保存时已校验
+func (s Schedule) interval() time.Duration {
+	interval, _ := time.ParseDuration(s.Interval)
+	return max(interval, minScheduleInterval)
+}
+
+// NextRun 下一次执行的时间：从未执行过的任务在保存后立即执行
+func (s Schedule) NextRun() time.Time {
+	if s.LastRun == nil {
+		return s.UpdatedAt
+	}
+	return s.LastRun.At.Add(s.interval())
+}
+
+// ScheduledResult 定时任务保存的一次工具输出
+type ScheduledResult struct {
+	Schedule   string                 `json:"schedule"`
+	Tool       string                 `json:"tool"`
+	Arguments  map[string]interface{} `json:"arguments"`
+	At         time.Time              `json:"at"`
+	DurationMs int64                  `json:"duration_ms"`
+	Text       string                 `json:"text"`
+	Structured interface{}            `json:"structured,omitempty"`
+}
+
+// ScheduledResultKey 定时任务在 at 时刻的结果的存储键
+func ScheduledResultKey(name string, at time.Time) string {
+	return ScheduledResultPrefix + name + "_" + at.UTC().Format(scheduledTimeLayout)
+}
+
+// ScheduleStore 保存在存储中的定时任务（任务名 → 任务）及其结果，并发安全
+type ScheduleStore struct {
+	storage types.DataStorage
+	mutex   sync.Mutex
+	changed chan struct{}
+}
+
+// NewScheduleStore 创建定时任务存储
+func NewScheduleStore(dataStorage types.DataStorage) *ScheduleStore {
+	return &ScheduleStore{
+		storage: dataStorage,
+		changed: make(chan struct{}, 1),
+	}
+}
+
+// Changed 任务被保存或删除时收到通知，调度器据此重新计算下一次执行时间
+func (ss *ScheduleStore) Changed() <-chan struct{} {
+	return ss.changed
+}
+
+// notify 发送变更通知，已有未处理的通知时无需重复发送
+func (ss *ScheduleStore) notify() {
+	select {
+	case ss.changed <- struct{}{}:
+	default:
+	}
+}
+
+// load 读取所有任务，需持有 mutex。尚未保存过任务时返回空集合
+func (ss *ScheduleStore) load() (map[string]Schedule, error) {
+	schedules := make(map[string]Schedule)
+	if !ss.storage.Exists(schedulesKey) {
+		return schedules, nil
+	}
+	if err := ss.storage.Load(schedulesKey, &schedules); err != nil {
+		return nil, wrapError("读取定时任务失败", err)
+	}
+	return schedules, nil
+}
+
+// save 写入所有任务，需持有 mutex
+func (ss *ScheduleStore) save(schedules map[string]Schedule) error {
+	if err := ss.storage.Save(schedulesKey, schedules); err != nil {
+		return wrapError("保存定时任务失败", err)
+	}
+	return nil
+}
+
+// All 获取所有任务
+func (ss *ScheduleStore) All() (map[string]Schedule, error) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	return ss.load()
+}
+
+// Set 保存任务，同名任务会被覆盖，但保留其最近一次执行的结果
+func (ss *ScheduleStore) Set(name string, schedule Schedule) error {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	schedules, err := ss.load()
+	if err != nil {
+		return err
+	}
+	if previous, found := schedules[name]; found {
+		schedule.LastRun = previous.LastRun
+		schedule.Failures = previous.Failures
+	}
+	schedules[name] = schedule
+	if err := ss.save(schedules); err != nil {
+		return err
+	}
+	ss.notify()
+	return nil
+}
+
+// Delete 删除任务及其保存的结果，不存在时返回 ERR_NOT_FOUND
+func (ss *ScheduleStore) Delete(name string) error {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	schedules, err := ss.load()
+	if err != nil {
+		return err
+	}
+	if _, found := schedules[name]; !found {
+		return notFound("定时任务不存在: %s", name)
+	}
+	delete(schedules, name)
+	if err := ss.save(schedules); err != nil {
+		return err
+	}
+	ss.notify()
+
+	keys, err := ss.ResultKeys(name)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := ss.storage.Delete(key); err != nil {
+			return wrapError("删除定时任务结果失败", err)
+		}
+	}
+	return nil
+}
+
+// RecordRun 记录任务的一次执行，任务在执行期间被删除时忽略
+func (ss *ScheduleStore) RecordRun(name string, run ScheduleRun) error {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	schedules, err := ss.load()
+	if err != nil {
+		return err
+	}
+	schedule, found := schedules[name]
+	if !found {
+		return nil
+	}
+	schedule.LastRun = &run
+	if run.Error != "" {
+		schedule.Failures++
+	} else {
+		schedule.Failures = 0
+	}
+	schedules[name] = schedule
+	return ss.save(schedules)
+}
+
+// SaveResult 保存任务的一次输出，并删除超出 retention 的旧结果
+func (ss *ScheduleStore) SaveResult(name string, result ScheduledResult, retention int) (string, error) {
+	key := ScheduledResultKey(name, result.At)
+	if err := ss.storage.Save(key, result); err != nil {
+		return "", wrapError("保存定时任务结果失败", err)
+	}
+
+	keys, err := ss.ResultKeys(name)
+	if err != nil {
+		return key, err
+	}
+	for len(keys) > retention {
+		if err := ss.storage.Delete(keys[0]); err != nil {
+			return key, wrapError("清理定时任务结果失败", err)
+		}
+		keys = keys[1:]
+	}
+	return key, nil
+}
+
+// ResultKeys 任务已保存的结果的存储键，按时间升序
+func (ss *ScheduleStore) ResultKeys(name string) ([]string, error) {
+	prefix := ScheduledResultPrefix + name + "_"
+	keys, err := ss.storage.ListKeysWithPrefix(prefix)
+	if err != nil {
+		return nil, wrapError("读取定时任务结果失败", err)
+	}
+
+	// 名称以 name_ 开头的其他任务的结果也匹配前缀，只保留剩余部分恰好是时间戳的键
+	matched := keys[:0]
+	for _, key := range keys {
+		if _, err := time.Parse(scheduledTimeLayout, strings.TrimPrefix(key, prefix)); err == nil {
+			matched = append(matched, key)
+		}
+	}
+	sort.Strings(matched)
+	return matched, nil
+}
+
+// Result 读取一次保存的结果
+func (ss *ScheduleStore) Result(key string) (ScheduledResult, error) {
+	var result ScheduledResult
+	if err := ss.storage.Load(key, &result); err != nil {
+		return result, wrapError("读取定时任务结果失败", err)
+	}
+	return result, nil
+}
+
+// ScheduleAdminTool 定时任务管理工具
+type ScheduleAdminTool struct {
+	schedules *ScheduleStore
+	lookup    ToolLookupFunc
+}
+
+// NewScheduleAdminTool 创建新的定时任务管理工具，lookup 用于在保存时按目标工具的参数模式校验参数
+func NewScheduleAdminTool(schedules *ScheduleStore, lookup ToolLookupFunc) *ScheduleAdminTool {
+	return &ScheduleAdminTool{
+		schedules: schedules,
+		lookup:    lookup,
+	}
+}
+
+// GetName 获取工具名称
+func (sa *ScheduleAdminTool) GetName() string {
+	return "schedule_admin"
+}
+
+// GetDescription 获取工具描述
+func (sa *ScheduleAdminTool) GetDescription() string {
+	return "管理定时任务：按固定间隔在后台调用只读工具并保存最近若干次的输出，可查看执行状态和保存的结果"
+}
+
+// GetAnnotations 获取工具注解
+func (sa *ScheduleAdminTool) GetAnnotations() types.ToolAnnotations {
+	return types.ToolAnnotations{
+		Title:          "定时任务管理",
+		IdempotentHint: true,
+	}
+}
+
+// scheduleAdminArgs schedule_admin 的参数
+type scheduleAdminArgs struct {
+	strictArgs
+	Action    string                 `arg:"action,enum=list|set|delete|results,default=list" desc:"操作: list 列出任务及最近一次执行状态, set 保存任务（同名覆盖）, delete 删除任务及其结果, results 查看任务保存的结果"`
+	Name      string                 `arg:"name" pattern:"^[A-Za-z0-9_.-]{1,64}$" desc:"任务名称（set、delete、results 必需），只能包含字母、数字、-、_ 和 ."`
+	Tool      string                 `arg:"tool" desc:"要调用的只读工具（set 必需）"`
+	Arguments map[string]interface{} `arg:"arguments" desc:"调用工具的参数（set 使用），保存时按工具的参数模式校验"`
+	Interval  time.Duration          `arg:"interval" pattern:"^[0-9]+(\\.[0-9]+)?(s|m|h)$" desc:"执行间隔（set 必需），如 10m、1h，最短 1m"`
+	Retention int                    `arg:"retention,default=24,min=1,max=1000" desc:"保留最近的结果数（set 使用）"`
+	Limit     int                    `arg:"limit,default=1,min=1,max=20" desc:"results 返回最近的结果数"`
+}
+
+// GetInputSchema 获取输入模式
+func (sa *ScheduleAdminTool) GetInputSchema() types.InputSchema {
+	return argsSchema(scheduleAdminArgs{})
+}
+
+// Examples 获取调用示例
+func (sa *ScheduleAdminTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "列出所有定时任务",
+			Arguments:   map[string]interface{}{"action": "list"},
+		},
+		{
+			Description: "每小时记录一次磁盘使用情况，保留最近 48 次",
+			Arguments: map[string]interface{}{
+				"action":    "set",
+				"name":      "disk-hourly",
+				"tool":      "disk_info",
+				"arguments": map[string]interface{}{"format": "json"},
+				"interval":  "1h",
+				"retention": 48,
+			},
+		},
+		{
+			Description: "每 10 分钟记录一次 CPU 占用最高的进程",
+			Arguments: map[string]interface{}{
+				"action":    "set",
+				"name":      "top-cpu",
+				"tool":      "top_processes",
+				"arguments": map[string]interface{}{"sort_by": "cpu", "limit": 10},
+				"interval":  "10m",
+			},
+		},
+		{
+			Description: "查看 disk-hourly 最近 3 次的输出",
+			Arguments:   map[string]interface{}{"action": "results", "name": "disk-hourly", "limit": 3},
+		},
+	}
+}
+
+// Execute 执行定时任务管理操作
+func (sa *ScheduleAdminTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a scheduleAdminArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+	name := a.Name
+
+	switch a.Action {
+	case "list":
+		return sa.list()
+
+	case "set":
+		if name == "" {
+			return "", argumentError("name", "set 操作需要提供 name")
+		}
+		return sa.set(a)
+
+	case "delete":
+		if name == "" {
+			return "", argumentError("name", "delete 操作需要提供 name")
+		}
+		if err := sa.schedules.Delete(name); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✅ 已删除定时任务及其保存的结果: %s\n", name), nil
+
+	case "results":
+		if name == "" {
+			return "", argumentError("name", "results 操作需要提供 name")
+		}
+		return sa.results(name, a.Limit)
+
+	default:
+		return "", argumentError("action", "不支持的操作: %s", a.Action)
+	}
+}
+
+// set 校验并保存任务
+func (sa *ScheduleAdminTool) set(a scheduleAdminArgs) (string, error) {
+	name, toolName, interval, retention := a.Name, a.Tool, a.Interval, a.Retention
+	if toolName == "" {
+		return "", argumentError("tool", "set 操作需要提供 tool")
+	}
+	tool, found := sa.lookup(toolName)
+	if !found {
+		return "", notFound("工具不存在或已被禁用: %s", toolName)
+	}
+	if tool.Annotations == nil || !tool.Annotations.ReadOnlyHint {
+		return "", argumentError("tool", "定时任务只能调用只读工具: %s", toolName)
+	}
+
+	if interval == 0 {
+		return "", argumentError("interval", "set 操作需要提供 interval")
+	}
+	if interval < minScheduleInterval {
+		return "", argumentError("interval", "无效的 interval: %s（最短 %s）", interval, minScheduleInterval)
+	}
+
+	arguments := a.Arguments
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+
+	// 定时执行时没有显式参数，必需参数也要在任务中提供
+	validated, err := ValidateArguments(tool.InputSchema, arguments)
+	if err != nil {
+		toolErr := ClassifyError(err)
+		return "", &Error{Code: toolErr.Code, Message: fmt.Sprintf("任务参数不符合 %s 的参数模式: %s", toolName, toolErr.Message), Hint: toolErr.Hint}
+	}
+
+	schedule := Schedule{
+		Tool:      toolName,
+		Arguments: validated,
+		Interval:  interval.String(),
+		Retention: retention,
+		UpdatedAt: time.Now(),
+	}
+	if err := sa.schedules.Set(name, schedule); err != nil {
+		return "", err
+	}
+
+	var result string
+	result += fmt.Sprintf("✅ 已保存定时任务: %s\n", name)
+	result += fmt.Sprintf("工具: %s %s\n", toolName, formatPresetArguments(validated))
+	result += fmt.Sprintf("间隔: %s，保留最近 %d 个结果\n", schedule.Interval, retention)
+	result += fmt.Sprintf("💡 结果保存在存储键 %s<时间戳>，可通过 results 操作查看\n", ScheduledResultPrefix+name+"_")
+	return result, nil
+}
+
+// list 列出所有任务及其最近一次执行的状态
+func (sa *ScheduleAdminTool) list() (string, error) {
+	schedules, err := sa.schedules.All()
+	if err != nil {
+		return "", err
+	}
+
+	var result string
+	result += "⏰ 定时任务\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	if len(schedules) == 0 {
+		result += "暂无定时任务，可通过 set 操作创建\n"
+		return result, nil
+	}
+
+	for _, name := range sortedKeys(schedules) {
+		schedule := schedules[name]
+		result += fmt.Sprintf("• %s → 每 %s 调用 %s %s（保留 %d 个结果）\n", name, schedule.Interval, schedule.Tool, formatPresetArguments(schedule.Arguments), schedule.Retention)
+
+		switch run := schedule.LastRun; {
+		case run == nil:
+			result += "  ⏳ 尚未执行\n"
+		case run.Error != "":
+			result += fmt.Sprintf("  ❌ %s 执行失败（连续 %d 次）: %s\n", run.At.Format("2006-01-02 15:04:05"), schedule.Failures, run.Error)
+		default:
+			result += fmt.Sprintf("  ✅ %s 执行成功，耗时 %dms\n", run.At.Format("2006-01-02 15:04:05"), run.DurationMs)
+		}
+		if keys, err := sa.schedules.ResultKeys(name); err == nil {
+			result += fmt.Sprintf("  📦 已保存 %d 个结果，下次执行: %s\n", len(keys), schedule.NextRun().Format("2006-01-02 15:04:05"))
+		}
+		if _, found := sa.lookup(schedule.Tool); !found {
+			result += "  ⚠️ 工具不存在或已被禁用\n"
+		}
+	}
+	result += fmt.Sprintf("\n共 %d 个定时任务\n", len(schedules))
+	return result, nil
+}
+
+// results 显示任务最近 limit 次保存的输出，最新的在前
+func (sa *ScheduleAdminTool) results(name string, limit int) (string, error) {
+	schedules, err := sa.schedules.All()
+	if err != nil {
+		return "", err
+	}
+	if _, found := schedules[name]; !found {
+		return "", notFound("定时任务不存在: %s", name)
+	}
+
+	keys, err := sa.schedules.ResultKeys(name)
+	if err != nil {
+		return "", err
+	}
+
+	var result string
+	result += fmt.Sprintf("📦 定时任务 %s 的结果（共 %d 个）\n", name, len(keys))
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	if len(keys) == 0 {
+		result += "尚无保存的结果\n"
+		return result, nil
+	}
+
+	for i := len(keys) - 1; i >= 0 && i >= len(keys)-limit; i-- {
+		saved, err := sa.schedules.Result(keys[i])
+		if err != nil {
+			return "", err
+		}
+		result += fmt.Sprintf("\n🕒 %s（%s，耗时 %dms）\n", saved.At.Format("2006-01-02 15:04:05"), keys[i], saved.DurationMs)
+		result += saved.Text
+		if !strings.HasSuffix(saved.Text, "\n") {
+			result += "\n"
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,282 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/types"
+	"mcp-example/internal/version"
+)
+
+// selfInfo 服务器进程自身的资源占用
+type selfInfo struct {
+	PID           int32               `json:"pid"`
+	RSSBytes      uint64              `json:"rss_bytes"`
+	VMSBytes      uint64              `json:"vms_bytes"`
+	CPUPercent    float64             `json:"cpu_percent"`
+	Goroutines    int                 `json:"goroutines"`
+	HeapAlloc     uint64              `json:"heap_alloc_bytes"`
+	Sys           uint64              `json:"sys_bytes"`
+	NumGC         uint32              `json:"num_gc"`
+	StartTime     time.Time           `json:"start_time"`
+	UptimeSeconds float64             `json:"uptime_seconds"`
+	OpenFDs       *int32              `json:"open_fds,omitempty"`
+	DataDir       string              `json:"data_dir,omitempty"`
+	DataDirBytes  *uint64             `json:"data_dir_bytes,omitempty"`
+	Build         types.BuildInfo     `json:"build"`
+	Platform      string              `json:"platform"`
+	Support       []platformSupport   `json:"platform_support"`
+	Notes         []string            `json:"notes,omitempty"`
+	Host          *types.HostIdentity `json:"host,omitempty"`
+}
+
+// 自身资源工具的操作
+const (
+	selfActionInfo          = "info"
+	selfActionGoroutineDump = "goroutine_dump"
+)
+
+// maxGoroutineDumpBytes goroutine 堆栈转储返回的最大字节数
+const maxGoroutineDumpBytes = 64 * 1024
+
+// maxGoroutineStackBuffer 采集 goroutine 堆栈时缓冲区的上限
+const maxGoroutineStackBuffer = 8 * 1024 * 1024
+
+// SelfInfoTool 服务器自身资源占用工具，每次调用实时采集，不使用缓存
+type SelfInfoTool struct {
+	storage   types.DataStorage
+	startTime time.Time
+	allowDump bool
+}
+
+// NewSelfInfoTool 创建新的自身资源占用工具，storage 用于确定数据目录，
+// allowDump 为 true 时（--enable-admin-tools）允许 goroutine_dump 操作
+func NewSelfInfoTool(storage types.DataStorage, allowDump bool) *SelfInfoTool {
+	return &SelfInfoTool{
+		storage:   storage,
+		startTime: time.Now(),
+		allowDump: allowDump,
+	}
+}
+
+// GetName 获取工具名称
+func (si *SelfInfoTool) GetName() string {
+	return "self_info"
+}
+
+// GetDescription 获取工具描述
+func (si *SelfInfoTool) GetDescription() string {
+	return "获取 MCP 服务器进程自身的资源占用（RSS、CPU、goroutine、Go 堆、文件描述符、数据目录大小）和构建信息"
+}
+
+// GetAnnotations 获取工具注解
+func (si *SelfInfoTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("服务器自身资源")
+}
+
+// selfInfoArgs self_info 的参数
+type selfInfoArgs struct {
+	Action string `arg:"action,enum=info|goroutine_dump,default=info" desc:"操作: info 资源占用，goroutine_dump 返回截断的 goroutine 堆栈（需 --enable-admin-tools）"`
+	formatArgs
+}
+
+// GetInputSchema 获取输入模式
+func (si *SelfInfoTool) GetInputSchema() types.InputSchema {
+	return argsSchema(selfInfoArgs{})
+}
+
+// Examples 获取调用示例
+func (si *SelfInfoTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "服务器进程自身的内存、goroutine 和构建信息",
+			Arguments:   map[string]interface{}{},
+		},
+		{
+			Description: "同样的信息，JSON 格式",
+			Arguments:   map[string]interface{}{"format": "json"},
+		},
+	}
+}
+
+// Execute 获取自身资源占用
+func (si *SelfInfoTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a selfInfoArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+
+	switch a.Action {
+	case selfActionInfo:
+	case selfActionGoroutineDump:
+		if !si.allowDump {
+			return "", argumentError("action", "goroutine_dump 操作需要使用 --enable-admin-tools 启动服务器")
+		}
+		return goroutineDump(), nil
+	default:
+		return "", argumentError("action", "不支持的操作: %s", a.Action)
+	}
+
+	info, err := si.collectSelfData(ctx)
+	if err != nil {
+		return "", wrapError("获取服务器自身资源占用失败", err)
+	}
+
+	if a.Format == "json" {
+		info.Host = identity.Get()
+		jsonData, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return "", wrapError("序列化自身资源占用失败", err)
+		}
+		return string(jsonData), nil
+	}
+
+	return si.formatSelfInfo(info), nil
+}
+
+// collectSelfData 采集自身资源占用，进程级指标不可用时记录备注并继续
+func (si *SelfInfoTool) collectSelfData(ctx context.Context) (selfInfo, error) {
+	p, err := providers.Process.NewProcess(ctx, int32(os.Getpid()))
+	if err != nil {
+		return selfInfo{}, err
+	}
+
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	info := selfInfo{
+		PID:        p.PID(),
+		Goroutines: runtime.NumGoroutine(),
+		HeapAlloc:  memStats.HeapAlloc,
+		Sys:        memStats.Sys,
+		NumGC:      memStats.NumGC,
+		StartTime:  si.startTime,
+		Build:      version.Get(),
+		Platform:   hostPlatform,
+		Support:    toolPlatformSupport(hostPlatform),
+	}
+
+	if memInfo, err := p.MemoryInfoWithContext(ctx); err != nil {
+		info.Notes = append(info.Notes, fmt.Sprintf("内存占用不可用: %v", err))
+	} else {
+		info.RSSBytes = memInfo.RSS
+		info.VMSBytes = memInfo.VMS
+	}
+
+	if cpuPercent, err := p.CPUPercentWithContext(ctx); err == nil {
+		info.CPUPercent = cpuPercent
+	}
+
+	// 进程创建时间比工具创建时间更早也更准确
+	if createTime, err := p.CreateTimeWithContext(ctx); err == nil {
+		info.StartTime = time.UnixMilli(createTime)
+	}
+	info.UptimeSeconds = time.Since(info.StartTime).Seconds()
+
+	if fds, err := p.NumFDsWithContext(ctx); err == nil {
+		info.OpenFDs = &fds
+	}
+
+	if provider, ok := si.storage.(types.StorageStatsProvider); ok {
+		if storageStats, err := provider.Stats(); err != nil {
+			info.Notes = append(info.Notes, fmt.Sprintf("数据目录大小不可用: %v", err))
+		} else if storageStats.DataDir != "" {
+			info.DataDir = storageStats.DataDir
+			info.DataDirBytes = &storageStats.TotalBytes
+		}
+	}
+
+	return info, ctx.Err()
+}
+
+// goroutineDump 获取所有 goroutine 的堆栈，超过 maxGoroutineDumpBytes 时截断
+func goroutineDump() string {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= maxGoroutineStackBuffer {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+
+	var result string
+	result += fmt.Sprintf("🧵 goroutine 堆栈（共 %d 个）\n", runtime.NumGoroutine())
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	if len(buf) > maxGoroutineDumpBytes {
+		result += string(buf[:maxGoroutineDumpBytes])
+		result += fmt.Sprintf("\n... 已截断（共 %d 字节，仅显示前 %d 字节）\n", len(buf), maxGoroutineDumpBytes)
+	} else {
+		result += string(buf)
+	}
+
+	return result
+}
+
+// formatSelfInfo 格式化自身资源占用
+func (si *SelfInfoTool) formatSelfInfo(info selfInfo) string {
+	var result string
+
+	result += "🔍 服务器自身资源\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result += fmt.Sprintf("PID: %d\n", info.PID)
+	result += fmt.Sprintf("RSS: %s\n", formatBytes(info.RSSBytes))
+	result += fmt.Sprintf("VSZ: %s\n", formatBytes(info.VMSBytes))
+	result += fmt.Sprintf("CPU 使用率: %.2f%%（启动以来平均）\n", info.CPUPercent)
+	result += fmt.Sprintf("Goroutine: %d\n", info.Goroutines)
+	result += fmt.Sprintf("Go 堆: 已分配 %s, 向系统申请 %s, GC %d 次\n",
+		formatBytes(info.HeapAlloc), formatBytes(info.Sys), info.NumGC)
+	result += fmt.Sprintf("运行时间: %s（启动于 %s）\n",
+		formatDurationWords(time.Duration(info.UptimeSeconds*float64(time.Second))),
+		formatTimestamp(info.StartTime))
+	if info.OpenFDs != nil {
+		result += fmt.Sprintf("打开的文件描述符: %d\n", *info.OpenFDs)
+	}
+	if info.DataDir != "" {
+		if info.DataDirBytes != nil {
+			result += fmt.Sprintf("数据目录: %s (%s)\n", info.DataDir, formatBytes(*info.DataDirBytes))
+		} else {
+			result += fmt.Sprintf("数据目录: %s\n", info.DataDir)
+		}
+	}
+
+	result += "\n🏷️ 构建信息\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result += fmt.Sprintf("版本: %s\n", info.Build.Version)
+	result += fmt.Sprintf("Go 版本: %s\n", info.Build.GoVersion)
+	if info.Build.Revision != "" {
+		revision := info.Build.Revision
+		if info.Build.Dirty {
+			revision += " (已修改)"
+		}
+		result += fmt.Sprintf("VCS 修订: %s\n", revision)
+	}
+	if info.Build.BuildDate != "" {
+		result += fmt.Sprintf("提交时间: %s\n", info.Build.BuildDate)
+	}
+
+	result += fmt.Sprintf("\n🧩 平台支持（%s，未列出的工具完整支持）\n", info.Platform)
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	for _, support := range info.Support {
+		line := fmt.Sprintf("  %s %-16s %s", supportIcon(support.Support), support.Tool, support.Support)
+		if support.Note != "" {
+			line += "：" + support.Note
+		}
+		result += line + "\n"
+	}
+
+	if len(info.Notes) > 0 {
+		result += "\n📝 备注:\n"
+		for _, note := range info.Notes {
+			result += fmt.Sprintf("  %s\n", note)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"mcp-example/internal/storage"
+)
+
+func TestSelfInfoGoroutineDump(t *testing.T) {
+	// 未使用 --enable-admin-tools 时拒绝
+	var toolErr *Error
+	if _, err := NewSelfInfoTool(storage.NewMemoryStorage(), false).Execute(context.Background(), map[string]interface{}{"action": "goroutine_dump"}); !errors.As(err, &toolErr) || toolErr.Code != ErrBadArgument || !strings.Contains(toolErr.Message, "--enable-admin-tools") {
+		t.Fatalf("goroutine_dump without admin tools = %v, want ErrBadArgument", err)
+	}
+
+	text, err := NewSelfInfoTool(storage.NewMemoryStorage(), true).Execute(context.Background(), map[string]interface{}{"action": "goroutine_dump"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(text, "🧵 goroutine 堆栈（共 ") || !strings.Contains(text, "TestSelfInfoGoroutineDump") || strings.Contains(text, "已截断") {
+		t.Errorf("goroutine_dump =\n%s", text)
+	}
+}
+
+func TestGoroutineDumpTruncated(t *testing.T) {
+	// 足够多的 goroutine 使堆栈超过返回上限
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 1000; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-release
+		}()
+	}
+	defer wg.Wait()
+	defer close(release)
+
+	text := goroutineDump()
+	if !strings.Contains(text, "... 已截断（共 ") || !strings.HasSuffix(text, "仅显示前 65536 字节）\n") {
+		t.Fatalf("goroutine_dump is not truncated:\n%s", text[len(text)-200:])
+	}
+	if len(text) > maxGoroutineDumpBytes+1024 {
+		t.Errorf("goroutine_dump returned %d bytes", len(text))
+	}
+}
@@ -0,0 +1,296 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"mcp-example/internal/types"
+	"mcp-example/internal/version"
+)
+
+// CollectorStatsFunc 获取后台采集器的运行统计，未启用后台采集时返回 nil
+type CollectorStatsFunc func() *types.CollectorStats
+
+// minCollectorTicks 后台采集的周期（完成的采样加跳过的周期）达到该数量后才检查跳过的比例
+const minCollectorTicks = 10
+
+// ServerStatsTool 服务器自身运行统计工具
+type ServerStatsTool struct {
+	cache       types.CacheStatsProvider
+	storage     types.DataStorage
+	warmup      *Warmup
+	recentCalls func() []types.ToolCallRecord
+	toolStats   func() []types.ToolCallStats
+	session     func(ctx context.Context) (types.SessionStats, bool)
+	cpuBaseline func() int
+	incidents   *IncidentLog
+	watches     func() []types.WatchInfo
+	collector   CollectorStatsFunc
+	startTime   time.Time
+}
+
+// NewServerStatsTool 创建新的服务器统计工具，warmup 为 nil 表示未启用启动预取，
+// recentCalls 返回最近的工具调用记录（最新的在前），toolStats 返回各工具的累计调用统计，
+// session 返回 ctx 中的请求所属连接的会话统计（没有会话时返回 false），cpuBaseline 返回 top_processes 保存 CPU 时间基线的进程数，为 nil 时不显示，
+// incidents 为 nil 时不显示未解决的告警事件数，watches 返回运行中的监视，为 nil 时不显示，
+// collector 返回后台采集器的运行统计，为 nil 时不显示
+func NewServerStatsTool(cache types.CacheStatsProvider, storage types.DataStorage, warmup *Warmup, recentCalls func() []types.ToolCallRecord, toolStats func() []types.ToolCallStats, session func(ctx context.Context) (types.SessionStats, bool), cpuBaseline func() int, incidents *IncidentLog, watches func() []types.WatchInfo, collector CollectorStatsFunc) *ServerStatsTool {
+	return &ServerStatsTool{
+		cache:       cache,
+		storage:     storage,
+		warmup:      warmup,
+		recentCalls: recentCalls,
+		toolStats:   toolStats,
+		session:     session,
+		cpuBaseline: cpuBaseline,
+		incidents:   incidents,
+		watches:     watches,
+		collector:   collector,
+		startTime:   time.Now(),
+	}
+}
+
+// GetName 获取工具名称
+func (ss *ServerStatsTool) GetName() string {
+	return "server_stats"
+}
+
+// GetDescription 获取工具描述
+func (ss *ServerStatsTool) GetDescription() string {
+	return "获取 MCP 服务器自身的运行统计（缓存、存储、启动预取）"
+}
+
+// GetAnnotations 获取工具注解
+func (ss *ServerStatsTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("服务器统计")
+}
+
+// GetInputSchema 获取输入模式
+func (ss *ServerStatsTool) GetInputSchema() types.InputSchema {
+	return argsSchema(noArgs{})
+}
+
+// Examples 获取调用示例
+func (ss *ServerStatsTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "运行时间、缓存命中率、存储后端和启动预取情况",
+			Arguments:   map[string]interface{}{},
+		},
+	}
+}
+
+// Execute 执行服务器统计
+func (ss *ServerStatsTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var result string
+
+	result += "📈 服务器统计\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result += fmt.Sprintf("版本: %s\n", version.String(version.Get()))
+	result += fmt.Sprintf("运行时间: %s\n", formatDurationWords(time.Since(ss.startTime)))
+
+	cacheStats := ss.cache.Stats()
+	result += fmt.Sprintf("缓存: %d 项, 命中 %d 次, 未命中 %d 次, 失败缓存命中 %d 次\n",
+		cacheStats.Size, cacheStats.Hits, cacheStats.Misses, cacheStats.NegativeHits)
+	if ss.cpuBaseline != nil {
+		result += fmt.Sprintf("进程 CPU 基线: %d 个进程（上限 %d）\n", ss.cpuBaseline(), maxCPUBaselines)
+	}
+
+	if ss.incidents != nil {
+		if counts, err := ss.incidents.Counts(); err == nil {
+			result += fmt.Sprintf("告警事件: %d 个未确认, %d 个已确认\n", counts.Open, counts.Acknowledged)
+		}
+	}
+
+	if provider, ok := ss.storage.(types.StorageStatsProvider); ok {
+		if storageStats, err := provider.Stats(); err == nil {
+			result += fmt.Sprintf("存储: %s, %d 个键", storageStats.Backend, storageStats.KeyCount)
+			if storageStats.DataDir != "" {
+				result += fmt.Sprintf(", %s", formatBytes(storageStats.TotalBytes))
+			}
+			result += "\n"
+		}
+	}
+
+	if ss.session != nil {
+		if session, ok := ss.session(ctx); ok {
+			result += "\n🤝 客户端\n"
+			result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+			result += formatClient(session)
+		}
+	}
+
+	if ss.watches != nil {
+		result += "\n👀 监视\n"
+		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		result += formatWatches(ss.watches())
+	}
+
+	if ss.collector != nil {
+		result += "\n📥 后台采集\n"
+		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		result += formatCollectorStats(ss.collector())
+	}
+
+	result += "\n🔥 启动预取\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result += ss.formatWarmup()
+
+	if ss.toolStats != nil {
+		result += "\n⏱️ 工具调用统计\n"
+		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		result += formatToolStats(ss.toolStats())
+	}
+
+	if ss.recentCalls != nil {
+		result += "\n🔎 最近的工具调用\n"
+		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		result += formatRecentCalls(ss.recentCalls())
+	}
+
+	return result, nil
+}
+
+// formatWatches 格式化运行中的监视
+func formatWatches(watches []types.WatchInfo) string {
+	if len(watches) == 0 {
+		return "没有运行中的监视\n"
+	}
+
+	var result string
+	for _, watch := range watches {
+		result += fmt.Sprintf("• %s → 每 %s 调用 %s %s，已执行 %d 次（失败 %d 次），到期: %s\n",
+			watch.ID, watch.Interval, watch.Tool, formatPresetArguments(watch.Arguments), watch.Runs, watch.Errors,
+			watch.ExpiresAt.Format("2006-01-02 15:04:05"))
+	}
+	return result
+}
+
+// formatCollectorStats 格式化后台采集器的采样次数、跳过的周期和采样耗时
+func formatCollectorStats(stats *types.CollectorStats) string {
+	if stats == nil {
+		return "未启用 (--collect-interval)\n"
+	}
+
+	var result string
+	interval := time.Duration(stats.IntervalSeconds * float64(time.Second))
+	result += fmt.Sprintf("间隔: %s, 已采样 %d 次（失败 %d 次）", durationText(interval), stats.Runs, stats.Failures)
+	if stats.LastRun != nil {
+		result += fmt.Sprintf(", 最近: %s", stats.LastRun.Format("15:04:05"))
+	}
+	result += "\n"
+	result += fmt.Sprintf("跳过的周期: %d (%.1f%%), 耗时超过间隔的采样: %d 次\n", stats.SkippedTicks, stats.SkipPercent, stats.OverlongRuns)
+	if stats.Runs > 0 {
+		result += fmt.Sprintf("采样耗时: p50 %s, p90 %s, p99 %s, 最长 %s\n",
+			formatMilliseconds(stats.DurationP50Ms), formatMilliseconds(stats.DurationP90Ms),
+			formatMilliseconds(stats.DurationP99Ms), formatMilliseconds(stats.DurationMaxMs))
+	}
+	return result
+}
+
+// formatMilliseconds 以毫秒计的时长，不足 1 毫秒时保留到微秒，否则保留到毫秒
+func formatMilliseconds(ms float64) string {
+	d := time.Duration(ms * float64(time.Millisecond))
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond).String()
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+// formatClient 格式化当前连接的客户端名称、版本、协议版本和启用的功能
+func formatClient(session types.SessionStats) string {
+	if session.ClientName == "" {
+		return "客户端未提供 clientInfo\n"
+	}
+
+	var result string
+	result += fmt.Sprintf("名称: %s %s\n", session.ClientName, session.ClientVersion)
+	result += fmt.Sprintf("协议版本: %s\n", session.ProtocolVersion)
+	if len(session.ClientFeatures) > 0 {
+		result += fmt.Sprintf("功能: %s\n", strings.Join(session.ClientFeatures, ", "))
+	} else {
+		result += "功能: 无\n"
+	}
+	return result
+}
+
+// formatRecentCalls 格式化最近的工具调用记录，可按追踪 ID 在日志中查找对应的请求
+func formatRecentCalls(records []types.ToolCallRecord) string {
+	if len(records) == 0 {
+		return "暂无调用记录\n"
+	}
+
+	var result string
+	result += fmt.Sprintf("%-10s %-14s %-22s %-10s %s\n", "时间", "追踪 ID", "工具", "耗时", "结果")
+	for _, record := range records {
+		outcome := record.Outcome
+		if record.ErrorCode != "" {
+			outcome += " (" + record.ErrorCode + ")"
+		}
+		result += fmt.Sprintf("%-10s %-14s %-22s %-10s %s\n",
+			record.StartedAt.Format("15:04:05"),
+			record.TraceID,
+			record.Tool,
+			fmt.Sprintf("%dms", record.DurationMs),
+			outcome,
+		)
+	}
+	return result
+}
+
+// formatToolStats 格式化各工具的调用次数、错误数、被限流的次数和处理耗时（平均/最长）
+func formatToolStats(stats []types.ToolCallStats) string {
+	if len(stats) == 0 {
+		return "暂无调用记录\n"
+	}
+
+	var result string
+	result += fmt.Sprintf("%-22s %-8s %-8s %-8s %-10s %s\n", "工具", "调用", "错误", "限流", "平均耗时", "最长耗时")
+	for _, toolStats := range stats {
+		// 只有被限流的调用时没有耗时
+		average := "-"
+		if toolStats.Calls > 0 {
+			average = fmt.Sprintf("%dms", toolStats.TotalMs/int64(toolStats.Calls))
+		}
+		result += fmt.Sprintf("%-22s %-8d %-8d %-8d %-10s %s\n",
+			toolStats.Tool,
+			toolStats.Calls,
+			toolStats.Errors,
+			toolStats.RateLimited,
+			average,
+			fmt.Sprintf("%dms", toolStats.MaxMs),
+		)
+	}
+	return result
+}
+
+// formatWarmup 格式化预取状态
+func (ss *ServerStatsTool) formatWarmup() string {
+	if ss.warmup == nil {
+		return "已禁用 (--no-prefetch)\n"
+	}
+
+	status := ss.warmup.Status()
+	if !status.Started {
+		return "尚未开始\n"
+	}
+
+	var result string
+	if status.Running {
+		result += "进行中\n"
+	} else {
+		result += fmt.Sprintf("已完成，总耗时 %s\n", status.Duration.Round(time.Millisecond))
+	}
+
+	for _, item := range status.Results {
+		if item.Error != "" {
+			result += fmt.Sprintf("  ❌ %-16s %8s  %s\n", item.Tool, item.Duration.Round(time.Microsecond), item.Error)
+		} else {
+			result += fmt.Sprintf("  ✅ %-16s %8s\n", item.Tool, item.Duration.Round(time.Microsecond))
+		}
+	}
+
+	return result
+}
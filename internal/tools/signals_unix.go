@@ -0,0 +1,15 @@
+//go:build !windows
+
+package tools
+
+import "syscall"
+
+// supportedSignals process_signal 支持的信号
+var supportedSignals = map[string]syscall.Signal{
+	"TERM": syscall.SIGTERM,
+	"KILL": syscall.SIGKILL,
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package tools
+
+import "syscall"
+
+// supportedSignals process_signal 支持的信号（Windows 不支持 USR1/USR2）
+var supportedSignals = map[string]syscall.Signal{
+	"TERM": syscall.SIGTERM,
+	"KILL": syscall.SIGKILL,
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+}
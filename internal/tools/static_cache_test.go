@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+)
+
+// infoCountingCPUProvider 记录型号查询次数的假 CPU 来源，每次采样的总使用率递增 10
+type infoCountingCPUProvider struct {
+	fakeCPUProvider
+	infoCalls int
+}
+
+func (ic *infoCountingCPUProvider) Info(ctx context.Context) ([]CPUInfoStat, error) {
+	ic.infoCalls++
+	return ic.fakeCPUProvider.Info(ctx)
+}
+
+func (ic *infoCountingCPUProvider) Percent(ctx context.Context, interval time.Duration, perCPU bool) ([]float64, error) {
+	if !perCPU {
+		ic.total += 10
+	}
+	return ic.fakeCPUProvider.Percent(ctx, interval, perCPU)
+}
+
+// partitionCountingDiskProvider 记录分区枚举次数的假磁盘来源，每次查询的已用空间递增 1GB。
+// 各分区的使用量并发查询，mu 保护 usage
+type partitionCountingDiskProvider struct {
+	fakeDiskProvider
+	partitionCalls int
+	mu             sync.Mutex
+}
+
+func (pc *partitionCountingDiskProvider) Partitions(ctx context.Context, all bool) ([]PartitionStat, error) {
+	pc.partitionCalls++
+	return pc.fakeDiskProvider.Partitions(ctx, all)
+}
+
+func (pc *partitionCountingDiskProvider) Usage(ctx context.Context, path string) (*UsageStat, error) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	usage := pc.usage[path]
+	usage.Used += gb
+	pc.usage[path] = usage
+	return pc.fakeDiskProvider.Usage(ctx, path)
+}
+
+// scopeTTLs 各作用域的缓存项剩余时间
+func scopeTTLs(cache *storage.MemoryCache) map[string]time.Duration {
+	ttls := make(map[string]time.Duration)
+	for _, entry := range cache.Entries() {
+		if cached, ok := entry.Value.(cacheEntry); ok {
+			ttls[cached.Scope] = entry.TTL
+		}
+	}
+	return ttls
+}
+
+// deleteScope 删除作用域下的缓存项，模拟缓存项过期
+func deleteScope(cache *storage.MemoryCache, scope string) {
+	for _, entry := range cache.Entries() {
+		if cached, ok := entry.Value.(cacheEntry); ok && cached.Scope == scope {
+			cache.Delete(entry.Key)
+		}
+	}
+}
+
+func TestCPUStaticCacheOutlivesUsage(t *testing.T) {
+	provider := &infoCountingCPUProvider{fakeCPUProvider: fakeCPUProvider{
+		infos:         []CPUInfoStat{{ModelName: "Fake CPU", PhysicalID: "0", CoreID: "0", Mhz: 2400}},
+		physicalCores: 1,
+		perCore:       []float64{10},
+	}}
+	current := providers
+	current.CPU = provider
+	t.Cleanup(SetProviders(current))
+	cache := storage.NewMemoryCache()
+	tool := NewCPUTool(cache, CacheOptions{}, NewOutputStyle(StylePlain, 0))
+	tool.platform = platformLinux
+
+	// 每次调用都重新采样使用率，型号只查询一次
+	var totals []float64
+	for i := 0; i < 3; i++ {
+		_, structured, err := tool.ExecuteStructured(context.Background(), map[string]interface{}{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		report := structured.(cpuReport)
+		if report.ModelName != "Fake CPU" || report.Frequency != 2.4 {
+			t.Fatalf("static info = %+v, want the fake model", report.CPUInfo)
+		}
+		totals = append(totals, report.Usage.Total)
+	}
+	if provider.infoCalls != 1 || totals[0] != 10 || totals[2] != 30 {
+		t.Fatalf("Info called %d times, totals %v; want the model cached and usage sampled on every call", provider.infoCalls, totals)
+	}
+
+	// 静态信息的缓存时间远长于使用率
+	ttls := scopeTTLs(cache)
+	if ttls["static_cpu"] <= 9*time.Minute || ttls["cpu_info"] > 30*time.Second {
+		t.Fatalf("cache TTLs = %v, want static_cpu ~%s and cpu_info <= 30s", ttls, staticCacheTTL)
+	}
+
+	// 使用率缓存过期后重新采样，静态信息仍然复用
+	deleteScope(cache, "cpu_info")
+	if total, _, err := cpuTotal(t, tool, map[string]interface{}{"cache": "auto"}); err != nil || total != 40 || provider.infoCalls != 1 {
+		t.Fatalf("after usage expiry: total %v, Info called %d times, %v", total, provider.infoCalls, err)
+	}
+	// 静态信息过期后才重新查询型号
+	deleteScope(cache, "static_cpu")
+	if _, _, err := cpuTotal(t, tool, map[string]interface{}{"cache": "auto"}); err != nil || provider.infoCalls != 2 {
+		t.Fatalf("after static expiry: Info called %d times, %v", provider.infoCalls, err)
+	}
+}
+
+func TestDiskStaticCacheOutlivesUsage(t *testing.T) {
+	provider := &partitionCountingDiskProvider{fakeDiskProvider: fakeDiskProvider{
+		partitions: []PartitionStat{
+			{Device: "/dev/sda1", Mountpoint: "/", Fstype: "ext4", Opts: []string{"rw"}},
+			{Device: "/dev/sdb1", Mountpoint: "/data", Fstype: "xfs", Opts: []string{"rw"}},
+		},
+		usage: map[string]UsageStat{
+			"/":     {Path: "/", Total: 100 * gb},
+			"/data": {Path: "/data", Total: 500 * gb},
+		},
+	}}
+	current := providers
+	current.Disk = provider
+	t.Cleanup(SetProviders(current))
+	cache := storage.NewMemoryCache()
+	tool := NewDiskTool(cache, CacheOptions{}, PartitionFilter{}, NewOutputStyle(StylePlain, 0), nil)
+	tool.platform = platformLinux
+	tool.mountsPath = writeTree(t, nil) + "/mounts"
+
+	used := func(args map[string]interface{}) map[string]uint64 {
+		t.Helper()
+		_, structured, err := tool.ExecuteStructured(context.Background(), args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		used := make(map[string]uint64)
+		for _, partition := range structured.(diskReport).Partitions {
+			used[partition.Mountpoint] = partition.Used
+		}
+		return used
+	}
+
+	// 每次调用都重新查询使用量，分区只枚举一次
+	first := used(map[string]interface{}{})
+	second := used(map[string]interface{}{})
+	if provider.partitionCalls != 1 || first["/data"] != gb || second["/data"] != 2*gb || len(second) != 2 {
+		t.Fatalf("Partitions called %d times, used %v then %v; want partitions cached and usage queried on every call", provider.partitionCalls, first, second)
+	}
+	ttls := scopeTTLs(cache)
+	if ttls["static_disk_partitions"] <= 9*time.Minute || ttls["disk_info"] > 30*time.Second {
+		t.Fatalf("cache TTLs = %v, want static_disk_partitions ~%s and disk_info <= 30s", ttls, staticCacheTTL)
+	}
+
+	// show_all 的分区列表单独缓存
+	used(map[string]interface{}{"show_all": true})
+	used(map[string]interface{}{"show_all": true})
+	if provider.partitionCalls != 2 {
+		t.Errorf("Partitions called %d times, want one more enumeration for show_all", provider.partitionCalls)
+	}
+
+	// 分区列表过期后重新枚举
+	deleteScope(cache, "static_disk_partitions")
+	if used(map[string]interface{}{}); provider.partitionCalls != 3 {
+		t.Errorf("Partitions called %d times after the partition list expired", provider.partitionCalls)
+	}
+}
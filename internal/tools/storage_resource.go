@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// StorageURI 服务器存储统计的资源 URI
+const StorageURI = "monitor://server/storage"
+
+// StorageStatsFunc 获取存储统计
+type StorageStatsFunc func() (types.StorageStats, error)
+
+// StorageResource 渲染服务器自身的存储统计：数据目录的占用和所在分区的剩余空间
+type StorageResource struct {
+	stats StorageStatsFunc
+	now   func() time.Time
+}
+
+// NewStorageResource 创建新的存储统计资源
+func NewStorageResource(stats StorageStatsFunc) *StorageResource {
+	return &StorageResource{
+		stats: stats,
+		now:   time.Now,
+	}
+}
+
+// GetResource 获取资源描述
+func (sr *StorageResource) GetResource() types.Resource {
+	return types.Resource{
+		URI:         StorageURI,
+		Name:        "存储统计",
+		Description: "服务器数据目录的大小、文件数、最早和最近的快照时间以及所在分区的剩余空间",
+		MimeType:    "text/plain",
+	}
+}
+
+// Read 读取存储统计
+func (sr *StorageResource) Read(ctx context.Context) (string, error) {
+	stats, err := sr.stats()
+	if err != nil {
+		return "", wrapError("获取存储统计失败", err)
+	}
+	return formatStorageStats(stats, sr.now()), nil
+}
+
+// formatStorageStats 格式化存储统计，快照的时间间隔相对于 now 计算
+func formatStorageStats(stats types.StorageStats, now time.Time) string {
+	var result string
+
+	result += "🗄️ 存储统计\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result += fmt.Sprintf("后端: %s\n", stats.Backend)
+	result += fmt.Sprintf("键数量: %d\n", stats.KeyCount)
+
+	if stats.DataDir == "" {
+		result += "当前存储后端没有数据目录\n"
+		return result
+	}
+
+	result += fmt.Sprintf("数据目录: %s\n", stats.DataDir)
+	result += fmt.Sprintf("占用: %s，%d 个文件\n", formatBytes(stats.TotalBytes), stats.FileCount)
+	if stats.OldestSnapshot != nil && stats.NewestSnapshot != nil {
+		result += fmt.Sprintf("最早快照: %s（%s）\n", formatTimestamp(*stats.OldestSnapshot), formatAgo(now.Sub(*stats.OldestSnapshot)))
+		result += fmt.Sprintf("最近快照: %s（%s）\n", formatTimestamp(*stats.NewestSnapshot), formatAgo(now.Sub(*stats.NewestSnapshot)))
+	}
+	if partition := stats.Partition; partition != nil {
+		result += fmt.Sprintf("所在分区: 剩余 %s / %s（已使用 %.1f%%）\n", formatBytes(partition.FreeBytes), formatBytes(partition.TotalBytes), partition.UsedPercent)
+	}
+
+	return result
+}
@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+func TestFormatStorageStatsUsesNow(t *testing.T) {
+	oldest := fixedTime.Add(-3 * 24 * time.Hour)
+	newest := fixedTime.Add(-90 * time.Second)
+	stats := types.StorageStats{
+		Backend:        "json",
+		KeyCount:       12,
+		DataDir:        "/var/lib/system-mcp",
+		TotalBytes:     3 * 1024 * 1024,
+		FileCount:      14,
+		OldestSnapshot: &oldest,
+		NewestSnapshot: &newest,
+		Partition:      &types.StoragePartition{TotalBytes: 100 << 30, FreeBytes: 40 << 30, UsedPercent: 60},
+	}
+
+	output := formatStorageStats(stats, fixedTime)
+	for _, want := range []string{
+		"后端: json\n",
+		"键数量: 12\n",
+		"占用: 3.00 MB，14 个文件\n",
+		"最早快照: " + formatTimestamp(oldest) + "（3天前）\n",
+		"最近快照: " + formatTimestamp(newest) + "（1分钟前）\n",
+		"所在分区: 剩余 40.00 GB / 100.00 GB（已使用 60.0%）\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+	if again := formatStorageStats(stats, fixedTime); again != output {
+		t.Fatalf("formatStorageStats is not deterministic:\n%s\n---\n%s", output, again)
+	}
+}
+
+func TestFormatStorageStatsWithoutDataDir(t *testing.T) {
+	output := formatStorageStats(types.StorageStats{Backend: "memory", KeyCount: 3}, fixedTime)
+	if !strings.HasSuffix(output, "当前存储后端没有数据目录\n") || strings.Contains(output, "快照") {
+		t.Fatalf("unexpected output:\n%s", output)
+	}
+}
+
+func TestStorageResourceRead(t *testing.T) {
+	newest := fixedTime.Add(-2 * time.Hour)
+	resource := NewStorageResource(func() (types.StorageStats, error) {
+		return types.StorageStats{Backend: "json", DataDir: "/data", OldestSnapshot: &newest, NewestSnapshot: &newest}, nil
+	})
+	resource.now = func() time.Time { return fixedTime }
+
+	output, err := resource.Read(context.Background())
+	if err != nil || !strings.Contains(output, "（2小时前）") {
+		t.Fatalf("Read() = %q, %v; want the snapshot age relative to the injected clock", output, err)
+	}
+
+	failing := NewStorageResource(func() (types.StorageStats, error) {
+		return types.StorageStats{}, errors.New("permission denied")
+	})
+	if _, err := failing.Read(context.Background()); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("Read() error = %v, want the wrapped stats error", err)
+	}
+}
@@ -1,23 +1,28 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"mcp-example/internal/identity"
+	"mcp-example/internal/permissions"
 	"mcp-example/internal/types"
-
-	"github.com/shirou/gopsutil/v3/host"
 )
 
 // SystemTool 系统信息工具
 type SystemTool struct {
-	cache types.Cache
+	cache        types.Cache
+	cacheOptions CacheOptions
+	platform     string
 }
 
 // NewSystemTool 创建新的系统信息工具
-func NewSystemTool(cache types.Cache) *SystemTool {
+func NewSystemTool(cache types.Cache, cacheOptions CacheOptions) *SystemTool {
 	return &SystemTool{
-		cache: cache,
+		cache:        cache,
+		cacheOptions: cacheOptions,
+		platform:     hostPlatform,
 	}
 }
 
@@ -31,78 +36,160 @@ func (st *SystemTool) GetDescription() string {
 	return "获取系统综合概览信息"
 }
 
+// GetAnnotations 获取工具注解
+func (st *SystemTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("系统概览")
+}
+
+// systemArgs system_overview 的参数
+type systemArgs struct {
+	IncludeLoad bool `arg:"include_load,default=true" desc:"是否包含系统负载信息"`
+	fallbackArgs
+	cacheArgs
+}
+
 // GetInputSchema 获取输入模式
 func (st *SystemTool) GetInputSchema() types.InputSchema {
-	return types.InputSchema{
-		Type: "object",
-		Properties: map[string]types.Property{
-			"include_load": {
-				Type:        "string",
-				Description: "是否包含系统负载信息",
-				Enum:        []string{"true", "false"},
-				Default:     "true",
-			},
-			"use_cache": {
-				Type:        "string",
-				Description: "是否使用缓存数据",
-				Enum:        []string{"true", "false"},
-				Default:     "false",
-			},
+	return argsSchema(systemArgs{})
+}
+
+// Examples 获取调用示例
+func (st *SystemTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "主机、CPU、内存、磁盘的综合概览和系统负载",
+			Arguments:   map[string]interface{}{},
+		},
+		{
+			Description: "不包含系统负载的概览",
+			Arguments:   map[string]interface{}{"include_load": "false"},
 		},
 	}
 }
 
+// systemReport system_overview 的结构化结果
+type systemReport struct {
+	types.SystemInfo
+	Host *types.HostIdentity `json:"host,omitempty"`
+	// Fallback 实时采集失败时返回降级数据的说明
+	Fallback *fallbackInfo `json:"fallback,omitempty"`
+	collectionInfo
+}
+
 // Execute 执行系统信息获取
-func (st *SystemTool) Execute(args map[string]interface{}) (string, error) {
-	// 解析参数
-	includeLoadStr, _ := args["include_load"].(string)
-	includeLoad := includeLoadStr != "false" // 默认为 true
-
-	useCacheStr, _ := args["use_cache"].(string)
-	useCache := useCacheStr == "true"
-
-	// 检查缓存
-	cacheKey := fmt.Sprintf("system_overview_%t", includeLoad)
-	if useCache {
-		if cachedData, found := st.cache.Get(cacheKey); found {
-			if sysInfo, ok := cachedData.(types.SystemInfo); ok {
-				return st.formatSystemInfo(sysInfo, includeLoad), nil
-			}
-		}
+func (st *SystemTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	text, _, err := st.ExecuteStructured(ctx, args)
+	return text, err
+}
+
+// ExecuteStructured 执行系统信息获取，同时返回结构化的系统信息
+func (st *SystemTool) ExecuteStructured(ctx context.Context, args map[string]interface{}) (string, interface{}, error) {
+	var a systemArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", nil, err
 	}
 
-	// 获取系统信息
-	sysInfo, err := st.getSystemInfo(includeLoad)
+	// 获取系统信息（缓存60秒）
+	sysInfo, meta, err := withCache(ctx, st.cache, st.cacheOptions.forCall(args), st.GetName(), 60*time.Second, func(ctx context.Context) (types.SystemInfo, error) {
+		return st.getSystemInfo(ctx, a.IncludeLoad)
+	})
 	if err != nil {
-		return "", fmt.Errorf("获取系统信息失败: %v", err)
+		return "", nil, wrapError("获取系统信息失败", err)
 	}
 
-	// 缓存结果（缓存60秒）
-	st.cache.Set(cacheKey, sysInfo, 60*time.Second)
+	report := systemReport{SystemInfo: sysInfo, Host: identity.Get(), Fallback: newFallbackInfo(meta), collectionInfo: newCollectionInfo(meta)}
+	return cacheHeader(meta) + st.formatSystemInfo(sysInfo, a.IncludeLoad) + cacheNote(meta), report, nil
+}
+
+// hostStatic 主机名、系统版本、虚拟化环境等几乎不变的主机信息
+type hostStatic struct {
+	Hostname             string
+	OS                   string
+	Platform             string
+	KernelVersion        string
+	Architecture         string
+	VirtualizationSystem string
+	VirtualizationRole   string
+	ContainerRuntime     string
+}
+
+// getHostStatic 获取主机静态信息，虚拟化检测较慢，结果缓存 staticCacheTTL
+func (st *SystemTool) getHostStatic(ctx context.Context) (hostStatic, error) {
+	static, _, err := withCache(ctx, st.cache, CacheOptions{Mode: CacheModeAuto}, "static_host", staticCacheTTL, func(ctx context.Context) (hostStatic, error) {
+		hostInfo, err := providers.Host.Info(ctx)
+		if err != nil {
+			return hostStatic{}, fmt.Errorf("获取主机信息失败: %w", err)
+		}
+
+		return hostStatic{
+			Hostname:             hostInfo.Hostname,
+			OS:                   hostInfo.OS,
+			Platform:             hostInfo.Platform,
+			KernelVersion:        hostInfo.KernelVersion,
+			Architecture:         hostInfo.KernelArch,
+			VirtualizationSystem: hostInfo.VirtualizationSystem,
+			VirtualizationRole:   hostInfo.VirtualizationRole,
+			ContainerRuntime:     hostEnvironment.containerRuntime(),
+		}, nil
+	})
+	return static, err
+}
 
-	return st.formatSystemInfo(sysInfo, includeLoad), nil
+// Prefetch 预取主机静态信息
+func (st *SystemTool) Prefetch(ctx context.Context) error {
+	_, err := st.getHostStatic(ctx)
+	// 预热时顺带执行权限探测，避免首次调用工具时等待
+	permissions.Get()
+	return err
 }
 
 // getSystemInfo 获取系统信息
-func (st *SystemTool) getSystemInfo(includeLoad bool) (types.SystemInfo, error) {
+func (st *SystemTool) getSystemInfo(ctx context.Context, includeLoad bool) (types.SystemInfo, error) {
 	var sysInfo types.SystemInfo
 
-	// 获取主机信息
-	hostInfo, err := host.Info()
+	// 获取主机静态信息（长时间缓存）
+	static, err := st.getHostStatic(ctx)
+	if err != nil {
+		return sysInfo, err
+	}
+
+	// 运行时间和进程数每次重新获取
+	uptime, err := providers.Host.Uptime(ctx)
 	if err != nil {
-		return sysInfo, fmt.Errorf("获取主机信息失败: %v", err)
+		return sysInfo, fmt.Errorf("获取系统运行时间失败: %w", err)
+	}
+	bootTime, err := st.GetBootTime(ctx)
+	if err != nil {
+		return sysInfo, err
+	}
+	pids, err := providers.Process.Pids(ctx)
+	if err != nil {
+		return sysInfo, fmt.Errorf("获取进程数失败: %w", err)
 	}
 
 	// 填充系统信息
-	sysInfo.Hostname = hostInfo.Hostname
-	sysInfo.OS = hostInfo.OS
-	sysInfo.Platform = hostInfo.Platform
-	sysInfo.KernelVersion = hostInfo.KernelVersion
-	sysInfo.Architecture = hostInfo.KernelArch
-	sysInfo.Uptime = hostInfo.Uptime
-	sysInfo.ProcessCount = hostInfo.Procs
+	sysInfo.Hostname = static.Hostname
+	sysInfo.OS = static.OS
+	sysInfo.Platform = static.Platform
+	sysInfo.KernelVersion = static.KernelVersion
+	sysInfo.Architecture = static.Architecture
+	sysInfo.Uptime = uptime
+	sysInfo.BootTime = uint64(bootTime.Unix())
+	sysInfo.ProcessCount = uint64(len(pids))
+	sysInfo.VirtualizationSystem = static.VirtualizationSystem
+	sysInfo.VirtualizationRole = static.VirtualizationRole
+	sysInfo.ContainerRuntime = static.ContainerRuntime
 	sysInfo.LastUpdated = time.Now()
 
+	// 负载获取失败不影响其余信息，输出时提示不可用
+	if includeLoad && hasLoadAverage(st.platform) {
+		if avg, err := providers.Host.LoadAvg(ctx); err == nil {
+			sysInfo.Load = &types.LoadAverage{Load1: avg.Load1, Load5: avg.Load5, Load15: avg.Load15}
+		}
+	}
+
+	sysInfo.Permissions = permissions.Get()
+
 	return sysInfo, nil
 }
 
@@ -117,23 +204,35 @@ func (st *SystemTool) formatSystemInfo(sysInfo types.SystemInfo, includeLoad boo
 	result += fmt.Sprintf("平台: %s\n", sysInfo.Platform)
 	result += fmt.Sprintf("内核版本: %s\n", sysInfo.KernelVersion)
 	result += fmt.Sprintf("架构: %s\n", sysInfo.Architecture)
+	result += fmt.Sprintf("运行环境: %s\n", describeEnvironment(sysInfo.VirtualizationSystem, sysInfo.VirtualizationRole, sysInfo.ContainerRuntime))
 
-	// 格式化运行时间
 	uptime := time.Duration(sysInfo.Uptime) * time.Second
-	days := int(uptime.Hours()) / 24
-	hours := int(uptime.Hours()) % 24
-	minutes := int(uptime.Minutes()) % 60
-	result += fmt.Sprintf("运行时间: %d天 %d小时 %d分钟\n", days, hours, minutes)
+	result += fmt.Sprintf("运行时间: %s\n", formatDurationWords(uptime))
+	if sysInfo.BootTime > 0 {
+		bootTime := time.Unix(int64(sysInfo.BootTime), 0)
+		result += fmt.Sprintf("启动时间: %s（%s）\n", formatTimestamp(bootTime), formatAgo(sysInfo.LastUpdated.Sub(bootTime)))
+	}
 
 	result += fmt.Sprintf("进程数: %d\n", sysInfo.ProcessCount)
 
-	// 包含负载信息 (在某些系统上可能不可用)
+	if note := containerNote(sysInfo.ContainerRuntime); note != "" {
+		result += fmt.Sprintf("⚠️  %s\n", note)
+	}
+	if sysInfo.Permissions != nil {
+		result += fmt.Sprintf("🔐 权限: %s\n", permissions.Summary(*sysInfo.Permissions))
+	}
+
 	if includeLoad {
-		// 注意：LoadAvg 在某些系统上可能不可用，这里暂时注释掉
-		// 可以根据需要实现替代方案
 		result += "\n📊 系统负载\n"
 		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
-		result += "系统负载信息在此平台暂不可用\n"
+		switch {
+		case sysInfo.Load != nil:
+			result += fmt.Sprintf("1 分钟: %.2f  5 分钟: %.2f  15 分钟: %.2f\n", sysInfo.Load.Load1, sysInfo.Load.Load5, sysInfo.Load.Load15)
+		case !hasLoadAverage(st.platform):
+			result += "Windows 没有系统负载指标，请使用 cpu_info 查看 CPU 使用率\n"
+		default:
+			result += "系统负载信息暂不可用\n"
+		}
 	}
 
 	result += fmt.Sprintf("\n📅 更新时间: %s\n", sysInfo.LastUpdated.Format("2006-01-02 15:04:05"))
@@ -142,25 +241,25 @@ func (st *SystemTool) formatSystemInfo(sysInfo types.SystemInfo, includeLoad boo
 }
 
 // GetSystemData 获取系统数据（供其他组件使用）
-func (st *SystemTool) GetSystemData(includeLoad bool) (types.SystemInfo, error) {
-	return st.getSystemInfo(includeLoad)
+func (st *SystemTool) GetSystemData(ctx context.Context, includeLoad bool) (types.SystemInfo, error) {
+	return st.getSystemInfo(ctx, includeLoad)
 }
 
 // GetBootTime 获取系统启动时间
-func (st *SystemTool) GetBootTime() (time.Time, error) {
-	bootTime, err := host.BootTime()
+func (st *SystemTool) GetBootTime(ctx context.Context) (time.Time, error) {
+	bootTime, err := providers.Host.BootTime(ctx)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("获取系统启动时间失败: %v", err)
+		return time.Time{}, fmt.Errorf("获取系统启动时间失败: %w", err)
 	}
 
 	return time.Unix(int64(bootTime), 0), nil
 }
 
 // GetSystemUsers 获取当前登录的用户
-func (st *SystemTool) GetSystemUsers() ([]map[string]interface{}, error) {
-	users, err := host.Users()
+func (st *SystemTool) GetSystemUsers(ctx context.Context) ([]map[string]interface{}, error) {
+	users, err := providers.Host.Users(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("获取系统用户失败: %v", err)
+		return nil, fmt.Errorf("获取系统用户失败: %w", err)
 	}
 
 	var result []map[string]interface{}
@@ -178,10 +277,13 @@ func (st *SystemTool) GetSystemUsers() ([]map[string]interface{}, error) {
 }
 
 // GetSystemTemperature 获取系统温度信息
-func (st *SystemTool) GetSystemTemperature() ([]map[string]interface{}, error) {
-	temps, err := host.SensorsTemperatures()
+func (st *SystemTool) GetSystemTemperature(ctx context.Context) ([]map[string]interface{}, error) {
+	temps, err := providers.Host.Temperatures(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("获取系统温度失败: %v", err)
+		if note := containerNote(hostEnvironment.containerRuntime()); note != "" {
+			return nil, fmt.Errorf("获取系统温度失败: %w（%s）", err, note)
+		}
+		return nil, fmt.Errorf("获取系统温度失败: %w", err)
 	}
 
 	var result []map[string]interface{}
@@ -200,6 +302,7 @@ func (st *SystemTool) GetSystemTemperature() ([]map[string]interface{}, error) {
 
 // GetComprehensiveOverview 获取综合系统概览（包含所有监控数据）
 func (st *SystemTool) GetComprehensiveOverview(
+	ctx context.Context,
 	cpuTool *CPUTool,
 	memTool *MemoryTool,
 	diskTool *DiskTool,
@@ -208,15 +311,16 @@ func (st *SystemTool) GetComprehensiveOverview(
 	var monitorData types.MonitorData
 
 	// 获取系统信息
-	sysInfo, err := st.getSystemInfo(true)
+	sysInfo, err := st.getSystemInfo(ctx, true)
 	if err != nil {
-		return monitorData, fmt.Errorf("获取系统信息失败: %v", err)
+		return monitorData, fmt.Errorf("获取系统信息失败: %w", err)
 	}
 	monitorData.System = sysInfo
+	monitorData.BootTime = sysInfo.BootTime
 
 	// 获取 CPU 信息
 	if cpuTool != nil {
-		cpuInfo, err := cpuTool.GetCPUData(time.Second)
+		cpuInfo, err := cpuTool.GetCPUData(ctx, time.Second)
 		if err == nil {
 			monitorData.CPU = cpuInfo
 		}
@@ -224,7 +328,7 @@ func (st *SystemTool) GetComprehensiveOverview(
 
 	// 获取内存信息
 	if memTool != nil {
-		memInfo, err := memTool.GetMemoryData()
+		memInfo, err := memTool.GetMemoryData(ctx)
 		if err == nil {
 			monitorData.Memory = memInfo
 		}
@@ -232,7 +336,7 @@ func (st *SystemTool) GetComprehensiveOverview(
 
 	// 获取磁盘信息
 	if diskTool != nil {
-		diskInfo, err := diskTool.GetDiskData(false)
+		diskInfo, err := diskTool.GetDiskData(ctx, false)
 		if err == nil {
 			monitorData.Disk = diskInfo
 		}
@@ -240,12 +344,13 @@ func (st *SystemTool) GetComprehensiveOverview(
 
 	// 获取网络信息
 	if netTool != nil {
-		netInfo, err := netTool.GetNetworkData(false, "")
+		netInfo, err := netTool.GetNetworkData(ctx, false, "")
 		if err == nil {
 			monitorData.Network = netInfo
 		}
 	}
 
+	monitorData.Host = identity.Get()
 	monitorData.Timestamp = time.Now()
 
 	return monitorData, nil
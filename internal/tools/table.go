@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"encoding/csv"
+	"strconv"
+	"strings"
+)
+
+// 表格类工具（top_processes、disk_info、network_stats）除 text 外支持的输出格式
+const (
+	// formatCSV 带表头的 CSV，数值为原始值（字节数不换算单位）
+	formatCSV = "csv"
+	// formatMarkdown Markdown 管道表格，数值与文本输出一样便于阅读
+	formatMarkdown = "markdown"
+)
+
+// tableFormatArgs 支持表格输出的工具共用的 format 参数
+type tableFormatArgs struct {
+	Format string `arg:"format,enum=text|json|csv|markdown,default=text" desc:"输出格式: text、json、csv（原始数值，适合电子表格）或 markdown（表格）"`
+	fieldsArgs
+}
+
+// isTableFormat format 是否为表格输出格式（csv 或 markdown）
+func isTableFormat(format string) bool {
+	return format == formatCSV || format == formatMarkdown
+}
+
+// tableColumn 表格的列：key 为 CSV 表头（与 JSON 字段名一致），title 为 Markdown 表头（与文本输出一致）
+type tableColumn struct {
+	key   string
+	title string
+}
+
+// tableCell 表格的单元格：raw 为 CSV 中的原始值，text 为 Markdown 中便于阅读的值
+type tableCell struct {
+	raw  string
+	text string
+}
+
+// table 工具结果的表格形式，由 CSV 和 Markdown 两种输出共用
+type table struct {
+	columns []tableColumn
+	rows    [][]tableCell
+}
+
+// addRow 追加一行，单元格按列的顺序
+func (t *table) addRow(cells ...tableCell) {
+	t.rows = append(t.rows, cells)
+}
+
+// textCell 原始值和显示值相同的单元格
+func textCell(text string) tableCell {
+	return tableCell{raw: text, text: text}
+}
+
+// intCell 整数单元格
+func intCell(n int64) tableCell {
+	return textCell(strconv.FormatInt(n, 10))
+}
+
+// floatCell 小数单元格，CSV 中为完整精度，Markdown 中保留两位小数
+func floatCell(value float64) tableCell {
+	return tableCell{raw: strconv.FormatFloat(value, 'f', -1, 64), text: strconv.FormatFloat(value, 'f', 2, 64)}
+}
+
+// bytesCell 字节数单元格，CSV 中为字节数，Markdown 中换算为 KB、MB 等单位
+func bytesCell(bytes uint64) tableCell {
+	return tableCell{raw: strconv.FormatUint(bytes, 10), text: formatBytes(bytes)}
+}
+
+// countCell 计数单元格，Markdown 中带千位分隔符
+func countCell(n uint64) tableCell {
+	return tableCell{raw: strconv.FormatUint(n, 10), text: formatCount(n)}
+}
+
+// rateCell 速率单元格，CSV 中为每秒字节数（取整），Markdown 中如 1.50 MB/s
+func rateCell(bytesPerSecond float64) tableCell {
+	return tableCell{raw: strconv.FormatFloat(bytesPerSecond, 'f', 0, 64), text: formatBytes(uint64(bytesPerSecond)) + "/s"}
+}
+
+// renderTable 按 format（csv 或 markdown）输出表格
+func renderTable(t table, format string) (string, error) {
+	if format == formatCSV {
+		return t.renderCSV()
+	}
+	return t.renderMarkdown(), nil
+}
+
+// renderCSV 以 encoding/csv 输出表头和各行的原始值
+func (t table) renderCSV() (string, error) {
+	var builder strings.Builder
+	writer := csv.NewWriter(&builder)
+
+	header := make([]string, len(t.columns))
+	for i, column := range t.columns {
+		header[i] = column.key
+	}
+	if err := writer.Write(header); err != nil {
+		return "", wrapError("生成 CSV 失败", err)
+	}
+	for _, row := range t.rows {
+		record := make([]string, len(row))
+		for i, cell := range row {
+			record[i] = cell.raw
+		}
+		if err := writer.Write(record); err != nil {
+			return "", wrapError("生成 CSV 失败", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", wrapError("生成 CSV 失败", err)
+	}
+	return builder.String(), nil
+}
+
+// renderMarkdown 输出 Markdown 管道表格，单元格中的竖线和换行经过转义，不会破坏表格结构
+func (t table) renderMarkdown() string {
+	var builder strings.Builder
+
+	writeRow := func(cells []string) {
+		builder.WriteString("|")
+		for _, cell := range cells {
+			builder.WriteString(" ")
+			builder.WriteString(escapeMarkdownCell(cell))
+			builder.WriteString(" |")
+		}
+		builder.WriteString("\n")
+	}
+
+	titles := make([]string, len(t.columns))
+	separators := make([]string, len(t.columns))
+	for i, column := range t.columns {
+		titles[i] = column.title
+		separators[i] = "---"
+	}
+	writeRow(titles)
+	builder.WriteString("|" + strings.Join(separators, "|") + "|\n")
+
+	for _, row := range t.rows {
+		texts := make([]string, len(row))
+		for i, cell := range row {
+			texts[i] = cell.text
+		}
+		writeRow(texts)
+	}
+	return builder.String()
+}
+
+// markdownEscaper 转义 Markdown 表格单元格中的反斜杠和竖线，换行替换为空格
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r\n", " ", "\n", " ", "\r", " ")
+
+// escapeMarkdownCell 转义 Markdown 表格单元格
+func escapeMarkdownCell(text string) string {
+	return markdownEscaper.Replace(text)
+}
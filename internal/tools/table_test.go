@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"encoding/csv"
+	"regexp"
+	"strings"
+	"testing"
+
+	"mcp-example/internal/fixtures"
+	"mcp-example/internal/types"
+)
+
+// awkwardName 同时包含 CSV 和 Markdown 需要转义的字符：逗号、双引号、竖线、反斜杠和换行
+const awkwardName = `report "daily", a|b \ c` + "\nnext"
+
+// tableFixtures 各工具的表格，每个表格的第一行使用 awkwardName
+func tableFixtures() map[string]table {
+	processTool, _, _ := newOutputTools()
+
+	processes := fixtures.ProcessList()
+	processes.Processes[0].Name = awkwardName
+	groups := fixtures.ProcessGroups()
+	groups.Groups[0].Key = awkwardName
+	partitions := fixtures.DiskInfo().Partitions
+	partitions[0].Mountpoint = awkwardName
+	interfaces := fixtures.NetworkInfo().Interfaces
+	interfaces[0].Name = awkwardName
+
+	return map[string]table{
+		"top_processes":        processTool.processTable(processes),
+		"top_processes_groups": processGroupTable(groups),
+		"disk_info":            partitionTable(partitions),
+		"network_stats":        interfaceTable(interfaces, nil),
+	}
+}
+
+func TestGoldenTables(t *testing.T) {
+	for name, rows := range tableFixtures() {
+		csvText, err := renderTable(rows, formatCSV)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertGolden(t, name+"_csv", csvText)
+
+		markdown, err := renderTable(rows, formatMarkdown)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertGolden(t, name+"_markdown", markdown)
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	for name, rows := range tableFixtures() {
+		text, err := rows.renderCSV()
+		if err != nil {
+			t.Fatal(err)
+		}
+		records, err := csv.NewReader(strings.NewReader(text)).ReadAll()
+		if err != nil {
+			t.Fatalf("%s: the CSV output does not parse: %v", name, err)
+		}
+		if len(records) != len(rows.rows)+1 {
+			t.Fatalf("%s: %d records, want the header and %d rows", name, len(records), len(rows.rows))
+		}
+		// 逗号、引号和换行原样保留在同一个字段中
+		if records[1][awkwardColumn(t, rows)] != awkwardName {
+			t.Errorf("%s: first field = %q, want %q", name, records[1][awkwardColumn(t, rows)], awkwardName)
+		}
+	}
+}
+
+// awkwardColumn 第一行中值为 awkwardName 的列：进程表为进程名，其余表格为第一列
+func awkwardColumn(t *testing.T, tb table) int {
+	t.Helper()
+	for i, cell := range tb.rows[0] {
+		if cell.raw == awkwardName {
+			return i
+		}
+	}
+	t.Fatal("no cell holds the awkward name")
+	return 0
+}
+
+// unescapedPipe 未被反斜杠转义的竖线（前面是偶数个反斜杠）
+var unescapedPipe = regexp.MustCompile(`(^|[^\\])(\\\\)*\|`)
+
+func TestMarkdownEscapesCells(t *testing.T) {
+	for name, rows := range tableFixtures() {
+		lines := strings.Split(strings.TrimSuffix(rows.renderMarkdown(), "\n"), "\n")
+		if len(lines) != len(rows.rows)+2 {
+			t.Fatalf("%s: %d lines, want the header, separator and %d rows on one line each", name, len(lines), len(rows.rows))
+		}
+		// 每行的单元格分隔符数量与表头相同，单元格中的竖线不会被当作分隔符
+		want := len(unescapedPipe.FindAllString(lines[0], -1))
+		for _, line := range lines[2:] {
+			if got := len(unescapedPipe.FindAllString(line, -1)); got != want {
+				t.Errorf("%s: row %q has %d separators, want %d", name, line, got, want)
+			}
+		}
+		if !strings.Contains(lines[2], `report "daily", a\|b \\ c next`) {
+			t.Errorf("%s: first row = %q, want the escaped name", name, lines[2])
+		}
+	}
+}
+
+func TestInterfaceTableRates(t *testing.T) {
+	interfaces := []types.NetworkInterface{{Name: "eth0"}, {Name: "eth1"}, {Name: "wlan0"}}
+	rates := map[string]interfaceRate{
+		"eth0": {SendRate: 1024, RecvRate: 2048},
+		"eth1": {CounterReset: true},
+	}
+	text, err := interfaceTable(interfaces, rates).renderCSV()
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(text)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header := strings.Join(records[0], ","); !strings.HasSuffix(header, ",send_rate,recv_rate") {
+		t.Fatalf("header = %s, want the rate columns", header)
+	}
+	// 没有速率（首次采样或计数器重置）时 CSV 中为空值，不会写入 0 被误读为空闲
+	for i, want := range [][2]string{{"1024", "2048"}, {"", ""}, {"", ""}} {
+		row := records[i+1]
+		if got := [2]string{row[len(row)-2], row[len(row)-1]}; got != want {
+			t.Errorf("%s rates = %q, want %q", row[0], got, want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ellipsis 截断文本时追加的省略号
+const ellipsis = "..."
+
+// wideRanges 在终端中占两列的字符范围（东亚宽字符、全角字符和常见的 emoji）
+var wideRanges = []struct{ lo, hi rune }{
+	{0x1100, 0x115F},   // 谚文字母
+	{0x2E80, 0x303E},   // CJK 部首、符号和标点
+	{0x3041, 0x33FF},   // 假名、注音、CJK 兼容字符
+	{0x3400, 0x4DBF},   // CJK 扩展 A
+	{0x4E00, 0x9FFF},   // CJK 统一汉字
+	{0xA000, 0xA4CF},   // 彝文
+	{0xAC00, 0xD7A3},   // 谚文音节
+	{0xF900, 0xFAFF},   // CJK 兼容汉字
+	{0xFE30, 0xFE4F},   // CJK 兼容形式
+	{0xFF00, 0xFF60},   // 全角字符
+	{0xFFE0, 0xFFE6},   // 全角符号
+	{0x1F300, 0x1F64F}, // 杂项符号、表情
+	{0x1F900, 0x1F9FF}, // 补充符号和象形文字
+	{0x20000, 0x3FFFD}, // CJK 扩展 B 及之后
+}
+
+// SanitizeText 将无效的 UTF-8 字节序列替换为 U+FFFD。进程名等来自系统的字符串不一定是合法的 UTF-8，
+// 放入调用结果前需经过此函数，否则部分客户端会拒绝整个响应
+func SanitizeText(text string) string {
+	return strings.ToValidUTF8(text, "\uFFFD")
+}
+
+// runeWidth 字符在终端中占的列数：组合字符和格式控制字符为 0，宽字符为 2，其余为 1
+func runeWidth(r rune) int {
+	if r == 0x200D || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	for _, wide := range wideRanges {
+		if r >= wide.lo && r <= wide.hi {
+			return 2
+		}
+	}
+	return 1
+}
+
+// displayWidth 字符串在终端中占的列数
+func displayWidth(text string) int {
+	width := 0
+	for _, r := range text {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// truncateWidth 将超过 width 列的文本截断并以 "..." 结尾，总宽度不超过 width。按字符截断，不拆分 UTF-8 字符；
+// 无效的字节序列先替换为 U+FFFD，否则之后再清理时一段字节变为一个字符，宽度随之改变
+func truncateWidth(text string, width int) string {
+	text = SanitizeText(text)
+	if displayWidth(text) <= width {
+		return text
+	}
+	limit := width - len(ellipsis)
+	used := 0
+	for i, r := range text {
+		w := runeWidth(r)
+		if used+w > limit {
+			return text[:i] + ellipsis
+		}
+		used += w
+	}
+	return text
+}
+
+// fitColumn 将文本截断到 width 列并以空格补足，用于对齐表格的列。fmt 的 %-25s 按字符数补齐，
+// 含宽字符的名称会使后面的列错位
+func fitColumn(text string, width int) string {
+	text = truncateWidth(text, width)
+	if padding := width - displayWidth(text); padding > 0 {
+		text += strings.Repeat(" ", padding)
+	}
+	return text
+}
@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"mcp-example/internal/fixtures"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	cases := []struct {
+		text  string
+		width int
+	}{
+		{"", 0},
+		{"nginx", 5},
+		{"数据库", 6},
+		{"ｎｇｉｎｘ", 10},
+		{"한국어", 6},
+		{"🔥x", 3},
+		{"e\u0301", 1},
+		{"👩\u200d💻", 4},
+		{"\uFFFD", 1},
+	}
+	for _, c := range cases {
+		if got := displayWidth(c.text); got != c.width {
+			t.Errorf("displayWidth(%q) = %d, want %d", c.text, got, c.width)
+		}
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	cases := []struct {
+		name  string
+		text  string
+		width int
+		want  string
+	}{
+		{"fits", "nginx", 10, "nginx"},
+		{"exact", "postgresql", 10, "postgresql"},
+		{"ascii", "postgresql-archiver", 10, "postgre..."},
+		{"cjk fits", "数据库", 6, "数据库"},
+		{"cjk", "数据库备份进程", 9, "数据库..."},
+		// 宽字符不能跨过截断位置，宁可少用一列
+		{"cjk short by one", "数据库备份进程", 10, "数据库..."},
+		{"mixed", "db备份job", 8, "db备..."},
+		{"emoji", "🔥🔥🔥🔥", 7, "🔥🔥..."},
+		// 组合字符跟随前面的字符，不单独留在截断位置之后
+		{"combining", "cafe\u0301-latte-grande", 7, "cafe\u0301..."},
+		{"invalid fits", "bad\xffname", 10, "bad\uFFFDname"},
+		// 连续的无效字节替换为一个 U+FFFD，按替换后的宽度截断
+		{"invalid run", "bad\xff\xfe\xfdname-longer", 10, "bad\uFFFDnam..."},
+		{"invalid in cut", "abcdef\xffghijk", 9, "abcdef..."},
+		{"truncated rune", "abc\xe6\x95", 10, "abc\uFFFD"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := truncateWidth(c.text, c.width)
+			if got != c.want {
+				t.Fatalf("truncateWidth(%q, %d) = %q, want %q", c.text, c.width, got, c.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Fatalf("truncateWidth(%q, %d) = %q is not valid UTF-8", c.text, c.width, got)
+			}
+			if width := displayWidth(got); width > c.width {
+				t.Fatalf("truncateWidth(%q, %d) is %d columns wide", c.text, c.width, width)
+			}
+		})
+	}
+}
+
+// TestTruncateWidthNeverSplitsRunes 对各种宽度截断多字节和含无效字节的文本，结果都是合法的 UTF-8，
+// 且去掉省略号后是（清理后）原文的前缀
+func TestTruncateWidthNeverSplitsRunes(t *testing.T) {
+	texts := []string{
+		"进程名称包含中文和English混合的很长的名字",
+		"🐘postgres🔥worker🚀",
+		"ｆｕｌｌｗｉｄｔｈ-ｎａｍｅ",
+		"e\u0301e\u0301e\u0301e\u0301e\u0301e\u0301e\u0301",
+		"\xff\xfe名字\xc3(bad)\xe6\x95\xb0\xe6\x8d",
+	}
+	for _, text := range texts {
+		sanitized := SanitizeText(text)
+		for width := len(ellipsis); width <= displayWidth(sanitized)+1; width++ {
+			got := truncateWidth(text, width)
+			if !utf8.ValidString(got) || displayWidth(got) > width {
+				t.Fatalf("truncateWidth(%q, %d) = %q (%d columns)", text, width, got, displayWidth(got))
+			}
+			if !strings.HasPrefix(sanitized, strings.TrimSuffix(got, ellipsis)) {
+				t.Fatalf("truncateWidth(%q, %d) = %q is not a prefix of the text", text, width, got)
+			}
+		}
+	}
+}
+
+func TestFitColumn(t *testing.T) {
+	for _, text := range []string{"nginx", "数据库", "数据库备份进程服务守护程序", "🔥hot", "bad\xff\xfe", "e\u0301"} {
+		got := fitColumn(text, 12)
+		if width := displayWidth(got); width != 12 {
+			t.Errorf("fitColumn(%q, 12) = %q is %d columns wide, want 12", text, got, width)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("fitColumn(%q, 12) = %q is not valid UTF-8", text, got)
+		}
+	}
+}
+
+// TestProcessListAlignsMultibyteNames 名称含宽字符或无效字节时，进程列表的后续列仍然对齐
+func TestProcessListAlignsMultibyteNames(t *testing.T) {
+	processTool, _, _ := newOutputTools()
+	processList := fixtures.ProcessList()
+	processList.Processes[0].Name = "数据库备份进程服务守护程序名称"
+	processList.Processes[1].Name = "🐘postgres🔥"
+	processList.Processes[2].Name = "bad\xff\xfe\xfdname"
+	processList.Processes[3].Name = "e\u0301e\u0301"
+
+	output := processTool.formatProcessList(processList, processQuery{SortBy: "memory", Limit: 5})
+	if !utf8.ValidString(output) {
+		t.Fatalf("output is not valid UTF-8:\n%q", output)
+	}
+	for _, proc := range processList.Processes {
+		var row string
+		for _, line := range strings.Split(output, "\n") {
+			if strings.HasPrefix(line, fmt.Sprintf("%-8d ", proc.PID)) {
+				row = line
+				break
+			}
+		}
+		if row == "" {
+			t.Fatalf("no row for PID %d:\n%s", proc.PID, output)
+		}
+		// PID 占 8 列，名称占 25 列，各有一个空格分隔
+		cpuColumn := strings.Index(row, fmt.Sprintf(" %.2f ", proc.CPUPercent)) + 1
+		if cpuColumn == 0 || displayWidth(row[:cpuColumn]) != 8+1+25+1 {
+			t.Errorf("CPU column of %q starts at display column %d, want 35", row, displayWidth(row[:cpuColumn]))
+		}
+	}
+}
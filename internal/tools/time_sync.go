@@ -0,0 +1,366 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/types"
+)
+
+// timeSyncStatus 时间同步状态，无法获取的字段为 nil
+type timeSyncStatus struct {
+	Source       string         `json:"source,omitempty"`
+	NTPEnabled   *bool          `json:"ntp_enabled,omitempty"`
+	Synchronized *bool          `json:"synchronized,omitempty"`
+	Offset       *time.Duration `json:"offset,omitempty"`
+	Reference    string         `json:"reference,omitempty"`
+	Timezone     string         `json:"timezone,omitempty"`
+}
+
+// timeSyncSource 时间同步状态来源
+type timeSyncSource interface {
+	Name() string
+	Query(ctx context.Context) (timeSyncStatus, error)
+}
+
+// timedatectlSource 通过 `timedatectl show` 获取 systemd-timesyncd 状态
+type timedatectlSource struct {
+	run commandRunner
+}
+
+// Name 来源名称
+func (s timedatectlSource) Name() string {
+	return "timedatectl"
+}
+
+// Query 查询同步状态
+func (s timedatectlSource) Query(ctx context.Context) (timeSyncStatus, error) {
+	output, err := s.run(ctx, "timedatectl", "show")
+	if err != nil {
+		return timeSyncStatus{}, err
+	}
+	return parseTimedatectl(output), nil
+}
+
+// parseTimedatectl 解析 `timedatectl show` 的 key=value 输出
+func parseTimedatectl(output []byte) timeSyncStatus {
+	status := timeSyncStatus{Source: "timedatectl"}
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !found {
+			continue
+		}
+
+		switch key {
+		case "Timezone":
+			status.Timezone = value
+		case "NTP":
+			enabled := value == "yes"
+			status.NTPEnabled = &enabled
+		case "NTPSynchronized":
+			synchronized := value == "yes"
+			status.Synchronized = &synchronized
+		}
+	}
+
+	return status
+}
+
+// chronySource 通过 `chronyc tracking` 获取 chrony 状态
+type chronySource struct {
+	run commandRunner
+}
+
+// Name 来源名称
+func (s chronySource) Name() string {
+	return "chrony"
+}
+
+// Query 查询同步状态
+func (s chronySource) Query(ctx context.Context) (timeSyncStatus, error) {
+	output, err := s.run(ctx, "chronyc", "tracking")
+	if err != nil {
+		return timeSyncStatus{}, err
+	}
+	return parseChronyTracking(output)
+}
+
+// parseChronyTracking 解析 `chronyc tracking` 输出，例如：
+//
+//	Reference ID    : A9FEA97B (169.254.169.123)
+//	System time     : 0.000012345 seconds fast of NTP time
+//	Leap status     : Normal
+func parseChronyTracking(output []byte) (timeSyncStatus, error) {
+	status := timeSyncStatus{Source: "chrony"}
+	parsed := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch key {
+		case "Reference ID":
+			status.Reference = value
+			parsed = true
+		case "System time":
+			fields := strings.Fields(value)
+			if len(fields) < 3 {
+				continue
+			}
+			seconds, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				continue
+			}
+			// slow 表示系统时间落后于 NTP 时间
+			if fields[2] == "slow" {
+				seconds = -seconds
+			}
+			offset := time.Duration(seconds * float64(time.Second))
+			status.Offset = &offset
+			parsed = true
+		case "Leap status":
+			synchronized := value != "Not synchronised"
+			status.Synchronized = &synchronized
+			parsed = true
+		}
+	}
+
+	if !parsed {
+		return status, fmt.Errorf("无法解析 chronyc tracking 输出")
+	}
+	return status, nil
+}
+
+// mergeTimeSyncStatus 合并多个来源的状态，先出现的来源优先
+func mergeTimeSyncStatus(statuses []timeSyncStatus) timeSyncStatus {
+	var merged timeSyncStatus
+	var sources []string
+	for _, status := range statuses {
+		sources = append(sources, status.Source)
+		if merged.NTPEnabled == nil {
+			merged.NTPEnabled = status.NTPEnabled
+		}
+		if merged.Synchronized == nil {
+			merged.Synchronized = status.Synchronized
+		}
+		if merged.Offset == nil {
+			merged.Offset = status.Offset
+		}
+		if merged.Reference == "" {
+			merged.Reference = status.Reference
+		}
+		if merged.Timezone == "" {
+			merged.Timezone = status.Timezone
+		}
+	}
+	merged.Source = strings.Join(sources, ", ")
+	return merged
+}
+
+// timeSyncReport 时间同步报告
+type timeSyncReport struct {
+	Now           time.Time           `json:"now"`
+	Timezone      string              `json:"timezone"`
+	UTCOffset     string              `json:"utc_offset"`
+	Status        timeSyncStatus      `json:"status"`
+	Warnings      []string            `json:"warnings,omitempty"`
+	Notes         []string            `json:"notes,omitempty"`
+	WarnThreshold time.Duration       `json:"warn_threshold"`
+	Host          *types.HostIdentity `json:"host,omitempty"`
+}
+
+// TimeSyncTool 时间同步状态工具
+type TimeSyncTool struct {
+	sources []timeSyncSource
+}
+
+// NewTimeSyncTool 创建新的时间同步状态工具
+func NewTimeSyncTool() *TimeSyncTool {
+	var sources []timeSyncSource
+	if runtime.GOOS == "linux" {
+		// chrony 能给出偏移量，优先使用
+		sources = []timeSyncSource{
+			chronySource{run: runCommand},
+			timedatectlSource{run: runCommand},
+		}
+	}
+
+	return &TimeSyncTool{
+		sources: sources,
+	}
+}
+
+// GetName 获取工具名称
+func (tt *TimeSyncTool) GetName() string {
+	return "time_sync"
+}
+
+// GetDescription 获取工具描述
+func (tt *TimeSyncTool) GetDescription() string {
+	return "获取系统时间、时区和时间同步状态（NTP/chrony/timesyncd）"
+}
+
+// GetAnnotations 获取工具注解
+func (tt *TimeSyncTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("时间同步")
+}
+
+// timeSyncArgs time_sync 的参数
+type timeSyncArgs struct {
+	OffsetThresholdMs float64 `arg:"offset_threshold_ms,default=100,min=0" desc:"时钟偏移超过该毫秒数时给出警告（必须大于 0）"`
+	formatArgs
+}
+
+// GetInputSchema 获取输入模式
+func (tt *TimeSyncTool) GetInputSchema() types.InputSchema {
+	return argsSchema(timeSyncArgs{})
+}
+
+// Examples 获取调用示例
+func (tt *TimeSyncTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "系统时间、时区和时间同步状态",
+			Arguments:   map[string]interface{}{},
+		},
+		{
+			Description: "时钟偏移超过 10 毫秒即给出警告，JSON 格式",
+			Arguments:   map[string]interface{}{"offset_threshold_ms": 10, "format": "json"},
+		},
+	}
+}
+
+// Execute 获取时间同步状态
+func (tt *TimeSyncTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a timeSyncArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+	if a.OffsetThresholdMs <= 0 {
+		return "", argumentError("offset_threshold_ms", "无效的偏移阈值: %v", a.OffsetThresholdMs)
+	}
+
+	report := tt.buildReport(ctx, time.Duration(a.OffsetThresholdMs*float64(time.Millisecond)))
+
+	if a.Format == "json" {
+		report.Host = identity.Get()
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", wrapError("序列化时间同步状态失败", err)
+		}
+		return string(jsonData), nil
+	}
+
+	return tt.formatReport(report), nil
+}
+
+// buildReport 查询所有来源并生成报告
+func (tt *TimeSyncTool) buildReport(ctx context.Context, threshold time.Duration) timeSyncReport {
+	now := time.Now()
+	zoneName, _ := now.Zone()
+
+	report := timeSyncReport{
+		Now:           now,
+		Timezone:      zoneName,
+		UTCOffset:     formatUTCOffset(now),
+		WarnThreshold: threshold,
+	}
+
+	if len(tt.sources) == 0 {
+		report.Notes = append(report.Notes, fmt.Sprintf("当前平台（%s）暂不支持查询时间同步状态，仅报告系统时间和时区", runtime.GOOS))
+		return report
+	}
+
+	var statuses []timeSyncStatus
+	for _, source := range tt.sources {
+		status, err := source.Query(ctx)
+		if err != nil {
+			report.Notes = append(report.Notes, fmt.Sprintf("%s 不可用: %v", source.Name(), err))
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+	report.Status = mergeTimeSyncStatus(statuses)
+	if report.Status.Timezone != "" {
+		report.Timezone = report.Status.Timezone
+	}
+
+	if report.Status.Synchronized != nil && !*report.Status.Synchronized {
+		report.Warnings = append(report.Warnings, "系统时钟未同步")
+	}
+	if report.Status.NTPEnabled != nil && !*report.Status.NTPEnabled {
+		report.Warnings = append(report.Warnings, "NTP 同步未启用")
+	}
+	if offset := report.Status.Offset; offset != nil && time.Duration(math.Abs(float64(*offset))) > threshold {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("时钟偏移 %s 超过阈值 %s", *offset, threshold))
+	}
+
+	return report
+}
+
+// formatReport 格式化时间同步报告
+func (tt *TimeSyncTool) formatReport(report timeSyncReport) string {
+	var result string
+
+	result += "🕰️  时间同步\n"
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result += fmt.Sprintf("当前时间: %s\n", report.Now.Format("2006-01-02 15:04:05.000"))
+	result += fmt.Sprintf("时区: %s (UTC%s)\n", report.Timezone, report.UTCOffset)
+
+	status := report.Status
+	if status.Source != "" {
+		result += fmt.Sprintf("数据来源: %s\n", status.Source)
+	}
+	if status.NTPEnabled != nil {
+		result += fmt.Sprintf("NTP 已启用: %s\n", yesNo(*status.NTPEnabled))
+	}
+	if status.Synchronized != nil {
+		result += fmt.Sprintf("已同步: %s\n", yesNo(*status.Synchronized))
+	}
+	if status.Offset != nil {
+		result += fmt.Sprintf("估计偏移: %s\n", *status.Offset)
+	}
+	if status.Reference != "" {
+		result += fmt.Sprintf("参考源: %s\n", status.Reference)
+	}
+
+	for _, warning := range report.Warnings {
+		result += fmt.Sprintf("⚠️  %s\n", warning)
+	}
+	if len(report.Notes) > 0 {
+		result += "\n"
+		for _, note := range report.Notes {
+			result += fmt.Sprintf("ℹ️  %s\n", note)
+		}
+	}
+
+	return result
+}
+
+// formatUTCOffset 格式化时区偏移，如 +08:00
+func formatUTCOffset(t time.Time) string {
+	return t.Format("-07:00")
+}
+
+// yesNo 布尔值的中文显示
+func yesNo(value bool) string {
+	if value {
+		return "是"
+	}
+	return "否"
+}
@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// timedatectlFixture `timedatectl show` 在已同步的 systemd-timesyncd 主机上的输出
+const timedatectlFixture = `Timezone=Asia/Shanghai
+LocalRTC=no
+CanNTP=yes
+NTP=yes
+NTPSynchronized=yes
+TimeUSec=Thu 2024-05-02 10:00:00 CST
+RTCTimeUSec=Thu 2024-05-02 10:00:00 CST
+`
+
+// chronyFixture `chronyc tracking` 的输出，系统时间比 NTP 时间快 0.25 秒
+const chronyFixture = `Reference ID    : A9FEA97B (169.254.169.123)
+Stratum         : 4
+Ref time (UTC)  : Thu May 02 02:00:00 2024
+System time     : 0.250000000 seconds fast of NTP time
+Last offset     : +0.000001234 seconds
+RMS offset      : 0.000012345 seconds
+Frequency       : 11.123 ppm slow
+Leap status     : Normal
+`
+
+// fakeCommands 按命令名返回夹具输出的 commandRunner，未列出的命令视为未安装
+func fakeCommands(outputs map[string]string) commandRunner {
+	return func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		output, ok := outputs[name]
+		if !ok {
+			return nil, notFound("未找到命令 %s", name)
+		}
+		return []byte(output), nil
+	}
+}
+
+// newTimeSyncTool 使用夹具命令输出的时间同步工具，来源顺序与 Linux 上一致
+func newTimeSyncTool(run commandRunner) *TimeSyncTool {
+	return &TimeSyncTool{sources: []timeSyncSource{chronySource{run: run}, timedatectlSource{run: run}}}
+}
+
+func TestParseTimedatectl(t *testing.T) {
+	status := parseTimedatectl([]byte(timedatectlFixture))
+	if status.Timezone != "Asia/Shanghai" || status.NTPEnabled == nil || !*status.NTPEnabled || status.Synchronized == nil || !*status.Synchronized {
+		t.Fatalf("parseTimedatectl() = %+v", status)
+	}
+	if status.Offset != nil {
+		t.Errorf("timedatectl reported an offset: %v", *status.Offset)
+	}
+
+	status = parseTimedatectl([]byte("NTP=no\nNTPSynchronized=no\n"))
+	if *status.NTPEnabled || *status.Synchronized || status.Timezone != "" {
+		t.Errorf("NTP off = %+v", status)
+	}
+
+	// 缺少的字段保持未知，而不是 false
+	status = parseTimedatectl([]byte("Timezone=UTC\ngarbage line\n"))
+	if status.NTPEnabled != nil || status.Synchronized != nil || status.Timezone != "UTC" {
+		t.Errorf("missing fields = %+v, want them unknown", status)
+	}
+}
+
+func TestParseChronyTracking(t *testing.T) {
+	status, err := parseChronyTracking([]byte(chronyFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Reference != "A9FEA97B (169.254.169.123)" || status.Offset == nil || *status.Offset != 250*time.Millisecond || !*status.Synchronized {
+		t.Fatalf("parseChronyTracking() = %+v", status)
+	}
+
+	// slow 表示落后于 NTP 时间，偏移为负
+	status, err = parseChronyTracking([]byte("System time     : 1.5 seconds slow of NTP time\nLeap status     : Not synchronised\n"))
+	if err != nil || *status.Offset != -1500*time.Millisecond || *status.Synchronized {
+		t.Errorf("slow and unsynchronised = %+v, %v", status, err)
+	}
+
+	// 无法解析的偏移量跳过，其他字段照常解析
+	status, err = parseChronyTracking([]byte("System time     : n/a\nLeap status     : Normal\n"))
+	if err != nil || status.Offset != nil || !*status.Synchronized {
+		t.Errorf("malformed offset = %+v, %v", status, err)
+	}
+
+	for _, output := range []string{"", "506 Cannot talk to daemon\n", "Stratum : 4\n"} {
+		if _, err := parseChronyTracking([]byte(output)); err == nil {
+			t.Errorf("parseChronyTracking(%q) succeeded, want an error", output)
+		}
+	}
+}
+
+func TestMergeTimeSyncStatus(t *testing.T) {
+	chrony, _ := parseChronyTracking([]byte(chronyFixture))
+	timedatectl := parseTimedatectl([]byte("Timezone=UTC\nNTP=yes\nNTPSynchronized=no\n"))
+
+	// 先出现的来源优先，缺少的字段由后面的来源补齐
+	merged := mergeTimeSyncStatus([]timeSyncStatus{chrony, timedatectl})
+	if merged.Source != "chrony, timedatectl" || !*merged.Synchronized || *merged.Offset != 250*time.Millisecond || !*merged.NTPEnabled || merged.Timezone != "UTC" {
+		t.Fatalf("merged = %+v", merged)
+	}
+	if merged := mergeTimeSyncStatus(nil); merged.Source != "" || merged.Synchronized != nil {
+		t.Errorf("merging nothing = %+v", merged)
+	}
+}
+
+func TestTimeSyncOffsetWarning(t *testing.T) {
+	tool := newTimeSyncTool(fakeCommands(map[string]string{"chronyc": chronyFixture, "timedatectl": timedatectlFixture}))
+
+	// 0.25 秒的偏移超过默认阈值 100ms，给出警告
+	text, err := tool.Execute(context.Background(), map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"时区: Asia/Shanghai", "数据来源: chrony, timedatectl\n", "NTP 已启用: 是\n", "估计偏移: 250ms\n", "⚠️  时钟偏移 250ms 超过阈值 100ms\n"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+
+	// 阈值可配置，偏移在阈值内时不警告
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"offset_threshold_ms": 500, "format": "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report timeSyncReport
+	if err := json.Unmarshal([]byte(text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Warnings) != 0 || report.WarnThreshold != 500*time.Millisecond {
+		t.Errorf("report = %+v, want no warnings under a 500ms threshold", report)
+	}
+
+	var toolErr *Error
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"offset_threshold_ms": 0}); !errors.As(err, &toolErr) || toolErr.Argument != "offset_threshold_ms" {
+		t.Errorf("zero threshold = %v, want an argument error", err)
+	}
+}
+
+func TestTimeSyncUnavailableSources(t *testing.T) {
+	// 没有 chronyc 时由 timedatectl 提供状态，并说明 chrony 不可用
+	tool := newTimeSyncTool(fakeCommands(map[string]string{"timedatectl": "NTP=no\nNTPSynchronized=no\n"}))
+	report := tool.buildReport(context.Background(), 100*time.Millisecond)
+	if report.Status.Source != "timedatectl" || len(report.Notes) != 1 || !strings.HasPrefix(report.Notes[0], "chrony 不可用: 未找到命令 chronyc") {
+		t.Fatalf("report = %+v", report)
+	}
+	if strings.Join(report.Warnings, "; ") != "系统时钟未同步; NTP 同步未启用" {
+		t.Errorf("warnings = %q", report.Warnings)
+	}
+
+	// 命令超时作为说明报告，不影响系统时间和时区的输出
+	timeout := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return nil, newError(ErrTimeout, "%s 执行超时（%s）", name, commandTimeout)
+	}
+	report = newTimeSyncTool(timeout).buildReport(context.Background(), 100*time.Millisecond)
+	if len(report.Notes) != 2 || !strings.Contains(report.Notes[1], "timedatectl 不可用: timedatectl 执行超时") || report.Timezone == "" || len(report.Warnings) != 0 {
+		t.Errorf("report = %+v", report)
+	}
+
+	// 不支持的平台只报告时间和时区
+	report = (&TimeSyncTool{}).buildReport(context.Background(), 100*time.Millisecond)
+	if len(report.Notes) != 1 || !strings.Contains(report.Notes[0], "暂不支持查询时间同步状态") || report.UTCOffset == "" {
+		t.Errorf("report without sources = %+v", report)
+	}
+}
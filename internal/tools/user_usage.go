@@ -0,0 +1,272 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"mcp-example/internal/identity"
+	"mcp-example/internal/types"
+)
+
+// userUsageWorkers 并发读取的进程数
+const userUsageWorkers = 8
+
+// UserUsageTool 按用户汇总资源占用：进程数、CPU%、RSS 和打开的文件描述符数
+type UserUsageTool struct {
+	// processTool 与 top_processes 共享 CPU 时间基线，CPU% 为两次采集之间的区间值
+	processTool *ProcessTool
+}
+
+// NewUserUsageTool 创建新的用户资源占用工具
+func NewUserUsageTool(processTool *ProcessTool) *UserUsageTool {
+	return &UserUsageTool{processTool: processTool}
+}
+
+// userUsage 单个用户的资源占用
+type userUsage struct {
+	User        string  `json:"user"`
+	Processes   int     `json:"processes"`
+	CPUPercent  float64 `json:"cpu_percent"`
+	MemoryBytes uint64  `json:"memory_bytes"`
+	NumFDs      int64   `json:"num_fds"`
+	// TopPID 该用户按排序指标占用最高的进程（合计中为空）
+	TopPID int32 `json:"top_pid,omitempty"`
+}
+
+// userUsageReport 按用户汇总的结果，Users 只保留前 limit 个，Total 为全部用户的合计
+type userUsageReport struct {
+	SortBy string      `json:"sort_by"`
+	Users  []userUsage `json:"users"`
+	// UserCount 有进程的用户数
+	UserCount int       `json:"user_count"`
+	Total     userUsage `json:"total"`
+	// 被排除或无法读取的进程数
+	ExcludedKernelThreads int `json:"excluded_kernel_threads,omitempty"`
+	Inaccessible          int `json:"inaccessible,omitempty"`
+	// FDsUnavailable 无法读取文件描述符数（权限不足或平台不支持）的进程数，这些进程按 0 计
+	FDsUnavailable int                 `json:"fds_unavailable,omitempty"`
+	IntervalCPU    int                 `json:"interval_cpu_count,omitempty"`
+	Host           *types.HostIdentity `json:"host,omitempty"`
+}
+
+// GetName 获取工具名称
+func (uu *UserUsageTool) GetName() string {
+	return "user_usage"
+}
+
+// GetDescription 获取工具描述
+func (uu *UserUsageTool) GetDescription() string {
+	return "按用户汇总资源占用（进程数、CPU%、内存、打开的文件描述符数），找出共享主机上占用最多的用户"
+}
+
+// GetAnnotations 获取工具注解
+func (uu *UserUsageTool) GetAnnotations() types.ToolAnnotations {
+	return types.ReadOnlyAnnotations("用户资源占用")
+}
+
+// userUsageArgs user_usage 的参数
+type userUsageArgs struct {
+	strictArgs
+	SortBy string `arg:"sort_by,enum=cpu|memory|fds|count,default=cpu" desc:"排序指标: cpu、memory、fds（文件描述符数）或 count（进程数）"`
+	Limit  int    `arg:"limit,default=10,min=1,max=100" desc:"最多列出的用户数"`
+	formatArgs
+}
+
+// GetInputSchema 获取输入模式
+func (uu *UserUsageTool) GetInputSchema() types.InputSchema {
+	return argsSchema(userUsageArgs{})
+}
+
+// Examples 获取调用示例
+func (uu *UserUsageTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "CPU 占用最多的用户",
+			Arguments:   map[string]interface{}{},
+		},
+		{
+			Description: "内存占用最多的 5 个用户，JSON 格式",
+			Arguments:   map[string]interface{}{"sort_by": "memory", "limit": 5, "format": "json"},
+		},
+	}
+}
+
+// Execute 按用户汇总资源占用
+func (uu *UserUsageTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a userUsageArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+
+	processes, err := providers.Process.Processes(ctx)
+	if err != nil {
+		return "", wrapError("获取进程列表失败", err)
+	}
+
+	procInfos, report := uu.collect(ctx, processes)
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	report = buildUserUsageReport(procInfos, report, a.SortBy, a.Limit)
+
+	if a.Format == "json" {
+		report.Host = identity.Get()
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", wrapError("序列化用户资源占用失败", err)
+		}
+		return string(jsonData), nil
+	}
+
+	return formatUserUsage(report, a.Limit), nil
+}
+
+// collect 并发读取每个进程的用户、CPU%、RSS 和文件描述符数。内核线程和无法读取的进程计数后跳过，
+// 用户名无法解析时使用数字 UID
+func (uu *UserUsageTool) collect(ctx context.Context, processes []Process) ([]types.ProcessInfo, userUsageReport) {
+	var report userUsageReport
+	var mutex sync.Mutex
+	var procInfos []types.ProcessInfo
+	usernames := make(usernameCache)
+	seen := make(map[int32]struct{}, len(processes))
+	now := uu.processTool.now()
+
+	forEachProcess(ctx, processes, userUsageWorkers, func(p Process) {
+		name, err := p.NameWithContext(ctx)
+		kernelThread := err == nil && isKernelThread(ctx, p)
+
+		var info types.ProcessInfo
+		var fdsErr error
+		var interval bool
+		if err == nil && !kernelThread {
+			info = types.ProcessInfo{PID: p.PID(), Name: name}
+			createTime, _ := p.CreateTimeWithContext(ctx)
+			info.CPUPercent, interval = uu.processTool.processCPUPercent(ctx, p, createTime, now)
+			if memInfo, err := p.MemoryInfoWithContext(ctx); err == nil && memInfo != nil {
+				info.MemoryBytes = memInfo.RSS
+			}
+			info.NumFDs, fdsErr = p.NumFDsWithContext(ctx)
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		seen[p.PID()] = struct{}{}
+		switch {
+		case err != nil:
+			report.Inaccessible++
+			return
+		case kernelThread:
+			report.ExcludedKernelThreads++
+			return
+		}
+		// 用户名缓存不是并发安全的，在锁内查询
+		info.Username = usernames.lookupOrUID(ctx, p)
+		if fdsErr != nil {
+			report.FDsUnavailable++
+		}
+		if interval {
+			report.IntervalCPU++
+		}
+		procInfos = append(procInfos, info)
+	})
+
+	if ctx.Err() == nil {
+		uu.processTool.cpuBaselines.prune(seen)
+	}
+	return procInfos, report
+}
+
+// buildUserUsageReport 按用户聚合进程（复用 top_processes 的分组），按排序指标降序保留前 limit 个用户并计算合计
+func buildUserUsageReport(procInfos []types.ProcessInfo, report userUsageReport, sortBy string, limit int) userUsageReport {
+	report.SortBy = sortBy
+	report.Users = []userUsage{}
+	report.Total.User = "total"
+
+	groups := groupProcesses(procInfos, groupByUser, sortBy)
+	report.UserCount = len(groups)
+	for i, group := range groups {
+		usage := userUsage{
+			User:        group.Key,
+			Processes:   group.Count,
+			CPUPercent:  group.CPUPercent,
+			MemoryBytes: group.MemoryBytes,
+			NumFDs:      group.NumFDs,
+			TopPID:      group.TopPID,
+		}
+		report.Total.Processes += usage.Processes
+		report.Total.CPUPercent += usage.CPUPercent
+		report.Total.MemoryBytes += usage.MemoryBytes
+		report.Total.NumFDs += usage.NumFDs
+		if i < limit {
+			report.Users = append(report.Users, usage)
+		}
+	}
+	return report
+}
+
+// userUsageShare 用户在排序指标上占合计的比例（百分比）及该指标的显示值，合计为 0 时比例为 0
+func userUsageShare(usage, total userUsage, sortBy string) (float64, string) {
+	var value, sum float64
+	var display string
+	switch sortBy {
+	case "cpu":
+		value, sum = usage.CPUPercent, total.CPUPercent
+		display = fmt.Sprintf("CPU %.1f%%", usage.CPUPercent)
+	case "memory":
+		value, sum = float64(usage.MemoryBytes), float64(total.MemoryBytes)
+		display = "内存 " + formatBytes(usage.MemoryBytes)
+	case "fds":
+		value, sum = float64(usage.NumFDs), float64(total.NumFDs)
+		display = fmt.Sprintf("%d 个文件描述符", usage.NumFDs)
+	default:
+		value, sum = float64(usage.Processes), float64(total.Processes)
+		display = fmt.Sprintf("%d 个进程", usage.Processes)
+	}
+	if sum <= 0 {
+		return 0, display
+	}
+	return value / sum * 100, display
+}
+
+// formatUserUsage 格式化按用户汇总的资源占用
+func formatUserUsage(report userUsageReport, limit int) string {
+	var result string
+
+	labels := map[string]string{"cpu": "CPU ", "memory": "内存", "fds": "文件描述符", "count": "进程数"}
+	result += fmt.Sprintf("👥 %s占用最多的 %d 个用户\n", labels[report.SortBy], limit)
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+
+	if len(report.Users) == 0 {
+		result += "没有可读取的进程\n"
+	} else {
+		result += fmt.Sprintf("%-20s %-8s %-10s %-12s %-10s %-10s\n", "用户", "进程数", "CPU%", "内存", "FD 数", "最高PID")
+		result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+		for _, usage := range report.Users {
+			result += fmt.Sprintf("%s %-8d %-10.2f %-12s %-10d %-10d\n",
+				fitColumn(usage.User, 20), usage.Processes, usage.CPUPercent, formatBytes(usage.MemoryBytes), usage.NumFDs, usage.TopPID)
+		}
+		result += fmt.Sprintf("%-20s %-8d %-10.2f %-12s %-10d\n",
+			"合计", report.Total.Processes, report.Total.CPUPercent, formatBytes(report.Total.MemoryBytes), report.Total.NumFDs)
+
+		top := report.Users[0]
+		share, display := userUsageShare(top, report.Total, report.SortBy)
+		result += fmt.Sprintf("\n📊 %s 占用最多：%s，占全部 %d 个用户的 %.1f%%\n", top.User, display, report.UserCount, share)
+	}
+
+	if report.UserCount > len(report.Users) {
+		result += fmt.Sprintf("显示 %d / %d 个用户，可调大 limit\n", len(report.Users), report.UserCount)
+	}
+	if report.ExcludedKernelThreads > 0 {
+		result += fmt.Sprintf("已排除 %d 个内核线程\n", report.ExcludedKernelThreads)
+	}
+	if report.Inaccessible > 0 {
+		result += fmt.Sprintf("⚠️ 跳过 %d 个无法读取的进程（可能已退出）\n", report.Inaccessible)
+	}
+	if report.FDsUnavailable > 0 {
+		result += fmt.Sprintf("🔒 %d 个进程无法读取文件描述符数（权限不足或平台不支持），按 0 计\n", report.FDsUnavailable)
+	}
+
+	return result
+}
@@ -0,0 +1,198 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/storage"
+)
+
+// userUsageProcesses 混合用户的进程列表：用户名无法解析的 UID、UID 也无法获取的进程、已退出的进程和内核线程
+func userUsageProcesses() []*fakeProcess {
+	return []*fakeProcess{
+		{pid: 10, name: "nginx", ppid: 1, cmdline: []string{"nginx"}, uids: []int32{1000}, username: "alice", cpuPercent: 30, memory: &MemoryInfoStat{RSS: 1 * gb}, numFDs: 100},
+		{pid: 11, name: "worker", ppid: 10, cmdline: []string{"worker"}, uids: []int32{1000}, username: "alice", cpuPercent: 20, memory: &MemoryInfoStat{RSS: 512 << 20}, numFDs: 50},
+		{pid: 20, name: "postgres", ppid: 1, cmdline: []string{"postgres"}, uids: []int32{1001}, username: "bob", cpuPercent: 10, memory: &MemoryInfoStat{RSS: 4 * gb}, numFDs: 400},
+		// 容器中不存在的 UID：按数字 UID 分组，无法读取的文件描述符数按 0 计
+		{pid: 30, name: "app", ppid: 1, cmdline: []string{"app"}, uids: []int32{4242}, cpuPercent: 5, memory: &MemoryInfoStat{RSS: 100 << 20}, numFDsErr: os.ErrPermission},
+		{pid: 31, name: "app", ppid: 1, cmdline: []string{"app"}, uids: []int32{4242}, cpuPercent: 1, memory: &MemoryInfoStat{RSS: 50 << 20}, numFDs: 10},
+		// 用户名和 UID 都无法获取
+		{pid: 40, name: "ghost", ppid: 1, cmdline: []string{"ghost"}},
+		{pid: 50, name: "gone", nameErr: errors.New("no such process")},
+		{pid: 60, name: "kworker/0:1", ppid: 2, uids: []int32{0}, username: "root", cpuPercent: 90},
+	}
+}
+
+func TestUserUsageTool(t *testing.T) {
+	useFakeProcesses(t, newFakeProcessProvider(userUsageProcesses()...))
+
+	cases := []struct {
+		sortBy string
+		limit  int
+		users  string
+	}{
+		{"cpu", 10, "alice:2:50:1536:150:10 bob:1:10:4096:400:20 4242:2:6:150:10:30 (未知):1:0:0:0:40"},
+		{"memory", 10, "bob:1:10:4096:400:20 alice:2:50:1536:150:10 4242:2:6:150:10:30 (未知):1:0:0:0:40"},
+		// PID 30 的文件描述符数无法读取，4242 最高的进程是 PID 31
+		{"fds", 10, "bob:1:10:4096:400:20 alice:2:50:1536:150:10 4242:2:6:150:10:31 (未知):1:0:0:0:40"},
+		// 进程数相同时按用户名升序
+		{"count", 10, "4242:2:6:150:10:30 alice:2:50:1536:150:10 (未知):1:0:0:0:40 bob:1:10:4096:400:20"},
+		// limit 只截断列表，合计和用户数包含全部用户
+		{"cpu", 2, "alice:2:50:1536:150:10 bob:1:10:4096:400:20"},
+	}
+	for _, c := range cases {
+		tool := NewUserUsageTool(NewProcessTool(storage.NewMemoryCache(), CacheOptions{}))
+		text, err := tool.Execute(context.Background(), map[string]interface{}{"sort_by": c.sortBy, "limit": c.limit, "format": "json"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var report userUsageReport
+		if err := json.Unmarshal([]byte(text), &report); err != nil {
+			t.Fatal(err)
+		}
+
+		var users []string
+		for _, usage := range report.Users {
+			users = append(users, fmt.Sprintf("%s:%d:%.0f:%d:%d:%d", usage.User, usage.Processes, usage.CPUPercent, usage.MemoryBytes>>20, usage.NumFDs, usage.TopPID))
+		}
+		if got := strings.Join(users, " "); got != c.users {
+			t.Errorf("%s limit %d: users = %s, want %s", c.sortBy, c.limit, got, c.users)
+		}
+		total := report.Total
+		if report.SortBy != c.sortBy || report.UserCount != 4 || total.User != "total" || total.Processes != 6 ||
+			total.CPUPercent != 66 || total.MemoryBytes != 5*gb+662<<20 || total.NumFDs != 560 || total.TopPID != 0 {
+			t.Errorf("%s limit %d: report = %+v", c.sortBy, c.limit, report)
+		}
+		if report.Inaccessible != 1 || report.FDsUnavailable != 1 || report.IntervalCPU != 0 {
+			t.Errorf("%s limit %d: skipped = %+v", c.sortBy, c.limit, report)
+		}
+		if runtime.GOOS == "linux" && report.ExcludedKernelThreads != 1 {
+			t.Errorf("%s limit %d: excluded kernel threads = %d", c.sortBy, c.limit, report.ExcludedKernelThreads)
+		}
+	}
+}
+
+func TestUserUsageUsernameCache(t *testing.T) {
+	// 同一 UID 只查询一次用户名
+	first := &fakeProcess{pid: 1, name: "a", ppid: 1, cmdline: []string{"a"}, uids: []int32{1000}, username: "alice"}
+	second := &fakeProcess{pid: 2, name: "b", ppid: 1, cmdline: []string{"b"}, uids: []int32{1000}, username: "renamed"}
+	usernames := make(usernameCache)
+	if got := usernames.lookupOrUID(context.Background(), first); got != "alice" {
+		t.Errorf("first lookup = %q", got)
+	}
+	if got := usernames.lookupOrUID(context.Background(), second); got != "alice" {
+		t.Errorf("cached lookup = %q", got)
+	}
+
+	// 解析失败同样被缓存，回退到数字 UID
+	unresolved := &fakeProcess{pid: 3, uids: []int32{4242}}
+	if got := usernames.lookupOrUID(context.Background(), unresolved); got != "4242" {
+		t.Errorf("unresolved lookup = %q", got)
+	}
+	if got := usernames.lookupOrUID(context.Background(), &fakeProcess{pid: 4}); got != "" {
+		t.Errorf("lookup without UID = %q", got)
+	}
+}
+
+func TestUserUsageIntervalCPU(t *testing.T) {
+	clock := &baselineClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	web := &fakeProcess{pid: 10, name: "web", ppid: 1, cmdline: []string{"web"}, uids: []int32{1000}, username: "alice", createTime: 1000, cpuPercent: 2, times: &TimesStat{User: 10}}
+	db := &fakeProcess{pid: 20, name: "db", ppid: 1, cmdline: []string{"db"}, uids: []int32{1001}, username: "bob", createTime: 1000, cpuPercent: 40, times: &TimesStat{User: 100}}
+	useFakeProcesses(t, newFakeProcessProvider(web, db))
+
+	processTool := NewProcessTool(storage.NewMemoryCache(), CacheOptions{})
+	processTool.now = clock.Now
+	tool := NewUserUsageTool(processTool)
+	usage := func() userUsageReport {
+		t.Helper()
+		text, err := tool.Execute(context.Background(), map[string]interface{}{"format": "json"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var report userUsageReport
+		if err := json.Unmarshal([]byte(text), &report); err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+
+	// 第一次调用使用启动以来的平均值
+	if report := usage(); report.Users[0].User != "bob" || report.IntervalCPU != 0 {
+		t.Errorf("first call = %+v", report)
+	}
+
+	// 与 top_processes 共用基线：10 秒内 web 使用了 5 秒 CPU，db 空闲
+	clock.now = clock.now.Add(10 * time.Second)
+	web.times = &TimesStat{User: 15}
+	report := usage()
+	if report.IntervalCPU != 2 || report.Users[0].User != "alice" || report.Users[0].CPUPercent != 50 || report.Users[1].CPUPercent != 0 {
+		t.Errorf("second call = %+v", report)
+	}
+	if processTool.CPUBaselineCount() != 2 {
+		t.Errorf("CPUBaselineCount() = %d, want 2", processTool.CPUBaselineCount())
+	}
+}
+
+func TestFormatUserUsage(t *testing.T) {
+	useFakeProcesses(t, newFakeProcessProvider(userUsageProcesses()...))
+	tool := NewUserUsageTool(NewProcessTool(storage.NewMemoryCache(), CacheOptions{}))
+
+	text, err := tool.Execute(context.Background(), map[string]interface{}{"limit": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"👥 CPU 占用最多的 2 个用户\n",
+		"\n合计                   6        66.00      5.65 GB      560       \n",
+		// 用户数按全部用户计，比例相对全部用户的合计
+		"\n📊 alice 占用最多：CPU 50.0%，占全部 4 个用户的 75.8%\n",
+		"显示 2 / 4 个用户，可调大 limit\n",
+		"⚠️ 跳过 1 个无法读取的进程（可能已退出）\n",
+		"🔒 1 个进程无法读取文件描述符数（权限不足或平台不支持），按 0 计\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output lacks %q:\n%s", want, text)
+		}
+	}
+
+	text, err = tool.Execute(context.Background(), map[string]interface{}{"sort_by": "memory"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "\n📊 bob 占用最多：内存 4.00 GB，占全部 4 个用户的 70.8%\n") || strings.Contains(text, "可调大 limit") {
+		t.Errorf("memory output:\n%s", text)
+	}
+
+	// 没有可读取的进程，合计为 0 时比例为 0
+	report := buildUserUsageReport(nil, userUsageReport{Inaccessible: 3}, "cpu", 10)
+	text = formatUserUsage(report, 10)
+	if !strings.Contains(text, "没有可读取的进程\n") || !strings.Contains(text, "跳过 3 个") || strings.Contains(text, "📊") {
+		t.Errorf("empty output:\n%s", text)
+	}
+	if share, display := userUsageShare(userUsage{Processes: 0}, userUsage{}, "count"); share != 0 || display != "0 个进程" {
+		t.Errorf("userUsageShare() = %v, %q", share, display)
+	}
+}
+
+func TestUserUsageArguments(t *testing.T) {
+	useFakeProcesses(t, newFakeProcessProvider())
+	tool := NewUserUsageTool(NewProcessTool(storage.NewMemoryCache(), CacheOptions{}))
+	for _, args := range []map[string]interface{}{
+		{"sort_by": "name"},
+		{"limit": 0},
+		{"limit": 101},
+	} {
+		_, err := tool.Execute(context.Background(), args)
+		var toolErr *Error
+		if !errors.As(err, &toolErr) || toolErr.Code != ErrBadArgument {
+			t.Errorf("%v: err = %v", args, err)
+		}
+	}
+}
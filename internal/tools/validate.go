@@ -0,0 +1,202 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"mcp-example/internal/types"
+)
+
+// patternCache 已编译的参数正则表达式，按模式字符串缓存
+var patternCache sync.Map
+
+// ValidateArguments 按工具的 InputSchema 校验调用参数，返回规范化后的参数副本。
+// 工具内部统一按字符串读取参数，因此 integer、number、boolean 参数既接受 JSON 数值/布尔值，
+// 也接受对应的字符串形式，校验通过后统一转换为字符串；校验失败时返回 ErrBadArgument。
+func ValidateArguments(schema types.InputSchema, args map[string]interface{}) (map[string]interface{}, error) {
+	normalized := make(map[string]interface{}, len(args))
+
+	for _, name := range schema.Required {
+		value, found := args[name]
+		if !found || value == nil || value == "" {
+			return nil, argumentError(name, "缺少必需参数: %s", name)
+		}
+	}
+
+	if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+		var unknown []string
+		for name := range args {
+			if _, declared := schema.Properties[name]; !declared {
+				unknown = append(unknown, name)
+			}
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			toolErr := badArgument("不支持的参数: %s", strings.Join(unknown, ", "))
+			if len(unknown) == 1 {
+				toolErr.Argument = unknown[0]
+			}
+			return nil, toolErr
+		}
+	}
+
+	for name, value := range args {
+		property, declared := schema.Properties[name]
+		if !declared || value == nil {
+			normalized[name] = value
+			continue
+		}
+
+		converted, err := validateValue(name, property, value)
+		if err != nil {
+			return nil, err
+		}
+		normalized[name] = converted
+	}
+
+	return normalized, nil
+}
+
+// validateValue 校验单个参数值，name 用于错误提示
+func validateValue(name string, property types.Property, value interface{}) (interface{}, error) {
+	// 空字符串表示使用默认值，不做类型、枚举和范围检查
+	if text, ok := value.(string); ok && text == "" {
+		return text, nil
+	}
+
+	switch property.Type {
+	case "integer":
+		number, ok := numericValue(value)
+		if !ok || number != math.Trunc(number) {
+			return nil, argumentError(name, "参数 %s 应为整数: %v", name, value)
+		}
+		if err := checkRange(name, property, number); err != nil {
+			return nil, err
+		}
+		return strconv.FormatInt(int64(number), 10), nil
+
+	case "number":
+		number, ok := numericValue(value)
+		if !ok {
+			return nil, argumentError(name, "参数 %s 应为数值: %v", name, value)
+		}
+		if err := checkRange(name, property, number); err != nil {
+			return nil, err
+		}
+		return strconv.FormatFloat(number, 'f', -1, 64), nil
+
+	case "boolean":
+		switch v := value.(type) {
+		case bool:
+			return strconv.FormatBool(v), nil
+		case string:
+			parsed, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, argumentError(name, "参数 %s 应为布尔值: %q", name, v)
+			}
+			return strconv.FormatBool(parsed), nil
+		}
+		return nil, argumentError(name, "参数 %s 应为布尔值: %v", name, value)
+
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, argumentError(name, "参数 %s 应为数组", name)
+		}
+		if property.Items == nil {
+			return items, nil
+		}
+		converted := make([]interface{}, len(items))
+		for i, item := range items {
+			itemValue, err := validateValue(fmt.Sprintf("%s[%d]", name, i), *property.Items, item)
+			if err != nil {
+				return nil, err
+			}
+			converted[i] = itemValue
+		}
+		return converted, nil
+
+	case "string":
+		var text string
+		switch v := value.(type) {
+		case string:
+			text = v
+		case bool, float64, json.Number:
+			// 兼容把 "true"、"10" 等写成 JSON 布尔值或数值的客户端
+			text = fmt.Sprint(v)
+		default:
+			return nil, argumentError(name, "参数 %s 应为字符串", name)
+		}
+		if len(property.Enum) > 0 && !slices.Contains(property.Enum, text) {
+			return nil, argumentError(name, "参数 %s 的值 %q 无效，可选值: %s", name, text, strings.Join(property.Enum, ", "))
+		}
+		if property.Pattern != "" {
+			pattern, err := compilePattern(property.Pattern)
+			if err != nil {
+				return nil, wrapError(fmt.Sprintf("参数 %s 的模式无效", name), err)
+			}
+			if !pattern.MatchString(text) {
+				return nil, argumentError(name, "参数 %s 的值 %q 格式无效（应匹配 %s）", name, text, property.Pattern)
+			}
+		}
+		return text, nil
+	}
+
+	return value, nil
+}
+
+// numericValue 将 JSON 数值或数值字符串转换为 float64
+func numericValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case json.Number:
+		parsed, err := v.Float64()
+		return parsed, err == nil
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+			return 0, false
+		}
+		return parsed, true
+	}
+	return 0, false
+}
+
+// checkRange 检查数值是否在 Minimum/Maximum 范围内
+func checkRange(name string, property types.Property, number float64) error {
+	if property.Minimum != nil && number < *property.Minimum {
+		return argumentError(name, "参数 %s 不能小于 %s: %s", name, formatBound(*property.Minimum), formatBound(number))
+	}
+	if property.Maximum != nil && number > *property.Maximum {
+		return argumentError(name, "参数 %s 不能大于 %s: %s", name, formatBound(*property.Maximum), formatBound(number))
+	}
+	return nil
+}
+
+// formatBound 格式化范围边界，整数不显示小数部分
+func formatBound(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
+
+// compilePattern 编译并缓存参数正则表达式
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if cached, found := patternCache.Load(pattern); found {
+		return cached.(*regexp.Regexp), nil
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(pattern, compiled)
+	return compiled, nil
+}
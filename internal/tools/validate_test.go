@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"mcp-example/internal/types"
+)
+
+// validateSchema 覆盖各类约束的参数模式
+func validateSchema() types.InputSchema {
+	return types.InputSchema{
+		Type: "object",
+		Properties: map[string]types.Property{
+			"name":    {Type: "string"},
+			"mode":    {Type: "string", Enum: []string{"fast", "slow"}},
+			"window":  {Type: "string", Pattern: "^[0-9]+[smh]$"},
+			"limit":   {Type: "integer", Minimum: types.Float64(1), Maximum: types.Float64(100)},
+			"ratio":   {Type: "number", Minimum: types.Float64(0), Maximum: types.Float64(0.5)},
+			"verbose": {Type: "boolean"},
+			"pids":    {Type: "array", Items: &types.Property{Type: "integer", Minimum: types.Float64(1)}},
+			"tags":    {Type: "array"},
+		},
+		Required: []string{"name"},
+	}
+}
+
+func TestValidateArgumentsNormalizes(t *testing.T) {
+	got, err := ValidateArguments(validateSchema(), map[string]interface{}{
+		"name":    true,
+		"mode":    "slow",
+		"window":  "15m",
+		"limit":   float64(100),
+		"ratio":   "0.25",
+		"verbose": "1",
+		"pids":    []interface{}{float64(1), "42"},
+		"tags":    []interface{}{"a", float64(2)},
+		"extra":   map[string]interface{}{"kept": true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 数值和布尔值统一转换为字符串；未声明的参数和没有元素模式的数组原样保留
+	want := map[string]interface{}{
+		"name":    "true",
+		"mode":    "slow",
+		"window":  "15m",
+		"limit":   "100",
+		"ratio":   "0.25",
+		"verbose": "true",
+		"pids":    []interface{}{"1", "42"},
+		"tags":    []interface{}{"a", float64(2)},
+		"extra":   map[string]interface{}{"kept": true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateArguments() = %#v, want %#v", got, want)
+	}
+
+	// 空字符串表示使用默认值，不检查枚举、范围和格式
+	if got, err := ValidateArguments(validateSchema(), map[string]interface{}{"name": "x", "mode": "", "limit": "", "window": ""}); err != nil || got["limit"] != "" {
+		t.Errorf("empty values = %v, %v", got, err)
+	}
+}
+
+func TestValidateArgumentsErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     map[string]interface{}
+		argument string
+		message  string
+	}{
+		{"missing required", map[string]interface{}{}, "name", "缺少必需参数: name"},
+		{"empty required", map[string]interface{}{"name": ""}, "name", "缺少必需参数: name"},
+		{"enum", map[string]interface{}{"name": "x", "mode": "medium"}, "mode", `参数 mode 的值 "medium" 无效，可选值: fast, slow`},
+		{"pattern", map[string]interface{}{"name": "x", "window": "5 minutes"}, "window", `参数 window 的值 "5 minutes" 格式无效（应匹配 ^[0-9]+[smh]$）`},
+		{"not an integer", map[string]interface{}{"name": "x", "limit": 2.5}, "limit", "参数 limit 应为整数: 2.5"},
+		{"integer text", map[string]interface{}{"name": "x", "limit": "ten"}, "limit", "参数 limit 应为整数: ten"},
+		{"below minimum", map[string]interface{}{"name": "x", "limit": float64(0)}, "limit", "参数 limit 不能小于 1: 0"},
+		{"above maximum", map[string]interface{}{"name": "x", "limit": "101"}, "limit", "参数 limit 不能大于 100: 101"},
+		{"number above maximum", map[string]interface{}{"name": "x", "ratio": 0.75}, "ratio", "参数 ratio 不能大于 0.5: 0.75"},
+		{"NaN", map[string]interface{}{"name": "x", "ratio": "NaN"}, "ratio", "参数 ratio 应为数值: NaN"},
+		{"boolean", map[string]interface{}{"name": "x", "verbose": "yes"}, "verbose", `参数 verbose 应为布尔值: "yes"`},
+		{"boolean type", map[string]interface{}{"name": "x", "verbose": float64(1)}, "verbose", "参数 verbose 应为布尔值: 1"},
+		{"not an array", map[string]interface{}{"name": "x", "pids": "1,2"}, "pids", "参数 pids 应为数组"},
+		{"array item", map[string]interface{}{"name": "x", "pids": []interface{}{float64(3), float64(0)}}, "pids[1]", "参数 pids[1] 不能小于 1: 0"},
+		{"string type", map[string]interface{}{"name": []interface{}{"x"}}, "name", "参数 name 应为字符串"},
+	}
+	for _, c := range cases {
+		_, err := ValidateArguments(validateSchema(), c.args)
+		var toolErr *Error
+		if !errors.As(err, &toolErr) || toolErr.Code != ErrBadArgument || toolErr.Argument != c.argument || toolErr.Message != c.message {
+			t.Errorf("%s: ValidateArguments() = %#v, want %s: %q", c.name, err, c.argument, c.message)
+		}
+	}
+}
+
+func TestValidateArgumentsAdditionalProperties(t *testing.T) {
+	schema := validateSchema()
+	schema.AdditionalProperties = types.Bool(false)
+
+	var toolErr *Error
+	_, err := ValidateArguments(schema, map[string]interface{}{"name": "x", "colour": "red"})
+	if !errors.As(err, &toolErr) || toolErr.Argument != "colour" || toolErr.Message != "不支持的参数: colour" {
+		t.Errorf("one unknown argument = %#v", err)
+	}
+	// 多个未声明参数按名称排序列出，不指明单个参数
+	_, err = ValidateArguments(schema, map[string]interface{}{"name": "x", "zeta": 1, "alpha": 2})
+	if !errors.As(err, &toolErr) || toolErr.Argument != "" || toolErr.Message != "不支持的参数: alpha, zeta" {
+		t.Errorf("two unknown arguments = %#v", err)
+	}
+
+	schema.AdditionalProperties = types.Bool(true)
+	if _, err := ValidateArguments(schema, map[string]interface{}{"name": "x", "colour": "red"}); err != nil {
+		t.Errorf("additionalProperties true = %v", err)
+	}
+}
+
+func TestValidateArgumentsInvalidPattern(t *testing.T) {
+	schema := types.InputSchema{Type: "object", Properties: map[string]types.Property{"id": {Type: "string", Pattern: "("}}}
+	_, err := ValidateArguments(schema, map[string]interface{}{"id": "x"})
+	if err == nil || !strings.Contains(err.Error(), "参数 id 的模式无效") {
+		t.Errorf("invalid pattern = %v", err)
+	}
+}
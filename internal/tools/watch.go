@@ -0,0 +1,198 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"mcp-example/internal/types"
+)
+
+// 监视的限制
+const (
+	// MinWatchInterval 最短执行间隔
+	MinWatchInterval = 5 * time.Second
+	// MaxWatchDuration 最长持续时间的上限
+	MaxWatchDuration = 24 * time.Hour
+)
+
+// WatchController 为当前会话启动和停止监视，由路由器实现（监视属于调用所在的会话，会话结束时一并停止）
+type WatchController interface {
+	StartWatch(ctx context.Context, tool string, args map[string]interface{}, interval, duration time.Duration) (types.WatchInfo, error)
+	StopWatch(ctx context.Context, id string) (types.WatchInfo, error)
+}
+
+// WatchStartTool 启动监视工具
+type WatchStartTool struct {
+	watches WatchController
+	lookup  ToolLookupFunc
+}
+
+// NewWatchStartTool 创建新的启动监视工具，lookup 用于按目标工具的参数模式校验参数
+func NewWatchStartTool(watches WatchController, lookup ToolLookupFunc) *WatchStartTool {
+	return &WatchStartTool{
+		watches: watches,
+		lookup:  lookup,
+	}
+}
+
+// GetName 获取工具名称
+func (ws *WatchStartTool) GetName() string {
+	return "watch_start"
+}
+
+// GetDescription 获取工具描述
+func (ws *WatchStartTool) GetDescription() string {
+	return "启动监视：按固定间隔在后台调用只读工具，并以 notifications/message（logger 为 watch）向当前会话推送每次的结果，直到 watch_stop、到期或会话结束"
+}
+
+// GetAnnotations 获取工具注解
+func (ws *WatchStartTool) GetAnnotations() types.ToolAnnotations {
+	return types.ToolAnnotations{Title: "启动监视"}
+}
+
+// watchStartArgs watch_start 的参数
+type watchStartArgs struct {
+	strictArgs
+	Tool        string                 `arg:"tool,required" desc:"要定时调用的只读工具"`
+	Arguments   map[string]interface{} `arg:"arguments" desc:"调用工具的参数，启动时按工具的参数模式校验"`
+	Interval    time.Duration          `arg:"interval,default=30s" pattern:"^[0-9]+(\\.[0-9]+)?(s|m|h)$" desc:"执行间隔，如 5s、30s、1m，最短 5s"`
+	MaxDuration time.Duration          `arg:"max_duration,default=10m" pattern:"^[0-9]+(\\.[0-9]+)?(s|m|h)$" desc:"最长持续时间，到期后自动停止，最长 24h"`
+}
+
+// GetInputSchema 获取输入模式
+func (ws *WatchStartTool) GetInputSchema() types.InputSchema {
+	return argsSchema(watchStartArgs{})
+}
+
+// Examples 获取调用示例
+func (ws *WatchStartTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "每 5 秒推送一次 CPU 使用率，持续 10 分钟",
+			Arguments:   map[string]interface{}{"tool": "cpu_info", "interval": "5s"},
+		},
+		{
+			Description: "每分钟推送一次内存占用最高的 5 个进程，持续 1 小时",
+			Arguments: map[string]interface{}{
+				"tool":         "top_processes",
+				"arguments":    map[string]interface{}{"sort_by": "memory", "limit": 5},
+				"interval":     "1m",
+				"max_duration": "1h",
+			},
+		},
+	}
+}
+
+// Execute 校验参数并启动监视
+func (ws *WatchStartTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a watchStartArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+	toolName, interval, duration := a.Tool, a.Interval, a.MaxDuration
+	tool, found := ws.lookup(toolName)
+	if !found {
+		return "", notFound("工具不存在或已被禁用: %s", toolName)
+	}
+	if tool.Annotations == nil || !tool.Annotations.ReadOnlyHint {
+		return "", argumentError("tool", "监视只能调用只读工具: %s", toolName)
+	}
+
+	if interval < MinWatchInterval {
+		return "", argumentError("interval", "interval 最短为 %s", MinWatchInterval)
+	}
+	if duration < interval || duration > MaxWatchDuration {
+		return "", argumentError("max_duration", "max_duration 应在 interval（%s）和 %s 之间", interval, MaxWatchDuration)
+	}
+
+	arguments := a.Arguments
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+
+	// 与定时任务相同，启动时就按目标工具的参数模式校验，避免每次执行都失败
+	validated, err := ValidateArguments(tool.InputSchema, arguments)
+	if err != nil {
+		toolErr := ClassifyError(err)
+		return "", &Error{Code: toolErr.Code, Message: fmt.Sprintf("监视参数不符合 %s 的参数模式: %s", toolName, toolErr.Message), Hint: toolErr.Hint}
+	}
+
+	watch, err := ws.watches.StartWatch(ctx, toolName, validated, interval, duration)
+	if err != nil {
+		return "", err
+	}
+
+	var result string
+	result += fmt.Sprintf("✅ 已启动监视: %s\n", watch.ID)
+	result += fmt.Sprintf("工具: %s %s\n", watch.Tool, formatPresetArguments(watch.Arguments))
+	result += fmt.Sprintf("间隔: %s，到期时间: %s\n", watch.Interval, watch.ExpiresAt.Format("2006-01-02 15:04:05"))
+	result += "💡 结果以 notifications/message（logger 为 watch）推送，可通过 watch_stop 提前停止\n"
+	return result, nil
+}
+
+// WatchStopTool 停止监视工具
+type WatchStopTool struct {
+	watches WatchController
+}
+
+// NewWatchStopTool 创建新的停止监视工具
+func NewWatchStopTool(watches WatchController) *WatchStopTool {
+	return &WatchStopTool{watches: watches}
+}
+
+// GetName 获取工具名称
+func (ws *WatchStopTool) GetName() string {
+	return "watch_stop"
+}
+
+// GetDescription 获取工具描述
+func (ws *WatchStopTool) GetDescription() string {
+	return "停止当前会话通过 watch_start 启动的监视"
+}
+
+// GetAnnotations 获取工具注解
+func (ws *WatchStopTool) GetAnnotations() types.ToolAnnotations {
+	return types.ToolAnnotations{
+		Title:          "停止监视",
+		IdempotentHint: true,
+	}
+}
+
+// watchStopArgs watch_stop 的参数
+type watchStopArgs struct {
+	strictArgs
+	ID string `arg:"id,required" desc:"watch_start 返回的监视 ID，如 watch-1"`
+}
+
+// GetInputSchema 获取输入模式
+func (ws *WatchStopTool) GetInputSchema() types.InputSchema {
+	return argsSchema(watchStopArgs{})
+}
+
+// Examples 获取调用示例
+func (ws *WatchStopTool) Examples() []types.ToolExample {
+	return []types.ToolExample{
+		{
+			Description: "停止监视 watch-1",
+			Arguments:   map[string]interface{}{"id": "watch-1"},
+		},
+		{
+			Description: "停止 server_stats 中列出的本会话的监视 watch-12",
+			Arguments:   map[string]interface{}{"id": "watch-12"},
+		},
+	}
+}
+
+// Execute 停止监视
+func (ws *WatchStopTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var a watchStopArgs
+	if err := decodeArgs(args, &a); err != nil {
+		return "", err
+	}
+	watch, err := ws.watches.StopWatch(ctx, a.ID)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("✅ 已停止监视: %s（%s，共执行 %d 次，失败 %d 次）\n", watch.ID, watch.Tool, watch.Runs, watch.Errors), nil
+}
@@ -0,0 +1,78 @@
+// Package trace 为每个请求分配追踪 ID，并通过 context 传递到日志和响应中
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+)
+
+// MaxIDLength 客户端提供的追踪 ID 的最大长度，超过时改为生成新的 ID
+const MaxIDLength = 64
+
+// idBytes 生成的追踪 ID 的随机字节数（输出为 2 倍长度的十六进制）
+const idBytes = 6
+
+type contextKey struct{}
+
+// NewID 生成一个简短的随机追踪 ID
+func NewID() string {
+	buf := make([]byte, idBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "000000000000"
+	}
+	return hex.EncodeToString(buf)
+}
+
+// ValidID 判断客户端提供的追踪 ID 是否可用：非空、不超过 MaxIDLength，且只包含可打印的 ASCII 字符
+func ValidID(id string) bool {
+	if id == "" || len(id) > MaxIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// WithID 返回携带追踪 ID 的 context
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext 获取 context 中的追踪 ID，没有时返回空字符串
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// handler 在日志记录中附加 context 中的追踪 ID
+type handler struct {
+	slog.Handler
+}
+
+// NewHandler 包装 slog.Handler：使用 *Context 方法记录日志时，自动附加 trace_id 属性
+func NewHandler(next slog.Handler) slog.Handler {
+	return handler{Handler: next}
+}
+
+// Handle 附加追踪 ID 后交给下一个 Handler
+func (h handler) Handle(ctx context.Context, record slog.Record) error {
+	if id := FromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("trace_id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs 保持包装
+func (h handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return handler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup 保持包装
+func (h handler) WithGroup(name string) slog.Handler {
+	return handler{Handler: h.Handler.WithGroup(name)}
+}
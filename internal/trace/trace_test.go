@@ -0,0 +1,95 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewID(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := NewID()
+		if len(id) != 2*idBytes || !ValidID(id) || strings.Trim(id, "0123456789abcdef") != "" {
+			t.Fatalf("NewID() = %q, want %d hex characters", id, 2*idBytes)
+		}
+		if seen[id] {
+			t.Fatalf("NewID() repeated %q", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestValidID(t *testing.T) {
+	cases := []struct {
+		id   string
+		want bool
+	}{
+		{"abc123", true},
+		{"client-trace/42:x~", true},
+		{strings.Repeat("a", MaxIDLength), true},
+		{"", false},
+		{strings.Repeat("a", MaxIDLength+1), false},
+		// 空格、控制字符和非 ASCII 字符会破坏日志格式
+		{"has space", false},
+		{"line\nbreak", false},
+		{"tab\t", false},
+		{"追踪", false},
+		{"del\x7f", false},
+	}
+	for _, c := range cases {
+		if got := ValidID(c.id); got != c.want {
+			t.Errorf("ValidID(%q) = %v, want %v", c.id, got, c.want)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	if id := FromContext(context.Background()); id != "" {
+		t.Errorf("FromContext(background) = %q", id)
+	}
+	ctx := WithID(context.Background(), "abc")
+	if id := FromContext(ctx); id != "abc" {
+		t.Errorf("FromContext() = %q, want abc", id)
+	}
+	// 派生的 context 保留追踪 ID
+	child, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if id := FromContext(child); id != "abc" {
+		t.Errorf("FromContext(child) = %q, want abc", id)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	})))
+	ctx := WithID(context.Background(), "abc123")
+
+	logger.InfoContext(ctx, "调用工具", "tool", "cpu_info")
+	// 没有追踪 ID 的 context 和不带 context 的日志不附加属性
+	logger.InfoContext(context.Background(), "后台采集")
+	logger.Info("启动")
+	// WithAttrs 和 WithGroup 之后仍然附加
+	logger.With("session", "s1").WarnContext(ctx, "慢调用")
+	logger.WithGroup("call").ErrorContext(ctx, "失败", "code", 1)
+
+	want := []string{
+		"level=INFO msg=调用工具 tool=cpu_info trace_id=abc123",
+		"level=INFO msg=后台采集",
+		"level=INFO msg=启动",
+		"level=WARN msg=慢调用 session=s1 trace_id=abc123",
+		"level=ERROR msg=失败 call.code=1 call.trace_id=abc123",
+	}
+	if got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("log =\n%s\nwant\n%s", buf.String(), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,199 @@
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// maxEntryBytes 读取记录文件时单行的大小上限
+const maxEntryBytes = 64 << 20
+
+// Processor 处理一条输入消息，返回序列化的响应，通知等没有响应的消息返回 nil
+type Processor func(message []byte) []byte
+
+// Mismatch 回放的响应与记录不一致的请求
+type Mismatch struct {
+	SessionID string
+	ID        string
+	Method    string
+	Reason    string
+}
+
+// Report 回放结果
+type Report struct {
+	// Sessions 回放的会话数，每个会话使用新的服务器实例
+	Sessions int
+	// Requests 回放的请求数（不包括通知）
+	Requests int
+	// Unrecorded 记录中没有对应响应（如记录在响应前结束）的请求数，不参与比较
+	Unrecorded int
+	Mismatches []Mismatch
+}
+
+// Read 读取会话记录文件中的所有条目
+func Read(filePath string) ([]Entry, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("打开会话记录文件失败: %v", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxEntryBytes)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("会话记录第 %d 行无效: %v", lineNumber, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取会话记录文件失败: %v", err)
+	}
+	return entries, nil
+}
+
+// message 回放需要的 JSON-RPC 消息字段
+type message struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+}
+
+// Replay 按会话依次将记录中的输入消息交给 newSession 创建的 Processor 处理，并与记录中相同 ID 的响应比较。
+// 每个会话结束后调用 newSession 返回的函数关闭会话。比较规则见 compareResponses
+func Replay(entries []Entry, newSession func(sessionID string) (Processor, func())) Report {
+	var report Report
+	var order []string
+	sessions := make(map[string][]Entry)
+	for _, entry := range entries {
+		if _, found := sessions[entry.SessionID]; !found {
+			order = append(order, entry.SessionID)
+		}
+		sessions[entry.SessionID] = append(sessions[entry.SessionID], entry)
+	}
+
+	for _, sessionID := range order {
+		report.Sessions++
+		recorded := recordedResponses(sessions[sessionID])
+		process, closeSession := newSession(sessionID)
+
+		for _, entry := range sessions[sessionID] {
+			if entry.Direction != DirectionIn {
+				continue
+			}
+			input := []byte(entry.Raw)
+			if len(entry.Message) > 0 {
+				input = entry.Message
+			}
+			response := process(input)
+
+			var request message
+			json.Unmarshal(input, &request)
+			if len(request.ID) == 0 || string(request.ID) == "null" {
+				continue
+			}
+			report.Requests++
+
+			id := string(request.ID)
+			expected, found := recorded[id]
+			if !found {
+				report.Unrecorded++
+				continue
+			}
+			if reason := compareResponses(request.Method, expected, response); reason != "" {
+				report.Mismatches = append(report.Mismatches, Mismatch{SessionID: sessionID, ID: id, Method: request.Method, Reason: reason})
+			}
+		}
+		closeSession()
+	}
+	return report
+}
+
+// recordedResponses 会话中服务器发出的响应（有 ID、没有 method），按 ID 索引
+func recordedResponses(entries []Entry) map[string]json.RawMessage {
+	responses := make(map[string]json.RawMessage)
+	for _, entry := range entries {
+		if entry.Direction != DirectionOut || len(entry.Message) == 0 {
+			continue
+		}
+		var response message
+		if err := json.Unmarshal(entry.Message, &response); err != nil || response.Method != "" || len(response.ID) == 0 {
+			continue
+		}
+		responses[string(response.ID)] = entry.Message
+	}
+	return responses
+}
+
+// rpcResponse 比较时使用的响应字段
+type rpcResponse struct {
+	Result map[string]interface{} `json:"result"`
+	Error  *struct {
+		Code int `json:"code"`
+	} `json:"error"`
+}
+
+// compareResponses 比较记录的响应和回放的响应，一致时返回空字符串。系统指标每次采集都不同，
+// tools/call 只比较结果是否成功（JSON-RPC 错误码和 isError）；其他方法（如 initialize、tools/list）
+// 比较去掉 _meta 后的完整结果
+func compareResponses(method string, expected, actual []byte) string {
+	if actual == nil {
+		return "回放没有返回响应"
+	}
+	var want, got rpcResponse
+	if err := json.Unmarshal(expected, &want); err != nil {
+		return fmt.Sprintf("无法解析记录的响应: %v", err)
+	}
+	if err := json.Unmarshal(actual, &got); err != nil {
+		return fmt.Sprintf("无法解析回放的响应: %v", err)
+	}
+
+	switch {
+	case want.Error != nil && got.Error != nil:
+		if want.Error.Code != got.Error.Code {
+			return fmt.Sprintf("错误码 %d → %d", want.Error.Code, got.Error.Code)
+		}
+		return ""
+	case want.Error != nil:
+		return fmt.Sprintf("记录为错误 %d，回放成功", want.Error.Code)
+	case got.Error != nil:
+		return fmt.Sprintf("记录为成功，回放返回错误 %d", got.Error.Code)
+	}
+
+	if method == "tools/call" {
+		wantError, _ := want.Result["isError"].(bool)
+		gotError, _ := got.Result["isError"].(bool)
+		if wantError != gotError {
+			return fmt.Sprintf("isError %t → %t", wantError, gotError)
+		}
+		return ""
+	}
+
+	delete(want.Result, "_meta")
+	delete(got.Result, "_meta")
+	var changed []string
+	for key := range want.Result {
+		if !reflect.DeepEqual(want.Result[key], got.Result[key]) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range got.Result {
+		if _, found := want.Result[key]; !found {
+			changed = append(changed, key)
+		}
+	}
+	if len(changed) > 0 {
+		sort.Strings(changed)
+		return "结果字段不同: " + strings.Join(changed, ", ")
+	}
+	return ""
+}
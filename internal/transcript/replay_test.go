@@ -0,0 +1,117 @@
+package transcript
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// entry 构造一条记录
+func entry(direction, sessionID, message string) Entry {
+	return Entry{Direction: direction, SessionID: sessionID, Message: json.RawMessage(message)}
+}
+
+// fixedProcessor 按请求 ID 返回固定响应的 Processor，记录收到的消息
+type fixedProcessor struct {
+	responses map[string]string
+	received  []string
+}
+
+func (fp *fixedProcessor) process(message []byte) []byte {
+	fp.received = append(fp.received, string(message))
+	var request struct {
+		ID json.RawMessage `json:"id"`
+	}
+	json.Unmarshal(message, &request)
+	if len(request.ID) == 0 {
+		return nil
+	}
+	return []byte(fp.responses[string(request.ID)])
+}
+
+func TestReplayReportsMismatches(t *testing.T) {
+	entries := []Entry{
+		entry(DirectionIn, "a", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`),
+		entry(DirectionOut, "a", `{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2025-06-18","_meta":{"host":"x"}}}`),
+		entry(DirectionIn, "a", `{"jsonrpc":"2.0","method":"notifications/initialized"}`),
+		entry(DirectionIn, "b", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"cpu_info"}}`),
+		entry(DirectionOut, "b", `{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"12%"}]}}`),
+		entry(DirectionIn, "a", `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`),
+		entry(DirectionOut, "a", `{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`),
+		entry(DirectionOut, "a", `{"jsonrpc":"2.0","id":2,"result":{"tools":[{"name":"cpu_info"}]}}`),
+		entry(DirectionIn, "b", `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"disk_info"}}`),
+		entry(DirectionOut, "b", `{"jsonrpc":"2.0","id":2,"error":{"code":-32602,"message":"bad"}}`),
+		entry(DirectionIn, "b", `{"jsonrpc":"2.0","id":3,"method":"ping"}`),
+	}
+	processors := map[string]*fixedProcessor{
+		"a": {responses: map[string]string{
+			// _meta 不参与比较
+			"1": `{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2025-06-18","_meta":{"host":"y"}}}`,
+			"2": `{"jsonrpc":"2.0","id":2,"result":{"tools":[{"name":"cpu_info"},{"name":"disk_info"}]}}`,
+		}},
+		"b": {responses: map[string]string{
+			// tools/call 的结果内容不同不算不一致
+			"1": `{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"87%"}]}}`,
+			"2": `{"jsonrpc":"2.0","id":2,"result":{"content":[]}}`,
+			"3": `{"jsonrpc":"2.0","id":3,"result":{}}`,
+		}},
+	}
+	var closed []string
+	report := Replay(entries, func(sessionID string) (Processor, func()) {
+		return processors[sessionID].process, func() { closed = append(closed, sessionID) }
+	})
+
+	if report.Sessions != 2 || report.Requests != 5 || report.Unrecorded != 1 {
+		t.Fatalf("report = %+v, want 2 sessions, 5 requests, 1 unrecorded", report)
+	}
+	if strings.Join(closed, ",") != "a,b" {
+		t.Fatalf("sessions closed in order %v, want a,b", closed)
+	}
+	// 每个会话只收到自己的输入（包括通知）
+	if len(processors["a"].received) != 3 || len(processors["b"].received) != 3 {
+		t.Fatalf("received a=%d b=%d messages, want 3 each", len(processors["a"].received), len(processors["b"].received))
+	}
+
+	want := map[string]string{
+		"a/2": "结果字段不同: tools",
+		"b/2": "记录为错误 -32602，回放成功",
+	}
+	if len(report.Mismatches) != len(want) {
+		t.Fatalf("mismatches = %+v, want %d", report.Mismatches, len(want))
+	}
+	for _, mismatch := range report.Mismatches {
+		if reason := want[mismatch.SessionID+"/"+mismatch.ID]; reason != mismatch.Reason {
+			t.Errorf("mismatch %s/%s: %q, want %q", mismatch.SessionID, mismatch.ID, mismatch.Reason, reason)
+		}
+	}
+}
+
+func TestCompareResponses(t *testing.T) {
+	tests := []struct {
+		method, expected, actual, want string
+	}{
+		{"tools/call", `{"result":{"isError":false}}`, `{"result":{"isError":true}}`, "isError false → true"},
+		{"tools/call", `{"error":{"code":-32601}}`, `{"error":{"code":-32602}}`, "错误码 -32601 → -32602"},
+		{"tools/call", `{"result":{}}`, `{"error":{"code":-32603}}`, "记录为成功，回放返回错误 -32603"},
+		{"initialize", `{"result":{"a":1}}`, `{"result":{"a":1,"b":2}}`, "结果字段不同: b"},
+		{"initialize", `{"result":{"a":1}}`, `{"result":{"a":1}}`, ""},
+	}
+	for _, test := range tests {
+		if got := compareResponses(test.method, []byte(test.expected), []byte(test.actual)); got != test.want {
+			t.Errorf("compareResponses(%s, %s, %s) = %q, want %q", test.method, test.expected, test.actual, got, test.want)
+		}
+	}
+	if got := compareResponses("ping", []byte(`{"result":{}}`), nil); got != "回放没有返回响应" {
+		t.Errorf("missing response = %q", got)
+	}
+}
+
+func TestReadRejectsInvalidLines(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "transcript.jsonl")
+	os.WriteFile(filePath, []byte(`{"direction":"in","session_id":"a","message":{"id":1}}`+"\n\n{broken\n"), 0600)
+	if _, err := Read(filePath); err == nil || !strings.Contains(err.Error(), "第 3 行") {
+		t.Fatalf("Read error = %v, want the invalid line number", err)
+	}
+}
@@ -0,0 +1,246 @@
+// Package transcript 将收发的 JSON-RPC 消息记录为 JSONL 会话记录（--record），并可回放记录中的请求（--replay）
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 消息方向
+const (
+	// DirectionIn 客户端发给服务器的请求和通知
+	DirectionIn = "in"
+	// DirectionOut 服务器发出的响应和通知
+	DirectionOut = "out"
+)
+
+const (
+	// DefaultMaxBytes 记录文件的默认大小上限，超过时轮转
+	DefaultMaxBytes = 64 << 20
+	// maxBackups 轮转保留的旧文件数（path.1 最新）
+	maxBackups = 3
+	// syncInterval 两次 fsync 的最短间隔。每条记录都直接写入文件（进程崩溃不丢失），
+	// 只有断电才可能丢失最近 syncInterval 内的记录
+	syncInterval = time.Second
+)
+
+// redactedValue 被隐藏的参数显示的值
+const redactedValue = "[redacted]"
+
+// Entry 会话记录中的一行
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"`
+	SessionID string    `json:"session_id"`
+	// Message 原始消息，不是合法 JSON 的输入行记录在 Raw 中
+	Message json.RawMessage `json:"message,omitempty"`
+	Raw     string          `json:"raw,omitempty"`
+}
+
+// Recorder 将消息追加到记录文件，文件超过大小上限时轮转。可在多个 goroutine 中调用，
+// 写入失败只记录一次警告，不影响服务器处理请求
+type Recorder struct {
+	path     string
+	maxBytes int64
+	patterns []string
+
+	mutex    sync.Mutex
+	file     *os.File
+	size     int64
+	lastSync time.Time
+	warned   bool
+}
+
+// NewRecorder 打开（追加）记录文件。maxBytes 不大于 0 时使用 DefaultMaxBytes；
+// 工具参数中名称匹配 redactPatterns（通配符，不区分大小写）的键的值记录为 [redacted]，无效的模式会被忽略
+func NewRecorder(filePath string, maxBytes int64, redactPatterns []string) (*Recorder, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+
+	patterns := make([]string, 0, len(redactPatterns))
+	for _, pattern := range redactPatterns {
+		pattern = strings.ToUpper(pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			slog.Warn("忽略无效的参数隐藏模式", "pattern", pattern, "error", err)
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	r := &Recorder{
+		path:     filePath,
+		maxBytes: maxBytes,
+		patterns: patterns,
+		lastSync: time.Now(),
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open 以追加方式打开记录文件，需持有 mutex（或在创建时调用）
+func (r *Recorder) open() error {
+	file, err := os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("打开会话记录文件失败: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("读取会话记录文件信息失败: %v", err)
+	}
+	r.file, r.size = file, info.Size()
+	return nil
+}
+
+// Record 记录一条消息。输入消息中的工具参数按隐藏模式处理
+func (r *Recorder) Record(direction, sessionID string, message []byte) {
+	entry := Entry{Time: time.Now(), Direction: direction, SessionID: sessionID}
+	if json.Valid(message) {
+		if direction == DirectionIn {
+			message = r.redact(message)
+		}
+		entry.Message = message
+	} else {
+		entry.Raw = string(message)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		r.warn(err)
+		return
+	}
+	line = append(line, '\n')
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.file == nil {
+		return
+	}
+	if r.size > 0 && r.size+int64(len(line)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			r.warn(err)
+			return
+		}
+	}
+	n, err := r.file.Write(line)
+	r.size += int64(n)
+	if err != nil {
+		r.warn(err)
+		return
+	}
+	if time.Since(r.lastSync) >= syncInterval {
+		r.file.Sync()
+		r.lastSync = time.Now()
+	}
+}
+
+// rotate 将 path 依次重命名为 path.1、path.2……（最多保留 maxBackups 个），然后打开新文件，需持有 mutex
+func (r *Recorder) rotate() error {
+	r.file.Sync()
+	if err := r.file.Close(); err != nil {
+		slog.Warn("关闭会话记录文件失败", "path", r.path, "error", err)
+	}
+	r.file = nil
+
+	for i := maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return fmt.Errorf("轮转会话记录文件失败: %v", err)
+	}
+	r.lastSync = time.Now()
+	return r.open()
+}
+
+// Close 同步并关闭记录文件，之后的 Record 不做任何事
+func (r *Recorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	r.file.Sync()
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// warn 第一次写入失败时记录警告，之后的失败不再重复记录
+func (r *Recorder) warn(err error) {
+	if r.warned {
+		return
+	}
+	r.warned = true
+	slog.Warn("写入会话记录失败，后续失败不再提示", "path", r.path, "error", err)
+}
+
+// redact 隐藏输入消息 params.arguments 中（包括嵌套的对象，如 multi_query 的各个调用）名称匹配隐藏模式的键的值。
+// 没有需要隐藏的键时原样返回，保持消息的原始格式
+func (r *Recorder) redact(message []byte) []byte {
+	if len(r.patterns) == 0 || !bytes.Contains(message, []byte(`"arguments"`)) {
+		return message
+	}
+
+	var request map[string]interface{}
+	if err := json.Unmarshal(message, &request); err != nil {
+		return message
+	}
+	params, _ := request["params"].(map[string]interface{})
+	if params == nil || !r.redactValue(params["arguments"]) {
+		return message
+	}
+
+	redacted, err := json.Marshal(request)
+	if err != nil {
+		return message
+	}
+	return redacted
+}
+
+// redactValue 递归隐藏对象中名称匹配的键的值，返回是否有值被隐藏
+func (r *Recorder) redactValue(value interface{}) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if r.matches(key) {
+				v[key] = redactedValue
+				changed = true
+				continue
+			}
+			if r.redactValue(item) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if r.redactValue(item) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// matches 键名是否匹配任一隐藏模式（不区分大小写）
+func (r *Recorder) matches(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, pattern := range r.patterns {
+		if matched, _ := path.Match(pattern, upper); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestRecorder 在临时目录中创建记录文件
+func newTestRecorder(t *testing.T, maxBytes int64, patterns ...string) (*Recorder, string) {
+	t.Helper()
+	filePath := filepath.Join(t.TempDir(), "transcript.jsonl")
+	recorder, err := NewRecorder(filePath, maxBytes, patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { recorder.Close() })
+	return recorder, filePath
+}
+
+// readEntries 读取记录文件，失败时测试失败
+func readEntries(t *testing.T, filePath string) []Entry {
+	t.Helper()
+	entries, err := Read(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestRecorderRedactsArguments(t *testing.T) {
+	recorder, filePath := newTestRecorder(t, 0, "*TOKEN*", "*PASSWORD*")
+	request := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"multi_query","arguments":{` +
+		`"api_token":"secret-1","calls":[{"tool":"process_detail","arguments":{"pid":1,"DB_Password":"secret-2"}}],"limit":5}}}`
+	recorder.Record(DirectionIn, "s1", []byte(request))
+	// 响应按原样记录
+	response := `{"jsonrpc":"2.0","id":1,"result":{"api_token":"kept"}}`
+	recorder.Record(DirectionOut, "s1", []byte(response))
+	recorder.Close()
+
+	data, _ := os.ReadFile(filePath)
+	if strings.Contains(string(data), "secret-") {
+		t.Fatalf("secrets leaked into the transcript:\n%s", data)
+	}
+	entries := readEntries(t, filePath)
+	var recorded struct {
+		Params struct {
+			Arguments map[string]interface{} `json:"arguments"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(entries[0].Message, &recorded); err != nil {
+		t.Fatal(err)
+	}
+	args := recorded.Params.Arguments
+	nested := args["calls"].([]interface{})[0].(map[string]interface{})["arguments"].(map[string]interface{})
+	if args["api_token"] != redactedValue || nested["DB_Password"] != redactedValue {
+		t.Fatalf("arguments = %v, want the secrets redacted", args)
+	}
+	if args["limit"] != float64(5) || nested["pid"] != float64(1) {
+		t.Fatalf("arguments = %v, other values must be kept", args)
+	}
+	if string(entries[1].Message) != response {
+		t.Fatalf("response recorded as %s, want it unchanged", entries[1].Message)
+	}
+}
+
+func TestRecorderKeepsMessagesWithoutSecrets(t *testing.T) {
+	recorder, filePath := newTestRecorder(t, 0, "*TOKEN*")
+	// 没有需要隐藏的键时不重新序列化，保持键的原始顺序
+	request := `{"id":2,"method":"tools/call","jsonrpc":"2.0","params":{"name":"cpu_info","arguments":{"per_cpu":true}}}`
+	recorder.Record(DirectionIn, "s1", []byte(request))
+	recorder.Record(DirectionIn, "s1", []byte("not json"))
+	recorder.Close()
+
+	entries := readEntries(t, filePath)
+	if len(entries) != 2 {
+		t.Fatalf("recorded %d entries, want 2", len(entries))
+	}
+	if string(entries[0].Message) != request {
+		t.Fatalf("message = %s, want the original key order", entries[0].Message)
+	}
+	if entries[1].Raw != "not json" || entries[1].Message != nil {
+		t.Fatalf("invalid line recorded as %+v, want raw", entries[1])
+	}
+	if entries[0].Direction != DirectionIn || entries[0].SessionID != "s1" || entries[0].Time.IsZero() {
+		t.Fatalf("entry = %+v", entries[0])
+	}
+}
+
+func TestRecorderRotates(t *testing.T) {
+	recorder, filePath := newTestRecorder(t, 400)
+	for i := 0; i < 40; i++ {
+		recorder.Record(DirectionOut, "s1", []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":{}}`, i)))
+	}
+	recorder.Close()
+
+	for _, name := range []string{filePath, filePath + ".1", filePath + ".2", filePath + ".3"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("missing %s: %v", filepath.Base(name), err)
+		}
+		if info.Size() > 400 {
+			t.Fatalf("%s is %d bytes, want at most 400", filepath.Base(name), info.Size())
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Fatalf("%s mode = %v, want 0600", filepath.Base(name), info.Mode().Perm())
+		}
+	}
+	if _, err := os.Stat(filePath + ".4"); !os.IsNotExist(err) {
+		t.Fatalf("found a fourth backup, want at most %d", maxBackups)
+	}
+
+	// 当前文件保存最新的记录，.1 紧接在它之前
+	current := readEntries(t, filePath)
+	previous := readEntries(t, filePath+".1")
+	last := current[len(current)-1].Message
+	if !strings.Contains(string(last), `"id":39`) {
+		t.Fatalf("last entry = %s, want id 39", last)
+	}
+	var lastPrevious, firstCurrent struct{ ID int }
+	json.Unmarshal(previous[len(previous)-1].Message, &lastPrevious)
+	json.Unmarshal(current[0].Message, &firstCurrent)
+	if firstCurrent.ID != lastPrevious.ID+1 {
+		t.Fatalf("rotation lost entries: .1 ends at %d, current starts at %d", lastPrevious.ID, firstCurrent.ID)
+	}
+}
+
+func TestRecorderAppendsAcrossRestarts(t *testing.T) {
+	recorder, filePath := newTestRecorder(t, 0)
+	recorder.Record(DirectionIn, "s1", []byte(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	recorder.Close()
+	recorder.Record(DirectionIn, "s1", []byte(`{"jsonrpc":"2.0","id":2,"method":"ping"}`))
+
+	reopened, err := NewRecorder(filePath, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened.Record(DirectionIn, "s2", []byte(`{"jsonrpc":"2.0","id":3,"method":"ping"}`))
+	reopened.Close()
+
+	entries := readEntries(t, filePath)
+	if len(entries) != 2 || entries[0].SessionID != "s1" || entries[1].SessionID != "s2" {
+		t.Fatalf("entries = %+v, want one per open recorder (Record after Close is ignored)", entries)
+	}
+}
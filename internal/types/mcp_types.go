@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 // MCP 协议相关类型定义
 
 // JSON-RPC 2.0 消息结构
@@ -17,21 +19,35 @@ type JSONRPCResponse struct {
 	Error   *RPCError   `json:"error,omitempty"`
 }
 
+// JSON-RPC 2.0 通知（服务器主动发送，没有 ID）
+type JSONRPCNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
 type RPCError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
+	// Data 附加信息，服务器在其中返回 trace_id
+	Data interface{} `json:"data,omitempty"`
 }
 
 // MCP 初始化相关结构
+
+// InitializeParams initialize 请求参数：客户端请求的协议版本、声明的能力和客户端信息
 type InitializeParams struct {
 	ProtocolVersion string             `json:"protocolVersion"`
 	Capabilities    ClientCapabilities `json:"capabilities"`
 	ClientInfo      ClientInfo         `json:"clientInfo"`
 }
 
+// ClientCapabilities 客户端声明的能力，未声明的字段为 nil
 type ClientCapabilities struct {
-	Roots    *RootsCapability    `json:"roots,omitempty"`
-	Sampling *SamplingCapability `json:"sampling,omitempty"`
+	Roots        *RootsCapability       `json:"roots,omitempty"`
+	Sampling     *SamplingCapability    `json:"sampling,omitempty"`
+	Elicitation  *ElicitationCapability `json:"elicitation,omitempty"`
+	Experimental map[string]interface{} `json:"experimental,omitempty"`
 }
 
 type RootsCapability struct {
@@ -40,6 +56,8 @@ type RootsCapability struct {
 
 type SamplingCapability struct{}
 
+type ElicitationCapability struct{}
+
 type ClientInfo struct {
 	Name    string `json:"name"`
 	Version string `json:"version"`
@@ -49,12 +67,79 @@ type InitializeResult struct {
 	ProtocolVersion string             `json:"protocolVersion"`
 	Capabilities    ServerCapabilities `json:"capabilities"`
 	ServerInfo      ServerInfo         `json:"serverInfo"`
+	Meta            *InitializeMeta    `json:"_meta,omitempty"`
+}
+
+// initialize 结果的扩展元数据
+type InitializeMeta struct {
+	Host *HostIdentity `json:"host,omitempty"`
 }
 
 type ServerCapabilities struct {
-	Tools     *ToolsCapability     `json:"tools,omitempty"`
-	Resources *ResourcesCapability `json:"resources,omitempty"`
-	Prompts   *PromptsCapability   `json:"prompts,omitempty"`
+	Tools       *ToolsCapability       `json:"tools,omitempty"`
+	Resources   *ResourcesCapability   `json:"resources,omitempty"`
+	Prompts     *PromptsCapability     `json:"prompts,omitempty"`
+	Completions *CompletionsCapability `json:"completions,omitempty"`
+	Logging     *LoggingCapability     `json:"logging,omitempty"`
+}
+
+type CompletionsCapability struct{}
+
+type LoggingCapability struct{}
+
+// SetLevelParams logging/setLevel 请求参数
+type SetLevelParams struct {
+	Level string `json:"level"`
+}
+
+// LogMessageParams notifications/message 通知参数
+type LogMessageParams struct {
+	Level  string      `json:"level"`
+	Logger string      `json:"logger,omitempty"`
+	Data   interface{} `json:"data"`
+}
+
+// SessionStats 单个会话的状态和统计
+type SessionStats struct {
+	ID              string    `json:"id"`
+	State           string    `json:"state"`
+	ProtocolVersion string    `json:"protocol_version,omitempty"`
+	ClientName      string    `json:"client_name,omitempty"`
+	ClientVersion   string    `json:"client_version,omitempty"`
+	ClientFeatures  []string  `json:"client_features,omitempty"`
+	LogLevel        string    `json:"log_level,omitempty"`
+	Subscriptions   int       `json:"subscriptions"`
+	Requests        uint64    `json:"requests"`
+	Errors          uint64    `json:"errors"`
+	InFlight        int       `json:"in_flight"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// WatchInfo 监视：按固定间隔调用工具，并以 notifications/message 向启动它的会话推送结果
+type WatchInfo struct {
+	ID        string                 `json:"id"`
+	SessionID string                 `json:"session_id"`
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments"`
+	Interval  string                 `json:"interval"`
+	StartedAt time.Time              `json:"started_at"`
+	ExpiresAt time.Time              `json:"expires_at"`
+	Runs      int                    `json:"runs"`
+	Errors    int                    `json:"errors"`
+	LastRun   *time.Time             `json:"last_run,omitempty"`
+}
+
+// WatchEvent 监视推送的一次结果（notifications/message 的 data），执行失败时 Error 不为空。
+// 监视到期时最后发送一条只包含 Ended 的事件
+type WatchEvent struct {
+	WatchID    string      `json:"watch_id"`
+	Tool       string      `json:"tool"`
+	Sequence   int         `json:"sequence"`
+	At         time.Time   `json:"at"`
+	Text       string      `json:"text,omitempty"`
+	Structured interface{} `json:"structured,omitempty"`
+	Error      *ToolError  `json:"error,omitempty"`
+	Ended      string      `json:"ended,omitempty"`
 }
 
 type ToolsCapability struct {
@@ -71,38 +156,204 @@ type PromptsCapability struct {
 }
 
 type ServerInfo struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
+	Name    string     `json:"name"`
+	Version string     `json:"version"`
+	Build   *BuildInfo `json:"build,omitempty"`
 }
 
 // Tool 相关结构
 type Tool struct {
-	Name        string      `json:"name"`
-	Description string      `json:"description,omitempty"`
-	InputSchema InputSchema `json:"inputSchema"`
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	InputSchema InputSchema      `json:"inputSchema"`
+	Annotations *ToolAnnotations `json:"annotations,omitempty"`
+	// Examples 调用示例（扩展字段，不属于 MCP 规范）
+	Examples []ToolExample `json:"examples,omitempty"`
+}
+
+// ToolExample 工具调用示例：参数和对返回内容的简短说明
+type ToolExample struct {
+	Description string                 `json:"description"`
+	Arguments   map[string]interface{} `json:"arguments"`
+}
+
+// ToolAnnotations 工具行为提示，客户端据此决定是否自动批准调用
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    bool   `json:"readOnlyHint"`
+	DestructiveHint bool   `json:"destructiveHint"`
+	IdempotentHint  bool   `json:"idempotentHint"`
+	OpenWorldHint   bool   `json:"openWorldHint"`
+}
+
+// ReadOnlyAnnotations 只读监控工具的默认注解
+func ReadOnlyAnnotations(title string) ToolAnnotations {
+	return ToolAnnotations{
+		Title:          title,
+		ReadOnlyHint:   true,
+		IdempotentHint: true,
+	}
 }
 
+// InputSchema 工具参数的 JSON Schema，调用前由 handler 按此校验参数
 type InputSchema struct {
 	Type       string              `json:"type"`
 	Properties map[string]Property `json:"properties,omitempty"`
 	Required   []string            `json:"required,omitempty"`
+	// AdditionalProperties 为 false 时拒绝未声明的参数，为 nil 时允许（默认）
+	AdditionalProperties *bool `json:"additionalProperties,omitempty"`
 }
 
+// Property 单个参数的 JSON Schema
 type Property struct {
-	Type        string   `json:"type"`
-	Description string   `json:"description,omitempty"`
-	Enum        []string `json:"enum,omitempty"`
-	Default     string   `json:"default,omitempty"`
+	Type        string      `json:"type"`
+	Description string      `json:"description,omitempty"`
+	Enum        []string    `json:"enum,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+	// Minimum/Maximum 数值参数（integer、number）的取值范围（包含边界）
+	Minimum *float64 `json:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty"`
+	// Pattern 字符串参数需要匹配的正则表达式（RE2 语法）
+	Pattern string `json:"pattern,omitempty"`
+	// Items 数组参数的元素模式
+	Items *Property `json:"items,omitempty"`
+}
+
+// Float64 返回 v 的指针，用于设置 Property.Minimum/Maximum
+func Float64(v float64) *float64 {
+	return &v
+}
+
+// Bool 返回 v 的指针，用于设置 InputSchema.AdditionalProperties
+func Bool(v bool) *bool {
+	return &v
 }
 
 type CallToolParams struct {
 	Name      string                 `json:"name"`
 	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Meta      *RequestMeta           `json:"_meta,omitempty"`
+}
+
+// MCP 资源
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// MCP 资源模板：参数化的资源 URI（RFC 6570），如 monitor://live/{component}
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// 资源读取参数
+type ReadResourceParams struct {
+	URI string `json:"uri"`
+}
+
+// 资源读取结果
+type ReadResourceResult struct {
+	Contents []ResourceContents `json:"contents"`
+}
+
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+// 资源订阅/取消订阅参数，也用于 notifications/resources/updated
+type ResourceURIParams struct {
+	URI string `json:"uri"`
+}
+
+// 请求元数据，客户端提供 progressToken 时服务器可发送进度通知
+type RequestMeta struct {
+	ProgressToken interface{} `json:"progressToken,omitempty"`
+	// TraceID 客户端指定的追踪 ID，用于端到端关联日志，为空时由服务器生成
+	TraceID string `json:"traceId,omitempty"`
+	// Traceparent 客户端的 W3C traceparent，启用 OTLP 导出时工具调用的 span 以其为父级
+	Traceparent string `json:"traceparent,omitempty"`
+}
+
+// 进度通知参数
+type ProgressParams struct {
+	ProgressToken interface{} `json:"progressToken"`
+	Progress      float64     `json:"progress"`
+	Total         float64     `json:"total,omitempty"`
+	Message       string      `json:"message,omitempty"`
+}
+
+// 参数补全相关结构
+type CompleteParams struct {
+	Ref      CompleteRef      `json:"ref"`
+	Argument CompleteArgument `json:"argument"`
+}
+
+type CompleteRef struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+	URI  string `json:"uri,omitempty"`
+}
+
+type CompleteArgument struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type CompleteResult struct {
+	Completion CompletionValues `json:"completion"`
+}
+
+type CompletionValues struct {
+	Values  []string `json:"values"`
+	Total   int      `json:"total"`
+	HasMore bool     `json:"hasMore"`
 }
 
 type CallToolResult struct {
-	Content []Content `json:"content"`
-	IsError bool      `json:"isError,omitempty"`
+	Content           []Content   `json:"content"`
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+	IsError           bool        `json:"isError,omitempty"`
+	// Meta 调用的元信息（是否来自缓存、数据时长、耗时、服务器版本、追踪 ID），不影响文本内容
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// 工具执行失败时 structuredContent.error 的内容
+type ToolError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+	// Argument 参数无效时出错的参数名
+	Argument string `json:"argument,omitempty"`
+	TraceID  string `json:"trace_id,omitempty"`
+}
+
+// ToolCallRecord 一次工具调用的记录，用于按追踪 ID 查找最近的调用
+type ToolCallRecord struct {
+	TraceID    string    `json:"trace_id"`
+	Tool       string    `json:"tool"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+	// Outcome 为 ok、error（工具返回错误）或 rpc_error（请求被拒绝）
+	Outcome   string `json:"outcome"`
+	ErrorCode string `json:"error_code,omitempty"`
+}
+
+// ToolCallStats 单个工具自服务器启动以来的调用统计，耗时为 tools/call 的总处理时间
+type ToolCallStats struct {
+	Tool    string `json:"tool"`
+	Calls   uint64 `json:"calls"`
+	Errors  uint64 `json:"errors"`
+	TotalMs int64  `json:"total_ms"`
+	MaxMs   int64  `json:"max_ms"`
+	// RateLimited 因限流被拒绝的调用数，不计入 Calls 和 Errors
+	RateLimited uint64 `json:"rate_limited,omitempty"`
 }
 
 type Content struct {
@@ -110,8 +361,16 @@ type Content struct {
 	Text string `json:"text"`
 }
 
+// 补全引用类型
+const (
+	RefTypeTool     = "ref/tool"
+	RefTypePrompt   = "ref/prompt"
+	RefTypeResource = "ref/resource"
+)
+
 // MCP 方法常量
 const (
+	MethodPing                    = "ping"
 	MethodInitialize              = "initialize"
 	MethodInitialized             = "initialized"
 	MethodNotificationInitialized = "notifications/initialized"
@@ -120,4 +379,13 @@ const (
 	MethodListPrompts             = "prompts/list"
 	MethodListResources           = "resources/list"
 	MethodReadResource            = "resources/read"
+	MethodListResourceTemplates   = "resources/templates/list"
+	MethodToolsListChanged        = "notifications/tools/list_changed"
+	MethodComplete                = "completion/complete"
+	MethodProgress                = "notifications/progress"
+	MethodSubscribeResource       = "resources/subscribe"
+	MethodUnsubscribeResource     = "resources/unsubscribe"
+	MethodResourceUpdated         = "notifications/resources/updated"
+	MethodSetLogLevel             = "logging/setLevel"
+	MethodLogMessage              = "notifications/message"
 )
@@ -1,29 +1,81 @@
 package types
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // 监控数据相关类型定义
 
 // 系统监控数据结构
 type SystemInfo struct {
-	Hostname      string    `json:"hostname"`
-	OS            string    `json:"os"`
-	Platform      string    `json:"platform"`
-	KernelVersion string    `json:"kernel_version"`
-	Architecture  string    `json:"architecture"`
-	Uptime        uint64    `json:"uptime"`
-	ProcessCount  uint64    `json:"process_count"`
-	LastUpdated   time.Time `json:"last_updated"`
+	Hostname             string       `json:"hostname"`
+	OS                   string       `json:"os"`
+	Platform             string       `json:"platform"`
+	KernelVersion        string       `json:"kernel_version"`
+	Architecture         string       `json:"architecture"`
+	Uptime               uint64       `json:"uptime"`
+	BootTime             uint64       `json:"boot_time"`
+	ProcessCount         uint64       `json:"process_count"`
+	VirtualizationSystem string       `json:"virtualization_system,omitempty"`
+	VirtualizationRole   string       `json:"virtualization_role,omitempty"`
+	ContainerRuntime     string       `json:"container_runtime,omitempty"`
+	Load                 *LoadAverage `json:"load,omitempty"`
+	// Permissions 服务器进程的权限限制，影响进程、连接等信息的完整性
+	Permissions *PermissionProfile `json:"permissions,omitempty"`
+	LastUpdated time.Time          `json:"last_updated"`
+}
+
+// PermissionProfile 服务器进程的有效权限探测结果，说明哪些数据因权限不足而不完整
+type PermissionProfile struct {
+	// EUID 有效用户 ID，Windows 上为 -1
+	EUID       int  `json:"euid"`
+	Privileged bool `json:"privileged"`
+	// OtherProcessIO 能否读取其他用户进程的 IO 计数和命令行
+	OtherProcessIO bool `json:"other_process_io"`
+	// ConnectionPIDs 网络连接能否对应到所属进程
+	ConnectionPIDs bool `json:"connection_pids"`
+	// Sensors 温度传感器是否可读
+	Sensors     bool      `json:"sensors"`
+	Limitations []string  `json:"limitations,omitempty"`
+	CheckedAt   time.Time `json:"checked_at"`
+}
+
+// LoadAverage 系统平均负载（Windows 上不存在）
+type LoadAverage struct {
+	Load1  float64 `json:"load1"`
+	Load5  float64 `json:"load5"`
+	Load15 float64 `json:"load15"`
 }
 
 // CPU 监控数据
 type CPUInfo struct {
-	ModelName    string    `json:"model_name"`
-	Cores        int32     `json:"cores"`
-	LogicalCores int       `json:"logical_cores"`
-	Frequency    float64   `json:"frequency_ghz"`
-	Usage        CPUUsage  `json:"usage"`
-	LastUpdated  time.Time `json:"last_updated"`
+	ModelName    string `json:"model_name"`
+	Sockets      int    `json:"sockets"`
+	Cores        int32  `json:"cores"`
+	LogicalCores int    `json:"logical_cores"`
+	// Frequency 主频（GHz），0 表示平台未提供（如 Apple Silicon）
+	Frequency   float64            `json:"frequency_ghz"`
+	Usage       CPUUsage           `json:"usage"`
+	Scheduler   *CPUSchedulerStats `json:"scheduler,omitempty"`
+	Thermal     *ThermalState      `json:"thermal,omitempty"`
+	LastUpdated time.Time          `json:"last_updated"`
+}
+
+// CPU 调度统计（Linux /proc/stat）
+type CPUSchedulerStats struct {
+	ContextSwitchesPerSec float64 `json:"context_switches_per_sec"`
+	InterruptsPerSec      float64 `json:"interrupts_per_sec"`
+	ProcsRunning          uint64  `json:"procs_running"`
+	ProcsBlocked          uint64  `json:"procs_blocked"`
+}
+
+// 温控状态（macOS pmset -g therm），WarningLevel 为 0 表示没有记录温控警告，
+// SpeedLimit 为 CPU 允许的最高速度百分比，0 表示未提供
+type ThermalState struct {
+	WarningLevel int  `json:"warning_level"`
+	SpeedLimit   int  `json:"cpu_speed_limit_percent,omitempty"`
+	Throttled    bool `json:"throttled"`
 }
 
 type CPUUsage struct {
@@ -33,15 +85,69 @@ type CPUUsage struct {
 
 // 内存监控数据
 type MemoryInfo struct {
-	Total       uint64    `json:"total_bytes"`
-	Used        uint64    `json:"used_bytes"`
-	Available   uint64    `json:"available_bytes"`
-	Free        uint64    `json:"free_bytes"`
-	Buffers     uint64    `json:"buffers_bytes"`
-	Cached      uint64    `json:"cached_bytes"`
-	UsedPercent float64   `json:"used_percent"`
-	Swap        SwapInfo  `json:"swap"`
-	LastUpdated time.Time `json:"last_updated"`
+	Total       uint64        `json:"total_bytes"`
+	Used        uint64        `json:"used_bytes"`
+	Available   uint64        `json:"available_bytes"`
+	Free        uint64        `json:"free_bytes"`
+	Buffers     uint64        `json:"buffers_bytes"`
+	Cached      uint64        `json:"cached_bytes"`
+	UsedPercent float64       `json:"used_percent"`
+	Swap        SwapInfo      `json:"swap"`
+	Detail      *MemoryDetail `json:"detail,omitempty"`
+	// Pressure 内存压力（仅 macOS）
+	Pressure    *MemoryPressure `json:"pressure,omitempty"`
+	OOMRisk     *OOMRisk        `json:"oom_risk,omitempty"`
+	LastUpdated time.Time       `json:"last_updated"`
+}
+
+// macOS 内存压力：Level 来自 kern.memorystatus_vm_pressure_level（normal、warn 或 critical），
+// 压缩内存来自 vm_stat。Compressed 为压缩器实际占用的内存，Stored 为被压缩页面的原始大小
+type MemoryPressure struct {
+	Level      string `json:"level,omitempty"`
+	Compressed uint64 `json:"compressed_bytes"`
+	Stored     uint64 `json:"stored_in_compressor_bytes"`
+}
+
+// OOM 风险评估：综合可用内存、空闲交换空间、近期换入速率和内存提交比例。
+// 缺少必要输入的平台上 Level 为空，Note 说明原因
+type OOMRisk struct {
+	Level   string          `json:"level,omitempty"`
+	Score   int             `json:"score"`
+	Factors []OOMRiskFactor `json:"factors"`
+	Inputs  OOMRiskInputs   `json:"inputs"`
+	Note    string          `json:"note,omitempty"`
+}
+
+// OOM 风险评估的输入，无法获取的值为 nil
+type OOMRiskInputs struct {
+	AvailablePercent float64 `json:"available_percent"`
+	SwapTotal        uint64  `json:"swap_total_bytes"`
+	SwapFreePercent  float64 `json:"swap_free_percent"`
+	// 两次采样之间的平均换入速率（字节/秒），首次采样或间隔过长时为 nil
+	SwapInRate *float64 `json:"swap_in_bytes_per_sec,omitempty"`
+	// Committed_AS / CommitLimit（仅 Linux）
+	CommitRatio *float64 `json:"commit_ratio,omitempty"`
+}
+
+// 提高 OOM 风险的因素
+type OOMRiskFactor struct {
+	Factor      string  `json:"factor"`
+	Value       float64 `json:"value"`
+	Points      int     `json:"points"`
+	Description string  `json:"description"`
+}
+
+// 内存详细信息（来自 Linux /proc/meminfo，其他平台为空）
+type MemoryDetail struct {
+	Shmem          uint64 `json:"shmem_bytes"`
+	Slab           uint64 `json:"slab_bytes"`
+	SReclaimable   uint64 `json:"slab_reclaimable_bytes"`
+	SUnreclaim     uint64 `json:"slab_unreclaimable_bytes"`
+	HugePagesTotal uint64 `json:"hugepages_total"`
+	HugePagesFree  uint64 `json:"hugepages_free"`
+	HugePageSize   uint64 `json:"hugepage_size_bytes"`
+	CommitLimit    uint64 `json:"commit_limit_bytes"`
+	CommittedAS    uint64 `json:"committed_as_bytes"`
 }
 
 type SwapInfo struct {
@@ -53,20 +159,51 @@ type SwapInfo struct {
 
 // 进程监控数据
 type ProcessInfo struct {
-	PID         int32     `json:"pid"`
-	Name        string    `json:"name"`
-	Status      string    `json:"status"`
-	CPUPercent  float64   `json:"cpu_percent"`
-	MemoryBytes uint64    `json:"memory_bytes"`
-	MemoryMB    float64   `json:"memory_mb"`
-	CreateTime  int64     `json:"create_time"`
+	PID         int32   `json:"pid"`
+	Name        string  `json:"name"`
+	Status      string  `json:"status"`
+	CPUPercent  float64 `json:"cpu_percent"`
+	MemoryBytes uint64  `json:"memory_bytes"`
+	MemoryMB    float64 `json:"memory_mb"`
+	CreateTime  int64   `json:"create_time"`
+	// StartTime 启动时间，与 CreateTime 相同，启动时间未知（CreateTime 为 0）时为空
+	StartTime *time.Time `json:"start_time,omitempty"`
+	Username  string     `json:"username,omitempty"`
+	// NumFDs 打开的文件描述符数，只在需要时读取（user_usage）
+	NumFDs      int32     `json:"num_fds,omitempty"`
 	LastUpdated time.Time `json:"last_updated"`
 }
 
 type ProcessList struct {
-	Processes   []ProcessInfo `json:"processes"`
-	Total       int           `json:"total_count"`
-	LastUpdated time.Time     `json:"last_updated"`
+	Processes []ProcessInfo  `json:"processes,omitempty"`
+	GroupBy   string         `json:"group_by,omitempty"`
+	Groups    []ProcessGroup `json:"groups,omitempty"`
+	Total     int            `json:"total_count"`
+	// 被过滤条件排除的进程数
+	ExcludedKernelThreads int `json:"excluded_kernel_threads,omitempty"`
+	ExcludedByUser        int `json:"excluded_by_user,omitempty"`
+	// 因权限不足或已退出而无法读取、被跳过的进程数
+	Inaccessible int `json:"inaccessible,omitempty"`
+	// CPU 使用率为距上次采集的区间值的进程数，其余进程为启动以来的平均值
+	IntervalCPU int `json:"interval_cpu_count,omitempty"`
+	// 分页：Matching 为过滤后的进程数（分组时为进程组数），Offset 为本页第一项的位置，
+	// NextOffset 为下一页的 offset，已是最后一页时为 0
+	Matching    int       `json:"matching_count"`
+	Offset      int       `json:"offset"`
+	NextOffset  int       `json:"next_offset,omitempty"`
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+// 按名称或用户聚合的进程组
+type ProcessGroup struct {
+	Key         string  `json:"key"`
+	Count       int     `json:"count"`
+	CPUPercent  float64 `json:"cpu_percent"`
+	MemoryBytes uint64  `json:"memory_bytes"`
+	MemoryMB    float64 `json:"memory_mb"`
+	NumFDs      int64   `json:"num_fds,omitempty"`
+	TopPID      int32   `json:"top_pid"`
+	PIDs        []int32 `json:"pids"`
 }
 
 // 网络监控数据
@@ -86,6 +223,11 @@ type NetworkInterface struct {
 	ErrorsOut   uint64 `json:"errors_out"`
 	DropIn      uint64 `json:"drop_in"`
 	DropOut     uint64 `json:"drop_out"`
+	// 计数器起点：FirstSeen 首次看到该接口的时间，ResetCount 此后检测到的计数器重置次数，
+	// LastResetAfter 最近一次重置发生在该时间之后（字节计数从那时起重新累计），从未重置时为空
+	FirstSeen      *time.Time `json:"first_seen,omitempty"`
+	ResetCount     int        `json:"reset_count"`
+	LastResetAfter *time.Time `json:"last_reset_after,omitempty"`
 }
 
 type NetworkConnections struct {
@@ -93,6 +235,11 @@ type NetworkConnections struct {
 	ByStatus   map[string]int     `json:"by_status"`
 	ByProtocol map[string]int     `json:"by_protocol"`
 	Details    []ConnectionDetail `json:"details,omitempty"`
+	// Shown 保留在 Details 中的连接数，Truncated 表示 Details 少于 Total
+	Shown     int  `json:"shown"`
+	Truncated bool `json:"truncated"`
+	// NoPID 没有可见所属进程的监听和已建立连接数（通常因权限不足）
+	NoPID int `json:"no_pid,omitempty"`
 }
 
 type ConnectionDetail struct {
@@ -107,8 +254,18 @@ type ConnectionDetail struct {
 
 // 磁盘监控数据
 type DiskInfo struct {
-	Partitions  []DiskPartition `json:"partitions"`
-	LastUpdated time.Time       `json:"last_updated"`
+	Partitions []DiskPartition `json:"partitions"`
+	// SkippedMounts 查询使用量超时或失败而跳过的挂载点，按分区列表的顺序
+	SkippedMounts []SkippedMount `json:"skipped_mounts,omitempty"`
+	LastUpdated   time.Time      `json:"last_updated"`
+}
+
+// SkippedMount 未能查询使用量的挂载点，Reason 为 timeout（无响应，如失联的 NFS）或 error
+type SkippedMount struct {
+	Mountpoint string `json:"mountpoint"`
+	Fstype     string `json:"fstype"`
+	Reason     string `json:"reason"`
+	Detail     string `json:"detail,omitempty"`
 }
 
 type DiskPartition struct {
@@ -119,25 +276,150 @@ type DiskPartition struct {
 	Used        uint64  `json:"used_bytes"`
 	Free        uint64  `json:"free_bytes"`
 	UsedPercent float64 `json:"used_percent"`
+	// 挂载选项，ReadOnly 表示以只读方式挂载，ExpectedReadOnly 表示按配置只读属于正常情况（如 squashfs）
+	Opts             []string `json:"opts,omitempty"`
+	ReadOnly         bool     `json:"read_only"`
+	ExpectedReadOnly bool     `json:"expected_read_only,omitempty"`
+	// APFSContainer macOS 上 APFS 卷所在的容器（如 disk3），同一容器中的卷共享总容量和可用空间
+	APFSContainer string `json:"apfs_container,omitempty"`
+}
+
+// 主机身份，汇总多台机器的数据时用于区分来源
+type HostIdentity struct {
+	Hostname      string `json:"hostname"`
+	HostID        string `json:"host_id,omitempty"`
+	PrimaryMAC    string `json:"primary_mac,omitempty"`
+	Fingerprint   string `json:"fingerprint"`
+	ServerName    string `json:"server_name,omitempty"`
+	ServerVersion string `json:"server_version,omitempty"`
+}
+
+// 构建信息，从二进制中嵌入的模块和 VCS 信息解析
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Module    string `json:"module,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	Dirty     bool   `json:"dirty,omitempty"`
+	BuildDate string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version"`
 }
 
 // 综合监控数据
 type MonitorData struct {
-	System    SystemInfo  `json:"system"`
-	CPU       CPUInfo     `json:"cpu"`
-	Memory    MemoryInfo  `json:"memory"`
-	Network   NetworkInfo `json:"network"`
-	Disk      DiskInfo    `json:"disk"`
-	Processes ProcessList `json:"processes"`
-	Timestamp time.Time   `json:"timestamp"`
+	Host      *HostIdentity `json:"host,omitempty"`
+	System    SystemInfo    `json:"system"`
+	CPU       CPUInfo       `json:"cpu"`
+	Memory    MemoryInfo    `json:"memory"`
+	Network   NetworkInfo   `json:"network"`
+	Disk      DiskInfo      `json:"disk"`
+	Processes ProcessList   `json:"processes"`
+	BootTime  uint64        `json:"boot_time,omitempty"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// 后台采集器的单次采样
+type MetricSample struct {
+	Timestamp     time.Time          `json:"timestamp"`
+	CPUPercent    float64            `json:"cpu_percent"`
+	MemoryPercent float64            `json:"memory_percent"`
+	DiskPercent   map[string]float64 `json:"disk_percent"`
+	NetRxBytes    map[string]uint64  `json:"net_rx_bytes"`
+	NetTxBytes    map[string]uint64  `json:"net_tx_bytes"`
+	Host          *HostIdentity      `json:"host,omitempty"`
+	// 系统启动时间（Unix 秒），用于识别跨越重启的计数器差值，旧样本为 0
+	BootTime uint64 `json:"boot_time,omitempty"`
+	// ClockJumpSeconds 与上一个样本之间检测到的系统时钟跳变（秒，正数为向前跳），没有跳变时为 0。
+	// 非 0 时两个样本的时间差不可信，不据此计算速率
+	ClockJumpSeconds float64 `json:"clock_jump_seconds,omitempty"`
+}
+
+// Anomaly 后台采集时检测到的异常样本：偏离滚动均值超过设定的标准差倍数
+type Anomaly struct {
+	Timestamp time.Time `json:"timestamp"`
+	Metric    string    `json:"metric"`
+	Selector  string    `json:"selector,omitempty"`
+	Value     float64   `json:"value"`
+	Mean      float64   `json:"baseline_mean"`
+	StdDev    float64   `json:"baseline_stddev"`
+	Sigmas    float64   `json:"sigmas"`
+}
+
+// Threshold 单个指标的阈值，达到 Warning 为警告、达到 Critical 为严重，0 表示不检查该级别
+type Threshold struct {
+	Warning  float64 `json:"warning"`
+	Critical float64 `json:"critical"`
+}
+
+// Thresholds 健康检查使用的各项阈值
+type Thresholds struct {
+	CPUPercent    Threshold `json:"cpu_percent"`
+	MemoryPercent Threshold `json:"memory_percent"`
+	SwapPercent   Threshold `json:"swap_percent"`
+	DiskPercent   Threshold `json:"disk_percent"`
+	LoadPerCore   Threshold `json:"load_per_core"`
+	Zombies       Threshold `json:"zombies"`
+	ClockOffsetMs Threshold `json:"clock_offset_ms"`
+	// SelfRSSMB 服务器进程自身的常驻内存（MB）
+	SelfRSSMB Threshold `json:"self_rss_mb"`
+	// DataDirMB 服务器数据目录的大小（MB），DataDirDiskPercent 数据目录所在分区的使用率
+	DataDirMB          Threshold `json:"data_dir_mb"`
+	DataDirDiskPercent Threshold `json:"data_dir_disk_percent"`
+	// CollectorSkipPercent 后台采集因上一轮采样未完成而跳过的周期占全部周期的百分比
+	CollectorSkipPercent Threshold `json:"collector_skip_percent"`
 }
 
 // 工具接口定义
+// Execute 的 ctx 在调用超时或服务器关闭时取消，工具应将其传递给底层采集调用
 type MonitorTool interface {
 	GetName() string
 	GetDescription() string
 	GetInputSchema() InputSchema
-	Execute(args map[string]interface{}) (string, error)
+	Execute(ctx context.Context, args map[string]interface{}) (string, error)
+}
+
+// StructuredTool 可选接口：工具在文本之外返回结构化结果，作为 structuredContent 提供给解析 JSON 的客户端
+type StructuredTool interface {
+	ExecuteStructured(ctx context.Context, args map[string]interface{}) (string, interface{}, error)
+}
+
+// MonitorResource 可通过 resources/read 读取的资源
+type MonitorResource interface {
+	GetResource() Resource
+	Read(ctx context.Context) (string, error)
+}
+
+// MonitorResourceTemplate 资源模板，按模板展开的 URI 在读取时解析为具体的资源。
+// 模板参数的可选值可通过实现 Completer 提供给 completion/complete（ref/resource）
+type MonitorResourceTemplate interface {
+	GetResourceTemplate() ResourceTemplate
+	// Resolve 解析具体的 URI：不属于该模板时返回 nil, nil；属于该模板但参数无效时返回错误
+	Resolve(uri string) (MonitorResource, error)
+}
+
+// MultiContentResource 可选接口：资源读取时返回多个内容（如文本和 JSON 两种形式）
+type MultiContentResource interface {
+	ReadContents(ctx context.Context) ([]ResourceContents, error)
+}
+
+// AnnotatedTool 可选接口：工具声明自己的行为注解。
+// 未实现该接口的工具按只读、幂等处理；会修改系统状态的工具必须实现并声明 DestructiveHint。
+type AnnotatedTool interface {
+	GetAnnotations() ToolAnnotations
+}
+
+// Completer 可选接口：工具为参数值提供自动补全候选，未知参数返回 nil
+type Completer interface {
+	Complete(ctx context.Context, argName, prefix string) []string
+}
+
+// ExampleTool 可选接口：工具提供调用示例，出现在 tools/list 的 examples 字段和 describe_tool 中
+type ExampleTool interface {
+	Examples() []ToolExample
+}
+
+// Prefetcher 可选接口：工具在启动时预先采集较慢的静态数据并写入缓存
+type Prefetcher interface {
+	Prefetch(ctx context.Context) error
 }
 
 // 数据存储接口
@@ -146,6 +428,35 @@ type DataStorage interface {
 	Load(key string, data interface{}) error
 	Delete(key string) error
 	Exists(key string) bool
+	ListKeys() ([]string, error)
+	ListKeysWithPrefix(prefix string) ([]string, error)
+}
+
+// 存储统计接口，由存储实现可选提供
+type StorageStatsProvider interface {
+	Stats() (StorageStats, error)
+}
+
+// 存储统计数据，数据目录相关字段只有文件存储提供
+type StorageStats struct {
+	Backend  string `json:"backend"`
+	KeyCount int    `json:"key_count"`
+	DataDir  string `json:"data_dir,omitempty"`
+	// 数据目录（含子目录）中所有文件的大小和数量
+	TotalBytes uint64 `json:"total_bytes,omitempty"`
+	FileCount  int    `json:"file_count,omitempty"`
+	// 存储键文件中最早和最近的修改时间
+	OldestSnapshot *time.Time `json:"oldest_snapshot,omitempty"`
+	NewestSnapshot *time.Time `json:"newest_snapshot,omitempty"`
+	// Partition 数据目录所在分区的使用情况，获取失败时为 nil
+	Partition *StoragePartition `json:"partition,omitempty"`
+}
+
+// StoragePartition 数据目录所在分区的容量
+type StoragePartition struct {
+	TotalBytes  uint64  `json:"total_bytes"`
+	FreeBytes   uint64  `json:"free_bytes"`
+	UsedPercent float64 `json:"used_percent"`
 }
 
 // 缓存接口
@@ -155,3 +466,69 @@ type Cache interface {
 	Delete(key string)
 	Clear()
 }
+
+// 失败结果缓存接口（负缓存），由缓存包装器可选实现
+type FailureCache interface {
+	SetFailure(key string, err error)
+	GetFailure(key string) (message string, retryAfter time.Duration, found bool)
+	ClearFailure(key string)
+}
+
+// 缓存统计接口，由缓存实现可选提供
+type CacheStatsProvider interface {
+	Stats() CacheStats
+}
+
+// 缓存统计数据
+type CacheStats struct {
+	Size         int    `json:"size"`
+	Hits         uint64 `json:"hits"`
+	Misses       uint64 `json:"misses"`
+	NegativeHits uint64 `json:"negative_hits"`
+}
+
+// CollectorStats 后台采集器的运行统计。采样耗时超过采集间隔时，期间到期的周期被跳过而不是排队补采
+type CollectorStats struct {
+	IntervalSeconds float64    `json:"interval_seconds"`
+	LastRun         *time.Time `json:"last_run,omitempty"`
+	// Runs 已完成的采样次数（包括失败的），Failures 其中失败的次数
+	Runs     uint64 `json:"runs"`
+	Failures uint64 `json:"failures"`
+	// SkippedTicks 因上一轮采样未完成而跳过的周期数，OverlongRuns 耗时超过采集间隔的采样次数
+	SkippedTicks uint64 `json:"skipped_ticks"`
+	OverlongRuns uint64 `json:"overlong_runs"`
+	// SkipPercent 跳过的周期占全部周期（完成的采样加跳过的周期）的百分比
+	SkipPercent float64 `json:"skip_percent"`
+	// 最近若干次采样的耗时分位数和最大值（毫秒），尚未采样时为 0
+	DurationP50Ms float64 `json:"duration_p50_ms"`
+	DurationP90Ms float64 `json:"duration_p90_ms"`
+	DurationP99Ms float64 `json:"duration_p99_ms"`
+	DurationMaxMs float64 `json:"duration_max_ms"`
+}
+
+// 诊断状态，由 --debug-addr 的 /healthz 返回
+type DiagnosticsStatus struct {
+	UptimeSeconds  float64         `json:"uptime_seconds"`
+	Goroutines     int             `json:"goroutines"`
+	LastCollectRun *time.Time      `json:"last_collect_run,omitempty"`
+	Collector      *CollectorStats `json:"collector,omitempty"`
+	Storage        *StorageStats   `json:"storage,omitempty"`
+	Cache          CacheStats      `json:"cache"`
+	Build          BuildInfo       `json:"build"`
+	Sessions       []SessionStats  `json:"sessions"`
+}
+
+// 可管理的缓存接口，供运维工具查看和清理缓存
+type AdminCache interface {
+	Cache
+	CacheStatsProvider
+	Entries() []CacheEntryInfo
+}
+
+// 缓存项信息
+type CacheEntryInfo struct {
+	Key string        `json:"key"`
+	TTL time.Duration `json:"ttl"`
+	// 缓存的值，供管理工具读取随缓存项保存的来源信息（不计入命中统计）
+	Value interface{} `json:"-"`
+}
@@ -0,0 +1,84 @@
+// Package version 从二进制中嵌入的构建信息解析服务器版本，版本号不再硬编码
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"mcp-example/internal/types"
+)
+
+// develVersion 无法从构建信息得到模块版本时（如 go run 或未在 VCS 中构建）使用的版本号
+const develVersion = "devel"
+
+// shortRevisionLength 简短显示时保留的修订号长度
+const shortRevisionLength = 12
+
+var (
+	once    sync.Once
+	current types.BuildInfo
+)
+
+// Get 获取当前二进制的构建信息，只解析一次
+func Get() types.BuildInfo {
+	once.Do(func() {
+		info, _ := debug.ReadBuildInfo()
+		current = FromBuildInfo(info)
+	})
+	return current
+}
+
+// FromBuildInfo 从 debug.BuildInfo 解析构建信息，info 为 nil 时只包含 Go 版本
+func FromBuildInfo(info *debug.BuildInfo) types.BuildInfo {
+	build := types.BuildInfo{
+		Version:   develVersion,
+		GoVersion: runtime.Version(),
+	}
+	if info == nil {
+		return build
+	}
+
+	build.Module = info.Main.Path
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		build.Version = info.Main.Version
+	}
+	if info.GoVersion != "" {
+		build.GoVersion = info.GoVersion
+	}
+
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Revision = setting.Value
+		case "vcs.time":
+			build.BuildDate = setting.Value
+		case "vcs.modified":
+			build.Dirty = setting.Value == "true"
+		}
+	}
+
+	return build
+}
+
+// String 格式化为一行，例如 "v1.2.0 (rev 0123456789ab, dirty, 2024-05-01T12:00:00Z, go1.22.3)"
+func String(build types.BuildInfo) string {
+	var details []string
+	if build.Revision != "" {
+		revision := build.Revision
+		if len(revision) > shortRevisionLength {
+			revision = revision[:shortRevisionLength]
+		}
+		details = append(details, "rev "+revision)
+	}
+	if build.Dirty {
+		details = append(details, "dirty")
+	}
+	if build.BuildDate != "" {
+		details = append(details, build.BuildDate)
+	}
+	details = append(details, build.GoVersion)
+
+	return build.Version + " (" + strings.Join(details, ", ") + ")"
+}
@@ -0,0 +1,81 @@
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"mcp-example/internal/types"
+)
+
+func TestFromBuildInfo(t *testing.T) {
+	cases := []struct {
+		name string
+		info *debug.BuildInfo
+		want types.BuildInfo
+	}{
+		// 读不到构建信息时（例如不是以模块方式构建的二进制）只有 Go 版本
+		{"missing", nil, types.BuildInfo{Version: develVersion, GoVersion: runtime.Version()}},
+		// go run 或工作区内构建时模块版本为 (devel)，没有 VCS 信息
+		{"devel", &debug.BuildInfo{
+			GoVersion: "go1.21.5",
+			Main:      debug.Module{Path: "mcp-example", Version: "(devel)"},
+		}, types.BuildInfo{Version: develVersion, Module: "mcp-example", GoVersion: "go1.21.5"}},
+		{"empty main version", &debug.BuildInfo{Main: debug.Module{Path: "mcp-example"}},
+			types.BuildInfo{Version: develVersion, Module: "mcp-example", GoVersion: runtime.Version()}},
+		{"release", &debug.BuildInfo{
+			GoVersion: "go1.22.3",
+			Main:      debug.Module{Path: "mcp-example", Version: "v1.2.0"},
+			Settings: []debug.BuildSetting{
+				{Key: "-trimpath", Value: "true"},
+				{Key: "vcs", Value: "git"},
+				{Key: "vcs.revision", Value: "0123456789abcdef0123456789abcdef01234567"},
+				{Key: "vcs.time", Value: "2024-05-01T12:00:00Z"},
+				{Key: "vcs.modified", Value: "false"},
+			},
+		}, types.BuildInfo{
+			Version: "v1.2.0", Module: "mcp-example", GoVersion: "go1.22.3",
+			Revision: "0123456789abcdef0123456789abcdef01234567", BuildDate: "2024-05-01T12:00:00Z",
+		}},
+		{"dirty checkout", &debug.BuildInfo{
+			GoVersion: "go1.22.3",
+			Main:      debug.Module{Path: "mcp-example", Version: "(devel)"},
+			Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "abc123"},
+				{Key: "vcs.modified", Value: "true"},
+			},
+		}, types.BuildInfo{Version: develVersion, Module: "mcp-example", GoVersion: "go1.22.3", Revision: "abc123", Dirty: true}},
+	}
+	for _, c := range cases {
+		if got := FromBuildInfo(c.info); got != c.want {
+			t.Errorf("%s: FromBuildInfo() = %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	cases := []struct {
+		build types.BuildInfo
+		want  string
+	}{
+		{types.BuildInfo{Version: develVersion, GoVersion: "go1.21.5"}, "devel (go1.21.5)"},
+		// 修订号截断为 12 位
+		{types.BuildInfo{
+			Version: "v1.2.0", GoVersion: "go1.22.3", Revision: "0123456789abcdef0123", Dirty: true, BuildDate: "2024-05-01T12:00:00Z",
+		}, "v1.2.0 (rev 0123456789ab, dirty, 2024-05-01T12:00:00Z, go1.22.3)"},
+		{types.BuildInfo{Version: "v1.2.0", GoVersion: "go1.22.3", Revision: "abc123"}, "v1.2.0 (rev abc123, go1.22.3)"},
+	}
+	for _, c := range cases {
+		if got := String(c.build); got != c.want {
+			t.Errorf("String(%+v) = %q, want %q", c.build, got, c.want)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	// 测试二进制同样带有构建信息；解析结果缓存，多次调用相同
+	build := Get()
+	if build.Version == "" || build.GoVersion == "" || build != Get() {
+		t.Errorf("Get() = %+v", build)
+	}
+}
@@ -1,45 +1,253 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"net"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"syscall"
+	"time"
 
+	"mcp-example/internal/anomaly"
+	"mcp-example/internal/config"
+	"mcp-example/internal/diagnostics"
+	"mcp-example/internal/identity"
 	"mcp-example/internal/router"
+	"mcp-example/internal/service"
 	"mcp-example/internal/storage"
+	"mcp-example/internal/telemetry/otlp"
+	"mcp-example/internal/tools"
+	"mcp-example/internal/trace"
+	"mcp-example/internal/transcript"
+	"mcp-example/internal/types"
+	"mcp-example/internal/version"
 )
 
 const (
-	DefaultServerName    = "system-monitor-mcp"
-	DefaultServerVersion = "1.0.0"
-	DefaultDataDir       = "data"
+	DefaultServerName  = "system-monitor-mcp"
+	DefaultDataDir     = "data"
+	DefaultLogLevel    = "info"
+	DefaultStorage     = "file"
+	DefaultToolTimeout = 60 * time.Second
+
+	// shutdownTimeout 关闭服务器时等待进行中的请求、后台采集和诊断服务的总时长
+	shutdownTimeout = 10 * time.Second
 )
 
 type ServerConfig struct {
-	ServerName    string
-	ServerVersion string
-	DataDir       string
-	CacheEnabled  bool
+	ServerName       string
+	ServerVersion    string
+	DataDir          string
+	Storage          string
+	CacheEnabled     bool
+	CompressStorage  bool
+	NegativeCacheTTL time.Duration
+	CollectInterval  time.Duration
+	ToolTimeout      time.Duration
+	EnableActions    bool
+	EnableAdminTools bool
+	NoPrefetch       bool
+	AllowTools       []string
+	DenyTools        []string
+	ReadOnly         bool
+	ExportPath       string
+	ImportPath       string
+	ImportOverwrite  bool
+	MetricsExport    string
+	MetricsFormat    string
+	MetricsSince     time.Duration
+	ConfigFile       string
+	LogLevel         string
+	Thresholds       types.Thresholds
+	OutputStyle      string
+	BarWidth         int
+	DebugAddr        string
+	AnomalySigmas    float64
+	IncidentResolve  time.Duration
+	FallbackMaxAge   time.Duration
+	MaxResultBytes   int
+	ResultRetention  time.Duration
+	HistoryRetention time.Duration
+	ServiceMode      bool
+	ServiceInstall   bool
+	ServiceUninstall bool
+	SocketPath       string
+	RecordPath       string
+	RecordMaxBytes   int64
+	ReplayPath       string
+	OtelEndpoint     string
+	OtelInterval     time.Duration
+	ToolConfigs      map[string]config.ToolConfig
 }
 
 func getDefaultConfig() *ServerConfig {
 	return &ServerConfig{
-		ServerName:    DefaultServerName,
-		ServerVersion: DefaultServerVersion,
-		DataDir:       DefaultDataDir,
-		CacheEnabled:  true,
+		ServerName:       DefaultServerName,
+		ServerVersion:    version.Get().Version,
+		DataDir:          DefaultDataDir,
+		Storage:          DefaultStorage,
+		CacheEnabled:     true,
+		NegativeCacheTTL: storage.DefaultNegativeTTL,
+		ToolTimeout:      DefaultToolTimeout,
+		LogLevel:         DefaultLogLevel,
+		Thresholds:       config.DefaultThresholds(),
+		OutputStyle:      tools.StyleRich,
+		AnomalySigmas:    anomaly.DefaultSigmas,
+		IncidentResolve:  tools.DefaultIncidentResolveAfter,
+		FallbackMaxAge:   tools.DefaultFallbackMaxAge,
+		ResultRetention:  tools.DefaultResultRetention,
+		MetricsFormat:    tools.ExportFormatInflux,
+		MetricsSince:     24 * time.Hour,
+		RecordMaxBytes:   transcript.DefaultMaxBytes,
+		OtelInterval:     otlp.DefaultInterval,
 	}
 }
 
-func initializeStorage(config *ServerConfig) (*storage.JSONStorage, error) {
+// applyConfigFile 加载配置文件，命令行显式指定的参数优先
+func applyConfigFile(serverConfig *ServerConfig) error {
+	if serverConfig.ConfigFile == "" {
+		return nil
+	}
+
+	fileConfig, err := config.Load(serverConfig.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	setFlags := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = true
+	})
+
+	if fileConfig.ServerName != "" && !setFlags["name"] {
+		serverConfig.ServerName = fileConfig.ServerName
+	}
+	if fileConfig.DataDir != "" && !setFlags["data-dir"] {
+		serverConfig.DataDir = fileConfig.DataDir
+	}
+	if fileConfig.Storage != "" && !setFlags["storage"] {
+		serverConfig.Storage = fileConfig.Storage
+	}
+	if fileConfig.LogLevel != "" && !setFlags["log-level"] {
+		serverConfig.LogLevel = fileConfig.LogLevel
+	}
+	if fileConfig.CacheEnabled != nil && !setFlags["cache"] {
+		serverConfig.CacheEnabled = *fileConfig.CacheEnabled
+	}
+	if fileConfig.CompressStorage != nil && !setFlags["compress"] {
+		serverConfig.CompressStorage = *fileConfig.CompressStorage
+	}
+	if fileConfig.CollectInterval != nil && !setFlags["collect-interval"] {
+		serverConfig.CollectInterval = time.Duration(*fileConfig.CollectInterval)
+	}
+	serverConfig.ToolConfigs = fileConfig.ToolsConfig
+	serverConfig.Thresholds = config.EffectiveThresholds(fileConfig.Thresholds)
+	if fileConfig.OutputStyle != "" && !setFlags["output-style"] {
+		serverConfig.OutputStyle = fileConfig.OutputStyle
+	}
+	if fileConfig.BarWidth != 0 && !setFlags["bar-width"] {
+		serverConfig.BarWidth = fileConfig.BarWidth
+	}
+	if fileConfig.AnomalySigmas > 0 && !setFlags["anomaly-sigmas"] {
+		serverConfig.AnomalySigmas = fileConfig.AnomalySigmas
+	}
+	if fileConfig.IncidentResolve != nil && !setFlags["incident-resolve-after"] {
+		serverConfig.IncidentResolve = time.Duration(*fileConfig.IncidentResolve)
+	}
+	if fileConfig.FallbackMaxAge != nil && !setFlags["fallback-max-age"] {
+		serverConfig.FallbackMaxAge = time.Duration(*fileConfig.FallbackMaxAge)
+	}
+	if fileConfig.MaxResultBytes > 0 && !setFlags["max-result-bytes"] {
+		serverConfig.MaxResultBytes = fileConfig.MaxResultBytes
+	}
+	if fileConfig.ResultRetention != nil && !setFlags["result-retention"] {
+		serverConfig.ResultRetention = time.Duration(*fileConfig.ResultRetention)
+	}
+	if fileConfig.HistoryRetention != nil && !setFlags["history-retention"] {
+		serverConfig.HistoryRetention = time.Duration(*fileConfig.HistoryRetention)
+	}
+
+	// 访问策略会在 SIGHUP 时重新加载，配置文件中删除的项需要恢复为默认值
+	if !setFlags["allow-tools"] {
+		serverConfig.AllowTools = fileConfig.AllowTools
+	}
+	if !setFlags["deny-tools"] {
+		serverConfig.DenyTools = fileConfig.DenyTools
+	}
+	if !setFlags["read-only"] {
+		serverConfig.ReadOnly = fileConfig.ReadOnly != nil && *fileConfig.ReadOnly
+	}
+
+	return nil
+}
+
+// buildPolicy 根据配置生成工具访问策略
+func buildPolicy(config *ServerConfig) router.Policy {
+	return router.Policy{
+		AllowTools: config.AllowTools,
+		DenyTools:  config.DenyTools,
+		ReadOnly:   config.ReadOnly,
+	}
+}
+
+// splitToolList 解析逗号分隔的工具列表
+func splitToolList(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// initializeLogger 初始化日志（输出到 stderr，避免干扰 stdout 上的 JSON-RPC）
+// logLevel 当前日志级别，SIGHUP 重新加载配置时可修改
+var logLevel = new(slog.LevelVar)
+
+func initializeLogger(config *ServerConfig) error {
+	if err := setLogLevel(config.LogLevel); err != nil {
+		return err
+	}
+
+	// 请求处理期间使用 *Context 方法记录的日志会自动带上 trace_id
+	slog.SetDefault(slog.New(trace.NewHandler(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}))))
+	return nil
+}
+
+// setLogLevel 解析并设置日志级别
+func setLogLevel(name string) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return fmt.Errorf("无效的日志级别 %q: %v", name, err)
+	}
+
+	logLevel.Set(level)
+	return nil
+}
+
+func initializeStorage(config *ServerConfig) (types.DataStorage, error) {
+	switch config.Storage {
+	case "memory":
+		return storage.NewMemoryStorage(), nil
+	case "file":
+	default:
+		return nil, fmt.Errorf("不支持的存储类型: %s", config.Storage)
+	}
+
 	if err := os.MkdirAll(config.DataDir, 0755); err != nil {
 		return nil, fmt.Errorf("创建数据目录失败: %v", err)
 	}
 
-	jsonStorage, err := storage.NewJSONStorage(config.DataDir)
+	jsonStorage, err := storage.NewJSONStorage(config.DataDir, storage.WithCompression(config.CompressStorage))
 	if err != nil {
 		return nil, fmt.Errorf("初始化存储失败: %v", err)
 	}
@@ -47,67 +255,670 @@ func initializeStorage(config *ServerConfig) (*storage.JSONStorage, error) {
 	return jsonStorage, nil
 }
 
-func initializeCache() *storage.MemoryCache {
-	return storage.NewMemoryCache()
+func initializeCache(config *ServerConfig) *storage.NegativeCache {
+	return storage.NewNegativeCache(storage.NewMemoryCache(), config.NegativeCacheTTL)
+}
+
+// initializeRouter 创建路由器，recorder 为 nil 时不记录会话
+func initializeRouter(config *ServerConfig, dataStorage types.DataStorage, cache *storage.NegativeCache, recorder router.Transcript) *router.Router {
+	// 启动时计算一次主机身份，附加到快照、采样和 JSON 输出中
+	identity.Init(config.ServerName, config.ServerVersion)
+
+	mcpRouter := router.NewRouter(config.ServerName, config.ServerVersion, dataStorage, cache, router.Options{
+		ToolConfigs:          config.ToolConfigs,
+		CollectInterval:      config.CollectInterval,
+		HistoryRetention:     config.HistoryRetention,
+		ToolTimeout:          config.ToolTimeout,
+		EnableActions:        config.EnableActions,
+		EnableAdminTools:     config.EnableAdminTools,
+		Prefetch:             !config.NoPrefetch,
+		Thresholds:           config.Thresholds,
+		OutputStyle:          tools.NewOutputStyle(config.OutputStyle, config.BarWidth),
+		AnomalySigmas:        config.AnomalySigmas,
+		IncidentResolveAfter: config.IncidentResolve,
+		FallbackMaxAge:       config.FallbackMaxAge,
+		MaxResultBytes:       config.MaxResultBytes,
+		ResultRetention:      config.ResultRetention,
+		Transcript:           recorder,
+	})
+
+	mcpRouter.SetPolicy(buildPolicy(config))
+
+	// 请求日志仅在 debug 级别输出到 stderr
+	mcpRouter.Use(router.LoggingMiddleware(slog.Default()))
+
+	return mcpRouter
+}
+
+// runDataTransfer 执行数据导出/导入（命令行模式，完成后退出）
+func runDataTransfer(config *ServerConfig, dataStorage types.DataStorage) error {
+	jsonStorage, ok := dataStorage.(*storage.JSONStorage)
+	if !ok {
+		return fmt.Errorf("数据导出/导入仅支持文件存储")
+	}
+
+	if config.ExportPath != "" {
+		file, err := os.Create(config.ExportPath)
+		if err != nil {
+			return fmt.Errorf("创建导出文件失败: %v", err)
+		}
+		defer file.Close()
+
+		manifest, err := jsonStorage.Export(file, config.ServerName, config.ServerVersion)
+		if err != nil {
+			return fmt.Errorf("导出数据失败: %v", err)
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("写入导出文件失败: %v", err)
+		}
+
+		fmt.Printf("已导出 %d 个数据文件到 %s\n", len(manifest.Files), config.ExportPath)
+		if len(manifest.Skipped) > 0 {
+			fmt.Printf("未导出（子目录和非数据文件）: %s\n", strings.Join(manifest.Skipped, ", "))
+		}
+		return nil
+	}
+
+	file, err := os.Open(config.ImportPath)
+	if err != nil {
+		return fmt.Errorf("打开导入文件失败: %v", err)
+	}
+	defer file.Close()
+
+	result, err := jsonStorage.Import(file, config.ImportOverwrite)
+	if err != nil {
+		return fmt.Errorf("导入数据失败: %v", err)
+	}
+
+	fmt.Printf("已从 %s 导入 %d 个数据文件（来源: %s v%s, 导出时间: %s）\n",
+		config.ImportPath, len(result.Restored), result.Manifest.ServerName, result.Manifest.ServerVersion,
+		result.Manifest.CreatedAt.Format("2006-01-02 15:04:05"))
+	for _, key := range result.Restored {
+		fmt.Printf("  • %s\n", key)
+	}
+	if len(result.Overwritten) > 0 {
+		fmt.Printf("覆盖了已存在的键: %v\n", result.Overwritten)
+	}
+
+	return nil
+}
+
+// runMetricsExport 导出最近 --export-since 内的采集历史（命令行模式，完成后退出）。
+// 统计信息写到 stderr，导出到标准输出时可以直接通过管道交给其他程序。
+func runMetricsExport(config *ServerConfig, dataStorage types.DataStorage) error {
+	if config.MetricsFormat != tools.ExportFormatInflux && config.MetricsFormat != tools.ExportFormatCSV {
+		return fmt.Errorf("不支持的导出格式: %s（可选 influx、csv）", config.MetricsFormat)
+	}
+
+	var w io.Writer = os.Stdout
+	var file *os.File
+	if config.MetricsExport != "-" {
+		var err error
+		if file, err = os.Create(config.MetricsExport); err != nil {
+			return fmt.Errorf("创建导出文件失败: %v", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	to := time.Now()
+	result, err := tools.ExportMetrics(w, dataStorage, to.Add(-config.MetricsSince), to, config.MetricsFormat)
+	if err != nil {
+		return fmt.Errorf("导出采集历史失败: %v", err)
+	}
+	if file != nil {
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("写入导出文件失败: %v", err)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "已导出 %d 个样本（%d 字节），跳过无效样本 %d 个，缺失或损坏的日文件 %d 个\n",
+		result.Samples, result.Bytes, result.Skipped, result.SkippedFiles)
+	return nil
+}
+
+// redactPatterns 会话记录中隐藏工具参数使用的模式，与 process_detail 隐藏环境变量的模式相同
+func redactPatterns(config *ServerConfig) []string {
+	if patterns := config.ToolConfigs["process_detail"].RedactEnvPatterns; patterns != nil {
+		return patterns
+	}
+	return tools.DefaultRedactEnvPatterns
+}
+
+// runReplay 将会话记录中的请求依次交给新的服务器实例处理，报告与记录不一致的响应（命令行模式，完成后退出）。
+// 每个会话使用内存存储和新的路由器，不注册操作工具，避免回放修改数据目录或系统状态
+func runReplay(config *ServerConfig) error {
+	entries, err := transcript.Read(config.ReplayPath)
+	if err != nil {
+		return err
+	}
+
+	replayConfig := *config
+	replayConfig.EnableActions = false
+	report := transcript.Replay(entries, func(sessionID string) (transcript.Processor, func()) {
+		mcpRouter := initializeRouter(&replayConfig, storage.NewMemoryStorage(), initializeCache(&replayConfig), nil)
+		mcpRouter.SetOutput(io.Discard)
+		if err := mcpRouter.InitializeTools(); err != nil {
+			slog.Error("初始化工具失败", "session", sessionID, "error", err)
+		}
+		return mcpRouter.ProcessMessage, func() {
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			mcpRouter.Shutdown(ctx)
+		}
+	})
+
+	fmt.Printf("已回放 %d 个会话的 %d 个请求，%d 个与记录不一致，%d 个在记录中没有响应\n",
+		report.Sessions, report.Requests, len(report.Mismatches), report.Unrecorded)
+	for _, mismatch := range report.Mismatches {
+		fmt.Printf("  • 会话 %s 请求 %s (%s): %s\n", mismatch.SessionID, mismatch.ID, mismatch.Method, mismatch.Reason)
+	}
+	if len(report.Mismatches) > 0 {
+		return fmt.Errorf("回放结果与记录不一致")
+	}
+	return nil
+}
+
+// startDiagnostics 指定 --debug-addr 时启动 pprof 和 /healthz 诊断服务，未指定时返回 nil
+func startDiagnostics(config *ServerConfig, mcpRouter *router.Router) (*diagnostics.Server, error) {
+	if config.DebugAddr == "" {
+		return nil, nil
+	}
+
+	server, err := diagnostics.Start(config.DebugAddr, mcpRouter.DiagnosticsStatus)
+	if err != nil {
+		return nil, fmt.Errorf("启动诊断服务失败: %v", err)
+	}
+
+	slog.Warn("诊断服务已启动，pprof 可能暴露敏感信息，请勿监听公网地址", "addr", server.Addr())
+	return server, nil
+}
+
+// listenSocket 指定 --socket 时监听 Unix 套接字，未指定时返回 nil。
+// 删除上次运行遗留的套接字文件，新文件只允许当前用户连接
+func listenSocket(config *ServerConfig) (net.Listener, error) {
+	if config.SocketPath == "" {
+		return nil, nil
+	}
+
+	if info, err := os.Lstat(config.SocketPath); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(config.SocketPath)
+	}
+	listener, err := net.Listen("unix", config.SocketPath)
+	if err != nil {
+		return nil, fmt.Errorf("监听套接字失败: %v", err)
+	}
+	if err := os.Chmod(config.SocketPath, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("设置套接字权限失败: %v", err)
+	}
+
+	slog.Info("套接字已开始监听", "path", config.SocketPath)
+	return listener, nil
+}
+
+// serveSocket 在套接字上接受连接直到套接字关闭
+func serveSocket(mcpRouter *router.Router, listener net.Listener) {
+	if err := mcpRouter.ServeListener(listener); err != nil {
+		slog.Error("套接字停止接受连接", "error", err)
+	}
 }
 
-func initializeRouter(config *ServerConfig, dataStorage *storage.JSONStorage, cache *storage.MemoryCache) *router.Router {
-	return router.NewRouter(config.ServerName, config.ServerVersion, dataStorage, cache)
+// startTelemetry 指定 --otel-endpoint 时通过 OTLP 导出工具调用的 span 和指标，未指定时返回 nil。
+// 需在 Start 之前调用，此后的工具调用才会生成 span
+func startTelemetry(ctx context.Context, config *ServerConfig, mcpRouter *router.Router) (*otlp.Exporter, error) {
+	if config.OtelEndpoint == "" {
+		return nil, nil
+	}
+
+	exporter, err := otlp.New(ctx, otlp.Options{
+		Endpoint:       config.OtelEndpoint,
+		Interval:       config.OtelInterval,
+		ServiceName:    config.ServerName,
+		ServiceVersion: config.ServerVersion,
+		HostName:       identity.Get().Hostname,
+	}, mcpRouter.TelemetrySnapshot)
+	if err != nil {
+		return nil, fmt.Errorf("启动 OTLP 导出失败: %v", err)
+	}
+	mcpRouter.SetTelemetry(exporter)
+
+	slog.Info("已启用 OTLP 导出", "endpoint", config.OtelEndpoint, "interval", config.OtelInterval)
+	return exporter, nil
 }
 
-func setupSignalHandling(mcpRouter *router.Router) {
+// setupSignalHandling 处理 SIGHUP（重新加载配置），返回在收到 SIGINT 或 SIGTERM 时取消的 ctx
+func setupSignalHandling(config *ServerConfig, mcpRouter *router.Router) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	go func() {
-		<-sigChan
-		mcpRouter.Stop()
-		os.Exit(0)
+		for sig := range sigChan {
+			if sig == syscall.SIGHUP {
+				reloadConfig(config, mcpRouter)
+				continue
+			}
+			slog.Info("收到信号，开始关闭", "signal", sig.String())
+			cancel()
+		}
 	}()
+
+	return ctx
 }
 
-func parseFlags() *ServerConfig {
+// shutdown 按顺序关闭服务器（请求、后台采集、后台刷新、诊断服务），最多等待 shutdownTimeout
+func shutdown(mcpRouter *router.Router) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := mcpRouter.Shutdown(ctx); err != nil {
+		slog.Warn("服务器未能在时限内完全关闭", "timeout", shutdownTimeout, "error", err)
+		return
+	}
+	slog.Info("服务器已关闭")
+}
+
+// reloadConfig 重新加载配置文件（SIGHUP）：日志级别、阈值、采集间隔和工具访问策略立即生效，
+// 其余修改过的配置项保持原值，并在 stderr 提示需要重启
+func reloadConfig(config *ServerConfig, mcpRouter *router.Router) {
+	next := *config
+	if err := applyConfigFile(&next); err != nil {
+		slog.Error("重新加载配置文件失败，保留当前配置", "error", err)
+		return
+	}
+
+	if next.LogLevel != config.LogLevel {
+		if err := setLogLevel(next.LogLevel); err != nil {
+			slog.Error("保留当前日志级别", "error", err)
+		} else {
+			config.LogLevel = next.LogLevel
+			slog.Info("已更新日志级别", "log_level", config.LogLevel)
+		}
+	}
+
+	if next.Thresholds != config.Thresholds {
+		config.Thresholds = next.Thresholds
+		mcpRouter.SetThresholds(config.Thresholds)
+		slog.Info("已更新健康检查阈值")
+	}
+
+	var requiresRestart []string
+	if next.CollectInterval != config.CollectInterval {
+		if mcpRouter.SetCollectInterval(next.CollectInterval) {
+			config.CollectInterval = next.CollectInterval
+			slog.Info("已更新后台采集间隔", "collect_interval", config.CollectInterval)
+		} else {
+			// 启用或停用后台采集需要重新创建采集器
+			requiresRestart = append(requiresRestart, "collect_interval")
+		}
+	}
+
+	config.AllowTools, config.DenyTools, config.ReadOnly = next.AllowTools, next.DenyTools, next.ReadOnly
+	policy := buildPolicy(config)
+	mcpRouter.SetPolicy(policy)
+	slog.Info("已重新加载工具访问策略", "allow_tools", policy.AllowTools, "deny_tools", policy.DenyTools, "read_only", policy.ReadOnly)
+
+	requiresRestart = append(requiresRestart, restartOnlyChanges(config, &next)...)
+	if len(requiresRestart) > 0 {
+		slog.Warn("以下配置项已修改，需要重启服务器才能生效", "fields", requiresRestart)
+	}
+}
+
+// restartOnlyChanges 列出无法在运行时生效且已修改的配置项
+func restartOnlyChanges(current, next *ServerConfig) []string {
+	var changed []string
+	for _, field := range []struct {
+		name  string
+		equal bool
+	}{
+		{"server_name", current.ServerName == next.ServerName},
+		{"data_dir", current.DataDir == next.DataDir},
+		{"storage", current.Storage == next.Storage},
+		{"cache_enabled", current.CacheEnabled == next.CacheEnabled},
+		{"compress_storage", current.CompressStorage == next.CompressStorage},
+		{"output_style", current.OutputStyle == next.OutputStyle},
+		{"bar_width", current.BarWidth == next.BarWidth},
+		{"anomaly_sigmas", current.AnomalySigmas == next.AnomalySigmas},
+		{"incident_resolve_after", current.IncidentResolve == next.IncidentResolve},
+		{"fallback_max_age", current.FallbackMaxAge == next.FallbackMaxAge},
+		{"max_result_bytes", current.MaxResultBytes == next.MaxResultBytes},
+		{"result_retention", current.ResultRetention == next.ResultRetention},
+		{"history_retention", current.HistoryRetention == next.HistoryRetention},
+		{"tools_config", reflect.DeepEqual(current.ToolConfigs, next.ToolConfigs)},
+	} {
+		if !field.equal {
+			changed = append(changed, field.name)
+		}
+	}
+	return changed
+}
+
+// parseFlags 解析命令行参数，第二个返回值表示是否请求了帮助信息（需在加载配置文件后输出）
+func parseFlags() (*ServerConfig, bool) {
 	config := getDefaultConfig()
 
 	flag.StringVar(&config.ServerName, "name", config.ServerName, "服务器名称")
 	flag.StringVar(&config.DataDir, "data-dir", config.DataDir, "数据目录")
+	flag.StringVar(&config.Storage, "storage", config.Storage, "存储类型 (file: JSON 文件, memory: 仅内存不写入磁盘)")
 	flag.BoolVar(&config.CacheEnabled, "cache", config.CacheEnabled, "启用缓存")
+	flag.BoolVar(&config.CompressStorage, "compress", config.CompressStorage, "使用 gzip 压缩存储的数据文件")
+	flag.DurationVar(&config.CollectInterval, "collect-interval", config.CollectInterval, "后台采集间隔，如 10s（0 表示不启用）")
+	flag.DurationVar(&config.ToolTimeout, "tool-timeout", config.ToolTimeout, "单次工具调用的超时时间（0 表示不限制）")
+	flag.BoolVar(&config.EnableActions, "enable-actions", config.EnableActions, "启用会修改系统状态的操作工具（如 process_signal）")
+	flag.BoolVar(&config.EnableAdminTools, "enable-admin-tools", config.EnableAdminTools, "启用运维管理工具（如 cache_admin）")
+	flag.BoolVar(&config.NoPrefetch, "no-prefetch", config.NoPrefetch, "启动时不预取 CPU 型号、分区列表等静态数据")
+	flag.Func("allow-tools", "仅允许调用的工具，逗号分隔（为空表示全部允许）", func(value string) error {
+		config.AllowTools = splitToolList(value)
+		return nil
+	})
+	flag.Func("deny-tools", "禁止调用的工具，逗号分隔（优先于 --allow-tools）", func(value string) error {
+		config.DenyTools = splitToolList(value)
+		return nil
+	})
+	flag.BoolVar(&config.ReadOnly, "read-only", config.ReadOnly, "只读模式，禁止所有非只读工具")
+	flag.StringVar(&config.ExportPath, "export", config.ExportPath, "导出数据目录到 tar.gz 文件后退出")
+	flag.StringVar(&config.ImportPath, "import", config.ImportPath, "从 tar.gz 文件导入数据后退出")
+	flag.BoolVar(&config.ImportOverwrite, "import-overwrite", config.ImportOverwrite, "导入时覆盖已存在的数据")
+	flag.StringVar(&config.MetricsExport, "export-metrics", config.MetricsExport, "导出采集历史到文件后退出（- 表示标准输出）")
+	flag.StringVar(&config.MetricsFormat, "export-metrics-format", config.MetricsFormat, "采集历史导出格式 (influx: InfluxDB 行协议, csv)")
+	flag.DurationVar(&config.MetricsSince, "export-since", config.MetricsSince, "导出最近多长时间内的采集历史")
+	flag.StringVar(&config.ConfigFile, "config", config.ConfigFile, "配置文件路径（JSON）")
+	flag.StringVar(&config.LogLevel, "log-level", config.LogLevel, "日志级别 (debug, info, warn, error)")
+	flag.StringVar(&config.OutputStyle, "output-style", config.OutputStyle, "文本输出风格 (rich: 显示使用率条, plain: 仅数字)")
+	flag.IntVar(&config.BarWidth, "bar-width", config.BarWidth, "使用率条宽度（0 表示默认 10，最大 50）")
+	flag.Float64Var(&config.AnomalySigmas, "anomaly-sigmas", config.AnomalySigmas, "后台采集时偏离滚动均值超过多少个标准差视为异常")
+	flag.DurationVar(&config.IncidentResolve, "incident-resolve-after", config.IncidentResolve, "指标恢复正常持续多久后自动解决告警事件")
+	flag.DurationVar(&config.FallbackMaxAge, "fallback-max-age", config.FallbackMaxAge, "实时采集失败时可返回的最近一次成功数据的最长有效期（0 表示不降级）")
+	flag.IntVar(&config.MaxResultBytes, "max-result-bytes", config.MaxResultBytes, "工具结果的大小上限（字节），超出时截断并将完整内容保存为 monitor://results/ 资源（0 表示不限制）")
+	flag.DurationVar(&config.ResultRetention, "result-retention", config.ResultRetention, "被截断结果的完整内容的保留时长")
+	flag.DurationVar(&config.HistoryRetention, "history-retention", config.HistoryRetention, "后台采集历史的保留时长，如 720h（0 表示不删除）")
+	flag.StringVar(&config.DebugAddr, "debug-addr", config.DebugAddr, "诊断服务监听地址，提供 pprof 和 /healthz，如 127.0.0.1:6060（为空表示不启用）")
+	flag.DurationVar(&config.NegativeCacheTTL, "negative-cache-ttl", config.NegativeCacheTTL, "采集失败的缓存时长（0 表示不缓存失败）")
+	flag.BoolVar(&config.ServiceMode, "service", config.ServiceMode, "以服务方式运行：标准输入关闭后继续运行后台采集、定时任务和诊断服务，直到收到停止信号")
+	flag.BoolVar(&config.ServiceInstall, "service-install", config.ServiceInstall, "以当前参数安装为系统服务（Linux: systemd 单元，Windows: 服务控制管理器）后退出")
+	flag.BoolVar(&config.ServiceUninstall, "service-uninstall", config.ServiceUninstall, "卸载 --service-install 安装的系统服务后退出")
+	flag.StringVar(&config.SocketPath, "socket", config.SocketPath, "同时在该路径的 Unix 套接字上接受客户端连接，每个连接使用独立的会话（为空表示只使用 stdio）")
+	flag.StringVar(&config.RecordPath, "record", config.RecordPath, "将收发的每条消息追加到 JSONL 会话记录文件（工具参数中的敏感值会被隐藏），用于排查客户端兼容问题")
+	flag.Int64Var(&config.RecordMaxBytes, "record-max-bytes", config.RecordMaxBytes, "会话记录文件的大小上限（字节），超过时轮转为 .1、.2、.3")
+	flag.StringVar(&config.OtelEndpoint, "otel-endpoint", config.OtelEndpoint, "OTLP/HTTP 接收端地址，如 http://localhost:4318，导出工具调用的追踪和系统指标（为空表示不启用）")
+	flag.DurationVar(&config.OtelInterval, "otel-interval", config.OtelInterval, "OTLP 指标的导出间隔")
+	flag.StringVar(&config.ReplayPath, "replay", config.ReplayPath, "回放会话记录中的请求并报告与记录不一致的响应后退出")
 
 	help := flag.Bool("help", false, "显示帮助信息")
-	version := flag.Bool("v", false, "显示版本信息")
+	showVersion := flag.Bool("v", false, "显示版本信息")
 
 	flag.Parse()
 
-	if *help {
-		fmt.Printf("系统监控 MCP 服务器 v%s\n\n", config.ServerVersion)
-		fmt.Println("💡 零配置启动：直接运行即可，无需任何参数！")
-		fmt.Println("\n用法:")
-		fmt.Printf("  %s                    # 使用默认配置启动\n", os.Args[0])
-		fmt.Printf("  %s --name my-monitor  # 自定义服务器名称\n\n", os.Args[0])
-		fmt.Println("可选参数:")
-		flag.PrintDefaults()
-		fmt.Println("\n支持的监控工具:")
-		fmt.Println("  • cpu_info      - CPU 使用率和详细信息")
-		fmt.Println("  • memory_info   - 内存使用情况")
-		fmt.Println("  • top_processes - CPU/内存占用最高的进程")
-		fmt.Println("  • network_stats - 网络连接状态和传输速度")
-		fmt.Println("  • disk_info     - 磁盘使用情况")
-		fmt.Println("  • system_overview - 系统综合概览")
+	if *showVersion {
+		build := version.Get()
+		fmt.Printf("%s %s\n", config.ServerName, build.Version)
+		if build.Module != "" {
+			fmt.Printf("模块: %s\n", build.Module)
+		}
+		if build.Revision != "" {
+			fmt.Printf("修订: %s\n", build.Revision)
+			fmt.Printf("已修改: %t\n", build.Dirty)
+		}
+		if build.BuildDate != "" {
+			fmt.Printf("提交时间: %s\n", build.BuildDate)
+		}
+		fmt.Printf("Go 版本: %s\n", build.GoVersion)
 		os.Exit(0)
 	}
 
-	if *version {
-		fmt.Printf("%s v%s\n", config.ServerName, config.ServerVersion)
-		os.Exit(0)
+	return config, *help
+}
+
+// printHelp 输出帮助信息，工具列表来自实际注册的工具（使用内存存储构建路由器，不创建数据目录）
+func printHelp(config *ServerConfig) {
+	fmt.Printf("系统监控 MCP 服务器 %s\n\n", config.ServerVersion)
+	fmt.Println("💡 零配置启动：直接运行即可，无需任何参数！")
+	fmt.Println("\n用法:")
+	fmt.Printf("  %s                    # 使用默认配置启动\n", os.Args[0])
+	fmt.Printf("  %s --name my-monitor  # 自定义服务器名称\n\n", os.Args[0])
+	fmt.Println("可选参数:")
+	flag.PrintDefaults()
+
+	mcpRouter := initializeRouter(config, storage.NewMemoryStorage(), initializeCache(config), nil)
+	if err := mcpRouter.InitializeTools(); err != nil {
+		fmt.Fprintf(os.Stderr, "初始化工具失败: %v\n", err)
+		return
+	}
+	fmt.Println("\n支持的监控工具:")
+	fmt.Print(router.FormatToolListings(mcpRouter.ToolListings()))
+	if !config.EnableActions || !config.EnableAdminTools {
+		fmt.Println("\n操作工具（如 process_signal）需 --enable-actions，管理工具（如 cache_admin）需 --enable-admin-tools 才会注册")
 	}
+}
 
-	return config
+// logToolListings 在启动日志中列出可用和被禁用的工具
+func logToolListings(mcpRouter *router.Router) {
+	var enabled, disabled []string
+	for _, listing := range mcpRouter.ToolListings() {
+		if listing.Disabled {
+			disabled = append(disabled, listing.Name)
+		} else {
+			enabled = append(enabled, listing.Name)
+		}
+	}
+	slog.Info("已注册工具", "count", len(enabled), "tools", strings.Join(enabled, ","), "disabled", strings.Join(disabled, ","))
+}
+
+// 就绪前需要完成的启动步骤（存储在 serve 之前已经初始化）
+const (
+	readyTools       = "tools"
+	readyDiagnostics = "diagnostics"
+	readyTransport   = "transport"
+)
+
+// serve 启动服务器直到输入结束（客户端断开，服务模式下忽略）、收到终止信号或 ctx 取消（服务停止请求），
+// 然后按顺序关闭。工具注册完成、诊断服务和套接字监听成功且开始读取输入后才向服务管理器报告就绪
+func serve(ctx context.Context, config *ServerConfig, dataStorage types.DataStorage, notifier service.Notifier) error {
+	readiness := service.NewReadiness(notifier, readyTools, readyDiagnostics, readyTransport)
+
+	var recorder router.Transcript
+	var closeRecorder func(ctx context.Context) error
+	if config.RecordPath != "" {
+		transcriptRecorder, err := transcript.NewRecorder(config.RecordPath, config.RecordMaxBytes, redactPatterns(config))
+		if err != nil {
+			return err
+		}
+		recorder = transcriptRecorder
+		closeRecorder = func(ctx context.Context) error { return transcriptRecorder.Close() }
+		slog.Warn("正在记录会话，记录文件包含工具结果等完整的消息内容", "path", config.RecordPath)
+	}
+
+	cache := initializeCache(config)
+	mcpRouter := initializeRouter(config, dataStorage, cache, recorder)
+	if err := mcpRouter.InitializeTools(); err != nil {
+		return fmt.Errorf("初始化工具失败: %v", err)
+	}
+	logToolListings(mcpRouter)
+	readiness.SetStatus(fmt.Sprintf("%s %s 运行中", config.ServerName, config.ServerVersion))
+	readiness.Done(readyTools)
+
+	debugServer, err := startDiagnostics(config, mcpRouter)
+	if err != nil {
+		return err
+	}
+	if debugServer != nil {
+		mcpRouter.OnShutdown("诊断服务", debugServer.Shutdown)
+	}
+	if closeRecorder != nil {
+		mcpRouter.OnShutdown("会话记录", closeRecorder)
+	}
+	exporter, err := startTelemetry(ctx, config, mcpRouter)
+	if err != nil {
+		return err
+	}
+	if exporter != nil {
+		mcpRouter.OnShutdown("OTLP 导出", exporter.Shutdown)
+	}
+	listener, err := listenSocket(config)
+	if err != nil {
+		return err
+	}
+	if listener != nil {
+		mcpRouter.OnShutdown("套接字", func(ctx context.Context) error { return listener.Close() })
+	}
+	readiness.Done(readyDiagnostics)
+
+	signalCtx := setupSignalHandling(config, mcpRouter)
+
+	watchdogCtx, stopWatchdog := context.WithCancel(ctx)
+	defer stopWatchdog()
+	go service.RunWatchdog(watchdogCtx, notifier)
+
+	// 启动服务器，输入结束（客户端断开）或收到终止信号时按顺序关闭
+	served := make(chan error, 1)
+	go func() {
+		served <- mcpRouter.Start()
+	}()
+	go func() {
+		select {
+		case <-mcpRouter.Ready():
+			if listener != nil {
+				go serveSocket(mcpRouter, listener)
+			}
+			readiness.Done(readyTransport)
+		case <-watchdogCtx.Done():
+		}
+	}()
+
+	select {
+	case err = <-served:
+		if err == nil && config.ServiceMode {
+			slog.Info("标准输入已关闭，服务模式下继续运行直到收到停止信号")
+			select {
+			case <-signalCtx.Done():
+			case <-ctx.Done():
+			}
+		}
+	case <-signalCtx.Done():
+	case <-ctx.Done():
+	}
+
+	if err := notifier.Notify(service.StateStopping); err != nil {
+		slog.Warn("停止通知发送失败", "error", err)
+	}
+	shutdown(mcpRouter)
+
+	if err != nil {
+		return fmt.Errorf("服务器启动失败: %v", err)
+	}
+	return nil
+}
+
+// runServiceCommand 执行 --service-install 或 --service-uninstall，返回要输出的结果说明
+func runServiceCommand(config *ServerConfig) (string, error) {
+	if config.ServiceUninstall {
+		return service.Uninstall(config.ServerName)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("获取程序路径失败: %v", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("获取工作目录失败: %v", err)
+	}
+
+	return service.Install(service.Config{
+		Name:        config.ServerName,
+		Description: fmt.Sprintf("系统监控 MCP 服务器 (%s)", config.ServerName),
+		Executable:  executable,
+		Args:        serviceArgs(os.Args[1:]),
+		WorkingDir:  workingDir,
+	})
+}
+
+// serviceArgs 安装服务时写入的启动参数：当前命令行参数去掉服务相关参数，
+// 数据目录、配置文件和会话记录文件转为绝对路径（服务的工作目录可能不同），最后加上 --service
+func serviceArgs(args []string) []string {
+	result := make([]string, 0, len(args)+1)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			result = append(result, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		switch name {
+		case "service", "service-install", "service-uninstall":
+			continue
+		case "data-dir", "config", "record", "socket":
+			if !hasValue && i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+			if value != "" {
+				if absolute, err := filepath.Abs(value); err == nil {
+					value = absolute
+				}
+			}
+			result = append(result, "--"+name+"="+value)
+			continue
+		}
+		result = append(result, arg)
+	}
+	return append(result, "--service")
 }
 
 func main() {
 	log.SetOutput(os.Stderr)
 
-	config := parseFlags()
+	config, help := parseFlags()
+
+	configErr := applyConfigFile(config)
+	if help {
+		if configErr != nil {
+			fmt.Fprintf(os.Stderr, "配置加载失败，工具列表未考虑配置文件: %v\n", configErr)
+		}
+		printHelp(config)
+		os.Exit(0)
+	}
+	if configErr != nil {
+		fmt.Fprintf(os.Stderr, "配置加载失败: %v\n", configErr)
+		os.Exit(1)
+	}
+
+	if config.OutputStyle != tools.StyleRich && config.OutputStyle != tools.StylePlain {
+		fmt.Fprintf(os.Stderr, "无效的输出风格 %q（可选 rich、plain）\n", config.OutputStyle)
+		os.Exit(1)
+	}
+
+	if err := initializeLogger(config); err != nil {
+		fmt.Fprintf(os.Stderr, "日志初始化失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	if config.ServiceInstall || config.ServiceUninstall {
+		message, err := runServiceCommand(config)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(message)
+		return
+	}
+
+	if config.ReplayPath != "" {
+		if err := runReplay(config); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 启动信息写到 stderr，不干扰 JSON-RPC
+	slog.Info("系统监控 MCP 服务器启动", "name", config.ServerName, "version", version.String(version.Get()))
 
 	// 初始化组件
 	dataStorage, err := initializeStorage(config)
@@ -116,14 +927,28 @@ func main() {
 		os.Exit(1)
 	}
 
-	cache := initializeCache()
-	mcpRouter := initializeRouter(config, dataStorage, cache)
+	if config.ExportPath != "" || config.ImportPath != "" {
+		if err := runDataTransfer(config, dataStorage); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	setupSignalHandling(mcpRouter)
+	if config.MetricsExport != "" {
+		if err := runMetricsExport(config, dataStorage); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	// 启动服务器
-	if err := mcpRouter.Start(); err != nil {
-		fmt.Fprintf(os.Stderr, "服务器启动失败: %v\n", err)
+	// 由 systemd 或 Windows 服务控制管理器启动时报告就绪和停止状态，否则与直接运行相同
+	err = service.Run(context.Background(), config.ServerName, func(ctx context.Context, notifier service.Notifier) error {
+		return serve(ctx, config, dataStorage, notifier)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-example/internal/router"
+	"mcp-example/internal/storage"
+	"mcp-example/internal/types"
+)
+
+// captureLogs 测试期间将默认日志记录到返回的缓冲区，日志级别跟随 logLevel
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buffer bytes.Buffer
+	previous, previousLevel := slog.Default(), logLevel.Level()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buffer, &slog.HandlerOptions{Level: logLevel})))
+	t.Cleanup(func() {
+		slog.SetDefault(previous)
+		logLevel.Set(previousLevel)
+	})
+	return &buffer
+}
+
+// reloadFixture 写入配置文件，返回指向它的运行中配置和启用了后台采集的路由器
+func reloadFixture(t *testing.T, content string) (*ServerConfig, *router.Router, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "server_config.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config := getDefaultConfig()
+	config.ConfigFile = path
+	if err := applyConfigFile(config); err != nil {
+		t.Fatal(err)
+	}
+	if err := setLogLevel(config.LogLevel); err != nil {
+		t.Fatal(err)
+	}
+	mcpRouter := router.NewRouter(config.ServerName, config.ServerVersion, storage.NewMemoryStorage(), storage.NewMemoryCache(), router.Options{
+		CollectInterval: config.CollectInterval,
+		Thresholds:      config.Thresholds,
+	})
+	// 后台采集器随内置工具创建，不启动路由器
+	if err := mcpRouter.InitializeTools(); err != nil {
+		t.Fatal(err)
+	}
+	return config, mcpRouter, path
+}
+
+// rewrite 替换配置文件的内容
+func rewrite(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadConfigAppliesLiveFields(t *testing.T) {
+	logs := captureLogs(t)
+	config, mcpRouter, path := reloadFixture(t, `{"log_level": "info", "collect_interval": "1h", "data_dir": "data"}`)
+
+	rewrite(t, path, `{
+		"log_level": "debug",
+		"collect_interval": "30m",
+		"data_dir": "data",
+		"deny_tools": ["process_signal"],
+		"thresholds": {"cpu_percent": {"warning": 50, "critical": 70}}
+	}`)
+	reloadConfig(config, mcpRouter)
+
+	if logLevel.Level() != slog.LevelDebug || config.LogLevel != "debug" {
+		t.Errorf("log level = %s (%q), want debug", logLevel.Level(), config.LogLevel)
+	}
+	if config.Thresholds.CPUPercent != (types.Threshold{Warning: 50, Critical: 70}) {
+		t.Errorf("cpu threshold = %+v, want the reloaded 50/70", config.Thresholds.CPUPercent)
+	}
+	// 文件中未设置的阈值恢复为默认值
+	if config.Thresholds.MemoryPercent.Warning == 0 {
+		t.Errorf("memory threshold = %+v, want the default", config.Thresholds.MemoryPercent)
+	}
+	if config.CollectInterval != 30*time.Minute {
+		t.Errorf("collect interval = %s, want 30m", config.CollectInterval)
+	}
+	if len(config.DenyTools) != 1 || config.DenyTools[0] != "process_signal" {
+		t.Errorf("deny tools = %v", config.DenyTools)
+	}
+	if text := logs.String(); strings.Contains(text, "需要重启") || !strings.Contains(text, "已更新后台采集间隔") {
+		t.Errorf("logs:\n%s", text)
+	}
+}
+
+func TestReloadConfigReportsRestartOnlyFields(t *testing.T) {
+	logs := captureLogs(t)
+	config, mcpRouter, path := reloadFixture(t, `{"collect_interval": "1h", "data_dir": "data"}`)
+
+	// 停用后台采集和修改数据目录都不能在运行时生效，保持原值
+	rewrite(t, path, `{"collect_interval": "0s", "data_dir": "elsewhere", "thresholds": {"zombies": {"warning": 5}}}`)
+	reloadConfig(config, mcpRouter)
+
+	if config.CollectInterval != time.Hour || config.DataDir != "data" {
+		t.Errorf("collect interval %s, data dir %q; want the running values", config.CollectInterval, config.DataDir)
+	}
+	if config.Thresholds.Zombies.Warning != 5 {
+		t.Errorf("zombies threshold = %+v, want the reloaded warning", config.Thresholds.Zombies)
+	}
+	text := logs.String()
+	if !strings.Contains(text, "需要重启服务器才能生效") || !strings.Contains(text, "collect_interval") || !strings.Contains(text, "data_dir") {
+		t.Errorf("logs do not report the restart-only fields:\n%s", text)
+	}
+}
+
+func TestReloadConfigRejectsInvalidFile(t *testing.T) {
+	logs := captureLogs(t)
+	config, mcpRouter, path := reloadFixture(t, `{"log_level": "warn", "collect_interval": "1h", "thresholds": {"cpu_percent": {"warning": 60, "critical": 90}}}`)
+	before := *config
+
+	// 无法解析的文件整体拒绝，运行中的配置不变
+	rewrite(t, path, `{"log_level": "debug", "thresholds": `)
+	reloadConfig(config, mcpRouter)
+	if config.LogLevel != before.LogLevel || config.Thresholds != before.Thresholds || logLevel.Level() != slog.LevelWarn {
+		t.Errorf("config changed after a malformed file: %s %+v", config.LogLevel, config.Thresholds.CPUPercent)
+	}
+	if !strings.Contains(logs.String(), "重新加载配置文件失败，保留当前配置") {
+		t.Errorf("logs:\n%s", logs.String())
+	}
+
+	// 无效的日志级别只保留当前日志级别，其余配置照常生效
+	rewrite(t, path, `{"log_level": "verbose", "collect_interval": "1h", "thresholds": {"cpu_percent": {"warning": 70, "critical": 90}}}`)
+	reloadConfig(config, mcpRouter)
+	if config.LogLevel != "warn" || logLevel.Level() != slog.LevelWarn {
+		t.Errorf("log level = %s (%q), want warn kept", logLevel.Level(), config.LogLevel)
+	}
+	if config.Thresholds.CPUPercent.Warning != 70 {
+		t.Errorf("cpu threshold = %+v, want the reloaded warning", config.Thresholds.CPUPercent)
+	}
+
+	// 配置文件被删除时同样保留当前配置
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	reloadConfig(config, mcpRouter)
+	if config.Thresholds.CPUPercent.Warning != 70 {
+		t.Errorf("cpu threshold = %+v after the file was removed", config.Thresholds.CPUPercent)
+	}
+}
+
+// captureStdout 执行 fn 期间的标准输出，标准错误（flag 的参数说明）被丢弃
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := os.Stdout
+	os.Stdout = writer
+	flag.CommandLine.SetOutput(io.Discard)
+	defer func() {
+		os.Stdout = previous
+		flag.CommandLine.SetOutput(nil)
+	}()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- string(data)
+	}()
+	fn()
+	writer.Close()
+	return <-output
+}
+
+func TestHelpListsRegisteredTools(t *testing.T) {
+	config := getDefaultConfig()
+	config.DenyTools = []string{"cpu_info"}
+
+	text := captureStdout(t, func() { printHelp(config) })
+
+	// 工具列表与按同一配置构建的路由器注册的工具一致，被禁用的工具有标注
+	mcpRouter := initializeRouter(config, storage.NewMemoryStorage(), initializeCache(config), nil)
+	if err := mcpRouter.InitializeTools(); err != nil {
+		t.Fatal(err)
+	}
+	listings := mcpRouter.ToolListings()
+	if !strings.Contains(text, "\n支持的监控工具:\n"+router.FormatToolListings(listings)) {
+		t.Errorf("help lacks the registered tools:\n%s", text)
+	}
+	if !strings.Contains(text, "cpu_info") || !strings.Contains(text, "（🚫 已被访问策略禁用）") {
+		t.Errorf("help does not mark the denied tool:\n%s", text)
+	}
+	// 默认不注册操作和管理工具，帮助中说明如何启用
+	if strings.Contains(text, "• process_signal") || !strings.Contains(text, "--enable-actions") {
+		t.Errorf("help lists action tools that are not registered:\n%s", text)
+	}
+}
+
+func TestLogToolListings(t *testing.T) {
+	logs := captureLogs(t)
+	logLevel.Set(slog.LevelInfo)
+	config := getDefaultConfig()
+	config.DenyTools = []string{"cpu_info", "memory_info"}
+	mcpRouter := initializeRouter(config, storage.NewMemoryStorage(), initializeCache(config), nil)
+	if err := mcpRouter.InitializeTools(); err != nil {
+		t.Fatal(err)
+	}
+
+	logToolListings(mcpRouter)
+	total := len(mcpRouter.ToolListings())
+	if !strings.Contains(logs.String(), fmt.Sprintf("count=%d ", total-2)) || !strings.Contains(logs.String(), "disabled=cpu_info,memory_info") {
+		t.Errorf("logs:\n%s", logs)
+	}
+}